@@ -0,0 +1,255 @@
+// Package client calls the PaperTrader API. Its types and methods, one per
+// operation in docs/openapi.yaml, are generated into client.gen.go by
+// cmd/apigen (scripts/generate-clients.sh); this file is the transport they
+// share. It is for bots and scripts; a conformance test runs it against the
+// real routes.
+package client
+
+//go:generate go run ../cmd/apigen -spec ../../docs/openapi.yaml -go client.gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	csrfCookieName = "csrf_token"
+	csrfHeaderName = "X-CSRF-Token"
+	requestTimeout = 30 * time.Second
+)
+
+// Config configures a Client.
+type Config struct {
+	// BaseURL is the API root, e.g. http://localhost:8080/api.
+	BaseURL string
+	// Origin is sent on state-changing requests, which the server accepts
+	// only from its frontend's origin (FRONTEND_URL).
+	Origin string
+	// HTTPClient is optional. The Client uses a copy of it with its own
+	// cookie jar unless it already has one.
+	HTTPClient *http.Client
+}
+
+// Client is a signed-in (or not yet signed-in) API session. The session
+// token and the CSRF token are cookies, kept in the client's jar as a
+// browser would; methods are safe for concurrent use.
+type Client struct {
+	base   *url.URL
+	origin string
+	http   *http.Client
+}
+
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(strings.TrimRight(cfg.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: base URL: %w", err)
+	}
+	hc := &http.Client{Timeout: requestTimeout}
+	if cfg.HTTPClient != nil {
+		c := *cfg.HTTPClient
+		hc = &c
+	}
+	if hc.Jar == nil {
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			return nil, err
+		}
+		hc.Jar = jar
+	}
+	return &Client{base: base, origin: strings.TrimRight(cfg.Origin, "/"), http: hc}, nil
+}
+
+// Error is a non-2xx response. Code is the response's error_code, when it
+// has one (e.g. INSUFFICIENT_FUNDS).
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("papertrader: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("papertrader: %d: %s", e.StatusCode, e.Message)
+}
+
+// IsCode reports whether err is an *Error with the given error_code.
+func IsCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+// request is one call, as a generated method builds it. path is already
+// escaped. The body is json encoded as JSON, or raw sent as contentType.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	json        any
+	raw         []byte
+	contentType string
+	accept      string
+}
+
+func (r *request) setQuery(key, value string) {
+	if r.query == nil {
+		r.query = url.Values{}
+	}
+	r.query.Set(key, value)
+}
+
+func (r *request) setHeader(key, value string) {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+	r.header.Set(key, value)
+}
+
+// response is a 2xx answer, read in full.
+type response struct {
+	method      string
+	path        string
+	status      int
+	contentType string
+	body        []byte
+}
+
+func (r *response) isJSON() bool {
+	mt, _, _ := mime.ParseMediaType(r.contentType)
+	return mt == "application/json"
+}
+
+func (r *response) decode(out any) error {
+	if err := json.Unmarshal(r.body, out); err != nil {
+		return fmt.Errorf("papertrader: decode %s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+// call sends r and decodes a 2xx JSON answer into out (if not nil).
+func (c *Client) call(ctx context.Context, r request, out any) error {
+	resp, err := c.send(ctx, r)
+	if err != nil || out == nil {
+		return err
+	}
+	return resp.decode(out)
+}
+
+// callRaw sends r and returns the body of a 2xx answer as is.
+func (c *Client) callRaw(ctx context.Context, r request) ([]byte, error) {
+	resp, err := c.send(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+// send sends r to its path under the base URL and reads the answer; a
+// non-2xx one is an *Error. State-changing requests carry the Origin and
+// CSRF headers the server requires.
+func (c *Client) send(ctx context.Context, r request) (*response, error) {
+	u, err := url.Parse(c.base.String() + r.path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = r.query.Encode()
+
+	var body io.Reader
+	contentType := r.contentType
+	switch {
+	case r.json != nil:
+		b, err := json.Marshal(r.json)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = bytes.NewReader(b), "application/json"
+	case r.raw != nil:
+		body = bytes.NewReader(r.raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if r.accept != "" {
+		req.Header.Set("Accept", r.accept)
+	}
+	if r.method != http.MethodGet {
+		token, err := c.csrfToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(csrfHeaderName, token)
+		if c.origin != "" {
+			req.Header.Set("Origin", c.origin)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, responseError(resp.StatusCode, raw)
+	}
+	return &response{
+		method:      r.method,
+		path:        r.path,
+		status:      resp.StatusCode,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        raw,
+	}, nil
+}
+
+// csrfToken returns the CSRF cookie's value, fetching one from /csrf if no
+// response has set it yet.
+func (c *Client) csrfToken(ctx context.Context) (string, error) {
+	for _, cookie := range c.http.Jar.Cookies(c.base) {
+		if cookie.Name == csrfCookieName {
+			return cookie.Value, nil
+		}
+	}
+	t, err := c.GetCSRFToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	return t.CSRFToken, nil
+}
+
+// responseError reads a failed response: JSON with a message and usually
+// an error_code, or the plain text middleware answers with.
+func responseError(status int, raw []byte) error {
+	e := &Error{StatusCode: status}
+	var body struct {
+		Message   string `json:"message"`
+		ErrorCode string `json:"error_code"`
+	}
+	if err := json.Unmarshal(raw, &body); err == nil && body.Message != "" {
+		e.Message, e.Code = body.Message, body.ErrorCode
+	} else {
+		e.Message = strings.TrimSpace(string(raw))
+	}
+	return e
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/api/account"
+	"papertrader/internal/api/investments"
+	"papertrader/internal/api/middleware"
+	"papertrader/internal/config"
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
+
+const testOrigin = "http://localhost:3000"
+
+// stubAuth signs in one user. Methods the test doesn't reach are left to
+// the embedded nil interface.
+type stubAuth struct {
+	account.AuthServicer
+	jwt  *service.JWTService
+	user *data.User
+}
+
+func (s *stubAuth) Login(_ context.Context, email, password string) (*data.User, string, error) {
+	if email != s.user.Email || password != "correct-horse" {
+		return nil, "", &service.InvalidCredentialsError{}
+	}
+	token, err := s.jwt.GenerateToken(s.user.ID, s.user.Email, s.user.Role)
+	return s.user, token, err
+}
+
+func (s *stubAuth) GetUserByID(context.Context, string) (*data.User, error) { return s.user, nil }
+
+// stubTrading keeps one user's holdings and trades in memory.
+type stubTrading struct {
+	investments.InvestmentServicer
+	cash     decimal.Decimal
+	holdings map[string]*data.UserStock
+	trades   []data.Trade
+}
+
+func (s *stubTrading) BuyStock(_ context.Context, userID, symbol string, quantity decimal.Decimal, _ string) (*data.UserStock, error) {
+	price := decimal.NewFromInt(100)
+	cost := price.Mul(quantity)
+	if cost.GreaterThan(s.cash) {
+		return nil, &service.InsufficientFundsError{}
+	}
+	s.cash = s.cash.Sub(cost)
+	h := s.holdings[symbol]
+	if h == nil {
+		h = &data.UserStock{ID: "h-" + symbol, UserID: userID, Symbol: symbol, AvgPrice: price, AssetClass: data.AssetClassEquity}
+		s.holdings[symbol] = h
+	}
+	h.Quantity = h.Quantity.Add(quantity)
+	h.Total = h.Quantity.Mul(price)
+	s.trades = append(s.trades, data.Trade{ID: "t-1", UserID: userID, Symbol: symbol, Action: "BUY",
+		Quantity: quantity, Price: price, Total: cost, Status: "COMPLETED", OrderType: data.OrderTypeMarket, ExecutedAt: time.Now()})
+	return h, nil
+}
+
+func (s *stubTrading) SellStock(_ context.Context, _, symbol string, quantity decimal.Decimal, _ string) (*data.UserStock, error) {
+	h := s.holdings[symbol]
+	if h == nil || quantity.GreaterThan(h.Quantity) {
+		return nil, &service.InsufficientStockError{}
+	}
+	h.Quantity = h.Quantity.Sub(quantity)
+	h.Total = h.Quantity.Mul(h.AvgPrice)
+	s.cash = s.cash.Add(quantity.Mul(h.AvgPrice))
+	return h, nil
+}
+
+func (s *stubTrading) GetUserStocks(context.Context, string) ([]data.UserStock, error) {
+	var out []data.UserStock
+	for _, h := range s.holdings {
+		out = append(out, *h)
+	}
+	return out, nil
+}
+
+func (s *stubTrading) GetUserTrades(_ context.Context, _ string, opts data.TradeQueryOpts) ([]data.Trade, int, error) {
+	var out []data.Trade
+	for _, t := range s.trades {
+		if opts.Symbol == "" || t.Symbol == opts.Symbol {
+			out = append(out, t)
+		}
+	}
+	return out, len(out), nil
+}
+
+type usdOnly struct{}
+
+func (usdOnly) DisplayRate(context.Context, string, string) (*service.FXRate, error) {
+	return &service.FXRate{From: "USD", To: "USD", Rate: decimal.NewFromInt(1)}, nil
+}
+
+type noGuard struct{}
+
+func (noGuard) ReauthRequiredAfter(context.Context, string) (*time.Time, error) { return nil, nil }
+func (noGuard) CheckVerified(context.Context, string) error                     { return nil }
+
+// newTestServer serves the routes the client calls the way main.go does,
+// behind the origin and CSRF checks, over stub services.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	cfg := &config.Config{Environment: "development", FrontendURL: testOrigin}
+	jwtService := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	jwtService.SetRevocations(service.NewMemorySessionRevocations())
+	user := &data.User{ID: "user-1", Email: "ada@example.com", Balance: decimal.NewFromInt(1000), Role: data.RoleUser, EmailVerified: true}
+
+	accountHandler := account.NewAccountHandler(&stubAuth{jwt: jwtService, user: user}, jwtService, cfg, account.AccountServices{})
+	investmentsHandler := investments.NewInvestmentsHandler(
+		&stubTrading{cash: user.Balance, holdings: map[string]*data.UserStock{}},
+		nil, nil, nil, nil, usdOnly{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, 10000)
+
+	router := mux.NewRouter()
+	router.Use(middleware.OriginCheck(cfg.FrontendURL))
+	router.Use(middleware.CSRF(cfg.IsProduction()))
+	api := router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/csrf", middleware.CSRFToken).Methods("GET")
+	account.Mount(api.PathPrefix("/account").Subrouter(), accountHandler, jwtService, noGuard{}, nil, cfg)
+	investments.Mount(api.PathPrefix("/investments").Subrouter(), investmentsHandler, jwtService, noGuard{}, cfg)
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// statusOf returns err's response status, or 0 if it isn't an *Error.
+func statusOf(err error) int {
+	if e, ok := err.(*Error); ok {
+		return e.StatusCode
+	}
+	return 0
+}
+
+func TestClientConformance(t *testing.T) {
+	ctx := context.Background()
+	srv := newTestServer(t)
+	c, err := New(Config{BaseURL: srv.URL + "/api", Origin: testOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetProfile(ctx); statusOf(err) != http.StatusUnauthorized {
+		t.Fatalf("profile before login: got %v, want 401", err)
+	}
+	if _, err := c.Login(ctx, LoginRequest{Email: "ada@example.com", Password: "wrong"}); statusOf(err) != http.StatusUnauthorized {
+		t.Fatalf("login with a bad password: got %v, want 401", err)
+	}
+	auth, err := c.Login(ctx, LoginRequest{Email: "ada@example.com", Password: "correct-horse"})
+	if err != nil || auth.User == nil || auth.User.ID != "user-1" {
+		t.Fatalf("Login: got %+v, %v", auth, err)
+	}
+	if p, err := c.GetProfile(ctx); err != nil || p.Email != "ada@example.com" || !p.EmailVerified {
+		t.Errorf("GetProfile: got %+v, %v", p, err)
+	}
+	if b, err := c.GetBalance(ctx); err != nil || !b.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("GetBalance: got %s, %v", b, err)
+	}
+
+	res, err := c.Buy(ctx, &BuyParams{IdempotencyKey: "buy-1"}, TradeRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(3)})
+	if err != nil || res.StatusCode != http.StatusOK || res.JSON200 == nil || res.JSON200.Symbol != "AAPL" || !res.JSON200.Quantity.Equal(decimal.NewFromInt(3)) {
+		t.Fatalf("Buy: got %+v, %v", res, err)
+	}
+	if res, err := c.Sell(ctx, nil, TradeRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)}); err != nil || res.JSON200 == nil || !res.JSON200.Quantity.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("Sell: got %+v, %v", res, err)
+	}
+	if _, err := c.Buy(ctx, nil, TradeRequest{Symbol: "MSFT", Quantity: decimal.NewFromInt(50)}); !IsCode(err, "INSUFFICIENT_FUNDS") {
+		t.Errorf("Buy beyond the balance: got %v, want INSUFFICIENT_FUNDS", err)
+	}
+	if _, err := c.Buy(ctx, nil, TradeRequest{Symbol: "not a symbol!", Quantity: decimal.NewFromInt(1)}); !IsCode(err, "VALIDATION_ERROR") {
+		t.Errorf("Buy of a bad symbol: got %v, want VALIDATION_ERROR", err)
+	}
+
+	holdings, err := c.ListHoldings(ctx, nil)
+	if err != nil || len(holdings) != 1 || holdings[0].Symbol != "AAPL" || holdings[0].Currency != "USD" {
+		t.Errorf("ListHoldings: got %+v, %v", holdings, err)
+	}
+	page, err := c.ListTrades(ctx, &ListTradesParams{Symbol: "AAPL", Limit: 10})
+	if err != nil || page.Total != 1 || page.Limit != 10 || len(page.Trades) != 1 || page.Trades[0].Action != "BUY" {
+		t.Errorf("ListTrades: got %+v, %v", page, err)
+	}
+
+	if _, err := c.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := c.GetBalance(ctx); statusOf(err) != http.StatusUnauthorized {
+		t.Errorf("balance after logout: got %v, want 401", err)
+	}
+}
+
+func TestClientWithoutOrigin(t *testing.T) {
+	srv := newTestServer(t)
+	c, err := New(Config{BaseURL: srv.URL + "/api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Login(context.Background(), LoginRequest{Email: "ada@example.com", Password: "correct-horse"}); !IsCode(err, "ORIGIN_REJECTED") {
+		t.Errorf("got %v, want ORIGIN_REJECTED", err)
+	}
+}
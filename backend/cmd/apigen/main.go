@@ -0,0 +1,52 @@
+// cmd/apigen generates the API clients from the OpenAPI spec: the Go client's
+// types and methods and the frontend's typed calls. scripts/generate-clients.sh
+// runs it with the repo's paths; run that after changing docs/openapi.yaml.
+//
+// Usage:
+//
+//	go run ./cmd/apigen -spec ../docs/openapi.yaml \
+//		-go client/client.gen.go -ts ../frontend/src/services/api.gen.ts
+//
+// Exit codes: 0 = written, 2 = error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"papertrader/internal/apigen"
+)
+
+func main() {
+	specFlag := flag.String("spec", "../docs/openapi.yaml", "the OpenAPI spec")
+	goFlag := flag.String("go", "", "write the Go client to this file (package client)")
+	tsFlag := flag.String("ts", "", "write the TypeScript client to this file")
+	flag.Parse()
+
+	spec, err := apigen.Load(*specFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apigen: %v\n", err)
+		os.Exit(2)
+	}
+	if *goFlag != "" {
+		if err := write(*goFlag, func() ([]byte, error) { return apigen.Go(spec, "client") }); err != nil {
+			fmt.Fprintf(os.Stderr, "apigen: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if *tsFlag != "" {
+		if err := write(*tsFlag, func() ([]byte, error) { return apigen.TypeScript(spec) }); err != nil {
+			fmt.Fprintf(os.Stderr, "apigen: %v\n", err)
+			os.Exit(2)
+		}
+	}
+}
+
+func write(path string, gen func() ([]byte, error)) error {
+	src, err := gen()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, src, 0o644)
+}
@@ -103,7 +103,8 @@ func main() {
 		redisClient = nil
 	}
 
-	voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey)
+	httpClient := config.NewHTTPClient(cfg)
+	voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey, httpClient)
 	var embedder research.Embedder
 	if redisClient != nil {
 		embedder = research.NewCachedEmbedder(voyage, redisClient)
@@ -111,8 +112,8 @@ func main() {
 		embedder = voyage
 	}
 
-	genGroq := research.NewGroqClientWithModel(cfg.GroqAPIKey, "llama-3.3-70b-versatile")
-	judgeGroq := research.NewGroqClientWithModel(cfg.GroqAPIKey, *judgeModel)
+	genGroq := research.NewGroqClientWithModel(cfg.GroqAPIKey, "llama-3.3-70b-versatile", httpClient)
+	judgeGroq := research.NewGroqClientWithModel(cfg.GroqAPIKey, *judgeModel, httpClient)
 
 	var genClient research.LLMClient = genGroq
 	var judgeClient research.LLMClient = judgeGroq
@@ -76,7 +76,8 @@ func main() {
 	}
 
 	var embedder research.Embedder
-	voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey)
+	httpClient := config.NewHTTPClient(cfg)
+	voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey, httpClient)
 	if redisClient != nil {
 		embedder = research.NewCachedEmbedder(voyage, redisClient)
 	} else {
@@ -87,7 +88,7 @@ func main() {
 	chunksStore := data.NewChunksStore(db)
 	embeddingsStore := data.NewEmbeddingsStore(db)
 
-	edgarClient := ingest.NewEdgarClient(cfg.SecUserAgent)
+	edgarClient := ingest.NewEdgarClient(cfg.SecUserAgent, httpClient)
 	pipeline := ingest.NewPipeline(edgarClient, embedder, docsStore, chunksStore, embeddingsStore)
 
 	formTypes := strings.Split(*formTypesFlag, ",")
@@ -37,7 +37,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	db, dbErr := config.ConnectPostgreSQL(cfg)
+	db, dbErr := config.ConnectDatabase(cfg)
 	if dbErr != nil {
 		fmt.Fprintf(os.Stderr, "migrate: failed to connect to database: %v\n", dbErr)
 		os.Exit(1)
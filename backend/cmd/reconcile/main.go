@@ -24,6 +24,7 @@ import (
 	"papertrader/internal/config"
 	"papertrader/internal/data"
 	"papertrader/internal/service"
+	"papertrader/internal/sqlite"
 )
 
 func main() {
@@ -49,12 +50,13 @@ func main() {
 	}
 	config.SetupLogger(cfg.Environment, cfg.LogLevel)
 
-	db, err := config.ConnectPostgreSQL(cfg)
+	db, err := config.ConnectDatabase(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: failed to connect to database: %v\n", err)
 		os.Exit(2)
 	}
 	defer db.Close()
+	data.UseSQLite(sqlite.Is(db))
 
 	portfolioStore := data.NewPortfolioStore(db)
 	tradesStore := data.NewTradesStore(db)
@@ -76,7 +76,8 @@ func main() {
 	}
 
 	var embedder research.Embedder
-	voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey)
+	httpClient := config.NewHTTPClient(cfg)
+	voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey, httpClient)
 	if redisClient != nil {
 		embedder = research.NewCachedEmbedder(voyage, redisClient)
 	} else {
@@ -0,0 +1,164 @@
+// cmd/users is a CLI tool for bulk user administration: importing a class
+// roster from CSV and exporting user lists with aggregate stats. It does the
+// same work as POST /api/admin/users/import and GET /api/admin/users/export.
+//
+// Usage:
+//
+//	go run ./cmd/users import -file roster.csv             # create users, email invites
+//	go run ./cmd/users import -file roster.csv -invite=false
+//	go run ./cmd/users export                              # CSV to stdout
+//	go run ./cmd/users export -league fall-2026 -json
+//
+// The import file has a header row with an email column and optional
+// starting_balance and league columns.
+//
+// Exit codes: 0 = success, 1 = some import rows were invalid, 2 = error.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"papertrader/internal/config"
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+	"papertrader/internal/sqlite"
+)
+
+func main() {
+	// Emit decimals in -json output as numbers, matching the API.
+	data.EnableUnquotedDecimalJSON()
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	fileFlag := importCmd.String("file", "", "CSV file to import (required)")
+	inviteFlag := importCmd.Bool("invite", true, "email each new user an invite link")
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	leagueFlag := exportCmd.String("league", "", "only export users in this league")
+	jsonFlag := exportCmd.Bool("json", false, "output JSON instead of CSV")
+
+	switch os.Args[1] {
+	case "import":
+		importCmd.Parse(os.Args[2:])
+		if *fileFlag == "" {
+			fmt.Fprintln(os.Stderr, "users import: -file is required")
+			os.Exit(2)
+		}
+	case "export":
+		exportCmd.Parse(os.Args[2:])
+	default:
+		usage()
+	}
+
+	// Load env vars (non-fatal if .env is absent — container environments use
+	// system env vars directly).
+	if err := godotenv.Load(); err != nil {
+		slog.Info("no .env file found, using system environment variables")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "users: invalid configuration: %v\n", err)
+		os.Exit(2)
+	}
+	config.SetupLogger(cfg.Environment, cfg.LogLevel)
+
+	db, err := config.ConnectDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: failed to connect to database: %v\n", err)
+		os.Exit(2)
+	}
+	defer db.Close()
+	data.UseSQLite(sqlite.Is(db))
+
+	var emailService *service.EmailService
+	if cfg.Email.Enabled() {
+		e := cfg.Email
+		sender, err := service.NewEmailSender(service.EmailSenderConfig{
+			Backend:      e.Backend,
+			ResendAPIKey: e.ResendAPIKey,
+			SMTP:         service.SMTPConfig{Host: e.SMTPHost, Port: e.SMTPPort, Username: e.SMTPUsername, Password: e.SMTPPassword},
+			SES:          service.SESConfig{Region: e.SESRegion, AccessKeyID: e.SESAccessKeyID, SecretAccessKey: e.SESSecretAccessKey, Endpoint: e.SESEndpoint},
+			OutboxDir:    e.OutboxDir,
+		}, config.NewHTTPClient(cfg))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(2)
+		}
+		emailService = service.NewEmailService(sender, e.FromEmail, cfg.FrontendURL, cfg.MobileAppScheme)
+	}
+	users := data.NewUserStore(db)
+	users.SetStartingBalance(cfg.StartingBalance)
+	svc := service.NewUserAdminService(users, service.NewJWTService(cfg.JWTSecret), emailService, cfg.InviteLinkTTL)
+	ctx := context.Background()
+
+	if os.Args[1] == "import" {
+		os.Exit(runImport(ctx, svc, *fileFlag, *inviteFlag))
+	}
+	os.Exit(runExport(ctx, svc, *leagueFlag, *jsonFlag))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: users import -file roster.csv [-invite=false]")
+	fmt.Fprintln(os.Stderr, "       users export [-league name] [-json]")
+	os.Exit(2)
+}
+
+func runImport(ctx context.Context, svc *service.UserAdminService, path string, invite bool) int {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	defer f.Close()
+
+	report, err := svc.ImportCSV(ctx, f, invite)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: import %s: %v\n", path, err)
+		return 2
+	}
+
+	fmt.Printf("%-6s  %-40s  %-8s  %-7s  %s\n", "line", "email", "status", "invited", "error")
+	fmt.Println("--------------------------------------------------------------------------------------")
+	for _, res := range report.Results {
+		fmt.Printf("%-6d  %-40s  %-8s  %-7t  %s\n", res.Line, res.Email, res.Status, res.Invited, res.Error)
+	}
+	fmt.Printf("\ncreated %d, existing %d, invalid %d, invited %d\n",
+		report.Created, report.Existing, report.Invalid, report.Invited)
+	if report.Invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runExport(ctx context.Context, svc *service.UserAdminService, league string, asJSON bool) int {
+	stats, err := svc.Export(ctx, league)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: export: %v\n", err)
+		return 2
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stats); err != nil {
+			fmt.Fprintf(os.Stderr, "error encoding JSON: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	if err := service.WriteUserStatsCSV(os.Stdout, stats); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing CSV: %v\n", err)
+		return 2
+	}
+	return 0
+}
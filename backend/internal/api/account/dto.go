@@ -1,10 +1,29 @@
 package account
 
-import "papertrader/internal/data"
+import (
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
 
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	Username   string `json:"username,omitempty"`
+	InviteCode string `json:"invite_code,omitempty"` // required when registration is invite-only
+}
+
+// GoogleLoginRequest signs in with a Google ID token. InviteCode is needed
+// only when the sign-in creates an account and registration is invite-only.
+type GoogleLoginRequest struct {
+	Token      string `json:"token"`
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+// GuestRequest is the optional body of POST /api/account/guest.
+type GuestRequest struct {
+	InviteCode string `json:"invite_code,omitempty"`
 }
 
 type LoginRequest struct {
@@ -12,9 +31,156 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// GuestUpgradeRequest attaches credentials to a guest account: either email
+// and password, or a Google ID token.
+type GuestUpgradeRequest struct {
+	Email       string `json:"email"`
+	Password    string `json:"password"`
+	GoogleToken string `json:"google_token"`
+}
+
 type AuthResponse struct {
 	Success bool       `json:"success"`
 	Message string     `json:"message"`
 	User    *data.User `json:"user,omitempty"`
 	Token   string     `json:"token,omitempty"`
 }
+
+// SudoRequest confirms identity for sudo mode. Password accounts send
+// password; Google accounts send a fresh Google ID token.
+type SudoRequest struct {
+	Password    string `json:"password"`
+	GoogleToken string `json:"google_token"`
+}
+
+// ChangePasswordRequest is the body of PUT /api/account/password.
+type ChangePasswordRequest struct {
+	Password string `json:"password"`
+}
+
+// ChangeEmailRequest is the body of PUT /api/account/email.
+type ChangeEmailRequest struct {
+	Email string `json:"email"`
+}
+
+type SudoResponse struct {
+	Success   bool      `json:"success"`
+	Message   string    `json:"message"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AvatarResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type UsernameRequest struct {
+	Username string `json:"username"`
+}
+
+type UsernameResponse struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Username string `json:"username"`
+}
+
+type UsernameAvailabilityResponse struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+}
+
+type DisplayCurrencyRequest struct {
+	Currency string `json:"currency"`
+}
+
+type DisplayCurrencyResponse struct {
+	Success         bool   `json:"success"`
+	Message         string `json:"message"`
+	DisplayCurrency string `json:"display_currency"`
+}
+
+// TimezoneRequest is the body of PUT /api/account/timezone. Timezone is an
+// IANA name such as "Europe/London".
+type TimezoneRequest struct {
+	Timezone string `json:"timezone"`
+}
+
+type TimezoneResponse struct {
+	Success  bool   `json:"success"`
+	Message  string `json:"message"`
+	Timezone string `json:"timezone"`
+}
+
+// AfterHoursOrdersRequest is the body of PUT /api/account/after-hours-orders.
+// Mode is REJECT or QUEUE.
+type AfterHoursOrdersRequest struct {
+	Mode string `json:"mode"`
+}
+
+type AfterHoursOrdersResponse struct {
+	Success          bool   `json:"success"`
+	Message          string `json:"message"`
+	AfterHoursOrders string `json:"after_hours_orders"`
+}
+
+// CostBasisMethodRequest is the body of PUT /api/account/cost-basis-method.
+// Method is FIFO, LIFO or AVERAGE.
+type CostBasisMethodRequest struct {
+	Method string `json:"method"`
+}
+
+type CostBasisMethodResponse struct {
+	Success         bool   `json:"success"`
+	Message         string `json:"message"`
+	CostBasisMethod string `json:"cost_basis_method"`
+}
+
+// TradeConfirmationEmailsRequest is the body of
+// PUT /api/account/trade-confirmation-emails.
+type TradeConfirmationEmailsRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type TradeConfirmationEmailsResponse struct {
+	Success                 bool   `json:"success"`
+	Message                 string `json:"message"`
+	TradeConfirmationEmails bool   `json:"trade_confirmation_emails"`
+}
+
+// StatementEmailsRequest is the body of PUT /api/account/statement-emails.
+type StatementEmailsRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type StatementEmailsResponse struct {
+	Success         bool   `json:"success"`
+	Message         string `json:"message"`
+	StatementEmails bool   `json:"statement_emails"`
+}
+
+// StatementReportsResponse is the body of GET /api/account/statements/reports.
+type StatementReportsResponse struct {
+	Reports []data.StatementReport `json:"reports"`
+}
+
+// GoalsResponse is the body of GET /api/account/goals.
+type GoalsResponse struct {
+	Goals []service.GoalProgress `json:"goals"`
+}
+
+// ResetAccountRequest is the body of POST /api/account/reset.
+type ResetAccountRequest struct {
+	ConfirmationToken string `json:"confirmation_token"`
+}
+
+// ResetConfirmationResponse is the body of POST /api/account/reset/confirmation.
+type ResetConfirmationResponse struct {
+	Success           bool      `json:"success"`
+	ConfirmationToken string    `json:"confirmation_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
@@ -3,33 +3,201 @@ package account
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"papertrader/internal/api/auth"
 	"papertrader/internal/config"
 	"papertrader/internal/data"
 	"papertrader/internal/service"
+	"papertrader/internal/util"
+	"strings"
 	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gorilla/mux"
 )
 
 // AuthServicer is the subset of service.AuthService used by AccountHandler.
 // Using an interface here makes the handler trivially testable without a real DB.
 type AuthServicer interface {
-	Register(ctx context.Context, email, password string) (*data.User, string, error)
+	Register(ctx context.Context, email, password, username, inviteCode string) (*data.User, string, error)
 	Login(ctx context.Context, email, password string) (*data.User, string, error)
 	GetUserByID(ctx context.Context, userID string) (*data.User, error)
 	VerifyEmail(ctx context.Context, token string) error
 	ResendVerificationEmail(ctx context.Context, email string) error
-	LoginWithGoogle(ctx context.Context, idToken string) (*data.User, string, error)
+	LoginWithGoogle(ctx context.Context, idToken, inviteCode string) (*data.User, string, error)
+	Elevate(ctx context.Context, userID, password, googleIDToken string, ttl time.Duration) (string, time.Time, error)
+	RequestMagicLink(ctx context.Context, email string) error
+	LoginWithMagicLink(ctx context.Context, token string) (*data.User, string, error)
+	ChangePassword(ctx context.Context, userID, password string) error
+	ChangeEmail(ctx context.Context, userID, email string) (*data.User, string, error)
+}
+
+// TradeLimitsServicer is the subset of service.TradeLimitService used by AccountHandler.
+type TradeLimitsServicer interface {
+	GetLimits(ctx context.Context, userID string) (*service.TradeLimits, error)
+}
+
+// AvatarServicer is the subset of service.AvatarService used by AccountHandler.
+type AvatarServicer interface {
+	Upload(ctx context.Context, userID string, body []byte) (string, error)
+	Remove(ctx context.Context, userID string) error
+	MaxBytes() int64
+}
+
+// UsernameServicer is the subset of service.UsernameService used by AccountHandler.
+type UsernameServicer interface {
+	Set(ctx context.Context, userID, name string) error
+	Available(ctx context.Context, name string) (bool, error)
+}
+
+// GuestServicer is the subset of service.GuestService used by AccountHandler.
+type GuestServicer interface {
+	Create(ctx context.Context, inviteCode string) (*data.User, string, error)
+	Upgrade(ctx context.Context, userID, email, password string) (*data.User, string, error)
+	UpgradeWithGoogle(ctx context.Context, userID, idToken string) (*data.User, string, error)
+}
+
+// PasskeyServicer is the subset of service.PasskeyService used by AccountHandler.
+type PasskeyServicer interface {
+	BeginRegistration(ctx context.Context, userID string) (*protocol.CredentialCreation, string, time.Time, error)
+	FinishRegistration(ctx context.Context, userID, ceremony, name string, response io.Reader) (*data.Passkey, error)
+	BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, time.Time, error)
+	FinishLogin(ctx context.Context, ceremony string, response io.Reader) (*data.User, string, error)
+	List(ctx context.Context, userID string) ([]data.Passkey, error)
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// UsageServicer is the subset of service.UsageService used by AccountHandler.
+type UsageServicer interface {
+	Report(ctx context.Context, userID, ipAddress string) (*service.UsageReport, error)
+}
+
+// DisplayCurrencyServicer is the subset of service.FXService used by
+// AccountHandler.
+type DisplayCurrencyServicer interface {
+	SetDisplayCurrency(ctx context.Context, userID, currency string) (string, error)
+	DisplayRate(ctx context.Context, userID, requested string) (*service.FXRate, error)
+}
+
+// AfterHoursServicer is the subset of service.MarketHours used by
+// AccountHandler.
+type AfterHoursServicer interface {
+	SetAfterHoursOrders(ctx context.Context, userID, mode string) (string, error)
+}
+
+// CostBasisServicer is the subset of service.CostBasisService used by
+// AccountHandler.
+type CostBasisServicer interface {
+	SetMethod(ctx context.Context, userID, method string) (string, error)
+}
+
+// TradeConfirmationServicer is the subset of service.TradeConfirmationService
+// used by AccountHandler.
+type TradeConfirmationServicer interface {
+	SetEnabled(ctx context.Context, userID string, enabled bool) (bool, error)
+}
+
+// StatementServicer is the subset of service.StatementService used by
+// AccountHandler.
+type StatementServicer interface {
+	Statement(ctx context.Context, userID, month string) (*service.AccountStatement, error)
+}
+
+// StatementEmailServicer is the subset of service.StatementEmailService
+// used by AccountHandler.
+type StatementEmailServicer interface {
+	SetEnabled(ctx context.Context, userID string, enabled bool) (bool, error)
+	Reports(ctx context.Context, userID string) ([]data.StatementReport, error)
+}
+
+// AccountResetServicer is the subset of service.AccountResetService used by
+// AccountHandler.
+type AccountResetServicer interface {
+	ConfirmationToken(ctx context.Context, userID string) (string, time.Time, error)
+	Reset(ctx context.Context, userID, token string) error
+}
+
+// TimezoneServicer is the subset of service.TimezoneService used by
+// AccountHandler.
+type TimezoneServicer interface {
+	SetTimezone(ctx context.Context, userID, name string) (string, error)
+}
+
+// GoalServicer is the subset of service.InvestmentGoalService used by
+// AccountHandler.
+type GoalServicer interface {
+	List(ctx context.Context, userID string) ([]service.GoalProgress, error)
+	Create(ctx context.Context, userID string, req service.InvestmentGoalRequest) (*service.GoalProgress, error)
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// BonusServicer is the subset of service.BonusService used by AccountHandler.
+type BonusServicer interface {
+	Status(ctx context.Context, userID string) (*service.BonusStatus, error)
+	Claim(ctx context.Context, userID string) (*service.BonusClaim, error)
+}
+
+// AccountTransferServicer is the subset of service.AccountTransferService
+// used by AccountHandler.
+type AccountTransferServicer interface {
+	Export(ctx context.Context, userID string) (*service.AccountBundle, error)
+	Import(ctx context.Context, userID string, bundle *service.AccountBundle, replace bool) (*service.AccountImport, error)
+}
+
+// AccountDeletionServicer is the subset of service.AccountDeletionService
+// used by AccountHandler.
+type AccountDeletionServicer interface {
+	Delete(ctx context.Context, userID string) error
+}
+
+// SessionServicer is the subset of service.JWTService used by AccountHandler
+// to sign sessions out before their tokens expire.
+type SessionServicer interface {
+	RevokeSession(ctx context.Context, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID string) error
+}
+
+// AccountServices are the services behind the account routes beyond
+// authentication and sessions. Wire every one the mounted routes use; a
+// route whose service is nil can't serve requests.
+type AccountServices struct {
+	Limits     TradeLimitsServicer
+	Avatars    AvatarServicer
+	Usernames  UsernameServicer
+	Guests     GuestServicer
+	Passkeys   PasskeyServicer
+	Usage      UsageServicer
+	Currency   DisplayCurrencyServicer
+	AfterHours AfterHoursServicer
+	CostBasis  CostBasisServicer
+	Confirms   TradeConfirmationServicer
+	Statements StatementServicer
+	Reports    StatementEmailServicer
+	Resets     AccountResetServicer
+	Timezones  TimezoneServicer
+	Goals      GoalServicer
+	Bonus      BonusServicer
+	Transfers  AccountTransferServicer
+	Deletions  AccountDeletionServicer
 }
 
 type AccountHandler struct {
 	AuthService AuthServicer
+	Sessions    SessionServicer
 	Config      *config.Config
+	AccountServices
 }
 
-func NewAccountHandler(authService AuthServicer, cfg *config.Config) *AccountHandler {
+func NewAccountHandler(authService AuthServicer, sessions SessionServicer, cfg *config.Config, services AccountServices) *AccountHandler {
 	return &AccountHandler{
-		AuthService: authService,
-		Config:      cfg,
+		AuthService:     authService,
+		Sessions:        sessions,
+		Config:          cfg,
+		AccountServices: services,
 	}
 }
 
@@ -96,6 +264,70 @@ func (h *AccountHandler) clearTokenCookie(w http.ResponseWriter, r *http.Request
 	http.SetCookie(w, cookie)
 }
 
+// setSudoCookie scopes the elevation token to /api and SameSite=Strict: it is
+// only ever needed on same-site API calls.
+func (h *AccountHandler) setSudoCookie(w http.ResponseWriter, r *http.Request, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SudoCookieName,
+		Value:    token,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   h.isSecureConnection(r),
+		Path:     "/api",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func (h *AccountHandler) clearSudoCookie(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     auth.SudoCookieName,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		Secure:   h.isSecureConnection(r),
+		Path:     "/api",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// passkeyCeremonyCookie carries the signed challenge from a passkey begin
+// request to the matching finish request. Only the passkey routes need it.
+const (
+	passkeyCeremonyCookie = "passkey_ceremony"
+	passkeyCookiePath     = "/api/account/passkeys"
+)
+
+func (h *AccountHandler) setPasskeyCeremonyCookie(w http.ResponseWriter, r *http.Request, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     passkeyCeremonyCookie,
+		Value:    token,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   h.isSecureConnection(r),
+		Path:     passkeyCookiePath,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// takePasskeyCeremony returns the ceremony token and clears the cookie: a
+// challenge is good for one finish attempt.
+func (h *AccountHandler) takePasskeyCeremony(w http.ResponseWriter, r *http.Request) string {
+	cookie, err := r.Cookie(passkeyCeremonyCookie)
+	if err != nil {
+		return ""
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     passkeyCeremonyCookie,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		Secure:   h.isSecureConnection(r),
+		Path:     passkeyCookiePath,
+		SameSite: http.SameSiteStrictMode,
+	})
+	return cookie.Value
+}
+
 // Custom error type
 type ValidationError struct {
 	Message string
@@ -118,11 +350,14 @@ func (h *AccountHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := h.AuthService.Register(r.Context(), req.Email, req.Password)
+	user, token, err := h.AuthService.Register(r.Context(), req.Email, req.Password, req.Username, req.InviteCode)
 	if err != nil {
 		switch err.(type) {
 		case *service.EmailExistsError:
 			h.writeErrorResponse(w, http.StatusBadRequest, "Email already exists")
+		case *service.InvalidUsernameError, *service.UsernameTakenError,
+			*service.InviteCodeRequiredError, *service.InvalidInviteCodeError:
+			util.WriteServiceError(w, err)
 		case *service.TokenGenerationError:
 			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
 		default:
@@ -159,6 +394,8 @@ func (h *AccountHandler) Login(w http.ResponseWriter, r *http.Request) {
 		switch err.(type) {
 		case *service.InvalidCredentialsError:
 			h.writeErrorResponse(w, http.StatusUnauthorized, "Invalid credentials")
+		case *service.AccountLockedError, *service.AdminLockedError:
+			util.WriteServiceError(w, err)
 		case *service.TokenGenerationError:
 			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to generate token")
 		default:
@@ -179,7 +416,15 @@ func (h *AccountHandler) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *AccountHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	// Revoke the session too, so a copy of the token taken before now stops
+	// working. The cookie is cleared regardless.
+	if sessionID, ok := auth.SessionIDFromContext(r.Context()); ok && h.Sessions != nil {
+		if err := h.Sessions.RevokeSession(r.Context(), sessionID); err != nil {
+			slog.Warn("failed to revoke session on logout", "user_id", r.Header.Get("X-User-ID"), "err", err)
+		}
+	}
 	h.clearTokenCookie(w, r)
+	h.clearSudoCookie(w, r)
 	response := AuthResponse{
 		Success: true,
 		Message: "Logout successful",
@@ -187,6 +432,28 @@ func (h *AccountHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// LogoutAll handles POST /api/account/logout-all: sign the user out of every
+// session, this one included, e.g. after a token may have been stolen.
+func (h *AccountHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	if err := h.Sessions.RevokeAllSessions(r.Context(), userID); err != nil {
+		util.WriteSafeError(w, http.StatusInternalServerError, "Could not sign out every session", err, "INTERNAL_ERROR")
+		return
+	}
+	slog.Info("signed out of every session", "user_id", userID)
+	h.clearTokenCookie(w, r)
+	h.clearSudoCookie(w, r)
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Signed out of every session",
+	})
+}
+
 func (h *AccountHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
@@ -228,76 +495,999 @@ func (h *AccountHandler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, user.Balance)
 }
 
-func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
-	token := r.URL.Query().Get("token")
-	if token == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Verification token required")
+// GetLimits returns the user's trade-count and pattern-day-trader standing.
+func (h *AccountHandler) GetLimits(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
 		return
 	}
 
-	err := h.AuthService.VerifyEmail(r.Context(), token)
+	limits, err := h.Limits.GetLimits(r.Context(), userID)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid or expired verification token")
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to load trade limits")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, limits)
+}
+
+// GetUsage returns the caller's recent API usage and rate-limit standing.
+func (h *AccountHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	report, err := h.Usage.Report(r.Context(), userID, service.ClientInfoFromContext(r.Context()).IP)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to load usage")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, report)
+}
+
+// Sudo re-confirms the caller's identity and issues a short-lived elevation
+// token (cookie) required by sensitive operations.
+func (h *AccountHandler) Sudo(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
 		return
 	}
 
+	var req SudoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Password == "" && req.GoogleToken == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Password or Google token required")
+		return
+	}
+
+	token, expiresAt, err := h.AuthService.Elevate(r.Context(), userID, req.Password, req.GoogleToken, h.Config.SudoTTL)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	h.setSudoCookie(w, r, token, expiresAt)
+	h.writeJSONResponse(w, http.StatusOK, SudoResponse{
+		Success:   true,
+		Message:   "Sudo mode enabled",
+		ExpiresAt: expiresAt,
+	})
+}
+
+// DropSudo ends sudo mode early.
+func (h *AccountHandler) DropSudo(w http.ResponseWriter, r *http.Request) {
+	h.clearSudoCookie(w, r)
 	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
 		Success: true,
-		Message: "Email verified successfully",
+		Message: "Sudo mode disabled",
 	})
 }
 
-func (h *AccountHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Email string `json:"email"`
+// ChangePassword sets a new password. Mounted behind RequireSudo, which has
+// already confirmed the current one.
+func (h *AccountHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
 	}
+
+	var req ChangePasswordRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	// Service swallows lookup failures by design — see ResendVerificationEmail.
-	// Any error returned here is a transport-layer failure we still want to
-	// surface, but the success message is identical regardless of whether the
-	// email matched a real account.
-	if err := h.AuthService.ResendVerificationEmail(r.Context(), req.Email); err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not process request")
+	if err := h.AuthService.ChangePassword(r.Context(), userID, req.Password); err != nil {
+		util.WriteServiceError(w, err)
 		return
 	}
-
 	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
 		Success: true,
-		Message: "If an account with that email exists and is not yet verified, a verification email has been sent",
+		Message: "Password changed",
 	})
 }
 
-func (h *AccountHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Token string `json:"token"`
+// ChangeEmail moves the account to a new email address and sends it a
+// verification link. The session cookie is reissued for the new address.
+// Mounted behind RequireSudo.
+func (h *AccountHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
 	}
 
+	var req ChangeEmailRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if req.Token == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "Google token required")
+	user, token, err := h.AuthService.ChangeEmail(r.Context(), userID, req.Email)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.setTokenCookie(w, r, token)
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Email changed; check your inbox to verify it",
+		User:    user,
+	})
+}
+
+// UploadAvatar replaces the user's avatar with the raw image in the request
+// body. The image type is detected from the bytes; Content-Type is ignored.
+func (h *AccountHandler) UploadAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
 		return
 	}
 
-	user, token, err := h.AuthService.LoginWithGoogle(r.Context(), req.Token)
+	// Read one byte past the cap so the service can tell "exactly max" from
+	// "too large" without buffering an arbitrarily large body.
+	body, err := io.ReadAll(io.LimitReader(r.Body, h.Avatars.MaxBytes()+1))
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusUnauthorized, "Google authentication failed")
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			util.WriteServiceError(w, &service.AvatarTooLargeError{MaxBytes: h.Avatars.MaxBytes()})
+			return
+		}
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	h.setTokenCookie(w, r, token)
+	url, err := h.Avatars.Upload(r.Context(), userID, body)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AvatarResponse{
+		Success:   true,
+		Message:   "Avatar updated",
+		AvatarURL: url,
+	})
+}
 
-	response := AuthResponse{
+// DeleteAvatar removes the user's avatar.
+func (h *AccountHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+	if err := h.Avatars.Remove(r.Context(), userID); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AvatarResponse{
 		Success: true,
-		Message: "Login successful",
-		User:    user,
+		Message: "Avatar removed",
+	})
+}
+
+// SetUsername sets or changes the caller's public username.
+func (h *AccountHandler) SetUsername(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
 	}
-	h.writeJSONResponse(w, http.StatusOK, response)
+
+	var req UsernameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Usernames.Set(r.Context(), userID, req.Username); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, UsernameResponse{
+		Success:  true,
+		Message:  "Username updated",
+		Username: req.Username,
+	})
+}
+
+// SetDisplayCurrency sets the currency quotes and portfolio values are shown
+// in when a request does not pass ?display_currency=.
+func (h *AccountHandler) SetDisplayCurrency(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req DisplayCurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	currency, err := h.Currency.SetDisplayCurrency(r.Context(), userID, req.Currency)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, DisplayCurrencyResponse{
+		Success:         true,
+		Message:         "Display currency updated",
+		DisplayCurrency: currency,
+	})
+}
+
+// SetTimezone sets the time zone the user's days and months are drawn in:
+// daily trade limits, day change and statements.
+func (h *AccountHandler) SetTimezone(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req TimezoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	timezone, err := h.Timezones.SetTimezone(r.Context(), userID, req.Timezone)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, TimezoneResponse{
+		Success:  true,
+		Message:  "Time zone updated",
+		Timezone: timezone,
+	})
+}
+
+// SetAfterHoursOrders sets whether buys and sells placed while the market is
+// closed are rejected or queued for the next open.
+func (h *AccountHandler) SetAfterHoursOrders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req AfterHoursOrdersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	mode, err := h.AfterHours.SetAfterHoursOrders(r.Context(), userID, req.Mode)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AfterHoursOrdersResponse{
+		Success:          true,
+		Message:          "After-hours order handling updated",
+		AfterHoursOrders: mode,
+	})
+}
+
+// SetCostBasisMethod sets which shares the user's sells realize gains
+// against: FIFO, LIFO or AVERAGE.
+func (h *AccountHandler) SetCostBasisMethod(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req CostBasisMethodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	method, err := h.CostBasis.SetMethod(r.Context(), userID, req.Method)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, CostBasisMethodResponse{
+		Success:         true,
+		Message:         "Cost-basis method updated",
+		CostBasisMethod: method,
+	})
+}
+
+// GetStatement returns the user's account statement for ?month=YYYY-MM, as
+// JSON or, with ?format=pdf, as a PDF download.
+func (h *AccountHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "pdf" {
+		util.WriteServiceError(w, &util.ValidationError{Field: "format", Message: "must be json or pdf"})
+		return
+	}
+	rate, err := h.Currency.DisplayRate(r.Context(), userID, q.Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	st, err := h.Statements.Statement(r.Context(), userID, q.Get("month"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	st.Convert(rate)
+
+	if format == "pdf" {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", `attachment; filename="statement-`+st.Month+`.pdf"`)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		w.Write(service.RenderStatementPDF(st))
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, st)
+}
+
+// SetTradeConfirmationEmails turns the user's trade confirmation emails on
+// or off.
+func (h *AccountHandler) SetTradeConfirmationEmails(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req TradeConfirmationEmailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	enabled, err := h.Confirms.SetEnabled(r.Context(), userID, req.Enabled)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, TradeConfirmationEmailsResponse{
+		Success:                 true,
+		Message:                 "Trade confirmation emails updated",
+		TradeConfirmationEmails: enabled,
+	})
+}
+
+// SetStatementEmails turns the user's monthly statement emails on or off.
+func (h *AccountHandler) SetStatementEmails(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req StatementEmailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	enabled, err := h.Reports.SetEnabled(r.Context(), userID, req.Enabled)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, StatementEmailsResponse{
+		Success:         true,
+		Message:         "Statement emails updated",
+		StatementEmails: enabled,
+	})
+}
+
+// ListStatementReports lists the monthly statements emailed to the user.
+func (h *AccountHandler) ListStatementReports(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	reports, err := h.Reports.Reports(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, StatementReportsResponse{Reports: reports})
+}
+
+// ListGoals returns the caller's investment goals with their progress.
+func (h *AccountHandler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	goals, err := h.Goals.List(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, GoalsResponse{Goals: goals})
+}
+
+// CreateGoal adds an investment goal for the caller.
+func (h *AccountHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req service.InvestmentGoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	goal, err := h.Goals.Create(r.Context(), userID, req)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusCreated, goal)
+}
+
+// DeleteGoal removes one of the caller's investment goals.
+func (h *AccountHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	if err := h.Goals.Delete(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Goal removed",
+	})
+}
+
+// GetBonus reports whether the caller can claim bonus cash and, if not,
+// when they next can.
+func (h *AccountHandler) GetBonus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	status, err := h.Bonus.Status(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, status)
+}
+
+// ClaimBonus credits the caller's cash with bonus cash, at most once per
+// cooldown.
+func (h *AccountHandler) ClaimBonus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	claim, err := h.Bonus.Claim(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, claim)
+}
+
+// ExportAccount downloads the caller's account as a signed bundle that
+// ImportAccount accepts on any deployment trusting this one's key.
+func (h *AccountHandler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	bundle, err := h.Transfers.Export(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="papertrader-account.json"`)
+	h.writeJSONResponse(w, http.StatusOK, bundle)
+}
+
+// ImportAccount recreates the account in an exported bundle as the caller's.
+// An account already in use is refused unless ?mode=replace, which wipes it
+// first.
+func (h *AccountHandler) ImportAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != "replace" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "mode must be replace or omitted")
+		return
+	}
+	var bundle service.AccountBundle
+	if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	result, err := h.Transfers.Import(r.Context(), userID, &bundle, mode == "replace")
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, result)
+}
+
+// UsernameAvailable reports whether ?name= can be registered. Invalid names
+// are answered with 400 and the reason, so forms can show it inline.
+func (h *AccountHandler) UsernameAvailable(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "name query parameter required")
+		return
+	}
+
+	available, err := h.Usernames.Available(r.Context(), name)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, UsernameAvailabilityResponse{
+		Username:  name,
+		Available: available,
+	})
+}
+
+// VerifyEmail confirms an email address. Without ?redirect it answers JSON
+// (the web frontend calls it with fetch). With ?redirect it acts as a landing
+// page: it verifies and then 303s to the target with status=verified or
+// status=failed appended, so a link opened on a phone can finish in the app.
+func (h *AccountHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var target *url.URL
+	if raw := r.URL.Query().Get("redirect"); raw != "" {
+		if target = h.verifyRedirectTarget(raw); target == nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Redirect target not allowed")
+			return
+		}
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		if target != nil {
+			h.redirectWithStatus(w, r, target, "failed")
+			return
+		}
+		h.writeErrorResponse(w, http.StatusBadRequest, "Verification token required")
+		return
+	}
+
+	err := h.AuthService.VerifyEmail(r.Context(), token)
+	if target != nil {
+		status := "verified"
+		if err != nil {
+			status = "failed"
+		}
+		h.redirectWithStatus(w, r, target, status)
+		return
+	}
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid or expired verification token")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Email verified successfully",
+	})
+}
+
+// verifyRedirectTarget parses raw and returns it only if it points at the
+// frontend's origin or uses the configured mobile app scheme; anything else
+// would make the endpoint an open redirect.
+func (h *AccountHandler) verifyRedirectTarget(raw string) *url.URL {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return nil
+	}
+	if scheme := h.Config.MobileAppScheme; scheme != "" && strings.EqualFold(u.Scheme, scheme) {
+		return u
+	}
+	frontend, err := url.Parse(h.Config.FrontendURL)
+	if err != nil || u.User != nil {
+		return nil
+	}
+	if strings.EqualFold(u.Scheme, frontend.Scheme) && strings.EqualFold(u.Host, frontend.Host) {
+		return u
+	}
+	return nil
+}
+
+func (h *AccountHandler) redirectWithStatus(w http.ResponseWriter, r *http.Request, target *url.URL, status string) {
+	dest := *target
+	q := dest.Query()
+	q.Set("status", status)
+	dest.RawQuery = q.Encode()
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, dest.String(), http.StatusSeeOther)
+}
+
+func (h *AccountHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Service swallows lookup failures by design — see ResendVerificationEmail.
+	// Any error returned here is a transport-layer failure we still want to
+	// surface, but the success message is identical regardless of whether the
+	// email matched a real account.
+	if err := h.AuthService.ResendVerificationEmail(r.Context(), req.Email); err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Could not process request")
+		return
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "If an account with that email exists and is not yet verified, a verification email has been sent",
+	})
+}
+
+// RequestMagicLink emails a passwordless login link. The response is the same
+// whether or not the address has an account.
+func (h *AccountHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	var req MagicLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Email == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	if err := h.AuthService.RequestMagicLink(r.Context(), req.Email); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "If an account with that email exists, a login link has been sent",
+	})
+}
+
+// MagicLinkCallback is the target of the emailed link. It exchanges the token
+// for the session cookie and sends the browser on to the frontend: the
+// dashboard on success, the login page with ?magic_link=failed otherwise.
+func (h *AccountHandler) MagicLinkCallback(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Redirect(w, r, h.Config.FrontendURL+"/login?magic_link=failed", http.StatusSeeOther)
+		return
+	}
+
+	_, sessionToken, err := h.AuthService.LoginWithMagicLink(r.Context(), token)
+	if err != nil {
+		http.Redirect(w, r, h.Config.FrontendURL+"/login?magic_link=failed", http.StatusSeeOther)
+		return
+	}
+
+	h.setTokenCookie(w, r, sessionToken)
+	http.Redirect(w, r, h.Config.FrontendURL+"/dashboard", http.StatusSeeOther)
+}
+
+func (h *AccountHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
+	var req GoogleLoginRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Token == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Google token required")
+		return
+	}
+
+	user, token, err := h.AuthService.LoginWithGoogle(r.Context(), req.Token, req.InviteCode)
+	if err != nil {
+		switch err.(type) {
+		case *service.InviteCodeRequiredError, *service.InvalidInviteCodeError, *service.AdminLockedError:
+			util.WriteServiceError(w, err)
+		default:
+			h.writeErrorResponse(w, http.StatusUnauthorized, "Google authentication failed")
+		}
+		return
+	}
+
+	h.setTokenCookie(w, r, token)
+
+	response := AuthResponse{
+		Success: true,
+		Message: "Login successful",
+		User:    user,
+	}
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
+// CreateGuest starts a guest session: a temporary account with no email that
+// is deleted when it expires unless upgraded. The body is optional; it
+// carries the invite code when registration is invite-only.
+func (h *AccountHandler) CreateGuest(w http.ResponseWriter, r *http.Request) {
+	var req GuestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, token, err := h.Guests.Create(r.Context(), req.InviteCode)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	h.setTokenCookie(w, r, token)
+	h.writeJSONResponse(w, http.StatusCreated, AuthResponse{
+		Success: true,
+		Message: "Guest session started",
+		User:    user,
+	})
+}
+
+// UpgradeGuest turns the caller's guest account into a full account, keeping
+// its trades and holdings. The body carries either email and password or a
+// Google ID token.
+func (h *AccountHandler) UpgradeGuest(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	var req GuestUpgradeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var (
+		user  *data.User
+		token string
+		err   error
+	)
+	switch {
+	case req.GoogleToken != "":
+		user, token, err = h.Guests.UpgradeWithGoogle(r.Context(), userID, req.GoogleToken)
+	case req.Email != "" && req.Password != "":
+		user, token, err = h.Guests.Upgrade(r.Context(), userID, req.Email, req.Password)
+	default:
+		h.writeErrorResponse(w, http.StatusBadRequest, "Email and password, or a Google token, are required")
+		return
+	}
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	h.setTokenCookie(w, r, token)
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Account upgraded",
+		User:    user,
+	})
+}
+
+// BeginPasskeyRegistration returns the options for navigator.credentials.create()
+// to add a passkey to the caller's account.
+func (h *AccountHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	options, ceremony, expiresAt, err := h.Passkeys.BeginRegistration(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.setPasskeyCeremonyCookie(w, r, ceremony, expiresAt)
+	h.writeJSONResponse(w, http.StatusOK, options)
+}
+
+// FinishPasskeyRegistration verifies the credential from
+// navigator.credentials.create() (the request body) and stores it. The
+// optional ?name= labels the passkey in the account's list.
+func (h *AccountHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	ceremony := h.takePasskeyCeremony(w, r)
+	passkey, err := h.Passkeys.FinishRegistration(r.Context(), userID, ceremony, r.URL.Query().Get("name"), r.Body)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusCreated, passkey)
+}
+
+// ListPasskeys returns the caller's passkeys.
+func (h *AccountHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	passkeys, err := h.Passkeys.List(r.Context(), userID)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to load passkeys")
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, passkeys)
+}
+
+// DeletePasskey removes one of the caller's passkeys.
+func (h *AccountHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	if err := h.Passkeys.Delete(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Passkey removed",
+	})
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get().
+func (h *AccountHandler) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	options, ceremony, expiresAt, err := h.Passkeys.BeginLogin(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.setPasskeyCeremonyCookie(w, r, ceremony, expiresAt)
+	h.writeJSONResponse(w, http.StatusOK, options)
+}
+
+// FinishPasskeyLogin verifies the assertion from navigator.credentials.get()
+// (the request body) and signs the user in, as Login does.
+func (h *AccountHandler) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	ceremony := h.takePasskeyCeremony(w, r)
+	user, token, err := h.Passkeys.FinishLogin(r.Context(), ceremony, r.Body)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	h.setTokenCookie(w, r, token)
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Login successful",
+		User:    user,
+	})
+}
+
+// ResetConfirmation issues the token POST /reset needs to go ahead.
+func (h *AccountHandler) ResetConfirmation(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	token, expiresAt, err := h.Resets.ConfirmationToken(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, ResetConfirmationResponse{
+		Success:           true,
+		ConfirmationToken: token,
+		ExpiresAt:         expiresAt,
+	})
+}
+
+// DeleteAccount handles DELETE /api/account: erase the account and
+// everything in it, then sign this browser out.
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	if err := h.Deletions.Delete(r.Context(), userID); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	// Sessions on other devices would otherwise outlive the account.
+	if h.Sessions != nil {
+		if err := h.Sessions.RevokeAllSessions(r.Context(), userID); err != nil {
+			slog.Warn("failed to revoke deleted account's sessions", "user_id", userID, "err", err)
+		}
+	}
+	h.clearTokenCookie(w, r)
+	h.clearSudoCookie(w, r)
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Account deleted",
+	})
+}
+
+// ResetAccount wipes the user's trades, holdings and orders and restores the
+// starting balance.
+func (h *AccountHandler) ResetAccount(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "User ID not found")
+		return
+	}
+
+	// The body is optional when confirmation is not required.
+	var req ResetAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.Resets.Reset(r.Context(), userID, req.ConfirmationToken); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	user, err := h.AuthService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, AuthResponse{
+		Success: true,
+		Message: "Account reset",
+		User:    user,
+	})
 }
@@ -5,16 +5,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-webauthn/webauthn/protocol"
 	"github.com/shopspring/decimal"
 
 	"papertrader/internal/config"
 	"papertrader/internal/data"
 	"papertrader/internal/service"
+	"papertrader/internal/util"
 )
 
 // mockAuthService implements AuthServicer for use in handler tests.
@@ -29,9 +34,27 @@ type mockAuthService struct {
 
 	getUserByIDUser *data.User
 	getUserByIDErr  error
+
+	elevateToken   string
+	elevateExpires time.Time
+	elevateErr     error
+
+	verifyErr error
+
+	magicLinkErr  error
+	magicUser     *data.User
+	magicToken    string
+	magicLoginErr error
+
+	googleErr error
+
+	changePasswordErr error
+	changeEmailUser   *data.User
+	changeEmailToken  string
+	changeEmailErr    error
 }
 
-func (m *mockAuthService) Register(_ context.Context, email, password string) (*data.User, string, error) {
+func (m *mockAuthService) Register(_ context.Context, email, password, username, inviteCode string) (*data.User, string, error) {
 	return m.registerUser, m.registerToken, m.registerErr
 }
 func (m *mockAuthService) Login(_ context.Context, email, password string) (*data.User, string, error) {
@@ -40,10 +63,25 @@ func (m *mockAuthService) Login(_ context.Context, email, password string) (*dat
 func (m *mockAuthService) GetUserByID(_ context.Context, userID string) (*data.User, error) {
 	return m.getUserByIDUser, m.getUserByIDErr
 }
-func (m *mockAuthService) VerifyEmail(_ context.Context, token string) error             { return nil }
+func (m *mockAuthService) VerifyEmail(_ context.Context, token string) error             { return m.verifyErr }
 func (m *mockAuthService) ResendVerificationEmail(_ context.Context, email string) error { return nil }
-func (m *mockAuthService) LoginWithGoogle(_ context.Context, token string) (*data.User, string, error) {
-	return nil, "", nil
+func (m *mockAuthService) Elevate(_ context.Context, userID, password, googleIDToken string, ttl time.Duration) (string, time.Time, error) {
+	return m.elevateToken, m.elevateExpires, m.elevateErr
+}
+func (m *mockAuthService) RequestMagicLink(_ context.Context, email string) error {
+	return m.magicLinkErr
+}
+func (m *mockAuthService) LoginWithMagicLink(_ context.Context, token string) (*data.User, string, error) {
+	return m.magicUser, m.magicToken, m.magicLoginErr
+}
+func (m *mockAuthService) LoginWithGoogle(_ context.Context, token, inviteCode string) (*data.User, string, error) {
+	return nil, "", m.googleErr
+}
+func (m *mockAuthService) ChangePassword(_ context.Context, userID, password string) error {
+	return m.changePasswordErr
+}
+func (m *mockAuthService) ChangeEmail(_ context.Context, userID, email string) (*data.User, string, error) {
+	return m.changeEmailUser, m.changeEmailToken, m.changeEmailErr
 }
 
 // helpers
@@ -123,6 +161,41 @@ func TestRegister_EmailAlreadyExists(t *testing.T) {
 	}
 }
 
+func TestRegister_UsernameTaken(t *testing.T) {
+	h := devHandler(&mockAuthService{registerErr: &service.UsernameTakenError{}})
+	req := httptest.NewRequest(http.MethodPost, "/register",
+		jsonBody(t, RegisterRequest{Email: "new@example.com", Password: "Secret1!", Username: "trader_joe"}))
+	w := httptest.NewRecorder()
+	h.Register(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestRegister_InviteCodeErrors(t *testing.T) {
+	for _, err := range []error{&service.InviteCodeRequiredError{}, &service.InvalidInviteCodeError{}} {
+		h := devHandler(&mockAuthService{registerErr: err})
+		req := httptest.NewRequest(http.MethodPost, "/register",
+			jsonBody(t, RegisterRequest{Email: "new@example.com", Password: "Secret1!", InviteCode: "NOPE"}))
+		w := httptest.NewRecorder()
+		h.Register(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%T: expected 403, got %d", err, w.Code)
+		}
+	}
+}
+
+func TestGoogleLogin_InviteCodeRequired(t *testing.T) {
+	h := devHandler(&mockAuthService{googleErr: &service.InviteCodeRequiredError{}})
+	req := httptest.NewRequest(http.MethodPost, "/google",
+		jsonBody(t, GoogleLoginRequest{Token: "google-id-token"}))
+	w := httptest.NewRecorder()
+	h.GoogleLogin(w, req)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "INVITE_CODE_REQUIRED") {
+		t.Errorf("expected 403 INVITE_CODE_REQUIRED, got %d %s", w.Code, w.Body.String())
+	}
+}
+
 func TestRegister_Success(t *testing.T) {
 	h := devHandler(&mockAuthService{
 		registerUser:  fakeUser(),
@@ -229,6 +302,95 @@ func TestLogout_AlwaysOK(t *testing.T) {
 	}
 }
 
+// ---- LogoutAll ----
+
+type mockSessions struct {
+	revokedUser string
+	err         error
+}
+
+func (m *mockSessions) RevokeSession(context.Context, string) error { return m.err }
+
+func (m *mockSessions) RevokeAllSessions(_ context.Context, userID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.revokedUser = userID
+	return nil
+}
+
+func TestLogoutAll_RevokesEverySession(t *testing.T) {
+	sessions := &mockSessions{}
+	h := devHandler(&mockAuthService{})
+	h.Sessions = sessions
+	req := httptest.NewRequest(http.MethodPost, "/logout-all", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.LogoutAll(w, req)
+	if w.Code != http.StatusOK || sessions.revokedUser != "user-1" {
+		t.Fatalf("got %d, revoked %q", w.Code, sessions.revokedUser)
+	}
+	cleared := false
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "token" && c.Value == "" {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("expected the token cookie to be cleared")
+	}
+
+	// Nothing was revoked, so the caller is told rather than signed out.
+	sessions.err = errors.New("redis down")
+	w = httptest.NewRecorder()
+	h.LogoutAll(w, req)
+	if w.Code != http.StatusInternalServerError || len(w.Result().Cookies()) != 0 {
+		t.Errorf("failed revocation: got %d with cookies %v", w.Code, w.Result().Cookies())
+	}
+}
+
+// ---- DeleteAccount ----
+
+type mockDeletions struct {
+	deleted string
+	err     error
+}
+
+func (m *mockDeletions) Delete(_ context.Context, userID string) error {
+	m.deleted = userID
+	return m.err
+}
+
+func TestDeleteAccount_SignsOut(t *testing.T) {
+	deletions := &mockDeletions{}
+	h := devHandler(&mockAuthService{})
+	h.Deletions = deletions
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.DeleteAccount(w, req)
+	if w.Code != http.StatusOK || deletions.deleted != "user-1" {
+		t.Fatalf("got %d, deleted %q", w.Code, deletions.deleted)
+	}
+	cleared := false
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "token" && c.Value == "" {
+			cleared = true
+		}
+	}
+	if !cleared {
+		t.Error("expected the token cookie to be cleared")
+	}
+
+	// A failed deletion keeps the session.
+	deletions.err = &service.UserNotFoundError{}
+	w = httptest.NewRecorder()
+	h.DeleteAccount(w, req)
+	if w.Code != http.StatusNotFound || len(w.Result().Cookies()) != 0 {
+		t.Errorf("failed deletion: got %d with cookies %v", w.Code, w.Result().Cookies())
+	}
+}
+
 // ---- GetProfile ----
 
 func TestGetProfile_MissingUserID(t *testing.T) {
@@ -269,3 +431,906 @@ func TestGetProfile_Success(t *testing.T) {
 		t.Errorf("user.ID = %q, want %q", user.ID, "user-1")
 	}
 }
+
+// ---- GetUsage ----
+
+type mockUsage struct {
+	report     *service.UsageReport
+	err        error
+	userID, ip string
+}
+
+func (m *mockUsage) Report(_ context.Context, userID, ipAddress string) (*service.UsageReport, error) {
+	m.userID, m.ip = userID, ipAddress
+	return m.report, m.err
+}
+
+func TestGetUsage_MissingUserID(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Usage = &mockUsage{}
+	w := httptest.NewRecorder()
+	h.GetUsage(w, httptest.NewRequest(http.MethodGet, "/usage", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestGetUsage_Success(t *testing.T) {
+	usage := &mockUsage{report: &service.UsageReport{
+		RateLimits: []service.RateLimitConsumption{{Name: "global", Scope: "user", Used: 4, Limit: 100, Remaining: 96, WindowSeconds: 3600}},
+		Totals:     map[string]service.UsageTotals{service.UsageRequests: {CurrentHour: 4, Last24Hours: 40}},
+	}}
+	h := devHandler(&mockAuthService{})
+	h.Usage = usage
+
+	req := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	req = req.WithContext(service.WithClientInfo(req.Context(), service.ClientInfo{IP: "10.0.0.1"}))
+	w := httptest.NewRecorder()
+	h.GetUsage(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if usage.userID != "user-1" || usage.ip != "10.0.0.1" {
+		t.Errorf("Report called with (%q, %q)", usage.userID, usage.ip)
+	}
+	var got service.UsageReport
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got.RateLimits) != 1 || got.RateLimits[0].Remaining != 96 || got.Totals[service.UsageRequests].Last24Hours != 40 {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestGetUsage_ServiceError(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Usage = &mockUsage{err: errors.New("redis down")}
+
+	req := httptest.NewRequest(http.MethodGet, "/usage", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetUsage(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
+
+// ---- GetStatement ----
+
+type mockStatements struct {
+	statement *service.AccountStatement
+	err       error
+	month     string
+}
+
+func (m *mockStatements) Statement(_ context.Context, userID, month string) (*service.AccountStatement, error) {
+	m.month = month
+	return m.statement, m.err
+}
+
+type mockCurrency struct{ rate *service.FXRate }
+
+func (m *mockCurrency) SetDisplayCurrency(_ context.Context, userID, currency string) (string, error) {
+	return currency, nil
+}
+func (m *mockCurrency) DisplayRate(_ context.Context, userID, requested string) (*service.FXRate, error) {
+	return m.rate, nil
+}
+
+func TestGetStatement_ConvertsAmounts(t *testing.T) {
+	net := decimal.NewFromInt(500)
+	statements := &mockStatements{statement: &service.AccountStatement{
+		Month:          "2026-09",
+		Closing:        &service.StatementBalance{Date: "2026-09-30", Cash: decimal.NewFromInt(5500), TotalValue: decimal.NewFromInt(10500)},
+		Buys:           decimal.NewFromInt(2000),
+		NetPerformance: &net,
+		Final:          true,
+	}}
+	h := devHandler(&mockAuthService{})
+	h.Statements = statements
+	h.Currency = &mockCurrency{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+
+	req := httptest.NewRequest(http.MethodGet, "/statements?month=2026-09", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetStatement(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got service.AccountStatement
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if statements.month != "2026-09" || got.Currency != "EUR" || got.Opening != nil || !got.Closing.Cash.Equal(decimal.NewFromInt(2750)) ||
+		!got.Buys.Equal(decimal.NewFromInt(1000)) || !got.NetPerformance.Equal(decimal.NewFromInt(250)) || !got.Final {
+		t.Errorf("unexpected body: %s", w.Body.String())
+	}
+}
+
+func TestGetStatement_PDF(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Statements = &mockStatements{statement: &service.AccountStatement{Month: "2026-09", Final: true}}
+	h.Currency = &mockCurrency{rate: &service.FXRate{From: "USD", To: "USD", Rate: decimal.NewFromInt(1)}}
+
+	req := httptest.NewRequest(http.MethodGet, "/statements?month=2026-09&format=pdf", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetStatement(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+		t.Errorf("Content-Type: got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="statement-2026-09.pdf"` {
+		t.Errorf("Content-Disposition: got %q", cd)
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) {
+		t.Errorf("body is not a PDF: %q", w.Body.String()[:min(20, w.Body.Len())])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/statements?month=2026-09&format=xlsx", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w = httptest.NewRecorder()
+	h.GetStatement(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: expected 400, got %d", w.Code)
+	}
+}
+
+// ---- GetLimits ----
+
+type mockLimits struct {
+	limits *service.TradeLimits
+	err    error
+}
+
+func (m *mockLimits) GetLimits(_ context.Context, userID string) (*service.TradeLimits, error) {
+	return m.limits, m.err
+}
+
+func TestGetLimits_MissingUserID(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Limits = &mockLimits{}
+	w := httptest.NewRecorder()
+	h.GetLimits(w, httptest.NewRequest(http.MethodGet, "/limits", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", w.Code)
+	}
+}
+
+func TestGetLimits_Success(t *testing.T) {
+	remaining := 97
+	h := devHandler(&mockAuthService{})
+	h.Limits = &mockLimits{limits: &service.TradeLimits{TradesToday: 3, MaxTradesPerDay: 100, TradesRemaining: &remaining}}
+
+	req := httptest.NewRequest(http.MethodGet, "/limits", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetLimits(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var got service.TradeLimits
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.TradesToday != 3 || got.TradesRemaining == nil || *got.TradesRemaining != 97 {
+		t.Errorf("unexpected limits: %+v", got)
+	}
+}
+
+func TestGetLimits_ServiceError(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Limits = &mockLimits{err: errors.New("db down")}
+
+	req := httptest.NewRequest(http.MethodGet, "/limits", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetLimits(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", w.Code)
+	}
+}
+
+// ---- Sudo ----
+
+func TestSudo_RequiresCredential(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	req := httptest.NewRequest(http.MethodPost, "/sudo", jsonBody(t, SudoRequest{}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.Sudo(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestSudo_SetsScopedCookie(t *testing.T) {
+	expires := time.Now().Add(5 * time.Minute)
+	h := devHandler(&mockAuthService{elevateToken: "sudo-tok", elevateExpires: expires})
+
+	req := httptest.NewRequest(http.MethodPost, "/sudo", jsonBody(t, SudoRequest{Password: "pw"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.Sudo(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var found bool
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "sudo_token" {
+			found = true
+			if c.Value != "sudo-tok" || !c.HttpOnly || c.Path != "/api" || c.SameSite != http.SameSiteStrictMode {
+				t.Errorf("unexpected sudo cookie: %+v", c)
+			}
+		}
+	}
+	if !found {
+		t.Error("sudo_token cookie not set")
+	}
+}
+
+func TestSudo_WrongPassword(t *testing.T) {
+	h := devHandler(&mockAuthService{elevateErr: &service.SudoConfirmationError{}})
+
+	req := httptest.NewRequest(http.MethodPost, "/sudo", jsonBody(t, SudoRequest{Password: "nope"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.Sudo(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", w.Code)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("no cookie should be set on failure")
+	}
+}
+
+func TestChangePassword_WeakPassword(t *testing.T) {
+	h := devHandler(&mockAuthService{changePasswordErr: &util.ValidationError{Field: "password", Message: "too short"}})
+
+	req := httptest.NewRequest(http.MethodPut, "/password", jsonBody(t, ChangePasswordRequest{Password: "short"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ChangePassword(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestChangeEmail_ReissuesSessionCookie(t *testing.T) {
+	user := fakeUser()
+	user.Email = "new@example.com"
+	h := devHandler(&mockAuthService{changeEmailUser: user, changeEmailToken: "new-session"})
+
+	req := httptest.NewRequest(http.MethodPut, "/email", jsonBody(t, ChangeEmailRequest{Email: "new@example.com"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ChangeEmail(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var found bool
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "token" && c.Value == "new-session" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("session cookie should be reissued with the new token")
+	}
+}
+
+func TestChangeEmail_Taken(t *testing.T) {
+	h := devHandler(&mockAuthService{changeEmailErr: &service.EmailExistsError{}})
+
+	req := httptest.NewRequest(http.MethodPut, "/email", jsonBody(t, ChangeEmailRequest{Email: "taken@example.com"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ChangeEmail(w, req)
+
+	if w.Code != http.StatusBadRequest || len(w.Result().Cookies()) != 0 {
+		t.Errorf("expected 400 and no cookie, got %d with %d cookie(s)", w.Code, len(w.Result().Cookies()))
+	}
+}
+
+// ---- VerifyEmail ----
+
+func verifyHandler(svc AuthServicer) *AccountHandler {
+	h := devHandler(svc)
+	h.Config.FrontendURL = "https://app.example.com"
+	h.Config.MobileAppScheme = "papertrader"
+	return h
+}
+
+func TestVerifyEmail_NoRedirectReturnsJSON(t *testing.T) {
+	h := verifyHandler(&mockAuthService{})
+	req := httptest.NewRequest(http.MethodGet, "/verify-email?token=abc", nil)
+	w := httptest.NewRecorder()
+	h.VerifyEmail(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON 200, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestVerifyEmail_RedirectsToApp(t *testing.T) {
+	cases := []struct {
+		name      string
+		verifyErr error
+		want      string
+	}{
+		{"verified", nil, "papertrader://verify-email?source=email&status=verified"},
+		{"failed", errors.New("invalid or expired verification token"), "papertrader://verify-email?source=email&status=failed"},
+	}
+	for _, tc := range cases {
+		h := verifyHandler(&mockAuthService{verifyErr: tc.verifyErr})
+		req := httptest.NewRequest(http.MethodGet,
+			"/verify-email?token=abc&redirect="+url.QueryEscape("papertrader://verify-email?source=email"), nil)
+		w := httptest.NewRecorder()
+		h.VerifyEmail(w, req)
+		if w.Code != http.StatusSeeOther || w.Header().Get("Location") != tc.want {
+			t.Errorf("%s: got %d Location=%q, want 303 %q", tc.name, w.Code, w.Header().Get("Location"), tc.want)
+		}
+	}
+}
+
+func TestVerifyEmail_RedirectToFrontend(t *testing.T) {
+	h := verifyHandler(&mockAuthService{})
+	req := httptest.NewRequest(http.MethodGet,
+		"/verify-email?token=abc&redirect="+url.QueryEscape("https://app.example.com/welcome"), nil)
+	w := httptest.NewRecorder()
+	h.VerifyEmail(w, req)
+	if got := w.Header().Get("Location"); got != "https://app.example.com/welcome?status=verified" {
+		t.Errorf("unexpected Location %q", got)
+	}
+}
+
+func TestVerifyEmail_RejectsOpenRedirect(t *testing.T) {
+	for _, target := range []string{
+		"https://evil.example.com/",
+		"https://app.example.com@evil.example.com/",
+		"javascript:alert(1)",
+		"//evil.example.com",
+	} {
+		h := verifyHandler(&mockAuthService{})
+		req := httptest.NewRequest(http.MethodGet, "/verify-email?token=abc&redirect="+url.QueryEscape(target), nil)
+		w := httptest.NewRecorder()
+		h.VerifyEmail(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", target, w.Code)
+		}
+	}
+}
+
+// ---- Magic link ----
+
+func TestRequestMagicLink_RateLimited(t *testing.T) {
+	h := devHandler(&mockAuthService{magicLinkErr: &service.MagicLinkRateLimitError{}})
+	req := httptest.NewRequest(http.MethodPost, "/magic-link", jsonBody(t, MagicLinkRequest{Email: "a@example.com"}))
+	w := httptest.NewRecorder()
+	h.RequestMagicLink(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", w.Code)
+	}
+}
+
+func TestMagicLinkCallback_SetsCookieAndRedirects(t *testing.T) {
+	h := devHandler(&mockAuthService{magicUser: fakeUser(), magicToken: "jwt-magic"})
+	h.Config.FrontendURL = "https://app.example.com"
+	req := httptest.NewRequest(http.MethodGet, "/magic-link/callback?token=abc", nil)
+	w := httptest.NewRecorder()
+	h.MagicLinkCallback(w, req)
+
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "https://app.example.com/dashboard" {
+		t.Errorf("expected 303 to dashboard, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	var found bool
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "token" && c.Value == "jwt-magic" && c.HttpOnly {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected HttpOnly token cookie")
+	}
+}
+
+func TestMagicLinkCallback_InvalidToken(t *testing.T) {
+	h := devHandler(&mockAuthService{magicLoginErr: &service.InvalidMagicLinkError{}})
+	h.Config.FrontendURL = "https://app.example.com"
+	req := httptest.NewRequest(http.MethodGet, "/magic-link/callback?token=used", nil)
+	w := httptest.NewRecorder()
+	h.MagicLinkCallback(w, req)
+
+	if got := w.Header().Get("Location"); got != "https://app.example.com/login?magic_link=failed" {
+		t.Errorf("unexpected Location %q", got)
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("no cookie should be set on failure")
+	}
+}
+
+// ---- Avatar ----
+
+type mockAvatars struct {
+	max      int64
+	url      string
+	err      error
+	gotBytes int
+	removed  bool
+}
+
+func (m *mockAvatars) Upload(_ context.Context, userID string, body []byte) (string, error) {
+	m.gotBytes = len(body)
+	return m.url, m.err
+}
+func (m *mockAvatars) Remove(_ context.Context, userID string) error {
+	m.removed = true
+	return m.err
+}
+func (m *mockAvatars) MaxBytes() int64 { return m.max }
+
+func TestUploadAvatar_Success(t *testing.T) {
+	avatars := &mockAvatars{max: 1024, url: "/api/uploads/avatars/user-1/a.png"}
+	h := devHandler(&mockAuthService{})
+	h.Avatars = avatars
+
+	req := httptest.NewRequest(http.MethodPut, "/avatar", bytes.NewReader(make([]byte, 100)))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.UploadAvatar(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp AvatarResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.AvatarURL != avatars.url || avatars.gotBytes != 100 {
+		t.Errorf("unexpected response %+v (service saw %d bytes)", resp, avatars.gotBytes)
+	}
+}
+
+func TestUploadAvatar_ReadsAtMostOneByteOverCap(t *testing.T) {
+	avatars := &mockAvatars{max: 10, err: &service.AvatarTooLargeError{MaxBytes: 10}}
+	h := devHandler(&mockAuthService{})
+	h.Avatars = avatars
+
+	req := httptest.NewRequest(http.MethodPut, "/avatar", bytes.NewReader(make([]byte, 5000)))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.UploadAvatar(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", w.Code)
+	}
+	if avatars.gotBytes != 11 {
+		t.Errorf("handler should pass max+1 bytes, passed %d", avatars.gotBytes)
+	}
+}
+
+func TestUploadAvatar_InvalidImage(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Avatars = &mockAvatars{max: 1024, err: &service.InvalidAvatarError{}}
+
+	req := httptest.NewRequest(http.MethodPut, "/avatar", bytes.NewReader([]byte("not an image")))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.UploadAvatar(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestDeleteAvatar(t *testing.T) {
+	avatars := &mockAvatars{}
+	h := devHandler(&mockAuthService{})
+	h.Avatars = avatars
+
+	req := httptest.NewRequest(http.MethodDelete, "/avatar", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.DeleteAvatar(w, req)
+
+	if w.Code != http.StatusOK || !avatars.removed {
+		t.Errorf("expected 200 and removal, got %d removed=%v", w.Code, avatars.removed)
+	}
+}
+
+// ---- Username ----
+
+type mockUsernames struct {
+	err       error
+	available bool
+	setName   string
+}
+
+func (m *mockUsernames) Set(_ context.Context, userID, name string) error {
+	m.setName = name
+	return m.err
+}
+func (m *mockUsernames) Available(_ context.Context, name string) (bool, error) {
+	return m.available, m.err
+}
+
+func TestSetUsername_Success(t *testing.T) {
+	usernames := &mockUsernames{}
+	h := devHandler(&mockAuthService{})
+	h.Usernames = usernames
+
+	req := httptest.NewRequest(http.MethodPut, "/username", jsonBody(t, UsernameRequest{Username: "Trader_Joe"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.SetUsername(w, req)
+
+	if w.Code != http.StatusOK || usernames.setName != "Trader_Joe" {
+		t.Errorf("expected 200 and name passed through, got %d %q", w.Code, usernames.setName)
+	}
+}
+
+func TestSetUsername_Cooldown(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Usernames = &mockUsernames{err: &service.UsernameCooldownError{RetryAt: time.Now().Add(time.Hour)}}
+
+	req := httptest.NewRequest(http.MethodPut, "/username", jsonBody(t, UsernameRequest{Username: "someone"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.SetUsername(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", w.Code)
+	}
+}
+
+func TestUsernameAvailable(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Usernames = &mockUsernames{available: true}
+
+	req := httptest.NewRequest(http.MethodGet, "/username/available?name=trader_joe", nil)
+	w := httptest.NewRecorder()
+	h.UsernameAvailable(w, req)
+
+	var resp UsernameAvailabilityResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || !resp.Available || resp.Username != "trader_joe" {
+		t.Errorf("unexpected response %d %+v", w.Code, resp)
+	}
+}
+
+func TestUsernameAvailable_InvalidName(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Usernames = &mockUsernames{err: &service.InvalidUsernameError{Reason: "That username is reserved"}}
+
+	req := httptest.NewRequest(http.MethodGet, "/username/available?name=admin", nil)
+	w := httptest.NewRecorder()
+	h.UsernameAvailable(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+// ---- Guest ----
+
+type mockGuests struct {
+	user        *data.User
+	token       string
+	err         error
+	upgradedVia string
+	inviteCode  string
+}
+
+func (m *mockGuests) Create(_ context.Context, inviteCode string) (*data.User, string, error) {
+	m.inviteCode = inviteCode
+	return m.user, m.token, m.err
+}
+func (m *mockGuests) Upgrade(_ context.Context, userID, email, password string) (*data.User, string, error) {
+	m.upgradedVia = "password"
+	return m.user, m.token, m.err
+}
+func (m *mockGuests) UpgradeWithGoogle(_ context.Context, userID, idToken string) (*data.User, string, error) {
+	m.upgradedVia = "google"
+	return m.user, m.token, m.err
+}
+
+func sessionCookie(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "token" {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestCreateGuest_SetsCookie(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Guests = &mockGuests{user: &data.User{ID: "guest-1", IsGuest: true}, token: "guest-jwt"}
+
+	w := httptest.NewRecorder()
+	h.CreateGuest(w, httptest.NewRequest(http.MethodPost, "/guest", nil))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	if c := sessionCookie(w); c == nil || c.Value != "guest-jwt" {
+		t.Errorf("expected guest session cookie, got %v", c)
+	}
+}
+
+func TestCreateGuest_PassesInviteCode(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	guests := &mockGuests{user: &data.User{ID: "guest-1", IsGuest: true}, token: "guest-jwt"}
+	h.Guests = guests
+
+	w := httptest.NewRecorder()
+	h.CreateGuest(w, httptest.NewRequest(http.MethodPost, "/guest", jsonBody(t, GuestRequest{InviteCode: "ABCDE23456"})))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", w.Code)
+	}
+	if guests.inviteCode != "ABCDE23456" {
+		t.Errorf("invite code: got %q", guests.inviteCode)
+	}
+}
+
+func TestUpgradeGuest_ChoosesMethod(t *testing.T) {
+	cases := []struct {
+		name string
+		body GuestUpgradeRequest
+		want string
+	}{
+		{"password", GuestUpgradeRequest{Email: "a@example.com", Password: "Secret123!"}, "password"},
+		{"google", GuestUpgradeRequest{GoogleToken: "id-token"}, "google"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			guests := &mockGuests{user: fakeUser(), token: "full-jwt"}
+			h := devHandler(&mockAuthService{})
+			h.Guests = guests
+
+			req := httptest.NewRequest(http.MethodPost, "/guest/upgrade", jsonBody(t, tc.body))
+			req.Header.Set("X-User-ID", "guest-1")
+			w := httptest.NewRecorder()
+			h.UpgradeGuest(w, req)
+
+			if w.Code != http.StatusOK || guests.upgradedVia != tc.want {
+				t.Errorf("expected 200 via %s, got %d via %q", tc.want, w.Code, guests.upgradedVia)
+			}
+			if c := sessionCookie(w); c == nil || c.Value != "full-jwt" {
+				t.Errorf("expected reissued session cookie, got %v", c)
+			}
+		})
+	}
+}
+
+func TestUpgradeGuest_MissingCredentials(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Guests = &mockGuests{}
+
+	req := httptest.NewRequest(http.MethodPost, "/guest/upgrade", jsonBody(t, GuestUpgradeRequest{Email: "a@example.com"}))
+	req.Header.Set("X-User-ID", "guest-1")
+	w := httptest.NewRecorder()
+	h.UpgradeGuest(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestUpgradeGuest_AlreadyFullAccount(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Guests = &mockGuests{err: &service.NotGuestError{}}
+
+	req := httptest.NewRequest(http.MethodPost, "/guest/upgrade", jsonBody(t, GuestUpgradeRequest{GoogleToken: "id-token"}))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.UpgradeGuest(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+// ---- Passkeys ----
+
+type mockPasskeys struct {
+	ceremony string
+	user     *data.User
+	token    string
+	err      error
+}
+
+func (m *mockPasskeys) BeginRegistration(_ context.Context, userID string) (*protocol.CredentialCreation, string, time.Time, error) {
+	return &protocol.CredentialCreation{}, "ceremony-jwt", time.Now().Add(time.Minute), m.err
+}
+func (m *mockPasskeys) FinishRegistration(_ context.Context, userID, ceremony, name string, _ io.Reader) (*data.Passkey, error) {
+	m.ceremony = ceremony
+	return &data.Passkey{ID: "cred-1", Name: name}, m.err
+}
+func (m *mockPasskeys) BeginLogin(_ context.Context) (*protocol.CredentialAssertion, string, time.Time, error) {
+	return &protocol.CredentialAssertion{}, "ceremony-jwt", time.Now().Add(time.Minute), m.err
+}
+func (m *mockPasskeys) FinishLogin(_ context.Context, ceremony string, _ io.Reader) (*data.User, string, error) {
+	m.ceremony = ceremony
+	return m.user, m.token, m.err
+}
+func (m *mockPasskeys) List(_ context.Context, userID string) ([]data.Passkey, error) {
+	return nil, m.err
+}
+func (m *mockPasskeys) Delete(_ context.Context, userID, id string) error {
+	return m.err
+}
+
+func TestBeginPasskeyLogin_SetsCeremonyCookie(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Passkeys = &mockPasskeys{}
+
+	w := httptest.NewRecorder()
+	h.BeginPasskeyLogin(w, httptest.NewRequest(http.MethodPost, "/passkeys/login/begin", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var ceremony *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == passkeyCeremonyCookie {
+			ceremony = c
+		}
+	}
+	if ceremony == nil || ceremony.Value != "ceremony-jwt" || !ceremony.HttpOnly || ceremony.Path != passkeyCookiePath {
+		t.Errorf("unexpected ceremony cookie %+v", ceremony)
+	}
+}
+
+func TestFinishPasskeyLogin_Success(t *testing.T) {
+	passkeys := &mockPasskeys{user: fakeUser(), token: "jwt-passkey"}
+	h := devHandler(&mockAuthService{})
+	h.Passkeys = passkeys
+
+	req := httptest.NewRequest(http.MethodPost, "/passkeys/login/finish", strings.NewReader(`{}`))
+	req.AddCookie(&http.Cookie{Name: passkeyCeremonyCookie, Value: "ceremony-jwt"})
+	w := httptest.NewRecorder()
+	h.FinishPasskeyLogin(w, req)
+
+	if w.Code != http.StatusOK || passkeys.ceremony != "ceremony-jwt" {
+		t.Fatalf("expected 200 with ceremony passed through, got %d %q", w.Code, passkeys.ceremony)
+	}
+	if c := sessionCookie(w); c == nil || c.Value != "jwt-passkey" {
+		t.Errorf("expected session cookie, got %v", c)
+	}
+}
+
+func TestFinishPasskeyLogin_Failure(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Passkeys = &mockPasskeys{err: &service.InvalidPasskeyError{}}
+
+	w := httptest.NewRecorder()
+	h.FinishPasskeyLogin(w, httptest.NewRequest(http.MethodPost, "/passkeys/login/finish", strings.NewReader(`{}`)))
+
+	if w.Code != http.StatusUnauthorized || sessionCookie(w) != nil {
+		t.Errorf("expected 401 without session cookie, got %d", w.Code)
+	}
+}
+
+func TestFinishPasskeyRegistration_Created(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Passkeys = &mockPasskeys{}
+
+	req := httptest.NewRequest(http.MethodPost, "/passkeys/register/finish?name=Laptop", strings.NewReader(`{}`))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.FinishPasskeyRegistration(w, req)
+
+	var resp data.Passkey
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusCreated || resp.Name != "Laptop" {
+		t.Errorf("unexpected response %d %+v", w.Code, resp)
+	}
+}
+
+type mockBonus struct {
+	claimed string
+	err     error
+}
+
+func (m *mockBonus) Status(_ context.Context, _ string) (*service.BonusStatus, error) {
+	return &service.BonusStatus{Enabled: true, Available: true}, nil
+}
+
+func (m *mockBonus) Claim(_ context.Context, userID string) (*service.BonusClaim, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.claimed = userID
+	return &service.BonusClaim{Amount: decimal.NewFromInt(1000), Balance: decimal.NewFromInt(1050)}, nil
+}
+
+func TestClaimBonus_Success(t *testing.T) {
+	bonus := &mockBonus{}
+	h := devHandler(&mockAuthService{})
+	h.Bonus = bonus
+
+	req := httptest.NewRequest(http.MethodPost, "/bonus", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ClaimBonus(w, req)
+
+	var resp service.BonusClaim
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || bonus.claimed != "user-1" || !resp.Balance.Equal(decimal.NewFromInt(1050)) {
+		t.Errorf("unexpected response %d %+v", w.Code, resp)
+	}
+}
+
+func TestClaimBonus_Cooldown(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Bonus = &mockBonus{err: &service.BonusCooldownError{RetryAt: time.Now().Add(time.Hour)}}
+
+	req := httptest.NewRequest(http.MethodPost, "/bonus", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ClaimBonus(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", w.Code)
+	}
+}
+
+type mockTransfers struct {
+	replace bool
+	err     error
+}
+
+func (m *mockTransfers) Export(_ context.Context, _ string) (*service.AccountBundle, error) {
+	return &service.AccountBundle{Payload: json.RawMessage(`{"schema_version":1}`), Signature: "abc"}, nil
+}
+
+func (m *mockTransfers) Import(_ context.Context, _ string, _ *service.AccountBundle, replace bool) (*service.AccountImport, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.replace = replace
+	return &service.AccountImport{Replaced: replace, Trades: 3}, nil
+}
+
+func TestImportAccount_Replace(t *testing.T) {
+	transfers := &mockTransfers{}
+	h := devHandler(&mockAuthService{})
+	h.Transfers = transfers
+
+	body := `{"payload":{"schema_version":1},"signature":"abc"}`
+	req := httptest.NewRequest(http.MethodPost, "/transfer/import?mode=replace", strings.NewReader(body))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ImportAccount(w, req)
+
+	if w.Code != http.StatusOK || !transfers.replace {
+		t.Errorf("unexpected response %d, replace=%v", w.Code, transfers.replace)
+	}
+}
+
+func TestImportAccount_NotEmpty(t *testing.T) {
+	h := devHandler(&mockAuthService{})
+	h.Transfers = &mockTransfers{err: &service.AccountNotEmptyError{}}
+
+	body := `{"payload":{"schema_version":1},"signature":"abc"}`
+	req := httptest.NewRequest(http.MethodPost, "/transfer/import", strings.NewReader(body))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ImportAccount(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
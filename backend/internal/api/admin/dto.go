@@ -0,0 +1,139 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
+
+type HaltRequest struct {
+	Reason string `json:"reason"`
+}
+
+// PrecisionRequest is the body of PUT /api/admin/instruments/{symbol}/precision.
+type PrecisionRequest struct {
+	TickSize      decimal.Decimal `json:"tick_size"`
+	PriceDecimals int32           `json:"price_decimals"`
+}
+
+// CuratedListRequest is the body of PUT /api/admin/watchlists/{slug}.
+type CuratedListRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Symbols     []string `json:"symbols"`
+	IsDefault   bool     `json:"is_default"`
+}
+
+// ClassificationRequest is the body of PUT /api/admin/classifications/{symbol}.
+type ClassificationRequest struct {
+	Sector   string   `json:"sector"`
+	Industry string   `json:"industry"`
+	Indices  []string `json:"indices"`
+}
+
+// ClassificationReloadResponse reports how many dataset rows a reload wrote;
+// symbols with an admin override are not counted.
+type ClassificationReloadResponse struct {
+	Written int `json:"written"`
+}
+
+// SymbolRenameRequest is the body of PUT /api/admin/symbols/renames/{symbol}.
+// EffectiveDate is the first session under the new symbol, YYYY-MM-DD.
+type SymbolRenameRequest struct {
+	NewSymbol     string `json:"new_symbol"`
+	EffectiveDate string `json:"effective_date"`
+}
+
+type SymbolRenameListResponse struct {
+	Items []data.SymbolAlias `json:"items"`
+}
+
+// InviteCodeRequest is the body of POST /api/admin/invite-codes. Count and
+// MaxUses default to 1; a nil ExpiresAt never expires. StartingBalance and
+// League set the cohort the admitted accounts join.
+type InviteCodeRequest struct {
+	Count           int              `json:"count"`
+	MaxUses         int              `json:"max_uses"`
+	ExpiresAt       *time.Time       `json:"expires_at"`
+	Note            string           `json:"note"`
+	StartingBalance *decimal.Decimal `json:"starting_balance"`
+	League          string           `json:"league"`
+}
+
+type InviteCodeListResponse struct {
+	Items []data.InviteCode `json:"items"`
+}
+
+type UserStatsListResponse struct {
+	Items []data.UserStats `json:"items"`
+}
+
+type AdminUserListResponse struct {
+	Items []data.AdminUser `json:"items"`
+}
+
+// BalanceAdjustmentRequest is the body of POST
+// /api/admin/users/{id}/balance. A negative Amount is a debit.
+type BalanceAdjustmentRequest struct {
+	Amount decimal.Decimal `json:"amount"`
+	Reason string          `json:"reason"`
+}
+
+// UserLockRequest is the body of POST /api/admin/users/{id}/lock.
+type UserLockRequest struct {
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+type HaltedListResponse struct {
+	Items []data.Instrument `json:"items"`
+}
+
+type AuditEventListResponse struct {
+	Items []data.AuditEvent `json:"items"`
+}
+
+type RateLimitBucketResponse struct {
+	Name          string `json:"name"`
+	UserLimit     int    `json:"user_limit"`
+	IPLimit       int    `json:"ip_limit"`
+	WindowSeconds int64  `json:"window_seconds"`
+}
+
+type RateLimitOverviewResponse struct {
+	Buckets []RateLimitBucketResponse  `json:"buckets"`
+	Metrics service.RateLimiterMetrics `json:"metrics"`
+}
+
+type RateLimitConsumerListResponse struct {
+	Items []service.RateLimitConsumer `json:"items"`
+}
+
+// EODRerunRequest is the body of POST /api/admin/eod/rerun. Date is the
+// session's New York trading day, YYYY-MM-DD.
+type EODRerunRequest struct {
+	Date string `json:"date"`
+}
+
+// MarketCacheInvalidateRequest is the body of POST
+// /api/admin/market-cache/invalidate: the kinds of cached market data to
+// flush (every kind when empty) for Symbols, or for every symbol when All
+// is set.
+type MarketCacheInvalidateRequest struct {
+	Symbols []string `json:"symbols"`
+	Kinds   []string `json:"kinds"`
+	All     bool     `json:"all"`
+}
+
+// TradeDisputeResolution is the body of POST /api/admin/disputes/{id}/reverse
+// and /reject. Note is shown to the user.
+type TradeDisputeResolution struct {
+	Note string `json:"note"`
+}
+
+type TradeDisputeListResponse struct {
+	Items []data.TradeDispute `json:"items"`
+}
@@ -0,0 +1,754 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/api/auth"
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+	"papertrader/internal/util"
+)
+
+// InstrumentAdminServicer is the subset of service.InstrumentService used by
+// the admin handler.
+type InstrumentAdminServicer interface {
+	Halt(ctx context.Context, symbol, reason string) (*data.Instrument, error)
+	Resume(ctx context.Context, symbol string) (*data.Instrument, error)
+	ListHalted(ctx context.Context) ([]data.Instrument, error)
+	SetPrecision(ctx context.Context, symbol string, tickSize decimal.Decimal, decimals int32) (*data.Instrument, error)
+}
+
+// AuditAdminServicer is the subset of service.AnomalyService used by the
+// admin handler.
+type AuditAdminServicer interface {
+	ListFlagged(ctx context.Context, limit int) ([]data.AuditEvent, error)
+}
+
+// RateLimitAdminServicer is the subset of service.RateLimitAdminService used
+// by the admin handler.
+type RateLimitAdminServicer interface {
+	Buckets() []service.RateLimitBucket
+	Metrics() service.RateLimiterMetrics
+	Inspect(ctx context.Context, bucket, scope, id string) (*service.RateLimitWindow, error)
+	Reset(ctx context.Context, bucket, scope, id string) error
+	TopConsumers(ctx context.Context, bucket, scope string, n int) ([]service.RateLimitConsumer, error)
+}
+
+// CuratedListAdminServicer is the subset of service.WatchlistService used by
+// the admin handler.
+type CuratedListAdminServicer interface {
+	SaveCuratedList(ctx context.Context, list data.CuratedList) (*data.CuratedList, error)
+	DeleteCuratedList(ctx context.Context, slug string) error
+}
+
+// ClassificationAdminServicer is the subset of service.ClassificationService
+// used by the admin handler.
+type ClassificationAdminServicer interface {
+	Override(ctx context.Context, c data.Classification) (*data.Classification, error)
+	LoadDataset(ctx context.Context) (int, error)
+}
+
+// UserAdminServicer is the subset of service.UserAdminService used by the
+// admin handler.
+type UserAdminServicer interface {
+	ImportCSV(ctx context.Context, r io.Reader, invite bool) (*service.UserImportReport, error)
+	Export(ctx context.Context, league string) ([]data.UserStats, error)
+	Search(ctx context.Context, query string, limit int) ([]data.AdminUser, error)
+	Get(ctx context.Context, userID string) (*data.AdminUser, error)
+	AdjustBalance(ctx context.Context, adminID, userID string, amount decimal.Decimal, reason string) (*service.BalanceAdjustment, error)
+	Lock(ctx context.Context, adminID, userID string, until time.Time, reason string) error
+	Unlock(ctx context.Context, adminID, userID string) error
+	ResendVerification(ctx context.Context, adminID, userID string) error
+	Delete(ctx context.Context, adminID, userID string) error
+}
+
+// InviteCodeAdminServicer is the subset of service.InviteService used by the
+// admin handler.
+type InviteCodeAdminServicer interface {
+	Generate(ctx context.Context, createdBy string, spec service.InviteCodeSpec) ([]data.InviteCode, error)
+	List(ctx context.Context) ([]data.InviteCode, error)
+	Get(ctx context.Context, code string) (*service.InviteCodeDetail, error)
+	Revoke(ctx context.Context, code string) error
+}
+
+// EODAdminServicer is the subset of service.EODCloseService used by the
+// admin handler.
+type EODAdminServicer interface {
+	Rerun(ctx context.Context, adminID string, date time.Time) (*service.EODRerun, error)
+}
+
+// SymbolAliasAdminServicer is the subset of service.SymbolAliasService used
+// by the admin handler.
+type SymbolAliasAdminServicer interface {
+	List(ctx context.Context) ([]data.SymbolAlias, error)
+	Record(ctx context.Context, a data.SymbolAlias) (*data.SymbolAlias, error)
+}
+
+// RetentionAdminServicer is the subset of service.RetentionService used by
+// the admin handler.
+type RetentionAdminServicer interface {
+	Report(ctx context.Context) (*service.RetentionReport, error)
+	RunOnce(ctx context.Context) (*service.RetentionRun, error)
+}
+
+// TradeDisputeAdminServicer is the subset of service.TradeDisputeService
+// used by the admin handler.
+type TradeDisputeAdminServicer interface {
+	List(ctx context.Context, status string, limit int) ([]data.TradeDispute, error)
+	Reverse(ctx context.Context, adminID, disputeID, note string) (*data.TradeDispute, error)
+	Reject(ctx context.Context, adminID, disputeID, note string) (*data.TradeDispute, error)
+}
+
+// MarketCacheAdminServicer is the subset of service.MarketCacheAdminService
+// used by the admin handler.
+type MarketCacheAdminServicer interface {
+	Invalidate(ctx context.Context, req service.MarketCacheInvalidation) (*service.MarketCacheInvalidated, error)
+}
+
+// MarketQuotaAdminServicer is the subset of service.QuotaTracker used by the
+// admin handler.
+type MarketQuotaAdminServicer interface {
+	Usage(ctx context.Context) (*service.QuotaUsage, error)
+}
+
+// maxUserImportBody caps POST /api/admin/users/import. 1000 rows of email,
+// balance and league fit comfortably.
+const maxUserImportBody = 1 << 20
+
+type AdminHandler struct {
+	instruments     InstrumentAdminServicer
+	audit           AuditAdminServicer
+	rateLimits      RateLimitAdminServicer
+	curatedLists    CuratedListAdminServicer
+	classifications ClassificationAdminServicer
+	users           UserAdminServicer
+	invites         InviteCodeAdminServicer
+	eod             EODAdminServicer
+	aliases         SymbolAliasAdminServicer
+	retention       RetentionAdminServicer
+	disputes        TradeDisputeAdminServicer
+	marketCache     MarketCacheAdminServicer
+	marketQuota     MarketQuotaAdminServicer
+}
+
+func NewAdminHandler(instruments InstrumentAdminServicer, audit AuditAdminServicer, rateLimits RateLimitAdminServicer, curatedLists CuratedListAdminServicer, classifications ClassificationAdminServicer, users UserAdminServicer, invites InviteCodeAdminServicer, eod EODAdminServicer, aliases SymbolAliasAdminServicer, retention RetentionAdminServicer, disputes TradeDisputeAdminServicer, marketCache MarketCacheAdminServicer, marketQuota MarketQuotaAdminServicer) *AdminHandler {
+	return &AdminHandler{instruments: instruments, audit: audit, rateLimits: rateLimits, curatedLists: curatedLists, classifications: classifications, users: users, invites: invites, eod: eod, aliases: aliases, retention: retention, disputes: disputes, marketCache: marketCache, marketQuota: marketQuota}
+}
+
+func (h *AdminHandler) ListHalted(w http.ResponseWriter, r *http.Request) {
+	items, err := h.instruments.ListHalted(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, HaltedListResponse{Items: items})
+}
+
+func (h *AdminHandler) HaltSymbol(w http.ResponseWriter, r *http.Request) {
+	var req HaltRequest
+	// Body is optional — an empty POST halts without a reason.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	inst, err := h.instruments.Halt(r.Context(), mux.Vars(r)["symbol"], req.Reason)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "halt_symbol", inst.Symbol)
+	writeJSON(w, http.StatusOK, inst)
+}
+
+func (h *AdminHandler) ResumeSymbol(w http.ResponseWriter, r *http.Request) {
+	inst, err := h.instruments.Resume(r.Context(), mux.Vars(r)["symbol"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "resume_symbol", inst.Symbol)
+	writeJSON(w, http.StatusOK, inst)
+}
+
+// SetPrecision handles PUT /api/admin/instruments/{symbol}/precision: set
+// the tick size orders and fills use for the symbol, and the places its
+// prices are shown to.
+func (h *AdminHandler) SetPrecision(w http.ResponseWriter, r *http.Request) {
+	var req PrecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	inst, err := h.instruments.SetPrecision(r.Context(), mux.Vars(r)["symbol"], req.TickSize, req.PriceDecimals)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "set_precision", inst.Symbol)
+	writeJSON(w, http.StatusOK, inst)
+}
+
+// ListAnomalies handles GET /api/admin/audit/anomalies?limit=N.
+func (h *AdminHandler) ListAnomalies(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be a positive integer", err, "INVALID_REQUEST")
+			return
+		}
+		limit = n
+	}
+
+	items, err := h.audit.ListFlagged(r.Context(), limit)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, AuditEventListResponse{Items: items})
+}
+
+// RateLimitOverview handles GET /api/admin/ratelimits: the configured buckets
+// and this instance's limiter metrics.
+func (h *AdminHandler) RateLimitOverview(w http.ResponseWriter, r *http.Request) {
+	buckets := h.rateLimits.Buckets()
+	resp := RateLimitOverviewResponse{
+		Buckets: make([]RateLimitBucketResponse, 0, len(buckets)),
+		Metrics: h.rateLimits.Metrics(),
+	}
+	for _, b := range buckets {
+		resp.Buckets = append(resp.Buckets, RateLimitBucketResponse{
+			Name:          b.Name,
+			UserLimit:     b.UserLimit,
+			IPLimit:       b.IPLimit,
+			WindowSeconds: int64(b.Window / time.Second),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// TopRateLimitConsumers handles GET /api/admin/ratelimits/{bucket}/{scope}/top?limit=N.
+func (h *AdminHandler) TopRateLimitConsumers(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be a positive integer", err, "INVALID_REQUEST")
+			return
+		}
+		limit = n
+	}
+
+	vars := mux.Vars(r)
+	items, err := h.rateLimits.TopConsumers(r.Context(), vars["bucket"], vars["scope"], limit)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, RateLimitConsumerListResponse{Items: items})
+}
+
+// InspectRateLimit handles GET /api/admin/ratelimits/{bucket}/{scope}/{id}.
+func (h *AdminHandler) InspectRateLimit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	window, err := h.rateLimits.Inspect(r.Context(), vars["bucket"], vars["scope"], vars["id"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, window)
+}
+
+// ResetRateLimit handles DELETE /api/admin/ratelimits/{bucket}/{scope}/{id}.
+func (h *AdminHandler) ResetRateLimit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.rateLimits.Reset(r.Context(), vars["bucket"], vars["scope"], vars["id"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "reset_rate_limit", vars["bucket"]+":"+vars["scope"]+":"+vars["id"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SaveCuratedList handles PUT /api/admin/watchlists/{slug}: create the
+// curated list or replace its contents.
+func (h *AdminHandler) SaveCuratedList(w http.ResponseWriter, r *http.Request) {
+	var req CuratedListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	list, err := h.curatedLists.SaveCuratedList(r.Context(), data.CuratedList{
+		Slug:        mux.Vars(r)["slug"],
+		Name:        req.Name,
+		Description: req.Description,
+		Symbols:     req.Symbols,
+		IsDefault:   req.IsDefault,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "save_curated_list", list.Slug)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// DeleteCuratedList handles DELETE /api/admin/watchlists/{slug}.
+func (h *AdminHandler) DeleteCuratedList(w http.ResponseWriter, r *http.Request) {
+	slug := mux.Vars(r)["slug"]
+	if err := h.curatedLists.DeleteCuratedList(r.Context(), slug); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "delete_curated_list", slug)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SaveClassification handles PUT /api/admin/classifications/{symbol}: set
+// the symbol's sector, industry and index memberships. The override
+// survives dataset reloads.
+func (h *AdminHandler) SaveClassification(w http.ResponseWriter, r *http.Request) {
+	var req ClassificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	c, err := h.classifications.Override(r.Context(), data.Classification{
+		Symbol:   mux.Vars(r)["symbol"],
+		Sector:   req.Sector,
+		Industry: req.Industry,
+		Indices:  req.Indices,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "save_classification", c.Symbol)
+	writeJSON(w, http.StatusOK, c)
+}
+
+// ReloadClassifications handles POST /api/admin/classifications/reload:
+// re-apply the bundled dataset without a restart.
+func (h *AdminHandler) ReloadClassifications(w http.ResponseWriter, r *http.Request) {
+	n, err := h.classifications.LoadDataset(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "reload_classifications", "dataset")
+	writeJSON(w, http.StatusOK, ClassificationReloadResponse{Written: n})
+}
+
+// ImportUsers handles POST /api/admin/users/import: the body is a CSV of
+// email, starting_balance and league. New users are emailed an invite link
+// unless ?invite=false.
+func (h *AdminHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	invite := true
+	if raw := r.URL.Query().Get("invite"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			util.WriteSafeError(w, http.StatusBadRequest, "invite must be true or false", err, "INVALID_REQUEST")
+			return
+		}
+		invite = b
+	}
+
+	report, err := h.users.ImportCSV(r.Context(), http.MaxBytesReader(w, r.Body, maxUserImportBody), invite)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			util.WriteSafeError(w, http.StatusRequestEntityTooLarge, "Import file too large", err, "INVALID_REQUEST")
+			return
+		}
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "import_users", strconv.Itoa(report.Created)+" created")
+	writeJSON(w, http.StatusOK, report)
+}
+
+// ExportUsers handles GET /api/admin/users/export?league=&format=: every
+// non-guest user with holdings and trade aggregates, as JSON or (format=csv)
+// a CSV download.
+func (h *AdminHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		util.WriteSafeError(w, http.StatusBadRequest, "format must be json or csv", nil, "INVALID_REQUEST")
+		return
+	}
+
+	stats, err := h.users.Export(r.Context(), q.Get("league"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "export_users", q.Get("league"))
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+		if err := service.WriteUserStatsCSV(w, stats); err != nil {
+			slog.Error("user export: write failed", "err", err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, UserStatsListResponse{Items: stats})
+}
+
+// SearchUsers handles GET /api/admin/users?q=&limit=: non-guest users whose
+// ID is q or whose email or username contains it, newest first.
+func (h *AdminHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be a positive integer", err, "INVALID_REQUEST")
+			return
+		}
+		limit = n
+	}
+
+	items, err := h.users.Search(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, AdminUserListResponse{Items: items})
+}
+
+// GetUser handles GET /api/admin/users/{id}: the account with its balance,
+// holdings and trade aggregates, role and lock.
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	u, err := h.users.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// AdjustUserBalance handles POST /api/admin/users/{id}/balance: credit or,
+// with a negative amount, debit the user's cash.
+func (h *AdminHandler) AdjustUserBalance(w http.ResponseWriter, r *http.Request) {
+	var req BalanceAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	userID := mux.Vars(r)["id"]
+	adj, err := h.users.AdjustBalance(r.Context(), adminID, userID, req.Amount, req.Reason)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "adjust_balance", userID)
+	writeJSON(w, http.StatusOK, adj)
+}
+
+// LockUser handles POST /api/admin/users/{id}/lock: refuse every login and
+// session of the user until the given time and sign out its sessions.
+func (h *AdminHandler) LockUser(w http.ResponseWriter, r *http.Request) {
+	var req UserLockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	userID := mux.Vars(r)["id"]
+	if err := h.users.Lock(r.Context(), adminID, userID, req.Until, req.Reason); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "lock_user", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockUser handles DELETE /api/admin/users/{id}/lock.
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	h.userAction(w, r, "unlock_user", h.users.Unlock)
+}
+
+// ResendUserVerification handles POST /api/admin/users/{id}/verification:
+// email the user a new verification link.
+func (h *AdminHandler) ResendUserVerification(w http.ResponseWriter, r *http.Request) {
+	h.userAction(w, r, "resend_verification", h.users.ResendVerification)
+}
+
+// DeleteUser handles DELETE /api/admin/users/{id}: erase the account and
+// everything stored under it.
+func (h *AdminHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	h.userAction(w, r, "delete_user", h.users.Delete)
+}
+
+func (h *AdminHandler) userAction(w http.ResponseWriter, r *http.Request, action string,
+	act func(ctx context.Context, adminID, userID string) error) {
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	userID := mux.Vars(r)["id"]
+	if err := act(r.Context(), adminID, userID); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, action, userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CreateInviteCodes handles POST /api/admin/invite-codes: generate a batch
+// of codes sharing a use limit, expiry, note and cohort.
+func (h *AdminHandler) CreateInviteCodes(w http.ResponseWriter, r *http.Request) {
+	var req InviteCodeRequest
+	// Body is optional — an empty POST makes one single-use code that never expires.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	codes, err := h.invites.Generate(r.Context(), adminID, service.InviteCodeSpec{
+		Count:           req.Count,
+		MaxUses:         req.MaxUses,
+		ExpiresAt:       req.ExpiresAt,
+		Note:            req.Note,
+		StartingBalance: req.StartingBalance,
+		League:          req.League,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "create_invite_codes", strconv.Itoa(len(codes))+" codes")
+	writeJSON(w, http.StatusCreated, InviteCodeListResponse{Items: codes})
+}
+
+// ListInviteCodes handles GET /api/admin/invite-codes.
+func (h *AdminHandler) ListInviteCodes(w http.ResponseWriter, r *http.Request) {
+	codes, err := h.invites.List(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, InviteCodeListResponse{Items: codes})
+}
+
+// GetInviteCode handles GET /api/admin/invite-codes/{code}: the code and the
+// accounts it admitted.
+func (h *AdminHandler) GetInviteCode(w http.ResponseWriter, r *http.Request) {
+	detail, err := h.invites.Get(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// RevokeInviteCode handles DELETE /api/admin/invite-codes/{code}. The code
+// and its redemption history are kept.
+func (h *AdminHandler) RevokeInviteCode(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+	if err := h.invites.Revoke(r.Context(), code); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "revoke_invite_code", code)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func logAdminAction(r *http.Request, action, target string) {
+	userID, _ := auth.UserIDFromContext(r.Context())
+	slog.Info("admin action", "action", action, "target", target, "admin_user_id", userID)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// RerunEOD handles POST /api/admin/eod/rerun: re-run the end-of-day close for
+// a past session after bad provider data.
+func (h *AdminHandler) RerunEOD(w http.ResponseWriter, r *http.Request) {
+	var req EODRerunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+	date, err := time.Parse(service.DateLayoutISO, req.Date)
+	if err != nil {
+		util.WriteServiceError(w, &util.ValidationError{Field: "date", Message: "must be YYYY-MM-DD"})
+		return
+	}
+
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	result, err := h.eod.Rerun(r.Context(), adminID, date)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "rerun_eod", result.SessionDate)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// InvalidateMarketCache handles POST /api/admin/market-cache/invalidate:
+// flush cached quotes, history, charts, company profiles or news for some
+// symbols or all of them.
+func (h *AdminHandler) InvalidateMarketCache(w http.ResponseWriter, r *http.Request) {
+	var req MarketCacheInvalidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	result, err := h.marketCache.Invalidate(r.Context(), service.MarketCacheInvalidation{Symbols: req.Symbols, Kinds: req.Kinds, All: req.All})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	target := strings.Join(result.Symbols, ",")
+	if result.All {
+		target = "*"
+	}
+	logAdminAction(r, "invalidate_market_cache", target)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// MarketDataQuota handles GET /api/admin/market-data/quota: MarketStack
+// requests made today and this month against the plan's quota.
+func (h *AdminHandler) MarketDataQuota(w http.ResponseWriter, r *http.Request) {
+	usage, err := h.marketQuota.Usage(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, usage)
+}
+
+// ListSymbolRenames handles GET /api/admin/symbols/renames.
+func (h *AdminHandler) ListSymbolRenames(w http.ResponseWriter, r *http.Request) {
+	items, err := h.aliases.List(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SymbolRenameListResponse{Items: items})
+}
+
+// RenameSymbol handles PUT /api/admin/symbols/renames/{symbol}: record that
+// the symbol trades under a new ticker from a date. Holdings, watchlists and
+// pending orders are moved to the new symbol once the date has come.
+func (h *AdminHandler) RenameSymbol(w http.ResponseWriter, r *http.Request) {
+	var req SymbolRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+	date, err := time.Parse(service.DateLayoutISO, req.EffectiveDate)
+	if err != nil {
+		util.WriteServiceError(w, &util.ValidationError{Field: "effective_date", Message: "must be YYYY-MM-DD"})
+		return
+	}
+
+	a, err := h.aliases.Record(r.Context(), data.SymbolAlias{
+		OldSymbol:     mux.Vars(r)["symbol"],
+		NewSymbol:     req.NewSymbol,
+		EffectiveDate: date,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "rename_symbol", a.OldSymbol+"->"+a.NewSymbol)
+	writeJSON(w, http.StatusOK, a)
+}
+
+// RetentionReport handles GET /api/admin/retention: the inactive-account
+// policy and how many accounts are inactive, warned and anonymized.
+func (h *AdminHandler) RetentionReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.retention.Report(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// RunRetention handles POST /api/admin/retention/run: run a retention pass
+// now rather than waiting for the hourly one.
+func (h *AdminHandler) RunRetention(w http.ResponseWriter, r *http.Request) {
+	run, err := h.retention.RunOnce(r.Context())
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, "run_retention", "")
+	writeJSON(w, http.StatusOK, run)
+}
+
+// ListTradeDisputes handles GET /api/admin/disputes?status=OPEN&limit=N:
+// trade disputes oldest first, all statuses when status is omitted.
+func (h *AdminHandler) ListTradeDisputes(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be a positive integer", err, "INVALID_REQUEST")
+			return
+		}
+		limit = n
+	}
+
+	items, err := h.disputes.List(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, TradeDisputeListResponse{Items: items})
+}
+
+// ReverseTradeDispute handles POST /api/admin/disputes/{id}/reverse: undo
+// the disputed trade with a compensating adjustment trade.
+func (h *AdminHandler) ReverseTradeDispute(w http.ResponseWriter, r *http.Request) {
+	h.resolveTradeDispute(w, r, "reverse_trade", h.disputes.Reverse)
+}
+
+// RejectTradeDispute handles POST /api/admin/disputes/{id}/reject: close the
+// dispute and leave the trade as it is. A note for the user is required.
+func (h *AdminHandler) RejectTradeDispute(w http.ResponseWriter, r *http.Request) {
+	h.resolveTradeDispute(w, r, "reject_trade_dispute", h.disputes.Reject)
+}
+
+func (h *AdminHandler) resolveTradeDispute(w http.ResponseWriter, r *http.Request, action string,
+	resolve func(ctx context.Context, adminID, disputeID, note string) (*data.TradeDispute, error)) {
+	var req TradeDisputeResolution
+	// Body is optional for a reversal — the note is.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	adminID, _ := auth.UserIDFromContext(r.Context())
+	d, err := resolve(r.Context(), adminID, mux.Vars(r)["id"], req.Note)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	logAdminAction(r, action, d.TradeID)
+	writeJSON(w, http.StatusOK, d)
+}
@@ -0,0 +1,753 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+	"papertrader/internal/util"
+)
+
+// mockInstruments implements InstrumentAdminServicer for handler tests.
+type mockInstruments struct {
+	lastSymbol string
+	lastReason string
+	haltErr    error
+}
+
+func (m *mockInstruments) Halt(_ context.Context, symbol, reason string) (*data.Instrument, error) {
+	m.lastSymbol, m.lastReason = symbol, reason
+	if m.haltErr != nil {
+		return nil, m.haltErr
+	}
+	return &data.Instrument{Symbol: symbol, Halted: true, HaltReason: reason, HaltSource: data.HaltSourceAdmin}, nil
+}
+func (m *mockInstruments) Resume(_ context.Context, symbol string) (*data.Instrument, error) {
+	m.lastSymbol = symbol
+	return &data.Instrument{Symbol: symbol}, nil
+}
+func (m *mockInstruments) ListHalted(_ context.Context) ([]data.Instrument, error) {
+	return []data.Instrument{{Symbol: "GME", Halted: true}}, nil
+}
+func (m *mockInstruments) SetPrecision(_ context.Context, symbol string, tickSize decimal.Decimal, decimals int32) (*data.Instrument, error) {
+	m.lastSymbol = symbol
+	return &data.Instrument{Symbol: symbol, TickSize: tickSize, PriceDecimals: decimals}, nil
+}
+
+func serve(h *AdminHandler, method, target, body string) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc("/instruments/halted", h.ListHalted).Methods("GET")
+	r.HandleFunc("/instruments/{symbol}/halt", h.HaltSymbol).Methods("POST")
+	r.HandleFunc("/instruments/{symbol}/halt", h.ResumeSymbol).Methods("DELETE")
+	r.HandleFunc("/instruments/{symbol}/precision", h.SetPrecision).Methods("PUT")
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestHaltSymbol_PassesSymbolAndReason(t *testing.T) {
+	svc := &mockInstruments{}
+	w := serve(NewAdminHandler(svc, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil), http.MethodPost, "/instruments/GME/halt", `{"reason":"volatility"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", w.Code)
+	}
+	if svc.lastSymbol != "GME" || svc.lastReason != "volatility" {
+		t.Errorf("service got (%q, %q)", svc.lastSymbol, svc.lastReason)
+	}
+	var inst data.Instrument
+	if err := json.NewDecoder(w.Body).Decode(&inst); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !inst.Halted {
+		t.Error("expected halted instrument in response")
+	}
+}
+
+func TestHaltSymbol_EmptyBodyAllowed(t *testing.T) {
+	w := serve(NewAdminHandler(&mockInstruments{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil), http.MethodPost, "/instruments/GME/halt", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("status: got %d, want 200", w.Code)
+	}
+}
+
+func TestHaltSymbol_ValidationError(t *testing.T) {
+	svc := &mockInstruments{haltErr: &util.ValidationError{Field: "symbol", Message: "invalid"}}
+	w := serve(NewAdminHandler(svc, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil), http.MethodPost, "/instruments/bad!/halt", "{}")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status: got %d, want 400", w.Code)
+	}
+}
+
+func TestResumeSymbol(t *testing.T) {
+	svc := &mockInstruments{}
+	w := serve(NewAdminHandler(svc, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil), http.MethodDelete, "/instruments/GME/halt", "")
+	if w.Code != http.StatusOK {
+		t.Errorf("status: got %d, want 200", w.Code)
+	}
+	if svc.lastSymbol != "GME" {
+		t.Errorf("symbol: got %q", svc.lastSymbol)
+	}
+}
+
+func TestSetPrecision(t *testing.T) {
+	svc := &mockInstruments{}
+	w := serve(NewAdminHandler(svc, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil), http.MethodPut, "/instruments/SNDL/precision", `{"tick_size":0.0001,"price_decimals":4}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var inst data.Instrument
+	if err := json.NewDecoder(w.Body).Decode(&inst); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if svc.lastSymbol != "SNDL" || !inst.TickSize.Equal(decimal.RequireFromString("0.0001")) || inst.PriceDecimals != 4 {
+		t.Errorf("got %s %s/%d", svc.lastSymbol, inst.TickSize, inst.PriceDecimals)
+	}
+}
+
+// mockRateLimits implements RateLimitAdminServicer for handler tests.
+type mockRateLimits struct {
+	lastBucket, lastScope, lastID string
+	lastN                         int
+	err                           error
+}
+
+func (m *mockRateLimits) Buckets() []service.RateLimitBucket {
+	return []service.RateLimitBucket{{Name: "global", UserLimit: 100, IPLimit: 200, Window: time.Hour}}
+}
+func (m *mockRateLimits) Metrics() service.RateLimiterMetrics {
+	return service.RateLimiterMetrics{Backend: "memory", Buckets: map[string]service.RateLimitCounts{"global": {Allowed: 5, Denied: 1}}}
+}
+func (m *mockRateLimits) Inspect(_ context.Context, bucket, scope, id string) (*service.RateLimitWindow, error) {
+	m.lastBucket, m.lastScope, m.lastID = bucket, scope, id
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.RateLimitWindow{Bucket: bucket, Scope: scope, ID: id, Count: 7, Limit: 100, Remaining: 93}, nil
+}
+func (m *mockRateLimits) Reset(_ context.Context, bucket, scope, id string) error {
+	m.lastBucket, m.lastScope, m.lastID = bucket, scope, id
+	return m.err
+}
+func (m *mockRateLimits) TopConsumers(_ context.Context, bucket, scope string, n int) ([]service.RateLimitConsumer, error) {
+	m.lastBucket, m.lastScope, m.lastN = bucket, scope, n
+	return []service.RateLimitConsumer{{ID: "user-1", Count: 40}}, m.err
+}
+
+func serveRateLimits(svc *mockRateLimits, method, target string) *httptest.ResponseRecorder {
+	h := NewAdminHandler(nil, nil, svc, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/ratelimits", h.RateLimitOverview).Methods("GET")
+	r.HandleFunc("/ratelimits/{bucket}/{scope}/top", h.TopRateLimitConsumers).Methods("GET")
+	r.HandleFunc("/ratelimits/{bucket}/{scope}/{id}", h.InspectRateLimit).Methods("GET")
+	r.HandleFunc("/ratelimits/{bucket}/{scope}/{id}", h.ResetRateLimit).Methods("DELETE")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestRateLimitOverview(t *testing.T) {
+	w := serveRateLimits(&mockRateLimits{}, http.MethodGet, "/ratelimits")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", w.Code)
+	}
+	var resp RateLimitOverviewResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Buckets) != 1 || resp.Buckets[0].WindowSeconds != 3600 || resp.Metrics.Buckets["global"].Denied != 1 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestTopRateLimitConsumers(t *testing.T) {
+	svc := &mockRateLimits{}
+	w := serveRateLimits(svc, http.MethodGet, "/ratelimits/global/user/top?limit=5")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", w.Code)
+	}
+	if svc.lastBucket != "global" || svc.lastScope != "user" || svc.lastN != 5 {
+		t.Errorf("service got (%q, %q, %d)", svc.lastBucket, svc.lastScope, svc.lastN)
+	}
+
+	if w := serveRateLimits(svc, http.MethodGet, "/ratelimits/global/user/top?limit=-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("negative limit: got %d, want 400", w.Code)
+	}
+}
+
+func TestInspectRateLimit(t *testing.T) {
+	svc := &mockRateLimits{}
+	w := serveRateLimits(svc, http.MethodGet, "/ratelimits/global/ip/10.0.0.1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", w.Code)
+	}
+	if svc.lastScope != "ip" || svc.lastID != "10.0.0.1" {
+		t.Errorf("service got (%q, %q)", svc.lastScope, svc.lastID)
+	}
+
+	svc.err = &service.RateLimitBucketNotFoundError{}
+	if w := serveRateLimits(svc, http.MethodGet, "/ratelimits/nope/ip/10.0.0.1"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown bucket: got %d, want 404", w.Code)
+	}
+}
+
+func TestResetRateLimit(t *testing.T) {
+	svc := &mockRateLimits{}
+	w := serveRateLimits(svc, http.MethodDelete, "/ratelimits/global/user/user-1")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status: got %d, want 204", w.Code)
+	}
+	if svc.lastID != "user-1" {
+		t.Errorf("service got id %q", svc.lastID)
+	}
+
+	svc.err = &service.RateLimitWindowNotFoundError{}
+	if w := serveRateLimits(svc, http.MethodDelete, "/ratelimits/global/user/user-2"); w.Code != http.StatusNotFound {
+		t.Errorf("missing window: got %d, want 404", w.Code)
+	}
+}
+
+// mockCuratedLists implements CuratedListAdminServicer for handler tests.
+type mockCuratedLists struct {
+	saved     data.CuratedList
+	deleteErr error
+}
+
+func (m *mockCuratedLists) SaveCuratedList(_ context.Context, list data.CuratedList) (*data.CuratedList, error) {
+	m.saved = list
+	return &list, nil
+}
+func (m *mockCuratedLists) DeleteCuratedList(_ context.Context, _ string) error {
+	return m.deleteErr
+}
+
+func serveCuratedLists(svc *mockCuratedLists, method, target, body string) *httptest.ResponseRecorder {
+	h := NewAdminHandler(nil, nil, nil, svc, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/watchlists/{slug}", h.SaveCuratedList).Methods("PUT")
+	r.HandleFunc("/watchlists/{slug}", h.DeleteCuratedList).Methods("DELETE")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w
+}
+
+func TestSaveCuratedList_UsesSlugFromPath(t *testing.T) {
+	svc := &mockCuratedLists{}
+	w := serveCuratedLists(svc, http.MethodPut, "/watchlists/big-tech",
+		`{"name":"Big tech","symbols":["AAPL","MSFT"],"is_default":true}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if svc.saved.Slug != "big-tech" || svc.saved.Name != "Big tech" || len(svc.saved.Symbols) != 2 || !svc.saved.IsDefault {
+		t.Errorf("service got %+v", svc.saved)
+	}
+}
+
+func TestDeleteCuratedList_NotFound(t *testing.T) {
+	w := serveCuratedLists(&mockCuratedLists{deleteErr: &service.CuratedListNotFoundError{}}, http.MethodDelete, "/watchlists/nope", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status: got %d, want 404", w.Code)
+	}
+}
+
+// mockClassifications implements ClassificationAdminServicer for handler tests.
+type mockClassifications struct {
+	saved data.Classification
+}
+
+func (m *mockClassifications) Override(_ context.Context, c data.Classification) (*data.Classification, error) {
+	m.saved = c
+	return &c, nil
+}
+func (m *mockClassifications) LoadDataset(_ context.Context) (int, error) {
+	return 54, nil
+}
+
+func TestSaveClassification_UsesSymbolFromPath(t *testing.T) {
+	svc := &mockClassifications{}
+	h := NewAdminHandler(nil, nil, nil, nil, svc, nil, nil, nil, nil, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/classifications/{symbol}", h.SaveClassification).Methods("PUT")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/classifications/PLTR",
+		strings.NewReader(`{"sector":"Information Technology","industry":"Application Software","indices":["SPX","NDX"]}`)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if svc.saved.Symbol != "PLTR" || svc.saved.Sector != "Information Technology" || len(svc.saved.Indices) != 2 {
+		t.Errorf("service got %+v", svc.saved)
+	}
+}
+
+// mockUserAdmin implements UserAdminServicer for handler tests.
+type mockUserAdmin struct {
+	body   string
+	invite bool
+	league string
+
+	lastQuery  string
+	lastUserID string
+	lastAmount decimal.Decimal
+	lastReason string
+	actions    []string
+	err        error
+}
+
+func (m *mockUserAdmin) ImportCSV(_ context.Context, r io.Reader, invite bool) (*service.UserImportReport, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	m.body, m.invite = string(b), invite
+	return &service.UserImportReport{Created: 1, Results: []service.UserImportResult{}}, nil
+}
+func (m *mockUserAdmin) Export(_ context.Context, league string) ([]data.UserStats, error) {
+	m.league = league
+	return []data.UserStats{{ID: "u1", Email: "ada@example.com", League: league, Balance: decimal.NewFromInt(10000), HoldingsCost: decimal.Zero}}, nil
+}
+
+func (m *mockUserAdmin) Search(_ context.Context, query string, _ int) ([]data.AdminUser, error) {
+	m.lastQuery = query
+	return []data.AdminUser{{UserStats: data.UserStats{ID: "u1", Email: "ada@example.com"}, Role: data.RoleUser}}, nil
+}
+func (m *mockUserAdmin) Get(_ context.Context, userID string) (*data.AdminUser, error) {
+	m.lastUserID = userID
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &data.AdminUser{UserStats: data.UserStats{ID: userID}, Role: data.RoleUser}, nil
+}
+func (m *mockUserAdmin) AdjustBalance(_ context.Context, _, userID string, amount decimal.Decimal, reason string) (*service.BalanceAdjustment, error) {
+	m.lastUserID, m.lastAmount, m.lastReason = userID, amount, reason
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.BalanceAdjustment{UserID: userID, Amount: amount, Balance: decimal.NewFromInt(10500), Reason: reason}, nil
+}
+func (m *mockUserAdmin) Lock(_ context.Context, _, userID string, _ time.Time, reason string) error {
+	m.lastUserID, m.lastReason = userID, reason
+	m.actions = append(m.actions, "lock")
+	return m.err
+}
+func (m *mockUserAdmin) Unlock(_ context.Context, _, userID string) error {
+	m.lastUserID = userID
+	m.actions = append(m.actions, "unlock")
+	return m.err
+}
+func (m *mockUserAdmin) ResendVerification(_ context.Context, _, userID string) error {
+	m.lastUserID = userID
+	m.actions = append(m.actions, "verification")
+	return m.err
+}
+func (m *mockUserAdmin) Delete(_ context.Context, _, userID string) error {
+	m.lastUserID = userID
+	m.actions = append(m.actions, "delete")
+	return m.err
+}
+
+func TestImportUsers_InviteFlag(t *testing.T) {
+	svc := &mockUserAdmin{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, svc, nil, nil, nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	h.ImportUsers(w, httptest.NewRequest(http.MethodPost, "/users/import?invite=false", strings.NewReader("email\nada@example.com\n")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if svc.invite || svc.body != "email\nada@example.com\n" {
+		t.Errorf("service got invite=%t body=%q", svc.invite, svc.body)
+	}
+
+	w = httptest.NewRecorder()
+	h.ImportUsers(w, httptest.NewRequest(http.MethodPost, "/users/import?invite=maybe", strings.NewReader("")))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad invite: got %d, want 400", w.Code)
+	}
+}
+
+func TestExportUsers_CSV(t *testing.T) {
+	svc := &mockUserAdmin{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, svc, nil, nil, nil, nil, nil, nil, nil)
+
+	w := httptest.NewRecorder()
+	h.ExportUsers(w, httptest.NewRequest(http.MethodGet, "/users/export?league=fall&format=csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200", w.Code)
+	}
+	if svc.league != "fall" {
+		t.Errorf("league: got %q", svc.league)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("content type: got %q", ct)
+	}
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "u1,ada@example.com,,fall,") {
+		t.Errorf("body: got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ExportUsers(w, httptest.NewRequest(http.MethodGet, "/users/export?format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad format: got %d, want 400", w.Code)
+	}
+}
+
+func TestUserManagement(t *testing.T) {
+	svc := &mockUserAdmin{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, svc, nil, nil, nil, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/users", h.SearchUsers).Methods("GET")
+	r.HandleFunc("/users/{id}", h.GetUser).Methods("GET")
+	r.HandleFunc("/users/{id}", h.DeleteUser).Methods("DELETE")
+	r.HandleFunc("/users/{id}/balance", h.AdjustUserBalance).Methods("POST")
+	r.HandleFunc("/users/{id}/lock", h.LockUser).Methods("POST")
+	r.HandleFunc("/users/{id}/lock", h.UnlockUser).Methods("DELETE")
+	r.HandleFunc("/users/{id}/verification", h.ResendUserVerification).Methods("POST")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?q=ada", nil))
+	var list AdminUserListResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || w.Code != http.StatusOK || len(list.Items) != 1 {
+		t.Fatalf("search: got %d, %v, %+v", w.Code, err, list)
+	}
+	if svc.lastQuery != "ada" {
+		t.Errorf("search query: got %q", svc.lastQuery)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?limit=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad limit: got %d, want 400", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/u1/balance", strings.NewReader(`{"amount":"-250.50","reason":"refund a duplicate bonus"}`)))
+	var adj service.BalanceAdjustment
+	if err := json.NewDecoder(w.Body).Decode(&adj); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("adjust: got %d, %v", w.Code, err)
+	}
+	if svc.lastUserID != "u1" || !svc.lastAmount.Equal(decimal.RequireFromString("-250.50")) || svc.lastReason != "refund a duplicate bonus" {
+		t.Errorf("adjust: service got %q %s %q", svc.lastUserID, svc.lastAmount, svc.lastReason)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/users/u1/lock", strings.NewReader(`{"until":"2026-10-17T15:00:00Z","reason":"leaked password"}`)),
+		httptest.NewRequest(http.MethodDelete, "/users/u1/lock", nil),
+		httptest.NewRequest(http.MethodPost, "/users/u1/verification", nil),
+		httptest.NewRequest(http.MethodDelete, "/users/u1", nil),
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("%s %s: got %d, want 204", req.Method, req.URL.Path, w.Code)
+		}
+	}
+	if got := strings.Join(svc.actions, ","); got != "lock,unlock,verification,delete" {
+		t.Errorf("actions: got %s", got)
+	}
+
+	svc.err = &service.UserNotFoundError{}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/nobody", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: got %d, want 404", w.Code)
+	}
+}
+
+// mockInvites implements InviteCodeAdminServicer for handler tests.
+type mockInvites struct {
+	spec    service.InviteCodeSpec
+	revoked string
+}
+
+func (m *mockInvites) Generate(_ context.Context, createdBy string, spec service.InviteCodeSpec) ([]data.InviteCode, error) {
+	m.spec = spec
+	return []data.InviteCode{{Code: "ABCDE23456", MaxUses: spec.MaxUses, Status: data.InviteCodeActive}}, nil
+}
+func (m *mockInvites) List(_ context.Context) ([]data.InviteCode, error) {
+	return []data.InviteCode{}, nil
+}
+func (m *mockInvites) Get(_ context.Context, code string) (*service.InviteCodeDetail, error) {
+	return nil, &service.InviteCodeNotFoundError{}
+}
+func (m *mockInvites) Revoke(_ context.Context, code string) error {
+	m.revoked = code
+	return nil
+}
+
+func TestInviteCodes(t *testing.T) {
+	svc := &mockInvites{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, svc, nil, nil, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/invite-codes", h.CreateInviteCodes).Methods("POST")
+	r.HandleFunc("/invite-codes/{code}", h.GetInviteCode).Methods("GET")
+	r.HandleFunc("/invite-codes/{code}", h.RevokeInviteCode).Methods("DELETE")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/invite-codes",
+		strings.NewReader(`{"count":30,"max_uses":1,"expires_at":"2026-12-31T00:00:00Z","note":"Period 3"}`)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201 (%s)", w.Code, w.Body.String())
+	}
+	if svc.spec.Count != 30 || svc.spec.ExpiresAt == nil || svc.spec.Note != "Period 3" {
+		t.Errorf("spec: got %+v", svc.spec)
+	}
+	var created InviteCodeListResponse
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil || len(created.Items) != 1 {
+		t.Errorf("create body: %v %+v", err, created)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/invite-codes/NOPE", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("get unknown: got %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/invite-codes/ABCDE23456", nil))
+	if w.Code != http.StatusNoContent || svc.revoked != "ABCDE23456" {
+		t.Errorf("revoke: got %d, revoked %q", w.Code, svc.revoked)
+	}
+}
+
+type mockEOD struct {
+	adminID string
+	date    time.Time
+}
+
+func (m *mockEOD) Rerun(_ context.Context, adminID string, date time.Time) (*service.EODRerun, error) {
+	m.adminID, m.date = adminID, date
+	return &service.EODRerun{SessionDate: date.Format(time.DateOnly), Corrected: []string{"AAPL"}, FailedSteps: []string{}}, nil
+}
+
+func TestRerunEOD(t *testing.T) {
+	svc := &mockEOD{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, svc, nil, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/eod/rerun", h.RerunEOD).Methods("POST")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/eod/rerun", strings.NewReader(`{"date":"2026-03-06"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	if svc.date.Format(time.DateOnly) != "2026-03-06" {
+		t.Errorf("unexpected date %v", svc.date)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/eod/rerun", strings.NewReader(`{"date":"03/06/2026"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad date: got %d, want 400", w.Code)
+	}
+}
+
+// mockAliases implements SymbolAliasAdminServicer for handler tests.
+type mockAliases struct {
+	saved data.SymbolAlias
+}
+
+func (m *mockAliases) List(context.Context) ([]data.SymbolAlias, error) { return nil, nil }
+func (m *mockAliases) Record(_ context.Context, a data.SymbolAlias) (*data.SymbolAlias, error) {
+	m.saved = a
+	return &a, nil
+}
+
+func TestRenameSymbol(t *testing.T) {
+	svc := &mockAliases{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil, svc, nil, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/symbols/renames/{symbol}", h.RenameSymbol).Methods("PUT")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/symbols/renames/FB",
+		strings.NewReader(`{"new_symbol":"META","effective_date":"2022-06-09"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	want := time.Date(2022, time.June, 9, 0, 0, 0, 0, time.UTC)
+	if svc.saved.OldSymbol != "FB" || svc.saved.NewSymbol != "META" || !svc.saved.EffectiveDate.Equal(want) {
+		t.Errorf("service got %+v", svc.saved)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/symbols/renames/FB",
+		strings.NewReader(`{"new_symbol":"META","effective_date":"06/09/2022"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad date: got %d, want 400", w.Code)
+	}
+}
+
+// mockRetention implements RetentionAdminServicer for handler tests.
+type mockRetention struct {
+	runs int
+	err  error
+}
+
+func (m *mockRetention) Report(context.Context) (*service.RetentionReport, error) {
+	return &service.RetentionReport{Enabled: m.err == nil, InactiveDays: 365, GraceDays: 30,
+		RetentionCounts: &data.RetentionCounts{Inactive: 4, Warned: 2, Anonymized: 9}}, nil
+}
+func (m *mockRetention) RunOnce(context.Context) (*service.RetentionRun, error) {
+	m.runs++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &service.RetentionRun{Warned: 3, Anonymized: 1}, nil
+}
+
+func TestRetention(t *testing.T) {
+	svc := &mockRetention{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, svc, nil, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/retention", h.RetentionReport).Methods("GET")
+	r.HandleFunc("/retention/run", h.RunRetention).Methods("POST")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/retention", nil))
+	var report map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("report: got %d, %v", w.Code, err)
+	}
+	if report["inactive"] != float64(4) || report["inactive_days"] != float64(365) {
+		t.Errorf("report body: %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/retention/run", nil))
+	if w.Code != http.StatusOK || svc.runs != 1 {
+		t.Errorf("run: got %d after %d runs", w.Code, svc.runs)
+	}
+
+	svc.err = &service.RetentionDisabledError{}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/retention/run", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("disabled: got %d, want 409", w.Code)
+	}
+}
+
+type mockTradeDisputes struct {
+	lastStatus, lastID, lastNote string
+	err                          error
+}
+
+func (m *mockTradeDisputes) List(_ context.Context, status string, _ int) ([]data.TradeDispute, error) {
+	m.lastStatus = status
+	return []data.TradeDispute{{ID: "d-1", TradeID: "t-1", Status: data.TradeDisputeOpen}}, nil
+}
+func (m *mockTradeDisputes) Reverse(_ context.Context, _, id, note string) (*data.TradeDispute, error) {
+	m.lastID, m.lastNote = id, note
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &data.TradeDispute{ID: id, TradeID: "t-1", Status: data.TradeDisputeReversed, ReversalTradeID: "t-2"}, nil
+}
+func (m *mockTradeDisputes) Reject(_ context.Context, _, id, note string) (*data.TradeDispute, error) {
+	m.lastID, m.lastNote = id, note
+	return &data.TradeDispute{ID: id, TradeID: "t-1", Status: data.TradeDisputeRejected, ResolutionNote: note}, nil
+}
+
+func TestTradeDisputes(t *testing.T) {
+	svc := &mockTradeDisputes{}
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, svc, nil, nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/disputes", h.ListTradeDisputes).Methods("GET")
+	r.HandleFunc("/disputes/{id}/reverse", h.ReverseTradeDispute).Methods("POST")
+	r.HandleFunc("/disputes/{id}/reject", h.RejectTradeDispute).Methods("POST")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/disputes?status=OPEN", nil))
+	if w.Code != http.StatusOK || svc.lastStatus != data.TradeDisputeOpen {
+		t.Fatalf("list: got %d with status %q", w.Code, svc.lastStatus)
+	}
+
+	// A reversal needs no body.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/disputes/d-1/reverse", nil))
+	var d data.TradeDispute
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("reverse: got %d, %v", w.Code, err)
+	}
+	if svc.lastID != "d-1" || d.ReversalTradeID != "t-2" {
+		t.Errorf("reverse: id %q, body %+v", svc.lastID, d)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/disputes/d-1/reject", strings.NewReader(`{"note":"the quote was live"}`)))
+	if w.Code != http.StatusOK || svc.lastNote != "the quote was live" {
+		t.Errorf("reject: got %d with note %q", w.Code, svc.lastNote)
+	}
+
+	svc.err = &service.TradeNotReversibleError{Reason: "the account no longer holds the shares bought"}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/disputes/d-1/reverse", nil))
+	if w.Code != http.StatusConflict {
+		t.Errorf("not reversible: got %d, want 409", w.Code)
+	}
+}
+
+func TestInvalidateMarketCache(t *testing.T) {
+	// Without Redis nothing is cached, but the request is still validated.
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, service.NewMarketCacheAdminService(nil), nil)
+	r := mux.NewRouter()
+	r.HandleFunc("/market-cache/invalidate", h.InvalidateMarketCache).Methods("POST")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/market-cache/invalidate", strings.NewReader(`{"symbols":["aapl","MSFT"],"kinds":["quotes"]}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var got service.MarketCacheInvalidated
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got.Symbols, ",") != "AAPL,MSFT" || strings.Join(got.Kinds, ",") != "quotes" || got.Deleted != 0 {
+		t.Errorf("unexpected result %+v", got)
+	}
+
+	for _, body := range []string{`{"kinds":["quotes"]}`, `{"symbols":["AAPL"],"kinds":["prices"]}`, `{"symbols":["AAPL"],"all":true}`} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/market-cache/invalidate", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestMarketDataQuota(t *testing.T) {
+	counter := service.NewMemoryQuotaCounter()
+	counter.Incr(context.Background(), service.ProviderMarketStack, time.Now())
+	h := NewAdminHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		service.NewQuotaTracker(service.ProviderMarketStack, counter, 10000, 90))
+	r := mux.NewRouter()
+	r.HandleFunc("/market-data/quota", h.MarketDataQuota).Methods("GET")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/market-data/quota", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var got service.QuotaUsage
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Provider != "marketstack" || got.MonthRequests != 1 || got.Remaining == nil || *got.Remaining != 9999 || got.Shedding {
+		t.Errorf("unexpected usage %+v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"net/http"
+
+	"papertrader/internal/api/auth"
+	"papertrader/internal/config"
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// Mount attaches the admin routes to r (a subrouter, e.g. /api/admin). Every
+// route requires a valid session with the admin role. Mutations also need
+// sudo mode (POST /api/account/sudo) and a session that is not pending
+// re-authentication after an account anomaly.
+func Mount(r *mux.Router, h *AdminHandler, jwtService *service.JWTService, reauth auth.ReauthGuard, cfg *config.Config) {
+	r.StrictSlash(false)
+	r.Use(auth.JWTMiddleware(jwtService, cfg))
+	r.Use(auth.RequireRole(data.RoleAdmin))
+
+	r.HandleFunc("/instruments/halted", h.ListHalted).Methods("GET")
+	reauthMiddleware := auth.RequireReauthCleared(reauth)
+	requireSudo := auth.RequireSudo(jwtService)
+	sudo := func(next http.Handler) http.Handler {
+		return reauthMiddleware(requireSudo(next))
+	}
+	r.Handle("/instruments/{symbol}/halt", sudo(http.HandlerFunc(h.HaltSymbol))).Methods("POST")
+	r.Handle("/instruments/{symbol}/halt", sudo(http.HandlerFunc(h.ResumeSymbol))).Methods("DELETE")
+	r.Handle("/instruments/{symbol}/precision", sudo(http.HandlerFunc(h.SetPrecision))).Methods("PUT")
+	r.HandleFunc("/audit/anomalies", h.ListAnomalies).Methods("GET")
+
+	r.HandleFunc("/ratelimits", h.RateLimitOverview).Methods("GET")
+	// Registered before {id} so "top" isn't taken for a user or IP.
+	r.HandleFunc("/ratelimits/{bucket}/{scope}/top", h.TopRateLimitConsumers).Methods("GET")
+	r.HandleFunc("/ratelimits/{bucket}/{scope}/{id}", h.InspectRateLimit).Methods("GET")
+	r.Handle("/ratelimits/{bucket}/{scope}/{id}", sudo(http.HandlerFunc(h.ResetRateLimit))).Methods("DELETE")
+
+	r.Handle("/watchlists/{slug}", sudo(http.HandlerFunc(h.SaveCuratedList))).Methods("PUT")
+	r.Handle("/watchlists/{slug}", sudo(http.HandlerFunc(h.DeleteCuratedList))).Methods("DELETE")
+
+	r.Handle("/classifications/reload", sudo(http.HandlerFunc(h.ReloadClassifications))).Methods("POST")
+	r.Handle("/classifications/{symbol}", sudo(http.HandlerFunc(h.SaveClassification))).Methods("PUT")
+
+	r.HandleFunc("/symbols/renames", h.ListSymbolRenames).Methods("GET")
+	r.Handle("/symbols/renames/{symbol}", sudo(http.HandlerFunc(h.RenameSymbol))).Methods("PUT")
+
+	r.Handle("/users/import", sudo(http.HandlerFunc(h.ImportUsers))).Methods("POST")
+	r.HandleFunc("/users/export", h.ExportUsers).Methods("GET")
+	// Registered after import and export so neither is taken for a user ID.
+	r.HandleFunc("/users", h.SearchUsers).Methods("GET")
+	r.HandleFunc("/users/{id}", h.GetUser).Methods("GET")
+	r.Handle("/users/{id}", sudo(http.HandlerFunc(h.DeleteUser))).Methods("DELETE")
+	r.Handle("/users/{id}/balance", sudo(http.HandlerFunc(h.AdjustUserBalance))).Methods("POST")
+	r.Handle("/users/{id}/lock", sudo(http.HandlerFunc(h.LockUser))).Methods("POST")
+	r.Handle("/users/{id}/lock", sudo(http.HandlerFunc(h.UnlockUser))).Methods("DELETE")
+	r.Handle("/users/{id}/verification", sudo(http.HandlerFunc(h.ResendUserVerification))).Methods("POST")
+
+	r.Handle("/invite-codes", sudo(http.HandlerFunc(h.CreateInviteCodes))).Methods("POST")
+	r.HandleFunc("/invite-codes", h.ListInviteCodes).Methods("GET")
+	r.HandleFunc("/invite-codes/{code}", h.GetInviteCode).Methods("GET")
+	r.Handle("/invite-codes/{code}", sudo(http.HandlerFunc(h.RevokeInviteCode))).Methods("DELETE")
+
+	r.Handle("/eod/rerun", sudo(http.HandlerFunc(h.RerunEOD))).Methods("POST")
+	r.Handle("/market-cache/invalidate", sudo(http.HandlerFunc(h.InvalidateMarketCache))).Methods("POST")
+	r.HandleFunc("/market-data/quota", h.MarketDataQuota).Methods("GET")
+
+	r.HandleFunc("/disputes", h.ListTradeDisputes).Methods("GET")
+	r.Handle("/disputes/{id}/reverse", sudo(http.HandlerFunc(h.ReverseTradeDispute))).Methods("POST")
+	r.Handle("/disputes/{id}/reject", sudo(http.HandlerFunc(h.RejectTradeDispute))).Methods("POST")
+
+	r.HandleFunc("/retention", h.RetentionReport).Methods("GET")
+	r.Handle("/retention/run", sudo(http.HandlerFunc(h.RunRetention))).Methods("POST")
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"papertrader/internal/config"
+	"papertrader/internal/data"
 	"papertrader/internal/service"
 )
 
@@ -21,6 +22,9 @@ type ctxKey int
 const (
 	userIDKey ctxKey = iota
 	emailKey
+	authTimeKey
+	sessionIDKey
+	roleKey
 )
 
 // UserIDFromContext returns the authenticated user ID populated by JWTMiddleware,
@@ -37,6 +41,29 @@ func EmailFromContext(ctx context.Context) (string, bool) {
 	return v, ok && v != ""
 }
 
+// AuthTimeFromContext returns when the caller last presented credentials, as
+// carried by the token's auth_time claim.
+func AuthTimeFromContext(ctx context.Context) (time.Time, bool) {
+	v, ok := ctx.Value(authTimeKey).(time.Time)
+	return v, ok && !v.IsZero()
+}
+
+// SessionIDFromContext returns the session the caller's token belongs to (its
+// jti). Tokens issued before sessions had an ID carry none.
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(sessionIDKey).(string)
+	return v, ok && v != ""
+}
+
+// RoleFromContext returns the caller's role as carried by the token,
+// data.RoleUser when the token predates roles.
+func RoleFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(roleKey).(string); ok && v != "" {
+		return v
+	}
+	return data.RoleUser
+}
+
 // WithUserID returns a derived context carrying userID. Intended for tests that
 // need to exercise handlers that read identity from context without spinning up
 // the full JWT middleware chain.
@@ -69,11 +96,24 @@ func JWTMiddleware(jwtService *service.JWTService, cfg *config.Config) func(http
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
 				return
 			}
+			// Checked before the refresh below so a signed-out session is
+			// never given a new token.
+			if jwtService.Revoked(r.Context(), claims) {
+				http.Error(w, "Session revoked", http.StatusUnauthorized)
+				return
+			}
+			if jwtService.AdminLocked(r.Context(), claims) {
+				http.Error(w, "Account locked", http.StatusUnauthorized)
+				return
+			}
 
 			// Sliding refresh: re-issue a fresh 24h cookie once the current token
 			// is more than half-way through its lifetime, keeping active sessions alive.
+			// The refreshed token keeps the original auth_time so a long-lived
+			// session never looks freshly authenticated, and carries the
+			// account's current role, which this request uses as well.
 			if claims.IssuedAt != nil && time.Since(claims.IssuedAt.Time) > tokenRefreshThreshold {
-				if newToken, genErr := jwtService.GenerateToken(claims.UserID, claims.Email); genErr == nil {
+				if newToken, genErr := jwtService.RefreshToken(r.Context(), claims); genErr == nil {
 					secure := r.Header.Get("X-Forwarded-Proto") == "https" || cfg.IsProduction()
 					http.SetCookie(w, &http.Cookie{
 						Name:     "token",
@@ -92,6 +132,9 @@ func JWTMiddleware(jwtService *service.JWTService, cfg *config.Config) func(http
 
 			ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
 			ctx = context.WithValue(ctx, emailKey, claims.Email)
+			ctx = context.WithValue(ctx, authTimeKey, claims.AuthenticatedAt())
+			ctx = context.WithValue(ctx, sessionIDKey, claims.ID)
+			ctx = context.WithValue(ctx, roleKey, claims.Role)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"papertrader/internal/config"
+	"papertrader/internal/data"
 	"papertrader/internal/service"
 )
 
@@ -54,7 +56,7 @@ func TestJWTMiddleware_RejectsInvalidSignature(t *testing.T) {
 	signer := service.NewJWTService("real-secret-32-chars-long-xxxxxx")
 	verifier := service.NewJWTService("DIFFERENT-secret-32chars-xxxxxxxx")
 
-	token, err := signer.GenerateToken("user-1", "u@example.com")
+	token, err := signer.GenerateToken("user-1", "u@example.com", data.RoleUser)
 	if err != nil {
 		t.Fatalf("GenerateToken: %v", err)
 	}
@@ -105,7 +107,7 @@ func TestJWTMiddleware_RejectsExpiredToken(t *testing.T) {
 
 func TestJWTMiddleware_AcceptsValidCookieTokenAndPopulatesContext(t *testing.T) {
 	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
-	token, err := jwtSvc.GenerateToken("user-42", "alice@example.com")
+	token, err := jwtSvc.GenerateToken("user-42", "alice@example.com", data.RoleUser)
 	if err != nil {
 		t.Fatalf("GenerateToken: %v", err)
 	}
@@ -134,7 +136,7 @@ func TestJWTMiddleware_AcceptsValidCookieTokenAndPopulatesContext(t *testing.T)
 
 func TestJWTMiddleware_AcceptsBearerHeader(t *testing.T) {
 	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
-	token, _ := jwtSvc.GenerateToken("user-bearer", "b@example.com")
+	token, _ := jwtSvc.GenerateToken("user-bearer", "b@example.com", data.RoleUser)
 
 	stub := &stubHandler{}
 	h := JWTMiddleware(jwtSvc, testCfg())(stub)
@@ -158,8 +160,8 @@ func TestJWTMiddleware_AcceptsBearerHeader(t *testing.T) {
 // captured a Bearer token override a valid cookie session.
 func TestJWTMiddleware_CookieTakesPrecedence(t *testing.T) {
 	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
-	cookieTok, _ := jwtSvc.GenerateToken("from-cookie", "c@example.com")
-	headerTok, _ := jwtSvc.GenerateToken("from-header", "h@example.com")
+	cookieTok, _ := jwtSvc.GenerateToken("from-cookie", "c@example.com", data.RoleUser)
+	headerTok, _ := jwtSvc.GenerateToken("from-header", "h@example.com", data.RoleUser)
 
 	stub := &stubHandler{}
 	h := JWTMiddleware(jwtSvc, testCfg())(stub)
@@ -228,7 +230,7 @@ func TestJWTMiddleware_SlidingRefresh(t *testing.T) {
 // every request and create extra response weight.
 func TestJWTMiddleware_NoRefreshForFreshToken(t *testing.T) {
 	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
-	token, _ := jwtSvc.GenerateToken("user-1", "u@example.com")
+	token, _ := jwtSvc.GenerateToken("user-1", "u@example.com", data.RoleUser)
 
 	stub := &stubHandler{}
 	h := JWTMiddleware(jwtSvc, testCfg())(stub)
@@ -244,3 +246,83 @@ func TestJWTMiddleware_NoRefreshForFreshToken(t *testing.T) {
 		}
 	}
 }
+
+// TestJWTMiddleware_RejectsRevokedSession covers logout: a session signed
+// out is refused, and an old copy of its token isn't refreshed back to life.
+func TestJWTMiddleware_RejectsRevokedSession(t *testing.T) {
+	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	jwtSvc.SetRevocations(service.NewMemorySessionRevocations())
+
+	// Issued 13h ago in session-1, so it would otherwise be refreshed.
+	old := jwt.NewWithClaims(jwt.SigningMethodHS256, &service.Claims{
+		UserID: "user-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "session-1",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(11 * time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now().Add(-13 * time.Hour)),
+		},
+	})
+	tokenStr, err := old.SignedString([]byte("testsecretkey-32-chars-long-xxxxx"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	if err := jwtSvc.RevokeSession(context.Background(), "session-1"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+
+	stub := &stubHandler{}
+	h := JWTMiddleware(jwtSvc, testCfg())(stub)
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: tokenStr})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status: got %d, want 401", w.Code)
+	}
+	if stub.called {
+		t.Error("downstream handler should not have been called")
+	}
+	if got := w.Result().Cookies(); len(got) != 0 {
+		t.Errorf("revoked session was refreshed: %v", got)
+	}
+}
+
+// adminLocks locks the listed users until the given time.
+type adminLocks map[string]time.Time
+
+func (l adminLocks) GetAdminLockedUntil(_ context.Context, userID string) (*time.Time, error) {
+	if until, ok := l[userID]; ok {
+		return &until, nil
+	}
+	return nil, nil
+}
+
+// TestJWTMiddleware_RejectsAdminLockedAccount covers an admin lock: the
+// account's unexpired token is refused until the lock ends.
+func TestJWTMiddleware_RejectsAdminLockedAccount(t *testing.T) {
+	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	locks := adminLocks{"user-1": time.Now().Add(time.Hour)}
+	jwtSvc.SetAdminLocks(locks)
+	tokenStr, err := jwtSvc.GenerateToken("user-1", "ada@example.com", data.RoleUser)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	serve := func() (int, bool) {
+		stub := &stubHandler{}
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: tokenStr})
+		w := httptest.NewRecorder()
+		JWTMiddleware(jwtSvc, testCfg())(stub).ServeHTTP(w, req)
+		return w.Code, stub.called
+	}
+	if code, called := serve(); code != http.StatusUnauthorized || called {
+		t.Errorf("while locked: got %d (handler called: %v), want 401", code, called)
+	}
+
+	locks["user-1"] = time.Now().Add(-time.Minute)
+	if code, called := serve(); code != http.StatusOK || !called {
+		t.Errorf("after the lock: got %d (handler called: %v), want 200", code, called)
+	}
+}
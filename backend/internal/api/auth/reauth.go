@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"papertrader/internal/service"
+	"papertrader/internal/util"
+)
+
+// ReauthGuard reports the instant before which a user's sessions must log in
+// again. Satisfied by *service.AnomalyService.
+type ReauthGuard interface {
+	ReauthRequiredAfter(ctx context.Context, userID string) (*time.Time, error)
+}
+
+// RequireReauthCleared rejects sessions authenticated before the user's
+// pending re-authentication cutoff (set when the anomaly detector flags the
+// account) with 401 REAUTH_REQUIRED. Logging in again clears it. Must be
+// mounted after JWTMiddleware. Fails closed if the cutoff cannot be loaded.
+func RequireReauthCleared(guard ReauthGuard) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			cutoff, err := guard.ReauthRequiredAfter(r.Context(), userID)
+			if err != nil {
+				util.WriteSafeError(w, http.StatusInternalServerError, "Could not verify session", err, "INTERNAL_ERROR")
+				return
+			}
+			if cutoff != nil {
+				authTime, _ := AuthTimeFromContext(r.Context())
+				// auth_time has second precision; compare at that granularity so
+				// a login in the same second as the flag still counts as fresh.
+				if authTime.Before(cutoff.Truncate(time.Second)) {
+					slog.Info("stale session rejected; re-authentication required",
+						"user_id", userID, "path", r.URL.Path, "component", "auth")
+					util.WriteServiceError(w, &service.ReauthRequiredError{})
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeReauthGuard struct {
+	cutoff *time.Time
+	err    error
+}
+
+func (f *fakeReauthGuard) ReauthRequiredAfter(context.Context, string) (*time.Time, error) {
+	return f.cutoff, f.err
+}
+
+func TestRequireReauthCleared(t *testing.T) {
+	authTime := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	before := authTime.Add(-time.Hour)
+	after := authTime.Add(time.Hour)
+	sameSecond := authTime.Add(400 * time.Millisecond)
+
+	cases := []struct {
+		name  string
+		guard *fakeReauthGuard
+		want  int
+	}{
+		{"no cutoff", &fakeReauthGuard{}, http.StatusOK},
+		{"logged in after cutoff", &fakeReauthGuard{cutoff: &before}, http.StatusOK},
+		{"logged in same second as cutoff", &fakeReauthGuard{cutoff: &sameSecond}, http.StatusOK},
+		{"session predates cutoff", &fakeReauthGuard{cutoff: &after}, http.StatusUnauthorized},
+		{"guard error fails closed", &fakeReauthGuard{err: errors.New("db down")}, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &stubHandler{}
+			h := RequireReauthCleared(tc.guard)(stub)
+
+			ctx := WithUserID(context.Background(), "user-1")
+			ctx = context.WithValue(ctx, authTimeKey, authTime)
+			req := httptest.NewRequest(http.MethodPost, "/admin/x", nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Errorf("status: got %d, want %d", w.Code, tc.want)
+			}
+			if stub.called != (tc.want == http.StatusOK) {
+				t.Errorf("downstream called = %v", stub.called)
+			}
+		})
+	}
+}
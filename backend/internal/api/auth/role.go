@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// RequireRole rejects requests whose session token doesn't carry one of
+// roles. Must be mounted after JWTMiddleware, which populates the role it
+// checks. The role is the one the account had at login, so a promotion or
+// demotion applies from the next one.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if role := RoleFromContext(r.Context()); !slices.Contains(roles, role) {
+				userID, _ := UserIDFromContext(r.Context())
+				slog.Warn("access denied by role", "user_id", userID, "role", role, "path", r.URL.Path)
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
+
+func TestRequireRole(t *testing.T) {
+	jwtSvc := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	// A token from before roles existed carries none.
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &service.Claims{UserID: "user-1"}).
+		SignedString([]byte("testsecretkey-32-chars-long-xxxxx"))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		token func() (string, error)
+		want  int
+	}{
+		{"admin", func() (string, error) { return jwtSvc.GenerateToken("user-1", "admin@example.com", data.RoleAdmin) }, http.StatusOK},
+		{"regular user", func() (string, error) { return jwtSvc.GenerateToken("user-1", "user@example.com", data.RoleUser) }, http.StatusForbidden},
+		{"token without a role", func() (string, error) { return legacy, nil }, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token, err := tc.token()
+			if err != nil {
+				t.Fatalf("token: %v", err)
+			}
+			stub := &stubHandler{}
+			h := JWTMiddleware(jwtSvc, testCfg())(RequireRole(data.RoleAdmin)(stub))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Errorf("status: got %d, want %d", w.Code, tc.want)
+			}
+			if stub.called != (tc.want == http.StatusOK) {
+				t.Errorf("downstream called = %v", stub.called)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+
+	"papertrader/internal/service"
+	"papertrader/internal/util"
+)
+
+// SudoCookieName holds the elevation token issued by POST /api/account/sudo.
+// Non-browser clients may send the token in SudoHeaderName instead.
+const (
+	SudoCookieName = "sudo_token"
+	SudoHeaderName = "X-Sudo-Token"
+)
+
+// RequireSudo rejects requests without a valid, unexpired elevation token
+// for the authenticated user with 403 SUDO_REQUIRED. Must be mounted after
+// JWTMiddleware.
+func RequireSudo(jwtService *service.JWTService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			tokenString := r.Header.Get(SudoHeaderName)
+			if cookie, err := r.Cookie(SudoCookieName); err == nil && cookie.Value != "" {
+				tokenString = cookie.Value
+			}
+			if tokenString == "" {
+				util.WriteServiceError(w, &service.SudoRequiredError{})
+				return
+			}
+
+			claims, err := jwtService.ValidateSudoToken(tokenString)
+			if err != nil || claims.UserID != userID {
+				slog.Info("sudo token rejected", "user_id", userID, "path", r.URL.Path, "component", "auth")
+				util.WriteServiceError(w, &service.SudoRequiredError{})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
+
+func TestRequireSudo(t *testing.T) {
+	jwt := service.NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	own, _, _ := jwt.GenerateSudoToken("user-1", time.Minute)
+	other, _, _ := jwt.GenerateSudoToken("user-2", time.Minute)
+	session, _ := jwt.GenerateToken("user-1", "u@example.com", data.RoleUser)
+
+	cases := []struct {
+		name   string
+		cookie string
+		header string
+		want   int
+	}{
+		{"no token", "", "", http.StatusForbidden},
+		{"own sudo cookie", own, "", http.StatusOK},
+		{"own sudo header", "", own, http.StatusOK},
+		{"another user's token", other, "", http.StatusForbidden},
+		{"session token is not sudo", session, "", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &stubHandler{}
+			h := RequireSudo(jwt)(stub)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/x", nil).
+				WithContext(WithUserID(context.Background(), "user-1"))
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: SudoCookieName, Value: tc.cookie})
+			}
+			if tc.header != "" {
+				req.Header.Set(SudoHeaderName, tc.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Errorf("status: got %d, want %d", w.Code, tc.want)
+			}
+			if stub.called != (tc.want == http.StatusOK) {
+				t.Errorf("downstream called = %v", stub.called)
+			}
+		})
+	}
+}
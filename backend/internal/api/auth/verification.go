@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"papertrader/internal/util"
+)
+
+// VerificationGuard decides whether a user may trade before verifying their
+// email. Satisfied by *service.VerificationService.
+type VerificationGuard interface {
+	CheckVerified(ctx context.Context, userID string) error
+}
+
+// RequireVerifiedEmail rejects requests from users the guard holds back
+// until they verify their email, with 403 VERIFICATION_REQUIRED. It only
+// refuses early, before a quote is fetched or an order stored; the guard
+// also runs as a pre-trade check on every trade. Must be mounted after
+// JWTMiddleware. Fails closed if the user cannot be loaded.
+func RequireVerifiedEmail(guard VerificationGuard) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := UserIDFromContext(r.Context())
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+			if err := guard.CheckVerified(r.Context(), userID); err != nil {
+				util.WriteServiceError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"papertrader/internal/service"
+)
+
+type fakeVerificationGuard struct {
+	err error
+}
+
+func (f *fakeVerificationGuard) CheckVerified(context.Context, string) error {
+	return f.err
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	cases := []struct {
+		name  string
+		guard *fakeVerificationGuard
+		want  int
+	}{
+		{"allowed", &fakeVerificationGuard{}, http.StatusOK},
+		{"verification required", &fakeVerificationGuard{err: &service.VerificationRequiredError{}}, http.StatusForbidden},
+		{"guard error fails closed", &fakeVerificationGuard{err: errors.New("db down")}, http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stub := &stubHandler{}
+			h := RequireVerifiedEmail(tc.guard)(stub)
+
+			req := httptest.NewRequest(http.MethodPost, "/buy", nil).WithContext(WithUserID(context.Background(), "user-1"))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Errorf("status: got %d, want %d", w.Code, tc.want)
+			}
+			if stub.called != (tc.want == http.StatusOK) {
+				t.Errorf("downstream called = %v", stub.called)
+			}
+		})
+	}
+}
@@ -1,7 +1,12 @@
 package investments
 
 import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
 	"papertrader/internal/data"
+	"papertrader/internal/service"
 )
 
 // BuyStockRequest / SellStockRequest are decoded from the JSON body of the
@@ -9,23 +14,153 @@ import (
 // backwards compatibility but ignored; the authoritative user is whatever the
 // JWT middleware writes into X-User-ID.
 type BuyStockRequest struct {
-	UserID   string `json:"userId"`
-	Symbol   string `json:"symbol"`
-	Quantity int    `json:"quantity"`
+	UserID   string          `json:"userId"`
+	Symbol   string          `json:"symbol"`
+	Quantity decimal.Decimal `json:"quantity"`
 }
 
 type SellStockRequest struct {
-	UserID   string `json:"userId"`
-	Symbol   string `json:"symbol"`
-	Quantity int    `json:"quantity"`
+	UserID   string          `json:"userId"`
+	Symbol   string          `json:"symbol"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
+
+// ShortOrderRequest is decoded from the JSON body of the /short and /cover
+// endpoints.
+type ShortOrderRequest struct {
+	Symbol   string          `json:"symbol"`
+	Quantity decimal.Decimal `json:"quantity"`
 }
 
-// TradeHistoryResponse is the paginated payload returned by GET /investments/history.
+// TradeHistoryResponse is the paginated payload returned by GET /investments/trades.
 // Total is the count of all trades matching the filter (independent of limit/offset),
 // so the UI can render "showing 1-50 of 142" and decide whether Next is enabled.
+// Page is the 1-based page offset falls on.
 type TradeHistoryResponse struct {
 	Trades []data.Trade `json:"trades"`
 	Total  int          `json:"total"`
 	Limit  int          `json:"limit"`
 	Offset int          `json:"offset"`
+	Page   int          `json:"page"`
+}
+
+// CreateOrderRequest is the body of POST /investments/orders. Type is
+// MARKET, LIMIT, STOP, STOP_LOSS or TAKE_PROFIT; Side is BUY or SELL and may
+// be omitted for STOP_LOSS and TAKE_PROFIT. TriggerPrice is omitted for
+// MARKET. TimeInForce is DAY or GTC (the default). ExpiresAt is optional and
+// GTC only.
+type CreateOrderRequest struct {
+	Symbol       string          `json:"symbol"`
+	Side         string          `json:"side"`
+	Type         string          `json:"type"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	TriggerPrice decimal.Decimal `json:"trigger_price"`
+	TimeInForce  string          `json:"time_in_force,omitempty"`
+	ExpiresAt    *time.Time      `json:"expires_at,omitempty"`
+}
+
+// CreateOCORequest is the body of POST /investments/orders/oco: a
+// take-profit and a stop-loss selling Quantity shares, TakeProfit above
+// StopLoss. TimeInForce and ExpiresAt are as for CreateOrderRequest and
+// apply to both.
+type CreateOCORequest struct {
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	TakeProfit  decimal.Decimal `json:"take_profit"`
+	StopLoss    decimal.Decimal `json:"stop_loss"`
+	TimeInForce string          `json:"time_in_force,omitempty"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+}
+
+// CreateBracketRequest is the body of POST /investments/orders/bracket: a
+// buy entry and the take-profit and stop-loss that sell the same shares
+// once it fills. EntryType is MARKET, LIMIT (the default) or STOP;
+// EntryPrice is omitted for MARKET and otherwise lies between StopLoss and
+// TakeProfit. TimeInForce and ExpiresAt are as for CreateOrderRequest and
+// apply to the entry.
+type CreateBracketRequest struct {
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	EntryType   string          `json:"entry_type,omitempty"`
+	EntryPrice  decimal.Decimal `json:"entry_price"`
+	TakeProfit  decimal.Decimal `json:"take_profit"`
+	StopLoss    decimal.Decimal `json:"stop_loss"`
+	TimeInForce string          `json:"time_in_force,omitempty"`
+	ExpiresAt   *time.Time      `json:"expires_at,omitempty"`
+}
+
+// QueuedTradeResponse is returned with 202 by /buy and /sell when the market
+// is closed and the user queues after-hours trades.
+type QueuedTradeResponse struct {
+	Queued   bool        `json:"queued"`
+	Order    *data.Order `json:"order"`
+	NextOpen time.Time   `json:"next_open"`
+}
+
+// LotsResponse is returned by GET /investments/lots. Lot prices are in
+// Currency.
+type LotsResponse struct {
+	service.LotsReport
+	Currency string `json:"currency"`
+}
+
+// OrderListResponse is returned by GET /investments/orders.
+type OrderListResponse struct {
+	Orders []data.Order `json:"orders"`
+}
+
+// CreateRecurringInvestmentRequest is the body of POST
+// /investments/recurring: buy Amount dollars of Symbol every day, week or
+// month. Day is the weekday (1 = Monday .. 5 = Friday) for WEEKLY and the
+// day of the month (1-28) for MONTHLY, and is omitted for DAILY.
+type CreateRecurringInvestmentRequest struct {
+	Symbol    string          `json:"symbol"`
+	Amount    decimal.Decimal `json:"amount"`
+	Frequency string          `json:"frequency"`
+	Day       *int            `json:"day,omitempty"`
+}
+
+// UpdateRecurringInvestmentRequest is the body of PUT
+// /investments/recurring/{id}: false pauses the plan, true resumes it.
+type UpdateRecurringInvestmentRequest struct {
+	Active *bool `json:"active"`
+}
+
+// RecurringInvestmentListResponse is returned by GET
+// /investments/recurring.
+type RecurringInvestmentListResponse struct {
+	RecurringInvestments []data.RecurringInvestment `json:"recurring_investments"`
+}
+
+// TradeNoteRequest is the body of PUT /investments/trades/{id}/note. It
+// replaces both fields; send an empty tags array to clear them.
+type TradeNoteRequest struct {
+	Note string   `json:"note"`
+	Tags []string `json:"tags"`
+}
+
+// TradeDisputeRequest is the body of POST /investments/trades/{id}/dispute.
+type TradeDisputeRequest struct {
+	Reason string `json:"reason"`
+}
+
+type TradeDisputeListResponse struct {
+	Items []data.TradeDispute `json:"items"`
+}
+
+// TradeSearchResponse is returned by GET /investments/search. Total counts
+// every match, independent of limit/offset.
+type TradeSearchResponse struct {
+	Results []data.TradeSearchResult `json:"results"`
+	Total   int                      `json:"total"`
+	Limit   int                      `json:"limit"`
+	Offset  int                      `json:"offset"`
+}
+
+// RebalanceRequest is the body of POST /investments/rebalance: target
+// weights as percent of account value by symbol. Execute places the orders;
+// otherwise the plan is only previewed.
+type RebalanceRequest struct {
+	Targets map[string]decimal.Decimal `json:"targets"`
+	Execute bool                       `json:"execute"`
 }
@@ -2,12 +2,23 @@ package investments
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
 
 	"papertrader/internal/data"
+	"papertrader/internal/service"
 	"papertrader/internal/util"
 )
 
@@ -41,22 +52,144 @@ func validateIdempotencyKey(r *http.Request) (string, string) {
 const (
 	maxHistoryLimit     = 200
 	defaultHistoryLimit = 50
+	maxHistoryPage      = 1000000 // keeps (page-1)*limit well inside an int
+)
+
+// Search query param bounds.
+const (
+	maxSearchLimit     = 100
+	defaultSearchLimit = 20
 )
 
 // InvestmentServicer is the subset of service.InvestmentService used by InvestmentsHandler.
 type InvestmentServicer interface {
-	BuyStock(ctx context.Context, userID, symbol string, quantity int, idempotencyKey string) (*data.UserStock, error)
-	SellStock(ctx context.Context, userID, symbol string, quantity int, idempotencyKey string) (*data.UserStock, error)
+	BuyStock(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)
+	SellStock(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)
+	SellShort(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)
+	BuyToCover(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)
 	GetUserStocks(ctx context.Context, userID string) ([]data.UserStock, error)
 	GetUserTrades(ctx context.Context, userID string, opts data.TradeQueryOpts) ([]data.Trade, int, error)
+	ExportTrades(ctx context.Context, userID string, opts data.TradeQueryOpts, fn func(*data.Trade) error) error
+}
+
+// OrderServicer is the subset of service.OrderService used by
+// InvestmentsHandler.
+type OrderServicer interface {
+	Create(ctx context.Context, userID string, req service.OrderRequest) (*data.Order, error)
+	CreateOCO(ctx context.Context, userID string, req service.OCORequest) (*service.OCOOrders, error)
+	CreateBracket(ctx context.Context, userID string, req service.BracketRequest) (*service.BracketOrders, error)
+	Get(ctx context.Context, userID, id string) (*data.Order, error)
+	List(ctx context.Context, userID, status string, limit int) ([]data.Order, error)
+	Cancel(ctx context.Context, userID, id string) (*data.Order, error)
+	Wait(ctx context.Context, userID, id string, timeout time.Duration) (*data.Order, error)
+	Holds(ctx context.Context, userID string) (*service.OrderHolds, error)
+}
+
+// RecurringInvestmentServicer is the subset of
+// service.RecurringInvestmentService used by InvestmentsHandler.
+type RecurringInvestmentServicer interface {
+	Create(ctx context.Context, userID string, req service.RecurringInvestmentRequest) (*data.RecurringInvestment, error)
+	List(ctx context.Context, userID string) ([]data.RecurringInvestment, error)
+	SetActive(ctx context.Context, userID, id string, active bool) (*data.RecurringInvestment, error)
+	Delete(ctx context.Context, userID, id string) error
+}
+
+// TradeNotesServicer is the subset of service.TradeNotesService used by
+// InvestmentsHandler.
+type TradeNotesServicer interface {
+	SetNote(ctx context.Context, userID, tradeID, note string, tags []string) (*data.TradeNote, error)
+	DeleteNote(ctx context.Context, userID, tradeID string) error
+	Search(ctx context.Context, userID, query string, from, to time.Time, limit, offset int) ([]data.TradeSearchResult, int, error)
+}
+
+// TradeDisputeServicer is the subset of service.TradeDisputeService used by
+// InvestmentsHandler.
+type TradeDisputeServicer interface {
+	File(ctx context.Context, userID, tradeID, reason string) (*data.TradeDispute, error)
+	ListForUser(ctx context.Context, userID string) ([]data.TradeDispute, error)
+}
+
+// CurrencyServicer is the subset of service.FXService used by
+// InvestmentsHandler.
+type CurrencyServicer interface {
+	DisplayRate(ctx context.Context, userID, requested string) (*service.FXRate, error)
+}
+
+// PnLServicer is the subset of service.PnLService used by
+// InvestmentsHandler.
+type PnLServicer interface {
+	Report(ctx context.Context, userID string, from, to time.Time) (*service.PnLReport, error)
+}
+
+// LotsServicer is the subset of service.CostBasisService used by
+// InvestmentsHandler.
+type LotsServicer interface {
+	Lots(ctx context.Context, userID string) (*service.LotsReport, error)
+}
+
+// PortfolioHistoryServicer is the subset of service.PortfolioHistoryService
+// used by InvestmentsHandler.
+type PortfolioHistoryServicer interface {
+	History(ctx context.Context, userID, rng string) (*service.PortfolioHistory, error)
+}
+
+// BenchmarkServicer is the subset of service.BenchmarkService used by
+// InvestmentsHandler.
+type BenchmarkServicer interface {
+	Compare(ctx context.Context, userID, rng, symbol string) (*service.BenchmarkComparison, error)
+}
+
+// PortfolioValueServicer is the subset of service.PortfolioValueService
+// used by InvestmentsHandler.
+type PortfolioValueServicer interface {
+	Estimate(ctx context.Context, userID string) (*service.PortfolioValue, error)
+}
+
+// RiskServicer is the subset of service.RiskService used by
+// InvestmentsHandler.
+type RiskServicer interface {
+	Metrics(ctx context.Context, userID, rng, symbol string) (*service.RiskMetrics, error)
+}
+
+// StatsServicer is the subset of service.StatsService used by
+// InvestmentsHandler.
+type StatsServicer interface {
+	Stats(ctx context.Context, userID string) (*service.TradingStats, error)
+}
+
+// ReplayServicer is the subset of service.ReplayService used by
+// InvestmentsHandler.
+type ReplayServicer interface {
+	Replay(ctx context.Context, userID, symbol string, days int) (*service.TradeReplay, error)
+}
+
+// RebalanceServicer is the subset of service.RebalanceService used by
+// InvestmentsHandler.
+type RebalanceServicer interface {
+	Rebalance(ctx context.Context, userID string, req service.RebalanceRequest) (*service.RebalancePlan, error)
 }
 
 type InvestmentsHandler struct {
-	service InvestmentServicer
+	service     InvestmentServicer
+	orders      OrderServicer
+	recurring   RecurringInvestmentServicer
+	notes       TradeNotesServicer
+	disputes    TradeDisputeServicer
+	fx          CurrencyServicer
+	pnl         PnLServicer
+	lots        LotsServicer
+	history     PortfolioHistoryServicer
+	benchmark   BenchmarkServicer
+	risk        RiskServicer
+	value       PortfolioValueServicer
+	stats       StatsServicer
+	replay      ReplayServicer
+	rebalance   RebalanceServicer
+	maxQuantity int // per-order share cap; <= 0 leaves the bound to the service
 }
 
-func NewInvestmentsHandler(s InvestmentServicer) *InvestmentsHandler {
-	return &InvestmentsHandler{service: s}
+func NewInvestmentsHandler(s InvestmentServicer, orders OrderServicer, recurring RecurringInvestmentServicer, notes TradeNotesServicer, disputes TradeDisputeServicer, fx CurrencyServicer, pnl PnLServicer, lots LotsServicer, history PortfolioHistoryServicer, benchmark BenchmarkServicer, risk RiskServicer, value PortfolioValueServicer, stats StatsServicer, replay ReplayServicer, rebalance RebalanceServicer, maxQuantity int) *InvestmentsHandler {
+	return &InvestmentsHandler{service: s, orders: orders, recurring: recurring, notes: notes, disputes: disputes, fx: fx, pnl: pnl, lots: lots, history: history, benchmark: benchmark, risk: risk, value: value, stats: stats, replay: replay, rebalance: rebalance, maxQuantity: maxQuantity}
 }
 
 func (h *InvestmentsHandler) BuyStock(w http.ResponseWriter, r *http.Request) {
@@ -72,15 +205,15 @@ func (h *InvestmentsHandler) BuyStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate quantity
-	if err := util.ValidateQuantity(req.Quantity); err != nil {
+	// Validate and sanitize symbol (defense in depth)
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
 		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
 		return
 	}
 
-	// Validate and sanitize symbol (defense in depth)
-	symbol, err := util.ValidateSymbol(req.Symbol)
-	if err != nil {
+	// Validate quantity
+	if err := util.ValidateQuantity(req.Quantity, h.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
 		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
 		return
 	}
@@ -94,7 +227,7 @@ func (h *InvestmentsHandler) BuyStock(w http.ResponseWriter, r *http.Request) {
 
 	userStock, err := h.service.BuyStock(r.Context(), userID, symbol, req.Quantity, idempotencyKey)
 	if err != nil {
-		util.WriteServiceError(w, err)
+		h.writeTradeError(w, r, userID, data.OrderSideBuy, symbol, req.Quantity, idempotencyKey, err)
 		return
 	}
 
@@ -117,15 +250,15 @@ func (h *InvestmentsHandler) SellStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate quantity
-	if err := util.ValidateQuantity(req.Quantity); err != nil {
+	// Validate and sanitize symbol (defense in depth)
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
 		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
 		return
 	}
 
-	// Validate and sanitize symbol (defense in depth)
-	symbol, err := util.ValidateSymbol(req.Symbol)
-	if err != nil {
+	// Validate quantity
+	if err := util.ValidateQuantity(req.Quantity, h.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
 		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
 		return
 	}
@@ -139,7 +272,7 @@ func (h *InvestmentsHandler) SellStock(w http.ResponseWriter, r *http.Request) {
 
 	userStock, err := h.service.SellStock(r.Context(), userID, symbol, req.Quantity, idempotencyKey)
 	if err != nil {
-		util.WriteServiceError(w, err)
+		h.writeTradeError(w, r, userID, data.OrderSideSell, symbol, req.Quantity, idempotencyKey, err)
 		return
 	}
 
@@ -149,9 +282,242 @@ func (h *InvestmentsHandler) SellStock(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(userStock)
 }
 
+// writeTradeError writes the error from a buy or sell. A trade rejected
+// because the market is closed, from a user who queues after-hours trades,
+// is instead placed as a MARKET order and answered 202 with the order. The
+// order keeps the request's idempotency key, so a retry gets the same order.
+func (h *InvestmentsHandler) writeTradeError(w http.ResponseWriter, r *http.Request, userID, side, symbol string, quantity decimal.Decimal, idempotencyKey string, err error) {
+	var closed *service.MarketClosedError
+	if !errors.As(err, &closed) || !closed.Queue {
+		util.WriteServiceError(w, err)
+		return
+	}
+	order, err := h.orders.Create(r.Context(), userID, service.OrderRequest{
+		Symbol:         symbol,
+		Side:           side,
+		Type:           data.OrderTypeMarket,
+		Quantity:       quantity,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(QueuedTradeResponse{Queued: true, Order: order, NextOpen: closed.NextOpen})
+}
+
+// ShortStock opens or adds to a short position. The body and validation are
+// those of SellStock.
+func (h *InvestmentsHandler) ShortStock(w http.ResponseWriter, r *http.Request) {
+	h.placeShortOrder(w, r, h.service.SellShort)
+}
+
+// CoverShort buys back shares of a short position. The body and validation
+// are those of BuyStock.
+func (h *InvestmentsHandler) CoverShort(w http.ResponseWriter, r *http.Request) {
+	h.placeShortOrder(w, r, h.service.BuyToCover)
+}
+
+func (h *InvestmentsHandler) placeShortOrder(w http.ResponseWriter, r *http.Request,
+	place func(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ShortOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+	if err := util.ValidateQuantity(req.Quantity, h.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+	idempotencyKey, errMsg := validateIdempotencyKey(r)
+	if errMsg != "" {
+		util.WriteSafeError(w, http.StatusBadRequest, errMsg, nil, "VALIDATION_ERROR")
+		return
+	}
+
+	position, err := place(r.Context(), userID, symbol, req.Quantity, idempotencyKey)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(position)
+}
+
+// parseDateRange reads the optional ?from= and ?to= dates (YYYY-MM-DD, UTC,
+// both inclusive) and returns them as a half-open range: to is the start of
+// the day after. Zero times mean unbounded. On a bad value it writes the 400
+// and returns ok=false.
+func parseDateRange(w http.ResponseWriter, q url.Values) (from, to time.Time, ok bool) {
+	if raw := q.Get("from"); raw != "" {
+		d, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			util.WriteSafeError(w, http.StatusBadRequest, "from must be a date in YYYY-MM-DD format", err, "VALIDATION_ERROR")
+			return time.Time{}, time.Time{}, false
+		}
+		from = d
+	}
+	if raw := q.Get("to"); raw != "" {
+		d, err := time.Parse(time.DateOnly, raw)
+		if err != nil {
+			util.WriteSafeError(w, http.StatusBadRequest, "to must be a date in YYYY-MM-DD format", err, "VALIDATION_ERROR")
+			return time.Time{}, time.Time{}, false
+		}
+		// Callers take an exclusive bound; include the whole "to" day.
+		to = d.AddDate(0, 0, 1)
+	}
+	return from, to, true
+}
+
+// parseTradeFilter reads the optional from, to, symbol and action filters
+// shared by the trade history and its export, writing a 400 and returning
+// ok=false if one is invalid.
+func parseTradeFilter(w http.ResponseWriter, q url.Values) (opts data.TradeQueryOpts, ok bool) {
+	if opts.From, opts.To, ok = parseDateRange(w, q); !ok {
+		return opts, false
+	}
+
+	// symbol: optional. If provided, must pass the same validation as buy/sell.
+	if raw := q.Get("symbol"); raw != "" {
+		s, err := util.ValidateSymbol(raw)
+		if err != nil {
+			util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+			return opts, false
+		}
+		opts.Symbol = s
+	}
+
+	// action: optional, must be BUY, SELL, SHORT or COVER if provided
+	opts.Action = q.Get("action")
+	switch opts.Action {
+	case "", "BUY", "SELL", "SHORT", "COVER":
+	default:
+		util.WriteSafeError(w, http.StatusBadRequest, "action must be BUY, SELL, SHORT or COVER", nil, "VALIDATION_ERROR")
+		return opts, false
+	}
+	return opts, true
+}
+
+// GetHistory handles GET /api/investments/history. With a range parameter
+// it returns the daily portfolio value series; without one it is the
+// original path of GetTradeHistory, kept for existing clients.
+func (h *InvestmentsHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	if !r.URL.Query().Has("range") {
+		h.GetTradeHistory(w, r)
+		return
+	}
+	h.GetPortfolioHistory(w, r)
+}
+
+// GetPortfolioHistory returns the user's account value at each trading
+// day's close over range=1M|3M|1Y, for an equity-curve chart.
+func (h *InvestmentsHandler) GetPortfolioHistory(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, q.Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	history, err := h.history.History(r.Context(), userID, q.Get("range"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	for i := range history.Points {
+		p := &history.Points[i]
+		p.Cash = rate.Apply(p.Cash)
+		p.HoldingsValue = rate.Apply(p.HoldingsValue)
+		p.TotalValue = rate.Apply(p.TotalValue)
+		p.BonusCash = rate.Apply(p.BonusCash)
+	}
+	history.Currency = rate.To
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetBenchmarkComparison compares the user's equity curve over
+// range=1M|3M|1Y with symbol= (default: the configured benchmark).
+func (h *InvestmentsHandler) GetBenchmarkComparison(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, q.Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	cmp, err := h.benchmark.Compare(r.Context(), userID, q.Get("range"), q.Get("symbol"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	for i := range cmp.Points {
+		p := &cmp.Points[i]
+		p.PortfolioValue = rate.Apply(p.PortfolioValue)
+		p.BenchmarkClose = rate.Apply(p.BenchmarkClose)
+	}
+	cmp.Currency = rate.To
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(cmp)
+}
+
+// GetRisk returns volatility, Sharpe ratio, beta and maximum drawdown over
+// range=1M|3M|1Y, with beta against symbol= (default: the configured
+// benchmark). The statistics are ratios and percentages, so they need no
+// currency conversion.
+func (h *InvestmentsHandler) GetRisk(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+
+	metrics, err := h.risk.Metrics(r.Context(), userID, q.Get("range"), q.Get("symbol"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(metrics)
+}
+
 // GetTradeHistory returns a paginated, filterable list of the user's trades.
-// Query params: limit (default 50, max 200), offset (>= 0), symbol (optional),
-// action (optional, BUY or SELL). All params are validated; bad input → 400.
+// Query params: limit (default 50, max 200), offset (>= 0) or page (>= 1),
+// symbol (optional), action (optional, BUY, SELL, SHORT or COVER), from and
+// to (optional dates, inclusive). All params are validated; bad input → 400.
 func (h *InvestmentsHandler) GetTradeHistory(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
@@ -183,30 +549,27 @@ func (h *InvestmentsHandler) GetTradeHistory(w http.ResponseWriter, r *http.Requ
 		offset = parsed
 	}
 
-	// symbol: optional. If provided, must pass the same validation as buy/sell.
-	var symbol string
-	if raw := q.Get("symbol"); raw != "" {
-		s, err := util.ValidateSymbol(raw)
-		if err != nil {
-			util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+	// page: optional 1-based alternative to offset, in pages of limit.
+	if raw := q.Get("page"); raw != "" {
+		if q.Get("offset") != "" {
+			util.WriteSafeError(w, http.StatusBadRequest, "page and offset cannot be combined", nil, "VALIDATION_ERROR")
+			return
+		}
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxHistoryPage {
+			util.WriteSafeError(w, http.StatusBadRequest, "page must be a positive integer", nil, "VALIDATION_ERROR")
 			return
 		}
-		symbol = s
+		offset = (parsed - 1) * limit
 	}
 
-	// action: optional, must be BUY or SELL if provided
-	action := q.Get("action")
-	if action != "" && action != "BUY" && action != "SELL" {
-		util.WriteSafeError(w, http.StatusBadRequest, "action must be BUY or SELL", nil, "VALIDATION_ERROR")
+	opts, ok := parseTradeFilter(w, q)
+	if !ok {
 		return
 	}
+	opts.Limit, opts.Offset = limit, offset
 
-	trades, total, err := h.service.GetUserTrades(r.Context(), userID, data.TradeQueryOpts{
-		Symbol: symbol,
-		Action: action,
-		Limit:  limit,
-		Offset: offset,
-	})
+	trades, total, err := h.service.GetUserTrades(r.Context(), userID, opts)
 	if err != nil {
 		util.WriteServiceError(w, err)
 		return
@@ -217,6 +580,7 @@ func (h *InvestmentsHandler) GetTradeHistory(w http.ResponseWriter, r *http.Requ
 		Total:  total,
 		Limit:  limit,
 		Offset: offset,
+		Page:   offset/limit + 1,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -224,6 +588,9 @@ func (h *InvestmentsHandler) GetTradeHistory(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(resp)
 }
 
+// GetUserStocks returns the user's holdings with their monetary fields in
+// ?display_currency= or, without it, the user's saved display currency, and
+// the shares of each held by pending sell orders.
 func (h *InvestmentsHandler) GetUserStocks(w http.ResponseWriter, r *http.Request) {
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
@@ -231,14 +598,910 @@ func (h *InvestmentsHandler) GetUserStocks(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	rate, err := h.fx.DisplayRate(r.Context(), userID, r.URL.Query().Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
 	stocks, err := h.service.GetUserStocks(r.Context(), userID)
 	if err != nil {
 		util.WriteServiceError(w, err)
 		return
 	}
+	var held map[string]decimal.Decimal
+	if h.orders != nil {
+		holds, err := h.orders.Holds(r.Context(), userID)
+		if err != nil {
+			util.WriteServiceError(w, err)
+			return
+		}
+		held = holds.Shares
+	}
+	for i := range stocks {
+		convertHolding(&stocks[i], rate)
+		stocks[i].QuantityOnHold = held[stocks[i].Symbol]
+	}
 
 	// Set Content-Type header before writing response
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(stocks)
 }
+
+// exportFlushRows is how many rows ExportTrades writes between flushes, so
+// a long history reaches the client as it is read.
+const exportFlushRows = 500
+
+// exportMaxRows caps one trade export, so a runaway history can't hold a
+// connection and the database cursor open indefinitely. Narrow the export
+// with the filters to get past it.
+const exportMaxRows = 100_000
+
+// ExportTrades handles GET /api/investments/trades/export: the user's whole
+// trade history, oldest first, as a CSV download (the default) or with
+// format=json as the trade history's list, optionally narrowed by the same
+// from, to, symbol and action filters. Rows are streamed as they are read,
+// so once the first is sent a failure can only cut the file short; it is
+// logged. At most exportMaxRows are sent: a CSV past the cap ends with the
+// trailer X-Export-Truncated: true, a JSON list with "truncated": true.
+func (h *InvestmentsHandler) ExportTrades(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	if !checkExportFormat(w, q, "json") {
+		return
+	}
+	opts, ok := parseTradeFilter(w, q)
+	if !ok {
+		return
+	}
+	if q.Get("format") == "json" {
+		h.exportTradesJSON(w, r, userID, opts)
+		return
+	}
+
+	cw := csv.NewWriter(w)
+	stream := util.NewStream(w, exportFlushRows, exportMaxRows)
+	started := false
+	start := func() {
+		started = true
+		setDownloadHeaders(w, "text/csv; charset=utf-8", "trades", "csv")
+		w.Header().Set("Trailer", "X-Export-Truncated")
+		w.WriteHeader(http.StatusOK)
+		cw.Write(service.TradeCSVHeader)
+	}
+	err := h.service.ExportTrades(r.Context(), userID, opts, func(t *data.Trade) error {
+		if err := stream.Row(); err != nil {
+			return err
+		}
+		if !started {
+			start()
+		}
+		cw.Write(service.TradeCSVRecord(t))
+		stream.Wrote(cw.Flush)
+		return cw.Error()
+	})
+	if err != nil && !errors.Is(err, util.ErrStreamFull) {
+		if !started {
+			util.WriteServiceError(w, err)
+			return
+		}
+		slog.Error("trade export cut short", "user_id", userID, "rows", stream.Items(), "err", err, "component", "investments")
+		return
+	}
+	if !started {
+		start()
+	}
+	cw.Flush()
+	if stream.Truncated() {
+		w.Header().Set("X-Export-Truncated", "true")
+	}
+}
+
+// exportTradesJSON is ExportTrades with format=json.
+func (h *InvestmentsHandler) exportTradesJSON(w http.ResponseWriter, r *http.Request, userID string, opts data.TradeQueryOpts) {
+	stream := util.NewJSONStream(w, exportFlushRows, exportMaxRows)
+	err := h.service.ExportTrades(r.Context(), userID, opts, func(t *data.Trade) error {
+		return stream.Write(t)
+	})
+	if err != nil && !errors.Is(err, util.ErrStreamFull) {
+		if !stream.Started() {
+			util.WriteServiceError(w, err)
+			return
+		}
+		// Leave the list unclosed so the client sees invalid JSON rather
+		// than a complete-looking, short history.
+		slog.Error("trade export cut short", "user_id", userID, "rows", stream.Items(), "err", err, "component", "investments")
+		return
+	}
+	if err := stream.Close(); err != nil {
+		slog.Error("trade export: write failed", "user_id", userID, "err", err, "component", "investments")
+	}
+}
+
+// ExportHoldings handles GET /api/investments/export: the user's holdings,
+// priced as for GET /api/investments, as a CSV download. Amounts are in
+// ?display_currency= or the user's saved display currency.
+func (h *InvestmentsHandler) ExportHoldings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	if !checkExportFormat(w, q) {
+		return
+	}
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, q.Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	stocks, err := h.service.GetUserStocks(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	for i := range stocks {
+		convertHolding(&stocks[i], rate)
+	}
+
+	setDownloadHeaders(w, "text/csv; charset=utf-8", "holdings", "csv")
+	w.WriteHeader(http.StatusOK)
+	if err := service.WriteHoldingsCSV(w, stocks); err != nil {
+		slog.Error("holdings export: write failed", "user_id", userID, "err", err, "component", "investments")
+	}
+}
+
+// checkExportFormat accepts an absent format, format=csv or a format listed
+// in also, writing a 400 otherwise.
+func checkExportFormat(w http.ResponseWriter, q url.Values, also ...string) bool {
+	f := q.Get("format")
+	if f == "" || f == "csv" || slices.Contains(also, f) {
+		return true
+	}
+	util.WriteSafeError(w, http.StatusBadRequest, "format must be "+strings.Join(append([]string{"csv"}, also...), " or "), nil, "VALIDATION_ERROR")
+	return false
+}
+
+// setDownloadHeaders marks the response as a file download named
+// <name>-<today UTC>.<ext>.
+func setDownloadHeaders(w http.ResponseWriter, contentType, name, ext string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().UTC().Format(time.DateOnly), ext))
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// GetPortfolioValue returns the user's account value marked to the latest
+// quotes, with the change since the last daily snapshot, for the dashboard.
+func (h *InvestmentsHandler) GetPortfolioValue(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, r.URL.Query().Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	v, err := h.value.Estimate(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	v.Cash = rate.Apply(v.Cash)
+	v.CashOnHold = rate.Apply(v.CashOnHold)
+	v.BuyingPower = rate.Apply(v.BuyingPower)
+	v.HoldingsValue = rate.Apply(v.HoldingsValue)
+	v.TotalValue = rate.Apply(v.TotalValue)
+	v.BonusCash = rate.Apply(v.BonusCash)
+	if v.PreviousValue != nil {
+		prev := rate.Apply(*v.PreviousValue)
+		v.PreviousValue = &prev
+	}
+	if v.DayChange != nil {
+		change := rate.Apply(*v.DayChange)
+		v.DayChange = &change
+	}
+	v.Currency = rate.To
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(v)
+}
+
+// GetStats returns streak, best and worst trade, hold time and drawdown
+// statistics for the user.
+func (h *InvestmentsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, r.URL.Query().Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	stats, err := h.stats.Stats(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	for _, t := range []*service.TradeHighlight{stats.BestTrade, stats.WorstTrade} {
+		if t != nil {
+			t.Gain = rate.Apply(t.Gain)
+		}
+	}
+	stats.CurrentValue = rate.Apply(stats.CurrentValue)
+	stats.PeakValue = rate.Apply(stats.PeakValue)
+	stats.Drawdown = rate.Apply(stats.Drawdown)
+	stats.Currency = rate.To
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GetReplay returns ?symbol='s daily closes over the last ?days= days
+// (default 90) with the user's trades in it, for drawing the trades on the
+// chart.
+func (h *InvestmentsHandler) GetReplay(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+
+	if q.Get("symbol") == "" {
+		util.WriteSafeError(w, http.StatusBadRequest, "symbol is required", nil, "VALIDATION_ERROR")
+		return
+	}
+	days := 0
+	if raw := q.Get("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			util.WriteSafeError(w, http.StatusBadRequest, "days must be a positive integer", nil, "VALIDATION_ERROR")
+			return
+		}
+		days = parsed
+	}
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, q.Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	replay, err := h.replay.Replay(r.Context(), userID, q.Get("symbol"), days)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	for i := range replay.Points {
+		replay.Points[i].Close = rate.Apply(replay.Points[i].Close)
+	}
+	for i := range replay.Trades {
+		replay.Trades[i].Price = rate.Apply(replay.Trades[i].Price)
+	}
+	replay.Currency = rate.To
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(replay)
+}
+
+// convertHolding rewrites every monetary field of h in the rate's target
+// currency. Quantity is left alone.
+func convertHolding(h *data.UserStock, rate *service.FXRate) {
+	h.AvgPrice = rate.Apply(h.AvgPrice)
+	h.Total = rate.Apply(h.Total)
+	h.Margin = rate.Apply(h.Margin)
+	h.CurrentStockPrice = rate.Apply(h.CurrentStockPrice)
+	if h.UnrealizedPnL != nil {
+		pnl := rate.Apply(*h.UnrealizedPnL)
+		h.UnrealizedPnL = &pnl
+	}
+	h.Currency = rate.To
+}
+
+// GetPnL handles GET /api/investments/pnl: realized gains from closed
+// trades plus unrealized gains on open positions, in ?display_currency= or
+// the user's saved display currency. Optional from and to dates (inclusive)
+// limit which closes count as realized.
+func (h *InvestmentsHandler) GetPnL(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	q := r.URL.Query()
+	from, to, ok := parseDateRange(w, q)
+	if !ok {
+		return
+	}
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, q.Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	report, err := h.pnl.Report(r.Context(), userID, from, to)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	convertPnL(report, rate)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// convertPnL rewrites every monetary field of p in the rate's target
+// currency.
+func convertPnL(p *service.PnLReport, rate *service.FXRate) {
+	p.Realized = rate.Apply(p.Realized)
+	p.Unrealized = rate.Apply(p.Unrealized)
+	p.Total = rate.Apply(p.Total)
+	for i := range p.Symbols {
+		s := &p.Symbols[i]
+		s.AvgPrice = rate.Apply(s.AvgPrice)
+		s.CurrentPrice = rate.Apply(s.CurrentPrice)
+		s.Realized = rate.Apply(s.Realized)
+		if s.Unrealized != nil {
+			u := rate.Apply(*s.Unrealized)
+			s.Unrealized = &u
+		}
+	}
+	p.Currency = rate.To
+}
+
+// GetLots handles GET /api/investments/lots: the user's cost-basis method
+// and open tax lots, priced in ?display_currency= or the saved display
+// currency.
+func (h *InvestmentsHandler) GetLots(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	rate, err := h.fx.DisplayRate(r.Context(), userID, r.URL.Query().Get("display_currency"))
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	report, err := h.lots.Lots(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	for i := range report.Lots {
+		report.Lots[i].Price = rate.Apply(report.Lots[i].Price)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LotsResponse{LotsReport: *report, Currency: rate.To})
+}
+
+// CreateOrder handles POST /api/investments/orders: place a limit, stop,
+// stop-loss or take-profit order.
+func (h *InvestmentsHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+	if err := util.ValidateQuantity(req.Quantity, h.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+
+	order, err := h.orders.Create(r.Context(), userID, service.OrderRequest{
+		Symbol:       symbol,
+		Side:         req.Side,
+		Type:         req.Type,
+		Quantity:     req.Quantity,
+		TriggerPrice: req.TriggerPrice,
+		TimeInForce:  req.TimeInForce,
+		ExpiresAt:    req.ExpiresAt,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+// CreateOCOOrder handles POST /api/investments/orders/oco: place a linked
+// take-profit and stop-loss on a holding, where filling one cancels the
+// other.
+func (h *InvestmentsHandler) CreateOCOOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateOCORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+	if err := util.ValidateQuantity(req.Quantity, h.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+
+	pair, err := h.orders.CreateOCO(r.Context(), userID, service.OCORequest{
+		Symbol:      symbol,
+		Quantity:    req.Quantity,
+		TakeProfit:  req.TakeProfit,
+		StopLoss:    req.StopLoss,
+		TimeInForce: req.TimeInForce,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(pair)
+}
+
+// CreateBracketOrder handles POST /api/investments/orders/bracket: place a
+// buy entry whose take-profit and stop-loss become active once it fills.
+func (h *InvestmentsHandler) CreateBracketOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateBracketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+	if err := util.ValidateQuantity(req.Quantity, h.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, err.Error(), err, "VALIDATION_ERROR")
+		return
+	}
+
+	bracket, err := h.orders.CreateBracket(r.Context(), userID, service.BracketRequest{
+		Symbol:      symbol,
+		Quantity:    req.Quantity,
+		EntryType:   req.EntryType,
+		EntryPrice:  req.EntryPrice,
+		TakeProfit:  req.TakeProfit,
+		StopLoss:    req.StopLoss,
+		TimeInForce: req.TimeInForce,
+		ExpiresAt:   req.ExpiresAt,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(bracket)
+}
+
+// ListOrders handles GET /api/investments/orders?status=PENDING&limit=N.
+func (h *InvestmentsHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be a positive integer", err, "VALIDATION_ERROR")
+			return
+		}
+		limit = n
+	}
+
+	orders, err := h.orders.List(r.Context(), userID, q.Get("status"), limit)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(OrderListResponse{Orders: orders})
+}
+
+// GetOrder handles GET /api/investments/orders/{id}.
+func (h *InvestmentsHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.orders.Get(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+// WaitOrder handles GET /api/investments/orders/{id}/wait: a long poll that
+// answers with the order once it fills or otherwise closes, or as it stands
+// after ?timeout= seconds (default and cap TRADING_ORDER_WAIT_MAX_SECONDS),
+// for clients that can't hold a live connection.
+func (h *InvestmentsHandler) WaitOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var timeout time.Duration
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		secs, err := strconv.Atoi(raw)
+		if err != nil {
+			util.WriteSafeError(w, http.StatusBadRequest, "timeout must be a whole number of seconds", err, "VALIDATION_ERROR")
+			return
+		}
+		timeout = time.Duration(secs) * time.Second
+	}
+
+	order, err := h.orders.Wait(r.Context(), userID, mux.Vars(r)["id"], timeout)
+	if r.Context().Err() != nil {
+		return // the client left
+	}
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	// The wait may have outlasted the server's write timeout.
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(10 * time.Second))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+// CancelOrder handles DELETE /api/investments/orders/{id} and returns the
+// cancelled order.
+func (h *InvestmentsHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.orders.Cancel(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+// CreateRecurringInvestment handles POST /api/investments/recurring.
+func (h *InvestmentsHandler) CreateRecurringInvestment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateRecurringInvestmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	plan, err := h.recurring.Create(r.Context(), userID, service.RecurringInvestmentRequest{
+		Symbol:    req.Symbol,
+		Amount:    req.Amount,
+		Frequency: req.Frequency,
+		Day:       req.Day,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// ListRecurringInvestments handles GET /api/investments/recurring.
+func (h *InvestmentsHandler) ListRecurringInvestments(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	plans, err := h.recurring.List(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RecurringInvestmentListResponse{RecurringInvestments: plans})
+}
+
+// UpdateRecurringInvestment handles PUT /api/investments/recurring/{id}:
+// pause or resume a plan.
+func (h *InvestmentsHandler) UpdateRecurringInvestment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateRecurringInvestmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+	if req.Active == nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "active is required", nil, "VALIDATION_ERROR")
+		return
+	}
+
+	plan, err := h.recurring.SetActive(r.Context(), userID, mux.Vars(r)["id"], *req.Active)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// DeleteRecurringInvestment handles DELETE /api/investments/recurring/{id}.
+func (h *InvestmentsHandler) DeleteRecurringInvestment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.recurring.Delete(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetTradeNote handles PUT /api/investments/trades/{id}/note: replace the
+// note and tags on one of the user's trades.
+func (h *InvestmentsHandler) SetTradeNote(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TradeNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	note, err := h.notes.SetNote(r.Context(), userID, mux.Vars(r)["id"], req.Note, req.Tags)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(note)
+}
+
+// DeleteTradeNote handles DELETE /api/investments/trades/{id}/note.
+func (h *InvestmentsHandler) DeleteTradeNote(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.notes.DeleteNote(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DisputeTrade handles POST /api/investments/trades/{id}/dispute: flag one of
+// the user's trades as wrong for an admin to review.
+func (h *InvestmentsHandler) DisputeTrade(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req TradeDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	dispute, err := h.disputes.File(r.Context(), userID, mux.Vars(r)["id"], req.Reason)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(dispute)
+}
+
+// ListDisputes handles GET /api/investments/disputes: the user's trade
+// disputes and how they were resolved, newest first.
+func (h *InvestmentsHandler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	items, err := h.disputes.ListForUser(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TradeDisputeListResponse{Items: items})
+}
+
+// SearchTrades handles GET /api/investments/search: full-text search over the
+// user's trades by symbol, tags and note. Query params: q (required), from
+// and to (optional YYYY-MM-DD, UTC, both inclusive), limit (default 20, max
+// 100), offset (>= 0).
+func (h *InvestmentsHandler) SearchTrades(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+
+	limit := defaultSearchLimit
+	if raw := q.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be an integer between 1 and 100", nil, "VALIDATION_ERROR")
+			return
+		}
+		limit = parsed
+	}
+
+	offset := 0
+	if raw := q.Get("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			util.WriteSafeError(w, http.StatusBadRequest, "offset must be a non-negative integer", nil, "VALIDATION_ERROR")
+			return
+		}
+		offset = parsed
+	}
+
+	from, to, ok := parseDateRange(w, q)
+	if !ok {
+		return
+	}
+
+	results, total, err := h.notes.Search(r.Context(), userID, q.Get("q"), from, to, limit, offset)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TradeSearchResponse{
+		Results: results,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// Rebalance handles POST /api/investments/rebalance. It previews the orders
+// that move the account to the requested weights, and places them when the
+// body sets execute. An Idempotency-Key applies to each executed order.
+func (h *InvestmentsHandler) Rebalance(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req RebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+	idempotencyKey, errMsg := validateIdempotencyKey(r)
+	if errMsg != "" {
+		util.WriteSafeError(w, http.StatusBadRequest, errMsg, nil, "VALIDATION_ERROR")
+		return
+	}
+
+	plan, err := h.rebalance.Rebalance(r.Context(), userID, service.RebalanceRequest{
+		Targets:        req.Targets,
+		Execute:        req.Execute,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(plan)
+}
@@ -6,12 +6,16 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/shopspring/decimal"
 
 	"papertrader/internal/data"
 	"papertrader/internal/service"
+	"papertrader/internal/util"
 )
 
 // mockInvestmentService implements InvestmentServicer for handler tests.
@@ -20,6 +24,9 @@ type mockInvestmentService struct {
 	buyErr             error
 	sellResult         *data.UserStock
 	sellErr            error
+	shortResult        *data.UserStock
+	shortErr           error
+	lastShortAction    string
 	stocks             []data.UserStock
 	stocksErr          error
 	trades             []data.Trade
@@ -29,14 +36,22 @@ type mockInvestmentService struct {
 	lastIdempotencyKey string
 }
 
-func (m *mockInvestmentService) BuyStock(_ context.Context, userID, symbol string, quantity int, idempotencyKey string) (*data.UserStock, error) {
+func (m *mockInvestmentService) BuyStock(_ context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
 	m.lastIdempotencyKey = idempotencyKey
 	return m.buyResult, m.buyErr
 }
-func (m *mockInvestmentService) SellStock(_ context.Context, userID, symbol string, quantity int, idempotencyKey string) (*data.UserStock, error) {
+func (m *mockInvestmentService) SellStock(_ context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
 	m.lastIdempotencyKey = idempotencyKey
 	return m.sellResult, m.sellErr
 }
+func (m *mockInvestmentService) SellShort(_ context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
+	m.lastIdempotencyKey, m.lastShortAction = idempotencyKey, "SHORT"
+	return m.shortResult, m.shortErr
+}
+func (m *mockInvestmentService) BuyToCover(_ context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
+	m.lastIdempotencyKey, m.lastShortAction = idempotencyKey, "COVER"
+	return m.shortResult, m.shortErr
+}
 func (m *mockInvestmentService) GetUserStocks(_ context.Context, userID string) ([]data.UserStock, error) {
 	return m.stocks, m.stocksErr
 }
@@ -44,9 +59,37 @@ func (m *mockInvestmentService) GetUserTrades(_ context.Context, userID string,
 	m.lastTradeOpts = opts
 	return m.trades, m.tradesTotal, m.tradesErr
 }
+func (m *mockInvestmentService) ExportTrades(_ context.Context, userID string, opts data.TradeQueryOpts, fn func(*data.Trade) error) error {
+	m.lastTradeOpts = opts
+	if m.tradesErr != nil {
+		return m.tradesErr
+	}
+	for i := range m.trades {
+		if err := fn(&m.trades[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mockFX implements CurrencyServicer with a fixed rate out of USD.
+type mockFX struct {
+	rate          *service.FXRate
+	err           error
+	lastRequested string
+}
+
+func (m *mockFX) DisplayRate(_ context.Context, _, requested string) (*service.FXRate, error) {
+	m.lastRequested = requested
+	return m.rate, m.err
+}
+
+func usdRate() *service.FXRate {
+	return &service.FXRate{From: "USD", To: "USD", Rate: decimal.NewFromInt(1)}
+}
 
 func newHandler(svc InvestmentServicer) *InvestmentsHandler {
-	return &InvestmentsHandler{service: svc}
+	return &InvestmentsHandler{service: svc, fx: &mockFX{rate: usdRate()}}
 }
 
 func jsonReq(t *testing.T, method, target string, body interface{}) *http.Request {
@@ -62,7 +105,7 @@ func jsonReq(t *testing.T, method, target string, body interface{}) *http.Reques
 
 func TestBuyStock_MissingUserID(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)})
 	w := httptest.NewRecorder()
 	h.BuyStock(w, req)
 	if w.Code != http.StatusUnauthorized {
@@ -72,7 +115,18 @@ func TestBuyStock_MissingUserID(t *testing.T) {
 
 func TestBuyStock_InvalidQuantity(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 0})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(0)})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.BuyStock(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestBuyStock_QuantityOverConfiguredMax(t *testing.T) {
+	h := NewInvestmentsHandler(&mockInvestmentService{}, nil, nil, nil, nil, &mockFX{rate: usdRate()}, nil, nil, nil, nil, nil, nil, nil, nil, nil, 10)
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(11)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.BuyStock(w, req)
@@ -84,7 +138,7 @@ func TestBuyStock_InvalidQuantity(t *testing.T) {
 func TestBuyStock_InvalidSymbol(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
 	// symbol starts with digits — invalid
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "123BAD", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "123BAD", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.BuyStock(w, req)
@@ -93,9 +147,20 @@ func TestBuyStock_InvalidSymbol(t *testing.T) {
 	}
 }
 
+func TestBuyStock_IndexIsNotTradable(t *testing.T) {
+	h := newHandler(&mockInvestmentService{})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "^GSPC", Quantity: decimal.NewFromInt(1)})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.BuyStock(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "cannot be traded") {
+		t.Errorf("expected 400 saying indexes cannot be traded, got %d %s", w.Code, w.Body.String())
+	}
+}
+
 func TestBuyStock_InsufficientFunds(t *testing.T) {
 	h := newHandler(&mockInvestmentService{buyErr: &service.InsufficientFundsError{}})
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.BuyStock(w, req)
@@ -106,9 +171,9 @@ func TestBuyStock_InsufficientFunds(t *testing.T) {
 }
 
 func TestBuyStock_Success(t *testing.T) {
-	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: 5}
+	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(5)}
 	h := newHandler(&mockInvestmentService{buyResult: stock})
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 5})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(5)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.BuyStock(w, req)
@@ -128,7 +193,7 @@ func TestBuyStock_Success(t *testing.T) {
 
 func TestSellStock_MissingUserID(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
-	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)})
 	w := httptest.NewRecorder()
 	h.SellStock(w, req)
 	if w.Code != http.StatusUnauthorized {
@@ -138,7 +203,7 @@ func TestSellStock_MissingUserID(t *testing.T) {
 
 func TestSellStock_InvalidQuantity(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
-	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: -1})
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(-1)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.SellStock(w, req)
@@ -149,7 +214,7 @@ func TestSellStock_InvalidQuantity(t *testing.T) {
 
 func TestSellStock_InvalidSymbol(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
-	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.SellStock(w, req)
@@ -160,7 +225,7 @@ func TestSellStock_InvalidSymbol(t *testing.T) {
 
 func TestSellStock_HoldingNotFound(t *testing.T) {
 	h := newHandler(&mockInvestmentService{sellErr: &service.StockHoldingNotFoundError{}})
-	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "TSLA", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "TSLA", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.SellStock(w, req)
@@ -171,9 +236,9 @@ func TestSellStock_HoldingNotFound(t *testing.T) {
 }
 
 func TestSellStock_Success(t *testing.T) {
-	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: 3}
+	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(3)}
 	h := newHandler(&mockInvestmentService{sellResult: stock})
-	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: 2})
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(2)})
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
 	h.SellStock(w, req)
@@ -189,6 +254,61 @@ func TestSellStock_Success(t *testing.T) {
 	}
 }
 
+// ---- ShortStock / CoverShort ----
+
+func TestShortStock_InvalidQuantity(t *testing.T) {
+	h := newHandler(&mockInvestmentService{})
+	req := jsonReq(t, http.MethodPost, "/short", ShortOrderRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(0)})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ShortStock(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestShortStock_Success(t *testing.T) {
+	position := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(-5)}
+	svc := &mockInvestmentService{shortResult: position}
+	h := newHandler(svc)
+	req := jsonReq(t, http.MethodPost, "/short", ShortOrderRequest{Symbol: "aapl", Quantity: decimal.NewFromInt(5)})
+	req.Header.Set("X-User-ID", "user-1")
+	req.Header.Set("Idempotency-Key", "short-1")
+	w := httptest.NewRecorder()
+	h.ShortStock(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if svc.lastShortAction != "SHORT" || svc.lastIdempotencyKey != "short-1" {
+		t.Errorf("service call: got (%q, %q), want (SHORT, short-1)", svc.lastShortAction, svc.lastIdempotencyKey)
+	}
+	var result data.UserStock
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if !result.Quantity.Equal(decimal.NewFromInt(-5)) {
+		t.Errorf("quantity: got %s, want -5", result.Quantity)
+	}
+}
+
+func TestCoverShort_ExceedsShort(t *testing.T) {
+	svc := &mockInvestmentService{shortErr: &service.CoverExceedsShortError{Short: decimal.NewFromInt(3)}}
+	h := newHandler(svc)
+	req := jsonReq(t, http.MethodPost, "/cover", ShortOrderRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(5)})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.CoverShort(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if svc.lastShortAction != "COVER" {
+		t.Errorf("service call: got %q, want COVER", svc.lastShortAction)
+	}
+	if !strings.Contains(w.Body.String(), "COVER_EXCEEDS_SHORT") {
+		t.Errorf("expected COVER_EXCEEDS_SHORT in body, got %s", w.Body.String())
+	}
+}
+
 // ---- GetUserStocks ----
 
 func TestGetUserStocks_MissingUserID(t *testing.T) {
@@ -203,10 +323,11 @@ func TestGetUserStocks_MissingUserID(t *testing.T) {
 
 func TestGetUserStocks_Success(t *testing.T) {
 	stocks := []data.UserStock{
-		{ID: "p1", UserID: "user-1", Symbol: "AAPL", Quantity: 5},
-		{ID: "p2", UserID: "user-1", Symbol: "TSLA", Quantity: 2},
+		{ID: "p1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(5)},
+		{ID: "p2", UserID: "user-1", Symbol: "TSLA", Quantity: decimal.NewFromInt(2)},
 	}
 	h := newHandler(&mockInvestmentService{stocks: stocks})
+	h.orders = &mockOrderService{}
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 	req.Header.Set("X-User-ID", "user-1")
 	w := httptest.NewRecorder()
@@ -221,6 +342,133 @@ func TestGetUserStocks_Success(t *testing.T) {
 	if len(result) != 2 {
 		t.Errorf("expected 2 stocks, got %d", len(result))
 	}
+	if !result[0].QuantityOnHold.Equal(decimal.NewFromInt(3)) || !result[1].QuantityOnHold.IsZero() {
+		t.Errorf("quantity on hold: got %s and %s, want 3 and 0", result[0].QuantityOnHold, result[1].QuantityOnHold)
+	}
+}
+
+func TestGetUserStocks_ConvertsToDisplayCurrency(t *testing.T) {
+	pnl := decimal.NewFromInt(50)
+	stocks := []data.UserStock{{
+		ID: "p1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(5),
+		AvgPrice: decimal.NewFromInt(100), Total: decimal.NewFromInt(500),
+		CurrentStockPrice: decimal.NewFromInt(110), UnrealizedPnL: &pnl,
+	}}
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.9")}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{stocks: stocks}, fx: fx}
+	req := httptest.NewRequest(http.MethodGet, "/?display_currency=eur", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetUserStocks(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if fx.lastRequested != "eur" {
+		t.Errorf("display_currency: got %q", fx.lastRequested)
+	}
+	var result []data.UserStock
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil || len(result) != 1 {
+		t.Fatalf("decode body: %v (%s)", err, w.Body.String())
+	}
+	got := result[0]
+	if got.Currency != "EUR" || !got.AvgPrice.Equal(decimal.NewFromInt(90)) || !got.Total.Equal(decimal.NewFromInt(450)) ||
+		!got.CurrentStockPrice.Equal(decimal.NewFromInt(99)) || !got.UnrealizedPnL.Equal(decimal.NewFromInt(45)) || !got.Quantity.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("converted holding = %+v", got)
+	}
+}
+
+// mockPnL implements PnLServicer for handler tests.
+type mockPnL struct {
+	report   *service.PnLReport
+	from, to time.Time
+}
+
+func (m *mockPnL) Report(_ context.Context, _ string, from, to time.Time) (*service.PnLReport, error) {
+	m.from, m.to = from, to
+	return m.report, nil
+}
+
+func TestGetPnL_ConvertsAndPassesRange(t *testing.T) {
+	unrealized := decimal.NewFromInt(20)
+	pnl := &mockPnL{report: &service.PnLReport{
+		Realized: decimal.NewFromInt(100), Unrealized: unrealized, Total: decimal.NewFromInt(120),
+		Symbols: []service.SymbolPnL{{Symbol: "AAPL", Quantity: decimal.NewFromInt(2), AvgPrice: decimal.NewFromInt(100),
+			CurrentPrice: decimal.NewFromInt(110), Realized: decimal.NewFromInt(100), Unrealized: &unrealized}},
+	}}
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+	h := &InvestmentsHandler{pnl: pnl, fx: fx}
+	req := httptest.NewRequest(http.MethodGet, "/pnl?from=2026-01-01&to=2026-01-31", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetPnL(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	if !pnl.from.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !pnl.to.Equal(time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("range: got [%s, %s)", pnl.from, pnl.to)
+	}
+	var got service.PnLReport
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.Currency != "EUR" || !got.Total.Equal(decimal.NewFromInt(60)) || !got.Symbols[0].Unrealized.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("converted report = %+v", got)
+	}
+}
+
+func TestGetPnL_InvalidDate(t *testing.T) {
+	h := &InvestmentsHandler{pnl: &mockPnL{}, fx: &mockFX{rate: usdRate()}}
+	req := httptest.NewRequest(http.MethodGet, "/pnl?from=yesterday", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetPnL(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+type mockPortfolioHistory struct {
+	rng string
+}
+
+func (m *mockPortfolioHistory) History(_ context.Context, _, rng string) (*service.PortfolioHistory, error) {
+	m.rng = rng
+	return &service.PortfolioHistory{Range: "1Y", Points: []data.PortfolioSnapshot{{
+		Date: "2026-10-15", Cash: decimal.NewFromInt(4000), HoldingsValue: decimal.NewFromInt(6000), TotalValue: decimal.NewFromInt(10000),
+	}}}, nil
+}
+
+func TestGetHistory_RangeSelectsPortfolioValue(t *testing.T) {
+	history := &mockPortfolioHistory{}
+	trades := &mockInvestmentService{trades: []data.Trade{}}
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+	h := &InvestmentsHandler{service: trades, history: history, fx: fx}
+
+	req := httptest.NewRequest(http.MethodGet, "/history?range=1Y", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetHistory(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	var got service.PortfolioHistory
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if history.rng != "1Y" || got.Currency != "EUR" || !got.Points[0].TotalValue.Equal(decimal.NewFromInt(5000)) {
+		t.Errorf("range %q, body %+v", history.rng, got)
+	}
+
+	// Without range, /history is still the trade list.
+	req = httptest.NewRequest(http.MethodGet, "/history?limit=10", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w = httptest.NewRecorder()
+	h.GetHistory(w, req)
+	if w.Code != http.StatusOK || trades.lastTradeOpts.Limit != 10 {
+		t.Errorf("trade history: got %d, limit %d", w.Code, trades.lastTradeOpts.Limit)
+	}
 }
 
 func TestGetUserStocks_Empty(t *testing.T) {
@@ -310,8 +558,8 @@ func TestGetTradeHistory_DefaultsApplied(t *testing.T) {
 
 func TestGetTradeHistory_Success(t *testing.T) {
 	trades := []data.Trade{
-		{ID: "t1", UserID: "user-1", Symbol: "AAPL", Action: "BUY", Quantity: 5, Price: decimal.NewFromInt(150), Total: decimal.NewFromInt(750), Status: "COMPLETED"},
-		{ID: "t2", UserID: "user-1", Symbol: "TSLA", Action: "SELL", Quantity: 2, Price: decimal.NewFromInt(250), Total: decimal.NewFromInt(500), Status: "COMPLETED"},
+		{ID: "t1", UserID: "user-1", Symbol: "AAPL", Action: "BUY", Quantity: decimal.NewFromInt(5), Price: decimal.NewFromInt(150), Total: decimal.NewFromInt(750), Status: "COMPLETED"},
+		{ID: "t2", UserID: "user-1", Symbol: "TSLA", Action: "SELL", Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(250), Total: decimal.NewFromInt(500), Status: "COMPLETED"},
 	}
 	mock := &mockInvestmentService{trades: trades, tradesTotal: 2}
 	h := newHandler(mock)
@@ -343,14 +591,153 @@ func TestGetTradeHistory_Success(t *testing.T) {
 	}
 }
 
+func TestGetTradeHistory_PageAndDateRange(t *testing.T) {
+	mock := &mockInvestmentService{trades: []data.Trade{}}
+	h := newHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/trades?limit=20&page=3&from=2024-03-01&to=2024-03-31", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetTradeHistory(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	opts := mock.lastTradeOpts
+	if opts.Offset != 40 || opts.Limit != 20 {
+		t.Errorf("limit/offset: got %d/%d, want 20/40", opts.Limit, opts.Offset)
+	}
+	if !opts.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !opts.To.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("range: got [%s, %s)", opts.From, opts.To)
+	}
+	var resp TradeHistoryResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Page != 3 {
+		t.Errorf("page: got %d (err %v), want 3", resp.Page, err)
+	}
+}
+
+func TestGetTradeHistory_InvalidPageOrDates(t *testing.T) {
+	for _, query := range []string{"page=0", "page=2&offset=10", "from=2024-13-01", "to=yesterday"} {
+		h := newHandler(&mockInvestmentService{})
+		req := httptest.NewRequest(http.MethodGet, "/trades?"+query, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		h.GetTradeHistory(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestExportTrades_CSV(t *testing.T) {
+	executed := time.Date(2026, 3, 6, 14, 30, 0, 0, time.UTC)
+	mock := &mockInvestmentService{trades: []data.Trade{
+		{ID: "t1", Symbol: "AAPL", Action: "BUY", Quantity: decimal.NewFromInt(5), Price: decimal.NewFromInt(150), Total: decimal.NewFromInt(750),
+			ExecutedAt: executed, Status: "COMPLETED", OrderType: "MARKET"},
+	}}
+	h := newHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/trades/export?symbol=aapl&from=2026-03-01", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ExportTrades(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("content type: got %q", ct)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, `attachment; filename="trades-`) {
+		t.Errorf("content disposition: got %q", cd)
+	}
+	want := "id,executed_at,symbol,action,quantity,price,total,slippage,order_type,status\n" +
+		"t1,2026-03-06T14:30:00Z,AAPL,BUY,5,150.00,750.00,0.00,MARKET,COMPLETED\n"
+	if w.Body.String() != want {
+		t.Errorf("body:\n%s\nwant:\n%s", w.Body.String(), want)
+	}
+	if mock.lastTradeOpts.Symbol != "AAPL" || mock.lastTradeOpts.From.IsZero() {
+		t.Errorf("filters not passed: %+v", mock.lastTradeOpts)
+	}
+}
+
+func TestExportTrades_ErrorBeforeFirstRow(t *testing.T) {
+	h := newHandler(&mockInvestmentService{tradesErr: &util.ValidationError{Field: "to", Message: "must be after from"}})
+	req := httptest.NewRequest(http.MethodGet, "/trades/export", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ExportTrades(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != "" {
+		t.Errorf("an error should not be a download, got %q", cd)
+	}
+}
+
+func TestExportTrades_JSON(t *testing.T) {
+	mock := &mockInvestmentService{trades: []data.Trade{
+		{ID: "t1", Symbol: "AAPL", Action: "BUY", Quantity: decimal.NewFromInt(5), Price: decimal.NewFromInt(150)},
+		{ID: "t2", Symbol: "AAPL", Action: "SELL", Quantity: decimal.NewFromInt(5), Price: decimal.NewFromInt(160)},
+	}}
+	h := newHandler(mock)
+	req := httptest.NewRequest(http.MethodGet, "/trades/export?format=json", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ExportTrades(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var got struct {
+		Data      []data.Trade `json:"data"`
+		Count     int          `json:"count"`
+		Truncated bool         `json:"truncated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, w.Body.String())
+	}
+	if got.Count != 2 || got.Truncated || len(got.Data) != 2 || got.Data[1].ID != "t2" {
+		t.Errorf("got %+v", got)
+	}
+	if tr := w.Result().Trailer.Get("X-Export-Truncated"); tr != "" {
+		t.Errorf("unexpected truncation trailer %q", tr)
+	}
+}
+
+func TestExportHoldings_CSV(t *testing.T) {
+	pnl := decimal.NewFromInt(50)
+	h := newHandler(&mockInvestmentService{stocks: []data.UserStock{
+		{Symbol: "AAPL", Quantity: decimal.NewFromInt(5), AvgPrice: decimal.NewFromInt(150), Total: decimal.NewFromInt(750),
+			CurrentStockPrice: decimal.NewFromInt(160), UnrealizedPnL: &pnl},
+	}})
+	for _, query := range []string{"", "?format=csv"} {
+		req := httptest.NewRequest(http.MethodGet, "/export"+query, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		h.ExportHoldings(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		want := "symbol,quantity,avg_price,cost,margin,current_price,unrealized_pnl,currency\n" +
+			"AAPL,5,150.00,750.00,0.00,160.00,50.00,USD\n"
+		if w.Body.String() != want {
+			t.Errorf("body:\n%s\nwant:\n%s", w.Body.String(), want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/export?format=xml", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ExportHoldings(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown format: expected 400, got %d", w.Code)
+	}
+}
+
 // ---- Idempotency-Key header tests ----
 
 func TestBuyStock_HeaderPropagated(t *testing.T) {
-	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: 5}
+	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(5)}
 	mock := &mockInvestmentService{buyResult: stock}
 	h := newHandler(mock)
 
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 5})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(5)})
 	req.Header.Set("X-User-ID", "user-1")
 	req.Header.Set("Idempotency-Key", "test-key-123")
 	w := httptest.NewRecorder()
@@ -373,7 +760,7 @@ func TestBuyStock_RejectsInvalidIdempotencyKey_TooLong(t *testing.T) {
 		key256 += "a"
 	}
 
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	req.Header.Set("Idempotency-Key", key256)
 	w := httptest.NewRecorder()
@@ -386,7 +773,7 @@ func TestBuyStock_RejectsInvalidIdempotencyKey_TooLong(t *testing.T) {
 func TestBuyStock_RejectsInvalidIdempotencyKey_NonASCII(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
 
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	// Tab character (0x09) is below 0x20 — not printable ASCII.
 	req.Header.Set("Idempotency-Key", "key\twith\ttabs")
@@ -398,11 +785,11 @@ func TestBuyStock_RejectsInvalidIdempotencyKey_NonASCII(t *testing.T) {
 }
 
 func TestSellStock_HeaderPropagated(t *testing.T) {
-	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: 3}
+	stock := &data.UserStock{ID: "port-1", UserID: "user-1", Symbol: "AAPL", Quantity: decimal.NewFromInt(3)}
 	mock := &mockInvestmentService{sellResult: stock}
 	h := newHandler(mock)
 
-	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: 2})
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(2)})
 	req.Header.Set("X-User-ID", "user-1")
 	req.Header.Set("Idempotency-Key", "sell-key-456")
 	w := httptest.NewRecorder()
@@ -419,7 +806,7 @@ func TestSellStock_HeaderPropagated(t *testing.T) {
 func TestBuyStock_RejectsBlankIdempotencyKey(t *testing.T) {
 	h := newHandler(&mockInvestmentService{})
 
-	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: 1})
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(1)})
 	req.Header.Set("X-User-ID", "user-1")
 	req.Header.Set("Idempotency-Key", "   ")
 	w := httptest.NewRecorder()
@@ -428,3 +815,479 @@ func TestBuyStock_RejectsBlankIdempotencyKey(t *testing.T) {
 		t.Errorf("expected 400 for blank idempotency key, got %d", w.Code)
 	}
 }
+
+// ---- Orders ----
+
+// mockOrderService implements OrderServicer for handler tests.
+type mockOrderService struct {
+	order      *data.Order
+	orders     []data.Order
+	err        error
+	lastReq    service.OrderRequest
+	lastOCO    service.OCORequest
+	pair       *service.OCOOrders
+	lastBrkt   service.BracketRequest
+	bracket    *service.BracketOrders
+	lastStatus string
+	lastID     string
+	lastWait   time.Duration
+}
+
+func (m *mockOrderService) Create(_ context.Context, _ string, req service.OrderRequest) (*data.Order, error) {
+	m.lastReq = req
+	return m.order, m.err
+}
+func (m *mockOrderService) CreateOCO(_ context.Context, _ string, req service.OCORequest) (*service.OCOOrders, error) {
+	m.lastOCO = req
+	return m.pair, m.err
+}
+func (m *mockOrderService) CreateBracket(_ context.Context, _ string, req service.BracketRequest) (*service.BracketOrders, error) {
+	m.lastBrkt = req
+	return m.bracket, m.err
+}
+func (m *mockOrderService) Get(_ context.Context, _, id string) (*data.Order, error) {
+	m.lastID = id
+	return m.order, m.err
+}
+func (m *mockOrderService) List(_ context.Context, _, status string, _ int) ([]data.Order, error) {
+	m.lastStatus = status
+	return m.orders, m.err
+}
+func (m *mockOrderService) Cancel(_ context.Context, _, id string) (*data.Order, error) {
+	m.lastID = id
+	return m.order, m.err
+}
+func (m *mockOrderService) Wait(_ context.Context, _, id string, timeout time.Duration) (*data.Order, error) {
+	m.lastID = id
+	m.lastWait = timeout
+	return m.order, m.err
+}
+
+func (m *mockOrderService) Holds(context.Context, string) (*service.OrderHolds, error) {
+	return &service.OrderHolds{Shares: map[string]decimal.Decimal{"AAPL": decimal.NewFromInt(3)}}, m.err
+}
+
+func TestCreateOrder_Success(t *testing.T) {
+	orders := &mockOrderService{order: &data.Order{ID: "ord-1", Symbol: "AAPL", Status: data.OrderPending}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := httptest.NewRequest(http.MethodPost, "/orders",
+		bytes.NewBufferString(`{"symbol":"AAPL","side":"BUY","type":"LIMIT","quantity":5,"trigger_price":142.50,"time_in_force":"GTC","expires_at":"2030-01-02T21:00:00Z"}`))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.CreateOrder(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	got := orders.lastReq
+	if got.Side != "BUY" || got.Type != "LIMIT" || got.TimeInForce != "GTC" || !got.TriggerPrice.Equal(decimal.RequireFromString("142.5")) {
+		t.Errorf("service got %+v", got)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(time.Date(2030, 1, 2, 21, 0, 0, 0, time.UTC)) {
+		t.Errorf("expires_at: got %v", got.ExpiresAt)
+	}
+}
+
+func TestCreateOrder_InvalidQuantity(t *testing.T) {
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: &mockOrderService{}}
+	req := jsonReq(t, http.MethodPost, "/orders", CreateOrderRequest{Symbol: "AAPL", Type: "STOP_LOSS", Quantity: decimal.NewFromInt(0)})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.CreateOrder(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestWaitOrder(t *testing.T) {
+	orders := &mockOrderService{order: &data.Order{ID: "ord-1", Symbol: "AAPL", Status: data.OrderFilled}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/orders/ord-1/wait?timeout=20", nil), map[string]string{"id": "ord-1"})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.WaitOrder(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if orders.lastID != "ord-1" || orders.lastWait != 20*time.Second {
+		t.Errorf("service got id %q, timeout %v", orders.lastID, orders.lastWait)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/orders/ord-1/wait?timeout=soon", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w = httptest.NewRecorder()
+	h.WaitOrder(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad timeout: expected 400, got %d", w.Code)
+	}
+}
+
+func TestCreateOCOOrder_Success(t *testing.T) {
+	orders := &mockOrderService{pair: &service.OCOOrders{
+		GroupID:    "grp-1",
+		TakeProfit: &data.Order{ID: "ord-1", OrderType: data.OrderTypeTakeProfit, OCOGroupID: "grp-1"},
+		StopLoss:   &data.Order{ID: "ord-2", OrderType: data.OrderTypeStopLoss, OCOGroupID: "grp-1"},
+	}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := httptest.NewRequest(http.MethodPost, "/orders/oco",
+		bytes.NewBufferString(`{"symbol":"aapl","quantity":5,"take_profit":180,"stop_loss":140.25}`))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.CreateOCOOrder(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	got := orders.lastOCO
+	if got.Symbol != "AAPL" || !got.Quantity.Equal(decimal.NewFromInt(5)) || !got.TakeProfit.Equal(decimal.NewFromInt(180)) ||
+		!got.StopLoss.Equal(decimal.RequireFromString("140.25")) {
+		t.Errorf("service got %+v", got)
+	}
+	var resp service.OCOOrders
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.GroupID != "grp-1" || resp.StopLoss == nil || resp.StopLoss.ID != "ord-2" {
+		t.Errorf("response: got %s (%v)", w.Body.String(), err)
+	}
+}
+
+func TestCreateBracketOrder_Success(t *testing.T) {
+	orders := &mockOrderService{bracket: &service.BracketOrders{
+		Entry: &data.Order{ID: "ord-1", OrderType: data.OrderTypeLimit, Status: data.OrderPending},
+		OCOOrders: service.OCOOrders{
+			GroupID:    "grp-1",
+			TakeProfit: &data.Order{ID: "ord-2", OrderType: data.OrderTypeTakeProfit, Status: data.OrderWaiting, ParentOrderID: "ord-1"},
+			StopLoss:   &data.Order{ID: "ord-3", OrderType: data.OrderTypeStopLoss, Status: data.OrderWaiting, ParentOrderID: "ord-1"},
+		},
+	}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := httptest.NewRequest(http.MethodPost, "/orders/bracket",
+		bytes.NewBufferString(`{"symbol":"aapl","quantity":5,"entry_price":150,"take_profit":180,"stop_loss":140.25}`))
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.CreateBracketOrder(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	got := orders.lastBrkt
+	if got.Symbol != "AAPL" || got.EntryType != "" || !got.EntryPrice.Equal(decimal.NewFromInt(150)) ||
+		!got.TakeProfit.Equal(decimal.NewFromInt(180)) || !got.StopLoss.Equal(decimal.RequireFromString("140.25")) {
+		t.Errorf("service got %+v", got)
+	}
+	var resp service.BracketOrders
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Entry == nil || resp.Entry.ID != "ord-1" ||
+		resp.GroupID != "grp-1" || resp.StopLoss == nil || resp.StopLoss.ParentOrderID != "ord-1" {
+		t.Errorf("response: got %s (%v)", w.Body.String(), err)
+	}
+}
+
+func TestBuyStock_MarketClosedQueuesOrder(t *testing.T) {
+	nextOpen := time.Date(2026, 3, 9, 14, 30, 0, 0, time.UTC)
+	orders := &mockOrderService{order: &data.Order{ID: "ord-1", Symbol: "AAPL", OrderType: data.OrderTypeMarket, Status: data.OrderPending}}
+	svc := &mockInvestmentService{buyErr: &service.MarketClosedError{NextOpen: nextOpen, Queue: true}}
+	h := &InvestmentsHandler{service: svc, orders: orders}
+	req := jsonReq(t, http.MethodPost, "/buy", BuyStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(3)})
+	req.Header.Set("X-User-ID", "user-1")
+	req.Header.Set("Idempotency-Key", "key-1")
+	w := httptest.NewRecorder()
+	h.BuyStock(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := orders.lastReq; got.Side != "BUY" || got.Type != "MARKET" || !got.Quantity.Equal(decimal.NewFromInt(3)) || !got.TriggerPrice.IsZero() || got.IdempotencyKey != "key-1" {
+		t.Errorf("service got %+v", got)
+	}
+	var resp QueuedTradeResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || !resp.Queued || resp.Order == nil || !resp.NextOpen.Equal(nextOpen) {
+		t.Errorf("unexpected body %s (err %v)", w.Body.String(), err)
+	}
+}
+
+func TestSellStock_MarketClosedRejected(t *testing.T) {
+	orders := &mockOrderService{}
+	svc := &mockInvestmentService{sellErr: &service.MarketClosedError{NextOpen: time.Now().Add(time.Hour)}}
+	h := &InvestmentsHandler{service: svc, orders: orders}
+	req := jsonReq(t, http.MethodPost, "/sell", SellStockRequest{Symbol: "AAPL", Quantity: decimal.NewFromInt(3)})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.SellStock(w, req)
+
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "MARKET_CLOSED") {
+		t.Fatalf("expected 409 MARKET_CLOSED, got %d: %s", w.Code, w.Body.String())
+	}
+	if orders.lastReq.Type != "" {
+		t.Errorf("rejected trade should not be queued, got %+v", orders.lastReq)
+	}
+}
+
+func TestListOrders_PassesStatus(t *testing.T) {
+	orders := &mockOrderService{orders: []data.Order{}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := httptest.NewRequest(http.MethodGet, "/orders?status=PENDING", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.ListOrders(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if orders.lastStatus != "PENDING" {
+		t.Errorf("status: got %q", orders.lastStatus)
+	}
+	var resp OrderListResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Orders == nil {
+		t.Errorf("expected an orders array, got %s (err %v)", w.Body.String(), err)
+	}
+}
+
+func TestGetOrder_NotFound(t *testing.T) {
+	orders := &mockOrderService{err: &service.OrderNotFoundError{}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/orders/ord-1", nil), map[string]string{"id": "ord-1"})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetOrder(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+	if orders.lastID != "ord-1" {
+		t.Errorf("id: got %q", orders.lastID)
+	}
+}
+
+func TestCancelOrder_NotPending(t *testing.T) {
+	orders := &mockOrderService{err: &service.OrderNotPendingError{}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, orders: orders}
+	req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/orders/ord-1", nil), map[string]string{"id": "ord-1"})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.CancelOrder(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+	if orders.lastID != "ord-1" {
+		t.Errorf("id: got %q", orders.lastID)
+	}
+}
+
+// ---- Trade notes and search ----
+
+// mockNotesService implements TradeNotesServicer for handler tests.
+type mockNotesService struct {
+	results    []data.TradeSearchResult
+	total      int
+	err        error
+	lastQuery  string
+	lastFrom   time.Time
+	lastTo     time.Time
+	lastLimit  int
+	lastOffset int
+}
+
+func (m *mockNotesService) SetNote(_ context.Context, _, tradeID, note string, tags []string) (*data.TradeNote, error) {
+	return &data.TradeNote{TradeID: tradeID, Note: note, Tags: tags}, m.err
+}
+func (m *mockNotesService) DeleteNote(_ context.Context, _, _ string) error { return m.err }
+func (m *mockNotesService) Search(_ context.Context, _, query string, from, to time.Time, limit, offset int) ([]data.TradeSearchResult, int, error) {
+	m.lastQuery, m.lastFrom, m.lastTo, m.lastLimit, m.lastOffset = query, from, to, limit, offset
+	return m.results, m.total, m.err
+}
+
+func TestSearchTrades_DateRangeInclusive(t *testing.T) {
+	notes := &mockNotesService{results: []data.TradeSearchResult{}}
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, notes: notes}
+	req := httptest.NewRequest(http.MethodGet, "/search?q=nvda+earnings&from=2024-03-01&to=2024-05-31", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.SearchTrades(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if notes.lastQuery != "nvda earnings" || notes.lastLimit != defaultSearchLimit {
+		t.Errorf("service got q=%q limit=%d", notes.lastQuery, notes.lastLimit)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !notes.lastFrom.Equal(want) {
+		t.Errorf("from: got %v, want %v", notes.lastFrom, want)
+	}
+	if want := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC); !notes.lastTo.Equal(want) {
+		t.Errorf("to: got %v, want %v (end of the to day)", notes.lastTo, want)
+	}
+}
+
+func TestSearchTrades_InvalidParams(t *testing.T) {
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, notes: &mockNotesService{}}
+	for _, target := range []string{
+		"/search?q=nvda&from=last-spring",
+		"/search?q=nvda&limit=101",
+		"/search?q=nvda&offset=-1",
+	} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		h.SearchTrades(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+}
+
+func TestSetTradeNote_NotFound(t *testing.T) {
+	h := &InvestmentsHandler{service: &mockInvestmentService{}, notes: &mockNotesService{err: &service.TradeNotFoundError{}}}
+	req := mux.SetURLVars(jsonReq(t, http.MethodPut, "/trades/t-1/note", TradeNoteRequest{Note: "x"}), map[string]string{"id": "t-1"})
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.SetTradeNote(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
+
+type mockBenchmark struct {
+	rng, symbol string
+}
+
+func (m *mockBenchmark) Compare(_ context.Context, _, rng, symbol string) (*service.BenchmarkComparison, error) {
+	m.rng, m.symbol = rng, symbol
+	return &service.BenchmarkComparison{Range: "3M", Symbol: "QQQ", Points: []service.BenchmarkPoint{{
+		Date: "2026-10-15", PortfolioValue: decimal.NewFromInt(10000), PortfolioReturn: decimal.NewFromInt(4),
+		BenchmarkClose: decimal.NewFromInt(500), BenchmarkReturn: decimal.NewFromInt(3),
+	}}, RelativeReturn: decimal.NewFromInt(1)}, nil
+}
+
+func TestGetBenchmarkComparison(t *testing.T) {
+	benchmark := &mockBenchmark{}
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+	h := &InvestmentsHandler{benchmark: benchmark, fx: fx}
+
+	req := httptest.NewRequest(http.MethodGet, "/performance/vs-benchmark?range=3M&symbol=qqq", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetBenchmarkComparison(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	var got service.BenchmarkComparison
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if benchmark.rng != "3M" || benchmark.symbol != "qqq" {
+		t.Errorf("args: range %q, symbol %q", benchmark.rng, benchmark.symbol)
+	}
+	p := got.Points[0]
+	// Values convert; returns are unitless and do not.
+	if got.Currency != "EUR" || !p.PortfolioValue.Equal(decimal.NewFromInt(5000)) || !p.BenchmarkClose.Equal(decimal.NewFromInt(250)) || !p.PortfolioReturn.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("body: %+v", got)
+	}
+}
+
+type mockPortfolioValue struct{}
+
+func (mockPortfolioValue) Estimate(context.Context, string) (*service.PortfolioValue, error) {
+	prev, change := decimal.NewFromInt(9800), decimal.NewFromInt(200)
+	return &service.PortfolioValue{
+		Cash: decimal.NewFromInt(4000), HoldingsValue: decimal.NewFromInt(6000), TotalValue: decimal.NewFromInt(10000),
+		PreviousDate: "2026-10-15", PreviousValue: &prev, DayChange: &change,
+	}, nil
+}
+
+func TestGetPortfolioValue_Converts(t *testing.T) {
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+	h := &InvestmentsHandler{value: mockPortfolioValue{}, fx: fx}
+
+	req := httptest.NewRequest(http.MethodGet, "/value", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetPortfolioValue(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	var got service.PortfolioValue
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.Currency != "EUR" || !got.TotalValue.Equal(decimal.NewFromInt(5000)) ||
+		!got.PreviousValue.Equal(decimal.NewFromInt(4900)) || !got.DayChange.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("body: %+v", got)
+	}
+}
+
+type mockStats struct{}
+
+func (mockStats) Stats(context.Context, string) (*service.TradingStats, error) {
+	return &service.TradingStats{
+		ClosedTrades: 3, LongestWinningStreak: 2,
+		BestTrade:    &service.TradeHighlight{TradeID: "t1", Gain: decimal.NewFromInt(300)},
+		CurrentValue: decimal.NewFromInt(9000), PeakValue: decimal.NewFromInt(10000), Drawdown: decimal.NewFromInt(1000),
+		DrawdownPercent: decimal.NewFromInt(10),
+	}, nil
+}
+
+func TestGetStats_Converts(t *testing.T) {
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+	h := &InvestmentsHandler{stats: mockStats{}, fx: fx}
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetStats(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	var got service.TradingStats
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if got.Currency != "EUR" || !got.BestTrade.Gain.Equal(decimal.NewFromInt(150)) || got.WorstTrade != nil ||
+		!got.Drawdown.Equal(decimal.NewFromInt(500)) || !got.DrawdownPercent.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("body: %+v", got)
+	}
+}
+
+type mockReplay struct {
+	symbol string
+	days   int
+}
+
+func (m *mockReplay) Replay(_ context.Context, _, symbol string, days int) (*service.TradeReplay, error) {
+	m.symbol, m.days = symbol, days
+	return &service.TradeReplay{
+		Symbol: "AAPL",
+		Points: []service.HistoricalSeriesPoint{{Date: "2026-10-15", Close: decimal.NewFromInt(200)}},
+		Trades: []service.TradeMarker{{TradeID: "t1", Action: "BUY", Quantity: decimal.NewFromInt(3), Price: decimal.NewFromInt(190), Date: "2026-10-15"}},
+	}, nil
+}
+
+func TestGetReplay(t *testing.T) {
+	replay := &mockReplay{}
+	fx := &mockFX{rate: &service.FXRate{From: "USD", To: "EUR", Rate: decimal.RequireFromString("0.5")}}
+	h := &InvestmentsHandler{replay: replay, fx: fx}
+
+	for _, target := range []string{"/replay", "/replay?symbol=AAPL&days=abc"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-User-ID", "user-1")
+		w := httptest.NewRecorder()
+		h.GetReplay(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/replay?symbol=AAPL&days=30", nil)
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	h.GetReplay(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%s)", w.Code, w.Body.String())
+	}
+	var got service.TradeReplay
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if replay.symbol != "AAPL" || replay.days != 30 || got.Currency != "EUR" ||
+		!got.Points[0].Close.Equal(decimal.NewFromInt(100)) || !got.Trades[0].Price.Equal(decimal.NewFromInt(95)) {
+		t.Errorf("args %q/%d, body %+v", replay.symbol, replay.days, got)
+	}
+}
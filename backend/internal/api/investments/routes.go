@@ -1,7 +1,10 @@
 package investments
 
 import (
+	"net/http"
+
 	"papertrader/internal/api/auth"
+	"papertrader/internal/api/middleware"
 	"papertrader/internal/config"
 	"papertrader/internal/service"
 
@@ -10,14 +13,61 @@ import (
 
 // Mount attaches the investments routes to r. r should be a subrouter scoped to
 // a path prefix (e.g. /api/investments); routes are registered relative to it,
-// so "" matches the bare prefix and "/buy" matches prefix + "/buy".
-func Mount(r *mux.Router, h *InvestmentsHandler, jwtService *service.JWTService, cfg *config.Config) {
+// so "" matches the bare prefix and "/buy" matches prefix + "/buy". Routes
+// that place trades are held back for users who must verify their email
+// first.
+func Mount(r *mux.Router, h *InvestmentsHandler, jwtService *service.JWTService, verification auth.VerificationGuard, cfg *config.Config) {
 	r.StrictSlash(false)
 	r.Use(auth.JWTMiddleware(jwtService, cfg))
 
-	r.HandleFunc("/buy", h.BuyStock).Methods("POST")
-	r.HandleFunc("/sell", h.SellStock).Methods("POST")
-	r.HandleFunc("/history", h.GetTradeHistory).Methods("GET")
+	trades := auth.RequireVerifiedEmail(verification)
+	r.Handle("/buy", trades(http.HandlerFunc(h.BuyStock))).Methods("POST")
+	r.Handle("/sell", trades(http.HandlerFunc(h.SellStock))).Methods("POST")
+	r.Handle("/short", trades(http.HandlerFunc(h.ShortStock))).Methods("POST")
+	r.Handle("/cover", trades(http.HandlerFunc(h.CoverShort))).Methods("POST")
+	r.HandleFunc("/trades", h.GetTradeHistory).Methods("GET")
+	// CSV downloads. The trade export streams the whole history, so it is
+	// bounded by the stream timeout rather than buffered by the request one.
+	r.Handle("/trades/export", middleware.Streamed(cfg.StreamTimeout)(
+		middleware.ConcurrencyLimit("trade_export",
+			cfg.RateLimits.ExpensiveConcurrency, cfg.RateLimits.ExpensiveRetryAfter)(
+			http.HandlerFunc(h.ExportTrades)))).Methods("GET")
+	r.HandleFunc("/export", h.ExportHoldings).Methods("GET")
+	// Daily portfolio value with ?range=; otherwise the original trades path.
+	r.HandleFunc("/history", h.GetHistory).Methods("GET")
+	r.HandleFunc("/value", h.GetPortfolioValue).Methods("GET")
+	r.HandleFunc("/stats", h.GetStats).Methods("GET")
+	r.HandleFunc("/replay", h.GetReplay).Methods("GET")
+	// Prices the portfolio and the benchmark over the whole range.
+	r.Handle("/performance/vs-benchmark", middleware.ConcurrencyLimit("benchmark_comparison",
+		cfg.RateLimits.ExpensiveConcurrency, cfg.RateLimits.ExpensiveRetryAfter)(
+		http.HandlerFunc(h.GetBenchmarkComparison))).Methods("GET")
+	// Prices the benchmark over the whole range, like the comparison.
+	r.Handle("/risk", middleware.ConcurrencyLimit("risk_metrics",
+		cfg.RateLimits.ExpensiveConcurrency, cfg.RateLimits.ExpensiveRetryAfter)(
+		http.HandlerFunc(h.GetRisk))).Methods("GET")
+	r.HandleFunc("/search", h.SearchTrades).Methods("GET")
+	r.HandleFunc("/pnl", h.GetPnL).Methods("GET")
+	r.HandleFunc("/lots", h.GetLots).Methods("GET")
+	r.HandleFunc("/trades/{id}/note", h.SetTradeNote).Methods("PUT")
+	r.HandleFunc("/trades/{id}/note", h.DeleteTradeNote).Methods("DELETE")
+	r.HandleFunc("/trades/{id}/dispute", h.DisputeTrade).Methods("POST")
+	r.HandleFunc("/disputes", h.ListDisputes).Methods("GET")
+	r.Handle("/orders", trades(http.HandlerFunc(h.CreateOrder))).Methods("POST")
+	r.HandleFunc("/orders", h.ListOrders).Methods("GET")
+	r.Handle("/orders/oco", trades(http.HandlerFunc(h.CreateOCOOrder))).Methods("POST")
+	r.Handle("/orders/bracket", trades(http.HandlerFunc(h.CreateBracketOrder))).Methods("POST")
+	r.HandleFunc("/orders/{id}", h.GetOrder).Methods("GET")
+	// Long-polls until the order closes; idle while it waits, so it is
+	// exempt from the request timeout like the live price streams.
+	r.Handle("/orders/{id}/wait", middleware.LongLived(http.HandlerFunc(h.WaitOrder))).Methods("GET")
+	r.HandleFunc("/orders/{id}", h.CancelOrder).Methods("DELETE")
+	// Previews the orders that reach target weights, or places them.
+	r.Handle("/rebalance", trades(http.HandlerFunc(h.Rebalance))).Methods("POST")
+	r.Handle("/recurring", trades(http.HandlerFunc(h.CreateRecurringInvestment))).Methods("POST")
+	r.HandleFunc("/recurring", h.ListRecurringInvestments).Methods("GET")
+	r.HandleFunc("/recurring/{id}", h.UpdateRecurringInvestment).Methods("PUT")
+	r.HandleFunc("/recurring/{id}", h.DeleteRecurringInvestment).Methods("DELETE")
 	r.HandleFunc("", h.GetUserStocks).Methods("GET")
 	r.HandleFunc("/", h.GetUserStocks).Methods("GET")
 }
@@ -0,0 +1,233 @@
+package market
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
+
+// mockMarket implements MarketServicer, recording the symbol asked for.
+type mockMarket struct{ symbol string }
+
+func (m *mockMarket) GetStock(_ context.Context, symbol string) (*service.StockData, error) {
+	m.symbol = symbol
+	return &service.StockData{Symbol: symbol, Price: decimal.NewFromInt(100)}, nil
+}
+func (m *mockMarket) GetHistoricalData(_ context.Context, symbol string) (*service.HistoricalData, error) {
+	m.symbol = symbol
+	return &service.HistoricalData{Symbol: symbol}, nil
+}
+func (m *mockMarket) GetBatchHistoricalData(context.Context, []string) (map[string]*service.HistoricalData, error) {
+	return nil, nil
+}
+func (m *mockMarket) GetHistoricalSeries(_ context.Context, symbol string, days int) (*service.HistoricalSeries, error) {
+	m.symbol = symbol
+	return &service.HistoricalSeries{Symbol: symbol}, nil
+}
+func (m *mockMarket) GetChart(_ context.Context, symbol, chartRange string) (*service.Chart, error) {
+	m.symbol = symbol
+	return &service.Chart{Symbol: symbol, Range: chartRange}, nil
+}
+
+func (m *mockMarket) GetCompanyProfile(_ context.Context, symbol string) (*service.CompanyProfile, error) {
+	m.symbol = symbol
+	return &service.CompanyProfile{Symbol: symbol, Name: "Apple Inc", Industry: "Consumer Electronics"}, nil
+}
+
+func (m *mockMarket) SearchSymbols(context.Context, string, int) ([]service.SymbolMatch, error) {
+	return nil, nil
+}
+
+type mockRecent struct{}
+
+func (mockRecent) Record(context.Context, string, string) {}
+func (mockRecent) List(context.Context, string) ([]service.RecentlyViewed, error) {
+	return nil, nil
+}
+
+type mockFX struct{}
+
+func (mockFX) Convert(context.Context, decimal.Decimal, string, string) (*service.FXConversion, error) {
+	return nil, nil
+}
+func (mockFX) DisplayRate(context.Context, string, string) (*service.FXRate, error) {
+	return &service.FXRate{From: "USD", To: "USD", Rate: decimal.NewFromInt(1)}, nil
+}
+
+func TestSymbolParam_PathOrQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name, target, path string
+		handler            func(h *StockHandler) http.HandlerFunc
+		want               string
+		code               int
+	}{
+		{"query", "/stock?symbol=MSFT", "", func(h *StockHandler) http.HandlerFunc { return h.GetStock }, "MSFT", http.StatusOK},
+		{"path", "/stock/AAPL", "AAPL", func(h *StockHandler) http.HandlerFunc { return h.GetStock }, "AAPL", http.StatusOK},
+		{"both agree", "/stock/AAPL?symbol=aapl", "AAPL", func(h *StockHandler) http.HandlerFunc { return h.GetStock }, "AAPL", http.StatusOK},
+		{"both disagree", "/stock/AAPL?symbol=MSFT", "AAPL", func(h *StockHandler) http.HandlerFunc { return h.GetStock }, "", http.StatusBadRequest},
+		{"daily", "/stock/TSLA/historical/daily", "TSLA", func(h *StockHandler) http.HandlerFunc { return h.GetStockHistoricalDataDaily }, "TSLA", http.StatusOK},
+		{"series", "/stock/NVDA/historical/series?days=5", "NVDA", func(h *StockHandler) http.HandlerFunc { return h.GetStockHistoricalSeries }, "NVDA", http.StatusOK},
+		{"chart", "/stock/chart?symbol=AMD&range=1Y", "", func(h *StockHandler) http.HandlerFunc { return h.GetStockChart }, "AMD", http.StatusOK},
+		{"company", "/company/META", "META", func(h *StockHandler) http.HandlerFunc { return h.GetCompany }, "META", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			market := &mockMarket{}
+			h := NewStockHandler(market, mockRecent{}, mockFX{}, nil, nil)
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			if tc.path != "" {
+				req = mux.SetURLVars(req, map[string]string{"symbol": tc.path})
+			}
+			w := httptest.NewRecorder()
+			tc.handler(h)(w, req)
+
+			if w.Code != tc.code {
+				t.Fatalf("expected %d, got %d: %s", tc.code, w.Code, w.Body.String())
+			}
+			if market.symbol != tc.want {
+				t.Errorf("symbol: got %q, want %q", market.symbol, tc.want)
+			}
+		})
+	}
+}
+
+type fixedPrecision service.Precision
+
+func (p fixedPrecision) Precision(context.Context, string) service.Precision {
+	return service.Precision(p)
+}
+
+func TestGetStock_PriceDecimals(t *testing.T) {
+	h := NewStockHandler(&mockMarket{}, mockRecent{}, mockFX{}, nil, nil)
+	h.SetPrecision(fixedPrecision{TickSize: decimal.NewFromInt(1), Decimals: 0})
+	w := httptest.NewRecorder()
+	h.GetStock(w, httptest.NewRequest(http.MethodGet, "/stock?symbol=BRK.A", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data service.StockData `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Data.PriceDecimals == nil || *resp.Data.PriceDecimals != 0 {
+		t.Errorf("price_decimals: got %v, want 0", resp.Data.PriceDecimals)
+	}
+}
+
+func (m *mockMarket) WarmQuotes(_ context.Context, symbols []string) (int, error) {
+	return 0, nil
+}
+
+// mockClassifications implements ClassificationServicer with one symbol.
+type mockClassifications struct{}
+
+func (mockClassifications) Get(_ context.Context, symbol string) (*data.Classification, error) {
+	if symbol != "AAPL" {
+		return nil, &service.ClassificationNotFoundError{}
+	}
+	return &data.Classification{Symbol: "AAPL", Sector: "Information Technology", Industry: "Technology Hardware"}, nil
+}
+func (mockClassifications) IndexMembers(context.Context, string) ([]data.Classification, error) {
+	return nil, nil
+}
+func (mockClassifications) SectorMembers(context.Context, string) ([]data.Classification, error) {
+	return nil, nil
+}
+
+func TestGetCompany_FillsBlankSectorFromClassification(t *testing.T) {
+	h := NewStockHandler(&mockMarket{}, mockRecent{}, mockFX{}, nil, mockClassifications{})
+	w := httptest.NewRecorder()
+	h.GetCompany(w, httptest.NewRequest(http.MethodGet, "/company?symbol=AAPL", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data service.CompanyProfile `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// The provider's industry is kept; only the blank sector is filled.
+	if resp.Data.Sector != "Information Technology" || resp.Data.Industry != "Consumer Electronics" {
+		t.Errorf("got sector %q, industry %q", resp.Data.Sector, resp.Data.Industry)
+	}
+}
+
+// mockMovers implements MoversServicer, recording the limit asked for.
+type mockMovers struct{ limit int }
+
+func (m *mockMovers) Movers(_ context.Context, limit int) (*service.MarketMovers, error) {
+	m.limit = limit
+	return &service.MarketMovers{}, nil
+}
+
+func TestGetMovers_Limit(t *testing.T) {
+	for _, c := range []struct {
+		query     string
+		wantCode  int
+		wantLimit int
+	}{
+		{"", http.StatusOK, service.DefaultMoversLimit},
+		{"?limit=5", http.StatusOK, 5},
+		{"?limit=five", http.StatusBadRequest, 0},
+	} {
+		movers := &mockMovers{}
+		h := NewStockHandler(&mockMarket{}, mockRecent{}, mockFX{}, nil, nil)
+		h.SetMovers(movers)
+		w := httptest.NewRecorder()
+		h.GetMovers(w, httptest.NewRequest(http.MethodGet, "/movers"+c.query, nil))
+		if w.Code != c.wantCode || movers.limit != c.wantLimit {
+			t.Errorf("%q: got %d with limit %d, want %d with limit %d", c.query, w.Code, movers.limit, c.wantCode, c.wantLimit)
+		}
+	}
+}
+
+// mockNews implements NewsServicer, recording what it was asked for.
+type mockNews struct {
+	symbol string
+	limit  int
+}
+
+func (m *mockNews) News(_ context.Context, symbol string, limit int) (*service.NewsFeed, error) {
+	m.symbol, m.limit = symbol, limit
+	return &service.NewsFeed{Symbol: symbol}, nil
+}
+
+func TestGetNews(t *testing.T) {
+	h := NewStockHandler(&mockMarket{}, mockRecent{}, mockFX{}, nil, nil)
+	w := httptest.NewRecorder()
+	h.GetNews(w, httptest.NewRequest(http.MethodGet, "/news", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a provider: got %d, want 503", w.Code)
+	}
+
+	for _, c := range []struct {
+		query      string
+		wantCode   int
+		wantSymbol string
+		wantLimit  int
+	}{
+		{"", http.StatusOK, "", service.DefaultNewsLimit},
+		{"?symbol=AAPL&limit=5", http.StatusOK, "AAPL", 5},
+		{"?symbol=AAPL&limit=five", http.StatusBadRequest, "", 0},
+	} {
+		news := &mockNews{}
+		h.SetNews(news)
+		w := httptest.NewRecorder()
+		h.GetNews(w, httptest.NewRequest(http.MethodGet, "/news"+c.query, nil))
+		if w.Code != c.wantCode || news.symbol != c.wantSymbol || news.limit != c.wantLimit {
+			t.Errorf("%q: got %d with %q, %d", c.query, w.Code, news.symbol, news.limit)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package market
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"papertrader/internal/service"
+	"papertrader/internal/util"
+)
+
+const (
+	// liveHeartbeat is how often an idle socket gets a heartbeat event, so
+	// proxies keep it open while the market is closed and prices don't move.
+	liveHeartbeat = 30 * time.Second
+	// liveWriteTimeout drops a client that can't take an event this fast.
+	liveWriteTimeout = 10 * time.Second
+	// liveMaxMessage caps one client message; a subscribe is a few symbols.
+	liveMaxMessage = 4 << 10
+)
+
+var errLiveOrigin = errors.New("websocket origin not allowed")
+
+// LivePricer is the subset of service.PriceHub used by LiveHandler.
+type LivePricer interface {
+	Subscribe() (*service.PriceSubscription, error)
+}
+
+// LiveHandler serves live prices to connected clients.
+type LiveHandler struct {
+	hub    LivePricer
+	origin string
+}
+
+// NewLiveHandler builds the handler. frontendURL is the only browser origin
+// allowed to open a socket, since the socket authenticates with the session
+// cookie and GETs are not covered by OriginCheck.
+func NewLiveHandler(hub LivePricer, frontendURL string) *LiveHandler {
+	return &LiveHandler{hub: hub, origin: strings.TrimRight(frontendURL, "/")}
+}
+
+// LiveRequest is a message from the client: action "subscribe" or
+// "unsubscribe" with the symbols to add or drop.
+type LiveRequest struct {
+	Action  string   `json:"action"`
+	Symbols []string `json:"symbols"`
+}
+
+// LiveEvent is a message to the client. Type is "price" with Data,
+// "portfolio" with Value, "subscribed" with every watched Symbols, "error"
+// with Message, or "heartbeat".
+type LiveEvent struct {
+	Type    string                  `json:"type"`
+	Data    *service.StockData      `json:"data,omitempty"`
+	Value   *service.PortfolioValue `json:"value,omitempty"`
+	Symbols []string                `json:"symbols,omitempty"`
+	Message string                  `json:"message,omitempty"`
+}
+
+// subscribe opens a subscription for a live request, watching
+// ?symbols=AAPL,MSFT and, with ?portfolio=true, the caller's account value.
+// It returns the last known prices of the symbols. On failure it writes the
+// error response and returns ok=false; otherwise the caller must Close the
+// subscription.
+func (h *LiveHandler) subscribe(w http.ResponseWriter, r *http.Request) (sub *service.PriceSubscription, initial []*service.StockData, ok bool) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+	q := r.URL.Query()
+	portfolio := false
+	if raw := q.Get("portfolio"); raw != "" {
+		var err error
+		if portfolio, err = strconv.ParseBool(raw); err != nil {
+			util.WriteServiceError(w, &util.ValidationError{Field: "portfolio", Message: "must be true or false"})
+			return nil, nil, false
+		}
+	}
+
+	sub, err := h.hub.Subscribe()
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return nil, nil, false
+	}
+	if raw := q.Get("symbols"); raw != "" {
+		if initial, err = sub.Add(strings.Split(raw, ",")); err != nil {
+			sub.Close()
+			util.WriteServiceError(w, err)
+			return nil, nil, false
+		}
+	}
+	if portfolio {
+		sub.WatchPortfolio(userID)
+	}
+	return sub, initial, true
+}
+
+// PriceSocket handles GET /api/market/ws: a WebSocket that pushes the price
+// of each watched symbol when it changes. Symbols may be given up front as
+// ?symbols=AAPL,MSFT and changed with subscribe and unsubscribe messages;
+// ?portfolio=true adds the caller's account value. Errors before the
+// upgrade (a bad symbol, the hub at capacity) are plain HTTP responses.
+func (h *LiveHandler) PriceSocket(w http.ResponseWriter, r *http.Request) {
+	sub, initial, ok := h.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	server := websocket.Server{
+		Handshake: h.checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			ws.MaxPayloadBytes = liveMaxMessage
+			h.serveSocket(ws, sub, initial)
+		},
+	}
+	server.ServeHTTP(hijacker{w}, r)
+}
+
+// checkOrigin accepts the frontend's origin, or none: clients outside a
+// browser don't send one and authenticate with a bearer token.
+func (h *LiveHandler) checkOrigin(_ *websocket.Config, r *http.Request) error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || strings.TrimRight(origin, "/") == h.origin {
+		return nil
+	}
+	return errLiveOrigin
+}
+
+func (h *LiveHandler) serveSocket(ws *websocket.Conn, sub *service.PriceSubscription, initial []*service.StockData) {
+	var mu sync.Mutex
+	send := func(ev LiveEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		return websocket.JSON.Send(ws, ev)
+	}
+
+	// Read client messages until it leaves; that ends the subscription,
+	// and with it the loop below.
+	go func() {
+		defer sub.Close()
+		for {
+			var req LiveRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			if err := h.handleRequest(sub, req, send); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, q := range initial {
+		if send(LiveEvent{Type: "price", Data: q}) != nil {
+			return
+		}
+	}
+	if send(LiveEvent{Type: "subscribed", Symbols: sub.Symbols()}) != nil {
+		return
+	}
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-sub.Done():
+			return
+		case q := <-sub.Updates():
+			err = send(LiveEvent{Type: "price", Data: q})
+		case v := <-sub.Values():
+			err = send(LiveEvent{Type: "portfolio", Value: v})
+		case <-heartbeat.C:
+			err = send(LiveEvent{Type: "heartbeat"})
+		}
+		if err != nil {
+			slog.Debug("live socket closed", "err", err, "component", "live")
+			return
+		}
+	}
+}
+
+// handleRequest applies one client message, answering it with the watched
+// symbols or an error event. It returns an error only if the answer could
+// not be sent.
+func (h *LiveHandler) handleRequest(sub *service.PriceSubscription, req LiveRequest, send func(LiveEvent) error) error {
+	switch req.Action {
+	case "subscribe":
+		known, err := sub.Add(req.Symbols)
+		if err != nil {
+			msg, _, _ := util.MapServiceError(err)
+			return send(LiveEvent{Type: "error", Message: msg})
+		}
+		for _, q := range known {
+			if err := send(LiveEvent{Type: "price", Data: q}); err != nil {
+				return err
+			}
+		}
+	case "unsubscribe":
+		sub.Remove(req.Symbols)
+	default:
+		return send(LiveEvent{Type: "error", Message: "action must be subscribe or unsubscribe"})
+	}
+	return send(LiveEvent{Type: "subscribed", Symbols: sub.Symbols()})
+}
+
+// PriceStream handles GET /api/market/stream: the same updates as
+// PriceSocket as Server-Sent Events, for clients that can't use a
+// WebSocket. The symbols and portfolio are fixed by the query; to change
+// them, reconnect. Each event is named for its LiveEvent type and carries
+// the LiveEvent as data, as on the socket; heartbeats are comments.
+func (h *LiveHandler) PriceStream(w http.ResponseWriter, r *http.Request) {
+	sub, initial, ok := h.subscribe(w, r)
+	if !ok {
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	// Tell a buffering reverse proxy (nginx) to pass events straight on.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	// send writes one event. The connection's write deadline, which would
+	// otherwise be the server's, is moved past each write as it is made.
+	send := func(ev LiveEvent) error {
+		rc.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+		if ev.Type == "heartbeat" {
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	for _, q := range initial {
+		if send(LiveEvent{Type: "price", Data: q}) != nil {
+			return
+		}
+	}
+	if send(LiveEvent{Type: "subscribed", Symbols: sub.Symbols()}) != nil {
+		return
+	}
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case q := <-sub.Updates():
+			err = send(LiveEvent{Type: "price", Data: q})
+		case v := <-sub.Values():
+			err = send(LiveEvent{Type: "portfolio", Value: v})
+		case <-heartbeat.C:
+			err = send(LiveEvent{Type: "heartbeat"})
+		}
+		if err != nil {
+			slog.Debug("live stream closed", "err", err, "component", "live")
+			return
+		}
+	}
+}
+
+// hijacker exposes the connection beneath the middleware's response
+// writers, which websocket.Server looks for with a type assertion.
+type hijacker struct{ http.ResponseWriter }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
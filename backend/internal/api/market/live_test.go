@@ -0,0 +1,121 @@
+package market
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"papertrader/internal/service"
+)
+
+// liveServer serves PriceStream at /stream paths and PriceSocket elsewhere,
+// as an authenticated user, as JWTMiddleware would.
+func liveServer(t *testing.T, hub LivePricer) *httptest.Server {
+	t.Helper()
+	h := NewLiveHandler(hub, "http://app.example")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("X-User-ID", "user-1")
+		if strings.HasSuffix(r.URL.Path, "/stream") {
+			h.PriceStream(w, r)
+			return
+		}
+		h.PriceSocket(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPriceSocket(t *testing.T) {
+	hub := service.NewPriceHub(&mockMarket{}, nil, time.Hour, 5, 5)
+	srv := liveServer(t, hub)
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/market/ws?symbols=AAPL"
+
+	if _, err := websocket.Dial(url, "", "http://evil.example"); err == nil {
+		t.Error("a foreign origin was allowed to connect")
+	}
+
+	ws, err := websocket.Dial(url, "", "http://app.example")
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var ev LiveEvent
+	if err := websocket.JSON.Receive(ws, &ev); err != nil || ev.Type != "subscribed" || len(ev.Symbols) != 1 {
+		t.Fatalf("first event: %+v, %v", ev, err)
+	}
+	if err := websocket.JSON.Send(ws, LiveRequest{Action: "subscribe", Symbols: []string{"msft"}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if err := websocket.JSON.Receive(ws, &ev); err != nil || strings.Join(ev.Symbols, ",") != "AAPL,MSFT" {
+		t.Errorf("after subscribe: %+v, %v", ev, err)
+	}
+	websocket.JSON.Send(ws, LiveRequest{Action: "sell"})
+	if err := websocket.JSON.Receive(ws, &ev); err != nil || ev.Type != "error" {
+		t.Errorf("unknown action: %+v, %v", ev, err)
+	}
+}
+
+func TestPriceSocket_BadSymbolBeforeUpgrade(t *testing.T) {
+	hub := service.NewPriceHub(&mockMarket{}, nil, time.Hour, 5, 5)
+	srv := liveServer(t, hub)
+	resp, err := http.Get(srv.URL + "/api/market/ws?symbols=AAPL,not-a-symbol!")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("got %d, want 400", resp.StatusCode)
+	}
+	if hub.Clients() != 0 {
+		t.Errorf("rejected request left %d subscriptions", hub.Clients())
+	}
+}
+
+func TestPriceStream(t *testing.T) {
+	hub := service.NewPriceHub(&mockMarket{}, nil, 10*time.Millisecond, 5, 5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go hub.Run(ctx)
+	srv := liveServer(t, hub)
+
+	resp, err := http.Get(srv.URL + "/api/market/stream?symbols=AAPL")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("content type %q", ct)
+	}
+
+	// Read events until the first price arrives from a refresh.
+	lines := bufio.NewScanner(resp.Body)
+	var events []string
+	for lines.Scan() && len(events) < 2 {
+		if line, ok := strings.CutPrefix(lines.Text(), "event: "); ok {
+			events = append(events, line)
+			if !lines.Scan() || !strings.HasPrefix(lines.Text(), `data: {"type":"`+line+`"`) {
+				t.Fatalf("%s event data: %q", line, lines.Text())
+			}
+		}
+	}
+	if strings.Join(events, ",") != "subscribed,price" {
+		t.Errorf("events: %v", events)
+	}
+
+	resp2, err := http.Get(srv.URL + "/api/market/stream?portfolio=maybe")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp2.Body.Close()
+	if resp2.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad portfolio flag: got %d, want 400", resp2.StatusCode)
+	}
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // Mount attaches market routes to r (a subrouter, e.g. /api/market).
-func Mount(r *mux.Router, h *StockHandler, jwtService *service.JWTService, rateLimiter service.RateLimiter, cfg *config.Config) {
+func Mount(r *mux.Router, h *StockHandler, live *LiveHandler, jwtService *service.JWTService, rateLimiter service.RateLimiter, cfg *config.Config) {
 	r.Use(auth.JWTMiddleware(jwtService, cfg))
 
 	// Rate-limit per-symbol endpoints; the batch endpoint is exempt because it
@@ -35,6 +35,34 @@ func Mount(r *mux.Router, h *StockHandler, jwtService *service.JWTService, rateL
 
 	r.HandleFunc("/stock", h.GetStock).Methods("GET")
 	r.HandleFunc("/stock/historical/daily", h.GetStockHistoricalDataDaily).Methods("GET")
-	r.HandleFunc("/stock/historical/daily/batch", h.GetBatchHistoricalDataDaily).Methods("GET")
+	// The batch endpoint skips the rate limit but fans out to up to 15
+	// symbols, so bound how many run at once instead.
+	r.Handle("/stock/historical/daily/batch", middleware.ConcurrencyLimit("batch_history",
+		cfg.RateLimits.ExpensiveConcurrency, cfg.RateLimits.ExpensiveRetryAfter)(
+		http.HandlerFunc(h.GetBatchHistoricalDataDaily))).Methods("GET")
 	r.HandleFunc("/stock/historical/series", h.GetStockHistoricalSeries).Methods("GET")
+	// Registered before /stock/{symbol} so "chart" isn't taken for a symbol.
+	r.HandleFunc("/stock/chart", h.GetStockChart).Methods("GET")
+	// Path-style equivalents of the ?symbol= routes above, so each symbol's
+	// data has a URL of its own (see StockHandler.symbolParam).
+	r.HandleFunc("/stock/{symbol}", h.GetStock).Methods("GET")
+	r.HandleFunc("/stock/{symbol}/historical/daily", h.GetStockHistoricalDataDaily).Methods("GET")
+	r.HandleFunc("/stock/{symbol}/historical/series", h.GetStockHistoricalSeries).Methods("GET")
+	r.HandleFunc("/stock/{symbol}/chart", h.GetStockChart).Methods("GET")
+	r.HandleFunc("/recent", h.GetRecentlyViewed).Methods("GET")
+	r.HandleFunc("/convert", h.ConvertCurrency).Methods("GET")
+	r.HandleFunc("/hours", h.GetMarketHours).Methods("GET")
+	r.HandleFunc("/company", h.GetCompany).Methods("GET")
+	r.HandleFunc("/company/{symbol}", h.GetCompany).Methods("GET")
+	r.HandleFunc("/search", h.SearchSymbols).Methods("GET")
+	r.HandleFunc("/movers", h.GetMovers).Methods("GET")
+	r.HandleFunc("/news", h.GetNews).Methods("GET")
+	r.HandleFunc("/classification", h.GetClassification).Methods("GET")
+	r.HandleFunc("/classification/{symbol}", h.GetClassification).Methods("GET")
+	r.HandleFunc("/classifications", h.ListClassifications).Methods("GET")
+	// Live prices over a WebSocket, or as Server-Sent Events for clients
+	// without one. The connection stays open for as long as the client
+	// does, so it is exempt from the request timeout.
+	r.Handle("/ws", middleware.LongLived(http.HandlerFunc(live.PriceSocket))).Methods("GET")
+	r.Handle("/stream", middleware.LongLived(http.HandlerFunc(live.PriceStream))).Methods("GET")
 }
@@ -9,6 +9,10 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/gorilla/mux"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
 	"papertrader/internal/service"
 	"papertrader/internal/util"
 )
@@ -22,14 +26,87 @@ type MarketServicer interface {
 	GetHistoricalData(ctx context.Context, symbol string) (*service.HistoricalData, error)
 	GetBatchHistoricalData(ctx context.Context, symbols []string) (map[string]*service.HistoricalData, error)
 	GetHistoricalSeries(ctx context.Context, symbol string, days int) (*service.HistoricalSeries, error)
+	GetChart(ctx context.Context, symbol, chartRange string) (*service.Chart, error)
+	GetCompanyProfile(ctx context.Context, symbol string) (*service.CompanyProfile, error)
+	SearchSymbols(ctx context.Context, query string, limit int) ([]service.SymbolMatch, error)
+}
+
+// RecentlyViewedServicer is the subset of service.RecentlyViewedService used
+// by StockHandler.
+type RecentlyViewedServicer interface {
+	Record(ctx context.Context, userID, symbol string)
+	List(ctx context.Context, userID string) ([]service.RecentlyViewed, error)
+}
+
+// CurrencyServicer is the subset of service.FXService used by StockHandler.
+type CurrencyServicer interface {
+	Convert(ctx context.Context, amount decimal.Decimal, from, to string) (*service.FXConversion, error)
+	DisplayRate(ctx context.Context, userID, requested string) (*service.FXRate, error)
+}
+
+// MarketHoursServicer is the subset of service.MarketHours used by
+// StockHandler.
+type MarketHoursServicer interface {
+	Status() service.MarketStatus
+	ExchangeStatus(mic string) (service.MarketStatus, error)
+}
+
+// ClassificationServicer is the subset of service.ClassificationService used
+// by StockHandler.
+type ClassificationServicer interface {
+	Get(ctx context.Context, symbol string) (*data.Classification, error)
+	IndexMembers(ctx context.Context, code string) ([]data.Classification, error)
+	SectorMembers(ctx context.Context, sector string) ([]data.Classification, error)
+}
+
+// PrecisionServicer is the subset of service.InstrumentService used by
+// StockHandler.
+type PrecisionServicer interface {
+	Precision(ctx context.Context, symbol string) service.Precision
+}
+
+// MoversServicer is the subset of service.MoversService used by
+// StockHandler.
+type MoversServicer interface {
+	Movers(ctx context.Context, limit int) (*service.MarketMovers, error)
+}
+
+// NewsServicer is the subset of service.NewsService used by StockHandler.
+type NewsServicer interface {
+	News(ctx context.Context, symbol string, limit int) (*service.NewsFeed, error)
 }
 
 type StockHandler struct {
-	service MarketServicer
+	service         MarketServicer
+	recent          RecentlyViewedServicer
+	fx              CurrencyServicer
+	hours           MarketHoursServicer
+	classifications ClassificationServicer
+	precision       PrecisionServicer
+	movers          MoversServicer
+	news            NewsServicer
 }
 
-func NewStockHandler(s MarketServicer) *StockHandler {
-	return &StockHandler{service: s}
+func NewStockHandler(s MarketServicer, recent RecentlyViewedServicer, fx CurrencyServicer, hours MarketHoursServicer, classifications ClassificationServicer) *StockHandler {
+	return &StockHandler{service: s, recent: recent, fx: fx, hours: hours, classifications: classifications}
+}
+
+// SetPrecision sets where quotes look up each symbol's price precision;
+// without one every quote is shown on the default penny grid.
+func (h *StockHandler) SetPrecision(p PrecisionServicer) {
+	h.precision = p
+}
+
+// SetMovers sets where GET /movers gets its ranking; without one the
+// endpoint answers 503.
+func (h *StockHandler) SetMovers(m MoversServicer) {
+	h.movers = m
+}
+
+// SetNews sets where GET /news gets its headlines; without one the endpoint
+// answers 503.
+func (h *StockHandler) SetNews(n NewsServicer) {
+	h.news = n
 }
 
 // Helpers
@@ -56,10 +133,33 @@ func (h *StockHandler) writeErrorResponse(w http.ResponseWriter, statusCode int,
 	h.writeJSONResponse(w, statusCode, response)
 }
 
+// symbolParam returns the symbol a per-symbol endpoint was asked for: the
+// {symbol} path segment of /stock/{symbol}-style routes or ?symbol=. Either
+// may be used; if both are, they must agree (ignoring case), otherwise a 400
+// is written and ok is false. A missing symbol is left to the service to
+// reject, as it is for the query style.
+func (h *StockHandler) symbolParam(w http.ResponseWriter, r *http.Request) (symbol string, ok bool) {
+	path, query := mux.Vars(r)["symbol"], r.URL.Query().Get("symbol")
+	if path != "" && query != "" && !strings.EqualFold(strings.TrimSpace(path), strings.TrimSpace(query)) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "symbol in the path and the query do not match")
+		return "", false
+	}
+	if path != "" {
+		return path, true
+	}
+	return query, true
+}
+
 // Handler Methods
 
+// GetStock returns the latest quote for /stock/{symbol} or ?symbol=, priced
+// in ?display_currency= or, without it, the caller's saved display currency.
 func (h *StockHandler) GetStock(w http.ResponseWriter, r *http.Request) {
-	symbol := r.URL.Query().Get("symbol")
+	symbol, ok := h.symbolParam(w, r)
+	if !ok {
+		return
+	}
+	userID := r.Header.Get("X-User-ID")
 
 	data, err := h.service.GetStock(r.Context(), symbol)
 	if err != nil {
@@ -68,11 +168,204 @@ func (h *StockHandler) GetStock(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.writeSuccessResponse(w, http.StatusOK, "Stock data retrieved successfully", data)
+	rate, err := h.fx.DisplayRate(r.Context(), userID, r.URL.Query().Get("display_currency"))
+	if err != nil {
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+
+	// Only a successful quote counts as a view; data.Symbol is the
+	// normalised form, so "aapl" and "AAPL" share one entry.
+	h.recent.Record(r.Context(), userID, data.Symbol)
+
+	prec := service.DefaultPrecision
+	if h.precision != nil {
+		prec = h.precision.Precision(r.Context(), data.Symbol)
+	}
+	prec = prec.At(data.Price)
+
+	// Copy before converting: the quote may be shared with the cache.
+	quote := *data
+	quote.Price = rate.ApplyPrice(quote.Price, prec.Decimals)
+	quote.Currency = rate.To
+	quote.PriceDecimals = &prec.Decimals
+	h.writeSuccessResponse(w, http.StatusOK, "Stock data retrieved successfully", &quote)
+}
+
+// ConvertCurrency handles GET /convert?amount=&from=&to=. from defaults to
+// USD, the currency prices are quoted in.
+func (h *StockHandler) ConvertCurrency(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	amount, err := decimal.NewFromString(q.Get("amount"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, "amount must be a number")
+		return
+	}
+	if q.Get("to") == "" {
+		h.writeErrorResponse(w, http.StatusBadRequest, "to parameter is required")
+		return
+	}
+	from := q.Get("from")
+	if from == "" {
+		from = service.BaseCurrency
+	}
+
+	conversion, err := h.fx.Convert(r.Context(), amount, from, q.Get("to"))
+	if err != nil {
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+
+	h.writeSuccessResponse(w, http.StatusOK, "Amount converted", conversion)
+}
+
+// GetMarketHours reports whether the market is open and the current or next
+// session's open and close: the NYSE's, or with ?exchange= the named
+// exchange's (XLON, XTSE).
+func (h *StockHandler) GetMarketHours(w http.ResponseWriter, r *http.Request) {
+	mic := r.URL.Query().Get("exchange")
+	if mic == "" {
+		h.writeSuccessResponse(w, http.StatusOK, "Market hours retrieved", h.hours.Status())
+		return
+	}
+	status, err := h.hours.ExchangeStatus(mic)
+	if err != nil {
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	h.writeSuccessResponse(w, http.StatusOK, "Market hours retrieved", status)
+}
+
+// GetMovers handles GET /api/market/movers?limit=: the day's top gainers,
+// losers and most active symbols, limit of each (default 10, at most 25).
+func (h *StockHandler) GetMovers(w http.ResponseWriter, r *http.Request) {
+	if h.movers == nil {
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "Market movers are unavailable")
+		return
+	}
+	limit := service.DefaultMoversLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	movers, err := h.movers.Movers(r.Context(), limit)
+	if err != nil {
+		slog.Warn("GetMovers failed", "err", err)
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	h.writeSuccessResponse(w, http.StatusOK, "Market movers retrieved", movers)
+}
+
+// GetNews handles GET /api/market/news?symbol=&limit=: the latest headlines
+// about symbol, or general market news without one, limit of them (default
+// 20, at most 50).
+func (h *StockHandler) GetNews(w http.ResponseWriter, r *http.Request) {
+	if h.news == nil {
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "News is unavailable")
+		return
+	}
+	q := r.URL.Query()
+	limit := service.DefaultNewsLimit
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	feed, err := h.news.News(r.Context(), q.Get("symbol"), limit)
+	if err != nil {
+		slog.Warn("GetNews failed", "symbol", q.Get("symbol"), "err", err)
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	h.writeSuccessResponse(w, http.StatusOK, "News retrieved", feed)
+}
+
+// GetClassification handles GET /classification/{symbol} or
+// /classification?symbol=: the symbol's sector, industry and index
+// memberships.
+func (h *StockHandler) GetClassification(w http.ResponseWriter, r *http.Request) {
+	symbol, ok := h.symbolParam(w, r)
+	if !ok {
+		return
+	}
+	c, err := h.classifications.Get(r.Context(), symbol)
+	if err != nil {
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	h.writeSuccessResponse(w, http.StatusOK, "Classification retrieved", c)
 }
 
+// ListClassifications handles GET /classifications?index= or ?sector=: the
+// members of one index (DJI, SPX, NDX) or one GICS sector. Exactly one
+// filter is required.
+func (h *StockHandler) ListClassifications(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	index, sector := q.Get("index"), q.Get("sector")
+	if (index == "") == (sector == "") {
+		h.writeErrorResponse(w, http.StatusBadRequest, "exactly one of index or sector is required")
+		return
+	}
+
+	var items []data.Classification
+	var err error
+	if index != "" {
+		items, err = h.classifications.IndexMembers(r.Context(), index)
+	} else {
+		items, err = h.classifications.SectorMembers(r.Context(), sector)
+	}
+	if err != nil {
+		slog.Warn("ListClassifications failed", "index", index, "sector", sector, "err", err)
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	h.writeSuccessResponse(w, http.StatusOK, fmt.Sprintf("%d classifications retrieved", len(items)), items)
+}
+
+// GetRecentlyViewed returns the symbols the caller has looked up via GetStock,
+// most recent first. The list is kept server-side, so it is the same on every
+// device.
+func (h *StockHandler) GetRecentlyViewed(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		h.writeErrorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	recent, err := h.recent.List(r.Context(), userID)
+	if err != nil {
+		slog.Warn("GetRecentlyViewed failed", "user_id", userID, "err", err)
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to load recently viewed symbols")
+		return
+	}
+
+	h.writeSuccessResponse(w, http.StatusOK, "Recently viewed symbols retrieved", recent)
+}
+
+// GetStockHistoricalDataDaily returns the latest daily bar for
+// /stock/{symbol}/historical/daily or /stock/historical/daily?symbol=.
 func (h *StockHandler) GetStockHistoricalDataDaily(w http.ResponseWriter, r *http.Request) {
-	symbol := r.URL.Query().Get("symbol")
+	symbol, ok := h.symbolParam(w, r)
+	if !ok {
+		return
+	}
 
 	data, err := h.service.GetHistoricalData(r.Context(), symbol)
 	if err != nil {
@@ -86,9 +379,13 @@ func (h *StockHandler) GetStockHistoricalDataDaily(w http.ResponseWriter, r *htt
 }
 
 // GetStockHistoricalSeries returns a daily-close time series for one symbol.
-// Reads ?symbol= and an optional ?days= (default 90, clamped to MaxHistoricalSeriesDays).
+// Reads the symbol (see symbolParam) and an optional ?days= (default 90,
+// clamped to MaxHistoricalSeriesDays).
 func (h *StockHandler) GetStockHistoricalSeries(w http.ResponseWriter, r *http.Request) {
-	symbol := r.URL.Query().Get("symbol")
+	symbol, ok := h.symbolParam(w, r)
+	if !ok {
+		return
+	}
 	days := 0
 	if raw := r.URL.Query().Get("days"); raw != "" {
 		parsed, err := strconv.Atoi(raw)
@@ -110,6 +407,84 @@ func (h *StockHandler) GetStockHistoricalSeries(w http.ResponseWriter, r *http.R
 	h.writeSuccessResponse(w, http.StatusOK, "Historical series retrieved", data)
 }
 
+// GetStockChart handles GET /stock/chart?symbol=&range= (or
+// /stock/{symbol}/chart): OHLCV candles for a candlestick chart over one of
+// 1D, 1W, 1M, 3M, 1Y or 5Y.
+func (h *StockHandler) GetStockChart(w http.ResponseWriter, r *http.Request) {
+	symbol, ok := h.symbolParam(w, r)
+	if !ok {
+		return
+	}
+	chartRange := r.URL.Query().Get("range")
+
+	chart, err := h.service.GetChart(r.Context(), symbol, chartRange)
+	if err != nil {
+		slog.Warn("GetStockChart failed", "symbol", symbol, "range", chartRange, "err", err)
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+
+	h.writeSuccessResponse(w, http.StatusOK, "Chart retrieved", chart)
+}
+
+// GetCompany handles GET /company?symbol= (or /company/{symbol}): name,
+// exchange, sector, industry, market cap and description. A sector or
+// industry the provider leaves blank is filled from the symbol's
+// classification, when it has one.
+func (h *StockHandler) GetCompany(w http.ResponseWriter, r *http.Request) {
+	symbol, ok := h.symbolParam(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := h.service.GetCompanyProfile(r.Context(), symbol)
+	if err != nil {
+		slog.Warn("GetCompany failed", "symbol", symbol, "err", err)
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	if (profile.Sector == "" || profile.Industry == "") && h.classifications != nil {
+		if c, err := h.classifications.Get(r.Context(), profile.Symbol); err == nil {
+			filled := *profile
+			if filled.Sector == "" {
+				filled.Sector = c.Sector
+			}
+			if filled.Industry == "" {
+				filled.Industry = c.Industry
+			}
+			profile = &filled
+		}
+	}
+
+	h.writeSuccessResponse(w, http.StatusOK, "Company profile retrieved", profile)
+}
+
+// SearchSymbols handles GET /api/market/search?q=&limit=: listings whose
+// symbol or company name matches q, for symbol lookup as the user types.
+func (h *StockHandler) SearchSymbols(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			h.writeErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	matches, err := h.service.SearchSymbols(r.Context(), q.Get("q"), limit)
+	if err != nil {
+		slog.Warn("SearchSymbols failed", "query", q.Get("q"), "err", err)
+		userMessage, statusCode, _ := util.MapServiceError(err)
+		h.writeErrorResponse(w, statusCode, userMessage)
+		return
+	}
+	h.writeSuccessResponse(w, http.StatusOK, "Symbols found", matches)
+}
+
 // GetBatchHistoricalDataDaily handles batch requests for multiple stock symbols
 func (h *StockHandler) GetBatchHistoricalDataDaily(w http.ResponseWriter, r *http.Request) {
 	// Get symbols from query parameter (comma-separated)
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"papertrader/internal/service"
+)
+
+// ClientInfo attaches the caller's IP and, when countryHeader is set, its
+// country to the request context (see service.ClientInfoFromContext).
+//
+// countryHeader must name a header the reverse proxy always overwrites (e.g.
+// Cloudflare's CF-IPCountry); otherwise clients can forge it. Values that are
+// not two letters — including Cloudflare's "XX" and "T1" sentinels — are
+// treated as unknown.
+func ClientInfo(countryHeader string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := service.ClientInfo{IP: getIPAddress(r)}
+			if countryHeader != "" {
+				info.Country = normalizeCountry(r.Header.Get(countryHeader))
+			}
+			next.ServeHTTP(w, r.WithContext(service.WithClientInfo(r.Context(), info)))
+		})
+	}
+}
+
+func normalizeCountry(v string) string {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	if len(v) != 2 || v == "XX" || v[0] < 'A' || v[0] > 'Z' || v[1] < 'A' || v[1] > 'Z' {
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ConcurrencyLimit caps how many requests to one expensive route run at
+// once, for endpoints that fan out to the market data provider. A request
+// arriving while max are in progress is not queued: it gets 429 with
+// Retry-After, so a burst can't tie up the HTTP client, the provider quota
+// or the CPU. Call it once per route; each call has its own semaphore.
+// max <= 0 disables the cap.
+func ConcurrencyLimit(route string, max int, retryAfter time.Duration) func(http.Handler) http.Handler {
+	if max <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	slots := make(chan struct{}, max)
+	retry := strconv.Itoa(int(retryAfter.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			default:
+				slog.Info("route at concurrency limit; rejecting", "route", route, "limit", max, "component", "concurrency_limit")
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Retry-After", retry)
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success":    false,
+					"message":    "Too many of these requests are in progress. Please try again shortly.",
+					"error_code": "CONCURRENCY_LIMIT",
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	h := ConcurrencyLimit("test", 1, 2*time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/batch", nil))
+		return w
+	}
+
+	done := make(chan int)
+	go func() { done <- serve().Code }()
+	<-entered
+
+	// The only slot is taken.
+	w := serve()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Errorf("saturated: got %d, Retry-After %q; want 429, 2", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("first request: got %d, want 200", code)
+	}
+	// The slot is free again.
+	go func() { <-entered }()
+	if w := serve(); w.Code != http.StatusOK {
+		t.Errorf("after release: got %d, want 200", w.Code)
+	}
+}
+
+func TestConcurrencyLimit_Disabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := ConcurrencyLimit("test", 0, time.Second)(next)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/batch", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("disabled: got %d, want 200", w.Code)
+	}
+}
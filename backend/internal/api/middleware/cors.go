@@ -25,7 +25,7 @@ func CORS(allowedOrigin string) func(http.Handler) http.Handler {
 			}
 			w.Header().Add("Vary", "Origin")
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, X-Sudo-Token, X-CSRF-Token")
 
 			if r.Method == http.MethodOptions {
 				w.WriteHeader(http.StatusNoContent)
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+)
+
+// CSRF token names. The cookie is readable by the frontend's JavaScript;
+// the header is what it sends the token back in.
+const (
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// csrfTokenBytes is the entropy of a token: 32 bytes, 43 characters
+// base64url-encoded.
+const csrfTokenBytes = 32
+
+type csrfContextKey struct{}
+
+// CSRF is a double-submit token check on state-changing requests, behind
+// OriginCheck. Every response to a browser without a token cookie sets one;
+// POST / PUT / PATCH / DELETE must echo the cookie in the X-CSRF-Token
+// header or are rejected with 403. A cross-site page can make the browser
+// send the cookie but cannot read it, so it cannot forge the header —
+// which still holds where Origin is missing or spoofable and the
+// Sec-Fetch-Site fallback in OriginCheck is all there is.
+//
+// The cookie is not HttpOnly, by design. It is Secure behind HTTPS or when
+// production is set, like the session cookie.
+func CSRF(production bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sent := ""
+			if c, err := r.Cookie(CSRFCookieName); err == nil && validCSRFToken(c.Value) {
+				sent = c.Value
+			}
+			token := sent
+			if token == "" {
+				token = newCSRFToken()
+				http.SetCookie(w, &http.Cookie{
+					Name:     CSRFCookieName,
+					Value:    token,
+					Path:     "/",
+					Secure:   production || r.Header.Get("X-Forwarded-Proto") == "https",
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+			r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, token))
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			header := r.Header.Get(CSRFHeaderName)
+			if sent != "" && subtle.ConstantTimeCompare([]byte(header), []byte(sent)) == 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"success":    false,
+				"message":    "missing or invalid CSRF token",
+				"error_code": "CSRF_TOKEN_INVALID",
+			})
+		})
+	}
+}
+
+// CSRFToken handles GET /api/csrf, returning the caller's token (and, via
+// CSRF, setting the cookie if it had none) for a frontend about to make its
+// first state-changing request.
+func CSRFToken(w http.ResponseWriter, r *http.Request) {
+	token, _ := r.Context().Value(csrfContextKey{}).(string)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]string{"csrf_token": token})
+}
+
+func newCSRFToken() string {
+	b := make([]byte, csrfTokenBytes)
+	rand.Read(b) // never fails since Go 1.24
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// validCSRFToken reports whether s has the shape newCSRFToken produces, so a
+// malformed cookie is replaced rather than echoed.
+func validCSRFToken(s string) bool {
+	if len(s) != base64.RawURLEncoding.EncodedLen(csrfTokenBytes) {
+		return false
+	}
+	_, err := base64.RawURLEncoding.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCSRF_IssuesTokenAndChecksItOnPost(t *testing.T) {
+	h := CSRF(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/csrf" {
+			CSRFToken(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, path string, cookie, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: cookie})
+		}
+		if header != "" {
+			req.Header.Set(CSRFHeaderName, header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	// A first GET sets the cookie and reports the same token.
+	w := serve(http.MethodGet, "/csrf", "", "")
+	var body struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("GET /csrf: got %d, %v", w.Code, err)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CSRFCookieName || cookies[0].HttpOnly || cookies[0].Value != body.CSRFToken {
+		t.Fatalf("cookie: got %+v, token %q", cookies, body.CSRFToken)
+	}
+	token := body.CSRFToken
+
+	// Later requests keep it.
+	if w := serve(http.MethodGet, "/portfolio", token, ""); len(w.Result().Cookies()) != 0 {
+		t.Errorf("GET with a token cookie set another: %v", w.Result().Cookies())
+	}
+
+	for name, tc := range map[string]struct {
+		cookie, header string
+		want           int
+	}{
+		"matching header":  {token, token, http.StatusOK},
+		"no header":        {token, "", http.StatusForbidden},
+		"wrong header":     {token, token[:42] + "A", http.StatusForbidden},
+		"no cookie":        {"", token, http.StatusForbidden},
+		"malformed cookie": {"forged", "forged", http.StatusForbidden},
+		"neither":          {"", "", http.StatusForbidden},
+	} {
+		if w := serve(http.MethodPost, "/investments/buy", tc.cookie, tc.header); w.Code != tc.want {
+			t.Errorf("%s: got %d, want %d", name, w.Code, tc.want)
+		}
+	}
+}
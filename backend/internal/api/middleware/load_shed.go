@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"papertrader/internal/config"
+)
+
+// LoadShed turns away low-priority requests while the server is overloaded,
+// so that trading and auth keep the capacity a small instance has. It is
+// overloaded while cfg.MaxInFlight requests are in progress or
+// cfg.DBPoolPct percent of the Postgres pool is in use; pool may be nil to
+// watch in-flight requests only. A request is low priority when its path
+// starts with one of cfg.LowPriorityPaths, compared case-insensitively
+// because the config loader upper-cases list entries. Shed requests get
+// 503 with Retry-After and never reach the handler; everything else is
+// always served.
+//
+// Register it outside the timeout middleware, so in-flight counts requests
+// until their handler returns rather than until the timeout fires. Routes
+// marked LongLived are shed like any other but never counted.
+func LoadShed(cfg config.LoadShedConfig, pool func() sql.DBStats) func(http.Handler) http.Handler {
+	var inFlight atomic.Int64
+	var shedding atomic.Bool
+	retryAfter := strconv.Itoa(int(cfg.RetryAfter.Seconds()))
+
+	overloaded := func(n int64) (bool, string) {
+		if cfg.MaxInFlight > 0 && n > int64(cfg.MaxInFlight) {
+			return true, "in_flight"
+		}
+		if cfg.DBPoolPct > 0 && pool != nil {
+			// MaxOpenConnections is 0 when the pool is unbounded.
+			if s := pool(); s.MaxOpenConnections > 0 && s.InUse*100 >= s.MaxOpenConnections*cfg.DBPoolPct {
+				return true, "db_pool"
+			}
+		}
+		return false, ""
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A long-lived connection is idle most of its life; counting it
+			// would let a few hundred open streams shed everything else.
+			var n int64
+			if _, ok := routeHandler(r).(longLived); ok {
+				n = inFlight.Load()
+			} else {
+				n = inFlight.Add(1)
+				defer inFlight.Add(-1)
+			}
+
+			if lowPriority(r.URL.Path, cfg.LowPriorityPaths) {
+				over, reason := overloaded(n)
+				// Log the transitions, not every shed request.
+				if over && !shedding.Swap(true) {
+					slog.Warn("overloaded; shedding low-priority requests", "reason", reason, "in_flight", n, "component", "load_shed")
+				} else if !over && shedding.Swap(false) {
+					slog.Info("load back to normal; no longer shedding", "in_flight", n, "component", "load_shed")
+				}
+				if over {
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Retry-After", retryAfter)
+					w.WriteHeader(http.StatusServiceUnavailable)
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"success":    false,
+						"message":    "Server is busy. Please try again shortly.",
+						"error_code": "OVERLOADED",
+					})
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func lowPriority(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"papertrader/internal/config"
+)
+
+func TestLoadShed(t *testing.T) {
+	cfg := config.LoadShedConfig{
+		MaxInFlight:      1,
+		DBPoolPct:        90,
+		RetryAfter:       5 * time.Second,
+		LowPriorityPaths: []string{"/api/market/"},
+	}
+	var stats sql.DBStats
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	h := LoadShed(cfg, func() sql.DBStats { return stats })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/investments/buy" {
+			entered <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := serve("/api/market/stock/AAPL"); w.Code != http.StatusOK {
+		t.Fatalf("idle: got %d, want 200", w.Code)
+	}
+
+	// One request in flight fills the budget: market requests are shed,
+	// trading requests still served.
+	done := make(chan struct{})
+	go func() { serve("/api/investments/buy"); close(done) }()
+	<-entered
+	w := serve("/api/market/stock/AAPL")
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+		t.Errorf("in-flight limit: got %d, Retry-After %q; want 503, 5", w.Code, w.Header().Get("Retry-After"))
+	}
+	if w := serve("/api/account/login"); w.Code != http.StatusOK {
+		t.Errorf("auth while overloaded: got %d, want 200", w.Code)
+	}
+	close(release)
+	<-done
+
+	// A saturated pool sheds on its own.
+	stats = sql.DBStats{MaxOpenConnections: 10, InUse: 9}
+	if w := serve("/api/market/stock/AAPL"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("pool saturated: got %d, want 503", w.Code)
+	}
+	// Prefixes match case-sensitively, as URL paths do.
+	if w := serve("/API/MARKET/stock/AAPL"); w.Code != http.StatusOK {
+		t.Errorf("other-case path: got %d, want 200", w.Code)
+	}
+	stats = sql.DBStats{MaxOpenConnections: 10, InUse: 8}
+	if w := serve("/api/market/stock/AAPL"); w.Code != http.StatusOK {
+		t.Errorf("pool recovered: got %d, want 200", w.Code)
+	}
+}
+
+func TestLoadShed_LongLivedNotCounted(t *testing.T) {
+	cfg := config.LoadShedConfig{MaxInFlight: 1, RetryAfter: 5 * time.Second, LowPriorityPaths: []string{"/api/market/"}}
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	router := mux.NewRouter()
+	router.Use(LoadShed(cfg, nil))
+	router.Handle("/api/market/ws", LongLived(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})))
+	router.HandleFunc("/api/market/stock", func(w http.ResponseWriter, r *http.Request) {})
+	serve := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	// Two open sockets, with a budget of one, leave room for a request.
+	done := make(chan struct{})
+	for range 2 {
+		go func() { serve("/api/market/ws"); done <- struct{}{} }()
+		<-entered
+	}
+	if code := serve("/api/market/stock"); code != http.StatusOK {
+		t.Errorf("with sockets open: got %d, want 200", code)
+	}
+	close(release)
+	<-done
+	<-done
+}
@@ -27,6 +27,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the connection's writer, so a
+// streamed response can still flush through the wrapper.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // RequestLogger returns a Gorilla Mux-compatible middleware that:
 //   - generates a unique request_id (UUID v4) per request,
 //   - stores it in the request context (retrieve with RequestIDFromContext),
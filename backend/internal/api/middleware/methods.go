@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// routedMethods are the methods probed for when building an Allow header,
+// in the order they are listed.
+var routedMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// Methods wraps router so a request to a routed path with the wrong method
+// gets the same answer on every subrouter. Left alone, mux answers 404 or a
+// bare 405 depending on which subrouter the path fell through, and skips
+// the router's middleware either way, so not even CORS headers are set.
+//
+//   - HEAD is served as GET, without the body, wherever GET is routed.
+//   - OPTIONS gets 204 with an Allow header, through cors so a preflight
+//     sees the same CORS headers as the route itself.
+//   - Any other method gets 405 METHOD_NOT_ALLOWED with an Allow header,
+//     also through cors so the frontend can read it.
+//
+// Paths with no routes at all are left to router, as are methods a route
+// handles itself (such as a route registered for OPTIONS).
+func Methods(router *mux.Router, cors func(http.Handler) http.Handler) http.Handler {
+	notAllowed := cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":    false,
+			"message":    "Method " + r.Method + " is not allowed on this path",
+			"error_code": "METHOD_NOT_ALLOWED",
+		})
+	}))
+	// cors answers OPTIONS itself; this only runs if it ever stops doing so.
+	preflight := cors(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if routes(router, r, r.Method) {
+			router.ServeHTTP(w, r)
+			return
+		}
+		allowed := allowedMethods(router, r)
+		if len(allowed) == 0 {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodHead:
+			if routes(router, r, http.MethodGet) {
+				get := r.Clone(r.Context())
+				get.Method = http.MethodGet
+				router.ServeHTTP(headWriter{w}, get)
+				return
+			}
+		case http.MethodOptions:
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			preflight.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		notAllowed.ServeHTTP(w, r)
+	})
+}
+
+// routes reports whether router has a route for r's path and method.
+func routes(router *mux.Router, r *http.Request, method string) bool {
+	probe := r
+	if r.Method != method {
+		probe = r.Clone(r.Context())
+		probe.Method = method
+	}
+	var match mux.RouteMatch
+	return router.Match(probe, &match) && match.MatchErr == nil
+}
+
+// allowedMethods lists the methods router has routes for on r's path, with
+// HEAD and OPTIONS (both answered by Methods) once there is any. It is
+// empty for a path with no routes.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	get := false
+	for _, method := range routedMethods {
+		switch {
+		case method == http.MethodHead && get, method == http.MethodOptions && len(allowed) > 0:
+			allowed = append(allowed, method)
+		case routes(router, r, method):
+			allowed = append(allowed, method)
+			get = get || method == http.MethodGet
+		}
+	}
+	return allowed
+}
+
+// headWriter drops the body of a GET answered for a HEAD request, keeping
+// its status and headers.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w headWriter) Write(b []byte) (int, error) {
+	return io.Discard.Write(b)
+}
+
+func (w headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
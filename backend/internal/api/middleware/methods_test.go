@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// methodsRouter mirrors main's layout: routes spread over several
+// subrouters, which is where mux's own answers disagree.
+func methodsRouter() http.Handler {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("body")) }
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api").Subrouter()
+	account := api.PathPrefix("/account").Subrouter()
+	account.HandleFunc("/profile", ok).Methods("GET")
+	account.HandleFunc("/avatar", ok).Methods("PUT")
+	account.HandleFunc("/avatar", ok).Methods("DELETE")
+	market := api.PathPrefix("/market").Subrouter()
+	market.HandleFunc("/stock", ok).Methods("GET")
+	return Methods(router, CORS(allowed))
+}
+
+func TestMethods(t *testing.T) {
+	h := methodsRouter()
+	for _, tc := range []struct {
+		method, path string
+		code         int
+		allow, body  string
+	}{
+		{http.MethodGet, "/api/account/profile", http.StatusOK, "", "body"},
+		{http.MethodHead, "/api/account/profile", http.StatusOK, "", ""},
+		{http.MethodPost, "/api/account/profile", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS", ""},
+		{http.MethodPost, "/api/market/stock", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS", ""},
+		{http.MethodGet, "/api/account/avatar", http.StatusMethodNotAllowed, "PUT, DELETE, OPTIONS", ""},
+		{http.MethodOptions, "/api/account/avatar", http.StatusNoContent, "PUT, DELETE, OPTIONS", ""},
+		{http.MethodPost, "/api/account/nope", http.StatusNotFound, "", ""},
+		{http.MethodOptions, "/api/nope", http.StatusNotFound, "", ""},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("Origin", allowed)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		name := tc.method + " " + tc.path
+		if w.Code != tc.code {
+			t.Errorf("%s: got %d, want %d", name, w.Code, tc.code)
+		}
+		if got := w.Header().Get("Allow"); got != tc.allow {
+			t.Errorf("%s: Allow %q, want %q", name, got, tc.allow)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s: body %q, want %q", name, w.Body.String(), tc.body)
+		}
+		if tc.method == http.MethodHead && w.Body.Len() != 0 {
+			t.Errorf("%s: HEAD response has a body", name)
+		}
+		if tc.code == http.StatusMethodNotAllowed || tc.code == http.StatusNoContent {
+			if w.Header().Get("Access-Control-Allow-Origin") != allowed {
+				t.Errorf("%s: missing CORS headers", name)
+			}
+		}
+	}
+}
@@ -1,13 +1,31 @@
 package middleware
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
 
 // RequestSizeLimitMiddleware caps request body size to defend against memory
 // exhaustion. Handlers that read the body get an error from the underlying
 // MaxBytesReader once the cap is exceeded.
+//
+// Bodies are never decompressed, so one sent with a Content-Encoding other
+// than identity is refused with 415 rather than handed to a JSON decoder as
+// bytes it can't read; the cap applies to what arrives on the wire.
 func RequestSizeLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if enc := r.Header.Get("Content-Encoding"); enc != "" && !strings.EqualFold(enc, "identity") {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"success":    false,
+					"message":    "Compressed request bodies are not accepted",
+					"error_code": "UNSUPPORTED_CONTENT_ENCODING",
+				})
+				return
+			}
 			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 			next.ServeHTTP(w, r)
 		})
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestSizeLimit_RejectsCompressedBodies(t *testing.T) {
+	h := RequestSizeLimitMiddleware(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+	for _, tc := range []struct {
+		encoding, body string
+		code           int
+	}{
+		{"", "{}", http.StatusOK},
+		{"identity", "{}", http.StatusOK},
+		{"gzip", "{}", http.StatusUnsupportedMediaType},
+		{"", "0123456789", http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/buy", strings.NewReader(tc.body))
+		if tc.encoding != "" {
+			req.Header.Set("Content-Encoding", tc.encoding)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%q %q: got %d, want %d", tc.encoding, tc.body, w.Code, tc.code)
+		}
+	}
+}
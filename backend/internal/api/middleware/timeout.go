@@ -1,14 +1,72 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
 // RequestTimeoutMiddleware wraps handlers with a timeout that returns a 503
-// "Request timeout exceeded" once the deadline elapses.
+// "Request timeout exceeded" once the deadline elapses. http.TimeoutHandler
+// holds the whole response in memory until the handler returns, so routes
+// mounted with Streamed or LongLived skip it and bound themselves instead.
 func RequestTimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		return http.TimeoutHandler(next, timeout, "Request timeout exceeded")
+		buffered := http.TimeoutHandler(next, timeout, "Request timeout exceeded")
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch routeHandler(r).(type) {
+			case streamed, longLived:
+				next.ServeHTTP(w, r)
+			default:
+				buffered.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// streamed is a route handler marked by Streamed.
+type streamed struct {
+	next    http.Handler
+	timeout time.Duration
+}
+
+// Streamed marks a route whose response is flushed as it is written (see
+// util.Stream), such as an export. RequestTimeoutMiddleware lets its writes
+// through unbuffered; the handler runs with a context deadline of timeout
+// instead, and may write for that long past the server's write timeout.
+// Wrap the route's handler with it outermost, so the router sees the mark.
+func Streamed(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return streamed{next: next, timeout: timeout}
+	}
+}
+
+func (s streamed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+	defer cancel()
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(s.timeout))
+	s.next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// longLived is a route handler marked by LongLived.
+type longLived struct{ http.Handler }
+
+// LongLived marks a route that holds its connection open to push updates,
+// such as a WebSocket. RequestTimeoutMiddleware passes it through with no
+// deadline, and LoadShed doesn't count it in flight, since it sits idle
+// between updates; the handler ends the connection itself. Wrap the route's
+// handler with it outermost, as for Streamed.
+func LongLived(next http.Handler) http.Handler {
+	return longLived{next}
+}
+
+// routeHandler is the handler registered for r's matched route, before the
+// router's middleware, or nil outside a route.
+func routeHandler(r *http.Request) http.Handler {
+	if route := mux.CurrentRoute(r); route != nil {
+		return route.GetHandler()
 	}
+	return nil
 }
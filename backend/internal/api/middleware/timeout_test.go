@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequestTimeout_StreamedRoutesFlush(t *testing.T) {
+	// Reports whether the handler could flush and had a deadline.
+	probe := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flushed := http.NewResponseController(w).Flush() == nil
+		_, deadline := r.Context().Deadline()
+		if flushed && deadline {
+			w.Write([]byte("streamed"))
+			return
+		}
+		w.Write([]byte("buffered"))
+	})
+	router := mux.NewRouter()
+	router.Use(RequestTimeoutMiddleware(time.Second))
+	api := router.PathPrefix("/api").Subrouter()
+	api.Handle("/export", Streamed(time.Minute)(probe)).Methods("GET")
+	api.Handle("/list", probe).Methods("GET")
+
+	for path, want := range map[string]string{"/api/export": "streamed", "/api/list": "buffered"} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Body.String() != want {
+			t.Errorf("%s: got %q, want %q", path, w.Body.String(), want)
+		}
+	}
+}
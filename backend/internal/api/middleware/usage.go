@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"papertrader/internal/service"
+)
+
+// UsageRecorder is the subset of service.UsageService used by TrackUsage.
+type UsageRecorder interface {
+	Record(ctx context.Context, metric, userID string)
+}
+
+// TrackUsage counts every authenticated request against its user for
+// GET /api/account/usage: all requests, those rejected with 429, and market
+// data requests.
+//
+// It runs globally, before routing, so the user is only known after the
+// handler returns: JWTMiddleware sets X-User-ID on the request's header map,
+// which this middleware shares. Anonymous requests are not counted.
+func TrackUsage(recorder UsageRecorder) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			userID := r.Header.Get("X-User-ID")
+			if userID == "" {
+				return
+			}
+			// The request context may already be cancelled (timeout, client
+			// gone); the count should land regardless.
+			ctx := context.WithoutCancel(r.Context())
+			recorder.Record(ctx, service.UsageRequests, userID)
+			if wrapped.status == http.StatusTooManyRequests {
+				recorder.Record(ctx, service.UsageThrottled, userID)
+			}
+			if strings.HasPrefix(r.URL.Path, "/api/market/") {
+				recorder.Record(ctx, service.UsageMarketData, userID)
+			}
+		})
+	}
+}
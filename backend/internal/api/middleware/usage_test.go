@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"papertrader/internal/service"
+)
+
+type recordedUsage struct{ metric, userID string }
+
+type fakeRecorder struct{ got []recordedUsage }
+
+func (f *fakeRecorder) Record(_ context.Context, metric, userID string) {
+	f.got = append(f.got, recordedUsage{metric, userID})
+}
+
+// authenticate mimics JWTMiddleware, which sets X-User-ID on the shared
+// header map during the request.
+func authenticate(status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("X-User-ID", "user-1")
+		w.WriteHeader(status)
+	})
+}
+
+func TestTrackUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		handler http.Handler
+		want    []recordedUsage
+	}{
+		{
+			name:    "anonymous request is not counted",
+			path:    "/api/account/login",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		},
+		{
+			name:    "authenticated request",
+			path:    "/api/investments",
+			handler: authenticate(http.StatusOK),
+			want:    []recordedUsage{{service.UsageRequests, "user-1"}},
+		},
+		{
+			name:    "throttled market request",
+			path:    "/api/market/stock",
+			handler: authenticate(http.StatusTooManyRequests),
+			want: []recordedUsage{
+				{service.UsageRequests, "user-1"},
+				{service.UsageThrottled, "user-1"},
+				{service.UsageMarketData, "user-1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &fakeRecorder{}
+			h := TrackUsage(rec)(tt.handler)
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if len(rec.got) != len(tt.want) {
+				t.Fatalf("recorded %v, want %v", rec.got, tt.want)
+			}
+			for i := range tt.want {
+				if rec.got[i] != tt.want[i] {
+					t.Errorf("recorded %v, want %v", rec.got, tt.want)
+				}
+			}
+		})
+	}
+}
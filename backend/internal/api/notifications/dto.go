@@ -0,0 +1,8 @@
+package notifications
+
+import "papertrader/internal/data"
+
+type ListResponse struct {
+	Items       []data.Notification `json:"items"`
+	UnreadCount int                 `json:"unread_count"`
+}
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+type NotificationServicer interface {
+	List(ctx context.Context, userID string, unreadOnly bool, limit int) ([]data.Notification, int, error)
+	MarkRead(ctx context.Context, userID, id string) error
+	MarkAllRead(ctx context.Context, userID string) error
+}
+
+type NotificationsHandler struct {
+	service NotificationServicer
+}
+
+func NewNotificationsHandler(s NotificationServicer) *NotificationsHandler {
+	return &NotificationsHandler{service: s}
+}
+
+// List handles GET /api/notifications?unread=true&limit=N.
+func (h *NotificationsHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	unreadOnly := q.Get("unread") == "true"
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			util.WriteSafeError(w, http.StatusBadRequest, "limit must be a positive integer", err, "INVALID_REQUEST")
+			return
+		}
+		limit = n
+	}
+
+	items, unread, err := h.service.List(r.Context(), userID, unreadOnly, limit)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ListResponse{Items: items, UnreadCount: unread})
+}
+
+// MarkRead handles POST /api/notifications/{id}/read.
+func (h *NotificationsHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.MarkRead(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, data.ErrNotificationNotFound) {
+			util.WriteSafeError(w, http.StatusNotFound, "Notification not found", err, "NOTIFICATION_NOT_FOUND")
+			return
+		}
+		util.WriteServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllRead handles POST /api/notifications/read-all.
+func (h *NotificationsHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.MarkAllRead(r.Context(), userID); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,22 @@
+package notifications
+
+import (
+	"papertrader/internal/api/auth"
+	"papertrader/internal/config"
+	"papertrader/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// Mount attaches the notification routes to r. See investments.Mount for the
+// subrouter-relative path convention.
+func Mount(r *mux.Router, h *NotificationsHandler, jwtService *service.JWTService, cfg *config.Config) {
+	r.StrictSlash(false)
+	r.Use(auth.JWTMiddleware(jwtService, cfg))
+
+	r.HandleFunc("", h.List).Methods("GET")
+	r.HandleFunc("/", h.List).Methods("GET")
+	// Registered before /{id}/read so "read-all" is never captured as an id.
+	r.HandleFunc("/read-all", h.MarkAllRead).Methods("POST")
+	r.HandleFunc("/{id}/read", h.MarkRead).Methods("POST")
+}
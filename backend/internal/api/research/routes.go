@@ -2,7 +2,6 @@ package research
 
 import (
 	"net/http"
-	"time"
 
 	"papertrader/internal/api/auth"
 	"papertrader/internal/api/middleware"
@@ -13,18 +12,16 @@ import (
 )
 
 // /api/research/ask is the most expensive endpoint (one LLM round-trip per
-// call), so it gets a tighter per-route bucket than the global default.
+// call), so it gets a tighter per-route bucket than the global default
+// (cfg.RateLimits.Ask*).
 //
 // Endpoint requires JWT, so anon traffic can't reach this limiter — the IP
 // cap exists as a backup against abuse from a single source (or one client
 // burning through many auth tokens). Set generously above the user limit so
 // real users behind shared NAT aren't punished.
-const (
-	askBucket    = "research_ask"
-	askUserLimit = 10
-	askIPLimit   = 30
-	askWindow    = time.Minute
-)
+//
+// Exported so the usage report (GET /api/account/usage) can read the bucket.
+const AskBucket = "research_ask"
 
 // Mount attaches research routes to r. r should be a subrouter scoped to
 // /api/research so paths here are registered relative to that prefix.
@@ -34,7 +31,8 @@ func Mount(r *mux.Router, h *Handler, jwtService *service.JWTService, rateLimite
 
 	askHandler := http.HandlerFunc(h.Ask)
 	if rateLimiter != nil {
-		rl := middleware.RateLimitMiddlewareCustom(rateLimiter, cfg, askBucket, askUserLimit, askIPLimit, askWindow)
+		rl := middleware.RateLimitMiddlewareCustom(rateLimiter, cfg, AskBucket,
+			cfg.RateLimits.AskUserLimit, cfg.RateLimits.AskIPLimit, cfg.RateLimits.AskWindow)
 		r.Handle("/ask", rl(askHandler)).Methods("POST", "OPTIONS")
 		r.Handle("/ask/", rl(askHandler)).Methods("POST", "OPTIONS")
 	} else {
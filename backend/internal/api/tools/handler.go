@@ -0,0 +1,52 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"papertrader/internal/service"
+	"papertrader/internal/util"
+)
+
+// StressServicer is the subset of service.StressService used by
+// ToolsHandler.
+type StressServicer interface {
+	Run(ctx context.Context, userID string, req service.StressTestRequest) (*service.StressTestResult, error)
+}
+
+// ToolsHandler serves the what-if tools, which read the account but never
+// change it.
+type ToolsHandler struct {
+	stress StressServicer
+}
+
+func NewToolsHandler(stress StressServicer) *ToolsHandler {
+	return &ToolsHandler{stress: stress}
+}
+
+// StressTest handles POST /api/tools/stress-test: the projected effect of a
+// historical or custom market shock on the user's current holdings.
+func (h *ToolsHandler) StressTest(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req service.StressTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		util.WriteSafeError(w, http.StatusBadRequest, "Invalid request body", err, "INVALID_REQUEST")
+		return
+	}
+
+	result, err := h.stress.Run(r.Context(), userID, req)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
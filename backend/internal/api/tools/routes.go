@@ -0,0 +1,27 @@
+package tools
+
+import (
+	"net/http"
+
+	"papertrader/internal/api/auth"
+	"papertrader/internal/api/middleware"
+	"papertrader/internal/config"
+	"papertrader/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// Mount attaches the tools routes to r. See investments.Mount for the
+// subrouter-relative path convention.
+func Mount(r *mux.Router, h *ToolsHandler, jwtService *service.JWTService, rateLimiter service.RateLimiter, cfg *config.Config) {
+	r.StrictSlash(false)
+	r.Use(auth.JWTMiddleware(jwtService, cfg))
+
+	// A stress test quotes every holding and may pull a year of history
+	// for each, so it shares the market data rate budget.
+	var stressTest http.Handler = http.HandlerFunc(h.StressTest)
+	if rateLimiter != nil {
+		stressTest = middleware.RateLimitMiddleware(rateLimiter, cfg)(stressTest)
+	}
+	r.Handle("/stress-test", stressTest).Methods("POST")
+}
@@ -1,6 +1,9 @@
 package watchlist
 
-import "papertrader/internal/service"
+import (
+	"papertrader/internal/data"
+	"papertrader/internal/service"
+)
 
 type AddRequest struct {
 	Symbol string `json:"symbol"`
@@ -8,4 +11,9 @@ type AddRequest struct {
 
 type ListResponse struct {
 	Items []service.WatchlistEntryView `json:"items"`
+	Lists []service.CuratedListView    `json:"lists"`
+}
+
+type CuratedListsResponse struct {
+	Items []data.CuratedList `json:"items"`
 }
@@ -16,7 +16,11 @@ import (
 type WatchlistServicer interface {
 	AddSymbol(ctx context.Context, userID, symbol string) (*service.WatchlistEntryView, error)
 	RemoveSymbol(ctx context.Context, userID, symbol string) error
-	List(ctx context.Context, userID string) ([]service.WatchlistEntryView, error)
+	List(ctx context.Context, userID string) (*service.WatchlistView, error)
+	CuratedLists(ctx context.Context, userID string) ([]data.CuratedList, error)
+	CuratedList(ctx context.Context, userID, slug string) (*service.CuratedListView, error)
+	SubscribeCuratedList(ctx context.Context, userID, slug string) error
+	UnsubscribeCuratedList(ctx context.Context, userID, slug string) error
 }
 
 type WatchlistHandler struct {
@@ -34,7 +38,7 @@ func (h *WatchlistHandler) List(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	items, err := h.service.List(r.Context(), userID)
+	view, err := h.service.List(r.Context(), userID)
 	if err != nil {
 		util.WriteServiceError(w, err)
 		return
@@ -42,7 +46,7 @@ func (h *WatchlistHandler) List(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(ListResponse{Items: items})
+	json.NewEncoder(w).Encode(ListResponse{Items: view.Items, Lists: view.Lists})
 }
 
 func (h *WatchlistHandler) Add(w http.ResponseWriter, r *http.Request) {
@@ -95,3 +99,72 @@ func (h *WatchlistHandler) Remove(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// CuratedLists handles GET /api/watchlist/lists: every curated list, marked
+// with whether the caller is subscribed.
+func (h *WatchlistHandler) CuratedLists(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	lists, err := h.service.CuratedLists(r.Context(), userID)
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(CuratedListsResponse{Items: lists})
+}
+
+// CuratedList handles GET /api/watchlist/lists/{slug}: one list with prices.
+func (h *WatchlistHandler) CuratedList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := h.service.CuratedList(r.Context(), userID, mux.Vars(r)["slug"])
+	if err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(list)
+}
+
+// Subscribe handles POST /api/watchlist/lists/{slug}/subscribe.
+func (h *WatchlistHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.SubscribeCuratedList(r.Context(), userID, mux.Vars(r)["slug"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Unsubscribe handles DELETE /api/watchlist/lists/{slug}/subscribe.
+func (h *WatchlistHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.service.UnsubscribeCuratedList(r.Context(), userID, mux.Vars(r)["slug"]); err != nil {
+		util.WriteServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -21,6 +21,13 @@ func Mount(r *mux.Router, h *WatchlistHandler, jwtService *service.JWTService, r
 	r.HandleFunc("/", h.List).Methods("GET")
 	r.HandleFunc("/{symbol}", h.Remove).Methods("DELETE")
 
+	// Curated lists are read from the DB and priced through the cached
+	// batch lookup, so like GET they are not rate-limited.
+	r.HandleFunc("/lists", h.CuratedLists).Methods("GET")
+	r.HandleFunc("/lists/{slug}", h.CuratedList).Methods("GET")
+	r.HandleFunc("/lists/{slug}/subscribe", h.Subscribe).Methods("POST")
+	r.HandleFunc("/lists/{slug}/subscribe", h.Unsubscribe).Methods("DELETE")
+
 	// Rate-limit POST: AddSymbol calls MarketStack on every new symbol, which
 	// burns shared free-tier quota. GET/DELETE only hit the DB so are exempt.
 	addHandler := http.HandlerFunc(h.Add)
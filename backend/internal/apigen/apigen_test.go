@@ -0,0 +1,84 @@
+package apigen
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestGeneratedClientsUpToDate fails when docs/openapi.yaml has changed
+// without scripts/generate-clients.sh being run.
+func TestGeneratedClientsUpToDate(t *testing.T) {
+	spec, err := Load("../../../docs/openapi.yaml")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, tc := range []struct {
+		path string
+		gen  func() ([]byte, error)
+	}{
+		{"../../client/client.gen.go", func() ([]byte, error) { return Go(spec, "client") }},
+		{"../../../frontend/src/services/api.gen.ts", func() ([]byte, error) { return TypeScript(spec) }},
+	} {
+		want, err := tc.gen()
+		if err != nil {
+			t.Fatalf("generate %s: %v", tc.path, err)
+		}
+		got, err := os.ReadFile(tc.path)
+		if err != nil {
+			t.Fatalf("read %s: %v", tc.path, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is out of date with docs/openapi.yaml; run scripts/generate-clients.sh", tc.path)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for _, tc := range []struct{ in, exported, unexported, pascal string }{
+		{"user_id", "UserID", "userID", "UserId"},
+		{"getCSRFToken", "GetCSRFToken", "getCSRFToken", "GetCSRFToken"},
+		{"Idempotency-Key", "IdempotencyKey", "idempotencyKey", "IdempotencyKey"},
+		{"unrealized_pnl", "UnrealizedPnL", "unrealizedPnL", "UnrealizedPnl"},
+		{"id", "ID", "id", "Id"},
+	} {
+		if got := exported(tc.in); got != tc.exported {
+			t.Errorf("exported(%q) = %q, want %q", tc.in, got, tc.exported)
+		}
+		if got := unexported(tc.in); got != tc.unexported {
+			t.Errorf("unexported(%q) = %q, want %q", tc.in, got, tc.unexported)
+		}
+		if got := pascal(tc.in); got != tc.pascal {
+			t.Errorf("pascal(%q) = %q, want %q", tc.in, got, tc.pascal)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	got := wrap("one two three four\n\nfive", 9)
+	want := []string{"one two", "three", "four", "", "five"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseRejectsUnknownRef(t *testing.T) {
+	raw := []byte(`
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /x:
+    get:
+      operationId: getX
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Missing"}
+`)
+	if _, err := Parse(raw); err == nil {
+		t.Error("Parse accepted a reference to an undefined schema")
+	}
+}
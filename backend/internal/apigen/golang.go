@@ -0,0 +1,400 @@
+package apigen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// Go renders the client's types and methods as the Go file client.gen.go
+// in package pkg. They run on the transport in client.go: request, send,
+// call and callRaw.
+func Go(spec *Spec, pkg string) ([]byte, error) {
+	g := &goGen{spec: spec, imports: map[string]bool{"context": true}, written: map[string]bool{}}
+	for _, name := range spec.SchemaNames {
+		g.structType(typeName(name), "the "+name+" schema", spec.Schemas[name])
+	}
+	for _, op := range spec.Operations {
+		if err := g.operation(op); err != nil {
+			return nil, fmt.Errorf("apigen: %s: %w", op.ID, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by apigen from docs/openapi.yaml; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	// The standard library first, then the rest, as goimports groups them.
+	sort.SliceStable(imports, func(i, j int) bool {
+		return !strings.Contains(imports[i], ".") && strings.Contains(imports[j], ".")
+	})
+	for i, imp := range imports {
+		if i > 0 && strings.Contains(imp, ".") && !strings.Contains(imports[i-1], ".") {
+			out.WriteString("\n")
+		}
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	out.WriteString(")\n")
+	out.Write(g.types.Bytes())
+	out.Write(g.funcs.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("apigen: format: %w\n%s", err, out.Bytes())
+	}
+	return src, nil
+}
+
+// reservedNames are taken in the generated code's scope: by client.go in
+// Go, and by the global Error in TypeScript. Schemas with these names get a
+// Body suffix.
+var reservedNames = map[string]bool{"Client": true, "Config": true, "Error": true}
+
+func typeName(schema string) string {
+	if reservedNames[schema] {
+		return schema + "Body"
+	}
+	return schema
+}
+
+type goGen struct {
+	spec    *Spec
+	imports map[string]bool
+	written map[string]bool
+	types   bytes.Buffer
+	funcs   bytes.Buffer
+}
+
+// comment writes text as a // comment at the given indent.
+func comment(b *bytes.Buffer, indent, text string) {
+	for _, line := range wrap(text, 76-len(indent)) {
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// structType writes a struct for an object schema, documented as what, and
+// the structs for any objects defined inline in it.
+func (g *goGen) structType(name, what string, s *Schema) {
+	if g.written[name] {
+		return
+	}
+	g.written[name] = true
+	var nested []func()
+	var fields bytes.Buffer
+	for _, prop := range s.Properties.Keys {
+		ps := s.Properties.Values[prop]
+		field := exported(prop)
+		optional := !s.requires(prop)
+		typ := g.typeOf(ps, name+field, optional, &nested)
+		tag := prop
+		if optional {
+			tag += ",omitempty"
+		}
+		if ps.Description != "" {
+			comment(&fields, "\t", ps.Description)
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+
+	fmt.Fprintf(&g.types, "\n// %s is %s.\n", name, what)
+	if s.Description != "" {
+		g.types.WriteString("//\n")
+		comment(&g.types, "", s.Description)
+	}
+	fmt.Fprintf(&g.types, "type %s struct {\n", name)
+	g.types.Write(fields.Bytes())
+	g.types.WriteString("}\n")
+	for _, n := range nested {
+		n()
+	}
+}
+
+// typeOf returns the Go type for s. Optional and nullable values whose
+// zero value is a valid answer are pointers, so absent and zero differ.
+func (g *goGen) typeOf(s *Schema, owner string, optional bool, nested *[]func()) string {
+	ptr := func(t string) string {
+		if optional || s.Nullable {
+			return "*" + t
+		}
+		return t
+	}
+	if s.Ref != "" {
+		return ptr(typeName(refName(s.Ref)))
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return ptr("time.Time")
+		case "binary":
+			return "[]byte"
+		}
+		if s.Nullable {
+			return "*string"
+		}
+		return "string"
+	case "number":
+		if s.Format == "double" || s.Format == "float" {
+			return ptr("float64")
+		}
+		g.imports["github.com/shopspring/decimal"] = true
+		return ptr("decimal.Decimal")
+	case "integer":
+		if s.Format == "int64" {
+			return ptr("int64")
+		}
+		return ptr("int")
+	case "boolean":
+		if s.Nullable {
+			return "*bool"
+		}
+		return "bool"
+	case "array":
+		return "[]" + g.typeOf(s.Items, owner+"Item", false, nested)
+	}
+	if len(s.Properties.Keys) > 0 {
+		*nested = append(*nested, func() { g.structType(owner, "an object the spec defines inline", s) })
+		return ptr(owner)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return "map[string]" + g.typeOf(s.AdditionalProperties.Schema, owner+"Value", false, nested)
+	}
+	g.imports["encoding/json"] = true
+	return "json.RawMessage"
+}
+
+// resultType returns the type a method returns for a JSON result: a
+// pointer for structs, the value for everything else.
+func (g *goGen) resultType(s *Schema, owner string) string {
+	var nested []func()
+	t := g.typeOf(s, owner, false, &nested)
+	for _, n := range nested {
+		n()
+	}
+	if s.Ref != "" || len(s.Properties.Keys) > 0 {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *goGen) operation(op *Operation) error {
+	name := exported(op.ID)
+	args := []string{"ctx context.Context"}
+	for _, p := range op.PathParams {
+		args = append(args, unexported(p.Name)+" string")
+	}
+	if len(op.Params) > 0 {
+		g.paramsType(op, name)
+		args = append(args, "params *"+name+"Params")
+	}
+
+	var bodyExpr string
+	if b := op.Body; b != nil {
+		switch {
+		case isJSON(b.MediaType):
+			t := name + "Body"
+			if b.Schema.Ref != "" {
+				t = typeName(refName(b.Schema.Ref))
+			} else {
+				g.structType(t, "the request body of "+name, b.Schema)
+			}
+			if !b.Required {
+				t = "*" + t
+			}
+			args = append(args, "body "+t)
+			bodyExpr = "json: body"
+			if !b.Required {
+				// A nil pointer would still encode as null.
+				bodyExpr = ""
+			}
+		default:
+			args = append(args, "body []byte")
+			bodyExpr = fmt.Sprintf("raw: body, contentType: %q", b.MediaType)
+		}
+	}
+
+	jsonResults := op.jsonResults()
+	var ret, zero string
+	multi := len(op.Results) > 1 && op.hasContent()
+	switch {
+	case multi:
+		g.responseType(op, name)
+		ret, zero = "*"+name+"Response", "nil"
+	case len(jsonResults) == 1:
+		ret = g.resultType(jsonResults[0].Schema, name+"Result")
+		zero = "nil"
+		if !strings.HasPrefix(ret, "*") && !strings.HasPrefix(ret, "[]") && !strings.HasPrefix(ret, "map[") {
+			zero = ret + "{}"
+			switch ret {
+			case "string":
+				zero = `""`
+			case "bool":
+				zero = "false"
+			case "int", "int64", "float64":
+				zero = "0"
+			}
+		}
+	case op.hasContent():
+		ret, zero = "[]byte", "nil"
+	}
+
+	b := &g.funcs
+	b.WriteString("\n")
+	comment(b, "", fmt.Sprintf("%s calls %s %s: %s.", name, op.Method, op.Path, strings.TrimSuffix(sentence(op.Summary), ".")))
+	if ret == "" {
+		fmt.Fprintf(b, "func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	} else {
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), ret)
+	}
+
+	fields := []string{fmt.Sprintf("method: %q", op.Method), "path: " + g.pathExpr(op)}
+	if bodyExpr != "" {
+		fields = append(fields, bodyExpr)
+	}
+	if mt := op.rawMediaType(); mt != "" && len(jsonResults) == 0 {
+		fields = append(fields, fmt.Sprintf("accept: %q", mt))
+	}
+	fmt.Fprintf(b, "\tr := request{%s}\n", strings.Join(fields, ", "))
+	if op.Body != nil && isJSON(op.Body.MediaType) && !op.Body.Required {
+		b.WriteString("\tif body != nil {\n\t\tr.json = body\n\t}\n")
+	}
+	if len(op.Params) > 0 {
+		g.paramsCode(op)
+	}
+
+	switch {
+	case ret == "":
+		b.WriteString("\treturn c.call(ctx, r, nil)\n")
+	case multi:
+		fmt.Fprintf(b, "\tresp, err := c.send(ctx, r)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		fmt.Fprintf(b, "\tout := &%sResponse{StatusCode: resp.status, ContentType: resp.contentType, Body: resp.body}\n", name)
+		for _, res := range jsonResults {
+			field := fmt.Sprintf("JSON%d", res.Status)
+			t := strings.TrimPrefix(g.resultType(res.Schema, fmt.Sprintf("%s%dResult", name, res.Status)), "*")
+			fmt.Fprintf(b, "\tif resp.status == %d && resp.isJSON() {\n\t\tout.%s = new(%s)\n", res.Status, field, t)
+			fmt.Fprintf(b, "\t\tif err := resp.decode(out.%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n\t}\n", field)
+		}
+		b.WriteString("\treturn out, nil\n")
+	case ret == "[]byte":
+		b.WriteString("\treturn c.callRaw(ctx, r)\n")
+	default:
+		t := strings.TrimPrefix(ret, "*")
+		fmt.Fprintf(b, "\tvar out %s\n\tif err := c.call(ctx, r, &out); err != nil {\n\t\treturn %s, err\n\t}\n", t, zero)
+		if strings.HasPrefix(ret, "*") {
+			b.WriteString("\treturn &out, nil\n")
+		} else {
+			b.WriteString("\treturn out, nil\n")
+		}
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// pathExpr returns a Go expression for the operation's path, with its path
+// parameters escaped.
+func (g *goGen) pathExpr(op *Operation) string {
+	if len(op.PathParams) == 0 {
+		return fmt.Sprintf("%q", op.Path)
+	}
+	g.imports["net/url"] = true
+	var parts []string
+	rest := op.Path
+	for _, p := range op.PathParams {
+		i := strings.Index(rest, "{"+p.Name+"}")
+		if i > 0 {
+			parts = append(parts, fmt.Sprintf("%q", rest[:i]))
+		}
+		parts = append(parts, "url.PathEscape("+unexported(p.Name)+")")
+		rest = rest[i+len(p.Name)+2:]
+	}
+	if rest != "" {
+		parts = append(parts, fmt.Sprintf("%q", rest))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// paramType returns the Go type of a query or header parameter. Zero values
+// are left out of the request.
+func (g *goGen) paramType(p *Parameter) string {
+	switch p.Schema.Type {
+	case "integer":
+		return "int"
+	case "boolean":
+		return "bool"
+	case "number":
+		g.imports["github.com/shopspring/decimal"] = true
+		return "decimal.Decimal"
+	}
+	return "string"
+}
+
+func (g *goGen) paramsType(op *Operation, name string) {
+	b := &g.types
+	b.WriteString("\n")
+	comment(b, "", fmt.Sprintf("%sParams are %s's query and header parameters. Zero values are left out of the request.", name, name))
+	fmt.Fprintf(b, "type %sParams struct {\n", name)
+	for _, p := range op.Params {
+		doc := p.Description
+		if p.Required {
+			doc = strings.TrimSpace("Required. " + doc)
+		}
+		if doc != "" {
+			comment(b, "\t", doc)
+		}
+		fmt.Fprintf(b, "\t%s %s\n", exported(p.Name), g.paramType(p))
+	}
+	b.WriteString("}\n")
+}
+
+func (g *goGen) paramsCode(op *Operation) {
+	b := &g.funcs
+	b.WriteString("\tif params != nil {\n")
+	for _, p := range op.Params {
+		field := "params." + exported(p.Name)
+		set := "r.setQuery"
+		if p.In == "header" {
+			set = "r.setHeader"
+		}
+		switch g.paramType(p) {
+		case "int":
+			g.imports["strconv"] = true
+			fmt.Fprintf(b, "\t\tif %s != 0 {\n\t\t\t%s(%q, strconv.Itoa(%s))\n\t\t}\n", field, set, p.Name, field)
+		case "bool":
+			fmt.Fprintf(b, "\t\tif %s {\n\t\t\t%s(%q, \"true\")\n\t\t}\n", field, set, p.Name)
+		case "decimal.Decimal":
+			fmt.Fprintf(b, "\t\tif !%s.IsZero() {\n\t\t\t%s(%q, %s.String())\n\t\t}\n", field, set, p.Name, field)
+		default:
+			fmt.Fprintf(b, "\t\tif %s != \"\" {\n\t\t\t%s(%q, %s)\n\t\t}\n", field, set, p.Name, field)
+		}
+	}
+	b.WriteString("\t}\n")
+}
+
+// responseType writes the struct an operation with several possible
+// answers returns.
+func (g *goGen) responseType(op *Operation, name string) {
+	var fields bytes.Buffer
+	for _, res := range op.jsonResults() {
+		t := g.resultType(res.Schema, fmt.Sprintf("%s%dResult", name, res.Status))
+		if !strings.HasPrefix(t, "*") {
+			t = "*" + t
+		}
+		fmt.Fprintf(&fields, "\t// JSON%d is the body of a %d JSON answer.\n\tJSON%d %s\n", res.Status, res.Status, res.Status, t)
+	}
+	b := &g.types
+	b.WriteString("\n")
+	comment(b, "", fmt.Sprintf("%sResponse is %s's answer. The JSON field for its status is set when the body is JSON; Body is the body as sent.", name, name))
+	fmt.Fprintf(b, "type %sResponse struct {\n\tStatusCode  int\n\tContentType string\n\tBody        []byte\n", name)
+	b.Write(fields.Bytes())
+	b.WriteString("}\n")
+}
@@ -0,0 +1,457 @@
+// Package apigen generates the API clients from docs/openapi.yaml: the Go
+// package in backend/client and the TypeScript module the frontend calls.
+// It reads only the parts of OpenAPI 3.0 the spec uses; anything else is an
+// error rather than a silently wrong client.
+package apigen
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ordered is a YAML mapping that keeps its keys in document order, so the
+// generated code follows the spec's.
+type ordered[T any] struct {
+	Keys   []string
+	Values map[string]T
+}
+
+func (o *ordered[T]) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: want a mapping", n.Line)
+	}
+	o.Values = make(map[string]T, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var v T
+		if err := n.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+		k := n.Content[i].Value
+		o.Keys = append(o.Keys, k)
+		o.Values[k] = v
+	}
+	return nil
+}
+
+type document struct {
+	Paths      ordered[pathItem] `yaml:"paths"`
+	Components struct {
+		Schemas    ordered[*Schema]      `yaml:"schemas"`
+		Parameters map[string]*Parameter `yaml:"parameters"`
+		Responses  map[string]*response  `yaml:"responses"`
+	} `yaml:"components"`
+}
+
+type pathItem struct {
+	Get    *operation `yaml:"get"`
+	Post   *operation `yaml:"post"`
+	Put    *operation `yaml:"put"`
+	Patch  *operation `yaml:"patch"`
+	Delete *operation `yaml:"delete"`
+}
+
+type operation struct {
+	OperationID string             `yaml:"operationId"`
+	Summary     string             `yaml:"summary"`
+	Parameters  []*Parameter       `yaml:"parameters"`
+	RequestBody *requestBody       `yaml:"requestBody"`
+	Responses   ordered[*response] `yaml:"responses"`
+	Client      *bool              `yaml:"x-client"`
+}
+
+type requestBody struct {
+	Required bool               `yaml:"required"`
+	Content  ordered[mediaType] `yaml:"content"`
+}
+
+type response struct {
+	Ref     string             `yaml:"$ref"`
+	Content ordered[mediaType] `yaml:"content"`
+}
+
+type mediaType struct {
+	Schema *Schema `yaml:"schema"`
+}
+
+// Schema is a JSON schema as OpenAPI 3.0 writes it.
+type Schema struct {
+	Ref                  string           `yaml:"$ref"`
+	Type                 string           `yaml:"type"`
+	Format               string           `yaml:"format"`
+	Nullable             bool             `yaml:"nullable"`
+	Enum                 []string         `yaml:"enum"`
+	Description          string           `yaml:"description"`
+	Required             []string         `yaml:"required"`
+	Properties           ordered[*Schema] `yaml:"properties"`
+	AdditionalProperties *additional      `yaml:"additionalProperties"`
+	Items                *Schema          `yaml:"items"`
+}
+
+// additional is additionalProperties: true for a free-form object, or the
+// schema of a map's values.
+type additional struct {
+	Any    bool
+	Schema *Schema
+}
+
+func (a *additional) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&a.Any)
+	}
+	return n.Decode(&a.Schema)
+}
+
+func (s *Schema) requires(name string) bool {
+	for _, r := range s.Required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parameter is a path, query or header parameter.
+type Parameter struct {
+	Ref         string  `yaml:"$ref"`
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Required    bool    `yaml:"required"`
+	Description string  `yaml:"description"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+// Body is an operation's request body.
+type Body struct {
+	MediaType string
+	Schema    *Schema
+	Required  bool
+}
+
+// Result is one 2xx response an operation can answer with. Schema is nil
+// when it has no content.
+type Result struct {
+	Status    int
+	MediaType string
+	Schema    *Schema
+}
+
+// Operation is a call the clients make, with its references resolved.
+type Operation struct {
+	ID      string
+	Method  string
+	Path    string
+	Summary string
+	// PathParams are in the order they appear in Path; Params are the
+	// query and header parameters.
+	PathParams []*Parameter
+	Params     []*Parameter
+	Body       *Body
+	Results    []Result
+}
+
+// Spec is the parsed spec: its component schemas and the operations the
+// clients make. Operations marked x-client: false (the magic-link redirect
+// and the live price streams) are left out.
+type Spec struct {
+	SchemaNames []string
+	Schemas     map[string]*Schema
+	Operations  []*Operation
+}
+
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// Load reads and resolves the spec at path.
+func Load(path string) (*Spec, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(raw)
+}
+
+// Parse resolves a spec already read.
+func Parse(raw []byte) (*Spec, error) {
+	var doc document
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("apigen: %w", err)
+	}
+	spec := &Spec{SchemaNames: doc.Components.Schemas.Keys, Schemas: doc.Components.Schemas.Values}
+	seen := map[string]bool{}
+	for _, path := range doc.Paths.Keys {
+		item := doc.Paths.Values[path]
+		for _, m := range []struct {
+			method string
+			op     *operation
+		}{{"GET", item.Get}, {"POST", item.Post}, {"PUT", item.Put}, {"PATCH", item.Patch}, {"DELETE", item.Delete}} {
+			if m.op == nil || (m.op.Client != nil && !*m.op.Client) {
+				continue
+			}
+			op, err := resolve(&doc, spec, m.method, path, m.op)
+			if err != nil {
+				return nil, fmt.Errorf("apigen: %s %s: %w", m.method, path, err)
+			}
+			if seen[op.ID] {
+				return nil, fmt.Errorf("apigen: duplicate operationId %s", op.ID)
+			}
+			seen[op.ID] = true
+			spec.Operations = append(spec.Operations, op)
+		}
+	}
+	return spec, nil
+}
+
+func resolve(doc *document, spec *Spec, method, path string, o *operation) (*Operation, error) {
+	if o.OperationID == "" {
+		return nil, fmt.Errorf("no operationId")
+	}
+	op := &Operation{ID: o.OperationID, Method: method, Path: path, Summary: o.Summary}
+
+	byName := map[string]*Parameter{}
+	for _, p := range o.Parameters {
+		if p.Ref != "" {
+			name := strings.TrimPrefix(p.Ref, "#/components/parameters/")
+			if p = doc.Components.Parameters[name]; p == nil {
+				return nil, fmt.Errorf("unknown parameter %s", name)
+			}
+		}
+		if err := checkRefs(spec, p.Schema); err != nil {
+			return nil, err
+		}
+		switch p.In {
+		case "path":
+			byName[p.Name] = p
+		case "query", "header":
+			op.Params = append(op.Params, p)
+		default:
+			return nil, fmt.Errorf("parameter %s: unsupported location %q", p.Name, p.In)
+		}
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		p := byName[m[1]]
+		if p == nil {
+			return nil, fmt.Errorf("path parameter %s is not declared", m[1])
+		}
+		op.PathParams = append(op.PathParams, p)
+	}
+	if len(op.PathParams) != len(byName) {
+		return nil, fmt.Errorf("a path parameter is declared but not in the path")
+	}
+
+	if rb := o.RequestBody; rb != nil {
+		if len(rb.Content.Keys) != 1 {
+			return nil, fmt.Errorf("request body: want one media type, got %d", len(rb.Content.Keys))
+		}
+		mt := rb.Content.Keys[0]
+		s := rb.Content.Values[mt].Schema
+		if err := checkRefs(spec, s); err != nil {
+			return nil, err
+		}
+		op.Body = &Body{MediaType: mt, Schema: s, Required: rb.Required}
+	}
+
+	for _, code := range o.Responses.Keys {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			return nil, fmt.Errorf("response %q: %w", code, err)
+		}
+		r := o.Responses.Values[code]
+		if r.Ref != "" {
+			name := strings.TrimPrefix(r.Ref, "#/components/responses/")
+			if r = doc.Components.Responses[name]; r == nil {
+				return nil, fmt.Errorf("unknown response %s", name)
+			}
+		}
+		if len(r.Content.Keys) == 0 {
+			op.Results = append(op.Results, Result{Status: status})
+		}
+		for _, mt := range r.Content.Keys {
+			s := r.Content.Values[mt].Schema
+			if err := checkRefs(spec, s); err != nil {
+				return nil, err
+			}
+			op.Results = append(op.Results, Result{Status: status, MediaType: mt, Schema: s})
+		}
+	}
+	if len(op.Results) == 0 {
+		return nil, fmt.Errorf("no 2xx response")
+	}
+	return op, nil
+}
+
+// checkRefs reports a reference in s to a schema the spec doesn't define.
+func checkRefs(spec *Spec, s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if _, ok := spec.Schemas[refName(s.Ref)]; !ok {
+			return fmt.Errorf("unknown schema %s", s.Ref)
+		}
+		return nil
+	}
+	for _, k := range s.Properties.Keys {
+		if err := checkRefs(spec, s.Properties.Values[k]); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil {
+		if err := checkRefs(spec, s.AdditionalProperties.Schema); err != nil {
+			return err
+		}
+	}
+	return checkRefs(spec, s.Items)
+}
+
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+// isJSON reports whether a media type is JSON.
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json"
+}
+
+// jsonResults returns the results with a JSON body.
+func (op *Operation) jsonResults() []Result {
+	var out []Result
+	for _, r := range op.Results {
+		if isJSON(r.MediaType) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// hasContent reports whether any result has a body.
+func (op *Operation) hasContent() bool {
+	for _, r := range op.Results {
+		if r.MediaType != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// rawMediaType returns the media type of the first result without a JSON
+// body, or "" if every result is JSON or empty.
+func (op *Operation) rawMediaType() string {
+	for _, r := range op.Results {
+		if r.MediaType != "" && !isJSON(r.MediaType) {
+			return r.MediaType
+		}
+	}
+	return ""
+}
+
+// requiresParams reports whether any query or header parameter is required.
+func (op *Operation) requiresParams() bool {
+	for _, p := range op.Params {
+		if p.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// words splits an identifier written in snake_case, kebab-case or
+// camelCase into its words.
+func words(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == '_' || r == '-' || r == ' ' || r == '.' }) {
+		start := 0
+		for i := 1; i < len(part); i++ {
+			lower := func(c byte) bool { return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' }
+			upper := func(c byte) bool { return c >= 'A' && c <= 'Z' }
+			// Split before an upper-case letter that follows a lower-case
+			// one, and before the last capital of a run followed by a
+			// lower-case letter (CSRFToken -> CSRF Token).
+			if upper(part[i]) && (lower(part[i-1]) || i+1 < len(part) && upper(part[i-1]) && lower(part[i+1])) {
+				out = append(out, part[start:i])
+				start = i
+			}
+		}
+		out = append(out, part[start:])
+	}
+	return out
+}
+
+// initialisms are the words Go spells in one case.
+var initialisms = map[string]string{
+	"api": "API", "csrf": "CSRF", "csv": "CSV", "eod": "EOD", "fx": "FX", "http": "HTTP",
+	"id": "ID", "ip": "IP", "json": "JSON", "mic": "MIC", "ms": "MS", "oco": "OCO",
+	"pdf": "PDF", "pdt": "PDT", "pnl": "PnL", "url": "URL", "utc": "UTC",
+}
+
+// exported returns s as an exported Go identifier.
+func exported(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		if i, ok := initialisms[strings.ToLower(w)]; ok {
+			b.WriteString(i)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// unexported returns s as an unexported Go identifier.
+func unexported(s string) string {
+	e := exported(s)
+	ws := words(e)
+	if len(ws) == 0 {
+		return e
+	}
+	first := ws[0]
+	return strings.ToLower(first) + e[len(first):]
+}
+
+// pascal returns s in PascalCase for a TypeScript type name.
+func pascal(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+	return b.String()
+}
+
+// sentence lower-cases the first word of a summary unless it is an
+// acronym, for use after a colon.
+func sentence(s string) string {
+	if len(s) > 1 && s[0] >= 'A' && s[0] <= 'Z' && (s[1] < 'A' || s[1] > 'Z') {
+		return strings.ToLower(s[:1]) + s[1:]
+	}
+	return s
+}
+
+// wrap breaks text into lines of at most width columns, keeping its
+// paragraphs; a blank line separates them.
+func wrap(text string, width int) []string {
+	var lines []string
+	for i, para := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		line := ""
+		for _, w := range strings.Fields(para) {
+			if line != "" && len(line)+1+len(w) > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += w
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
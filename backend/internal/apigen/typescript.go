@@ -0,0 +1,290 @@
+package apigen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TypeScript renders the frontend's API module: an interface per schema and
+// a function per operation, over the request runtime in services/http.ts.
+func TypeScript(spec *Spec) ([]byte, error) {
+	g := &tsGen{spec: spec, written: map[string]bool{}}
+	for _, name := range spec.SchemaNames {
+		g.iface(typeName(name), spec.Schemas[name])
+	}
+	for _, op := range spec.Operations {
+		g.operation(op)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by apigen from docs/openapi.yaml; DO NOT EDIT.\n")
+	out.WriteString("// Regenerate with scripts/generate-clients.sh.\n\n")
+	out.WriteString("import { request } from './http';\n")
+	out.Write(g.types.Bytes())
+	out.Write(g.funcs.Bytes())
+	return out.Bytes(), nil
+}
+
+type tsGen struct {
+	spec    *Spec
+	written map[string]bool
+	types   bytes.Buffer
+	funcs   bytes.Buffer
+}
+
+var tsIdent = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsKey quotes a property name that isn't an identifier.
+func tsKey(name string) string {
+	if tsIdent.MatchString(name) {
+		return name
+	}
+	return fmt.Sprintf("'%s'", name)
+}
+
+// jsdoc writes text as a /** */ comment at the given indent.
+func jsdoc(b *bytes.Buffer, indent, text string) {
+	lines := wrap(text, 96-len(indent))
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, l := range lines {
+		if l == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s * %s\n", indent, l)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+func (g *tsGen) iface(name string, s *Schema) {
+	if g.written[name] {
+		return
+	}
+	g.written[name] = true
+	var nested []func()
+	var fields bytes.Buffer
+	for _, prop := range s.Properties.Keys {
+		ps := s.Properties.Values[prop]
+		if ps.Description != "" {
+			jsdoc(&fields, "  ", ps.Description)
+		}
+		opt := ""
+		if !s.requires(prop) {
+			opt = "?"
+		}
+		fmt.Fprintf(&fields, "  %s%s: %s;\n", tsKey(prop), opt, g.typeOf(ps, name+pascal(prop), &nested))
+	}
+	g.types.WriteString("\n")
+	if s.Description != "" {
+		jsdoc(&g.types, "", s.Description)
+	}
+	fmt.Fprintf(&g.types, "export interface %s {\n", name)
+	g.types.Write(fields.Bytes())
+	g.types.WriteString("}\n")
+	for _, n := range nested {
+		n()
+	}
+}
+
+func (g *tsGen) typeOf(s *Schema, owner string, nested *[]func()) string {
+	t := g.baseType(s, owner, nested)
+	if s.Nullable {
+		return t + " | null"
+	}
+	return t
+}
+
+func (g *tsGen) baseType(s *Schema, owner string, nested *[]func()) string {
+	if s.Ref != "" {
+		return typeName(refName(s.Ref))
+	}
+	switch s.Type {
+	case "string":
+		if s.Format == "binary" {
+			return "Blob"
+		}
+		if len(s.Enum) > 0 {
+			quoted := make([]string, len(s.Enum))
+			for i, e := range s.Enum {
+				quoted[i] = fmt.Sprintf("'%s'", e)
+			}
+			return strings.Join(quoted, " | ")
+		}
+		return "string"
+	case "number", "integer":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		item := g.typeOf(s.Items, owner+"Item", nested)
+		if strings.Contains(item, " ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	}
+	if len(s.Properties.Keys) > 0 {
+		*nested = append(*nested, func() { g.iface(owner, s) })
+		return owner
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return "Record<string, " + g.typeOf(s.AdditionalProperties.Schema, owner+"Value", nested) + ">"
+	}
+	return "Record<string, unknown>"
+}
+
+// namedType returns the type for a body or result schema, declaring an
+// interface named owner for an inline object.
+func (g *tsGen) namedType(s *Schema, owner string) string {
+	var nested []func()
+	t := g.typeOf(s, owner, &nested)
+	for _, n := range nested {
+		n()
+	}
+	return t
+}
+
+// tsParamName is a parameter's key in the Params interface: the query
+// parameter's own name, or a header's name in camelCase.
+func tsParamName(p *Parameter) string {
+	if p.In == "header" {
+		e := pascal(p.Name)
+		return strings.ToLower(e[:1]) + e[1:]
+	}
+	return p.Name
+}
+
+func (g *tsGen) operation(op *Operation) {
+	name := pascal(op.ID)
+	var args []string
+	for _, p := range op.PathParams {
+		args = append(args, p.Name+": string")
+	}
+
+	var body string
+	if b := op.Body; b != nil {
+		t := "Blob | string"
+		if isJSON(b.MediaType) {
+			t = g.namedType(b.Schema, name+"Body")
+			body = "body"
+		} else {
+			body = fmt.Sprintf("body\x00contentType: '%s'", b.MediaType)
+		}
+		if b.Required {
+			args = append(args, "body: "+t)
+		} else {
+			args = append(args, "body?: "+t)
+		}
+	}
+
+	if len(op.Params) > 0 {
+		var fields bytes.Buffer
+		for _, p := range op.Params {
+			if p.Description != "" {
+				jsdoc(&fields, "  ", p.Description)
+			}
+			opt := "?"
+			if p.Required {
+				opt = ""
+			}
+			var nested []func()
+			fmt.Fprintf(&fields, "  %s%s: %s;\n", tsKey(tsParamName(p)), opt, g.typeOf(p.Schema, name+"Param", &nested))
+		}
+		fmt.Fprintf(&g.types, "\nexport interface %sParams {\n", name)
+		g.types.Write(fields.Bytes())
+		g.types.WriteString("}\n")
+		if op.requiresParams() {
+			args = append(args, "params: "+name+"Params")
+		} else {
+			args = append(args, "params: "+name+"Params = {}")
+		}
+	}
+
+	var results []string
+	seen := map[string]bool{}
+	for _, r := range op.Results {
+		t := "void"
+		switch {
+		case isJSON(r.MediaType):
+			owner := name + "Result"
+			if len(op.jsonResults()) > 1 {
+				owner = fmt.Sprintf("%s%dResult", name, r.Status)
+			}
+			t = g.namedType(r.Schema, owner)
+		case r.MediaType != "":
+			t = "Blob"
+		}
+		if !seen[t] {
+			seen[t] = true
+			results = append(results, t)
+		}
+	}
+	ret := strings.Join(results, " | ")
+
+	var opts []string
+	if body != "" {
+		opts = append(opts, strings.Split(body, "\x00")...)
+	}
+	// The Params keys are the query parameters' own names, so without
+	// headers the params are the query.
+	var query, headers []string
+	for _, p := range op.Params {
+		entry := fmt.Sprintf("%s: params%s", tsKey(p.Name), tsAccess(tsParamName(p)))
+		if p.In == "header" {
+			headers = append(headers, entry)
+		} else {
+			query = append(query, entry)
+		}
+	}
+	switch {
+	case len(headers) == 0 && len(query) > 0:
+		opts = append(opts, "query: params")
+	case len(query) > 0:
+		opts = append(opts, "query: { "+strings.Join(query, ", ")+" }")
+	}
+	if len(headers) > 0 {
+		opts = append(opts, "headers: { "+strings.Join(headers, ", ")+" }")
+	}
+	if mt := op.rawMediaType(); mt != "" && len(op.jsonResults()) == 0 {
+		opts = append(opts, fmt.Sprintf("accept: '%s'", mt))
+	}
+
+	call := fmt.Sprintf("request<%s>('%s', %s", ret, op.Method, g.pathExpr(op))
+	switch inline := ", { " + strings.Join(opts, ", ") + " })"; {
+	case len(opts) == 0:
+		call += ")"
+	case len(call)+len(inline) <= 96:
+		call += inline
+	default:
+		call += ", {\n    " + strings.Join(opts, ",\n    ") + ",\n  })"
+	}
+
+	b := &g.funcs
+	b.WriteString("\n")
+	jsdoc(b, "", fmt.Sprintf("%s.\n\n`%s %s`", strings.TrimSuffix(op.Summary, "."), op.Method, op.Path))
+	sig := fmt.Sprintf("export const %s = (%s): Promise<%s> =>", op.ID, strings.Join(args, ", "), ret)
+	if len(sig) > 100 {
+		sig = fmt.Sprintf("export const %s = (\n  %s\n): Promise<%s> =>", op.ID, strings.Join(args, ",\n  "), ret)
+	}
+	fmt.Fprintf(b, "%s\n  %s;\n", sig, call)
+}
+
+// tsAccess returns a property access for key.
+func tsAccess(key string) string {
+	if tsIdent.MatchString(key) {
+		return "." + key
+	}
+	return "['" + key + "']"
+}
+
+func (g *tsGen) pathExpr(op *Operation) string {
+	if len(op.PathParams) == 0 {
+		return "'" + op.Path + "'"
+	}
+	return "`" + pathParam.ReplaceAllString(op.Path, "$${encodeURIComponent($1)}") + "`"
+}
@@ -0,0 +1,448 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func problemKeys(t *testing.T, err error) []string {
+	t.Helper()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+	keys := make([]string, 0, len(verr.Problems))
+	for _, p := range verr.Problems {
+		keys = append(keys, p.Key)
+	}
+	return keys
+}
+
+func assertKeys(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("problem keys: got %v, want %v", got, want)
+	}
+}
+
+func TestLoad_DefaultsAreValid(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("defaults should validate, got %v", err)
+	}
+	if cfg.Port != "8080" || cfg.SudoTTL.Seconds() != 300 {
+		t.Errorf("unexpected defaults: port=%q sudo=%v", cfg.Port, cfg.SudoTTL)
+	}
+}
+
+func TestLoad_CollectsEveryProblem(t *testing.T) {
+	t.Setenv("PORT", "99999")
+	t.Setenv("REDIS_DB", "two")
+	t.Setenv("MIGRATE_ON_START", "yes")
+	t.Setenv("FRONTEND_URL", "localhost:3000")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("TRADING_MIN_PRICE", "-1")
+	t.Setenv("SUDO_TTL_SECONDS", "7200")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err),
+		// Malformed values are reported in the order they are read...
+		"REDIS_DB", "MIGRATE_ON_START", "TRADING_MIN_PRICE",
+		// ...followed by range and cross-field checks.
+		"PORT", "FRONTEND_URL", "LOG_LEVEL", "SUDO_TTL_SECONDS")
+	if !strings.Contains(err.Error(), "7 configuration problem(s)") {
+		t.Errorf("error should summarise the count, got %q", err.Error())
+	}
+}
+
+func TestLoad_Sections(t *testing.T) {
+	t.Setenv("RATE_LIMIT_USER", "50")
+	t.Setenv("RATE_LIMIT_ASK_WINDOW_SECONDS", "120")
+	t.Setenv("CACHE_STOCK_TTL_SECONDS", "60")
+	t.Setenv("TRADING_MAX_QUANTITY", "5000")
+	t.Setenv("RESEND_API_KEY", "re_123")
+	t.Setenv("FROM_EMAIL", "PaperTrader <noreply@example.com>")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.RateLimits.UserLimit != 50 || cfg.RateLimits.IPLimit != 200 || cfg.RateLimits.AskWindow != 2*time.Minute {
+		t.Errorf("rate limits: %+v", cfg.RateLimits)
+	}
+	if cfg.Cache.StockTTL != time.Minute || cfg.Cache.HistoricalTTL != 24*time.Hour {
+		t.Errorf("cache: %+v", cfg.Cache)
+	}
+	if cfg.Trading.MaxQuantity != 5000 || cfg.Trading.MaxTradesPerDay != 100 {
+		t.Errorf("trading: %+v", cfg.Trading)
+	}
+	if !cfg.Email.Enabled() {
+		t.Error("email should be enabled when both key and sender are set")
+	}
+}
+
+func TestLoad_SectionRanges(t *testing.T) {
+	t.Setenv("RATE_LIMIT_IP", "0")
+	t.Setenv("CACHE_HISTORICAL_TTL_SECONDS", "-1")
+	t.Setenv("TRADING_MAX_QUANTITY", "3000000000")
+	t.Setenv("LIVE_MAX_SYMBOLS", "0")
+	t.Setenv("MARKET_MOVERS_INDEX", "ftse")
+	t.Setenv("NEWS_PROVIDER", "bloomberg")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "CACHE_HISTORICAL_TTL_SECONDS", "LIVE_MAX_SYMBOLS", "MARKET_MOVERS_INDEX", "NEWS_PROVIDER", "RATE_LIMIT_IP", "TRADING_MAX_QUANTITY")
+}
+
+func TestLoad_CacheTTLs(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Cache.StockTTL != time.Hour || cfg.Cache.HistoricalTTL != 24*time.Hour || cfg.Cache.IntradayTTL != 5*time.Minute {
+		t.Errorf("development cache defaults: %+v", cfg.Cache)
+	}
+
+	t.Setenv("CACHE_STOCK_TTL_SECONDS", "5")
+	t.Setenv("CACHE_HISTORICAL_TTL_SECONDS", "1209600")
+	t.Setenv("CACHE_INTRADAY_TTL_SECONDS", "30")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "CACHE_STOCK_TTL_SECONDS", "CACHE_HISTORICAL_TTL_SECONDS")
+}
+
+func TestValidate_ExpensiveLimitsReportedTogether(t *testing.T) {
+	// The loader already rejects a retry-after below one second, so check
+	// validate directly.
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	cfg.RateLimits.ExpensiveConcurrency = -1
+	cfg.RateLimits.ExpensiveRetryAfter = 0
+
+	keys := make([]string, 0, 2)
+	for _, p := range validate(cfg) {
+		keys = append(keys, p.Key)
+	}
+	assertKeys(t, keys, "RATE_LIMIT_EXPENSIVE_CONCURRENCY", "RATE_LIMIT_EXPENSIVE_RETRY_AFTER_SECONDS")
+}
+
+func TestLoad_HTTPClient(t *testing.T) {
+	t.Setenv("HTTP_CLIENT_MAX_CONNS_PER_HOST", "4")
+	t.Setenv("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "8")
+	t.Setenv("HTTP_CLIENT_PROXY_URL", "ftp://proxy.internal:3128")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "HTTP_CLIENT_PROXY_URL")
+
+	t.Setenv("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "4")
+	t.Setenv("HTTP_CLIENT_PROXY_URL", "http://proxy.internal:3128")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client := NewHTTPClient(cfg); client.Timeout != time.Minute {
+		t.Errorf("client timeout: got %v", client.Timeout)
+	}
+	transport := newTransport(cfg.HTTPClient)
+	if transport.MaxConnsPerHost != 4 || transport.MaxIdleConnsPerHost != 4 || transport.IdleConnTimeout != 90*time.Second {
+		t.Errorf("transport: %+v", transport)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.marketstack.com/v1/eod", nil)
+	if proxy, err := transport.Proxy(req); err != nil || proxy.String() != "http://proxy.internal:3128" {
+		t.Errorf("proxy: got %v, %v", proxy, err)
+	}
+}
+
+func TestLoad_EmailSettings(t *testing.T) {
+	t.Setenv("RESEND_API_KEY", "re_123")
+	t.Setenv("ADMIN_EMAILS", "ops@example.com, not-an-email")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "FROM_EMAIL", "ADMIN_EMAILS")
+}
+
+func TestLoad_EmailBackend(t *testing.T) {
+	t.Setenv("FROM_EMAIL", "PaperTrader <noreply@example.com>")
+
+	// A Resend key alone still picks Resend.
+	t.Setenv("RESEND_API_KEY", "re_123")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Email.Backend != "resend" || !cfg.Email.Enabled() {
+		t.Errorf("backend: got %q", cfg.Email.Backend)
+	}
+
+	t.Setenv("EMAIL_BACKEND", "SMTP")
+	t.Setenv("SMTP_PORT", "0")
+	t.Setenv("SMTP_USERNAME", "apikey")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "SMTP_HOST", "SMTP_PORT", "SMTP_PASSWORD")
+
+	t.Setenv("EMAIL_BACKEND", "ses")
+	t.Setenv("SES_REGION", "eu-west-1")
+	t.Setenv("SES_ACCESS_KEY_ID", "AKID")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "SES_SECRET_ACCESS_KEY")
+
+	t.Setenv("SES_SECRET_ACCESS_KEY", "secret")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Email.SESEndpoint != "https://email.eu-west-1.amazonaws.com" {
+		t.Errorf("SES endpoint: got %q", cfg.Email.SESEndpoint)
+	}
+
+	t.Setenv("EMAIL_BACKEND", "pigeon")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "EMAIL_BACKEND")
+}
+
+func TestLoad_MobileAppScheme(t *testing.T) {
+	t.Setenv("MOBILE_APP_SCHEME", "PaperTrader")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MobileAppScheme != "papertrader" {
+		t.Errorf("scheme should be lower-cased, got %q", cfg.MobileAppScheme)
+	}
+
+	for _, bad := range []string{"https", "javascript", "my app", "1app"} {
+		t.Setenv("MOBILE_APP_SCHEME", bad)
+		_, err := Load()
+		assertKeys(t, problemKeys(t, err), "MOBILE_APP_SCHEME")
+	}
+}
+
+func TestLoad_MagicLinkRanges(t *testing.T) {
+	t.Setenv("MAGIC_LINK_TTL_SECONDS", "86400")
+	t.Setenv("MAGIC_LINK_EMAIL_LIMIT", "0")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "MAGIC_LINK_TTL_SECONDS", "MAGIC_LINK_EMAIL_LIMIT")
+}
+
+func TestLoad_WebAuthnRPID(t *testing.T) {
+	t.Setenv("FRONTEND_URL", "https://app.PaperTrader.example:8443")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.WebAuthnRPID != "app.papertrader.example" {
+		t.Errorf("RP ID should default to the frontend host, got %q", cfg.WebAuthnRPID)
+	}
+	if cfg.FrontendOrigin() != "https://app.papertrader.example:8443" {
+		t.Errorf("unexpected frontend origin %q", cfg.FrontendOrigin())
+	}
+
+	t.Setenv("WEBAUTHN_RP_ID", "papertrader.example")
+	if _, err := Load(); err != nil {
+		t.Errorf("parent domain should be accepted: %v", err)
+	}
+
+	for _, bad := range []string{"other.example", "per.example", "example.app.papertrader.example"} {
+		t.Setenv("WEBAUTHN_RP_ID", bad)
+		_, err := Load()
+		assertKeys(t, problemKeys(t, err), "WEBAUTHN_RP_ID")
+	}
+}
+
+func TestLoad_RetentionRanges(t *testing.T) {
+	t.Setenv("RETENTION_INACTIVE_DAYS", "7")
+	t.Setenv("RETENTION_GRACE_DAYS", "0")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "RETENTION_INACTIVE_DAYS", "RETENTION_GRACE_DAYS")
+
+	t.Setenv("RETENTION_INACTIVE_DAYS", "365")
+	t.Setenv("RETENTION_GRACE_DAYS", "30")
+	if _, err := Load(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestLoad_TradingRanges(t *testing.T) {
+	t.Setenv("TRADING_ALLOWED_EXCHANGES", "XNAS,NYSE-ARCA")
+	t.Setenv("TRADING_MAX_TRADES_PER_DAY", "-5")
+	t.Setenv("TRADING_PDT_ENABLED", "true")
+	t.Setenv("TRADING_PDT_MAX_DAY_TRADES", "0")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err),
+		"TRADING_ALLOWED_EXCHANGES", "TRADING_MAX_TRADES_PER_DAY", "TRADING_PDT_MAX_DAY_TRADES")
+}
+
+func TestLoad_EmailVerification(t *testing.T) {
+	t.Setenv("TRADING_EMAIL_VERIFICATION", "sometimes")
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "TRADING_EMAIL_VERIFICATION")
+
+	// Enforcing verification needs email to send the links.
+	t.Setenv("TRADING_EMAIL_VERIFICATION", "Cap")
+	t.Setenv("TRADING_UNVERIFIED_TRADE_CAP", "0")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "TRADING_EMAIL_VERIFICATION", "TRADING_UNVERIFIED_TRADE_CAP")
+
+	t.Setenv("FROM_EMAIL", "papertrader@example.com")
+	t.Setenv("EMAIL_OUTBOX_DIR", t.TempDir())
+	t.Setenv("TRADING_UNVERIFIED_TRADE_CAP", "5")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Trading.EmailVerification != "cap" || cfg.Trading.UnverifiedTradeCap != 5 {
+		t.Errorf("got %q with cap %d", cfg.Trading.EmailVerification, cfg.Trading.UnverifiedTradeCap)
+	}
+}
+
+func TestLoad_StartingBalance(t *testing.T) {
+	for _, bad := range []string{"10.005", "20000000"} {
+		t.Setenv("STARTING_BALANCE", bad)
+		_, err := Load()
+		assertKeys(t, problemKeys(t, err), "STARTING_BALANCE")
+	}
+
+	t.Setenv("STARTING_BALANCE", "25000.50")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.StartingBalance.String() != "25000.5" {
+		t.Errorf("unexpected starting balance %s", cfg.StartingBalance)
+	}
+}
+
+func TestLoad_AccountTransferKeys(t *testing.T) {
+	seed := "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=" // 32 bytes
+	t.Setenv("ACCOUNT_TRANSFER_SIGNING_KEY", "c2hvcnQ=")
+	t.Setenv("ACCOUNT_TRANSFER_TRUSTED_KEYS", seed+",not base64")
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "ACCOUNT_TRANSFER_SIGNING_KEY", "ACCOUNT_TRANSFER_TRUSTED_KEYS")
+
+	// Base64 is case-sensitive, so the list keeps its case.
+	t.Setenv("ACCOUNT_TRANSFER_SIGNING_KEY", seed)
+	t.Setenv("ACCOUNT_TRANSFER_TRUSTED_KEYS", " iojj3XQJ8ZX9UtstPLpdcspnCb8dlBIb83SIAbQPb1w= ")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.AccountTransferTrustedKeys) != 1 || cfg.AccountTransferTrustedKeys[0] != "iojj3XQJ8ZX9UtstPLpdcspnCb8dlBIb83SIAbQPb1w=" {
+		t.Errorf("trusted keys: %q", cfg.AccountTransferTrustedKeys)
+	}
+}
+
+func TestLoad_MarketDataProviders(t *testing.T) {
+	t.Setenv("MARKET_DATA_PROVIDER", "bloomberg")
+	t.Setenv("MARKET_DATA_FALLBACK_PROVIDER", "alphavantage")
+	t.Setenv("MARKET_DATA_BREAKER_THRESHOLD", "0")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err),
+		"MARKET_DATA_PROVIDER", "MARKET_DATA_FALLBACK_PROVIDER", "MARKET_DATA_BREAKER_THRESHOLD")
+
+	t.Setenv("MARKET_DATA_PROVIDER", "MarketStack")
+	t.Setenv("ALPHAVANTAGE_API_KEY", "av_key")
+	t.Setenv("MARKET_DATA_BREAKER_THRESHOLD", "5")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MarketDataProvider != "marketstack" || cfg.MarketDataKey(cfg.MarketDataFallback) != "av_key" {
+		t.Errorf("unexpected providers: %q then %q", cfg.MarketDataProvider, cfg.MarketDataFallback)
+	}
+}
+
+func TestLoad_MarketStackQuota(t *testing.T) {
+	t.Setenv("MARKETSTACK_QUOTA_SHED_PERCENT", "80")
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "MARKETSTACK_QUOTA_SHED_PERCENT")
+
+	t.Setenv("MARKETSTACK_MONTHLY_QUOTA", "-1")
+	t.Setenv("MARKETSTACK_QUOTA_SHED_PERCENT", "101")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "MARKETSTACK_MONTHLY_QUOTA", "MARKETSTACK_QUOTA_SHED_PERCENT")
+
+	t.Setenv("MARKETSTACK_MONTHLY_QUOTA", "10000")
+	t.Setenv("MARKETSTACK_QUOTA_SHED_PERCENT", "80")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MarketStackMonthlyQuota != 10000 || cfg.MarketStackQuotaShedPercent != 80 {
+		t.Errorf("unexpected quota: %d, shed at %d%%", cfg.MarketStackMonthlyQuota, cfg.MarketStackQuotaShedPercent)
+	}
+}
+
+func TestLoad_LoginLockout(t *testing.T) {
+	t.Setenv("LOGIN_LOCKOUT_SECONDS", "3600")
+	t.Setenv("LOGIN_LOCKOUT_MAX_SECONDS", "600")
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err), "LOGIN_LOCKOUT_MAX_SECONDS")
+
+	// Durations don't matter with lockout disabled.
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "0")
+	if _, err := Load(); err != nil {
+		t.Errorf("unexpected error with lockout disabled: %v", err)
+	}
+
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "-1")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "LOGIN_LOCKOUT_THRESHOLD")
+}
+
+func TestLoad_Standalone(t *testing.T) {
+	t.Setenv("STANDALONE", "true")
+	t.Setenv("EMAIL_OUTBOX_DIR", "/var/lib/papertrader/outbox")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MarketDataProvider != "simulated" || cfg.CryptoDataProvider != "none" || cfg.RedisURL != "" || !cfg.MigrateOnStart {
+		t.Errorf("unexpected standalone defaults: %+v", cfg)
+	}
+	if !cfg.Email.Enabled() || cfg.Email.OutboxDir != "/var/lib/papertrader/outbox" {
+		t.Errorf("email should go to the outbox: %+v", cfg.Email)
+	}
+
+	t.Setenv("STANDALONE", "false")
+	t.Setenv("MARKET_DATA_FALLBACK_PROVIDER", "simulated")
+	_, err = Load()
+	assertKeys(t, problemKeys(t, err), "MARKET_DATA_FALLBACK_PROVIDER", "FROM_EMAIL")
+}
+
+func TestLoad_ProductionRequirements(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("DATABASE_URL", "postgres://u:p@db.example.com:5432/app")
+	t.Setenv("RESEARCH_ENABLED", "true")
+	t.Setenv("FROM_EMAIL", "noreply@example.com")
+	t.Setenv("EMAIL_BACKEND", "log")
+
+	_, err := Load()
+	assertKeys(t, problemKeys(t, err),
+		"JWT_SECRET", "EMAIL_BACKEND", "MARKETSTACK_API_KEY", "DATABASE_URL", "FRONTEND_URL",
+		"VOYAGE_API_KEY", "GROQ_API_KEY")
+}
+
+func TestLoad_ProductionValid(t *testing.T) {
+	t.Setenv("ENVIRONMENT", "production")
+	t.Setenv("JWT_SECRET", strings.Repeat("s", 32))
+	t.Setenv("MARKETSTACK_API_KEY", "ms_key")
+	t.Setenv("DATABASE_URL", "postgres://u:p@db.example.com:5432/app?sslmode=require")
+	t.Setenv("FRONTEND_URL", "https://papertrader.example.com")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("expected valid production config, got %v", err)
+	}
+	if cfg.Cache.StockTTL != 15*time.Minute || cfg.Cache.IntradayTTL != time.Minute {
+		t.Errorf("production cache defaults: %+v", cfg.Cache)
+	}
+}
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"papertrader/internal/sqlite"
+)
+
+// ConnectDatabase opens the database DATABASE_URL names: a SQLite file for a
+// sqlite: URL, PostgreSQL otherwise. A caller using the stores in
+// internal/data on it must call data.UseSQLite(sqlite.Is(db)).
+func ConnectDatabase(cfg *Config) (*sql.DB, error) {
+	if !sqlite.IsURL(cfg.DatabaseURL) {
+		return ConnectPostgreSQL(cfg)
+	}
+	path := sqlite.Path(cfg.DatabaseURL)
+	db, err := sqlite.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	slog.Info("opened SQLite database", "path", path)
+	return db, nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+// NewHTTPClient builds the client shared by every outbound call. One
+// transport means one connection pool, so repeat calls to a provider reuse
+// warm keep-alive connections instead of paying a TCP and TLS handshake each
+// time. Every call is logged with credentials redacted (see
+// util.LoggingTransport). Load has already validated ProxyURL.
+func NewHTTPClient(cfg *Config) *http.Client {
+	return &http.Client{
+		Transport: util.LoggingTransport(newTransport(cfg.HTTPClient)),
+		Timeout:   cfg.HTTPClient.Timeout,
+	}
+}
+
+func newTransport(hc HTTPClientConfig) *http.Transport {
+	proxy := http.ProxyFromEnvironment
+	if hc.ProxyURL != "" {
+		if u, err := url.Parse(hc.ProxyURL); err == nil {
+			proxy = http.ProxyURL(u)
+		}
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          hc.MaxIdleConns,
+		MaxIdleConnsPerHost:   hc.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       hc.MaxConnsPerHost,
+		IdleConnTimeout:       hc.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
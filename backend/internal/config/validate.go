@@ -0,0 +1,601 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/sqlite"
+)
+
+const (
+	defaultJWTSecret = "default-insecure-secret-key-change-me"
+	minJWTSecretLen  = 32
+
+	minSudoTTL = 30 * time.Second
+	maxSudoTTL = time.Hour
+
+	minMagicLinkTTL = time.Minute
+	maxMagicLinkTTL = time.Hour
+
+	minGuestAccountTTL = time.Hour
+
+	// minRetentionInactiveDays keeps a typo from warning every account that
+	// skipped a few weeks; maxRetentionGraceDays bounds how long a warning
+	// stays pending.
+	minRetentionInactiveDays = 30
+	minRetentionGraceDays    = 7
+	maxRetentionGraceDays    = 365
+
+	// maxStartingBalance matches the cap on cohort balances set by invite
+	// codes and the bulk import.
+	maxStartingBalance = 10000000
+
+	// minBonusCashCooldown keeps bonus cash a comeback path rather than a
+	// tap to hold down.
+	minBonusCashCooldown = time.Hour
+
+	// Market data cache lifetimes. Quotes kept longer than a day would go
+	// on being served after the next close; EOD ranges are keyed by day, so
+	// a week is already far more than they can be used for.
+	minStockCacheTTL      = 10 * time.Second
+	maxStockCacheTTL      = 24 * time.Hour
+	minHistoricalCacheTTL = time.Minute
+	maxHistoricalCacheTTL = 7 * 24 * time.Hour
+	minIntradayCacheTTL   = 10 * time.Second
+	maxIntradayCacheTTL   = time.Hour
+)
+
+// marketDataProviders are the names NewMarketDataProvider accepts.
+var marketDataProviders = map[string]bool{"marketstack": true, "alphavantage": true, "simulated": true}
+
+var appSchemePattern = regexp.MustCompile(`^[a-z][a-z0-9+.-]*$`)
+
+// reservedSchemes can never be a mobile app's own scheme; allowing them
+// would turn deep-link redirects into open redirects or script execution.
+var reservedSchemes = map[string]bool{
+	"http": true, "https": true, "javascript": true, "data": true, "file": true,
+	"vbscript": true, "blob": true, "about": true, "mailto": true, "tel": true,
+}
+
+// Problem is one configuration error, attributed to the environment variable
+// that caused it.
+type Problem struct {
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Key + ": " + p.Message
+}
+
+// ValidationError collects every problem found by Load.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d configuration problem(s):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+// validate checks cross-field rules and value ranges on a loaded Config.
+// Production adds requirements for secrets and TLS that would only be
+// friction in development.
+func validate(cfg *Config) []Problem {
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		add("PORT", "must be a port number between 1 and 65535, got %q", cfg.Port)
+	}
+	if msg := checkURL(cfg.FrontendURL, "http", "https"); msg != "" {
+		add("FRONTEND_URL", "%s", msg)
+	}
+	if cfg.DatabaseURL == "" {
+		add("DATABASE_URL", "is required")
+	} else if sqlite.IsURL(cfg.DatabaseURL) {
+		if sqlite.Path(cfg.DatabaseURL) == "" {
+			add("DATABASE_URL", "must name a file after sqlite:, got %q", cfg.DatabaseURL)
+		}
+		if cfg.ResearchEnabled {
+			// Research retrieval needs pgvector.
+			add("RESEARCH_ENABLED", "requires a PostgreSQL DATABASE_URL")
+		}
+	} else if msg := checkURL(cfg.DatabaseURL, "postgres", "postgresql"); msg != "" {
+		add("DATABASE_URL", "%s", msg)
+	}
+	if !marketDataProviders[cfg.MarketDataProvider] {
+		add("MARKET_DATA_PROVIDER", "must be one of marketstack, alphavantage, simulated; got %q", cfg.MarketDataProvider)
+	}
+	if cfg.MarketDataFallback != "" {
+		// Failing over to invented prices would pass them off as real ones.
+		if !marketDataProviders[cfg.MarketDataFallback] || cfg.MarketDataFallback == "simulated" {
+			add("MARKET_DATA_FALLBACK_PROVIDER", "must be empty or one of marketstack, alphavantage; got %q", cfg.MarketDataFallback)
+		} else if cfg.MarketDataFallback == cfg.MarketDataProvider {
+			add("MARKET_DATA_FALLBACK_PROVIDER", "must differ from MARKET_DATA_PROVIDER")
+		} else if cfg.MarketDataKey(cfg.MarketDataFallback) == "" {
+			add("MARKET_DATA_FALLBACK_PROVIDER", "%s needs its API key set", cfg.MarketDataFallback)
+		}
+	}
+	if cfg.CryptoDataProvider != "coinbase" && cfg.CryptoDataProvider != "none" {
+		add("CRYPTO_DATA_PROVIDER", "must be coinbase or none; got %q", cfg.CryptoDataProvider)
+	}
+	if cfg.MarketDataBreakerThreshold < 1 {
+		add("MARKET_DATA_BREAKER_THRESHOLD", "must be at least 1, got %d", cfg.MarketDataBreakerThreshold)
+	}
+	if cfg.MarketStackMonthlyQuota < 0 {
+		add("MARKETSTACK_MONTHLY_QUOTA", "must not be negative, got %d", cfg.MarketStackMonthlyQuota)
+	}
+	if p := cfg.MarketStackQuotaShedPercent; p < 0 || p > 100 {
+		add("MARKETSTACK_QUOTA_SHED_PERCENT", "must be between 0 and 100, got %d", p)
+	} else if p > 0 && cfg.MarketStackMonthlyQuota <= 0 {
+		add("MARKETSTACK_QUOTA_SHED_PERCENT", "needs MARKETSTACK_MONTHLY_QUOTA set")
+	}
+	if msg := checkURL(cfg.FXAPIURL, "http", "https"); msg != "" {
+		add("FX_API_URL", "%s", msg)
+	}
+	if cfg.RedisURL != "" {
+		if msg := checkURL(cfg.RedisURL, "redis", "rediss"); msg != "" {
+			add("REDIS_URL", "%s", msg)
+		}
+	}
+	if cfg.RedisDB < 0 {
+		add("REDIS_DB", "must not be negative, got %d", cfg.RedisDB)
+	}
+	for _, c := range []struct {
+		key      string
+		ttl      time.Duration
+		min, max time.Duration
+	}{
+		{"CACHE_STOCK_TTL_SECONDS", cfg.Cache.StockTTL, minStockCacheTTL, maxStockCacheTTL},
+		{"CACHE_HISTORICAL_TTL_SECONDS", cfg.Cache.HistoricalTTL, minHistoricalCacheTTL, maxHistoricalCacheTTL},
+		{"CACHE_INTRADAY_TTL_SECONDS", cfg.Cache.IntradayTTL, minIntradayCacheTTL, maxIntradayCacheTTL},
+	} {
+		if c.ttl < c.min || c.ttl > c.max {
+			add(c.key, "must be between %d and %d, got %d", int(c.min.Seconds()), int(c.max.Seconds()), int(c.ttl.Seconds()))
+		}
+	}
+	if cfg.Cache.MemoryEntries < 0 {
+		add("CACHE_MEMORY_MAX_ENTRIES", "must not be negative, got %d", cfg.Cache.MemoryEntries)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.LogLevel)) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		add("LOG_LEVEL", "must be one of debug, info, warn, error; got %q", cfg.LogLevel)
+	}
+
+	if cfg.Email.FromEmail != "" {
+		if _, err := mail.ParseAddress(cfg.Email.FromEmail); err != nil {
+			add("FROM_EMAIL", "is not a valid email address: %q", cfg.Email.FromEmail)
+		}
+	} else if cfg.Email.Backend != "" {
+		add("FROM_EMAIL", "is required when EMAIL_BACKEND, RESEND_API_KEY or EMAIL_OUTBOX_DIR is set; email would be silently disabled")
+	}
+	switch e := cfg.Email; e.Backend {
+	case "", "log":
+	case "resend":
+		if e.ResendAPIKey == "" {
+			add("RESEND_API_KEY", "is required when EMAIL_BACKEND=resend")
+		}
+	case "outbox":
+		if e.OutboxDir == "" {
+			add("EMAIL_OUTBOX_DIR", "is required when EMAIL_BACKEND=outbox")
+		}
+	case "smtp":
+		if e.SMTPHost == "" {
+			add("SMTP_HOST", "is required when EMAIL_BACKEND=smtp")
+		}
+		if e.SMTPPort < 1 || e.SMTPPort > 65535 {
+			add("SMTP_PORT", "must be between 1 and 65535, got %d", e.SMTPPort)
+		}
+		if e.SMTPUsername != "" && e.SMTPPassword == "" {
+			add("SMTP_PASSWORD", "is required when SMTP_USERNAME is set")
+		}
+		if e.SMTPPassword != "" && e.SMTPUsername == "" {
+			add("SMTP_USERNAME", "is required when SMTP_PASSWORD is set")
+		}
+	case "ses":
+		if msg := checkURL(e.SESEndpoint, "http", "https"); msg != "" {
+			add("SES_ENDPOINT", "%s", msg)
+		}
+		for _, c := range []struct{ key, value string }{
+			{"SES_REGION", e.SESRegion},
+			{"SES_ACCESS_KEY_ID", e.SESAccessKeyID},
+			{"SES_SECRET_ACCESS_KEY", e.SESSecretAccessKey},
+		} {
+			if c.value == "" {
+				add(c.key, "is required when EMAIL_BACKEND=ses")
+			}
+		}
+	default:
+		add("EMAIL_BACKEND", "must be resend, smtp, ses, outbox or log, got %q", e.Backend)
+	}
+	for _, email := range cfg.AdminEmails {
+		if _, err := mail.ParseAddress(email); err != nil {
+			add("ADMIN_EMAILS", "contains an invalid email address: %q", email)
+		}
+	}
+
+	if cfg.MobileAppScheme != "" {
+		if !appSchemePattern.MatchString(cfg.MobileAppScheme) {
+			add("MOBILE_APP_SCHEME", "must be a URL scheme (letters, digits, '+', '-', '.'; starting with a letter), got %q", cfg.MobileAppScheme)
+		} else if reservedSchemes[cfg.MobileAppScheme] {
+			add("MOBILE_APP_SCHEME", "must be an app-specific scheme, not %q", cfg.MobileAppScheme)
+		}
+	}
+
+	if cfg.ResearchIngestMaxFilings < 1 {
+		add("RESEARCH_INGEST_MAX_FILINGS", "must be at least 1, got %d", cfg.ResearchIngestMaxFilings)
+	}
+
+	ls := cfg.LoadShed
+	if ls.MaxInFlight < 0 {
+		add("LOAD_SHED_MAX_IN_FLIGHT", "must be 0 (disabled) or more, got %d", ls.MaxInFlight)
+	}
+	if ls.DBPoolPct < 0 || ls.DBPoolPct > 100 {
+		add("LOAD_SHED_DB_POOL_PCT", "must be between 0 (disabled) and 100, got %d", ls.DBPoolPct)
+	}
+	if ls.RetryAfter < time.Second {
+		add("LOAD_SHED_RETRY_AFTER_SECONDS", "must be at least 1, got %d", int(ls.RetryAfter.Seconds()))
+	}
+	for _, prefix := range ls.LowPriorityPaths {
+		if !strings.HasPrefix(prefix, "/") {
+			add("LOAD_SHED_LOW_PRIORITY_PATHS", "entries must be path prefixes starting with /, got %q", prefix)
+		}
+	}
+
+	live := cfg.Live
+	if live.Interval < time.Second {
+		add("LIVE_PRICE_INTERVAL_SECONDS", "must be at least 1, got %d", int(live.Interval.Seconds()))
+	}
+	if live.MaxClients < 1 {
+		add("LIVE_MAX_CLIENTS", "must be at least 1, got %d", live.MaxClients)
+	}
+	if live.MaxSymbols < 1 {
+		add("LIVE_MAX_SYMBOLS", "must be at least 1, got %d", live.MaxSymbols)
+	}
+
+	movers := cfg.Movers
+	switch movers.Index {
+	case "", "DJI", "SPX", "NDX":
+	default:
+		add("MARKET_MOVERS_INDEX", "must be DJI, SPX, NDX or empty, got %q", movers.Index)
+	}
+	if movers.Interval < time.Minute {
+		add("MARKET_MOVERS_INTERVAL_SECONDS", "must be at least 60, got %d", int(movers.Interval.Seconds()))
+	}
+
+	news := cfg.News
+	switch news.Provider {
+	case "", "alphavantage", "finnhub":
+	default:
+		add("NEWS_PROVIDER", "must be alphavantage, finnhub or empty, got %q", news.Provider)
+	}
+	if news.CacheTTL < time.Minute {
+		add("NEWS_CACHE_TTL_SECONDS", "must be at least 60, got %d", int(news.CacheTTL.Seconds()))
+	}
+
+	rl := cfg.RateLimits
+	for _, c := range []struct {
+		key   string
+		value int
+	}{
+		{"RATE_LIMIT_USER", rl.UserLimit},
+		{"RATE_LIMIT_IP", rl.IPLimit},
+		{"RATE_LIMIT_ASK_USER", rl.AskUserLimit},
+		{"RATE_LIMIT_ASK_IP", rl.AskIPLimit},
+	} {
+		if c.value < 1 {
+			add(c.key, "must be at least 1, got %d", c.value)
+		}
+	}
+	if rl.ExpensiveConcurrency < 0 {
+		add("RATE_LIMIT_EXPENSIVE_CONCURRENCY", "must be 0 (disabled) or more, got %d", rl.ExpensiveConcurrency)
+	}
+	if rl.ExpensiveRetryAfter < time.Second {
+		add("RATE_LIMIT_EXPENSIVE_RETRY_AFTER_SECONDS", "must be at least 1, got %d", int(rl.ExpensiveRetryAfter.Seconds()))
+	}
+
+	// trades.quantity is a Postgres INTEGER.
+	if cfg.Trading.MaxQuantity < 1 || cfg.Trading.MaxQuantity > math.MaxInt32 {
+		add("TRADING_MAX_QUANTITY", "must be between 1 and %d, got %d", math.MaxInt32, cfg.Trading.MaxQuantity)
+	}
+	for _, mic := range cfg.Trading.AllowedExchanges {
+		if len(mic) != 4 {
+			add("TRADING_ALLOWED_EXCHANGES", "entries must be 4-character exchange MICs, got %q", mic)
+		}
+	}
+	if cfg.Trading.MaxTradesPerDay < 0 {
+		add("TRADING_MAX_TRADES_PER_DAY", "must be 0 (unlimited) or more, got %d", cfg.Trading.MaxTradesPerDay)
+	}
+	if cfg.Trading.PDTEnabled && cfg.Trading.PDTMaxDayTrades < 1 {
+		add("TRADING_PDT_MAX_DAY_TRADES", "must be at least 1 when TRADING_PDT_ENABLED=true, got %d", cfg.Trading.PDTMaxDayTrades)
+	}
+	if cfg.Trading.MaxConditionalOrders < 1 {
+		add("TRADING_MAX_CONDITIONAL_ORDERS", "must be at least 1, got %d", cfg.Trading.MaxConditionalOrders)
+	}
+	if cfg.Trading.GTCMaxDays < 0 {
+		add("TRADING_GTC_MAX_DAYS", "must be 0 (no cap) or more, got %d", cfg.Trading.GTCMaxDays)
+	}
+	if w := cfg.Trading.OrderWaitMax; w < time.Second || w > 5*time.Minute {
+		add("TRADING_ORDER_WAIT_MAX_SECONDS", "must be between 1 and 300, got %d", int(w.Seconds()))
+	}
+	if cfg.Trading.MaxRecurringInvestments < 0 {
+		add("TRADING_MAX_RECURRING_INVESTMENTS", "must be 0 (unlimited) or more, got %d", cfg.Trading.MaxRecurringInvestments)
+	}
+	if cfg.Trading.MaxHoldings < 0 {
+		add("TRADING_MAX_HOLDINGS", "must be 0 (unlimited) or more, got %d", cfg.Trading.MaxHoldings)
+	}
+	if cfg.WatchlistMaxEntries < 0 {
+		add("WATCHLIST_MAX_ENTRIES", "must be 0 (unlimited) or more, got %d", cfg.WatchlistMaxEntries)
+	}
+	if pct := cfg.Trading.ShortMarginPct; !pct.IsPositive() || pct.GreaterThan(decimal.NewFromInt(1000)) {
+		add("TRADING_SHORT_MARGIN_PCT", "must be greater than 0 and at most 1000, got %s", pct)
+	}
+	if pct := cfg.Trading.SlippagePct; pct.IsNegative() || pct.GreaterThan(decimal.NewFromInt(10)) {
+		add("TRADING_SLIPPAGE_PCT", "must be between 0 and 10, got %s", pct)
+	}
+	if pct := cfg.Trading.RiskFreeRatePct; pct.GreaterThan(decimal.NewFromInt(20)) {
+		add("TRADING_RISK_FREE_RATE_PCT", "must be between 0 and 20, got %s", pct)
+	}
+	switch cfg.Trading.EmailVerification {
+	case "off":
+	case "block", "cap":
+		if !cfg.Email.Enabled() {
+			add("TRADING_EMAIL_VERIFICATION", "%q needs email configured, or nobody could verify", cfg.Trading.EmailVerification)
+		}
+		if cfg.Trading.EmailVerification == "cap" && cfg.Trading.UnverifiedTradeCap < 1 {
+			add("TRADING_UNVERIFIED_TRADE_CAP", "must be at least 1 when TRADING_EMAIL_VERIFICATION=cap, got %d", cfg.Trading.UnverifiedTradeCap)
+		}
+	default:
+		add("TRADING_EMAIL_VERIFICATION", "must be off, block or cap, got %q", cfg.Trading.EmailVerification)
+	}
+
+	switch st := cfg.Storage; st.Driver {
+	case "local":
+		if st.LocalDir == "" {
+			add("STORAGE_LOCAL_DIR", "is required when STORAGE_DRIVER=local")
+		}
+	case "s3":
+		if msg := checkURL(st.S3Endpoint, "http", "https"); msg != "" {
+			add("STORAGE_S3_ENDPOINT", "%s", msg)
+		}
+		for _, c := range []struct{ key, value string }{
+			{"STORAGE_S3_BUCKET", st.S3Bucket},
+			{"STORAGE_S3_ACCESS_KEY_ID", st.S3AccessKeyID},
+			{"STORAGE_S3_SECRET_ACCESS_KEY", st.S3SecretAccessKey},
+		} {
+			if c.value == "" {
+				add(c.key, "is required when STORAGE_DRIVER=s3")
+			}
+		}
+		if st.PublicURL != "" {
+			if msg := checkURL(st.PublicURL, "http", "https"); msg != "" {
+				add("STORAGE_PUBLIC_URL", "%s", msg)
+			}
+		}
+	default:
+		add("STORAGE_DRIVER", "must be local or s3, got %q", st.Driver)
+	}
+	if cfg.Storage.AvatarMaxBytes > cfg.MaxRequestSize {
+		add("AVATAR_MAX_BYTES", "must not exceed MAX_REQUEST_SIZE (%d), got %d", cfg.MaxRequestSize, cfg.Storage.AvatarMaxBytes)
+	}
+
+	hc := cfg.HTTPClient
+	for _, c := range []struct {
+		key   string
+		value int
+	}{
+		{"HTTP_CLIENT_MAX_IDLE_CONNS", hc.MaxIdleConns},
+		{"HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", hc.MaxIdleConnsPerHost},
+		{"HTTP_CLIENT_MAX_CONNS_PER_HOST", hc.MaxConnsPerHost},
+	} {
+		if c.value < 0 {
+			add(c.key, "must be 0 (unlimited) or more, got %d", c.value)
+		}
+	}
+	if hc.MaxConnsPerHost > 0 && hc.MaxIdleConnsPerHost > hc.MaxConnsPerHost {
+		add("HTTP_CLIENT_MAX_IDLE_CONNS_PER_HOST", "must not exceed HTTP_CLIENT_MAX_CONNS_PER_HOST (%d), got %d", hc.MaxConnsPerHost, hc.MaxIdleConnsPerHost)
+	}
+	if hc.ProxyURL != "" {
+		if msg := checkURL(hc.ProxyURL, "http", "https", "socks5"); msg != "" {
+			add("HTTP_CLIENT_PROXY_URL", "%s", msg)
+		}
+	}
+
+	if cfg.AnomalyFailedLoginThreshold < 0 {
+		add("ANOMALY_FAILED_LOGIN_THRESHOLD", "must be 0 (disabled) or more, got %d", cfg.AnomalyFailedLoginThreshold)
+	}
+	if cfg.LoginLockoutThreshold < 0 {
+		add("LOGIN_LOCKOUT_THRESHOLD", "must be 0 (disabled) or more, got %d", cfg.LoginLockoutThreshold)
+	}
+	if cfg.LoginLockoutThreshold > 0 {
+		if cfg.LoginLockoutDuration < time.Second {
+			add("LOGIN_LOCKOUT_SECONDS", "must be at least 1, got %d", int(cfg.LoginLockoutDuration.Seconds()))
+		}
+		if cfg.LoginLockoutMaxDuration < cfg.LoginLockoutDuration {
+			add("LOGIN_LOCKOUT_MAX_SECONDS", "must be at least LOGIN_LOCKOUT_SECONDS (%d), got %d",
+				int(cfg.LoginLockoutDuration.Seconds()), int(cfg.LoginLockoutMaxDuration.Seconds()))
+		}
+	}
+	if cfg.SudoTTL < minSudoTTL || cfg.SudoTTL > maxSudoTTL {
+		add("SUDO_TTL_SECONDS", "must be between %d and %d, got %d",
+			int(minSudoTTL.Seconds()), int(maxSudoTTL.Seconds()), int(cfg.SudoTTL.Seconds()))
+	}
+
+	if cfg.MagicLinkTTL < minMagicLinkTTL || cfg.MagicLinkTTL > maxMagicLinkTTL {
+		add("MAGIC_LINK_TTL_SECONDS", "must be between %d and %d, got %d",
+			int(minMagicLinkTTL.Seconds()), int(maxMagicLinkTTL.Seconds()), int(cfg.MagicLinkTTL.Seconds()))
+	}
+	if cfg.MagicLinkEmailLimit < 1 {
+		add("MAGIC_LINK_EMAIL_LIMIT", "must be at least 1, got %d", cfg.MagicLinkEmailLimit)
+	}
+	if cfg.MagicLinkIPLimit < 1 {
+		add("MAGIC_LINK_IP_LIMIT", "must be at least 1, got %d", cfg.MagicLinkIPLimit)
+	}
+
+	// Browsers only use a passkey on pages whose host is the RP ID or a
+	// subdomain of it.
+	if u, err := url.Parse(cfg.FrontendURL); err == nil && u.Hostname() != "" {
+		host := strings.ToLower(u.Hostname())
+		if cfg.WebAuthnRPID != host && !strings.HasSuffix(host, "."+cfg.WebAuthnRPID) {
+			add("WEBAUTHN_RP_ID", "must be the FRONTEND_URL host (%s) or a parent domain of it, got %q", host, cfg.WebAuthnRPID)
+		}
+	}
+
+	if cfg.GuestAccountTTL < minGuestAccountTTL {
+		add("GUEST_ACCOUNT_TTL_SECONDS", "must be at least %d, got %d",
+			int(minGuestAccountTTL.Seconds()), int(cfg.GuestAccountTTL.Seconds()))
+	}
+
+	if d := cfg.RetentionInactiveDays; d != 0 && d < minRetentionInactiveDays {
+		add("RETENTION_INACTIVE_DAYS", "must be 0 (off) or at least %d, got %d", minRetentionInactiveDays, d)
+	}
+	if d := cfg.RetentionGraceDays; d < minRetentionGraceDays || d > maxRetentionGraceDays {
+		add("RETENTION_GRACE_DAYS", "must be between %d and %d, got %d", minRetentionGraceDays, maxRetentionGraceDays, d)
+	}
+
+	if b := cfg.StartingBalance; b.GreaterThan(decimal.NewFromInt(maxStartingBalance)) || !b.Equal(b.Round(2)) {
+		add("STARTING_BALANCE", "must be between 0 and %d with at most 2 decimal places, got %s", maxStartingBalance, b)
+	}
+	if b := cfg.BonusCashAmount; b.GreaterThan(decimal.NewFromInt(maxStartingBalance)) || !b.Equal(b.Round(2)) {
+		add("BONUS_CASH_AMOUNT", "must be between 0 and %d with at most 2 decimal places, got %s", maxStartingBalance, b)
+	}
+	if cfg.BonusCashAmount.IsPositive() && cfg.BonusCashCooldown < minBonusCashCooldown {
+		add("BONUS_CASH_COOLDOWN_SECONDS", "must be at least %d when BONUS_CASH_AMOUNT is set, got %d",
+			int(minBonusCashCooldown.Seconds()), int(cfg.BonusCashCooldown.Seconds()))
+	}
+
+	// Ed25519: a 32-byte seed or 64-byte private key, and 32-byte public keys.
+	if k := cfg.AccountTransferSigningKey; k != "" {
+		if msg := checkBase64Key(k, 32, 64); msg != "" {
+			add("ACCOUNT_TRANSFER_SIGNING_KEY", "%s", msg)
+		}
+	}
+	for i, k := range cfg.AccountTransferTrustedKeys {
+		if msg := checkBase64Key(k, 32); msg != "" {
+			add("ACCOUNT_TRANSFER_TRUSTED_KEYS", "entry %d %s", i+1, msg)
+		}
+	}
+
+	if cfg.IsProduction() {
+		problems = append(problems, validateProduction(cfg)...)
+	}
+	return problems
+}
+
+func validateProduction(cfg *Config) []Problem {
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if cfg.JWTSecret == defaultJWTSecret || len(cfg.JWTSecret) < minJWTSecretLen {
+		add("JWT_SECRET", "must be set to a strong secret (%d+ characters) in production; current length: %d", minJWTSecretLen, len(cfg.JWTSecret))
+	}
+
+	// The dry-run backend logs the links in each email, which sign people in.
+	if cfg.Email.Backend == "log" {
+		add("EMAIL_BACKEND", "must not be log in production; it writes login links to the logs")
+	}
+
+	if cfg.MarketDataProvider == "marketstack" && cfg.MarketStackKey == "" {
+		add("MARKETSTACK_API_KEY", "is required in production")
+	}
+	if cfg.MarketDataProvider == "alphavantage" && cfg.AlphaVantageKey == "" {
+		add("ALPHAVANTAGE_API_KEY", "is required in production when MARKET_DATA_PROVIDER=alphavantage")
+	}
+
+	// Database SSL — sslmode=prefer is intentionally NOT accepted: it falls back
+	// to plaintext without error if the server doesn't offer TLS, which silently
+	// downgrades security on misconfigured external connections.
+	hasSSLMode := strings.Contains(cfg.DatabaseURL, "sslmode=require") ||
+		strings.Contains(cfg.DatabaseURL, "sslmode=verify-full") ||
+		strings.Contains(cfg.DatabaseURL, "sslmode=verify-ca") ||
+		strings.Contains(cfg.DatabaseURL, "sslmode=disable")
+
+	// sslmode=disable is permitted only for internal Docker/loopback connections,
+	// where traffic never leaves the host.
+	isInternalConnection := strings.Contains(cfg.DatabaseURL, "@postgres:") ||
+		strings.Contains(cfg.DatabaseURL, "@localhost:") ||
+		strings.Contains(cfg.DatabaseURL, "@127.0.0.1:")
+
+	switch {
+	case sqlite.IsURL(cfg.DatabaseURL):
+		// A SQLite file has no connection to secure.
+	case !hasSSLMode:
+		add("DATABASE_URL", "must specify sslmode in production; add sslmode=require (external) or sslmode=disable (internal Docker)")
+	case !isInternalConnection &&
+		!strings.Contains(cfg.DatabaseURL, "sslmode=require") &&
+		!strings.Contains(cfg.DatabaseURL, "sslmode=verify-full"):
+		add("DATABASE_URL", "external database connections must use SSL in production; add sslmode=require")
+	}
+
+	if cfg.FrontendURL == "" || cfg.FrontendURL == "http://localhost:3000" {
+		add("FRONTEND_URL", "must be set to the production domain in production")
+	}
+
+	if cfg.ResearchEnabled {
+		if cfg.VoyageAPIKey == "" {
+			add("VOYAGE_API_KEY", "is required in production when RESEARCH_ENABLED=true")
+		}
+		if cfg.GroqAPIKey == "" {
+			add("GROQ_API_KEY", "is required in production when RESEARCH_ENABLED=true")
+		}
+		if !strings.Contains(cfg.SecUserAgent, "@") {
+			add("SEC_USER_AGENT", "must contain a valid contact email (with '@') in production")
+		}
+	}
+	return problems
+}
+
+// checkBase64Key returns a description of what is wrong with raw, or "" when
+// it is standard base64 of one of the given byte lengths.
+func checkBase64Key(raw string, sizes ...int) string {
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return "must be base64"
+	}
+	want := make([]string, len(sizes))
+	for i, n := range sizes {
+		if len(key) == n {
+			return ""
+		}
+		want[i] = strconv.Itoa(n)
+	}
+	return fmt.Sprintf("must decode to %s bytes, got %d", strings.Join(want, " or "), len(key))
+}
+
+// checkURL returns a description of what is wrong with raw, or "" when it is
+// an absolute URL with a host and one of the given schemes.
+func checkURL(raw string, schemes ...string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Sprintf("is not a valid URL: %v", err)
+	}
+	if u.Host == "" {
+		return fmt.Sprintf("must be an absolute URL with a host, got %q", raw)
+	}
+	for _, s := range schemes {
+		if strings.EqualFold(u.Scheme, s) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("scheme must be %s, got %q", strings.Join(schemes, " or "), u.Scheme)
+}
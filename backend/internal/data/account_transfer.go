@@ -0,0 +1,297 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrAccountNotEmpty is returned by AccountTransferStore.Import when the
+// account already has trades, holdings or pending orders and the import
+// was not asked to replace them.
+var ErrAccountNotEmpty = errors.New("account is not empty")
+
+// ErrBundleImported is returned by AccountTransferStore.Import when the
+// bundle has been imported before, into any account.
+var ErrBundleImported = errors.New("account bundle already imported")
+
+// AccountState is the part of a paper account that moves with its owner
+// between deployments: cash, settings, holdings, open tax lots and the trade
+// history. Sign-in identity, the watchlist, orders, bonus cash and the daily
+// snapshots stay behind.
+type AccountState struct {
+	Balance         decimal.Decimal `json:"balance"`
+	StartingBalance decimal.Decimal `json:"starting_balance"`
+	Settings        AccountSettings `json:"settings"`
+	Holdings        []HoldingState  `json:"holdings"`
+	Lots            []LotState      `json:"tax_lots"`
+	Trades          []TradeState    `json:"trades"`
+}
+
+// AccountSettings are the user_settings columns, defaults filled in.
+type AccountSettings struct {
+	DisplayCurrency         string `json:"display_currency"`
+	AfterHoursOrders        string `json:"after_hours_orders"`
+	CostBasisMethod         string `json:"cost_basis_method"`
+	TradeConfirmationEmails bool   `json:"trade_confirmation_emails"`
+	StatementEmails         bool   `json:"statement_emails"`
+	Timezone                string `json:"timezone"`
+}
+
+// HoldingState is one position. Quantity is negative for a short, which
+// also carries the margin it posted.
+type HoldingState struct {
+	Symbol   string          `json:"symbol"`
+	Quantity decimal.Decimal `json:"quantity"`
+	AvgPrice decimal.Decimal `json:"avg_price"`
+	Margin   decimal.Decimal `json:"margin"`
+}
+
+// LotState is one open tax lot. TradeID is the exported ID of the buy that
+// opened it, empty for lots backfilled from a holding.
+type LotState struct {
+	TradeID    string          `json:"trade_id,omitempty"`
+	Symbol     string          `json:"symbol"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Remaining  decimal.Decimal `json:"remaining"`
+	Price      decimal.Decimal `json:"price"`
+	AcquiredAt time.Time       `json:"acquired_at"`
+}
+
+// TradeState is one trade as it was executed.
+type TradeState struct {
+	ID         string          `json:"id"`
+	Symbol     string          `json:"symbol"`
+	Action     string          `json:"action"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"`
+	Slippage   decimal.Decimal `json:"slippage"`
+	OrderType  string          `json:"order_type"`
+	Status     string          `json:"status"`
+	ExecutedAt time.Time       `json:"executed_at"`
+}
+
+// AccountTransferStore reads a user's AccountState out for export and writes
+// one back in on import.
+type AccountTransferStore struct {
+	db DBTX
+}
+
+func NewAccountTransferStore(db DBTX) *AccountTransferStore {
+	return &AccountTransferStore{db: db}
+}
+
+// Export returns userID's account state, trades oldest first. Returns
+// sql.ErrNoRows when the user does not exist.
+func (s *AccountTransferStore) Export(ctx context.Context, userID string) (*AccountState, error) {
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return exportAccount(ctx, s.db, userID)
+	}
+	// One snapshot, so a trade landing mid-export can't leave the holdings
+	// and the history disagreeing.
+	tx, err := beginner.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	return exportAccount(ctx, tx, userID)
+}
+
+func exportAccount(ctx context.Context, db DBTX, userID string) (*AccountState, error) {
+	st := &AccountState{Holdings: make([]HoldingState, 0), Lots: make([]LotState, 0), Trades: make([]TradeState, 0)}
+	query := `SELECT users.balance, users.starting_balance, ` + userSettingDisplayCurrency + `, ` +
+		userSettingAfterHoursOrders + `, ` + userSettingCostBasisMethod + `, ` + userSettingTradeConfirmationEmails + `, ` +
+		`COALESCE(s.statement_emails, FALSE), ` + userSettingTimezone + ` FROM ` + userTables + ` WHERE users.id = $1`
+	err := db.QueryRowContext(ctx, query, userID).Scan(&st.Balance, &st.StartingBalance,
+		&st.Settings.DisplayCurrency, &st.Settings.AfterHoursOrders, &st.Settings.CostBasisMethod,
+		&st.Settings.TradeConfirmationEmails, &st.Settings.StatementEmails, &st.Settings.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+	SELECT symbol, quantity, avg_price, margin
+	FROM portfolio
+	WHERE user_id = $1 AND quantity <> 0
+	ORDER BY symbol`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h HoldingState
+		if err := rows.Scan(&h.Symbol, &h.Quantity, &h.AvgPrice, &h.Margin); err != nil {
+			return nil, err
+		}
+		st.Holdings = append(st.Holdings, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	lots, err := db.QueryContext(ctx, `
+	SELECT COALESCE(trade_id, ''), symbol, quantity, remaining, price, acquired_at
+	FROM tax_lots
+	WHERE user_id = $1 AND remaining > 0
+	ORDER BY acquired_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer lots.Close()
+	for lots.Next() {
+		var l LotState
+		if err := lots.Scan(&l.TradeID, &l.Symbol, &l.Quantity, &l.Remaining, &l.Price, &l.AcquiredAt); err != nil {
+			return nil, err
+		}
+		st.Lots = append(st.Lots, l)
+	}
+	if err := lots.Err(); err != nil {
+		return nil, err
+	}
+
+	trades, err := db.QueryContext(ctx, `
+	SELECT id, symbol, action, quantity, price, slippage, order_type, status, executed_at
+	FROM trades
+	WHERE user_id = $1
+	ORDER BY executed_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer trades.Close()
+	for trades.Next() {
+		var t TradeState
+		if err := trades.Scan(&t.ID, &t.Symbol, &t.Action, &t.Quantity, &t.Price, &t.Slippage, &t.OrderType, &t.Status, &t.ExecutedAt); err != nil {
+			return nil, err
+		}
+		st.Trades = append(st.Trades, t)
+	}
+	return st, trades.Err()
+}
+
+// BundleImport identifies an imported bundle: its ID, the deployment it
+// names as its source and the public key it was signed with.
+type BundleImport struct {
+	BundleID string
+	Source   string
+	KeyID    string
+}
+
+// Import writes st, from the bundle imp, into userID's account in one
+// transaction. A bundle already imported is refused with ErrBundleImported,
+// and imp is recorded as imported with the rest. An account with trades,
+// holdings or pending orders is refused with ErrAccountNotEmpty unless
+// replace is set, in which case it is first reset as by
+// UserStore.ResetAccount and Import reports true. Trades get new IDs, and
+// lots are linked to the new ID of their trade; executed_at and acquired_at
+// are kept. Returns sql.ErrNoRows when the user does not exist.
+func (s *AccountTransferStore) Import(ctx context.Context, userID string, imp BundleImport, st *AccountState, replace bool) (bool, error) {
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return importAccount(ctx, s.db, userID, imp, st, replace)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	replaced, err := importAccount(ctx, tx, userID, imp, st, replace)
+	if err != nil {
+		return false, err
+	}
+	return replaced, tx.Commit()
+}
+
+func importAccount(ctx context.Context, db DBTX, userID string, imp BundleImport, st *AccountState, replace bool) (bool, error) {
+	var used bool
+	err := db.QueryRowContext(ctx, `
+	SELECT EXISTS (SELECT 1 FROM trades WHERE user_id = u.id)
+	    OR EXISTS (SELECT 1 FROM portfolio WHERE user_id = u.id AND quantity <> 0)
+	    OR EXISTS (SELECT 1 FROM orders WHERE user_id = u.id AND status IN ('PENDING', 'WAITING'))
+	FROM (SELECT id FROM users WHERE id = $1 FOR UPDATE) u`, userID).Scan(&used)
+	if err != nil {
+		return false, err
+	}
+	// A concurrent import of the same bundle waits on the key and then
+	// conflicts.
+	res, err := db.ExecContext(ctx, `
+	INSERT INTO account_bundle_imports (bundle_id, user_id, source, key_id, replaced) VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (bundle_id) DO NOTHING`, imp.BundleID, userID, imp.Source, imp.KeyID, used)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, ErrBundleImported
+	}
+	if used {
+		if !replace {
+			return false, ErrAccountNotEmpty
+		}
+		if err := resetAccount(ctx, db, userID); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE users SET balance = $2, starting_balance = $3 WHERE id = $1`,
+		userID, st.Balance, st.StartingBalance); err != nil {
+		return false, err
+	}
+	_, err = db.ExecContext(ctx, `
+	INSERT INTO user_settings (user_id, display_currency, after_hours_orders, cost_basis_method,
+		trade_confirmation_emails, statement_emails, timezone)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_id) DO UPDATE SET
+		display_currency = EXCLUDED.display_currency,
+		after_hours_orders = EXCLUDED.after_hours_orders,
+		cost_basis_method = EXCLUDED.cost_basis_method,
+		trade_confirmation_emails = EXCLUDED.trade_confirmation_emails,
+		statement_emails = EXCLUDED.statement_emails,
+		timezone = EXCLUDED.timezone`,
+		userID, st.Settings.DisplayCurrency, st.Settings.AfterHoursOrders, st.Settings.CostBasisMethod,
+		st.Settings.TradeConfirmationEmails, st.Settings.StatementEmails, st.Settings.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	for _, h := range st.Holdings {
+		_, err := db.ExecContext(ctx, `
+		INSERT INTO portfolio (id, user_id, symbol, quantity, avg_price, margin, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)`,
+			uuid.New().String(), userID, h.Symbol, h.Quantity, h.AvgPrice, h.Margin)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	tradeIDs := make(map[string]string, len(st.Trades))
+	for _, t := range st.Trades {
+		id := uuid.New().String()
+		tradeIDs[t.ID] = id
+		_, err := db.ExecContext(ctx, `
+		INSERT INTO trades (id, user_id, symbol, action, quantity, price, status, order_type, slippage, executed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			id, userID, t.Symbol, t.Action, t.Quantity, t.Price, t.Status, t.OrderType, t.Slippage, t.ExecutedAt)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, l := range st.Lots {
+		_, err := db.ExecContext(ctx, `
+		INSERT INTO tax_lots (id, user_id, symbol, trade_id, quantity, remaining, price, acquired_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8)`,
+			uuid.New().String(), userID, l.Symbol, tradeIDs[l.TradeID], l.Quantity, l.Remaining, l.Price, l.AcquiredAt)
+		if err != nil {
+			return false, err
+		}
+	}
+	return used, nil
+}
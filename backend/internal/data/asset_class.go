@@ -0,0 +1,20 @@
+package data
+
+import "strings"
+
+// Asset classes, stored on trades and portfolio rows as a column generated
+// from the symbol.
+const (
+	AssetClassEquity = "equity"
+	AssetClassCrypto = "crypto"
+)
+
+// AssetClassOf returns the asset class of symbol by the same rule as the
+// generated asset_class column: crypto pairs are quoted in dollars
+// (BTC-USD), which no stock symbol is.
+func AssetClassOf(symbol string) string {
+	if strings.HasSuffix(symbol, "-USD") {
+		return AssetClassCrypto
+	}
+	return AssetClassEquity
+}
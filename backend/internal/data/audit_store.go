@@ -0,0 +1,105 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent is one row in the security audit log.
+type AuditEvent struct {
+	ID        string          `json:"id"`
+	UserID    string          `json:"user_id,omitempty"`
+	Kind      string          `json:"kind"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	Country   string          `json:"country,omitempty"` // ISO 3166-1 alpha-2, "" when unknown
+	Details   json.RawMessage `json:"details,omitempty"`
+	Flagged   bool            `json:"flagged"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type AuditStore struct {
+	db DBTX
+}
+
+func NewAuditStore(db DBTX) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+// Record inserts e, filling in ID when empty. An empty UserID is stored as
+// NULL and nil Details as '{}'.
+func (s *AuditStore) Record(ctx context.Context, e *AuditEvent) error {
+	if e.ID == "" {
+		e.ID = uuid.New().String()
+	}
+	details := e.Details
+	if len(details) == 0 {
+		details = json.RawMessage(`{}`)
+	}
+	query := `
+	INSERT INTO audit_events (id, user_id, kind, ip_address, country, details, flagged)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.ExecContext(ctx, query,
+		e.ID, sql.NullString{String: e.UserID, Valid: e.UserID != ""}, e.Kind,
+		e.IPAddress, e.Country, []byte(details), e.Flagged)
+	return err
+}
+
+// CountSince returns how many events of kind the user has recorded at or
+// after since.
+func (s *AuditStore) CountSince(ctx context.Context, userID, kind string, since time.Time) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM audit_events WHERE user_id = $1 AND kind = $2 AND created_at >= $3`,
+		userID, kind, since,
+	).Scan(&n)
+	return n, err
+}
+
+// CountryHistory reports whether the user has any prior event of kind with a
+// known country, and whether country is among them.
+func (s *AuditStore) CountryHistory(ctx context.Context, userID, kind, country string) (seenAny, seenCountry bool, err error) {
+	query := `
+	SELECT COUNT(*) > 0, COALESCE(BOOL_OR(country = $3), FALSE)
+	FROM audit_events
+	WHERE user_id = $1 AND kind = $2 AND country <> ''`
+
+	err = s.db.QueryRowContext(ctx, query, userID, kind, country).Scan(&seenAny, &seenCountry)
+	return seenAny, seenCountry, err
+}
+
+// ListFlagged returns the most recent flagged events across all users, newest
+// first.
+func (s *AuditStore) ListFlagged(ctx context.Context, limit int) ([]AuditEvent, error) {
+	query := `
+	SELECT id, COALESCE(user_id, ''), kind, ip_address, country, details, flagged, created_at
+	FROM audit_events
+	WHERE flagged
+	ORDER BY created_at DESC, id DESC
+	LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]AuditEvent, 0)
+	for rows.Next() {
+		var e AuditEvent
+		var details []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Kind, &e.IPAddress, &e.Country, &details, &e.Flagged, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Details = json.RawMessage(details)
+		out = append(out, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
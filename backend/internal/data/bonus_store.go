@@ -0,0 +1,104 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ErrBonusCooldown is returned by BonusStore.Grant when the user's last
+// grant was too recent.
+var ErrBonusCooldown = errors.New("bonus cash claimed too recently")
+
+// BonusStore records bonus cash grants: paper money credited to a user on
+// request, at most once per cooldown.
+type BonusStore struct {
+	db DBTX
+}
+
+func NewBonusStore(db DBTX) *BonusStore {
+	return &BonusStore{db: db}
+}
+
+// Grant credits amount to userID's balance and records the grant at now,
+// returning the new balance. It is refused with ErrBonusCooldown when the
+// previous grant was after notBefore, in which case the time of that grant
+// is returned instead. The check and the credit are one UPDATE, so two
+// concurrent claims cannot both pass.
+func (s *BonusStore) Grant(ctx context.Context, userID string, amount decimal.Decimal, notBefore, now time.Time) (decimal.Decimal, time.Time, error) {
+	query := `
+	WITH u AS (
+		UPDATE users SET balance = balance + $2, bonus_claimed_at = $4
+		WHERE id = $1 AND (bonus_claimed_at IS NULL OR bonus_claimed_at <= $3)
+		RETURNING id, balance
+	), g AS (
+		INSERT INTO bonus_grants (id, user_id, amount, granted_at)
+		SELECT $5, id, $2, $4 FROM u
+	)
+	SELECT balance FROM u`
+
+	args := []any{userID, amount, notBefore.UTC(), now.UTC(), uuid.New().String()}
+
+	var balance decimal.Decimal
+	var err error
+	if sqliteStorage.Load() {
+		err = inTx(ctx, s.db, func(db DBTX) error {
+			err := db.QueryRowContext(ctx, `
+			UPDATE users SET balance = balance + $2, bonus_claimed_at = $4
+			WHERE id = $1 AND (bonus_claimed_at IS NULL OR bonus_claimed_at <= $3)
+			RETURNING balance`, args...).Scan(&balance)
+			if err != nil {
+				return err
+			}
+			_, err = db.ExecContext(ctx,
+				`INSERT INTO bonus_grants (id, user_id, amount, granted_at) VALUES ($5, $1, $2, $4)`, args...)
+			return err
+		})
+	} else {
+		err = s.db.QueryRowContext(ctx, query, args...).Scan(&balance)
+	}
+	if err == nil {
+		return balance, time.Time{}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return decimal.Zero, time.Time{}, err
+	}
+
+	// No row updated: either the user is gone or the cooldown applies.
+	var claimedAt sql.NullTime
+	err = s.db.QueryRowContext(ctx, `SELECT bonus_claimed_at FROM users WHERE id = $1`, userID).Scan(&claimedAt)
+	if err != nil {
+		return decimal.Zero, time.Time{}, err
+	}
+	return decimal.Zero, claimedAt.Time, ErrBonusCooldown
+}
+
+// Summary returns when userID last claimed bonus cash (nil if never) and the
+// total granted to them. sql.ErrNoRows means there is no such user.
+func (s *BonusStore) Summary(ctx context.Context, userID string) (*time.Time, decimal.Decimal, error) {
+	var claimedAt sql.NullTime
+	var total decimal.Decimal
+	err := s.db.QueryRowContext(ctx, `
+	SELECT u.bonus_claimed_at, COALESCE((SELECT SUM(amount) FROM bonus_grants WHERE user_id = u.id), 0)
+	FROM users u
+	WHERE u.id = $1`, userID).Scan(&claimedAt, &total)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+	if !claimedAt.Valid {
+		return nil, total, nil
+	}
+	return &claimedAt.Time, total, nil
+}
+
+// Total returns the bonus cash granted to userID so far.
+func (s *BonusStore) Total(ctx context.Context, userID string) (decimal.Decimal, error) {
+	var total decimal.Decimal
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0) FROM bonus_grants WHERE user_id = $1`, userID).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,184 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Classification is a symbol's sector, industry and the market indices it
+// belongs to (index codes such as "SPX").
+type Classification struct {
+	Symbol    string    `json:"symbol"`
+	Sector    string    `json:"sector"`
+	Industry  string    `json:"industry"`
+	Indices   []string  `json:"indices"`
+	Source    string    `json:"source"` // ClassificationSourceDataset or ClassificationSourceAdmin
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Classification sources. Reloading the dataset only touches dataset rows;
+// admin overrides stay until an admin changes them.
+const (
+	ClassificationSourceDataset = "dataset"
+	ClassificationSourceAdmin   = "admin"
+)
+
+var ErrClassificationNotFound = errors.New("classification not found")
+
+const classificationColumns = `symbol, sector, industry, indices, source, updated_at`
+
+func scanClassification(row rowScanner) (*Classification, error) {
+	var c Classification
+	if err := row.Scan(&c.Symbol, &c.Sector, &c.Industry, pq.Array(&c.Indices), &c.Source, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if c.Indices == nil {
+		c.Indices = []string{}
+	}
+	return &c, nil
+}
+
+type ClassificationStore struct {
+	db DBTX
+}
+
+func NewClassificationStore(db DBTX) *ClassificationStore {
+	return &ClassificationStore{db: db}
+}
+
+// Get returns the classification for symbol, or ErrClassificationNotFound.
+func (s *ClassificationStore) Get(ctx context.Context, symbol string) (*Classification, error) {
+	items, err := s.query(ctx, `SELECT `+classificationColumns+` FROM instrument_classifications WHERE symbol = $1`, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrClassificationNotFound
+	}
+	return &items[0], nil
+}
+
+// GetMany returns the classifications for symbols keyed by symbol. Symbols
+// without one are absent from the map.
+func (s *ClassificationStore) GetMany(ctx context.Context, symbols []string) (map[string]Classification, error) {
+	items, err := s.query(ctx, `SELECT `+classificationColumns+` FROM instrument_classifications WHERE symbol = ANY($1)`, pq.Array(symbols))
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Classification, len(items))
+	for _, c := range items {
+		out[c.Symbol] = c
+	}
+	return out, nil
+}
+
+// ListByIndex returns the members of the index with code, by symbol.
+func (s *ClassificationStore) ListByIndex(ctx context.Context, code string) ([]Classification, error) {
+	query := `SELECT ` + classificationColumns + ` FROM instrument_classifications
+	WHERE indices @> ARRAY[$1]::TEXT[] ORDER BY symbol`
+	return s.query(ctx, query, code)
+}
+
+// ListBySector returns the symbols classified under sector, by symbol.
+func (s *ClassificationStore) ListBySector(ctx context.Context, sector string) ([]Classification, error) {
+	query := `SELECT ` + classificationColumns + ` FROM instrument_classifications
+	WHERE sector = $1 ORDER BY symbol`
+	return s.query(ctx, query, sector)
+}
+
+func (s *ClassificationStore) query(ctx context.Context, query string, args ...any) ([]Classification, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Classification, 0)
+	for rows.Next() {
+		c, err := scanClassification(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Save creates or replaces the classification for c.Symbol as an admin
+// override and returns the stored row.
+func (s *ClassificationStore) Save(ctx context.Context, c *Classification) (*Classification, error) {
+	query := `
+	INSERT INTO instrument_classifications (symbol, sector, industry, indices, source)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (symbol) DO UPDATE
+	SET sector = EXCLUDED.sector,
+	    industry = EXCLUDED.industry,
+	    indices = EXCLUDED.indices,
+	    source = EXCLUDED.source,
+	    updated_at = CURRENT_TIMESTAMP
+	RETURNING ` + classificationColumns
+
+	return scanClassification(s.db.QueryRowContext(ctx, query,
+		c.Symbol, c.Sector, c.Industry, pq.Array(c.Indices), ClassificationSourceAdmin))
+}
+
+// ReplaceDataset makes the dataset rows match items in one transaction:
+// each item is inserted or refreshed unless the symbol has an admin
+// override, and dataset rows for symbols no longer in items are deleted.
+// Returns the number of rows written.
+func (s *ClassificationStore) ReplaceDataset(ctx context.Context, items []Classification) (int, error) {
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return replaceClassificationDataset(ctx, s.db, items)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n, err := replaceClassificationDataset(ctx, tx, items)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func replaceClassificationDataset(ctx context.Context, db DBTX, items []Classification) (int, error) {
+	query := `
+	INSERT INTO instrument_classifications (symbol, sector, industry, indices, source)
+	VALUES ($1, $2, $3, $4, 'dataset')
+	ON CONFLICT (symbol) DO UPDATE
+	SET sector = EXCLUDED.sector,
+	    industry = EXCLUDED.industry,
+	    indices = EXCLUDED.indices,
+	    updated_at = CURRENT_TIMESTAMP
+	WHERE instrument_classifications.source = 'dataset'`
+
+	written := 0
+	symbols := make([]string, 0, len(items))
+	for _, c := range items {
+		result, err := db.ExecContext(ctx, query, c.Symbol, c.Sector, c.Industry, pq.Array(c.Indices))
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		written += int(n)
+		symbols = append(symbols, c.Symbol)
+	}
+
+	if _, err := db.ExecContext(ctx,
+		`DELETE FROM instrument_classifications WHERE source = 'dataset' AND NOT (symbol = ANY($1))`,
+		pq.Array(symbols)); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
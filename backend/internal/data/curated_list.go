@@ -0,0 +1,198 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CuratedList is an admin-maintained list of symbols users can subscribe to,
+// e.g. "Big tech". Subscribed is filled in for the user the list was read for.
+type CuratedList struct {
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Symbols     []string  `json:"symbols"`
+	IsDefault   bool      `json:"is_default"`
+	Subscribed  bool      `json:"subscribed"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+var ErrCuratedListNotFound = errors.New("curated list not found")
+
+// curatedListColumns selects a curated_lists row aliased as l plus whether
+// the user in $1 is subscribed to it.
+const curatedListColumns = `l.slug, l.name, l.description, l.symbols, l.is_default, l.created_at, l.updated_at,
+	EXISTS (SELECT 1 FROM curated_list_subscriptions s WHERE s.list_slug = l.slug AND s.user_id = $1)`
+
+func scanCuratedList(row rowScanner) (*CuratedList, error) {
+	var l CuratedList
+	err := row.Scan(&l.Slug, &l.Name, &l.Description, pq.Array(&l.Symbols), &l.IsDefault,
+		&l.CreatedAt, &l.UpdatedAt, &l.Subscribed)
+	if err != nil {
+		return nil, err
+	}
+	if l.Symbols == nil {
+		l.Symbols = []string{}
+	}
+	return &l, nil
+}
+
+type CuratedListStore struct {
+	db DBTX
+}
+
+func NewCuratedListStore(db DBTX) *CuratedListStore {
+	return &CuratedListStore{db: db}
+}
+
+// List returns every curated list, the default first and the rest by name,
+// each marked with whether userID is subscribed.
+func (s *CuratedListStore) List(ctx context.Context, userID string) ([]CuratedList, error) {
+	query := `SELECT ` + curatedListColumns + `
+	FROM curated_lists l ORDER BY l.is_default DESC, l.name`
+	return s.query(ctx, query, userID)
+}
+
+// ListSubscribed returns the curated lists userID is subscribed to, in the
+// order they subscribed.
+func (s *CuratedListStore) ListSubscribed(ctx context.Context, userID string) ([]CuratedList, error) {
+	query := `SELECT ` + curatedListColumns + `
+	FROM curated_lists l
+	JOIN curated_list_subscriptions sub ON sub.list_slug = l.slug AND sub.user_id = $1
+	ORDER BY sub.created_at, l.slug`
+	return s.query(ctx, query, userID)
+}
+
+func (s *CuratedListStore) query(ctx context.Context, query string, args ...any) ([]CuratedList, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]CuratedList, 0)
+	for rows.Next() {
+		l, err := scanCuratedList(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Get returns the list with slug as seen by userID, or ErrCuratedListNotFound.
+func (s *CuratedListStore) Get(ctx context.Context, userID, slug string) (*CuratedList, error) {
+	lists, err := s.query(ctx, `SELECT `+curatedListColumns+` FROM curated_lists l WHERE l.slug = $2`, userID, slug)
+	if err != nil {
+		return nil, err
+	}
+	if len(lists) == 0 {
+		return nil, ErrCuratedListNotFound
+	}
+	return &lists[0], nil
+}
+
+// Save creates the list or replaces the name, description, symbols and
+// default flag of an existing one. Making a list the default clears the flag
+// on any other list in the same transaction. Existing subscriptions are kept.
+func (s *CuratedListStore) Save(ctx context.Context, list *CuratedList) (*CuratedList, error) {
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return saveCuratedList(ctx, s.db, list)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	saved, err := saveCuratedList(ctx, tx, list)
+	if err != nil {
+		return nil, err
+	}
+	return saved, tx.Commit()
+}
+
+func saveCuratedList(ctx context.Context, db DBTX, list *CuratedList) (*CuratedList, error) {
+	if list.IsDefault {
+		if _, err := db.ExecContext(ctx,
+			`UPDATE curated_lists SET is_default = FALSE, updated_at = CURRENT_TIMESTAMP
+			 WHERE is_default AND slug <> $1`, list.Slug); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+	INSERT INTO curated_lists (slug, name, description, symbols, is_default)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (slug) DO UPDATE
+	SET name = EXCLUDED.name,
+	    description = EXCLUDED.description,
+	    symbols = EXCLUDED.symbols,
+	    is_default = EXCLUDED.is_default,
+	    updated_at = CURRENT_TIMESTAMP
+	RETURNING slug, name, description, symbols, is_default, created_at, updated_at`
+
+	var l CuratedList
+	err := db.QueryRowContext(ctx, query, list.Slug, list.Name, list.Description, pq.Array(list.Symbols), list.IsDefault).
+		Scan(&l.Slug, &l.Name, &l.Description, pq.Array(&l.Symbols), &l.IsDefault, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if l.Symbols == nil {
+		l.Symbols = []string{}
+	}
+	return &l, nil
+}
+
+// Delete removes the list and every subscription to it. Returns
+// ErrCuratedListNotFound if there is no such list.
+func (s *CuratedListStore) Delete(ctx context.Context, slug string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM curated_lists WHERE slug = $1`, slug)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCuratedListNotFound
+	}
+	return nil
+}
+
+// Subscribe subscribes userID to the list. Subscribing twice is not an
+// error. Returns ErrCuratedListNotFound if there is no such list.
+func (s *CuratedListStore) Subscribe(ctx context.Context, userID, slug string) error {
+	query := `
+	INSERT INTO curated_list_subscriptions (user_id, list_slug)
+	VALUES ($1, $2)
+	ON CONFLICT DO NOTHING`
+
+	if _, err := s.db.ExecContext(ctx, query, userID, slug); err != nil {
+		// SQLite doesn't name the foreign key; the user is the signed-in
+		// one, so it is the list that is missing.
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" &&
+			(pqErr.Constraint == "curated_list_subscriptions_list_slug_fkey" || pqErr.Constraint == "") {
+			return ErrCuratedListNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Unsubscribe removes userID's subscription to the list, if any.
+func (s *CuratedListStore) Unsubscribe(ctx context.Context, userID, slug string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM curated_list_subscriptions WHERE user_id = $1 AND list_slug = $2`, userID, slug)
+	return err
+}
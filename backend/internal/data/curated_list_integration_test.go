@@ -0,0 +1,81 @@
+//go:build integration
+
+package data_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"papertrader/internal/data"
+	"papertrader/internal/testutil"
+)
+
+// TestCuratedLists_DefaultSubscription checks that new accounts are
+// subscribed to the default list by the users trigger, and that making
+// another list the default moves the flag rather than violating the
+// one-default index.
+func TestCuratedLists_DefaultSubscription(t *testing.T) {
+	db := testutil.NewIntegrationDB(t)
+	testutil.Truncate(t, db, "trades", "portfolio", "users")
+	ctx := context.Background()
+	store := data.NewCuratedListStore(db)
+
+	newUser := func(email string) string {
+		id := uuid.New().String()
+		if _, err := db.Exec(
+			`INSERT INTO users (id, email, password, email_verified, created_via) VALUES ($1, $2, 'x', FALSE, 'email')`,
+			id, email,
+		); err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+		return id
+	}
+
+	first := newUser("curated-first@example.com")
+	subscribed, err := store.ListSubscribed(ctx, first)
+	if err != nil {
+		t.Fatalf("ListSubscribed: %v", err)
+	}
+	if len(subscribed) != 1 || subscribed[0].Slug != "getting-started" || !subscribed[0].Subscribed {
+		t.Fatalf("new user subscriptions = %+v, want [getting-started]", subscribed)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM curated_lists WHERE slug = 'test-default'`)
+		db.Exec(`UPDATE curated_lists SET is_default = (slug = 'getting-started')`)
+	})
+	if _, err := store.Save(ctx, &data.CuratedList{
+		Slug: "test-default", Name: "Test default", Symbols: []string{"IBM"}, IsDefault: true,
+	}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	old, err := store.Get(ctx, first, "getting-started")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if old.IsDefault {
+		t.Error("getting-started is still the default after another list took over")
+	}
+
+	second := newUser("curated-second@example.com")
+	subscribed, err = store.ListSubscribed(ctx, second)
+	if err != nil {
+		t.Fatalf("ListSubscribed: %v", err)
+	}
+	if len(subscribed) != 1 || subscribed[0].Slug != "test-default" {
+		t.Fatalf("second user subscriptions = %+v, want [test-default]", subscribed)
+	}
+
+	if err := store.Subscribe(ctx, second, "no-such-list"); !errors.Is(err, data.ErrCuratedListNotFound) {
+		t.Errorf("Subscribe unknown list: err = %v, want ErrCuratedListNotFound", err)
+	}
+	if err := store.Subscribe(ctx, second, "big-tech"); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := store.Subscribe(ctx, second, "big-tech"); err != nil {
+		t.Errorf("second Subscribe should be a no-op, got %v", err)
+	}
+}
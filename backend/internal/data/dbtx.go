@@ -11,3 +11,9 @@ type DBTX interface {
 	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
+
+// txBeginner is implemented by *sql.DB. Stores that need a transaction for a
+// single call check for it; when they already hold a *sql.Tx they run in it.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
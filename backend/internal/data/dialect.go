@@ -0,0 +1,50 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+// sqliteStorage is set when the stores run on a SQLite database opened by
+// internal/sqlite, which translates their PostgreSQL as each statement is
+// prepared. The few statements it can't translate, mostly data-modifying
+// CTEs, check it and run a SQLite form instead.
+var sqliteStorage atomic.Bool
+
+// UseSQLite switches the stores to their SQLite statements. Call it once at
+// start-up, before any store is used, when the database is SQLite.
+func UseSQLite(on bool) {
+	sqliteStorage.Store(on)
+}
+
+// inTx runs fn in a transaction, opening one when db is not already one.
+func inTx(ctx context.Context, db DBTX, fn func(DBTX) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execAll runs queries in order with the same args and returns the last
+// one's result. It is the SQLite form of a statement whose CTEs change
+// several tables; run it in a transaction.
+func execAll(ctx context.Context, db DBTX, queries []string, args ...any) (sql.Result, error) {
+	var result sql.Result
+	for _, query := range queries {
+		var err error
+		if result, err = db.ExecContext(ctx, query, args...); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// EODRun is the end-of-day close job's record of one trading session.
+type EODRun struct {
+	SessionDate time.Time  `json:"session_date"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Symbols     int        `json:"symbols"`
+	Closes      int        `json:"closes"`
+	FailedSteps []string   `json:"failed_steps"`
+}
+
+type EODRunStore struct {
+	db DBTX
+}
+
+func NewEODRunStore(db DBTX) *EODRunStore {
+	return &EODRunStore{db: db}
+}
+
+// Claim starts the run for sessionDate, returning false if it has already
+// completed or another instance started it less than staleAfter ago. A
+// stale, incomplete claim is taken over, so a run lost to a crash is
+// retried.
+func (s *EODRunStore) Claim(ctx context.Context, sessionDate time.Time, staleAfter time.Duration) (bool, error) {
+	staleBefore := `CURRENT_TIMESTAMP - make_interval(secs => $2)`
+	if sqliteStorage.Load() {
+		staleBefore = `strftime('%Y-%m-%d %H:%M:%f', 'now', '-' || $2 || ' seconds')`
+	}
+
+	var date time.Time
+	err := s.db.QueryRowContext(ctx, `
+	INSERT INTO eod_runs (session_date) VALUES ($1)
+	ON CONFLICT (session_date) DO UPDATE SET started_at = CURRENT_TIMESTAMP
+	WHERE eod_runs.completed_at IS NULL
+	  AND eod_runs.started_at < `+staleBefore+`
+	RETURNING session_date`,
+		sessionDate.Format(time.DateOnly), staleAfter.Seconds()).Scan(&date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Complete marks run's session done with its counts.
+func (s *EODRunStore) Complete(ctx context.Context, run *EODRun) error {
+	failed := run.FailedSteps
+	if failed == nil {
+		failed = []string{}
+	}
+	_, err := s.db.ExecContext(ctx, `
+	UPDATE eod_runs SET completed_at = CURRENT_TIMESTAMP, symbols = $2, closes = $3, failed_steps = $4
+	WHERE session_date = $1`,
+		run.SessionDate.Format(time.DateOnly), run.Symbols, run.Closes, pq.Array(failed))
+	return err
+}
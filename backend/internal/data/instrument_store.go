@@ -0,0 +1,209 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Instrument is the reference record for a tradable symbol.
+type Instrument struct {
+	Symbol    string    `json:"symbol"`
+	Name      string    `json:"name"`
+	Exchange  string    `json:"exchange"` // ISO 10383 MIC, e.g. XNAS
+	AssetType string    `json:"asset_type"`
+	Currency  string    `json:"currency"` // ISO 4217 code the listing is priced in
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Halted     bool       `json:"halted"`
+	HaltReason string     `json:"halt_reason,omitempty"`
+	HaltSource string     `json:"halt_source,omitempty"` // HaltSourceAdmin or HaltSourceProvider
+	HaltedAt   *time.Time `json:"halted_at,omitempty"`
+
+	// TickSize is the smallest price increment the symbol trades in and
+	// PriceDecimals the places its prices are shown to.
+	TickSize      decimal.Decimal `json:"tick_size"`
+	PriceDecimals int32           `json:"price_decimals"`
+}
+
+// Halt sources. A provider-driven resume only lifts provider halts; admin
+// halts stay until an admin lifts them.
+const (
+	HaltSourceAdmin    = "admin"
+	HaltSourceProvider = "provider"
+)
+
+var ErrInstrumentNotFound = errors.New("instrument not found")
+
+const instrumentColumns = `symbol, name, exchange, asset_type, currency, created_at, updated_at,
+	halted, halt_reason, halt_source, halted_at, tick_size, price_decimals`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInstrument(row rowScanner) (*Instrument, error) {
+	var inst Instrument
+	var haltedAt sql.NullTime
+	err := row.Scan(
+		&inst.Symbol,
+		&inst.Name,
+		&inst.Exchange,
+		&inst.AssetType,
+		&inst.Currency,
+		&inst.CreatedAt,
+		&inst.UpdatedAt,
+		&inst.Halted,
+		&inst.HaltReason,
+		&inst.HaltSource,
+		&haltedAt,
+		&inst.TickSize,
+		&inst.PriceDecimals,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if haltedAt.Valid {
+		inst.HaltedAt = &haltedAt.Time
+	}
+	return &inst, nil
+}
+
+type InstrumentStore struct {
+	db DBTX
+}
+
+func NewInstrumentStore(db DBTX) *InstrumentStore {
+	return &InstrumentStore{db: db}
+}
+
+// GetInstrument returns the instrument for symbol, or ErrInstrumentNotFound.
+func (s *InstrumentStore) GetInstrument(ctx context.Context, symbol string) (*Instrument, error) {
+	query := `SELECT ` + instrumentColumns + ` FROM instruments WHERE symbol = $1`
+
+	inst, err := scanInstrument(s.db.QueryRowContext(ctx, query, symbol))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInstrumentNotFound
+		}
+		return nil, err
+	}
+	return inst, nil
+}
+
+// ListHalted returns every currently halted instrument, most recent first.
+func (s *InstrumentStore) ListHalted(ctx context.Context) ([]Instrument, error) {
+	query := `SELECT ` + instrumentColumns + ` FROM instruments WHERE halted ORDER BY halted_at DESC, symbol`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Instrument, 0)
+	for rows.Next() {
+		inst, err := scanInstrument(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *inst)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetHalt places a halt on symbol, creating a bare instrument row if none
+// exists yet. Returns true when the row transitioned from trading to halted
+// (false when it was already halted, so callers don't re-notify).
+func (s *InstrumentStore) SetHalt(ctx context.Context, symbol, reason, source string) (bool, error) {
+	query := `
+	WITH prev AS (SELECT halted FROM instruments WHERE symbol = $1)
+	INSERT INTO instruments (symbol, halted, halt_reason, halt_source, halted_at)
+	VALUES ($1, TRUE, $2, $3, CURRENT_TIMESTAMP)
+	ON CONFLICT (symbol) DO UPDATE
+	SET halted = TRUE,
+	    halt_reason = EXCLUDED.halt_reason,
+	    halt_source = EXCLUDED.halt_source,
+	    halted_at = COALESCE(instruments.halted_at, EXCLUDED.halted_at),
+	    updated_at = CURRENT_TIMESTAMP
+	RETURNING COALESCE((SELECT halted FROM prev), FALSE)`
+
+	var wasHalted bool
+	var err error
+	if sqliteStorage.Load() {
+		// SQLite reads prev after the upsert, so it is read first.
+		err = inTx(ctx, s.db, func(db DBTX) error {
+			err := db.QueryRowContext(ctx, `SELECT halted FROM instruments WHERE symbol = $1`, symbol).Scan(&wasHalted)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+			_, err = db.ExecContext(ctx, query, symbol, reason, source)
+			return err
+		})
+	} else {
+		err = s.db.QueryRowContext(ctx, query, symbol, reason, source).Scan(&wasHalted)
+	}
+	if err != nil {
+		return false, err
+	}
+	return !wasHalted, nil
+}
+
+// ClearHalt lifts a halt on symbol. When source is non-empty only a halt with
+// that source is lifted. Returns true when a halted row was resumed.
+func (s *InstrumentStore) ClearHalt(ctx context.Context, symbol, source string) (bool, error) {
+	query := `
+	UPDATE instruments
+	SET halted = FALSE, halt_reason = '', halt_source = '', halted_at = NULL,
+	    updated_at = CURRENT_TIMESTAMP
+	WHERE symbol = $1 AND halted AND ($2 = '' OR halt_source = $2)`
+
+	result, err := s.db.ExecContext(ctx, query, symbol, source)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SetPrecision sets symbol's tick size and display places, creating a bare
+// instrument row if none exists yet, and returns the row.
+func (s *InstrumentStore) SetPrecision(ctx context.Context, symbol string, tickSize decimal.Decimal, decimals int32) (*Instrument, error) {
+	query := `
+	INSERT INTO instruments (symbol, tick_size, price_decimals)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (symbol) DO UPDATE
+	SET tick_size = EXCLUDED.tick_size,
+	    price_decimals = EXCLUDED.price_decimals,
+	    updated_at = CURRENT_TIMESTAMP
+	RETURNING ` + instrumentColumns
+	return scanInstrument(s.db.QueryRowContext(ctx, query, symbol, tickSize, decimals))
+}
+
+// UpsertInstrument inserts inst or refreshes name/exchange/asset_type/currency
+// on an existing row. An empty currency is stored as USD. Halt state is
+// managed separately via SetHalt / ClearHalt.
+func (s *InstrumentStore) UpsertInstrument(ctx context.Context, inst *Instrument) error {
+	query := `
+	INSERT INTO instruments (symbol, name, exchange, asset_type, currency)
+	VALUES ($1, $2, $3, $4, COALESCE(NULLIF($5, ''), 'USD'))
+	ON CONFLICT (symbol) DO UPDATE
+	SET name = EXCLUDED.name,
+	    exchange = EXCLUDED.exchange,
+	    asset_type = EXCLUDED.asset_type,
+	    currency = EXCLUDED.currency,
+	    updated_at = CURRENT_TIMESTAMP`
+
+	_, err := s.db.ExecContext(ctx, query, inst.Symbol, inst.Name, inst.Exchange, inst.AssetType, inst.Currency)
+	return err
+}
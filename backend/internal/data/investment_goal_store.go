@@ -0,0 +1,204 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// InvestmentGoal is a target a user is working towards: a total account
+// value (GoalValue, Target in dollars) or a return on the account since the
+// goal was set (GoalReturn, Target in percent), optionally by TargetDate.
+// BaselineValue is the account value progress is measured from; it is nil
+// until the goal's first snapshot. BaselineBonusCash is the bonus cash
+// granted by then, so grants since can be left out. LastMilestone is the highest quarter of
+// the way (25, 50, 75, 100) the user has been told about. Dates are New
+// York session dates.
+type InvestmentGoal struct {
+	ID                string           `json:"id"`
+	UserID            string           `json:"user_id"`
+	Kind              string           `json:"kind"`
+	Name              string           `json:"name"`
+	Target            decimal.Decimal  `json:"target"`
+	TargetDate        string           `json:"target_date,omitempty"` // YYYY-MM-DD
+	BaselineValue     *decimal.Decimal `json:"baseline_value,omitempty"`
+	BaselineBonusCash decimal.Decimal  `json:"-"`
+	LastMilestone     int              `json:"last_milestone"`
+	AchievedOn        string           `json:"achieved_on,omitempty"` // YYYY-MM-DD
+	CreatedAt         time.Time        `json:"created_at"`
+}
+
+// Investment goal kinds.
+const (
+	GoalValue  = "VALUE"
+	GoalReturn = "RETURN"
+)
+
+var ErrInvestmentGoalNotFound = errors.New("investment goal not found")
+
+const investmentGoalColumns = `id, user_id, kind, name, target, target_date,
+	baseline_value, baseline_bonus_cash, last_milestone, achieved_on, created_at`
+
+type InvestmentGoalStore struct {
+	db DBTX
+}
+
+func NewInvestmentGoalStore(db DBTX) *InvestmentGoalStore {
+	return &InvestmentGoalStore{db: db}
+}
+
+func scanInvestmentGoal(row rowScanner, extra ...any) (*InvestmentGoal, error) {
+	var g InvestmentGoal
+	var targetDate, achievedOn sql.NullTime
+	var baseline decimal.NullDecimal
+	dest := append([]any{&g.ID, &g.UserID, &g.Kind, &g.Name, &g.Target, &targetDate,
+		&baseline, &g.BaselineBonusCash, &g.LastMilestone, &achievedOn, &g.CreatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if targetDate.Valid {
+		g.TargetDate = targetDate.Time.Format(time.DateOnly)
+	}
+	if baseline.Valid {
+		g.BaselineValue = &baseline.Decimal
+	}
+	if achievedOn.Valid {
+		g.AchievedOn = achievedOn.Time.Format(time.DateOnly)
+	}
+	return &g, nil
+}
+
+// Create inserts goal and returns the stored row. ID is assigned here;
+// LastMilestone and AchievedOn are ignored.
+func (s *InvestmentGoalStore) Create(ctx context.Context, goal *InvestmentGoal) (*InvestmentGoal, error) {
+	query := `
+	INSERT INTO investment_goals (id, user_id, kind, name, target, target_date, baseline_value, baseline_bonus_cash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING ` + investmentGoalColumns
+
+	var targetDate sql.NullString
+	if goal.TargetDate != "" {
+		targetDate = sql.NullString{String: goal.TargetDate, Valid: true}
+	}
+	var baseline decimal.NullDecimal
+	if goal.BaselineValue != nil {
+		baseline = decimal.NullDecimal{Decimal: *goal.BaselineValue, Valid: true}
+	}
+	return scanInvestmentGoal(s.db.QueryRowContext(ctx, query,
+		uuid.New().String(), goal.UserID, goal.Kind, goal.Name, goal.Target, targetDate, baseline, goal.BaselineBonusCash))
+}
+
+// ListByUser returns userID's goals, oldest first.
+func (s *InvestmentGoalStore) ListByUser(ctx context.Context, userID string) ([]InvestmentGoal, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+investmentGoalColumns+`
+	FROM investment_goals WHERE user_id = $1 ORDER BY created_at, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]InvestmentGoal, 0)
+	for rows.Next() {
+		g, err := scanInvestmentGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CountByUser returns how many goals userID has, reached or not.
+func (s *InvestmentGoalStore) CountByUser(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM investment_goals WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+// Delete removes one of userID's goals. Returns ErrInvestmentGoalNotFound
+// if no row was deleted.
+func (s *InvestmentGoalStore) Delete(ctx context.Context, userID, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM investment_goals WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvestmentGoalNotFound
+	}
+	return nil
+}
+
+// OpenGoal is an unreached goal beside its owner's snapshot value and bonus
+// cash granted for one day.
+type OpenGoal struct {
+	Goal       InvestmentGoal
+	TotalValue decimal.Decimal
+	BonusCash  decimal.Decimal
+}
+
+// ListOpen returns the goals not yet reached and not past their target date
+// on date (YYYY-MM-DD) whose owner has a snapshot for date, with that
+// snapshot's total value and bonus cash.
+func (s *InvestmentGoalStore) ListOpen(ctx context.Context, date string) ([]OpenGoal, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+investmentGoalColumns+`, ph.total_value, ph.bonus_cash
+	FROM investment_goals
+	JOIN portfolio_history ph USING (user_id)
+	WHERE ph.snapshot_date = $1 AND achieved_on IS NULL AND (target_date IS NULL OR target_date >= $1)
+	ORDER BY user_id, created_at, id`, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]OpenGoal, 0)
+	for rows.Next() {
+		var o OpenGoal
+		g, err := scanInvestmentGoal(rows, &o.TotalValue, &o.BonusCash)
+		if err != nil {
+			return nil, err
+		}
+		o.Goal = *g
+		out = append(out, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Advance records that goal id has come milestone percent of the way,
+// reached on achievedOn (empty if not yet reached), setting its baseline to
+// baseline and baselineBonus if it had none. The update only applies while
+// the goal's last milestone is still fromMilestone, so it reports false
+// when another instance advanced it first or the user deleted it.
+func (s *InvestmentGoalStore) Advance(ctx context.Context, id string, fromMilestone, milestone int, baseline, baselineBonus decimal.Decimal, achievedOn string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+	UPDATE investment_goals
+	SET last_milestone = $3,
+		baseline_bonus_cash = CASE WHEN baseline_value IS NULL THEN $5 ELSE baseline_bonus_cash END,
+		baseline_value = COALESCE(baseline_value, $4),
+		achieved_on = NULLIF($6, '')::date
+	WHERE id = $1 AND last_milestone = $2 AND achieved_on IS NULL`,
+		id, fromMilestone, milestone, baseline, baselineBonus, achievedOn)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,248 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// InviteCode admits up to MaxUses new accounts while registration is
+// invite-only. Uses counts redemptions so far. StartingBalance and League
+// are the cohort the admitted accounts join; nil and empty use the defaults.
+type InviteCode struct {
+	Code            string           `json:"code"`
+	Note            string           `json:"note"`
+	MaxUses         int              `json:"max_uses"`
+	Uses            int              `json:"uses"`
+	StartingBalance *decimal.Decimal `json:"starting_balance,omitempty"`
+	League          string           `json:"league,omitempty"`
+	ExpiresAt       *time.Time       `json:"expires_at"` // nil: never expires
+	RevokedAt       *time.Time       `json:"revoked_at"`
+	CreatedBy       string           `json:"created_by,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	Status          string           `json:"status"` // StatusAt when read
+}
+
+// Cohort returns the cohort c admits accounts into, or nil when it sets
+// nothing.
+func (c *InviteCode) Cohort() *Cohort {
+	if c.StartingBalance == nil && c.League == "" {
+		return nil
+	}
+	return &Cohort{StartingBalance: c.StartingBalance, League: c.League}
+}
+
+// InviteRedemption is one account admitted by an invite code.
+type InviteRedemption struct {
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email,omitempty"` // empty for guests
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// Invite code statuses. A code is active until it is revoked, expires or
+// reaches its use limit, checked in that order.
+const (
+	InviteCodeActive  = "active"
+	InviteCodeRevoked = "revoked"
+	InviteCodeExpired = "expired"
+	InviteCodeUsedUp  = "used_up"
+)
+
+// StatusAt returns c's InviteCode* status at now.
+func (c *InviteCode) StatusAt(now time.Time) string {
+	switch {
+	case c.RevokedAt != nil:
+		return InviteCodeRevoked
+	case c.ExpiresAt != nil && !now.Before(*c.ExpiresAt):
+		return InviteCodeExpired
+	case c.Uses >= c.MaxUses:
+		return InviteCodeUsedUp
+	}
+	return InviteCodeActive
+}
+
+var ErrInviteCodeNotFound = errors.New("invite code not found")
+
+const inviteCodeColumns = `code, note, max_uses, uses, starting_balance, COALESCE(league, ''), expires_at, revoked_at, created_by, created_at`
+
+type InviteCodeStore struct {
+	db DBTX
+}
+
+func NewInviteCodeStore(db DBTX) *InviteCodeStore {
+	return &InviteCodeStore{db: db}
+}
+
+// Create saves codes with no uses, filling in CreatedAt, all or none in
+// one transaction.
+func (s *InviteCodeStore) Create(ctx context.Context, codes []InviteCode) error {
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return createInviteCodes(ctx, s.db, codes)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := createInviteCodes(ctx, tx, codes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func createInviteCodes(ctx context.Context, db DBTX, codes []InviteCode) error {
+	query := `
+	INSERT INTO invite_codes (code, note, max_uses, expires_at, created_by, starting_balance, league)
+	VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''))
+	RETURNING created_at`
+	for i := range codes {
+		c := &codes[i]
+		if err := db.QueryRowContext(ctx, query, c.Code, c.Note, c.MaxUses, c.ExpiresAt, c.CreatedBy, c.StartingBalance, c.League).
+			Scan(&c.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns every invite code, newest first.
+func (s *InviteCodeStore) List(ctx context.Context) ([]InviteCode, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+inviteCodeColumns+` FROM invite_codes ORDER BY created_at DESC, code`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]InviteCode, 0)
+	for rows.Next() {
+		c, err := scanInviteCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Get returns one invite code, or ErrInviteCodeNotFound.
+func (s *InviteCodeStore) Get(ctx context.Context, code string) (*InviteCode, error) {
+	c, err := scanInviteCode(s.db.QueryRowContext(ctx, `SELECT `+inviteCodeColumns+` FROM invite_codes WHERE code = $1`, code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInviteCodeNotFound
+	}
+	return c, err
+}
+
+// Redemptions returns the accounts code admitted, oldest first. Accounts
+// deleted since are absent.
+func (s *InviteCodeStore) Redemptions(ctx context.Context, code string) ([]InviteRedemption, error) {
+	query := `
+	SELECT r.user_id, COALESCE(u.email, ''), r.redeemed_at
+	FROM invite_code_redemptions r
+	JOIN users u ON u.id = r.user_id
+	WHERE r.code = $1
+	ORDER BY r.redeemed_at, r.user_id`
+	rows, err := s.db.QueryContext(ctx, query, code)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]InviteRedemption, 0)
+	for rows.Next() {
+		var r InviteRedemption
+		if err := rows.Scan(&r.UserID, &r.Email, &r.RedeemedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Revoke stops code admitting anyone else. Revoking a revoked code keeps the
+// original time.
+func (s *InviteCodeStore) Revoke(ctx context.Context, code string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE invite_codes SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP) WHERE code = $1`, code)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInviteCodeNotFound
+	}
+	return nil
+}
+
+// Claim takes one use of code if it is unrevoked, unexpired and not used
+// up, reporting whether it did and the cohort the code admits into (nil
+// for the defaults). The check and the increment are one statement, so
+// concurrent sign-ups cannot overrun max_uses.
+func (s *InviteCodeStore) Claim(ctx context.Context, code string) (*Cohort, bool, error) {
+	query := `
+	UPDATE invite_codes SET uses = uses + 1
+	WHERE code = $1 AND revoked_at IS NULL AND uses < max_uses
+		AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)
+	RETURNING starting_balance, COALESCE(league, '')`
+	var c InviteCode
+	var balance decimal.NullDecimal
+	err := s.db.QueryRowContext(ctx, query, code).Scan(&balance, &c.League)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if balance.Valid {
+		c.StartingBalance = &balance.Decimal
+	}
+	return c.Cohort(), true, nil
+}
+
+// Release gives back a use taken by Claim, for a sign-up that failed.
+func (s *InviteCodeStore) Release(ctx context.Context, code string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE invite_codes SET uses = uses - 1 WHERE code = $1 AND uses > 0`, code)
+	return err
+}
+
+// RecordRedemption notes that code admitted userID.
+func (s *InviteCodeStore) RecordRedemption(ctx context.Context, code, userID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO invite_code_redemptions (code, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, code, userID)
+	return err
+}
+
+func scanInviteCode(row rowScanner) (*InviteCode, error) {
+	var c InviteCode
+	var balance decimal.NullDecimal
+	var expiresAt, revokedAt sql.NullTime
+	var createdBy sql.NullString
+	if err := row.Scan(&c.Code, &c.Note, &c.MaxUses, &c.Uses, &balance, &c.League, &expiresAt, &revokedAt, &createdBy, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	if balance.Valid {
+		c.StartingBalance = &balance.Decimal
+	}
+	if expiresAt.Valid {
+		c.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		c.RevokedAt = &revokedAt.Time
+	}
+	c.CreatedBy = createdBy.String
+	c.Status = c.StatusAt(time.Now())
+	return &c, nil
+}
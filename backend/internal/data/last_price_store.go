@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// LastPrice is the latest quote fetched for a symbol. QuoteDate is the
+// quote's own date as the provider reported it (MM/DD/YYYY).
+type LastPrice struct {
+	Symbol    string
+	Price     decimal.Decimal
+	QuoteDate string
+	FetchedAt time.Time
+}
+
+// LastPriceStore keeps the latest quote fetched for each symbol, so prices
+// survive a provider outage with an empty cache.
+type LastPriceStore struct {
+	db DBTX
+}
+
+func NewLastPriceStore(db DBTX) *LastPriceStore {
+	return &LastPriceStore{db: db}
+}
+
+// Save records p as symbol's latest price. An older fetch never replaces a
+// newer one, so concurrent lookups can't roll the price back.
+func (s *LastPriceStore) Save(ctx context.Context, p LastPrice) error {
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO last_prices (symbol, price, quote_date, fetched_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (symbol) DO UPDATE SET
+		price = EXCLUDED.price,
+		quote_date = EXCLUDED.quote_date,
+		fetched_at = EXCLUDED.fetched_at
+	WHERE last_prices.fetched_at <= EXCLUDED.fetched_at`,
+		p.Symbol, p.Price, p.QuoteDate, p.FetchedAt.UTC())
+	return err
+}
+
+// Get returns symbol's latest price, or sql.ErrNoRows if none was saved.
+func (s *LastPriceStore) Get(ctx context.Context, symbol string) (*LastPrice, error) {
+	p := &LastPrice{Symbol: symbol}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT price, quote_date, fetched_at FROM last_prices WHERE symbol = $1`, symbol).
+		Scan(&p.Price, &p.QuoteDate, &p.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TaxLot is the shares bought by one trade. Remaining drops as sells draw
+// from the lot; a lot with nothing remaining is closed.
+type TaxLot struct {
+	ID         string          `json:"id"`
+	UserID     string          `json:"-"`
+	Symbol     string          `json:"symbol"`
+	TradeID    string          `json:"trade_id,omitempty"` // empty for lots backfilled from a holding
+	Quantity   decimal.Decimal `json:"quantity"`
+	Remaining  decimal.Decimal `json:"remaining"`
+	Price      decimal.Decimal `json:"price"`
+	AcquiredAt time.Time       `json:"acquired_at"`
+}
+
+// LotDisposal is the part of a sell drawn from one lot, and the gain
+// realized on it. LotID is empty for shares no lot covered.
+type LotDisposal struct {
+	ID          string          `json:"id"`
+	UserID      string          `json:"-"`
+	SellTradeID string          `json:"sell_trade_id"`
+	LotID       string          `json:"lot_id,omitempty"`
+	Symbol      string          `json:"symbol"`
+	Quantity    decimal.Decimal `json:"quantity"`
+	CostPrice   decimal.Decimal `json:"cost_price"`
+	SalePrice   decimal.Decimal `json:"sale_price"`
+	Realized    decimal.Decimal `json:"realized"`
+	Method      string          `json:"method"` // the user's CostBasis* method at the time of the sell
+	DisposedAt  time.Time       `json:"disposed_at"`
+}
+
+type LotStore struct {
+	db DBTX
+}
+
+func NewLotStore(db DBTX) *LotStore {
+	return &LotStore{db: db}
+}
+
+// CreateLot records lot, filling in its ID. acquired_at is set by the DB.
+func (s *LotStore) CreateLot(ctx context.Context, lot *TaxLot) error {
+	lot.ID = uuid.New().String()
+	query := `
+	INSERT INTO tax_lots (id, user_id, symbol, trade_id, quantity, remaining, price)
+	VALUES ($1, $2, $3, NULLIF($4, ''), $5, $5, $6)
+	RETURNING acquired_at`
+	return s.db.QueryRowContext(ctx, query, lot.ID, lot.UserID, lot.Symbol, lot.TradeID, lot.Quantity, lot.Price).
+		Scan(&lot.AcquiredAt)
+}
+
+// OpenLotsForUpdate returns userID's open lots in symbol, oldest first (or
+// newest first when newestFirst is set), and locks them until the
+// surrounding transaction ends.
+func (s *LotStore) OpenLotsForUpdate(ctx context.Context, userID, symbol string, newestFirst bool) ([]TaxLot, error) {
+	order := `acquired_at, id`
+	if newestFirst {
+		order = `acquired_at DESC, id DESC`
+	}
+	query := `SELECT ` + lotColumns + ` FROM tax_lots
+	WHERE user_id = $1 AND symbol = $2 AND remaining > 0
+	ORDER BY ` + order + ` FOR UPDATE`
+	return s.queryLots(ctx, query, userID, symbol)
+}
+
+// ListOpenLots returns all of userID's open lots by symbol, oldest first.
+func (s *LotStore) ListOpenLots(ctx context.Context, userID string) ([]TaxLot, error) {
+	query := `SELECT ` + lotColumns + ` FROM tax_lots
+	WHERE user_id = $1 AND remaining > 0
+	ORDER BY symbol, acquired_at, id`
+	return s.queryLots(ctx, query, userID)
+}
+
+// ReduceLot takes quantity shares off the lot's remaining count.
+func (s *LotStore) ReduceLot(ctx context.Context, lotID string, quantity decimal.Decimal) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE tax_lots SET remaining = remaining - $2 WHERE id = $1`, lotID, quantity)
+	return err
+}
+
+// RestoreLot puts quantity shares back on the lot's remaining count, as
+// when the sell that drew them is reversed.
+func (s *LotStore) RestoreLot(ctx context.Context, lotID string, quantity decimal.Decimal) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE tax_lots SET remaining = remaining + $2 WHERE id = $1`, lotID, quantity)
+	return err
+}
+
+// DeleteDisposals removes the disposals recorded for one sell and returns
+// them, so the sell can be undone.
+func (s *LotStore) DeleteDisposals(ctx context.Context, userID, sellTradeID string) ([]LotDisposal, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	DELETE FROM lot_disposals WHERE user_id = $1 AND sell_trade_id = $2
+	RETURNING id, user_id, sell_trade_id, lot_id, symbol, quantity, cost_price, sale_price, realized, method, disposed_at`,
+		userID, sellTradeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]LotDisposal, 0)
+	for rows.Next() {
+		var d LotDisposal
+		var lotID sql.NullString
+		if err := rows.Scan(&d.ID, &d.UserID, &d.SellTradeID, &lotID, &d.Symbol, &d.Quantity,
+			&d.CostPrice, &d.SalePrice, &d.Realized, &d.Method, &d.DisposedAt); err != nil {
+			return nil, err
+		}
+		d.LotID = lotID.String
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// CreateDisposal records d, filling in its ID.
+func (s *LotStore) CreateDisposal(ctx context.Context, d *LotDisposal) error {
+	d.ID = uuid.New().String()
+	query := `
+	INSERT INTO lot_disposals (id, user_id, sell_trade_id, lot_id, symbol, quantity, cost_price, sale_price, realized, method)
+	VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10)
+	RETURNING disposed_at`
+	return s.db.QueryRowContext(ctx, query, d.ID, d.UserID, d.SellTradeID, d.LotID, d.Symbol, d.Quantity,
+		d.CostPrice, d.SalePrice, d.Realized, d.Method).Scan(&d.DisposedAt)
+}
+
+// RealizedBySellTrade returns the gain realized against lots by each of
+// userID's sells, keyed by sell trade ID. Sells from before lots were
+// tracked are absent.
+func (s *LotStore) RealizedBySellTrade(ctx context.Context, userID string) (map[string]decimal.Decimal, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT sell_trade_id, SUM(realized) FROM lot_disposals WHERE user_id = $1 GROUP BY sell_trade_id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[string]decimal.Decimal{}
+	for rows.Next() {
+		var tradeID string
+		var realized decimal.Decimal
+		if err := rows.Scan(&tradeID, &realized); err != nil {
+			return nil, err
+		}
+		out[tradeID] = realized
+	}
+	return out, rows.Err()
+}
+
+const lotColumns = `id, user_id, symbol, trade_id, quantity, remaining, price, acquired_at`
+
+func (s *LotStore) queryLots(ctx context.Context, query string, args ...any) ([]TaxLot, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TaxLot, 0)
+	for rows.Next() {
+		var lot TaxLot
+		var tradeID sql.NullString
+		if err := rows.Scan(&lot.ID, &lot.UserID, &lot.Symbol, &tradeID, &lot.Quantity, &lot.Remaining, &lot.Price, &lot.AcquiredAt); err != nil {
+			return nil, err
+		}
+		lot.TradeID = tradeID.String
+		out = append(out, lot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,44 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Quarantine reasons.
+const (
+	QuarantineNonPositive   = "non_positive_price"
+	QuarantineDuplicateDate = "duplicate_date"
+	QuarantineExcessiveMove = "excessive_move"
+)
+
+// QuarantinedPrice is a provider price the market data checks refused.
+// ReferencePrice is the accepted price it was compared against, if any.
+type QuarantinedPrice struct {
+	Symbol         string
+	Source         string // "quote" or "eod"
+	TradeDate      time.Time
+	Price          decimal.Decimal
+	ReferencePrice *decimal.Decimal
+	Reason         string
+}
+
+// MarketQuarantineStore records rejected provider prices so a bad upstream
+// tick can be looked at after the fact.
+type MarketQuarantineStore struct {
+	db DBTX
+}
+
+func NewMarketQuarantineStore(db DBTX) *MarketQuarantineStore {
+	return &MarketQuarantineStore{db: db}
+}
+
+func (s *MarketQuarantineStore) Record(ctx context.Context, q QuarantinedPrice) error {
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO market_data_quarantine (symbol, source, trade_date, price, reference_price, reason)
+	VALUES ($1, $2, $3, $4, $5, $6)`,
+		q.Symbol, q.Source, q.TradeDate, q.Price, q.ReferencePrice, q.Reason)
+	return err
+}
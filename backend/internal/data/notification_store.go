@@ -0,0 +1,146 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification is a single in-app message for a user.
+type Notification struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+type NotificationStore struct {
+	db DBTX
+}
+
+func NewNotificationStore(db DBTX) *NotificationStore {
+	return &NotificationStore{db: db}
+}
+
+// notificationBatchSize bounds a single multi-VALUES insert (4 params per row).
+const notificationBatchSize = 1000
+
+// CreateForUsers inserts the same notification for every user in userIDs.
+// No-op on empty input.
+func (s *NotificationStore) CreateForUsers(ctx context.Context, userIDs []string, kind, title, body string) error {
+	for i := 0; i < len(userIDs); i += notificationBatchSize {
+		end := i + notificationBatchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		if err := s.insertChunk(ctx, userIDs[i:end], kind, title, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *NotificationStore) insertChunk(ctx context.Context, userIDs []string, kind, title, body string) error {
+	var b strings.Builder
+	b.WriteString("INSERT INTO notifications (id, user_id, kind, title, body) VALUES ")
+
+	// kind/title/body are shared by every row, so they take $1..$3 once.
+	args := make([]any, 0, 3+len(userIDs)*2)
+	args = append(args, kind, title, body)
+	for i, userID := range userIDs {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		base := 4 + i*2
+		b.WriteString("($")
+		b.WriteString(strconv.Itoa(base))
+		b.WriteString(",$")
+		b.WriteString(strconv.Itoa(base + 1))
+		b.WriteString(",$1,$2,$3)")
+		args = append(args, uuid.New().String(), userID)
+	}
+
+	_, err := s.db.ExecContext(ctx, b.String(), args...)
+	return err
+}
+
+// ListByUser returns the user's most recent notifications, newest first.
+func (s *NotificationStore) ListByUser(ctx context.Context, userID string, unreadOnly bool, limit int) ([]Notification, error) {
+	query := `
+	SELECT id, user_id, kind, title, body, read_at, created_at
+	FROM notifications
+	WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+
+	rows, err := s.db.QueryContext(ctx, query, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Notification, 0)
+	for rows.Next() {
+		var n Notification
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Title, &n.Body, &readAt, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+		}
+		out = append(out, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CountUnread returns how many of the user's notifications have not been read.
+func (s *NotificationStore) CountUnread(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	).Scan(&n)
+	return n, err
+}
+
+// MarkRead marks one notification as read. Scoped by user_id so a caller can
+// never acknowledge another user's notification. Returns
+// ErrNotificationNotFound when no matching row exists.
+func (s *NotificationStore) MarkRead(ctx context.Context, userID, id string) error {
+	query := `
+	UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+	WHERE id = $1 AND user_id = $2`
+
+	result, err := s.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread notification for the user as read.
+func (s *NotificationStore) MarkAllRead(ctx context.Context, userID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND read_at IS NULL`, userID)
+	return err
+}
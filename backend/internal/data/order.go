@@ -0,0 +1,406 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Order is a resting order that waits for its trigger price. OrderType is
+// one of OrderTypeMarket, OrderTypeLimit, OrderTypeStop, OrderTypeStopLoss or
+// OrderTypeTakeProfit; the last two always sell. TriggerPrice is the limit
+// price for LIMIT and TAKE_PROFIT orders and the stop price for STOP and
+// STOP_LOSS. MARKET orders have none: they are trades queued while the
+// market was closed and fill at the next open. OCOGroupID links the two
+// halves of a one-cancels-other pair; it is empty for a standalone order.
+// ParentOrderID links the take-profit and stop-loss of a bracket to its
+// entry order; they are WAITING until the entry fills. TimeInForce is
+// OrderTIFDay or OrderTIFGTC; either way the order is expired once
+// ExpiresAt passes. IdempotencyKey is the Idempotency-Key of the buy or sell
+// that queued a MARKET order, empty otherwise.
+type Order struct {
+	ID             string           `json:"id"`
+	UserID         string           `json:"user_id"`
+	Symbol         string           `json:"symbol"`
+	Side           string           `json:"side"` // BUY or SELL
+	OrderType      string           `json:"order_type"`
+	Quantity       decimal.Decimal  `json:"quantity"`
+	TimeInForce    string           `json:"time_in_force"`
+	TriggerPrice   *decimal.Decimal `json:"trigger_price,omitempty"`
+	Status         string           `json:"status"` // WAITING, PENDING, FILLED, CANCELLED, EXPIRED, FAILED
+	CreatedAt      time.Time        `json:"created_at"`
+	ExpiresAt      *time.Time       `json:"expires_at,omitempty"`
+	ClosedAt       *time.Time       `json:"closed_at,omitempty"`
+	TradeID        string           `json:"trade_id,omitempty"`
+	FillPrice      *decimal.Decimal `json:"fill_price,omitempty"`
+	FailureReason  string           `json:"failure_reason,omitempty"`
+	OCOGroupID     string           `json:"oco_group_id,omitempty"`
+	IdempotencyKey string           `json:"idempotency_key,omitempty"`
+	ParentOrderID  string           `json:"parent_order_id,omitempty"`
+}
+
+// Order sides.
+const (
+	OrderSideBuy  = "BUY"
+	OrderSideSell = "SELL"
+)
+
+// Order times in force. A DAY order expires at the close of the session it
+// was placed for; a GTC (good-til-cancelled) order rests until its
+// expires_at, if any.
+const (
+	OrderTIFDay = "DAY"
+	OrderTIFGTC = "GTC"
+)
+
+// Order statuses. PENDING orders fill once triggered. WAITING is a bracket
+// exit whose entry has not filled yet: it never fills, and the statements
+// that close the entry make it PENDING or cancel it with them. Every other
+// status is terminal.
+const (
+	OrderWaiting   = "WAITING"
+	OrderPending   = "PENDING"
+	OrderFilled    = "FILLED"
+	OrderCancelled = "CANCELLED"
+	OrderExpired   = "EXPIRED"
+	OrderFailed    = "FAILED"
+)
+
+var (
+	ErrOrderNotFound   = errors.New("order not found")
+	ErrOrderNotPending = errors.New("order is no longer pending")
+)
+
+const orderColumns = `id, user_id, symbol, side, order_type, quantity, trigger_price, status,
+	created_at, expires_at, closed_at, trade_id, fill_price, failure_reason, oco_group_id, time_in_force, idempotency_key, parent_order_id`
+
+type OrderStore struct {
+	db DBTX
+}
+
+func NewOrderStore(db DBTX) *OrderStore {
+	return &OrderStore{db: db}
+}
+
+func scanOrder(row rowScanner) (*Order, error) {
+	var o Order
+	var expiresAt, closedAt sql.NullTime
+	var tradeID, reason, group, ikey, parent sql.NullString
+	var trigger, fill decimal.NullDecimal
+	if err := row.Scan(&o.ID, &o.UserID, &o.Symbol, &o.Side, &o.OrderType, &o.Quantity, &trigger, &o.Status,
+		&o.CreatedAt, &expiresAt, &closedAt, &tradeID, &fill, &reason, &group, &o.TimeInForce, &ikey, &parent); err != nil {
+		return nil, err
+	}
+	if trigger.Valid {
+		o.TriggerPrice = &trigger.Decimal
+	}
+	if expiresAt.Valid {
+		o.ExpiresAt = &expiresAt.Time
+	}
+	if closedAt.Valid {
+		o.ClosedAt = &closedAt.Time
+	}
+	if fill.Valid {
+		o.FillPrice = &fill.Decimal
+	}
+	o.TradeID = tradeID.String
+	o.FailureReason = reason.String
+	o.OCOGroupID = group.String
+	o.IdempotencyKey = ikey.String
+	o.ParentOrderID = parent.String
+	return &o, nil
+}
+
+func (s *OrderStore) query(ctx context.Context, query string, args ...any) ([]Order, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Create inserts order as a new PENDING order, or WAITING if it has a
+// ParentOrderID, and returns the stored row. ID is assigned here; Status
+// and the fill fields are ignored. An empty
+// TimeInForce is stored as GTC. A non-empty IdempotencyKey that the user has
+// already used fails with a unique violation.
+func (s *OrderStore) Create(ctx context.Context, order *Order) (*Order, error) {
+	query := `
+	INSERT INTO orders (id, user_id, symbol, side, order_type, quantity, trigger_price, expires_at, oco_group_id, time_in_force, idempotency_key,
+		parent_order_id, status)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	RETURNING ` + orderColumns
+
+	var trigger decimal.NullDecimal
+	if order.TriggerPrice != nil {
+		trigger = decimal.NullDecimal{Decimal: *order.TriggerPrice, Valid: true}
+	}
+	var expiresAt sql.NullTime
+	if order.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: order.ExpiresAt.UTC(), Valid: true}
+	}
+	tif := order.TimeInForce
+	if tif == "" {
+		tif = OrderTIFGTC
+	}
+	status := OrderPending
+	if order.ParentOrderID != "" {
+		status = OrderWaiting
+	}
+	return scanOrder(s.db.QueryRowContext(ctx, query,
+		uuid.New().String(), order.UserID, order.Symbol, order.Side, order.OrderType,
+		order.Quantity, trigger, expiresAt, sql.NullString{String: order.OCOGroupID, Valid: order.OCOGroupID != ""}, tif,
+		sql.NullString{String: order.IdempotencyKey, Valid: order.IdempotencyKey != ""},
+		sql.NullString{String: order.ParentOrderID, Valid: order.ParentOrderID != ""}, status))
+}
+
+// Get returns one of the user's orders, or ErrOrderNotFound.
+func (s *OrderStore) Get(ctx context.Context, userID, id string) (*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE id = $1 AND user_id = $2`
+
+	o, err := scanOrder(s.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetByIdempotencyKey returns the user's order queued with key, or (nil, nil)
+// if there is none.
+func (s *OrderStore) GetByIdempotencyKey(ctx context.Context, userID, key string) (*Order, error) {
+	query := `SELECT ` + orderColumns + ` FROM orders WHERE user_id = $1 AND idempotency_key = $2`
+
+	o, err := scanOrder(s.db.QueryRowContext(ctx, query, userID, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return o, err
+}
+
+// ListByUser returns the user's orders, newest first. status "" means all.
+func (s *OrderStore) ListByUser(ctx context.Context, userID, status string, limit int) ([]Order, error) {
+	query := `
+	SELECT ` + orderColumns + `
+	FROM orders
+	WHERE user_id = $1 AND ($2 = '' OR status = $2)
+	ORDER BY created_at DESC, id DESC
+	LIMIT $3`
+	return s.query(ctx, query, userID, status, limit)
+}
+
+// CountPending returns how many open (PENDING or WAITING) orders the user
+// has.
+func (s *OrderStore) CountPending(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM orders WHERE user_id = $1 AND status IN ('PENDING', 'WAITING')`, userID,
+	).Scan(&n)
+	return n, err
+}
+
+// PendingSymbols returns the distinct symbols with at least one PENDING order.
+func (s *OrderStore) PendingSymbols(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT DISTINCT symbol FROM orders WHERE status = 'PENDING' ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	symbols := make([]string, 0)
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+// ListPendingBySymbol returns every PENDING order on symbol, oldest first so
+// earlier orders fill first when they compete for the same shares or cash.
+func (s *OrderStore) ListPendingBySymbol(ctx context.Context, symbol string) ([]Order, error) {
+	query := `
+	SELECT ` + orderColumns + `
+	FROM orders
+	WHERE symbol = $1 AND status = 'PENDING'
+	ORDER BY created_at ASC, id ASC`
+	return s.query(ctx, query, symbol)
+}
+
+// ListPendingByUser returns every PENDING order of the user's, oldest first.
+// The per-user pending cap keeps the list short.
+func (s *OrderStore) ListPendingByUser(ctx context.Context, userID string) ([]Order, error) {
+	query := `
+	SELECT ` + orderColumns + `
+	FROM orders
+	WHERE user_id = $1 AND status = 'PENDING'
+	ORDER BY created_at ASC, id ASC`
+	return s.query(ctx, query, userID)
+}
+
+// Cancel moves the user's open order to CANCELLED, along with the waiting
+// exits of a bracket entry, and returns it. Returns ErrOrderNotFound if the
+// user has no such order and ErrOrderNotPending if it is already filled,
+// cancelled, expired or failed.
+func (s *OrderStore) Cancel(ctx context.Context, userID, id string) (*Order, error) {
+	query := `
+	UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND user_id = $2 AND status IN ('PENDING', 'WAITING')
+	RETURNING ` + orderColumns
+	if !sqliteStorage.Load() {
+		// On SQLite the orders_bracket_exits_cancel trigger does this.
+		query = `
+	WITH exits AS (
+		UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+		WHERE parent_order_id = $1 AND user_id = $2 AND status = 'WAITING'
+	)` + query
+	}
+
+	o, err := scanOrder(s.db.QueryRowContext(ctx, query, id, userID))
+	if err == nil {
+		return o, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND user_id = $2)`, id, userID,
+	).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrOrderNotPending
+	}
+	return nil, ErrOrderNotFound
+}
+
+// ExpireDue moves every PENDING order whose expires_at is at or before now to
+// EXPIRED and returns them. The waiting exits of an expired bracket entry
+// are cancelled with it.
+func (s *OrderStore) ExpireDue(ctx context.Context, now time.Time) ([]Order, error) {
+	query := `
+	WITH expired AS (
+		UPDATE orders SET status = 'EXPIRED', closed_at = CURRENT_TIMESTAMP
+		WHERE status = 'PENDING' AND expires_at <= $1
+		RETURNING ` + orderColumns + `
+	), exits AS (
+		UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+		WHERE status = 'WAITING' AND parent_order_id IN (SELECT id FROM expired)
+	)
+	SELECT ` + orderColumns + ` FROM expired`
+	if sqliteStorage.Load() {
+		// The orders_bracket_exits_cancel trigger cancels the exits.
+		query = `
+	UPDATE orders SET status = 'EXPIRED', closed_at = CURRENT_TIMESTAMP
+	WHERE status = 'PENDING' AND expires_at <= $1
+	RETURNING ` + orderColumns
+	}
+	return s.query(ctx, query, now.UTC())
+}
+
+// MarkFilled records the fill of a PENDING order and makes the waiting exits
+// of a bracket entry PENDING. Run it in the same transaction as the trade:
+// the row lock it takes makes a concurrent cancel or a second monitor wait,
+// and they then see the order is no longer pending. Returns
+// ErrOrderNotPending if the order is not PENDING.
+func (s *OrderStore) MarkFilled(ctx context.Context, id, tradeID string, fillPrice decimal.Decimal) error {
+	query := `
+	UPDATE orders
+	SET status = 'FILLED', closed_at = CURRENT_TIMESTAMP, trade_id = $2, fill_price = $3
+	WHERE id = $1 AND status = 'PENDING'`
+	if !sqliteStorage.Load() {
+		// On SQLite the orders_bracket_exits_release trigger does this.
+		query = `
+	WITH exits AS (
+		UPDATE orders SET status = 'PENDING'
+		WHERE parent_order_id = $1 AND status = 'WAITING'
+	)` + query
+	}
+	return s.close(ctx, query, id, tradeID, fillPrice)
+}
+
+// LockOCOGroup takes the row locks on every order in group, in id order, so
+// that fills of both halves of a pair on two instances queue behind each
+// other instead of deadlocking. Run it in the fill's transaction before
+// MarkFilled.
+func (s *OrderStore) LockOCOGroup(ctx context.Context, group string) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id FROM orders WHERE oco_group_id = $1 ORDER BY id FOR UPDATE`, group)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// CancelOCOGroup cancels the open orders in group other than exceptID and
+// returns them.
+func (s *OrderStore) CancelOCOGroup(ctx context.Context, group, exceptID string) ([]Order, error) {
+	query := `
+	UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+	WHERE oco_group_id = $1 AND id <> $2 AND status IN ('PENDING', 'WAITING')
+	RETURNING ` + orderColumns
+	return s.query(ctx, query, group, exceptID)
+}
+
+// MarkFailed closes a PENDING order that triggered but could not be filled,
+// cancelling the waiting exits of a bracket entry. Returns
+// ErrOrderNotPending if the order is not PENDING.
+func (s *OrderStore) MarkFailed(ctx context.Context, id, reason string) error {
+	query := `
+	UPDATE orders
+	SET status = 'FAILED', closed_at = CURRENT_TIMESTAMP, failure_reason = $2
+	WHERE id = $1 AND status = 'PENDING'`
+	if !sqliteStorage.Load() {
+		// On SQLite the orders_bracket_exits_cancel trigger does this.
+		query = `
+	WITH exits AS (
+		UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+		WHERE parent_order_id = $1 AND status = 'WAITING'
+	)` + query
+	}
+	return s.close(ctx, query, id, reason)
+}
+
+func (s *OrderStore) close(ctx context.Context, query string, args ...any) error {
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrOrderNotPending
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+)
+
+var orderCols = []string{
+	"id", "user_id", "symbol", "side", "order_type", "quantity", "trigger_price", "status",
+	"created_at", "expires_at", "closed_at", "trade_id", "fill_price", "failure_reason", "oco_group_id", "time_in_force", "idempotency_key",
+	"parent_order_id",
+}
+
+func TestOrderCancel_DistinguishesMissingFromClosed(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		exists bool
+		want   error
+	}{
+		{"not found", false, ErrOrderNotFound},
+		{"already closed", true, ErrOrderNotPending},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("UPDATE orders SET status = 'CANCELLED'").
+				WithArgs("ord-1", "user-1").
+				WillReturnRows(sqlmock.NewRows(orderCols))
+			mock.ExpectQuery("SELECT EXISTS").
+				WithArgs("ord-1", "user-1").
+				WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(tc.exists))
+
+			_, err = NewOrderStore(db).Cancel(context.Background(), "user-1", "ord-1")
+			if !errors.Is(err, tc.want) {
+				t.Errorf("got %v, want %v", err, tc.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestOrderMarkFilled_NotPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE orders").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewOrderStore(db).MarkFilled(context.Background(), "ord-1", "trade-1", decimal.NewFromInt(90))
+	if !errors.Is(err, ErrOrderNotPending) {
+		t.Errorf("expected ErrOrderNotPending, got %v", err)
+	}
+}
+
+func TestOrderGet_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT id, user_id, symbol, side").
+		WithArgs("ord-1", "user-1").
+		WillReturnRows(sqlmock.NewRows(orderCols))
+
+	if _, err := NewOrderStore(db).Get(context.Background(), "user-1", "ord-1"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestOrderExpireDue_ReturnsExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 3, 2, 21, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE orders SET status = 'EXPIRED'").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+			"ord-1", "user-1", "AAPL", "BUY", "LIMIT", 5, decimal.NewFromInt(100), "EXPIRED",
+			now.Add(-time.Hour), now, now, nil, nil, nil, nil, "GTC", nil, nil))
+
+	expired, err := NewOrderStore(db).ExpireDue(context.Background(), now)
+	if err != nil {
+		t.Fatalf("ExpireDue: %v", err)
+	}
+	if len(expired) != 1 || expired[0].Status != OrderExpired || expired[0].ExpiresAt == nil {
+		t.Errorf("expired = %+v", expired)
+	}
+}
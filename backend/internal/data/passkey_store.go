@@ -0,0 +1,164 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Passkey is a WebAuthn credential registered to a user. Credential is the
+// library's JSON encoding of the public key and authenticator state; it is
+// opaque to this package.
+type Passkey struct {
+	ID         string     `json:"id"` // base64url credential ID
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	Credential []byte     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+var (
+	ErrPasskeyNotFound = errors.New("passkey not found")
+	ErrPasskeyExists   = errors.New("passkey already registered")
+)
+
+type PasskeyStore struct {
+	db DBTX
+}
+
+func NewPasskeyStore(db DBTX) *PasskeyStore {
+	return &PasskeyStore{db: db}
+}
+
+// Create stores a newly registered passkey. A credential ID can belong to
+// only one account; registering it again returns ErrPasskeyExists.
+func (s *PasskeyStore) Create(ctx context.Context, p *Passkey) error {
+	query := `
+	INSERT INTO webauthn_credentials (id, user_id, name, credential, created_at)
+	VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := s.db.ExecContext(ctx, query, p.ID, p.UserID, p.Name, p.Credential, p.CreatedAt.UTC())
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrPasskeyExists
+		}
+		return err
+	}
+	return nil
+}
+
+// ListByUser returns the user's passkeys, oldest first.
+func (s *PasskeyStore) ListByUser(ctx context.Context, userID string) ([]Passkey, error) {
+	query := `
+	SELECT id, user_id, name, credential, created_at, last_used_at
+	FROM webauthn_credentials
+	WHERE user_id = $1
+	ORDER BY created_at, id`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]Passkey, 0)
+	for rows.Next() {
+		p, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetByID looks up a passkey by credential ID.
+func (s *PasskeyStore) GetByID(ctx context.Context, id string) (*Passkey, error) {
+	query := `
+	SELECT id, user_id, name, credential, created_at, last_used_at
+	FROM webauthn_credentials
+	WHERE id = $1`
+
+	p, err := scanPasskey(s.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPasskeyNotFound
+	}
+	return p, err
+}
+
+// RecordUse stores the credential state after a successful login (the
+// authenticator's sign counter moves on every use) and the time of use.
+func (s *PasskeyStore) RecordUse(ctx context.Context, id string, credential []byte, usedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE webauthn_credentials SET credential = $2, last_used_at = $3 WHERE id = $1`,
+		id, credential, usedAt.UTC())
+	return err
+}
+
+// Delete removes one of the user's passkeys. Scoped by user_id so a caller
+// can never remove another user's passkey.
+func (s *PasskeyStore) Delete(ctx context.Context, userID, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM webauthn_credentials WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// CreateLoginChallenge records a passkey login challenge that may be
+// answered once before expiresAt. Expired challenges are swept on the way.
+func (s *PasskeyStore) CreateLoginChallenge(ctx context.Context, challenge string, expiresAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx,
+		`DELETE FROM passkey_login_challenges WHERE expires_at <= CURRENT_TIMESTAMP`); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO passkey_login_challenges (challenge, expires_at) VALUES ($1, $2)`,
+		challenge, expiresAt.UTC())
+	return err
+}
+
+// ConsumeLoginChallenge deletes the challenge if it is outstanding and
+// unexpired. Returns false when it was already used, expired or never
+// issued.
+func (s *PasskeyStore) ConsumeLoginChallenge(ctx context.Context, challenge string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM passkey_login_challenges WHERE challenge = $1 AND expires_at > CURRENT_TIMESTAMP`,
+		challenge)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func scanPasskey(row rowScanner) (*Passkey, error) {
+	var p Passkey
+	var lastUsed sql.NullTime
+	if err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.Credential, &p.CreatedAt, &lastUsed); err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		p.LastUsedAt = &lastUsed.Time
+	}
+	return &p, nil
+}
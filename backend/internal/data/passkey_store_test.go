@@ -0,0 +1,88 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var passkeyCols = []string{"id", "user_id", "name", "credential", "created_at", "last_used_at"}
+
+func TestPasskeyCreate_DuplicateCredential(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO webauthn_credentials").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "webauthn_credentials_pkey"})
+
+	err = NewPasskeyStore(db).Create(context.Background(), &Passkey{
+		ID: "cred-1", UserID: "user-1", Credential: []byte(`{}`), CreatedAt: time.Now(),
+	})
+	if !errors.Is(err, ErrPasskeyExists) {
+		t.Errorf("expected ErrPasskeyExists, got %v", err)
+	}
+}
+
+func TestPasskeyListByUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	used := time.Now()
+	mock.ExpectQuery("SELECT id, user_id, name, credential, created_at, last_used_at\\s+FROM webauthn_credentials\\s+WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(passkeyCols).
+			AddRow("cred-1", "user-1", "Laptop", []byte(`{}`), time.Now(), nil).
+			AddRow("cred-2", "user-1", "Phone", []byte(`{}`), time.Now(), used))
+
+	passkeys, err := NewPasskeyStore(db).ListByUser(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("ListByUser: %v", err)
+	}
+	if len(passkeys) != 2 || passkeys[0].LastUsedAt != nil || passkeys[1].LastUsedAt == nil {
+		t.Errorf("unexpected passkeys %+v", passkeys)
+	}
+}
+
+func TestPasskeyGetByID_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM webauthn_credentials").WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows(passkeyCols))
+
+	_, err = NewPasskeyStore(db).GetByID(context.Background(), "missing")
+	if !errors.Is(err, ErrPasskeyNotFound) {
+		t.Errorf("expected ErrPasskeyNotFound, got %v", err)
+	}
+}
+
+func TestPasskeyDelete_ScopedToUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// Another user's credential: the user_id predicate matches nothing.
+	mock.ExpectExec("DELETE FROM webauthn_credentials WHERE id = \\$1 AND user_id = \\$2").
+		WithArgs("cred-1", "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = NewPasskeyStore(db).Delete(context.Background(), "user-2", "cred-1")
+	if !errors.Is(err, ErrPasskeyNotFound) {
+		t.Errorf("expected ErrPasskeyNotFound, got %v", err)
+	}
+}
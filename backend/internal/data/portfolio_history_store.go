@@ -0,0 +1,233 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// PortfolioSnapshot is a user's account value at one trading day's close.
+// HoldingsValue counts long positions at the closing price and shorts as
+// their margin less the cost to buy them back. Partial is set when a held
+// symbol had no closing price and was valued at cost instead. BonusCash is
+// the bonus cash granted to the user so far; it is part of Cash, but put in
+// rather than earned, so returns leave it out.
+type PortfolioSnapshot struct {
+	Date          string          `json:"date"` // YYYY-MM-DD, the session's New York date
+	Cash          decimal.Decimal `json:"cash"`
+	HoldingsValue decimal.Decimal `json:"holdings_value"`
+	TotalValue    decimal.Decimal `json:"total_value"`
+	Partial       bool            `json:"partial"`
+	BonusCash     decimal.Decimal `json:"bonus_cash"`
+}
+
+type PortfolioHistoryStore struct {
+	db DBTX
+}
+
+func NewPortfolioHistoryStore(db DBTX) *PortfolioHistoryStore {
+	return &PortfolioHistoryStore{db: db}
+}
+
+// HasSnapshot reports whether any snapshot was recorded for date (in its
+// location).
+func (s *PortfolioHistoryStore) HasSnapshot(ctx context.Context, date time.Time) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM portfolio_history WHERE snapshot_date = $1)`, date.Format(time.DateOnly)).
+		Scan(&exists)
+	return exists, err
+}
+
+// Record snapshots every user created before sessionClose for the session's
+// date (in sessionClose's location), valuing each position at
+// prices[symbol] or, for a symbol missing from prices, its average cost.
+// Users who already have a snapshot for the date are skipped, so a rerun
+// (or a second instance) adds nothing. Returns the number recorded.
+func (s *PortfolioHistoryStore) Record(ctx context.Context, sessionClose time.Time, prices map[string]decimal.Decimal) (int64, error) {
+	symbols := make([]string, 0, len(prices))
+	values := make([]string, 0, len(prices))
+	for symbol, price := range prices {
+		symbols = append(symbols, symbol)
+		values = append(values, price.String())
+	}
+
+	query := `
+	INSERT INTO portfolio_history (user_id, snapshot_date, cash, holdings_value, total_value, partial, bonus_cash)
+	SELECT u.id, $1, u.balance, v.holdings, u.balance + v.holdings, v.partial,
+	       COALESCE((SELECT SUM(b.amount) FROM bonus_grants b WHERE b.user_id = u.id), 0)
+	FROM users u
+	CROSS JOIN LATERAL (
+		SELECT ROUND(COALESCE(SUM(p.quantity * COALESCE(px.price, p.avg_price) + p.margin), 0), 2) AS holdings,
+		       COALESCE(BOOL_OR(px.price IS NULL), FALSE) AS partial
+		FROM portfolio p
+		LEFT JOIN UNNEST($2::text[], $3::numeric[]) AS px(symbol, price) ON px.symbol = p.symbol
+		WHERE p.user_id = u.id AND p.quantity <> 0
+	) v
+	WHERE u.created_at < $4
+	ON CONFLICT (user_id, snapshot_date) DO NOTHING`
+	if sqliteStorage.Load() {
+		// SQLite has no LATERAL or UNNEST: the same valuation, per user.
+		query = `
+	WITH px AS (
+		SELECT s.value AS symbol, CAST(v.value AS REAL) AS price
+		FROM json_each(pg_array_json($2)) s
+		JOIN json_each(pg_array_json($3)) v ON v.key = s.key
+	), v AS (
+		SELECT p.user_id, ROUND(SUM(p.quantity * COALESCE(px.price, p.avg_price) + p.margin), 2) AS holdings,
+		       MAX(px.price IS NULL) AS partial
+		FROM portfolio p
+		LEFT JOIN px ON px.symbol = p.symbol
+		WHERE p.quantity <> 0
+		GROUP BY p.user_id
+	)
+	INSERT INTO portfolio_history (user_id, snapshot_date, cash, holdings_value, total_value, partial, bonus_cash)
+	SELECT u.id, $1, u.balance, COALESCE(v.holdings, 0), u.balance + COALESCE(v.holdings, 0), COALESCE(v.partial, FALSE),
+	       COALESCE((SELECT SUM(b.amount) FROM bonus_grants b WHERE b.user_id = u.id), 0)
+	FROM users u
+	LEFT JOIN v ON v.user_id = u.id
+	WHERE u.created_at < $4
+	ON CONFLICT (user_id, snapshot_date) DO NOTHING`
+	}
+	result, err := s.db.ExecContext(ctx, query, sessionClose.Format(time.DateOnly), pq.Array(symbols), pq.Array(values), sessionClose)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Revalue corrects the snapshots for sessionClose's date after the closes
+// for symbols changed from before to after (parallel to symbols). Each snapshot
+// moves by the user's position in each symbol at sessionClose (the current
+// position less completed trades since) times the change in its close; cash
+// is untouched. Returns the number of snapshots changed.
+func (s *PortfolioHistoryStore) Revalue(ctx context.Context, sessionClose time.Time, symbols []string, before, after []decimal.Decimal) (int64, error) {
+	oldValues := make([]string, len(before))
+	for i, p := range before {
+		oldValues[i] = p.String()
+	}
+	newValues := make([]string, len(after))
+	for i, p := range after {
+		newValues[i] = p.String()
+	}
+
+	px := `SELECT * FROM UNNEST($2::text[], $3::numeric[], $4::numeric[]) AS px(symbol, old_price, new_price)`
+	if sqliteStorage.Load() {
+		// SQLite has no UNNEST: the arrays are paired up by position.
+		px = `
+		SELECT s.value AS symbol, CAST(o.value AS REAL) AS old_price, CAST(n.value AS REAL) AS new_price
+		FROM json_each(pg_array_json($2)) s
+		JOIN json_each(pg_array_json($3)) o ON o.key = s.key
+		JOIN json_each(pg_array_json($4)) n ON n.key = s.key`
+	}
+	query := `
+	WITH px AS (
+		` + px + `
+	), later AS (
+		SELECT user_id, symbol,
+		       SUM(CASE WHEN action IN ('BUY', 'COVER') THEN quantity ELSE -quantity END) AS quantity
+		FROM trades
+		WHERE status = 'COMPLETED' AND executed_at > $5 AND symbol = ANY($2)
+		GROUP BY user_id, symbol
+	), held AS (
+		SELECT COALESCE(p.user_id, l.user_id) AS user_id, COALESCE(p.symbol, l.symbol) AS symbol,
+		       COALESCE(p.quantity, 0) - COALESCE(l.quantity, 0) AS quantity
+		FROM (SELECT user_id, symbol, quantity FROM portfolio WHERE symbol = ANY($2)) p
+		FULL JOIN later l ON l.user_id = p.user_id AND l.symbol = p.symbol
+	), delta AS (
+		SELECT h.user_id, ROUND(SUM(h.quantity * (px.new_price - px.old_price)), 2) AS amount
+		FROM held h
+		JOIN px ON px.symbol = h.symbol
+		WHERE h.quantity <> 0
+		GROUP BY h.user_id
+	)
+	UPDATE portfolio_history ph
+	SET holdings_value = ph.holdings_value + d.amount, total_value = ph.total_value + d.amount
+	FROM delta d
+	WHERE ph.user_id = d.user_id AND ph.snapshot_date = $1 AND d.amount <> 0`
+	result, err := s.db.ExecContext(ctx, query, sessionClose.Format(time.DateOnly),
+		pq.Array(symbols), pq.Array(oldValues), pq.Array(newValues), sessionClose)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Latest returns userID's most recent snapshot, or nil if there is none.
+func (s *PortfolioHistoryStore) Latest(ctx context.Context, userID string) (*PortfolioSnapshot, error) {
+	return s.one(ctx, `
+	SELECT snapshot_date, cash, holdings_value, total_value, partial, bonus_cash
+	FROM portfolio_history
+	WHERE user_id = $1
+	ORDER BY snapshot_date DESC
+	LIMIT 1`, userID)
+}
+
+// Peak returns userID's highest-valued snapshot net of the bonus cash
+// granted by then (the earliest, on a tie), or nil if there is none.
+func (s *PortfolioHistoryStore) Peak(ctx context.Context, userID string) (*PortfolioSnapshot, error) {
+	return s.one(ctx, `
+	SELECT snapshot_date, cash, holdings_value, total_value, partial, bonus_cash
+	FROM portfolio_history
+	WHERE user_id = $1
+	ORDER BY total_value - bonus_cash DESC, snapshot_date
+	LIMIT 1`, userID)
+}
+
+// LastBefore returns userID's latest snapshot dated before the day of
+// before (in its location), or nil if there is none.
+func (s *PortfolioHistoryStore) LastBefore(ctx context.Context, userID string, before time.Time) (*PortfolioSnapshot, error) {
+	return s.one(ctx, `
+	SELECT snapshot_date, cash, holdings_value, total_value, partial, bonus_cash
+	FROM portfolio_history
+	WHERE user_id = $1 AND snapshot_date < $2
+	ORDER BY snapshot_date DESC
+	LIMIT 1`, userID, before.Format(time.DateOnly))
+}
+
+// Range returns userID's snapshots dated from onwards, oldest first.
+func (s *PortfolioHistoryStore) Range(ctx context.Context, userID string, from time.Time) ([]PortfolioSnapshot, error) {
+	query := `
+	SELECT snapshot_date, cash, holdings_value, total_value, partial, bonus_cash
+	FROM portfolio_history
+	WHERE user_id = $1 AND snapshot_date >= $2
+	ORDER BY snapshot_date`
+	rows, err := s.db.QueryContext(ctx, query, userID, from.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]PortfolioSnapshot, 0)
+	for rows.Next() {
+		var p PortfolioSnapshot
+		var date time.Time
+		if err := rows.Scan(&date, &p.Cash, &p.HoldingsValue, &p.TotalValue, &p.Partial, &p.BonusCash); err != nil {
+			return nil, err
+		}
+		p.Date = date.Format(time.DateOnly)
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *PortfolioHistoryStore) one(ctx context.Context, query string, args ...any) (*PortfolioSnapshot, error) {
+	var p PortfolioSnapshot
+	var date time.Time
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&date, &p.Cash, &p.HoldingsValue, &p.TotalValue, &p.Partial, &p.BonusCash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.Date = date.Format(time.DateOnly)
+	return &p, nil
+}
@@ -10,20 +10,60 @@ import (
 	"github.com/shopspring/decimal"
 )
 
-// UserStock represents a user's stock holding (moved from collections package)
+// UserStock represents a user's stock holding (moved from collections package).
+// A negative Quantity is a short position: AvgPrice is then the average price
+// the borrowed shares were sold at, Total is negative, and Margin is the cash
+// held against the position.
 type UserStock struct {
 	ID                string          `json:"id"`
 	UserID            string          `json:"user_id"`
 	Symbol            string          `json:"symbol"`
-	Quantity          int             `json:"quantity"`
+	AssetClass        string          `json:"asset_class"` // AssetClassEquity or AssetClassCrypto
+	Quantity          decimal.Decimal `json:"quantity"`
 	AvgPrice          decimal.Decimal `json:"avg_price"`
 	Total             decimal.Decimal `json:"total"`
+	Margin            decimal.Decimal `json:"margin"`
 	CurrentStockPrice decimal.Decimal `json:"current_stock_price"`
-	CreatedAt         time.Time       `json:"created_at"`
-	UpdatedAt         time.Time       `json:"updated_at"`
+	// UnrealizedPnL is (CurrentStockPrice - AvgPrice) * Quantity, which is
+	// right for both longs and shorts. Nil when there is no current price.
+	UnrealizedPnL *decimal.Decimal `json:"unrealized_pnl,omitempty"`
+	// Currency is set when the holding is returned by the portfolio
+	// endpoint, whose amounts may be converted out of USD.
+	Currency string `json:"currency,omitempty"`
+	// QuantityOnHold is set by the portfolio endpoint: the shares pending
+	// sell orders would sell.
+	QuantityOnHold decimal.Decimal `json:"quantity_on_hold"`
+	// Stale is set on a trade response whose fill price was the last known
+	// price, served while the market data provider was down.
+	Stale     bool      `json:"stale,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
-var ErrStockHoldingNotFound = errors.New("stock holding not found")
+// IsShort reports whether the holding is a short position.
+func (h *UserStock) IsShort() bool {
+	return h.Quantity.IsNegative()
+}
+
+// MarkToMarket sets CurrentStockPrice and, when price is known (positive),
+// UnrealizedPnL.
+func (h *UserStock) MarkToMarket(price decimal.Decimal) {
+	h.CurrentStockPrice = price
+	h.UnrealizedPnL = nil
+	if price.IsPositive() {
+		pnl := price.Sub(h.AvgPrice).Mul(h.Quantity)
+		h.UnrealizedPnL = &pnl
+	}
+}
+
+var (
+	ErrStockHoldingNotFound = errors.New("stock holding not found")
+	// ErrShortPositionOpen is returned by UpdatePortfolioWithBuy when the user
+	// is short the symbol; a short is closed with a cover, not a buy.
+	ErrShortPositionOpen = errors.New("short position open")
+)
+
+const portfolioColumns = `id, user_id, symbol, quantity, avg_price, margin, created_at, updated_at`
 
 type PortfolioStore struct {
 	db DBTX
@@ -39,14 +79,16 @@ func NewPortfolioStore(db DBTX) *PortfolioStore {
 // each other's weighted average. The user-balance lock in InvestmentService
 // already serialises buys for the same user, but this lock keeps the store
 // safe in isolation.
-func (ps *PortfolioStore) UpdatePortfolioWithBuy(ctx context.Context, userID, symbol string, quantity int, price decimal.Decimal) error {
+func (ps *PortfolioStore) UpdatePortfolioWithBuy(ctx context.Context, userID, symbol string, quantity, price decimal.Decimal) error {
 	existing, err := ps.GetPortfolioBySymbolForUpdate(ctx, userID, symbol)
 	if err != nil && err != ErrStockHoldingNotFound {
 		return err
 	}
+	if existing != nil && existing.IsShort() {
+		return ErrShortPositionOpen
+	}
 
-	var newQuantity int
-	var newAvgPrice decimal.Decimal
+	var newQuantity, newAvgPrice decimal.Decimal
 	var portfolioID string
 
 	if existing == nil {
@@ -57,11 +99,10 @@ func (ps *PortfolioStore) UpdatePortfolioWithBuy(ctx context.Context, userID, sy
 	} else {
 		// Existing holding - calculate weighted average using exact decimal arithmetic.
 		portfolioID = existing.ID
-		originalQuantity := existing.Quantity
-		newQuantity = existing.Quantity + quantity
-		existingTotal := existing.AvgPrice.Mul(decimal.NewFromInt(int64(originalQuantity)))
-		addedTotal := price.Mul(decimal.NewFromInt(int64(quantity)))
-		newAvgPrice = existingTotal.Add(addedTotal).Div(decimal.NewFromInt(int64(newQuantity)))
+		newQuantity = existing.Quantity.Add(quantity)
+		existingTotal := existing.AvgPrice.Mul(existing.Quantity)
+		addedTotal := price.Mul(quantity)
+		newAvgPrice = existingTotal.Add(addedTotal).Div(newQuantity)
 	}
 
 	// Use PostgreSQL INSERT ... ON CONFLICT for atomic upsert
@@ -86,13 +127,13 @@ func (ps *PortfolioStore) UpdatePortfolioWithBuy(ctx context.Context, userID, sy
 // quantity <= currentQuantity before calling this. Re-reading the row here
 // would be either redundant (the caller's lock makes the value unchanged) or
 // — if the lock is ever dropped — racy. Pass the locked currentQuantity in.
-func (ps *PortfolioStore) UpdatePortfolioWithSell(ctx context.Context, userID, symbol string, currentQuantity, quantity int) error {
-	if quantity > currentQuantity {
+func (ps *PortfolioStore) UpdatePortfolioWithSell(ctx context.Context, userID, symbol string, currentQuantity, quantity decimal.Decimal) error {
+	if quantity.GreaterThan(currentQuantity) {
 		return errors.New("insufficient stock quantity to sell")
 	}
 
-	newQuantity := currentQuantity - quantity
-	if newQuantity == 0 {
+	newQuantity := currentQuantity.Sub(quantity)
+	if newQuantity.IsZero() {
 		return ps.DeletePortfolio(ctx, userID, symbol)
 	}
 
@@ -101,10 +142,68 @@ func (ps *PortfolioStore) UpdatePortfolioWithSell(ctx context.Context, userID, s
 	return err
 }
 
-// GetPortfolioByUserID gets all holdings for a user
+// SetAvgPrice overwrites a holding's average cost, as when a reversal takes
+// out the trade that set it. The caller holds the row's lock.
+func (ps *PortfolioStore) SetAvgPrice(ctx context.Context, userID, symbol string, avgPrice decimal.Decimal) error {
+	query := `UPDATE portfolio SET avg_price = $1, updated_at = CURRENT_TIMESTAMP WHERE user_id = $2 AND symbol = $3`
+	_, err := ps.db.ExecContext(ctx, query, avgPrice, userID, symbol)
+	return err
+}
+
+// UpdatePortfolioWithShort opens or adds to a short position: the position
+// grows by quantity borrowed shares sold at price, its average sale price is
+// re-weighted, and margin is added to the cash held against it.
+//
+// As with UpdatePortfolioWithSell, the caller locks the row first (see
+// GetPortfolioBySymbolForUpdate) and passes it in as existing, or nil when
+// the user has no position in symbol. existing must not be a long holding.
+func (ps *PortfolioStore) UpdatePortfolioWithShort(ctx context.Context, userID, symbol string, existing *UserStock, quantity, price, margin decimal.Decimal) error {
+	if existing == nil {
+		query := `
+		INSERT INTO portfolio (id, user_id, symbol, quantity, avg_price, margin, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)`
+		_, err := ps.db.ExecContext(ctx, query, uuid.New().String(), userID, symbol, quantity.Neg(), price, margin)
+		return err
+	}
+	if existing.Quantity.IsPositive() {
+		return errors.New("cannot short a symbol held long")
+	}
+
+	shorted := existing.Quantity.Neg()
+	newShorted := shorted.Add(quantity)
+	existingTotal := existing.AvgPrice.Mul(shorted)
+	addedTotal := price.Mul(quantity)
+	newAvgPrice := existingTotal.Add(addedTotal).Div(newShorted)
+
+	query := `UPDATE portfolio SET quantity = $1, avg_price = $2, margin = $3, updated_at = CURRENT_TIMESTAMP
+	          WHERE user_id = $4 AND symbol = $5`
+	_, err := ps.db.ExecContext(ctx, query, newShorted.Neg(), newAvgPrice, existing.Margin.Add(margin), userID, symbol)
+	return err
+}
+
+// UpdatePortfolioWithCover buys back quantity shares of a short position,
+// leaving margin held against what remains. The row is deleted once the
+// position is fully covered. The caller locks the row and passes its
+// (negative) currentQuantity, as for UpdatePortfolioWithSell.
+func (ps *PortfolioStore) UpdatePortfolioWithCover(ctx context.Context, userID, symbol string, currentQuantity, quantity, margin decimal.Decimal) error {
+	if quantity.GreaterThan(currentQuantity.Neg()) {
+		return errors.New("cover exceeds short position")
+	}
+
+	newQuantity := currentQuantity.Add(quantity)
+	if newQuantity.IsZero() {
+		return ps.DeletePortfolio(ctx, userID, symbol)
+	}
+
+	query := `UPDATE portfolio SET quantity = $1, margin = $2, updated_at = CURRENT_TIMESTAMP WHERE user_id = $3 AND symbol = $4`
+	_, err := ps.db.ExecContext(ctx, query, newQuantity, margin, userID, symbol)
+	return err
+}
+
+// GetPortfolioByUserID gets all holdings for a user, long and short.
 func (ps *PortfolioStore) GetPortfolioByUserID(ctx context.Context, userID string) ([]UserStock, error) {
-	query := `SELECT id, user_id, symbol, quantity, avg_price, created_at, updated_at
-	          FROM portfolio WHERE user_id = $1 AND quantity > 0 ORDER BY symbol`
+	query := `SELECT ` + portfolioColumns + `
+	          FROM portfolio WHERE user_id = $1 AND quantity <> 0 ORDER BY symbol`
 
 	rows, err := ps.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -121,6 +220,7 @@ func (ps *PortfolioStore) GetPortfolioByUserID(ctx context.Context, userID strin
 			&holding.Symbol,
 			&holding.Quantity,
 			&holding.AvgPrice,
+			&holding.Margin,
 			&holding.CreatedAt,
 			&holding.UpdatedAt,
 		)
@@ -128,7 +228,8 @@ func (ps *PortfolioStore) GetPortfolioByUserID(ctx context.Context, userID strin
 			return nil, err
 		}
 		// Calculate derived fields
-		holding.Total = holding.AvgPrice.Mul(decimal.NewFromInt(int64(holding.Quantity)))
+		holding.Total = holding.AvgPrice.Mul(holding.Quantity)
+		holding.AssetClass = AssetClassOf(holding.Symbol)
 		holdings = append(holdings, holding)
 	}
 
@@ -141,16 +242,16 @@ func (ps *PortfolioStore) GetPortfolioByUserID(ctx context.Context, userID strin
 
 // GetPortfolioBySymbol gets a specific holding
 func (ps *PortfolioStore) GetPortfolioBySymbol(ctx context.Context, userID, symbol string) (*UserStock, error) {
-	return ps.scanHolding(ctx, `SELECT id, user_id, symbol, quantity, avg_price, created_at, updated_at
+	return ps.scanHolding(ctx, `SELECT `+portfolioColumns+`
 	          FROM portfolio WHERE user_id = $1 AND symbol = $2`, userID, symbol)
 }
 
 // GetPortfolioBySymbolForUpdate is GetPortfolioBySymbol with FOR UPDATE so the
 // caller's transaction holds the row lock until commit. Required inside
 // SellStock — without it, two concurrent sells of the same holding can both
-// pass the quantity check and oversell.
+// pass the quantity check and oversell — and likewise for shorts and covers.
 func (ps *PortfolioStore) GetPortfolioBySymbolForUpdate(ctx context.Context, userID, symbol string) (*UserStock, error) {
-	return ps.scanHolding(ctx, `SELECT id, user_id, symbol, quantity, avg_price, created_at, updated_at
+	return ps.scanHolding(ctx, `SELECT `+portfolioColumns+`
 	          FROM portfolio WHERE user_id = $1 AND symbol = $2 FOR UPDATE`, userID, symbol)
 }
 
@@ -162,6 +263,7 @@ func (ps *PortfolioStore) scanHolding(ctx context.Context, query, userID, symbol
 		&holding.Symbol,
 		&holding.Quantity,
 		&holding.AvgPrice,
+		&holding.Margin,
 		&holding.CreatedAt,
 		&holding.UpdatedAt,
 	)
@@ -171,7 +273,8 @@ func (ps *PortfolioStore) scanHolding(ctx context.Context, query, userID, symbol
 		}
 		return nil, err
 	}
-	holding.Total = holding.AvgPrice.Mul(decimal.NewFromInt(int64(holding.Quantity)))
+	holding.Total = holding.AvgPrice.Mul(holding.Quantity)
+	holding.AssetClass = AssetClassOf(holding.Symbol)
 	return &holding, nil
 }
 
@@ -201,3 +304,62 @@ func (ps *PortfolioStore) DeleteAllPortfolio(ctx context.Context, userID string)
 	_, err := ps.db.ExecContext(ctx, query, userID)
 	return err
 }
+
+// GetHolderIDsBySymbol returns the IDs of every user with a non-zero position
+// in symbol, long or short. Used to fan out symbol-level notifications (e.g.
+// trading halts).
+func (ps *PortfolioStore) GetHolderIDsBySymbol(ctx context.Context, symbol string) ([]string, error) {
+	query := `SELECT user_id FROM portfolio WHERE symbol = $1 AND quantity <> 0 ORDER BY user_id`
+
+	rows, err := ps.db.QueryContext(ctx, query, symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CountPositions returns how many symbols userID holds a non-zero position
+// in, long or short.
+func (ps *PortfolioStore) CountPositions(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := ps.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM portfolio WHERE user_id = $1 AND quantity <> 0`, userID,
+	).Scan(&n)
+	return n, err
+}
+
+// HeldSymbols returns every symbol anyone holds a non-zero position in, long
+// or short.
+func (ps *PortfolioStore) HeldSymbols(ctx context.Context) ([]string, error) {
+	rows, err := ps.db.QueryContext(ctx, `SELECT DISTINCT symbol FROM portfolio WHERE quantity <> 0 ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	symbols := make([]string, 0)
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
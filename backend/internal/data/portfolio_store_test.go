@@ -11,12 +11,12 @@ import (
 
 // portfolioQueryCols matches the SELECT column list in GetPortfolioByUserID / GetPortfolioBySymbol.
 var portfolioQueryCols = []string{
-	"id", "user_id", "symbol", "quantity", "avg_price", "created_at", "updated_at",
+	"id", "user_id", "symbol", "quantity", "avg_price", "margin", "created_at", "updated_at",
 }
 
 func portfolioRow(id, userID, symbol string, quantity int, avgPrice decimal.Decimal) *sqlmock.Rows {
 	return sqlmock.NewRows(portfolioQueryCols).AddRow(
-		id, userID, symbol, quantity, avgPrice, time.Now(), time.Now(),
+		id, userID, symbol, quantity, avgPrice, decimal.Zero, time.Now(), time.Now(),
 	)
 }
 
@@ -30,8 +30,8 @@ func TestGetPortfolioByUserID_ReturnsHoldings(t *testing.T) {
 	defer db.Close()
 
 	rows := sqlmock.NewRows(portfolioQueryCols).
-		AddRow("p1", "user-1", "AAPL", 10, decimal.NewFromFloat(150.0), time.Now(), time.Now()).
-		AddRow("p2", "user-1", "TSLA", 5, decimal.NewFromFloat(250.0), time.Now(), time.Now())
+		AddRow("p1", "user-1", "AAPL", 10, decimal.NewFromFloat(150.0), decimal.Zero, time.Now(), time.Now()).
+		AddRow("p2", "user-1", "TSLA", 5, decimal.NewFromFloat(250.0), decimal.Zero, time.Now(), time.Now())
 
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1").
@@ -104,8 +104,8 @@ func TestGetPortfolioBySymbol_Found(t *testing.T) {
 	if holding.Symbol != "AAPL" {
 		t.Errorf("Symbol: got %q, want %q", holding.Symbol, "AAPL")
 	}
-	if holding.Quantity != 10 {
-		t.Errorf("Quantity: got %d, want %d", holding.Quantity, 10)
+	if !holding.Quantity.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("Quantity: got %s, want %d", holding.Quantity, 10)
 	}
 	wantHoldingTotal := decimal.NewFromFloat(150.0 * 10)
 	if !holding.Total.Equal(wantHoldingTotal) {
@@ -156,11 +156,11 @@ func TestUpdatePortfolioWithBuy_NewHolding(t *testing.T) {
 
 	// 2. INSERT ... ON CONFLICT upsert
 	mock.ExpectExec("INSERT INTO portfolio").
-		WithArgs(sqlmock.AnyArg(), "user-1", "NVDA", 3, decimal.NewFromFloat(500.0)).
+		WithArgs(sqlmock.AnyArg(), "user-1", "NVDA", decimal.NewFromInt(3), decimal.NewFromFloat(500.0)).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	store := NewPortfolioStore(db)
-	if err := store.UpdatePortfolioWithBuy(context.Background(), "user-1", "NVDA", 3, decimal.NewFromFloat(500.0)); err != nil {
+	if err := store.UpdatePortfolioWithBuy(context.Background(), "user-1", "NVDA", decimal.NewFromInt(3), decimal.NewFromFloat(500.0)); err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -182,14 +182,43 @@ func TestUpdatePortfolioWithBuy_ExistingHolding(t *testing.T) {
 
 	// 2. Upsert: new qty=5+3=8, new avg=(100*5 + 200*3)/8 = 1100/8 = 137.5
 	mock.ExpectExec("INSERT INTO portfolio").
-		WithArgs("p1", "user-1", "AAPL", 8, decimal.NewFromFloat(137.5)).
+		WithArgs("p1", "user-1", "AAPL", decimal.NewFromInt(8), decimal.NewFromFloat(137.5)).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	store := NewPortfolioStore(db)
-	if err := store.UpdatePortfolioWithBuy(context.Background(), "user-1", "AAPL", 3, decimal.NewFromFloat(200.0)); err != nil {
+	if err := store.UpdatePortfolioWithBuy(context.Background(), "user-1", "AAPL", decimal.NewFromInt(3), decimal.NewFromFloat(200.0)); err != nil {
 		t.Errorf("unexpected error: %v", err)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
+
+// ---- MarkToMarket ----
+
+func TestMarkToMarket_LongAndShort(t *testing.T) {
+	cases := []struct {
+		name     string
+		quantity int64
+		price    string
+		want     string
+	}{
+		{"long gains when price rises", 10, "110", "100"},
+		{"short loses when price rises", -10, "110", "-100"},
+		{"short gains when price falls", -10, "90", "100"},
+	}
+	for _, tc := range cases {
+		h := UserStock{Quantity: decimal.NewFromInt(tc.quantity), AvgPrice: decimal.NewFromInt(100)}
+		h.MarkToMarket(decimal.RequireFromString(tc.price))
+		if h.UnrealizedPnL == nil || !h.UnrealizedPnL.Equal(decimal.RequireFromString(tc.want)) {
+			t.Errorf("%s: UnrealizedPnL = %v, want %s", tc.name, h.UnrealizedPnL, tc.want)
+		}
+	}
+
+	// No quote: no P&L rather than a loss of the whole cost basis.
+	h := UserStock{Quantity: decimal.NewFromInt(10), AvgPrice: decimal.NewFromInt(100)}
+	h.MarkToMarket(decimal.Zero)
+	if h.UnrealizedPnL != nil {
+		t.Errorf("zero price: UnrealizedPnL = %v, want nil", h.UnrealizedPnL)
+	}
+}
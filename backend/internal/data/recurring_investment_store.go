@@ -0,0 +1,204 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// RecurringInvestment is a standing instruction to buy Amount dollars of
+// Symbol on a schedule. Day is the weekday (1 = Monday .. 5 = Friday) for
+// WEEKLY plans and the day of the month (1-28) for MONTHLY; it is nil for
+// DAILY. Dates are New York calendar dates. LastTradeID and LastError
+// describe the most recent run: a trade, or why there was none.
+type RecurringInvestment struct {
+	ID          string          `json:"id"`
+	UserID      string          `json:"user_id"`
+	Symbol      string          `json:"symbol"`
+	Amount      decimal.Decimal `json:"amount"`
+	Frequency   string          `json:"frequency"`
+	Day         *int            `json:"day,omitempty"`
+	Active      bool            `json:"active"`
+	NextRunOn   string          `json:"next_run_on"`           // YYYY-MM-DD
+	LastRunOn   string          `json:"last_run_on,omitempty"` // YYYY-MM-DD
+	LastTradeID string          `json:"last_trade_id,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Recurring investment frequencies.
+const (
+	RecurringDaily   = "DAILY"
+	RecurringWeekly  = "WEEKLY"
+	RecurringMonthly = "MONTHLY"
+)
+
+var ErrRecurringInvestmentNotFound = errors.New("recurring investment not found")
+
+const recurringInvestmentColumns = `id, user_id, symbol, amount, frequency, day, active,
+	next_run_on, last_run_on, last_trade_id, last_error, created_at`
+
+type RecurringInvestmentStore struct {
+	db DBTX
+}
+
+func NewRecurringInvestmentStore(db DBTX) *RecurringInvestmentStore {
+	return &RecurringInvestmentStore{db: db}
+}
+
+func scanRecurringInvestment(row rowScanner) (*RecurringInvestment, error) {
+	var r RecurringInvestment
+	var day sql.NullInt64
+	var next time.Time
+	var last sql.NullTime
+	var tradeID, lastErr sql.NullString
+	if err := row.Scan(&r.ID, &r.UserID, &r.Symbol, &r.Amount, &r.Frequency, &day, &r.Active,
+		&next, &last, &tradeID, &lastErr, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	if day.Valid {
+		d := int(day.Int64)
+		r.Day = &d
+	}
+	r.NextRunOn = next.Format(time.DateOnly)
+	if last.Valid {
+		r.LastRunOn = last.Time.Format(time.DateOnly)
+	}
+	r.LastTradeID = tradeID.String
+	r.LastError = lastErr.String
+	return &r, nil
+}
+
+func (s *RecurringInvestmentStore) query(ctx context.Context, query string, args ...any) ([]RecurringInvestment, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]RecurringInvestment, 0)
+	for rows.Next() {
+		r, err := scanRecurringInvestment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Create inserts plan as a new active plan and returns the stored row. ID
+// is assigned here; Active and the last-run fields are ignored.
+func (s *RecurringInvestmentStore) Create(ctx context.Context, plan *RecurringInvestment) (*RecurringInvestment, error) {
+	query := `
+	INSERT INTO recurring_investments (id, user_id, symbol, amount, frequency, day, next_run_on)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING ` + recurringInvestmentColumns
+
+	var day sql.NullInt64
+	if plan.Day != nil {
+		day = sql.NullInt64{Int64: int64(*plan.Day), Valid: true}
+	}
+	return scanRecurringInvestment(s.db.QueryRowContext(ctx, query,
+		uuid.New().String(), plan.UserID, plan.Symbol, plan.Amount, plan.Frequency, day, plan.NextRunOn))
+}
+
+// ListByUser returns userID's plans, oldest first.
+func (s *RecurringInvestmentStore) ListByUser(ctx context.Context, userID string) ([]RecurringInvestment, error) {
+	return s.query(ctx, `SELECT `+recurringInvestmentColumns+`
+	FROM recurring_investments WHERE user_id = $1 ORDER BY created_at, id`, userID)
+}
+
+// Get returns one of userID's plans, or ErrRecurringInvestmentNotFound if
+// it doesn't exist or belongs to someone else.
+func (s *RecurringInvestmentStore) Get(ctx context.Context, userID, id string) (*RecurringInvestment, error) {
+	plan, err := scanRecurringInvestment(s.db.QueryRowContext(ctx, `SELECT `+recurringInvestmentColumns+`
+	FROM recurring_investments WHERE id = $1 AND user_id = $2`, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecurringInvestmentNotFound
+	}
+	return plan, err
+}
+
+// CountByUser returns how many plans userID has, active or paused.
+func (s *RecurringInvestmentStore) CountByUser(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM recurring_investments WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+// SetActive pauses or resumes one of userID's plans. nextRunOn replaces the
+// due date when resuming, so runs missed while paused are skipped rather
+// than made up; it is ignored when pausing. Returns
+// ErrRecurringInvestmentNotFound if the plan doesn't exist or belongs to
+// someone else.
+func (s *RecurringInvestmentStore) SetActive(ctx context.Context, userID, id string, active bool, nextRunOn string) (*RecurringInvestment, error) {
+	query := `
+	UPDATE recurring_investments
+	SET active = $3,
+	    next_run_on = CASE WHEN $3 THEN $4::date ELSE next_run_on END
+	WHERE id = $1 AND user_id = $2
+	RETURNING ` + recurringInvestmentColumns
+
+	plan, err := scanRecurringInvestment(s.db.QueryRowContext(ctx, query, id, userID, active, nextRunOn))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRecurringInvestmentNotFound
+	}
+	return plan, err
+}
+
+// Delete removes one of userID's plans. Returns
+// ErrRecurringInvestmentNotFound if no row was deleted.
+func (s *RecurringInvestmentStore) Delete(ctx context.Context, userID, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM recurring_investments WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRecurringInvestmentNotFound
+	}
+	return nil
+}
+
+// ListDue returns the active plans due on or before date (a YYYY-MM-DD New
+// York date), oldest due first.
+func (s *RecurringInvestmentStore) ListDue(ctx context.Context, date string) ([]RecurringInvestment, error) {
+	return s.query(ctx, `SELECT `+recurringInvestmentColumns+`
+	FROM recurring_investments
+	WHERE active AND next_run_on <= $1
+	ORDER BY next_run_on, id`, date)
+}
+
+// Advance records a run on ranOn and moves the plan's due date from
+// dueOn to nextRunOn. tradeID and lastError describe the run; either may be
+// empty. The update only applies while the plan is still due on dueOn, so
+// it reports false when another instance advanced the plan first or the
+// user paused or deleted it.
+func (s *RecurringInvestmentStore) Advance(ctx context.Context, id, dueOn, ranOn, nextRunOn, tradeID, lastError string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+	UPDATE recurring_investments
+	SET next_run_on = $4, last_run_on = $3, last_trade_id = NULLIF($5, ''), last_error = NULLIF($6, '')
+	WHERE id = $1 AND next_run_on = $2 AND active`,
+		id, dueOn, ranOn, nextRunOn, tradeID, lastError)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,97 @@
+package data
+
+import (
+	"context"
+	"time"
+)
+
+// StatementRecipient is a user due a statement email.
+type StatementRecipient struct {
+	UserID string
+	Email  string
+}
+
+// StatementReport records a monthly statement emailed to a user.
+type StatementReport struct {
+	Month  string    `json:"month"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// StatementReportStore keeps the monthly statements emailed to users who
+// opted in (user_settings.statement_emails), one row per user and month.
+type StatementReportStore struct {
+	db DBTX
+}
+
+func NewStatementReportStore(db DBTX) *StatementReportStore {
+	return &StatementReportStore{db: db}
+}
+
+// Pending returns the opted-in users with an email address who have not been
+// sent month's (YYYY-MM) statement. Users who signed up at or after end, when
+// the month closed, have no statement for it and are left out.
+func (s *StatementReportStore) Pending(ctx context.Context, month string, end time.Time) ([]StatementRecipient, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT u.id, u.email
+	FROM users u
+	JOIN user_settings s ON s.user_id = u.id
+	WHERE s.statement_emails AND u.email IS NOT NULL AND u.created_at < $2
+	  AND NOT EXISTS (SELECT 1 FROM statement_reports r WHERE r.user_id = u.id AND r.month = $1)
+	ORDER BY u.id`, month, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []StatementRecipient
+	for rows.Next() {
+		var r StatementRecipient
+		if err := rows.Scan(&r.UserID, &r.Email); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Claim records month's statement as sent to userID, reporting false if it
+// already was. Claiming before sending keeps two instances from both sending.
+func (s *StatementReportStore) Claim(ctx context.Context, userID, month string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+	INSERT INTO statement_reports (user_id, month) VALUES ($1, $2)
+	ON CONFLICT (user_id, month) DO NOTHING`, userID, month)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Release removes a claim whose email could not be sent, so it is retried.
+func (s *StatementReportStore) Release(ctx context.Context, userID, month string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM statement_reports WHERE user_id = $1 AND month = $2`, userID, month)
+	return err
+}
+
+// List returns the statements emailed to userID, newest month first.
+func (s *StatementReportStore) List(ctx context.Context, userID string) ([]StatementReport, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT month, sent_at FROM statement_reports
+	WHERE user_id = $1
+	ORDER BY month DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []StatementReport{}
+	for rows.Next() {
+		var r StatementReport
+		if err := rows.Scan(&r.Month, &r.SentAt); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,45 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// StatementStore keeps monthly account statements once their month has
+// closed, so they are computed once rather than on every request. The
+// statement is stored as the JSON the API returns.
+type StatementStore struct {
+	db DBTX
+}
+
+func NewStatementStore(db DBTX) *StatementStore {
+	return &StatementStore{db: db}
+}
+
+// Get returns userID's stored statement for month (YYYY-MM), or nil if none
+// has been stored.
+func (s *StatementStore) Get(ctx context.Context, userID, month string) (json.RawMessage, error) {
+	var body []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT statement FROM account_statements WHERE user_id = $1 AND month = $2`, userID, month).
+		Scan(&body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(body), nil
+}
+
+// Save stores userID's statement for month. A statement already stored for
+// the month is kept.
+func (s *StatementStore) Save(ctx context.Context, userID, month string, statement json.RawMessage) error {
+	_, err := s.db.ExecContext(ctx, `
+	INSERT INTO account_statements (user_id, month, statement)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, month) DO NOTHING`, userID, month, []byte(statement))
+	return err
+}
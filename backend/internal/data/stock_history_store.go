@@ -54,6 +54,27 @@ func (s *StockHistoryStore) GetRange(ctx context.Context, symbol string, from, t
 	return out, nil
 }
 
+// ClosesOn returns every stored close for date, keyed by symbol.
+func (s *StockHistoryStore) ClosesOn(ctx context.Context, date time.Time) (map[string]decimal.Decimal, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT symbol, close FROM stock_history WHERE trade_date = $1`, date.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]decimal.Decimal)
+	for rows.Next() {
+		var symbol string
+		var close decimal.Decimal
+		if err := rows.Scan(&symbol, &close); err != nil {
+			return nil, err
+		}
+		out[symbol] = close
+	}
+	return out, rows.Err()
+}
+
 // upsertBatchSize bounds how many rows are sent in a single multi-VALUES
 // statement. Postgres caps prepared-statement parameters at 65535, and we use
 // 4 params per row, so the absolute ceiling is 16383. We pick a far smaller
@@ -0,0 +1,218 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// SymbolAlias records that OldSymbol trades as NewSymbol from EffectiveDate.
+// MigratedAt is set once the rows keyed by OldSymbol have been re-keyed.
+type SymbolAlias struct {
+	OldSymbol     string     `json:"old_symbol"`
+	NewSymbol     string     `json:"new_symbol"`
+	EffectiveDate time.Time  `json:"effective_date"`
+	Source        string     `json:"source"` // SymbolAliasSourceDataset or SymbolAliasSourceAdmin
+	MigratedAt    *time.Time `json:"migrated_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Symbol alias sources. Reloading the dataset only touches dataset rows.
+const (
+	SymbolAliasSourceDataset = "dataset"
+	SymbolAliasSourceAdmin   = "admin"
+)
+
+// ErrSymbolRekeyConflict is returned by Rekey when a user holds the old
+// symbol one way and the new one the other (long and short), which can't be
+// merged into one position.
+var ErrSymbolRekeyConflict = errors.New("symbol rename conflicts with an opposite position")
+
+// SymbolRekey counts the rows Rekey moved to the new symbol.
+type SymbolRekey struct {
+	Holdings  int `json:"holdings"`
+	Lots      int `json:"lots"`
+	Watchlist int `json:"watchlist"`
+	Orders    int `json:"orders"`
+	Recurring int `json:"recurring"`
+}
+
+const symbolAliasColumns = `old_symbol, new_symbol, effective_date, source, migrated_at, created_at`
+
+func scanSymbolAlias(row rowScanner) (*SymbolAlias, error) {
+	var a SymbolAlias
+	var migratedAt sql.NullTime
+	if err := row.Scan(&a.OldSymbol, &a.NewSymbol, &a.EffectiveDate, &a.Source, &migratedAt, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	if migratedAt.Valid {
+		a.MigratedAt = &migratedAt.Time
+	}
+	return &a, nil
+}
+
+type SymbolAliasStore struct {
+	db DBTX
+}
+
+func NewSymbolAliasStore(db DBTX) *SymbolAliasStore {
+	return &SymbolAliasStore{db: db}
+}
+
+// List returns every alias, newest effective date first.
+func (s *SymbolAliasStore) List(ctx context.Context) ([]SymbolAlias, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+symbolAliasColumns+` FROM symbol_aliases ORDER BY effective_date DESC, old_symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]SymbolAlias, 0)
+	for rows.Next() {
+		a, err := scanSymbolAlias(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// Save creates or replaces the alias for a.OldSymbol as an admin entry and
+// returns the stored row. Changing the new symbol or date of an alias
+// clears migrated_at, so rows keyed by the old symbol since are moved too.
+func (s *SymbolAliasStore) Save(ctx context.Context, a *SymbolAlias) (*SymbolAlias, error) {
+	query := `
+	INSERT INTO symbol_aliases (old_symbol, new_symbol, effective_date, source)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (old_symbol) DO UPDATE
+	SET new_symbol = EXCLUDED.new_symbol,
+	    effective_date = EXCLUDED.effective_date,
+	    source = EXCLUDED.source,
+	    migrated_at = CASE
+	        WHEN symbol_aliases.new_symbol = EXCLUDED.new_symbol AND symbol_aliases.effective_date = EXCLUDED.effective_date
+	        THEN symbol_aliases.migrated_at
+	    END
+	RETURNING ` + symbolAliasColumns
+	return scanSymbolAlias(s.db.QueryRowContext(ctx, query, a.OldSymbol, a.NewSymbol, a.EffectiveDate, SymbolAliasSourceAdmin))
+}
+
+// SaveDataset inserts or refreshes each dataset alias, leaving admin entries
+// alone. Aliases are never deleted: a rename that has been applied stays
+// applied. Returns the number of rows written.
+func (s *SymbolAliasStore) SaveDataset(ctx context.Context, items []SymbolAlias) (int, error) {
+	query := `
+	INSERT INTO symbol_aliases (old_symbol, new_symbol, effective_date, source)
+	VALUES ($1, $2, $3, 'dataset')
+	ON CONFLICT (old_symbol) DO UPDATE
+	SET new_symbol = EXCLUDED.new_symbol,
+	    effective_date = EXCLUDED.effective_date
+	WHERE symbol_aliases.source = 'dataset'
+	  AND (symbol_aliases.new_symbol, symbol_aliases.effective_date) IS DISTINCT FROM (EXCLUDED.new_symbol, EXCLUDED.effective_date)`
+
+	written := 0
+	for _, a := range items {
+		result, err := s.db.ExecContext(ctx, query, a.OldSymbol, a.NewSymbol, a.EffectiveDate)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		written += int(n)
+	}
+	return written, nil
+}
+
+// Rekey moves the rows keyed by a.OldSymbol to a.NewSymbol in one
+// transaction and marks the alias migrated:
+//
+//   - holdings, merged into an existing holding of the new symbol at the
+//     combined average price;
+//   - tax lots that are still open;
+//   - watchlist entries, dropped where the new symbol is already watched;
+//   - pending orders and recurring investment plans.
+//
+// History (trades, lot disposals, price history) keeps the symbol it was
+// recorded under.
+func (s *SymbolAliasStore) Rekey(ctx context.Context, a SymbolAlias) (*SymbolRekey, error) {
+	beginner, ok := s.db.(txBeginner)
+	if !ok {
+		return rekeySymbol(ctx, s.db, a.OldSymbol, a.NewSymbol)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	result, err := rekeySymbol(ctx, tx, a.OldSymbol, a.NewSymbol)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+func rekeySymbol(ctx context.Context, db DBTX, oldSymbol, newSymbol string) (*SymbolRekey, error) {
+	var conflicts int
+	if err := db.QueryRowContext(ctx, `
+	SELECT COUNT(*) FROM portfolio o
+	JOIN portfolio n ON n.user_id = o.user_id AND n.symbol = $2
+	WHERE o.symbol = $1 AND SIGN(n.quantity) <> SIGN(o.quantity)`, oldSymbol, newSymbol).Scan(&conflicts); err != nil {
+		return nil, err
+	}
+	if conflicts > 0 {
+		return nil, ErrSymbolRekeyConflict
+	}
+
+	var result SymbolRekey
+	exec := func(count *int, query string) error {
+		res, err := db.ExecContext(ctx, query, oldSymbol, newSymbol)
+		if err != nil {
+			return err
+		}
+		if count != nil {
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			*count += int(n)
+		}
+		return nil
+	}
+
+	// Merge into holdings of the new symbol first; the merged old rows are
+	// then dropped and the rest renamed in place.
+	steps := []struct {
+		count *int
+		query string
+	}{
+		{&result.Holdings, `
+		UPDATE portfolio n
+		SET quantity = n.quantity + o.quantity,
+		    avg_price = (n.avg_price * ABS(n.quantity) + o.avg_price * ABS(o.quantity)) / ABS(n.quantity + o.quantity),
+		    margin = n.margin + o.margin,
+		    updated_at = CURRENT_TIMESTAMP
+		FROM portfolio o
+		WHERE o.symbol = $1 AND n.symbol = $2 AND n.user_id = o.user_id`},
+		{nil, `
+		DELETE FROM portfolio
+		WHERE symbol = $1 AND user_id IN (SELECT user_id FROM portfolio WHERE symbol = $2)`},
+		{&result.Holdings, `UPDATE portfolio SET symbol = $2, updated_at = CURRENT_TIMESTAMP WHERE symbol = $1`},
+		{&result.Lots, `UPDATE tax_lots SET symbol = $2 WHERE symbol = $1 AND remaining > 0`},
+		{nil, `
+		DELETE FROM watchlist
+		WHERE symbol = $1 AND user_id IN (SELECT user_id FROM watchlist WHERE symbol = $2)`},
+		{&result.Watchlist, `UPDATE watchlist SET symbol = $2 WHERE symbol = $1`},
+		{&result.Orders, `UPDATE orders SET symbol = $2 WHERE symbol = $1 AND status IN ('PENDING', 'WAITING')`},
+		{&result.Recurring, `UPDATE recurring_investments SET symbol = $2 WHERE symbol = $1`},
+		{nil, `UPDATE symbol_aliases SET migrated_at = CURRENT_TIMESTAMP WHERE old_symbol = $1 AND new_symbol = $2`},
+	}
+	for _, step := range steps {
+		if err := exec(step.count, step.query); err != nil {
+			return nil, err
+		}
+	}
+	return &result, nil
+}
@@ -17,19 +17,43 @@ type Trade struct {
 	UserID         string          `json:"user_id"`
 	Symbol         string          `json:"symbol"`
 	Action         string          `json:"action"`
-	Quantity       int             `json:"quantity"`
+	Quantity       decimal.Decimal `json:"quantity"` // whole shares, or a fraction of a crypto unit
 	Price          decimal.Decimal `json:"price"`
 	Total          decimal.Decimal `json:"total"`
 	ExecutedAt     time.Time       `json:"executed_at"`
 	Status         string          `json:"status"` // PENDING, COMPLETED, FAILED
 	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	OrderType      string          `json:"order_type"`  // MARKET, LIMIT, STOP, STOP_LOSS, TAKE_PROFIT, ADJUSTMENT
+	AssetClass     string          `json:"asset_class"` // AssetClassEquity or AssetClassCrypto
+	// Slippage is the per-share amount Price moved from the quote under the
+	// simulated spread: positive for buys and covers, negative for sells and
+	// shorts, zero when the model is off.
+	Slippage decimal.Decimal `json:"slippage"`
 }
 
+// Trade order types. MARKET trades are placed directly by the user; the
+// next four are fills of a pending order from the orders table. ADJUSTMENT
+// trades are posted by an admin to reverse a disputed trade.
+const (
+	OrderTypeMarket     = "MARKET"
+	OrderTypeLimit      = "LIMIT"
+	OrderTypeStop       = "STOP"
+	OrderTypeStopLoss   = "STOP_LOSS"
+	OrderTypeTakeProfit = "TAKE_PROFIT"
+	OrderTypeAdjustment = "ADJUSTMENT"
+)
+
+var ErrTradeNotFound = errors.New("trade not found")
+
 // TradeQueryOpts are filters/pagination for GetTradesByUserID and CountTradesByUserID.
-// Symbol and Action are optional ("" means no filter); Limit/Offset are pre-validated by the handler.
+// Symbol and Action are optional ("" means no filter); From and To bound
+// executed_at, From inclusive and To exclusive, with the zero time meaning
+// unbounded. Limit/Offset are pre-validated by the handler.
 type TradeQueryOpts struct {
 	Symbol string
 	Action string
+	From   time.Time
+	To     time.Time
 	Limit  int
 	Offset int
 }
@@ -46,34 +70,39 @@ func NewTradesStore(db DBTX) *TradesStore {
 // (CURRENT_TIMESTAMP) so callers don't need to set it.
 // IdempotencyKey is stored as NULL when empty so the partial unique index
 // only constrains non-null keys (legacy rows are unaffected).
+// OrderType defaults to MARKET.
 func (uts *TradesStore) CreateTrade(ctx context.Context, trade *Trade) error {
 	if trade.Status == "" {
 		trade.Status = "COMPLETED"
 	}
+	if trade.OrderType == "" {
+		trade.OrderType = OrderTypeMarket
+	}
 	var ikey sql.NullString
 	if trade.IdempotencyKey != "" {
 		ikey = sql.NullString{String: trade.IdempotencyKey, Valid: true}
 	}
-	query := `INSERT INTO trades (id, user_id, symbol, action, quantity, price, status, idempotency_key) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
-	_, err := uts.db.ExecContext(ctx, query, trade.ID, trade.UserID, trade.Symbol, trade.Action, trade.Quantity, trade.Price, trade.Status, ikey)
+	query := `INSERT INTO trades (id, user_id, symbol, action, quantity, price, status, idempotency_key, order_type, slippage) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	_, err := uts.db.ExecContext(ctx, query, trade.ID, trade.UserID, trade.Symbol, trade.Action, trade.Quantity, trade.Price, trade.Status, ikey, trade.OrderType, trade.Slippage)
 	return err
 }
 
 func (uts *TradesStore) GetTradeByID(ctx context.Context, id string) (*Trade, error) {
-	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key FROM trades WHERE id = $1`
+	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key, order_type, slippage FROM trades WHERE id = $1`
 
 	var trade Trade
 	var ikey sql.NullString
-	err := uts.db.QueryRowContext(ctx, query, id).Scan(&trade.ID, &trade.UserID, &trade.Symbol, &trade.Action, &trade.Quantity, &trade.Price, &trade.Total, &trade.ExecutedAt, &trade.Status, &ikey)
+	err := uts.db.QueryRowContext(ctx, query, id).Scan(&trade.ID, &trade.UserID, &trade.Symbol, &trade.Action, &trade.Quantity, &trade.Price, &trade.Total, &trade.ExecutedAt, &trade.Status, &ikey, &trade.OrderType, &trade.Slippage)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("trade not found")
+			return nil, ErrTradeNotFound
 		}
 		return nil, err
 	}
 	if ikey.Valid {
 		trade.IdempotencyKey = ikey.String
 	}
+	trade.AssetClass = AssetClassOf(trade.Symbol)
 
 	return &trade, nil
 }
@@ -95,20 +124,31 @@ func buildTradeFilter(opts TradeQueryOpts, startIdx int) (string, []interface{})
 		args = append(args, opts.Action)
 		idx++
 	}
+	if !opts.From.IsZero() {
+		clauses += " AND executed_at >= $" + strconv.Itoa(idx)
+		args = append(args, opts.From.UTC())
+		idx++
+	}
+	if !opts.To.IsZero() {
+		clauses += " AND executed_at < $" + strconv.Itoa(idx)
+		args = append(args, opts.To.UTC())
+		idx++
+	}
 	return clauses, args
 }
 
-// GetTradesByUserID returns a page of trades for a user, newest first.
-// total is computed server-side as quantity * price.
+// GetTradesByUserID returns a page of trades for a user, newest first, with
+// id breaking ties so pages do not overlap. total is computed server-side as
+// quantity * price.
 func (uts *TradesStore) GetTradesByUserID(ctx context.Context, userID string, opts TradeQueryOpts) ([]Trade, error) {
 	filter, filterArgs := buildTradeFilter(opts, 2)
 	limitIdx := 2 + len(filterArgs)
 	offsetIdx := limitIdx + 1
 
-	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key
+	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key, order_type, slippage
 		FROM trades
 		WHERE user_id = $1` + filter + `
-		ORDER BY executed_at DESC
+		ORDER BY executed_at DESC, id DESC
 		LIMIT $` + strconv.Itoa(limitIdx) + ` OFFSET $` + strconv.Itoa(offsetIdx)
 
 	args := append([]interface{}{userID}, filterArgs...)
@@ -124,12 +164,13 @@ func (uts *TradesStore) GetTradesByUserID(ctx context.Context, userID string, op
 	for rows.Next() {
 		var t Trade
 		var ikey sql.NullString
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Symbol, &t.Action, &t.Quantity, &t.Price, &t.Total, &t.ExecutedAt, &t.Status, &ikey); err != nil {
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Symbol, &t.Action, &t.Quantity, &t.Price, &t.Total, &t.ExecutedAt, &t.Status, &ikey, &t.OrderType, &t.Slippage); err != nil {
 			return nil, err
 		}
 		if ikey.Valid {
 			t.IdempotencyKey = ikey.String
 		}
+		t.AssetClass = AssetClassOf(t.Symbol)
 		trades = append(trades, t)
 	}
 	if err := rows.Err(); err != nil {
@@ -142,7 +183,7 @@ func (uts *TradesStore) GetTradesByUserID(ctx context.Context, userID string, op
 // (oldest first). Intended for internal use by the reconciliation service —
 // not paginated and not exposed as an HTTP endpoint.
 func (uts *TradesStore) GetAllTradesByUserID(ctx context.Context, userID string) ([]Trade, error) {
-	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key
+	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key, order_type, slippage
 		FROM trades
 		WHERE user_id = $1
 		ORDER BY executed_at ASC`
@@ -157,12 +198,13 @@ func (uts *TradesStore) GetAllTradesByUserID(ctx context.Context, userID string)
 	for rows.Next() {
 		var t Trade
 		var ikey sql.NullString
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Symbol, &t.Action, &t.Quantity, &t.Price, &t.Total, &t.ExecutedAt, &t.Status, &ikey); err != nil {
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Symbol, &t.Action, &t.Quantity, &t.Price, &t.Total, &t.ExecutedAt, &t.Status, &ikey, &t.OrderType, &t.Slippage); err != nil {
 			return nil, err
 		}
 		if ikey.Valid {
 			t.IdempotencyKey = ikey.String
 		}
+		t.AssetClass = AssetClassOf(t.Symbol)
 		trades = append(trades, t)
 	}
 	if err := rows.Err(); err != nil {
@@ -171,10 +213,44 @@ func (uts *TradesStore) GetAllTradesByUserID(ctx context.Context, userID string)
 	return trades, nil
 }
 
+// EachTrade calls fn with each of userID's trades matching opts (Limit and
+// Offset are ignored), oldest first, reading them from the database as it
+// goes so a whole history can be streamed. It stops at fn's first error and
+// returns it.
+func (uts *TradesStore) EachTrade(ctx context.Context, userID string, opts TradeQueryOpts, fn func(*Trade) error) error {
+	filter, filterArgs := buildTradeFilter(opts, 2)
+	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key, order_type, slippage
+		FROM trades
+		WHERE user_id = $1` + filter + `
+		ORDER BY executed_at ASC, id ASC`
+
+	rows, err := uts.db.QueryContext(ctx, query, append([]interface{}{userID}, filterArgs...)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Trade
+		var ikey sql.NullString
+		if err := rows.Scan(&t.ID, &t.UserID, &t.Symbol, &t.Action, &t.Quantity, &t.Price, &t.Total, &t.ExecutedAt, &t.Status, &ikey, &t.OrderType, &t.Slippage); err != nil {
+			return err
+		}
+		if ikey.Valid {
+			t.IdempotencyKey = ikey.String
+		}
+		t.AssetClass = AssetClassOf(t.Symbol)
+		if err := fn(&t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // GetTradeByIdempotencyKey returns the trade for (userID, key), or (nil, nil)
 // if no such key exists. Used to short-circuit duplicate buy/sell requests.
 func (uts *TradesStore) GetTradeByIdempotencyKey(ctx context.Context, userID, key string) (*Trade, error) {
-	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key
+	query := `SELECT id, user_id, symbol, action, quantity, price, (quantity * price) AS total, executed_at, status, idempotency_key, order_type, slippage
 		FROM trades
 		WHERE user_id = $1 AND idempotency_key = $2`
 
@@ -183,7 +259,7 @@ func (uts *TradesStore) GetTradeByIdempotencyKey(ctx context.Context, userID, ke
 	err := uts.db.QueryRowContext(ctx, query, userID, key).Scan(
 		&trade.ID, &trade.UserID, &trade.Symbol, &trade.Action,
 		&trade.Quantity, &trade.Price, &trade.Total, &trade.ExecutedAt,
-		&trade.Status, &ikey,
+		&trade.Status, &ikey, &trade.OrderType, &trade.Slippage,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -194,6 +270,7 @@ func (uts *TradesStore) GetTradeByIdempotencyKey(ctx context.Context, userID, ke
 	if ikey.Valid {
 		trade.IdempotencyKey = ikey.String
 	}
+	trade.AssetClass = AssetClassOf(trade.Symbol)
 	return &trade, nil
 }
 
@@ -210,3 +287,81 @@ func (uts *TradesStore) CountTradesByUserID(ctx context.Context, userID string,
 	}
 	return count, nil
 }
+
+// CountCompletedTradesSince returns how many COMPLETED trades the user has
+// executed at or after since. Backs the daily trade-count limit.
+func (uts *TradesStore) CountCompletedTradesSince(ctx context.Context, userID string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM trades WHERE user_id = $1 AND status = 'COMPLETED' AND executed_at >= $2`
+
+	var count int
+	if err := uts.db.QueryRowContext(ctx, query, userID, since).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// TradeTotals sums a user's completed trades over a period. Bought is the
+// cost of buys and covers and Sold the proceeds of sells and shorts. Spread
+// is what the simulated spread cost: slippage times shares, whatever the
+// side.
+type TradeTotals struct {
+	Count  int
+	Bought decimal.Decimal
+	Sold   decimal.Decimal
+	Spread decimal.Decimal
+}
+
+// Totals sums userID's COMPLETED trades executed in [from, to).
+func (uts *TradesStore) Totals(ctx context.Context, userID string, from, to time.Time) (*TradeTotals, error) {
+	query := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(quantity * price) FILTER (WHERE action IN ('BUY', 'COVER')), 0),
+		       COALESCE(SUM(quantity * price) FILTER (WHERE action IN ('SELL', 'SHORT')), 0),
+		       COALESCE(SUM(quantity * ABS(slippage)), 0)
+		FROM trades
+		WHERE user_id = $1 AND status = 'COMPLETED' AND executed_at >= $2 AND executed_at < $3`
+
+	var t TradeTotals
+	if err := uts.db.QueryRowContext(ctx, query, userID, from.UTC(), to.UTC()).Scan(&t.Count, &t.Bought, &t.Sold, &t.Spread); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// CountDayTradesSince returns the number of day trades since the given time:
+// (symbol, calendar day in loc) pairs with at least one BUY and one SELL, or
+// at least one SHORT and one COVER.
+func (uts *TradesStore) CountDayTradesSince(ctx context.Context, userID string, since time.Time, loc *time.Location) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM (
+			SELECT symbol, (executed_at AT TIME ZONE $3)::date
+			FROM trades
+			WHERE user_id = $1 AND status = 'COMPLETED' AND executed_at >= $2
+			GROUP BY symbol, (executed_at AT TIME ZONE $3)::date
+			HAVING (COUNT(*) FILTER (WHERE action = 'BUY') > 0
+			    AND COUNT(*) FILTER (WHERE action = 'SELL') > 0)
+			    OR (COUNT(*) FILTER (WHERE action = 'SHORT') > 0
+			    AND COUNT(*) FILTER (WHERE action = 'COVER') > 0)
+		) day_trades`
+
+	var count int
+	if err := uts.db.QueryRowContext(ctx, query, userID, since, loc.String()).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// HasTradeSince reports whether the user has a COMPLETED trade with the given
+// symbol and action at or after since.
+func (uts *TradesStore) HasTradeSince(ctx context.Context, userID, symbol, action string, since time.Time) (bool, error) {
+	query := `SELECT EXISTS (
+		SELECT 1 FROM trades
+		WHERE user_id = $1 AND symbol = $2 AND action = $3 AND status = 'COMPLETED' AND executed_at >= $4
+	)`
+
+	var exists bool
+	if err := uts.db.QueryRowContext(ctx, query, userID, symbol, action, since).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
@@ -0,0 +1,156 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// TradeDispute is a user's claim that one of their trades is wrong, and an
+// admin's resolution of it. ReversalTradeID is the compensating trade posted
+// when the dispute was resolved by reversing the trade.
+type TradeDispute struct {
+	ID              string     `json:"id"`
+	TradeID         string     `json:"trade_id"`
+	UserID          string     `json:"user_id"`
+	Reason          string     `json:"reason"`
+	Status          string     `json:"status"` // TradeDisputeOpen, TradeDisputeReversed or TradeDisputeRejected
+	ResolutionNote  string     `json:"resolution_note,omitempty"`
+	ResolvedBy      string     `json:"resolved_by,omitempty"`
+	ReversalTradeID string     `json:"reversal_trade_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+}
+
+// Trade dispute statuses.
+const (
+	TradeDisputeOpen     = "OPEN"
+	TradeDisputeReversed = "REVERSED"
+	TradeDisputeRejected = "REJECTED"
+)
+
+var (
+	ErrTradeDisputeNotFound = errors.New("trade dispute not found")
+	// ErrTradeAlreadyDisputed is returned by Create for a trade that already
+	// has a dispute, open or resolved.
+	ErrTradeAlreadyDisputed = errors.New("trade already disputed")
+)
+
+const tradeDisputeColumns = `id, trade_id, user_id, reason, status, resolution_note,
+	resolved_by, reversal_trade_id, created_at, resolved_at`
+
+func scanTradeDispute(row rowScanner) (*TradeDispute, error) {
+	var d TradeDispute
+	var resolvedBy, reversalTradeID sql.NullString
+	var resolvedAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.TradeID, &d.UserID, &d.Reason, &d.Status, &d.ResolutionNote,
+		&resolvedBy, &reversalTradeID, &d.CreatedAt, &resolvedAt); err != nil {
+		return nil, err
+	}
+	d.ResolvedBy = resolvedBy.String
+	d.ReversalTradeID = reversalTradeID.String
+	if resolvedAt.Valid {
+		d.ResolvedAt = &resolvedAt.Time
+	}
+	return &d, nil
+}
+
+type TradeDisputeStore struct {
+	db DBTX
+}
+
+func NewTradeDisputeStore(db DBTX) *TradeDisputeStore {
+	return &TradeDisputeStore{db: db}
+}
+
+// Create opens a dispute on one of the user's trades. Returns
+// ErrTradeNotFound if the user has no such trade and ErrTradeAlreadyDisputed
+// if it has been disputed before.
+func (s *TradeDisputeStore) Create(ctx context.Context, userID, tradeID, reason string) (*TradeDispute, error) {
+	query := `
+	INSERT INTO trade_disputes (id, trade_id, user_id, reason)
+	SELECT $1, id, user_id, $4 FROM trades WHERE id = $2 AND user_id = $3
+	RETURNING ` + tradeDisputeColumns
+
+	d, err := scanTradeDispute(s.db.QueryRowContext(ctx, query, uuid.New().String(), tradeID, userID, reason))
+	if err != nil {
+		var pqErr *pq.Error
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrTradeNotFound
+		case errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "trade_disputes_trade_id_key":
+			return nil, ErrTradeAlreadyDisputed
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListByUser returns the user's disputes, newest first.
+func (s *TradeDisputeStore) ListByUser(ctx context.Context, userID string) ([]TradeDispute, error) {
+	return s.query(ctx, `SELECT `+tradeDisputeColumns+` FROM trade_disputes
+	WHERE user_id = $1 ORDER BY created_at DESC, id`, userID)
+}
+
+// List returns up to limit disputes across all users, oldest first so the
+// queue is worked in order. status filters when non-empty.
+func (s *TradeDisputeStore) List(ctx context.Context, status string, limit int) ([]TradeDispute, error) {
+	return s.query(ctx, `SELECT `+tradeDisputeColumns+` FROM trade_disputes
+	WHERE ($1 = '' OR status = $1) ORDER BY created_at, id LIMIT $2`, status, limit)
+}
+
+// GetForUpdate returns the dispute and locks it until the surrounding
+// transaction ends, so two admins can't resolve it at once.
+func (s *TradeDisputeStore) GetForUpdate(ctx context.Context, id string) (*TradeDispute, error) {
+	d, err := scanTradeDispute(s.db.QueryRowContext(ctx,
+		`SELECT `+tradeDisputeColumns+` FROM trade_disputes WHERE id = $1 FOR UPDATE`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTradeDisputeNotFound
+	}
+	return d, err
+}
+
+// Resolve closes an open dispute with status, filling in d's resolution
+// fields. reversalTradeID is "" unless the trade was reversed.
+func (s *TradeDisputeStore) Resolve(ctx context.Context, d *TradeDispute, status, note, adminID, reversalTradeID string) error {
+	query := `
+	UPDATE trade_disputes
+	SET status = $2, resolution_note = $3, resolved_by = $4, reversal_trade_id = NULLIF($5, ''),
+	    resolved_at = CURRENT_TIMESTAMP
+	WHERE id = $1 AND status = 'OPEN'
+	RETURNING resolved_at`
+
+	var resolvedAt time.Time
+	err := s.db.QueryRowContext(ctx, query, d.ID, status, note, adminID, reversalTradeID).Scan(&resolvedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTradeDisputeNotFound
+	}
+	if err != nil {
+		return err
+	}
+	d.Status, d.ResolutionNote, d.ResolvedBy, d.ReversalTradeID = status, note, adminID, reversalTradeID
+	d.ResolvedAt = &resolvedAt
+	return nil
+}
+
+func (s *TradeDisputeStore) query(ctx context.Context, query string, args ...any) ([]TradeDispute, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]TradeDispute, 0)
+	for rows.Next() {
+		d, err := scanTradeDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *d)
+	}
+	return out, rows.Err()
+}
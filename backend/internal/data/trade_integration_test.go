@@ -11,8 +11,11 @@ package data_test
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
@@ -44,7 +47,7 @@ func TestTradesAppendOnly_UpdateRejected(t *testing.T) {
 		UserID:   userID,
 		Symbol:   "AAPL",
 		Action:   "BUY",
-		Quantity: 1,
+		Quantity: decimal.NewFromInt(1),
 		Price:    decimal.NewFromFloat(100.0),
 		Status:   "COMPLETED",
 	}
@@ -84,7 +87,7 @@ func TestTradesAppendOnly_DeleteRejected(t *testing.T) {
 		UserID:   userID,
 		Symbol:   "TSLA",
 		Action:   "SELL",
-		Quantity: 2,
+		Quantity: decimal.NewFromInt(2),
 		Price:    decimal.NewFromFloat(250.0),
 		Status:   "COMPLETED",
 	}
@@ -102,6 +105,88 @@ func TestTradesAppendOnly_DeleteRejected(t *testing.T) {
 	}
 }
 
+// TestDeleteExpiredGuests_PurgesTrades checks that the guest purge may delete
+// trades despite the append-only trigger, and that the exemption does not
+// outlive the purge's transaction.
+func TestDeleteExpiredGuests_PurgesTrades(t *testing.T) {
+	db := testutil.NewIntegrationDB(t)
+	testutil.Truncate(t, db, "trades", "portfolio", "users")
+	ctx := context.Background()
+
+	users := data.NewUserStore(db)
+	guest, err := users.CreateGuestUser(ctx, time.Now().Add(-time.Hour), nil)
+	if err != nil {
+		t.Fatalf("CreateGuestUser: %v", err)
+	}
+	trade := &data.Trade{
+		ID:       uuid.New().String(),
+		UserID:   guest.ID,
+		Symbol:   "AAPL",
+		Action:   "BUY",
+		Quantity: decimal.NewFromInt(1),
+		Price:    decimal.NewFromFloat(100.0),
+	}
+	if err := data.NewTradesStore(db).CreateTrade(ctx, trade); err != nil {
+		t.Fatalf("CreateTrade: %v", err)
+	}
+
+	n, err := users.DeleteExpiredGuests(ctx, time.Now(), 10)
+	if err != nil {
+		t.Fatalf("DeleteExpiredGuests: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("purged %d users, want 1", n)
+	}
+	var remaining int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM trades WHERE user_id = $1`, guest.ID).Scan(&remaining); err != nil {
+		t.Fatalf("count trades: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("%d trades left for purged guest", remaining)
+	}
+
+	// A later plain DELETE is still rejected.
+	other := &data.Trade{ID: uuid.New().String(), UserID: guest.ID, Symbol: "MSFT", Action: "BUY", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromFloat(10)}
+	if err := data.NewTradesStore(db).CreateTrade(ctx, other); err != nil {
+		t.Fatalf("CreateTrade: %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM trades WHERE id = $1`, other.ID); err == nil || !containsAppendOnly(err.Error()) {
+		t.Errorf("expected append-only rejection after purge, got %v", err)
+	}
+}
+
+// TestDeleteAccount_PurgesEverything checks that a user's own deletion
+// removes their trades and holdings along with the user row.
+func TestDeleteAccount_PurgesEverything(t *testing.T) {
+	db := testutil.NewIntegrationDB(t)
+	testutil.Truncate(t, db, "trades", "portfolio", "users")
+	ctx := context.Background()
+
+	users := data.NewUserStore(db)
+	user, err := users.CreateGuestUser(ctx, time.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("CreateGuestUser: %v", err)
+	}
+	trade := &data.Trade{ID: uuid.New().String(), UserID: user.ID, Symbol: "AAPL", Action: "BUY", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromFloat(100)}
+	if err := data.NewTradesStore(db).CreateTrade(ctx, trade); err != nil {
+		t.Fatalf("CreateTrade: %v", err)
+	}
+
+	if _, err := users.DeleteAccount(ctx, user.ID); err != nil {
+		t.Fatalf("DeleteAccount: %v", err)
+	}
+	var trades, rows int
+	if err := db.QueryRow(`SELECT (SELECT COUNT(*) FROM trades WHERE user_id = $1), (SELECT COUNT(*) FROM users WHERE id = $1)`, user.ID).Scan(&trades, &rows); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if trades != 0 || rows != 0 {
+		t.Errorf("%d trades and %d user rows left after deletion", trades, rows)
+	}
+	if _, err := users.DeleteAccount(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("second deletion: got %v, want sql.ErrNoRows", err)
+	}
+}
+
 // containsAppendOnly reports whether the error message from the DB trigger is
 // present. The trigger raises: 'trades is append-only — % is not permitted'.
 func containsAppendOnly(msg string) bool {
@@ -0,0 +1,178 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TradeNote is the user's annotation on one of their trades.
+type TradeNote struct {
+	TradeID   string    `json:"trade_id"`
+	Note      string    `json:"note"`
+	Tags      []string  `json:"tags"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TradeSearchResult is a trade matched by SearchTrades.
+type TradeSearchResult struct {
+	Trade
+	Note string   `json:"note"`
+	Tags []string `json:"tags"`
+	Rank float64  `json:"rank"`
+	// Highlight is the note excerpt with matches delimited by the caller's
+	// start/stop markers; "" when the note has no match.
+	Highlight string `json:"highlight"`
+}
+
+// TradeSearchOpts are the inputs of SearchTrades. Query is in websearch
+// syntax ("nvda earnings", "-loss", "\"gap up\""). From/To bound executed_at
+// (From inclusive, To exclusive) when non-zero.
+type TradeSearchOpts struct {
+	Query    string
+	From, To time.Time
+	StartSel string // ts_headline markers around each match
+	StopSel  string
+	Limit    int
+	Offset   int
+}
+
+type TradeNotesStore struct {
+	db DBTX
+}
+
+func NewTradeNotesStore(db DBTX) *TradeNotesStore {
+	return &TradeNotesStore{db: db}
+}
+
+// Upsert sets the note and tags on one of the user's trades. Returns
+// ErrTradeNotFound if the user has no such trade.
+func (s *TradeNotesStore) Upsert(ctx context.Context, userID, tradeID, note string, tags []string) (*TradeNote, error) {
+	query := `
+	INSERT INTO trade_notes (trade_id, user_id, note, tags)
+	SELECT id, user_id, $3, $4 FROM trades WHERE id = $1 AND user_id = $2
+	ON CONFLICT (trade_id) DO UPDATE
+	SET note = EXCLUDED.note, tags = EXCLUDED.tags, updated_at = CURRENT_TIMESTAMP
+	RETURNING trade_id, note, tags, updated_at`
+
+	var n TradeNote
+	err := s.db.QueryRowContext(ctx, query, tradeID, userID, note, pq.Array(tags)).
+		Scan(&n.TradeID, &n.Note, pq.Array(&n.Tags), &n.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTradeNotFound
+		}
+		return nil, err
+	}
+	if n.Tags == nil {
+		n.Tags = []string{}
+	}
+	return &n, nil
+}
+
+// Delete removes the note on one of the user's trades. Deleting a note that
+// doesn't exist is not an error, but the trade itself must exist.
+func (s *TradeNotesStore) Delete(ctx context.Context, userID, tradeID string) error {
+	var exists bool
+	if err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM trades WHERE id = $1 AND user_id = $2)`, tradeID, userID,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTradeNotFound
+	}
+	_, err := s.db.ExecContext(ctx, `DELETE FROM trade_notes WHERE trade_id = $1 AND user_id = $2`, tradeID, userID)
+	return err
+}
+
+// SearchTrades ranks the user's trades against opts.Query over the symbol
+// (weight A), tags (B) and note (C), best match first, and returns one page
+// plus the total number of matches.
+//
+// The document is built per row at query time rather than indexed: the
+// user_id filter keeps the scan to one user's trades, which stays small, and
+// an index would need the symbol copied out of the append-only trades table.
+func (s *TradeNotesStore) SearchTrades(ctx context.Context, userID string, opts TradeSearchOpts) ([]TradeSearchResult, int, error) {
+	query := `
+	WITH q AS (SELECT websearch_to_tsquery('english', $2) AS query)
+	SELECT t.id, t.user_id, t.symbol, t.action, t.quantity, t.price, (t.quantity * t.price) AS total,
+	       t.executed_at, t.status, t.idempotency_key, t.order_type, t.slippage,
+	       COALESCE(n.note, ''), COALESCE(n.tags, '{}'),
+	       ts_rank(d.doc, q.query) AS rank,
+	       CASE WHEN to_tsvector('english', COALESCE(n.note, '')) @@ q.query
+	            THEN ts_headline('english', n.note, q.query, $3) ELSE '' END,
+	       COUNT(*) OVER () AS matches
+	FROM trades t
+	LEFT JOIN trade_notes n ON n.trade_id = t.id
+	CROSS JOIN q
+	CROSS JOIN LATERAL (
+		SELECT setweight(to_tsvector('english', t.symbol), 'A')
+		    || setweight(to_tsvector('english', array_to_string(COALESCE(n.tags, '{}'), ' ')), 'B')
+		    || setweight(to_tsvector('english', COALESCE(n.note, '')), 'C') AS doc
+	) d
+	WHERE t.user_id = $1
+	  AND d.doc @@ q.query
+	  AND ($4::timestamptz IS NULL OR t.executed_at >= $4)
+	  AND ($5::timestamptz IS NULL OR t.executed_at < $5)
+	ORDER BY rank DESC, t.executed_at DESC, t.id
+	LIMIT $6 OFFSET $7`
+	if sqliteStorage.Load() {
+		// SQLite has no text search types; internal/sqlite's websearch_rank
+		// and websearch_headline weigh the same document.
+		query = `
+	SELECT id, user_id, symbol, action, quantity, price, total, executed_at, status, idempotency_key,
+	       order_type, slippage, note, tags, rank, headline, COUNT(*) OVER () AS matches
+	FROM (
+		SELECT t.id, t.user_id, t.symbol, t.action, t.quantity, t.price, (t.quantity * t.price) AS total,
+		       t.executed_at, t.status, t.idempotency_key, t.order_type, t.slippage,
+		       COALESCE(n.note, '') AS note, COALESCE(n.tags, '{}') AS tags,
+		       websearch_rank($2, t.symbol, n.tags, n.note) AS rank,
+		       websearch_headline($2, n.note, $3) AS headline
+		FROM trades t
+		LEFT JOIN trade_notes n ON n.trade_id = t.id
+		WHERE t.user_id = $1
+		  AND ($4 IS NULL OR t.executed_at >= $4)
+		  AND ($5 IS NULL OR t.executed_at < $5)
+	) m
+	WHERE rank > 0
+	ORDER BY rank DESC, executed_at DESC, id
+	LIMIT $6 OFFSET $7`
+	}
+
+	headlineOpts := `StartSel="` + opts.StartSel + `", StopSel="` + opts.StopSel + `", MaxFragments=2, MinWords=5, MaxWords=20`
+	rows, err := s.db.QueryContext(ctx, query, userID, opts.Query, headlineOpts,
+		nullTime(opts.From), nullTime(opts.To), opts.Limit, opts.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := make([]TradeSearchResult, 0)
+	total := 0
+	for rows.Next() {
+		var r TradeSearchResult
+		var ikey sql.NullString
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Symbol, &r.Action, &r.Quantity, &r.Price, &r.Total,
+			&r.ExecutedAt, &r.Status, &ikey, &r.OrderType, &r.Slippage,
+			&r.Note, pq.Array(&r.Tags), &r.Rank, &r.Highlight, &total); err != nil {
+			return nil, 0, err
+		}
+		r.IdempotencyKey = ikey.String
+		if r.Tags == nil {
+			r.Tags = []string{}
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
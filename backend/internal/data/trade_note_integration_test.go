@@ -0,0 +1,94 @@
+//go:build integration
+
+package data_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/testutil"
+)
+
+// TestSearchTrades_RanksAndHighlights checks the full-text query end to end:
+// symbol and note terms both match, results are scoped to the user, the date
+// bounds apply, and the headline wraps matches in the given markers.
+func TestSearchTrades_RanksAndHighlights(t *testing.T) {
+	db := testutil.NewIntegrationDB(t)
+	testutil.Truncate(t, db, "trades", "portfolio", "users")
+	ctx := context.Background()
+
+	newUser := func(email string) string {
+		id := uuid.New().String()
+		if _, err := db.Exec(
+			`INSERT INTO users (id, email, password, email_verified, created_via) VALUES ($1, $2, 'x', FALSE, 'email')`,
+			id, email,
+		); err != nil {
+			t.Fatalf("insert user: %v", err)
+		}
+		return id
+	}
+	userID := newUser("search@example.com")
+	otherID := newUser("search-other@example.com")
+
+	trades := data.NewTradesStore(db)
+	notes := data.NewTradeNotesStore(db)
+	newTrade := func(user, symbol string) string {
+		trade := &data.Trade{ID: uuid.New().String(), UserID: user, Symbol: symbol, Action: "BUY",
+			Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(100)}
+		if err := trades.CreateTrade(ctx, trade); err != nil {
+			t.Fatalf("CreateTrade: %v", err)
+		}
+		return trade.ID
+	}
+
+	nvda := newTrade(userID, "NVDA")
+	if _, err := notes.Upsert(ctx, userID, nvda, "Bought ahead of the earnings call", []string{"earnings", "swing"}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	amd := newTrade(userID, "AMD")
+	if _, err := notes.Upsert(ctx, userID, amd, "Sympathy play after NVDA earnings", nil); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	newTrade(userID, "AAPL")
+	other := newTrade(otherID, "NVDA")
+	if _, err := notes.Upsert(ctx, otherID, other, "earnings", nil); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if _, err := notes.Upsert(ctx, userID, other, "not mine", nil); err != data.ErrTradeNotFound {
+		t.Fatalf("Upsert on another user's trade: got %v, want ErrTradeNotFound", err)
+	}
+
+	opts := data.TradeSearchOpts{Query: "nvda earnings", StartSel: "[", StopSel: "]", Limit: 10}
+	results, total, err := notes.SearchTrades(ctx, userID, opts)
+	if err != nil {
+		t.Fatalf("SearchTrades: %v", err)
+	}
+	if total != 2 || len(results) != 2 {
+		t.Fatalf("got %d results (total %d), want 2", len(results), total)
+	}
+	// NVDA matches on the symbol (weight A); AMD only in its note (C).
+	if results[0].ID != nvda || results[1].ID != amd {
+		t.Errorf("order: got %s, %s; want NVDA then AMD", results[0].Symbol, results[1].Symbol)
+	}
+	if !strings.Contains(results[1].Highlight, "[NVDA]") || !strings.Contains(results[1].Highlight, "[earnings]") {
+		t.Errorf("highlight: got %q", results[1].Highlight)
+	}
+	if len(results[0].Tags) != 2 {
+		t.Errorf("tags: got %v", results[0].Tags)
+	}
+
+	opts.From = time.Now().Add(time.Hour)
+	results, total, err = notes.SearchTrades(ctx, userID, opts)
+	if err != nil {
+		t.Fatalf("SearchTrades with from: %v", err)
+	}
+	if total != 0 || len(results) != 0 {
+		t.Errorf("future from: got %d results, want none", len(results))
+	}
+}
@@ -1,6 +1,9 @@
 package data
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Trades is the storage contract for the trades append-only log.
 // Implemented by TradesStore (see trade.go).
@@ -12,5 +15,9 @@ type Trades interface {
 	GetTradesByUserID(ctx context.Context, userID string, opts TradeQueryOpts) ([]Trade, error)
 	CountTradesByUserID(ctx context.Context, userID string, opts TradeQueryOpts) (int, error)
 	GetAllTradesByUserID(ctx context.Context, userID string) ([]Trade, error)
+	EachTrade(ctx context.Context, userID string, opts TradeQueryOpts, fn func(*Trade) error) error
 	GetTradeByIdempotencyKey(ctx context.Context, userID, key string) (*Trade, error)
+	CountCompletedTradesSince(ctx context.Context, userID string, since time.Time) (int, error)
+	CountDayTradesSince(ctx context.Context, userID string, since time.Time, loc *time.Location) (int, error)
+	HasTradeSince(ctx context.Context, userID, symbol, action string, since time.Time) (bool, error)
 }
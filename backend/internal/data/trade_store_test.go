@@ -13,7 +13,7 @@ import (
 // tradeCols matches the SELECT column list returned by GetTradeByID and
 // GetTradesByUserID (total is a computed expression, not a stored column).
 var tradeCols = []string{
-	"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status", "idempotency_key",
+	"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status", "idempotency_key", "order_type", "slippage",
 }
 
 // ---- CreateTrade ----
@@ -30,13 +30,13 @@ func TestCreateTrade_HappyPath(t *testing.T) {
 		UserID:   "user-1",
 		Symbol:   "AAPL",
 		Action:   "BUY",
-		Quantity: 5,
+		Quantity: decimal.NewFromInt(5),
 		Price:    decimal.NewFromFloat(150.0),
 		Status:   "COMPLETED",
 	}
 
 	mock.ExpectExec("INSERT INTO trades").
-		WithArgs(trade.ID, trade.UserID, trade.Symbol, trade.Action, trade.Quantity, trade.Price, trade.Status, sql.NullString{}).
+		WithArgs(trade.ID, trade.UserID, trade.Symbol, trade.Action, trade.Quantity, trade.Price, trade.Status, sql.NullString{}, "MARKET", decimal.Zero).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	store := NewTradesStore(db)
@@ -61,13 +61,13 @@ func TestCreateTrade_DefaultStatus(t *testing.T) {
 		UserID:   "user-1",
 		Symbol:   "TSLA",
 		Action:   "SELL",
-		Quantity: 2,
+		Quantity: decimal.NewFromInt(2),
 		Price:    decimal.NewFromFloat(250.0),
 		Status:   "",
 	}
 
 	mock.ExpectExec("INSERT INTO trades").
-		WithArgs(trade.ID, trade.UserID, trade.Symbol, trade.Action, trade.Quantity, trade.Price, "COMPLETED", sql.NullString{}).
+		WithArgs(trade.ID, trade.UserID, trade.Symbol, trade.Action, trade.Quantity, trade.Price, "COMPLETED", sql.NullString{}, "MARKET", decimal.Zero).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	store := NewTradesStore(db)
@@ -77,6 +77,9 @@ func TestCreateTrade_DefaultStatus(t *testing.T) {
 	if trade.Status != "COMPLETED" {
 		t.Errorf("Status: got %q, want %q", trade.Status, "COMPLETED")
 	}
+	if trade.OrderType != OrderTypeMarket {
+		t.Errorf("OrderType: got %q, want %q", trade.OrderType, OrderTypeMarket)
+	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unmet expectations: %v", err)
 	}
@@ -95,7 +98,7 @@ func TestGetTradeByID_Found(t *testing.T) {
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("trade-1").
 		WillReturnRows(sqlmock.NewRows(tradeCols).AddRow(
-			"trade-1", "user-1", "AAPL", "BUY", 5, decimal.NewFromFloat(150.0), decimal.NewFromFloat(750.0), executedAt, "COMPLETED", nil,
+			"trade-1", "user-1", "AAPL", "BUY", 5, decimal.NewFromFloat(150.0), decimal.NewFromFloat(750.0), executedAt, "COMPLETED", nil, "MARKET", "0",
 		))
 
 	store := NewTradesStore(db)
@@ -112,8 +115,8 @@ func TestGetTradeByID_Found(t *testing.T) {
 	if trade.Action != "BUY" {
 		t.Errorf("Action: got %q, want %q", trade.Action, "BUY")
 	}
-	if trade.Quantity != 5 {
-		t.Errorf("Quantity: got %d, want %d", trade.Quantity, 5)
+	if !trade.Quantity.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("Quantity: got %s, want %d", trade.Quantity, 5)
 	}
 	wantTradeTotal := decimal.NewFromFloat(750.0)
 	if !trade.Total.Equal(wantTradeTotal) {
@@ -161,8 +164,8 @@ func TestGetTradesByUserID_HappyPath(t *testing.T) {
 	mock.ExpectQuery(`SELECT id, user_id, symbol, action, quantity, price, \(quantity \* price\) AS total, executed_at, status, idempotency_key`).
 		WithArgs("user-1", 50, 0).
 		WillReturnRows(sqlmock.NewRows(tradeCols).
-			AddRow("t-2", "user-1", "TSLA", "SELL", 3, decimal.NewFromFloat(250.0), decimal.NewFromFloat(750.0), now, "COMPLETED", nil).
-			AddRow("t-1", "user-1", "AAPL", "BUY", 5, decimal.NewFromFloat(150.0), decimal.NewFromFloat(750.0), now.Add(-time.Hour), "COMPLETED", nil),
+			AddRow("t-2", "user-1", "TSLA", "SELL", 3, decimal.NewFromFloat(250.0), decimal.NewFromFloat(750.0), now, "COMPLETED", nil, "MARKET", "0").
+			AddRow("t-1", "user-1", "AAPL", "BUY", 5, decimal.NewFromFloat(150.0), decimal.NewFromFloat(750.0), now.Add(-time.Hour), "COMPLETED", nil, "MARKET", "0"),
 		)
 
 	store := NewTradesStore(db)
@@ -228,6 +231,31 @@ func TestGetTradesByUserID_SymbolAndActionFilter(t *testing.T) {
 	}
 }
 
+func TestGetTradesByUserID_DateRange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`AND action = \$2 AND executed_at >= \$3 AND executed_at < \$4\s+ORDER BY executed_at DESC, id DESC`).
+		WithArgs("user-1", "SELL", from, to, 50, 0).
+		WillReturnRows(sqlmock.NewRows(tradeCols))
+
+	store := NewTradesStore(db)
+	_, err = store.GetTradesByUserID(context.Background(), "user-1", TradeQueryOpts{
+		Action: "SELL", From: from, To: to, Limit: 50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
 // ---- CountTradesByUserID ----
 
 func TestCountTradesByUserID_HappyPath(t *testing.T) {
@@ -292,7 +320,7 @@ func TestGetTradeByIdempotencyKey_Found(t *testing.T) {
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1", "key-abc").
 		WillReturnRows(sqlmock.NewRows(tradeCols).AddRow(
-			"trade-1", "user-1", "AAPL", "BUY", 5, decimal.NewFromFloat(150.0), decimal.NewFromFloat(750.0), now, "COMPLETED", ikey,
+			"trade-1", "user-1", "AAPL", "BUY", 5, decimal.NewFromFloat(150.0), decimal.NewFromFloat(750.0), now, "COMPLETED", ikey, "MARKET", "0",
 		))
 
 	store := NewTradesStore(db)
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -24,14 +25,88 @@ type User struct {
 	VerificationTokenExpires *time.Time      `json:"-"`
 	GoogleID                 *string         `json:"-"`
 	CreatedVia               string          `json:"created_via"`
+	AvatarURL                string          `json:"avatar_url,omitempty"`
+	Username                 string          `json:"username,omitempty"`
+	IsGuest                  bool            `json:"is_guest"`
+	GuestExpiresAt           *time.Time      `json:"guest_expires_at,omitempty"`
+	DisplayCurrency          string          `json:"display_currency"`
+	AfterHoursOrders         string          `json:"after_hours_orders"` // AfterHoursReject or AfterHoursQueue
+	League                   string          `json:"league,omitempty"`
+	CostBasisMethod          string          `json:"cost_basis_method"` // CostBasisFIFO, CostBasisLIFO or CostBasisAverage
+	TradeConfirmationEmails  bool            `json:"trade_confirmation_emails"`
+	Timezone                 string          `json:"timezone"` // IANA name
+	Role                     string          `json:"role"`     // RoleUser or RoleAdmin
+}
+
+// What an account may do: admins can also use /api/admin.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// What happens to a buy or sell placed while the market is closed.
+const (
+	AfterHoursReject = "REJECT"
+	AfterHoursQueue  = "QUEUE"
+)
+
+// Which shares a sell realizes gains against: the oldest lots, the newest,
+// or the holding's average cost.
+const (
+	CostBasisFIFO    = "FIFO"
+	CostBasisLIFO    = "LIFO"
+	CostBasisAverage = "AVERAGE"
+)
+
+// DefaultTimezone is the time zone of a user who has not chosen one: the
+// market's.
+const DefaultTimezone = "America/New_York"
+
+var (
+	ErrUsernameTaken    = errors.New("username already taken")
+	ErrUsernameCooldown = errors.New("username changed too recently")
+	ErrEmailTaken       = errors.New("email already taken")
+	ErrGoogleIDTaken    = errors.New("google account already linked to another user")
+	ErrNotGuest         = errors.New("user is not a guest")
+)
+
+// DefaultStartingBalance is what a new account opens with unless
+// SetStartingBalance or a Cohort says otherwise.
+var DefaultStartingBalance = decimal.NewFromInt(10000)
+
+// Cohort is what an organiser gives the accounts of one group: a starting
+// balance (nil for the store's) and a league (empty for none).
+type Cohort struct {
+	StartingBalance *decimal.Decimal
+	League          string
 }
 
 type UserStore struct {
-	db DBTX
+	db              DBTX
+	startingBalance decimal.Decimal
 }
 
 func NewUserStore(db DBTX) *UserStore {
-	return &UserStore{db: db}
+	return &UserStore{db: db, startingBalance: DefaultStartingBalance}
+}
+
+// SetStartingBalance sets the balance new accounts open with when their
+// cohort doesn't set one.
+func (us *UserStore) SetStartingBalance(balance decimal.Decimal) {
+	us.startingBalance = balance
+}
+
+// opening returns the starting balance and league for a new account in
+// cohort, which may be nil.
+func (us *UserStore) opening(cohort *Cohort) (decimal.Decimal, string) {
+	if cohort == nil {
+		return us.startingBalance, ""
+	}
+	balance := us.startingBalance
+	if cohort.StartingBalance != nil {
+		balance = *cohort.StartingBalance
+	}
+	return balance, cohort.League
 }
 
 // GetBalanceForUpdate returns the user's balance and locks the row until the
@@ -62,10 +137,10 @@ func (us *UserStore) CreateUser(ctx context.Context, email, password string) (*U
 	email = normalizeEmail(email)
 
 	query := `
-	INSERT INTO users (id, email, password, created_at, balance, email_verified, created_via)
-	VALUES ($1, $2, $3, CURRENT_TIMESTAMP, 10000.00, FALSE, 'email')`
+	INSERT INTO users (id, email, password, created_at, balance, starting_balance, email_verified, created_via)
+	VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $4, $4, FALSE, 'email')`
 
-	_, err = us.db.ExecContext(ctx, query, userID, email, string(hashedPassword))
+	_, err = us.db.ExecContext(ctx, query, userID, email, string(hashedPassword), us.startingBalance)
 	if err != nil {
 		return nil, fmt.Errorf("error creating user: %w", err)
 	}
@@ -73,7 +148,10 @@ func (us *UserStore) CreateUser(ctx context.Context, email, password string) (*U
 	return us.GetUserByID(ctx, userID)
 }
 
-func (us *UserStore) CreateUserWithVerification(ctx context.Context, email, password string) (*User, string, error) {
+// CreateUserWithVerification creates an email/password account with a fresh
+// verification token. username may be empty; a taken one yields
+// ErrUsernameTaken. cohort may be nil.
+func (us *UserStore) CreateUserWithVerification(ctx context.Context, email, password, username string, cohort *Cohort) (*User, string, error) {
 	userID := uuid.New().String()
 	verificationToken := uuid.New().String()
 	expiresAt := time.Now().Add(24 * time.Hour)
@@ -85,12 +163,16 @@ func (us *UserStore) CreateUserWithVerification(ctx context.Context, email, pass
 	}
 	email = normalizeEmail(email)
 
+	balance, league := us.opening(cohort)
 	query := `
-	INSERT INTO users (id, email, password, created_at, balance, email_verified, verification_token, verification_token_expires, created_via)
-	VALUES ($1, $2, $3, CURRENT_TIMESTAMP, 10000.00, FALSE, $4, $5, 'email')`
+	INSERT INTO users (id, email, password, created_at, balance, starting_balance, email_verified, verification_token, verification_token_expires, created_via, username, league)
+	VALUES ($1, $2, $3, CURRENT_TIMESTAMP, $7, $7, FALSE, $4, $5, 'email', NULLIF($6, ''), NULLIF($8, ''))`
 
-	_, err = us.db.ExecContext(ctx, query, userID, email, string(hashedPassword), verificationToken, expiresAt)
+	_, err = us.db.ExecContext(ctx, query, userID, email, string(hashedPassword), verificationToken, expiresAt, username, balance, league)
 	if err != nil {
+		if isUsernameConflict(err) {
+			return nil, "", ErrUsernameTaken
+		}
 		return nil, "", fmt.Errorf("error creating user: %w", err)
 	}
 
@@ -102,15 +184,18 @@ func (us *UserStore) CreateUserWithVerification(ctx context.Context, email, pass
 	return user, verificationToken, nil
 }
 
-func (us *UserStore) CreateGoogleUser(ctx context.Context, email, googleID string) (*User, error) {
+// CreateGoogleUser creates an account for a Google sign-in. cohort may be
+// nil.
+func (us *UserStore) CreateGoogleUser(ctx context.Context, email, googleID string, cohort *Cohort) (*User, error) {
 	userID := uuid.New().String()
 	email = normalizeEmail(email)
+	balance, league := us.opening(cohort)
 
 	query := `
-	INSERT INTO users (id, email, password, created_at, balance, email_verified, google_id, created_via)
-	VALUES ($1, $2, NULL, CURRENT_TIMESTAMP, 10000.00, TRUE, $3, 'google')`
+	INSERT INTO users (id, email, password, created_at, balance, starting_balance, email_verified, google_id, created_via, league)
+	VALUES ($1, $2, NULL, CURRENT_TIMESTAMP, $4, $4, TRUE, $3, 'google', NULLIF($5, ''))`
 
-	_, err := us.db.ExecContext(ctx, query, userID, email, googleID)
+	_, err := us.db.ExecContext(ctx, query, userID, email, googleID, balance, league)
 	if err != nil {
 		return nil, fmt.Errorf("error creating user: %w", err)
 	}
@@ -118,6 +203,110 @@ func (us *UserStore) CreateGoogleUser(ctx context.Context, email, googleID strin
 	return us.GetUserByID(ctx, userID)
 }
 
+// CreateImportedUser creates a passwordless account for an admin bulk
+// import in cohort, which may be nil. The user signs in through the emailed
+// invite link. Returns ErrEmailTaken when the address already has an
+// account.
+func (us *UserStore) CreateImportedUser(ctx context.Context, email string, cohort *Cohort) (*User, error) {
+	userID := uuid.New().String()
+	email = normalizeEmail(email)
+	balance, league := us.opening(cohort)
+
+	query := `
+	INSERT INTO users (id, email, password, created_at, balance, starting_balance, email_verified, created_via, league)
+	VALUES ($1, $2, NULL, CURRENT_TIMESTAMP, $3, $3, FALSE, 'import', NULLIF($4, ''))`
+
+	_, err := us.db.ExecContext(ctx, query, userID, email, balance, league)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_email_key" {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("error creating user: %w", err)
+	}
+
+	return us.GetUserByID(ctx, userID)
+}
+
+// UserStats is one row of the admin user export: the account plus
+// aggregates over its holdings and trades. HoldingsCost is long positions
+// at average cost; market value would need a quote per symbol.
+type UserStats struct {
+	ID           string          `json:"id"`
+	Email        string          `json:"email"`
+	Username     string          `json:"username,omitempty"`
+	League       string          `json:"league,omitempty"`
+	CreatedVia   string          `json:"created_via"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Balance      decimal.Decimal `json:"balance"`
+	Positions    int             `json:"positions"`
+	HoldingsCost decimal.Decimal `json:"holdings_cost"`
+	TradeCount   int             `json:"trade_count"`
+	LastTradeAt  *time.Time      `json:"last_trade_at,omitempty"`
+}
+
+// ListUserStats returns every non-guest user, or only those in league when
+// it is non-empty, oldest account first.
+func (us *UserStore) ListUserStats(ctx context.Context, league string) ([]UserStats, error) {
+	query := `SELECT ` + userStatsColumns + ` FROM ` + userStatsTables + `
+	WHERE NOT u.is_guest AND ($1 = '' OR u.league = $1)
+	ORDER BY u.created_at, u.id`
+
+	rows, err := us.db.QueryContext(ctx, query, league)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]UserStats, 0)
+	for rows.Next() {
+		var st UserStats
+		if err := scanUserStats(rows, &st); err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// userStatsColumns is the column list scanUserStats expects, in order,
+// selected from userStatsTables.
+const userStatsColumns = `u.id, COALESCE(u.email, ''), COALESCE(u.username, ''), COALESCE(u.league, ''),
+	       COALESCE(u.created_via, ''), u.created_at, u.balance,
+	       COALESCE(p.positions, 0), COALESCE(p.cost, 0),
+	       COALESCE(t.trades, 0), t.last_trade_at`
+
+// userStatsTables joins each user (u) to the aggregates of their holdings
+// and completed trades.
+const userStatsTables = `users u
+	LEFT JOIN (
+		SELECT user_id, COUNT(*) AS positions,
+		       SUM(CASE WHEN quantity > 0 THEN quantity * avg_price ELSE 0 END) AS cost
+		FROM portfolio GROUP BY user_id
+	) p ON p.user_id = u.id
+	LEFT JOIN (
+		SELECT user_id, COUNT(*) AS trades, MAX(executed_at) AS last_trade_at
+		FROM trades WHERE status = 'COMPLETED' GROUP BY user_id
+	) t ON t.user_id = u.id`
+
+// scanUserStats scans userStatsColumns into st, then any further columns
+// into extra.
+func scanUserStats(row rowScanner, st *UserStats, extra ...any) error {
+	var lastTrade sql.NullTime
+	dest := append([]any{&st.ID, &st.Email, &st.Username, &st.League, &st.CreatedVia, &st.CreatedAt, &st.Balance,
+		&st.Positions, &st.HoldingsCost, &st.TradeCount, &lastTrade}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	if lastTrade.Valid {
+		st.LastTradeAt = &lastTrade.Time
+	}
+	return nil
+}
+
 func (us *UserStore) VerifyEmail(ctx context.Context, token string) error {
 	query := `
 	UPDATE users
@@ -144,40 +333,8 @@ func (us *UserStore) VerifyEmail(ctx context.Context, token string) error {
 }
 
 func (us *UserStore) GetUserByGoogleID(ctx context.Context, googleID string) (*User, error) {
-	query := `SELECT id, email, password, created_at, balance, email_verified, verification_token, verification_token_expires, google_id, created_via
-	FROM users WHERE google_id = $1`
-
-	var user User
-	var password, verificationToken, googleIDVal sql.NullString
-	var verificationTokenExpires sql.NullTime
-
-	err := us.db.QueryRowContext(ctx, query, googleID).Scan(
-		&user.ID, &user.Email, &password,
-		&user.CreatedAt, &user.Balance, &user.EmailVerified,
-		&verificationToken, &verificationTokenExpires, &googleIDVal, &user.CreatedVia,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-
-	if password.Valid {
-		user.Password = password.String
-	}
-	if verificationToken.Valid {
-		user.VerificationToken = &verificationToken.String
-	}
-	if verificationTokenExpires.Valid {
-		user.VerificationTokenExpires = &verificationTokenExpires.Time
-	}
-	if googleIDVal.Valid {
-		user.GoogleID = &googleIDVal.String
-	}
-
-	return &user, nil
+	query := `SELECT ` + userColumns + ` FROM ` + userTables + ` WHERE google_id = $1`
+	return scanUser(us.db.QueryRowContext(ctx, query, googleID))
 }
 
 func (us *UserStore) UpdateVerificationToken(ctx context.Context, userID string, token string, expiresAt time.Time) error {
@@ -193,55 +350,36 @@ func (us *UserStore) LinkGoogleAccount(ctx context.Context, userID string, googl
 }
 
 func (us *UserStore) GetUserByEmail(ctx context.Context, email string) (*User, error) {
-	query := `SELECT id, email, password, created_at, balance, email_verified, verification_token, verification_token_expires, google_id, created_via FROM users WHERE email = $1`
-
-	var user User
-	var password, verificationToken, googleID sql.NullString
-	var verificationTokenExpires sql.NullTime
-
-	email = normalizeEmail(email)
-	err := us.db.QueryRowContext(ctx, query, email).Scan(
-		&user.ID, &user.Email, &password,
-		&user.CreatedAt, &user.Balance, &user.EmailVerified,
-		&verificationToken, &verificationTokenExpires, &googleID, &user.CreatedVia,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
-	}
-
-	if password.Valid {
-		user.Password = password.String
-	}
-	if verificationToken.Valid {
-		user.VerificationToken = &verificationToken.String
-	}
-	if verificationTokenExpires.Valid {
-		user.VerificationTokenExpires = &verificationTokenExpires.Time
-	}
-	if googleID.Valid {
-		user.GoogleID = &googleID.String
-	}
-
-	return &user, nil
+	query := `SELECT ` + userColumns + ` FROM ` + userTables + ` WHERE email = $1`
+	return scanUser(us.db.QueryRowContext(ctx, query, normalizeEmail(email)))
 }
 
 func (us *UserStore) GetUserByID(ctx context.Context, id string) (*User, error) {
-	query := `SELECT id, email, password, created_at, balance, email_verified, verification_token, verification_token_expires, google_id, created_via FROM users WHERE id = $1`
+	query := `SELECT ` + userColumns + ` FROM ` + userTables + ` WHERE id = $1`
+	return scanUser(us.db.QueryRowContext(ctx, query, id))
+}
+
+// userColumns is the column list scanUser expects, in order, selected from
+// userTables. Guests have no email, so it is read as the empty string; the
+// settings of a user without a user_settings row read as their defaults.
+const userColumns = `id, COALESCE(email, ''), password, created_at, balance, email_verified, verification_token, verification_token_expires, google_id, created_via, avatar_url, username, is_guest, guest_expires_at, ` +
+	userSettingDisplayCurrency + `, ` + userSettingAfterHoursOrders + `, league, ` + userSettingCostBasisMethod + `, ` + userSettingTradeConfirmationEmails + `, ` + userSettingTimezone + `, role`
+
+// userTables joins each user to their settings row, if any.
+const userTables = `users LEFT JOIN user_settings s ON s.user_id = users.id`
 
+func scanUser(row *sql.Row) (*User, error) {
 	var user User
-	var password, verificationToken, googleID sql.NullString
-	var verificationTokenExpires sql.NullTime
+	var password, verificationToken, googleID, avatarURL, username, league sql.NullString
+	var verificationTokenExpires, guestExpiresAt sql.NullTime
 
-	err := us.db.QueryRowContext(ctx, query, id).Scan(
+	err := row.Scan(
 		&user.ID, &user.Email, &password,
 		&user.CreatedAt, &user.Balance, &user.EmailVerified,
-		&verificationToken, &verificationTokenExpires, &googleID, &user.CreatedVia,
+		&verificationToken, &verificationTokenExpires, &googleID, &user.CreatedVia, &avatarURL, &username,
+		&user.IsGuest, &guestExpiresAt, &user.DisplayCurrency, &user.AfterHoursOrders, &league, &user.CostBasisMethod,
+		&user.TradeConfirmationEmails, &user.Timezone, &user.Role,
 	)
-
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("user not found")
@@ -261,6 +399,12 @@ func (us *UserStore) GetUserByID(ctx context.Context, id string) (*User, error)
 	if googleID.Valid {
 		user.GoogleID = &googleID.String
 	}
+	if guestExpiresAt.Valid {
+		user.GuestExpiresAt = &guestExpiresAt.Time
+	}
+	user.AvatarURL = avatarURL.String
+	user.Username = username.String
+	user.League = league.String
 
 	return &user, nil
 }
@@ -273,6 +417,38 @@ func (us *UserStore) ValidatePassword(user *User, password string) bool {
 	return err == nil
 }
 
+// SetPassword replaces the user's password.
+func (us *UserStore) SetPassword(ctx context.Context, userID, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return fmt.Errorf("error hashing password: %w", err)
+	}
+	_, err = us.db.ExecContext(ctx, `UPDATE users SET password = $2 WHERE id = $1`, userID, string(hashedPassword))
+	return err
+}
+
+// ChangeEmail moves the user to a new, unverified email address and returns
+// its verification token. Returns ErrEmailTaken if another account has it.
+func (us *UserStore) ChangeEmail(ctx context.Context, userID, email string) (string, error) {
+	verificationToken := uuid.New().String()
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	query := `
+	UPDATE users
+	SET email = $2, email_verified = FALSE, verification_token = $3, verification_token_expires = $4
+	WHERE id = $1`
+
+	_, err := us.db.ExecContext(ctx, query, userID, normalizeEmail(email), verificationToken, expiresAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "users_email_key" {
+			return "", ErrEmailTaken
+		}
+		return "", err
+	}
+	return verificationToken, nil
+}
+
 func (us *UserStore) UpdateBalance(ctx context.Context, userID string, newBalance decimal.Decimal) error {
 	query := `UPDATE users SET balance = $1 WHERE id = $2`
 	_, err := us.db.ExecContext(ctx, query, newBalance, userID)
@@ -289,3 +465,496 @@ func (us *UserStore) GetBalance(ctx context.Context, userID string) (decimal.Dec
 func normalizeEmail(s string) string {
 	return strings.ToLower(strings.TrimSpace(s))
 }
+
+// GrantAdmin gives the admin role to the accounts with these emails and
+// returns how many were promoted. Only verified emails count, so nobody
+// becomes an admin by signing up with a listed address they don't own. It
+// never demotes anyone.
+func (us *UserStore) GrantAdmin(ctx context.Context, emails []string) (int64, error) {
+	normalized := make([]string, len(emails))
+	for i, email := range emails {
+		normalized[i] = normalizeEmail(email)
+	}
+	result, err := us.db.ExecContext(ctx,
+		`UPDATE users SET role = $1 WHERE email = ANY($2) AND email_verified AND role <> $1`, RoleAdmin, pq.Array(normalized))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetRole returns userID's role, or sql.ErrNoRows when the user does not
+// exist.
+func (us *UserStore) GetRole(ctx context.Context, userID string) (string, error) {
+	var role string
+	err := us.db.QueryRowContext(ctx, `SELECT role FROM users WHERE id = $1`, userID).Scan(&role)
+	return role, err
+}
+
+// RequireReauth marks every session authenticated before at as stale for
+// sensitive actions. A later call only ever moves the cutoff forward.
+func (us *UserStore) RequireReauth(ctx context.Context, userID string, at time.Time) error {
+	query := `
+	UPDATE users SET reauth_required_after = GREATEST(COALESCE(reauth_required_after, $1), $1)
+	WHERE id = $2`
+	_, err := us.db.ExecContext(ctx, query, at.UTC(), userID)
+	return err
+}
+
+// GetReauthRequiredAfter returns the re-authentication cutoff set by
+// RequireReauth, or nil when none is pending.
+func (us *UserStore) GetReauthRequiredAfter(ctx context.Context, userID string) (*time.Time, error) {
+	var at sql.NullTime
+	err := us.db.QueryRowContext(ctx, `SELECT reauth_required_after FROM users WHERE id = $1`, userID).Scan(&at)
+	if err != nil {
+		return nil, err
+	}
+	if !at.Valid {
+		return nil, nil
+	}
+	return &at.Time, nil
+}
+
+// SetAvatar records a new avatar for the user and returns the storage key of
+// the one it replaced ("" when there was none) so the caller can delete it.
+// Empty key and url clear the avatar.
+func (us *UserStore) SetAvatar(ctx context.Context, userID, key, url string) (string, error) {
+	query := `
+	UPDATE users u SET avatar_key = NULLIF($2, ''), avatar_url = NULLIF($3, '')
+	FROM (SELECT id, avatar_key FROM users WHERE id = $1 FOR UPDATE) old
+	WHERE u.id = old.id
+	RETURNING COALESCE(old.avatar_key, '')`
+
+	var previous string
+	var err error
+	if sqliteStorage.Load() {
+		// SQLite's RETURNING sees only the updated row.
+		err = inTx(ctx, us.db, func(db DBTX) error {
+			if err := db.QueryRowContext(ctx,
+				`SELECT COALESCE(avatar_key, '') FROM users WHERE id = $1`, userID).Scan(&previous); err != nil {
+				return err
+			}
+			_, err := db.ExecContext(ctx,
+				`UPDATE users SET avatar_key = NULLIF($2, ''), avatar_url = NULLIF($3, '') WHERE id = $1`, userID, key, url)
+			return err
+		})
+	} else {
+		err = us.db.QueryRowContext(ctx, query, userID, key, url).Scan(&previous)
+	}
+	if err == sql.ErrNoRows {
+		return "", errors.New("user not found")
+	}
+	return previous, err
+}
+
+// SetUsername sets the user's username. Picking the first one (or re-setting
+// the current one) is always allowed and does not start the cooldown;
+// replacing an existing one is refused with ErrUsernameCooldown when the
+// previous change was after notBefore, in which case the time of that change
+// is returned. A case-insensitive collision with another user yields
+// ErrUsernameTaken.
+func (us *UserStore) SetUsername(ctx context.Context, userID, username string, notBefore, now time.Time) (time.Time, error) {
+	query := `
+	UPDATE users u SET username = $2,
+		username_changed_at = CASE WHEN old.username IS NULL OR old.username = $2 THEN old.username_changed_at ELSE $4 END
+	FROM (SELECT id, username, username_changed_at FROM users WHERE id = $1 FOR UPDATE) old
+	WHERE u.id = old.id
+	AND (old.username IS NULL OR old.username = $2 OR old.username_changed_at IS NULL OR old.username_changed_at <= $3)
+	RETURNING u.id`
+	if sqliteStorage.Load() {
+		// SQLite's RETURNING can't name the alias; the SET and WHERE see the
+		// old row anyway.
+		query = `
+	UPDATE users SET username = $2,
+		username_changed_at = CASE WHEN username IS NULL OR username = $2 THEN username_changed_at ELSE $4 END
+	WHERE id = $1
+	AND (username IS NULL OR username = $2 OR username_changed_at IS NULL OR username_changed_at <= $3)
+	RETURNING id`
+	}
+
+	var id string
+	err := us.db.QueryRowContext(ctx, query, userID, username, notBefore.UTC(), now.UTC()).Scan(&id)
+	if err == nil {
+		return time.Time{}, nil
+	}
+	if isUsernameConflict(err) {
+		return time.Time{}, ErrUsernameTaken
+	}
+	if err != sql.ErrNoRows {
+		return time.Time{}, err
+	}
+
+	// No row updated: either the user is gone or the cooldown applies.
+	var changedAt sql.NullTime
+	err = us.db.QueryRowContext(ctx, `SELECT username_changed_at FROM users WHERE id = $1`, userID).Scan(&changedAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, errors.New("user not found")
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return changedAt.Time, ErrUsernameCooldown
+}
+
+// UsernameExists reports whether any user holds username, ignoring case.
+func (us *UserStore) UsernameExists(ctx context.Context, username string) (bool, error) {
+	var exists bool
+	err := us.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(username) = LOWER($1))`, username).Scan(&exists)
+	return exists, err
+}
+
+func isUsernameConflict(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_users_username_lower"
+}
+
+// SetMagicLinkToken records jti as the user's only valid magic link,
+// replacing any earlier one.
+func (us *UserStore) SetMagicLinkToken(ctx context.Context, userID, jti string, expiresAt time.Time) error {
+	query := `UPDATE users SET magic_link_jti = $1, magic_link_expires = $2 WHERE id = $3`
+	_, err := us.db.ExecContext(ctx, query, jti, expiresAt.UTC(), userID)
+	return err
+}
+
+// ConsumeMagicLinkToken clears the user's magic link if it matches jti and
+// has not expired. Following the link proves control of the inbox, so the
+// email is marked verified too. Returns false when the link was already
+// used, superseded or expired.
+func (us *UserStore) ConsumeMagicLinkToken(ctx context.Context, userID, jti string) (bool, error) {
+	query := `
+	UPDATE users
+	SET magic_link_jti = NULL, magic_link_expires = NULL, email_verified = TRUE,
+		verification_token = NULL, verification_token_expires = NULL
+	WHERE id = $1
+	AND magic_link_jti = $2
+	AND magic_link_expires > CURRENT_TIMESTAMP`
+
+	result, err := us.db.ExecContext(ctx, query, userID, jti)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected == 1, nil
+}
+
+// CreateGuestUser creates an account with no email or credentials that
+// expires at expiresAt unless upgraded. cohort may be nil.
+func (us *UserStore) CreateGuestUser(ctx context.Context, expiresAt time.Time, cohort *Cohort) (*User, error) {
+	userID := uuid.New().String()
+	balance, league := us.opening(cohort)
+
+	query := `
+	INSERT INTO users (id, email, password, created_at, balance, starting_balance, email_verified, created_via, is_guest, guest_expires_at, league)
+	VALUES ($1, NULL, NULL, CURRENT_TIMESTAMP, $3, $3, FALSE, 'guest', TRUE, $2, NULLIF($4, ''))`
+
+	_, err := us.db.ExecContext(ctx, query, userID, expiresAt.UTC(), balance, league)
+	if err != nil {
+		return nil, fmt.Errorf("error creating guest: %w", err)
+	}
+
+	return us.GetUserByID(ctx, userID)
+}
+
+// UpgradeGuest turns a guest into an email/password account in place, so its
+// trades and holdings are kept. Returns the new email verification token.
+func (us *UserStore) UpgradeGuest(ctx context.Context, userID, email, password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 12)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	verificationToken := uuid.New().String()
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	query := `
+	UPDATE users
+	SET email = $2, password = $3, verification_token = $4, verification_token_expires = $5,
+		is_guest = FALSE, guest_expires_at = NULL
+	WHERE id = $1 AND is_guest`
+
+	err = us.upgradeGuest(ctx, query, userID, normalizeEmail(email), string(hashedPassword), verificationToken, expiresAt)
+	return verificationToken, err
+}
+
+// UpgradeGuestWithGoogle turns a guest into a Google account in place. Google
+// has verified the email, so it is marked verified.
+func (us *UserStore) UpgradeGuestWithGoogle(ctx context.Context, userID, email, googleID string) error {
+	query := `
+	UPDATE users
+	SET email = $2, google_id = $3, email_verified = TRUE, is_guest = FALSE, guest_expires_at = NULL
+	WHERE id = $1 AND is_guest`
+
+	return us.upgradeGuest(ctx, query, userID, normalizeEmail(email), googleID)
+}
+
+func (us *UserStore) upgradeGuest(ctx context.Context, query string, args ...any) error {
+	result, err := us.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			switch pqErr.Constraint {
+			case "users_email_key":
+				return ErrEmailTaken
+			case "users_google_id_key":
+				return ErrGoogleIDTaken
+			}
+		}
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotGuest
+	}
+	return nil
+}
+
+// DeleteExpiredGuests removes up to limit guests whose expiry is before now,
+// together with their trades, holdings and watchlist, in one statement.
+// Research queries are kept for evaluation with the user detached.
+// Notifications and audit events cascade.
+//
+// trades is append-only; the delete is let through by setting
+// papertrader.purging_users for this transaction only. When the store is not
+// already inside a transaction it opens one, since the setting would
+// otherwise end with its own statement.
+func (us *UserStore) DeleteExpiredGuests(ctx context.Context, now time.Time, limit int) (int64, error) {
+	beginner, ok := us.db.(txBeginner)
+	if !ok {
+		return deleteExpiredGuests(ctx, us.db, now, limit)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	n, err := deleteExpiredGuests(ctx, tx, now, limit)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func deleteExpiredGuests(ctx context.Context, db DBTX, now time.Time, limit int) (int64, error) {
+	if _, err := db.ExecContext(ctx, `SELECT set_config('papertrader.purging_users', 'on', true)`); err != nil {
+		return 0, err
+	}
+
+	query := `
+	WITH expired AS (
+		SELECT id FROM users WHERE is_guest AND guest_expires_at <= $1 LIMIT $2
+	), w AS (
+		DELETE FROM watchlist WHERE user_id IN (SELECT id FROM expired)
+	), p AS (
+		DELETE FROM portfolio WHERE user_id IN (SELECT id FROM expired)
+	), t AS (
+		DELETE FROM trades WHERE user_id IN (SELECT id FROM expired)
+	), r AS (
+		UPDATE research_queries SET user_id = NULL WHERE user_id IN (SELECT id FROM expired)
+	)
+	DELETE FROM users WHERE id IN (SELECT id FROM expired)`
+
+	if sqliteStorage.Load() {
+		return deleteExpiredGuestsSQLite(ctx, db, now, limit)
+	}
+	result, err := db.ExecContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// deleteExpiredGuestsSQLite is deleteExpiredGuests as one statement per
+// table, for SQLite.
+func deleteExpiredGuestsSQLite(ctx context.Context, db DBTX, now time.Time, limit int) (int64, error) {
+	rows, err := db.QueryContext(ctx,
+		`SELECT id FROM users WHERE is_guest AND guest_expires_at <= $1 LIMIT $2`, now.UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	result, err := execAll(ctx, db, []string{
+		`DELETE FROM watchlist WHERE user_id = ANY($1)`,
+		`DELETE FROM portfolio WHERE user_id = ANY($1)`,
+		`DELETE FROM trades WHERE user_id = ANY($1)`,
+		`UPDATE research_queries SET user_id = NULL WHERE user_id = ANY($1)`,
+		`DELETE FROM users WHERE id = ANY($1)`,
+	}, pq.Array(ids))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ResetAccount returns userID's paper account to its opening state: trades,
+// holdings, tax lots, orders, trade notes, portfolio history, the
+// investment goals measured from it and the statements built from it are
+// removed, recurring plans paused and the balance set back to the starting
+// balance, all in one transaction. The user row is locked first so a trade
+// in flight either finishes before the reset or sees the reset account.
+// Settings, watchlist and the plans themselves are kept. Returns
+// sql.ErrNoRows when the user does not exist.
+//
+// trades is append-only; the delete is let through the same way as
+// DeleteExpiredGuests.
+func (us *UserStore) ResetAccount(ctx context.Context, userID string) error {
+	beginner, ok := us.db.(txBeginner)
+	if !ok {
+		return resetAccount(ctx, us.db, userID)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := resetAccount(ctx, tx, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func resetAccount(ctx context.Context, db DBTX, userID string) error {
+	var id string
+	if err := db.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, `SELECT set_config('papertrader.purging_users', 'on', true)`); err != nil {
+		return err
+	}
+
+	query := `
+	WITH n AS (
+		DELETE FROM trade_notes WHERE user_id = $1
+	), d AS (
+		DELETE FROM lot_disposals WHERE user_id = $1
+	), l AS (
+		DELETE FROM tax_lots WHERE user_id = $1
+	), o AS (
+		DELETE FROM orders WHERE user_id = $1
+	), p AS (
+		DELETE FROM portfolio WHERE user_id = $1
+	), h AS (
+		DELETE FROM portfolio_history WHERE user_id = $1
+	), g AS (
+		DELETE FROM investment_goals WHERE user_id = $1
+	), s AS (
+		DELETE FROM account_statements WHERE user_id = $1
+	), r AS (
+		DELETE FROM statement_reports WHERE user_id = $1
+	), ri AS (
+		UPDATE recurring_investments SET active = FALSE WHERE user_id = $1 AND active
+	), t AS (
+		DELETE FROM trades WHERE user_id = $1
+	)
+	UPDATE users SET balance = starting_balance WHERE id = $1`
+
+	if sqliteStorage.Load() {
+		_, err := execAll(ctx, db, []string{
+			`DELETE FROM trade_notes WHERE user_id = $1`,
+			`DELETE FROM lot_disposals WHERE user_id = $1`,
+			`DELETE FROM tax_lots WHERE user_id = $1`,
+			`DELETE FROM orders WHERE user_id = $1`,
+			`DELETE FROM portfolio WHERE user_id = $1`,
+			`DELETE FROM portfolio_history WHERE user_id = $1`,
+			`DELETE FROM investment_goals WHERE user_id = $1`,
+			`DELETE FROM account_statements WHERE user_id = $1`,
+			`DELETE FROM statement_reports WHERE user_id = $1`,
+			`UPDATE recurring_investments SET active = FALSE WHERE user_id = $1 AND active`,
+			`DELETE FROM trades WHERE user_id = $1`,
+			`UPDATE users SET balance = starting_balance WHERE id = $1`,
+		}, userID)
+		return err
+	}
+	_, err := db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// DeletedAccount is what DeleteAccount leaves the caller to clean up outside
+// the database.
+type DeletedAccount struct {
+	Email     string // empty for a guest or an email-less account
+	AvatarKey string // object storage key of the avatar, if any
+}
+
+// DeleteAccount removes userID at the user's request, in one transaction:
+// trades, holdings and watchlist are deleted, research queries detached,
+// and the user row deleted, which cascades to everything else keyed by it
+// (orders, tax lots, notes, notifications, settings, passkeys, history,
+// statements, goals). The row is locked first so a trade in flight either
+// finishes before the deletion or fails against a missing account.
+// Returns sql.ErrNoRows when the user does not exist.
+//
+// trades is append-only; the delete is let through the same way as
+// DeleteExpiredGuests.
+func (us *UserStore) DeleteAccount(ctx context.Context, userID string) (*DeletedAccount, error) {
+	beginner, ok := us.db.(txBeginner)
+	if !ok {
+		return deleteAccount(ctx, us.db, userID)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	deleted, err := deleteAccount(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+func deleteAccount(ctx context.Context, db DBTX, userID string) (*DeletedAccount, error) {
+	var email, avatarKey sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT email, avatar_key FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&email, &avatarKey)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.ExecContext(ctx, `SELECT set_config('papertrader.purging_users', 'on', true)`); err != nil {
+		return nil, err
+	}
+
+	query := `
+	WITH w AS (
+		DELETE FROM watchlist WHERE user_id = $1
+	), p AS (
+		DELETE FROM portfolio WHERE user_id = $1
+	), t AS (
+		DELETE FROM trades WHERE user_id = $1
+	), r AS (
+		UPDATE research_queries SET user_id = NULL WHERE user_id = $1
+	)
+	DELETE FROM users WHERE id = $1`
+
+	if sqliteStorage.Load() {
+		_, err = execAll(ctx, db, []string{
+			`DELETE FROM watchlist WHERE user_id = $1`,
+			`DELETE FROM portfolio WHERE user_id = $1`,
+			`DELETE FROM trades WHERE user_id = $1`,
+			`UPDATE research_queries SET user_id = NULL WHERE user_id = $1`,
+			`DELETE FROM users WHERE id = $1`,
+		}, userID)
+	} else {
+		_, err = db.ExecContext(ctx, query, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &DeletedAccount{Email: email.String, AvatarKey: avatarKey.String}, nil
+}
@@ -0,0 +1,106 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrNegativeBalance is returned by AdjustBalance when the adjustment would
+// take the balance below zero.
+var ErrNegativeBalance = errors.New("balance would go negative")
+
+// AdminUser is a user as the admin user search shows it: the export stats
+// plus the state of the account.
+type AdminUser struct {
+	UserStats
+	Role             string     `json:"role"`
+	EmailVerified    bool       `json:"email_verified"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+	AdminLockedUntil *time.Time `json:"admin_locked_until,omitempty"`
+}
+
+const adminUserColumns = userStatsColumns + `, u.role, u.email_verified, u.locked_until, u.admin_locked_until`
+
+func scanAdminUser(row rowScanner) (*AdminUser, error) {
+	var u AdminUser
+	var lockedUntil, adminLockedUntil sql.NullTime
+	if err := scanUserStats(row, &u.UserStats, &u.Role, &u.EmailVerified, &lockedUntil, &adminLockedUntil); err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		u.LockedUntil = &lockedUntil.Time
+	}
+	if adminLockedUntil.Valid {
+		u.AdminLockedUntil = &adminLockedUntil.Time
+	}
+	return &u, nil
+}
+
+// SearchUsers returns up to limit non-guest users, newest first: those whose
+// ID is query or whose email or username contains it, ignoring case, or
+// every user when query is empty.
+func (us *UserStore) SearchUsers(ctx context.Context, query string, limit int) ([]AdminUser, error) {
+	q := `SELECT ` + adminUserColumns + ` FROM ` + userStatsTables + `
+	WHERE NOT u.is_guest
+	  AND ($1 = '' OR u.id = $1
+	       OR strpos(LOWER(COALESCE(u.email, '')), $2) > 0
+	       OR strpos(LOWER(COALESCE(u.username, '')), $2) > 0)
+	ORDER BY u.created_at DESC, u.id
+	LIMIT $3`
+
+	rows, err := us.db.QueryContext(ctx, q, query, strings.ToLower(query), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]AdminUser, 0)
+	for rows.Next() {
+		u, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetAdminUser returns the non-guest user userID. sql.ErrNoRows means there
+// is no such user.
+func (us *UserStore) GetAdminUser(ctx context.Context, userID string) (*AdminUser, error) {
+	q := `SELECT ` + adminUserColumns + ` FROM ` + userStatsTables + ` WHERE u.id = $1 AND NOT u.is_guest`
+	return scanAdminUser(us.db.QueryRowContext(ctx, q, userID))
+}
+
+// AdjustBalance adds delta, which may be negative, to userID's balance and
+// returns the new balance. It is refused with ErrNegativeBalance when the
+// balance would go below zero; the check and the update are one statement.
+// sql.ErrNoRows means there is no such user.
+func (us *UserStore) AdjustBalance(ctx context.Context, userID string, delta decimal.Decimal) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := us.db.QueryRowContext(ctx, `
+	UPDATE users SET balance = balance + $2
+	WHERE id = $1 AND balance + $2 >= 0
+	RETURNING balance`, userID, delta).Scan(&balance)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return balance, err
+	}
+
+	// No row updated: either the user is gone or the balance is too low.
+	var exists bool
+	if err := us.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		return decimal.Zero, err
+	}
+	if !exists {
+		return decimal.Zero, sql.ErrNoRows
+	}
+	return decimal.Zero, ErrNegativeBalance
+}
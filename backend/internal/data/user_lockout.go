@@ -0,0 +1,75 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// RecordFailedLogin counts a wrong password for userID and returns its
+// consecutive failures so far.
+func (us *UserStore) RecordFailedLogin(ctx context.Context, userID string) (int, error) {
+	var failures int
+	err := us.db.QueryRowContext(ctx,
+		`UPDATE users SET failed_logins = failed_logins + 1 WHERE id = $1 RETURNING failed_logins`, userID).Scan(&failures)
+	return failures, err
+}
+
+// LockAccount refuses password logins for userID until until.
+func (us *UserStore) LockAccount(ctx context.Context, userID string, until time.Time) error {
+	_, err := us.db.ExecContext(ctx, `UPDATE users SET locked_until = $1 WHERE id = $2`, until.UTC(), userID)
+	return err
+}
+
+// GetLockedUntil returns when userID's lock ends, or nil when it was never
+// locked or has since logged in. The time may be in the past.
+func (us *UserStore) GetLockedUntil(ctx context.Context, userID string) (*time.Time, error) {
+	var until sql.NullTime
+	err := us.db.QueryRowContext(ctx, `SELECT locked_until FROM users WHERE id = $1`, userID).Scan(&until)
+	if err != nil {
+		return nil, err
+	}
+	if !until.Valid {
+		return nil, nil
+	}
+	return &until.Time, nil
+}
+
+// ClearFailedLogins resets userID's failure count and lifts the lock the
+// failures set; an admin lock is left in place. Rows with nothing to clear
+// are left alone, so a routine login writes nothing.
+func (us *UserStore) ClearFailedLogins(ctx context.Context, userID string) error {
+	_, err := us.db.ExecContext(ctx, `
+	UPDATE users SET failed_logins = 0, locked_until = NULL
+	WHERE id = $1 AND (failed_logins > 0 OR locked_until IS NOT NULL)`, userID)
+	return err
+}
+
+// AdminLockAccount refuses every login and session for userID until until.
+// It is kept apart from the failed-login lock, so only ClearAdminLock lifts
+// it.
+func (us *UserStore) AdminLockAccount(ctx context.Context, userID string, until time.Time) error {
+	_, err := us.db.ExecContext(ctx, `UPDATE users SET admin_locked_until = $1 WHERE id = $2`, until.UTC(), userID)
+	return err
+}
+
+// GetAdminLockedUntil returns when userID's admin lock ends, or nil when it
+// has none. The time may be in the past.
+func (us *UserStore) GetAdminLockedUntil(ctx context.Context, userID string) (*time.Time, error) {
+	var until sql.NullTime
+	err := us.db.QueryRowContext(ctx, `SELECT admin_locked_until FROM users WHERE id = $1`, userID).Scan(&until)
+	if err != nil {
+		return nil, err
+	}
+	if !until.Valid {
+		return nil, nil
+	}
+	return &until.Time, nil
+}
+
+// ClearAdminLock lifts userID's admin lock.
+func (us *UserStore) ClearAdminLock(ctx context.Context, userID string) error {
+	_, err := us.db.ExecContext(ctx,
+		`UPDATE users SET admin_locked_until = NULL WHERE id = $1 AND admin_locked_until IS NOT NULL`, userID)
+	return err
+}
@@ -0,0 +1,248 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// InactiveUser is an account claimed for an inactivity warning.
+type InactiveUser struct {
+	ID          string
+	Email       string
+	LastLoginAt time.Time
+}
+
+// RetentionCounts summarises where accounts stand in the retention process.
+type RetentionCounts struct {
+	Inactive   int `json:"inactive"`   // past the threshold, not yet warned
+	Warned     int `json:"warned"`     // warned, awaiting a login or anonymization
+	Anonymized int `json:"anonymized"` // anonymized, ever
+}
+
+// RecordLogin moves userID's last login to now and withdraws any pending
+// inactivity warning.
+func (us *UserStore) RecordLogin(ctx context.Context, userID string) error {
+	_, err := us.db.ExecContext(ctx,
+		`UPDATE users SET last_login_at = CURRENT_TIMESTAMP, inactivity_notice_sent_at = NULL WHERE id = $1`, userID)
+	return err
+}
+
+// ClaimInactive marks up to limit full accounts whose last login is before
+// cutoff as warned at now and returns them, skipping anonymized accounts,
+// accounts already warned and those in exclude (by email). Rows locked by
+// another instance's claim are skipped, so each account is warned once.
+func (us *UserStore) ClaimInactive(ctx context.Context, cutoff, now time.Time, limit int, exclude []string) ([]InactiveUser, error) {
+	query := `
+	WITH due AS (
+		SELECT id FROM users
+		WHERE NOT is_guest AND anonymized_at IS NULL AND inactivity_notice_sent_at IS NULL
+		  AND email IS NOT NULL AND last_login_at < $1 AND NOT (LOWER(email) = ANY($4))
+		ORDER BY last_login_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE users u SET inactivity_notice_sent_at = $2
+	FROM due WHERE u.id = due.id
+	RETURNING u.id, u.email, u.last_login_at`
+	if sqliteStorage.Load() {
+		// SQLite has one writer at a time, so there are no locked rows to
+		// skip.
+		query = `
+	UPDATE users SET inactivity_notice_sent_at = $2
+	WHERE id IN (
+		SELECT id FROM users
+		WHERE NOT is_guest AND anonymized_at IS NULL AND inactivity_notice_sent_at IS NULL
+		  AND email IS NOT NULL AND last_login_at < $1 AND NOT (LOWER(email) = ANY($4))
+		ORDER BY last_login_at
+		LIMIT $3
+	)
+	RETURNING id, email, last_login_at`
+	}
+
+	rows, err := us.db.QueryContext(ctx, query, cutoff.UTC(), now.UTC(), limit, pq.Array(exclude))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]InactiveUser, 0)
+	for rows.Next() {
+		var u InactiveUser
+		if err := rows.Scan(&u.ID, &u.Email, &u.LastLoginAt); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// ReleaseInactivityNotice withdraws a warning claimed by ClaimInactive whose
+// email could not be sent, so the next pass tries again.
+func (us *UserStore) ReleaseInactivityNotice(ctx context.Context, userID string) error {
+	_, err := us.db.ExecContext(ctx, `UPDATE users SET inactivity_notice_sent_at = NULL WHERE id = $1 AND anonymized_at IS NULL`, userID)
+	return err
+}
+
+// AnonymizeWarned scrubs the personal data of up to limit accounts warned
+// before cutoff that have not logged in since, and returns the object
+// storage keys of their avatars for the caller to delete.
+//
+// The email, credentials, username and avatar are cleared, and passkeys,
+// trade notes, notifications, audit events, settings, watchlists and
+// recurring plans deleted; pending orders are cancelled and research queries
+// detached. Trades, holdings, tax lots, portfolio history and statements
+// are kept, so aggregate statistics are unchanged. Each row is locked, so a
+// login racing the pass either lands first, withdrawing the warning, or
+// finds the account anonymized.
+func (us *UserStore) AnonymizeWarned(ctx context.Context, cutoff, now time.Time, limit int) (int, []string, error) {
+	beginner, ok := us.db.(txBeginner)
+	if !ok {
+		return anonymizeWarned(ctx, us.db, cutoff, now, limit)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	n, keys, err := anonymizeWarned(ctx, tx, cutoff, now, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, keys, tx.Commit()
+}
+
+func anonymizeWarned(ctx context.Context, db DBTX, cutoff, now time.Time, limit int) (int, []string, error) {
+	query := `
+	WITH due AS (
+		SELECT id, avatar_key FROM users
+		WHERE anonymized_at IS NULL AND inactivity_notice_sent_at <= $1
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	), c AS (
+		DELETE FROM webauthn_credentials WHERE user_id IN (SELECT id FROM due)
+	), n AS (
+		DELETE FROM trade_notes WHERE user_id IN (SELECT id FROM due)
+	), nt AS (
+		DELETE FROM notifications WHERE user_id IN (SELECT id FROM due)
+	), a AS (
+		DELETE FROM audit_events WHERE user_id IN (SELECT id FROM due)
+	), s AS (
+		DELETE FROM user_settings WHERE user_id IN (SELECT id FROM due)
+	), w AS (
+		DELETE FROM watchlist WHERE user_id IN (SELECT id FROM due)
+	), cs AS (
+		DELETE FROM curated_list_subscriptions WHERE user_id IN (SELECT id FROM due)
+	), ri AS (
+		DELETE FROM recurring_investments WHERE user_id IN (SELECT id FROM due)
+	), o AS (
+		UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+		WHERE user_id IN (SELECT id FROM due) AND status IN ('PENDING', 'WAITING')
+	), r AS (
+		UPDATE research_queries SET user_id = NULL WHERE user_id IN (SELECT id FROM due)
+	)
+	UPDATE users u
+	SET email = NULL, password = NULL, google_id = NULL, username = NULL,
+	    avatar_key = NULL, avatar_url = NULL, email_verified = FALSE,
+	    verification_token = NULL, verification_token_expires = NULL,
+	    magic_link_jti = NULL, magic_link_expires = NULL,
+	    anonymized_at = $2
+	FROM due WHERE u.id = due.id
+	RETURNING due.avatar_key`
+
+	if sqliteStorage.Load() {
+		return anonymizeWarnedSQLite(ctx, db, cutoff, now, limit)
+	}
+	rows, err := db.QueryContext(ctx, query, cutoff.UTC(), now.UTC(), limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	n := 0
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key sql.NullString
+		if err := rows.Scan(&key); err != nil {
+			return 0, nil, err
+		}
+		n++
+		if key.Valid && key.String != "" {
+			keys = append(keys, key.String)
+		}
+	}
+	return n, keys, rows.Err()
+}
+
+// anonymizeWarnedSQLite is anonymizeWarned as one statement per table, for
+// SQLite.
+func anonymizeWarnedSQLite(ctx context.Context, db DBTX, cutoff, now time.Time, limit int) (int, []string, error) {
+	rows, err := db.QueryContext(ctx, `
+	SELECT id, avatar_key FROM users
+	WHERE anonymized_at IS NULL AND inactivity_notice_sent_at <= $1
+	LIMIT $2`, cutoff.UTC(), limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+	ids := make([]string, 0)
+	keys := make([]string, 0)
+	for rows.Next() {
+		var id string
+		var key sql.NullString
+		if err := rows.Scan(&id, &key); err != nil {
+			return 0, nil, err
+		}
+		ids = append(ids, id)
+		if key.Valid && key.String != "" {
+			keys = append(keys, key.String)
+		}
+	}
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return 0, keys, err
+	}
+
+	_, err = execAll(ctx, db, []string{
+		`DELETE FROM webauthn_credentials WHERE user_id = ANY($1)`,
+		`DELETE FROM trade_notes WHERE user_id = ANY($1)`,
+		`DELETE FROM notifications WHERE user_id = ANY($1)`,
+		`DELETE FROM audit_events WHERE user_id = ANY($1)`,
+		`DELETE FROM user_settings WHERE user_id = ANY($1)`,
+		`DELETE FROM watchlist WHERE user_id = ANY($1)`,
+		`DELETE FROM curated_list_subscriptions WHERE user_id = ANY($1)`,
+		`DELETE FROM recurring_investments WHERE user_id = ANY($1)`,
+		`UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP
+		WHERE user_id = ANY($1) AND status IN ('PENDING', 'WAITING')`,
+		`UPDATE research_queries SET user_id = NULL WHERE user_id = ANY($1)`,
+		`UPDATE users
+		SET email = NULL, password = NULL, google_id = NULL, username = NULL,
+		    avatar_key = NULL, avatar_url = NULL, email_verified = FALSE,
+		    verification_token = NULL, verification_token_expires = NULL,
+		    magic_link_jti = NULL, magic_link_expires = NULL,
+		    anonymized_at = $2
+		WHERE id = ANY($1)`,
+	}, pq.Array(ids), now.UTC())
+	if err != nil {
+		return 0, nil, err
+	}
+	return len(ids), keys, nil
+}
+
+// RetentionCounts counts full accounts inactive since before cutoff and not
+// yet warned, accounts warned, and accounts anonymized.
+func (us *UserStore) RetentionCounts(ctx context.Context, cutoff time.Time) (*RetentionCounts, error) {
+	query := `
+	SELECT
+		COUNT(*) FILTER (WHERE anonymized_at IS NULL AND inactivity_notice_sent_at IS NULL AND last_login_at < $1),
+		COUNT(*) FILTER (WHERE anonymized_at IS NULL AND inactivity_notice_sent_at IS NOT NULL),
+		COUNT(*) FILTER (WHERE anonymized_at IS NOT NULL)
+	FROM users WHERE NOT is_guest`
+
+	var c RetentionCounts
+	if err := us.db.QueryRowContext(ctx, query, cutoff.UTC()).Scan(&c.Inactive, &c.Warned, &c.Anonymized); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Settings columns of userTables, each read as its default when the user
+// has no user_settings row. The defaults match the table's.
+const (
+	userSettingDisplayCurrency         = `COALESCE(s.display_currency, 'USD')`
+	userSettingAfterHoursOrders        = `COALESCE(s.after_hours_orders, 'REJECT')`
+	userSettingCostBasisMethod         = `COALESCE(s.cost_basis_method, 'AVERAGE')`
+	userSettingTradeConfirmationEmails = `COALESCE(s.trade_confirmation_emails, FALSE)`
+	userSettingTimezone                = `COALESCE(s.timezone, '` + DefaultTimezone + `')`
+)
+
+// getSetting scans one settings column of the user into dst.
+func (us *UserStore) getSetting(ctx context.Context, userID, column string, dst any) error {
+	query := `SELECT ` + column + ` FROM ` + userTables + ` WHERE id = $1`
+	err := us.db.QueryRowContext(ctx, query, userID).Scan(dst)
+	if err == sql.ErrNoRows {
+		return errors.New("user not found")
+	}
+	return err
+}
+
+// setSetting stores one settings column, creating the user's settings row on
+// first use. column is one of the user_settings column names.
+func (us *UserStore) setSetting(ctx context.Context, userID, column string, value any) error {
+	query := `
+	INSERT INTO user_settings (user_id, ` + column + `) VALUES ($1, $2)
+	ON CONFLICT (user_id) DO UPDATE SET ` + column + ` = EXCLUDED.` + column
+
+	_, err := us.db.ExecContext(ctx, query, userID, value)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return errors.New("user not found")
+	}
+	return err
+}
+
+// GetDisplayCurrency returns the ISO 4217 code the user wants amounts shown in.
+func (us *UserStore) GetDisplayCurrency(ctx context.Context, userID string) (string, error) {
+	var currency string
+	err := us.getSetting(ctx, userID, userSettingDisplayCurrency, &currency)
+	return currency, err
+}
+
+// SetDisplayCurrency stores the user's display currency. currency must be an
+// upper-case ISO 4217 code.
+func (us *UserStore) SetDisplayCurrency(ctx context.Context, userID, currency string) error {
+	return us.setSetting(ctx, userID, "display_currency", currency)
+}
+
+// GetAfterHoursOrders returns what the user wants done with trades placed
+// while the market is closed: AfterHoursReject or AfterHoursQueue.
+func (us *UserStore) GetAfterHoursOrders(ctx context.Context, userID string) (string, error) {
+	var mode string
+	err := us.getSetting(ctx, userID, userSettingAfterHoursOrders, &mode)
+	return mode, err
+}
+
+// SetAfterHoursOrders stores the user's after-hours mode. mode must be
+// AfterHoursReject or AfterHoursQueue.
+func (us *UserStore) SetAfterHoursOrders(ctx context.Context, userID, mode string) error {
+	return us.setSetting(ctx, userID, "after_hours_orders", mode)
+}
+
+// GetCostBasisMethod returns how the user's sells pick their cost basis:
+// CostBasisFIFO, CostBasisLIFO or CostBasisAverage.
+func (us *UserStore) GetCostBasisMethod(ctx context.Context, userID string) (string, error) {
+	var method string
+	err := us.getSetting(ctx, userID, userSettingCostBasisMethod, &method)
+	return method, err
+}
+
+// SetCostBasisMethod stores the user's cost-basis method. method must be
+// CostBasisFIFO, CostBasisLIFO or CostBasisAverage.
+func (us *UserStore) SetCostBasisMethod(ctx context.Context, userID, method string) error {
+	return us.setSetting(ctx, userID, "cost_basis_method", method)
+}
+
+// SetTradeConfirmationEmails turns the user's trade confirmation emails on
+// or off.
+func (us *UserStore) SetTradeConfirmationEmails(ctx context.Context, userID string, enabled bool) error {
+	return us.setSetting(ctx, userID, "trade_confirmation_emails", enabled)
+}
+
+// SetStatementEmails turns the user's monthly statement emails on or off.
+func (us *UserStore) SetStatementEmails(ctx context.Context, userID string, enabled bool) error {
+	return us.setSetting(ctx, userID, "statement_emails", enabled)
+}
+
+// GetTimezone returns the IANA name of the time zone the user's days are
+// drawn in.
+func (us *UserStore) GetTimezone(ctx context.Context, userID string) (string, error) {
+	var name string
+	err := us.getSetting(ctx, userID, userSettingTimezone, &name)
+	return name, err
+}
+
+// SetTimezone stores the user's time zone. name must be an IANA name.
+func (us *UserStore) SetTimezone(ctx context.Context, userID, name string) error {
+	return us.setSetting(ctx, userID, "timezone", name)
+}
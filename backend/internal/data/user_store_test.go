@@ -2,10 +2,12 @@ package data
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
@@ -13,17 +15,48 @@ import (
 var userQueryCols = []string{
 	"id", "email", "password", "created_at", "balance",
 	"email_verified", "verification_token", "verification_token_expires",
-	"google_id", "created_via",
+	"google_id", "created_via", "avatar_url", "username", "is_guest", "guest_expires_at", "display_currency", "after_hours_orders", "league", "cost_basis_method", "trade_confirmation_emails", "timezone", "role",
 }
 
-// addUserRow appends a standard user row with nil nullable fields.
-func addUserRow(rows *sqlmock.Rows, id, email string, balance decimal.Decimal) *sqlmock.Rows {
-	return rows.AddRow(
-		id, email, "hashed-pw", time.Now(), balance,
-		false, nil, nil, nil, "email",
+// userRow returns the row a user read scans for u. Empty nullable fields
+// read as NULL, and an empty created_via and empty settings as a new email
+// account's, so a new users or user_settings column only touches
+// userQueryCols and this helper.
+func userRow(u User) *sqlmock.Rows {
+	createdVia := u.CreatedVia
+	if createdVia == "" {
+		createdVia = "email"
+	}
+	return sqlmock.NewRows(userQueryCols).AddRow(
+		u.ID, u.Email, nullString(u.Password), time.Now(), u.Balance,
+		u.EmailVerified, deref(u.VerificationToken), deref(u.VerificationTokenExpires), deref(u.GoogleID), createdVia,
+		nullString(u.AvatarURL), nullString(u.Username), u.IsGuest, deref(u.GuestExpiresAt),
+		withDefault(u.DisplayCurrency, "USD"), withDefault(u.AfterHoursOrders, AfterHoursReject), nullString(u.League),
+		withDefault(u.CostBasisMethod, CostBasisAverage), u.TradeConfirmationEmails, withDefault(u.Timezone, DefaultTimezone), withDefault(u.Role, RoleUser),
 	)
 }
 
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func withDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
 // ---- GetUserByID ----
 
 func TestGetUserByID_Found(t *testing.T) {
@@ -33,8 +66,8 @@ func TestGetUserByID_Found(t *testing.T) {
 	}
 	defer db.Close()
 
-	rows := addUserRow(sqlmock.NewRows(userQueryCols), "user-1", "alice@example.com", decimal.NewFromFloat(9500.0))
-	mock.ExpectQuery("SELECT id, email, password").
+	rows := userRow(User{ID: "user-1", Email: "alice@example.com", Password: "hashed-pw", Balance: decimal.NewFromFloat(9500.0)})
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs("user-1").
 		WillReturnRows(rows)
 
@@ -65,7 +98,7 @@ func TestGetUserByID_NotFound(t *testing.T) {
 	}
 	defer db.Close()
 
-	mock.ExpectQuery("SELECT id, email, password").
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs("nonexistent").
 		WillReturnRows(sqlmock.NewRows(userQueryCols)) // empty → sql.ErrNoRows
 
@@ -94,12 +127,12 @@ func TestCreateUser_HappyPath(t *testing.T) {
 
 	// INSERT INTO users — uuid and bcrypt hash are unpredictable, use AnyArg
 	mock.ExpectExec("INSERT INTO users").
-		WithArgs(sqlmock.AnyArg(), "bob@example.com", sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "bob@example.com", sqlmock.AnyArg(), DefaultStartingBalance).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// GetUserByID called after INSERT — uuid is unknown, so match any arg
-	rows := addUserRow(sqlmock.NewRows(userQueryCols), "some-uuid", "bob@example.com", decimal.NewFromFloat(10000.0))
-	mock.ExpectQuery("SELECT id, email, password").
+	rows := userRow(User{ID: "some-uuid", Email: "bob@example.com", Password: "hashed-pw", Balance: decimal.NewFromFloat(10000.0)})
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs(sqlmock.AnyArg()).
 		WillReturnRows(rows)
 
@@ -158,3 +191,113 @@ func TestUpdateBalance_ZeroAllowed(t *testing.T) {
 		t.Errorf("unmet expectations: %v", err)
 	}
 }
+
+// ---- Usernames ----
+
+func TestCreateUserWithVerification_UsernameTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO users").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "idx_users_username_lower"})
+
+	store := NewUserStore(db)
+	_, _, err = store.CreateUserWithVerification(context.Background(), "bob@example.com", "Password1!", "Bob", nil)
+	if !errors.Is(err, ErrUsernameTaken) {
+		t.Errorf("expected ErrUsernameTaken, got %v", err)
+	}
+}
+
+func TestChangeEmail_Taken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE users\\s+SET email = \\$2, email_verified = FALSE").
+		WithArgs("user-1", "bob@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_key"})
+
+	store := NewUserStore(db)
+	_, err = store.ChangeEmail(context.Background(), "user-1", " Bob@Example.com ")
+	if !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("expected ErrEmailTaken, got %v", err)
+	}
+}
+
+func TestSetUsername_CooldownReturnsLastChange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	changedAt := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE users u SET username").
+		WithArgs("user-1", "new_name", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"})) // guard failed → no row
+	mock.ExpectQuery("SELECT username_changed_at FROM users").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"username_changed_at"}).AddRow(changedAt))
+
+	store := NewUserStore(db)
+	now := time.Now()
+	got, err := store.SetUsername(context.Background(), "user-1", "new_name", now.Add(-time.Hour), now)
+	if !errors.Is(err, ErrUsernameCooldown) || !got.Equal(changedAt) {
+		t.Errorf("expected cooldown at %v, got %v, %v", changedAt, got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSetAfterHoursOrders_UpsertsSettingsRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO user_settings \\(user_id, after_hours_orders\\) VALUES \\(\\$1, \\$2\\)\\s+ON CONFLICT \\(user_id\\) DO UPDATE").
+		WithArgs("user-1", AfterHoursQueue).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO user_settings").
+		WithArgs("ghost", AfterHoursQueue).
+		WillReturnError(&pq.Error{Code: "23503", Constraint: "user_settings_user_id_fkey"})
+
+	store := NewUserStore(db)
+	if err := store.SetAfterHoursOrders(context.Background(), "user-1", AfterHoursQueue); err != nil {
+		t.Fatalf("SetAfterHoursOrders: %v", err)
+	}
+	if err := store.SetAfterHoursOrders(context.Background(), "ghost", AfterHoursQueue); err == nil || err.Error() != "user not found" {
+		t.Errorf("unknown user: got %v, want user not found", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGrantAdmin_MatchesNormalizedEmails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE users SET role = \\$1 WHERE email = ANY\\(\\$2\\) AND email_verified AND role <> \\$1").
+		WithArgs(RoleAdmin, pq.Array([]string{"admin@example.com", "ops@example.com"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	store := NewUserStore(db)
+	promoted, err := store.GrantAdmin(context.Background(), []string{"ADMIN@EXAMPLE.COM", " ops@example.com"})
+	if err != nil || promoted != 1 {
+		t.Errorf("GrantAdmin: promoted %d, %v", promoted, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
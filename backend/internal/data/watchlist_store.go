@@ -73,6 +73,13 @@ func (ws *WatchlistStore) Remove(ctx context.Context, userID, symbol string) err
 	return nil
 }
 
+// Count returns how many symbols userID watches.
+func (ws *WatchlistStore) Count(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := ws.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM watchlist WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
 // ListByUser returns all watchlist entries for a user, ordered by symbol.
 func (ws *WatchlistStore) ListByUser(ctx context.Context, userID string) ([]WatchlistEntry, error) {
 	query := `SELECT id, user_id, symbol, created_at
@@ -97,3 +104,25 @@ func (ws *WatchlistStore) ListByUser(ctx context.Context, userID string) ([]Watc
 	}
 	return entries, nil
 }
+
+// WatchedSymbols returns every symbol on anyone's watchlist.
+func (ws *WatchlistStore) WatchedSymbols(ctx context.Context) ([]string, error) {
+	rows, err := ws.db.QueryContext(ctx, `SELECT DISTINCT symbol FROM watchlist ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	symbols := make([]string, 0)
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
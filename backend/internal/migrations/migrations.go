@@ -7,25 +7,44 @@ import (
 	"fmt"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"papertrader/internal/sqlite"
 )
 
 //go:embed sql/*.sql
 var migrationFS embed.FS
 
+// sqliteFS holds the schema for SQLite databases, which is kept separately
+// since it can't be written in the PostgreSQL migrations' dialect.
+//
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
 func newMigrate(db *sql.DB) (*migrate.Migrate, error) {
-	src, err := iofs.New(migrationFS, "sql")
+	fsys, dir, name := migrationFS, "sql", "postgres"
+	if sqlite.Is(db) {
+		fsys, dir, name = sqliteFS, "sqlite", "sqlite3"
+	}
+	src, err := iofs.New(fsys, dir)
 	if err != nil {
 		return nil, fmt.Errorf("migrations: source: %w", err)
 	}
 
-	drv, err := postgres.WithInstance(db, &postgres.Config{})
+	var drv database.Driver
+	if sqlite.Is(db) {
+		drv, err = sqlite3.WithInstance(db, &sqlite3.Config{})
+	} else {
+		drv, err = postgres.WithInstance(db, &postgres.Config{})
+	}
 	if err != nil {
 		return nil, fmt.Errorf("migrations: driver: %w", err)
 	}
 
-	m, err := migrate.NewWithInstance("iofs", src, "postgres", drv)
+	m, err := migrate.NewWithInstance("iofs", src, name, drv)
 	if err != nil {
 		return nil, fmt.Errorf("migrations: instance: %w", err)
 	}
@@ -0,0 +1,12 @@
+-- Reference data for tradable symbols. Populated lazily from MarketStack's
+-- tickers endpoint the first time a symbol is traded; the trading policy reads
+-- `exchange` to decide whether a symbol is inside the allowed venue set.
+CREATE TABLE IF NOT EXISTS instruments (
+    symbol      VARCHAR(20) PRIMARY KEY,
+    name        TEXT NOT NULL DEFAULT '',
+    exchange    VARCHAR(20) NOT NULL DEFAULT '',
+    asset_type  VARCHAR(20) NOT NULL DEFAULT 'stock',
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_instruments_exchange ON instruments(exchange);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS notifications;
+DROP INDEX IF EXISTS idx_instruments_halted;
+ALTER TABLE instruments DROP COLUMN IF EXISTS halted_at;
+ALTER TABLE instruments DROP COLUMN IF EXISTS halt_source;
+ALTER TABLE instruments DROP COLUMN IF EXISTS halt_reason;
+ALTER TABLE instruments DROP COLUMN IF EXISTS halted;
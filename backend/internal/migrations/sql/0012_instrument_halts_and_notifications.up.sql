@@ -0,0 +1,20 @@
+-- Per-symbol trading halts. halt_source records who set the halt so that a
+-- provider-driven resume never lifts a halt an admin placed by hand.
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS halted BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS halt_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS halt_source VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS halted_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_instruments_halted ON instruments(symbol) WHERE halted;
+
+-- In-app notifications. Rows are per-user; read_at is NULL until acknowledged.
+CREATE TABLE IF NOT EXISTS notifications (
+    id          VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind        VARCHAR(50) NOT NULL,
+    title       TEXT NOT NULL,
+    body        TEXT NOT NULL DEFAULT '',
+    read_at     TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS reauth_required_after;
+DROP TABLE IF EXISTS audit_events;
@@ -0,0 +1,20 @@
+-- Security audit log. Every successful and failed password login is recorded
+-- so the anomaly detector has history to compare against; detections are
+-- written as additional rows with flagged = TRUE. user_id is nullable so
+-- events that cannot be tied to an account can still be kept.
+CREATE TABLE IF NOT EXISTS audit_events (
+    id          VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+    kind        VARCHAR(64) NOT NULL,
+    ip_address  VARCHAR(64) NOT NULL DEFAULT '',
+    country     VARCHAR(2) NOT NULL DEFAULT '',
+    details     JSONB NOT NULL DEFAULT '{}',
+    flagged     BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_audit_events_user_kind_created ON audit_events(user_id, kind, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_events_flagged ON audit_events(created_at DESC) WHERE flagged;
+
+-- Sessions authenticated before this instant must log in again before
+-- sensitive actions. NULL means no re-authentication is pending.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS reauth_required_after TIMESTAMP;
@@ -0,0 +1,5 @@
+-- Profile avatars. avatar_key is the object-storage key (kept so the previous
+-- image can be deleted on replace); avatar_url is the public URL served to
+-- clients. Both are NULL when the user has no avatar.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_url TEXT;
@@ -0,0 +1,8 @@
+-- Public display names. username is optional (NULL until chosen) and unique
+-- case-insensitively; the original casing is kept for display.
+-- username_changed_at drives the change cooldown and stays NULL until the
+-- first change after the initial pick.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_changed_at TIMESTAMP;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_lower ON users (LOWER(username));
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS magic_link_expires;
+ALTER TABLE users DROP COLUMN IF EXISTS magic_link_jti;
@@ -0,0 +1,6 @@
+-- Passwordless login. A magic link carries a signed token whose ID (jti) is
+-- stored here until the link is used or superseded, which makes each link
+-- single-use: consuming it clears the column. Only the newest link per user
+-- is valid.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS magic_link_jti VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS magic_link_expires TIMESTAMP;
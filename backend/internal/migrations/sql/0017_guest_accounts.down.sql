@@ -0,0 +1,14 @@
+-- Guests cannot satisfy NOT NULL email; remove them and their data before
+-- restoring it.
+DELETE FROM watchlist WHERE user_id IN (SELECT id FROM users WHERE email IS NULL);
+DELETE FROM portfolio WHERE user_id IN (SELECT id FROM users WHERE email IS NULL);
+ALTER TABLE trades DISABLE TRIGGER trades_no_delete;
+DELETE FROM trades WHERE user_id IN (SELECT id FROM users WHERE email IS NULL);
+ALTER TABLE trades ENABLE TRIGGER trades_no_delete;
+UPDATE research_queries SET user_id = NULL WHERE user_id IN (SELECT id FROM users WHERE email IS NULL);
+DELETE FROM users WHERE email IS NULL;
+
+DROP INDEX IF EXISTS idx_users_guest_expires;
+ALTER TABLE users DROP COLUMN IF EXISTS guest_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS is_guest;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL;
@@ -0,0 +1,9 @@
+-- Guest accounts: a temporary portfolio without an email. Guests have a NULL
+-- email (UNIQUE allows any number of NULLs) and expire at guest_expires_at
+-- unless upgraded, which clears both guest columns in place so trades and
+-- holdings keyed by user_id carry over.
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_guest BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS guest_expires_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_guest_expires ON users(guest_expires_at) WHERE is_guest;
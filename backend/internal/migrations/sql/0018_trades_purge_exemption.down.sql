@@ -0,0 +1,5 @@
+CREATE OR REPLACE FUNCTION reject_trade_mutation() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'trades is append-only — % is not permitted', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,11 @@
+-- trades stays append-only, except that account purges may delete a user's
+-- trades. A purge opts in for its own transaction only, with
+-- SELECT set_config('papertrader.purging_users', 'on', true).
+CREATE OR REPLACE FUNCTION reject_trade_mutation() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' AND current_setting('papertrader.purging_users', true) = 'on' THEN
+    RETURN OLD;
+  END IF;
+  RAISE EXCEPTION 'trades is append-only — % is not permitted', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
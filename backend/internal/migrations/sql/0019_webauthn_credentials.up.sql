@@ -0,0 +1,13 @@
+-- Passkeys (WebAuthn credentials). id is the base64url credential ID chosen
+-- by the authenticator. credential holds the verified public key, sign
+-- counter and flags as JSON, exactly as the WebAuthn library returns them, so
+-- library upgrades that add fields need no migration.
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    id           VARCHAR(1400) PRIMARY KEY,
+    user_id      VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR(64) NOT NULL DEFAULT '',
+    credential   JSONB NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON webauthn_credentials(user_id);
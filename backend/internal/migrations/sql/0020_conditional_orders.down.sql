@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS conditional_orders;
+-- Trades are append-only; dropping a column is DDL and is not blocked by the
+-- row triggers.
+ALTER TABLE trades DROP COLUMN IF EXISTS order_type;
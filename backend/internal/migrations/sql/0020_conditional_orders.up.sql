@@ -0,0 +1,24 @@
+-- Stop-loss / take-profit orders. A conditional order sits ACTIVE until the
+-- price monitor sees its trigger price reached, then sells and records the
+-- resulting trade. Unlike trades this table is mutable: rows move from ACTIVE
+-- to exactly one of TRIGGERED, CANCELLED or FAILED.
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS order_type VARCHAR(20) NOT NULL DEFAULT 'MARKET';
+
+CREATE TABLE IF NOT EXISTS conditional_orders (
+    id             VARCHAR(255) PRIMARY KEY,
+    user_id        VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol         VARCHAR(10) NOT NULL,
+    order_type     VARCHAR(20) NOT NULL CHECK (order_type IN ('STOP_LOSS', 'TAKE_PROFIT')),
+    quantity       INTEGER NOT NULL CHECK (quantity > 0),
+    trigger_price  NUMERIC(15,2) NOT NULL CHECK (trigger_price > 0),
+    status         VARCHAR(20) NOT NULL DEFAULT 'ACTIVE'
+                   CHECK (status IN ('ACTIVE', 'TRIGGERED', 'CANCELLED', 'FAILED')),
+    created_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at      TIMESTAMP,
+    trade_id       VARCHAR(255),
+    fill_price     NUMERIC(15,2),
+    failure_reason TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_conditional_orders_user ON conditional_orders(user_id, created_at DESC);
+-- The monitor only ever scans active orders.
+CREATE INDEX IF NOT EXISTS idx_conditional_orders_active_symbol ON conditional_orders(symbol) WHERE status = 'ACTIVE';
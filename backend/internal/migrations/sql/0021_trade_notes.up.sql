@@ -0,0 +1,11 @@
+-- Free-text notes and tags on a user's own trades, searchable from
+-- GET /api/investments/search. Kept out of trades because trades is
+-- append-only; notes are edited freely.
+CREATE TABLE IF NOT EXISTS trade_notes (
+    trade_id   VARCHAR(255) PRIMARY KEY REFERENCES trades(id) ON DELETE CASCADE,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note       TEXT NOT NULL DEFAULT '',
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_trade_notes_user ON trade_notes(user_id);
@@ -0,0 +1,15 @@
+-- Short positions cannot be represented without margin. Unwind them at their
+-- entry price (the user gets their collateral back, with no gain or loss)
+-- before dropping the column. Their trades stay in the ledger.
+UPDATE users u
+SET balance = u.balance + s.collateral
+FROM (
+    SELECT user_id, SUM(margin + quantity * avg_price) AS collateral
+    FROM portfolio
+    WHERE quantity < 0
+    GROUP BY user_id
+) s
+WHERE s.user_id = u.id;
+
+DELETE FROM portfolio WHERE quantity < 0;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS margin;
@@ -0,0 +1,7 @@
+-- Short selling. A short position is a portfolio row with a negative
+-- quantity (shares borrowed and sold) and avg_price the average price they
+-- were sold at. margin is the cash set aside while the short is open: the
+-- sale proceeds plus the user's own collateral. It leaves users.balance when
+-- the short opens and is released, less the cost of buying the shares back,
+-- as the position is covered. Long holdings always have margin 0.
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS margin NUMERIC(15,2) NOT NULL DEFAULT 0 CHECK (margin >= 0);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS users_subscribe_default_list ON users;
+DROP FUNCTION IF EXISTS subscribe_default_curated_list();
+DROP TABLE IF EXISTS curated_list_subscriptions;
+DROP TABLE IF EXISTS curated_lists;
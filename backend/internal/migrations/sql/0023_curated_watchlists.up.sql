@@ -0,0 +1,46 @@
+-- Admin-curated symbol lists shown alongside each user's own watchlist.
+-- Users subscribe to lists; the list marked is_default is subscribed to
+-- automatically when an account is created so a new dashboard isn't empty.
+CREATE TABLE IF NOT EXISTS curated_lists (
+    slug        VARCHAR(64) PRIMARY KEY,
+    name        VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    symbols     TEXT[] NOT NULL DEFAULT '{}',
+    is_default  BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- At most one default list.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_curated_lists_default ON curated_lists (is_default) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS curated_list_subscriptions (
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    list_slug  VARCHAR(64) NOT NULL REFERENCES curated_lists(slug) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, list_slug)
+);
+
+INSERT INTO curated_lists (slug, name, description, symbols, is_default) VALUES
+    ('getting-started', 'Getting started', 'Widely held large caps and index funds to follow while you learn.',
+     ARRAY['SPY', 'QQQ', 'AAPL', 'MSFT', 'AMZN', 'JPM', 'KO'], TRUE),
+    ('dividend-aristocrats', 'Dividend aristocrats', 'S&P 500 companies that have raised their dividend for at least 25 consecutive years.',
+     ARRAY['KO', 'PG', 'JNJ', 'PEP', 'MMM', 'ABBV', 'CL', 'WMT', 'MCD', 'XOM'], FALSE),
+    ('big-tech', 'Big tech', 'The largest US technology companies by market capitalisation.',
+     ARRAY['AAPL', 'MSFT', 'GOOGL', 'AMZN', 'META', 'NVDA', 'TSLA'], FALSE)
+ON CONFLICT (slug) DO NOTHING;
+
+CREATE OR REPLACE FUNCTION subscribe_default_curated_list() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO curated_list_subscriptions (user_id, list_slug)
+  SELECT NEW.id, slug FROM curated_lists WHERE is_default
+  ON CONFLICT DO NOTHING;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- A trigger rather than application code so every sign-up path (password,
+-- Google, guest) gets the default list.
+DROP TRIGGER IF EXISTS users_subscribe_default_list ON users;
+CREATE TRIGGER users_subscribe_default_list
+  AFTER INSERT ON users
+  FOR EACH ROW EXECUTE FUNCTION subscribe_default_curated_list();
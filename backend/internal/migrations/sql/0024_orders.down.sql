@@ -0,0 +1,29 @@
+-- Buy orders have no equivalent in conditional_orders; sell-side LIMIT and
+-- STOP orders map to TAKE_PROFIT and STOP_LOSS.
+DELETE FROM orders WHERE side = 'BUY';
+UPDATE orders SET order_type = 'TAKE_PROFIT' WHERE order_type = 'LIMIT';
+UPDATE orders SET order_type = 'STOP_LOSS' WHERE order_type = 'STOP';
+UPDATE orders SET status = 'ACTIVE' WHERE status = 'PENDING';
+UPDATE orders SET status = 'TRIGGERED' WHERE status = 'FILLED';
+UPDATE orders SET status = 'CANCELLED' WHERE status = 'EXPIRED';
+
+DROP INDEX IF EXISTS idx_orders_pending_expiry;
+DROP INDEX IF EXISTS idx_orders_pending_symbol;
+ALTER INDEX idx_orders_user RENAME TO idx_conditional_orders_user;
+
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders DROP CONSTRAINT orders_protective_side_check;
+ALTER TABLE orders DROP CONSTRAINT orders_order_type_check;
+ALTER TABLE orders DROP CONSTRAINT orders_side_check;
+ALTER TABLE orders DROP COLUMN expires_at;
+ALTER TABLE orders DROP COLUMN side;
+ALTER TABLE orders ALTER COLUMN status SET DEFAULT 'ACTIVE';
+
+ALTER TABLE orders ADD CONSTRAINT conditional_orders_status_check
+    CHECK (status IN ('ACTIVE', 'TRIGGERED', 'CANCELLED', 'FAILED'));
+ALTER TABLE orders ADD CONSTRAINT conditional_orders_order_type_check
+    CHECK (order_type IN ('STOP_LOSS', 'TAKE_PROFIT'));
+ALTER TABLE orders RENAME CONSTRAINT orders_user_id_fkey TO conditional_orders_user_id_fkey;
+ALTER TABLE orders RENAME CONSTRAINT orders_pkey TO conditional_orders_pkey;
+ALTER TABLE orders RENAME TO conditional_orders;
+CREATE INDEX IF NOT EXISTS idx_conditional_orders_active_symbol ON conditional_orders(symbol) WHERE status = 'ACTIVE';
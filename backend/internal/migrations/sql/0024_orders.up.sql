@@ -0,0 +1,33 @@
+-- Generalise conditional_orders into an order book. Stop-loss and
+-- take-profit orders stay as they were; LIMIT and STOP orders can also buy.
+-- Every order rests PENDING until it moves to exactly one of FILLED,
+-- CANCELLED, EXPIRED (expires_at passed) or FAILED (triggered but could not
+-- be filled).
+ALTER TABLE conditional_orders RENAME TO orders;
+ALTER TABLE orders RENAME CONSTRAINT conditional_orders_pkey TO orders_pkey;
+ALTER TABLE orders RENAME CONSTRAINT conditional_orders_user_id_fkey TO orders_user_id_fkey;
+ALTER TABLE orders DROP CONSTRAINT conditional_orders_order_type_check;
+ALTER TABLE orders DROP CONSTRAINT conditional_orders_status_check;
+
+UPDATE orders SET status = 'PENDING' WHERE status = 'ACTIVE';
+UPDATE orders SET status = 'FILLED' WHERE status = 'TRIGGERED';
+
+ALTER TABLE orders ALTER COLUMN status SET DEFAULT 'PENDING';
+ALTER TABLE orders ADD COLUMN side VARCHAR(4) NOT NULL DEFAULT 'SELL';
+ALTER TABLE orders ADD COLUMN expires_at TIMESTAMP;
+
+ALTER TABLE orders ADD CONSTRAINT orders_side_check CHECK (side IN ('BUY', 'SELL'));
+ALTER TABLE orders ADD CONSTRAINT orders_order_type_check
+    CHECK (order_type IN ('LIMIT', 'STOP', 'STOP_LOSS', 'TAKE_PROFIT'));
+-- Stop-loss and take-profit protect a holding, so they only ever sell.
+ALTER TABLE orders ADD CONSTRAINT orders_protective_side_check
+    CHECK (order_type IN ('LIMIT', 'STOP') OR side = 'SELL');
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('PENDING', 'FILLED', 'CANCELLED', 'EXPIRED', 'FAILED'));
+
+ALTER INDEX idx_conditional_orders_user RENAME TO idx_orders_user;
+DROP INDEX IF EXISTS idx_conditional_orders_active_symbol;
+-- The monitor only ever scans pending orders.
+CREATE INDEX IF NOT EXISTS idx_orders_pending_symbol ON orders(symbol) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_orders_pending_expiry ON orders(expires_at)
+    WHERE status = 'PENDING' AND expires_at IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Currency the user wants quotes and portfolio values shown in. Everything is
+-- still stored in USD; conversion happens when a response is built.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_currency VARCHAR(3) NOT NULL DEFAULT 'USD'
+    CHECK (display_currency ~ '^[A-Z]{3}$');
@@ -0,0 +1,13 @@
+-- Queued market orders cannot be represented without a trigger price.
+DELETE FROM orders WHERE order_type = 'MARKET';
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_trigger_price_check;
+ALTER TABLE orders DROP CONSTRAINT orders_protective_side_check;
+ALTER TABLE orders ADD CONSTRAINT orders_protective_side_check
+    CHECK (order_type IN ('LIMIT', 'STOP') OR side = 'SELL');
+ALTER TABLE orders DROP CONSTRAINT orders_order_type_check;
+ALTER TABLE orders ADD CONSTRAINT orders_order_type_check
+    CHECK (order_type IN ('LIMIT', 'STOP', 'STOP_LOSS', 'TAKE_PROFIT'));
+ALTER TABLE orders ALTER COLUMN trigger_price SET NOT NULL;
+
+ALTER TABLE users DROP COLUMN IF EXISTS after_hours_orders;
@@ -0,0 +1,15 @@
+-- What happens to a buy or sell placed while the market is closed: REJECT
+-- fails it, QUEUE rests it as a MARKET order that fills at the next open.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS after_hours_orders VARCHAR(6) NOT NULL DEFAULT 'REJECT'
+    CHECK (after_hours_orders IN ('REJECT', 'QUEUE'));
+
+-- MARKET orders have no trigger; every other type must have one.
+ALTER TABLE orders ALTER COLUMN trigger_price DROP NOT NULL;
+ALTER TABLE orders DROP CONSTRAINT orders_order_type_check;
+ALTER TABLE orders ADD CONSTRAINT orders_order_type_check
+    CHECK (order_type IN ('MARKET', 'LIMIT', 'STOP', 'STOP_LOSS', 'TAKE_PROFIT'));
+ALTER TABLE orders DROP CONSTRAINT orders_protective_side_check;
+ALTER TABLE orders ADD CONSTRAINT orders_protective_side_check
+    CHECK (order_type IN ('MARKET', 'LIMIT', 'STOP') OR side = 'SELL');
+ALTER TABLE orders ADD CONSTRAINT orders_trigger_price_check
+    CHECK ((order_type = 'MARKET') = (trigger_price IS NULL));
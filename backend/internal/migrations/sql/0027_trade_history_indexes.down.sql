@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_trades_user_action_executed_at;
+DROP INDEX IF EXISTS idx_trades_user_symbol_executed_at;
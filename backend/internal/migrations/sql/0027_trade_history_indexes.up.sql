@@ -0,0 +1,5 @@
+-- Trade history filters by symbol or action within a user, newest first.
+-- idx_trades_user_id_executed_at already serves the unfiltered list and date
+-- ranges; these keep a filtered page from scanning the user's whole history.
+CREATE INDEX IF NOT EXISTS idx_trades_user_symbol_executed_at ON trades(user_id, symbol, executed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_trades_user_action_executed_at ON trades(user_id, action, executed_at DESC);
@@ -0,0 +1,16 @@
+-- Sector, industry and index membership per symbol. Kept apart from
+-- instruments, which is only populated lazily as symbols are traded, so
+-- analytics can classify symbols nobody has touched yet. Rows come from the
+-- dataset bundled with the server (source 'dataset') or an admin override
+-- (source 'admin'); reloading the dataset never replaces an override.
+CREATE TABLE IF NOT EXISTS instrument_classifications (
+    symbol      VARCHAR(20) PRIMARY KEY,
+    sector      VARCHAR(64) NOT NULL,
+    industry    VARCHAR(128) NOT NULL DEFAULT '',
+    indices     TEXT[] NOT NULL DEFAULT '{}',
+    source      VARCHAR(20) NOT NULL DEFAULT 'dataset' CHECK (source IN ('dataset', 'admin')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_instrument_classifications_sector ON instrument_classifications(sector);
+-- Index membership lookups ("every NDX member") use indices @> ARRAY[code].
+CREATE INDEX IF NOT EXISTS idx_instrument_classifications_indices ON instrument_classifications USING GIN (indices);
@@ -0,0 +1,4 @@
+-- Optional grouping for users onboarded together, e.g. one classroom. Set
+-- by the admin bulk import; NULL for everyone who signed up on their own.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS league VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_users_league ON users(league) WHERE league IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS lot_disposals;
+DROP TABLE IF EXISTS tax_lots;
+ALTER TABLE users DROP COLUMN IF EXISTS cost_basis_method;
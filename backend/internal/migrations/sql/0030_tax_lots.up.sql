@@ -0,0 +1,46 @@
+-- Tax lots: each buy opens a lot, and each sell closes shares from lots in
+-- the order the user's cost-basis method picks (FIFO, LIFO, or AVERAGE,
+-- which realizes against the holding's average cost). Shorts keep average
+-- cost and have no lots.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS cost_basis_method VARCHAR(7) NOT NULL DEFAULT 'AVERAGE'
+    CHECK (cost_basis_method IN ('FIFO', 'LIFO', 'AVERAGE'));
+
+CREATE TABLE IF NOT EXISTS tax_lots (
+    id          VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol      VARCHAR(10) NOT NULL,
+    trade_id    VARCHAR(255),
+    quantity    INTEGER NOT NULL CHECK (quantity > 0),
+    remaining   INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= quantity),
+    price       NUMERIC(20,8) NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_lots_open ON tax_lots(user_id, symbol, acquired_at) WHERE remaining > 0;
+
+-- One row per lot a sell drew from. lot_id is NULL for shares the lots did
+-- not cover, which are realized against the holding's average cost.
+CREATE TABLE IF NOT EXISTS lot_disposals (
+    id            VARCHAR(255) PRIMARY KEY,
+    user_id       VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sell_trade_id VARCHAR(255) NOT NULL,
+    lot_id        VARCHAR(255) REFERENCES tax_lots(id) ON DELETE SET NULL,
+    symbol        VARCHAR(10) NOT NULL,
+    quantity      INTEGER NOT NULL CHECK (quantity > 0),
+    cost_price    NUMERIC(20,8) NOT NULL,
+    sale_price    NUMERIC(15,2) NOT NULL,
+    realized      NUMERIC(15,2) NOT NULL,
+    method        VARCHAR(7) NOT NULL,
+    disposed_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lot_disposals_sell_trade ON lot_disposals(sell_trade_id);
+CREATE INDEX IF NOT EXISTS idx_lot_disposals_user ON lot_disposals(user_id, disposed_at);
+
+-- Existing long holdings become one lot each at their average cost.
+INSERT INTO tax_lots (id, user_id, symbol, quantity, remaining, price, acquired_at)
+SELECT 'legacy-' || p.id, p.user_id, p.symbol, p.quantity, p.quantity, p.avg_price, COALESCE(p.created_at, CURRENT_TIMESTAMP)
+FROM portfolio p
+JOIN users u ON u.id = p.user_id
+WHERE p.quantity > 0
+ON CONFLICT (id) DO NOTHING;
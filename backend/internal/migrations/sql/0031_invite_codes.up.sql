@@ -0,0 +1,22 @@
+-- Invite codes for invite-only registration (REGISTRATION_INVITE_ONLY).
+-- A code admits up to max_uses new accounts before expires_at; uses counts
+-- redemptions and never goes down, even when a redeeming account is deleted.
+CREATE TABLE IF NOT EXISTS invite_codes (
+    code        VARCHAR(32) PRIMARY KEY,
+    note        VARCHAR(200) NOT NULL DEFAULT '',
+    max_uses    INTEGER NOT NULL CHECK (max_uses > 0),
+    uses        INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0 AND uses <= max_uses),
+    expires_at  TIMESTAMPTZ,
+    revoked_at  TIMESTAMPTZ,
+    created_by  VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Which accounts each code admitted.
+CREATE TABLE IF NOT EXISTS invite_code_redemptions (
+    code        VARCHAR(32) NOT NULL REFERENCES invite_codes(code) ON DELETE CASCADE,
+    user_id     VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redeemed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (code, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_invite_code_redemptions_user ON invite_code_redemptions(user_id);
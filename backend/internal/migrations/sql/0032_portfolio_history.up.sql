@@ -0,0 +1,15 @@
+-- One row per user per trading day: the account's value at that day's close,
+-- recorded by the daily snapshot job and charted by
+-- GET /api/investments/history?range=. partial is set when a held symbol had
+-- no closing price and was valued at cost instead.
+CREATE TABLE IF NOT EXISTS portfolio_history (
+    user_id        VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    snapshot_date  DATE NOT NULL,
+    cash           NUMERIC(15,2) NOT NULL,
+    holdings_value NUMERIC(20,2) NOT NULL,
+    total_value    NUMERIC(20,2) NOT NULL,
+    partial        BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, snapshot_date)
+);
+CREATE INDEX IF NOT EXISTS idx_portfolio_history_date ON portfolio_history(snapshot_date);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS statement_reports;
+ALTER TABLE users DROP COLUMN IF EXISTS statement_emails;
@@ -0,0 +1,11 @@
+-- Monthly statement emails: an opt-in setting, and one row per statement
+-- emailed (month is YYYY-MM) so each month is sent once and users can list
+-- the statements they were sent.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS statement_emails BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS statement_reports (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month   VARCHAR(7) NOT NULL CHECK (month ~ '^[0-9]{4}-[0-9]{2}$'),
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, month)
+);
@@ -0,0 +1,4 @@
+-- Whether the user is emailed a confirmation, with the realized P&L and the
+-- remaining position, each time one of their sells executes. Off until the
+-- user turns it on.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS trade_confirmation_emails BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,5 @@
+-- Per-share difference between a trade's fill price and the quote it was
+-- priced from, under the simulated spread/slippage model: positive when a
+-- buy paid above the quote, negative when a sell received below it. Zero for
+-- trades placed with the model off, including every trade before it existed.
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS slippage NUMERIC(15,2) NOT NULL DEFAULT 0;
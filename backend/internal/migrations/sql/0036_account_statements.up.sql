@@ -0,0 +1,10 @@
+-- Monthly account statements (GET /api/account/statements?month=), stored
+-- once the month has closed and its last snapshot is in portfolio_history.
+-- month is YYYY-MM; statement is the JSON the API returns.
+CREATE TABLE IF NOT EXISTS account_statements (
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month      VARCHAR(7) NOT NULL CHECK (month ~ '^[0-9]{4}-[0-9]{2}$'),
+    statement  JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, month)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_oco_group;
+ALTER TABLE orders DROP COLUMN IF EXISTS oco_group_id;
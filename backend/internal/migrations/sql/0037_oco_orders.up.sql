@@ -0,0 +1,6 @@
+-- One-cancels-other pairs: a take-profit and a stop-loss placed together on
+-- one holding share an oco_group_id. When one fills, the fill's transaction
+-- cancels the other; cancelling either cancels both. NULL for orders placed
+-- on their own.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS oco_group_id VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_orders_oco_group ON orders(oco_group_id) WHERE oco_group_id IS NOT NULL;
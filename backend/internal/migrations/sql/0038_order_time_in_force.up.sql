@@ -0,0 +1,6 @@
+-- Time in force: a DAY order expires at the close of the session it was
+-- placed for; a GTC order rests until it fills, is cancelled or reaches its
+-- expires_at, which the API caps at TRADING_GTC_MAX_DAYS. Orders placed
+-- before this column existed are GTC and keep their expiry, if any.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS time_in_force VARCHAR(3) NOT NULL DEFAULT 'GTC'
+    CHECK (time_in_force IN ('DAY', 'GTC'));
@@ -0,0 +1,27 @@
+-- Recurring investments: "buy $amount of symbol every day, week or month".
+-- day is the weekday (1 = Monday .. 5 = Friday) for WEEKLY plans and the day
+-- of the month (1-28) for MONTHLY; DAILY plans have none. next_run_on is the
+-- New York date the plan is next due; a due date that is not a trading day
+-- runs at the next session. The worker advances it after every attempt, so
+-- last_trade_id and last_error describe the most recent run.
+CREATE TABLE IF NOT EXISTS recurring_investments (
+    id            VARCHAR(255) PRIMARY KEY,
+    user_id       VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol        VARCHAR(10) NOT NULL,
+    amount        NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    frequency     VARCHAR(7) NOT NULL CHECK (frequency IN ('DAILY', 'WEEKLY', 'MONTHLY')),
+    day           SMALLINT,
+    active        BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_on   DATE NOT NULL,
+    last_run_on   DATE,
+    last_trade_id VARCHAR(255),
+    last_error    TEXT,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (
+        (frequency = 'DAILY' AND day IS NULL) OR
+        (frequency = 'WEEKLY' AND day BETWEEN 1 AND 5) OR
+        (frequency = 'MONTHLY' AND day BETWEEN 1 AND 28)
+    )
+);
+CREATE INDEX IF NOT EXISTS idx_recurring_investments_user ON recurring_investments(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_recurring_investments_due ON recurring_investments(next_run_on) WHERE active;
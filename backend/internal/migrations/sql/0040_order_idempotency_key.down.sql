@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_user_idempotency_key;
+ALTER TABLE orders DROP COLUMN IF EXISTS idempotency_key;
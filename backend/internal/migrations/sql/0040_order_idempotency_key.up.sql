@@ -0,0 +1,8 @@
+-- A buy or sell queued as a MARKET order while the market is closed keeps
+-- the request's Idempotency-Key, so a retry returns the queued order instead
+-- of queuing a second one. Keys are per user, as on trades.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_user_idempotency_key
+    ON orders(user_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,19 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS display_currency VARCHAR(3) NOT NULL DEFAULT 'USD'
+    CHECK (display_currency ~ '^[A-Z]{3}$');
+ALTER TABLE users ADD COLUMN IF NOT EXISTS after_hours_orders VARCHAR(6) NOT NULL DEFAULT 'REJECT'
+    CHECK (after_hours_orders IN ('REJECT', 'QUEUE'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS cost_basis_method VARCHAR(7) NOT NULL DEFAULT 'AVERAGE'
+    CHECK (cost_basis_method IN ('FIFO', 'LIFO', 'AVERAGE'));
+ALTER TABLE users ADD COLUMN IF NOT EXISTS trade_confirmation_emails BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS statement_emails BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE users u
+SET display_currency = s.display_currency,
+    after_hours_orders = s.after_hours_orders,
+    cost_basis_method = s.cost_basis_method,
+    trade_confirmation_emails = s.trade_confirmation_emails,
+    statement_emails = s.statement_emails
+FROM user_settings s
+WHERE s.user_id = u.id;
+
+DROP TABLE IF EXISTS user_settings;
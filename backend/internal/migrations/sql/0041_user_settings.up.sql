@@ -0,0 +1,30 @@
+-- Per-user preferences move out of users into their own table, one row per
+-- user. A user without a row has every default; reads LEFT JOIN it and fall
+-- back to the same defaults, so only users who change a setting get a row.
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id                   VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    display_currency          VARCHAR(3) NOT NULL DEFAULT 'USD'
+                              CHECK (display_currency ~ '^[A-Z]{3}$'),
+    after_hours_orders        VARCHAR(6) NOT NULL DEFAULT 'REJECT'
+                              CHECK (after_hours_orders IN ('REJECT', 'QUEUE')),
+    cost_basis_method         VARCHAR(7) NOT NULL DEFAULT 'AVERAGE'
+                              CHECK (cost_basis_method IN ('FIFO', 'LIFO', 'AVERAGE')),
+    trade_confirmation_emails BOOLEAN NOT NULL DEFAULT FALSE,
+    statement_emails          BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+INSERT INTO user_settings (user_id, display_currency, after_hours_orders, cost_basis_method, trade_confirmation_emails, statement_emails)
+SELECT id, display_currency, after_hours_orders, cost_basis_method, trade_confirmation_emails, statement_emails
+FROM users
+WHERE display_currency <> 'USD'
+   OR after_hours_orders <> 'REJECT'
+   OR cost_basis_method <> 'AVERAGE'
+   OR trade_confirmation_emails
+   OR statement_emails
+ON CONFLICT (user_id) DO NOTHING;
+
+ALTER TABLE users DROP COLUMN IF EXISTS display_currency;
+ALTER TABLE users DROP COLUMN IF EXISTS after_hours_orders;
+ALTER TABLE users DROP COLUMN IF EXISTS cost_basis_method;
+ALTER TABLE users DROP COLUMN IF EXISTS trade_confirmation_emails;
+ALTER TABLE users DROP COLUMN IF EXISTS statement_emails;
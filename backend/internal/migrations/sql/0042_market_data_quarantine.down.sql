@@ -0,0 +1 @@
+DROP TABLE IF EXISTS market_data_quarantine;
@@ -0,0 +1,15 @@
+-- Provider prices rejected by the market data sanity checks (non-positive,
+-- repeated date, or an unconfirmed jump), kept for inspection. source is
+-- 'quote' for a latest-price lookup and 'eod' for a daily close.
+CREATE TABLE IF NOT EXISTS market_data_quarantine (
+    id              BIGSERIAL PRIMARY KEY,
+    symbol          VARCHAR(10) NOT NULL,
+    source          VARCHAR(10) NOT NULL CHECK (source IN ('quote', 'eod')),
+    trade_date      DATE NOT NULL,
+    price           NUMERIC(20, 8) NOT NULL,
+    reference_price NUMERIC(20, 8),
+    reason          VARCHAR(30) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_market_data_quarantine_symbol ON market_data_quarantine (symbol, created_at DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE invite_codes DROP COLUMN IF EXISTS league;
+ALTER TABLE invite_codes DROP COLUMN IF EXISTS starting_balance;
+ALTER TABLE users DROP COLUMN IF EXISTS starting_balance;
@@ -0,0 +1,11 @@
+-- The balance each account opened with, which POST /api/account/reset
+-- restores. New accounts get STARTING_BALANCE unless an invite code or the
+-- admin bulk import sets one for their cohort. Existing accounts are taken
+-- to have opened with the old fixed 10000.00.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS starting_balance NUMERIC(15,2) NOT NULL DEFAULT 10000.00
+    CHECK (starting_balance >= 0);
+
+-- Cohort settings an invite code gives the accounts it admits. NULL/empty
+-- uses the defaults.
+ALTER TABLE invite_codes ADD COLUMN IF NOT EXISTS starting_balance NUMERIC(15,2) CHECK (starting_balance >= 0);
+ALTER TABLE invite_codes ADD COLUMN IF NOT EXISTS league VARCHAR(64);
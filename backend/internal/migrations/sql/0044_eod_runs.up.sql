@@ -0,0 +1,12 @@
+-- One row per trading session the end-of-day close job has run for. An
+-- instance claims a session by inserting its row; completed_at is set once
+-- every step has run, and a claim left incomplete for an hour (a crashed
+-- instance) can be taken over.
+CREATE TABLE IF NOT EXISTS eod_runs (
+    session_date DATE PRIMARY KEY,
+    started_at   TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMPTZ,
+    symbols      INTEGER NOT NULL DEFAULT 0,
+    closes       INTEGER NOT NULL DEFAULT 0,
+    failed_steps TEXT[] NOT NULL DEFAULT '{}'
+);
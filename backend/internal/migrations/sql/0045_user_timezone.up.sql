@@ -0,0 +1,3 @@
+-- The IANA time zone a user's days and months are drawn in (daily trade
+-- limits, day change, statements). Timestamps stay UTC.
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'America/New_York';
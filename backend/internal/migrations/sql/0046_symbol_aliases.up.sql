@@ -0,0 +1,15 @@
+-- Ticker changes (FB -> META). From effective_date the old symbol is looked
+-- up as the new one, and once migrated_at is set the holdings, lots,
+-- watchlist rows, pending orders and recurring plans that were keyed by the
+-- old symbol have been re-keyed. Trades, disposals and stock_history keep
+-- the symbol they were recorded under. Rows come from the bundled
+-- corporate-actions dataset (source 'dataset') or an admin (source 'admin').
+CREATE TABLE IF NOT EXISTS symbol_aliases (
+    old_symbol     VARCHAR(10) PRIMARY KEY,
+    new_symbol     VARCHAR(10) NOT NULL CHECK (new_symbol <> old_symbol),
+    effective_date DATE NOT NULL,
+    source         VARCHAR(20) NOT NULL DEFAULT 'dataset' CHECK (source IN ('dataset', 'admin')),
+    migrated_at    TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_symbol_aliases_new ON symbol_aliases(new_symbol);
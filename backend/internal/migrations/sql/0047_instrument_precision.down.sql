@@ -0,0 +1,7 @@
+ALTER TABLE lot_disposals ALTER COLUMN sale_price TYPE NUMERIC(15,2);
+ALTER TABLE orders ALTER COLUMN fill_price TYPE NUMERIC(15,2);
+ALTER TABLE orders ALTER COLUMN trigger_price TYPE NUMERIC(15,2);
+ALTER TABLE trades ALTER COLUMN slippage TYPE NUMERIC(15,2);
+ALTER TABLE trades ALTER COLUMN price TYPE NUMERIC(15,2);
+ALTER TABLE instruments DROP COLUMN IF EXISTS price_decimals;
+ALTER TABLE instruments DROP COLUMN IF EXISTS tick_size;
@@ -0,0 +1,13 @@
+-- Per-instrument price precision. tick_size is the smallest price increment
+-- orders may be placed and filled at, price_decimals the places prices are
+-- shown to. The defaults are the US equity penny tick.
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS tick_size NUMERIC(20,8) NOT NULL DEFAULT 0.01 CHECK (tick_size > 0);
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS price_decimals SMALLINT NOT NULL DEFAULT 2 CHECK (price_decimals BETWEEN 0 AND 8);
+
+-- Fill and trigger prices follow the instrument's tick, which may be finer
+-- than a cent (sub-dollar equities, crypto). Cash amounts stay in cents.
+ALTER TABLE trades ALTER COLUMN price TYPE NUMERIC(20,8);
+ALTER TABLE trades ALTER COLUMN slippage TYPE NUMERIC(20,8);
+ALTER TABLE orders ALTER COLUMN trigger_price TYPE NUMERIC(20,8);
+ALTER TABLE orders ALTER COLUMN fill_price TYPE NUMERIC(20,8);
+ALTER TABLE lot_disposals ALTER COLUMN sale_price TYPE NUMERIC(20,8);
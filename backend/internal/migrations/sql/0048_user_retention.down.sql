@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_last_login;
+ALTER TABLE users DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE users DROP COLUMN IF EXISTS inactivity_notice_sent_at;
+ALTER TABLE users DROP COLUMN IF EXISTS last_login_at;
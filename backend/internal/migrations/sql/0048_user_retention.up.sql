@@ -0,0 +1,10 @@
+-- Inactive-account retention. last_login_at starts at account creation (or
+-- at this migration for existing accounts) and moves on every login. An
+-- account inactive past RETENTION_INACTIVE_DAYS is emailed a warning,
+-- recorded in inactivity_notice_sent_at, which a login clears; absent one,
+-- its PII is scrubbed once the grace period ends and anonymized_at is set.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_login_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS inactivity_notice_sent_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP;
+CREATE INDEX IF NOT EXISTS idx_users_last_login ON users(last_login_at)
+    WHERE NOT is_guest AND anonymized_at IS NULL;
@@ -0,0 +1,19 @@
+-- A user's claim that one of their trades is wrong (e.g. filled at a bad
+-- cached price). An admin either rejects it or reverses the trade, which
+-- posts a compensating trade (order_type 'ADJUSTMENT', recorded in
+-- reversal_trade_id) and moves cash and shares back. A trade can be
+-- disputed once.
+CREATE TABLE IF NOT EXISTS trade_disputes (
+    id                VARCHAR(255) PRIMARY KEY,
+    trade_id          VARCHAR(255) NOT NULL UNIQUE REFERENCES trades(id) ON DELETE CASCADE,
+    user_id           VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason            TEXT NOT NULL,
+    status            VARCHAR(10) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'REVERSED', 'REJECTED')),
+    resolution_note   TEXT NOT NULL DEFAULT '',
+    resolved_by       VARCHAR(255),
+    reversal_trade_id VARCHAR(255),
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at       TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_trade_disputes_user ON trade_disputes(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_trade_disputes_open ON trade_disputes(created_at) WHERE status = 'OPEN';
@@ -0,0 +1,18 @@
+-- Crypto positions can't be represented in whole shares; drop them along
+-- with their history before narrowing the columns back.
+DELETE FROM orders WHERE symbol LIKE '%-USD';
+DELETE FROM lot_disposals WHERE symbol LIKE '%-USD';
+DELETE FROM tax_lots WHERE symbol LIKE '%-USD';
+DELETE FROM portfolio WHERE asset_class = 'crypto';
+DELETE FROM trades WHERE asset_class = 'crypto';
+
+DROP INDEX IF EXISTS idx_trades_asset_class;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS asset_class;
+ALTER TABLE trades DROP COLUMN IF EXISTS asset_class;
+
+ALTER TABLE orders ALTER COLUMN quantity TYPE INTEGER;
+ALTER TABLE lot_disposals ALTER COLUMN quantity TYPE INTEGER;
+ALTER TABLE tax_lots ALTER COLUMN quantity TYPE INTEGER,
+    ALTER COLUMN remaining TYPE INTEGER;
+ALTER TABLE portfolio ALTER COLUMN quantity TYPE INTEGER;
+ALTER TABLE trades ALTER COLUMN quantity TYPE INTEGER;
@@ -0,0 +1,16 @@
+-- Crypto pairs (BTC-USD, ETH-USD) trade in fractions of a unit, so every
+-- ledger quantity moves from whole shares to NUMERIC with 8 decimal places.
+-- Stocks still trade in whole shares; the service enforces that.
+ALTER TABLE trades ALTER COLUMN quantity TYPE NUMERIC(24,8);
+ALTER TABLE portfolio ALTER COLUMN quantity TYPE NUMERIC(24,8);
+ALTER TABLE tax_lots ALTER COLUMN quantity TYPE NUMERIC(24,8),
+    ALTER COLUMN remaining TYPE NUMERIC(24,8);
+ALTER TABLE lot_disposals ALTER COLUMN quantity TYPE NUMERIC(24,8);
+ALTER TABLE orders ALTER COLUMN quantity TYPE NUMERIC(24,8);
+
+-- asset_class follows from the symbol: only crypto pairs end in -USD.
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS asset_class VARCHAR(10)
+    GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS asset_class VARCHAR(10)
+    GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED;
+CREATE INDEX IF NOT EXISTS idx_trades_asset_class ON trades(asset_class, executed_at);
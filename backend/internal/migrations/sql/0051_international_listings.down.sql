@@ -0,0 +1,36 @@
+-- Non-US listings don't fit the narrower columns; drop them along with
+-- their history before narrowing the columns back.
+ALTER TABLE instruments DROP COLUMN IF EXISTS currency;
+
+DELETE FROM symbol_aliases WHERE length(old_symbol) > 10 OR length(new_symbol) > 10;
+DELETE FROM market_data_quarantine WHERE length(symbol) > 10;
+DELETE FROM recurring_investments WHERE length(symbol) > 10;
+DELETE FROM lot_disposals WHERE length(symbol) > 10;
+DELETE FROM tax_lots WHERE length(symbol) > 10;
+DELETE FROM orders WHERE length(symbol) > 10;
+DELETE FROM stock_history WHERE length(symbol) > 10;
+DELETE FROM watchlist WHERE length(symbol) > 10;
+DELETE FROM portfolio WHERE length(symbol) > 10;
+DELETE FROM trades WHERE length(symbol) > 10;
+
+DROP INDEX IF EXISTS idx_trades_asset_class;
+ALTER TABLE trades DROP COLUMN IF EXISTS asset_class;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS asset_class;
+
+ALTER TABLE symbol_aliases ALTER COLUMN old_symbol TYPE VARCHAR(10),
+    ALTER COLUMN new_symbol TYPE VARCHAR(10);
+ALTER TABLE market_data_quarantine ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE recurring_investments ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE lot_disposals ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE tax_lots ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE orders ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE stock_history ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE watchlist ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE portfolio ALTER COLUMN symbol TYPE VARCHAR(10);
+ALTER TABLE trades ALTER COLUMN symbol TYPE VARCHAR(10);
+
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS asset_class VARCHAR(10)
+    GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS asset_class VARCHAR(10)
+    GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED;
+CREATE INDEX IF NOT EXISTS idx_trades_asset_class ON trades(asset_class, executed_at);
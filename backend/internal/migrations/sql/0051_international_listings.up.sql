@@ -0,0 +1,31 @@
+-- Non-US listings carry their exchange's MIC after the ticker (VOD.XLON,
+-- BBD.B.XTSE), which outgrows VARCHAR(10); symbol columns widen to 20 as
+-- instruments.symbol already is. The generated asset_class columns read
+-- symbol, so they are dropped and re-added around the change.
+DROP INDEX IF EXISTS idx_trades_asset_class;
+ALTER TABLE trades DROP COLUMN IF EXISTS asset_class;
+ALTER TABLE portfolio DROP COLUMN IF EXISTS asset_class;
+
+ALTER TABLE trades ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE portfolio ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE watchlist ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE stock_history ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE orders ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE tax_lots ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE lot_disposals ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE recurring_investments ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE market_data_quarantine ALTER COLUMN symbol TYPE VARCHAR(20);
+ALTER TABLE symbol_aliases ALTER COLUMN old_symbol TYPE VARCHAR(20),
+    ALTER COLUMN new_symbol TYPE VARCHAR(20);
+
+ALTER TABLE trades ADD COLUMN IF NOT EXISTS asset_class VARCHAR(10)
+    GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED;
+ALTER TABLE portfolio ADD COLUMN IF NOT EXISTS asset_class VARCHAR(10)
+    GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED;
+CREATE INDEX IF NOT EXISTS idx_trades_asset_class ON trades(asset_class, executed_at);
+
+-- The currency a listing is priced in. Prices themselves are stored
+-- converted into USD.
+ALTER TABLE instruments ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+UPDATE instruments SET currency = 'GBP' WHERE symbol LIKE '%.XLON';
+UPDATE instruments SET currency = 'CAD' WHERE symbol LIKE '%.XTSE';
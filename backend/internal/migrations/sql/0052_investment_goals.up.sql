@@ -0,0 +1,22 @@
+-- A user's investment goal: reach a total account value (VALUE, target in
+-- dollars) or a return on the account since the goal was set (RETURN,
+-- target in percent), optionally by target_date. Progress is measured from
+-- baseline_value, the account's value in the latest snapshot when the goal
+-- was set, or at the first close after it for an account with none yet.
+-- last_milestone is the highest quarter of the way (25, 50, 75 or 100) the
+-- user has been notified of; reaching 100 sets achieved_on, the session
+-- date of the snapshot that got there.
+CREATE TABLE IF NOT EXISTS investment_goals (
+    id             VARCHAR(255) PRIMARY KEY,
+    user_id        VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind           VARCHAR(6) NOT NULL CHECK (kind IN ('VALUE', 'RETURN')),
+    name           VARCHAR(100) NOT NULL,
+    target         NUMERIC(15,2) NOT NULL CHECK (target > 0),
+    target_date    DATE,
+    baseline_value NUMERIC(20,2),
+    last_milestone SMALLINT NOT NULL DEFAULT 0,
+    achieved_on    DATE,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_investment_goals_user ON investment_goals(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_investment_goals_open ON investment_goals(user_id) WHERE achieved_on IS NULL;
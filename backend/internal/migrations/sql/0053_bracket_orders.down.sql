@@ -0,0 +1,6 @@
+UPDATE orders SET status = 'CANCELLED', closed_at = CURRENT_TIMESTAMP WHERE status = 'WAITING';
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('PENDING', 'FILLED', 'CANCELLED', 'EXPIRED', 'FAILED'));
+DROP INDEX IF EXISTS idx_orders_parent;
+ALTER TABLE orders DROP COLUMN IF EXISTS parent_order_id;
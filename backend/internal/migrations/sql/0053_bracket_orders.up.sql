@@ -0,0 +1,10 @@
+-- Bracket orders: an entry with a take-profit and a stop-loss attached. The
+-- two exits point at the entry through parent_order_id and wait as WAITING,
+-- never filling, until it fills; the fill then makes them PENDING as an OCO
+-- pair. An entry that is cancelled, expires or fails takes its waiting exits
+-- with it. NULL for every other order.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS parent_order_id VARCHAR(255) REFERENCES orders(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_orders_parent ON orders(parent_order_id) WHERE parent_order_id IS NOT NULL;
+ALTER TABLE orders DROP CONSTRAINT orders_status_check;
+ALTER TABLE orders ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('WAITING', 'PENDING', 'FILLED', 'CANCELLED', 'EXPIRED', 'FAILED'));
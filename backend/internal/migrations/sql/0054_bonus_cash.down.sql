@@ -0,0 +1,3 @@
+ALTER TABLE portfolio_history DROP COLUMN IF EXISTS bonus_cash;
+ALTER TABLE users DROP COLUMN IF EXISTS bonus_claimed_at;
+DROP TABLE IF EXISTS bonus_grants;
@@ -0,0 +1,18 @@
+-- Bonus cash: a small paper-money grant a user can claim again once a
+-- cooldown has passed, so a busted account can get back into the game
+-- without a full reset. Every grant is recorded in bonus_grants, which a
+-- reset leaves alone so the cooldown outlives it; users.bonus_claimed_at is
+-- the time of the latest, checked and set in the same UPDATE that credits
+-- the balance.
+CREATE TABLE IF NOT EXISTS bonus_grants (
+    id         VARCHAR(255) PRIMARY KEY,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount     NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_bonus_grants_user ON bonus_grants(user_id, granted_at);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS bonus_claimed_at TIMESTAMPTZ;
+
+-- The total granted to the user when the snapshot was taken, so returns can
+-- leave grants out: a grant is cash put in, not a gain.
+ALTER TABLE portfolio_history ADD COLUMN IF NOT EXISTS bonus_cash NUMERIC(15,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+-- The latest quote fetched for each symbol. MarketService falls back to it,
+-- flagged stale, when the provider is down and Redis has nothing cached, so
+-- an outage doesn't stop every price lookup and trade.
+CREATE TABLE IF NOT EXISTS last_prices (
+    symbol VARCHAR(20) PRIMARY KEY,
+    price NUMERIC(24, 8) NOT NULL CHECK (price > 0),
+    quote_date VARCHAR(10) NOT NULL,
+    fetched_at TIMESTAMPTZ NOT NULL
+);
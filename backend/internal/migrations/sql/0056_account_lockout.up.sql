@@ -0,0 +1,5 @@
+-- Consecutive failed password logins, reset by any successful login. Once
+-- they reach the lockout threshold the account refuses password logins
+-- until locked_until.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_logins INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP;
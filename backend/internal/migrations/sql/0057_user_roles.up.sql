@@ -0,0 +1,5 @@
+-- What an account may do. Admins can also use /api/admin; the role travels
+-- in the session token, so a change applies from the next login. Accounts
+-- in ADMIN_EMAILS are promoted at startup.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'user'
+    CONSTRAINT users_role_valid CHECK (role IN ('user', 'admin'));
@@ -0,0 +1,9 @@
+-- Account bundles already imported, so each is imported once: a bundle
+-- replayed into the same or another account is refused. user_id is kept
+-- only for support and survives the account's deletion as NULL, so the
+-- bundle stays used.
+CREATE TABLE IF NOT EXISTS account_bundle_imports (
+    bundle_id   VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    imported_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
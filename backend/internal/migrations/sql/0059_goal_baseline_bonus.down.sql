@@ -0,0 +1 @@
+ALTER TABLE investment_goals DROP COLUMN IF EXISTS baseline_bonus_cash;
@@ -0,0 +1,14 @@
+-- The bonus cash granted to the user by the goal's baseline snapshot, so
+-- progress can leave out grants made since: a grant is cash put in, not
+-- progress. Existing goals take it from the latest snapshot on or before
+-- the day they were set, or the first one after for a goal set before any.
+ALTER TABLE investment_goals ADD COLUMN IF NOT EXISTS baseline_bonus_cash NUMERIC(15,2) NOT NULL DEFAULT 0;
+UPDATE investment_goals g SET baseline_bonus_cash = COALESCE(
+    (SELECT ph.bonus_cash FROM portfolio_history ph
+     WHERE ph.user_id = g.user_id AND ph.snapshot_date <= (g.created_at AT TIME ZONE 'America/New_York')::date
+     ORDER BY ph.snapshot_date DESC LIMIT 1),
+    (SELECT ph.bonus_cash FROM portfolio_history ph
+     WHERE ph.user_id = g.user_id AND ph.snapshot_date > (g.created_at AT TIME ZONE 'America/New_York')::date
+     ORDER BY ph.snapshot_date LIMIT 1),
+    0)
+WHERE g.baseline_value IS NOT NULL;
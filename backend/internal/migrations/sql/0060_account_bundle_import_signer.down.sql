@@ -0,0 +1,4 @@
+ALTER TABLE account_bundle_imports
+    DROP COLUMN IF EXISTS replaced,
+    DROP COLUMN IF EXISTS key_id,
+    DROP COLUMN IF EXISTS source;
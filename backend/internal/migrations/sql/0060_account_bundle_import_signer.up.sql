@@ -0,0 +1,6 @@
+-- Where each imported bundle came from: the source deployment it names, the
+-- public key that signed it, and whether it replaced an account in use.
+ALTER TABLE account_bundle_imports
+    ADD COLUMN IF NOT EXISTS source   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS key_id   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS replaced BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- An admin's lock, kept apart from locked_until so that signing in another
+-- way (which clears the failed-login lock) can't lift it. Only an admin
+-- unlock clears it.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS admin_locked_until TIMESTAMP;
@@ -0,0 +1,7 @@
+-- Outstanding passkey login challenges. The ceremony token carries the
+-- challenge to the client; a row here is what makes it usable once. Finishing
+-- a login deletes the row, so a replayed ceremony token finds nothing.
+CREATE TABLE IF NOT EXISTS passkey_login_challenges (
+    challenge  VARCHAR(128) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
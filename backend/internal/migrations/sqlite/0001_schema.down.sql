@@ -0,0 +1,35 @@
+DROP TABLE IF EXISTS passkey_login_challenges;
+DROP TABLE IF EXISTS account_bundle_imports;
+DROP TABLE IF EXISTS last_prices;
+DROP TABLE IF EXISTS bonus_grants;
+DROP TABLE IF EXISTS investment_goals;
+DROP TABLE IF EXISTS trade_disputes;
+DROP TABLE IF EXISTS symbol_aliases;
+DROP TABLE IF EXISTS eod_runs;
+DROP TABLE IF EXISTS market_data_quarantine;
+DROP TABLE IF EXISTS user_settings;
+DROP TABLE IF EXISTS recurring_investments;
+DROP TABLE IF EXISTS account_statements;
+DROP TABLE IF EXISTS statement_reports;
+DROP TABLE IF EXISTS portfolio_history;
+DROP TABLE IF EXISTS invite_code_redemptions;
+DROP TABLE IF EXISTS invite_codes;
+DROP TABLE IF EXISTS lot_disposals;
+DROP TABLE IF EXISTS tax_lots;
+DROP TABLE IF EXISTS instrument_classifications;
+DROP TABLE IF EXISTS curated_list_subscriptions;
+DROP TABLE IF EXISTS curated_lists;
+DROP TABLE IF EXISTS trade_notes;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS webauthn_credentials;
+DROP TABLE IF EXISTS audit_events;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS instruments;
+DROP TABLE IF EXISTS research_queries;
+DROP TABLE IF EXISTS chunks;
+DROP TABLE IF EXISTS documents;
+DROP TABLE IF EXISTS stock_history;
+DROP TABLE IF EXISTS watchlist;
+DROP TABLE IF EXISTS portfolio;
+DROP TABLE IF EXISTS trades;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,508 @@
+-- The schema for standalone deployments that keep their data in SQLite
+-- (DATABASE_URL=sqlite:...): the PostgreSQL schema as of migration 0062,
+-- written out in full. Types follow internal/sqlite's storage: times are
+-- UTC text, decimals numbers, JSONB and arrays text. The research tables
+-- are kept so account deletion can detach a user's queries, but there are
+-- no embeddings (pgvector), so research stays PostgreSQL-only.
+--
+-- A PostgreSQL migration that changes the schema needs a matching migration
+-- here.
+
+CREATE TABLE users (
+    id                         VARCHAR(255) PRIMARY KEY,
+    email                      VARCHAR(255) UNIQUE,
+    password                   TEXT,
+    created_at                 TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    balance                    NUMERIC(15,2) DEFAULT 10000.00 CONSTRAINT users_balance_non_negative CHECK (balance >= 0),
+    email_verified             BOOLEAN DEFAULT FALSE,
+    verification_token         VARCHAR(255),
+    verification_token_expires TIMESTAMP,
+    google_id                  VARCHAR(255) UNIQUE,
+    created_via                VARCHAR(50) DEFAULT 'email',
+    reauth_required_after      TIMESTAMP,
+    avatar_key                 TEXT,
+    avatar_url                 TEXT,
+    username                   VARCHAR(20),
+    username_changed_at        TIMESTAMP,
+    magic_link_jti             VARCHAR(64),
+    magic_link_expires         TIMESTAMP,
+    is_guest                   BOOLEAN NOT NULL DEFAULT FALSE,
+    guest_expires_at           TIMESTAMP,
+    league                     VARCHAR(64),
+    starting_balance           NUMERIC(15,2) NOT NULL DEFAULT 10000.00 CHECK (starting_balance >= 0),
+    last_login_at              TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    inactivity_notice_sent_at  TIMESTAMP,
+    anonymized_at              TIMESTAMP,
+    bonus_claimed_at           TIMESTAMP,
+    failed_logins              INT NOT NULL DEFAULT 0,
+    locked_until               TIMESTAMP,
+    role                       VARCHAR(16) NOT NULL DEFAULT 'user' CONSTRAINT users_role_valid CHECK (role IN ('user', 'admin')),
+    admin_locked_until         TIMESTAMP
+);
+CREATE UNIQUE INDEX idx_users_username_lower ON users (LOWER(username));
+CREATE INDEX idx_users_guest_expires ON users(guest_expires_at) WHERE is_guest;
+CREATE INDEX idx_users_league ON users(league) WHERE league IS NOT NULL;
+CREATE INDEX idx_users_last_login ON users(last_login_at) WHERE NOT is_guest AND anonymized_at IS NULL;
+
+CREATE TABLE trades (
+    id              VARCHAR(255) PRIMARY KEY,
+    user_id         VARCHAR(255) NOT NULL,
+    symbol          VARCHAR(20) NOT NULL,
+    action          VARCHAR(10) NOT NULL,
+    quantity        NUMERIC(24,8) NOT NULL,
+    price           NUMERIC(20,8) NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'COMPLETED',
+    executed_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    idempotency_key VARCHAR(255),
+    order_type      VARCHAR(20) NOT NULL DEFAULT 'MARKET',
+    slippage        NUMERIC(20,8) NOT NULL DEFAULT 0,
+    asset_class     VARCHAR(10) GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED
+);
+CREATE INDEX idx_trades_user_id_executed_at ON trades(user_id, executed_at DESC);
+CREATE UNIQUE INDEX idx_trades_user_idempotency_key ON trades(user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+CREATE INDEX idx_trades_user_symbol_executed_at ON trades(user_id, symbol, executed_at DESC);
+CREATE INDEX idx_trades_user_action_executed_at ON trades(user_id, action, executed_at DESC);
+CREATE INDEX idx_trades_asset_class ON trades(asset_class, executed_at);
+
+-- trades is append-only, except that account purges may delete a user's
+-- trades after SELECT set_config('papertrader.purging_users', 'on', true).
+CREATE TRIGGER trades_no_update BEFORE UPDATE ON trades
+BEGIN
+    SELECT RAISE(ABORT, 'trades is append-only — UPDATE is not permitted');
+END;
+CREATE TRIGGER trades_no_delete BEFORE DELETE ON trades
+WHEN current_setting('papertrader.purging_users', TRUE) IS NOT 'on'
+BEGIN
+    SELECT RAISE(ABORT, 'trades is append-only — DELETE is not permitted');
+END;
+
+CREATE TABLE portfolio (
+    id          VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) NOT NULL,
+    symbol      VARCHAR(20) NOT NULL,
+    quantity    NUMERIC(24,8) NOT NULL DEFAULT 0,
+    avg_price   NUMERIC(20,8) NOT NULL,
+    created_at  TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    margin      NUMERIC(15,2) NOT NULL DEFAULT 0 CHECK (margin >= 0),
+    asset_class VARCHAR(10) GENERATED ALWAYS AS (CASE WHEN symbol LIKE '%-USD' THEN 'crypto' ELSE 'equity' END) STORED,
+    UNIQUE(user_id, symbol)
+);
+CREATE INDEX idx_portfolio_user_id ON portfolio(user_id);
+
+CREATE TABLE watchlist (
+    id         VARCHAR(255) PRIMARY KEY,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id),
+    symbol     VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    UNIQUE(user_id, symbol)
+);
+CREATE INDEX idx_watchlist_user_id ON watchlist(user_id);
+
+CREATE TABLE stock_history (
+    symbol     VARCHAR(20) NOT NULL,
+    trade_date DATE NOT NULL,
+    close      NUMERIC(20,8) NOT NULL,
+    volume     BIGINT NOT NULL DEFAULT 0,
+    fetched_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (symbol, trade_date)
+);
+
+CREATE TABLE documents (
+    id          VARCHAR(64) PRIMARY KEY,
+    source_type VARCHAR(20) NOT NULL,
+    source_url  TEXT NOT NULL CHECK (source_url GLOB 'http://*' OR source_url GLOB 'https://*'),
+    symbol      VARCHAR(10),
+    title       TEXT,
+    filed_at    TIMESTAMP,
+    metadata    TEXT NOT NULL DEFAULT '{}',
+    fetched_at  TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    UNIQUE(source_url)
+);
+CREATE INDEX idx_documents_symbol_filed_at ON documents(symbol, filed_at DESC);
+CREATE INDEX idx_documents_source_type ON documents(source_type);
+
+CREATE TABLE chunks (
+    id          VARCHAR(64) PRIMARY KEY,
+    document_id VARCHAR(64) NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+    chunk_index INTEGER NOT NULL,
+    text        TEXT NOT NULL,
+    token_count INTEGER NOT NULL,
+    section     TEXT,
+    UNIQUE(document_id, chunk_index)
+);
+CREATE INDEX idx_chunks_document_id ON chunks(document_id);
+
+CREATE TABLE research_queries (
+    id                VARCHAR(64) PRIMARY KEY,
+    user_id           VARCHAR(255) REFERENCES users(id),
+    query_text        TEXT NOT NULL,
+    answer_text       TEXT,
+    refused           BOOLEAN NOT NULL DEFAULT FALSE,
+    refusal_reason    VARCHAR(64),
+    citations         TEXT NOT NULL DEFAULT '[]',
+    retrieval_ms      INTEGER,
+    generation_ms     INTEGER,
+    total_ms          INTEGER,
+    embed_tokens      INTEGER,
+    prompt_tokens     INTEGER,
+    completion_tokens INTEGER,
+    cost_usd_micros   INTEGER,
+    model             VARCHAR(64),
+    created_at        TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_research_queries_user_id ON research_queries(user_id);
+CREATE INDEX idx_research_queries_created_at ON research_queries(created_at DESC);
+
+CREATE TABLE instruments (
+    symbol         VARCHAR(20) PRIMARY KEY,
+    name           TEXT NOT NULL DEFAULT '',
+    exchange       VARCHAR(20) NOT NULL DEFAULT '',
+    asset_type     VARCHAR(20) NOT NULL DEFAULT 'stock',
+    created_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    halted         BOOLEAN NOT NULL DEFAULT FALSE,
+    halt_reason    TEXT NOT NULL DEFAULT '',
+    halt_source    VARCHAR(20) NOT NULL DEFAULT '',
+    halted_at      TIMESTAMP,
+    tick_size      NUMERIC(20,8) NOT NULL DEFAULT 0.01 CHECK (tick_size > 0),
+    price_decimals SMALLINT NOT NULL DEFAULT 2 CHECK (price_decimals BETWEEN 0 AND 8),
+    currency       CHAR(3) NOT NULL DEFAULT 'USD'
+);
+CREATE INDEX idx_instruments_exchange ON instruments(exchange);
+CREATE INDEX idx_instruments_halted ON instruments(symbol) WHERE halted;
+
+CREATE TABLE notifications (
+    id         VARCHAR(255) PRIMARY KEY,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind       VARCHAR(50) NOT NULL,
+    title      TEXT NOT NULL,
+    body       TEXT NOT NULL DEFAULT '',
+    read_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_notifications_user_created ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_user_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+CREATE TABLE audit_events (
+    id         VARCHAR(255) PRIMARY KEY,
+    user_id    VARCHAR(255) REFERENCES users(id) ON DELETE CASCADE,
+    kind       VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    country    VARCHAR(2) NOT NULL DEFAULT '',
+    details    TEXT NOT NULL DEFAULT '{}',
+    flagged    BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_audit_events_user_kind_created ON audit_events(user_id, kind, created_at DESC);
+CREATE INDEX idx_audit_events_flagged ON audit_events(created_at DESC) WHERE flagged;
+
+CREATE TABLE webauthn_credentials (
+    id           VARCHAR(1400) PRIMARY KEY,
+    user_id      VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name         VARCHAR(64) NOT NULL DEFAULT '',
+    credential   TEXT NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    last_used_at TIMESTAMP
+);
+CREATE INDEX idx_webauthn_credentials_user ON webauthn_credentials(user_id);
+
+CREATE TABLE orders (
+    id              VARCHAR(255) PRIMARY KEY,
+    user_id         VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol          VARCHAR(20) NOT NULL,
+    order_type      VARCHAR(20) NOT NULL,
+    quantity        NUMERIC(24,8) NOT NULL CHECK (quantity > 0),
+    trigger_price   NUMERIC(20,8) CHECK (trigger_price > 0),
+    status          VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+    created_at      TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    closed_at       TIMESTAMP,
+    trade_id        VARCHAR(255),
+    fill_price      NUMERIC(20,8),
+    failure_reason  TEXT,
+    side            VARCHAR(4) NOT NULL DEFAULT 'SELL',
+    expires_at      TIMESTAMP,
+    oco_group_id    VARCHAR(255),
+    time_in_force   VARCHAR(3) NOT NULL DEFAULT 'GTC' CHECK (time_in_force IN ('DAY', 'GTC')),
+    idempotency_key VARCHAR(255),
+    parent_order_id VARCHAR(255) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT orders_side_check CHECK (side IN ('BUY', 'SELL')),
+    CONSTRAINT orders_order_type_check CHECK (order_type IN ('MARKET', 'LIMIT', 'STOP', 'STOP_LOSS', 'TAKE_PROFIT')),
+    CONSTRAINT orders_protective_side_check CHECK (order_type IN ('MARKET', 'LIMIT', 'STOP') OR side = 'SELL'),
+    CONSTRAINT orders_status_check CHECK (status IN ('WAITING', 'PENDING', 'FILLED', 'CANCELLED', 'EXPIRED', 'FAILED')),
+    CONSTRAINT orders_trigger_price_check CHECK ((order_type = 'MARKET') = (trigger_price IS NULL))
+);
+CREATE INDEX idx_orders_user ON orders(user_id, created_at DESC);
+CREATE INDEX idx_orders_pending_symbol ON orders(symbol) WHERE status = 'PENDING';
+CREATE INDEX idx_orders_pending_expiry ON orders(expires_at) WHERE status = 'PENDING' AND expires_at IS NOT NULL;
+CREATE INDEX idx_orders_oco_group ON orders(oco_group_id) WHERE oco_group_id IS NOT NULL;
+CREATE UNIQUE INDEX idx_orders_user_idempotency_key ON orders(user_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+CREATE INDEX idx_orders_parent ON orders(parent_order_id) WHERE parent_order_id IS NOT NULL;
+
+-- A bracket entry's exits wait until it fills, which makes them PENDING,
+-- and are cancelled with it if it is cancelled, expires or fails. On
+-- PostgreSQL the statements that close the entry also update its exits, in
+-- data-modifying CTEs that SQLite lacks; here these triggers do it.
+CREATE TRIGGER orders_bracket_exits_cancel AFTER UPDATE OF status ON orders
+WHEN NEW.status IN ('CANCELLED', 'EXPIRED', 'FAILED') AND OLD.status IN ('PENDING', 'WAITING')
+BEGIN
+    UPDATE orders SET status = 'CANCELLED', closed_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+    WHERE parent_order_id = NEW.id AND status = 'WAITING';
+END;
+CREATE TRIGGER orders_bracket_exits_release AFTER UPDATE OF status ON orders
+WHEN NEW.status = 'FILLED' AND OLD.status = 'PENDING'
+BEGIN
+    UPDATE orders SET status = 'PENDING' WHERE parent_order_id = NEW.id AND status = 'WAITING';
+END;
+
+CREATE TABLE trade_notes (
+    trade_id   VARCHAR(255) PRIMARY KEY REFERENCES trades(id) ON DELETE CASCADE,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    note       TEXT NOT NULL DEFAULT '',
+    tags       TEXT NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_trade_notes_user ON trade_notes(user_id);
+
+CREATE TABLE curated_lists (
+    slug        VARCHAR(64) PRIMARY KEY,
+    name        VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    symbols     TEXT NOT NULL DEFAULT '{}',
+    is_default  BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE UNIQUE INDEX idx_curated_lists_default ON curated_lists (is_default) WHERE is_default;
+
+CREATE TABLE curated_list_subscriptions (
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    list_slug  VARCHAR(64) NOT NULL REFERENCES curated_lists(slug) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, list_slug)
+);
+
+INSERT INTO curated_lists (slug, name, description, symbols, is_default) VALUES
+    ('getting-started', 'Getting started', 'Widely held large caps and index funds to follow while you learn.',
+     '{SPY,QQQ,AAPL,MSFT,AMZN,JPM,KO}', TRUE),
+    ('dividend-aristocrats', 'Dividend aristocrats', 'S&P 500 companies that have raised their dividend for at least 25 consecutive years.',
+     '{KO,PG,JNJ,PEP,MMM,ABBV,CL,WMT,MCD,XOM}', FALSE),
+    ('big-tech', 'Big tech', 'The largest US technology companies by market capitalisation.',
+     '{AAPL,MSFT,GOOGL,AMZN,META,NVDA,TSLA}', FALSE);
+
+-- Every sign-up path (password, Google, guest) gets the default list.
+CREATE TRIGGER users_subscribe_default_list AFTER INSERT ON users
+BEGIN
+    INSERT OR IGNORE INTO curated_list_subscriptions (user_id, list_slug)
+    SELECT NEW.id, slug FROM curated_lists WHERE is_default;
+END;
+
+CREATE TABLE instrument_classifications (
+    symbol     VARCHAR(20) PRIMARY KEY,
+    sector     VARCHAR(64) NOT NULL,
+    industry   VARCHAR(128) NOT NULL DEFAULT '',
+    indices    TEXT NOT NULL DEFAULT '{}',
+    source     VARCHAR(20) NOT NULL DEFAULT 'dataset' CHECK (source IN ('dataset', 'admin')),
+    updated_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_instrument_classifications_sector ON instrument_classifications(sector);
+
+CREATE TABLE tax_lots (
+    id          VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol      VARCHAR(20) NOT NULL,
+    trade_id    VARCHAR(255),
+    quantity    NUMERIC(24,8) NOT NULL CHECK (quantity > 0),
+    remaining   NUMERIC(24,8) NOT NULL CHECK (remaining >= 0 AND remaining <= quantity),
+    price       NUMERIC(20,8) NOT NULL,
+    acquired_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_tax_lots_open ON tax_lots(user_id, symbol, acquired_at) WHERE remaining > 0;
+
+CREATE TABLE lot_disposals (
+    id            VARCHAR(255) PRIMARY KEY,
+    user_id       VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sell_trade_id VARCHAR(255) NOT NULL,
+    lot_id        VARCHAR(255) REFERENCES tax_lots(id) ON DELETE SET NULL,
+    symbol        VARCHAR(20) NOT NULL,
+    quantity      NUMERIC(24,8) NOT NULL CHECK (quantity > 0),
+    cost_price    NUMERIC(20,8) NOT NULL,
+    sale_price    NUMERIC(20,8) NOT NULL,
+    realized      NUMERIC(15,2) NOT NULL,
+    method        VARCHAR(7) NOT NULL,
+    disposed_at   TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_lot_disposals_sell_trade ON lot_disposals(sell_trade_id);
+CREATE INDEX idx_lot_disposals_user ON lot_disposals(user_id, disposed_at);
+
+CREATE TABLE invite_codes (
+    code             VARCHAR(32) PRIMARY KEY,
+    note             VARCHAR(200) NOT NULL DEFAULT '',
+    max_uses         INTEGER NOT NULL CHECK (max_uses > 0),
+    uses             INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0 AND uses <= max_uses),
+    expires_at       TIMESTAMP,
+    revoked_at       TIMESTAMP,
+    created_by       VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    created_at       TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    starting_balance NUMERIC(15,2) CHECK (starting_balance >= 0),
+    league           VARCHAR(64)
+);
+
+CREATE TABLE invite_code_redemptions (
+    code        VARCHAR(32) NOT NULL REFERENCES invite_codes(code) ON DELETE CASCADE,
+    user_id     VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redeemed_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (code, user_id)
+);
+CREATE INDEX idx_invite_code_redemptions_user ON invite_code_redemptions(user_id);
+
+CREATE TABLE portfolio_history (
+    user_id        VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    snapshot_date  DATE NOT NULL,
+    cash           NUMERIC(15,2) NOT NULL,
+    holdings_value NUMERIC(20,2) NOT NULL,
+    total_value    NUMERIC(20,2) NOT NULL,
+    partial        BOOLEAN NOT NULL DEFAULT FALSE,
+    recorded_at    TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    bonus_cash     NUMERIC(15,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, snapshot_date)
+);
+CREATE INDEX idx_portfolio_history_date ON portfolio_history(snapshot_date);
+
+CREATE TABLE statement_reports (
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month   VARCHAR(7) NOT NULL CHECK (month GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]'),
+    sent_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, month)
+);
+
+CREATE TABLE account_statements (
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month      VARCHAR(7) NOT NULL CHECK (month GLOB '[0-9][0-9][0-9][0-9]-[0-9][0-9]'),
+    statement  TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (user_id, month)
+);
+
+CREATE TABLE recurring_investments (
+    id            VARCHAR(255) PRIMARY KEY,
+    user_id       VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    symbol        VARCHAR(20) NOT NULL,
+    amount        NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    frequency     VARCHAR(7) NOT NULL CHECK (frequency IN ('DAILY', 'WEEKLY', 'MONTHLY')),
+    day           SMALLINT,
+    active        BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_on   DATE NOT NULL,
+    last_run_on   DATE,
+    last_trade_id VARCHAR(255),
+    last_error    TEXT,
+    created_at    TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    CHECK (
+        (frequency = 'DAILY' AND day IS NULL) OR
+        (frequency = 'WEEKLY' AND day BETWEEN 1 AND 5) OR
+        (frequency = 'MONTHLY' AND day BETWEEN 1 AND 28)
+    )
+);
+CREATE INDEX idx_recurring_investments_user ON recurring_investments(user_id, created_at);
+CREATE INDEX idx_recurring_investments_due ON recurring_investments(next_run_on) WHERE active;
+
+CREATE TABLE user_settings (
+    user_id                   VARCHAR(255) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    display_currency          VARCHAR(3) NOT NULL DEFAULT 'USD' CHECK (display_currency GLOB '[A-Z][A-Z][A-Z]'),
+    after_hours_orders        VARCHAR(6) NOT NULL DEFAULT 'REJECT' CHECK (after_hours_orders IN ('REJECT', 'QUEUE')),
+    cost_basis_method         VARCHAR(7) NOT NULL DEFAULT 'AVERAGE' CHECK (cost_basis_method IN ('FIFO', 'LIFO', 'AVERAGE')),
+    trade_confirmation_emails BOOLEAN NOT NULL DEFAULT FALSE,
+    statement_emails          BOOLEAN NOT NULL DEFAULT FALSE,
+    timezone                  VARCHAR(64) NOT NULL DEFAULT 'America/New_York'
+);
+
+CREATE TABLE market_data_quarantine (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    symbol          VARCHAR(20) NOT NULL,
+    source          VARCHAR(10) NOT NULL CHECK (source IN ('quote', 'eod')),
+    trade_date      DATE NOT NULL,
+    price           NUMERIC(20,8) NOT NULL,
+    reference_price NUMERIC(20,8),
+    reason          VARCHAR(30) NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_market_data_quarantine_symbol ON market_data_quarantine (symbol, created_at DESC);
+
+CREATE TABLE eod_runs (
+    session_date DATE PRIMARY KEY,
+    started_at   TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    completed_at TIMESTAMP,
+    symbols      INTEGER NOT NULL DEFAULT 0,
+    closes       INTEGER NOT NULL DEFAULT 0,
+    failed_steps TEXT NOT NULL DEFAULT '{}'
+);
+
+CREATE TABLE symbol_aliases (
+    old_symbol     VARCHAR(20) PRIMARY KEY,
+    new_symbol     VARCHAR(20) NOT NULL CHECK (new_symbol <> old_symbol),
+    effective_date DATE NOT NULL,
+    source         VARCHAR(20) NOT NULL DEFAULT 'dataset' CHECK (source IN ('dataset', 'admin')),
+    migrated_at    TIMESTAMP,
+    created_at     TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_symbol_aliases_new ON symbol_aliases(new_symbol);
+
+CREATE TABLE trade_disputes (
+    id                VARCHAR(255) PRIMARY KEY,
+    trade_id          VARCHAR(255) NOT NULL UNIQUE REFERENCES trades(id) ON DELETE CASCADE,
+    user_id           VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason            TEXT NOT NULL,
+    status            VARCHAR(10) NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'REVERSED', 'REJECTED')),
+    resolution_note   TEXT NOT NULL DEFAULT '',
+    resolved_by       VARCHAR(255),
+    reversal_trade_id VARCHAR(255),
+    created_at        TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    resolved_at       TIMESTAMP
+);
+CREATE INDEX idx_trade_disputes_user ON trade_disputes(user_id, created_at DESC);
+CREATE INDEX idx_trade_disputes_open ON trade_disputes(created_at) WHERE status = 'OPEN';
+
+CREATE TABLE investment_goals (
+    id                  VARCHAR(255) PRIMARY KEY,
+    user_id             VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind                VARCHAR(6) NOT NULL CHECK (kind IN ('VALUE', 'RETURN')),
+    name                VARCHAR(100) NOT NULL,
+    target              NUMERIC(15,2) NOT NULL CHECK (target > 0),
+    target_date         DATE,
+    baseline_value      NUMERIC(20,2),
+    last_milestone      SMALLINT NOT NULL DEFAULT 0,
+    achieved_on         DATE,
+    created_at          TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    baseline_bonus_cash NUMERIC(15,2) NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_investment_goals_user ON investment_goals(user_id, created_at);
+CREATE INDEX idx_investment_goals_open ON investment_goals(user_id) WHERE achieved_on IS NULL;
+
+CREATE TABLE bonus_grants (
+    id         VARCHAR(255) PRIMARY KEY,
+    user_id    VARCHAR(255) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount     NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+    granted_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+CREATE INDEX idx_bonus_grants_user ON bonus_grants(user_id, granted_at);
+
+CREATE TABLE last_prices (
+    symbol     VARCHAR(20) PRIMARY KEY,
+    price      NUMERIC(24,8) NOT NULL CHECK (price > 0),
+    quote_date VARCHAR(10) NOT NULL,
+    fetched_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE account_bundle_imports (
+    bundle_id   VARCHAR(255) PRIMARY KEY,
+    user_id     VARCHAR(255) REFERENCES users(id) ON DELETE SET NULL,
+    imported_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    source      TEXT NOT NULL DEFAULT '',
+    key_id      TEXT NOT NULL DEFAULT '',
+    replaced    BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE passkey_login_challenges (
+    challenge  VARCHAR(128) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL
+);
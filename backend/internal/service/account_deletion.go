@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+
+	"papertrader/internal/data"
+)
+
+// AccountDeletionSender is the subset of EmailService used by
+// AccountDeletionService.
+type AccountDeletionSender interface {
+	SendAccountDeletedEmail(to string) error
+}
+
+// AccountDeletionService erases an account at its owner's request (the
+// GDPR right to erasure). Unlike retention anonymization, nothing of the
+// account is kept: trades and holdings go with it.
+type AccountDeletionService struct {
+	users   *data.UserStore
+	email   AccountDeletionSender
+	storage ObjectStorage
+	client  *redis.Client
+}
+
+// NewAccountDeletionService builds the service. email, storage and client
+// may each be nil: no confirmation is sent, the avatar object is left
+// behind, or there are no Redis keys to clear.
+func NewAccountDeletionService(users *data.UserStore, email AccountDeletionSender, storage ObjectStorage, client *redis.Client) *AccountDeletionService {
+	return &AccountDeletionService{users: users, email: email, storage: storage, client: client}
+}
+
+// Delete removes userID and everything stored under it in one transaction
+// (see data.UserStore.DeleteAccount), then deletes its avatar object,
+// clears its recently viewed list, usage counters and rate-limit windows
+// from Redis, and emails a confirmation to the address it had. Those
+// follow-ups are logged when they fail: the account is already gone.
+func (s *AccountDeletionService) Delete(ctx context.Context, userID string) error {
+	deleted, err := s.users.DeleteAccount(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &UserNotFoundError{}
+		}
+		return err
+	}
+	slog.Info("account deleted at the user's request", "user_id", userID, "component", "account")
+
+	if s.storage != nil && deleted.AvatarKey != "" {
+		if err := s.storage.Delete(ctx, deleted.AvatarKey); err != nil {
+			slog.Warn("failed to delete avatar object", "key", deleted.AvatarKey, "err", err, "component", "account")
+		}
+	}
+	if s.client != nil {
+		for _, pattern := range []string{recentlyViewedKey(userID), "usage:*:" + userID + ":*", "*:user:" + userID} {
+			if _, err := unlinkMatching(ctx, s.client, pattern); err != nil {
+				slog.Warn("failed to clear deleted account's keys", "pattern", pattern, "err", err, "component", "account")
+			}
+		}
+	}
+	if s.email != nil && deleted.Email != "" {
+		if err := s.email.SendAccountDeletedEmail(deleted.Email); err != nil {
+			slog.Warn("account deletion confirmation failed", "user_id", userID, "err", err, "component", "account")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+// deletionRecorder records confirmations and deleted objects.
+type deletionRecorder struct {
+	ObjectStorage
+	sentTo  []string
+	deleted []string
+}
+
+func (r *deletionRecorder) SendAccountDeletedEmail(to string) error {
+	r.sentTo = append(r.sentTo, to)
+	return nil
+}
+func (r *deletionRecorder) Delete(_ context.Context, key string) error {
+	r.deleted = append(r.deleted, key)
+	return nil
+}
+
+func TestAccountDeletion_DeletesAndConfirms(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	rec := &deletionRecorder{}
+	svc := NewAccountDeletionService(data.NewUserStore(db), rec, rec, nil)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT email, avatar_key FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"email", "avatar_key"}).AddRow("a@example.com", "avatars/user-1.png"))
+	mock.ExpectExec("set_config\\('papertrader.purging_users'").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM trades WHERE user_id = \\$1(.|\n)*DELETE FROM users WHERE id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := svc.Delete(context.Background(), "user-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if len(rec.sentTo) != 1 || rec.sentTo[0] != "a@example.com" {
+		t.Errorf("confirmation sent to %v", rec.sentTo)
+	}
+	if len(rec.deleted) != 1 || rec.deleted[0] != "avatars/user-1.png" {
+		t.Errorf("deleted objects %v", rec.deleted)
+	}
+
+	// An unknown user is not found, and nobody is emailed.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT email, avatar_key FROM users").WithArgs("ghost").
+		WillReturnRows(sqlmock.NewRows([]string{"email", "avatar_key"}))
+	mock.ExpectRollback()
+	var notFound *UserNotFoundError
+	if err := svc.Delete(context.Background(), "ghost"); !errors.As(err, &notFound) {
+		t.Errorf("unknown user: got %v, want UserNotFoundError", err)
+	}
+	if len(rec.sentTo) != 1 {
+		t.Errorf("emailed %v for an unknown user", rec.sentTo)
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// resetConfirmationTTL is how long a reset confirmation token stays valid:
+// long enough to answer an "are you sure?" prompt, too short to be reused
+// by accident later.
+const resetConfirmationTTL = 5 * time.Minute
+
+// AccountResetService starts a user's paper account over from the starting
+// balance.
+type AccountResetService struct {
+	users          *data.UserStore
+	jwt            *JWTService
+	requireConfirm bool
+}
+
+// NewAccountResetService builds the service. With requireConfirm set, Reset
+// needs a token from ConfirmationToken, so a single stray request cannot wipe
+// an account.
+func NewAccountResetService(users *data.UserStore, jwt *JWTService, requireConfirm bool) *AccountResetService {
+	return &AccountResetService{users: users, jwt: jwt, requireConfirm: requireConfirm}
+}
+
+// ConfirmationToken issues the token Reset expects for userID.
+func (s *AccountResetService) ConfirmationToken(ctx context.Context, userID string) (string, time.Time, error) {
+	return s.jwt.GenerateResetToken(userID, resetConfirmationTTL)
+}
+
+// Reset wipes userID's trades, holdings and orders and restores the
+// starting balance (see UserStore.ResetAccount). token is checked when
+// confirmation is required, and otherwise may be empty.
+func (s *AccountResetService) Reset(ctx context.Context, userID, token string) error {
+	if s.requireConfirm || token != "" {
+		claims, err := s.jwt.ValidateResetToken(token)
+		if err != nil || claims.UserID != userID {
+			return &ResetConfirmationRequiredError{}
+		}
+	}
+	if err := s.users.ResetAccount(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &UserNotFoundError{}
+		}
+		return err
+	}
+	slog.Info("paper account reset", "user_id", userID, "component", "account")
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+func TestAccountReset_RequiresConfirmation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	jwt := NewJWTService("test-secret")
+	svc := NewAccountResetService(data.NewUserStore(db), jwt, true)
+	ctx := context.Background()
+
+	var confirm *ResetConfirmationRequiredError
+	if err := svc.Reset(ctx, "user-1", ""); !errors.As(err, &confirm) {
+		t.Fatalf("no token: got %v, want ResetConfirmationRequiredError", err)
+	}
+	other, _, _ := jwt.GenerateResetToken("user-2", resetConfirmationTTL)
+	if err := svc.Reset(ctx, "user-1", other); !errors.As(err, &confirm) {
+		t.Fatalf("another user's token: got %v, want ResetConfirmationRequiredError", err)
+	}
+	sudo, _, _ := jwt.GenerateSudoToken("user-1", resetConfirmationTTL)
+	if err := svc.Reset(ctx, "user-1", sudo); !errors.As(err, &confirm) {
+		t.Fatalf("sudo token: got %v, want ResetConfirmationRequiredError", err)
+	}
+
+	token, _, err := svc.ConfirmationToken(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ConfirmationToken: %v", err)
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user-1"))
+	mock.ExpectExec("set_config\\('papertrader.purging_users'").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM trades WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := svc.Reset(ctx, "user-1", token); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
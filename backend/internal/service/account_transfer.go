@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// AccountBundleVersion is the schema version of the bundles Export writes.
+// Import accepts it and any older version upgradeBundle knows.
+const AccountBundleVersion = 1
+
+// accountBundleTTL is how long a bundle can be imported after its export.
+const accountBundleTTL = 7 * 24 * time.Hour
+
+// AuditAccountImported is the flagged audit event recorded for each import,
+// so accounts that arrived with their history are visible to admins.
+const AuditAccountImported = "account.imported"
+
+// maxBundleBalance is one below what a NUMERIC(15,2) balance column holds.
+var maxBundleBalance = decimal.New(1, 13)
+
+// AccountBundle is a signed account export. Payload is the
+// AccountBundlePayload JSON exactly as signed, Signature its Ed25519
+// signature and KeyID the signer's public key, both base64-encoded.
+type AccountBundle struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+	KeyID     string          `json:"key_id"`
+}
+
+// AccountBundlePayload is what a bundle carries: the account state, the
+// schema version it was written in, where and when it was exported, and the
+// ID and expiry that let it be imported once, within accountBundleTTL.
+type AccountBundlePayload struct {
+	SchemaVersion int       `json:"schema_version"`
+	BundleID      string    `json:"bundle_id"`
+	ExportedAt    time.Time `json:"exported_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	Source        string    `json:"source,omitempty"` // the exporting deployment's FRONTEND_URL
+	data.AccountState
+}
+
+// AccountImport summarises an import.
+type AccountImport struct {
+	Source     string    `json:"source,omitempty"`
+	ExportedAt time.Time `json:"exported_at"`
+	Replaced   bool      `json:"replaced"`
+	Holdings   int       `json:"holdings"`
+	TaxLots    int       `json:"tax_lots"`
+	Trades     int       `json:"trades"`
+}
+
+// AccountTransferService moves a paper account between deployments, such as
+// from a self-hosted instance to the hosted one: Export writes the account
+// as a bundle signed with this deployment's Ed25519 key and Import
+// recreates it. Import only accepts bundles signed by a trusted key, so a
+// deployment's key can't be used to forge bundles for any other that does
+// not list it. Export without a signing key, and Import without trusted
+// keys, are refused with AccountTransferDisabledError.
+type AccountTransferService struct {
+	store   *data.AccountTransferStore
+	audit   *data.AuditStore
+	key     ed25519.PrivateKey
+	trusted map[string]ed25519.PublicKey // by KeyID
+	source  string
+	now     func() time.Time
+}
+
+// NewAccountTransferService builds the service. key signs the bundles this
+// deployment exports and may be nil; trusted are the public keys of the
+// deployments whose bundles it imports. source names this deployment in the
+// bundles it writes.
+func NewAccountTransferService(store *data.AccountTransferStore, key ed25519.PrivateKey, trusted []ed25519.PublicKey, source string) *AccountTransferService {
+	s := &AccountTransferService{store: store, key: key, trusted: make(map[string]ed25519.PublicKey, len(trusted)), source: source, now: time.Now}
+	for _, pub := range trusted {
+		s.trusted[AccountTransferKeyID(pub)] = pub
+	}
+	return s
+}
+
+// SetAuditStore records each import as a flagged audit event.
+func (s *AccountTransferService) SetAuditStore(store *data.AuditStore) {
+	s.audit = store
+}
+
+// ParseAccountTransferKeys decodes the base64 Ed25519 signing key, a
+// 32-byte seed (e.g. from openssl rand -base64 32) or a 64-byte private
+// key, and the trusted public keys, as AccountTransferKeyID encodes them.
+// An empty signing key decodes to nil.
+func ParseAccountTransferKeys(signing string, trusted []string) (ed25519.PrivateKey, []ed25519.PublicKey, error) {
+	var key ed25519.PrivateKey
+	if signing != "" {
+		raw, err := base64.StdEncoding.DecodeString(signing)
+		switch {
+		case err != nil:
+			return nil, nil, fmt.Errorf("signing key is not base64: %w", err)
+		case len(raw) == ed25519.SeedSize:
+			key = ed25519.NewKeyFromSeed(raw)
+		case len(raw) == ed25519.PrivateKeySize:
+			key = ed25519.PrivateKey(raw)
+		default:
+			return nil, nil, fmt.Errorf("signing key must be %d or %d bytes, got %d", ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+		}
+	}
+	pubs := make([]ed25519.PublicKey, 0, len(trusted))
+	for i, encoded := range trusted {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("trusted key %d is not base64: %w", i+1, err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("trusted key %d must be %d bytes, got %d", i+1, ed25519.PublicKeySize, len(raw))
+		}
+		pubs = append(pubs, ed25519.PublicKey(raw))
+	}
+	return key, pubs, nil
+}
+
+// AccountTransferKeyID is the base64 public key bundles name their signer
+// by, and what other deployments list to trust this one.
+func AccountTransferKeyID(pub ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+// Export returns userID's account as a signed bundle.
+func (s *AccountTransferService) Export(ctx context.Context, userID string) (*AccountBundle, error) {
+	if s.key == nil {
+		return nil, &AccountTransferDisabledError{}
+	}
+	st, err := s.store.Export(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &UserNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	now, bundleID := s.now().UTC(), uuid.New().String()
+	payload, err := json.Marshal(AccountBundlePayload{
+		SchemaVersion: AccountBundleVersion,
+		BundleID:      bundleID,
+		ExportedAt:    now,
+		ExpiresAt:     now.Add(accountBundleTTL),
+		Source:        s.source,
+		AccountState:  *st,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("account exported", "user_id", userID, "bundle_id", bundleID, "trades", len(st.Trades), "component", "account_transfer")
+	return &AccountBundle{
+		Payload:   payload,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+		KeyID:     AccountTransferKeyID(s.key.Public().(ed25519.PublicKey)),
+	}, nil
+}
+
+// Import recreates the account in bundle as userID's. The signer, the
+// signature, schema version, expiry and contents are checked before
+// anything is written, and a bundle this deployment exported is refused: it
+// would copy an account within one server. Each bundle imports once; a
+// second import is refused with AccountBundleUsedError. An account already
+// in use is only overwritten when replace is set; otherwise the import is
+// refused with AccountNotEmptyError. The import is recorded with its source
+// and signer, and flagged in the audit log.
+func (s *AccountTransferService) Import(ctx context.Context, userID string, bundle *AccountBundle, replace bool) (*AccountImport, error) {
+	if len(s.trusted) == 0 {
+		return nil, &AccountTransferDisabledError{}
+	}
+	if s.key != nil && bundle.KeyID == AccountTransferKeyID(s.key.Public().(ed25519.PublicKey)) {
+		return nil, &InvalidAccountBundleError{Reason: "the bundle was exported by this server; import it on another deployment"}
+	}
+	pub, ok := s.trusted[bundle.KeyID]
+	if !ok {
+		return nil, &InvalidAccountBundleError{Reason: "the bundle was signed by a deployment this server does not trust"}
+	}
+	sig, err := base64.StdEncoding.DecodeString(bundle.Signature)
+	if err != nil || len(bundle.Payload) == 0 || !ed25519.Verify(pub, bundle.Payload, sig) {
+		return nil, &InvalidAccountBundleError{Reason: "signature does not match; the bundle was altered after export"}
+	}
+	var p AccountBundlePayload
+	if err := json.Unmarshal(bundle.Payload, &p); err != nil {
+		return nil, &InvalidAccountBundleError{Reason: "payload is not valid JSON"}
+	}
+	if err := upgradeBundle(&p); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.BundleID == "":
+		return nil, &InvalidAccountBundleError{Reason: "bundle_id is missing"}
+	case p.ExpiresAt.IsZero() || !s.now().Before(p.ExpiresAt):
+		return nil, &InvalidAccountBundleError{Reason: "the bundle has expired; export the account again"}
+	case s.source != "" && p.Source == s.source:
+		return nil, &InvalidAccountBundleError{Reason: "the bundle was exported by this server; import it on another deployment"}
+	}
+	if err := validateAccountState(&p.AccountState); err != nil {
+		return nil, err
+	}
+
+	imp := data.BundleImport{BundleID: p.BundleID, Source: p.Source, KeyID: bundle.KeyID}
+	replaced, err := s.store.Import(ctx, userID, imp, &p.AccountState, replace)
+	switch {
+	case errors.Is(err, data.ErrBundleImported):
+		return nil, &AccountBundleUsedError{}
+	case errors.Is(err, data.ErrAccountNotEmpty):
+		return nil, &AccountNotEmptyError{}
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, &UserNotFoundError{}
+	case err != nil:
+		return nil, err
+	}
+	slog.Info("account imported", "user_id", userID, "bundle_id", p.BundleID, "source", p.Source, "key_id", bundle.KeyID,
+		"schema_version", p.SchemaVersion, "trades", len(p.Trades), "replaced", replaced, "component", "account_transfer")
+	result := &AccountImport{
+		Source:     p.Source,
+		ExportedAt: p.ExportedAt,
+		Replaced:   replaced,
+		Holdings:   len(p.Holdings),
+		TaxLots:    len(p.Lots),
+		Trades:     len(p.Trades),
+	}
+	s.recordImport(ctx, userID, imp, result)
+	return result, nil
+}
+
+// recordImport flags the import in the audit log. Best-effort: the import
+// has already committed.
+func (s *AccountTransferService) recordImport(ctx context.Context, userID string, imp data.BundleImport, result *AccountImport) {
+	if s.audit == nil {
+		return
+	}
+	details, err := json.Marshal(map[string]any{
+		"bundle_id": imp.BundleID,
+		"source":    imp.Source,
+		"key_id":    imp.KeyID,
+		"replaced":  result.Replaced,
+		"holdings":  result.Holdings,
+		"trades":    result.Trades,
+	})
+	if err != nil {
+		details = nil
+	}
+	client := ClientInfoFromContext(ctx)
+	err = s.audit.Record(ctx, &data.AuditEvent{
+		UserID:    userID,
+		Kind:      AuditAccountImported,
+		IPAddress: client.IP,
+		Country:   client.Country,
+		Details:   details,
+		Flagged:   true,
+	})
+	if err != nil {
+		slog.Warn("account import not audited", "user_id", userID, "bundle_id", imp.BundleID, "err", err, "component", "account_transfer")
+	}
+}
+
+// upgradeBundle brings p up to AccountBundleVersion. Version 1 is the first,
+// so there is nothing to upgrade yet; a later version adds its step here.
+func upgradeBundle(p *AccountBundlePayload) error {
+	switch {
+	case p.SchemaVersion < 1:
+		return &InvalidAccountBundleError{Reason: "schema_version is missing"}
+	case p.SchemaVersion > AccountBundleVersion:
+		return &InvalidAccountBundleError{Reason: fmt.Sprintf(
+			"schema version %d is newer than this server reads (%d); upgrade the server first", p.SchemaVersion, AccountBundleVersion)}
+	}
+	p.SchemaVersion = AccountBundleVersion
+	return nil
+}
+
+// validateAccountState checks what Import will write, normalising symbols.
+// It is the last check before the database, so a bundle that was signed but
+// hand-edited before signing can't write what trading never would.
+func validateAccountState(st *data.AccountState) error {
+	invalid := func(format string, args ...any) error {
+		return &InvalidAccountBundleError{Reason: fmt.Sprintf(format, args...)}
+	}
+	for name, amount := range map[string]decimal.Decimal{"balance": st.Balance, "starting_balance": st.StartingBalance} {
+		if amount.IsNegative() || !amount.LessThan(maxBundleBalance) || !amount.Equal(amount.Round(2)) {
+			return invalid("%s must be between 0 and %s with at most 2 decimal places", name, maxBundleBalance)
+		}
+	}
+
+	set := st.Settings
+	if !currencyCodePattern.MatchString(set.DisplayCurrency) {
+		return invalid("settings.display_currency must be an ISO 4217 code")
+	}
+	if set.AfterHoursOrders != data.AfterHoursReject && set.AfterHoursOrders != data.AfterHoursQueue {
+		return invalid("settings.after_hours_orders must be REJECT or QUEUE")
+	}
+	switch set.CostBasisMethod {
+	case data.CostBasisFIFO, data.CostBasisLIFO, data.CostBasisAverage:
+	default:
+		return invalid("settings.cost_basis_method must be FIFO, LIFO or AVERAGE")
+	}
+	if _, err := time.LoadLocation(set.Timezone); err != nil || set.Timezone == "" || set.Timezone == "Local" {
+		return invalid("settings.timezone must be an IANA time zone")
+	}
+
+	held := make(map[string]bool, len(st.Holdings))
+	for i := range st.Holdings {
+		h := &st.Holdings[i]
+		symbol, err := util.ValidateSymbol(h.Symbol)
+		if err != nil {
+			return invalid("holdings[%d]: bad symbol %q", i, h.Symbol)
+		}
+		h.Symbol = symbol
+		switch {
+		case held[symbol]:
+			return invalid("holdings[%d]: %s appears twice", i, symbol)
+		case h.Quantity.IsZero():
+			return invalid("holdings[%d]: quantity must not be 0", i)
+		case !h.AvgPrice.IsPositive():
+			return invalid("holdings[%d]: avg_price must be positive", i)
+		case h.Margin.IsNegative(), h.Quantity.IsPositive() && !h.Margin.IsZero():
+			return invalid("holdings[%d]: margin must be 0 for a long and not negative for a short", i)
+		}
+		held[symbol] = true
+	}
+
+	tradeIDs := make(map[string]bool, len(st.Trades))
+	for i := range st.Trades {
+		t := &st.Trades[i]
+		symbol, err := util.ValidateSymbol(t.Symbol)
+		if err != nil {
+			return invalid("trades[%d]: bad symbol %q", i, t.Symbol)
+		}
+		t.Symbol = symbol
+		switch {
+		case t.ID == "" || tradeIDs[t.ID]:
+			return invalid("trades[%d]: id must be present and unique", i)
+		case t.Action != "BUY" && t.Action != "SELL" && t.Action != "SHORT" && t.Action != "COVER":
+			return invalid("trades[%d]: unknown action %q", i, t.Action)
+		case t.Status != "PENDING" && t.Status != "COMPLETED" && t.Status != "FAILED":
+			return invalid("trades[%d]: unknown status %q", i, t.Status)
+		case !t.Quantity.IsPositive() || t.Price.IsNegative():
+			return invalid("trades[%d]: quantity must be positive and price not negative", i)
+		case t.ExecutedAt.IsZero():
+			return invalid("trades[%d]: executed_at is missing", i)
+		}
+		switch t.OrderType {
+		case data.OrderTypeMarket, data.OrderTypeLimit, data.OrderTypeStop, data.OrderTypeStopLoss,
+			data.OrderTypeTakeProfit, data.OrderTypeAdjustment:
+		default:
+			return invalid("trades[%d]: unknown order_type %q", i, t.OrderType)
+		}
+		tradeIDs[t.ID] = true
+	}
+
+	for i := range st.Lots {
+		l := &st.Lots[i]
+		symbol, err := util.ValidateSymbol(l.Symbol)
+		if err != nil {
+			return invalid("tax_lots[%d]: bad symbol %q", i, l.Symbol)
+		}
+		l.Symbol = symbol
+		switch {
+		case !held[symbol]:
+			return invalid("tax_lots[%d]: %s is not held", i, symbol)
+		case !l.Remaining.IsPositive() || l.Remaining.GreaterThan(l.Quantity):
+			return invalid("tax_lots[%d]: remaining must be positive and at most quantity", i)
+		case !l.Price.IsPositive():
+			return invalid("tax_lots[%d]: price must be positive", i)
+		case l.AcquiredAt.IsZero():
+			return invalid("tax_lots[%d]: acquired_at is missing", i)
+		}
+		if !tradeIDs[l.TradeID] {
+			// The lot outlives a trade the bundle doesn't carry.
+			l.TradeID = ""
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+// The self-hosted deployment exports with selfHostedKey; the hosted one
+// signs with hostedKey and trusts the self-hosted one.
+var (
+	selfHostedKey = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	hostedKey     = ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	selfHostedPub = selfHostedKey.Public().(ed25519.PublicKey)
+)
+
+func newHostedTransfers(db *data.AccountTransferStore) *AccountTransferService {
+	return NewAccountTransferService(db, hostedKey, []ed25519.PublicKey{selfHostedPub}, "https://hosted.example")
+}
+
+// exportTestBundle exports a one-holding, one-trade account through sqlmock.
+func exportTestBundle(t *testing.T) *AccountBundle {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	executed := time.Date(2026, 9, 1, 14, 30, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT users.balance, users.starting_balance").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance", "starting_balance", "display_currency", "after_hours_orders",
+			"cost_basis_method", "trade_confirmation_emails", "statement_emails", "timezone"}).
+			AddRow("8500.00", "10000.00", "EUR", "QUEUE", "LIFO", true, false, "Europe/Berlin"))
+	mock.ExpectQuery("FROM portfolio").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "quantity", "avg_price", "margin"}).
+			AddRow("AAPL", "10", "150.00", "0"))
+	mock.ExpectQuery("FROM tax_lots").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"trade_id", "symbol", "quantity", "remaining", "price", "acquired_at"}).
+			AddRow("trade-1", "AAPL", "10", "10", "150.00", executed))
+	mock.ExpectQuery("FROM trades").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "symbol", "action", "quantity", "price", "slippage", "order_type", "status", "executed_at"}).
+			AddRow("trade-1", "AAPL", "BUY", "10", "150.00", "0", "MARKET", "COMPLETED", executed))
+	mock.ExpectRollback()
+
+	svc := NewAccountTransferService(data.NewAccountTransferStore(db), selfHostedKey, nil, "https://self-hosted.example")
+	bundle, err := svc.Export(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+	if bundle.KeyID != AccountTransferKeyID(selfHostedPub) {
+		t.Errorf("key_id = %q, want the self-hosted public key", bundle.KeyID)
+	}
+	return bundle
+}
+
+func TestAccountTransfer_RoundTrip(t *testing.T) {
+	bundle := exportTestBundle(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"used"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO account_bundle_imports").
+		WithArgs(sqlmock.AnyArg(), "user-2", "https://self-hosted.example", AccountTransferKeyID(selfHostedPub), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET balance").WithArgs("user-2", "8500", "10000").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO user_settings").
+		WithArgs("user-2", "EUR", "QUEUE", "LIFO", true, false, "Europe/Berlin").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO portfolio").WithArgs(sqlmock.AnyArg(), "user-2", "AAPL", "10", "150", "0").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").WithArgs(sqlmock.AnyArg(), "user-2", "AAPL", "BUY", "10", "150", "COMPLETED", "MARKET", "0", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO tax_lots").WithArgs(sqlmock.AnyArg(), "user-2", "AAPL", sqlmock.AnyArg(), "10", "10", "150", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), "user-2", AuditAccountImported, "", "", sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	svc := newHostedTransfers(data.NewAccountTransferStore(db))
+	svc.SetAuditStore(data.NewAuditStore(db))
+	result, err := svc.Import(context.Background(), "user-2", bundle, false)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Source != "https://self-hosted.example" || result.Replaced || result.Holdings != 1 || result.Trades != 1 || result.TaxLots != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAccountTransfer_RejectsTamperedBundle(t *testing.T) {
+	bundle := exportTestBundle(t)
+	bundle.Payload = bytes.Replace(bundle.Payload, []byte(`"8500`), []byte(`"9500`), 1)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := newHostedTransfers(data.NewAccountTransferStore(db))
+	_, err = svc.Import(context.Background(), "user-2", bundle, false)
+
+	var invalid *InvalidAccountBundleError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidAccountBundleError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+}
+
+func TestAccountTransfer_RejectsUntrustedSigner(t *testing.T) {
+	bundle := exportTestBundle(t)
+
+	// A deployment that trusts only some other key.
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize))
+	svc := NewAccountTransferService(nil, hostedKey, []ed25519.PublicKey{other.Public().(ed25519.PublicKey)}, "")
+	_, err := svc.Import(context.Background(), "user-2", bundle, false)
+
+	var invalid *InvalidAccountBundleError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidAccountBundleError, got %v", err)
+	}
+
+	// A bundle naming a trusted key but signed with another.
+	payload := bundle.Payload
+	forged := &AccountBundle{Payload: payload, KeyID: bundle.KeyID,
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(other, payload))}
+	if _, err := newHostedTransfers(nil).Import(context.Background(), "user-2", forged, false); !errors.As(err, &invalid) {
+		t.Fatalf("forged: expected InvalidAccountBundleError, got %v", err)
+	}
+}
+
+func TestAccountTransfer_RejectsNewerSchema(t *testing.T) {
+	svc := newHostedTransfers(nil)
+	payload := []byte(`{"schema_version":99,"exported_at":"2026-10-01T00:00:00Z"}`)
+	bundle := &AccountBundle{Payload: payload, KeyID: AccountTransferKeyID(selfHostedPub),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(selfHostedKey, payload))}
+	_, err := svc.Import(context.Background(), "user-2", bundle, false)
+
+	var invalid *InvalidAccountBundleError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected InvalidAccountBundleError, got %v", err)
+	}
+}
+
+func TestAccountTransfer_NotEmpty(t *testing.T) {
+	bundle := exportTestBundle(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"used"}).AddRow(true))
+	mock.ExpectExec("INSERT INTO account_bundle_imports").
+		WithArgs(sqlmock.AnyArg(), "user-2", sqlmock.AnyArg(), sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	svc := newHostedTransfers(data.NewAccountTransferStore(db))
+	_, err = svc.Import(context.Background(), "user-2", bundle, false)
+
+	var notEmpty *AccountNotEmptyError
+	if !errors.As(err, &notEmpty) {
+		t.Fatalf("expected AccountNotEmptyError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAccountTransfer_ImportsOnce(t *testing.T) {
+	bundle := exportTestBundle(t)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("user-3").
+		WillReturnRows(sqlmock.NewRows([]string{"used"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO account_bundle_imports").
+		WithArgs(sqlmock.AnyArg(), "user-3", sqlmock.AnyArg(), sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	svc := newHostedTransfers(data.NewAccountTransferStore(db))
+	_, err = svc.Import(context.Background(), "user-3", bundle, false)
+
+	var used *AccountBundleUsedError
+	if !errors.As(err, &used) {
+		t.Fatalf("expected AccountBundleUsedError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAccountTransfer_RejectsExpiredAndOwnBundles(t *testing.T) {
+	bundle := exportTestBundle(t)
+
+	expired := newHostedTransfers(nil)
+	expired.now = func() time.Time { return time.Now().Add(accountBundleTTL + time.Minute) }
+	// The exporting deployment, even if it lists its own key.
+	own := NewAccountTransferService(nil, selfHostedKey, []ed25519.PublicKey{selfHostedPub}, "https://self-hosted.example")
+
+	for name, svc := range map[string]*AccountTransferService{"expired": expired, "own": own} {
+		_, err := svc.Import(context.Background(), "user-2", bundle, false)
+		var invalid *InvalidAccountBundleError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidAccountBundleError, got %v", name, err)
+		}
+	}
+}
+
+func TestAccountTransfer_DisabledWithoutKeys(t *testing.T) {
+	svc := NewAccountTransferService(nil, nil, nil, "")
+	var disabled *AccountTransferDisabledError
+	if _, err := svc.Export(context.Background(), "user-1"); !errors.As(err, &disabled) {
+		t.Errorf("export without a signing key: expected AccountTransferDisabledError, got %v", err)
+	}
+	if _, err := svc.Import(context.Background(), "user-1", &AccountBundle{}, false); !errors.As(err, &disabled) {
+		t.Errorf("import without trusted keys: expected AccountTransferDisabledError, got %v", err)
+	}
+}
+
+func TestParseAccountTransferKeys(t *testing.T) {
+	seed := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	key, trusted, err := ParseAccountTransferKeys(seed, []string{AccountTransferKeyID(selfHostedPub)})
+	if err != nil {
+		t.Fatalf("ParseAccountTransferKeys: %v", err)
+	}
+	if !key.Equal(selfHostedKey) || len(trusted) != 1 || !trusted[0].Equal(selfHostedPub) {
+		t.Errorf("got key %x, trusted %x", key, trusted)
+	}
+	if key, _, err := ParseAccountTransferKeys("", nil); key != nil || err != nil {
+		t.Errorf("empty signing key: got %x, %v", key, err)
+	}
+	for _, bad := range [][2]string{{"not base64!", ""}, {base64.StdEncoding.EncodeToString([]byte("short")), ""}, {"", seed + "AA"}} {
+		var trusted []string
+		if bad[1] != "" {
+			trusted = []string{bad[1]}
+		}
+		if _, _, err := ParseAccountTransferKeys(bad[0], trusted); err == nil {
+			t.Errorf("ParseAccountTransferKeys(%q, %q): expected an error", bad[0], bad[1])
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// Audit event kinds. Anomalies are stored with flagged = TRUE.
+const (
+	AuditLoginSucceeded       = "login.succeeded"
+	AuditLoginFailed          = "login.failed"
+	AuditAnomalyNewCountry    = "anomaly.new_country"
+	AuditAnomalyFailedLogins  = "anomaly.failed_login_burst"
+	AuditAnomalyBalanceChange = "anomaly.large_balance_change"
+)
+
+const (
+	defaultAuditListLimit = 50
+	maxAuditListLimit     = 500
+)
+
+// anomalyAlertTimeout bounds the lookups and sends behind one alert, which
+// run after the login or trade that triggered it has returned.
+const anomalyAlertTimeout = 30 * time.Second
+
+// AnomalyPolicy configures AnomalyService. A zero FailedLoginThreshold
+// disables the failed-login rule and a zero BalanceChangePct the balance rule.
+type AnomalyPolicy struct {
+	Enabled              bool
+	FailedLoginThreshold int
+	FailedLoginWindow    time.Duration
+	BalanceChangePct     decimal.Decimal // percent of the pre-trade balance
+	BalanceChangeMin     decimal.Decimal // absolute floor below which nothing is flagged
+}
+
+// AnomalyService watches logins and trades for signs of account takeover:
+//
+//   - a successful login from a country the account has never logged in from
+//   - FailedLoginThreshold failed password attempts within FailedLoginWindow
+//   - a single trade that moves the cash balance by BalanceChangePct or more
+//
+// Each detection is written to the audit log and sent to the user by email
+// and in-app notification. The failed-login rule also requires every
+// existing session to log in again before sudo-level actions (see
+// auth.RequireReauthCleared). A new country does not, since that login has
+// just presented the password, and neither does a large balance change,
+// which the account's own session may well have made on purpose.
+//
+// Detection is best-effort: failures are logged and never block the login or
+// trade that triggered them. Alerts are delivered in the background.
+type AnomalyService struct {
+	audit         *data.AuditStore
+	users         *data.UserStore
+	emailService  *EmailService
+	notifications *NotificationService
+	policy        AnomalyPolicy
+	now           func() time.Time
+	pending       sync.WaitGroup // alerts still being delivered
+}
+
+// NewAnomalyService builds the detector. emailService and notifications may
+// be nil, in which case that channel is skipped.
+func NewAnomalyService(audit *data.AuditStore, users *data.UserStore, emailService *EmailService, notifications *NotificationService, policy AnomalyPolicy) *AnomalyService {
+	return &AnomalyService{
+		audit:         audit,
+		users:         users,
+		emailService:  emailService,
+		notifications: notifications,
+		policy:        policy,
+		now:           time.Now,
+	}
+}
+
+// LoginSucceeded implements LoginObserver.
+func (s *AnomalyService) LoginSucceeded(ctx context.Context, user *data.User) {
+	if !s.policy.Enabled {
+		return
+	}
+	client := ClientInfoFromContext(ctx)
+
+	// Compare against history before recording this login, otherwise the
+	// country would always look familiar. The very first login with a known
+	// country establishes the baseline and is not flagged.
+	if client.Country != "" {
+		seenAny, seenCountry, err := s.audit.CountryHistory(ctx, user.ID, AuditLoginSucceeded, client.Country)
+		if err != nil {
+			slog.Warn("login country lookup failed", "user_id", user.ID, "err", err, "component", "anomaly")
+		} else if seenAny && !seenCountry {
+			s.flag(ctx, user, AuditAnomalyNewCountry, map[string]any{"country": client.Country}, false,
+				"New sign-in location",
+				fmt.Sprintf("Your PaperTrader account was signed in to from a new country (%s, IP %s).", client.Country, client.IP))
+		}
+	}
+
+	s.record(ctx, &data.AuditEvent{UserID: user.ID, Kind: AuditLoginSucceeded, IPAddress: client.IP, Country: client.Country})
+}
+
+// LoginFailed implements LoginObserver. Only called for wrong passwords on
+// existing accounts; unknown emails have no user to attribute the event to.
+func (s *AnomalyService) LoginFailed(ctx context.Context, user *data.User) {
+	if !s.policy.Enabled {
+		return
+	}
+	client := ClientInfoFromContext(ctx)
+	s.record(ctx, &data.AuditEvent{UserID: user.ID, Kind: AuditLoginFailed, IPAddress: client.IP, Country: client.Country})
+
+	if s.policy.FailedLoginThreshold <= 0 {
+		return
+	}
+	count, err := s.audit.CountSince(ctx, user.ID, AuditLoginFailed, s.now().Add(-s.policy.FailedLoginWindow))
+	if err != nil {
+		slog.Warn("failed-login count failed", "user_id", user.ID, "err", err, "component", "anomaly")
+		return
+	}
+	// Flag exactly when the threshold is crossed so a sustained attack sends
+	// one alert per window rather than one per attempt.
+	if count != s.policy.FailedLoginThreshold {
+		return
+	}
+	s.flag(ctx, user, AuditAnomalyFailedLogins,
+		map[string]any{"failures": count, "window_seconds": int(s.policy.FailedLoginWindow.Seconds())}, true,
+		"Repeated failed sign-in attempts",
+		fmt.Sprintf("There were %d failed attempts to sign in to your PaperTrader account in the last %d minutes. "+
+			"As a precaution, you will need to sign in again before sensitive actions.",
+			count, int(s.policy.FailedLoginWindow.Minutes())))
+}
+
+// TradeExecuted implements TradeObserver. The check runs after the trade's
+// request has returned.
+func (s *AnomalyService) TradeExecuted(ctx context.Context, exec TradeExecution) {
+	if !s.policy.Enabled || !s.policy.BalanceChangePct.IsPositive() {
+		return
+	}
+	s.background(ctx, func(ctx context.Context) { s.checkBalanceChange(ctx, exec) })
+}
+
+func (s *AnomalyService) checkBalanceChange(ctx context.Context, exec TradeExecution) {
+	change := exec.BalanceAfter.Sub(exec.BalanceBefore).Abs()
+	if change.LessThan(s.policy.BalanceChangeMin) {
+		return
+	}
+	if exec.BalanceBefore.IsPositive() {
+		pct := change.Div(exec.BalanceBefore).Mul(decimal.NewFromInt(100))
+		if pct.LessThan(s.policy.BalanceChangePct) {
+			return
+		}
+	}
+
+	user, err := s.users.GetUserByID(ctx, exec.UserID)
+	if err != nil {
+		slog.Warn("anomaly user lookup failed", "user_id", exec.UserID, "err", err, "component", "anomaly")
+		return
+	}
+	s.flag(ctx, user, AuditAnomalyBalanceChange, map[string]any{
+		"action":         exec.Action,
+		"symbol":         exec.Symbol,
+		"quantity":       exec.Quantity,
+		"total":          exec.Total.StringFixed(2),
+		"balance_before": exec.BalanceBefore.StringFixed(2),
+		"balance_after":  exec.BalanceAfter.StringFixed(2),
+	}, false,
+		"Large balance change",
+		fmt.Sprintf("A %s of %s %s moved your cash balance from $%s to $%s. "+
+			"If this wasn't you, change your password and sign out everywhere.",
+			exec.Action, exec.Quantity, exec.Symbol, exec.BalanceBefore.StringFixed(2), exec.BalanceAfter.StringFixed(2)))
+}
+
+// ReauthRequiredAfter implements auth.ReauthGuard.
+func (s *AnomalyService) ReauthRequiredAfter(ctx context.Context, userID string) (*time.Time, error) {
+	return s.users.GetReauthRequiredAfter(ctx, userID)
+}
+
+// ListFlagged returns the most recent anomalies across all users. limit is
+// clamped to [1, maxAuditListLimit]; zero selects the default.
+func (s *AnomalyService) ListFlagged(ctx context.Context, limit int) ([]data.AuditEvent, error) {
+	if limit <= 0 {
+		limit = defaultAuditListLimit
+	}
+	if limit > maxAuditListLimit {
+		limit = maxAuditListLimit
+	}
+	return s.audit.ListFlagged(ctx, limit)
+}
+
+func (s *AnomalyService) flag(ctx context.Context, user *data.User, kind string, details map[string]any, requireReauth bool, title, body string) {
+	client := ClientInfoFromContext(ctx)
+	raw, err := json.Marshal(details)
+	if err != nil {
+		raw = nil
+	}
+	slog.Warn("account anomaly detected", "user_id", user.ID, "kind", kind, "remote_addr", client.IP, "component", "anomaly")
+	s.record(ctx, &data.AuditEvent{
+		UserID:    user.ID,
+		Kind:      kind,
+		IPAddress: client.IP,
+		Country:   client.Country,
+		Details:   raw,
+		Flagged:   true,
+	})
+
+	if requireReauth {
+		if err := s.users.RequireReauth(ctx, user.ID, s.now()); err != nil {
+			slog.Error("failed to require re-authentication", "user_id", user.ID, "err", err, "component", "anomaly")
+		}
+	}
+	s.background(ctx, func(ctx context.Context) { s.alert(ctx, user, title, body) })
+}
+
+// alert tells the user about an anomaly in-app and by email.
+func (s *AnomalyService) alert(ctx context.Context, user *data.User, title, body string) {
+	if s.notifications != nil {
+		if err := s.notifications.Notify(ctx, []string{user.ID}, NotificationSecurityAlert, title, body); err != nil {
+			slog.Warn("security notification failed", "user_id", user.ID, "err", err, "component", "anomaly")
+		}
+	}
+	// Guests have no email address; the in-app notification is all they get.
+	if s.emailService != nil && user.Email != "" {
+		if err := s.emailService.SendSecurityAlertEmail(user.Email, title, body); err != nil {
+			slog.Warn("security alert email failed", "user_id", user.ID, "err", err, "component", "anomaly")
+		}
+	}
+}
+
+// background runs fn in a goroutine with a context that outlives ctx's
+// cancellation but not anomalyAlertTimeout.
+func (s *AnomalyService) background(ctx context.Context, fn func(context.Context)) {
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), anomalyAlertTimeout)
+		defer cancel()
+		fn(ctx)
+	}()
+}
+
+func (s *AnomalyService) record(ctx context.Context, e *data.AuditEvent) {
+	if err := s.audit.Record(ctx, e); err != nil {
+		slog.Warn("audit event not recorded", "user_id", e.UserID, "kind", e.Kind, "err", err, "component", "anomaly")
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+var anomalyNow = time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+
+func newTestAnomalies(db *sql.DB) *AnomalyService {
+	s := NewAnomalyService(data.NewAuditStore(db), data.NewUserStore(db), nil,
+		NewNotificationService(data.NewNotificationStore(db)), AnomalyPolicy{
+			Enabled:              true,
+			FailedLoginThreshold: 3,
+			FailedLoginWindow:    15 * time.Minute,
+			BalanceChangePct:     decimal.NewFromInt(50),
+			BalanceChangeMin:     decimal.NewFromInt(5000),
+		})
+	s.now = func() time.Time { return anomalyNow }
+	return s
+}
+
+func testUser() *data.User {
+	return &data.User{ID: "user-1", Email: "test@example.com"}
+}
+
+func TestAnomaly_FailedLoginBurstFlagsAndRequiresReauth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: "203.0.113.9"})
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditLoginFailed, "203.0.113.9", "", sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM audit_events").
+		WithArgs("user-1", AuditLoginFailed, anomalyNow.Add(-15*time.Minute)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditAnomalyFailedLogins, "203.0.113.9", "", sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET reauth_required_after").
+		WithArgs(anomalyNow, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(NotificationSecurityAlert, "Repeated failed sign-in attempts", sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := newTestAnomalies(db)
+	s.LoginFailed(ctx, testUser())
+	s.pending.Wait()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestAnomaly_FailedLoginBelowThresholdOnlyRecords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO audit_events").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM audit_events").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	newTestAnomalies(db).LoginFailed(context.Background(), testUser())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestAnomaly_NewCountryLoginFlagsWithoutReauth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// The notification is sent in the background, so it may land either
+	// side of the login's own audit event.
+	mock.MatchExpectationsInOrder(false)
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: "198.51.100.7", Country: "BR"})
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) > 0, COALESCE\\(BOOL_OR").
+		WithArgs("user-1", AuditLoginSucceeded, "BR").
+		WillReturnRows(sqlmock.NewRows([]string{"seen_any", "seen_country"}).AddRow(true, false))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditAnomalyNewCountry, "198.51.100.7", "BR", sqlmock.AnyArg(), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(NotificationSecurityAlert, "New sign-in location", sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditLoginSucceeded, "198.51.100.7", "BR", sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := newTestAnomalies(db)
+	s.LoginSucceeded(ctx, testUser())
+	s.pending.Wait()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestAnomaly_FirstKnownCountryIsBaseline(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: "198.51.100.7", Country: "US"})
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) > 0, COALESCE\\(BOOL_OR").
+		WillReturnRows(sqlmock.NewRows([]string{"seen_any", "seen_country"}).AddRow(false, false))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditLoginSucceeded, "198.51.100.7", "US", sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	newTestAnomalies(db).LoginSucceeded(ctx, testUser())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestAnomaly_LargeBalanceChange(t *testing.T) {
+	trade := func(before, after int64) TradeExecution {
+		return TradeExecution{
+			TradeIntent:   TradeIntent{UserID: "user-1", Symbol: "AAPL", Action: "BUY", Quantity: decimal.NewFromInt(40), Price: decimal.NewFromInt(200)},
+			Total:         decimal.NewFromInt(before - after),
+			BalanceBefore: decimal.NewFromInt(before),
+			BalanceAfter:  decimal.NewFromInt(after),
+		}
+	}
+
+	t.Run("below thresholds is ignored", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("sqlmock.New: %v", err)
+		}
+		defer db.Close()
+
+		s := newTestAnomalies(db)
+		s.TradeExecuted(context.Background(), trade(10000, 6000)) // 40%
+		s.TradeExecuted(context.Background(), trade(4000, 0))     // 100% but under the $5000 floor
+		s.pending.Wait()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("no queries expected: %v", err)
+		}
+	})
+
+	t.Run("large change flags without requiring reauth", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("sqlmock.New: %v", err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").WithArgs("user-1").
+			WillReturnRows(newUserRow(decimal.NewFromInt(2000)))
+		mock.ExpectExec("INSERT INTO audit_events").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditAnomalyBalanceChange, "", "", sqlmock.AnyArg(), true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO notifications").
+			WithArgs(NotificationSecurityAlert, "Large balance change", sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		s := newTestAnomalies(db)
+		s.TradeExecuted(context.Background(), trade(10000, 2000))
+		s.pending.Wait()
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unfulfilled sql expectations: %v", err)
+		}
+	})
+}
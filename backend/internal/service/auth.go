@@ -7,29 +7,80 @@ import (
 	"log/slog"
 	"net/mail"
 	"papertrader/internal/data"
+	"papertrader/internal/util"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// LoginObserver is told about login outcomes for existing accounts.
+// Satisfied by *AnomalyService.
+type LoginObserver interface {
+	LoginSucceeded(ctx context.Context, user *data.User)
+	LoginFailed(ctx context.Context, user *data.User)
+}
+
+// LoginObservers fans login outcomes out to several observers, in order.
+type LoginObservers []LoginObserver
+
+func (o LoginObservers) LoginSucceeded(ctx context.Context, user *data.User) {
+	for _, observer := range o {
+		observer.LoginSucceeded(ctx, user)
+	}
+}
+
+func (o LoginObservers) LoginFailed(ctx context.Context, user *data.User) {
+	for _, observer := range o {
+		observer.LoginFailed(ctx, user)
+	}
+}
+
 type AuthService struct {
 	users        *data.UserStore
 	jwtService   *JWTService
 	emailService *EmailService
 	googleOAuth  *GoogleOAuthService
+	usernames    *UsernameService
+	logins       LoginObserver
+	invites      *InviteService
+	lockout      *LockoutService
+
+	magicLinks       MagicLinkPolicy
+	magicLinkLimiter RateLimiter
 }
 
-func NewAuthService(users *data.UserStore, jwtService *JWTService, emailService *EmailService, googleOAuth *GoogleOAuthService) *AuthService {
+// NewAuthService builds the service. usernames and logins may be nil; without
+// usernames, names chosen at registration are checked against the built-in
+// rules only.
+func NewAuthService(users *data.UserStore, jwtService *JWTService, emailService *EmailService, googleOAuth *GoogleOAuthService, usernames *UsernameService, logins LoginObserver) *AuthService {
 	return &AuthService{
 		users:        users,
 		jwtService:   jwtService,
 		emailService: emailService,
 		googleOAuth:  googleOAuth,
+		usernames:    usernames,
+		logins:       logins,
+		magicLinks:   MagicLinkPolicy{TTL: DefaultMagicLinkTTL},
 	}
 }
 
-// Register registers a new user
-func (s *AuthService) Register(ctx context.Context, email, password string) (*data.User, string, error) {
+// SetInvites makes new accounts subject to invites, which requires an invite
+// code when registration is invite-only.
+func (s *AuthService) SetInvites(invites *InviteService) {
+	s.invites = invites
+}
+
+// SetLockout makes password logins subject to lockout after too many wrong
+// passwords, and every login to an admin's lock. lockout should also be one
+// of the service's LoginObservers, so failures are counted.
+func (s *AuthService) SetLockout(lockout *LockoutService) {
+	s.lockout = lockout
+}
+
+// Register registers a new user. username is optional and can be chosen
+// later via UsernameService.Set. inviteCode is checked only when
+// registration is invite-only.
+func (s *AuthService) Register(ctx context.Context, email, password, username, inviteCode string) (*data.User, string, error) {
 	// Validate email
 	_, err := mail.ParseAddress(email)
 	if err != nil {
@@ -41,17 +92,33 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*da
 		return nil, "", err
 	}
 
+	if username != "" {
+		if err := s.validateUsername(username); err != nil {
+			return nil, "", err
+		}
+	}
+
 	// Check if email already exists
 	_, err = s.users.GetUserByEmail(ctx, email)
 	if err == nil {
 		return nil, "", &EmailExistsError{}
 	}
 
+	invite, err := s.invites.claim(ctx, inviteCode)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Create user with verification token
-	user, verificationToken, err := s.users.CreateUserWithVerification(ctx, email, password)
+	user, verificationToken, err := s.users.CreateUserWithVerification(ctx, email, password, username, invite.Cohort())
 	if err != nil {
+		invite.release(ctx)
+		if errors.Is(err, data.ErrUsernameTaken) {
+			return nil, "", &UsernameTakenError{}
+		}
 		return nil, "", err
 	}
+	invite.redeemed(ctx, user.ID)
 
 	// Send verification email
 	if s.emailService != nil {
@@ -60,9 +127,10 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*da
 			slog.Warn("send verification email failed", "err", err)
 		}
 	}
+	s.sendWelcome(user)
 
 	// Generate JWT token (user needs to verify email to use it fully)
-	token, err := s.jwtService.GenerateToken(user.ID, user.Email)
+	token, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, "", &TokenGenerationError{}
 	}
@@ -70,6 +138,24 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (*da
 	return user, token, nil
 }
 
+// sendWelcome emails a new account its welcome. A failure is logged: the
+// account exists either way.
+func (s *AuthService) sendWelcome(user *data.User) {
+	if s.emailService == nil {
+		return
+	}
+	if err := s.emailService.SendWelcomeEmail(user.Email, user.Username, user.Balance); err != nil {
+		slog.Warn("send welcome email failed", "user_id", user.ID, "err", err)
+	}
+}
+
+func (s *AuthService) validateUsername(name string) error {
+	if s.usernames != nil {
+		return s.usernames.Validate(name)
+	}
+	return validateUsername(name, blockedUsernameTerms)
+}
+
 // VerifyEmail verifies a user's email using the verification token
 func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
 	return s.users.VerifyEmail(ctx, token)
@@ -119,8 +205,17 @@ func (s *AuthService) ResendVerificationEmail(ctx context.Context, email string)
 // existing local account have verified emails — this prevents pre-verification
 // account squatting where an attacker registers an unverified account with
 // someone else's email and then takes it over when the real owner clicks
-// "Sign in with Google".
-func (s *AuthService) LoginWithGoogle(ctx context.Context, idToken string) (*data.User, string, error) {
+// "Sign in with Google". inviteCode is only checked when the sign-in would
+// create an account and registration is invite-only.
+func (s *AuthService) LoginWithGoogle(ctx context.Context, idToken, inviteCode string) (*data.User, string, error) {
+	user, token, err := s.loginWithGoogle(ctx, idToken, inviteCode)
+	if err == nil && s.logins != nil {
+		s.logins.LoginSucceeded(ctx, user)
+	}
+	return user, token, err
+}
+
+func (s *AuthService) loginWithGoogle(ctx context.Context, idToken, inviteCode string) (*data.User, string, error) {
 	googleUser, err := s.googleOAuth.VerifyIDToken(ctx, idToken)
 	if err != nil {
 		return nil, "", fmt.Errorf("invalid Google token: %w", err)
@@ -135,7 +230,10 @@ func (s *AuthService) LoginWithGoogle(ctx context.Context, idToken string) (*dat
 
 	// Existing user, matched by Google sub claim — fast path.
 	if user, err := s.users.GetUserByGoogleID(ctx, googleUser.ID); err == nil {
-		jwtToken, err := s.jwtService.GenerateToken(user.ID, user.Email)
+		if err := s.lockout.CheckAdminLock(ctx, user.ID); err != nil {
+			return nil, "", err
+		}
+		jwtToken, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
 		if err != nil {
 			return nil, "", &TokenGenerationError{}
 		}
@@ -148,25 +246,35 @@ func (s *AuthService) LoginWithGoogle(ctx context.Context, idToken string) (*dat
 		if !existingUser.EmailVerified {
 			return nil, "", errors.New("an account exists for this email but is not verified; verify via the verification email or sign in with password to link Google")
 		}
+		if err := s.lockout.CheckAdminLock(ctx, existingUser.ID); err != nil {
+			return nil, "", err
+		}
 		if err := s.users.LinkGoogleAccount(ctx, existingUser.ID, googleUser.ID); err != nil {
 			return nil, "", err
 		}
 		existingUser.GoogleID = &googleUser.ID
 		existingUser.EmailVerified = true
 
-		jwtToken, err := s.jwtService.GenerateToken(existingUser.ID, existingUser.Email)
+		jwtToken, err := s.jwtService.GenerateToken(existingUser.ID, existingUser.Email, existingUser.Role)
 		if err != nil {
 			return nil, "", &TokenGenerationError{}
 		}
 		return existingUser, jwtToken, nil
 	}
 
-	user, err := s.users.CreateGoogleUser(ctx, googleUser.Email, googleUser.ID)
+	invite, err := s.invites.claim(ctx, inviteCode)
 	if err != nil {
 		return nil, "", err
 	}
+	user, err := s.users.CreateGoogleUser(ctx, googleUser.Email, googleUser.ID, invite.Cohort())
+	if err != nil {
+		invite.release(ctx)
+		return nil, "", err
+	}
+	invite.redeemed(ctx, user.ID)
+	s.sendWelcome(user)
 
-	jwtToken, err := s.jwtService.GenerateToken(user.ID, user.Email)
+	jwtToken, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, "", &TokenGenerationError{}
 	}
@@ -181,17 +289,121 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (*data.
 		return nil, "", &InvalidCredentialsError{}
 	}
 
+	// A locked account refuses the attempt before the password is checked,
+	// so guessing gets no answer while it lasts.
+	if err := s.lockout.CheckAdminLock(ctx, user.ID); err != nil {
+		return nil, "", err
+	}
+	if s.lockout != nil {
+		if err := s.lockout.Check(ctx, user.ID); err != nil {
+			return nil, "", err
+		}
+	}
+
 	// Validate password
 	if !s.users.ValidatePassword(user, password) {
+		if s.logins != nil {
+			s.logins.LoginFailed(ctx, user)
+		}
 		return nil, "", &InvalidCredentialsError{}
 	}
 
 	// Generate JWT token
-	token, err := s.jwtService.GenerateToken(user.ID, user.Email)
+	token, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, "", &TokenGenerationError{}
 	}
 
+	if s.logins != nil {
+		s.logins.LoginSucceeded(ctx, user)
+	}
+	return user, token, nil
+}
+
+// Elevate re-confirms the identity of an already-logged-in user and returns a
+// sudo token valid for ttl. Password accounts confirm with their password;
+// Google accounts with a fresh Google ID token for the linked Google account.
+// A wrong password counts as a failed login for anomaly detection and
+// lockout.
+func (s *AuthService) Elevate(ctx context.Context, userID, password, googleIDToken string, ttl time.Duration) (string, time.Time, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, &UserNotFoundError{}
+	}
+
+	switch {
+	case password != "":
+		if s.lockout != nil {
+			if err := s.lockout.Check(ctx, user.ID); err != nil {
+				return "", time.Time{}, err
+			}
+		}
+		if !s.users.ValidatePassword(user, password) {
+			if s.logins != nil {
+				s.logins.LoginFailed(ctx, user)
+			}
+			return "", time.Time{}, &SudoConfirmationError{}
+		}
+	case googleIDToken != "" && s.googleOAuth != nil:
+		googleUser, err := s.googleOAuth.VerifyIDToken(ctx, googleIDToken)
+		if err != nil || user.GoogleID == nil || *user.GoogleID != googleUser.ID {
+			return "", time.Time{}, &SudoConfirmationError{}
+		}
+	default:
+		return "", time.Time{}, &SudoConfirmationError{}
+	}
+
+	token, expiresAt, err := s.jwtService.GenerateSudoToken(user.ID, ttl)
+	if err != nil {
+		return "", time.Time{}, &TokenGenerationError{}
+	}
+	slog.Info("sudo mode granted", "user_id", user.ID, "expires_at", expiresAt)
+	return token, expiresAt, nil
+}
+
+// ChangePassword sets a new password. The caller must already have
+// confirmed the current credentials (sudo mode).
+func (s *AuthService) ChangePassword(ctx context.Context, userID, password string) error {
+	if err := validatePasswordStrength(password); err != nil {
+		return &util.ValidationError{Field: "password", Message: err.Error()}
+	}
+	if err := s.users.SetPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	slog.Info("password changed", "user_id", userID)
+	return nil
+}
+
+// ChangeEmail moves the account to a new email address, which must be
+// verified again, and returns the updated user with a session token that
+// carries the new address. The caller must be in sudo mode.
+func (s *AuthService) ChangeEmail(ctx context.Context, userID, email string) (*data.User, string, error) {
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, "", &util.ValidationError{Field: "email", Message: "invalid email format"}
+	}
+
+	verificationToken, err := s.users.ChangeEmail(ctx, userID, email)
+	if errors.Is(err, data.ErrEmailTaken) {
+		return nil, "", &EmailExistsError{}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", &UserNotFoundError{}
+	}
+	if s.emailService != nil {
+		if err := s.emailService.SendVerificationEmail(user.Email, verificationToken); err != nil {
+			slog.Warn("send verification email failed", "user_id", userID, "err", err)
+		}
+	}
+	token, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, "", &TokenGenerationError{}
+	}
+	slog.Info("email changed", "user_id", userID)
 	return user, token, nil
 }
 
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+	"golang.org/x/crypto/bcrypt"
 
 	"papertrader/internal/data"
 )
@@ -16,7 +18,48 @@ import (
 var authUserCols = []string{
 	"id", "email", "password", "created_at", "balance",
 	"email_verified", "verification_token", "verification_token_expires",
-	"google_id", "created_via",
+	"google_id", "created_via", "avatar_url", "username", "is_guest", "guest_expires_at", "display_currency", "after_hours_orders", "league", "cost_basis_method", "trade_confirmation_emails", "timezone", "role",
+}
+
+// userRow returns the row a user read (GetUserByID and friends) scans for u.
+// Empty nullable fields read as NULL, and an empty created_via and empty
+// settings as a new email account's, so a new users or user_settings column
+// only touches authUserCols and this helper.
+func userRow(u data.User) *sqlmock.Rows {
+	createdVia := u.CreatedVia
+	if createdVia == "" {
+		createdVia = "email"
+	}
+	return sqlmock.NewRows(authUserCols).AddRow(
+		u.ID, u.Email, nullString(u.Password), time.Now(), u.Balance,
+		u.EmailVerified, deref(u.VerificationToken), deref(u.VerificationTokenExpires), deref(u.GoogleID), createdVia,
+		nullString(u.AvatarURL), nullString(u.Username), u.IsGuest, deref(u.GuestExpiresAt),
+		withDefault(u.DisplayCurrency, "USD"), withDefault(u.AfterHoursOrders, data.AfterHoursReject), nullString(u.League),
+		withDefault(u.CostBasisMethod, data.CostBasisAverage), u.TradeConfirmationEmails, withDefault(u.Timezone, data.DefaultTimezone), withDefault(u.Role, data.RoleUser),
+	)
+}
+
+func ptr[T any](v T) *T { return &v }
+
+func deref[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func withDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
 }
 
 // validPassword satisfies the Register password-strength rules
@@ -34,7 +77,7 @@ func newAuthService(t *testing.T) (*AuthService, sqlmock.Sqlmock, func()) {
 	}
 	jwtSvc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
 	users := data.NewUserStore(db)
-	svc := NewAuthService(users, jwtSvc, nil, nil)
+	svc := NewAuthService(users, jwtSvc, nil, nil, nil, nil)
 	return svc, mock, func() { db.Close() }
 }
 
@@ -44,7 +87,7 @@ func TestRegister_RejectsInvalidEmail(t *testing.T) {
 	svc, _, cleanup := newAuthService(t)
 	defer cleanup()
 
-	_, _, err := svc.Register(context.Background(), "not-an-email", validPassword)
+	_, _, err := svc.Register(context.Background(), "not-an-email", validPassword, "", "")
 	if err == nil {
 		t.Fatal("expected error for invalid email, got nil")
 	}
@@ -69,7 +112,7 @@ func TestRegister_RejectsWeakPassword(t *testing.T) {
 	}
 	for _, tc := range weakCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, _, err := svc.Register(context.Background(), "user@example.com", tc.pw)
+			_, _, err := svc.Register(context.Background(), "user@example.com", tc.pw, "", "")
 			if err == nil {
 				t.Errorf("expected error for password %q, got nil", tc.pw)
 			}
@@ -82,14 +125,11 @@ func TestRegister_RejectsDuplicateEmail(t *testing.T) {
 	defer cleanup()
 
 	// GetUserByEmail returns an existing row → email already exists.
-	mock.ExpectQuery("SELECT id, email, password").
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs("dupe@example.com").
-		WillReturnRows(sqlmock.NewRows(authUserCols).AddRow(
-			"user-existing", "dupe@example.com", "hashed", time.Now(), 100.0,
-			true, nil, nil, nil, "email",
-		))
+		WillReturnRows(userRow(data.User{ID: "user-existing", Email: "dupe@example.com", Password: "hashed", Balance: decimal.NewFromInt(100), EmailVerified: true}))
 
-	_, _, err := svc.Register(context.Background(), "dupe@example.com", validPassword)
+	_, _, err := svc.Register(context.Background(), "dupe@example.com", validPassword, "", "")
 	var emailExists *EmailExistsError
 	if !errors.As(err, &emailExists) {
 		t.Errorf("expected *EmailExistsError, got %T (%v)", err, err)
@@ -104,7 +144,7 @@ func TestLogin_WrongEmail(t *testing.T) {
 
 	// GetUserByEmail returns no rows → invalid credentials (do not leak whether
 	// the email exists).
-	mock.ExpectQuery("SELECT id, email, password").
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs("nobody@example.com").
 		WillReturnRows(sqlmock.NewRows(authUserCols))
 
@@ -123,12 +163,9 @@ func TestLogin_WrongPassword(t *testing.T) {
 	// password presented to ValidatePassword will fail the constant-time
 	// comparison.
 	const realPasswordHash = "$2a$12$h7XaMZJk2WbLVLR6IqJ9j.0IFh2K5VPXQbEEwHx2SsW1Q5/L0XfPe"
-	mock.ExpectQuery("SELECT id, email, password").
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs("alice@example.com").
-		WillReturnRows(sqlmock.NewRows(authUserCols).AddRow(
-			"user-alice", "alice@example.com", realPasswordHash, time.Now(), 100.0,
-			true, nil, nil, nil, "email",
-		))
+		WillReturnRows(userRow(data.User{ID: "user-alice", Email: "alice@example.com", Password: realPasswordHash, Balance: decimal.NewFromInt(100), EmailVerified: true}))
 
 	_, _, err := svc.Login(context.Background(), "alice@example.com", "WrongGuess1!")
 	var invalid *InvalidCredentialsError
@@ -146,12 +183,9 @@ func TestLogin_GoogleOnlyAccountRejectsPassword(t *testing.T) {
 	defer cleanup()
 
 	// password column is NULL — sql.NullString unset → Password field is "".
-	mock.ExpectQuery("SELECT id, email, password").
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
 		WithArgs("g@example.com").
-		WillReturnRows(sqlmock.NewRows(authUserCols).AddRow(
-			"user-g", "g@example.com", nil, time.Now(), 100.0,
-			true, nil, nil, "google-sub-1", "google",
-		))
+		WillReturnRows(userRow(data.User{ID: "user-g", Email: "g@example.com", Balance: decimal.NewFromInt(100), EmailVerified: true, GoogleID: ptr("google-sub-1"), CreatedVia: "google"}))
 
 	_, _, err := svc.Login(context.Background(), "g@example.com", validPassword)
 	var invalid *InvalidCredentialsError
@@ -169,10 +203,71 @@ func TestLoginWithGoogle_RejectsWhenClientIDUnset(t *testing.T) {
 	svc, _, cleanup := newAuthService(t)
 	defer cleanup()
 	// Wire a Google service with an empty client ID.
-	svc.googleOAuth = NewGoogleOAuthService(svc.users, svc.jwtService, "")
+	svc.googleOAuth = NewGoogleOAuthService(svc.users, svc.jwtService, "", nil)
 
-	_, _, err := svc.LoginWithGoogle(context.Background(), "anything")
+	_, _, err := svc.LoginWithGoogle(context.Background(), "anything", "")
 	if err == nil {
 		t.Fatal("expected error when GOOGLE_CLIENT_ID is empty, got nil")
 	}
 }
+
+// ---- Elevate (sudo mode) ----
+
+func TestElevate_WrongPasswordFails(t *testing.T) {
+	svc, mock, cleanup := newAuthService(t)
+	defer cleanup()
+
+	const realPasswordHash = "$2a$12$h7XaMZJk2WbLVLR6IqJ9j.0IFh2K5VPXQbEEwHx2SsW1Q5/L0XfPe"
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WithArgs("user-alice").
+		WillReturnRows(userRow(data.User{ID: "user-alice", Email: "alice@example.com", Password: realPasswordHash, Balance: decimal.NewFromInt(100), EmailVerified: true}))
+
+	_, _, err := svc.Elevate(context.Background(), "user-alice", "WrongGuess1!", "", 5*time.Minute)
+	var failed *SudoConfirmationError
+	if !errors.As(err, &failed) {
+		t.Errorf("expected *SudoConfirmationError, got %T (%v)", err, err)
+	}
+}
+
+func TestElevate_IssuesScopedToken(t *testing.T) {
+	svc, mock, cleanup := newAuthService(t)
+	defer cleanup()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(validPassword), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt: %v", err)
+	}
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WithArgs("user-alice").
+		WillReturnRows(userRow(data.User{ID: "user-alice", Email: "alice@example.com", Password: string(hash), Balance: decimal.NewFromInt(100), EmailVerified: true}))
+
+	token, expiresAt, err := svc.Elevate(context.Background(), "user-alice", validPassword, "", 5*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if until := time.Until(expiresAt); until <= 4*time.Minute || until > 5*time.Minute {
+		t.Errorf("expires_at: %v from now, want ~5m", until)
+	}
+	claims, err := svc.jwtService.ValidateSudoToken(token)
+	if err != nil || claims.UserID != "user-alice" {
+		t.Errorf("ValidateSudoToken: claims=%+v err=%v", claims, err)
+	}
+	if _, err := svc.jwtService.ValidateToken(token); err == nil {
+		t.Error("sudo token must not validate as a session token")
+	}
+}
+
+func TestElevate_GoogleOnlyAccountNeedsGoogleToken(t *testing.T) {
+	svc, mock, cleanup := newAuthService(t)
+	defer cleanup()
+
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WithArgs("user-g").
+		WillReturnRows(userRow(data.User{ID: "user-g", Email: "g@example.com", Balance: decimal.NewFromInt(100), EmailVerified: true, GoogleID: ptr("google-sub-1"), CreatedVia: "google"}))
+
+	_, _, err := svc.Elevate(context.Background(), "user-g", validPassword, "", 5*time.Minute)
+	var failed *SudoConfirmationError
+	if !errors.As(err, &failed) {
+		t.Errorf("expected *SudoConfirmationError, got %T (%v)", err, err)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"papertrader/internal/data"
+)
+
+// avatarTypes maps sniffed content types to the file extension used in the
+// storage key. SVG is deliberately absent: it can carry script.
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// AvatarService stores profile images. Uploads are proxied through the API
+// so the content type can be verified from the bytes rather than trusted
+// from the client.
+type AvatarService struct {
+	users    *data.UserStore
+	storage  ObjectStorage
+	maxBytes int64
+}
+
+func NewAvatarService(users *data.UserStore, storage ObjectStorage, maxBytes int64) *AvatarService {
+	return &AvatarService{users: users, storage: storage, maxBytes: maxBytes}
+}
+
+// MaxBytes is the largest accepted upload.
+func (s *AvatarService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Upload validates body, stores it under a fresh key and points the user's
+// profile at it. The previous avatar, if any, is deleted afterwards.
+func (s *AvatarService) Upload(ctx context.Context, userID string, body []byte) (string, error) {
+	if int64(len(body)) > s.maxBytes {
+		return "", &AvatarTooLargeError{MaxBytes: s.maxBytes}
+	}
+	if len(body) == 0 {
+		return "", &InvalidAvatarError{}
+	}
+	contentType := http.DetectContentType(body)
+	ext, ok := avatarTypes[contentType]
+	if !ok {
+		return "", &InvalidAvatarError{}
+	}
+
+	// A new key per upload lets the object be cached indefinitely.
+	key := "avatars/" + userID + "/" + uuid.New().String() + ext
+	url, err := s.storage.Put(ctx, key, contentType, body)
+	if err != nil {
+		return "", err
+	}
+	previous, err := s.users.SetAvatar(ctx, userID, key, url)
+	if err != nil {
+		s.deleteObject(ctx, key)
+		return "", err
+	}
+	if previous != "" {
+		s.deleteObject(ctx, previous)
+	}
+	return url, nil
+}
+
+// Remove clears the user's avatar.
+func (s *AvatarService) Remove(ctx context.Context, userID string) error {
+	previous, err := s.users.SetAvatar(ctx, userID, "", "")
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		s.deleteObject(ctx, previous)
+	}
+	return nil
+}
+
+// deleteObject is best-effort: an orphaned object costs storage, not
+// correctness.
+func (s *AvatarService) deleteObject(ctx context.Context, key string) {
+	if err := s.storage.Delete(ctx, key); err != nil {
+		slog.Warn("failed to delete avatar object", "key", key, "err", err, "component", "avatar")
+	}
+}
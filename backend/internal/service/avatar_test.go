@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+// fakeStorage records Put/Delete calls in memory.
+type fakeStorage struct {
+	puts    map[string]string // key -> content type
+	deleted []string
+}
+
+func (f *fakeStorage) Put(_ context.Context, key, contentType string, _ []byte) (string, error) {
+	if f.puts == nil {
+		f.puts = map[string]string{}
+	}
+	f.puts[key] = contentType
+	return "https://cdn.example.com/" + key, nil
+}
+
+func (f *fakeStorage) Delete(_ context.Context, key string) error {
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+// pngHeader is enough for http.DetectContentType to report image/png.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestAvatarUpload_RejectsInvalidImages(t *testing.T) {
+	svc := NewAvatarService(nil, &fakeStorage{}, 256)
+
+	cases := map[string][]byte{
+		"empty": nil,
+		"svg":   []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`),
+		"html":  []byte("<html><body>hi</body></html>"),
+	}
+	for name, body := range cases {
+		_, err := svc.Upload(context.Background(), "user-1", body)
+		var invalid *InvalidAvatarError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidAvatarError, got %v", name, err)
+		}
+	}
+
+	_, err := svc.Upload(context.Background(), "user-1", append(pngHeader, make([]byte, 256)...))
+	var tooLarge *AvatarTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("expected AvatarTooLargeError, got %v", err)
+	}
+}
+
+func TestAvatarUpload_StoresAndReplacesPrevious(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE users u SET avatar_key").
+		WithArgs("user-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"avatar_key"}).AddRow("avatars/user-1/old.png"))
+
+	storage := &fakeStorage{}
+	url, err := NewAvatarService(data.NewUserStore(db), storage, 1024).Upload(context.Background(), "user-1", pngHeader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(url, "https://cdn.example.com/avatars/user-1/") || !strings.HasSuffix(url, ".png") {
+		t.Errorf("unexpected url %q", url)
+	}
+	for key, ct := range storage.puts {
+		if ct != "image/png" {
+			t.Errorf("stored %s as %q, want image/png", key, ct)
+		}
+	}
+	if len(storage.deleted) != 1 || storage.deleted[0] != "avatars/user-1/old.png" {
+		t.Errorf("expected previous avatar to be deleted, got %v", storage.deleted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
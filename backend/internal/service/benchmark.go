@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+// HistoricalSeriesSource is the subset of MarketService used by
+// BenchmarkService and ReplayService.
+type HistoricalSeriesSource interface {
+	GetHistoricalSeries(ctx context.Context, symbol string, days int) (*HistoricalSeries, error)
+}
+
+// BenchmarkPoint is one trading day of a benchmark comparison. Returns are
+// percentages since the comparison's first point. PortfolioReturn is
+// time-weighted, so bonus cash granted along the way doesn't count as a
+// gain: each day's return is the move in value less that day's grants.
+type BenchmarkPoint struct {
+	Date            string          `json:"date"` // YYYY-MM-DD
+	PortfolioValue  decimal.Decimal `json:"portfolio_value"`
+	PortfolioReturn decimal.Decimal `json:"portfolio_return"`
+	BenchmarkClose  decimal.Decimal `json:"benchmark_close"`
+	BenchmarkReturn decimal.Decimal `json:"benchmark_return"`
+
+	// netValue is the first point's value grown by PortfolioReturn, unrounded:
+	// the equity curve without grants, for the risk statistics.
+	netValue decimal.Decimal
+}
+
+// BenchmarkComparison sets a user's equity curve against a benchmark's
+// closes over the same days. The summary returns are as of the last point;
+// RelativeReturn is PortfolioReturn less BenchmarkReturn, in percentage
+// points.
+type BenchmarkComparison struct {
+	Range           string           `json:"range"`
+	Symbol          string           `json:"symbol"`
+	Points          []BenchmarkPoint `json:"points"`
+	PortfolioReturn decimal.Decimal  `json:"portfolio_return"`
+	BenchmarkReturn decimal.Decimal  `json:"benchmark_return"`
+	RelativeReturn  decimal.Decimal  `json:"relative_return"`
+	Currency        string           `json:"currency,omitempty"`
+}
+
+// BenchmarkService compares the daily portfolio snapshots with an index or
+// ETF, SPY unless configured or requested otherwise.
+type BenchmarkService struct {
+	history *PortfolioHistoryService
+	series  HistoricalSeriesSource
+	symbol  string
+}
+
+func NewBenchmarkService(history *PortfolioHistoryService, series HistoricalSeriesSource, symbol string) *BenchmarkService {
+	return &BenchmarkService{history: history, series: series, symbol: symbol}
+}
+
+// Compare returns userID's snapshots over rng (see PortfolioHistoryService.History)
+// beside symbol's closes, or the default benchmark's when symbol is empty.
+// A snapshot on a day the benchmark did not trade takes its previous close;
+// snapshots before the benchmark's first close, or while the account was
+// worth nothing, are left out so both series start from the same day.
+func (s *BenchmarkService) Compare(ctx context.Context, userID, rng, symbol string) (*BenchmarkComparison, error) {
+	if strings.TrimSpace(symbol) == "" {
+		symbol = s.symbol
+	}
+	symbol, err := util.ValidateQuoteSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.history.History(ctx, userID, rng)
+	if err != nil {
+		return nil, err
+	}
+	out := &BenchmarkComparison{Range: history.Range, Symbol: symbol, Points: make([]BenchmarkPoint, 0, len(history.Points))}
+	if len(history.Points) == 0 {
+		return out, nil
+	}
+
+	first, err := time.Parse(time.DateOnly, history.Points[0].Date)
+	if err != nil {
+		return nil, err
+	}
+	// A week's slack finds the close in force on the first snapshot's day.
+	days := int(startOfUTCDay(s.history.now()).Sub(first).Hours()/24) + 7
+	series, err := s.series.GetHistoricalSeries(ctx, symbol, days)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseValue, baseClose, lastClose, lastBonus decimal.Decimal
+	// scale takes each value to what it would be without the grants since
+	// the first point: it shrinks by (value - grant) / value on a day with
+	// a grant, and stays exactly 1 while there are none.
+	scale := decimal.NewFromInt(1)
+	j := 0
+	for _, p := range history.Points {
+		for j < len(series.Points) && series.Points[j].Date <= p.Date {
+			lastClose = series.Points[j].Close
+			j++
+		}
+		if !lastClose.IsPositive() {
+			continue
+		}
+		if len(out.Points) == 0 {
+			if !p.TotalValue.IsPositive() {
+				continue
+			}
+			baseValue, baseClose, lastBonus = p.TotalValue, lastClose, p.BonusCash
+		}
+		if granted := p.BonusCash.Sub(lastBonus); granted.IsPositive() && p.TotalValue.IsPositive() {
+			scale = scale.Mul(p.TotalValue.Sub(granted)).Div(p.TotalValue)
+		}
+		lastBonus = p.BonusCash
+		net := p.TotalValue.Mul(scale)
+		out.Points = append(out.Points, BenchmarkPoint{
+			Date:            p.Date,
+			PortfolioValue:  p.TotalValue,
+			PortfolioReturn: percentChange(baseValue, net),
+			BenchmarkClose:  lastClose,
+			BenchmarkReturn: percentChange(baseClose, lastClose),
+			netValue:        net,
+		})
+	}
+	if n := len(out.Points); n > 0 {
+		last := out.Points[n-1]
+		out.PortfolioReturn = last.PortfolioReturn
+		out.BenchmarkReturn = last.BenchmarkReturn
+		out.RelativeReturn = last.PortfolioReturn.Sub(last.BenchmarkReturn)
+	}
+	return out, nil
+}
+
+// percentChange is the change from base to v as a percentage, to two
+// places. base must be positive.
+func percentChange(base, v decimal.Decimal) decimal.Decimal {
+	return v.Sub(base).Div(base).Mul(decimal.NewFromInt(100)).Round(2)
+}
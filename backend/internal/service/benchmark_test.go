@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+)
+
+type mockSeries struct {
+	series *HistoricalSeries
+	symbol string
+	days   int
+}
+
+func (m *mockSeries) GetHistoricalSeries(_ context.Context, symbol string, days int) (*HistoricalSeries, error) {
+	m.symbol, m.days = symbol, days
+	return m.series, nil
+}
+
+func TestBenchmarkCompare_AlignsSeries(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	history, mock, cleanup := newPortfolioHistoryService(t, now)
+	defer cleanup()
+	series := &mockSeries{series: &HistoricalSeries{Symbol: "SPY", Points: []HistoricalSeriesPoint{
+		{Date: "2026-10-09", Close: decimal.NewFromInt(500)},
+		{Date: "2026-10-13", Close: decimal.NewFromInt(510)},
+		{Date: "2026-10-14", Close: decimal.NewFromInt(505)},
+	}}}
+	svc := NewBenchmarkService(history, series, "SPY")
+
+	day := func(d int) time.Time { return time.Date(2026, time.October, d, 0, 0, 0, 0, time.UTC) }
+	mock.ExpectQuery("FROM portfolio_history").
+		WithArgs("user-1", "2026-09-16").
+		WillReturnRows(sqlmock.NewRows([]string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}).
+			AddRow(day(8), "10000", "0", "10000", false, "0").  // before the benchmark's first close
+			AddRow(day(12), "10000", "0", "10000", false, "0"). // no close that day: takes Friday's
+			AddRow(day(13), "5000", "5500", "10500", false, "0").
+			AddRow(day(14), "5000", "4900", "9900", false, "0"))
+
+	got, err := svc.Compare(context.Background(), "user-1", "", "")
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if series.symbol != "SPY" || series.days != 15 {
+		t.Errorf("series fetch: symbol %q, days %d", series.symbol, series.days)
+	}
+	want := []struct {
+		date              string
+		portfolio, market string
+	}{
+		{"2026-10-12", "0", "0"},
+		{"2026-10-13", "5", "2"},
+		{"2026-10-14", "-1", "1"},
+	}
+	if len(got.Points) != len(want) {
+		t.Fatalf("points: got %+v", got.Points)
+	}
+	for i, w := range want {
+		p := got.Points[i]
+		if p.Date != w.date || !p.PortfolioReturn.Equal(decimal.RequireFromString(w.portfolio)) || !p.BenchmarkReturn.Equal(decimal.RequireFromString(w.market)) {
+			t.Errorf("point %d: got %+v, want %+v", i, p, w)
+		}
+	}
+	if !got.RelativeReturn.Equal(decimal.NewFromInt(-2)) || got.Range != "1M" || got.Symbol != "SPY" {
+		t.Errorf("summary: got %+v", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBenchmarkCompare_LeavesOutBonusCash(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	history, mock, cleanup := newPortfolioHistoryService(t, now)
+	defer cleanup()
+	series := &mockSeries{series: &HistoricalSeries{Symbol: "SPY", Points: []HistoricalSeriesPoint{
+		{Date: "2026-10-09", Close: decimal.NewFromInt(500)},
+	}}}
+	svc := NewBenchmarkService(history, series, "SPY")
+
+	// 1000 bonus cash on the 13th, then 10% on each day.
+	day := func(d int) time.Time { return time.Date(2026, time.October, d, 0, 0, 0, 0, time.UTC) }
+	mock.ExpectQuery("FROM portfolio_history").
+		WillReturnRows(sqlmock.NewRows([]string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}).
+			AddRow(day(12), "0", "1000", "1000", false, "0").
+			AddRow(day(13), "1000", "1100", "2100", false, "1000").
+			AddRow(day(14), "1000", "1310", "2310", false, "1000"))
+
+	got, err := svc.Compare(context.Background(), "user-1", "", "")
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	want := []string{"0", "10", "21"}
+	if len(got.Points) != len(want) {
+		t.Fatalf("points: got %+v", got.Points)
+	}
+	for i, w := range want {
+		if p := got.Points[i]; !p.PortfolioReturn.Equal(decimal.RequireFromString(w)) {
+			t.Errorf("point %d: got return %s, want %s", i, p.PortfolioReturn, w)
+		}
+	}
+	if !got.Points[2].PortfolioValue.Equal(decimal.NewFromInt(2310)) {
+		t.Errorf("value: got %s, want the account's actual 2310", got.Points[2].PortfolioValue)
+	}
+}
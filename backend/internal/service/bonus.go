@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// BonusStatus is what a user can expect from claiming bonus cash. Amount is
+// granted per claim; NextClaimAt is set while the cooldown still runs.
+// TotalClaimed is every grant so far, which returns leave out.
+type BonusStatus struct {
+	Enabled       bool            `json:"enabled"`
+	Amount        decimal.Decimal `json:"amount"`
+	Cooldown      int64           `json:"cooldown_seconds"`
+	Available     bool            `json:"available"`
+	LastClaimedAt *time.Time      `json:"last_claimed_at,omitempty"`
+	NextClaimAt   *time.Time      `json:"next_claim_at,omitempty"`
+	TotalClaimed  decimal.Decimal `json:"total_claimed"`
+}
+
+// BonusClaim is the outcome of a successful claim.
+type BonusClaim struct {
+	Amount      decimal.Decimal `json:"amount"`
+	Balance     decimal.Decimal `json:"balance"`
+	NextClaimAt time.Time       `json:"next_claim_at"`
+}
+
+// BonusService grants bonus cash: a fixed amount of paper money a user can
+// claim once per cooldown, giving a busted account a way back short of a
+// full reset. Grants are recorded so performance figures can treat them as
+// money put in rather than earned.
+type BonusService struct {
+	store    *data.BonusStore
+	amount   decimal.Decimal
+	cooldown time.Duration
+	now      func() time.Time
+}
+
+// NewBonusService builds the service. A zero amount disables claims.
+func NewBonusService(store *data.BonusStore, amount decimal.Decimal, cooldown time.Duration) *BonusService {
+	return &BonusService{store: store, amount: amount, cooldown: cooldown, now: time.Now}
+}
+
+// Status reports whether userID can claim now and, if not, when.
+func (s *BonusService) Status(ctx context.Context, userID string) (*BonusStatus, error) {
+	last, total, err := s.store.Summary(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &UserNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	st := &BonusStatus{
+		Enabled:       s.amount.IsPositive(),
+		Amount:        s.amount,
+		Cooldown:      int64(s.cooldown / time.Second),
+		LastClaimedAt: last,
+		TotalClaimed:  total,
+	}
+	if last != nil {
+		if next := last.Add(s.cooldown); next.After(s.now()) {
+			st.NextClaimAt = &next
+		}
+	}
+	st.Available = st.Enabled && st.NextClaimAt == nil
+	return st, nil
+}
+
+// Claim credits the bonus amount to userID's cash, enforcing the cooldown.
+func (s *BonusService) Claim(ctx context.Context, userID string) (*BonusClaim, error) {
+	if !s.amount.IsPositive() {
+		return nil, &BonusDisabledError{}
+	}
+	now := s.now()
+	balance, claimedAt, err := s.store.Grant(ctx, userID, s.amount, now.Add(-s.cooldown), now)
+	switch {
+	case errors.Is(err, data.ErrBonusCooldown):
+		return nil, &BonusCooldownError{RetryAt: claimedAt.Add(s.cooldown)}
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, &UserNotFoundError{}
+	case err != nil:
+		return nil, err
+	}
+	slog.Info("bonus cash claimed", "user_id", userID, "amount", s.amount, "component", "bonus")
+	return &BonusClaim{Amount: s.amount, Balance: balance, NextClaimAt: now.Add(s.cooldown).UTC()}, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func TestBonusClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE users SET balance = balance").
+		WithArgs("user-1", "1000", now.Add(-7*24*time.Hour), now, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("1012.50"))
+
+	svc := NewBonusService(data.NewBonusStore(db), decimal.NewFromInt(1000), 7*24*time.Hour)
+	svc.now = func() time.Time { return now }
+	claim, err := svc.Claim(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if !claim.Balance.Equal(decimal.RequireFromString("1012.50")) || !claim.NextClaimAt.Equal(now.Add(7*24*time.Hour)) {
+		t.Errorf("claim: got %+v", claim)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestBonusClaim_Cooldown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	claimedAt := time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE users SET balance = balance").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}))
+	mock.ExpectQuery("SELECT bonus_claimed_at FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"bonus_claimed_at"}).AddRow(claimedAt))
+
+	svc := NewBonusService(data.NewBonusStore(db), decimal.NewFromInt(1000), 7*24*time.Hour)
+	_, err = svc.Claim(context.Background(), "user-1")
+
+	var cooldown *BonusCooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("expected BonusCooldownError, got %v", err)
+	}
+	if want := claimedAt.Add(7 * 24 * time.Hour); !cooldown.RetryAt.Equal(want) {
+		t.Errorf("RetryAt: got %v, want %v", cooldown.RetryAt, want)
+	}
+}
+
+func TestBonusClaim_Disabled(t *testing.T) {
+	svc := NewBonusService(nil, decimal.Zero, time.Hour)
+	var disabled *BonusDisabledError
+	if _, err := svc.Claim(context.Background(), "user-1"); !errors.As(err, &disabled) {
+		t.Errorf("expected BonusDisabledError, got %v", err)
+	}
+}
+
+func TestBonusStatus(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	claimedAt := now.Add(-24 * time.Hour)
+	mock.ExpectQuery("SELECT u.bonus_claimed_at").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"bonus_claimed_at", "total"}).AddRow(claimedAt, "2000"))
+
+	svc := NewBonusService(data.NewBonusStore(db), decimal.NewFromInt(1000), 7*24*time.Hour)
+	svc.now = func() time.Time { return now }
+	st, err := svc.Status(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if st.Available || st.NextClaimAt == nil || !st.NextClaimAt.Equal(claimedAt.Add(7*24*time.Hour)) ||
+		!st.TotalClaimed.Equal(decimal.NewFromInt(2000)) || st.Cooldown != 604800 {
+		t.Errorf("status: got %+v", st)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// FallbackStockCache reads and writes primary (Redis), turning to fallback
+// (in process) only while primary fails, so an outage doesn't send every
+// quote to the provider. Entries written to fallback during an outage are
+// ignored once primary answers again.
+type FallbackStockCache struct {
+	primary  StockCache
+	fallback StockCache
+}
+
+func NewFallbackStockCache(primary, fallback StockCache) *FallbackStockCache {
+	return &FallbackStockCache{primary: primary, fallback: fallback}
+}
+
+// GetStock implements StockCache.
+func (c *FallbackStockCache) GetStock(ctx context.Context, symbol, date string) (*StockData, error) {
+	data, err := c.primary.GetStock(ctx, symbol, date)
+	if err != nil {
+		return c.fallback.GetStock(ctx, symbol, date)
+	}
+	return data, nil
+}
+
+// SetStock implements StockCache.
+func (c *FallbackStockCache) SetStock(ctx context.Context, symbol, date string, data *StockData, ttl time.Duration) error {
+	if err := c.primary.SetStock(ctx, symbol, date, data, ttl); err != nil {
+		return c.fallback.SetStock(ctx, symbol, date, data, ttl)
+	}
+	return nil
+}
+
+// InvalidateStock implements StockCache.
+func (c *FallbackStockCache) InvalidateStock(ctx context.Context, symbol string) error {
+	return c.InvalidateStocks(ctx, []string{symbol})
+}
+
+// InvalidateStocks implements StockCache, clearing both caches.
+func (c *FallbackStockCache) InvalidateStocks(ctx context.Context, symbols []string) error {
+	err := c.primary.InvalidateStocks(ctx, symbols)
+	if ferr := c.fallback.InvalidateStocks(ctx, symbols); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// FallbackHistoricalCache is FallbackStockCache for EOD ranges.
+type FallbackHistoricalCache struct {
+	primary  HistoricalCache
+	fallback HistoricalCache
+}
+
+func NewFallbackHistoricalCache(primary, fallback HistoricalCache) *FallbackHistoricalCache {
+	return &FallbackHistoricalCache{primary: primary, fallback: fallback}
+}
+
+// GetHistorical implements HistoricalCache.
+func (c *FallbackHistoricalCache) GetHistorical(ctx context.Context, symbol, startDate, endDate string) (*HistoricalData, error) {
+	data, err := c.primary.GetHistorical(ctx, symbol, startDate, endDate)
+	if err != nil {
+		return c.fallback.GetHistorical(ctx, symbol, startDate, endDate)
+	}
+	return data, nil
+}
+
+// SetHistorical implements HistoricalCache.
+func (c *FallbackHistoricalCache) SetHistorical(ctx context.Context, symbol, startDate, endDate string, data *HistoricalData, ttl time.Duration) error {
+	if err := c.primary.SetHistorical(ctx, symbol, startDate, endDate, data, ttl); err != nil {
+		return c.fallback.SetHistorical(ctx, symbol, startDate, endDate, data, ttl)
+	}
+	return nil
+}
+
+// IsRangeEmpty implements HistoricalCache.
+func (c *FallbackHistoricalCache) IsRangeEmpty(ctx context.Context, symbol, startDate, endDate string) (bool, error) {
+	empty, err := c.primary.IsRangeEmpty(ctx, symbol, startDate, endDate)
+	if err != nil {
+		return c.fallback.IsRangeEmpty(ctx, symbol, startDate, endDate)
+	}
+	return empty, nil
+}
+
+// MarkRangeEmpty implements HistoricalCache.
+func (c *FallbackHistoricalCache) MarkRangeEmpty(ctx context.Context, symbol, startDate, endDate string, ttl time.Duration) error {
+	if err := c.primary.MarkRangeEmpty(ctx, symbol, startDate, endDate, ttl); err != nil {
+		return c.fallback.MarkRangeEmpty(ctx, symbol, startDate, endDate, ttl)
+	}
+	return nil
+}
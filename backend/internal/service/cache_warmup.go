@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// warmupTimeout bounds the start-up quote warm-up, so a slow provider
+// cannot leave it running long after the first requests have filled the
+// cache themselves.
+const warmupTimeout = 2 * time.Minute
+
+// QuoteWarmer is the subset of MarketService used by CacheWarmer.
+type QuoteWarmer interface {
+	WarmQuotes(ctx context.Context, symbols []string) (int, error)
+	WarmQuotesUntil(ctx context.Context, symbols []string, date string, expires time.Time) (int, error)
+}
+
+// CacheWarmer pre-fetches quotes for every held or watched symbol after a
+// deploy or restart, and again after each session's close, so the first
+// users don't each pay a cold cache and send the provider a burst of
+// single-symbol lookups. Its fetches are non-critical: they are the first
+// shed when the provider's monthly quota runs low (see QuotaTracker).
+type CacheWarmer struct {
+	portfolio *data.PortfolioStore
+	watchlist *data.WatchlistStore
+	market    QuoteWarmer
+	now       func() time.Time
+}
+
+func NewCacheWarmer(portfolio *data.PortfolioStore, watchlist *data.WatchlistStore, market QuoteWarmer) *CacheWarmer {
+	return &CacheWarmer{portfolio: portfolio, watchlist: watchlist, market: market, now: time.Now}
+}
+
+// Run warms the quote cache once and returns. It is meant to run in the
+// background at start-up; failures are logged, since a cold cache only
+// costs latency.
+func (w *CacheWarmer) Run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(nonCritical(ctx), warmupTimeout)
+	defer cancel()
+
+	started := time.Now()
+	symbols, err := w.symbols(ctx)
+	if err != nil {
+		slog.Warn("cache warm-up: listing symbols failed", "err", err, "component", "cache_warmup")
+		return
+	}
+	if len(symbols) == 0 {
+		return
+	}
+	warmed, err := w.market.WarmQuotes(ctx, symbols)
+	if err != nil {
+		slog.Warn("cache warm-up stopped", "warmed", warmed, "symbols", len(symbols), "err", err, "component", "cache_warmup")
+		return
+	}
+	slog.Info("cache warm-up done", "warmed", warmed, "symbols", len(symbols),
+		"duration_ms", time.Since(started).Milliseconds(), "component", "cache_warmup")
+}
+
+// WarmClose caches the closing quote of every held or watched symbol until
+// next opens, under the date GetStock looks quotes up on that morning, so
+// the first requests of the day are cache hits. It is the EOD close's
+// "cache_warmup" step; the daily history those requests read was cached
+// when the close fetched it. A rerun, whose next session has already
+// opened, warms nothing.
+func (w *CacheWarmer) WarmClose(ctx context.Context, next MarketSession) error {
+	if !next.Open.After(w.now()) {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(nonCritical(ctx), warmupTimeout)
+	defer cancel()
+
+	symbols, err := w.symbols(ctx)
+	if err != nil || len(symbols) == 0 {
+		return err
+	}
+	date := next.Open.In(time.Local).Format(DateLayoutUS)
+	warmed, err := w.market.WarmQuotesUntil(ctx, symbols, date, next.Open)
+	if err != nil {
+		return err
+	}
+	slog.Info("closing quotes cached", "warmed", warmed, "symbols", len(symbols), "date", date,
+		"expires", next.Open, "component", "cache_warmup")
+	return nil
+}
+
+// symbols returns the distinct held and watched symbols, sorted.
+func (w *CacheWarmer) symbols(ctx context.Context) ([]string, error) {
+	held, err := w.portfolio.HeldSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	watched, err := w.watchlist.WatchedSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(held)+len(watched))
+	out := make([]string, 0, len(held)+len(watched))
+	for _, symbol := range append(held, watched...) {
+		if !seen[symbol] {
+			seen[symbol] = true
+			out = append(out, symbol)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+type mockWarmer struct {
+	symbols []string
+	date    string
+	expires time.Time
+}
+
+func (m *mockWarmer) WarmQuotes(_ context.Context, symbols []string) (int, error) {
+	m.symbols = symbols
+	return len(symbols), nil
+}
+
+func (m *mockWarmer) WarmQuotesUntil(_ context.Context, symbols []string, date string, expires time.Time) (int, error) {
+	m.symbols, m.date, m.expires = symbols, date, expires
+	return len(symbols), nil
+}
+
+func TestCacheWarmer_WarmsHeldAndWatchedSymbolsOnce(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM portfolio").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL").AddRow("MSFT"))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM watchlist").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL").AddRow("GOOG"))
+
+	market := &mockWarmer{}
+	NewCacheWarmer(data.NewPortfolioStore(db), data.NewWatchlistStore(db), market).Run(context.Background())
+
+	if want := []string{"AAPL", "GOOG", "MSFT"}; !reflect.DeepEqual(market.symbols, want) {
+		t.Errorf("warmed %v, want %v", market.symbols, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCacheWarmer_WarmCloseCachesUntilNextOpen(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM portfolio").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("MSFT"))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM watchlist").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+
+	market := &mockWarmer{}
+	w := NewCacheWarmer(data.NewPortfolioStore(db), data.NewWatchlistStore(db), market)
+	now := time.Date(2026, 10, 16, 0, 30, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+	next := MarketSession{Open: time.Date(2026, 10, 16, 13, 30, 0, 0, time.UTC), Close: time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)}
+
+	if err := w.WarmClose(context.Background(), next); err != nil {
+		t.Fatalf("WarmClose: %v", err)
+	}
+	if want := []string{"AAPL", "MSFT"}; !reflect.DeepEqual(market.symbols, want) {
+		t.Errorf("warmed %v, want %v", market.symbols, want)
+	}
+	if want := next.Open.In(time.Local).Format(DateLayoutUS); market.date != want || !market.expires.Equal(next.Open) {
+		t.Errorf("cached under %s until %v, want %s until %v", market.date, market.expires, want, next.Open)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	// Once the session has opened (a rerun), there is nothing to warm.
+	market.symbols = nil
+	now = next.Open.Add(time.Minute)
+	if err := w.WarmClose(context.Background(), next); err != nil || market.symbols != nil {
+		t.Errorf("after the open: warmed %v, %v", market.symbols, err)
+	}
+}
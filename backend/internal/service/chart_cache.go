@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ChartCache caches candlestick charts per symbol and range. Each range
+// sets its own TTL: an intraday chart goes stale in minutes, a five-year
+// one not until the next close.
+type ChartCache interface {
+	GetChart(ctx context.Context, symbol, chartRange string) (*Chart, error)
+	SetChart(ctx context.Context, chart *Chart, ttl time.Duration) error
+}
+
+// RedisChartCache implements ChartCache using Redis.
+type RedisChartCache struct {
+	client *redis.Client
+}
+
+func NewRedisChartCache(client *redis.Client) *RedisChartCache {
+	return &RedisChartCache{client: client}
+}
+
+func chartKey(symbol, chartRange string) string {
+	return fmt.Sprintf("chart:%s:%s", symbol, chartRange)
+}
+
+// GetChart returns the cached chart, or nil on a miss. Redis errors are
+// logged and treated as a miss.
+func (c *RedisChartCache) GetChart(ctx context.Context, symbol, chartRange string) (*Chart, error) {
+	val, err := c.client.Get(ctx, chartKey(symbol, chartRange)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Error("Redis error getting chart", "symbol", symbol, "range", chartRange, "err", err, "component", "chart_cache")
+		}
+		return nil, nil
+	}
+	var chart Chart
+	if err := json.Unmarshal([]byte(val), &chart); err != nil {
+		slog.Error("failed to unmarshal chart cache entry", "symbol", symbol, "range", chartRange, "err", err, "component", "chart_cache")
+		return nil, nil
+	}
+	return &chart, nil
+}
+
+// SetChart stores chart under its symbol and range for ttl.
+func (c *RedisChartCache) SetChart(ctx context.Context, chart *Chart, ttl time.Duration) error {
+	raw, err := json.Marshal(chart)
+	if err != nil {
+		return fmt.Errorf("error marshaling chart: %w", err)
+	}
+	if err := c.client.Set(ctx, chartKey(chart.Symbol, chart.Range), raw, ttl).Err(); err != nil {
+		slog.Error("failed to set chart cache entry", "symbol", chart.Symbol, "range", chart.Range, "err", err, "component", "chart_cache")
+		return err
+	}
+	return nil
+}
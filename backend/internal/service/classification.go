@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// classificationDataset is the bundled reference data: one row per symbol
+// with its GICS sector and sub-industry and "|"-separated index codes. It
+// covers the Dow 30 and the largest S&P 500 and Nasdaq-100 names; edit the
+// file and reload (or restart) when constituents change.
+//
+//go:embed refdata/classifications.csv
+var classificationDataset []byte
+
+// Index codes used in Classification.Indices.
+const (
+	IndexDowJones   = "DJI" // Dow Jones Industrial Average
+	IndexSP500      = "SPX" // S&P 500
+	IndexNasdaq100  = "NDX" // Nasdaq-100
+	maxIndustryName = 128
+)
+
+var knownIndices = map[string]bool{IndexDowJones: true, IndexSP500: true, IndexNasdaq100: true}
+
+// GICSSectors are the eleven GICS sectors, the only values accepted for
+// Classification.Sector.
+var GICSSectors = []string{
+	"Communication Services",
+	"Consumer Discretionary",
+	"Consumer Staples",
+	"Energy",
+	"Financials",
+	"Health Care",
+	"Industrials",
+	"Information Technology",
+	"Materials",
+	"Real Estate",
+	"Utilities",
+}
+
+// ClassificationService serves sector, industry and index membership for
+// symbols. It is the reference the allocation, movers and competition
+// features look symbols up in; the provider has no sector data on our plan,
+// so rows come from the bundled dataset plus admin overrides.
+type ClassificationService struct {
+	store *data.ClassificationStore
+}
+
+func NewClassificationService(store *data.ClassificationStore) *ClassificationService {
+	return &ClassificationService{store: store}
+}
+
+// LoadDataset writes the bundled dataset to the store, leaving admin
+// overrides alone and dropping dataset rows for symbols no longer in it.
+// Returns the number of rows written.
+func (s *ClassificationService) LoadDataset(ctx context.Context) (int, error) {
+	items, err := parseClassificationDataset(classificationDataset)
+	if err != nil {
+		return 0, fmt.Errorf("parse classification dataset: %w", err)
+	}
+	n, err := s.store.ReplaceDataset(ctx, items)
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("classification dataset loaded", "symbols", len(items), "written", n, "component", "classification")
+	return n, nil
+}
+
+// Get returns symbol's classification.
+func (s *ClassificationService) Get(ctx context.Context, symbol string) (*data.Classification, error) {
+	symbol, err := util.ValidateSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.store.Get(ctx, symbol)
+	if err != nil {
+		if errors.Is(err, data.ErrClassificationNotFound) {
+			return nil, &ClassificationNotFoundError{}
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// Classify returns the classifications of symbols keyed by symbol.
+// Unclassified symbols are left out; callers bucket them as they see fit.
+func (s *ClassificationService) Classify(ctx context.Context, symbols []string) (map[string]data.Classification, error) {
+	if len(symbols) == 0 {
+		return map[string]data.Classification{}, nil
+	}
+	return s.store.GetMany(ctx, symbols)
+}
+
+// IndexMembers returns the classified members of the index with code.
+func (s *ClassificationService) IndexMembers(ctx context.Context, code string) ([]data.Classification, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !knownIndices[code] {
+		return nil, &util.ValidationError{Field: "index", Message: "must be DJI, SPX or NDX"}
+	}
+	return s.store.ListByIndex(ctx, code)
+}
+
+// SectorMembers returns the symbols classified under sector, which is
+// matched case-insensitively against GICSSectors.
+func (s *ClassificationService) SectorMembers(ctx context.Context, sector string) ([]data.Classification, error) {
+	canonical, ok := canonicalSector(sector)
+	if !ok {
+		return nil, &util.ValidationError{Field: "sector", Message: "must be a GICS sector"}
+	}
+	return s.store.ListBySector(ctx, canonical)
+}
+
+// Override saves an admin classification for c.Symbol, which survives
+// dataset reloads.
+func (s *ClassificationService) Override(ctx context.Context, c data.Classification) (*data.Classification, error) {
+	if err := normaliseClassification(&c); err != nil {
+		return nil, err
+	}
+	return s.store.Save(ctx, &c)
+}
+
+// normaliseClassification validates c in place: the symbol, a known sector
+// (canonicalised), a bounded industry and de-duplicated known index codes.
+func normaliseClassification(c *data.Classification) error {
+	symbol, err := util.ValidateSymbol(c.Symbol)
+	if err != nil {
+		return err
+	}
+	c.Symbol = symbol
+
+	sector, ok := canonicalSector(c.Sector)
+	if !ok {
+		return &util.ValidationError{Field: "sector", Message: "must be a GICS sector"}
+	}
+	c.Sector = sector
+
+	c.Industry = strings.TrimSpace(c.Industry)
+	if len(c.Industry) > maxIndustryName {
+		return &util.ValidationError{Field: "industry", Message: fmt.Sprintf("must be at most %d characters", maxIndustryName)}
+	}
+
+	indices := make([]string, 0, len(c.Indices))
+	seen := make(map[string]bool, len(c.Indices))
+	for _, raw := range c.Indices {
+		code := strings.ToUpper(strings.TrimSpace(raw))
+		if !knownIndices[code] {
+			return &util.ValidationError{Field: "indices", Message: "must contain only DJI, SPX or NDX"}
+		}
+		if !seen[code] {
+			seen[code] = true
+			indices = append(indices, code)
+		}
+	}
+	c.Indices = indices
+	return nil
+}
+
+func canonicalSector(sector string) (string, bool) {
+	sector = strings.TrimSpace(sector)
+	for _, s := range GICSSectors {
+		if strings.EqualFold(s, sector) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// parseClassificationDataset reads the bundled CSV, validating every row
+// the same way as an admin override.
+func parseClassificationDataset(raw []byte) ([]data.Classification, error) {
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty dataset")
+	}
+
+	items := make([]data.Classification, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		if len(rec) != 4 {
+			return nil, fmt.Errorf("line %d: want 4 fields, got %d", i+2, len(rec))
+		}
+		c := data.Classification{Symbol: rec[0], Sector: rec[1], Industry: rec[2]}
+		if rec[3] != "" {
+			c.Indices = strings.Split(rec[3], "|")
+		}
+		if err := normaliseClassification(&c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		items = append(items, c)
+	}
+	return items, nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestParseClassificationDataset_Bundled(t *testing.T) {
+	items, err := parseClassificationDataset(classificationDataset)
+	if err != nil {
+		t.Fatalf("bundled dataset: %v", err)
+	}
+
+	dow := 0
+	seen := make(map[string]bool, len(items))
+	for _, c := range items {
+		if seen[c.Symbol] {
+			t.Errorf("%s listed twice", c.Symbol)
+		}
+		seen[c.Symbol] = true
+		for _, code := range c.Indices {
+			if code == IndexDowJones {
+				dow++
+			}
+		}
+	}
+	if dow != 30 {
+		t.Errorf("Dow members: got %d, want 30", dow)
+	}
+}
+
+func TestParseClassificationDataset_RejectsBadRows(t *testing.T) {
+	cases := map[string]string{
+		"unknown sector": "symbol,sector,industry,indices\nAAPL,Tech,Hardware,SPX\n",
+		"unknown index":  "symbol,sector,industry,indices\nAAPL,Information Technology,Hardware,FTSE\n",
+		"missing field":  "symbol,sector,industry,indices\nAAPL,Information Technology,Hardware\n",
+	}
+	for name, raw := range cases {
+		if _, err := parseClassificationDataset([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestClassificationOverride_Normalises(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewClassificationService(data.NewClassificationStore(db))
+	ctx := context.Background()
+
+	var ve *util.ValidationError
+	if _, err := svc.Override(ctx, data.Classification{Symbol: "AAPL", Sector: "Tech"}); !errors.As(err, &ve) || ve.Field != "sector" {
+		t.Errorf("expected validation error on sector, got %v", err)
+	}
+	if _, err := svc.Override(ctx, data.Classification{Symbol: "AAPL", Sector: "Energy", Indices: []string{"DAX"}}); !errors.As(err, &ve) || ve.Field != "indices" {
+		t.Errorf("expected validation error on indices, got %v", err)
+	}
+
+	mock.ExpectQuery("INSERT INTO instrument_classifications").
+		WithArgs("AAPL", "Information Technology", "Semiconductors", `{"SPX","NDX"}`, data.ClassificationSourceAdmin).
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "sector", "industry", "indices", "source", "updated_at"}).
+			AddRow("AAPL", "Information Technology", "Semiconductors", `{SPX,NDX}`, data.ClassificationSourceAdmin, time.Now()))
+	c, err := svc.Override(ctx, data.Classification{
+		Symbol:   "aapl",
+		Sector:   " information technology ",
+		Industry: "Semiconductors",
+		Indices:  []string{"spx", "NDX", "SPX"},
+	})
+	if err != nil || c.Source != data.ClassificationSourceAdmin || len(c.Indices) != 2 {
+		t.Errorf("got (%+v, %v)", c, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestClassificationLoadDataset_KeepsOverrides(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	items, err := parseClassificationDataset(classificationDataset)
+	if err != nil {
+		t.Fatalf("bundled dataset: %v", err)
+	}
+
+	mock.ExpectBegin()
+	for i := range items {
+		// Pretend the first symbol has an admin override, so its upsert
+		// matches no row.
+		affected := int64(1)
+		if i == 0 {
+			affected = 0
+		}
+		mock.ExpectExec("INSERT INTO instrument_classifications").
+			WillReturnResult(sqlmock.NewResult(0, affected))
+	}
+	mock.ExpectExec("DELETE FROM instrument_classifications WHERE source = 'dataset'").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	svc := NewClassificationService(data.NewClassificationStore(db))
+	n, err := svc.LoadDataset(context.Background())
+	if err != nil {
+		t.Fatalf("LoadDataset: %v", err)
+	}
+	if n != len(items)-1 {
+		t.Errorf("written: got %d, want %d", n, len(items)-1)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestClassificationIndexMembers_UnknownIndex(t *testing.T) {
+	svc := NewClassificationService(nil)
+	var ve *util.ValidationError
+	if _, err := svc.IndexMembers(context.Background(), "FTSE"); !errors.As(err, &ve) || ve.Field != "index" {
+		t.Errorf("expected validation error on index, got %v", err)
+	}
+}
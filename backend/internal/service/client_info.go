@@ -0,0 +1,24 @@
+package service
+
+import "context"
+
+// ClientInfo describes where a request came from. Populated by the
+// middleware.ClientInfo middleware; fields are "" when unknown.
+type ClientInfo struct {
+	IP      string
+	Country string // ISO 3166-1 alpha-2, upper-case
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo returns a derived context carrying info.
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the ClientInfo attached to ctx, or the zero
+// value when none was set.
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
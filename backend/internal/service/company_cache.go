@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// CompanyCache caches company profiles per symbol.
+type CompanyCache interface {
+	GetCompany(ctx context.Context, symbol string) (*CompanyProfile, error)
+	SetCompany(ctx context.Context, profile *CompanyProfile, ttl time.Duration) error
+}
+
+// RedisCompanyCache implements CompanyCache using Redis.
+type RedisCompanyCache struct {
+	client *redis.Client
+}
+
+func NewRedisCompanyCache(client *redis.Client) *RedisCompanyCache {
+	return &RedisCompanyCache{client: client}
+}
+
+func companyKey(symbol string) string {
+	return fmt.Sprintf("company:%s", symbol)
+}
+
+// GetCompany returns the cached profile, or nil on a miss. Redis errors are
+// logged and treated as a miss.
+func (c *RedisCompanyCache) GetCompany(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	val, err := c.client.Get(ctx, companyKey(symbol)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Error("Redis error getting company profile", "symbol", symbol, "err", err, "component", "company_cache")
+		}
+		return nil, nil
+	}
+	var profile CompanyProfile
+	if err := json.Unmarshal([]byte(val), &profile); err != nil {
+		slog.Error("failed to unmarshal company cache entry", "symbol", symbol, "err", err, "component", "company_cache")
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+// SetCompany stores profile under its symbol for ttl.
+func (c *RedisCompanyCache) SetCompany(ctx context.Context, profile *CompanyProfile, ttl time.Duration) error {
+	raw, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("error marshaling company profile: %w", err)
+	}
+	if err := c.client.Set(ctx, companyKey(profile.Symbol), raw, ttl).Err(); err != nil {
+		slog.Error("failed to set company cache entry", "symbol", profile.Symbol, "err", err, "component", "company_cache")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// LotsReport is a user's cost-basis method and open tax lots.
+type LotsReport struct {
+	Method string        `json:"method"`
+	Lots   []data.TaxLot `json:"lots"`
+}
+
+// CostBasisService manages the per-user cost-basis method and lists tax
+// lots. The lots themselves are opened and closed inside the buy and sell
+// transactions (openLot, closeLots).
+type CostBasisService struct {
+	users *data.UserStore
+	lots  *data.LotStore
+}
+
+func NewCostBasisService(users *data.UserStore, lots *data.LotStore) *CostBasisService {
+	return &CostBasisService{users: users, lots: lots}
+}
+
+// SetMethod saves userID's cost-basis method and returns it normalised.
+// It applies to sells from now on; past gains are not restated.
+func (s *CostBasisService) SetMethod(ctx context.Context, userID, method string) (string, error) {
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if method != data.CostBasisFIFO && method != data.CostBasisLIFO && method != data.CostBasisAverage {
+		return "", &util.ValidationError{Field: "method", Message: "must be FIFO, LIFO or AVERAGE"}
+	}
+	if err := s.users.SetCostBasisMethod(ctx, userID, method); err != nil {
+		return "", err
+	}
+	return method, nil
+}
+
+// Lots returns userID's cost-basis method and open lots.
+func (s *CostBasisService) Lots(ctx context.Context, userID string) (*LotsReport, error) {
+	method, err := s.users.GetCostBasisMethod(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	lots, err := s.lots.ListOpenLots(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &LotsReport{Method: method, Lots: lots}, nil
+}
+
+// openLot records the shares bought by trade as a new lot. db is the buy's
+// transaction.
+func openLot(ctx context.Context, db data.DBTX, trade *data.Trade) error {
+	return data.NewLotStore(db).CreateLot(ctx, &data.TaxLot{
+		UserID:   trade.UserID,
+		Symbol:   trade.Symbol,
+		TradeID:  trade.ID,
+		Quantity: trade.Quantity,
+		Price:    trade.Price,
+	})
+}
+
+// closeLots draws the shares sold by trade from the user's open lots and
+// records the gain realized on each. FIFO takes the oldest lots first and
+// LIFO the newest, each realizing against the lot's price; AVERAGE takes
+// lots oldest first but realizes against holding's average cost, matching
+// the portfolio. Shares the lots don't cover (a holding older than lot
+// tracking that was since added to) are realized at the average cost too.
+// db is the sell's transaction; holding is the position before the sell.
+func closeLots(ctx context.Context, db data.DBTX, trade *data.Trade, holding *data.UserStock) ([]data.LotDisposal, error) {
+	method, err := data.NewUserStore(db).GetCostBasisMethod(ctx, trade.UserID)
+	if err != nil {
+		return nil, err
+	}
+	lots := data.NewLotStore(db)
+	open, err := lots.OpenLotsForUpdate(ctx, trade.UserID, trade.Symbol, method == data.CostBasisLIFO)
+	if err != nil {
+		return nil, err
+	}
+
+	disposals := make([]data.LotDisposal, 0, 1)
+	dispose := func(lotID string, qty, cost decimal.Decimal) error {
+		d := data.LotDisposal{
+			UserID:      trade.UserID,
+			SellTradeID: trade.ID,
+			LotID:       lotID,
+			Symbol:      trade.Symbol,
+			Quantity:    qty,
+			CostPrice:   cost,
+			SalePrice:   trade.Price,
+			Realized:    trade.Price.Sub(cost).Mul(qty).Round(2),
+			Method:      method,
+		}
+		if err := lots.CreateDisposal(ctx, &d); err != nil {
+			return err
+		}
+		disposals = append(disposals, d)
+		return nil
+	}
+
+	left := trade.Quantity
+	for _, lot := range open {
+		if left.IsZero() {
+			break
+		}
+		take := decimal.Min(left, lot.Remaining)
+		if err := lots.ReduceLot(ctx, lot.ID, take); err != nil {
+			return nil, err
+		}
+		cost := lot.Price
+		if method == data.CostBasisAverage {
+			cost = holding.AvgPrice
+		}
+		if err := dispose(lot.ID, take, cost); err != nil {
+			return nil, err
+		}
+		left = left.Sub(take)
+	}
+	if left.IsPositive() {
+		if err := dispose("", left, holding.AvgPrice); err != nil {
+			return nil, err
+		}
+	}
+	return disposals, nil
+}
@@ -2,26 +2,58 @@ package service
 
 import (
 	"fmt"
+	"html"
 	"net/url"
+	"strings"
+	"time"
 
-	"github.com/resend/resend-go/v2"
+	"github.com/shopspring/decimal"
 )
 
 type EmailService struct {
-	client      *resend.Client
+	sender      EmailSender
 	fromEmail   string
 	frontendURL string
+	appScheme   string
 }
 
-func NewEmailService(apiKey, fromEmail, frontendURL string) *EmailService {
-	client := resend.NewClient(apiKey)
+// NewEmailService builds the service, which hands every email it renders to
+// sender. appScheme is the mobile app's URL scheme; when set, verification
+// emails also carry a link that completes verification and opens the app.
+func NewEmailService(sender EmailSender, fromEmail, frontendURL, appScheme string) *EmailService {
 	return &EmailService{
-		client:      client,
+		sender:      sender,
 		fromEmail:   fromEmail,
 		frontendURL: frontendURL,
+		appScheme:   appScheme,
 	}
 }
 
+func (es *EmailService) send(msg *EmailMessage) error {
+	return es.sender.Send(msg)
+}
+
+// appLink builds a deep link into the mobile app, e.g.
+// papertrader://verify-email?status=verified.
+func appLink(scheme, path string, query url.Values) string {
+	link := scheme + "://" + strings.TrimLeft(path, "/")
+	if len(query) > 0 {
+		link += "?" + query.Encode()
+	}
+	return link
+}
+
+// appVerificationURL points at the API's verification landing, which
+// verifies the token server-side and then redirects into the app. Custom
+// schemes are not clickable in many mail clients, so the email itself only
+// ever contains an https link.
+func (es *EmailService) appVerificationURL(token string) string {
+	q := url.Values{}
+	q.Set("token", token)
+	q.Set("redirect", appLink(es.appScheme, "verify-email", nil))
+	return es.frontendURL + "/api/account/verify-email?" + q.Encode()
+}
+
 func (es *EmailService) SendVerificationEmail(to, token string) error {
 	// URL-encode the token defensively. Today the token is a UUID v4 (hex
 	// digits and hyphens only), but if the token format ever changes to
@@ -29,6 +61,14 @@ func (es *EmailService) SendVerificationEmail(to, token string) error {
 	// break without this.
 	verificationURL := fmt.Sprintf("%s/verify-email?token=%s", es.frontendURL, url.QueryEscape(token))
 
+	appButton := ""
+	if es.appScheme != "" {
+		appButton = fmt.Sprintf(`
+		<div style="text-align: center; margin: -10px 0 30px;">
+			<a href="%s" style="color: #3498db;">On your phone? Verify and open the PaperTrader app</a>
+		</div>`, html.EscapeString(es.appVerificationURL(token)))
+	}
+
 	htmlContent := fmt.Sprintf(`
 	<!DOCTYPE html>
 	<html>
@@ -42,21 +82,372 @@ func (es *EmailService) SendVerificationEmail(to, token string) error {
 		<p>Please click the button below to verify your email address:</p>
 		<div style="text-align: center; margin: 30px 0;">
 			<a href="%s" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Verify Email</a>
-		</div>
+		</div>%s
 		<p>Or copy and paste this link into your browser:</p>
 		<p style="word-break: break-all; color: #7f8c8d;">%s</p>
 		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">This link will expire in 24 hours.</p>
 	</body>
 	</html>
-	`, verificationURL, verificationURL)
+	`, verificationURL, appButton, verificationURL)
 
-	params := &resend.SendEmailRequest{
+	msg := &EmailMessage{
 		From:    es.fromEmail,
 		To:      []string{to},
 		Subject: "Verify Your Email Address - PaperTrader",
-		Html:    htmlContent,
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendMagicLinkEmail sends a one-click login link. The link hits the API
+// directly, which sets the session cookie and redirects into the frontend.
+func (es *EmailService) SendMagicLinkEmail(to, token string, ttl time.Duration) error {
+	loginURL := fmt.Sprintf("%s/api/account/magic-link/callback?token=%s", es.frontendURL, url.QueryEscape(token))
+
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Your PaperTrader login link</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Log in to PaperTrader</h2>
+		<p>Click the button below to log in. The link works once.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Log In</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #7f8c8d;">%s</p>
+		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">This link will expire in %d minutes. If you didn't ask to log in, you can ignore this email.</p>
+	</body>
+	</html>
+	`, html.EscapeString(loginURL), html.EscapeString(loginURL), int(ttl.Minutes()))
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: "Your login link - PaperTrader",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendWelcomeEmail greets a user who has just signed up, with the virtual
+// balance they start with. username may be empty.
+func (es *EmailService) SendWelcomeEmail(to, username string, balance decimal.Decimal) error {
+	greeting := "Hi there,"
+	if username != "" {
+		greeting = fmt.Sprintf("Hi %s,", username)
+	}
+
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Welcome to PaperTrader</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Welcome to PaperTrader</h2>
+		<p>%s</p>
+		<p>Your account is open with <strong>$%s</strong> in virtual cash. Buy and sell real stocks at live prices, and see how your portfolio does without risking a cent.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s/dashboard" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Start Trading</a>
+		</div>
+		<p>Want an email each time a trade goes through? Turn on trade confirmations in your account settings.</p>
+		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">You are receiving this because you just created a PaperTrader account.</p>
+	</body>
+	</html>
+	`, html.EscapeString(greeting), balance.StringFixed(2), es.frontendURL)
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: "Welcome to PaperTrader",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendInviteEmail welcomes a user created by an admin bulk import. The
+// button is a magic login link, like SendMagicLinkEmail, but valid for the
+// longer invite TTL. league is shown when the user was placed in one.
+func (es *EmailService) SendInviteEmail(to, token string, ttl time.Duration, league string) error {
+	loginURL := fmt.Sprintf("%s/api/account/magic-link/callback?token=%s", es.frontendURL, url.QueryEscape(token))
+
+	leagueLine := ""
+	if league != "" {
+		leagueLine = fmt.Sprintf("\n\t\t<p>You have been added to the <strong>%s</strong> league.</p>", html.EscapeString(league))
+	}
+
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>You're invited to PaperTrader</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Welcome to PaperTrader</h2>
+		<p>An account has been created for you with a virtual balance to trade with.</p>%s
+		<p>Click the button below to log in. The link works once; after that, request a new login link from the sign-in page.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Get Started</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #7f8c8d;">%s</p>
+		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">This link will expire in %d days.</p>
+	</body>
+	</html>
+	`, leagueLine, html.EscapeString(loginURL), html.EscapeString(loginURL), int(ttl.Hours()/24))
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: "You're invited to PaperTrader",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendSecurityAlertEmail tells the user about suspicious activity on their
+// account. title and body are plain text and are HTML-escaped here.
+func (es *EmailService) SendSecurityAlertEmail(to, title, body string) error {
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>%s</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #c0392b;">%s</h2>
+		<p>%s</p>
+		<p>If this was you, no action is needed. If not, sign in and change your password right away.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s/login" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Sign In</a>
+		</div>
+	</body>
+	</html>
+	`, html.EscapeString(title), html.EscapeString(title), html.EscapeString(body), es.frontendURL)
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: title + " - PaperTrader",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendAccountLockedEmail tells the owner that password logins are refused
+// until until, with a magic login link that lifts the lock.
+func (es *EmailService) SendAccountLockedEmail(to, unlockToken string, until time.Time, linkTTL time.Duration) error {
+	unlockURL := fmt.Sprintf("%s/api/account/magic-link/callback?token=%s", es.frontendURL, url.QueryEscape(unlockToken))
+
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Your PaperTrader account is locked</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #c0392b;">Your account is locked</h2>
+		<p>There were too many failed attempts to sign in to your PaperTrader account with a password, so password sign-in is paused until %s.</p>
+		<p>If that was you, the button below signs you in and lifts the lock. If it wasn't, someone may know your email address: once signed in, consider changing your password.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Unlock and Sign In</a>
+		</div>
+		<p>Or copy and paste this link into your browser:</p>
+		<p style="word-break: break-all; color: #7f8c8d;">%s</p>
+		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">This link works once and will expire in %d minutes.</p>
+	</body>
+	</html>
+	`, until.UTC().Format("January 2, 2006 15:04 UTC"), html.EscapeString(unlockURL), html.EscapeString(unlockURL), int(linkTTL.Minutes()))
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: "Your account is locked - PaperTrader",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendInactivityNoticeEmail warns that an account unused since lastLogin
+// will be anonymized at deadline unless the user signs in before then.
+func (es *EmailService) SendInactivityNoticeEmail(to string, lastLogin, deadline time.Time) error {
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Your PaperTrader account is inactive</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">We miss you at PaperTrader</h2>
+		<p>You haven't signed in since %s.</p>
+		<p>If you don't sign in by <strong>%s</strong>, we will remove your personal details: your email address, username, sign-in methods and notes. Your past trades are kept anonymously in our statistics, and the account can no longer be used.</p>
+		<p>To keep your account, just sign in.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s/login" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">Sign In</a>
+		</div>
+	</body>
+	</html>
+	`, lastLogin.UTC().Format("January 2, 2006"), deadline.UTC().Format("January 2, 2006"), es.frontendURL)
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: "Your PaperTrader account is inactive",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendAccountDeletedEmail confirms that an account was deleted at its
+// owner's request.
+func (es *EmailService) SendAccountDeletedEmail(to string) error {
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Your PaperTrader account has been deleted</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">Your account has been deleted</h2>
+		<p>As you asked, we have deleted your PaperTrader account together with its trades, holdings, watchlists, notes and settings.</p>
+		<p>If you didn't ask for this, reply to this email. You are welcome to <a href="%s/register">sign up again</a> at any time.</p>
+	</body>
+	</html>
+	`, es.frontendURL)
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: "Your PaperTrader account has been deleted",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendTradeConfirmationEmail confirms an executed trade with its fill and
+// the cash left afterwards; sells also report the gain they realized and
+// what is left of the position, buys the position they added to.
+func (es *EmailService) SendTradeConfirmationEmail(to string, c TradeConfirmation) error {
+	var title, totalLabel, position string
+	switch c.Action {
+	case "BUY":
+		title, totalLabel = fmt.Sprintf("Bought %s %s", c.Quantity, c.Symbol), "Cost"
+		position = fmt.Sprintf("You now have %s shares of %s, at an average cost of $%s.",
+			c.RemainingQuantity, c.Symbol, c.AvgPrice.StringFixed(2))
+	case "SELL":
+		title, totalLabel = fmt.Sprintf("Sold %s %s", c.Quantity, c.Symbol), "Proceeds"
+		position = fmt.Sprintf("You have %s shares of %s left, at an average cost of $%s.",
+			c.RemainingQuantity, c.Symbol, c.AvgPrice.StringFixed(2))
+		if c.RemainingQuantity.IsZero() {
+			position = fmt.Sprintf("Your %s position is now closed.", c.Symbol)
+		}
+	case "SHORT":
+		title, totalLabel = fmt.Sprintf("Sold short %s %s", c.Quantity, c.Symbol), "Value"
+	default:
+		title, totalLabel = fmt.Sprintf("Covered %s %s", c.Quantity, c.Symbol), "Cost"
+	}
+
+	rows := fmt.Sprintf(`
+			<tr><td style="padding: 6px 0; color: #7f8c8d;">Price</td><td style="text-align: right;">$%s</td></tr>
+			<tr><td style="padding: 6px 0; color: #7f8c8d;">%s</td><td style="text-align: right;">$%s</td></tr>`,
+		c.Price.StringFixed(2), totalLabel, c.Total.StringFixed(2))
+	if c.Realized != nil {
+		gainColor, gainLabel := "#27ae60", "Realized gain"
+		if c.Realized.IsNegative() {
+			gainColor, gainLabel = "#c0392b", "Realized loss"
+		}
+		rows += fmt.Sprintf(`
+			<tr><td style="padding: 6px 0; color: #7f8c8d;">%s</td><td style="text-align: right; color: %s;"><strong>$%s</strong></td></tr>`,
+			gainLabel, gainColor, c.Realized.Abs().StringFixed(2))
+	}
+	rows += fmt.Sprintf(`
+			<tr><td style="padding: 6px 0; color: #7f8c8d;">Cash balance</td><td style="text-align: right;">$%s</td></tr>`,
+		c.CashBalance.StringFixed(2))
+	if position != "" {
+		position = "<p>" + html.EscapeString(position) + "</p>"
+	}
+
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>%s</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">%s</h2>
+		<table style="width: 100%%; border-collapse: collapse; margin: 20px 0;">%s
+		</table>
+		%s
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s/history" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">View Trades</a>
+		</div>
+		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">You are receiving this because trade confirmation emails are on. You can turn them off in your account settings.</p>
+	</body>
+	</html>
+	`, html.EscapeString(title), html.EscapeString(title), rows, position, es.frontendURL)
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: title + " - PaperTrader",
+		HTML:    htmlContent,
+	}
+
+	return es.send(msg)
+}
+
+// SendStatementEmail sends the user's monthly account statement for month
+// (YYYY-MM) as an attached PDF.
+func (es *EmailService) SendStatementEmail(to, month string, pdf []byte) error {
+	title := "Your " + month + " statement"
+	htmlContent := fmt.Sprintf(`
+	<!DOCTYPE html>
+	<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>%s</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+		<h2 style="color: #2c3e50;">%s</h2>
+		<p>Your PaperTrader account statement for %s is attached as a PDF.</p>
+		<div style="text-align: center; margin: 30px 0;">
+			<a href="%s/dashboard" style="background-color: #3498db; color: white; padding: 12px 30px; text-decoration: none; border-radius: 5px; display: inline-block;">View Dashboard</a>
+		</div>
+		<p style="margin-top: 30px; font-size: 12px; color: #95a5a6;">You are receiving this because monthly statement emails are on. You can turn them off in your account settings.</p>
+	</body>
+	</html>
+	`, html.EscapeString(title), html.EscapeString(title), html.EscapeString(month), es.frontendURL)
+
+	msg := &EmailMessage{
+		From:    es.fromEmail,
+		To:      []string{to},
+		Subject: title + " - PaperTrader",
+		HTML:    htmlContent,
+		Attachments: []EmailAttachment{{
+			Content:     pdf,
+			Filename:    "papertrader-statement-" + month + ".pdf",
+			ContentType: "application/pdf",
+		}},
 	}
 
-	_, err := es.client.Emails.Send(params)
-	return err
+	return es.send(msg)
 }
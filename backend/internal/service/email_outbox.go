@@ -0,0 +1,117 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// OutboxEmailSender writes each email as a .eml file under a directory
+// instead of sending it, for servers with no mail provider.
+type OutboxEmailSender struct {
+	dir string
+	now func() time.Time
+}
+
+// NewOutboxEmailSender writes emails under dir, creating it on first use.
+func NewOutboxEmailSender(dir string) *OutboxEmailSender {
+	return &OutboxEmailSender{dir: dir, now: time.Now}
+}
+
+func (s *OutboxEmailSender) Send(msg *EmailMessage) error {
+	return writeOutbox(s.dir, msg, s.now())
+}
+
+// writeOutbox writes msg as a MIME message to a new .eml file in dir,
+// which any mail client opens. Files are named by send time, so a listing
+// sorts oldest first.
+func writeOutbox(dir string, msg *EmailMessage, now time.Time) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("create email outbox: %w", err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	raw, err := mimeMessage(msg, now)
+	if err != nil {
+		return err
+	}
+	name := now.UTC().Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(suffix) + ".eml"
+	if err := os.WriteFile(filepath.Join(dir, name), raw, 0o640); err != nil {
+		return fmt.Errorf("write email outbox: %w", err)
+	}
+	return nil
+}
+
+// mimeMessage renders msg as multipart/mixed: the HTML body (or the text
+// one) followed by any attachments. The outbox stores it as is; SMTP and SES
+// send it.
+func mimeMessage(msg *EmailMessage, now time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	contentType, content := "text/html; charset=UTF-8", msg.HTML
+	if content == "" {
+		contentType, content = "text/plain; charset=UTF-8", msg.Text
+	}
+	w, err := parts.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeBase64(w, []byte(content)); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64(w, a.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "From: %s\r\n", msg.From)
+	fmt.Fprintf(&out, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&out, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&out, "Date: %s\r\n", now.UTC().Format(time.RFC1123Z))
+	fmt.Fprintf(&out, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&out, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", parts.Boundary())
+	out.Write(body.Bytes())
+	return out.Bytes(), nil
+}
+
+// writeBase64 writes b base64-encoded in 76-character lines.
+func writeBase64(w io.Writer, b []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(b)
+	for len(encoded) > 76 {
+		if _, err := fmt.Fprintf(w, "%s\r\n", encoded[:76]); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := fmt.Fprintf(w, "%s\r\n", encoded)
+	return err
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmailOutbox(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	es := NewEmailService(NewOutboxEmailSender(dir), "papertrader@localhost", "http://localhost:3000", "")
+
+	if err := es.SendStatementEmail("ada@example.com", "2026-09", []byte("%PDF-1.4")); err != nil {
+		t.Fatalf("SendStatementEmail: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one .eml file, got %v (%v)", files, err)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	msg, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("outbox file is not a mail message: %v", err)
+	}
+	if msg.Header.Get("To") != "ada@example.com" {
+		t.Errorf("To: got %q", msg.Header.Get("To"))
+	}
+	subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if subject != "Your 2026-09 statement - PaperTrader" {
+		t.Errorf("Subject: got %q", subject)
+	}
+
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	var names []string
+	for {
+		p, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, p.FileName())
+	}
+	if len(names) != 2 || names[1] != "papertrader-statement-2026-09.pdf" {
+		t.Errorf("expected the body and the PDF, got parts %q", names)
+	}
+}
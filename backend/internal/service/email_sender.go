@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/resend/resend-go/v2"
+
+	"papertrader/internal/util"
+)
+
+// Email backends, selected by EmailSenderConfig.Backend.
+const (
+	EmailBackendResend = "resend"
+	EmailBackendSMTP   = "smtp"
+	EmailBackendSES    = "ses"
+	EmailBackendOutbox = "outbox"
+	EmailBackendLog    = "log"
+)
+
+const resendRequestTimeout = 15 * time.Second
+
+// EmailMessage is one rendered email. HTML is preferred over Text when both
+// are set.
+type EmailMessage struct {
+	From        string // an RFC 5322 address, e.g. "PaperTrader <noreply@example.com>"
+	To          []string
+	Subject     string
+	HTML        string
+	Text        string
+	Attachments []EmailAttachment
+}
+
+// EmailAttachment is a file attached to an EmailMessage.
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// EmailSender delivers the emails EmailService renders. Implementations
+// bound their own network calls, since EmailService's callers pass no
+// context.
+type EmailSender interface {
+	Send(msg *EmailMessage) error
+}
+
+// EmailSenderConfig selects and configures the EmailSender NewEmailSender
+// builds. Only the settings of the chosen Backend are read.
+type EmailSenderConfig struct {
+	Backend      string // one of the EmailBackend constants
+	ResendAPIKey string
+	SMTP         SMTPConfig
+	SES          SESConfig
+	OutboxDir    string
+}
+
+// NewEmailSender builds the backend cfg selects. The HTTP backends, Resend
+// and SES, call out through client, the shared outbound client, which may be
+// nil.
+func NewEmailSender(cfg EmailSenderConfig, client *http.Client) (EmailSender, error) {
+	switch cfg.Backend {
+	case EmailBackendResend:
+		return NewResendEmailSender(cfg.ResendAPIKey, client), nil
+	case EmailBackendSMTP:
+		return NewSMTPEmailSender(cfg.SMTP), nil
+	case EmailBackendSES:
+		return NewSESEmailSender(cfg.SES, client), nil
+	case EmailBackendOutbox:
+		return NewOutboxEmailSender(cfg.OutboxDir), nil
+	case EmailBackendLog:
+		return LogEmailSender{}, nil
+	}
+	return nil, fmt.Errorf("unknown email backend %q", cfg.Backend)
+}
+
+// ResendEmailSender sends through the Resend API.
+type ResendEmailSender struct {
+	client *resend.Client
+}
+
+// NewResendEmailSender sends with apiKey through httpClient, which may be
+// nil.
+func NewResendEmailSender(apiKey string, httpClient *http.Client) *ResendEmailSender {
+	client := resend.NewCustomClient(util.ClientWithTimeout(httpClient, resendRequestTimeout), apiKey)
+	return &ResendEmailSender{client: client}
+}
+
+func (s *ResendEmailSender) Send(msg *EmailMessage) error {
+	params := &resend.SendEmailRequest{
+		From:    msg.From,
+		To:      msg.To,
+		Subject: msg.Subject,
+		Html:    msg.HTML,
+		Text:    msg.Text,
+	}
+	for _, a := range msg.Attachments {
+		params.Attachments = append(params.Attachments, &resend.Attachment{
+			Content:     a.Content,
+			Filename:    a.Filename,
+			ContentType: a.ContentType,
+		})
+	}
+	_, err := s.client.Emails.Send(params)
+	return err
+}
+
+// LogEmailSender logs each email instead of sending it: a dry run for
+// development. The log line carries the links in the body, so verification
+// and login links can be followed from it; since those links sign people
+// in, it is refused in production.
+type LogEmailSender struct{}
+
+var emailLinkPattern = regexp.MustCompile(`href="([^"]+)"`)
+
+func (LogEmailSender) Send(msg *EmailMessage) error {
+	var links, attachments []string
+	for _, m := range emailLinkPattern.FindAllStringSubmatch(msg.HTML, -1) {
+		links = append(links, html.UnescapeString(m[1]))
+	}
+	for _, a := range msg.Attachments {
+		attachments = append(attachments, a.Filename)
+	}
+	slog.Info("email not sent (dry run)", "from", msg.From, "to", msg.To, "subject", msg.Subject,
+		"links", links, "attachments", attachments, "component", "email")
+	return nil
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+const sesRequestTimeout = 15 * time.Second
+
+// SESConfig configures SESEmailSender.
+type SESConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Endpoint        string // defaults to https://email.<region>.amazonaws.com
+}
+
+// SESEmailSender sends through the Amazon SES v2 API, as raw MIME so
+// attachments go through unchanged. Requests are signed with SigV4 rather
+// than through the AWS SDK, like S3ObjectStorage. The From address must be
+// verified in SES.
+type SESEmailSender struct {
+	cfg    SESConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewSESEmailSender(cfg SESConfig, client *http.Client) *SESEmailSender {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://email." + cfg.Region + ".amazonaws.com"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &SESEmailSender{
+		cfg:    cfg,
+		client: util.ClientWithTimeout(client, sesRequestTimeout),
+		now:    time.Now,
+	}
+}
+
+// sesRawEmail is the body of SES v2 SendEmail with raw content. Data is
+// base64-encoded by encoding/json.
+type sesRawEmail struct {
+	Destination struct {
+		ToAddresses []string
+	}
+	Content struct {
+		Raw struct {
+			Data []byte
+		}
+	}
+}
+
+func (s *SESEmailSender) Send(msg *EmailMessage) error {
+	now := s.now()
+	raw, err := mimeMessage(msg, now)
+	if err != nil {
+		return err
+	}
+	var req sesRawEmail
+	req.Destination.ToAddresses = msg.To
+	req.Content.Raw.Data = raw
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, s.cfg.Endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	sigV4Signer{
+		region:          s.cfg.Region,
+		service:         "ses",
+		accessKeyID:     s.cfg.AccessKeyID,
+		secretAccessKey: s.cfg.SecretAccessKey,
+	}.sign(httpReq, body, now)
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ses: status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
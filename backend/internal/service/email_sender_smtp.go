@@ -0,0 +1,122 @@
+package service
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds one whole SMTP session: connecting, the handshake and
+// the message.
+const smtpTimeout = 30 * time.Second
+
+// SMTPConfig configures SMTPEmailSender.
+type SMTPConfig struct {
+	Host     string
+	Port     int // 465 means TLS from the start; any other port upgrades with STARTTLS when offered
+	Username string
+	Password string // with Username, sent with AUTH PLAIN, which is refused on an unencrypted connection except to localhost
+}
+
+// SMTPEmailSender sends through any SMTP relay: a provider's (SendGrid,
+// Postmark, Mailgun, SES's SMTP interface), the organisation's own, or a
+// local catcher such as Mailpit. Each email is one connection.
+type SMTPEmailSender struct {
+	cfg SMTPConfig
+	now func() time.Time
+}
+
+func NewSMTPEmailSender(cfg SMTPConfig) *SMTPEmailSender {
+	return &SMTPEmailSender{cfg: cfg, now: time.Now}
+}
+
+func (s *SMTPEmailSender) Send(msg *EmailMessage) error {
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("smtp: sender %q: %w", msg.From, err)
+	}
+	to := make([]string, 0, len(msg.To))
+	for _, addr := range msg.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return fmt.Errorf("smtp: recipient %q: %w", addr, err)
+		}
+		to = append(to, a.Address)
+	}
+	raw, err := mimeMessage(msg, s.now())
+	if err != nil {
+		return err
+	}
+
+	c, err := s.dial()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp: MAIL FROM: %w", err)
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return fmt.Errorf("smtp: RCPT TO %s: %w", addr, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: DATA: %w", err)
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("smtp: DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: DATA: %w", err)
+	}
+	return c.Quit()
+}
+
+// dial connects and, unless the connection is TLS already, upgrades it with
+// STARTTLS when the server offers it.
+func (s *SMTPEmailSender) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Timeout: smtpTimeout}
+	tlsConfig := &tls.Config{ServerName: s.cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if s.cfg.Port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if s.cfg.Port != 465 {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEmail = &EmailMessage{
+	From:    "PaperTrader <noreply@example.com>",
+	To:      []string{"ada@example.com"},
+	Subject: "Welcome to PaperTrader",
+	HTML:    `<p>Hi</p><a href="http://localhost:3000/verify?token=a&amp;b">Verify</a>`,
+}
+
+func TestSESEmailSender_SignedSend(t *testing.T) {
+	var gotPath, gotAuth string
+	var got sesRawEmail
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.Write([]byte(`{"MessageId":"m-1"}`))
+	}))
+	defer srv.Close()
+
+	s := NewSESEmailSender(SESConfig{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: srv.URL + "/"}, nil)
+	s.now = func() time.Time { return time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC) }
+
+	if err := s.Send(testEmail); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotPath != "/v2/email/outbound-emails" {
+		t.Errorf("path: got %q", gotPath)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/20240313/eu-west-1/ses/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=") {
+		t.Errorf("authorization header: %q", gotAuth)
+	}
+	if len(got.Destination.ToAddresses) != 1 || got.Destination.ToAddresses[0] != "ada@example.com" {
+		t.Errorf("destination: %+v", got.Destination)
+	}
+	if raw := string(got.Content.Raw.Data); !strings.Contains(raw, "Subject: Welcome to PaperTrader\r\n") {
+		t.Errorf("raw message missing subject:\n%s", raw)
+	}
+}
+
+func TestSESEmailSender_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Email address is not verified."}`, http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	s := NewSESEmailSender(SESConfig{Region: "us-east-1", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: srv.URL}, nil)
+	if err := s.Send(testEmail); err == nil || !strings.Contains(err.Error(), "not verified") {
+		t.Errorf("expected the SES error, got %v", err)
+	}
+}
+
+// fakeSMTPServer accepts one session on a local port, answering every
+// command with success, and sends the commands and message it saw on done.
+func fakeSMTPServer(t *testing.T) (port int, done <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	ch := make(chan []string, 1)
+	go func() {
+		var seen []string
+		defer func() { ch <- seen }()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("220 localhost ESMTP\r\n"))
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			seen = append(seen, line)
+			switch {
+			case inData && line == ".":
+				inData = false
+				conn.Write([]byte("250 queued\r\n"))
+			case inData:
+			case strings.HasPrefix(line, "EHLO"):
+				conn.Write([]byte("250-localhost\r\n250 8BITMIME\r\n"))
+			case line == "DATA":
+				inData = true
+				conn.Write([]byte("354 go ahead\r\n"))
+			case line == "QUIT":
+				conn.Write([]byte("221 bye\r\n"))
+				return
+			default:
+				conn.Write([]byte("250 ok\r\n"))
+			}
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, ch
+}
+
+func TestSMTPEmailSender_Send(t *testing.T) {
+	port, done := fakeSMTPServer(t)
+	s := NewSMTPEmailSender(SMTPConfig{Host: "127.0.0.1", Port: port})
+
+	if err := s.Send(testEmail); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	seen := strings.Join(<-done, "\n")
+	for _, want := range []string{"MAIL FROM:<noreply@example.com>", "RCPT TO:<ada@example.com>", "Subject: Welcome to PaperTrader", "QUIT"} {
+		if !strings.Contains(seen, want) {
+			t.Errorf("session is missing %q:\n%s", want, seen)
+		}
+	}
+}
+
+func TestNewEmailSender_UnknownBackend(t *testing.T) {
+	if _, err := NewEmailSender(EmailSenderConfig{Backend: "pigeon"}, nil); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
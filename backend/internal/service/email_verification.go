@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// Email verification policies for trading (TRADING_EMAIL_VERIFICATION).
+const (
+	VerificationOff   = "off"
+	VerificationBlock = "block" // no trades until the email is verified
+	VerificationCap   = "cap"   // TradeCap trades, then none until it is
+)
+
+// VerificationPolicy configures VerificationService.
+type VerificationPolicy struct {
+	Mode     string
+	TradeCap int // completed trades an unverified account may make under VerificationCap
+}
+
+// VerificationService decides whether an account with an unverified email
+// may trade. As a PreTradeCheck it holds back every trade, including
+// order fills and recurring buys, so an order or plan set up while allowed
+// stops once the cap is reached or a changed email is no longer verified.
+// Guests have no email to verify and are let through; they are asked for
+// one when they upgrade.
+type VerificationService struct {
+	users  *data.UserStore
+	trades *data.TradesStore
+	policy VerificationPolicy
+}
+
+func NewVerificationService(users *data.UserStore, trades *data.TradesStore, policy VerificationPolicy) *VerificationService {
+	return &VerificationService{users: users, trades: trades, policy: policy}
+}
+
+// CheckTrade implements PreTradeCheck.
+func (s *VerificationService) CheckTrade(ctx context.Context, intent TradeIntent) error {
+	return s.CheckVerified(ctx, intent.UserID)
+}
+
+// CheckVerified returns a VerificationRequiredError when userID may not
+// place a trade until its email is verified. It reads nothing while the
+// policy is off.
+func (s *VerificationService) CheckVerified(ctx context.Context, userID string) error {
+	if s.policy.Mode != VerificationBlock && s.policy.Mode != VerificationCap {
+		return nil
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified || user.IsGuest {
+		return nil
+	}
+	if s.policy.Mode == VerificationBlock {
+		return &VerificationRequiredError{}
+	}
+	trades, err := s.trades.CountCompletedTradesSince(ctx, userID, time.Time{})
+	if err != nil {
+		return err
+	}
+	if trades >= s.policy.TradeCap {
+		return &VerificationRequiredError{TradeCap: s.policy.TradeCap}
+	}
+	return nil
+}
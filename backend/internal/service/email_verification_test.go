@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+func TestVerificationService_CheckVerified(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	unverified := data.User{ID: "user-1", Email: "ada@example.com"}
+	newService := func(mode string) *VerificationService {
+		return NewVerificationService(data.NewUserStore(db), data.NewTradesStore(db), VerificationPolicy{Mode: mode, TradeCap: 3})
+	}
+
+	// Off reads nothing.
+	if err := newService(VerificationOff).CheckVerified(ctx, "user-1"); err != nil {
+		t.Errorf("off: %v", err)
+	}
+
+	var required *VerificationRequiredError
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user-1").WillReturnRows(userRow(unverified))
+	if err := newService(VerificationBlock).CheckVerified(ctx, "user-1"); !errors.As(err, &required) || required.TradeCap != 0 {
+		t.Errorf("block: got %v, want VerificationRequiredError", err)
+	}
+
+	// Under the cap the trade goes through; at it, it doesn't.
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user-1").WillReturnRows(userRow(unverified))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	if err := newService(VerificationCap).CheckVerified(ctx, "user-1"); err != nil {
+		t.Errorf("cap, 2 trades: %v", err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user-1").WillReturnRows(userRow(unverified))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	if err := newService(VerificationCap).CheckVerified(ctx, "user-1"); !errors.As(err, &required) || required.TradeCap != 3 {
+		t.Errorf("cap, 3 trades: got %v, want VerificationRequiredError", err)
+	}
+
+	// As a pre-trade check it holds back fills and recurring buys the same way.
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user-1").WillReturnRows(userRow(unverified))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	if err := newService(VerificationCap).CheckTrade(ctx, TradeIntent{UserID: "user-1", Action: "BUY"}); !errors.As(err, &required) {
+		t.Errorf("CheckTrade, 3 trades: got %v, want VerificationRequiredError", err)
+	}
+
+	// Verified accounts and guests are never held back.
+	verified := unverified
+	verified.EmailVerified = true
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user-1").WillReturnRows(userRow(verified))
+	if err := newService(VerificationBlock).CheckVerified(ctx, "user-1"); err != nil {
+		t.Errorf("verified: %v", err)
+	}
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("guest-1").WillReturnRows(userRow(data.User{ID: "guest-1", IsGuest: true}))
+	if err := newService(VerificationBlock).CheckVerified(ctx, "guest-1"); err != nil {
+		t.Errorf("guest: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// AuditEODRerun is the audit event kind recorded for an admin's EOD rerun.
+const AuditEODRerun = "admin.eod_rerun"
+
+const (
+	// eodCloseInterval is how often RunClose checks for a session that has
+	// closed but not been processed.
+	eodCloseInterval = time.Hour
+	// eodClaimStale is how long an incomplete run stays claimed before
+	// another instance may take it over.
+	eodClaimStale = time.Hour
+)
+
+// EODClose is what the end-of-day job hands each step: the session that
+// closed and the final close of every active symbol that has one. Previous
+// is set only on a rerun, to the closes stored before it (possibly empty),
+// so a step can correct its earlier work instead of repeating it.
+type EODClose struct {
+	Session  MarketSession
+	Closes   map[string]decimal.Decimal
+	Previous map[string]decimal.Decimal
+}
+
+// IsRerun reports whether this is an admin's rerun of a past session.
+func (e *EODClose) IsRerun() bool { return e.Previous != nil }
+
+// EODRerun is the result of re-running a past session. Corrected lists the
+// symbols whose stored close changed.
+type EODRerun struct {
+	SessionDate string   `json:"session_date"`
+	Symbols     int      `json:"symbols"`
+	Closes      int      `json:"closes"`
+	Corrected   []string `json:"corrected"`
+	FailedSteps []string `json:"failed_steps"`
+}
+
+// EODMarket is the subset of MarketService used by EODCloseService.
+type EODMarket interface {
+	GetBatchHistoricalData(ctx context.Context, symbols []string) (map[string]*HistoricalData, error)
+	ClosesOn(ctx context.Context, symbols []string, date time.Time) (map[string]*HistoricalData, error)
+}
+
+// EODStep is one piece of downstream work run after the closes are in.
+type EODStep struct {
+	Name string
+	Run  func(ctx context.Context, eod *EODClose) error
+}
+
+// EODCloseService runs once per trading session after its close: it fetches
+// final closes for every held, watched or pending-order symbol, persists
+// them to price history, runs the registered steps in order, records the
+// run and tells its listeners. Scheduled features hang off it with AddStep
+// or OnComplete rather than polling on their own.
+type EODCloseService struct {
+	runs      *data.EODRunStore
+	history   *data.StockHistoryStore
+	portfolio *data.PortfolioStore
+	watchlist *data.WatchlistStore
+	orders    *data.OrderStore
+	audit     *data.AuditStore
+	market    EODMarket
+	calendar  *MarketCalendar
+	steps     []EODStep
+	listeners []func(ctx context.Context, eod *EODClose, run *data.EODRun)
+	now       func() time.Time
+}
+
+func NewEODCloseService(runs *data.EODRunStore, history *data.StockHistoryStore, portfolio *data.PortfolioStore, watchlist *data.WatchlistStore, orders *data.OrderStore, market EODMarket, calendar *MarketCalendar) *EODCloseService {
+	return &EODCloseService{runs: runs, history: history, portfolio: portfolio, watchlist: watchlist,
+		orders: orders, market: market, calendar: calendar, now: time.Now}
+}
+
+// SetAuditStore records each rerun in the audit log.
+func (s *EODCloseService) SetAuditStore(store *data.AuditStore) {
+	s.audit = store
+}
+
+// AddStep appends a step. Steps run in the order added; one failing is
+// logged and recorded on the run without stopping the rest.
+func (s *EODCloseService) AddStep(name string, run func(ctx context.Context, eod *EODClose) error) {
+	s.steps = append(s.steps, EODStep{Name: name, Run: run})
+}
+
+// OnComplete registers fn to be called after each completed run.
+func (s *EODCloseService) OnComplete(fn func(ctx context.Context, eod *EODClose, run *data.EODRun)) {
+	s.listeners = append(s.listeners, fn)
+}
+
+// RunClose processes the latest closed session once it is due (see Close)
+// and then checks again every eodCloseInterval until ctx is done.
+func (s *EODCloseService) RunClose(ctx context.Context) {
+	ticker := time.NewTicker(eodCloseInterval)
+	defer ticker.Stop()
+	for {
+		if run, err := s.Close(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("eod close failed", "err", err, "component", "eod_close")
+		} else if run != nil {
+			slog.Info("eod close done", "session", run.SessionDate.Format(time.DateOnly),
+				"symbols", run.Symbols, "closes", run.Closes, "failed_steps", run.FailedSteps, "component", "eod_close")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close processes the latest session that ended before the current UTC
+// day, which gives the EOD feed time to publish the close. It returns nil
+// when that session is already done or being run by another instance.
+func (s *EODCloseService) Close(ctx context.Context) (*data.EODRun, error) {
+	session := s.calendar.PreviousSession(startOfUTCDay(s.now()))
+	claimed, err := s.runs.Claim(ctx, session.Close, eodClaimStale)
+	if err != nil || !claimed {
+		return nil, err
+	}
+
+	symbols, err := s.activeSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	eod := &EODClose{Session: session, Closes: make(map[string]decimal.Decimal, len(symbols))}
+	run := &data.EODRun{SessionDate: session.Close, StartedAt: s.now(), Symbols: len(symbols), FailedSteps: []string{}}
+
+	if err := s.fetchCloses(ctx, symbols, eod); err != nil {
+		slog.Warn("eod close: persisting closes failed", "err", err, "component", "eod_close")
+		run.FailedSteps = append(run.FailedSteps, "closes")
+	}
+	run.Closes = len(eod.Closes)
+
+	failed, err := s.runSteps(ctx, eod)
+	if err != nil {
+		return nil, err
+	}
+	run.FailedSteps = append(run.FailedSteps, failed...)
+
+	if err := s.runs.Complete(ctx, run); err != nil {
+		return nil, err
+	}
+	for _, fn := range s.listeners {
+		fn(ctx, eod, run)
+	}
+	return run, nil
+}
+
+// Rerun re-runs the close of the session on date's calendar day, for when a
+// provider outage left it with bad data: it refetches the closes of every
+// symbol stored for the date or active now, bypassing the cache, overwrites
+// them in price history and runs the steps again with the stored closes as
+// EODClose.Previous. Running it twice changes nothing the second time. The
+// rerun is recorded in the audit log as adminID's.
+func (s *EODCloseService) Rerun(ctx context.Context, adminID string, date time.Time) (*EODRerun, error) {
+	y, m, d := date.Date()
+	session, ok := s.calendar.Session(time.Date(y, m, d, 0, 0, 0, 0, s.calendar.loc))
+	if !ok {
+		return nil, &util.ValidationError{Field: "date", Message: "must be a trading day"}
+	}
+	if session.Close.After(s.calendar.PreviousSession(startOfUTCDay(s.now())).Close) {
+		return nil, &util.ValidationError{Field: "date", Message: "must be a session that has already been closed"}
+	}
+
+	previous, err := s.history.ClosesOn(ctx, session.Close)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.activeSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	symbols := slices.Sorted(maps.Keys(previous))
+	for _, symbol := range active {
+		if _, ok := previous[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	fresh, err := s.market.ClosesOn(ctx, symbols, session.Close)
+	if err != nil {
+		slog.Warn("eod rerun: refetch failed", "session", session.Close.Format(time.DateOnly), "err", err, "component", "eod_close")
+		return nil, &EODRefetchFailedError{}
+	}
+	eod := &EODClose{Session: session, Closes: make(map[string]decimal.Decimal, len(fresh)), Previous: previous}
+	if err := s.storeCloses(ctx, fresh, eod); err != nil {
+		return nil, err
+	}
+
+	result := &EODRerun{SessionDate: session.Close.Format(time.DateOnly), Symbols: len(symbols),
+		Closes: len(eod.Closes), Corrected: []string{}}
+	for _, symbol := range symbols {
+		price, ok := eod.Closes[symbol]
+		if old, had := previous[symbol]; ok && had && !old.Equal(price) {
+			result.Corrected = append(result.Corrected, symbol)
+		}
+	}
+	if result.FailedSteps, err = s.runSteps(ctx, eod); err != nil {
+		return nil, err
+	}
+	s.recordRerun(ctx, adminID, result)
+	return result, nil
+}
+
+// runSteps runs every step against eod, returning the names of those that
+// failed. It stops early only if ctx is done.
+func (s *EODCloseService) runSteps(ctx context.Context, eod *EODClose) ([]string, error) {
+	failed := []string{}
+	for _, step := range s.steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := step.Run(ctx, eod); err != nil {
+			slog.Warn("eod close step failed", "step", step.Name, "rerun", eod.IsRerun(), "err", err, "component", "eod_close")
+			failed = append(failed, step.Name)
+		}
+	}
+	return failed, nil
+}
+
+// recordRerun writes result to the audit log. A failure is logged: the
+// rerun itself has already been applied.
+func (s *EODCloseService) recordRerun(ctx context.Context, adminID string, result *EODRerun) {
+	slog.Info("eod rerun done", "admin_id", adminID, "session", result.SessionDate,
+		"corrected", result.Corrected, "failed_steps", result.FailedSteps, "component", "eod_close")
+	if s.audit == nil {
+		return
+	}
+	details, _ := json.Marshal(result)
+	if err := s.audit.Record(ctx, &data.AuditEvent{UserID: adminID, Kind: AuditEODRerun, Details: details}); err != nil {
+		slog.Warn("audit event not recorded", "user_id", adminID, "kind", AuditEODRerun, "err", err, "component", "eod_close")
+	}
+}
+
+// fetchCloses stores each symbol's latest close (see storeCloses).
+func (s *EODCloseService) fetchCloses(ctx context.Context, symbols []string, eod *EODClose) error {
+	if len(symbols) == 0 {
+		return nil
+	}
+	latest, err := s.market.GetBatchHistoricalData(ctx, symbols)
+	if err != nil {
+		slog.Warn("eod close: price fetch failed", "err", err, "component", "eod_close")
+	}
+	return s.storeCloses(ctx, latest, eod)
+}
+
+// storeCloses adds each positive close in latest to eod.Closes and upserts
+// them into price history. A symbol the provider has no close for is left
+// out; the steps decide what that means for them.
+func (s *EODCloseService) storeCloses(ctx context.Context, latest map[string]*HistoricalData, eod *EODClose) error {
+	points := make([]data.StockHistoryPoint, 0, len(latest))
+	for symbol, hist := range latest {
+		if hist == nil || !hist.Price.IsPositive() {
+			continue
+		}
+		eod.Closes[symbol] = hist.Price
+		date, err := time.Parse(DateLayoutUS, hist.Date)
+		if err != nil {
+			continue
+		}
+		points = append(points, data.StockHistoryPoint{Symbol: symbol, TradeDate: date, Close: hist.Price, Volume: int64(hist.Volume)})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Symbol < points[j].Symbol })
+	return s.history.UpsertMany(ctx, points)
+}
+
+// activeSymbols returns the distinct held, watched and pending-order
+// symbols, sorted.
+func (s *EODCloseService) activeSymbols(ctx context.Context) ([]string, error) {
+	held, err := s.portfolio.HeldSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	watched, err := s.watchlist.WatchedSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.orders.PendingSymbols(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(held)+len(watched)+len(pending))
+	out := make([]string, 0, len(held)+len(watched)+len(pending))
+	for _, group := range [][]string{held, watched, pending} {
+		for _, symbol := range group {
+			if !seen[symbol] {
+				seen[symbol] = true
+				out = append(out, symbol)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// eodMarket adds ClosesOn to mockMarket.
+type eodMarket struct {
+	mockMarket
+	on map[string]*HistoricalData
+}
+
+func (m *eodMarket) ClosesOn(_ context.Context, _ []string, _ time.Time) (map[string]*HistoricalData, error) {
+	return m.on, nil
+}
+
+func newEODCloseService(t *testing.T, market EODMarket, now time.Time) (*EODCloseService, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	svc := NewEODCloseService(data.NewEODRunStore(db), data.NewStockHistoryStore(db), data.NewPortfolioStore(db),
+		data.NewWatchlistStore(db), data.NewOrderStore(db), market, newCalendar(t))
+	svc.SetAuditStore(data.NewAuditStore(db))
+	svc.now = func() time.Time { return now }
+	return svc, mock, func() { db.Close() }
+}
+
+func TestEODClose_RunsStepsAndRecordsRun(t *testing.T) {
+	market := &eodMarket{mockMarket: mockMarket{batch: map[string]*HistoricalData{
+		"AAPL": {Symbol: "AAPL", Date: "03/06/2026", Price: decimal.RequireFromString("231.5"), Volume: 100},
+		"MSFT": {Symbol: "MSFT", Date: "03/06/2026", Price: decimal.Zero}, // no close
+	}}}
+	// Saturday: the last session to close before the UTC day began is Friday's.
+	now := time.Date(2026, time.March, 7, 15, 0, 0, 0, time.UTC)
+	svc, mock, cleanup := newEODCloseService(t, market, now)
+	defer cleanup()
+
+	var stepped []string
+	svc.AddStep("first", func(_ context.Context, eod *EODClose) error {
+		stepped = append(stepped, "first")
+		if !eod.Closes["AAPL"].Equal(decimal.RequireFromString("231.5")) {
+			t.Errorf("AAPL close: got %s", eod.Closes["AAPL"])
+		}
+		if _, ok := eod.Closes["MSFT"]; ok {
+			t.Error("MSFT has no close and should be left out")
+		}
+		return errors.New("boom")
+	})
+	svc.AddStep("second", func(context.Context, *EODClose) error {
+		stepped = append(stepped, "second")
+		return nil
+	})
+	var completed *data.EODRun
+	svc.OnComplete(func(_ context.Context, _ *EODClose, run *data.EODRun) { completed = run })
+
+	mock.ExpectQuery("INSERT INTO eod_runs").
+		WithArgs("2026-03-06", float64(3600)).
+		WillReturnRows(sqlmock.NewRows([]string{"session_date"}).AddRow(time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC)))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM portfolio").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM watchlist").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL").AddRow("MSFT"))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+	mock.ExpectExec("INSERT INTO stock_history").
+		WithArgs("AAPL", time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC), "231.5", int64(100)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE eod_runs SET completed_at").
+		WithArgs("2026-03-06", 2, 1, pq.Array([]string{"first"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	run, err := svc.Close(context.Background())
+	if err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if run == nil || run.Symbols != 2 || run.Closes != 1 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+	if len(stepped) != 2 {
+		t.Errorf("a failing step should not stop the rest, ran %v", stepped)
+	}
+	if completed != run {
+		t.Error("listener was not told about the run")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestEODClose_SkipsClaimedSession(t *testing.T) {
+	now := time.Date(2026, time.March, 7, 15, 0, 0, 0, time.UTC)
+	svc, mock, cleanup := newEODCloseService(t, &eodMarket{}, now)
+	defer cleanup()
+	svc.AddStep("never", func(context.Context, *EODClose) error {
+		t.Error("step ran for a claimed session")
+		return nil
+	})
+
+	mock.ExpectQuery("INSERT INTO eod_runs").
+		WithArgs("2026-03-06", float64(3600)).
+		WillReturnRows(sqlmock.NewRows([]string{"session_date"}))
+
+	if run, err := svc.Close(context.Background()); run != nil || err != nil {
+		t.Errorf("got %+v, %v", run, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestEODRerun_CorrectsStoredCloses(t *testing.T) {
+	market := &eodMarket{on: map[string]*HistoricalData{
+		"AAPL": {Symbol: "AAPL", Date: "03/06/2026", Price: decimal.RequireFromString("232.75"), Volume: 100},
+		"MSFT": {Symbol: "MSFT", Date: "03/06/2026", Price: decimal.RequireFromString("410")},
+	}}
+	now := time.Date(2026, time.March, 9, 15, 0, 0, 0, time.UTC)
+	svc, mock, cleanup := newEODCloseService(t, market, now)
+	defer cleanup()
+
+	svc.AddStep("snapshots", func(_ context.Context, eod *EODClose) error {
+		if !eod.IsRerun() || !eod.Previous["AAPL"].Equal(decimal.RequireFromString("2.31")) {
+			t.Errorf("step should see the stored closes, got %v", eod.Previous)
+		}
+		return nil
+	})
+
+	mock.ExpectQuery("SELECT symbol, close FROM stock_history").
+		WithArgs("2026-03-06").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol", "close"}).AddRow("AAPL", "2.31").AddRow("MSFT", "410"))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM portfolio").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM watchlist").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+	mock.ExpectExec("INSERT INTO stock_history").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditEODRerun, "", "", sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := svc.Rerun(context.Background(), "admin-1", time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Rerun: %v", err)
+	}
+	if result.SessionDate != "2026-03-06" || result.Closes != 2 || len(result.Corrected) != 1 || result.Corrected[0] != "AAPL" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestEODRerun_RejectsDatesWithoutAClosedSession(t *testing.T) {
+	now := time.Date(2026, time.March, 9, 15, 0, 0, 0, time.UTC)
+	svc, _, cleanup := newEODCloseService(t, &eodMarket{}, now)
+	defer cleanup()
+
+	for _, date := range []time.Time{
+		time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC), // Saturday
+		time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC), // not yet processed
+	} {
+		var ve *util.ValidationError
+		if _, err := svc.Rerun(context.Background(), "admin-1", date); !errors.As(err, &ve) || ve.Field != "date" {
+			t.Errorf("%s: got %v, want a date validation error", date.Format(time.DateOnly), err)
+		}
+	}
+}
@@ -1,6 +1,13 @@
 package service
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // Error types in this file implement util.HTTPError so handlers can map them to
 // HTTP responses without inspecting their string form. Each type declares the
@@ -78,3 +85,800 @@ func (e *SymbolNotFoundError) ErrorCode() string   { return "SYMBOL_NOT_FOUND" }
 // ErrSymbolNotFound is the sentinel value of SymbolNotFoundError, retained for
 // callers that prefer errors.Is over errors.As.
 var ErrSymbolNotFound = &SymbolNotFoundError{}
+
+// SymbolRestrictedError is returned when the deployment's trading policy
+// blocks a symbol (penny stock, OTC or otherwise non-allowed venue). Reason is
+// user-safe and surfaced verbatim.
+type SymbolRestrictedError struct {
+	Reason string
+}
+
+func (e *SymbolRestrictedError) Error() string   { return "symbol restricted: " + e.Reason }
+func (e *SymbolRestrictedError) HTTPStatus() int { return http.StatusForbidden }
+func (e *SymbolRestrictedError) UserMessage() string {
+	return "Trading in this symbol is not allowed: " + e.Reason
+}
+func (e *SymbolRestrictedError) ErrorCode() string { return "SYMBOL_RESTRICTED" }
+
+// TradingHaltedError is returned for orders on a symbol whose trading is
+// halted, either by an admin or because the data provider reports it
+// suspended.
+type TradingHaltedError struct {
+	Symbol string
+	Reason string
+}
+
+func (e *TradingHaltedError) Error() string   { return "trading halted: " + e.Symbol }
+func (e *TradingHaltedError) HTTPStatus() int { return http.StatusConflict }
+func (e *TradingHaltedError) UserMessage() string {
+	if e.Reason != "" {
+		return "Trading in " + e.Symbol + " is halted: " + e.Reason
+	}
+	return "Trading in " + e.Symbol + " is halted"
+}
+func (e *TradingHaltedError) ErrorCode() string { return "HALTED" }
+
+// StaleQuoteError is returned for a trade whose only price is a last known
+// one, served during a provider outage, fetched longer ago than trades may
+// fill at.
+type StaleQuoteError struct {
+	Symbol string
+}
+
+func (e *StaleQuoteError) Error() string   { return "quote too stale to trade: " + e.Symbol }
+func (e *StaleQuoteError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *StaleQuoteError) UserMessage() string {
+	return "No current price for " + e.Symbol + " is available. Try again shortly"
+}
+func (e *StaleQuoteError) ErrorCode() string { return "STALE_QUOTE" }
+
+type InstrumentNotFoundError struct{}
+
+func (e *InstrumentNotFoundError) Error() string       { return "instrument not found" }
+func (e *InstrumentNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *InstrumentNotFoundError) UserMessage() string { return "Instrument not found" }
+func (e *InstrumentNotFoundError) ErrorCode() string   { return "INSTRUMENT_NOT_FOUND" }
+
+// DailyTradeLimitError is returned when a user has used up the configured
+// number of trades for the current UTC day.
+type DailyTradeLimitError struct {
+	Limit int
+}
+
+func (e *DailyTradeLimitError) Error() string   { return "daily trade limit reached" }
+func (e *DailyTradeLimitError) HTTPStatus() int { return http.StatusForbidden }
+func (e *DailyTradeLimitError) UserMessage() string {
+	return fmt.Sprintf("Daily trade limit of %d reached. Trading resets at midnight UTC", e.Limit)
+}
+func (e *DailyTradeLimitError) ErrorCode() string { return "DAILY_TRADE_LIMIT" }
+
+// VerificationRequiredError is returned for a trade by an account whose
+// email is unverified while TRADING_EMAIL_VERIFICATION forbids it. TradeCap
+// is the number of trades it was allowed, 0 when it was allowed none.
+type VerificationRequiredError struct {
+	TradeCap int
+}
+
+func (e *VerificationRequiredError) Error() string   { return "email verification required to trade" }
+func (e *VerificationRequiredError) HTTPStatus() int { return http.StatusForbidden }
+func (e *VerificationRequiredError) UserMessage() string {
+	if e.TradeCap > 0 {
+		return fmt.Sprintf("Verify your email address to make more than %d trades", e.TradeCap)
+	}
+	return "Verify your email address to start trading"
+}
+func (e *VerificationRequiredError) ErrorCode() string { return "VERIFICATION_REQUIRED" }
+
+// PatternDayTraderError is returned when an order would open a further day
+// trade for an account under the PDT equity threshold.
+type PatternDayTraderError struct {
+	MaxDayTrades int
+}
+
+func (e *PatternDayTraderError) Error() string   { return "pattern day trader restriction" }
+func (e *PatternDayTraderError) HTTPStatus() int { return http.StatusForbidden }
+func (e *PatternDayTraderError) UserMessage() string {
+	return fmt.Sprintf("Pattern day trader rule: accounts under the equity threshold may make at most %d day trades in 5 business days", e.MaxDayTrades)
+}
+func (e *PatternDayTraderError) ErrorCode() string { return "PDT_RESTRICTED" }
+
+// ReauthRequiredError is returned for sensitive actions when the session
+// predates a security flag on the account; the user must log in again.
+type ReauthRequiredError struct{}
+
+func (e *ReauthRequiredError) Error() string   { return "re-authentication required" }
+func (e *ReauthRequiredError) HTTPStatus() int { return http.StatusUnauthorized }
+func (e *ReauthRequiredError) UserMessage() string {
+	return "Unusual activity was detected on your account. Please log in again to continue"
+}
+func (e *ReauthRequiredError) ErrorCode() string { return "REAUTH_REQUIRED" }
+
+// SudoConfirmationError is returned when POST /api/account/sudo is given a
+// wrong password or a Google token for a different account.
+type SudoConfirmationError struct{}
+
+func (e *SudoConfirmationError) Error() string       { return "sudo confirmation failed" }
+func (e *SudoConfirmationError) HTTPStatus() int     { return http.StatusForbidden }
+func (e *SudoConfirmationError) UserMessage() string { return "Could not confirm your identity" }
+func (e *SudoConfirmationError) ErrorCode() string   { return "SUDO_CONFIRMATION_FAILED" }
+
+// SudoRequiredError is returned for sensitive operations called without a
+// valid elevation token from POST /api/account/sudo.
+type SudoRequiredError struct{}
+
+func (e *SudoRequiredError) Error() string   { return "sudo mode required" }
+func (e *SudoRequiredError) HTTPStatus() int { return http.StatusForbidden }
+func (e *SudoRequiredError) UserMessage() string {
+	return "Please confirm your password to continue"
+}
+func (e *SudoRequiredError) ErrorCode() string { return "SUDO_REQUIRED" }
+
+// ResetConfirmationRequiredError is returned by POST /api/account/reset
+// without a valid confirmation token for the caller.
+type ResetConfirmationRequiredError struct{}
+
+func (e *ResetConfirmationRequiredError) Error() string   { return "reset confirmation required" }
+func (e *ResetConfirmationRequiredError) HTTPStatus() int { return http.StatusPreconditionRequired }
+func (e *ResetConfirmationRequiredError) UserMessage() string {
+	return "Confirm the reset to continue"
+}
+func (e *ResetConfirmationRequiredError) ErrorCode() string { return "RESET_CONFIRMATION_REQUIRED" }
+
+// InvalidAvatarError rejects an avatar upload that is empty or not one of the
+// accepted image types.
+type InvalidAvatarError struct{}
+
+func (e *InvalidAvatarError) Error() string   { return "invalid avatar image" }
+func (e *InvalidAvatarError) HTTPStatus() int { return http.StatusBadRequest }
+func (e *InvalidAvatarError) UserMessage() string {
+	return "Avatar must be a PNG, JPEG, GIF or WebP image"
+}
+func (e *InvalidAvatarError) ErrorCode() string { return "INVALID_AVATAR" }
+
+// AvatarTooLargeError rejects an avatar upload over the configured size cap.
+type AvatarTooLargeError struct {
+	MaxBytes int64
+}
+
+func (e *AvatarTooLargeError) Error() string   { return "avatar too large" }
+func (e *AvatarTooLargeError) HTTPStatus() int { return http.StatusRequestEntityTooLarge }
+func (e *AvatarTooLargeError) UserMessage() string {
+	return fmt.Sprintf("Avatar must be %d KB or smaller", e.MaxBytes/1024)
+}
+func (e *AvatarTooLargeError) ErrorCode() string { return "AVATAR_TOO_LARGE" }
+
+// InvalidUsernameError rejects a username that is malformed, reserved or
+// contains a blocked term. Reason is safe to show to the user.
+type InvalidUsernameError struct {
+	Reason string
+}
+
+func (e *InvalidUsernameError) Error() string       { return "invalid username: " + e.Reason }
+func (e *InvalidUsernameError) HTTPStatus() int     { return http.StatusBadRequest }
+func (e *InvalidUsernameError) UserMessage() string { return e.Reason }
+func (e *InvalidUsernameError) ErrorCode() string   { return "INVALID_USERNAME" }
+
+type UsernameTakenError struct{}
+
+func (e *UsernameTakenError) Error() string       { return "username already taken" }
+func (e *UsernameTakenError) HTTPStatus() int     { return http.StatusConflict }
+func (e *UsernameTakenError) UserMessage() string { return "That username is already taken" }
+func (e *UsernameTakenError) ErrorCode() string   { return "USERNAME_TAKEN" }
+
+// UsernameCooldownError is returned when a user changes their username again
+// before the configured cooldown has passed.
+type UsernameCooldownError struct {
+	RetryAt time.Time
+}
+
+func (e *UsernameCooldownError) Error() string   { return "username changed too recently" }
+func (e *UsernameCooldownError) HTTPStatus() int { return http.StatusTooManyRequests }
+func (e *UsernameCooldownError) UserMessage() string {
+	return "You can change your username again after " + e.RetryAt.UTC().Format("2006-01-02 15:04 UTC")
+}
+func (e *UsernameCooldownError) ErrorCode() string { return "USERNAME_COOLDOWN" }
+
+// BonusCooldownError is returned when a user claims bonus cash again before
+// the configured cooldown has passed.
+type BonusCooldownError struct {
+	RetryAt time.Time
+}
+
+func (e *BonusCooldownError) Error() string   { return "bonus cash claimed too recently" }
+func (e *BonusCooldownError) HTTPStatus() int { return http.StatusTooManyRequests }
+func (e *BonusCooldownError) UserMessage() string {
+	return "You can claim bonus cash again after " + e.RetryAt.UTC().Format("2006-01-02 15:04 UTC")
+}
+func (e *BonusCooldownError) ErrorCode() string { return "BONUS_COOLDOWN" }
+
+// BonusDisabledError is returned when bonus cash is claimed on a server
+// that doesn't grant any.
+type BonusDisabledError struct{}
+
+func (e *BonusDisabledError) Error() string       { return "bonus cash disabled" }
+func (e *BonusDisabledError) HTTPStatus() int     { return http.StatusForbidden }
+func (e *BonusDisabledError) UserMessage() string { return "Bonus cash is not available" }
+func (e *BonusDisabledError) ErrorCode() string   { return "BONUS_DISABLED" }
+
+// InvalidAccountBundleError is returned when an account import bundle fails
+// its signature, schema version or content checks.
+type InvalidAccountBundleError struct {
+	Reason string
+}
+
+func (e *InvalidAccountBundleError) Error() string   { return "invalid account bundle: " + e.Reason }
+func (e *InvalidAccountBundleError) HTTPStatus() int { return http.StatusBadRequest }
+func (e *InvalidAccountBundleError) UserMessage() string {
+	return "Invalid account bundle: " + e.Reason
+}
+func (e *InvalidAccountBundleError) ErrorCode() string { return "BUNDLE_INVALID" }
+
+// AccountNotEmptyError is returned when an account is imported over one
+// that already has trades, holdings or pending orders without asking to
+// replace it.
+type AccountNotEmptyError struct{}
+
+func (e *AccountNotEmptyError) Error() string   { return "account is not empty" }
+func (e *AccountNotEmptyError) HTTPStatus() int { return http.StatusConflict }
+func (e *AccountNotEmptyError) UserMessage() string {
+	return "This account already has trades or holdings; import with mode=replace to overwrite them"
+}
+func (e *AccountNotEmptyError) ErrorCode() string { return "ACCOUNT_NOT_EMPTY" }
+
+// AccountBundleUsedError is returned when an account bundle that has
+// already been imported, into any account, is imported again.
+type AccountBundleUsedError struct{}
+
+func (e *AccountBundleUsedError) Error() string   { return "account bundle already imported" }
+func (e *AccountBundleUsedError) HTTPStatus() int { return http.StatusConflict }
+func (e *AccountBundleUsedError) UserMessage() string {
+	return "This bundle has already been imported; export the account again to move it"
+}
+func (e *AccountBundleUsedError) ErrorCode() string { return "BUNDLE_USED" }
+
+// AccountTransferDisabledError is returned when an account is exported on a
+// server without ACCOUNT_TRANSFER_SIGNING_KEY, or imported on one without
+// ACCOUNT_TRANSFER_TRUSTED_KEYS.
+type AccountTransferDisabledError struct{}
+
+func (e *AccountTransferDisabledError) Error() string   { return "account transfer disabled" }
+func (e *AccountTransferDisabledError) HTTPStatus() int { return http.StatusForbidden }
+func (e *AccountTransferDisabledError) UserMessage() string {
+	return "Account transfer is not available on this server"
+}
+func (e *AccountTransferDisabledError) ErrorCode() string { return "TRANSFER_DISABLED" }
+
+// InvalidMagicLinkError is returned when a magic-link token is malformed,
+// expired, superseded by a newer link or already used.
+type InvalidMagicLinkError struct{}
+
+func (e *InvalidMagicLinkError) Error() string   { return "invalid magic link" }
+func (e *InvalidMagicLinkError) HTTPStatus() int { return http.StatusUnauthorized }
+func (e *InvalidMagicLinkError) UserMessage() string {
+	return "This login link is invalid, expired or has already been used"
+}
+func (e *InvalidMagicLinkError) ErrorCode() string { return "MAGIC_LINK_INVALID" }
+
+// MagicLinkRateLimitError is returned when too many login links were
+// requested for one email address or from one client.
+type MagicLinkRateLimitError struct {
+	RetryAt time.Time
+}
+
+func (e *MagicLinkRateLimitError) Error() string   { return "magic link rate limit exceeded" }
+func (e *MagicLinkRateLimitError) HTTPStatus() int { return http.StatusTooManyRequests }
+func (e *MagicLinkRateLimitError) UserMessage() string {
+	return "Too many login links requested. Please try again later"
+}
+func (e *MagicLinkRateLimitError) ErrorCode() string { return "MAGIC_LINK_RATE_LIMITED" }
+
+// AccountLockedError is returned for a password login to an account locked
+// after too many wrong passwords.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string   { return "account locked after failed logins" }
+func (e *AccountLockedError) HTTPStatus() int { return http.StatusTooManyRequests }
+func (e *AccountLockedError) UserMessage() string {
+	return "Too many failed sign-in attempts. Try again after " + e.Until.UTC().Format("2006-01-02 15:04 UTC") +
+		", or use the unlock link we emailed you"
+}
+func (e *AccountLockedError) ErrorCode() string { return "ACCOUNT_LOCKED" }
+
+// AdminLockedError is returned for any login to an account an admin has
+// locked.
+type AdminLockedError struct {
+	Until time.Time
+}
+
+func (e *AdminLockedError) Error() string   { return "account locked by an admin" }
+func (e *AdminLockedError) HTTPStatus() int { return http.StatusForbidden }
+func (e *AdminLockedError) UserMessage() string {
+	return "This account has been locked until " + e.Until.UTC().Format("2006-01-02 15:04 UTC") +
+		". Contact support to have it unlocked"
+}
+func (e *AdminLockedError) ErrorCode() string { return "ACCOUNT_ADMIN_LOCKED" }
+
+// NotGuestError is returned when a guest upgrade is attempted on an account
+// that is already a full account.
+type NotGuestError struct{}
+
+func (e *NotGuestError) Error() string       { return "account is not a guest" }
+func (e *NotGuestError) HTTPStatus() int     { return http.StatusConflict }
+func (e *NotGuestError) UserMessage() string { return "This account has already been upgraded" }
+func (e *NotGuestError) ErrorCode() string   { return "NOT_GUEST" }
+
+// GoogleAccountInUseError is returned when a guest tries to upgrade with a
+// Google account that already belongs to another user.
+type GoogleAccountInUseError struct{}
+
+func (e *GoogleAccountInUseError) Error() string   { return "google account already in use" }
+func (e *GoogleAccountInUseError) HTTPStatus() int { return http.StatusConflict }
+func (e *GoogleAccountInUseError) UserMessage() string {
+	return "That Google account is already linked to another PaperTrader account"
+}
+func (e *GoogleAccountInUseError) ErrorCode() string { return "GOOGLE_ACCOUNT_IN_USE" }
+
+// InvalidPasskeyError is returned when a passkey login fails: unknown
+// credential, bad signature, expired or replayed ceremony or a cloned
+// authenticator.
+type InvalidPasskeyError struct{}
+
+func (e *InvalidPasskeyError) Error() string       { return "invalid passkey assertion" }
+func (e *InvalidPasskeyError) HTTPStatus() int     { return http.StatusUnauthorized }
+func (e *InvalidPasskeyError) UserMessage() string { return "Passkey sign-in failed. Please try again" }
+func (e *InvalidPasskeyError) ErrorCode() string   { return "PASSKEY_INVALID" }
+
+// PasskeyRegistrationError is returned when a new passkey can't be verified
+// or is already registered.
+type PasskeyRegistrationError struct {
+	Reason string
+}
+
+func (e *PasskeyRegistrationError) Error() string       { return "passkey registration failed: " + e.Reason }
+func (e *PasskeyRegistrationError) HTTPStatus() int     { return http.StatusBadRequest }
+func (e *PasskeyRegistrationError) UserMessage() string { return e.Reason }
+func (e *PasskeyRegistrationError) ErrorCode() string   { return "PASSKEY_REGISTRATION_FAILED" }
+
+// PasskeyNotFoundError is returned when a passkey does not exist or belongs
+// to another user.
+type PasskeyNotFoundError struct{}
+
+func (e *PasskeyNotFoundError) Error() string       { return "passkey not found" }
+func (e *PasskeyNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *PasskeyNotFoundError) UserMessage() string { return "Passkey not found" }
+func (e *PasskeyNotFoundError) ErrorCode() string   { return "PASSKEY_NOT_FOUND" }
+
+// RateLimitBucketNotFoundError is returned when an admin names a rate-limit
+// bucket that isn't configured.
+type RateLimitBucketNotFoundError struct{}
+
+func (e *RateLimitBucketNotFoundError) Error() string       { return "rate-limit bucket not found" }
+func (e *RateLimitBucketNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *RateLimitBucketNotFoundError) UserMessage() string { return "Rate-limit bucket not found" }
+func (e *RateLimitBucketNotFoundError) ErrorCode() string   { return "RATE_LIMIT_BUCKET_NOT_FOUND" }
+
+// RateLimitWindowNotFoundError is returned when resetting a user or IP that
+// has no requests in the window.
+type RateLimitWindowNotFoundError struct{}
+
+func (e *RateLimitWindowNotFoundError) Error() string   { return "rate-limit window not found" }
+func (e *RateLimitWindowNotFoundError) HTTPStatus() int { return http.StatusNotFound }
+func (e *RateLimitWindowNotFoundError) UserMessage() string {
+	return "No rate-limit window for that user or IP"
+}
+func (e *RateLimitWindowNotFoundError) ErrorCode() string { return "RATE_LIMIT_WINDOW_NOT_FOUND" }
+
+// OrderNotFoundError is returned when an order does not exist or belongs to
+// another user.
+type OrderNotFoundError struct{}
+
+func (e *OrderNotFoundError) Error() string       { return "order not found" }
+func (e *OrderNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *OrderNotFoundError) UserMessage() string { return "Order not found" }
+func (e *OrderNotFoundError) ErrorCode() string   { return "ORDER_NOT_FOUND" }
+
+// OrderNotPendingError is returned when cancelling an order that has already
+// been filled, cancelled, expired or failed.
+type OrderNotPendingError struct{}
+
+func (e *OrderNotPendingError) Error() string       { return "order not pending" }
+func (e *OrderNotPendingError) HTTPStatus() int     { return http.StatusConflict }
+func (e *OrderNotPendingError) UserMessage() string { return "Order is no longer pending" }
+func (e *OrderNotPendingError) ErrorCode() string   { return "ORDER_NOT_PENDING" }
+
+// OrderLimitError is returned when a user already has the maximum number of
+// pending orders.
+type OrderLimitError struct {
+	Limit int
+}
+
+func (e *OrderLimitError) Error() string   { return "pending order limit reached" }
+func (e *OrderLimitError) HTTPStatus() int { return http.StatusConflict }
+func (e *OrderLimitError) UserMessage() string {
+	return fmt.Sprintf("You can have at most %d pending orders", e.Limit)
+}
+func (e *OrderLimitError) ErrorCode() string { return "ORDER_LIMIT" }
+
+// HoldingsLimitError is returned when a buy or short would open a position
+// beyond the maximum number of distinct holdings.
+type HoldingsLimitError struct {
+	Limit int
+}
+
+func (e *HoldingsLimitError) Error() string   { return "holdings limit reached" }
+func (e *HoldingsLimitError) HTTPStatus() int { return http.StatusConflict }
+func (e *HoldingsLimitError) UserMessage() string {
+	return fmt.Sprintf("You can hold at most %d different symbols. Close a position first", e.Limit)
+}
+func (e *HoldingsLimitError) ErrorCode() string { return "HOLDINGS_LIMIT" }
+
+// WatchlistLimitError is returned when a user's watchlist is already at the
+// maximum length.
+type WatchlistLimitError struct {
+	Limit int
+}
+
+func (e *WatchlistLimitError) Error() string   { return "watchlist limit reached" }
+func (e *WatchlistLimitError) HTTPStatus() int { return http.StatusConflict }
+func (e *WatchlistLimitError) UserMessage() string {
+	return fmt.Sprintf("You can watch at most %d symbols", e.Limit)
+}
+func (e *WatchlistLimitError) ErrorCode() string { return "WATCHLIST_LIMIT" }
+
+// TradeNotFoundError is returned when a trade does not exist or belongs to
+// another user.
+type TradeNotFoundError struct{}
+
+func (e *TradeNotFoundError) Error() string       { return "trade not found" }
+func (e *TradeNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *TradeNotFoundError) UserMessage() string { return "Trade not found" }
+func (e *TradeNotFoundError) ErrorCode() string   { return "TRADE_NOT_FOUND" }
+
+// ShortPositionOpenError is returned when buying a symbol the user is short;
+// the short has to be covered instead.
+type ShortPositionOpenError struct{}
+
+func (e *ShortPositionOpenError) Error() string   { return "short position open" }
+func (e *ShortPositionOpenError) HTTPStatus() int { return http.StatusConflict }
+func (e *ShortPositionOpenError) UserMessage() string {
+	return "You are short this stock; buy to cover instead"
+}
+func (e *ShortPositionOpenError) ErrorCode() string { return "SHORT_POSITION_OPEN" }
+
+// LongPositionOpenError is returned when shorting a symbol the user holds;
+// the holding has to be sold first.
+type LongPositionOpenError struct{}
+
+func (e *LongPositionOpenError) Error() string   { return "long position open" }
+func (e *LongPositionOpenError) HTTPStatus() int { return http.StatusConflict }
+func (e *LongPositionOpenError) UserMessage() string {
+	return "You hold this stock; sell it before shorting"
+}
+func (e *LongPositionOpenError) ErrorCode() string { return "LONG_POSITION_OPEN" }
+
+// ShortPositionNotFoundError is returned when covering a symbol the user is
+// not short.
+type ShortPositionNotFoundError struct{}
+
+func (e *ShortPositionNotFoundError) Error() string       { return "short position not found" }
+func (e *ShortPositionNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *ShortPositionNotFoundError) UserMessage() string { return "Short position not found" }
+func (e *ShortPositionNotFoundError) ErrorCode() string   { return "SHORT_NOT_FOUND" }
+
+// CoverExceedsShortError is returned when covering more shares than are
+// short.
+type CoverExceedsShortError struct {
+	Short decimal.Decimal
+}
+
+func (e *CoverExceedsShortError) Error() string   { return "cover exceeds short position" }
+func (e *CoverExceedsShortError) HTTPStatus() int { return http.StatusBadRequest }
+func (e *CoverExceedsShortError) UserMessage() string {
+	return fmt.Sprintf("You are short only %s shares", e.Short)
+}
+func (e *CoverExceedsShortError) ErrorCode() string { return "COVER_EXCEEDS_SHORT" }
+
+// CuratedListNotFoundError is returned for an unknown curated watchlist slug.
+type CuratedListNotFoundError struct{}
+
+func (e *CuratedListNotFoundError) Error() string       { return "curated list not found" }
+func (e *CuratedListNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *CuratedListNotFoundError) UserMessage() string { return "Curated list not found" }
+func (e *CuratedListNotFoundError) ErrorCode() string   { return "CURATED_LIST_NOT_FOUND" }
+
+// UnsupportedCurrencyError is returned for a currency code the FX provider
+// does not quote.
+type UnsupportedCurrencyError struct {
+	Code string
+}
+
+func (e *UnsupportedCurrencyError) Error() string   { return "unsupported currency: " + e.Code }
+func (e *UnsupportedCurrencyError) HTTPStatus() int { return http.StatusBadRequest }
+func (e *UnsupportedCurrencyError) UserMessage() string {
+	return fmt.Sprintf("Currency %q is not supported", e.Code)
+}
+func (e *UnsupportedCurrencyError) ErrorCode() string { return "UNSUPPORTED_CURRENCY" }
+
+// ExchangeRatesUnavailableError is returned when exchange rates cannot be
+// fetched and none are cached.
+type ExchangeRatesUnavailableError struct{}
+
+func (e *ExchangeRatesUnavailableError) Error() string   { return "exchange rates unavailable" }
+func (e *ExchangeRatesUnavailableError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *ExchangeRatesUnavailableError) UserMessage() string {
+	return "Exchange rates are temporarily unavailable"
+}
+func (e *ExchangeRatesUnavailableError) ErrorCode() string { return "FX_UNAVAILABLE" }
+
+// MarketClosedError is returned for a trade placed outside the regular
+// session. Queue is set when the user has chosen to queue such trades and
+// the trade can be rested as a MARKET order instead.
+type MarketClosedError struct {
+	NextOpen time.Time
+	Queue    bool
+}
+
+func (e *MarketClosedError) Error() string   { return "market closed" }
+func (e *MarketClosedError) HTTPStatus() int { return http.StatusConflict }
+func (e *MarketClosedError) UserMessage() string {
+	return "The market is closed; it next opens " + e.NextOpen.Format("Mon Jan 2 15:04 MST")
+}
+func (e *MarketClosedError) ErrorCode() string { return "MARKET_CLOSED" }
+
+// ClassificationNotFoundError is returned for a symbol with no sector or
+// index classification.
+type ClassificationNotFoundError struct{}
+
+func (e *ClassificationNotFoundError) Error() string   { return "classification not found" }
+func (e *ClassificationNotFoundError) HTTPStatus() int { return http.StatusNotFound }
+func (e *ClassificationNotFoundError) UserMessage() string {
+	return "No classification for this symbol"
+}
+func (e *ClassificationNotFoundError) ErrorCode() string { return "CLASSIFICATION_NOT_FOUND" }
+
+// InviteCodeRequiredError is returned when registration is invite-only and
+// a new account was attempted without an invite code.
+type InviteCodeRequiredError struct{}
+
+func (e *InviteCodeRequiredError) Error() string   { return "invite code required" }
+func (e *InviteCodeRequiredError) HTTPStatus() int { return http.StatusForbidden }
+func (e *InviteCodeRequiredError) UserMessage() string {
+	return "Sign-up is by invitation only. Please enter an invite code"
+}
+func (e *InviteCodeRequiredError) ErrorCode() string { return "INVITE_CODE_REQUIRED" }
+
+// InvalidInviteCodeError is returned for an invite code that does not exist,
+// was revoked, has expired or has no uses left.
+type InvalidInviteCodeError struct{}
+
+func (e *InvalidInviteCodeError) Error() string   { return "invalid invite code" }
+func (e *InvalidInviteCodeError) HTTPStatus() int { return http.StatusForbidden }
+func (e *InvalidInviteCodeError) UserMessage() string {
+	return "This invite code is invalid, expired or has already been used"
+}
+func (e *InvalidInviteCodeError) ErrorCode() string { return "INVITE_CODE_INVALID" }
+
+// InviteCodeNotFoundError is returned by the admin invite code endpoints for
+// an unknown code.
+type InviteCodeNotFoundError struct{}
+
+func (e *InviteCodeNotFoundError) Error() string       { return "invite code not found" }
+func (e *InviteCodeNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *InviteCodeNotFoundError) UserMessage() string { return "Invite code not found" }
+func (e *InviteCodeNotFoundError) ErrorCode() string   { return "INVITE_CODE_NOT_FOUND" }
+
+// RecurringInvestmentNotFoundError is returned when a recurring investment
+// does not exist or belongs to another user.
+type RecurringInvestmentNotFoundError struct{}
+
+func (e *RecurringInvestmentNotFoundError) Error() string   { return "recurring investment not found" }
+func (e *RecurringInvestmentNotFoundError) HTTPStatus() int { return http.StatusNotFound }
+func (e *RecurringInvestmentNotFoundError) UserMessage() string {
+	return "Recurring investment not found"
+}
+func (e *RecurringInvestmentNotFoundError) ErrorCode() string {
+	return "RECURRING_INVESTMENT_NOT_FOUND"
+}
+
+// RecurringInvestmentLimitError is returned when a user already has the
+// maximum number of recurring investments.
+type RecurringInvestmentLimitError struct {
+	Limit int
+}
+
+func (e *RecurringInvestmentLimitError) Error() string   { return "recurring investment limit reached" }
+func (e *RecurringInvestmentLimitError) HTTPStatus() int { return http.StatusConflict }
+func (e *RecurringInvestmentLimitError) UserMessage() string {
+	return fmt.Sprintf("You can have at most %d recurring investments", e.Limit)
+}
+func (e *RecurringInvestmentLimitError) ErrorCode() string { return "RECURRING_LIMIT" }
+
+// InvestmentGoalNotFoundError is returned when an investment goal does not
+// exist or belongs to another user.
+type InvestmentGoalNotFoundError struct{}
+
+func (e *InvestmentGoalNotFoundError) Error() string       { return "investment goal not found" }
+func (e *InvestmentGoalNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *InvestmentGoalNotFoundError) UserMessage() string { return "Goal not found" }
+func (e *InvestmentGoalNotFoundError) ErrorCode() string   { return "GOAL_NOT_FOUND" }
+
+// InvestmentGoalLimitError is returned when a user already has the maximum
+// number of investment goals.
+type InvestmentGoalLimitError struct {
+	Limit int
+}
+
+func (e *InvestmentGoalLimitError) Error() string   { return "investment goal limit reached" }
+func (e *InvestmentGoalLimitError) HTTPStatus() int { return http.StatusConflict }
+func (e *InvestmentGoalLimitError) UserMessage() string {
+	return fmt.Sprintf("You can have at most %d goals", e.Limit)
+}
+func (e *InvestmentGoalLimitError) ErrorCode() string { return "GOAL_LIMIT" }
+
+// PriceUnavailableError is returned when the provider's price for a symbol
+// failed the sanity checks and there is no earlier accepted price to serve.
+type PriceUnavailableError struct{}
+
+func (e *PriceUnavailableError) Error() string   { return "no reliable price available" }
+func (e *PriceUnavailableError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *PriceUnavailableError) UserMessage() string {
+	return "No reliable price is available for this symbol right now"
+}
+func (e *PriceUnavailableError) ErrorCode() string { return "PRICE_UNAVAILABLE" }
+
+// EODRefetchFailedError is returned when an end-of-day rerun cannot refetch
+// the session's closes from the provider. Nothing is changed.
+type EODRefetchFailedError struct{}
+
+func (e *EODRefetchFailedError) Error() string   { return "eod rerun: refetching closes failed" }
+func (e *EODRefetchFailedError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *EODRefetchFailedError) UserMessage() string {
+	return "The market data provider could not supply the closes; try again later"
+}
+func (e *EODRefetchFailedError) ErrorCode() string { return "EOD_REFETCH_FAILED" }
+
+// LiveCapacityError is returned when the live price hub already has as many
+// connected clients as it allows. Clients fall back to polling.
+type LiveCapacityError struct{}
+
+func (e *LiveCapacityError) Error() string   { return "live price hub is full" }
+func (e *LiveCapacityError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *LiveCapacityError) UserMessage() string {
+	return "Live prices are at capacity; try again shortly"
+}
+func (e *LiveCapacityError) ErrorCode() string { return "LIVE_CAPACITY" }
+
+// SymbolRenameConflictError is returned when a rename can't re-key a
+// holding because the user is long one symbol and short the other. The
+// rename still applies to lookups; the holdings are left for an admin.
+type SymbolRenameConflictError struct {
+	OldSymbol, NewSymbol string
+}
+
+func (e *SymbolRenameConflictError) Error() string {
+	return fmt.Sprintf("rename %s to %s: opposite positions in both", e.OldSymbol, e.NewSymbol)
+}
+func (e *SymbolRenameConflictError) HTTPStatus() int { return http.StatusConflict }
+func (e *SymbolRenameConflictError) UserMessage() string {
+	return "A user holds " + e.OldSymbol + " and " + e.NewSymbol + " in opposite directions; close one before renaming"
+}
+func (e *SymbolRenameConflictError) ErrorCode() string { return "SYMBOL_RENAME_CONFLICT" }
+
+// RetentionDisabledError is returned when an admin asks for a retention pass
+// while the job is off: RETENTION_INACTIVE_DAYS is 0 or email isn't set up.
+type RetentionDisabledError struct{}
+
+func (e *RetentionDisabledError) Error() string   { return "retention job disabled" }
+func (e *RetentionDisabledError) HTTPStatus() int { return http.StatusConflict }
+func (e *RetentionDisabledError) UserMessage() string {
+	return "The retention job is off; set RETENTION_INACTIVE_DAYS and configure email to enable it"
+}
+func (e *RetentionDisabledError) ErrorCode() string { return "RETENTION_DISABLED" }
+
+// EmailDisabledError is returned when an admin asks for an email to be sent
+// while email is not configured.
+type EmailDisabledError struct{}
+
+func (e *EmailDisabledError) Error() string   { return "email not configured" }
+func (e *EmailDisabledError) HTTPStatus() int { return http.StatusConflict }
+func (e *EmailDisabledError) UserMessage() string {
+	return "Email is not configured on this server"
+}
+func (e *EmailDisabledError) ErrorCode() string { return "EMAIL_DISABLED" }
+
+// EmailAlreadyVerifiedError is returned when a verification email is
+// requested for an address that is already verified.
+type EmailAlreadyVerifiedError struct{}
+
+func (e *EmailAlreadyVerifiedError) Error() string   { return "email already verified" }
+func (e *EmailAlreadyVerifiedError) HTTPStatus() int { return http.StatusConflict }
+func (e *EmailAlreadyVerifiedError) UserMessage() string {
+	return "This email address is already verified"
+}
+func (e *EmailAlreadyVerifiedError) ErrorCode() string { return "EMAIL_ALREADY_VERIFIED" }
+
+// TradeDisputeNotFoundError is returned when a trade dispute does not exist.
+type TradeDisputeNotFoundError struct{}
+
+func (e *TradeDisputeNotFoundError) Error() string       { return "trade dispute not found" }
+func (e *TradeDisputeNotFoundError) HTTPStatus() int     { return http.StatusNotFound }
+func (e *TradeDisputeNotFoundError) UserMessage() string { return "Trade dispute not found" }
+func (e *TradeDisputeNotFoundError) ErrorCode() string   { return "TRADE_DISPUTE_NOT_FOUND" }
+
+// TradeAlreadyDisputedError is returned when disputing a trade that has
+// been disputed before; each trade gets one dispute.
+type TradeAlreadyDisputedError struct{}
+
+func (e *TradeAlreadyDisputedError) Error() string   { return "trade already disputed" }
+func (e *TradeAlreadyDisputedError) HTTPStatus() int { return http.StatusConflict }
+func (e *TradeAlreadyDisputedError) UserMessage() string {
+	return "This trade has already been disputed"
+}
+func (e *TradeAlreadyDisputedError) ErrorCode() string { return "TRADE_ALREADY_DISPUTED" }
+
+// TradeDisputeClosedError is returned when resolving a dispute that has
+// already been reversed or rejected.
+type TradeDisputeClosedError struct {
+	Status string
+}
+
+func (e *TradeDisputeClosedError) Error() string {
+	return "trade dispute already " + strings.ToLower(e.Status)
+}
+func (e *TradeDisputeClosedError) HTTPStatus() int { return http.StatusConflict }
+func (e *TradeDisputeClosedError) UserMessage() string {
+	return "This dispute has already been " + strings.ToLower(e.Status)
+}
+func (e *TradeDisputeClosedError) ErrorCode() string { return "TRADE_DISPUTE_CLOSED" }
+
+// TradeNotReversibleError is returned when a disputed trade can't be
+// reversed, e.g. the shares it bought have since been sold.
+type TradeNotReversibleError struct {
+	Reason string
+}
+
+func (e *TradeNotReversibleError) Error() string   { return "trade not reversible: " + e.Reason }
+func (e *TradeNotReversibleError) HTTPStatus() int { return http.StatusConflict }
+func (e *TradeNotReversibleError) UserMessage() string {
+	return "The trade can't be reversed: " + e.Reason
+}
+func (e *TradeNotReversibleError) ErrorCode() string { return "TRADE_NOT_REVERSIBLE" }
+
+// ProviderUnavailableError is returned when a market data provider timed
+// out, failed with a 5xx or rate-limited us, or when every provider's
+// circuit breaker is open. It is what triggers failover.
+type ProviderUnavailableError struct {
+	Provider string
+	Err      error
+}
+
+func (e *ProviderUnavailableError) Error() string {
+	return fmt.Sprintf("market data provider %s unavailable: %v", e.Provider, e.Err)
+}
+func (e *ProviderUnavailableError) Unwrap() error   { return e.Err }
+func (e *ProviderUnavailableError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *ProviderUnavailableError) UserMessage() string {
+	return "Market data is temporarily unavailable; try again shortly"
+}
+func (e *ProviderUnavailableError) ErrorCode() string { return "MARKET_DATA_UNAVAILABLE" }
+
+// QuotaShedError is returned instead of calling a provider for a
+// non-critical request (a search, chart, company profile or cache warm-up)
+// once its monthly quota is nearly spent; see QuotaTracker. FailoverProvider
+// moves on without counting it against the provider's circuit breaker.
+type QuotaShedError struct {
+	Provider string
+	Used     int64
+	Quota    int64
+}
+
+func (e *QuotaShedError) Error() string {
+	return fmt.Sprintf("market data provider %s quota nearly spent (%d of %d requests this month); non-critical call refused", e.Provider, e.Used, e.Quota)
+}
+func (e *QuotaShedError) HTTPStatus() int { return http.StatusServiceUnavailable }
+func (e *QuotaShedError) UserMessage() string {
+	return "This market data is unavailable for now; quotes and trading are unaffected"
+}
+func (e *QuotaShedError) ErrorCode() string { return "MARKET_DATA_QUOTA" }
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// TradeCSVHeader is the header row of a trade history export; TradeCSVRecord
+// gives the matching row for one trade.
+var TradeCSVHeader = []string{"id", "executed_at", "symbol", "action", "quantity", "price", "total",
+	"slippage", "order_type", "status"}
+
+// TradeCSVRecord is t as a CSV row under TradeCSVHeader. Amounts are USD.
+func TradeCSVRecord(t *data.Trade) []string {
+	return []string{
+		t.ID, t.ExecutedAt.UTC().Format(time.RFC3339), t.Symbol, t.Action, t.Quantity.String(),
+		t.Price.StringFixed(2), t.Total.StringFixed(2), t.Slippage.StringFixed(2), t.OrderType, t.Status,
+	}
+}
+
+// WriteHoldingsCSV writes holdings as CSV with a header row. current_price
+// and unrealized_pnl are blank for a holding without a price.
+func WriteHoldingsCSV(w io.Writer, holdings []data.UserStock) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"symbol", "quantity", "avg_price", "cost", "margin", "current_price", "unrealized_pnl", "currency"})
+	for _, h := range holdings {
+		price, pnl := "", ""
+		if h.CurrentStockPrice.IsPositive() {
+			price = h.CurrentStockPrice.StringFixed(2)
+		}
+		if h.UnrealizedPnL != nil {
+			pnl = h.UnrealizedPnL.StringFixed(2)
+		}
+		currency := h.Currency
+		if currency == "" {
+			currency = BaseCurrency
+		}
+		cw.Write([]string{
+			h.Symbol, h.Quantity.String(), h.AvgPrice.StringFixed(2), h.Total.StringFixed(2),
+			h.Margin.StringFixed(2), price, pnl, currency,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
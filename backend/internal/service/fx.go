@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+const (
+	// BaseCurrency is the currency every price, balance and trade is stored
+	// in. MarketStack quotes US listings in USD.
+	BaseCurrency = "USD"
+
+	fxTimeout = 10 * time.Second
+	// fxRatePlaces is the precision rates are kept at; converted amounts are
+	// rounded to cents.
+	fxRatePlaces = 6
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// FXRate is the price of one unit of From in To, as published on Date.
+type FXRate struct {
+	From string          `json:"from"`
+	To   string          `json:"to"`
+	Rate decimal.Decimal `json:"rate"`
+	Date string          `json:"date"` // ISO YYYY-MM-DD
+}
+
+// Apply converts amount at the rate, rounded to cents.
+func (r *FXRate) Apply(amount decimal.Decimal) decimal.Decimal {
+	return amount.Mul(r.Rate).Round(2)
+}
+
+// ApplyPrice converts a per-share price at the rate, rounded to places
+// rather than cents so a sub-penny quote keeps its precision.
+func (r *FXRate) ApplyPrice(price decimal.Decimal, places int32) decimal.Decimal {
+	return price.Mul(r.Rate).Round(places)
+}
+
+// FXConversion is the result of FXService.Convert.
+type FXConversion struct {
+	FXRate
+	Amount    decimal.Decimal `json:"amount"`
+	Converted decimal.Decimal `json:"converted"`
+}
+
+// fxTable is one fetch of the provider's rates, all against BaseCurrency.
+type fxTable struct {
+	rates     map[string]decimal.Decimal
+	date      string
+	fetchedAt time.Time
+}
+
+// FXService converts amounts between currencies using reference rates from a
+// Frankfurter-compatible API (ECB rates, published once per working day) and
+// keeps each user's display currency. Rates are fetched against BaseCurrency
+// at most once per ttl and held in process; cross rates are derived from
+// them. When a refresh fails the previous table keeps being served.
+type FXService struct {
+	baseURL string
+	ttl     time.Duration
+	users   *data.UserStore
+	client  *http.Client
+
+	mu    sync.Mutex
+	table *fxTable
+}
+
+func NewFXService(baseURL string, client *http.Client, ttl time.Duration, users *data.UserStore) *FXService {
+	return &FXService{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		ttl:     ttl,
+		users:   users,
+		client:  util.ClientWithTimeout(client, fxTimeout),
+	}
+}
+
+// normalizeCurrency upper-cases code and checks it looks like an ISO 4217
+// code. Whether the provider quotes it is checked separately.
+func normalizeCurrency(field, code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !currencyCodePattern.MatchString(code) {
+		return "", &util.ValidationError{Field: field, Message: "must be a 3-letter ISO 4217 currency code"}
+	}
+	return code, nil
+}
+
+// Rate returns the exchange rate from one currency to another.
+func (s *FXService) Rate(ctx context.Context, from, to string) (*FXRate, error) {
+	from, err := normalizeCurrency("from", from)
+	if err != nil {
+		return nil, err
+	}
+	to, err = normalizeCurrency("to", to)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return &FXRate{From: from, To: to, Rate: decimal.NewFromInt(1), Date: time.Now().UTC().Format(DateLayoutISO)}, nil
+	}
+
+	table, err := s.rates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fromRate, ok := table.rates[from]
+	if !ok {
+		return nil, &UnsupportedCurrencyError{Code: from}
+	}
+	toRate, ok := table.rates[to]
+	if !ok {
+		return nil, &UnsupportedCurrencyError{Code: to}
+	}
+	return &FXRate{From: from, To: to, Rate: toRate.DivRound(fromRate, fxRatePlaces), Date: table.date}, nil
+}
+
+// Convert converts amount from one currency to another.
+func (s *FXService) Convert(ctx context.Context, amount decimal.Decimal, from, to string) (*FXConversion, error) {
+	if amount.IsNegative() {
+		return nil, &util.ValidationError{Field: "amount", Message: "must not be negative"}
+	}
+	rate, err := s.Rate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &FXConversion{FXRate: *rate, Amount: amount, Converted: rate.Apply(amount)}, nil
+}
+
+// DisplayRate resolves the currency a response for userID should be shown in
+// and returns the rate from BaseCurrency to it. requested, typically the
+// display_currency query parameter, wins over the user's saved setting. A
+// failure to read the setting falls back to BaseCurrency rather than failing
+// the request.
+func (s *FXService) DisplayRate(ctx context.Context, userID, requested string) (*FXRate, error) {
+	currency := BaseCurrency
+	if requested != "" {
+		code, err := normalizeCurrency("display_currency", requested)
+		if err != nil {
+			return nil, err
+		}
+		currency = code
+	} else if userID != "" && s.users != nil {
+		saved, err := s.users.GetDisplayCurrency(ctx, userID)
+		if err != nil {
+			slog.Warn("failed to read display currency; using base currency", "user_id", userID, "err", err, "component", "fx")
+		} else {
+			currency = saved
+		}
+	}
+	return s.Rate(ctx, BaseCurrency, currency)
+}
+
+// SetDisplayCurrency saves the currency userID wants amounts shown in and
+// returns the normalised code. The provider must quote it.
+func (s *FXService) SetDisplayCurrency(ctx context.Context, userID, currency string) (string, error) {
+	code, err := normalizeCurrency("currency", currency)
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.Rate(ctx, BaseCurrency, code); err != nil {
+		return "", err
+	}
+	if err := s.users.SetDisplayCurrency(ctx, userID, code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// rates returns the current rate table, refreshing it when older than ttl.
+// The lock is held across the fetch so concurrent misses make one call.
+func (s *FXService) rates(ctx context.Context) (*fxTable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.table != nil && time.Since(s.table.fetchedAt) < s.ttl {
+		return s.table, nil
+	}
+	table, err := s.fetchRates(ctx)
+	if err != nil {
+		if s.table != nil {
+			slog.Warn("exchange rate refresh failed; serving cached rates", "date", s.table.date, "err", err, "component", "fx")
+			return s.table, nil
+		}
+		slog.Error("exchange rate fetch failed", "err", err, "component", "fx")
+		return nil, &ExchangeRatesUnavailableError{}
+	}
+	s.table = table
+	return table, nil
+}
+
+func (s *FXService) fetchRates(ctx context.Context) (*fxTable, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/latest", nil)
+	if err != nil {
+		return nil, err
+	}
+	q := httpReq.URL.Query()
+	q.Add("from", BaseCurrency)
+	httpReq.URL.RawQuery = q.Encode()
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FX API returned status %d", resp.StatusCode)
+	}
+
+	var apiResp struct {
+		Base  string                     `json:"base"`
+		Date  string                     `json:"date"`
+		Rates map[string]decimal.Decimal `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	if apiResp.Base != BaseCurrency || len(apiResp.Rates) == 0 {
+		return nil, fmt.Errorf("FX API returned rates against %q", apiResp.Base)
+	}
+
+	table := &fxTable{rates: make(map[string]decimal.Decimal, len(apiResp.Rates)+1), date: apiResp.Date, fetchedAt: time.Now()}
+	for code, rate := range apiResp.Rates {
+		if rate.IsPositive() {
+			table.rates[strings.ToUpper(code)] = rate
+		}
+	}
+	table.rates[BaseCurrency] = decimal.NewFromInt(1)
+	return table, nil
+}
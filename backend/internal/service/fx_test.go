@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// newFXServer serves a fixed Frankfurter response and counts calls. fail
+// makes it answer 500.
+func newFXServer(t *testing.T, fail *atomic.Bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Path != "/latest" || r.URL.Query().Get("from") != "USD" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if fail != nil && fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"amount":1.0,"base":"USD","date":"2026-03-02","rates":{"EUR":0.9,"GBP":0.8,"JPY":150}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestFXRate_CrossRateAndCaching(t *testing.T) {
+	srv, calls := newFXServer(t, nil)
+	svc := NewFXService(srv.URL, nil, time.Hour, nil)
+	ctx := context.Background()
+
+	rate, err := svc.Rate(ctx, "eur", "GBP")
+	if err != nil {
+		t.Fatalf("Rate: %v", err)
+	}
+	if rate.From != "EUR" || rate.To != "GBP" || !rate.Rate.Equal(decimal.RequireFromString("0.888889")) || rate.Date != "2026-03-02" {
+		t.Errorf("rate = %+v", rate)
+	}
+
+	conv, err := svc.Convert(ctx, decimal.NewFromInt(250), "USD", "JPY")
+	if err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+	if !conv.Converted.Equal(decimal.NewFromInt(37500)) {
+		t.Errorf("converted = %s, want 37500", conv.Converted)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("provider called %d times, want 1", n)
+	}
+}
+
+func TestFXRate_UnsupportedAndInvalid(t *testing.T) {
+	srv, _ := newFXServer(t, nil)
+	svc := NewFXService(srv.URL, nil, time.Hour, nil)
+	ctx := context.Background()
+
+	var uce *UnsupportedCurrencyError
+	if _, err := svc.Rate(ctx, "USD", "XYZ"); !errors.As(err, &uce) || uce.Code != "XYZ" {
+		t.Errorf("expected UnsupportedCurrencyError, got %v", err)
+	}
+	var ve *util.ValidationError
+	if _, err := svc.Rate(ctx, "US", "EUR"); !errors.As(err, &ve) || ve.Field != "from" {
+		t.Errorf("expected validation error on from, got %v", err)
+	}
+	if _, err := svc.Convert(ctx, decimal.NewFromInt(-1), "USD", "EUR"); !errors.As(err, &ve) || ve.Field != "amount" {
+		t.Errorf("expected validation error on amount, got %v", err)
+	}
+}
+
+func TestFXRate_ServesStaleRatesWhenRefreshFails(t *testing.T) {
+	var fail atomic.Bool
+	srv, _ := newFXServer(t, &fail)
+	svc := NewFXService(srv.URL, nil, time.Nanosecond, nil)
+	ctx := context.Background()
+
+	if _, err := svc.Rate(ctx, "USD", "EUR"); err != nil {
+		t.Fatalf("first Rate: %v", err)
+	}
+	fail.Store(true)
+	rate, err := svc.Rate(ctx, "USD", "EUR")
+	if err != nil || !rate.Rate.Equal(decimal.RequireFromString("0.9")) {
+		t.Errorf("stale Rate = (%+v, %v), want the cached rate", rate, err)
+	}
+}
+
+func TestFXRate_UnavailableWithoutCache(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	srv, _ := newFXServer(t, &fail)
+	svc := NewFXService(srv.URL, nil, time.Hour, nil)
+
+	var fue *ExchangeRatesUnavailableError
+	if _, err := svc.Rate(context.Background(), "USD", "EUR"); !errors.As(err, &fue) {
+		t.Errorf("expected ExchangeRatesUnavailableError, got %v", err)
+	}
+}
+
+func TestFXDisplayRate(t *testing.T) {
+	srv, calls := newFXServer(t, nil)
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewFXService(srv.URL, nil, time.Hour, data.NewUserStore(db))
+	ctx := context.Background()
+
+	// The query parameter wins and the saved setting is not read.
+	rate, err := svc.DisplayRate(ctx, "user-1", "gbp")
+	if err != nil || rate.To != "GBP" {
+		t.Fatalf("requested: got (%+v, %v)", rate, err)
+	}
+
+	mock.ExpectQuery("SELECT COALESCE\\(s.display_currency, 'USD'\\) FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"display_currency"}).AddRow("EUR"))
+	rate, err = svc.DisplayRate(ctx, "user-1", "")
+	if err != nil || rate.To != "EUR" || !rate.Rate.Equal(decimal.RequireFromString("0.9")) {
+		t.Fatalf("saved: got (%+v, %v)", rate, err)
+	}
+
+	// Anonymous callers get USD without a provider call.
+	before := calls.Load()
+	rate, err = svc.DisplayRate(ctx, "", "")
+	if err != nil || rate.To != "USD" || !rate.Rate.Equal(decimal.NewFromInt(1)) || calls.Load() != before {
+		t.Errorf("anonymous: got (%+v, %v)", rate, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"papertrader/internal/data"
 	"strings"
 
 	"google.golang.org/api/idtoken"
+	"google.golang.org/api/option"
 )
 
 // GoogleOAuthService verifies Google-issued ID tokens.
@@ -21,6 +23,8 @@ type GoogleOAuthService struct {
 	users      *data.UserStore
 	jwtService *JWTService
 	clientID   string
+	validator  *idtoken.Validator
+	initErr    error
 }
 
 type GoogleUserInfo struct {
@@ -30,11 +34,20 @@ type GoogleUserInfo struct {
 	EmailVerified bool
 }
 
-func NewGoogleOAuthService(users *data.UserStore, jwtService *JWTService, clientID string) *GoogleOAuthService {
+// NewGoogleOAuthService builds the service. Google's signing keys are
+// fetched, and cached, through client; nil uses http.DefaultClient.
+func NewGoogleOAuthService(users *data.UserStore, jwtService *JWTService, clientID string, client *http.Client) *GoogleOAuthService {
+	var opts []idtoken.ClientOption
+	if client != nil {
+		opts = append(opts, option.WithHTTPClient(client))
+	}
+	validator, err := idtoken.NewValidator(context.Background(), opts...)
 	return &GoogleOAuthService{
 		users:      users,
 		jwtService: jwtService,
 		clientID:   clientID,
+		validator:  validator,
+		initErr:    err,
 	}
 }
 
@@ -49,12 +62,16 @@ func (s *GoogleOAuthService) VerifyIDToken(ctx context.Context, idToken string)
 		return nil, errors.New("empty id token")
 	}
 
-	payload, err := idtoken.Validate(ctx, idToken, s.clientID)
+	if s.initErr != nil {
+		return nil, fmt.Errorf("google oauth validator: %w", s.initErr)
+	}
+
+	payload, err := s.validator.Validate(ctx, idToken, s.clientID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid id token: %w", err)
 	}
 
-	// idtoken.Validate already checks aud == clientID, exp, iat, and signature.
+	// Validate already checks aud == clientID, exp, iat, and signature.
 	// Belt-and-braces: confirm issuer is Google.
 	if payload.Issuer != "https://accounts.google.com" && payload.Issuer != "accounts.google.com" {
 		return nil, fmt.Errorf("unexpected token issuer: %q", payload.Issuer)
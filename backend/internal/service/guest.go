@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+const (
+	// guestPurgeInterval is how often RunPurge looks for expired guests.
+	guestPurgeInterval = time.Hour
+	// guestPurgeBatch bounds one purge statement so a backlog can't hold
+	// locks on trades/portfolio for long.
+	guestPurgeBatch = 500
+)
+
+// GuestService manages guest accounts: a trial portfolio with no email that
+// expires unless upgraded to a full account. Upgrading happens in place, so
+// the guest's trades and holdings carry over.
+type GuestService struct {
+	users        *data.UserStore
+	jwtService   *JWTService
+	emailService *EmailService
+	googleOAuth  *GoogleOAuthService
+	invites      *InviteService
+	ttl          time.Duration
+}
+
+// NewGuestService builds the service. emailService and googleOAuth may be nil;
+// without googleOAuth, guests can only upgrade with email and password.
+func NewGuestService(users *data.UserStore, jwtService *JWTService, emailService *EmailService, googleOAuth *GoogleOAuthService, ttl time.Duration) *GuestService {
+	return &GuestService{
+		users:        users,
+		jwtService:   jwtService,
+		emailService: emailService,
+		googleOAuth:  googleOAuth,
+		ttl:          ttl,
+	}
+}
+
+// SetInvites makes guest sessions subject to invites, which requires an
+// invite code when registration is invite-only.
+func (s *GuestService) SetInvites(invites *InviteService) {
+	s.invites = invites
+}
+
+// Create starts a guest session. inviteCode is checked only when
+// registration is invite-only; the guest keeps its admission when upgraded.
+func (s *GuestService) Create(ctx context.Context, inviteCode string) (*data.User, string, error) {
+	invite, err := s.invites.claim(ctx, inviteCode)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := s.users.CreateGuestUser(ctx, time.Now().Add(s.ttl), invite.Cohort())
+	if err != nil {
+		invite.release(ctx)
+		return nil, "", err
+	}
+	invite.redeemed(ctx, user.ID)
+	token, err := s.jwtService.GenerateToken(user.ID, "", user.Role)
+	if err != nil {
+		return nil, "", &TokenGenerationError{}
+	}
+	slog.Info("guest account created", "user_id", user.ID, "expires_at", user.GuestExpiresAt)
+	return user, token, nil
+}
+
+// Upgrade attaches an email and password to the guest and sends the usual
+// verification email. The returned session token carries the new email.
+func (s *GuestService) Upgrade(ctx context.Context, userID, email, password string) (*data.User, string, error) {
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, "", &util.ValidationError{Field: "email", Message: "invalid email format"}
+	}
+	if err := validatePasswordStrength(password); err != nil {
+		return nil, "", &util.ValidationError{Field: "password", Message: err.Error()}
+	}
+
+	verificationToken, err := s.users.UpgradeGuest(ctx, userID, email, password)
+	if err != nil {
+		return nil, "", upgradeError(err)
+	}
+	if s.emailService != nil {
+		if err := s.emailService.SendVerificationEmail(email, verificationToken); err != nil {
+			slog.Warn("send verification email failed", "err", err)
+		}
+	}
+	return s.upgraded(ctx, userID)
+}
+
+// UpgradeWithGoogle links the Google account behind idToken to the guest.
+func (s *GuestService) UpgradeWithGoogle(ctx context.Context, userID, idToken string) (*data.User, string, error) {
+	if s.googleOAuth == nil {
+		return nil, "", &InvalidCredentialsError{}
+	}
+	googleUser, err := s.googleOAuth.VerifyIDToken(ctx, idToken)
+	if err != nil || !googleUser.EmailVerified {
+		return nil, "", &InvalidCredentialsError{}
+	}
+
+	if err := s.users.UpgradeGuestWithGoogle(ctx, userID, googleUser.Email, googleUser.ID); err != nil {
+		return nil, "", upgradeError(err)
+	}
+	return s.upgraded(ctx, userID)
+}
+
+func (s *GuestService) upgraded(ctx context.Context, userID string) (*data.User, string, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", &UserNotFoundError{}
+	}
+	token, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, "", &TokenGenerationError{}
+	}
+	slog.Info("guest account upgraded", "user_id", user.ID)
+	return user, token, nil
+}
+
+func upgradeError(err error) error {
+	switch {
+	case errors.Is(err, data.ErrEmailTaken):
+		return &EmailExistsError{}
+	case errors.Is(err, data.ErrGoogleIDTaken):
+		return &GoogleAccountInUseError{}
+	case errors.Is(err, data.ErrNotGuest):
+		return &NotGuestError{}
+	}
+	return err
+}
+
+// PurgeExpired deletes expired guests and everything they own, in batches,
+// and returns how many were removed.
+func (s *GuestService) PurgeExpired(ctx context.Context) (int64, error) {
+	var total int64
+	for {
+		n, err := s.users.DeleteExpiredGuests(ctx, time.Now(), guestPurgeBatch)
+		total += n
+		if err != nil || n < guestPurgeBatch {
+			return total, err
+		}
+	}
+}
+
+// RunPurge calls PurgeExpired periodically until ctx is cancelled.
+func (s *GuestService) RunPurge(ctx context.Context) {
+	ticker := time.NewTicker(guestPurgeInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.PurgeExpired(ctx); err != nil {
+			slog.Error("guest purge failed", "err", err, "component", "guest")
+		} else if n > 0 {
+			slog.Info("expired guests purged", "count", n, "component", "guest")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+func newGuestService(t *testing.T) (*GuestService, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	jwtSvc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	svc := NewGuestService(data.NewUserStore(db), jwtSvc, nil, nil, 24*time.Hour)
+	return svc, mock, func() { db.Close() }
+}
+
+func TestGuestCreate(t *testing.T) {
+	svc, mock, cleanup := newGuestService(t)
+	defer cleanup()
+
+	expires := time.Now().Add(24 * time.Hour)
+	mock.ExpectExec("INSERT INTO users .* 'guest', TRUE").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WillReturnRows(userRow(data.User{ID: "guest-1", Balance: decimal.NewFromInt(10000), CreatedVia: "guest", IsGuest: true, GuestExpiresAt: ptr(expires)}))
+
+	user, token, err := svc.Create(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !user.IsGuest || user.GuestExpiresAt == nil || token == "" {
+		t.Errorf("expected guest with expiry and token, got %+v token=%q", user, token)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestGuestUpgrade_KeepsUserID(t *testing.T) {
+	svc, mock, cleanup := newGuestService(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE users\\s+SET email = \\$2, password = \\$3").
+		WithArgs("guest-1", "new@example.com", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WithArgs("guest-1").
+		WillReturnRows(userRow(data.User{ID: "guest-1", Email: "new@example.com", Password: "hash", Balance: decimal.NewFromInt(9500), VerificationToken: ptr("tok"), VerificationTokenExpires: ptr(time.Now()), CreatedVia: "guest"}))
+
+	user, token, err := svc.Upgrade(context.Background(), "guest-1", "New@Example.com", validPassword)
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	if user.ID != "guest-1" || user.IsGuest || token == "" {
+		t.Errorf("expected same user upgraded in place, got %+v", user)
+	}
+	claims, err := svc.jwtService.ValidateToken(token)
+	if err != nil || claims.Email != "new@example.com" {
+		t.Errorf("expected session token for new email, got %+v err=%v", claims, err)
+	}
+}
+
+func TestGuestUpgrade_EmailTaken(t *testing.T) {
+	svc, mock, cleanup := newGuestService(t)
+	defer cleanup()
+
+	mock.ExpectExec("UPDATE users").WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_key"})
+	_, _, err := svc.Upgrade(context.Background(), "guest-1", "taken@example.com", validPassword)
+	var target *EmailExistsError
+	if !errors.As(err, &target) {
+		t.Errorf("expected EmailExistsError, got %v", err)
+	}
+}
+
+func TestGuestUpgrade_NotGuest(t *testing.T) {
+	svc, mock, cleanup := newGuestService(t)
+	defer cleanup()
+
+	// A full account doesn't match "WHERE is_guest", so nothing is updated.
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, _, err := svc.Upgrade(context.Background(), "user-1", "a@example.com", validPassword)
+	var target *NotGuestError
+	if !errors.As(err, &target) {
+		t.Errorf("expected NotGuestError, got %v", err)
+	}
+}
+
+func TestGuestUpgrade_WeakPassword(t *testing.T) {
+	svc, _, cleanup := newGuestService(t)
+	defer cleanup()
+
+	_, _, err := svc.Upgrade(context.Background(), "guest-1", "a@example.com", "short")
+	var verr *util.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "password" {
+		t.Errorf("expected password validation error, got %v", err)
+	}
+}
+
+func TestGuestPurgeExpired_Batches(t *testing.T) {
+	svc, mock, cleanup := newGuestService(t)
+	defer cleanup()
+
+	for _, n := range []int64{guestPurgeBatch, 3} {
+		mock.ExpectBegin()
+		mock.ExpectExec("set_config\\('papertrader.purging_users'").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("WITH expired AS").WillReturnResult(sqlmock.NewResult(0, n))
+		mock.ExpectCommit()
+	}
+
+	n, err := svc.PurgeExpired(context.Background())
+	if err != nil || n != guestPurgeBatch+3 {
+		t.Errorf("expected %d purged, got %d err=%v", guestPurgeBatch+3, n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
@@ -15,6 +15,8 @@ import (
 // IsRangeEmpty / MarkRangeEmpty exist so weekend/holiday gap-fill calls don't
 // burn MarketStack quota every time the chart is loaded — once we've seen an
 // empty result for a range, we skip refetching it until the marker expires.
+// As with StockCache, a read error means the cache couldn't be reached and
+// is treated as a miss.
 type HistoricalCache interface {
 	GetHistorical(ctx context.Context, symbol, startDate, endDate string) (*HistoricalData, error)
 	SetHistorical(ctx context.Context, symbol, startDate, endDate string, data *HistoricalData, ttl time.Duration) error
@@ -28,17 +30,27 @@ type RedisHistoricalCache struct {
 	defaultTTL time.Duration
 }
 
-// NewRedisHistoricalCache creates a new Redis-based historical data cache
-func NewRedisHistoricalCache(client *redis.Client) *RedisHistoricalCache {
+// NewRedisHistoricalCache creates a new Redis-based historical data cache.
+// defaultTTL applies when SetHistorical is called with a zero ttl.
+func NewRedisHistoricalCache(client *redis.Client, defaultTTL time.Duration) *RedisHistoricalCache {
 	return &RedisHistoricalCache{
 		client:     client,
-		defaultTTL: 24 * time.Hour, // Daily cache
+		defaultTTL: defaultTTL,
 	}
 }
 
+func historicalKey(symbol, startDate, endDate string) string {
+	return fmt.Sprintf("historical:%s:%s:%s", symbol, startDate, endDate)
+}
+
+// historicalEmptyKey marks a range known to have no data (see MarkRangeEmpty).
+func historicalEmptyKey(symbol, startDate, endDate string) string {
+	return fmt.Sprintf("historical-empty:%s:%s:%s", symbol, startDate, endDate)
+}
+
 // GetHistorical retrieves historical data from Redis cache
 func (c *RedisHistoricalCache) GetHistorical(ctx context.Context, symbol, startDate, endDate string) (*HistoricalData, error) {
-	key := fmt.Sprintf("historical:%s:%s:%s", symbol, startDate, endDate)
+	key := historicalKey(symbol, startDate, endDate)
 
 	val, err := c.client.Get(ctx, key).Result()
 	if err != nil {
@@ -46,7 +58,7 @@ func (c *RedisHistoricalCache) GetHistorical(ctx context.Context, symbol, startD
 			// Cache miss - return nil, nil (not an error)
 			return nil, nil
 		}
-		// Redis error - log but don't fail, return nil to allow fallback
+		// Redis error - log and report it, so a fallback cache can answer
 		slog.Error("Redis error getting historical data",
 			"symbol", symbol,
 			"start_date", startDate,
@@ -54,7 +66,7 @@ func (c *RedisHistoricalCache) GetHistorical(ctx context.Context, symbol, startD
 			"err", err,
 			"component", "historical_cache",
 		)
-		return nil, nil
+		return nil, err
 	}
 
 	var historicalData HistoricalData
@@ -74,9 +86,10 @@ func (c *RedisHistoricalCache) GetHistorical(ctx context.Context, symbol, startD
 
 // IsRangeEmpty reports whether a recent fetch for this range returned zero
 // rows and is still considered fresh enough to skip. Returns false for cache
-// miss or any Redis error — both interpreted as "not known empty, go fetch".
+// miss or any Redis error — both interpreted as "not known empty, go fetch" —
+// with the error in the second case.
 func (c *RedisHistoricalCache) IsRangeEmpty(ctx context.Context, symbol, startDate, endDate string) (bool, error) {
-	key := fmt.Sprintf("historical-empty:%s:%s:%s", symbol, startDate, endDate)
+	key := historicalEmptyKey(symbol, startDate, endDate)
 	_, err := c.client.Get(ctx, key).Result()
 	if err == redis.Nil {
 		return false, nil
@@ -86,7 +99,7 @@ func (c *RedisHistoricalCache) IsRangeEmpty(ctx context.Context, symbol, startDa
 			"symbol", symbol, "start_date", startDate, "end_date", endDate, "err", err,
 			"component", "historical_cache",
 		)
-		return false, nil
+		return false, err
 	}
 	return true, nil
 }
@@ -98,7 +111,7 @@ func (c *RedisHistoricalCache) MarkRangeEmpty(ctx context.Context, symbol, start
 	if ttl == 0 {
 		ttl = 6 * time.Hour
 	}
-	key := fmt.Sprintf("historical-empty:%s:%s:%s", symbol, startDate, endDate)
+	key := historicalEmptyKey(symbol, startDate, endDate)
 	if err := c.client.Set(ctx, key, "1", ttl).Err(); err != nil {
 		slog.Error("failed to mark range empty",
 			"symbol", symbol, "start_date", startDate, "end_date", endDate, "err", err,
@@ -115,7 +128,7 @@ func (c *RedisHistoricalCache) SetHistorical(ctx context.Context, symbol, startD
 		ttl = c.defaultTTL
 	}
 
-	key := fmt.Sprintf("historical:%s:%s:%s", symbol, startDate, endDate)
+	key := historicalKey(symbol, startDate, endDate)
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"papertrader/internal/data"
+)
+
+// HoldingsQuota caps how many distinct symbols a user may hold, long or
+// short, to bound per-user portfolio size. As a PreTradeCheck it rejects a
+// buy or short that would open a new position once the cap is reached;
+// adding to an existing position, selling and covering are always allowed.
+type HoldingsQuota struct {
+	portfolio *data.PortfolioStore
+	max       int
+}
+
+// NewHoldingsQuota builds the check. max <= 0 disables it.
+func NewHoldingsQuota(portfolio *data.PortfolioStore, max int) *HoldingsQuota {
+	return &HoldingsQuota{portfolio: portfolio, max: max}
+}
+
+// CheckTrade implements PreTradeCheck. The count is read outside the trade
+// transaction, so two concurrent buys of new symbols can overshoot the cap by
+// one; it is a soft limit.
+func (q *HoldingsQuota) CheckTrade(ctx context.Context, intent TradeIntent) error {
+	if q.max <= 0 || (intent.Action != "BUY" && intent.Action != "SHORT") {
+		return nil
+	}
+	holding, err := q.portfolio.GetPortfolioBySymbol(ctx, intent.UserID, intent.Symbol)
+	switch {
+	case err == nil && !holding.Quantity.IsZero():
+		return nil
+	case err != nil && !errors.Is(err, data.ErrStockHoldingNotFound):
+		return err
+	}
+	n, err := q.portfolio.CountPositions(ctx, intent.UserID)
+	if err != nil {
+		return err
+	}
+	if n >= q.max {
+		return &HoldingsLimitError{Limit: q.max}
+	}
+	return nil
+}
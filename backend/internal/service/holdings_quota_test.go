@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func TestHoldingsQuota(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	q := NewHoldingsQuota(data.NewPortfolioStore(db), 2)
+	ctx := context.Background()
+	intent := func(action, symbol string) TradeIntent {
+		return TradeIntent{UserID: "user-1", Symbol: symbol, Action: action, Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(100)}
+	}
+
+	// Closing trades never touch the store.
+	for _, action := range []string{"SELL", "COVER"} {
+		if err := q.CheckTrade(ctx, intent(action, "AAPL")); err != nil {
+			t.Errorf("%s: got %v", action, err)
+		}
+	}
+
+	// Adding to a held symbol is allowed at the cap.
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2").WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
+			"port-1", "user-1", "AAPL", 5, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now()))
+	if err := q.CheckTrade(ctx, intent("BUY", "AAPL")); err != nil {
+		t.Errorf("buy of held symbol: got %v", err)
+	}
+
+	// A new symbol at the cap is rejected.
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2").WithArgs("user-1", "TSLA").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	var le *HoldingsLimitError
+	if err := q.CheckTrade(ctx, intent("SHORT", "TSLA")); !errors.As(err, &le) || le.Limit != 2 {
+		t.Errorf("short of new symbol: got %v, want HoldingsLimitError{2}", err)
+	}
+
+	// Disabled: no lookups at all.
+	if err := NewHoldingsQuota(nil, 0).CheckTrade(ctx, intent("BUY", "TSLA")); err != nil {
+		t.Errorf("disabled: got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// instrumentRefreshAge is how long a stored instrument is trusted before
+// Resolve re-asks the provider. Bounds how late an automatic halt can land.
+const instrumentRefreshAge = 24 * time.Hour
+
+// maxHaltReasonLength caps the admin-supplied halt reason, which is shown to
+// users in errors and notifications.
+const maxHaltReasonLength = 200
+
+// InstrumentLookup resolves reference data for a symbol from the market data
+// provider. Satisfied by *MarketService.
+type InstrumentLookup interface {
+	LookupInstrument(ctx context.Context, symbol string) (*data.Instrument, error)
+}
+
+// InstrumentService owns the instruments table: lazy population from the
+// provider, and per-symbol trading halts (admin-set or provider-driven).
+type InstrumentService struct {
+	store          *data.InstrumentStore
+	lookup         InstrumentLookup
+	portfolioStore *data.PortfolioStore
+	notifications  *NotificationService
+}
+
+// NewInstrumentService wires the service. lookup, portfolioStore and
+// notifications may be nil (no provider refresh / no holder notifications).
+func NewInstrumentService(store *data.InstrumentStore, lookup InstrumentLookup, portfolioStore *data.PortfolioStore, notifications *NotificationService) *InstrumentService {
+	return &InstrumentService{
+		store:          store,
+		lookup:         lookup,
+		portfolioStore: portfolioStore,
+		notifications:  notifications,
+	}
+}
+
+// Resolve returns the stored instrument, fetching it from the provider when
+// missing or older than instrumentRefreshAge. A refresh also applies the
+// provider's trading status: a suspended symbol is halted automatically, and a
+// provider halt is lifted once the provider reports the symbol trading again.
+// A failed refresh falls back to the stale row when there is one.
+func (s *InstrumentService) Resolve(ctx context.Context, symbol string) (*data.Instrument, error) {
+	stored, err := s.store.GetInstrument(ctx, symbol)
+	if err != nil && !errors.Is(err, data.ErrInstrumentNotFound) {
+		return nil, err
+	}
+	if stored != nil && time.Since(stored.UpdatedAt) < instrumentRefreshAge {
+		return stored, nil
+	}
+	if s.lookup == nil {
+		if stored == nil {
+			return nil, err
+		}
+		return stored, nil
+	}
+
+	fetched, lerr := s.lookup.LookupInstrument(ctx, symbol)
+	if lerr != nil {
+		if stored != nil {
+			slog.Warn("instrument refresh failed; using stored row",
+				"symbol", symbol, "err", lerr, "component", "instrument")
+			return stored, nil
+		}
+		return nil, lerr
+	}
+	if err := s.store.UpsertInstrument(ctx, fetched); err != nil {
+		slog.Warn("failed to persist instrument", "symbol", symbol, "err", err, "component", "instrument")
+		return fetched, nil
+	}
+
+	if fetched.Halted {
+		if err := s.setHalt(ctx, symbol, fetched.HaltReason, data.HaltSourceProvider); err != nil {
+			slog.Warn("failed to apply provider halt", "symbol", symbol, "err", err, "component", "instrument")
+		}
+	} else if stored != nil && stored.Halted && stored.HaltSource == data.HaltSourceProvider {
+		if err := s.clearHalt(ctx, symbol, data.HaltSourceProvider); err != nil {
+			slog.Warn("failed to lift provider halt", "symbol", symbol, "err", err, "component", "instrument")
+		}
+	}
+
+	return s.store.GetInstrument(ctx, symbol)
+}
+
+// IsHalted reports whether trading in symbol is halted, reading only the
+// stored state (no provider call). Unknown symbols are not halted.
+func (s *InstrumentService) IsHalted(ctx context.Context, symbol string) (bool, error) {
+	inst, err := s.store.GetInstrument(ctx, symbol)
+	if err != nil {
+		if errors.Is(err, data.ErrInstrumentNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return inst.Halted, nil
+}
+
+// CheckTrade implements PreTradeCheck: orders on a halted symbol are rejected
+// in both directions. Lookup failures fail open so a provider outage doesn't
+// stop all trading.
+func (s *InstrumentService) CheckTrade(ctx context.Context, intent TradeIntent) error {
+	inst, err := s.Resolve(ctx, intent.Symbol)
+	if err != nil {
+		slog.Warn("instrument resolve failed; skipping halt check",
+			"symbol", intent.Symbol, "err", err, "component", "instrument")
+		return nil
+	}
+	if inst.Halted {
+		return &TradingHaltedError{Symbol: inst.Symbol, Reason: inst.HaltReason}
+	}
+	return nil
+}
+
+// Halt places an admin halt on symbol and notifies its holders.
+func (s *InstrumentService) Halt(ctx context.Context, symbol, reason string) (*data.Instrument, error) {
+	symbol, err := util.ValidateSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	reason = strings.TrimSpace(util.SanitizeString(reason))
+	if len(reason) > maxHaltReasonLength {
+		return nil, &util.ValidationError{Field: "reason", Message: fmt.Sprintf("must be at most %d characters", maxHaltReasonLength)}
+	}
+	if err := s.setHalt(ctx, symbol, reason, data.HaltSourceAdmin); err != nil {
+		return nil, err
+	}
+	return s.store.GetInstrument(ctx, symbol)
+}
+
+// Resume lifts any halt on symbol, regardless of who placed it, and notifies
+// holders. Resuming a symbol that is not halted is a no-op.
+func (s *InstrumentService) Resume(ctx context.Context, symbol string) (*data.Instrument, error) {
+	symbol, err := util.ValidateSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.clearHalt(ctx, symbol, ""); err != nil {
+		return nil, err
+	}
+	inst, err := s.store.GetInstrument(ctx, symbol)
+	if errors.Is(err, data.ErrInstrumentNotFound) {
+		return nil, &InstrumentNotFoundError{}
+	}
+	return inst, err
+}
+
+// ListHalted returns every halted instrument.
+func (s *InstrumentService) ListHalted(ctx context.Context) ([]data.Instrument, error) {
+	return s.store.ListHalted(ctx)
+}
+
+func (s *InstrumentService) setHalt(ctx context.Context, symbol, reason, source string) error {
+	transitioned, err := s.store.SetHalt(ctx, symbol, reason, source)
+	if err != nil {
+		return err
+	}
+	slog.Info("trading halted", "symbol", symbol, "source", source, "reason", reason, "new", transitioned)
+	if transitioned {
+		body := "New orders are blocked until trading resumes."
+		if reason != "" {
+			body = "Reason: " + reason + ". " + body
+		}
+		s.notifyHolders(ctx, symbol, NotificationTradingHalted, "Trading halted in "+symbol, body)
+	}
+	return nil
+}
+
+func (s *InstrumentService) clearHalt(ctx context.Context, symbol, source string) error {
+	resumed, err := s.store.ClearHalt(ctx, symbol, source)
+	if err != nil {
+		return err
+	}
+	if resumed {
+		slog.Info("trading resumed", "symbol", symbol)
+		s.notifyHolders(ctx, symbol, NotificationTradingResumed, "Trading resumed in "+symbol,
+			"Orders for "+symbol+" are accepted again.")
+	}
+	return nil
+}
+
+// notifyHolders is best-effort: the halt itself has already been recorded,
+// so a failure here is logged rather than returned.
+func (s *InstrumentService) notifyHolders(ctx context.Context, symbol, kind, title, body string) {
+	if s.portfolioStore == nil || s.notifications == nil {
+		return
+	}
+	holders, err := s.portfolioStore.GetHolderIDsBySymbol(ctx, symbol)
+	if err != nil {
+		slog.Warn("failed to load holders for notification", "symbol", symbol, "err", err, "component", "instrument")
+		return
+	}
+	if err := s.notifications.Notify(ctx, holders, kind, title, body); err != nil {
+		slog.Warn("failed to notify holders", "symbol", symbol, "holders", len(holders), "err", err, "component", "instrument")
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+func haltedInstrumentRow(symbol, reason, source string) *sqlmock.Rows {
+	return sqlmock.NewRows(instrumentCols).
+		AddRow(symbol, symbol+" Inc", "XNAS", "stock", "USD", time.Now(), time.Now(), true, reason, source, time.Now(), "0.01", 2)
+}
+
+func newTestInstrumentService(db *sql.DB, lookup InstrumentLookup) *InstrumentService {
+	return NewInstrumentService(data.NewInstrumentStore(db), lookup, data.NewPortfolioStore(db),
+		NewNotificationService(data.NewNotificationStore(db)))
+}
+
+func TestInstrumentCheckTrade_RejectsHaltedSymbol(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("GME").
+		WillReturnRows(haltedInstrumentRow("GME", "volatility", data.HaltSourceAdmin))
+
+	sell := buyIntent("GME", 20)
+	sell.Action = "SELL"
+	err = newTestInstrumentService(db, nil).CheckTrade(context.Background(), sell)
+	var halted *TradingHaltedError
+	if !errors.As(err, &halted) {
+		t.Fatalf("expected TradingHaltedError, got %v", err)
+	}
+	if halted.ErrorCode() != "HALTED" {
+		t.Errorf("error code: got %q, want HALTED", halted.ErrorCode())
+	}
+}
+
+func TestInstrumentHalt_NotifiesHoldersOnTransition(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO instruments").
+		WithArgs("GME", "volatility", data.HaltSourceAdmin).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id FROM portfolio").
+		WithArgs("GME").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1").AddRow("user-2"))
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(NotificationTradingHalted, "Trading halted in GME", sqlmock.AnyArg(),
+			sqlmock.AnyArg(), "user-1", sqlmock.AnyArg(), "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("GME").
+		WillReturnRows(haltedInstrumentRow("GME", "volatility", data.HaltSourceAdmin))
+
+	inst, err := newTestInstrumentService(db, nil).Halt(context.Background(), "gme", "  volatility ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inst.Halted {
+		t.Error("expected halted instrument")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestInstrumentHalt_AlreadyHaltedDoesNotRenotify(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO instruments").
+		WithArgs("GME", "", data.HaltSourceAdmin).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(true))
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("GME").
+		WillReturnRows(haltedInstrumentRow("GME", "", data.HaltSourceAdmin))
+
+	if _, err := newTestInstrumentService(db, nil).Halt(context.Background(), "GME", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestInstrumentResolve_AppliesProviderSuspension(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("SUSP").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO instruments").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO instruments").
+		WithArgs("SUSP", "suspended by the data provider", data.HaltSourceProvider).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id FROM portfolio").
+		WithArgs("SUSP").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("SUSP").
+		WillReturnRows(haltedInstrumentRow("SUSP", "suspended by the data provider", data.HaltSourceProvider))
+
+	lookup := &fakeLookup{inst: &data.Instrument{
+		Symbol: "SUSP", Exchange: "XNAS", AssetType: "stock",
+		Halted: true, HaltReason: "suspended by the data provider",
+	}}
+	inst, err := newTestInstrumentService(db, lookup).Resolve(context.Background(), "SUSP")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !inst.Halted || inst.HaltSource != data.HaltSourceProvider {
+		t.Errorf("expected provider halt, got halted=%v source=%q", inst.Halted, inst.HaltSource)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
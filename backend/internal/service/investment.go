@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -22,25 +23,184 @@ type MarketPricer interface {
 	GetBatchHistoricalData(ctx context.Context, symbols []string) (map[string]*HistoricalData, error)
 }
 
+// TradeIntent describes an order after its quote has been fetched but before
+// any balance or holding has been touched.
+type TradeIntent struct {
+	UserID   string
+	Symbol   string
+	Action   string // "BUY", "SELL", "SHORT" or "COVER"
+	Quantity decimal.Decimal
+	Price    decimal.Decimal
+}
+
+// PreTradeCheck is a policy hook run by BuyStock/SellStock before the trade
+// transaction opens. Returning an error rejects the order; service errors that
+// implement util.HTTPError reach the client as-is.
+type PreTradeCheck interface {
+	CheckTrade(ctx context.Context, intent TradeIntent) error
+}
+
+// TxTradeCheck is a PreTradeCheck that is run again inside the trade's
+// transaction, before anything is written, for a rule that concurrent trades
+// could otherwise all pass at once. Returning an error rolls the trade back.
+type TxTradeCheck interface {
+	CheckTradeTx(ctx context.Context, tx *sql.Tx, intent TradeIntent) error
+}
+
+// TradeExecution describes a trade after its transaction has committed.
+// Realized is set for sells: the gain drawn from the tax lots under the
+// user's cost-basis method.
+type TradeExecution struct {
+	TradeIntent
+	TradeID       string
+	Total         decimal.Decimal
+	BalanceBefore decimal.Decimal
+	BalanceAfter  decimal.Decimal
+	Realized      *decimal.Decimal
+}
+
+// TradeObserver is told about every committed trade. Observers run on the
+// request path and cannot fail the trade; anything slow should be handed off
+// to a goroutine.
+type TradeObserver interface {
+	TradeExecuted(ctx context.Context, exec TradeExecution)
+}
+
 type InvestmentService struct {
 	db             *sql.DB
 	marketService  MarketPricer
 	portfolioStore *data.PortfolioStore
 	tradesStore    *data.TradesStore
+	checks         []PreTradeCheck
+	observers      []TradeObserver
+	maxQuantity    int             // per-order share cap; 0 = no cap
+	shortMargin    decimal.Decimal // collateral a short posts from cash, as a fraction of its value
+	slippage       decimal.Decimal // adverse fill adjustment, as a fraction of the quote; 0 = fill at the quote
+	precision      PrecisionSource // nil = every symbol on DefaultPrecision
+	staleQuoteAge  time.Duration   // oldest stale quote a trade fills at; 0 = none
 }
 
-func NewInvestmentService(db *sql.DB, marketService MarketPricer, portfolioStore *data.PortfolioStore, tradesStore *data.TradesStore) *InvestmentService {
+// defaultShortMargin is Regulation T's 50% initial margin.
+var defaultShortMargin = decimal.RequireFromString("0.5")
+
+func NewInvestmentService(db *sql.DB, marketService MarketPricer, portfolioStore *data.PortfolioStore, tradesStore *data.TradesStore, checks ...PreTradeCheck) *InvestmentService {
 	return &InvestmentService{
 		db:             db,
 		marketService:  marketService,
 		portfolioStore: portfolioStore,
 		tradesStore:    tradesStore,
+		checks:         checks,
+		shortMargin:    defaultShortMargin,
 	}
 }
 
-func (s *InvestmentService) BuyStock(ctx context.Context, userID string, symbol string, quantity int, idempotencyKey string) (*data.UserStock, error) {
+// AddObservers registers observers to be told about every committed trade.
+// Call during wiring, before the service handles requests.
+func (s *InvestmentService) AddObservers(observers ...TradeObserver) {
+	s.observers = append(s.observers, observers...)
+}
+
+// SetMaxQuantity caps the number of shares in a single order. Call during
+// wiring, before the service handles requests.
+func (s *InvestmentService) SetMaxQuantity(n int) {
+	s.maxQuantity = n
+}
+
+// SetShortMarginPct sets the collateral a short sale must post from the
+// user's balance, as a percentage of the sale value. Call during wiring,
+// before the service handles requests.
+func (s *InvestmentService) SetShortMarginPct(pct decimal.Decimal) {
+	s.shortMargin = pct.Div(decimal.NewFromInt(100))
+}
+
+// SetSlippagePct turns on the simulated spread: buys and covers fill pct
+// percent above the quote, sells and shorts pct percent below it. 0 fills at
+// the quote. Call during wiring, before the service handles requests.
+func (s *InvestmentService) SetSlippagePct(pct decimal.Decimal) {
+	s.slippage = pct.Div(decimal.NewFromInt(100))
+}
+
+// SetPrecision sets where fills look up each symbol's tick size. Call during
+// wiring, before the service handles requests.
+func (s *InvestmentService) SetPrecision(src PrecisionSource) {
+	s.precision = src
+}
+
+// SetStaleQuoteMaxAge lets trades fill at a stale quote, the last known
+// price served while the provider is down, fetched at most maxAge ago. 0,
+// the default, refuses every stale quote. Call during wiring, before the
+// service handles requests.
+func (s *InvestmentService) SetStaleQuoteMaxAge(maxAge time.Duration) {
+	s.staleQuoteAge = maxAge
+}
+
+// tradeQuote returns the quote symbol trades at, or StaleQuoteError when
+// the only price is a stale one older than the service allows: anyone who
+// can see the real price could trade against it.
+func (s *InvestmentService) tradeQuote(ctx context.Context, symbol string) (*StockData, error) {
+	quote, err := s.marketService.GetStock(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if quote.Stale && (quote.FetchedAt == nil || time.Since(*quote.FetchedAt) > s.staleQuoteAge) {
+		return nil, &StaleQuoteError{Symbol: symbol}
+	}
+	return quote, nil
+}
+
+// precisionOf returns the precision symbol trades at.
+func (s *InvestmentService) precisionOf(ctx context.Context, symbol string) Precision {
+	if s.precision == nil {
+		return DefaultPrecision
+	}
+	return s.precision.Precision(ctx, symbol)
+}
+
+// fillPrice is the price an action fills at given quote, rounded to the
+// nearest tick of prec, and the per-share adjustment from the quote
+// recorded on the trade as its slippage.
+func (s *InvestmentService) fillPrice(action string, quote decimal.Decimal, prec Precision) (price, slippage decimal.Decimal) {
+	adjustment := quote.Mul(s.slippage)
+	if action == "SELL" || action == "SHORT" {
+		adjustment = adjustment.Neg()
+	}
+	price = prec.Round(quote.Add(adjustment))
+	return price, price.Sub(quote)
+}
+
+func (s *InvestmentService) notifyObservers(ctx context.Context, exec TradeExecution) {
+	for _, o := range s.observers {
+		o.TradeExecuted(ctx, exec)
+	}
+}
+
+// runTxTradeChecks runs, inside tx, every configured check that is a
+// TxTradeCheck and returns the first rejection.
+func (s *InvestmentService) runTxTradeChecks(ctx context.Context, tx *sql.Tx, intent TradeIntent) error {
+	for _, check := range s.checks {
+		if txCheck, ok := check.(TxTradeCheck); ok {
+			if err := txCheck.CheckTradeTx(ctx, tx, intent); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runPreTradeChecks evaluates every configured check in order and returns the
+// first rejection.
+func (s *InvestmentService) runPreTradeChecks(ctx context.Context, intent TradeIntent) error {
+	for _, check := range s.checks {
+		if err := check.CheckTrade(ctx, intent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *InvestmentService) BuyStock(ctx context.Context, userID string, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
 	// Validate quantity (defense in depth)
-	if err := util.ValidateQuantity(quantity); err != nil {
+	if err := util.ValidateQuantity(quantity, s.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
 		return nil, err
 	}
 
@@ -56,44 +216,23 @@ func (s *InvestmentService) BuyStock(ctx context.Context, userID string, symbol
 	}
 
 	// 1. Get Stock Price from MarketService (Redis-backed)
-	stockData, err := s.marketService.GetStock(ctx, symbol)
+	stockData, err := s.tradeQuote(ctx, symbol)
 	if err != nil {
 		return nil, err
 	}
-	price := stockData.Price
-	totalPrice := price.Mul(decimal.NewFromInt(int64(quantity)))
+	price, slippage := s.fillPrice("BUY", stockData.Price, s.precisionOf(ctx, stockData.Symbol))
 
-	// 2. Start PostgreSQL Transaction (ACID - all operations atomic)
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
+	if err := s.runPreTradeChecks(ctx, TradeIntent{
+		UserID:   userID,
+		Symbol:   symbol,
+		Action:   "BUY",
+		Quantity: quantity,
+		Price:    price,
+	}); err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
-
-	userStoreTx := data.NewUserStore(tx)
-	tradeStoreTx := data.NewTradesStore(tx)
-	portfolioStoreTx := data.NewPortfolioStore(tx)
 
-	// 3. Get User Balance with row lock and Validate.
-	// FOR UPDATE prevents two concurrent buys from both reading the same balance
-	// and both passing the funds check; the second buy now blocks until the
-	// first's tx commits or rolls back.
-	balance, err := userStoreTx.GetBalanceForUpdate(ctx, userID)
-	if err != nil {
-		return nil, err
-	}
-
-	if balance.LessThan(totalPrice) {
-		return nil, &InsufficientFundsError{}
-	}
-
-	// 4. Deduct Balance
-	newBalance := balance.Sub(totalPrice)
-	if err := userStoreTx.UpdateBalance(ctx, userID, newBalance); err != nil {
-		return nil, err
-	}
-
-	// 5. Create Trade — executed_at is filled by the DB default.
+	// 2-7. Lock and debit the balance, record the trade, add to the holding.
 	trade := &data.Trade{
 		ID:             uuid.New().String(),
 		UserID:         userID,
@@ -103,14 +242,14 @@ func (s *InvestmentService) BuyStock(ctx context.Context, userID string, symbol
 		Price:          price,
 		Status:         "COMPLETED",
 		IdempotencyKey: idempotencyKey,
+		OrderType:      data.OrderTypeMarket,
+		Slippage:       slippage,
 	}
-
-	if err := tradeStoreTx.CreateTrade(ctx, trade); err != nil {
+	if err := s.executeBuy(ctx, trade, nil); err != nil {
 		// Unique violation on idempotency key — concurrent retry won the race.
-		// Roll back and return the existing trade result.
+		// executeBuy has already rolled back; return the existing trade result.
 		var pqErr *pq.Error
 		if idempotencyKey != "" && errors.As(err, &pqErr) && pqErr.Code == "23505" {
-			tx.Rollback()
 			existing, fetchErr := s.tradesStore.GetTradeByIdempotencyKey(ctx, userID, idempotencyKey)
 			if fetchErr != nil {
 				return nil, fetchErr
@@ -127,18 +266,100 @@ func (s *InvestmentService) BuyStock(ctx context.Context, userID string, symbol
 		return nil, err
 	}
 
+	// 8. Fetch updated portfolio for response
+	userStock, err := s.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
+	if err != nil {
+		// If not found after update, create a response object
+		userStock = &data.UserStock{
+			UserID:            userID,
+			Symbol:            symbol,
+			Quantity:          quantity,
+			AvgPrice:          price,
+			Total:             price.Mul(quantity),
+			CurrentStockPrice: stockData.Price,
+		}
+	} else {
+		// Add current stock price to response
+		userStock.CurrentStockPrice = stockData.Price
+		userStock.Total = userStock.AvgPrice.Mul(userStock.Quantity)
+	}
+	userStock.Stale = stockData.Stale
+
+	return userStock, nil
+}
+
+// executeBuy runs the buy transaction for trade: lock and debit the balance,
+// record the trade and add to the holding. claim, if set, runs first inside
+// the same transaction; an error from it aborts the purchase. Errors from
+// CreateTrade are returned unwrapped so callers can spot idempotency-key
+// conflicts.
+func (s *InvestmentService) executeBuy(ctx context.Context, trade *data.Trade, claim func(tx *sql.Tx) error) error {
+	userID, symbol, quantity, price := trade.UserID, trade.Symbol, trade.Quantity, trade.Price
+	totalPrice := price.Mul(quantity).Round(2)
+
+	// 2. Start PostgreSQL Transaction (ACID - all operations atomic)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if claim != nil {
+		if err := claim(tx); err != nil {
+			return err
+		}
+	}
+	if err := s.runTxTradeChecks(ctx, tx, TradeIntent{UserID: userID, Symbol: symbol, Action: trade.Action, Quantity: quantity, Price: price}); err != nil {
+		return err
+	}
+
+	userStoreTx := data.NewUserStore(tx)
+	tradeStoreTx := data.NewTradesStore(tx)
+	portfolioStoreTx := data.NewPortfolioStore(tx)
+
+	// 3. Get User Balance with row lock and Validate.
+	// FOR UPDATE prevents two concurrent buys from both reading the same balance
+	// and both passing the funds check; the second buy now blocks until the
+	// first's tx commits or rolls back.
+	balance, err := userStoreTx.GetBalanceForUpdate(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if balance.LessThan(totalPrice) {
+		return &InsufficientFundsError{}
+	}
+
+	// 4. Deduct Balance
+	newBalance := balance.Sub(totalPrice)
+	if err := userStoreTx.UpdateBalance(ctx, userID, newBalance); err != nil {
+		return err
+	}
+
+	// 5. Create Trade — executed_at is filled by the DB default.
+	if err := tradeStoreTx.CreateTrade(ctx, trade); err != nil {
+		return err
+	}
+
 	// 6. Update Portfolio (all in same transaction)
 	if err := portfolioStoreTx.UpdatePortfolioWithBuy(ctx, userID, symbol, quantity, price); err != nil {
-		return nil, err
+		if errors.Is(err, data.ErrShortPositionOpen) {
+			return &ShortPositionOpenError{}
+		}
+		return err
+	}
+	if err := openLot(ctx, tx, trade); err != nil {
+		return err
 	}
 
 	// 7. Commit Transaction (all or nothing)
 	if err := tx.Commit(); err != nil {
-		return nil, err
+		return err
 	}
 
 	slog.Info("trade executed",
 		"action", "BUY",
+		"order_type", trade.OrderType,
 		"user_id", userID,
 		"symbol", symbol,
 		"quantity", quantity,
@@ -146,25 +367,15 @@ func (s *InvestmentService) BuyStock(ctx context.Context, userID string, symbol
 		"new_balance", newBalance,
 	)
 
-	// 8. Fetch updated portfolio for response
-	userStock, err := s.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
-	if err != nil {
-		// If not found after update, create a response object
-		userStock = &data.UserStock{
-			UserID:            userID,
-			Symbol:            symbol,
-			Quantity:          quantity,
-			AvgPrice:          price,
-			Total:             totalPrice,
-			CurrentStockPrice: price,
-		}
-	} else {
-		// Add current stock price to response
-		userStock.CurrentStockPrice = price
-		userStock.Total = userStock.AvgPrice.Mul(decimal.NewFromInt(int64(userStock.Quantity)))
-	}
+	s.notifyObservers(ctx, TradeExecution{
+		TradeIntent:   TradeIntent{UserID: userID, Symbol: symbol, Action: "BUY", Quantity: quantity, Price: price},
+		TradeID:       trade.ID,
+		Total:         totalPrice,
+		BalanceBefore: balance,
+		BalanceAfter:  newBalance,
+	})
 
-	return userStock, nil
+	return nil
 }
 
 // buildBuyReplay fetches current portfolio state for a previously-recorded BUY
@@ -182,19 +393,19 @@ func (s *InvestmentService) buildBuyReplay(ctx context.Context, userID string, t
 			Symbol:            trade.Symbol,
 			Quantity:          trade.Quantity,
 			AvgPrice:          trade.Price,
-			Total:             trade.Price.Mul(decimal.NewFromInt(int64(trade.Quantity))),
+			Total:             trade.Price.Mul(trade.Quantity),
 			CurrentStockPrice: trade.Price,
 		}
 	} else {
 		userStock.CurrentStockPrice = trade.Price
-		userStock.Total = userStock.AvgPrice.Mul(decimal.NewFromInt(int64(userStock.Quantity)))
+		userStock.Total = userStock.AvgPrice.Mul(userStock.Quantity)
 	}
 	return userStock, nil
 }
 
-func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol string, quantity int, idempotencyKey string) (*data.UserStock, error) {
+func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
 	// Validate quantity (defense in depth)
-	if err := util.ValidateQuantity(quantity); err != nil {
+	if err := util.ValidateQuantity(quantity, s.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
 		return nil, err
 	}
 
@@ -210,12 +421,87 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 	}
 
 	// 1. Get Stock Price from MarketService (Redis-backed)
-	stockData, err := s.marketService.GetStock(ctx, symbol)
+	stockData, err := s.tradeQuote(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	price, slippage := s.fillPrice("SELL", stockData.Price, s.precisionOf(ctx, stockData.Symbol))
+
+	if err := s.runPreTradeChecks(ctx, TradeIntent{
+		UserID:   userID,
+		Symbol:   symbol,
+		Action:   "SELL",
+		Quantity: quantity,
+		Price:    price,
+	}); err != nil {
+		return nil, err
+	}
+
+	// 2-7. Lock, credit the proceeds, record the trade, decrement the holding.
+	trade := &data.Trade{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Symbol:         symbol,
+		Action:         "SELL",
+		Quantity:       quantity,
+		Price:          price,
+		Status:         "COMPLETED",
+		IdempotencyKey: idempotencyKey,
+		OrderType:      data.OrderTypeMarket,
+		Slippage:       slippage,
+	}
+	existingHolding, err := s.executeSell(ctx, trade, nil)
 	if err != nil {
+		// Unique violation on idempotency key — concurrent retry won the race.
+		// executeSell has already rolled back.
+		var pqErr *pq.Error
+		if idempotencyKey != "" && errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			existing, fetchErr := s.tradesStore.GetTradeByIdempotencyKey(ctx, userID, idempotencyKey)
+			if fetchErr != nil {
+				return nil, fetchErr
+			}
+			if existing != nil {
+				return s.buildSellReplay(ctx, userID, existing)
+			}
+			// See BuyStock for rationale on the wrapped error.
+			return nil, fmt.Errorf("idempotency conflict but no prior trade found: %w", err)
+		}
 		return nil, err
 	}
-	price := stockData.Price
-	totalPrice := price.Mul(decimal.NewFromInt(int64(quantity)))
+
+	// 8. Fetch updated portfolio for response
+	userStock, err := s.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
+	if err != nil {
+		if err == data.ErrStockHoldingNotFound {
+			// Portfolio was deleted (quantity reached 0), return empty state
+			userStock = &data.UserStock{
+				UserID:            userID,
+				Symbol:            symbol,
+				Quantity:          decimal.Zero,
+				AvgPrice:          existingHolding.AvgPrice,
+				Total:             decimal.Zero,
+				CurrentStockPrice: stockData.Price,
+			}
+		} else {
+			return nil, err
+		}
+	} else {
+		userStock.CurrentStockPrice = stockData.Price
+		userStock.Total = userStock.AvgPrice.Mul(userStock.Quantity)
+	}
+	userStock.Stale = stockData.Stale
+
+	return userStock, nil
+}
+
+// executeSell runs the sell transaction for trade: lock the holding, credit
+// the proceeds, record the trade and decrement the holding. claim, if set,
+// runs first inside the same transaction; an error from it aborts the sale.
+// Returns the holding as it was before the sale. Errors from CreateTrade are
+// returned unwrapped so callers can spot idempotency-key conflicts.
+func (s *InvestmentService) executeSell(ctx context.Context, trade *data.Trade, claim func(tx *sql.Tx) error) (*data.UserStock, error) {
+	userID, symbol, quantity, price := trade.UserID, trade.Symbol, trade.Quantity, trade.Price
+	totalPrice := price.Mul(quantity).Round(2)
 
 	// 2. Start PostgreSQL Transaction (ACID - all operations atomic)
 	tx, err := s.db.BeginTx(ctx, nil)
@@ -224,6 +510,15 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 	}
 	defer tx.Rollback()
 
+	if claim != nil {
+		if err := claim(tx); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.runTxTradeChecks(ctx, tx, TradeIntent{UserID: userID, Symbol: symbol, Action: trade.Action, Quantity: quantity, Price: price}); err != nil {
+		return nil, err
+	}
+
 	userStoreTx := data.NewUserStore(tx)
 	tradeStoreTx := data.NewTradesStore(tx)
 	portfolioStoreTx := data.NewPortfolioStore(tx)
@@ -238,7 +533,7 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 		return nil, err
 	}
 
-	if existingHolding.Quantity < quantity {
+	if existingHolding.Quantity.LessThan(quantity) {
 		return nil, &InsufficientStockError{}
 	}
 
@@ -257,32 +552,7 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 	}
 
 	// 5. Create Trade — executed_at is filled by the DB default.
-	trade := &data.Trade{
-		ID:             uuid.New().String(),
-		UserID:         userID,
-		Symbol:         symbol,
-		Action:         "SELL",
-		Quantity:       quantity,
-		Price:          price,
-		Status:         "COMPLETED",
-		IdempotencyKey: idempotencyKey,
-	}
-
 	if err := tradeStoreTx.CreateTrade(ctx, trade); err != nil {
-		// Unique violation on idempotency key — concurrent retry won the race.
-		var pqErr *pq.Error
-		if idempotencyKey != "" && errors.As(err, &pqErr) && pqErr.Code == "23505" {
-			tx.Rollback()
-			existing, fetchErr := s.tradesStore.GetTradeByIdempotencyKey(ctx, userID, idempotencyKey)
-			if fetchErr != nil {
-				return nil, fetchErr
-			}
-			if existing != nil {
-				return s.buildSellReplay(ctx, userID, existing)
-			}
-			// See BuyStock for rationale on the wrapped error.
-			return nil, fmt.Errorf("idempotency conflict but no prior trade found: %w", err)
-		}
 		return nil, err
 	}
 
@@ -291,6 +561,15 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 	if err := portfolioStoreTx.UpdatePortfolioWithSell(ctx, userID, symbol, existingHolding.Quantity, quantity); err != nil {
 		return nil, err
 	}
+	// Draw the shares from tax lots per the user's cost-basis method.
+	disposals, err := closeLots(ctx, tx, trade, existingHolding)
+	if err != nil {
+		return nil, err
+	}
+	realized := decimal.Zero
+	for _, d := range disposals {
+		realized = realized.Add(d.Realized)
+	}
 
 	// 7. Commit Transaction (all or nothing)
 	if err := tx.Commit(); err != nil {
@@ -299,6 +578,7 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 
 	slog.Info("trade executed",
 		"action", "SELL",
+		"order_type", trade.OrderType,
 		"user_id", userID,
 		"symbol", symbol,
 		"quantity", quantity,
@@ -306,28 +586,16 @@ func (s *InvestmentService) SellStock(ctx context.Context, userID string, symbol
 		"new_balance", newBalance,
 	)
 
-	// 8. Fetch updated portfolio for response
-	userStock, err := s.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
-	if err != nil {
-		if err == data.ErrStockHoldingNotFound {
-			// Portfolio was deleted (quantity reached 0), return empty state
-			userStock = &data.UserStock{
-				UserID:            userID,
-				Symbol:            symbol,
-				Quantity:          0,
-				AvgPrice:          existingHolding.AvgPrice,
-				Total:             decimal.Zero,
-				CurrentStockPrice: price,
-			}
-		} else {
-			return nil, err
-		}
-	} else {
-		userStock.CurrentStockPrice = price
-		userStock.Total = userStock.AvgPrice.Mul(decimal.NewFromInt(int64(userStock.Quantity)))
-	}
+	s.notifyObservers(ctx, TradeExecution{
+		TradeIntent:   TradeIntent{UserID: userID, Symbol: symbol, Action: "SELL", Quantity: quantity, Price: price},
+		TradeID:       trade.ID,
+		Total:         totalPrice,
+		BalanceBefore: balance,
+		BalanceAfter:  newBalance,
+		Realized:      &realized,
+	})
 
-	return userStock, nil
+	return existingHolding, nil
 }
 
 // buildSellReplay returns current portfolio state for a previously-recorded SELL.
@@ -342,19 +610,326 @@ func (s *InvestmentService) buildSellReplay(ctx context.Context, userID string,
 		userStock = &data.UserStock{
 			UserID:            userID,
 			Symbol:            trade.Symbol,
-			Quantity:          0,
+			Quantity:          decimal.Zero,
 			AvgPrice:          trade.Price,
 			Total:             decimal.Zero,
 			CurrentStockPrice: trade.Price,
 		}
 	} else {
 		userStock.CurrentStockPrice = trade.Price
-		userStock.Total = userStock.AvgPrice.Mul(decimal.NewFromInt(int64(userStock.Quantity)))
+		userStock.Total = userStock.AvgPrice.Mul(userStock.Quantity)
 	}
 	return userStock, nil
 }
 
-// GetUserStocks returns all portfolio holdings enriched with current prices.
+// SellShort opens or adds to a short position: quantity borrowed shares of
+// symbol are sold at the current quote. The proceeds are not paid out; they
+// are held as margin on the position together with collateral of shortMargin
+// times the sale value, taken from the user's balance. A user cannot be long
+// and short the same symbol at once.
+func (s *InvestmentService) SellShort(ctx context.Context, userID string, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
+	if err := util.ValidateQuantity(quantity, s.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey != "" {
+		existing, err := s.tradesStore.GetTradeByIdempotencyKey(ctx, userID, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return s.buildShortReplay(ctx, userID, existing)
+		}
+	}
+
+	stockData, err := s.tradeQuote(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	price, slippage := s.fillPrice("SHORT", stockData.Price, s.precisionOf(ctx, stockData.Symbol))
+	value := price.Mul(quantity).Round(2)
+	collateral := value.Mul(s.shortMargin).RoundCeil(2)
+
+	intent := TradeIntent{
+		UserID:   userID,
+		Symbol:   symbol,
+		Action:   "SHORT",
+		Quantity: quantity,
+		Price:    price,
+	}
+	if err := s.runPreTradeChecks(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	userStoreTx := data.NewUserStore(tx)
+	tradeStoreTx := data.NewTradesStore(tx)
+	portfolioStoreTx := data.NewPortfolioStore(tx)
+
+	if err := s.runTxTradeChecks(ctx, tx, intent); err != nil {
+		return nil, err
+	}
+
+	// Lock the position, then the balance — the same order as executeSell.
+	existing, err := portfolioStoreTx.GetPortfolioBySymbolForUpdate(ctx, userID, symbol)
+	switch {
+	case err == data.ErrStockHoldingNotFound:
+		existing = nil
+	case err != nil:
+		return nil, err
+	case !existing.IsShort():
+		return nil, &LongPositionOpenError{}
+	}
+
+	balance, err := userStoreTx.GetBalanceForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if balance.LessThan(collateral) {
+		return nil, &InsufficientFundsError{}
+	}
+	newBalance := balance.Sub(collateral)
+	if err := userStoreTx.UpdateBalance(ctx, userID, newBalance); err != nil {
+		return nil, err
+	}
+
+	trade := &data.Trade{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Symbol:         symbol,
+		Action:         "SHORT",
+		Quantity:       quantity,
+		Price:          price,
+		Status:         "COMPLETED",
+		IdempotencyKey: idempotencyKey,
+		OrderType:      data.OrderTypeMarket,
+		Slippage:       slippage,
+	}
+	if err := tradeStoreTx.CreateTrade(ctx, trade); err != nil {
+		tx.Rollback()
+		return s.replayShortConflict(ctx, userID, idempotencyKey, err)
+	}
+
+	if err := portfolioStoreTx.UpdatePortfolioWithShort(ctx, userID, symbol, existing, quantity, price, value.Add(collateral)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("trade executed",
+		"action", "SHORT",
+		"user_id", userID,
+		"symbol", symbol,
+		"quantity", quantity,
+		"price", price,
+		"collateral", collateral,
+		"new_balance", newBalance,
+	)
+
+	s.notifyObservers(ctx, TradeExecution{
+		TradeIntent:   TradeIntent{UserID: userID, Symbol: symbol, Action: "SHORT", Quantity: quantity, Price: price},
+		TradeID:       trade.ID,
+		Total:         value,
+		BalanceBefore: balance,
+		BalanceAfter:  newBalance,
+	})
+
+	after, err := s.shortPosition(ctx, userID, symbol, stockData.Price)
+	if err != nil {
+		return nil, err
+	}
+	after.Stale = stockData.Stale
+	return after, nil
+}
+
+// BuyToCover buys back quantity shares of a short position at the current
+// quote. The margin held against the covered shares is released to the
+// user's balance and the cost of the shares is paid out of it, so the balance
+// moves by the realized gain or loss plus the collateral returned. A cover
+// whose loss exceeds both the released margin and the user's cash is
+// rejected with InsufficientFundsError.
+func (s *InvestmentService) BuyToCover(ctx context.Context, userID string, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error) {
+	if err := util.ValidateQuantity(quantity, s.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		return nil, err
+	}
+
+	if idempotencyKey != "" {
+		existing, err := s.tradesStore.GetTradeByIdempotencyKey(ctx, userID, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return s.buildShortReplay(ctx, userID, existing)
+		}
+	}
+
+	stockData, err := s.tradeQuote(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	price, slippage := s.fillPrice("COVER", stockData.Price, s.precisionOf(ctx, stockData.Symbol))
+	cost := price.Mul(quantity).Round(2)
+
+	intent := TradeIntent{
+		UserID:   userID,
+		Symbol:   symbol,
+		Action:   "COVER",
+		Quantity: quantity,
+		Price:    price,
+	}
+	if err := s.runPreTradeChecks(ctx, intent); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	userStoreTx := data.NewUserStore(tx)
+	tradeStoreTx := data.NewTradesStore(tx)
+	portfolioStoreTx := data.NewPortfolioStore(tx)
+
+	if err := s.runTxTradeChecks(ctx, tx, intent); err != nil {
+		return nil, err
+	}
+
+	position, err := portfolioStoreTx.GetPortfolioBySymbolForUpdate(ctx, userID, symbol)
+	if err != nil {
+		if err == data.ErrStockHoldingNotFound {
+			return nil, &ShortPositionNotFoundError{}
+		}
+		return nil, err
+	}
+	if !position.IsShort() {
+		return nil, &ShortPositionNotFoundError{}
+	}
+	shorted := position.Quantity.Neg()
+	if quantity.GreaterThan(shorted) {
+		return nil, &CoverExceedsShortError{Short: shorted}
+	}
+
+	// Release margin pro rata. A full cover releases all of it so no rounding
+	// remainder is left behind.
+	released := position.Margin
+	if quantity.LessThan(shorted) {
+		released = position.Margin.Mul(quantity).
+			Div(shorted).Round(2)
+	}
+
+	balance, err := userStoreTx.GetBalanceForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	newBalance := balance.Add(released).Sub(cost)
+	if newBalance.IsNegative() {
+		return nil, &InsufficientFundsError{}
+	}
+	if err := userStoreTx.UpdateBalance(ctx, userID, newBalance); err != nil {
+		return nil, err
+	}
+
+	trade := &data.Trade{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Symbol:         symbol,
+		Action:         "COVER",
+		Quantity:       quantity,
+		Price:          price,
+		Status:         "COMPLETED",
+		IdempotencyKey: idempotencyKey,
+		OrderType:      data.OrderTypeMarket,
+		Slippage:       slippage,
+	}
+	if err := tradeStoreTx.CreateTrade(ctx, trade); err != nil {
+		tx.Rollback()
+		return s.replayShortConflict(ctx, userID, idempotencyKey, err)
+	}
+
+	if err := portfolioStoreTx.UpdatePortfolioWithCover(ctx, userID, symbol, position.Quantity, quantity, position.Margin.Sub(released)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("trade executed",
+		"action", "COVER",
+		"user_id", userID,
+		"symbol", symbol,
+		"quantity", quantity,
+		"price", price,
+		"realized_pnl", position.AvgPrice.Sub(price).Mul(quantity).Round(2),
+		"new_balance", newBalance,
+	)
+
+	s.notifyObservers(ctx, TradeExecution{
+		TradeIntent:   TradeIntent{UserID: userID, Symbol: symbol, Action: "COVER", Quantity: quantity, Price: price},
+		TradeID:       trade.ID,
+		Total:         cost,
+		BalanceBefore: balance,
+		BalanceAfter:  newBalance,
+	})
+
+	after, err := s.shortPosition(ctx, userID, symbol, stockData.Price)
+	if err != nil {
+		return nil, err
+	}
+	after.Stale = stockData.Stale
+	return after, nil
+}
+
+// replayShortConflict handles a CreateTrade error from SellShort or
+// BuyToCover. A unique violation on the idempotency key means a concurrent
+// retry won the race, so its trade is replayed; any other error is returned.
+// The caller must already have rolled back.
+func (s *InvestmentService) replayShortConflict(ctx context.Context, userID, idempotencyKey string, err error) (*data.UserStock, error) {
+	var pqErr *pq.Error
+	if idempotencyKey == "" || !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return nil, err
+	}
+	existing, fetchErr := s.tradesStore.GetTradeByIdempotencyKey(ctx, userID, idempotencyKey)
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	if existing != nil {
+		return s.buildShortReplay(ctx, userID, existing)
+	}
+	// See BuyStock for rationale on the wrapped error.
+	return nil, fmt.Errorf("idempotency conflict but no prior trade found: %w", err)
+}
+
+// buildShortReplay returns current position state for a previously-recorded
+// SHORT or COVER.
+func (s *InvestmentService) buildShortReplay(ctx context.Context, userID string, trade *data.Trade) (*data.UserStock, error) {
+	return s.shortPosition(ctx, userID, trade.Symbol, trade.Price)
+}
+
+// shortPosition returns the user's position in symbol marked at price, or an
+// empty one if it has been fully covered.
+func (s *InvestmentService) shortPosition(ctx context.Context, userID, symbol string, price decimal.Decimal) (*data.UserStock, error) {
+	position, err := s.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
+	if err != nil {
+		if err != data.ErrStockHoldingNotFound {
+			return nil, err
+		}
+		position = &data.UserStock{UserID: userID, Symbol: symbol}
+	}
+	position.MarkToMarket(price)
+	return position, nil
+}
+
+// GetUserStocks returns all portfolio holdings, long and short, enriched with
+// current prices and unrealized P&L.
 // It uses a single batch call to GetBatchHistoricalData (24h cache) instead of
 // per-symbol GetStock calls to stay within MarketStack's free-tier limits.
 // If the batch fetch fails the holdings are still returned; CurrentStockPrice
@@ -381,10 +956,10 @@ func (s *InvestmentService) GetUserStocks(ctx context.Context, userID string) ([
 			)
 		}
 		for i := range holdings {
-			holdings[i].Total = holdings[i].AvgPrice.Mul(decimal.NewFromInt(int64(holdings[i].Quantity)))
+			holdings[i].Total = holdings[i].AvgPrice.Mul(holdings[i].Quantity)
 			if priceData != nil {
 				if hist, ok := priceData[holdings[i].Symbol]; ok && hist != nil {
-					holdings[i].CurrentStockPrice = hist.Price
+					holdings[i].MarkToMarket(hist.Price)
 				}
 			}
 		}
@@ -398,6 +973,9 @@ func (s *InvestmentService) GetUserStocks(ctx context.Context, userID string) ([
 // Both queries run on the non-transactional trades store; the trades log is
 // append-only so a missed-row anomaly between the two queries is not a concern.
 func (s *InvestmentService) GetUserTrades(ctx context.Context, userID string, opts data.TradeQueryOpts) ([]data.Trade, int, error) {
+	if !opts.From.IsZero() && !opts.To.IsZero() && !opts.To.After(opts.From) {
+		return nil, 0, &util.ValidationError{Field: "to", Message: "must be after from"}
+	}
 	trades, err := s.tradesStore.GetTradesByUserID(ctx, userID, opts)
 	if err != nil {
 		return nil, 0, err
@@ -408,3 +986,12 @@ func (s *InvestmentService) GetUserTrades(ctx context.Context, userID string, op
 	}
 	return trades, total, nil
 }
+
+// ExportTrades calls fn with each of userID's trades matching opts, oldest
+// first, for streaming an export. Limit and Offset are ignored.
+func (s *InvestmentService) ExportTrades(ctx context.Context, userID string, opts data.TradeQueryOpts, fn func(*data.Trade) error) error {
+	if !opts.From.IsZero() && !opts.To.IsZero() && !opts.To.After(opts.From) {
+		return &util.ValidationError{Field: "to", Message: "must be after from"}
+	}
+	return s.tradesStore.EachTrade(ctx, userID, opts, fn)
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// maxGoalsPerUser caps how many goals one user can keep, reached or not.
+const maxGoalsPerUser = 20
+
+// Bounds on goal targets. investment_goals.target is NUMERIC(15,2).
+var (
+	maxGoalValue  = decimal.NewFromInt(1_000_000_000_000)
+	maxGoalReturn = decimal.NewFromInt(1000)
+)
+
+// Goal statuses, as reported by InvestmentGoalService.List.
+const (
+	GoalActive   = "active"
+	GoalAchieved = "achieved"
+	GoalMissed   = "missed"
+)
+
+// goalMilestone is the step, in percent of the way, users are notified at.
+const goalMilestone = 25
+
+// InvestmentGoalRequest is the body of POST /api/account/goals. Target is
+// dollars for a VALUE goal and percent for a RETURN goal; Name and
+// TargetDate (YYYY-MM-DD) are optional.
+type InvestmentGoalRequest struct {
+	Kind       string          `json:"kind"`
+	Name       string          `json:"name"`
+	Target     decimal.Decimal `json:"target"`
+	TargetDate string          `json:"target_date"`
+}
+
+// GoalProgress is a goal with how far along it is as of the user's latest
+// snapshot (AsOf). Progress is the percentage of the way from the baseline
+// to the target, 0 to 100; CurrentReturn is the return since the baseline,
+// in percent, for RETURN goals. CurrentValue leaves out bonus cash granted
+// since the baseline, which is cash put in rather than progress. A goal
+// with no snapshot since it was set has no current figures and no progress.
+type GoalProgress struct {
+	data.InvestmentGoal
+	Status        string           `json:"status"`
+	Progress      decimal.Decimal  `json:"progress"`
+	CurrentValue  *decimal.Decimal `json:"current_value,omitempty"`
+	CurrentReturn *decimal.Decimal `json:"current_return,omitempty"`
+	AsOf          string           `json:"as_of,omitempty"` // YYYY-MM-DD
+}
+
+// InvestmentGoalService keeps users' investment goals and, at each close,
+// moves them along from the day's portfolio snapshots, notifying users as
+// they pass each quarter of the way.
+type InvestmentGoalService struct {
+	store         *data.InvestmentGoalStore
+	history       *data.PortfolioHistoryStore
+	notifications *NotificationService
+	now           func() time.Time
+}
+
+func NewInvestmentGoalService(store *data.InvestmentGoalStore, history *data.PortfolioHistoryStore, notifications *NotificationService) *InvestmentGoalService {
+	return &InvestmentGoalService{store: store, history: history, notifications: notifications, now: time.Now}
+}
+
+// Create adds a goal measured from the user's latest snapshot, or from the
+// next close's if they have none yet. A VALUE goal must be above that
+// snapshot's value, and a target date must be after today.
+func (s *InvestmentGoalService) Create(ctx context.Context, userID string, req InvestmentGoalRequest) (*GoalProgress, error) {
+	kind := strings.ToUpper(strings.TrimSpace(req.Kind))
+	target := req.Target
+	switch kind {
+	case data.GoalValue:
+		if !target.IsPositive() || target.GreaterThan(maxGoalValue) {
+			return nil, &util.ValidationError{Field: "target", Message: "must be between $0.01 and $" + maxGoalValue.StringFixed(2)}
+		}
+	case data.GoalReturn:
+		if !target.IsPositive() || target.GreaterThan(maxGoalReturn) {
+			return nil, &util.ValidationError{Field: "target", Message: "must be between 0.01 and " + maxGoalReturn.String() + " percent"}
+		}
+	default:
+		return nil, &util.ValidationError{Field: "kind", Message: "must be VALUE or RETURN"}
+	}
+	if !target.Equal(target.Round(2)) {
+		return nil, &util.ValidationError{Field: "target", Message: "must have at most 2 decimal places"}
+	}
+	name := util.SanitizeString(req.Name)
+	if len(name) > 100 {
+		return nil, &util.ValidationError{Field: "name", Message: "must be at most 100 characters"}
+	}
+	if name == "" {
+		name = defaultGoalName(kind, target)
+	}
+	targetDate := strings.TrimSpace(req.TargetDate)
+	if targetDate != "" {
+		d, err := time.Parse(time.DateOnly, targetDate)
+		if err != nil {
+			return nil, &util.ValidationError{Field: "target_date", Message: "must be a date (YYYY-MM-DD)"}
+		}
+		if d.Format(time.DateOnly) <= s.today() {
+			return nil, &util.ValidationError{Field: "target_date", Message: "must be after today"}
+		}
+	}
+
+	n, err := s.store.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxGoalsPerUser {
+		return nil, &InvestmentGoalLimitError{Limit: maxGoalsPerUser}
+	}
+
+	latest, err := s.history.Latest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var baseline *decimal.Decimal
+	if latest != nil {
+		baseline = &latest.TotalValue
+		if kind == data.GoalValue && !target.GreaterThan(latest.TotalValue) {
+			return nil, &util.ValidationError{
+				Field:   "target",
+				Message: "must be above the account's latest value of $" + latest.TotalValue.StringFixed(2),
+			}
+		}
+	}
+
+	goal := &data.InvestmentGoal{
+		UserID:        userID,
+		Kind:          kind,
+		Name:          name,
+		Target:        target,
+		TargetDate:    targetDate,
+		BaselineValue: baseline,
+	}
+	if latest != nil {
+		goal.BaselineBonusCash = latest.BonusCash
+	}
+	goal, err = s.store.Create(ctx, goal)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("investment goal created", "goal_id", goal.ID, "user_id", userID, "kind", kind,
+		"target", target, "target_date", targetDate, "component", "goals")
+	return s.progress(goal, latest), nil
+}
+
+func defaultGoalName(kind string, target decimal.Decimal) string {
+	if kind == data.GoalReturn {
+		return target.String() + "% return"
+	}
+	return "Reach $" + target.StringFixed(2)
+}
+
+// List returns the user's goals, oldest first, with their progress as of
+// their latest snapshot.
+func (s *InvestmentGoalService) List(ctx context.Context, userID string) ([]GoalProgress, error) {
+	goals, err := s.store.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := s.history.Latest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]GoalProgress, 0, len(goals))
+	for i := range goals {
+		out = append(out, *s.progress(&goals[i], latest))
+	}
+	return out, nil
+}
+
+// Delete removes a goal.
+func (s *InvestmentGoalService) Delete(ctx context.Context, userID, id string) error {
+	err := s.store.Delete(ctx, userID, id)
+	if errors.Is(err, data.ErrInvestmentGoalNotFound) {
+		return &InvestmentGoalNotFoundError{}
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("investment goal deleted", "goal_id", id, "user_id", userID, "component", "goals")
+	return nil
+}
+
+// progress reports goal against latest, which may be nil. Snapshots from
+// before the goal had a baseline don't count.
+func (s *InvestmentGoalService) progress(goal *data.InvestmentGoal, latest *data.PortfolioSnapshot) *GoalProgress {
+	p := &GoalProgress{InvestmentGoal: *goal, Status: GoalActive}
+	switch {
+	case goal.AchievedOn != "":
+		p.Status = GoalAchieved
+	case goal.TargetDate != "" && goal.TargetDate < s.today():
+		p.Status = GoalMissed
+	}
+	if latest != nil && goal.BaselineValue != nil {
+		value := goalValue(goal, latest.TotalValue, latest.BonusCash)
+		p.CurrentValue = &value
+		p.AsOf = latest.Date
+		p.Progress, p.CurrentReturn = goalProgress(goal, *goal.BaselineValue, value)
+	}
+	if p.Status == GoalAchieved {
+		p.Progress = decimal.NewFromInt(100)
+	}
+	return p
+}
+
+// goalValue is total less the bonus cash granted since goal's baseline:
+// bonus is the cumulative grants as of total, as in a portfolio snapshot.
+// Like the benchmark comparison, a grant moves the account value without
+// being a gain.
+func goalValue(goal *data.InvestmentGoal, total, bonus decimal.Decimal) decimal.Decimal {
+	return total.Sub(bonus.Sub(goal.BaselineBonusCash))
+}
+
+// goalProgress returns how far value has come from baseline towards goal's
+// target, as a percentage clamped to [0, 100], and for a RETURN goal the
+// return from baseline to value in percent.
+func goalProgress(goal *data.InvestmentGoal, baseline, value decimal.Decimal) (decimal.Decimal, *decimal.Decimal) {
+	hundred := decimal.NewFromInt(100)
+	var progress decimal.Decimal
+	var ret *decimal.Decimal
+	switch goal.Kind {
+	case data.GoalValue:
+		span := goal.Target.Sub(baseline)
+		switch {
+		case !value.LessThan(goal.Target):
+			progress = hundred
+		case span.IsPositive():
+			progress = value.Sub(baseline).Div(span).Mul(hundred)
+		}
+	case data.GoalReturn:
+		if baseline.IsPositive() {
+			r := value.Div(baseline).Sub(decimal.NewFromInt(1)).Mul(hundred).Round(2)
+			ret = &r
+			progress = r.Div(goal.Target).Mul(hundred)
+		}
+	}
+	if progress.IsNegative() {
+		progress = decimal.Zero
+	}
+	if progress.GreaterThan(hundred) {
+		progress = hundred
+	}
+	return progress.Round(2), ret
+}
+
+// Evaluate is the end-of-day close step that moves every open goal along
+// from the day's snapshots. A goal without a baseline takes the day's value
+// as it. When a goal passes a new quarter of the way its owner is notified,
+// once, of the furthest one; at the whole way it is reached and evaluated
+// no more. Goals past their target date are left alone. A rerun of a past
+// session does nothing, so milestones never go backwards. Returns the
+// number of notifications sent.
+func (s *InvestmentGoalService) Evaluate(ctx context.Context, eod *EODClose) (int, error) {
+	if eod.IsRerun() {
+		return 0, nil
+	}
+	date := eod.Session.Close.Format(time.DateOnly)
+	open, err := s.store.ListOpen(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, o := range open {
+		g := o.Goal
+		baseline := o.TotalValue
+		if g.BaselineValue != nil {
+			baseline = *g.BaselineValue
+		} else {
+			g.BaselineBonusCash = o.BonusCash
+		}
+		progress, _ := goalProgress(&g, baseline, goalValue(&g, o.TotalValue, o.BonusCash))
+		milestone := max(int(progress.IntPart())/goalMilestone*goalMilestone, g.LastMilestone)
+		if milestone == g.LastMilestone && g.BaselineValue != nil {
+			continue
+		}
+		achievedOn := ""
+		if milestone == 100 {
+			achievedOn = date
+		}
+		ok, err := s.store.Advance(ctx, g.ID, g.LastMilestone, milestone, baseline, g.BaselineBonusCash, achievedOn)
+		if err != nil {
+			return sent, err
+		}
+		if !ok || milestone == g.LastMilestone {
+			continue
+		}
+		title, body := "Goal progress", fmt.Sprintf("You're %d%% of the way to your goal %q.", milestone, g.Name)
+		kind := NotificationGoalMilestone
+		if achievedOn != "" {
+			title, body = "Goal reached", fmt.Sprintf("You reached your goal %q.", g.Name)
+			kind = NotificationGoalReached
+		}
+		if s.notifications != nil {
+			if err := s.notifications.Notify(ctx, []string{g.UserID}, kind, title, body); err != nil {
+				slog.Warn("failed to send goal notification", "goal_id", g.ID, "user_id", g.UserID, "err", err, "component", "goals")
+				continue
+			}
+		}
+		slog.Info("investment goal milestone", "goal_id", g.ID, "user_id", g.UserID, "milestone", milestone, "component", "goals")
+		sent++
+	}
+	return sent, nil
+}
+
+// today is the current New York date.
+func (s *InvestmentGoalService) today() string {
+	return s.now().In(newYork).Format(time.DateOnly)
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+var goalCols = []string{"id", "user_id", "kind", "name", "target", "target_date",
+	"baseline_value", "baseline_bonus_cash", "last_milestone", "achieved_on", "created_at"}
+
+var snapshotCols = []string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}
+
+func newTestGoals(t *testing.T) (*InvestmentGoalService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	svc := NewInvestmentGoalService(data.NewInvestmentGoalStore(db), data.NewPortfolioHistoryStore(db),
+		NewNotificationService(data.NewNotificationStore(db)))
+	svc.now = func() time.Time { return time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC) }
+	return svc, mock
+}
+
+func TestGoalProgress(t *testing.T) {
+	value := &data.InvestmentGoal{Kind: data.GoalValue, Target: decimal.NewFromInt(15000)}
+	ret := &data.InvestmentGoal{Kind: data.GoalReturn, Target: decimal.NewFromInt(20)}
+	cases := []struct {
+		goal            *data.InvestmentGoal
+		baseline, value int64
+		want            string
+	}{
+		{value, 10000, 12500, "50"},
+		{value, 10000, 9000, "0"},
+		{value, 10000, 16000, "100"},
+		{ret, 10000, 11000, "50"},
+		{ret, 10000, 13000, "100"},
+		{ret, 0, 13000, "0"},
+	}
+	for _, c := range cases {
+		got, _ := goalProgress(c.goal, decimal.NewFromInt(c.baseline), decimal.NewFromInt(c.value))
+		if got.String() != c.want {
+			t.Errorf("%s %d→%d: got %s, want %s", c.goal.Kind, c.baseline, c.value, got, c.want)
+		}
+	}
+	if _, r := goalProgress(ret, decimal.NewFromInt(10000), decimal.NewFromInt(11000)); r == nil || r.String() != "10" {
+		t.Errorf("current return: got %v, want 10", r)
+	}
+}
+
+func TestInvestmentGoalCreate_Validation(t *testing.T) {
+	svc, _ := newTestGoals(t)
+	cases := []InvestmentGoalRequest{
+		{Kind: "SAVINGS", Target: decimal.NewFromInt(100)},
+		{Kind: "VALUE", Target: decimal.Zero},
+		{Kind: "VALUE", Target: decimal.RequireFromString("100.001")},
+		{Kind: "RETURN", Target: decimal.NewFromInt(5000)},
+		{Kind: "RETURN", Target: decimal.NewFromInt(20), TargetDate: "2026-10-16"},
+		{Kind: "RETURN", Target: decimal.NewFromInt(20), TargetDate: "December"},
+	}
+	for _, req := range cases {
+		var verr *util.ValidationError
+		if _, err := svc.Create(context.Background(), "user-1", req); !errors.As(err, &verr) {
+			t.Errorf("%+v: got %v, want a validation error", req, err)
+		}
+	}
+}
+
+func TestInvestmentGoalCreate_ValueMustBeAboveLatest(t *testing.T) {
+	svc, mock := newTestGoals(t)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM investment_goals").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM portfolio_history").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), "2000", "14000", "16000", false, "0"))
+
+	_, err := svc.Create(context.Background(), "user-1", InvestmentGoalRequest{Kind: "value", Target: decimal.NewFromInt(15000)})
+	var verr *util.ValidationError
+	if !errors.As(err, &verr) || verr.Field != "target" {
+		t.Errorf("got %v, want a target validation error", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestInvestmentGoalCreate(t *testing.T) {
+	svc, mock := newTestGoals(t)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM investment_goals").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM portfolio_history").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), "2000", "10000", "12000", false, "500"))
+	mock.ExpectQuery("INSERT INTO investment_goals").
+		WithArgs(sqlmock.AnyArg(), "user-1", data.GoalValue, "Reach $15000.00", decimal.NewFromInt(15000), "2026-12-31", decimal.NewFromInt(12000), decimal.NewFromInt(500)).
+		WillReturnRows(sqlmock.NewRows(goalCols).
+			AddRow("g-1", "user-1", "VALUE", "Reach $15000.00", "15000", time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC), "12000", "500", 0, nil, time.Now()))
+
+	goal, err := svc.Create(context.Background(), "user-1", InvestmentGoalRequest{Kind: "VALUE", Target: decimal.NewFromInt(15000), TargetDate: "2026-12-31"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if goal.Status != GoalActive || !goal.Progress.IsZero() || goal.AsOf != "2026-10-15" || goal.TargetDate != "2026-12-31" ||
+		goal.CurrentValue == nil || !goal.CurrentValue.Equal(decimal.NewFromInt(12000)) {
+		t.Errorf("got %+v", goal)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestInvestmentGoalEvaluate(t *testing.T) {
+	svc, mock := newTestGoals(t)
+	closeAt := time.Date(2026, 10, 15, 16, 0, 0, 0, newYork)
+	mock.ExpectQuery("FROM investment_goals").
+		WithArgs("2026-10-15").
+		WillReturnRows(sqlmock.NewRows(append(goalCols, "total_value", "bonus_cash")).
+			// 60% of the way from 10000 to 15000: the 50% milestone.
+			AddRow("g-1", "user-1", "VALUE", "Reach $15k", "15000", nil, "10000", "0", 25, nil, time.Now(), "13000", "0").
+			// No new milestone: nothing to do.
+			AddRow("g-2", "user-1", "RETURN", "20% return", "20", nil, "10000", "0", 25, nil, time.Now(), "10600", "0").
+			// 14000 but 2000 of it granted since the baseline: still short
+			// of the 50% milestone.
+			AddRow("g-5", "user-1", "VALUE", "Reach $15k", "15000", nil, "10000", "1000", 25, nil, time.Now(), "14000", "3000").
+			// First snapshot since it was set: takes the day's value and
+			// bonus cash as its baseline.
+			AddRow("g-3", "user-2", "RETURN", "10% return", "10", nil, nil, "0", 0, nil, time.Now(), "9000", "500").
+			// Reached.
+			AddRow("g-4", "user-2", "VALUE", "Reach $8k", "8000", nil, "5000", "0", 75, nil, time.Now(), "9000", "0"))
+	mock.ExpectExec("UPDATE investment_goals").
+		WithArgs("g-1", 25, 50, decimal.NewFromInt(10000), decimal.Zero, "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(NotificationGoalMilestone, "Goal progress", `You're 50% of the way to your goal "Reach $15k".`, sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE investment_goals").
+		WithArgs("g-3", 0, 0, decimal.NewFromInt(9000), decimal.NewFromInt(500), "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE investment_goals").
+		WithArgs("g-4", 75, 100, decimal.NewFromInt(5000), decimal.Zero, "2026-10-15").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notifications").
+		WithArgs(NotificationGoalReached, "Goal reached", `You reached your goal "Reach $8k".`, sqlmock.AnyArg(), "user-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sent, err := svc.Evaluate(context.Background(), &EODClose{Session: MarketSession{Close: closeAt}})
+	if err != nil || sent != 2 {
+		t.Errorf("Evaluate: got %d, %v; want 2 notifications", sent, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+
+	// A rerun of a past session leaves goals alone.
+	if sent, err := svc.Evaluate(context.Background(), &EODClose{Session: MarketSession{Close: closeAt}, Previous: map[string]decimal.Decimal{}}); sent != 0 || err != nil {
+		t.Errorf("rerun: got %d, %v", sent, err)
+	}
+}
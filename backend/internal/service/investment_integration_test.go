@@ -86,7 +86,7 @@ func TestBuyStock_RollsBackOnPortfolioFailure(t *testing.T) {
 	)
 
 	// BuyStock must fail because the portfolio upsert trips the check constraint.
-	_, err = svc.BuyStock(context.Background(), userID, "AAPL", 1, "")
+	_, err = svc.BuyStock(context.Background(), userID, "AAPL", decimal.NewFromInt(1), "")
 	if err == nil {
 		t.Fatal("expected BuyStock to return an error when portfolio upsert fails, got nil")
 	}
@@ -156,7 +156,7 @@ func TestBuyStock_ConcurrentSameIdempotencyKey(t *testing.T) {
 		i := i
 		go func() {
 			defer wg.Done()
-			stock, err := svc.BuyStock(context.Background(), userID, "AAPL", 1, idempotencyKey)
+			stock, err := svc.BuyStock(context.Background(), userID, "AAPL", decimal.NewFromInt(1), idempotencyKey)
 			results[i] = result{stock: stock, err: err}
 		}()
 	}
@@ -215,10 +215,73 @@ func TestBuyStock_ConcurrentSameIdempotencyKey(t *testing.T) {
 			if r.err != nil || r.stock == nil {
 				continue
 			}
-			if r.stock.Quantity != firstStock.Quantity {
-				t.Errorf("goroutine %d: quantity %d disagrees with first caller's %d",
+			if !r.stock.Quantity.Equal(firstStock.Quantity) {
+				t.Errorf("goroutine %d: quantity %s disagrees with first caller's %s",
 					i, r.stock.Quantity, firstStock.Quantity)
 			}
 		}
 	}
 }
+
+// TestShortThenCover_RoundTrip shorts 10 shares, covers them in two lots at a
+// lower price, and checks the balance ends up moved by exactly the gain, the
+// position is gone and the ledger reconciles.
+func TestShortThenCover_RoundTrip(t *testing.T) {
+	db := testutil.NewIntegrationDB(t)
+	testutil.Truncate(t, db, "trades", "portfolio", "users")
+	ctx := context.Background()
+
+	userID := uuid.New().String()
+	userStore := data.NewUserStore(db)
+	_, err := db.Exec(
+		`INSERT INTO users (id, email, password, balance, email_verified, created_via)
+		 VALUES ($1, $2, 'testhash', 1000.00, TRUE, 'email')`,
+		userID, fmt.Sprintf("short-%s@example.com", userID[:8]),
+	)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+
+	market := &integrationMarket{symbol: "AAPL", price: decimal.NewFromFloat(100.0)}
+	portfolioStore := data.NewPortfolioStore(db)
+	tradesStore := data.NewTradesStore(db)
+	svc := NewInvestmentService(db, market, portfolioStore, tradesStore)
+
+	position, err := svc.SellShort(ctx, userID, "AAPL", decimal.NewFromInt(10), "")
+	if err != nil {
+		t.Fatalf("SellShort: %v", err)
+	}
+	if !position.Quantity.Equal(decimal.NewFromInt(-10)) || !position.Margin.Equal(decimal.NewFromInt(1500)) {
+		t.Fatalf("after short: got qty %s margin %s, want -10 and 1500", position.Quantity, position.Margin)
+	}
+	if balance, _ := userStore.GetBalance(ctx, userID); !balance.Equal(decimal.NewFromInt(500)) {
+		t.Errorf("balance after short: got %s, want 500 (50%% collateral held)", balance)
+	}
+	if _, err := svc.BuyStock(ctx, userID, "AAPL", decimal.NewFromInt(1), ""); err == nil {
+		t.Error("BuyStock while short: expected an error, got nil")
+	}
+
+	market.price = decimal.NewFromFloat(80.0)
+	if _, err := svc.BuyToCover(ctx, userID, "AAPL", decimal.NewFromInt(3), ""); err != nil {
+		t.Fatalf("BuyToCover 3: %v", err)
+	}
+	position, err = svc.BuyToCover(ctx, userID, "AAPL", decimal.NewFromInt(7), "")
+	if err != nil {
+		t.Fatalf("BuyToCover 7: %v", err)
+	}
+	if !position.Quantity.IsZero() {
+		t.Errorf("after full cover: got qty %s, want 0", position.Quantity)
+	}
+
+	// Sold at 100, bought back at 80: a $200 gain on 10 shares.
+	if balance, _ := userStore.GetBalance(ctx, userID); !balance.Equal(decimal.NewFromInt(1200)) {
+		t.Errorf("balance after cover: got %s, want 1200", balance)
+	}
+	discrepancies, err := NewReconcileService(db, portfolioStore, tradesStore).Reconcile(ctx, userID)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("Reconcile: expected no discrepancies, got %+v", discrepancies)
+	}
+}
@@ -15,23 +15,19 @@ import (
 
 // mockMarket implements MarketPricer for tests.
 type mockMarket struct {
-	stock    *StockData
-	stockErr error
+	stock      *StockData
+	stockErr   error
+	batch      map[string]*HistoricalData
+	stockCalls int
 }
 
 func (m *mockMarket) GetStock(_ context.Context, _ string) (*StockData, error) {
+	m.stockCalls++
 	return m.stock, m.stockErr
 }
 
 func (m *mockMarket) GetBatchHistoricalData(_ context.Context, _ []string) (map[string]*HistoricalData, error) {
-	return nil, nil
-}
-
-// userCols are the columns returned by GetUserByID.
-var userCols = []string{
-	"id", "email", "password", "created_at", "balance",
-	"email_verified", "verification_token", "verification_token_expires",
-	"google_id", "created_via",
+	return m.batch, nil
 }
 
 // balanceCols are the columns returned by GetBalanceForUpdate.
@@ -39,14 +35,11 @@ var balanceCols = []string{"balance"}
 
 // portfolioCols are the columns returned by GetPortfolioBySymbol.
 var portfolioCols = []string{
-	"id", "user_id", "symbol", "quantity", "avg_price", "created_at", "updated_at",
+	"id", "user_id", "symbol", "quantity", "avg_price", "margin", "created_at", "updated_at",
 }
 
 func newUserRow(balance decimal.Decimal) *sqlmock.Rows {
-	return sqlmock.NewRows(userCols).AddRow(
-		"user-1", "test@example.com", "hashed", time.Now(), balance,
-		true, nil, nil, nil, "email",
-	)
+	return userRow(data.User{ID: "user-1", Email: "test@example.com", Password: "hashed", Balance: balance, EmailVerified: true})
 }
 
 func newBalanceRow(balance decimal.Decimal) *sqlmock.Rows {
@@ -64,7 +57,7 @@ func TestBuyStock_InvalidQuantity(t *testing.T) {
 
 	svc := NewInvestmentService(db, &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(100)}}, data.NewPortfolioStore(db), data.NewTradesStore(db))
 
-	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", 0, "")
+	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(0), "")
 	if err == nil {
 		t.Error("expected error for quantity 0, got nil")
 	}
@@ -80,7 +73,7 @@ func TestBuyStock_MarketError(t *testing.T) {
 	market := &mockMarket{stockErr: errors.New("marketstack unavailable")}
 	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
 
-	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", 1, "")
+	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), "")
 	if err == nil || err.Error() != "marketstack unavailable" {
 		t.Errorf("expected market error, got %v", err)
 	}
@@ -103,7 +96,7 @@ func TestBuyStock_InsufficientFunds(t *testing.T) {
 		WillReturnRows(newBalanceRow(decimal.NewFromFloat(50.0)))
 	mock.ExpectRollback()
 
-	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", 1, "")
+	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), "")
 	if err == nil || err.Error() != "insufficient funds" {
 		t.Errorf("expected 'insufficient funds', got %v", err)
 	}
@@ -112,6 +105,42 @@ func TestBuyStock_InsufficientFunds(t *testing.T) {
 	}
 }
 
+func TestBuyStock_StaleQuote(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	fetchedAt := time.Now().Add(-10 * time.Minute)
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(200), Stale: true, FetchedAt: &fetchedAt}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	// Refused by default, before any query.
+	var stale *StaleQuoteError
+	if _, err := svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), ""); !errors.As(err, &stale) {
+		t.Fatalf("expected StaleQuoteError, got %v", err)
+	}
+	svc.SetStaleQuoteMaxAge(5 * time.Minute)
+	if _, err := svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), ""); !errors.As(err, &stale) {
+		t.Fatalf("expected StaleQuoteError past the max age, got %v", err)
+	}
+
+	// Within the max age the trade goes ahead.
+	svc.SetStaleQuoteMaxAge(15 * time.Minute)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromFloat(50.0)))
+	mock.ExpectRollback()
+	if _, err := svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), ""); err == nil || err.Error() != "insufficient funds" {
+		t.Errorf("expected 'insufficient funds', got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
 // ---- SellStock tests ----
 
 func TestSellStock_InvalidQuantity(t *testing.T) {
@@ -123,7 +152,7 @@ func TestSellStock_InvalidQuantity(t *testing.T) {
 
 	svc := NewInvestmentService(db, &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(150)}}, data.NewPortfolioStore(db), data.NewTradesStore(db))
 
-	_, err = svc.SellStock(context.Background(), "user-1", "AAPL", 0, "")
+	_, err = svc.SellStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(0), "")
 	if err == nil {
 		t.Error("expected error for quantity 0, got nil")
 	}
@@ -139,7 +168,7 @@ func TestSellStock_MarketError(t *testing.T) {
 	market := &mockMarket{stockErr: errors.New("API timeout")}
 	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
 
-	_, err = svc.SellStock(context.Background(), "user-1", "AAPL", 1, "")
+	_, err = svc.SellStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), "")
 	if err == nil || err.Error() != "API timeout" {
 		t.Errorf("expected market error, got %v", err)
 	}
@@ -161,7 +190,7 @@ func TestSellStock_HoldingNotFound(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows(portfolioCols)) // empty result → ErrNoRows
 	mock.ExpectRollback()
 
-	_, err = svc.SellStock(context.Background(), "user-1", "TSLA", 1, "")
+	_, err = svc.SellStock(context.Background(), "user-1", "TSLA", decimal.NewFromInt(1), "")
 	if err == nil {
 		t.Error("expected error for holding not found, got nil")
 	}
@@ -184,11 +213,11 @@ func TestSellStock_InsufficientQuantity(t *testing.T) {
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1", "AAPL").
 		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
-			"port-1", "user-1", "AAPL", 2, decimal.NewFromInt(100), time.Now(), time.Now(),
+			"port-1", "user-1", "AAPL", 2, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now(),
 		))
 	mock.ExpectRollback()
 
-	_, err = svc.SellStock(context.Background(), "user-1", "AAPL", 5, "") // wants 5, has 2
+	_, err = svc.SellStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(5), "") // wants 5, has 2
 	if err == nil || err.Error() != "insufficient stock quantity" {
 		t.Errorf("expected 'insufficient stock quantity', got %v", err)
 	}
@@ -201,7 +230,7 @@ func TestSellStock_InsufficientQuantity(t *testing.T) {
 
 // tradeCols mirrors the columns returned by GetTradeByIdempotencyKey.
 var idempColsCols = []string{
-	"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status", "idempotency_key",
+	"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status", "idempotency_key", "order_type", "slippage",
 }
 
 func TestBuyStock_IdempotencyReplay(t *testing.T) {
@@ -222,16 +251,16 @@ func TestBuyStock_IdempotencyReplay(t *testing.T) {
 		WithArgs("user-1", "idempkey-1").
 		WillReturnRows(sqlmock.NewRows(idempColsCols).AddRow(
 			"trade-existing", "user-1", "AAPL", "BUY", 5, decimal.NewFromInt(150), decimal.NewFromInt(750), executedAt, "COMPLETED",
-			"idempkey-1",
+			"idempkey-1", "MARKET", "0",
 		))
 	// GetPortfolioBySymbol for replay
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1", "AAPL").
 		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
-			"port-1", "user-1", "AAPL", 5, decimal.NewFromInt(150), executedAt, executedAt,
+			"port-1", "user-1", "AAPL", 5, decimal.NewFromInt(150), decimal.Zero, executedAt, executedAt,
 		))
 
-	result, err := svc.BuyStock(context.Background(), "user-1", "AAPL", 5, "idempkey-1")
+	result, err := svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(5), "idempkey-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -259,16 +288,16 @@ func TestSellStock_IdempotencyReplay(t *testing.T) {
 		WithArgs("user-1", "sell-key-1").
 		WillReturnRows(sqlmock.NewRows(idempColsCols).AddRow(
 			"trade-sell", "user-1", "AAPL", "SELL", 3, decimal.NewFromInt(150), decimal.NewFromInt(450), executedAt, "COMPLETED",
-			"sell-key-1",
+			"sell-key-1", "MARKET", "0",
 		))
 	// After replay, GetPortfolioBySymbol returns remaining holding
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1", "AAPL").
 		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
-			"port-1", "user-1", "AAPL", 2, decimal.NewFromInt(150), executedAt, executedAt,
+			"port-1", "user-1", "AAPL", 2, decimal.NewFromInt(150), decimal.Zero, executedAt, executedAt,
 		))
 
-	result, err := svc.SellStock(context.Background(), "user-1", "AAPL", 3, "sell-key-1")
+	result, err := svc.SellStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(3), "sell-key-1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -297,16 +326,16 @@ func TestBuyStock_IdempotencyDifferentArgs(t *testing.T) {
 		WithArgs("user-1", "same-key").
 		WillReturnRows(sqlmock.NewRows(idempColsCols).AddRow(
 			"trade-original", "user-1", "AAPL", "BUY", 5, decimal.NewFromInt(150), decimal.NewFromInt(750), executedAt, "COMPLETED",
-			"same-key",
+			"same-key", "MARKET", "0",
 		))
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1", "AAPL").
 		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
-			"port-1", "user-1", "AAPL", 5, decimal.NewFromInt(150), executedAt, executedAt,
+			"port-1", "user-1", "AAPL", 5, decimal.NewFromInt(150), decimal.Zero, executedAt, executedAt,
 		))
 
 	// Called with qty=10 (different from original 5) — must still replay
-	result, err := svc.BuyStock(context.Background(), "user-1", "AAPL", 10, "same-key")
+	result, err := svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(10), "same-key")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -366,7 +395,7 @@ func TestBuyStock_UniqueViolationButReFetchEmpty(t *testing.T) {
 		WithArgs("user-1", ikey).
 		WillReturnRows(sqlmock.NewRows(idempColsCols))
 
-	stock, err := svc.BuyStock(context.Background(), "user-1", "AAPL", 1, ikey)
+	stock, err := svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), ikey)
 
 	if stock != nil {
 		t.Errorf("expected nil stock, got %+v", stock)
@@ -383,3 +412,252 @@ func TestBuyStock_UniqueViolationButReFetchEmpty(t *testing.T) {
 		t.Errorf("unfulfilled sql expectations: %v", err)
 	}
 }
+
+// ---- Short selling tests ----
+
+func shortPositionRow(quantity int, avgPrice, margin decimal.Decimal) *sqlmock.Rows {
+	return sqlmock.NewRows(portfolioCols).AddRow(
+		"port-1", "user-1", "AAPL", quantity, avgPrice, margin, time.Now(), time.Now(),
+	)
+}
+
+func TestSellShort_OpensPositionAndHoldsMargin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	price := decimal.NewFromInt(100)
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: price}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	// 10 shares at $100: $1000 of proceeds plus $500 (50%) of the user's cash
+	// are held as margin.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(1000)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WithArgs(decimal.NewFromInt(500), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "SHORT", decimal.NewFromInt(10), price, "COMPLETED", sqlmock.AnyArg(), data.OrderTypeMarket, decimal.Zero).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO portfolio").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", decimal.NewFromInt(-10), price, decimal.NewFromInt(1500)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(-10, price, decimal.NewFromInt(1500)))
+
+	position, err := svc.SellShort(context.Background(), "user-1", "AAPL", decimal.NewFromInt(10), "")
+	if err != nil {
+		t.Fatalf("SellShort: %v", err)
+	}
+	if !position.Quantity.Equal(decimal.NewFromInt(-10)) || !position.Margin.Equal(decimal.NewFromInt(1500)) {
+		t.Errorf("position: got qty %s margin %s, want -10 and 1500", position.Quantity, position.Margin)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestSellShort_RejectsWhenLong(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(100)}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(5, decimal.NewFromInt(90), decimal.Zero))
+	mock.ExpectRollback()
+
+	_, err = svc.SellShort(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), "")
+	var lpe *LongPositionOpenError
+	if !errors.As(err, &lpe) {
+		t.Errorf("expected LongPositionOpenError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestBuyToCover_PartialReleasesMarginProRata(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	price := decimal.NewFromInt(80)
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: price}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	// Short 10 at $100 with $1500 margin; cover 4 at $80. 4/10 of the margin
+	// ($600) is released and the shares cost $320: balance 500 → 780.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(-10, decimal.NewFromInt(100), decimal.NewFromInt(1500)))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(500)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WithArgs(decimal.NewFromInt(780), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "COVER", decimal.NewFromInt(4), price, "COMPLETED", sqlmock.AnyArg(), data.OrderTypeMarket, decimal.Zero).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE portfolio SET quantity").
+		WithArgs(decimal.NewFromInt(-6), decimal.NewFromInt(900), "user-1", "AAPL").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(-6, decimal.NewFromInt(100), decimal.NewFromInt(900)))
+
+	position, err := svc.BuyToCover(context.Background(), "user-1", "AAPL", decimal.NewFromInt(4), "")
+	if err != nil {
+		t.Fatalf("BuyToCover: %v", err)
+	}
+	// The price fell $20 on 6 remaining short shares: a $120 gain.
+	if position.UnrealizedPnL == nil || !position.UnrealizedPnL.Equal(decimal.NewFromInt(120)) {
+		t.Errorf("UnrealizedPnL: got %v, want 120", position.UnrealizedPnL)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestBuyToCover_LossBeyondMarginAndCash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(300)}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	// Covering 10 at $300 costs $3000; the $1500 margin plus $100 cash falls short.
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(-10, decimal.NewFromInt(100), decimal.NewFromInt(1500)))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(100)))
+	mock.ExpectRollback()
+
+	_, err = svc.BuyToCover(context.Background(), "user-1", "AAPL", decimal.NewFromInt(10), "")
+	var ife *InsufficientFundsError
+	if !errors.As(err, &ife) {
+		t.Errorf("expected InsufficientFundsError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestBuyToCover_ExceedsShort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(90)}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(-3, decimal.NewFromInt(100), decimal.NewFromInt(450)))
+	mock.ExpectRollback()
+
+	_, err = svc.BuyToCover(context.Background(), "user-1", "AAPL", decimal.NewFromInt(5), "")
+	var cee *CoverExceedsShortError
+	if !errors.As(err, &cee) || !cee.Short.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("expected CoverExceedsShortError{3}, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestBuyStock_RejectsWhenShort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	price := decimal.NewFromInt(100)
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: price}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(1000)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(shortPositionRow(-2, price, decimal.NewFromInt(300)))
+	mock.ExpectRollback()
+
+	_, err = svc.BuyStock(context.Background(), "user-1", "AAPL", decimal.NewFromInt(1), "")
+	var spe *ShortPositionOpenError
+	if !errors.As(err, &spe) {
+		t.Errorf("expected ShortPositionOpenError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestFillPrice(t *testing.T) {
+	svc := NewInvestmentService(nil, &mockMarket{}, nil, nil)
+	quote := decimal.RequireFromString("200.00")
+
+	if price, slippage := svc.fillPrice("BUY", quote, DefaultPrecision); !price.Equal(quote) || !slippage.IsZero() {
+		t.Errorf("off: got %s (%s), want the quote", price, slippage)
+	}
+
+	svc.SetSlippagePct(decimal.RequireFromString("0.25"))
+	for _, tc := range []struct {
+		action, price, slippage string
+	}{
+		{"BUY", "200.50", "0.50"},
+		{"COVER", "200.50", "0.50"},
+		{"SELL", "199.50", "-0.50"},
+		{"SHORT", "199.50", "-0.50"},
+	} {
+		price, slippage := svc.fillPrice(tc.action, quote, DefaultPrecision)
+		if !price.Equal(decimal.RequireFromString(tc.price)) || !slippage.Equal(decimal.RequireFromString(tc.slippage)) {
+			t.Errorf("%s: got %s (%s), want %s (%s)", tc.action, price, slippage, tc.price, tc.slippage)
+		}
+	}
+
+	// Fills land on the instrument's tick; the slippage is measured from the
+	// unrounded quote.
+	nickel := Precision{TickSize: decimal.RequireFromString("0.05"), Decimals: 2}
+	price, slippage := svc.fillPrice("BUY", decimal.RequireFromString("40.03"), nickel)
+	if !price.Equal(decimal.RequireFromString("40.15")) || !slippage.Equal(decimal.RequireFromString("0.12")) {
+		t.Errorf("nickel tick: got %s (%s), want 40.15 (0.12)", price, slippage)
+	}
+	price, _ = svc.fillPrice("SELL", decimal.RequireFromString("0.5123"), DefaultPrecision)
+	if !price.Equal(decimal.RequireFromString("0.511")) {
+		t.Errorf("sub-dollar: got %s, want 0.511", price)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// Invite code limits. A batch covers a class roster; a multi-use code covers
+// a whole beta cohort.
+const (
+	maxInviteCodeBatch = 500
+	maxInviteCodeUses  = 10000
+	maxInviteCodeNote  = 200
+	inviteCodeLength   = 10
+)
+
+// inviteCodeAlphabet leaves out 0, O, 1, I and L so a code copied off a
+// whiteboard or read aloud survives.
+const inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// InviteCodeSpec describes a batch of invite codes to generate. Count and
+// MaxUses default to 1. StartingBalance and League, when set, are given to
+// every account the codes admit.
+type InviteCodeSpec struct {
+	Count           int
+	MaxUses         int
+	ExpiresAt       *time.Time
+	Note            string
+	StartingBalance *decimal.Decimal
+	League          string
+}
+
+// InviteCodeDetail is an invite code and the accounts it admitted.
+type InviteCodeDetail struct {
+	data.InviteCode
+	Redemptions []data.InviteRedemption `json:"redemptions"`
+}
+
+// InviteService issues invite codes and, when registration is invite-only,
+// checks them as accounts are created.
+type InviteService struct {
+	codes    *data.InviteCodeStore
+	required bool
+}
+
+// NewInviteService builds the service. required turns on invite-only
+// registration; codes can be managed either way.
+func NewInviteService(codes *data.InviteCodeStore, required bool) *InviteService {
+	return &InviteService{codes: codes, required: required}
+}
+
+// Generate creates spec.Count random codes, each admitting spec.MaxUses
+// accounts until spec.ExpiresAt. createdBy is the admin's user ID.
+func (s *InviteService) Generate(ctx context.Context, createdBy string, spec InviteCodeSpec) ([]data.InviteCode, error) {
+	if spec.Count == 0 {
+		spec.Count = 1
+	}
+	if spec.MaxUses == 0 {
+		spec.MaxUses = 1
+	}
+	if spec.Count < 1 || spec.Count > maxInviteCodeBatch {
+		return nil, &util.ValidationError{Field: "count", Message: fmt.Sprintf("must be between 1 and %d", maxInviteCodeBatch)}
+	}
+	if spec.MaxUses < 1 || spec.MaxUses > maxInviteCodeUses {
+		return nil, &util.ValidationError{Field: "max_uses", Message: fmt.Sprintf("must be between 1 and %d", maxInviteCodeUses)}
+	}
+	if spec.ExpiresAt != nil && !spec.ExpiresAt.After(time.Now()) {
+		return nil, &util.ValidationError{Field: "expires_at", Message: "must be in the future"}
+	}
+	note := strings.TrimSpace(spec.Note)
+	if len(note) > maxInviteCodeNote {
+		return nil, &util.ValidationError{Field: "note", Message: fmt.Sprintf("must be at most %d characters", maxInviteCodeNote)}
+	}
+	if spec.StartingBalance != nil {
+		if err := validateStartingBalance(*spec.StartingBalance); err != nil {
+			return nil, &util.ValidationError{Field: "starting_balance", Message: err.Error()}
+		}
+	}
+	league := strings.TrimSpace(spec.League)
+	if len(league) > maxLeagueName {
+		return nil, &util.ValidationError{Field: "league", Message: fmt.Sprintf("must be at most %d characters", maxLeagueName)}
+	}
+
+	codes := make([]data.InviteCode, spec.Count)
+	for i := range codes {
+		code, err := newInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = data.InviteCode{
+			Code:            code,
+			Note:            note,
+			MaxUses:         spec.MaxUses,
+			StartingBalance: spec.StartingBalance,
+			League:          league,
+			ExpiresAt:       spec.ExpiresAt,
+			CreatedBy:       createdBy,
+			Status:          data.InviteCodeActive,
+		}
+	}
+	if err := s.codes.Create(ctx, codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// List returns every invite code, newest first.
+func (s *InviteService) List(ctx context.Context) ([]data.InviteCode, error) {
+	return s.codes.List(ctx)
+}
+
+// Get returns code and the accounts it admitted.
+func (s *InviteService) Get(ctx context.Context, code string) (*InviteCodeDetail, error) {
+	c, err := s.codes.Get(ctx, normalizeInviteCode(code))
+	if errors.Is(err, data.ErrInviteCodeNotFound) {
+		return nil, &InviteCodeNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	redemptions, err := s.codes.Redemptions(ctx, c.Code)
+	if err != nil {
+		return nil, err
+	}
+	return &InviteCodeDetail{InviteCode: *c, Redemptions: redemptions}, nil
+}
+
+// Revoke stops code admitting new accounts. Accounts it already admitted
+// are unaffected.
+func (s *InviteService) Revoke(ctx context.Context, code string) error {
+	err := s.codes.Revoke(ctx, normalizeInviteCode(code))
+	if errors.Is(err, data.ErrInviteCodeNotFound) {
+		return &InviteCodeNotFoundError{}
+	}
+	return err
+}
+
+// inviteClaim is one use of an invite code, held while an account is
+// created. A nil claim (registration is open) does nothing.
+type inviteClaim struct {
+	codes  *data.InviteCodeStore
+	code   string
+	cohort *data.Cohort
+}
+
+// claim takes a use of code for a new account. It returns a nil claim when
+// registration is open, whatever code is given.
+func (s *InviteService) claim(ctx context.Context, code string) (*inviteClaim, error) {
+	if s == nil || !s.required {
+		return nil, nil
+	}
+	code = normalizeInviteCode(code)
+	if code == "" {
+		return nil, &InviteCodeRequiredError{}
+	}
+	cohort, ok, err := s.codes.Claim(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, &InvalidInviteCodeError{}
+	}
+	return &inviteClaim{codes: s.codes, code: code, cohort: cohort}, nil
+}
+
+// Cohort returns the cohort the new account joins, nil for the defaults.
+func (c *inviteClaim) Cohort() *data.Cohort {
+	if c == nil {
+		return nil
+	}
+	return c.cohort
+}
+
+// release gives the use back after the account could not be created.
+func (c *inviteClaim) release(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	if err := c.codes.Release(ctx, c.code); err != nil {
+		slog.Error("invite code release failed", "code", c.code, "err", err, "component", "invite")
+	}
+}
+
+// redeemed records that the claim admitted userID. A failure is logged
+// rather than returned: the account exists and the use is already counted.
+func (c *inviteClaim) redeemed(ctx context.Context, userID string) {
+	if c == nil {
+		return
+	}
+	if err := c.codes.RecordRedemption(ctx, c.code, userID); err != nil {
+		slog.Error("invite redemption record failed", "code", c.code, "user_id", userID, "err", err, "component", "invite")
+		return
+	}
+	slog.Info("invite code redeemed", "code", c.code, "user_id", userID, "component", "invite")
+}
+
+// normalizeInviteCode accepts a code typed in lower case or with spaces or
+// dashes between groups.
+func normalizeInviteCode(code string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+func newInviteCode() (string, error) {
+	b := make([]byte, inviteCodeLength)
+	n := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range b {
+		k, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return "", fmt.Errorf("generate invite code: %w", err)
+		}
+		b[i] = inviteCodeAlphabet[k.Int64()]
+	}
+	return string(b), nil
+}
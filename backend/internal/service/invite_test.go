@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestInviteGenerate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewInviteService(data.NewInviteCodeStore(db), true)
+
+	expires := time.Now().Add(48 * time.Hour)
+	balance := decimal.NewFromInt(2500)
+	mock.ExpectBegin()
+	for range 3 {
+		mock.ExpectQuery("INSERT INTO invite_codes").
+			WithArgs(sqlmock.AnyArg(), "Period 3", 1, &expires, "admin-1", &balance, "fall").
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	}
+	mock.ExpectCommit()
+
+	codes, err := svc.Generate(context.Background(), "admin-1", InviteCodeSpec{Count: 3, ExpiresAt: &expires, Note: " Period 3 ", StartingBalance: &balance, League: " fall "})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if len(codes) != 3 {
+		t.Fatalf("codes: got %d, want 3", len(codes))
+	}
+	for _, c := range codes {
+		if len(c.Code) != inviteCodeLength || strings.Trim(c.Code, inviteCodeAlphabet) != "" {
+			t.Errorf("malformed code %q", c.Code)
+		}
+		if c.Status != data.InviteCodeActive || c.MaxUses != 1 {
+			t.Errorf("code %s: got %+v", c.Code, c)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	bad := map[string]InviteCodeSpec{
+		"count":            {Count: maxInviteCodeBatch + 1},
+		"max_uses":         {MaxUses: -1},
+		"expires_at":       {ExpiresAt: &past},
+		"note":             {Note: strings.Repeat("x", maxInviteCodeNote+1)},
+		"starting_balance": {StartingBalance: ptr(decimal.RequireFromString("10.005"))},
+		"league":           {League: strings.Repeat("x", maxLeagueName+1)},
+	}
+	for field, spec := range bad {
+		var ve *util.ValidationError
+		if _, err := svc.Generate(context.Background(), "admin-1", spec); !errors.As(err, &ve) || ve.Field != field {
+			t.Errorf("%s: expected validation error, got %v", field, err)
+		}
+	}
+}
+
+func TestInviteClaim(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	// Open registration ignores codes and touches nothing.
+	if c, err := NewInviteService(data.NewInviteCodeStore(db), false).claim(ctx, "whatever"); c != nil || err != nil {
+		t.Errorf("open registration: got %v, %v", c, err)
+	}
+	var nilService *InviteService
+	if c, err := nilService.claim(ctx, ""); c != nil || err != nil {
+		t.Errorf("nil service: got %v, %v", c, err)
+	}
+
+	svc := NewInviteService(data.NewInviteCodeStore(db), true)
+	if _, err := svc.claim(ctx, "  "); !errors.As(err, new(*InviteCodeRequiredError)) {
+		t.Errorf("blank code: expected InviteCodeRequiredError, got %v", err)
+	}
+
+	mock.ExpectQuery("UPDATE invite_codes SET uses = uses \\+ 1").
+		WithArgs("USEDUP2345").
+		WillReturnRows(sqlmock.NewRows([]string{"starting_balance", "league"}))
+	if _, err := svc.claim(ctx, "usedup-2345"); !errors.As(err, new(*InvalidInviteCodeError)) {
+		t.Errorf("used-up code: expected InvalidInviteCodeError, got %v", err)
+	}
+
+	mock.ExpectQuery("UPDATE invite_codes SET uses = uses \\+ 1").
+		WithArgs("ABCDE23456").
+		WillReturnRows(sqlmock.NewRows([]string{"starting_balance", "league"}).AddRow(nil, ""))
+	mock.ExpectExec("UPDATE invite_codes SET uses = uses - 1").
+		WithArgs("ABCDE23456").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	c, err := svc.claim(ctx, "abcde 23456")
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	c.release(ctx)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGuestCreate_RedeemsInviteCode(t *testing.T) {
+	svc, mock, cleanup := newGuestService(t)
+	defer cleanup()
+	db, inviteMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc.SetInvites(NewInviteService(data.NewInviteCodeStore(db), true))
+
+	// The code's cohort sets the guest's balance and league.
+	inviteMock.ExpectQuery("UPDATE invite_codes SET uses = uses \\+ 1").
+		WithArgs("ABCDE23456").
+		WillReturnRows(sqlmock.NewRows([]string{"starting_balance", "league"}).AddRow("50000.00", "fall"))
+	mock.ExpectExec("INSERT INTO users .* 'guest', TRUE").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), decimal.RequireFromString("50000.00"), "fall").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WillReturnRows(userRow(data.User{ID: "guest-1", Balance: decimal.NewFromInt(10000), CreatedVia: "guest", IsGuest: true, GuestExpiresAt: ptr(time.Now().Add(time.Hour))}))
+	inviteMock.ExpectExec("INSERT INTO invite_code_redemptions").
+		WithArgs("ABCDE23456", "guest-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, _, err := svc.Create(context.Background(), "ABCDE23456"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, _, err := svc.Create(context.Background(), ""); !errors.As(err, new(*InviteCodeRequiredError)) {
+		t.Errorf("no code: expected InviteCodeRequiredError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet user expectations: %v", err)
+	}
+	if err := inviteMock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet invite expectations: %v", err)
+	}
+}
+
+func TestInviteCodeStatusAt(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	cases := map[string]data.InviteCode{
+		data.InviteCodeActive:  {MaxUses: 30, Uses: 29, ExpiresAt: &future},
+		data.InviteCodeUsedUp:  {MaxUses: 1, Uses: 1},
+		data.InviteCodeExpired: {MaxUses: 1, ExpiresAt: &past},
+		data.InviteCodeRevoked: {MaxUses: 1, ExpiresAt: &past, RevokedAt: &past},
+	}
+	for want, c := range cases {
+		if got := c.StatusAt(now); got != want {
+			t.Errorf("%+v: got %s, want %s", c, got, want)
+		}
+	}
+}
@@ -1,32 +1,156 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// ScopeSudo marks the short-lived elevation token issued by
+// POST /api/account/sudo. Scoped tokens are never accepted as sessions.
+const ScopeSudo = "sudo"
+
+// ScopeMagicLink marks the single-use login token emailed by
+// POST /api/account/magic-link.
+const ScopeMagicLink = "magic_link"
+
+// ScopeWebAuthn marks the token that carries passkey ceremony state between
+// the begin and finish requests.
+const ScopeWebAuthn = "webauthn"
+
+// ScopeAccountReset marks the confirmation token POST /api/account/reset
+// expects, issued by POST /api/account/reset/confirmation.
+const ScopeAccountReset = "account_reset"
+
+var errTokenScope = errors.New("token has the wrong scope")
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
+	// AuthTime is when the user last presented credentials (Unix seconds).
+	// Unlike IssuedAt it survives sliding refresh, so it can gate actions that
+	// need a recent login. Zero on tokens issued before the claim existed.
+	AuthTime int64 `json:"auth_time,omitempty"`
+	// Role is the account's role (data.RoleUser or data.RoleAdmin) when the
+	// token was issued; sliding refresh reads it again. Empty on tokens
+	// issued before the claim existed, which count as data.RoleUser.
+	Role string `json:"role,omitempty"`
+	// Scope is empty for session tokens and ScopeSudo for elevation tokens.
+	Scope string `json:"scope,omitempty"`
+	// Ceremony is the WebAuthn session data on ScopeWebAuthn tokens.
+	Ceremony json.RawMessage `json:"ceremony,omitempty"`
+	// IssuedAtMs is when a session token was issued, in Unix milliseconds.
+	// IssuedAt is whole seconds, too coarse for signing out everywhere to
+	// tell a token issued just before it from one issued by a sign-in in
+	// the same second. Zero on tokens issued before the claim existed.
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// issuedAt returns IssuedAtMs, falling back to IssuedAt for older tokens.
+func (c *Claims) issuedAt() time.Time {
+	if c.IssuedAtMs > 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// AuthenticatedAt returns AuthTime, falling back to IssuedAt for older tokens.
+func (c *Claims) AuthenticatedAt() time.Time {
+	if c.AuthTime > 0 {
+		return time.Unix(c.AuthTime, 0)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+var errNoSessionRevocations = errors.New("session revocation is not configured")
+
+// RoleSource looks up an account's current role. Satisfied by
+// *data.UserStore.
+type RoleSource interface {
+	GetRole(ctx context.Context, userID string) (string, error)
+}
+
+// AdminLockSource looks up when an admin's lock on an account ends.
+// Satisfied by *data.UserStore.
+type AdminLockSource interface {
+	GetAdminLockedUntil(ctx context.Context, userID string) (*time.Time, error)
+}
+
 type JWTService struct {
-	secretKey []byte
+	secretKey   []byte
+	revocations SessionRevocations
+	roles       RoleSource
+	adminLocks  AdminLockSource
 }
 
 func NewJWTService(secretKey string) *JWTService {
 	return &JWTService{secretKey: []byte(secretKey)}
 }
 
-func (j *JWTService) GenerateToken(userID, email string) (string, error) {
+// SetRevocations enables signing sessions out before their tokens expire.
+func (j *JWTService) SetRevocations(revocations SessionRevocations) {
+	j.revocations = revocations
+}
+
+// SetRoles makes sliding refresh re-read the account's role, so a role
+// change reaches a session within one refresh instead of at the next login.
+func (j *JWTService) SetRoles(roles RoleSource) {
+	j.roles = roles
+}
+
+// SetAdminLocks makes AdminLocked read admin locks, so a locked account's
+// tokens are refused on every request and not only at its next login.
+func (j *JWTService) SetAdminLocks(locks AdminLockSource) {
+	j.adminLocks = locks
+}
+
+// GenerateToken issues a token for a user who has just authenticated, starting
+// a new session: the token's jti identifies it until the user signs out.
+func (j *JWTService) GenerateToken(userID, email, role string) (string, error) {
+	return j.sign(userID, email, role, time.Now(), uuid.New().String())
+}
+
+// RefreshToken re-issues claims with a fresh expiry, keeping the original
+// authentication time and session. With SetRoles the role is read again and
+// claims.Role updated to it, so the request being served sees it too; if it
+// can't be read the token is not refreshed. Without SetRoles the role is
+// kept.
+func (j *JWTService) RefreshToken(ctx context.Context, claims *Claims) (string, error) {
+	if j.roles != nil {
+		role, err := j.roles.GetRole(ctx, claims.UserID)
+		if err != nil {
+			return "", err
+		}
+		claims.Role = role
+	}
+	return j.sign(claims.UserID, claims.Email, claims.Role, claims.AuthenticatedAt(), claims.ID)
+}
+
+func (j *JWTService) sign(userID, email, role string, authTime time.Time, sessionID string) (string, error) {
+	now := time.Now()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:     userID,
+		Email:      email,
+		Role:       role,
+		AuthTime:   authTime.Unix(),
+		IssuedAtMs: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(sessionTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -34,7 +158,194 @@ func (j *JWTService) GenerateToken(userID, email string) (string, error) {
 	return token.SignedString(j.secretKey)
 }
 
+// GenerateSudoToken issues an elevation token for userID valid for ttl.
+func (j *JWTService) GenerateSudoToken(userID string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &Claims{
+		UserID:   userID,
+		AuthTime: now.Unix(),
+		Scope:    ScopeSudo,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	return token, expiresAt, err
+}
+
+// GenerateMagicLinkToken issues a login token for userID valid for ttl. The
+// returned jti must be stored so the token can be used only once.
+func (j *JWTService) GenerateMagicLinkToken(userID string, ttl time.Duration) (token, jti string, expiresAt time.Time, err error) {
+	now := time.Now()
+	expiresAt = now.Add(ttl)
+	jti = uuid.New().String()
+	claims := &Claims{
+		UserID: userID,
+		Scope:  ScopeMagicLink,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	return token, jti, expiresAt, err
+}
+
+// ValidateMagicLinkToken validates a token from GenerateMagicLinkToken. It
+// checks the signature and expiry only; single use is enforced by the caller.
+func (j *JWTService) ValidateMagicLinkToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != ScopeMagicLink || claims.ID == "" {
+		return nil, errTokenScope
+	}
+	return claims, nil
+}
+
+// GenerateWebAuthnToken wraps passkey ceremony state so the client can hand
+// it back on the finish request. userID is empty for login ceremonies.
+func (j *JWTService) GenerateWebAuthnToken(userID string, ceremony []byte, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &Claims{
+		UserID:   userID,
+		Scope:    ScopeWebAuthn,
+		Ceremony: ceremony,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	return token, expiresAt, err
+}
+
+// ValidateWebAuthnToken validates a token from GenerateWebAuthnToken.
+func (j *JWTService) ValidateWebAuthnToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != ScopeWebAuthn || len(claims.Ceremony) == 0 {
+		return nil, errTokenScope
+	}
+	return claims, nil
+}
+
+// ValidateToken validates a session token. Elevation tokens are rejected so a
+// leaked sudo token can't be replayed as a login.
 func (j *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != "" {
+		return nil, errTokenScope
+	}
+	return claims, nil
+}
+
+// Revoked reports whether the session token carrying claims was signed out.
+// It fails open, logging, when the revocation store can't be read: an
+// outage shouldn't sign everyone out.
+func (j *JWTService) Revoked(ctx context.Context, claims *Claims) bool {
+	if j.revocations == nil {
+		return false
+	}
+	revoked, err := j.revocations.Revoked(ctx, claims)
+	if err != nil {
+		slog.Warn("failed to check session revocation", "user_id", claims.UserID, "err", err, "component", "auth")
+		return false
+	}
+	return revoked
+}
+
+// AdminLocked reports whether an admin has locked the account claims were
+// issued to. Like Revoked it fails open, logging, when the lock can't be
+// read.
+func (j *JWTService) AdminLocked(ctx context.Context, claims *Claims) bool {
+	if j.adminLocks == nil {
+		return false
+	}
+	until, err := j.adminLocks.GetAdminLockedUntil(ctx, claims.UserID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("failed to check admin lock", "user_id", claims.UserID, "err", err, "component", "auth")
+		}
+		return false
+	}
+	return until != nil && time.Now().Before(*until)
+}
+
+// RevokeSession signs out one session. Tokens issued before sessions had
+// an ID can't be revoked one at a time; sessionID is empty for those and
+// nothing is done.
+func (j *JWTService) RevokeSession(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	if j.revocations == nil {
+		return errNoSessionRevocations
+	}
+	return j.revocations.RevokeSession(ctx, sessionID)
+}
+
+// RevokeAllSessions signs userID out everywhere: every token issued to it
+// until now is refused. Signing in again starts a new session.
+func (j *JWTService) RevokeAllSessions(ctx context.Context, userID string) error {
+	if j.revocations == nil {
+		return errNoSessionRevocations
+	}
+	return j.revocations.RevokeUser(ctx, userID, time.Now())
+}
+
+// ValidateSudoToken validates an elevation token from GenerateSudoToken.
+func (j *JWTService) ValidateSudoToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != ScopeSudo {
+		return nil, errTokenScope
+	}
+	return claims, nil
+}
+
+// GenerateResetToken issues an account reset confirmation token for userID
+// valid for ttl.
+func (j *JWTService) GenerateResetToken(userID string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &Claims{
+		UserID: userID,
+		Scope:  ScopeAccountReset,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	return token, expiresAt, err
+}
+
+// ValidateResetToken validates a token from GenerateResetToken.
+func (j *JWTService) ValidateResetToken(tokenString string) (*Claims, error) {
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Scope != ScopeAccountReset {
+		return nil, errTokenScope
+	}
+	return claims, nil
+}
+
+func (j *JWTService) parse(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	// Pin the signing method explicitly. Without WithValidMethods, a future
 	// refactor that adds (say) an RSA key handler would risk algorithm-
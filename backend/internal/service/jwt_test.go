@@ -1,15 +1,18 @@
 package service
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"papertrader/internal/data"
 )
 
 func TestJWT_GenerateAndValidate(t *testing.T) {
 	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
-	token, err := svc.GenerateToken("user-123", "test@example.com")
+	token, err := svc.GenerateToken("user-123", "test@example.com", data.RoleUser)
 	if err != nil {
 		t.Fatalf("GenerateToken: %v", err)
 	}
@@ -48,7 +51,7 @@ func TestJWT_WrongSecret(t *testing.T) {
 	svc1 := NewJWTService("secret1-32-chars-long-xxxxxxxxxxx")
 	svc2 := NewJWTService("secret2-32-chars-long-xxxxxxxxxxx")
 
-	token, err := svc1.GenerateToken("user-1", "t@t.com")
+	token, err := svc1.GenerateToken("user-1", "t@t.com", data.RoleUser)
 	if err != nil {
 		t.Fatalf("GenerateToken: %v", err)
 	}
@@ -113,7 +116,7 @@ func TestJWT_RejectsHS512(t *testing.T) {
 // validation — the canonical signature check.
 func TestJWT_RejectsTamperedClaims(t *testing.T) {
 	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
-	tokenStr, err := svc.GenerateToken("user-1", "t@t.com")
+	tokenStr, err := svc.GenerateToken("user-1", "t@t.com", data.RoleUser)
 	if err != nil {
 		t.Fatalf("GenerateToken: %v", err)
 	}
@@ -144,7 +147,7 @@ func TestJWT_RejectsTamperedClaims(t *testing.T) {
 func TestJWT_IssuedAtPopulated(t *testing.T) {
 	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
 	before := time.Now().Add(-time.Second)
-	token, _ := svc.GenerateToken("user-1", "t@t.com")
+	token, _ := svc.GenerateToken("user-1", "t@t.com", data.RoleUser)
 	claims, err := svc.ValidateToken(token)
 	if err != nil {
 		t.Fatalf("ValidateToken: %v", err)
@@ -152,4 +155,143 @@ func TestJWT_IssuedAtPopulated(t *testing.T) {
 	if claims.IssuedAt == nil || claims.IssuedAt.Time.Before(before) {
 		t.Error("IssuedAt should be set to approximately now")
 	}
+	if ms := time.UnixMilli(claims.IssuedAtMs); ms.Before(before) || ms.Sub(claims.IssuedAt.Time) >= time.Second {
+		t.Errorf("iat_ms %v should be IssuedAt %v to the millisecond", ms, claims.IssuedAt.Time)
+	}
+}
+
+// TestJWT_RefreshKeepsAuthTime guards the sliding refresh: a refreshed token
+// must not look freshly authenticated, or re-authentication gates could be
+// bypassed just by keeping a session alive. It must also stay in its
+// session, so signing out revokes every copy, and keep its role.
+func TestJWT_RefreshKeepsAuthTime(t *testing.T) {
+	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	authTime := time.Now().Add(-20 * time.Hour).Truncate(time.Second)
+	old, err := svc.sign("user-1", "t@t.com", data.RoleAdmin, authTime, "session-1")
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	claims, err := svc.ValidateToken(old)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	refreshed, err := svc.RefreshToken(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	got, err := svc.ValidateToken(refreshed)
+	if err != nil {
+		t.Fatalf("ValidateToken(refreshed): %v", err)
+	}
+	if !got.AuthenticatedAt().Equal(authTime) {
+		t.Errorf("auth_time: got %v, want %v", got.AuthenticatedAt(), authTime)
+	}
+	if !got.IssuedAt.After(authTime) {
+		t.Error("refreshed token should have a new iat")
+	}
+	if got.ID != "session-1" {
+		t.Errorf("jti: got %q, want session-1", got.ID)
+	}
+	if got.Role != data.RoleAdmin {
+		t.Errorf("role: got %q, want %q", got.Role, data.RoleAdmin)
+	}
+}
+
+// fixedRole is a RoleSource that reports every account as role.
+type fixedRole string
+
+func (r fixedRole) GetRole(context.Context, string) (string, error) { return string(r), nil }
+
+// A demoted admin's session loses the role at its next refresh, not at the
+// next login.
+func TestJWT_RefreshRereadsRole(t *testing.T) {
+	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	svc.SetRoles(fixedRole(data.RoleUser))
+	old, err := svc.sign("user-1", "t@t.com", data.RoleAdmin, time.Now(), "session-1")
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	claims, err := svc.ValidateToken(old)
+	if err != nil {
+		t.Fatalf("ValidateToken: %v", err)
+	}
+
+	refreshed, err := svc.RefreshToken(context.Background(), claims)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	got, err := svc.ValidateToken(refreshed)
+	if err != nil {
+		t.Fatalf("ValidateToken(refreshed): %v", err)
+	}
+	if got.Role != data.RoleUser || claims.Role != data.RoleUser {
+		t.Errorf("role: token %q, claims %q, want %q", got.Role, claims.Role, data.RoleUser)
+	}
+}
+
+func TestJWT_SudoAndSessionTokensAreNotInterchangeable(t *testing.T) {
+	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+
+	session, err := svc.GenerateToken("user-1", "t@t.com", data.RoleUser)
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+	if _, err := svc.ValidateSudoToken(session); err == nil {
+		t.Error("session token must not validate as a sudo token")
+	}
+
+	sudo, _, err := svc.GenerateSudoToken("user-1", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateSudoToken: %v", err)
+	}
+	if _, err := svc.ValidateToken(sudo); err == nil {
+		t.Error("sudo token must not validate as a session token")
+	}
+	if _, err := svc.ValidateSudoToken(sudo); err != nil {
+		t.Errorf("ValidateSudoToken: %v", err)
+	}
+}
+
+func TestJWT_MagicLinkTokenIsScoped(t *testing.T) {
+	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+
+	link, jti, _, err := svc.GenerateMagicLinkToken("user-1", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateMagicLinkToken: %v", err)
+	}
+	if _, err := svc.ValidateToken(link); err == nil {
+		t.Error("magic-link token must not validate as a session token")
+	}
+	claims, err := svc.ValidateMagicLinkToken(link)
+	if err != nil {
+		t.Fatalf("ValidateMagicLinkToken: %v", err)
+	}
+	if claims.ID != jti || claims.UserID != "user-1" {
+		t.Errorf("unexpected claims %+v (jti %q)", claims, jti)
+	}
+
+	session, _ := svc.GenerateToken("user-1", "t@t.com", data.RoleUser)
+	if _, err := svc.ValidateMagicLinkToken(session); err == nil {
+		t.Error("session token must not validate as a magic-link token")
+	}
+}
+
+func TestJWT_WebAuthnTokenIsScoped(t *testing.T) {
+	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+
+	token, _, err := svc.GenerateWebAuthnToken("user-1", []byte(`{"challenge":"abc"}`), time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateWebAuthnToken: %v", err)
+	}
+	if _, err := svc.ValidateToken(token); err == nil {
+		t.Error("ceremony token must not validate as a session token")
+	}
+	claims, err := svc.ValidateWebAuthnToken(token)
+	if err != nil {
+		t.Fatalf("ValidateWebAuthnToken: %v", err)
+	}
+	if claims.UserID != "user-1" || string(claims.Ceremony) != `{"challenge":"abc"}` {
+		t.Errorf("unexpected claims %+v", claims)
+	}
 }
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// LockoutPolicy configures LockoutService. A zero Threshold disables
+// lockout.
+type LockoutPolicy struct {
+	Threshold     int           // consecutive wrong passwords that lock the account
+	Duration      time.Duration // the first lock; each further failure doubles it
+	MaxDuration   time.Duration // cap on a single lock
+	UnlockLinkTTL time.Duration // lifetime of the emailed unlock link
+}
+
+// lockDuration is how long the failures-th consecutive failure locks the
+// account for: Duration at the threshold, doubling with each failure after
+// the lock ends, up to MaxDuration.
+func (p LockoutPolicy) lockDuration(failures int) time.Duration {
+	d := p.Duration
+	for i := p.Threshold; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDuration)
+}
+
+// LockoutSender is the subset of EmailService used by LockoutService.
+type LockoutSender interface {
+	SendAccountLockedEmail(to, unlockToken string, until time.Time, linkTTL time.Duration) error
+}
+
+// LockoutService stops credential stuffing against a single account, which
+// the per-IP rate limiter can't see when the attempts come from many
+// addresses. After Threshold consecutive wrong passwords the account refuses
+// password logins until the lock ends, and the owner is emailed a
+// single-use login link that lifts it. Any successful login resets the
+// count. Passwordless sign-ins (Google, passkeys, magic links) are not
+// locked: they don't guess anything.
+//
+// An admin's lock (Lock) is separate and stronger: it refuses every way of
+// signing in and every session until it ends, and neither a login nor the
+// unlock link lifts it; only Unlock does.
+//
+// A locked account answers differently from an unknown email, so lockout
+// reveals that an address has an account once Threshold guesses were made
+// against it.
+type LockoutService struct {
+	users      *data.UserStore
+	jwtService *JWTService
+	email      LockoutSender
+	policy     LockoutPolicy
+	now        func() time.Time
+}
+
+// NewLockoutService builds the service. email may be nil, in which case
+// locks simply run out.
+func NewLockoutService(users *data.UserStore, jwtService *JWTService, email LockoutSender, policy LockoutPolicy) *LockoutService {
+	return &LockoutService{users: users, jwtService: jwtService, email: email, policy: policy, now: time.Now}
+}
+
+// Enabled reports whether accounts are ever locked.
+func (s *LockoutService) Enabled() bool {
+	return s.policy.Threshold > 0
+}
+
+// Check returns an AccountLockedError while userID is locked after failed
+// logins. It fails open, logging, when the lock can't be read: the password
+// is still checked.
+func (s *LockoutService) Check(ctx context.Context, userID string) error {
+	until, err := s.users.GetLockedUntil(ctx, userID)
+	if err != nil {
+		slog.Warn("failed to read account lock", "user_id", userID, "err", err, "component", "lockout")
+		return nil
+	}
+	if until != nil && s.now().Before(*until) {
+		return &AccountLockedError{Until: *until}
+	}
+	return nil
+}
+
+// CheckAdminLock returns an AdminLockedError while an admin's lock on
+// userID lasts. Every way of signing in calls it before issuing a session.
+// Unlike Check it fails closed. A nil service locks nothing.
+func (s *LockoutService) CheckAdminLock(ctx context.Context, userID string) error {
+	if s == nil {
+		return nil
+	}
+	until, err := s.users.GetAdminLockedUntil(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if until != nil && s.now().Before(*until) {
+		return &AdminLockedError{Until: *until}
+	}
+	return nil
+}
+
+// LoginSucceeded implements LoginObserver: the owner got in, so the count
+// starts over and the failed-login lock is lifted. An admin lock stays.
+func (s *LockoutService) LoginSucceeded(ctx context.Context, user *data.User) {
+	if err := s.users.ClearFailedLogins(ctx, user.ID); err != nil {
+		slog.Warn("failed to clear failed logins", "user_id", user.ID, "err", err, "component", "lockout")
+	}
+}
+
+// LoginFailed implements LoginObserver, locking the account once the
+// failures reach the threshold.
+func (s *LockoutService) LoginFailed(ctx context.Context, user *data.User) {
+	if !s.Enabled() {
+		return
+	}
+	failures, err := s.users.RecordFailedLogin(ctx, user.ID)
+	if err != nil {
+		slog.Warn("failed to record failed login", "user_id", user.ID, "err", err, "component", "lockout")
+		return
+	}
+	if failures < s.policy.Threshold {
+		return
+	}
+	until := s.now().Add(s.policy.lockDuration(failures))
+	if err := s.users.LockAccount(ctx, user.ID, until); err != nil {
+		slog.Error("failed to lock account", "user_id", user.ID, "err", err, "component", "lockout")
+		return
+	}
+	slog.Warn("account locked after failed logins", "user_id", user.ID, "failures", failures,
+		"until", until, "ip", ClientInfoFromContext(ctx).IP, "component", "lockout")
+	s.sendUnlockLink(ctx, user, until)
+}
+
+// Lock locks userID's account until until on an admin's say-so, whether or
+// not automatic lockout is on. No unlock link is sent: only Unlock lifts it.
+func (s *LockoutService) Lock(ctx context.Context, userID string, until time.Time) error {
+	return s.users.AdminLockAccount(ctx, userID, until)
+}
+
+// Unlock lifts any lock on userID, an admin's or the failed-login one, and
+// starts its failure count over.
+func (s *LockoutService) Unlock(ctx context.Context, userID string) error {
+	if err := s.users.ClearAdminLock(ctx, userID); err != nil {
+		return err
+	}
+	return s.users.ClearFailedLogins(ctx, userID)
+}
+
+// sendUnlockLink emails the owner a magic login link, which lifts the
+// failed-login lock when used (see LoginSucceeded). It replaces any login
+// link already sent.
+func (s *LockoutService) sendUnlockLink(ctx context.Context, user *data.User, until time.Time) {
+	if s.email == nil || user.Email == "" {
+		return
+	}
+	token, jti, expiresAt, err := s.jwtService.GenerateMagicLinkToken(user.ID, s.policy.UnlockLinkTTL)
+	if err != nil {
+		slog.Error("failed to sign unlock link", "user_id", user.ID, "err", err, "component", "lockout")
+		return
+	}
+	if err := s.users.SetMagicLinkToken(ctx, user.ID, jti, expiresAt); err != nil {
+		slog.Error("failed to store unlock link", "user_id", user.ID, "err", err, "component", "lockout")
+		return
+	}
+	if err := s.email.SendAccountLockedEmail(user.Email, token, until, s.policy.UnlockLinkTTL); err != nil {
+		slog.Warn("account locked email failed", "user_id", user.ID, "err", err, "component", "lockout")
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+func TestLockoutPolicy_DoublesUpToTheCap(t *testing.T) {
+	p := LockoutPolicy{Threshold: 5, Duration: 15 * time.Minute, MaxDuration: 2 * time.Hour}
+	for failures, want := range map[int]time.Duration{
+		5:  15 * time.Minute,
+		6:  30 * time.Minute,
+		7:  time.Hour,
+		8:  2 * time.Hour,
+		20: 2 * time.Hour,
+	} {
+		if got := p.lockDuration(failures); got != want {
+			t.Errorf("failure %d: locked for %v, want %v", failures, got, want)
+		}
+	}
+}
+
+// lockoutRecorder records account locked emails.
+type lockoutRecorder struct {
+	sentTo []string
+	until  time.Time
+}
+
+func (r *lockoutRecorder) SendAccountLockedEmail(to, _ string, until time.Time, _ time.Duration) error {
+	r.sentTo = append(r.sentTo, to)
+	r.until = until
+	return nil
+}
+
+func TestLockout_LocksAtThresholdAndEmailsUnlockLink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	rec := &lockoutRecorder{}
+	svc := NewLockoutService(data.NewUserStore(db), NewJWTService("testsecretkey-32-chars-long-xxxxx"), rec,
+		LockoutPolicy{Threshold: 3, Duration: 15 * time.Minute, MaxDuration: time.Hour, UnlockLinkTTL: 15 * time.Minute})
+	svc.now = func() time.Time { return now }
+	user := &data.User{ID: "user-1", Email: "a@example.com"}
+	ctx := context.Background()
+
+	// Under the threshold the failure is only counted.
+	mock.ExpectQuery("UPDATE users SET failed_logins = failed_logins \\+ 1").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_logins"}).AddRow(2))
+	svc.LoginFailed(ctx, user)
+
+	mock.ExpectQuery("UPDATE users SET failed_logins = failed_logins \\+ 1").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"failed_logins"}).AddRow(3))
+	mock.ExpectExec("UPDATE users SET locked_until = \\$1 WHERE id = \\$2").
+		WithArgs(now.Add(15*time.Minute), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET magic_link_jti = \\$1").WillReturnResult(sqlmock.NewResult(0, 1))
+	svc.LoginFailed(ctx, user)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if len(rec.sentTo) != 1 || rec.sentTo[0] != "a@example.com" || !rec.until.Equal(now.Add(15*time.Minute)) {
+		t.Errorf("locked email: sent to %v, until %v", rec.sentTo, rec.until)
+	}
+}
+
+func TestLockout_CheckRefusesUntilTheLockEnds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	svc := NewLockoutService(data.NewUserStore(db), nil, nil, LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour})
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	until := now.Add(time.Minute)
+	mock.ExpectQuery("SELECT locked_until FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"locked_until"}).AddRow(until))
+	var locked *AccountLockedError
+	if err := svc.Check(ctx, "user-1"); !errors.As(err, &locked) || !locked.Until.Equal(until) {
+		t.Fatalf("Check while locked: got %v, want an AccountLockedError", err)
+	}
+
+	now = until
+	mock.ExpectQuery("SELECT locked_until FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"locked_until"}).AddRow(until))
+	if err := svc.Check(ctx, "user-1"); err != nil {
+		t.Errorf("Check after the lock: %v", err)
+	}
+
+	// An unreadable lock doesn't block the login.
+	mock.ExpectQuery("SELECT locked_until FROM users").WillReturnError(errors.New("connection reset"))
+	if err := svc.Check(ctx, "user-1"); err != nil {
+		t.Errorf("Check with the database down: %v", err)
+	}
+}
+
+func TestLockout_AdminLockOutlastsLoginsUntilUnlock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	svc := NewLockoutService(data.NewUserStore(db), nil, nil, LockoutPolicy{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour})
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	until := now.Add(24 * time.Hour)
+	mock.ExpectExec("UPDATE users SET admin_locked_until = \\$1 WHERE id = \\$2").WithArgs(until, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := svc.Lock(ctx, "user-1", until); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+
+	// A login another way clears only the failed-login lock.
+	mock.ExpectExec("UPDATE users SET failed_logins = 0, locked_until = NULL").WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	svc.LoginSucceeded(ctx, &data.User{ID: "user-1"})
+	mock.ExpectQuery("SELECT admin_locked_until FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"admin_locked_until"}).AddRow(until))
+	var locked *AdminLockedError
+	if err := svc.CheckAdminLock(ctx, "user-1"); !errors.As(err, &locked) || !locked.Until.Equal(until) {
+		t.Fatalf("CheckAdminLock after a login: got %v, want an AdminLockedError", err)
+	}
+
+	// Unlike the failed-login lock, an unreadable admin lock refuses.
+	mock.ExpectQuery("SELECT admin_locked_until FROM users").WillReturnError(errors.New("connection reset"))
+	if err := svc.CheckAdminLock(ctx, "user-1"); err == nil {
+		t.Error("CheckAdminLock with the database down: got nil, want an error")
+	}
+
+	mock.ExpectExec("UPDATE users SET admin_locked_until = NULL").WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET failed_logins = 0, locked_until = NULL").WithArgs("user-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := svc.Unlock(ctx, "user-1"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	mock.ExpectQuery("SELECT admin_locked_until FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"admin_locked_until"}).AddRow(nil))
+	if err := svc.CheckAdminLock(ctx, "user-1"); err != nil {
+		t.Errorf("CheckAdminLock after Unlock: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// DefaultMagicLinkTTL is used until SetMagicLinks configures a policy.
+const DefaultMagicLinkTTL = 15 * time.Minute
+
+// MagicLinkPolicy configures passwordless login.
+type MagicLinkPolicy struct {
+	TTL        time.Duration // how long an emailed link stays valid
+	EmailLimit int           // link requests per window per email address
+	IPLimit    int           // link requests per window per client IP
+	Window     time.Duration
+}
+
+// SetMagicLinks sets the magic-link policy and the limiter that enforces its
+// request limits. limiter may be nil, which disables the limits.
+func (s *AuthService) SetMagicLinks(limiter RateLimiter, policy MagicLinkPolicy) {
+	s.magicLinkLimiter = limiter
+	s.magicLinks = policy
+}
+
+// RequestMagicLink emails a single-use login link to email.
+//
+// Like ResendVerificationEmail, the outcome is the same whether or not the
+// address has an account, so the endpoint can't be used to enumerate users.
+// The per-email limit is applied before the lookup for the same reason; it
+// is the only error returned.
+func (s *AuthService) RequestMagicLink(ctx context.Context, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if _, err := mail.ParseAddress(email); err != nil {
+		slog.Info("magic link: malformed email (silently ignored)")
+		return nil
+	}
+
+	if s.magicLinkLimiter != nil {
+		p := s.magicLinks
+		result, err := s.magicLinkLimiter.CheckLimitWithBucket(ctx, "magiclink", "email:"+email,
+			ClientInfoFromContext(ctx).IP, p.EmailLimit, p.IPLimit, p.Window)
+		if err != nil {
+			slog.Warn("magic link: rate limiter error; allowing request", "err", err)
+		} else if !result.Allowed {
+			return &MagicLinkRateLimitError{RetryAt: result.ResetTime}
+		}
+	}
+
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		slog.Info("magic link: no account for email (silently ignored)")
+		return nil
+	}
+	if s.emailService == nil {
+		slog.Warn("magic link: email service not configured; cannot send", "user_id", user.ID)
+		return nil
+	}
+
+	token, jti, expiresAt, err := s.jwtService.GenerateMagicLinkToken(user.ID, s.magicLinks.TTL)
+	if err != nil {
+		slog.Error("magic link: failed to sign token", "user_id", user.ID, "err", err)
+		return nil
+	}
+	if err := s.users.SetMagicLinkToken(ctx, user.ID, jti, expiresAt); err != nil {
+		slog.Error("magic link: failed to store token", "user_id", user.ID, "err", err)
+		return nil
+	}
+	if err := s.emailService.SendMagicLinkEmail(user.Email, token, s.magicLinks.TTL); err != nil {
+		slog.Error("magic link: send failed", "user_id", user.ID, "err", err)
+	}
+	return nil
+}
+
+// LoginWithMagicLink exchanges a magic-link token for a session token. The
+// link is consumed, so a second use fails.
+func (s *AuthService) LoginWithMagicLink(ctx context.Context, token string) (*data.User, string, error) {
+	claims, err := s.jwtService.ValidateMagicLinkToken(token)
+	if err != nil {
+		return nil, "", &InvalidMagicLinkError{}
+	}
+
+	// Checked before the link is spent, so it still works once an admin
+	// lock is lifted. The failed-login lock is what the link is for.
+	if err := s.lockout.CheckAdminLock(ctx, claims.UserID); err != nil {
+		return nil, "", err
+	}
+	ok, err := s.users.ConsumeMagicLinkToken(ctx, claims.UserID, claims.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ok {
+		return nil, "", &InvalidMagicLinkError{}
+	}
+
+	user, err := s.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, "", &UserNotFoundError{}
+	}
+	sessionToken, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, "", &TokenGenerationError{}
+	}
+	if s.logins != nil {
+		s.logins.LoginSucceeded(ctx, user)
+	}
+	return user, sessionToken, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func TestRequestMagicLink_RateLimitedPerEmail(t *testing.T) {
+	svc, mock, cleanup := newAuthService(t)
+	defer cleanup()
+	svc.SetMagicLinks(NewMemoryRateLimiter(RateLimitPolicy{}), MagicLinkPolicy{
+		TTL: time.Minute, EmailLimit: 2, IPLimit: 100, Window: time.Hour,
+	})
+
+	// Unknown address: each allowed request does a lookup and nothing else.
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").WillReturnRows(sqlmock.NewRows(authUserCols))
+		if err := svc.RequestMagicLink(context.Background(), "Someone@Example.com"); err != nil {
+			t.Fatalf("request %d: unexpected error %v", i+1, err)
+		}
+	}
+
+	// Case and whitespace variants share the per-email bucket.
+	err := svc.RequestMagicLink(context.Background(), " someone@example.com")
+	var limited *MagicLinkRateLimitError
+	if !errors.As(err, &limited) {
+		t.Fatalf("expected MagicLinkRateLimitError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestLoginWithMagicLink_SingleUse(t *testing.T) {
+	svc, mock, cleanup := newAuthService(t)
+	defer cleanup()
+
+	token, jti, _, err := svc.jwtService.GenerateMagicLinkToken("user-alice", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateMagicLinkToken: %v", err)
+	}
+
+	mock.ExpectExec("UPDATE users\\s+SET magic_link_jti = NULL").
+		WithArgs("user-alice", jti).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WithArgs("user-alice").
+		WillReturnRows(userRow(data.User{ID: "user-alice", Email: "alice@example.com", Balance: decimal.NewFromInt(100), EmailVerified: true}))
+	user, session, err := svc.LoginWithMagicLink(context.Background(), token)
+	if err != nil || user.ID != "user-alice" || session == "" {
+		t.Fatalf("first use: user=%v session=%q err=%v", user, session, err)
+	}
+
+	// Second use: the jti has been cleared, so nothing matches.
+	mock.ExpectExec("UPDATE users\\s+SET magic_link_jti = NULL").
+		WithArgs("user-alice", jti).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, _, err = svc.LoginWithMagicLink(context.Background(), token)
+	var invalid *InvalidMagicLinkError
+	if !errors.As(err, &invalid) {
+		t.Errorf("second use: expected InvalidMagicLinkError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestLoginWithMagicLink_RejectsSessionToken(t *testing.T) {
+	svc, _, cleanup := newAuthService(t)
+	defer cleanup()
+
+	session, _ := svc.jwtService.GenerateToken("user-alice", "alice@example.com", data.RoleUser)
+	_, _, err := svc.LoginWithMagicLink(context.Background(), session)
+	var invalid *InvalidMagicLinkError
+	if !errors.As(err, &invalid) {
+		t.Errorf("expected InvalidMagicLinkError, got %v", err)
+	}
+}
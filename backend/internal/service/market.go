@@ -2,15 +2,16 @@ package service
 
 import (
 	"context"
-	"encoding/json"
+	"database/sql"
+	"errors"
 	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
+	"golang.org/x/sync/singleflight"
 
 	"papertrader/internal/data"
 	"papertrader/internal/util"
@@ -25,29 +26,93 @@ const (
 	// DateLayoutMarketStack is the timestamp format MarketStack returns on response payloads.
 	DateLayoutMarketStack = "2006-01-02T15:04:05+0000"
 	MaxSymbolLength       = 10
+	// maxQuoteBatch is the most symbols fetched in one eod/latest call, and
+	// MarketStack's largest page.
+	maxQuoteBatch = 100
+
+	maxSymbolSearchQuery       = 100
+	defaultSymbolSearchResults = 10
+	maxSymbolSearchResults     = 50
 )
 
 type MarketService struct {
-	apiKey            string
+	provider          MarketDataProvider
 	stockCache        StockCache
 	historicalCache   HistoricalCache
 	stockHistoryStore *data.StockHistoryStore
+	guard             *quoteGuard
+	quarantine        *data.MarketQuarantineStore
+	aliases           SymbolResolver
+	chartCache        ChartCache
+	intradayTTL       time.Duration
+	companyCache      CompanyCache
+	lastPrices        *data.LastPriceStore
+	staleMaxAge       time.Duration
+	now               func() time.Time
+	// fetches collapses concurrent cache misses for the same quote or
+	// daily history into one provider call (see shareFetch).
+	fetches singleflight.Group
+}
+
+// SymbolResolver maps a symbol to the one it trades under now, following
+// ticker changes. Satisfied by *SymbolAliasService.
+type SymbolResolver interface {
+	Resolve(symbol string) string
 }
 
-func NewMarketService(apiKey string, stockCache StockCache, historicalCache HistoricalCache, stockHistoryStore *data.StockHistoryStore) *MarketService {
+// NewMarketService builds the service on top of provider, which every cache
+// miss goes to (see NewMarketDataProvider).
+func NewMarketService(provider MarketDataProvider, stockCache StockCache, historicalCache HistoricalCache, stockHistoryStore *data.StockHistoryStore) *MarketService {
 	return &MarketService{
-		apiKey:            apiKey,
+		provider:          provider,
 		stockCache:        stockCache,
 		historicalCache:   historicalCache,
 		stockHistoryStore: stockHistoryStore,
+		guard:             newQuoteGuard(),
+		now:               time.Now,
 	}
 }
 
+// SetLastPrices persists every quote fetched to store, and makes GetStock
+// fall back to the stored price, flagged Stale, when the provider fails and
+// the cache has nothing, as long as it was fetched within maxAge.
+func (s *MarketService) SetLastPrices(store *data.LastPriceStore, maxAge time.Duration) {
+	s.lastPrices = store
+	s.staleMaxAge = maxAge
+}
+
+// SetAliases makes quote and history lookups follow ticker changes: a
+// symbol that has been renamed is looked up as its new symbol, so holdings
+// and history under the old ticker keep their prices and charts.
+func (s *MarketService) SetAliases(aliases SymbolResolver) {
+	s.aliases = aliases
+}
+
+// current returns the symbol symbol trades under now.
+func (s *MarketService) current(symbol string) string {
+	if s.aliases == nil {
+		return symbol
+	}
+	return s.aliases.Resolve(symbol)
+}
+
 // DTOs for Service Layer
 type StockData struct {
 	Symbol string          `json:"symbol"`
 	Date   string          `json:"date"`
 	Price  decimal.Decimal `json:"price"`
+	// Currency is set on API responses, which may be converted out of USD.
+	Currency string `json:"currency,omitempty"`
+	// PriceDecimals is set on API responses: the places Price is shown to.
+	PriceDecimals *int32 `json:"price_decimals,omitempty"`
+	// ListingPrice is the exchange's own price, in ListingCurrency, for a
+	// listing not priced in USD; Price is then its conversion (see ListingFX).
+	ListingPrice    *decimal.Decimal `json:"listing_price,omitempty"`
+	ListingCurrency string           `json:"listing_currency,omitempty"`
+	// Stale marks the last known price, served while the provider is
+	// unavailable; FetchedAt is when it was fetched.
+	Stale     bool       `json:"stale,omitempty"`
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
 }
 
 type HistoricalData struct {
@@ -76,10 +141,11 @@ type HistoricalSeries struct {
 
 // GetStock retrieves stock data by symbol
 func (s *MarketService) GetStock(ctx context.Context, symbol string) (*StockData, error) {
-	symbol, err := util.ValidateSymbol(symbol)
+	symbol, err := util.ValidateQuoteSymbol(symbol)
 	if err != nil {
 		return nil, err
 	}
+	symbol = s.current(symbol)
 
 	today := time.Now().Format(DateLayoutUS)
 
@@ -95,16 +161,33 @@ func (s *MarketService) GetStock(ctx context.Context, symbol string) (*StockData
 		slog.Warn("stock cache unavailable", "symbol", symbol, "component", "market")
 	}
 
-	// Cache miss - fetch from external API
-	if s.apiKey == "" {
-		return nil, fmt.Errorf("API key not configured")
+	// Cache miss - fetch from the provider, once for every caller missing
+	// the same symbol at the same time
+	v, err := s.shareFetch(ctx, "quote:"+symbol, func(ctx context.Context) (any, error) {
+		return s.loadStock(ctx, symbol)
+	})
+	if err != nil {
+		if stale := s.lastKnownPrice(ctx, symbol, err); stale != nil {
+			return stale, nil
+		}
+		return nil, err
 	}
+	stockData := *v.(*StockData)
+	return &stockData, nil
+}
 
+// loadStock fetches symbol's quote from the provider, checks it, and
+// stores it as the last known price and in the cache.
+func (s *MarketService) loadStock(ctx context.Context, symbol string) (*StockData, error) {
 	stockData, err := s.fetchStockData(ctx, symbol)
 	if err != nil {
-		slog.Warn("MarketStack API call failed for GetStock", "symbol", symbol, "err", err)
+		slog.Warn("market data fetch failed for GetStock", "provider", s.provider.Name(), "symbol", symbol, "err", err)
 		return nil, err
 	}
+	if stockData, err = s.checkQuote(ctx, stockData); err != nil {
+		return nil, err
+	}
+	s.rememberPrice(ctx, stockData)
 
 	// Cache the result in Redis
 	if s.stockCache != nil {
@@ -112,13 +195,170 @@ func (s *MarketService) GetStock(ctx context.Context, symbol string) (*StockData
 			slog.Warn("failed to cache stock result", "symbol", symbol, "err", err, "component", "market")
 		}
 	}
-
 	return stockData, nil
 }
 
+// shareFetch runs fetch for key unless a fetch for key is already running,
+// in which case it waits for that one's result instead, so a burst of
+// requests for one symbol makes a single provider call. The result is
+// shared: callers must copy it before changing it. fetch runs detached from
+// ctx, bounded by MarketStackTimeout, so the caller that started it going
+// away doesn't fail the others; each caller still stops waiting when its
+// own ctx is done.
+func (s *MarketService) shareFetch(ctx context.Context, key string, fetch func(context.Context) (any, error)) (any, error) {
+	ch := s.fetches.DoChan(key, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), MarketStackTimeout)
+		defer cancel()
+		return fetch(fetchCtx)
+	})
+	select {
+	case res := <-ch:
+		if res.Shared {
+			slog.Debug("shared provider fetch", "key", key, "component", "market")
+		}
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// rememberPrice saves quote as its symbol's last known price.
+func (s *MarketService) rememberPrice(ctx context.Context, quote *StockData) {
+	if s.lastPrices == nil {
+		return
+	}
+	err := s.lastPrices.Save(ctx, data.LastPrice{Symbol: quote.Symbol, Price: quote.Price, QuoteDate: quote.Date, FetchedAt: s.now()})
+	if err != nil {
+		slog.Warn("failed to save last price", "symbol", quote.Symbol, "err", err, "component", "market")
+	}
+}
+
+// lastKnownPrice returns symbol's last known price, flagged stale, after
+// the provider failed with fetchErr, or nil when there is none recent
+// enough. A symbol the provider doesn't list gets none: that's an answer,
+// not an outage.
+func (s *MarketService) lastKnownPrice(ctx context.Context, symbol string, fetchErr error) *StockData {
+	if s.lastPrices == nil || s.staleMaxAge <= 0 || errors.Is(fetchErr, ErrSymbolNotFound) || ctx.Err() != nil {
+		return nil
+	}
+	last, err := s.lastPrices.Get(ctx, symbol)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.Warn("failed to read last price", "symbol", symbol, "err", err, "component", "market")
+		}
+		return nil
+	}
+	if s.now().Sub(last.FetchedAt) > s.staleMaxAge {
+		return nil
+	}
+	slog.Warn("serving stale price", "symbol", symbol, "fetched_at", last.FetchedAt, "component", "market")
+	fetchedAt := last.FetchedAt.UTC()
+	return &StockData{Symbol: symbol, Date: last.QuoteDate, Price: last.Price, Stale: true, FetchedAt: &fetchedAt}
+}
+
+// WarmQuotes loads the latest quote for each of symbols into the stock
+// cache, fetching those not already cached maxQuoteBatch at a time. It
+// returns how many quotes it cached; a failed batch is logged and skipped.
+func (s *MarketService) WarmQuotes(ctx context.Context, symbols []string) (int, error) {
+	return s.warmQuotes(ctx, symbols, "", 0)
+}
+
+// WarmQuotesUntil is WarmQuotes for quotes that won't move before expires,
+// such as closes between sessions: each is cached under date, the day
+// GetStock will look it up on, until expires.
+func (s *MarketService) WarmQuotesUntil(ctx context.Context, symbols []string, date string, expires time.Time) (int, error) {
+	ttl := expires.Sub(s.now())
+	if ttl <= 0 {
+		return 0, nil
+	}
+	return s.warmQuotes(ctx, symbols, date, ttl)
+}
+
+// warmQuotes caches the quotes of symbols not yet cached under date for
+// ttl. An empty date caches each under its own date, looking up today's;
+// a zero ttl is the cache's default.
+func (s *MarketService) warmQuotes(ctx context.Context, symbols []string, date string, ttl time.Duration) (int, error) {
+	if s.stockCache == nil {
+		return 0, fmt.Errorf("stock cache not configured")
+	}
+
+	lookup := date
+	if lookup == "" {
+		lookup = time.Now().Format(DateLayoutUS)
+	}
+	missing := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		symbol = s.current(symbol)
+		if slices.Contains(missing, symbol) {
+			continue
+		}
+		if cached, err := s.stockCache.GetStock(ctx, symbol, lookup); err == nil && cached != nil {
+			continue
+		}
+		missing = append(missing, symbol)
+	}
+
+	warmed := 0
+	for start := 0; start < len(missing); start += maxQuoteBatch {
+		if err := ctx.Err(); err != nil {
+			return warmed, err
+		}
+		batch := missing[start:min(start+maxQuoteBatch, len(missing))]
+		quotes, err := s.provider.GetQuote(ctx, batch)
+		if err != nil {
+			slog.Warn("quote warm-up batch failed", "symbols", len(batch), "err", err, "component", "market")
+			continue
+		}
+		for _, quote := range quotes {
+			if quote, err = s.checkQuote(ctx, quote); err != nil {
+				continue
+			}
+			s.rememberPrice(ctx, quote)
+			key := date
+			if key == "" {
+				key = quote.Date
+			}
+			if err := s.stockCache.SetStock(ctx, quote.Symbol, key, quote, ttl); err != nil {
+				slog.Warn("failed to cache stock result", "symbol", quote.Symbol, "err", err, "component", "market")
+				continue
+			}
+			warmed++
+		}
+	}
+	return warmed, nil
+}
+
 // GetBatchHistoricalData retrieves historical data for multiple symbols in a single request
-// This is more efficient than making individual requests for each symbol
+// This is more efficient than making individual requests for each symbol.
+// A renamed symbol is fetched as its new symbol, and its data is keyed
+// under both.
 func (s *MarketService) GetBatchHistoricalData(ctx context.Context, symbols []string) (map[string]*HistoricalData, error) {
+	if s.aliases == nil {
+		return s.getBatchHistoricalData(ctx, symbols)
+	}
+	renamed := make(map[string][]string)
+	current := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		if validated, err := util.ValidateQuoteSymbol(symbol); err == nil {
+			if now := s.current(validated); now != validated {
+				renamed[now] = append(renamed[now], validated)
+				symbol = now
+			}
+		}
+		current = append(current, symbol)
+	}
+	result, err := s.getBatchHistoricalData(ctx, current)
+	for now, olds := range renamed {
+		if hist, ok := result[now]; ok {
+			for _, old := range olds {
+				result[old] = hist
+			}
+		}
+	}
+	return result, err
+}
+
+func (s *MarketService) getBatchHistoricalData(ctx context.Context, symbols []string) (map[string]*HistoricalData, error) {
 	if len(symbols) == 0 {
 		return make(map[string]*HistoricalData), nil
 	}
@@ -126,7 +366,7 @@ func (s *MarketService) GetBatchHistoricalData(ctx context.Context, symbols []st
 	// Validate all symbols first
 	validatedSymbols := make([]string, 0, len(symbols))
 	for _, symbol := range symbols {
-		validated, err := util.ValidateSymbol(symbol)
+		validated, err := util.ValidateQuoteSymbol(symbol)
 		if err != nil {
 			slog.Debug("skipping invalid symbol in batch", "symbol", symbol, "err", err)
 			continue
@@ -166,7 +406,8 @@ func (s *MarketService) GetBatchHistoricalData(ctx context.Context, symbols []st
 		return result, nil
 	}
 
-	slog.Debug("fetching symbols from MarketStack API",
+	slog.Debug("fetching symbols from market data provider",
+		"provider", s.provider.Name(),
 		"fetch_count", len(symbolsToFetch),
 		"cached_count", len(validatedSymbols)-len(symbolsToFetch),
 	)
@@ -180,7 +421,7 @@ func (s *MarketService) GetBatchHistoricalData(ctx context.Context, symbols []st
 		}
 		batch := symbolsToFetch[i:end]
 
-		batchData, err := s.fetchBatchHistoricalStockData(ctx, batch, startDate, endDate)
+		batchData, err := s.fetchBatchHistoricalStockData(ctx, batch, sevenDaysAgo, yesterday)
 		if err != nil {
 			slog.Warn("batch historical fetch failed", "symbols", batch, "err", err, "component", "market")
 			// Continue with other batches even if one fails
@@ -202,126 +443,100 @@ func (s *MarketService) GetBatchHistoricalData(ctx context.Context, symbols []st
 	return result, nil
 }
 
-// fetchBatchHistoricalStockData fetches historical data for multiple symbols in one API call
-func (s *MarketService) fetchBatchHistoricalStockData(ctx context.Context, symbols []string, startDate, endDate string) (map[string]*HistoricalData, error) {
-	if s.apiKey == "" {
-		return nil, fmt.Errorf("API key not configured")
-	}
+// ClosesOn fetches each symbol's close for date straight from the provider,
+// skipping the cache, for re-running a past session whose data went bad.
+// Symbols with no close on date itself are left out.
+func (s *MarketService) ClosesOn(ctx context.Context, symbols []string, date time.Time) (map[string]*HistoricalData, error) {
+	want := date.Format(DateLayoutUS)
 
-	const baseURL = "https://api.marketstack.com/v1/eod"
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", baseURL, nil)
-	if err != nil {
-		return nil, err
+	result := make(map[string]*HistoricalData, len(symbols))
+	const batchSize = 5
+	for i := 0; i < len(symbols); i += batchSize {
+		batch := symbols[i:min(i+batchSize, len(symbols))]
+		batchData, err := s.fetchBatchHistoricalStockData(ctx, batch, date.AddDate(0, 0, -7), date)
+		if err != nil {
+			return result, err
+		}
+		for symbol, hist := range batchData {
+			if hist.Date == want {
+				result[symbol] = hist
+			}
+		}
 	}
+	return result, nil
+}
 
-	q := httpReq.URL.Query()
-	// MarketStack supports comma-separated symbols
-	q.Add("symbols", strings.Join(symbols, ","))
-	q.Add("date_from", startDate)
-	q.Add("date_to", endDate)
-	q.Add("access_key", s.apiKey)
-	httpReq.URL.RawQuery = q.Encode()
-
-	client := &http.Client{Timeout: MarketStackTimeout}
-	resp, err := client.Do(httpReq)
+// fetchBatchHistoricalStockData fetches daily closes for several symbols in
+// one provider call and builds each one's latest daily change.
+func (s *MarketService) fetchBatchHistoricalStockData(ctx context.Context, symbols []string, from, to time.Time) (map[string]*HistoricalData, error) {
+	bars, err := s.provider.GetEOD(ctx, BarRequest{Symbols: symbols, From: from, To: to, Limit: eodPageSize})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var apiResp struct {
-		Data []struct {
-			Symbol string  `json:"symbol"`
-			Date   string  `json:"date"`
-			Close  float64 `json:"close"`
-			Volume float64 `json:"volume"`
-		} `json:"data"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
-	}
-
-	if len(apiResp.Data) == 0 {
+	if len(bars) == 0 {
 		return nil, fmt.Errorf("no data returned from API")
 	}
 
 	// Group data by symbol
-	// MarketStack returns data sorted by date (most recent first) for all symbols
-	symbolData := make(map[string][]struct {
-		Date   string
-		Close  float64
-		Volume float64
-	})
-
-	for _, entry := range apiResp.Data {
-		symbolData[entry.Symbol] = append(symbolData[entry.Symbol], struct {
-			Date   string
-			Close  float64
-			Volume float64
-		}{
-			Date:   entry.Date,
-			Close:  entry.Close,
-			Volume: entry.Volume,
-		})
+	symbolData := make(map[string][]data.StockHistoryPoint)
+	for _, bar := range bars {
+		symbolData[bar.Symbol] = append(symbolData[bar.Symbol], eodPoint(bar.Symbol, bar))
 	}
 
 	// Process each symbol's data
 	result := make(map[string]*HistoricalData)
 	for _, symbol := range symbols {
-		data, exists := symbolData[symbol]
-		if !exists || len(data) < 2 {
-			slog.Debug("insufficient data for symbol in batch", "symbol", symbol, "days_returned", len(data))
+		points := s.sanitizeEOD(ctx, symbol, symbolData[symbol])
+		if len(points) < 2 {
+			slog.Debug("insufficient data for symbol in batch", "symbol", symbol, "days_returned", len(points))
 			continue
 		}
+		result[symbol] = dailyChange(symbol, points)
+	}
 
-		// MarketStack returns data sorted by date (most recent first)
-		// Use first 2 entries (latest and previous trading days)
-		latest := data[0]
-		previous := data[1]
-
-		// Convert from float64 (external API) to decimal at the boundary.
-		// -2 exponent snaps to 2dp (stock prices are natively 2dp).
-		latestDec := decimal.NewFromFloatWithExponent(latest.Close, -2)
-		prevDec := decimal.NewFromFloatWithExponent(previous.Close, -2)
+	return result, nil
+}
 
-		priceChange := latestDec.Sub(prevDec)
-		var changePercent decimal.Decimal
-		if !prevDec.IsZero() {
-			changePercent = priceChange.Div(prevDec).Mul(decimal.NewFromInt(100)).Round(2)
-		}
+// eodPoint converts one daily bar into a stock_history row for symbol.
+func eodPoint(symbol string, bar Bar) data.StockHistoryPoint {
+	return data.StockHistoryPoint{
+		Symbol:    symbol,
+		TradeDate: bar.Time,
+		Close:     bar.Close,
+		Volume:    bar.Volume,
+	}
+}
 
-		parsedDate, err := time.Parse(DateLayoutMarketStack, latest.Date)
-		if err != nil {
-			slog.Warn("failed to parse date for symbol", "symbol", symbol, "err", err, "component", "market")
-			continue
-		}
+// dailyChange builds the latest day's change from closes sorted oldest
+// first, of which there must be at least two.
+func dailyChange(symbol string, points []data.StockHistoryPoint) *HistoricalData {
+	latest := points[len(points)-1]
+	previous := points[len(points)-2]
 
-		result[symbol] = &HistoricalData{
-			Symbol:           symbol,
-			Date:             parsedDate.Format(DateLayoutUS),
-			PreviousPrice:    prevDec,
-			Price:            latestDec,
-			Volume:           int(latest.Volume),
-			Change:           priceChange.Round(2),
-			ChangePercentage: changePercent,
-		}
+	priceChange := latest.Close.Sub(previous.Close)
+	var changePercent decimal.Decimal
+	if !previous.Close.IsZero() {
+		changePercent = priceChange.Div(previous.Close).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+	return &HistoricalData{
+		Symbol:           symbol,
+		Date:             latest.TradeDate.Format(DateLayoutUS),
+		PreviousPrice:    previous.Close,
+		Price:            latest.Close,
+		Volume:           int(latest.Volume),
+		Change:           priceChange.Round(2),
+		ChangePercentage: changePercent,
 	}
-
-	return result, nil
 }
 
 // GetHistoricalData retrieves historical data
 // Requests last 7 days to ensure we get at least 2 trading days (accounting for weekends/holidays)
 func (s *MarketService) GetHistoricalData(ctx context.Context, symbol string) (*HistoricalData, error) {
-	symbol, err := util.ValidateSymbol(symbol)
+	symbol, err := util.ValidateQuoteSymbol(symbol)
 	if err != nil {
 		return nil, err
 	}
+	symbol = s.current(symbol)
 
 	now := time.Now()
 	// Request last 7 days to ensure we get at least 2 trading days even over weekends/holidays
@@ -342,150 +557,96 @@ func (s *MarketService) GetHistoricalData(ctx context.Context, symbol string) (*
 		slog.Warn("historical cache unavailable", "symbol", symbol, "component", "market")
 	}
 
-	// Cache miss - fetch from API
-	return s.fetchHistoricalStockData(ctx, symbol, startDate, endDate)
-}
-
-// Private helpers
-func (s *MarketService) fetchStockData(ctx context.Context, symbol string) (*StockData, error) {
-	const baseURL = "https://api.marketstack.com/v1/eod/latest"
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", baseURL, nil)
+	// Cache miss - fetch from the provider, shared like GetStock's
+	v, err := s.shareFetch(ctx, "eod:"+symbol+":"+startDate+":"+endDate, func(ctx context.Context) (any, error) {
+		return s.fetchHistoricalStockData(ctx, symbol, sevenDaysAgo, yesterday)
+	})
 	if err != nil {
 		return nil, err
 	}
+	hist := *v.(*HistoricalData)
+	return &hist, nil
+}
 
-	q := httpReq.URL.Query()
-	q.Add("symbols", symbol)
-	q.Add("access_key", s.apiKey)
-	httpReq.URL.RawQuery = q.Encode()
-	httpReq.Header.Set("Accept", "application/json")
-
-	client := &http.Client{Timeout: MarketStackTimeout}
-	resp, err := client.Do(httpReq)
+// LookupInstrument fetches reference data (name, listing venue, trading
+// status) for symbol from the provider. Not cached here — callers persist
+// the result in the instruments table.
+func (s *MarketService) LookupInstrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	symbol, err := util.ValidateSymbol(symbol)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return s.provider.Instrument(ctx, symbol)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
+// SearchSymbols returns up to limit listings whose symbol or name matches
+// query, as the provider ranks them. limit is clamped to
+// [1, maxSymbolSearchResults] with zero meaning defaultSymbolSearchResults.
+func (s *MarketService) SearchSymbols(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, &util.ValidationError{Field: "q", Message: "is required"}
 	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if len(query) > maxSymbolSearchQuery {
+		return nil, &util.ValidationError{Field: "q", Message: fmt.Sprintf("must be at most %d characters", maxSymbolSearchQuery)}
 	}
-
-	var apiResp struct {
-		Data []struct {
-			Close  float64 `json:"close"`
-			Symbol string  `json:"symbol"`
-			Date   string  `json:"date"`
-		} `json:"data"`
+	if limit <= 0 {
+		limit = defaultSymbolSearchResults
 	}
-
-	if err := json.Unmarshal(body, &apiResp); err != nil {
+	limit = min(limit, maxSymbolSearchResults)
+	matches, err := s.provider.Search(nonCritical(ctx), query, limit)
+	if err != nil {
+		slog.Warn("symbol search failed", "provider", s.provider.Name(), "query", query, "err", err)
 		return nil, err
 	}
+	return matches, nil
+}
 
-	if len(apiResp.Data) == 0 {
-		return nil, fmt.Errorf("no data found")
-	}
-
-	entry := apiResp.Data[0]
-	parsedDate, err := time.Parse(DateLayoutMarketStack, entry.Date)
+// Private helpers
+func (s *MarketService) fetchStockData(ctx context.Context, symbol string) (*StockData, error) {
+	quotes, err := s.provider.GetQuote(ctx, []string{symbol})
 	if err != nil {
-		return nil, fmt.Errorf("parse date %q: %w", entry.Date, err)
+		return nil, err
 	}
-
-	stockData := &StockData{
-		Symbol: entry.Symbol,
-		Price:  decimal.NewFromFloatWithExponent(entry.Close, -2),
-		Date:   parsedDate.Format(DateLayoutUS),
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("no data found")
 	}
-
-	slog.Info("MarketStack API call succeeded for GetStock", "symbol", symbol, "price", stockData.Price, "date", stockData.Date)
+	stockData := quotes[0]
+	slog.Info("market data fetch succeeded for GetStock", "provider", s.provider.Name(), "symbol", symbol, "price", stockData.Price, "date", stockData.Date)
 	return stockData, nil
 }
 
-func (s *MarketService) fetchHistoricalStockData(ctx context.Context, symbol, startDate, endDate string) (*HistoricalData, error) {
-	const baseURL = "https://api.marketstack.com/v1/eod"
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", baseURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	q := httpReq.URL.Query()
-	q.Add("symbols", symbol)
-	q.Add("date_from", startDate)
-	q.Add("date_to", endDate)
-	q.Add("access_key", s.apiKey)
-	httpReq.URL.RawQuery = q.Encode()
-
-	client := &http.Client{Timeout: MarketStackTimeout}
-	resp, err := client.Do(httpReq)
+func (s *MarketService) fetchHistoricalStockData(ctx context.Context, symbol string, from, to time.Time) (*HistoricalData, error) {
+	bars, err := s.provider.GetEOD(ctx, BarRequest{Symbols: []string{symbol}, From: from, To: to})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	var apiResp struct {
-		Data []struct {
-			Date   string  `json:"date"`
-			Close  float64 `json:"close"`
-			Volume float64 `json:"volume"`
-		} `json:"data"`
+	points := make([]data.StockHistoryPoint, 0, len(bars))
+	for _, bar := range bars {
+		points = append(points, eodPoint(symbol, bar))
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
-	}
-
-	if len(apiResp.Data) < 2 {
-		slog.Warn("insufficient historical data from MarketStack", "symbol", symbol, "days_returned", len(apiResp.Data), "days_needed", 2)
+	// The latest and previous trading days are the last two closes left
+	// after the sanity checks, even with weekends or holidays in the range.
+	points = s.sanitizeEOD(ctx, symbol, points)
+	if len(points) < 2 {
+		slog.Warn("insufficient historical data from provider", "provider", s.provider.Name(), "symbol", symbol, "days_returned", len(points), "days_needed", 2)
 		return nil, &InsufficientHistoricalDataError{}
 	}
+	response := dailyChange(symbol, points)
 
-	// MarketStack returns data sorted by date (most recent first)
-	// Use the first 2 entries (latest and previous trading days)
-	// This works even if there were weekends/holidays in the date range
-	latest := apiResp.Data[0]
-	previous := apiResp.Data[1]
-
-	// Convert from float64 (external API) to decimal at the boundary.
-	latestDec := decimal.NewFromFloatWithExponent(latest.Close, -2)
-	prevDec := decimal.NewFromFloatWithExponent(previous.Close, -2)
-
-	priceChange := latestDec.Sub(prevDec)
-	var changePercent decimal.Decimal
-	if !prevDec.IsZero() {
-		changePercent = priceChange.Div(prevDec).Mul(decimal.NewFromInt(100)).Round(2)
-	}
-
-	parsedDate, err := time.Parse(DateLayoutMarketStack, latest.Date)
-	if err != nil {
-		return nil, fmt.Errorf("parse latest date %q: %w", latest.Date, err)
-	}
-
-	response := &HistoricalData{
-		Symbol:           symbol,
-		Date:             parsedDate.Format(DateLayoutUS),
-		PreviousPrice:    prevDec,
-		Price:            latestDec,
-		Volume:           int(latest.Volume),
-		Change:           priceChange.Round(2),
-		ChangePercentage: changePercent,
-	}
-
-	slog.Info("MarketStack API call succeeded for GetHistoricalData",
+	slog.Info("market data fetch succeeded for GetHistoricalData",
+		"provider", s.provider.Name(),
 		"symbol", symbol,
 		"price", response.Price,
 		"change", response.Change,
 		"change_pct", response.ChangePercentage,
-		"trading_days", len(apiResp.Data),
+		"trading_days", len(points),
 	)
 
 	// Cache in Redis
 	if s.historicalCache != nil {
+		startDate, endDate := from.Format(DateLayoutISO), to.Format(DateLayoutISO)
 		if err := s.historicalCache.SetHistorical(ctx, symbol, startDate, endDate, response, 0); err != nil {
 			slog.Warn("failed to cache historical result", "symbol", symbol, "err", err, "component", "market")
 		}
@@ -496,7 +657,7 @@ func (s *MarketService) fetchHistoricalStockData(ctx context.Context, symbol, st
 
 const (
 	// MaxHistoricalSeriesDays caps how far back the chart endpoint will fetch.
-	// MarketStack free tier returns up to 1y of EOD per call; clamp here to avoid
+	// MarketStack's free tier returns up to 1y of EOD per call; clamp here to avoid
 	// surprising upstream costs.
 	MaxHistoricalSeriesDays = 365
 	// minSeriesDays guards against degenerate ranges (e.g. ?days=0).
@@ -505,15 +666,16 @@ const (
 
 // GetHistoricalSeries returns daily closes for [today-days, yesterday], serving
 // from the local stock_history table whenever possible and only hitting
-// MarketStack for the gap between the latest stored row and yesterday.
+// the provider for the gap between the latest stored row and yesterday.
 //
 // Without a stock_history_store wired in (e.g. tests), this falls back to a
 // pure-API fetch with no persistence.
 func (s *MarketService) GetHistoricalSeries(ctx context.Context, symbol string, days int) (*HistoricalSeries, error) {
-	symbol, err := util.ValidateSymbol(symbol)
+	symbol, err := util.ValidateQuoteSymbol(symbol)
 	if err != nil {
 		return nil, err
 	}
+	symbol = s.current(symbol)
 
 	if days <= 0 {
 		days = 90
@@ -540,6 +702,7 @@ func (s *MarketService) GetHistoricalSeries(ctx context.Context, symbol string,
 		if err != nil {
 			return nil, err
 		}
+		fetched = s.sanitizeEOD(ctx, symbol, fetched)
 		if len(fetched) == 0 {
 			return nil, &InsufficientHistoricalDataError{}
 		}
@@ -556,6 +719,7 @@ func (s *MarketService) GetHistoricalSeries(ctx context.Context, symbol string,
 		if ferr != nil {
 			return nil, ferr
 		}
+		fetched = s.sanitizeEOD(ctx, symbol, fetched)
 		if len(fetched) == 0 {
 			return nil, &InsufficientHistoricalDataError{}
 		}
@@ -596,16 +760,13 @@ func (s *MarketService) GetHistoricalSeries(ctx context.Context, symbol string,
 	return assembleSeries(symbol, from, to, stored), nil
 }
 
-// MarketStack pagination constants. eodPageSize matches the free-tier cap;
-// without paging a 1Y window would silently truncate to ~100 trading days.
-// eodMaxPages is sized as ceil(MaxHistoricalSeriesDays/eodPageSize) plus a
-// small buffer so a tight loop can never run away even if the API misbehaves.
-const (
-	eodPageSize = 100
-	eodMaxPages = 6
-)
+// eodMaxRows caps the bars one series fetch asks the provider for. Without
+// paging a 1Y window would silently truncate to a single page; the cap is
+// sized as MaxHistoricalSeriesDays plus a buffer so a tight paging loop can
+// never run away even if the API misbehaves.
+const eodMaxRows = 600
 
-// emptyRangeTTL is how long we trust a "MarketStack returned zero rows for this
+// emptyRangeTTL is how long we trust a "the provider returned zero rows for this
 // gap" memo. Six hours covers the full weekend if a Saturday request memos
 // Sat→Sun as empty, while still being short enough that Tuesday morning sees
 // Monday's close land within a single TTL window.
@@ -629,13 +790,13 @@ func (s *MarketService) fillGap(ctx context.Context, symbol string, from, to tim
 
 	fetched, err := s.fetchEODSeries(ctx, symbol, from, to)
 	if err != nil {
-		slog.Warn("MarketStack gap fetch failed; serving what we have",
+		slog.Warn("market data gap fetch failed; serving what we have",
 			"symbol", symbol, "from", fromStr, "to", toStr, "gap", label, "err", err)
 		return nil, false
 	}
 
 	if len(fetched) == 0 {
-		// MarketStack returned no rows. Memoize so we don't ask again for the
+		// The provider returned no rows. Memoize so we don't ask again for the
 		// duration of emptyRangeTTL — the typical case is Sat/Sun in the
 		// forward gap until Monday's close is published.
 		if s.historicalCache != nil {
@@ -644,6 +805,12 @@ func (s *MarketService) fillGap(ctx context.Context, symbol string, from, to tim
 		return nil, true
 	}
 
+	// Only closes that pass the sanity checks are persisted; a held-back
+	// close is fetched again with the next forward gap.
+	fetched = s.sanitizeEOD(ctx, symbol, fetched)
+	if len(fetched) == 0 {
+		return nil, true
+	}
 	if uerr := s.stockHistoryStore.UpsertMany(ctx, fetched); uerr != nil {
 		slog.Warn("stock_history UpsertMany failed",
 			"symbol", symbol, "rows", len(fetched), "gap", label, "err", uerr)
@@ -651,94 +818,20 @@ func (s *MarketService) fillGap(ctx context.Context, symbol string, from, to tim
 	return fetched, true
 }
 
-// fetchEODSeries pulls daily closes from MarketStack for [from, to] (inclusive)
-// and returns them as StockHistoryPoint rows ready to upsert. Paginates because
-// MarketStack's free tier caps each response at 100 results.
-// Returns an empty slice (not an error) when MarketStack returns no data.
+// fetchEODSeries pulls daily closes from the provider for [from, to]
+// (inclusive) and returns them as StockHistoryPoint rows ready to upsert.
+// Returns an empty slice (not an error) when the provider has no data.
 func (s *MarketService) fetchEODSeries(ctx context.Context, symbol string, from, to time.Time) ([]data.StockHistoryPoint, error) {
-	if s.apiKey == "" {
-		return nil, fmt.Errorf("API key not configured")
-	}
 	if from.After(to) {
 		return nil, nil
 	}
-
-	out := make([]data.StockHistoryPoint, 0, 256)
-	for pageIdx := 0; pageIdx < eodMaxPages; pageIdx++ {
-		rows, err := s.fetchEODPage(ctx, symbol, from, to, pageIdx*eodPageSize, eodPageSize)
-		if err != nil {
-			return nil, err
-		}
-		out = append(out, rows...)
-
-		// Short page (or empty) → no more results. When the true row count is
-		// an exact multiple of eodPageSize this still costs one extra request
-		// that returns zero rows; the eodMaxPages ceiling caps the wasted calls.
-		if len(rows) < eodPageSize {
-			break
-		}
-	}
-	return out, nil
-}
-
-// marketStackEODURL is overridable so HTTP-mock tests can point fetchEODPage
-// at an httptest.Server.
-var marketStackEODURL = "https://api.marketstack.com/v1/eod"
-
-// fetchEODPage runs a single paginated request to the MarketStack EOD endpoint.
-// Extracted so the response body has a single defer that runs on every exit.
-func (s *MarketService) fetchEODPage(ctx context.Context, symbol string, from, to time.Time, offset, limit int) ([]data.StockHistoryPoint, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", marketStackEODURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	q := httpReq.URL.Query()
-	q.Add("symbols", symbol)
-	q.Add("date_from", from.Format(DateLayoutISO))
-	q.Add("date_to", to.Format(DateLayoutISO))
-	q.Add("limit", fmt.Sprintf("%d", limit))
-	q.Add("offset", fmt.Sprintf("%d", offset))
-	q.Add("access_key", s.apiKey)
-	httpReq.URL.RawQuery = q.Encode()
-
-	client := &http.Client{Timeout: MarketStackTimeout}
-	resp, err := client.Do(httpReq)
+	bars, err := s.provider.GetEOD(ctx, BarRequest{Symbols: []string{symbol}, From: from, To: to, Limit: eodMaxRows})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var apiResp struct {
-		Data []struct {
-			Symbol string  `json:"symbol"`
-			Date   string  `json:"date"`
-			Close  float64 `json:"close"`
-			Volume float64 `json:"volume"`
-		} `json:"data"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return nil, err
-	}
-
-	out := make([]data.StockHistoryPoint, 0, len(apiResp.Data))
-	for _, entry := range apiResp.Data {
-		parsed, perr := time.Parse(DateLayoutMarketStack, entry.Date)
-		if perr != nil {
-			slog.Warn("skipping unparseable EOD date", "symbol", symbol, "date", entry.Date, "err", perr)
-			continue
-		}
-		date := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC)
-		out = append(out, data.StockHistoryPoint{
-			Symbol:    symbol,
-			TradeDate: date,
-			Close:     decimal.NewFromFloatWithExponent(entry.Close, -2),
-			Volume:    int64(entry.Volume),
-		})
+	out := make([]data.StockHistoryPoint, 0, len(bars))
+	for _, bar := range bars {
+		out = append(out, eodPoint(symbol, bar))
 	}
 	return out, nil
 }
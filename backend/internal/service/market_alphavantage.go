@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// alphaVantageURL is overridable so HTTP-mock tests can point the provider
+// at an httptest.Server.
+var alphaVantageURL = "https://www.alphavantage.co/query"
+
+// AlphaVantageTimeout caps each Alpha Vantage call.
+const AlphaVantageTimeout = 30 * time.Second
+
+// alphaVantageCompactDays is how far back the compact daily series (the
+// last 100 sessions) reaches with room to spare; older ranges need the
+// full series.
+const alphaVantageCompactDays = 140
+
+// alphaVantageIntervals maps our intraday intervals to Alpha Vantage's.
+var alphaVantageIntervals = map[string]string{
+	"1min": "1min", "5min": "5min", "15min": "15min", "30min": "30min", "1hour": "60min",
+}
+
+// alphaVantageMICs maps the exchange names Alpha Vantage reports to MICs.
+var alphaVantageMICs = map[string]string{
+	"NASDAQ": "XNAS", "NYSE": "XNYS", "NYSE ARCA": "ARCX", "NYSE MKT": "XASE", "AMEX": "XASE", "BATS": "BATS",
+	"LSE": "XLON", "TSX": "XTSE",
+}
+
+// alphaVantageSuffixes maps our exchange suffixes to the ones Alpha Vantage
+// puts on non-US tickers: VOD.XLON is VOD.LON there, SHOP.XTSE SHOP.TRT.
+var alphaVantageSuffixes = map[string]string{"XLON": "LON", "XTSE": "TRT"}
+
+// avSymbol returns symbol as Alpha Vantage names it.
+func avSymbol(symbol string) string {
+	if mic := util.SymbolExchange(symbol); mic != "" {
+		return strings.TrimSuffix(symbol, mic) + alphaVantageSuffixes[mic]
+	}
+	return symbol
+}
+
+// fromAVSymbol is the inverse of avSymbol.
+func fromAVSymbol(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	for mic, suffix := range alphaVantageSuffixes {
+		if base, ok := strings.CutSuffix(symbol, "."+suffix); ok {
+			return base + "." + mic
+		}
+	}
+	return symbol
+}
+
+// AlphaVantage is the MarketDataProvider backed by alphavantage.co. It
+// answers one symbol per call, so it suits a fallback better than a
+// primary, and reports no trading halts.
+type AlphaVantage struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewAlphaVantage builds the provider. client is the shared outbound
+// client; calls are capped at AlphaVantageTimeout on top of its own limit.
+func NewAlphaVantage(apiKey string, client *http.Client) *AlphaVantage {
+	return &AlphaVantage{apiKey: apiKey, client: util.ClientWithTimeout(client, AlphaVantageTimeout)}
+}
+
+func (a *AlphaVantage) Name() string { return ProviderAlphaVantage }
+
+// GetQuote asks GLOBAL_QUOTE for each symbol in turn.
+func (a *AlphaVantage) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	quotes := make([]*StockData, 0, len(symbols))
+	for _, symbol := range symbols {
+		var resp struct {
+			Quote struct {
+				Symbol string `json:"01. symbol"`
+				Price  string `json:"05. price"`
+				Day    string `json:"07. latest trading day"`
+			} `json:"Global Quote"`
+		}
+		err := a.query(ctx, url.Values{"function": {"GLOBAL_QUOTE"}, "symbol": {avSymbol(symbol)}}, &resp)
+		if errors.Is(err, ErrSymbolNotFound) || (err == nil && resp.Quote.Symbol == "") {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		price, err := decimal.NewFromString(resp.Quote.Price)
+		if err != nil {
+			return nil, fmt.Errorf("parse price %q: %w", resp.Quote.Price, err)
+		}
+		day, err := time.Parse(DateLayoutISO, resp.Quote.Day)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", resp.Quote.Day, err)
+		}
+		quotes = append(quotes, &StockData{
+			Symbol: fromAVSymbol(resp.Quote.Symbol),
+			Price:  price.Round(maxPriceDecimals),
+			Date:   day.Format(DateLayoutUS),
+		})
+	}
+	return quotes, nil
+}
+
+// avBar is one bar of an Alpha Vantage time series.
+type avBar struct {
+	Open   string `json:"1. open"`
+	High   string `json:"2. high"`
+	Low    string `json:"3. low"`
+	Close  string `json:"4. close"`
+	Volume string `json:"5. volume"`
+}
+
+// GetEOD asks TIME_SERIES_DAILY for each symbol, compact when the range
+// allows, keeping the sessions in [req.From, req.To].
+func (a *AlphaVantage) GetEOD(ctx context.Context, req BarRequest) ([]Bar, error) {
+	size := "compact"
+	if time.Since(req.From) > alphaVantageCompactDays*24*time.Hour {
+		size = "full"
+	}
+	from := time.Date(req.From.Year(), req.From.Month(), req.From.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(req.To.Year(), req.To.Month(), req.To.Day(), 0, 0, 0, 0, time.UTC)
+	var out []Bar
+	for _, symbol := range req.Symbols {
+		var resp struct {
+			Series map[string]avBar `json:"Time Series (Daily)"`
+		}
+		q := url.Values{"function": {"TIME_SERIES_DAILY"}, "symbol": {avSymbol(symbol)}, "outputsize": {size}}
+		if err := a.query(ctx, q, &resp); err != nil {
+			if errors.Is(err, ErrSymbolNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		for day, bar := range resp.Series {
+			t, err := time.Parse(DateLayoutISO, day)
+			if err != nil || t.Before(from) || t.After(to) {
+				continue
+			}
+			out = append(out, bar.bar(symbol, t))
+		}
+	}
+	return capBars(out, req.Limit), nil
+}
+
+// GetIntraday asks TIME_SERIES_INTRADAY for each symbol. Alpha Vantage
+// stamps bars in New York time.
+func (a *AlphaVantage) GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error) {
+	interval, ok := alphaVantageIntervals[req.Interval]
+	if !ok {
+		return nil, fmt.Errorf("alphavantage: unsupported interval %q", req.Interval)
+	}
+	to := req.To.AddDate(0, 0, 1)
+	var out []Bar
+	for _, symbol := range req.Symbols {
+		var resp map[string]json.RawMessage
+		q := url.Values{"function": {"TIME_SERIES_INTRADAY"}, "symbol": {avSymbol(symbol)}, "interval": {interval}, "outputsize": {"full"}}
+		if err := a.query(ctx, q, &resp); err != nil {
+			if errors.Is(err, ErrSymbolNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		var series map[string]avBar
+		if raw, ok := resp["Time Series ("+interval+")"]; ok {
+			if err := json.Unmarshal(raw, &series); err != nil {
+				return nil, err
+			}
+		}
+		for stamp, bar := range series {
+			t, err := time.ParseInLocation("2006-01-02 15:04:05", stamp, newYork)
+			if err != nil || t.Before(req.From) || !t.Before(to) {
+				continue
+			}
+			out = append(out, bar.bar(symbol, t.UTC()))
+		}
+	}
+	return capBars(out, req.Limit), nil
+}
+
+func (b avBar) bar(symbol string, t time.Time) Bar {
+	price := func(s string) decimal.Decimal {
+		d, _ := decimal.NewFromString(s)
+		return d.Round(maxPriceDecimals)
+	}
+	volume, _ := decimal.NewFromString(b.Volume)
+	return Bar{
+		Symbol: symbol,
+		Time:   t,
+		Open:   price(b.Open),
+		High:   price(b.High),
+		Low:    price(b.Low),
+		Close:  price(b.Close),
+		Volume: volume.IntPart(),
+	}
+}
+
+// capBars keeps at most limit bars; zero keeps them all.
+func capBars(bars []Bar, limit int) []Bar {
+	if limit > 0 && len(bars) > limit {
+		return bars[:limit]
+	}
+	return bars
+}
+
+// Search uses SYMBOL_SEARCH, which reports a region rather than a venue,
+// so matches have no exchange.
+func (a *AlphaVantage) Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	var resp struct {
+		Matches []struct {
+			Symbol string `json:"1. symbol"`
+			Name   string `json:"2. name"`
+		} `json:"bestMatches"`
+	}
+	if err := a.query(ctx, url.Values{"function": {"SYMBOL_SEARCH"}, "keywords": {query}}, &resp); err != nil {
+		if errors.Is(err, ErrSymbolNotFound) {
+			return []SymbolMatch{}, nil
+		}
+		return nil, err
+	}
+	matches := make([]SymbolMatch, 0, len(resp.Matches))
+	for _, m := range resp.Matches {
+		if m.Symbol == "" {
+			continue
+		}
+		matches = append(matches, SymbolMatch{Symbol: fromAVSymbol(m.Symbol), Name: strings.TrimSpace(m.Name)})
+	}
+	return matches[:min(len(matches), limit)], nil
+}
+
+// avOverview is the OVERVIEW response, shared by Instrument and Company.
+type avOverview struct {
+	Symbol      string `json:"Symbol"`
+	AssetType   string `json:"AssetType"` // "Common Stock", "ETF", ...
+	Name        string `json:"Name"`
+	Exchange    string `json:"Exchange"`
+	Sector      string `json:"Sector"`
+	Industry    string `json:"Industry"`
+	MarketCap   string `json:"MarketCapitalization"`
+	Description string `json:"Description"`
+}
+
+func (a *AlphaVantage) overview(ctx context.Context, symbol string) (*avOverview, error) {
+	var resp avOverview
+	if err := a.query(ctx, url.Values{"function": {"OVERVIEW"}, "symbol": {avSymbol(symbol)}}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Symbol == "" {
+		return nil, ErrSymbolNotFound
+	}
+	return &resp, nil
+}
+
+func (a *AlphaVantage) Instrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	o, err := a.overview(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	inst := &data.Instrument{
+		Symbol:    symbol,
+		Name:      strings.TrimSpace(o.Name),
+		Exchange:  alphaVantageMICs[strings.ToUpper(strings.TrimSpace(o.Exchange))],
+		AssetType: "stock",
+	}
+	if strings.EqualFold(strings.TrimSpace(o.AssetType), "ETF") {
+		inst.AssetType = "etf"
+	}
+	return inst, nil
+}
+
+func (a *AlphaVantage) Company(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	o, err := a.overview(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	profile := &CompanyProfile{
+		Symbol:      symbol,
+		Name:        strings.TrimSpace(o.Name),
+		Exchange:    strings.ToUpper(strings.TrimSpace(o.Exchange)),
+		Sector:      strings.TrimSpace(o.Sector),
+		Industry:    strings.TrimSpace(o.Industry),
+		Description: strings.TrimSpace(o.Description),
+	}
+	if marketCap, err := decimal.NewFromString(o.MarketCap); err == nil && marketCap.IsPositive() {
+		profile.MarketCap = &marketCap
+	}
+	return profile, nil
+}
+
+// query runs one Alpha Vantage function and decodes the answer into out.
+// Alpha Vantage reports problems with a 200 and a message in the body: an
+// "Error Message" for an unknown symbol, a "Note" or "Information" when
+// rate-limited. The key is stripped from transport errors as for MarketStack.
+func (a *AlphaVantage) query(ctx context.Context, q url.Values, out any) error {
+	if a.apiKey == "" {
+		return errNoAPIKey
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", alphaVantageURL, nil)
+	if err != nil {
+		return err
+	}
+	q.Set("apikey", a.apiKey)
+	httpReq.URL.RawQuery = q.Encode()
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			uerr.URL = util.RedactURL(httpReq.URL)
+		}
+		if ctx.Err() == nil {
+			return &ProviderUnavailableError{Provider: ProviderAlphaVantage, Err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return &ProviderUnavailableError{Provider: ProviderAlphaVantage, Err: err}
+	}
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("API returned status %d", resp.StatusCode)
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return &ProviderUnavailableError{Provider: ProviderAlphaVantage, Err: err}
+		}
+		return err
+	}
+
+	var notice struct {
+		Error       string `json:"Error Message"`
+		Note        string `json:"Note"`
+		Information string `json:"Information"`
+	}
+	if json.Unmarshal(body, &notice) == nil {
+		switch {
+		case strings.Contains(notice.Error, "apikey"):
+			return errors.New("alphavantage: API key rejected")
+		case notice.Error != "":
+			return ErrSymbolNotFound
+		case notice.Note != "" || notice.Information != "":
+			msg := strings.ReplaceAll(notice.Note+notice.Information, a.apiKey, util.Redacted)
+			return &ProviderUnavailableError{Provider: ProviderAlphaVantage, Err: errors.New(msg)}
+		}
+	}
+	return json.Unmarshal(body, out)
+}
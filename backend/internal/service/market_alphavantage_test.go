@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func withMockAlphaVantage(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	prev := alphaVantageURL
+	alphaVantageURL = srv.URL
+	t.Cleanup(func() {
+		alphaVantageURL = prev
+		srv.Close()
+	})
+}
+
+func TestAlphaVantage_GetQuote(t *testing.T) {
+	withMockAlphaVantage(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("function") != "GLOBAL_QUOTE" {
+			t.Errorf("function: got %q", r.URL.Query().Get("function"))
+		}
+		switch r.URL.Query().Get("symbol") {
+		case "IBM":
+			w.Write([]byte(`{"Global Quote":{"01. symbol":"IBM","05. price":"185.1200","07. latest trading day":"2026-10-15"}}`))
+		default:
+			w.Write([]byte(`{"Global Quote":{}}`))
+		}
+	})
+
+	quotes, err := NewAlphaVantage("test-key", http.DefaultClient).GetQuote(context.Background(), []string{"IBM", "ZZZZ"})
+	if err != nil {
+		t.Fatalf("GetQuote: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Symbol != "IBM" || quotes[0].Price.String() != "185.12" || quotes[0].Date != "10/15/2026" {
+		t.Errorf("got %+v", quotes)
+	}
+}
+
+func TestAlphaVantage_NoticesAreErrors(t *testing.T) {
+	body := `{"Note":"Thank you for using Alpha Vantage! Our standard API rate limit is 25 requests per day."}`
+	withMockAlphaVantage(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+	av := NewAlphaVantage("test-key", http.DefaultClient)
+
+	var unavailable *ProviderUnavailableError
+	if _, err := av.Company(context.Background(), "IBM"); !errors.As(err, &unavailable) {
+		t.Errorf("rate limit: got %v, want a ProviderUnavailableError", err)
+	}
+
+	body = `{"Error Message":"Invalid API call. Please retry or visit the documentation."}`
+	if _, err := av.Company(context.Background(), "ZZZZ"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("unknown symbol: got %v, want ErrSymbolNotFound", err)
+	}
+}
+
+func TestAlphaVantage_ExchangeSuffixes(t *testing.T) {
+	withMockAlphaVantage(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("symbol"); got != "VOD.LON" {
+			t.Errorf("symbol: got %q, want VOD.LON", got)
+		}
+		w.Write([]byte(`{"Global Quote":{"01. symbol":"VOD.LON","05. price":"71.3400","07. latest trading day":"2026-10-15"}}`))
+	})
+
+	quotes, err := NewAlphaVantage("test-key", http.DefaultClient).GetQuote(context.Background(), []string{"VOD.XLON"})
+	if err != nil || len(quotes) != 1 || quotes[0].Symbol != "VOD.XLON" {
+		t.Fatalf("GetQuote: got %v, %v", quotes, err)
+	}
+}
+
+func TestAlphaVantage_InstrumentAssetType(t *testing.T) {
+	withMockAlphaVantage(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("symbol") {
+		case "QQQ":
+			w.Write([]byte(`{"Symbol":"QQQ","AssetType":"ETF","Name":"Invesco QQQ Trust","Exchange":"NASDAQ"}`))
+		default:
+			w.Write([]byte(`{"Symbol":"AAPL","AssetType":"Common Stock","Name":"Apple Inc","Exchange":"NASDAQ"}`))
+		}
+	})
+
+	av := NewAlphaVantage("test-key", http.DefaultClient)
+	for symbol, want := range map[string]string{"QQQ": "etf", "AAPL": "stock"} {
+		inst, err := av.Instrument(context.Background(), symbol)
+		if err != nil || inst.AssetType != want || inst.Exchange != "XNAS" {
+			t.Errorf("%s: got %+v, %v; want asset type %s", symbol, inst, err, want)
+		}
+	}
+}
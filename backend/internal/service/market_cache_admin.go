@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/redis/go-redis/v9"
+
+	"papertrader/internal/util"
+)
+
+// Market cache kinds an admin can flush.
+const (
+	MarketCacheQuotes     = "quotes"
+	MarketCacheHistorical = "historical"
+	MarketCacheCharts     = "charts"
+	MarketCacheCompany    = "company"
+	MarketCacheNews       = "news"
+)
+
+// maxMarketCacheSymbols caps the symbols one invalidation names.
+const maxMarketCacheSymbols = 100
+
+// marketCachePatterns maps each kind to the keys it covers for a symbol,
+// "*" being every symbol.
+var marketCachePatterns = map[string]func(symbol string) []string{
+	MarketCacheQuotes: func(symbol string) []string { return []string{stockKey(symbol, "*")} },
+	MarketCacheHistorical: func(symbol string) []string {
+		return []string{historicalKey(symbol, "*", "*"), historicalEmptyKey(symbol, "*", "*")}
+	},
+	MarketCacheCharts:  func(symbol string) []string { return []string{chartKey(symbol, "*")} },
+	MarketCacheCompany: func(symbol string) []string { return []string{companyKey(symbol)} },
+	MarketCacheNews:    func(symbol string) []string { return []string{newsKey(symbol)} },
+}
+
+// MarketCacheKinds lists the kinds in the order they are flushed.
+var MarketCacheKinds = []string{MarketCacheQuotes, MarketCacheHistorical, MarketCacheCharts, MarketCacheCompany, MarketCacheNews}
+
+// MarketCacheInvalidation says what to flush: the named kinds (all of
+// them when empty) for Symbols, or for every symbol when All is set.
+type MarketCacheInvalidation struct {
+	Symbols []string
+	Kinds   []string
+	All     bool
+}
+
+// MarketCacheInvalidated reports a flush: the kinds and symbols it covered
+// and how many Redis keys it removed.
+type MarketCacheInvalidated struct {
+	Symbols []string `json:"symbols"`
+	Kinds   []string `json:"kinds"`
+	All     bool     `json:"all"`
+	Deleted int      `json:"deleted"`
+}
+
+// MarketCacheAdminService lets admins flush cached market data, say after
+// the provider served bad prices, without restarting Redis. A nil client
+// (no Redis configured) has nothing cached, so every flush removes nothing.
+type MarketCacheAdminService struct {
+	client *redis.Client
+}
+
+func NewMarketCacheAdminService(client *redis.Client) *MarketCacheAdminService {
+	return &MarketCacheAdminService{client: client}
+}
+
+// Invalidate flushes what req names. Keys are found with SCAN, so a flush
+// of every symbol doesn't block Redis while it walks the keyspace.
+func (s *MarketCacheAdminService) Invalidate(ctx context.Context, req MarketCacheInvalidation) (*MarketCacheInvalidated, error) {
+	kinds := req.Kinds
+	if len(kinds) == 0 {
+		kinds = MarketCacheKinds
+	}
+	for _, kind := range kinds {
+		if _, ok := marketCachePatterns[kind]; !ok {
+			return nil, &util.ValidationError{Field: "kinds", Message: fmt.Sprintf("unknown kind %q", kind)}
+		}
+	}
+
+	symbols := make([]string, 0, len(req.Symbols))
+	switch {
+	case req.All && len(req.Symbols) > 0:
+		return nil, &util.ValidationError{Field: "symbols", Message: "must be empty when all is set"}
+	case req.All:
+	case len(req.Symbols) == 0:
+		return nil, &util.ValidationError{Field: "symbols", Message: "is required unless all is set"}
+	case len(req.Symbols) > maxMarketCacheSymbols:
+		return nil, &util.ValidationError{Field: "symbols", Message: fmt.Sprintf("must have at most %d entries", maxMarketCacheSymbols)}
+	}
+	for _, raw := range req.Symbols {
+		symbol, err := util.ValidateQuoteSymbol(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(symbols, symbol) {
+			symbols = append(symbols, symbol)
+		}
+	}
+
+	result := &MarketCacheInvalidated{Symbols: symbols, Kinds: kinds, All: req.All}
+	if s.client == nil {
+		return result, nil
+	}
+	targets := symbols
+	if req.All {
+		targets = []string{"*"}
+	}
+	for _, kind := range kinds {
+		for _, symbol := range targets {
+			for _, pattern := range marketCachePatterns[kind](symbol) {
+				n, err := unlinkMatching(ctx, s.client, pattern)
+				result.Deleted += n
+				if err != nil {
+					return nil, fmt.Errorf("flush %s cache: %w", kind, err)
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMarketCacheAdmin_Invalidate(t *testing.T) {
+	svc := NewMarketCacheAdminService(nil)
+
+	got, err := svc.Invalidate(context.Background(), MarketCacheInvalidation{Symbols: []string{"aapl", "AAPL", "BRK.B"}})
+	if err != nil {
+		t.Fatalf("Invalidate: %v", err)
+	}
+	if want := []string{"AAPL", "BRK.B"}; !reflect.DeepEqual(got.Symbols, want) {
+		t.Errorf("symbols %v, want %v", got.Symbols, want)
+	}
+	if !reflect.DeepEqual(got.Kinds, MarketCacheKinds) {
+		t.Errorf("kinds %v, want every kind", got.Kinds)
+	}
+
+	if got, err := svc.Invalidate(context.Background(), MarketCacheInvalidation{Kinds: []string{MarketCacheCharts}, All: true}); err != nil || !got.All {
+		t.Errorf("all: %+v, %v", got, err)
+	}
+
+	many := make([]string, maxMarketCacheSymbols+1)
+	for i := range many {
+		many[i] = "AAPL"
+	}
+	if _, err := svc.Invalidate(context.Background(), MarketCacheInvalidation{Symbols: many}); err == nil {
+		t.Error("expected an error for too many symbols")
+	}
+	if _, err := svc.Invalidate(context.Background(), MarketCacheInvalidation{Symbols: []string{"not a symbol"}}); err == nil {
+		t.Error("expected an error for an invalid symbol")
+	}
+}
+
+func TestMarketCachePatterns(t *testing.T) {
+	want := map[string][]string{
+		MarketCacheQuotes:     {"stock:AAPL:*"},
+		MarketCacheHistorical: {"historical:AAPL:*:*", "historical-empty:AAPL:*:*"},
+		MarketCacheCharts:     {"chart:AAPL:*"},
+		MarketCacheCompany:    {"company:AAPL"},
+		MarketCacheNews:       {"news:AAPL"},
+	}
+	for kind, patterns := range want {
+		if got := marketCachePatterns[kind]("AAPL"); !reflect.DeepEqual(got, patterns) {
+			t.Errorf("%s: got %v, want %v", kind, got, patterns)
+		}
+	}
+}
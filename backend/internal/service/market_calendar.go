@@ -0,0 +1,189 @@
+package service
+
+import (
+	"time"
+	_ "time/tzdata" // exchange time zones must resolve in minimal containers
+)
+
+// MarketSession is one trading day's regular session.
+type MarketSession struct {
+	Open       time.Time `json:"open"`
+	Close      time.Time `json:"close"`
+	EarlyClose bool      `json:"early_close"`
+}
+
+// MarketCalendar knows when one exchange's regular session runs. The NYSE
+// session is 9:30 to 16:00 New York time on weekdays, closed on exchange
+// holidays and closing at 13:00 on the usual half days; London and Toronto
+// follow their own hours and holidays (see exchanges). Holidays are computed
+// from each exchange's rules rather than read from a table, so the calendar
+// needs no upkeep but will miss one-off closures (national days of mourning
+// and the like). Pre-market and after-hours sessions are not modelled.
+type MarketCalendar struct {
+	exchange *Exchange
+	loc      *time.Location
+	// venues holds every exchange's calendar by MIC, shared between them.
+	venues map[string]*MarketCalendar
+}
+
+// NewMarketCalendar returns the home exchange's calendar; ForSymbol and
+// ForExchange reach the others.
+func NewMarketCalendar() (*MarketCalendar, error) {
+	venues := make(map[string]*MarketCalendar, len(exchanges))
+	for mic, ex := range exchanges {
+		loc, err := time.LoadLocation(ex.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		venues[mic] = &MarketCalendar{exchange: ex, loc: loc, venues: venues}
+	}
+	return venues[HomeExchange], nil
+}
+
+// Exchange returns the exchange whose sessions c reports.
+func (c *MarketCalendar) Exchange() *Exchange {
+	return c.exchange
+}
+
+// ForExchange returns the calendar of the exchange with the given MIC.
+func (c *MarketCalendar) ForExchange(mic string) (*MarketCalendar, bool) {
+	cal, ok := c.venues[mic]
+	return cal, ok
+}
+
+// ForSymbol returns the calendar of the exchange symbol trades on.
+func (c *MarketCalendar) ForSymbol(symbol string) *MarketCalendar {
+	return c.venues[ExchangeOf(symbol).MIC]
+}
+
+// Session returns the regular session on the exchange's local calendar day
+// that contains t. ok is false on weekends and holidays.
+func (c *MarketCalendar) Session(t time.Time) (session MarketSession, ok bool) {
+	t = t.In(c.loc)
+	y, m, d := t.Date()
+	if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday || c.exchange.holiday(y, m, d) {
+		return MarketSession{}, false
+	}
+	closeAt := c.exchange.close
+	early := c.exchange.isEarlyClose(y, m, d)
+	if early {
+		closeAt = c.exchange.earlyClose
+	}
+	return MarketSession{
+		Open:       time.Date(y, m, d, c.exchange.open.hour, c.exchange.open.minute, 0, 0, c.loc),
+		Close:      time.Date(y, m, d, closeAt.hour, closeAt.minute, 0, 0, c.loc),
+		EarlyClose: early,
+	}, true
+}
+
+// IsOpen reports whether the regular session is running at t. The open is
+// inclusive and the close exclusive.
+func (c *MarketCalendar) IsOpen(t time.Time) bool {
+	s, ok := c.Session(t)
+	return ok && !t.Before(s.Open) && t.Before(s.Close)
+}
+
+// NextSession returns the first session that has not closed by t: the
+// current one while the market is open, otherwise the next to open.
+func (c *MarketCalendar) NextSession(t time.Time) MarketSession {
+	day := t.In(c.loc)
+	for {
+		if s, ok := c.Session(day); ok && t.Before(s.Close) {
+			return s
+		}
+		y, m, d := day.Date()
+		day = time.Date(y, m, d+1, 12, 0, 0, 0, c.loc)
+	}
+}
+
+// PreviousSession returns the last session that closed at or before t.
+func (c *MarketCalendar) PreviousSession(t time.Time) MarketSession {
+	day := t.In(c.loc)
+	for {
+		if s, ok := c.Session(day); ok && !t.Before(s.Close) {
+			return s
+		}
+		y, m, d := day.Date()
+		day = time.Date(y, m, d-1, 12, 0, 0, 0, c.loc)
+	}
+}
+
+// isNYSEHoliday reports whether the exchange is closed all day on the given
+// date. A holiday falling on a Saturday is observed on the Friday before and
+// one on a Sunday on the Monday after. New Year's Day on a Saturday is not
+// observed at all, which falls out of only ever comparing against the
+// date's own year.
+func isNYSEHoliday(y int, m time.Month, d int) bool {
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	observed := func(month time.Month, day int) bool {
+		h := time.Date(y, month, day, 0, 0, 0, 0, time.UTC)
+		switch h.Weekday() {
+		case time.Saturday:
+			h = h.AddDate(0, 0, -1)
+		case time.Sunday:
+			h = h.AddDate(0, 0, 1)
+		}
+		return h.Equal(date)
+	}
+
+	switch {
+	case observed(time.January, 1),
+		date.Equal(nthWeekday(y, time.January, time.Monday, 3)),  // Martin Luther King Jr. Day
+		date.Equal(nthWeekday(y, time.February, time.Monday, 3)), // Washington's Birthday
+		date.Equal(easterSunday(y).AddDate(0, 0, -2)),            // Good Friday
+		date.Equal(lastWeekday(y, time.May, time.Monday)),        // Memorial Day
+		y >= 2022 && observed(time.June, 19),                     // Juneteenth
+		observed(time.July, 4),
+		date.Equal(nthWeekday(y, time.September, time.Monday, 1)),  // Labor Day
+		date.Equal(nthWeekday(y, time.November, time.Thursday, 4)), // Thanksgiving
+		observed(time.December, 25):
+		return true
+	}
+	return false
+}
+
+// isNYSEEarlyClose reports whether the session on the given date ends at
+// 13:00: the day after Thanksgiving, and 3 July and 24 December when they
+// fall Monday to Thursday.
+func isNYSEEarlyClose(y int, m time.Month, d int) bool {
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if date.Equal(nthWeekday(y, time.November, time.Thursday, 4).AddDate(0, 0, 1)) {
+		return true
+	}
+	if (m == time.July && d == 3) || (m == time.December && d == 24) {
+		wd := date.Weekday()
+		return wd >= time.Monday && wd <= time.Thursday
+	}
+	return false
+}
+
+// nthWeekday returns the nth (1-based) given weekday of the month.
+func nthWeekday(y int, m time.Month, wd time.Weekday, n int) time.Time {
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(wd) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// lastWeekday returns the last given weekday of the month.
+func lastWeekday(y int, m time.Month, wd time.Weekday) time.Time {
+	last := time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(wd) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easterSunday returns Western Easter for year y (anonymous Gregorian
+// algorithm).
+func easterSunday(y int) time.Time {
+	a := y % 19
+	b, c := y/100, y%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(y, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
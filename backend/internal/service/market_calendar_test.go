@@ -0,0 +1,198 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func newCalendar(t *testing.T) *MarketCalendar {
+	t.Helper()
+	cal, err := NewMarketCalendar()
+	if err != nil {
+		t.Fatalf("NewMarketCalendar: %v", err)
+	}
+	return cal
+}
+
+// ny returns a New York wall-clock time.
+func ny(cal *MarketCalendar, y int, m time.Month, d, hour, minute int) time.Time {
+	return time.Date(y, m, d, hour, minute, 0, 0, cal.loc)
+}
+
+func TestMarketCalendar_Holidays(t *testing.T) {
+	cal := newCalendar(t)
+	closed := []struct {
+		name string
+		y    int
+		m    time.Month
+		d    int
+	}{
+		{"New Year's Day", 2026, time.January, 1},
+		{"New Year's Day on a Sunday", 2023, time.January, 2},
+		{"Martin Luther King Jr. Day", 2026, time.January, 19},
+		{"Washington's Birthday", 2026, time.February, 16},
+		{"Good Friday", 2026, time.April, 3},
+		{"Memorial Day", 2026, time.May, 25},
+		{"Juneteenth", 2026, time.June, 19},
+		{"Independence Day on a Saturday", 2026, time.July, 3},
+		{"Labor Day", 2026, time.September, 7},
+		{"Thanksgiving", 2026, time.November, 26},
+		{"Christmas", 2026, time.December, 25},
+		{"Christmas on a Sunday", 2022, time.December, 26},
+	}
+	for _, tc := range closed {
+		if _, ok := cal.Session(ny(cal, tc.y, tc.m, tc.d, 12, 0)); ok {
+			t.Errorf("%s (%d-%02d-%02d): expected no session", tc.name, tc.y, tc.m, tc.d)
+		}
+	}
+
+	open := []struct {
+		name string
+		y    int
+		m    time.Month
+		d    int
+	}{
+		// New Year's Day 2022 was a Saturday and was not observed.
+		{"31 December before a Saturday New Year", 2021, time.December, 31},
+		{"Juneteenth before it was a holiday", 2021, time.June, 18},
+		{"ordinary Monday", 2026, time.March, 2},
+	}
+	for _, tc := range open {
+		if _, ok := cal.Session(ny(cal, tc.y, tc.m, tc.d, 12, 0)); !ok {
+			t.Errorf("%s (%d-%02d-%02d): expected a session", tc.name, tc.y, tc.m, tc.d)
+		}
+	}
+}
+
+func TestMarketCalendar_EarlyCloses(t *testing.T) {
+	cal := newCalendar(t)
+	for _, d := range []time.Time{
+		ny(cal, 2026, time.November, 27, 12, 0), // day after Thanksgiving
+		ny(cal, 2026, time.December, 24, 12, 0), // Thursday
+		ny(cal, 2025, time.July, 3, 12, 0),      // Thursday
+	} {
+		s, ok := cal.Session(d)
+		if !ok || !s.EarlyClose || s.Close.Hour() != 13 {
+			t.Errorf("%s: got (%+v, %v), want a 13:00 close", d.Format(DateLayoutISO), s, ok)
+		}
+	}
+	if s, ok := cal.Session(ny(cal, 2026, time.March, 2, 12, 0)); !ok || s.EarlyClose || s.Close.Hour() != 16 {
+		t.Errorf("ordinary day: got (%+v, %v), want a 16:00 close", s, ok)
+	}
+}
+
+func TestMarketCalendar_IsOpen(t *testing.T) {
+	cal := newCalendar(t)
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{ny(cal, 2026, time.March, 2, 9, 29), false},
+		{ny(cal, 2026, time.March, 2, 9, 30), true},
+		{ny(cal, 2026, time.March, 2, 15, 59), true},
+		{ny(cal, 2026, time.March, 2, 16, 0), false},
+		{ny(cal, 2026, time.March, 7, 12, 0), false}, // Saturday
+		{ny(cal, 2026, time.November, 27, 13, 30), false},
+		// 14:45 UTC is 9:45 in New York during standard time.
+		{time.Date(2026, time.March, 2, 14, 45, 0, 0, time.UTC), true},
+	}
+	for _, tc := range cases {
+		if got := cal.IsOpen(tc.at); got != tc.want {
+			t.Errorf("IsOpen(%s) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+}
+
+func TestMarketCalendar_NextSession(t *testing.T) {
+	cal := newCalendar(t)
+	cases := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"before the open", ny(cal, 2026, time.March, 2, 8, 0), ny(cal, 2026, time.March, 2, 9, 30)},
+		{"during the session", ny(cal, 2026, time.March, 2, 11, 0), ny(cal, 2026, time.March, 2, 9, 30)},
+		{"after the close", ny(cal, 2026, time.March, 2, 17, 0), ny(cal, 2026, time.March, 3, 9, 30)},
+		{"Good Friday weekend", ny(cal, 2026, time.April, 2, 16, 30), ny(cal, 2026, time.April, 6, 9, 30)},
+	}
+	for _, tc := range cases {
+		if got := cal.NextSession(tc.at).Open; !got.Equal(tc.want) {
+			t.Errorf("%s: next open %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMarketCalendar_PreviousSession(t *testing.T) {
+	cal := newCalendar(t)
+	cases := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"at the close", ny(cal, 2026, time.March, 3, 16, 0), ny(cal, 2026, time.March, 3, 16, 0)},
+		{"during the session", ny(cal, 2026, time.March, 3, 11, 0), ny(cal, 2026, time.March, 2, 16, 0)},
+		{"over the weekend", ny(cal, 2026, time.March, 8, 12, 0), ny(cal, 2026, time.March, 6, 16, 0)},
+		{"Good Friday weekend", ny(cal, 2026, time.April, 6, 9, 0), ny(cal, 2026, time.April, 2, 16, 0)},
+	}
+	for _, tc := range cases {
+		if got := cal.PreviousSession(tc.at).Close; !got.Equal(tc.want) {
+			t.Errorf("%s: previous close %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMarketCalendar_LondonAndToronto(t *testing.T) {
+	home := newCalendar(t)
+	london, toronto := home.ForSymbol("VOD.XLON"), home.ForSymbol("SHOP.XTSE")
+	if london.Exchange().MIC != "XLON" || toronto.Exchange().MIC != "XTSE" || home.ForSymbol("AAPL") != home {
+		t.Fatalf("ForSymbol: got %s, %s", london.Exchange().MIC, toronto.Exchange().MIC)
+	}
+	local := func(cal *MarketCalendar, y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 12, 0, 0, 0, cal.loc)
+	}
+
+	cases := []struct {
+		name   string
+		cal    *MarketCalendar
+		y      int
+		m      time.Month
+		d      int
+		closed bool
+	}{
+		{"London: Easter Monday", london, 2026, time.April, 6, true},
+		{"London: Early May bank holiday", london, 2026, time.May, 4, true},
+		{"London: Summer bank holiday", london, 2026, time.August, 31, true},
+		{"London: New Year's Day on a Saturday", london, 2022, time.January, 3, true},
+		{"London: Christmas on a Sunday", london, 2022, time.December, 27, true},
+		{"London: Boxing Day on a Saturday", london, 2026, time.December, 28, true},
+		{"London: Thanksgiving", london, 2026, time.November, 26, false},
+		{"Toronto: Family Day", toronto, 2026, time.February, 16, true},
+		{"Toronto: Victoria Day", toronto, 2026, time.May, 18, true},
+		{"Toronto: Canada Day on a Saturday", toronto, 2023, time.July, 3, true},
+		{"Toronto: Thanksgiving", toronto, 2026, time.October, 12, true},
+		{"Toronto: Easter Monday", toronto, 2026, time.April, 6, false},
+		{"Toronto: Independence Day", toronto, 2025, time.July, 4, false},
+	}
+	for _, tc := range cases {
+		if _, ok := tc.cal.Session(local(tc.cal, tc.y, tc.m, tc.d)); ok == tc.closed {
+			t.Errorf("%s (%d-%02d-%02d): session %v, want %v", tc.name, tc.y, tc.m, tc.d, ok, !tc.closed)
+		}
+	}
+
+	s, _ := london.Session(local(london, 2026, time.March, 2))
+	if s.Open.Hour() != 8 || s.Close.Hour() != 16 || s.Close.Minute() != 30 {
+		t.Errorf("London session: got %s to %s, want 08:00 to 16:30", s.Open, s.Close)
+	}
+	if s, _ := london.Session(local(london, 2026, time.December, 24)); !s.EarlyClose || s.Close.Hour() != 12 || s.Close.Minute() != 30 {
+		t.Errorf("London Christmas Eve: got %+v, want a 12:30 close", s)
+	}
+	if s, _ := toronto.Session(local(toronto, 2026, time.December, 24)); !s.EarlyClose || s.Close.Hour() != 13 {
+		t.Errorf("Toronto Christmas Eve: got %+v, want a 13:00 close", s)
+	}
+	// 10:00 in New York is 15:00 in London, inside both sessions; two hours
+	// later London has closed.
+	at := ny(home, 2026, time.March, 2, 10, 0)
+	if !london.IsOpen(at) || !toronto.IsOpen(at) || london.IsOpen(at.Add(2*time.Hour)) {
+		t.Errorf("IsOpen at %s: london %v, toronto %v", at, london.IsOpen(at), toronto.IsOpen(at))
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+// Candle is one bar of a chart. Time is the bar's start: the minute for an
+// intraday bar, midnight UTC of the day (or the week's first day) otherwise.
+type Candle struct {
+	Time   time.Time       `json:"time"`
+	Open   decimal.Decimal `json:"open"`
+	High   decimal.Decimal `json:"high"`
+	Low    decimal.Decimal `json:"low"`
+	Close  decimal.Decimal `json:"close"`
+	Volume int64           `json:"volume"`
+}
+
+// Chart is the response shape for GetChart: candles oldest first.
+type Chart struct {
+	Symbol   string   `json:"symbol"`
+	Range    string   `json:"range"`
+	Interval string   `json:"interval"` // "5min", "1hour", "1day" or "1week"
+	Candles  []Candle `json:"candles"`
+}
+
+// chartSpec is how one chart range is drawn. Intraday ranges come from
+// the provider's intraday bars at interval; the others from EOD bars,
+// merged into weekly bars when weekly is set so a long range stays a
+// readable number of candles.
+type chartSpec struct {
+	days     int
+	intraday bool
+	interval string
+	weekly   bool
+	ttl      time.Duration
+}
+
+// chartRanges are the ranges GetChart accepts. 1D asks for four days so a
+// weekend or holiday still finds the last session, then keeps only that
+// session's bars.
+var chartRanges = map[string]chartSpec{
+	"1D": {days: 4, intraday: true, interval: "5min", ttl: time.Minute},
+	"1W": {days: 7, intraday: true, interval: "1hour", ttl: 10 * time.Minute},
+	"1M": {days: 31, interval: "1day", ttl: time.Hour},
+	"3M": {days: 92, interval: "1day", ttl: 3 * time.Hour},
+	"1Y": {days: 366, interval: "1day", ttl: 6 * time.Hour},
+	"5Y": {days: 5*365 + 2, interval: "1week", weekly: true, ttl: 24 * time.Hour},
+}
+
+// ChartRanges lists the accepted ranges, shortest first.
+var ChartRanges = []string{"1D", "1W", "1M", "3M", "1Y", "5Y"}
+
+// chartMaxBars bounds the bars one chart fetches: five years of daily
+// bars is about 1,260 rows.
+const chartMaxBars = 1400
+
+// SetChartCache caches charts per range; without one every GetChart goes
+// to the provider.
+func (s *MarketService) SetChartCache(cache ChartCache) {
+	s.chartCache = cache
+}
+
+// SetIntradayTTL sets how long the 1D chart is cached; the other intraday
+// range keeps its TTL's proportion to it (the 1W chart, ten times as long).
+// Daily ranges keep their own.
+func (s *MarketService) SetIntradayTTL(ttl time.Duration) {
+	s.intradayTTL = ttl
+}
+
+// chartTTL is how long a chart drawn by spec is cached.
+func (s *MarketService) chartTTL(spec chartSpec) time.Duration {
+	if !spec.intraday || s.intradayTTL <= 0 {
+		return spec.ttl
+	}
+	return s.intradayTTL * (spec.ttl / chartRanges["1D"].ttl)
+}
+
+// GetChart returns symbol's OHLCV candles over chartRange, one of
+// ChartRanges (case-insensitive, default 1M), served from the chart cache
+// for the range's TTL. Intraday ranges may need a provider plan with
+// intraday data.
+func (s *MarketService) GetChart(ctx context.Context, symbol, chartRange string) (*Chart, error) {
+	symbol, err := util.ValidateQuoteSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	symbol = s.current(symbol)
+	chartRange = strings.ToUpper(strings.TrimSpace(chartRange))
+	if chartRange == "" {
+		chartRange = "1M"
+	}
+	spec, ok := chartRanges[chartRange]
+	if !ok {
+		return nil, &util.ValidationError{Field: "range", Message: "must be one of " + strings.Join(ChartRanges, ", ")}
+	}
+
+	if s.chartCache != nil {
+		if cached, _ := s.chartCache.GetChart(ctx, symbol, chartRange); cached != nil {
+			return cached, nil
+		}
+	}
+
+	to := time.Now().UTC()
+	candles, err := s.fetchCandles(ctx, spec, symbol, to.AddDate(0, 0, -spec.days), to)
+	if err != nil {
+		slog.Warn("market data chart fetch failed", "provider", s.provider.Name(), "symbol", symbol, "range", chartRange, "err", err)
+		return nil, err
+	}
+	if chartRange == "1D" {
+		candles = lastSession(candles)
+	}
+	if spec.weekly {
+		candles = weeklyCandles(candles)
+	}
+	if len(candles) == 0 {
+		return nil, &InsufficientHistoricalDataError{}
+	}
+
+	chart := &Chart{Symbol: symbol, Range: chartRange, Interval: spec.interval, Candles: candles}
+	if s.chartCache != nil {
+		if err := s.chartCache.SetChart(ctx, chart, s.chartTTL(spec)); err != nil {
+			slog.Warn("failed to cache chart", "symbol", symbol, "range", chartRange, "err", err, "component", "market")
+		}
+	}
+	return chart, nil
+}
+
+// fetchCandles fetches symbol's bars in [from, to] for spec, oldest first:
+// intraday bars at spec.interval or daily ones. Bars with a missing or
+// non-positive price are dropped.
+func (s *MarketService) fetchCandles(ctx context.Context, spec chartSpec, symbol string, from, to time.Time) ([]Candle, error) {
+	req := BarRequest{Symbols: []string{symbol}, From: from, To: to, Limit: chartMaxBars}
+	ctx = nonCritical(ctx)
+	var bars []Bar
+	var err error
+	if spec.intraday {
+		req.Interval = spec.interval
+		bars, err = s.provider.GetIntraday(ctx, req)
+	} else {
+		bars, err = s.provider.GetEOD(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Candle, 0, len(bars))
+	for _, bar := range bars {
+		if !bar.Open.IsPositive() || !bar.High.IsPositive() || !bar.Low.IsPositive() || !bar.Close.IsPositive() {
+			continue
+		}
+		out = append(out, Candle{
+			Time:   bar.Time,
+			Open:   bar.Open,
+			High:   bar.High,
+			Low:    bar.Low,
+			Close:  bar.Close,
+			Volume: bar.Volume,
+		})
+	}
+	slices.SortFunc(out, func(a, b Candle) int { return a.Time.Compare(b.Time) })
+	return out, nil
+}
+
+// newYork is the exchange's time zone. time/tzdata, imported by the market
+// calendar, makes sure it loads.
+var newYork, _ = time.LoadLocation("America/New_York")
+
+// lastSession keeps the candles on the last day present, in New York time.
+func lastSession(candles []Candle) []Candle {
+	if len(candles) == 0 {
+		return candles
+	}
+	day := func(c Candle) string { return c.Time.In(newYork).Format(DateLayoutISO) }
+	last := day(candles[len(candles)-1])
+	start := len(candles) - 1
+	for start > 0 && day(candles[start-1]) == last {
+		start--
+	}
+	return candles[start:]
+}
+
+// weeklyCandles merges daily candles into one per ISO week, stamped with
+// the week's first trading day.
+func weeklyCandles(daily []Candle) []Candle {
+	out := make([]Candle, 0, len(daily)/5+1)
+	lastYear, lastWeek := 0, 0
+	for _, c := range daily {
+		year, week := c.Time.ISOWeek()
+		if len(out) == 0 || year != lastYear || week != lastWeek {
+			out = append(out, c)
+			lastYear, lastWeek = year, week
+			continue
+		}
+		w := &out[len(out)-1]
+		w.High = decimal.Max(w.High, c.High)
+		w.Low = decimal.Min(w.Low, c.Low)
+		w.Close = c.Close
+		w.Volume += c.Volume
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+// memoryChartCache is a ChartCache recording the TTL each chart was set with.
+type memoryChartCache struct {
+	charts map[string]*Chart
+	ttls   map[string]time.Duration
+}
+
+func (c *memoryChartCache) GetChart(_ context.Context, symbol, chartRange string) (*Chart, error) {
+	return c.charts[chartKey(symbol, chartRange)], nil
+}
+
+func (c *memoryChartCache) SetChart(_ context.Context, chart *Chart, ttl time.Duration) error {
+	c.charts[chartKey(chart.Symbol, chart.Range)] = chart
+	c.ttls[chartKey(chart.Symbol, chart.Range)] = ttl
+	return nil
+}
+
+type ohlcvRow struct {
+	Date   string   `json:"date"`
+	Open   float64  `json:"open"`
+	High   float64  `json:"high"`
+	Low    float64  `json:"low"`
+	Close  *float64 `json:"close"`
+	Last   float64  `json:"last,omitempty"`
+	Volume float64  `json:"volume"`
+}
+
+// withMockChartServer points the EOD and intraday endpoints at a server
+// answering every request with rows, newest first as MarketStack does.
+func withMockChartServer(t *testing.T, rows []ohlcvRow, calls *int) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Data []ohlcvRow `json:"data"`
+		}{rows})
+	}))
+	prevEOD, prevIntraday := marketStackEODURL, marketStackIntradayURL
+	marketStackEODURL, marketStackIntradayURL = srv.URL+"/eod", srv.URL+"/intraday"
+	t.Cleanup(func() {
+		marketStackEODURL, marketStackIntradayURL = prevEOD, prevIntraday
+		srv.Close()
+	})
+}
+
+func price(v float64) *float64 { return &v }
+
+func TestGetChart_Weekly(t *testing.T) {
+	calls := 0
+	// Wednesday to Monday: two ISO weeks.
+	withMockChartServer(t, []ohlcvRow{
+		{Date: msDate("2026-01-12"), Open: 14, High: 15, Low: 13, Close: price(14.5), Volume: 40},
+		{Date: msDate("2026-01-09"), Open: 12, High: 13, Low: 11, Close: price(12.5), Volume: 30},
+		{Date: msDate("2026-01-08"), Open: 11, High: 16, Low: 10, Close: price(12), Volume: 20},
+		{Date: msDate("2026-01-07"), Open: 10, High: 11, Low: 9, Close: price(11), Volume: 10},
+		{Date: msDate("2026-01-06"), Open: 0, High: 0, Low: 0, Close: price(0), Volume: 0},
+	}, &calls)
+	cache := &memoryChartCache{charts: map[string]*Chart{}, ttls: map[string]time.Duration{}}
+	svc := &MarketService{provider: NewMarketStack("test-key", http.DefaultClient), chartCache: cache}
+
+	chart, err := svc.GetChart(context.Background(), "aapl", "5y")
+	if err != nil {
+		t.Fatalf("GetChart: %v", err)
+	}
+	if chart.Symbol != "AAPL" || chart.Range != "5Y" || chart.Interval != "1week" || len(chart.Candles) != 2 {
+		t.Fatalf("got %+v", chart)
+	}
+	week := chart.Candles[0]
+	want := Candle{Time: mustDate("2026-01-07"), Open: decimal.NewFromInt(10), High: decimal.NewFromInt(16),
+		Low: decimal.NewFromInt(9), Close: decimal.RequireFromString("12.5"), Volume: 60}
+	if !week.Time.Equal(want.Time) || !week.Open.Equal(want.Open) || !week.High.Equal(want.High) ||
+		!week.Low.Equal(want.Low) || !week.Close.Equal(want.Close) || week.Volume != want.Volume {
+		t.Errorf("first week: got %+v, want %+v", week, want)
+	}
+
+	// Served from the cache, which holds it for the range's TTL.
+	if _, err := svc.GetChart(context.Background(), "AAPL", "5Y"); err != nil || calls != 1 {
+		t.Errorf("second call: %v after %d API calls, want a cache hit", err, calls)
+	}
+	if ttl := cache.ttls[chartKey("AAPL", "5Y")]; ttl != 24*time.Hour {
+		t.Errorf("ttl: got %v, want 24h", ttl)
+	}
+}
+
+func TestGetChart_IntradayLastSession(t *testing.T) {
+	calls := 0
+	withMockChartServer(t, []ohlcvRow{
+		// The forming bar has no close yet; last stands in.
+		{Date: "2026-01-09T15:00:00+0000", Open: 101, High: 102, Low: 100, Last: 101.5, Volume: 5},
+		{Date: "2026-01-09T14:55:00+0000", Open: 100, High: 101, Low: 99, Close: price(101), Volume: 7},
+		{Date: "2026-01-08T20:55:00+0000", Open: 98, High: 99, Low: 97, Close: price(98), Volume: 9},
+	}, &calls)
+	svc := &MarketService{provider: NewMarketStack("test-key", http.DefaultClient)}
+
+	chart, err := svc.GetChart(context.Background(), "AAPL", "1D")
+	if err != nil {
+		t.Fatalf("GetChart: %v", err)
+	}
+	if chart.Interval != "5min" || len(chart.Candles) != 2 {
+		t.Fatalf("got %+v, want the two bars of January 9th", chart)
+	}
+	if !chart.Candles[1].Close.Equal(decimal.RequireFromString("101.5")) {
+		t.Errorf("forming bar close: got %s, want 101.5", chart.Candles[1].Close)
+	}
+}
+
+func TestGetChart_BadRange(t *testing.T) {
+	svc := &MarketService{provider: NewMarketStack("test-key", http.DefaultClient)}
+	var verr *util.ValidationError
+	if _, err := svc.GetChart(context.Background(), "AAPL", "10Y"); !errors.As(err, &verr) || verr.Field != "range" {
+		t.Errorf("got %v, want a range validation error", err)
+	}
+}
+
+func TestChartTTL_ScalesIntradayRanges(t *testing.T) {
+	svc := NewMarketService(nil, nil, nil, nil)
+	if got := svc.chartTTL(chartRanges["1W"]); got != 10*time.Minute {
+		t.Errorf("default 1W TTL: got %v", got)
+	}
+
+	svc.SetIntradayTTL(5 * time.Minute)
+	for r, want := range map[string]time.Duration{"1D": 5 * time.Minute, "1W": 50 * time.Minute, "1M": time.Hour} {
+		if got := svc.chartTTL(chartRanges[r]); got != want {
+			t.Errorf("%s: got %v, want %v", r, got, want)
+		}
+	}
+}
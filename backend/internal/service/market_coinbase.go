@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// coinbaseURL is overridable so HTTP-mock tests can point the provider at
+// an httptest.Server.
+var coinbaseURL = "https://api.exchange.coinbase.com"
+
+// CoinbaseTimeout caps each Coinbase call.
+const CoinbaseTimeout = 15 * time.Second
+
+// coinbaseMaxCandles is the most candles Coinbase returns per request;
+// longer ranges are fetched in windows of this many.
+const coinbaseMaxCandles = 300
+
+// coinbaseGranularities maps our bar intervals to Coinbase candle widths
+// in seconds. Coinbase has no 30-minute candle.
+var coinbaseGranularities = map[string]int{
+	"1min": 60, "5min": 300, "15min": 900, "1hour": 3600,
+}
+
+// coinbaseDaily is the daily candle width. Coinbase days run midnight to
+// midnight UTC.
+const coinbaseDaily = 86400
+
+// Coinbase is the MarketDataProvider for crypto pairs, backed by the public
+// Coinbase Exchange market data API, which needs no key. Products are named
+// as our crypto symbols are (BTC-USD). It has no company profiles.
+type Coinbase struct {
+	client *http.Client
+}
+
+// NewCoinbase builds the provider. client is the shared outbound client;
+// calls are capped at CoinbaseTimeout on top of its own limit.
+func NewCoinbase(client *http.Client) *Coinbase {
+	return &Coinbase{client: util.ClientWithTimeout(client, CoinbaseTimeout)}
+}
+
+func (c *Coinbase) Name() string { return ProviderCoinbase }
+
+// GetQuote reads each product's ticker in turn.
+func (c *Coinbase) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	quotes := make([]*StockData, 0, len(symbols))
+	for _, symbol := range symbols {
+		var ticker struct {
+			Price string    `json:"price"`
+			Time  time.Time `json:"time"`
+		}
+		err := c.get(ctx, "/products/"+url.PathEscape(symbol)+"/ticker", nil, &ticker)
+		if errors.Is(err, ErrSymbolNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		price, err := decimal.NewFromString(ticker.Price)
+		if err != nil {
+			return nil, fmt.Errorf("parse price %q: %w", ticker.Price, err)
+		}
+		quotes = append(quotes, &StockData{
+			Symbol: symbol,
+			Price:  price.Round(maxPriceDecimals),
+			Date:   ticker.Time.UTC().Format(DateLayoutUS),
+		})
+	}
+	return quotes, nil
+}
+
+// GetEOD reads daily candles for each symbol.
+func (c *Coinbase) GetEOD(ctx context.Context, req BarRequest) ([]Bar, error) {
+	from := time.Date(req.From.Year(), req.From.Month(), req.From.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(req.To.Year(), req.To.Month(), req.To.Day(), 0, 0, 0, 0, time.UTC)
+	return c.candles(ctx, req.Symbols, coinbaseDaily, from, to, req.Limit)
+}
+
+// GetIntraday reads req.Interval candles for each symbol, through the end
+// of req.To.
+func (c *Coinbase) GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error) {
+	granularity, ok := coinbaseGranularities[req.Interval]
+	if !ok {
+		return nil, fmt.Errorf("coinbase: unsupported interval %q", req.Interval)
+	}
+	to := req.To.AddDate(0, 0, 1).Add(-time.Duration(granularity) * time.Second)
+	return c.candles(ctx, req.Symbols, granularity, req.From, to, req.Limit)
+}
+
+// candles pages through each symbol's candles of granularity seconds
+// starting between from and to, both inclusive, stopping at limit bars
+// (zero for no limit). Coinbase answers [time, low, high, open, close,
+// volume] rows, newest first.
+func (c *Coinbase) candles(ctx context.Context, symbols []string, granularity int, from, to time.Time, limit int) ([]Bar, error) {
+	step := time.Duration(granularity) * time.Second
+	var out []Bar
+	for _, symbol := range symbols {
+		for start := from; !start.After(to); start = start.Add(coinbaseMaxCandles * step) {
+			end := start.Add((coinbaseMaxCandles - 1) * step)
+			if end.After(to) {
+				end = to
+			}
+			q := url.Values{
+				"granularity": {fmt.Sprint(granularity)},
+				"start":       {start.UTC().Format(time.RFC3339)},
+				"end":         {end.UTC().Format(time.RFC3339)},
+			}
+			var rows [][6]json.Number
+			err := c.get(ctx, "/products/"+url.PathEscape(symbol)+"/candles", q, &rows)
+			if errors.Is(err, ErrSymbolNotFound) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				out = append(out, coinbaseBar(symbol, row))
+			}
+			if limit > 0 && len(out) >= limit {
+				return capBars(out, limit), nil
+			}
+		}
+	}
+	return out, nil
+}
+
+func coinbaseBar(symbol string, row [6]json.Number) Bar {
+	num := func(n json.Number) decimal.Decimal {
+		d, _ := decimal.NewFromString(n.String())
+		return d
+	}
+	price := func(n json.Number) decimal.Decimal { return num(n).Round(maxPriceDecimals) }
+	return Bar{
+		Symbol: symbol,
+		Time:   time.Unix(num(row[0]).IntPart(), 0).UTC(),
+		Low:    price(row[1]),
+		High:   price(row[2]),
+		Open:   price(row[3]),
+		Close:  price(row[4]),
+		Volume: num(row[5]).IntPart(),
+	}
+}
+
+// coinbaseProduct is one entry of the product list.
+type coinbaseProduct struct {
+	ID              string `json:"id"`
+	BaseCurrency    string `json:"base_currency"`
+	QuoteCurrency   string `json:"quote_currency"`
+	Status          string `json:"status"`
+	TradingDisabled bool   `json:"trading_disabled"`
+}
+
+// Search matches query against the base currency of the dollar-quoted
+// products, e.g. "btc" finds BTC-USD. Coinbase's product list carries no
+// asset names, so matches are named by their pair.
+func (c *Coinbase) Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	var products []coinbaseProduct
+	if err := c.get(ctx, "/products", nil, &products); err != nil {
+		return nil, err
+	}
+	query = strings.ToUpper(strings.TrimSpace(query))
+	matches := make([]SymbolMatch, 0)
+	for _, p := range products {
+		if p.QuoteCurrency != "USD" || !util.IsCryptoPair(p.ID) || !strings.HasPrefix(p.BaseCurrency, query) {
+			continue
+		}
+		matches = append(matches, SymbolMatch{Symbol: p.ID, Name: p.ID})
+		if len(matches) == limit {
+			break
+		}
+	}
+	return matches, nil
+}
+
+// Instrument reads the product and its base currency's name. A product
+// Coinbase has taken offline or disabled is reported halted.
+func (c *Coinbase) Instrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	var p coinbaseProduct
+	if err := c.get(ctx, "/products/"+url.PathEscape(symbol), nil, &p); err != nil {
+		return nil, err
+	}
+	inst := &data.Instrument{Symbol: symbol, Name: p.BaseCurrency, AssetType: data.AssetClassCrypto}
+	var currency struct {
+		Name string `json:"name"`
+	}
+	if err := c.get(ctx, "/currencies/"+url.PathEscape(p.BaseCurrency), nil, &currency); err == nil && currency.Name != "" {
+		inst.Name = currency.Name
+	}
+	if p.TradingDisabled || (p.Status != "" && p.Status != "online") {
+		inst.Halted = true
+		inst.HaltReason = "suspended by the data provider"
+	}
+	return inst, nil
+}
+
+// Company always returns ErrSymbolNotFound: crypto assets have no company.
+func (c *Coinbase) Company(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	return nil, ErrSymbolNotFound
+}
+
+// get requests path under coinbaseURL and decodes the answer into out. A
+// 404 is ErrSymbolNotFound; timeouts, 5xx and 429s are unavailability.
+func (c *Coinbase) get(ctx context.Context, path string, q url.Values, out any) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", coinbaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.URL.RawQuery = q.Encode()
+	httpReq.Header.Set("Accept", "application/json")
+	// Coinbase rejects requests without a User-Agent.
+	httpReq.Header.Set("User-Agent", "papertrader")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == nil {
+			return &ProviderUnavailableError{Provider: ProviderCoinbase, Err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return &ProviderUnavailableError{Provider: ProviderCoinbase, Err: err}
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrSymbolNotFound
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return &ProviderUnavailableError{Provider: ProviderCoinbase, Err: fmt.Errorf("API returned status %d", resp.StatusCode)}
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("coinbase: API returned status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// withMockCoinbase points the Coinbase provider at handler for the test.
+func withMockCoinbase(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	prev := coinbaseURL
+	coinbaseURL = srv.URL
+	t.Cleanup(func() {
+		coinbaseURL = prev
+		srv.Close()
+	})
+}
+
+func TestCoinbase_GetQuote(t *testing.T) {
+	withMockCoinbase(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			t.Error("request sent without a User-Agent")
+		}
+		switch r.URL.Path {
+		case "/products/BTC-USD/ticker":
+			w.Write([]byte(`{"price":"67012.345678","time":"2026-10-15T21:04:05.123Z"}`))
+		default:
+			http.Error(w, `{"message":"NotFound"}`, http.StatusNotFound)
+		}
+	})
+
+	quotes, err := NewCoinbase(http.DefaultClient).GetQuote(context.Background(), []string{"BTC-USD", "NOPE-USD"})
+	if err != nil {
+		t.Fatalf("GetQuote: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Symbol != "BTC-USD" || quotes[0].Price.String() != "67012.345678" || quotes[0].Date != "10/15/2026" {
+		t.Errorf("got %+v", quotes)
+	}
+}
+
+func TestCoinbase_GetEODPagesCandles(t *testing.T) {
+	var windows int
+	withMockCoinbase(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/products/ETH-USD/candles" || r.URL.Query().Get("granularity") != "86400" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		windows++
+		start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		w.Write([]byte(`[[` + strconv.FormatInt(start.Unix(), 10) + `,2400.5,2510,2450,2500.25,1234.5]]`))
+	})
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 400)
+	bars, err := NewCoinbase(http.DefaultClient).GetEOD(context.Background(), BarRequest{Symbols: []string{"ETH-USD"}, From: from, To: to})
+	if err != nil {
+		t.Fatalf("GetEOD: %v", err)
+	}
+	// 401 days is two 300-candle windows.
+	if windows != 2 || len(bars) != 2 {
+		t.Fatalf("got %d windows and %d bars, want 2 and 2", windows, len(bars))
+	}
+	if b := bars[0]; !b.Time.Equal(from) || b.Close.String() != "2500.25" || b.Low.String() != "2400.5" || b.Volume != 1234 {
+		t.Errorf("first bar: got %+v", b)
+	}
+}
+
+func TestCoinbase_Instrument(t *testing.T) {
+	withMockCoinbase(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/products/SOL-USD":
+			w.Write([]byte(`{"id":"SOL-USD","base_currency":"SOL","quote_currency":"USD","status":"online","trading_disabled":true}`))
+		case "/currencies/SOL":
+			w.Write([]byte(`{"id":"SOL","name":"Solana"}`))
+		case "/products/BTC-USD":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	cb := NewCoinbase(http.DefaultClient)
+	ctx := context.Background()
+
+	inst, err := cb.Instrument(ctx, "SOL-USD")
+	if err != nil {
+		t.Fatalf("Instrument: %v", err)
+	}
+	if inst.Name != "Solana" || inst.AssetType != data.AssetClassCrypto || !inst.Halted {
+		t.Errorf("got %+v, want halted crypto named Solana", inst)
+	}
+
+	var unavailable *ProviderUnavailableError
+	if _, err := cb.Instrument(ctx, "BTC-USD"); !errors.As(err, &unavailable) {
+		t.Errorf("503: got %v, want a ProviderUnavailableError", err)
+	}
+	if _, err := cb.Instrument(ctx, "NOPE-USD"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("404: got %v, want ErrSymbolNotFound", err)
+	}
+}
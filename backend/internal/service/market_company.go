@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+// companyProfileTTL is how long a company profile is cached. Names,
+// sectors and descriptions rarely change, and market cap is indicative.
+const companyProfileTTL = 24 * time.Hour
+
+// CompanyProfile is the response shape for GetCompanyProfile.
+type CompanyProfile struct {
+	Symbol      string           `json:"symbol"`
+	Name        string           `json:"name"`
+	Exchange    string           `json:"exchange"`
+	Sector      string           `json:"sector"`
+	Industry    string           `json:"industry"`
+	MarketCap   *decimal.Decimal `json:"market_cap"` // USD; nil when the provider has none
+	Description string           `json:"description"`
+}
+
+// SetCompanyCache caches company profiles for companyProfileTTL; without
+// one every GetCompanyProfile goes to the provider.
+func (s *MarketService) SetCompanyCache(cache CompanyCache) {
+	s.companyCache = cache
+}
+
+// GetCompanyProfile returns symbol's company profile from the provider,
+// served from the company cache for a day. Indexes have none and are
+// ErrSymbolNotFound without asking the provider.
+func (s *MarketService) GetCompanyProfile(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	symbol, err := util.ValidateQuoteSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if util.IsIndexSymbol(symbol) {
+		return nil, ErrSymbolNotFound
+	}
+	symbol = s.current(symbol)
+
+	if s.companyCache != nil {
+		if cached, _ := s.companyCache.GetCompany(ctx, symbol); cached != nil {
+			return cached, nil
+		}
+	}
+
+	profile, err := s.provider.Company(nonCritical(ctx), symbol)
+	if err != nil {
+		slog.Warn("company profile fetch failed", "provider", s.provider.Name(), "symbol", symbol, "err", err)
+		return nil, err
+	}
+	if s.companyCache != nil {
+		if err := s.companyCache.SetCompany(ctx, profile, companyProfileTTL); err != nil {
+			slog.Warn("failed to cache company profile", "symbol", symbol, "err", err, "component", "market")
+		}
+	}
+	return profile, nil
+}
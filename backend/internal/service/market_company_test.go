@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memoryCompanyCache is a CompanyCache recording the TTL each profile was
+// set with.
+type memoryCompanyCache struct {
+	profiles map[string]*CompanyProfile
+	ttls     map[string]time.Duration
+}
+
+func (c *memoryCompanyCache) GetCompany(_ context.Context, symbol string) (*CompanyProfile, error) {
+	return c.profiles[symbol], nil
+}
+
+func (c *memoryCompanyCache) SetCompany(_ context.Context, profile *CompanyProfile, ttl time.Duration) error {
+	c.profiles[profile.Symbol] = profile
+	c.ttls[profile.Symbol] = ttl
+	return nil
+}
+
+func TestGetCompanyProfile(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("ticker") != "AAPL" {
+			w.Write([]byte(`{"data":{}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"name":"Apple Inc","ticker":"AAPL","exchange_code":"nasdaq","sector":"Technology",
+			"industry":"Consumer Electronics","market_cap":3400000000000,"about":" Apple designs smartphones. "}}`))
+	}))
+	prev := marketStackTickerInfoURL
+	marketStackTickerInfoURL = srv.URL
+	t.Cleanup(func() {
+		marketStackTickerInfoURL = prev
+		srv.Close()
+	})
+	cache := &memoryCompanyCache{profiles: map[string]*CompanyProfile{}, ttls: map[string]time.Duration{}}
+	svc := &MarketService{provider: NewMarketStack("test-key", http.DefaultClient), companyCache: cache}
+
+	p, err := svc.GetCompanyProfile(context.Background(), "aapl")
+	if err != nil {
+		t.Fatalf("GetCompanyProfile: %v", err)
+	}
+	if p.Symbol != "AAPL" || p.Name != "Apple Inc" || p.Exchange != "NASDAQ" || p.Sector != "Technology" ||
+		p.Description != "Apple designs smartphones." || p.MarketCap == nil || p.MarketCap.String() != "3400000000000" {
+		t.Errorf("got %+v", p)
+	}
+
+	// Served from the cache, which holds it for a day.
+	if _, err := svc.GetCompanyProfile(context.Background(), "AAPL"); err != nil || calls != 1 {
+		t.Errorf("second call: %v after %d API calls, want a cache hit", err, calls)
+	}
+	if ttl := cache.ttls["AAPL"]; ttl != 24*time.Hour {
+		t.Errorf("ttl: got %v, want 24h", ttl)
+	}
+
+	if _, err := svc.GetCompanyProfile(context.Background(), "ZZZZ"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("unknown symbol: got %v, want ErrSymbolNotFound", err)
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"time"
+
+	"papertrader/internal/util"
+)
+
+// HomeExchange is the MIC of the exchange whose calendar US listings,
+// statements and end-of-day closes run on.
+const HomeExchange = "XNYS"
+
+// clockTime is a local time of day.
+type clockTime struct{ hour, minute int }
+
+// Exchange is a listing venue: where its regular session runs, when, and
+// the currency it prices in. Non-US listings name theirs with a MIC suffix
+// on the ticker (VOD.XLON); every other stock trades on the NYSE's hours.
+type Exchange struct {
+	MIC      string `json:"mic"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"` // ISO 4217 code listings are priced in
+	Timezone string `json:"timezone"`
+	// MinorUnitQuotes marks venues whose prices are in the currency's
+	// minor unit, as the LSE quotes in pence.
+	MinorUnitQuotes bool `json:"-"`
+
+	open, close, earlyClose clockTime
+	holiday                 func(y int, m time.Month, d int) bool
+	isEarlyClose            func(y int, m time.Month, d int) bool
+}
+
+// exchanges are the venues symbols can trade on, by MIC.
+var exchanges = map[string]*Exchange{
+	"XNYS": {
+		MIC: "XNYS", Name: "New York Stock Exchange", Currency: BaseCurrency, Timezone: "America/New_York",
+		open: clockTime{9, 30}, close: clockTime{16, 0}, earlyClose: clockTime{13, 0},
+		holiday: isNYSEHoliday, isEarlyClose: isNYSEEarlyClose,
+	},
+	"XLON": {
+		MIC: "XLON", Name: "London Stock Exchange", Currency: "GBP", Timezone: "Europe/London", MinorUnitQuotes: true,
+		open: clockTime{8, 0}, close: clockTime{16, 30}, earlyClose: clockTime{12, 30},
+		holiday: isLSEHoliday, isEarlyClose: isLSEEarlyClose,
+	},
+	"XTSE": {
+		MIC: "XTSE", Name: "Toronto Stock Exchange", Currency: "CAD", Timezone: "America/Toronto",
+		open: clockTime{9, 30}, close: clockTime{16, 0}, earlyClose: clockTime{13, 0},
+		holiday: isTSXHoliday, isEarlyClose: isTSXEarlyClose,
+	},
+}
+
+// ExchangeOf returns the exchange symbol trades on: the one its suffix
+// names, otherwise the home exchange.
+func ExchangeOf(symbol string) *Exchange {
+	if ex, ok := exchanges[util.SymbolExchange(symbol)]; ok {
+		return ex
+	}
+	return exchanges[HomeExchange]
+}
+
+// isLSEHoliday reports whether the London Stock Exchange is closed all day
+// on the given date: the English bank holidays, with New Year's Day,
+// Christmas and Boxing Day on a weekend moving to the next free weekday.
+// One-off bank holidays (coronations, jubilees) are not modelled.
+func isLSEHoliday(y int, m time.Month, d int) bool {
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	easter := easterSunday(y)
+	switch {
+	case observedOn(date, time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		date.Equal(easter.AddDate(0, 0, -2)),                 // Good Friday
+		date.Equal(easter.AddDate(0, 0, 1)),                  // Easter Monday
+		date.Equal(nthWeekday(y, time.May, time.Monday, 1)),  // Early May bank holiday
+		date.Equal(lastWeekday(y, time.May, time.Monday)),    // Spring bank holiday
+		date.Equal(lastWeekday(y, time.August, time.Monday)), // Summer bank holiday
+		observedOn(date, christmasAndBoxingDay(y)...):
+		return true
+	}
+	return false
+}
+
+// isLSEEarlyClose reports whether the London session on the given date ends
+// at 12:30: Christmas Eve and New Year's Eve.
+func isLSEEarlyClose(y int, m time.Month, d int) bool {
+	return m == time.December && (d == 24 || d == 31)
+}
+
+// isTSXHoliday reports whether the Toronto Stock Exchange is closed all day
+// on the given date: the Ontario statutory holidays plus the Civic Holiday,
+// with New Year's Day, Canada Day, Christmas and Boxing Day on a weekend
+// moving to the next free weekday.
+func isTSXHoliday(y int, m time.Month, d int) bool {
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	switch {
+	case observedOn(date, time.Date(y, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		date.Equal(nthWeekday(y, time.February, time.Monday, 3)), // Family Day
+		date.Equal(easterSunday(y).AddDate(0, 0, -2)),            // Good Friday
+		date.Equal(victoriaDay(y)),
+		observedOn(date, time.Date(y, time.July, 1, 0, 0, 0, 0, time.UTC)), // Canada Day
+		date.Equal(nthWeekday(y, time.August, time.Monday, 1)),             // Civic Holiday
+		date.Equal(nthWeekday(y, time.September, time.Monday, 1)),          // Labour Day
+		date.Equal(nthWeekday(y, time.October, time.Monday, 2)),            // Thanksgiving
+		observedOn(date, christmasAndBoxingDay(y)...):
+		return true
+	}
+	return false
+}
+
+// isTSXEarlyClose reports whether the Toronto session on the given date
+// ends at 13:00: Christmas Eve.
+func isTSXEarlyClose(y int, m time.Month, d int) bool {
+	return m == time.December && d == 24
+}
+
+func christmasAndBoxingDay(y int) []time.Time {
+	return []time.Time{
+		time.Date(y, time.December, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(y, time.December, 26, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// observedOn reports whether date is the day off for one of holidays,
+// taken in order: a holiday on a weekend, or on a day an earlier one took,
+// is observed on the next weekday still free.
+func observedOn(date time.Time, holidays ...time.Time) bool {
+	var taken []time.Time
+	isTaken := func(t time.Time) bool {
+		for _, u := range taken {
+			if u.Equal(t) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, h := range holidays {
+		for h.Weekday() == time.Saturday || h.Weekday() == time.Sunday || isTaken(h) {
+			h = h.AddDate(0, 0, 1)
+		}
+		if h.Equal(date) {
+			return true
+		}
+		taken = append(taken, h)
+	}
+	return false
+}
+
+// victoriaDay returns the last Monday before 25 May.
+func victoriaDay(y int) time.Time {
+	d := time.Date(y, time.May, 24, 0, 0, 0, 0, time.UTC)
+	return d.AddDate(0, 0, -((int(d.Weekday()) - int(time.Monday) + 7) % 7))
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// maxBreakerCooldown caps how long a breaker stays open after repeated
+// failed probes; the cooldown doubles on each one up to this.
+const maxBreakerCooldown = 10 * time.Minute
+
+// Circuit breaker states, as reported by ProviderStatus.
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// circuitBreaker stops calls to a provider after threshold consecutive
+// ProviderUnavailableErrors. Once cooldown has passed one probe call is let
+// through: success closes the breaker, failure reopens it for twice as
+// long, up to maxBreakerCooldown.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     string
+	failures  int // consecutive, while closed
+	trips     int // consecutive openings without a success
+	openUntil time.Time
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: max(threshold, 1), cooldown: cooldown, now: time.Now, state: BreakerClosed}
+}
+
+// allow reports whether a call may go to the provider, turning an open
+// breaker whose cooldown has passed half-open for the caller's probe.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if b.now().Before(b.openUntil) {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		return true
+	}
+	return false // a probe is already in flight
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.trips = BreakerClosed, 0, 0
+}
+
+// failure records an unavailable provider and reports whether it opened
+// the breaker.
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		b.failures++
+		if b.failures < b.threshold {
+			return false
+		}
+	case BreakerOpen:
+		return false // a call that started before the breaker opened
+	}
+	b.trips++
+	b.state, b.failures = BreakerOpen, 0
+	b.openUntil = b.now().Add(min(b.cooldown<<(b.trips-1), maxBreakerCooldown))
+	return true
+}
+
+// release ends a probe whose caller gave up before it said anything about
+// the provider; the next call probes again.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen {
+		b.state = BreakerOpen
+	}
+}
+
+// ProviderStatus is one provider's circuit breaker, as shown at /health.
+type ProviderStatus struct {
+	Name    string
+	State   string    // BreakerClosed, BreakerOpen or BreakerHalfOpen
+	RetryAt time.Time // when an open breaker next lets a probe through
+}
+
+type failoverMember struct {
+	provider MarketDataProvider
+	breaker  *circuitBreaker
+}
+
+// FailoverProvider is a MarketDataProvider that tries each of its
+// providers in order, moving to the next when one is unavailable (a
+// ProviderUnavailableError), has shed the call to save quota (a
+// QuotaShedError) or its circuit breaker is open. Any other
+// answer, including ErrSymbolNotFound, comes from the first provider that
+// gives one.
+type FailoverProvider struct {
+	members []failoverMember
+}
+
+// NewFailoverProvider puts a circuit breaker in front of each of providers,
+// primary first. A breaker opens after threshold consecutive failures and
+// first retries after cooldown.
+func NewFailoverProvider(providers []MarketDataProvider, threshold int, cooldown time.Duration) *FailoverProvider {
+	f := &FailoverProvider{}
+	for _, p := range providers {
+		f.members = append(f.members, failoverMember{provider: p, breaker: newCircuitBreaker(threshold, cooldown)})
+	}
+	return f
+}
+
+// Name lists the providers in the order they are tried, e.g.
+// "marketstack,alphavantage".
+func (f *FailoverProvider) Name() string {
+	names := make([]string, len(f.members))
+	for i, m := range f.members {
+		names[i] = m.provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Status reports each provider's breaker, primary first.
+func (f *FailoverProvider) Status() []ProviderStatus {
+	out := make([]ProviderStatus, len(f.members))
+	for i, m := range f.members {
+		m.breaker.mu.Lock()
+		out[i] = ProviderStatus{Name: m.provider.Name(), State: m.breaker.state}
+		if m.breaker.state == BreakerOpen {
+			out[i].RetryAt = m.breaker.openUntil
+		}
+		m.breaker.mu.Unlock()
+	}
+	return out
+}
+
+// failover runs call against each provider in turn; see FailoverProvider.
+func failover[T any](ctx context.Context, f *FailoverProvider, op string, call func(MarketDataProvider) (T, error)) (T, error) {
+	var zero T
+	var lastErr error
+	for i, m := range f.members {
+		if !m.breaker.allow() {
+			continue
+		}
+		v, err := call(m.provider)
+		var unavailable *ProviderUnavailableError
+		var shed *QuotaShedError
+		switch {
+		case errors.As(err, &shed):
+			// The provider was never asked, so its breaker learns nothing.
+			lastErr = err
+			m.breaker.release()
+			continue
+		case errors.As(err, &unavailable):
+			lastErr = err
+			if m.breaker.failure() {
+				slog.Warn("market data circuit breaker opened", "provider", m.provider.Name(), "err", err, "component", "market")
+			}
+			slog.Warn("market data provider unavailable", "provider", m.provider.Name(), "op", op, "err", err, "component", "market")
+			continue
+		case err != nil && ctx.Err() != nil:
+			m.breaker.release()
+			return zero, err
+		}
+		m.breaker.success()
+		if i > 0 {
+			slog.Info("served by fallback market data provider", "provider", m.provider.Name(), "op", op, "component", "market")
+		}
+		return v, err
+	}
+	if lastErr == nil {
+		lastErr = &ProviderUnavailableError{Provider: f.Name(), Err: errors.New("circuit breaker open")}
+	}
+	return zero, lastErr
+}
+
+func (f *FailoverProvider) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	return failover(ctx, f, "quote", func(p MarketDataProvider) ([]*StockData, error) {
+		return p.GetQuote(ctx, symbols)
+	})
+}
+
+func (f *FailoverProvider) GetEOD(ctx context.Context, req BarRequest) ([]Bar, error) {
+	return failover(ctx, f, "eod", func(p MarketDataProvider) ([]Bar, error) {
+		return p.GetEOD(ctx, req)
+	})
+}
+
+func (f *FailoverProvider) GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error) {
+	return failover(ctx, f, "intraday", func(p MarketDataProvider) ([]Bar, error) {
+		return p.GetIntraday(ctx, req)
+	})
+}
+
+func (f *FailoverProvider) Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	return failover(ctx, f, "search", func(p MarketDataProvider) ([]SymbolMatch, error) {
+		return p.Search(ctx, query, limit)
+	})
+}
+
+func (f *FailoverProvider) Instrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	return failover(ctx, f, "instrument", func(p MarketDataProvider) (*data.Instrument, error) {
+		return p.Instrument(ctx, symbol)
+	})
+}
+
+func (f *FailoverProvider) Company(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	return failover(ctx, f, "company", func(p MarketDataProvider) (*CompanyProfile, error) {
+		return p.Company(ctx, symbol)
+	})
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// stubProvider answers quotes with err, or a quote for each symbol.
+type stubProvider struct {
+	MarketDataProvider
+	name  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Name() string { return p.name }
+func (p *stubProvider) GetQuote(_ context.Context, symbols []string) ([]*StockData, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []*StockData{{Symbol: symbols[0], Price: decimal.NewFromInt(100)}}, nil
+}
+
+func TestFailoverProvider_FailsOverAndBacksOff(t *testing.T) {
+	down := &ProviderUnavailableError{Provider: "primary", Err: errors.New("status 503")}
+	primary := &stubProvider{name: "primary", err: down}
+	secondary := &stubProvider{name: "secondary"}
+	f := NewFailoverProvider([]MarketDataProvider{primary, secondary}, 2, time.Minute)
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	for _, m := range f.members {
+		m.breaker.now = func() time.Time { return now }
+	}
+	ctx := context.Background()
+
+	for range 3 {
+		if quotes, err := f.GetQuote(ctx, []string{"AAPL"}); err != nil || len(quotes) != 1 {
+			t.Fatalf("GetQuote: %v, %v", quotes, err)
+		}
+	}
+	// The breaker opened after two failures, so the third call skipped the
+	// primary.
+	if primary.calls != 2 || secondary.calls != 3 {
+		t.Errorf("calls: primary %d, secondary %d; want 2 and 3", primary.calls, secondary.calls)
+	}
+	if st := f.Status()[0]; st.State != BreakerOpen || !st.RetryAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("primary status: got %+v", st)
+	}
+
+	// A failed probe reopens it for twice as long.
+	now = now.Add(time.Minute)
+	f.GetQuote(ctx, []string{"AAPL"})
+	if st := f.Status()[0]; primary.calls != 3 || st.State != BreakerOpen || !st.RetryAt.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("after failed probe: %d calls, status %+v", primary.calls, st)
+	}
+
+	// A successful probe closes it.
+	now = now.Add(2 * time.Minute)
+	primary.err = nil
+	f.GetQuote(ctx, []string{"AAPL"})
+	if st := f.Status()[0]; st.State != BreakerClosed || secondary.calls != 4 {
+		t.Errorf("after good probe: status %+v, secondary %d calls", st, secondary.calls)
+	}
+}
+
+func TestFailoverProvider_KeepsOtherErrors(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: ErrSymbolNotFound}
+	secondary := &stubProvider{name: "secondary"}
+	f := NewFailoverProvider([]MarketDataProvider{primary, secondary}, 1, time.Minute)
+
+	if _, err := f.GetQuote(context.Background(), []string{"ZZZZ"}); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("got %v, want ErrSymbolNotFound", err)
+	}
+	if secondary.calls != 0 || f.Status()[0].State != BreakerClosed {
+		t.Errorf("a missing symbol should neither fail over nor trip the breaker")
+	}
+}
+
+func TestFailoverProvider_AllDown(t *testing.T) {
+	down := &ProviderUnavailableError{Provider: "only", Err: errors.New("timeout")}
+	f := NewFailoverProvider([]MarketDataProvider{&stubProvider{name: "only", err: down}}, 1, time.Minute)
+	f.GetQuote(context.Background(), []string{"AAPL"})
+
+	_, err := f.GetQuote(context.Background(), []string{"AAPL"})
+	var unavailable *ProviderUnavailableError
+	if !errors.As(err, &unavailable) || unavailable.HTTPStatus() != http.StatusServiceUnavailable {
+		t.Errorf("got %v, want a ProviderUnavailableError while the breaker is open", err)
+	}
+}
+
+func TestMarketStack_RateLimitIsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"code":"rate_limit_reached"}}`))
+	}))
+	prev := marketStackLatestURL
+	marketStackLatestURL = srv.URL
+	t.Cleanup(func() {
+		marketStackLatestURL = prev
+		srv.Close()
+	})
+
+	_, err := NewMarketStack("test-key", http.DefaultClient).GetQuote(context.Background(), []string{"AAPL"})
+	var unavailable *ProviderUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Errorf("got %v, want a ProviderUnavailableError", err)
+	}
+}
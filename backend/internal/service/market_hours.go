@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// MarketStatus is an exchange's state at a point in time.
+type MarketStatus struct {
+	Exchange *Exchange `json:"exchange"`
+	IsOpen   bool      `json:"is_open"`
+	// Session is the current session while open, otherwise the next one.
+	Session MarketSession `json:"session"`
+}
+
+// MarketHours confines stock trading to the regular session of the
+// exchange each symbol lists on. As a PreTradeCheck it rejects buys, sells,
+// shorts and covers while that market is closed; buys and sells by users who
+// chose AfterHoursQueue are flagged so the caller can rest them as MARKET
+// orders for the next open instead. Crypto pairs trade around the clock and
+// are never held back.
+type MarketHours struct {
+	calendar *MarketCalendar
+	users    *data.UserStore
+	now      func() time.Time
+}
+
+func NewMarketHours(calendar *MarketCalendar, users *data.UserStore) *MarketHours {
+	return &MarketHours{calendar: calendar, users: users, now: time.Now}
+}
+
+// IsOpenFor reports whether symbol's market is open now. Crypto's always
+// is.
+func (m *MarketHours) IsOpenFor(symbol string) bool {
+	return util.IsCryptoPair(symbol) || m.calendar.ForSymbol(symbol).IsOpen(m.now())
+}
+
+// Status returns whether the home exchange is open now and the relevant
+// session.
+func (m *MarketHours) Status() MarketStatus {
+	return m.status(m.calendar)
+}
+
+// ExchangeStatus is Status for the exchange with the given MIC.
+func (m *MarketHours) ExchangeStatus(mic string) (MarketStatus, error) {
+	cal, ok := m.calendar.ForExchange(strings.ToUpper(strings.TrimSpace(mic)))
+	if !ok {
+		return MarketStatus{}, &util.ValidationError{Field: "exchange", Message: "must be XNYS, XLON or XTSE"}
+	}
+	return m.status(cal), nil
+}
+
+func (m *MarketHours) status(cal *MarketCalendar) MarketStatus {
+	now := m.now()
+	return MarketStatus{Exchange: cal.Exchange(), IsOpen: cal.IsOpen(now), Session: cal.NextSession(now)}
+}
+
+// CheckTrade implements PreTradeCheck. A failure to read the user's
+// after-hours setting rejects rather than queues.
+func (m *MarketHours) CheckTrade(ctx context.Context, intent TradeIntent) error {
+	if util.IsCryptoPair(intent.Symbol) {
+		return nil
+	}
+	cal := m.calendar.ForSymbol(intent.Symbol)
+	now := m.now()
+	if cal.IsOpen(now) {
+		return nil
+	}
+	closed := &MarketClosedError{NextOpen: cal.NextSession(now).Open}
+	if intent.Action == data.OrderSideBuy || intent.Action == data.OrderSideSell {
+		mode, err := m.users.GetAfterHoursOrders(ctx, intent.UserID)
+		if err != nil {
+			slog.Warn("failed to read after-hours setting; rejecting", "user_id", intent.UserID, "err", err, "component", "market_hours")
+		}
+		closed.Queue = mode == data.AfterHoursQueue
+	}
+	return closed
+}
+
+// SetAfterHoursOrders saves what userID wants done with buys and sells
+// placed while the market is closed and returns the normalised mode.
+func (m *MarketHours) SetAfterHoursOrders(ctx context.Context, userID, mode string) (string, error) {
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	if mode != data.AfterHoursReject && mode != data.AfterHoursQueue {
+		return "", &util.ValidationError{Field: "mode", Message: "must be REJECT or QUEUE"}
+	}
+	if err := m.users.SetAfterHoursOrders(ctx, userID, mode); err != nil {
+		return "", err
+	}
+	return mode, nil
+}
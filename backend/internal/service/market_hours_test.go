@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// newMarketHours returns a MarketHours whose clock reads at.
+func newMarketHours(t *testing.T, at time.Time) (*MarketHours, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	hours := NewMarketHours(newCalendar(t), data.NewUserStore(db))
+	hours.now = func() time.Time { return at }
+	return hours, mock
+}
+
+func TestMarketHours_CheckTrade(t *testing.T) {
+	ctx := context.Background()
+	cal := newCalendar(t)
+	saturday := ny(cal, 2026, time.March, 7, 12, 0)
+	monday := ny(cal, 2026, time.March, 9, 9, 30)
+
+	hours, mock := newMarketHours(t, ny(cal, 2026, time.March, 6, 10, 0))
+	if err := hours.CheckTrade(ctx, TradeIntent{UserID: "user-1", Action: "BUY"}); err != nil {
+		t.Errorf("open market: got %v", err)
+	}
+
+	hours.now = func() time.Time { return saturday }
+	mock.ExpectQuery("SELECT COALESCE\\(s.after_hours_orders, 'REJECT'\\) FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"after_hours_orders"}).AddRow(data.AfterHoursQueue))
+	var closed *MarketClosedError
+	err := hours.CheckTrade(ctx, TradeIntent{UserID: "user-1", Action: "SELL"})
+	if !errors.As(err, &closed) || !closed.Queue || !closed.NextOpen.Equal(monday) {
+		t.Errorf("queued sell: got %+v", err)
+	}
+
+	mock.ExpectQuery("SELECT COALESCE\\(s.after_hours_orders, 'REJECT'\\) FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"after_hours_orders"}).AddRow(data.AfterHoursReject))
+	err = hours.CheckTrade(ctx, TradeIntent{UserID: "user-1", Action: "BUY"})
+	if !errors.As(err, &closed) || closed.Queue {
+		t.Errorf("rejected buy: got %+v", err)
+	}
+
+	// Shorts cannot rest in the order book, so the setting is not read.
+	err = hours.CheckTrade(ctx, TradeIntent{UserID: "user-1", Action: "SHORT"})
+	if !errors.As(err, &closed) || closed.Queue {
+		t.Errorf("short: got %+v", err)
+	}
+
+	// Crypto trades around the clock.
+	if err := hours.CheckTrade(ctx, TradeIntent{UserID: "user-1", Symbol: "BTC-USD", Action: "BUY"}); err != nil {
+		t.Errorf("crypto on a weekend: got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestMarketHours_SetAfterHoursOrders(t *testing.T) {
+	hours, mock := newMarketHours(t, time.Now())
+	ctx := context.Background()
+
+	var ve *util.ValidationError
+	if _, err := hours.SetAfterHoursOrders(ctx, "user-1", "later"); !errors.As(err, &ve) || ve.Field != "mode" {
+		t.Errorf("expected validation error on mode, got %v", err)
+	}
+
+	mock.ExpectExec("INSERT INTO user_settings \\(user_id, after_hours_orders\\)").WithArgs("user-1", data.AfterHoursQueue).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mode, err := hours.SetAfterHoursOrders(ctx, "user-1", " queue ")
+	if err != nil || mode != data.AfterHoursQueue {
+		t.Errorf("got (%q, %v), want QUEUE", mode, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
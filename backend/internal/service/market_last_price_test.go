@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+func TestGetStock_SavesAndFallsBackToLastPrice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	provider := &stubProvider{name: "primary"}
+	svc := NewMarketService(provider, nil, nil, nil)
+	svc.SetLastPrices(data.NewLastPriceStore(db), time.Hour)
+	svc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	mock.ExpectExec("INSERT INTO last_prices").WithArgs("AAPL", "100", "", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	quote, err := svc.GetStock(ctx, "AAPL")
+	if err != nil || quote.Stale {
+		t.Fatalf("fresh quote: %+v, %v", quote, err)
+	}
+
+	// The provider goes down; the saved price is served, flagged stale.
+	provider.err = &ProviderUnavailableError{Provider: "primary", Err: errors.New("status 503")}
+	fetched := now.Add(-10 * time.Minute)
+	mock.ExpectQuery("FROM last_prices").WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "quote_date", "fetched_at"}).AddRow("99.50", "10/16/2026", fetched))
+	quote, err = svc.GetStock(ctx, "AAPL")
+	if err != nil {
+		t.Fatalf("stale fallback: %v", err)
+	}
+	if !quote.Stale || quote.FetchedAt == nil || !quote.FetchedAt.Equal(fetched) || quote.Price.String() != "99.5" {
+		t.Errorf("unexpected stale quote %+v", quote)
+	}
+
+	// Too old to serve: the outage surfaces.
+	mock.ExpectQuery("FROM last_prices").WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"price", "quote_date", "fetched_at"}).AddRow("99.50", "10/15/2026", now.Add(-2*time.Hour)))
+	if _, err := svc.GetStock(ctx, "AAPL"); err == nil {
+		t.Error("expected the provider error for a price older than the max age")
+	}
+
+	// A symbol the provider doesn't list never falls back.
+	provider.err = ErrSymbolNotFound
+	if _, err := svc.GetStock(ctx, "AAPL"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("expected ErrSymbolNotFound, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// CurrencyRater is the subset of FXService ListingFX needs.
+type CurrencyRater interface {
+	Rate(ctx context.Context, from, to string) (*FXRate, error)
+}
+
+// ListingFX is a MarketDataProvider that prices every listing in
+// BaseCurrency, so cash, trades and valuations stay in one currency. Quotes
+// and bars for symbols on exchanges that price in another currency (see
+// Exchange) are converted at the day's reference rate; quotes keep the
+// exchange's own price alongside, in major units (pounds, not pence). Bars
+// are converted at today's rate too, which is close enough for charts but
+// not a historical FX record.
+type ListingFX struct {
+	provider MarketDataProvider
+	fx       CurrencyRater
+}
+
+func NewListingFX(provider MarketDataProvider, fx CurrencyRater) *ListingFX {
+	return &ListingFX{provider: provider, fx: fx}
+}
+
+func (l *ListingFX) Name() string { return l.provider.Name() }
+
+// toBase returns what one unit of symbol's listing price is worth in
+// BaseCurrency, or ok false for a symbol already priced in it. rates
+// memoises the lookup per exchange within one call.
+func (l *ListingFX) toBase(ctx context.Context, symbol string, rates map[string]decimal.Decimal) (factor decimal.Decimal, ok bool, err error) {
+	ex := ExchangeOf(symbol)
+	if ex.Currency == BaseCurrency {
+		return decimal.Zero, false, nil
+	}
+	if factor, ok := rates[ex.MIC]; ok {
+		return factor, true, nil
+	}
+	rate, err := l.fx.Rate(ctx, ex.Currency, BaseCurrency)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	factor = rate.Rate
+	if ex.MinorUnitQuotes {
+		factor = factor.Div(decimal.NewFromInt(100))
+	}
+	rates[ex.MIC] = factor
+	return factor, true, nil
+}
+
+func (l *ListingFX) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	quotes, err := l.provider.GetQuote(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]decimal.Decimal)
+	for _, q := range quotes {
+		factor, ok, err := l.toBase(ctx, q.Symbol, rates)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		ex := ExchangeOf(q.Symbol)
+		listed := q.Price
+		if ex.MinorUnitQuotes {
+			listed = listed.Div(decimal.NewFromInt(100))
+		}
+		q.ListingPrice = &listed
+		q.ListingCurrency = ex.Currency
+		q.Price = q.Price.Mul(factor).Round(maxPriceDecimals)
+	}
+	return quotes, nil
+}
+
+func (l *ListingFX) GetEOD(ctx context.Context, req BarRequest) ([]Bar, error) {
+	bars, err := l.provider.GetEOD(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return l.convertBars(ctx, bars)
+}
+
+func (l *ListingFX) GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error) {
+	bars, err := l.provider.GetIntraday(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return l.convertBars(ctx, bars)
+}
+
+func (l *ListingFX) convertBars(ctx context.Context, bars []Bar) ([]Bar, error) {
+	rates := make(map[string]decimal.Decimal)
+	for i := range bars {
+		factor, ok, err := l.toBase(ctx, bars[i].Symbol, rates)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		b := &bars[i]
+		b.Open = b.Open.Mul(factor).Round(maxPriceDecimals)
+		b.High = b.High.Mul(factor).Round(maxPriceDecimals)
+		b.Low = b.Low.Mul(factor).Round(maxPriceDecimals)
+		b.Close = b.Close.Mul(factor).Round(maxPriceDecimals)
+	}
+	return bars, nil
+}
+
+func (l *ListingFX) Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	return l.provider.Search(ctx, query, limit)
+}
+
+// Instrument passes through, filling in the listing currency.
+func (l *ListingFX) Instrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	inst, err := l.provider.Instrument(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	if inst.Currency == "" {
+		inst.Currency = ExchangeOf(symbol).Currency
+	}
+	return inst, nil
+}
+
+func (l *ListingFX) Company(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	return l.provider.Company(ctx, symbol)
+}
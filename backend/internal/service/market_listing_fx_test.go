@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// fixedRater quotes every currency at rate against USD.
+type fixedRater struct {
+	rate  decimal.Decimal
+	asked []string
+}
+
+func (r *fixedRater) Rate(_ context.Context, from, to string) (*FXRate, error) {
+	r.asked = append(r.asked, from+"/"+to)
+	return &FXRate{From: from, To: to, Rate: r.rate}, nil
+}
+
+func TestListingFX_ConvertsNonUSListings(t *testing.T) {
+	fx := &fixedRater{rate: decimal.RequireFromString("1.25")}
+	l := NewListingFX(&echoProvider{name: "marketstack"}, fx)
+
+	quotes, err := l.GetQuote(context.Background(), []string{"AAPL", "VOD.XLON", "BP.XLON", "SHOP.XTSE"})
+	if err != nil {
+		t.Fatalf("GetQuote: %v", err)
+	}
+	// echoProvider quotes 1 for everything: $1, 1p and C$1.
+	want := map[string]string{"AAPL": "1", "VOD.XLON": "0.0125", "BP.XLON": "0.0125", "SHOP.XTSE": "1.25"}
+	for _, q := range quotes {
+		if q.Price.String() != want[q.Symbol] {
+			t.Errorf("%s: price %s, want %s", q.Symbol, q.Price, want[q.Symbol])
+		}
+	}
+	if quotes[0].ListingPrice != nil || quotes[1].ListingCurrency != "GBP" || !quotes[1].ListingPrice.Equal(decimal.RequireFromString("0.01")) {
+		t.Errorf("listing prices: got %+v and %+v", quotes[0], quotes[1])
+	}
+	// One rate per exchange, not per symbol.
+	if len(fx.asked) != 2 {
+		t.Errorf("rates asked: %v", fx.asked)
+	}
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// moversTop is how many symbols each list of a MarketMovers keeps, and so
+// the most GET /api/market/movers can ask for.
+const moversTop = 25
+
+// DefaultMoversLimit is how many symbols per list Movers returns when the
+// caller doesn't say.
+const DefaultMoversLimit = 10
+
+// maxMoversUniverse caps how many symbols one refresh prices, so a large
+// index plus every held and watched symbol can't turn a refresh into
+// thousands of provider calls.
+const maxMoversUniverse = 600
+
+// MarketMovers ranks the day's moves across the tracked universe: the
+// biggest percentage gainers and losers and the most traded by volume, each
+// from the symbols' latest daily bars. Universe is how many symbols had a
+// bar to rank.
+type MarketMovers struct {
+	Gainers    []HistoricalData `json:"gainers"`
+	Losers     []HistoricalData `json:"losers"`
+	MostActive []HistoricalData `json:"most_active"`
+	Universe   int              `json:"universe"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// MoversSource is the subset of MarketService used by MoversService.
+type MoversSource interface {
+	GetBatchHistoricalData(ctx context.Context, symbols []string) (map[string]*HistoricalData, error)
+}
+
+// IndexMemberLister is the subset of ClassificationService used by
+// MoversService.
+type IndexMemberLister interface {
+	IndexMembers(ctx context.Context, code string) ([]data.Classification, error)
+}
+
+// MoversCache holds the latest ranking, shared by every instance.
+type MoversCache interface {
+	GetMovers(ctx context.Context) (*MarketMovers, error)
+	SetMovers(ctx context.Context, movers *MarketMovers, ttl time.Duration) error
+}
+
+// RedisMoversCache implements MoversCache using Redis.
+type RedisMoversCache struct {
+	client *redis.Client
+}
+
+func NewRedisMoversCache(client *redis.Client) *RedisMoversCache {
+	return &RedisMoversCache{client: client}
+}
+
+const moversKey = "market:movers"
+
+// GetMovers returns the cached ranking, or nil on a miss. Redis errors are
+// logged and treated as a miss.
+func (c *RedisMoversCache) GetMovers(ctx context.Context) (*MarketMovers, error) {
+	val, err := c.client.Get(ctx, moversKey).Result()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Error("Redis error getting market movers", "err", err, "component", "movers_cache")
+		}
+		return nil, nil
+	}
+	var movers MarketMovers
+	if err := json.Unmarshal([]byte(val), &movers); err != nil {
+		slog.Error("failed to unmarshal market movers cache entry", "err", err, "component", "movers_cache")
+		return nil, nil
+	}
+	return &movers, nil
+}
+
+// SetMovers stores movers for ttl.
+func (c *RedisMoversCache) SetMovers(ctx context.Context, movers *MarketMovers, ttl time.Duration) error {
+	raw, err := json.Marshal(movers)
+	if err != nil {
+		return fmt.Errorf("error marshaling market movers: %w", err)
+	}
+	if err := c.client.Set(ctx, moversKey, raw, ttl).Err(); err != nil {
+		slog.Error("failed to set market movers cache entry", "err", err, "component", "movers_cache")
+		return err
+	}
+	return nil
+}
+
+// MoversService keeps the dashboard's market movers. The universe it ranks
+// is the members of one index, a configured list, and every symbol anyone
+// holds or watches; Run re-ranks it every interval and shares the result
+// through the cache, so requests never price the universe themselves unless
+// nothing fresh is to hand.
+type MoversService struct {
+	market          MoversSource
+	classifications IndexMemberLister
+	portfolio       *data.PortfolioStore
+	watchlist       *data.WatchlistStore
+	cache           MoversCache
+	index           string
+	symbols         []string
+	interval        time.Duration
+	now             func() time.Time
+
+	refreshMu sync.Mutex // one refresh at a time
+	mu        sync.Mutex
+	last      *MarketMovers
+}
+
+// NewMoversService ranks the members of index (DJI, SPX or NDX; empty for
+// none) together with symbols and the held and watched symbols. portfolio
+// and watchlist may be nil to leave those out.
+func NewMoversService(market MoversSource, classifications IndexMemberLister, portfolio *data.PortfolioStore, watchlist *data.WatchlistStore, index string, symbols []string, interval time.Duration) *MoversService {
+	return &MoversService{
+		market:          market,
+		classifications: classifications,
+		portfolio:       portfolio,
+		watchlist:       watchlist,
+		index:           index,
+		symbols:         symbols,
+		interval:        interval,
+		now:             time.Now,
+	}
+}
+
+// SetCache shares the ranking across instances; without one each instance
+// keeps its own.
+func (s *MoversService) SetCache(cache MoversCache) {
+	s.cache = cache
+}
+
+// Run refreshes the ranking now and then every interval until ctx is done.
+func (s *MoversService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("market movers refresh failed", "err", err, "component", "movers")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Movers returns the latest ranking with at most limit symbols per list,
+// 1 to 25. A ranking older than the refresh interval is replaced first:
+// from the cache if another instance has refreshed, otherwise by refreshing
+// here.
+func (s *MoversService) Movers(ctx context.Context, limit int) (*MarketMovers, error) {
+	if limit < 1 || limit > moversTop {
+		return nil, &util.ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", moversTop)}
+	}
+	movers := s.fresh(ctx)
+	if movers == nil {
+		s.refreshMu.Lock()
+		// Another request may have refreshed while this one waited.
+		if movers = s.fresh(ctx); movers == nil {
+			var err error
+			movers, err = s.refresh(ctx)
+			if err != nil {
+				s.refreshMu.Unlock()
+				return nil, err
+			}
+		}
+		s.refreshMu.Unlock()
+	}
+	out := *movers
+	out.Gainers = out.Gainers[:min(limit, len(out.Gainers))]
+	out.Losers = out.Losers[:min(limit, len(out.Losers))]
+	out.MostActive = out.MostActive[:min(limit, len(out.MostActive))]
+	return &out, nil
+}
+
+// fresh returns a ranking no older than the interval, from memory or the
+// cache, or nil if there is none.
+func (s *MoversService) fresh(ctx context.Context) *MarketMovers {
+	s.mu.Lock()
+	last := s.last
+	s.mu.Unlock()
+	if last != nil && s.now().Sub(last.UpdatedAt) < s.interval {
+		return last
+	}
+	if s.cache == nil {
+		return nil
+	}
+	cached, _ := s.cache.GetMovers(ctx)
+	if cached == nil || s.now().Sub(cached.UpdatedAt) >= s.interval {
+		return nil
+	}
+	s.mu.Lock()
+	s.last = cached
+	s.mu.Unlock()
+	return cached
+}
+
+// Refresh re-ranks the universe and stores the result.
+func (s *MoversService) Refresh(ctx context.Context) (*MarketMovers, error) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.refresh(ctx)
+}
+
+func (s *MoversService) refresh(ctx context.Context) (*MarketMovers, error) {
+	started := s.now()
+	universe, err := s.universe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bars, err := s.market.GetBatchHistoricalData(ctx, universe)
+	if err != nil {
+		return nil, err
+	}
+	movers := rankMovers(bars, moversTop)
+	movers.UpdatedAt = s.now()
+
+	s.mu.Lock()
+	s.last = movers
+	s.mu.Unlock()
+	if s.cache != nil {
+		if err := s.cache.SetMovers(ctx, movers, s.interval); err != nil {
+			slog.Warn("failed to cache market movers", "err", err, "component", "movers")
+		}
+	}
+	slog.Info("market movers refreshed", "symbols", len(universe), "ranked", movers.Universe,
+		"duration_ms", s.now().Sub(started).Milliseconds(), "component", "movers")
+	return movers, nil
+}
+
+// universe returns the distinct tradable symbols to rank, sorted, at most
+// maxMoversUniverse of them. Indexes are left out: they have no volume and
+// can't be traded.
+func (s *MoversService) universe(ctx context.Context) ([]string, error) {
+	all := append([]string(nil), s.symbols...)
+	if s.index != "" && s.classifications != nil {
+		members, err := s.classifications.IndexMembers(ctx, s.index)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			all = append(all, m.Symbol)
+		}
+	}
+	if s.portfolio != nil {
+		held, err := s.portfolio.HeldSymbols(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, held...)
+	}
+	if s.watchlist != nil {
+		watched, err := s.watchlist.WatchedSymbols(ctx)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, watched...)
+	}
+
+	seen := make(map[string]bool, len(all))
+	out := make([]string, 0, len(all))
+	for _, symbol := range all {
+		if seen[symbol] || util.IsIndexSymbol(symbol) {
+			continue
+		}
+		seen[symbol] = true
+		out = append(out, symbol)
+	}
+	sort.Strings(out)
+	if len(out) > maxMoversUniverse {
+		slog.Warn("market movers universe truncated", "symbols", len(out), "max", maxMoversUniverse, "component", "movers")
+		out = out[:maxMoversUniverse]
+	}
+	return out, nil
+}
+
+// rankMovers picks the top gainers (positive changes only, largest first),
+// losers (negative only, largest fall first) and most active symbols from
+// bars, top of each. Ties go to the symbol that sorts first.
+func rankMovers(bars map[string]*HistoricalData, top int) *MarketMovers {
+	all := make([]HistoricalData, 0, len(bars))
+	for _, b := range bars {
+		if b != nil {
+			all = append(all, *b)
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Symbol < all[j].Symbol })
+
+	movers := &MarketMovers{
+		Gainers:    make([]HistoricalData, 0),
+		Losers:     make([]HistoricalData, 0),
+		MostActive: make([]HistoricalData, 0),
+		Universe:   len(all),
+	}
+	for _, b := range all {
+		switch {
+		case b.ChangePercentage.IsPositive():
+			movers.Gainers = append(movers.Gainers, b)
+		case b.ChangePercentage.IsNegative():
+			movers.Losers = append(movers.Losers, b)
+		}
+		if b.Volume > 0 {
+			movers.MostActive = append(movers.MostActive, b)
+		}
+	}
+	sort.SliceStable(movers.Gainers, func(i, j int) bool {
+		return movers.Gainers[i].ChangePercentage.GreaterThan(movers.Gainers[j].ChangePercentage)
+	})
+	sort.SliceStable(movers.Losers, func(i, j int) bool {
+		return movers.Losers[i].ChangePercentage.LessThan(movers.Losers[j].ChangePercentage)
+	})
+	sort.SliceStable(movers.MostActive, func(i, j int) bool {
+		return movers.MostActive[i].Volume > movers.MostActive[j].Volume
+	})
+	movers.Gainers = movers.Gainers[:min(top, len(movers.Gainers))]
+	movers.Losers = movers.Losers[:min(top, len(movers.Losers))]
+	movers.MostActive = movers.MostActive[:min(top, len(movers.MostActive))]
+	return movers
+}
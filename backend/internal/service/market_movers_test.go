@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// fakeMoversSource records the symbols it was asked for and answers from
+// bars.
+type fakeMoversSource struct {
+	bars  map[string]*HistoricalData
+	calls [][]string
+}
+
+func (f *fakeMoversSource) GetBatchHistoricalData(_ context.Context, symbols []string) (map[string]*HistoricalData, error) {
+	f.calls = append(f.calls, symbols)
+	out := make(map[string]*HistoricalData)
+	for _, s := range symbols {
+		if b, ok := f.bars[s]; ok {
+			out[s] = b
+		}
+	}
+	return out, nil
+}
+
+type fakeIndexMembers []string
+
+func (f fakeIndexMembers) IndexMembers(_ context.Context, code string) ([]data.Classification, error) {
+	out := make([]data.Classification, 0, len(f))
+	for _, s := range f {
+		out = append(out, data.Classification{Symbol: s})
+	}
+	return out, nil
+}
+
+type memoryMoversCache struct {
+	movers *MarketMovers
+	ttl    time.Duration
+}
+
+func (c *memoryMoversCache) GetMovers(context.Context) (*MarketMovers, error) { return c.movers, nil }
+
+func (c *memoryMoversCache) SetMovers(_ context.Context, movers *MarketMovers, ttl time.Duration) error {
+	c.movers, c.ttl = movers, ttl
+	return nil
+}
+
+func bar(symbol string, pct int64, volume int) *HistoricalData {
+	return &HistoricalData{Symbol: symbol, ChangePercentage: decimal.NewFromInt(pct), Volume: volume}
+}
+
+func symbolsOf(list []HistoricalData) string {
+	out := make([]string, 0, len(list))
+	for _, b := range list {
+		out = append(out, b.Symbol)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestRankMovers(t *testing.T) {
+	movers := rankMovers(map[string]*HistoricalData{
+		"AAPL": bar("AAPL", 3, 100),
+		"MSFT": bar("MSFT", -2, 300),
+		"NVDA": bar("NVDA", 5, 200),
+		"KO":   bar("KO", 0, 50),
+		"IBM":  bar("IBM", -4, 0),
+		"JPM":  bar("JPM", 3, 100),
+	}, 2)
+
+	if got := symbolsOf(movers.Gainers); got != "NVDA,AAPL" {
+		t.Errorf("gainers: got %s, want NVDA,AAPL", got)
+	}
+	if got := symbolsOf(movers.Losers); got != "IBM,MSFT" {
+		t.Errorf("losers: got %s, want IBM,MSFT", got)
+	}
+	if got := symbolsOf(movers.MostActive); got != "MSFT,NVDA" {
+		t.Errorf("most active: got %s, want MSFT,NVDA", got)
+	}
+	if movers.Universe != 6 {
+		t.Errorf("universe: got %d, want 6", movers.Universe)
+	}
+}
+
+func TestMovers_RefreshesOncePerInterval(t *testing.T) {
+	source := &fakeMoversSource{bars: map[string]*HistoricalData{
+		"AAPL": bar("AAPL", 3, 100),
+		"MSFT": bar("MSFT", -2, 300),
+		"NVDA": bar("NVDA", 5, 200),
+	}}
+	cache := &memoryMoversCache{}
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	svc := NewMoversService(source, fakeIndexMembers{"MSFT", "AAPL", "^DJI"}, nil, nil, "DJI", []string{"NVDA", "AAPL"}, 15*time.Minute)
+	svc.SetCache(cache)
+	svc.now = func() time.Time { return now }
+
+	movers, err := svc.Movers(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Movers: %v", err)
+	}
+	if symbolsOf(movers.Gainers) != "NVDA" || symbolsOf(movers.Losers) != "MSFT" || symbolsOf(movers.MostActive) != "MSFT" {
+		t.Errorf("got %+v", movers)
+	}
+	if len(source.calls) != 1 || strings.Join(source.calls[0], ",") != "AAPL,MSFT,NVDA" {
+		t.Errorf("priced %v, want one call for AAPL,MSFT,NVDA", source.calls)
+	}
+	if cache.movers == nil || cache.ttl != 15*time.Minute || len(cache.movers.Gainers) != 2 {
+		t.Errorf("cached %+v for %s", cache.movers, cache.ttl)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, err := svc.Movers(context.Background(), 10); err != nil || len(source.calls) != 1 {
+		t.Errorf("within the interval: got %v after %d calls, want the held ranking", err, len(source.calls))
+	}
+
+	// Another instance refreshed in the meantime: use its ranking.
+	now = now.Add(10 * time.Minute)
+	cache.movers = &MarketMovers{Gainers: []HistoricalData{*bar("KO", 1, 10)}, UpdatedAt: now.Add(-time.Minute)}
+	movers, err = svc.Movers(context.Background(), 10)
+	if err != nil || symbolsOf(movers.Gainers) != "KO" || len(source.calls) != 1 {
+		t.Errorf("from the cache: got %+v, %v after %d calls", movers, err, len(source.calls))
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := svc.Movers(context.Background(), 10); err != nil || len(source.calls) != 2 {
+		t.Errorf("stale: got %v after %d calls, want a refresh", err, len(source.calls))
+	}
+}
+
+func TestMovers_LimitRange(t *testing.T) {
+	svc := NewMoversService(&fakeMoversSource{}, nil, nil, nil, "", nil, time.Minute)
+	for _, limit := range []int{0, 26} {
+		var verr *util.ValidationError
+		if _, err := svc.Movers(context.Background(), limit); !errors.As(err, &verr) {
+			t.Errorf("limit %d: got %v, want a validation error", limit, err)
+		}
+	}
+}
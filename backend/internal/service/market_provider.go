@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// Market data provider names, as set in MARKET_DATA_PROVIDER.
+const (
+	ProviderMarketStack  = "marketstack"
+	ProviderAlphaVantage = "alphavantage"
+	// ProviderCoinbase prices crypto pairs only, set in CRYPTO_DATA_PROVIDER.
+	ProviderCoinbase = "coinbase"
+	// ProviderSimulated invents prices offline; see Simulated.
+	ProviderSimulated = "simulated"
+)
+
+// MarketDataProvider fetches market data from one upstream API. It only
+// translates requests and responses: MarketService keeps the caches, quote
+// sanity checks, stock_history persistence and ticker changes in front of
+// it, so a new provider implements this interface and is named in
+// NewMarketDataProvider without any caller changing. Every method returns
+// ErrSymbolNotFound for a symbol the provider doesn't list, and a
+// ProviderUnavailableError for a timeout, 5xx or rate limit, on which
+// FailoverProvider moves to the next provider.
+type MarketDataProvider interface {
+	// Name identifies the provider in logs, e.g. "marketstack".
+	Name() string
+	// GetQuote returns the latest price of each of symbols, at most
+	// maxQuoteBatch of them. Symbols without data are left out.
+	GetQuote(ctx context.Context, symbols []string) ([]*StockData, error)
+	// GetEOD returns daily bars for req.Symbols between req.From and req.To,
+	// in no particular order.
+	GetEOD(ctx context.Context, req BarRequest) ([]Bar, error)
+	// GetIntraday returns req.Interval bars for req.Symbols, in no
+	// particular order. Providers may need a paid plan for it.
+	GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error)
+	// Search returns up to limit listings whose symbol or name matches query.
+	Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error)
+	// Instrument returns symbol's reference data: name, listing venue (a MIC)
+	// and whether the provider still prices it.
+	Instrument(ctx context.Context, symbol string) (*data.Instrument, error)
+	// Company returns symbol's company profile.
+	Company(ctx context.Context, symbol string) (*CompanyProfile, error)
+}
+
+// BarRequest asks a provider for price bars. From and To are inclusive
+// dates. Limit caps the bars returned across all symbols; providers page
+// until they have Limit or run out.
+type BarRequest struct {
+	Symbols  []string
+	From     time.Time
+	To       time.Time
+	Interval string // intraday only: "5min", "1hour", ...
+	Limit    int
+}
+
+// Bar is one OHLCV bar. Time is midnight UTC of the session for a daily
+// bar and the bar's start for an intraday one. A price the provider left
+// out is zero; an intraday bar still forming closes at its latest trade.
+type Bar struct {
+	Symbol string
+	Time   time.Time
+	Open   decimal.Decimal
+	High   decimal.Decimal
+	Low    decimal.Decimal
+	Close  decimal.Decimal
+	Volume int64
+}
+
+// SymbolMatch is one search result.
+type SymbolMatch struct {
+	Symbol   string `json:"symbol"`
+	Name     string `json:"name"`
+	Exchange string `json:"exchange"` // MIC, e.g. XNAS; empty when unknown
+}
+
+// NewMarketDataProvider returns the provider called name, or an error if no
+// provider has that name. client is the shared outbound client.
+func NewMarketDataProvider(name, apiKey string, client *http.Client) (MarketDataProvider, error) {
+	switch name {
+	case ProviderMarketStack:
+		return NewMarketStack(apiKey, client), nil
+	case ProviderAlphaVantage:
+		return NewAlphaVantage(apiKey, client), nil
+	case ProviderSimulated:
+		return NewSimulated(), nil
+	}
+	return nil, fmt.Errorf("unknown market data provider %q", name)
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Quota periods are UTC days and calendar months; the month is what
+// MarketStack plans bill, the day is for spotting a spike before it eats
+// the month.
+const (
+	quotaDayLayout   = "2006-01-02"
+	quotaMonthLayout = "2006-01"
+	// Counters outlive their period so the last one is still readable
+	// just after it ends.
+	quotaDayRetention   = 48 * time.Hour
+	quotaMonthRetention = 32 * 24 * time.Hour
+)
+
+// QuotaCounter counts the requests made to each market data provider per
+// day and month.
+type QuotaCounter interface {
+	// Incr counts one request to provider made at at.
+	Incr(ctx context.Context, provider string, at time.Time) error
+	// Counts returns provider's requests on at's day and in at's month.
+	Counts(ctx context.Context, provider string, at time.Time) (day, month int64, err error)
+}
+
+func quotaDayKey(provider string, at time.Time) string {
+	return fmt.Sprintf("quota:%s:day:%s", provider, at.UTC().Format(quotaDayLayout))
+}
+
+func quotaMonthKey(provider string, at time.Time) string {
+	return fmt.Sprintf("quota:%s:month:%s", provider, at.UTC().Format(quotaMonthLayout))
+}
+
+// RedisQuotaCounter keeps quota:<provider>:day:<YYYY-MM-DD> and
+// quota:<provider>:month:<YYYY-MM>, shared by every instance.
+type RedisQuotaCounter struct {
+	client *redis.Client
+}
+
+func NewRedisQuotaCounter(client *redis.Client) *RedisQuotaCounter {
+	return &RedisQuotaCounter{client: client}
+}
+
+// Incr implements QuotaCounter.
+func (c *RedisQuotaCounter) Incr(ctx context.Context, provider string, at time.Time) error {
+	day, month := quotaDayKey(provider, at), quotaMonthKey(provider, at)
+	pipe := c.client.Pipeline()
+	pipe.Incr(ctx, day)
+	pipe.Expire(ctx, day, quotaDayRetention)
+	pipe.Incr(ctx, month)
+	pipe.Expire(ctx, month, quotaMonthRetention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Counts implements QuotaCounter.
+func (c *RedisQuotaCounter) Counts(ctx context.Context, provider string, at time.Time) (int64, int64, error) {
+	vals, err := c.client.MGet(ctx, quotaDayKey(provider, at), quotaMonthKey(provider, at)).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	var counts [2]int64
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			counts[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return counts[0], counts[1], nil
+}
+
+// MemoryQuotaCounter is the in-process QuotaCounter used without Redis.
+// Counts are per instance and lost on restart, so it undercounts a
+// deployment of several.
+type MemoryQuotaCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64 // by quotaDayKey / quotaMonthKey
+}
+
+func NewMemoryQuotaCounter() *MemoryQuotaCounter {
+	return &MemoryQuotaCounter{counts: make(map[string]int64)}
+}
+
+// Incr implements QuotaCounter, dropping the provider's earlier periods.
+func (c *MemoryQuotaCounter) Incr(_ context.Context, provider string, at time.Time) error {
+	day, month := quotaDayKey(provider, at), quotaMonthKey(provider, at)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.counts[day]; !ok {
+		prefix := "quota:" + provider + ":"
+		for key := range c.counts {
+			if strings.HasPrefix(key, prefix) && key != month {
+				delete(c.counts, key)
+			}
+		}
+	}
+	c.counts[day]++
+	c.counts[month]++
+	return nil
+}
+
+// Counts implements QuotaCounter.
+func (c *MemoryQuotaCounter) Counts(_ context.Context, provider string, at time.Time) (int64, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[quotaDayKey(provider, at)], c.counts[quotaMonthKey(provider, at)], nil
+}
+
+type nonCriticalKey struct{}
+
+// nonCritical marks ctx's provider calls as ones the app can do without
+// (searches, charts, company profiles, cache warm-up), which QuotaTracker
+// turns away once the month's budget is nearly spent.
+func nonCritical(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nonCriticalKey{}, true)
+}
+
+func isNonCritical(ctx context.Context) bool {
+	v, _ := ctx.Value(nonCriticalKey{}).(bool)
+	return v
+}
+
+// QuotaUsage is a provider's request count against its plan, as shown at
+// GET /api/admin/market-data/quota.
+type QuotaUsage struct {
+	Provider      string    `json:"provider"`
+	Day           string    `json:"day"`   // UTC, YYYY-MM-DD
+	Month         string    `json:"month"` // UTC, YYYY-MM
+	DayRequests   int64     `json:"day_requests"`
+	MonthRequests int64     `json:"month_requests"`
+	MonthlyQuota  int64     `json:"monthly_quota"` // 0 when not configured
+	Remaining     *int64    `json:"remaining"`     // nil without a quota
+	ShedPercent   int       `json:"shed_percent"`  // 0 never sheds
+	Shedding      bool      `json:"shedding"`      // non-critical calls are being refused
+	ResetsAt      time.Time `json:"resets_at"`     // start of the next UTC month
+}
+
+// QuotaTracker counts every request one provider makes and, once
+// shedPercent of the monthly quota is used, refuses non-critical ones so
+// what is left goes to quotes, EOD closes and portfolio history. Counting
+// fails open: if the counter is unreachable the request still goes out.
+type QuotaTracker struct {
+	provider    string
+	counter     QuotaCounter
+	monthly     int64
+	shedPercent int
+	now         func() time.Time
+}
+
+// NewQuotaTracker tracks provider against a plan of monthlyQuota requests
+// (0 when unknown: counted, never shed). shedPercent of 0 never sheds.
+func NewQuotaTracker(provider string, counter QuotaCounter, monthlyQuota, shedPercent int) *QuotaTracker {
+	return &QuotaTracker{provider: provider, counter: counter, monthly: int64(monthlyQuota), shedPercent: shedPercent, now: time.Now}
+}
+
+// shedAt is the monthly count from which non-critical calls are refused,
+// or 0 when they never are.
+func (q *QuotaTracker) shedAt() int64 {
+	if q.monthly <= 0 || q.shedPercent <= 0 {
+		return 0
+	}
+	return q.monthly * int64(q.shedPercent) / 100
+}
+
+// Spend counts one request about to be made, first refusing it with a
+// QuotaShedError when ctx is non-critical and the month has reached the
+// shedding threshold.
+func (q *QuotaTracker) Spend(ctx context.Context) error {
+	now := q.now()
+	if limit := q.shedAt(); limit > 0 && isNonCritical(ctx) {
+		_, month, err := q.counter.Counts(ctx, q.provider, now)
+		if err != nil {
+			slog.Warn("failed to read provider quota", "provider", q.provider, "err", err, "component", "market")
+		} else if month >= limit {
+			return &QuotaShedError{Provider: q.provider, Used: month, Quota: q.monthly}
+		}
+	}
+	if err := q.counter.Incr(ctx, q.provider, now); err != nil {
+		slog.Warn("failed to count provider request", "provider", q.provider, "err", err, "component", "market")
+	}
+	return nil
+}
+
+// Usage reports the current day's and month's counts.
+func (q *QuotaTracker) Usage(ctx context.Context) (*QuotaUsage, error) {
+	now := q.now().UTC()
+	day, month, err := q.counter.Counts(ctx, q.provider, now)
+	if err != nil {
+		return nil, fmt.Errorf("read %s quota: %w", q.provider, err)
+	}
+	usage := &QuotaUsage{
+		Provider:      q.provider,
+		Day:           now.Format(quotaDayLayout),
+		Month:         now.Format(quotaMonthLayout),
+		DayRequests:   day,
+		MonthRequests: month,
+		MonthlyQuota:  q.monthly,
+		ShedPercent:   q.shedPercent,
+		ResetsAt:      time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if q.monthly > 0 {
+		remaining := max(q.monthly-month, 0)
+		usage.Remaining = &remaining
+	}
+	if limit := q.shedAt(); limit > 0 {
+		usage.Shedding = month >= limit
+	}
+	return usage, nil
+}
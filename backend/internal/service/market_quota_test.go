@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQuotaTracker_ShedsNonCriticalCallsPastThreshold(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[{"symbol":"AAPL","name":"Apple Inc","close":230.5,"date":"2026-10-15T00:00:00+0000"}]}`))
+	}))
+	prevTickers, prevLatest := marketStackTickersURL, marketStackLatestURL
+	marketStackTickersURL, marketStackLatestURL = srv.URL, srv.URL
+	t.Cleanup(func() {
+		marketStackTickersURL, marketStackLatestURL = prevTickers, prevLatest
+		srv.Close()
+	})
+
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	counter := NewMemoryQuotaCounter()
+	for range 3 {
+		counter.Incr(ctx, ProviderMarketStack, now.AddDate(0, 0, -1))
+	}
+	quota := NewQuotaTracker(ProviderMarketStack, counter, 10, 50)
+	quota.now = func() time.Time { return now }
+	provider := NewMarketStack("test-key", http.DefaultClient)
+	provider.SetQuota(quota)
+	svc := NewMarketService(provider, nil, nil, nil)
+
+	// The fifth request of the month reaches 50% of 10; searches stop there.
+	for range 2 {
+		if _, err := svc.SearchSymbols(ctx, "apple", 1); err != nil {
+			t.Fatalf("SearchSymbols under the threshold: %v", err)
+		}
+	}
+	var shed *QuotaShedError
+	if _, err := svc.SearchSymbols(ctx, "apple", 1); !errors.As(err, &shed) || shed.Used != 5 {
+		t.Fatalf("SearchSymbols past the threshold: got %v, want a QuotaShedError", err)
+	}
+	// Quotes still go out.
+	if _, err := svc.GetStock(ctx, "AAPL"); err != nil {
+		t.Fatalf("GetStock past the threshold: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("provider got %d requests, want 3", n)
+	}
+
+	usage, err := quota.Usage(ctx)
+	if err != nil {
+		t.Fatalf("Usage: %v", err)
+	}
+	if usage.DayRequests != 3 || usage.MonthRequests != 6 || usage.Remaining == nil || *usage.Remaining != 4 || !usage.Shedding {
+		t.Errorf("usage: got %+v", usage)
+	}
+	if want := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC); !usage.ResetsAt.Equal(want) {
+		t.Errorf("resets at %v, want %v", usage.ResetsAt, want)
+	}
+}
+
+func TestMemoryQuotaCounter_StartsANewMonth(t *testing.T) {
+	ctx := context.Background()
+	counter := NewMemoryQuotaCounter()
+	october := time.Date(2026, 10, 31, 23, 0, 0, 0, time.UTC)
+	counter.Incr(ctx, ProviderMarketStack, october)
+	counter.Incr(ctx, ProviderMarketStack, october.Add(2*time.Hour))
+
+	if day, month, _ := counter.Counts(ctx, ProviderMarketStack, october.Add(2*time.Hour)); day != 1 || month != 1 {
+		t.Errorf("November: got %d today and %d this month, want 1 and 1", day, month)
+	}
+	if len(counter.counts) != 2 {
+		t.Errorf("kept %d counters, want only November's 2", len(counter.counts))
+	}
+}
+
+func TestFailoverProvider_MovesOnFromShedCall(t *testing.T) {
+	shed := &stubProvider{name: "primary", err: &QuotaShedError{Provider: "primary", Used: 90, Quota: 100}}
+	secondary := &stubProvider{name: "secondary"}
+	f := NewFailoverProvider([]MarketDataProvider{shed, secondary}, 1, time.Minute)
+
+	if quotes, err := f.GetQuote(nonCritical(context.Background()), []string{"AAPL"}); err != nil || len(quotes) != 1 {
+		t.Fatalf("GetQuote: %v, %v", quotes, err)
+	}
+	// A shed call says nothing about the provider's health.
+	if st := f.Status()[0]; st.State != BreakerClosed {
+		t.Errorf("primary breaker %s after a shed call, want closed", st.State)
+	}
+}
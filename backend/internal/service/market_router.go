@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// AssetRouter is a MarketDataProvider that sends crypto pairs to one
+// provider and everything else to another, so MarketService prices both
+// without knowing which is which. Batch calls are split by asset class and
+// the answers merged. A nil crypto provider leaves crypto pairs unlisted.
+type AssetRouter struct {
+	stocks MarketDataProvider
+	crypto MarketDataProvider
+}
+
+func NewAssetRouter(stocks, crypto MarketDataProvider) *AssetRouter {
+	return &AssetRouter{stocks: stocks, crypto: crypto}
+}
+
+// Name is the stock provider's name, then the crypto provider's after a
+// "+", e.g. "marketstack+coinbase".
+func (r *AssetRouter) Name() string {
+	if r.crypto == nil {
+		return r.stocks.Name()
+	}
+	return r.stocks.Name() + "+" + r.crypto.Name()
+}
+
+// splitByAssetClass partitions symbols into stocks and crypto pairs.
+func splitByAssetClass(symbols []string) (stocks, crypto []string) {
+	for _, s := range symbols {
+		if util.IsCryptoPair(s) {
+			crypto = append(crypto, s)
+		} else {
+			stocks = append(stocks, s)
+		}
+	}
+	return stocks, crypto
+}
+
+// route runs call on the stock provider with the stock symbols and on the
+// crypto provider with the crypto pairs, skipping a side with none, and
+// concatenates the answers.
+func route[T any](r *AssetRouter, symbols []string, call func(p MarketDataProvider, symbols []string) ([]T, error)) ([]T, error) {
+	stocks, crypto := splitByAssetClass(symbols)
+	var out []T
+	if len(stocks) > 0 {
+		v, err := call(r.stocks, stocks)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v...)
+	}
+	if len(crypto) > 0 && r.crypto != nil {
+		v, err := call(r.crypto, crypto)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v...)
+	}
+	return out, nil
+}
+
+func (r *AssetRouter) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	return route(r, symbols, func(p MarketDataProvider, symbols []string) ([]*StockData, error) {
+		return p.GetQuote(ctx, symbols)
+	})
+}
+
+func (r *AssetRouter) GetEOD(ctx context.Context, req BarRequest) ([]Bar, error) {
+	bars, err := route(r, req.Symbols, func(p MarketDataProvider, symbols []string) ([]Bar, error) {
+		sub := req
+		sub.Symbols = symbols
+		return p.GetEOD(ctx, sub)
+	})
+	return capBars(bars, req.Limit), err
+}
+
+func (r *AssetRouter) GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error) {
+	bars, err := route(r, req.Symbols, func(p MarketDataProvider, symbols []string) ([]Bar, error) {
+		sub := req
+		sub.Symbols = symbols
+		return p.GetIntraday(ctx, sub)
+	})
+	return capBars(bars, req.Limit), err
+}
+
+// Search asks the stock provider, then fills any room left under limit
+// with crypto pairs. A failed crypto search is logged and the stock
+// matches returned alone.
+func (r *AssetRouter) Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	matches, err := r.stocks.Search(ctx, query, limit)
+	if err != nil || r.crypto == nil || len(matches) >= limit {
+		return matches, err
+	}
+	crypto, err := r.crypto.Search(ctx, strings.TrimSuffix(strings.ToUpper(query), "-USD"), limit-len(matches))
+	if err != nil {
+		slog.Warn("crypto symbol search failed", "provider", r.crypto.Name(), "err", err, "component", "market")
+		return matches, nil
+	}
+	return append(matches, crypto...), nil
+}
+
+// provider returns the provider symbol routes to, or nil for a crypto
+// pair with no crypto provider.
+func (r *AssetRouter) provider(symbol string) MarketDataProvider {
+	if util.IsCryptoPair(symbol) {
+		return r.crypto
+	}
+	return r.stocks
+}
+
+func (r *AssetRouter) Instrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	p := r.provider(symbol)
+	if p == nil {
+		return nil, ErrSymbolNotFound
+	}
+	return p.Instrument(ctx, symbol)
+}
+
+func (r *AssetRouter) Company(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	p := r.provider(symbol)
+	if p == nil {
+		return nil, ErrSymbolNotFound
+	}
+	return p.Company(ctx, symbol)
+}
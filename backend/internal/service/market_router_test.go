@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// echoProvider quotes every symbol it is asked for and records them.
+type echoProvider struct {
+	MarketDataProvider
+	name  string
+	asked []string
+}
+
+func (p *echoProvider) Name() string { return p.name }
+func (p *echoProvider) GetQuote(_ context.Context, symbols []string) ([]*StockData, error) {
+	p.asked = append(p.asked, symbols...)
+	quotes := make([]*StockData, len(symbols))
+	for i, s := range symbols {
+		quotes[i] = &StockData{Symbol: s, Price: decimal.NewFromInt(1)}
+	}
+	return quotes, nil
+}
+
+func TestAssetRouter_SplitsByAssetClass(t *testing.T) {
+	stocks := &echoProvider{name: "marketstack"}
+	crypto := &echoProvider{name: "coinbase"}
+	r := NewAssetRouter(stocks, crypto)
+
+	quotes, err := r.GetQuote(context.Background(), []string{"AAPL", "BTC-USD", "MSFT"})
+	if err != nil {
+		t.Fatalf("GetQuote: %v", err)
+	}
+	if len(quotes) != 3 || len(stocks.asked) != 2 || len(crypto.asked) != 1 || crypto.asked[0] != "BTC-USD" {
+		t.Errorf("stocks asked %v, crypto asked %v, %d quotes", stocks.asked, crypto.asked, len(quotes))
+	}
+	if r.Name() != "marketstack+coinbase" {
+		t.Errorf("name: got %q", r.Name())
+	}
+}
+
+func TestAssetRouter_NoCryptoProvider(t *testing.T) {
+	stocks := &echoProvider{name: "marketstack"}
+	r := NewAssetRouter(stocks, nil)
+
+	quotes, err := r.GetQuote(context.Background(), []string{"BTC-USD"})
+	if err != nil || len(quotes) != 0 || len(stocks.asked) != 0 {
+		t.Errorf("got %v, %v; stocks asked %v", quotes, err, stocks.asked)
+	}
+	if _, err := r.Instrument(context.Background(), "BTC-USD"); !errors.Is(err, ErrSymbolNotFound) {
+		t.Errorf("instrument: got %v, want ErrSymbolNotFound", err)
+	}
+}
@@ -173,7 +173,7 @@ func TestFetchEODSeries_PaginatesUntilShortPage(t *testing.T) {
 		"200": page2,
 	}, &calls)
 
-	svc := &MarketService{apiKey: "test-key"}
+	svc := &MarketService{provider: NewMarketStack("test-key", http.DefaultClient)}
 	got, err := svc.fetchEODSeries(context.Background(), "AAPL",
 		mustDate("2026-01-01"), mustDate("2026-01-10"))
 	if err != nil {
@@ -216,7 +216,7 @@ func TestGetHistoricalSeries_ServesFromDB_NoAPICall(t *testing.T) {
 
 	store := data.NewStockHistoryStore(db)
 	svc := &MarketService{
-		apiKey:            "test-key",
+		provider:          NewMarketStack("test-key", http.DefaultClient),
 		stockHistoryStore: store,
 		historicalCache:   newFakeHistoricalCache(),
 	}
@@ -254,7 +254,7 @@ func TestGetHistoricalSeries_EmptyGapMemoizedToCache(t *testing.T) {
 
 	cache := newFakeHistoricalCache()
 	svc := &MarketService{
-		apiKey:            "test-key",
+		provider:          NewMarketStack("test-key", http.DefaultClient),
 		stockHistoryStore: data.NewStockHistoryStore(db),
 		historicalCache:   cache,
 	}
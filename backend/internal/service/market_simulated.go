@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// simulatedIntervals maps our bar intervals to their width.
+var simulatedIntervals = map[string]time.Duration{
+	"1min": time.Minute, "5min": 5 * time.Minute, "15min": 15 * time.Minute,
+	"30min": 30 * time.Minute, "1hour": time.Hour,
+}
+
+// simulatedSession is the trading day the simulated provider prices: 14:30
+// to 21:00 UTC, the New York session outside daylight saving.
+const (
+	simulatedOpen    = 14*time.Hour + 30*time.Minute
+	simulatedSession = 6*time.Hour + 30*time.Minute
+)
+
+// simulatedListings name the symbols Search suggests. Every other valid
+// symbol is priced too, named after itself.
+var simulatedListings = map[string]string{
+	"AAPL": "Apple Inc.", "MSFT": "Microsoft Corporation", "GOOGL": "Alphabet Inc. Class A",
+	"AMZN": "Amazon.com, Inc.", "NVDA": "NVIDIA Corporation", "META": "Meta Platforms, Inc.",
+	"TSLA": "Tesla, Inc.", "JPM": "JPMorgan Chase & Co.", "V": "Visa Inc.",
+	"KO": "The Coca-Cola Company", "DIS": "The Walt Disney Company", "NFLX": "Netflix, Inc.",
+	"SPY": "SPDR S&P 500 ETF Trust", "QQQ": "Invesco QQQ Trust",
+}
+
+// Simulated is an offline MarketDataProvider for self-hosting without a
+// data vendor: it invents prices instead of fetching them. Every valid
+// symbol is listed on XNAS and follows its own smooth, deterministic path,
+// so quotes, bars and charts agree with each other across restarts and
+// instances. The prices are not real market data.
+type Simulated struct {
+	now func() time.Time
+}
+
+// NewSimulated builds the provider.
+func NewSimulated() *Simulated {
+	return &Simulated{now: time.Now}
+}
+
+func (s *Simulated) Name() string { return ProviderSimulated }
+
+// GetQuote prices each symbol as of now.
+func (s *Simulated) GetQuote(_ context.Context, symbols []string) ([]*StockData, error) {
+	now := s.now().UTC()
+	quotes := make([]*StockData, 0, len(symbols))
+	for _, symbol := range symbols {
+		if _, err := util.ValidateQuoteSymbol(symbol); err != nil {
+			continue
+		}
+		quotes = append(quotes, &StockData{
+			Symbol: symbol,
+			Price:  simulatedPrice(symbol, now),
+			Date:   now.Format(DateLayoutUS),
+		})
+	}
+	return quotes, nil
+}
+
+// GetEOD returns a bar per weekday session between req.From and req.To
+// that has closed by now.
+func (s *Simulated) GetEOD(_ context.Context, req BarRequest) ([]Bar, error) {
+	now := s.now().UTC()
+	var out []Bar
+	for _, symbol := range req.Symbols {
+		for day := simulatedDay(req.From); !day.After(req.To); day = day.AddDate(0, 0, 1) {
+			open := day.Add(simulatedOpen)
+			if !simulatedTrades(day) || open.Add(simulatedSession).After(now) {
+				continue
+			}
+			out = append(out, simulatedBar(symbol, open, simulatedSession, day))
+		}
+	}
+	return capBars(out, req.Limit), nil
+}
+
+// GetIntraday returns req.Interval bars for each weekday session between
+// req.From and the end of req.To, up to the one forming now.
+func (s *Simulated) GetIntraday(_ context.Context, req BarRequest) ([]Bar, error) {
+	width, ok := simulatedIntervals[req.Interval]
+	if !ok {
+		return nil, fmt.Errorf("simulated: unsupported interval %q", req.Interval)
+	}
+	now := s.now().UTC()
+	var out []Bar
+	for _, symbol := range req.Symbols {
+		for day := simulatedDay(req.From); !day.After(req.To); day = day.AddDate(0, 0, 1) {
+			if !simulatedTrades(day) {
+				continue
+			}
+			for start := day.Add(simulatedOpen); start.Before(day.Add(simulatedOpen + simulatedSession)); start = start.Add(width) {
+				if start.After(now) {
+					break
+				}
+				out = append(out, simulatedBar(symbol, start, width, start))
+			}
+		}
+	}
+	return capBars(out, req.Limit), nil
+}
+
+// Search matches query against the built-in listings by symbol or name. A
+// query that is itself a valid symbol matches that symbol too.
+func (s *Simulated) Search(_ context.Context, query string, limit int) ([]SymbolMatch, error) {
+	query = strings.TrimSpace(query)
+	upper := strings.ToUpper(query)
+	matches := make([]SymbolMatch, 0)
+	if symbol, err := util.ValidateSymbol(query); err == nil {
+		if _, listed := simulatedListings[symbol]; !listed {
+			matches = append(matches, SymbolMatch{Symbol: symbol, Name: symbol, Exchange: "XNAS"})
+		}
+	}
+	symbols := make([]string, 0, len(simulatedListings))
+	for symbol, name := range simulatedListings {
+		if strings.HasPrefix(symbol, upper) || strings.Contains(strings.ToUpper(name), upper) {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		matches = append(matches, SymbolMatch{Symbol: symbol, Name: simulatedListings[symbol], Exchange: "XNAS"})
+	}
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Instrument lists every valid symbol on XNAS.
+func (s *Simulated) Instrument(_ context.Context, symbol string) (*data.Instrument, error) {
+	if _, err := util.ValidateQuoteSymbol(symbol); err != nil {
+		return nil, ErrSymbolNotFound
+	}
+	inst := &data.Instrument{Symbol: symbol, Name: simulatedName(symbol), Exchange: "XNAS", AssetType: "stock"}
+	if util.IsIndexSymbol(symbol) {
+		inst.AssetType = "index"
+	}
+	return inst, nil
+}
+
+// Company returns a profile that says the prices are simulated.
+func (s *Simulated) Company(_ context.Context, symbol string) (*CompanyProfile, error) {
+	if _, err := util.ValidateQuoteSymbol(symbol); err != nil {
+		return nil, ErrSymbolNotFound
+	}
+	return &CompanyProfile{
+		Symbol:      symbol,
+		Name:        simulatedName(symbol),
+		Exchange:    "XNAS",
+		Description: "Prices for this symbol are simulated by this PaperTrader server and are not real market data.",
+	}, nil
+}
+
+func simulatedName(symbol string) string {
+	if name, ok := simulatedListings[symbol]; ok {
+		return name
+	}
+	return symbol
+}
+
+func simulatedDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func simulatedTrades(day time.Time) bool {
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// simulatedBar is the bar of width starting at start, sampled every
+// minute. Its volume is seeded by symbol and seed.
+func simulatedBar(symbol string, start time.Time, width time.Duration, seed time.Time) Bar {
+	bar := Bar{Symbol: symbol, Time: seed, Open: simulatedPrice(symbol, start)}
+	bar.High, bar.Low = bar.Open, bar.Open
+	step := width / 30
+	if step < time.Minute {
+		step = time.Minute
+	}
+	for t := start.Add(step); !t.After(start.Add(width)); t = t.Add(step) {
+		p := simulatedPrice(symbol, t)
+		bar.High = decimal.Max(bar.High, p)
+		bar.Low = decimal.Min(bar.Low, p)
+		bar.Close = p
+	}
+	bar.Volume = int64(simulatedHash(symbol+seed.Format(time.RFC3339))%900_000+100_000) * int64(width/time.Minute) / int64(simulatedSession/time.Minute)
+	return bar
+}
+
+// simulatedPrice is symbol's price at t: a per-symbol base between 20 and
+// 500 moved by three waves of different lengths and phases, so it drifts
+// over months, swings over weeks and wiggles within the day, but never
+// strays more than 25% from its base.
+func simulatedPrice(symbol string, t time.Time) decimal.Decimal {
+	h := simulatedHash(symbol)
+	base := 20 + float64(h%48_000)/100
+	days := float64(t.Unix()) / 86400
+	phase := func(shift uint) float64 { return float64((h>>shift)%1000) / 1000 * 2 * math.Pi }
+	move := 0.15*math.Sin(2*math.Pi*days/120+phase(8)) +
+		0.07*math.Sin(2*math.Pi*days/17+phase(20)) +
+		0.03*math.Sin(2*math.Pi*days*3+phase(32))
+	return decimal.NewFromFloat(base * (1 + move)).Round(2)
+}
+
+func simulatedHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSimulated_QuoteMatchesBars(t *testing.T) {
+	// Friday 2026-10-16, after the close.
+	now := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)
+	s := NewSimulated()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	quotes, err := s.GetQuote(ctx, []string{"AAPL"})
+	if err != nil || len(quotes) != 1 || !quotes[0].Price.IsPositive() {
+		t.Fatalf("GetQuote: %v %+v", err, quotes)
+	}
+
+	bars, err := s.GetEOD(ctx, BarRequest{Symbols: []string{"AAPL"}, From: now.AddDate(0, 0, -6), To: now})
+	if err != nil {
+		t.Fatalf("GetEOD: %v", err)
+	}
+	// Sat 10th through Fri 16th: five sessions, the weekend left out.
+	if len(bars) != 5 {
+		t.Fatalf("expected 5 daily bars, got %d", len(bars))
+	}
+	for _, b := range bars {
+		if b.Low.GreaterThan(b.Open) || b.Low.GreaterThan(b.Close) || b.High.LessThan(b.Open) || b.High.LessThan(b.Close) {
+			t.Errorf("inconsistent bar %+v", b)
+		}
+	}
+	last := bars[len(bars)-1]
+	if !last.Close.Equal(simulatedPrice("AAPL", time.Date(2026, 10, 16, 21, 0, 0, 0, time.UTC))) {
+		t.Errorf("close %s should be the price at the end of the session", last.Close)
+	}
+}
+
+func TestSimulated_IntradayStopsAtNow(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 10, 0, 0, time.UTC)
+	s := NewSimulated()
+	s.now = func() time.Time { return now }
+
+	bars, err := s.GetIntraday(context.Background(), BarRequest{Symbols: []string{"MSFT"}, From: now, To: now, Interval: "15min"})
+	if err != nil {
+		t.Fatalf("GetIntraday: %v", err)
+	}
+	// 14:30, 14:45 and 15:00 have started by 15:10.
+	if len(bars) != 3 || !bars[2].Time.Equal(time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected bars %+v", bars)
+	}
+}
+
+func TestSimulated_Search(t *testing.T) {
+	// MICRO is a valid symbol too, so it leads.
+	matches, err := NewSimulated().Search(context.Background(), "micro", 5)
+	if err != nil || len(matches) != 2 || matches[1].Symbol != "MSFT" {
+		t.Fatalf("Search: %v %+v", err, matches)
+	}
+	matches, _ = NewSimulated().Search(context.Background(), "zzzz", 5)
+	if len(matches) != 1 || matches[0].Symbol != "ZZZZ" {
+		t.Errorf("an unlisted valid symbol should match itself: %+v", matches)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// gatedProvider answers quotes once release is closed, counting calls and
+// signalling entered on the first.
+type gatedProvider struct {
+	MarketDataProvider
+	calls   atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (p *gatedProvider) Name() string { return "gated" }
+func (p *gatedProvider) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	if p.calls.Add(1) == 1 {
+		close(p.entered)
+	}
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return []*StockData{{Symbol: symbols[0], Price: decimal.NewFromInt(100)}}, nil
+}
+
+func TestGetStock_SharesConcurrentFetches(t *testing.T) {
+	provider := &gatedProvider{entered: make(chan struct{}), release: make(chan struct{})}
+	svc := NewMarketService(provider, nil, nil, nil)
+
+	// The caller that starts the fetch gives up; the fetch carries on for
+	// everyone else.
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := svc.GetStock(leaderCtx, "AAPL")
+		leaderErr <- err
+	}()
+	<-provider.entered
+
+	const callers = 50
+	quotes := make([]*StockData, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			quotes[i], errs[i] = svc.GetStock(context.Background(), "AAPL")
+		}()
+	}
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader: got %v, want context.Canceled", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+
+	if n := provider.calls.Load(); n != 1 {
+		t.Errorf("provider called %d times, want 1", n)
+	}
+	for i := range callers {
+		if errs[i] != nil || !quotes[i].Price.Equal(decimal.NewFromInt(100)) {
+			t.Fatalf("caller %d: %+v, %v", i, quotes[i], errs[i])
+		}
+	}
+	// Each caller gets its own copy to convert.
+	quotes[0].Price = decimal.NewFromInt(1)
+	if !quotes[1].Price.Equal(decimal.NewFromInt(100)) {
+		t.Error("callers share one StockData")
+	}
+
+	// Once it has finished, the next miss fetches again.
+	if _, err := svc.GetStock(context.Background(), "AAPL"); err != nil || provider.calls.Load() != 2 {
+		t.Errorf("after the shared fetch: %d calls, %v", provider.calls.Load(), err)
+	}
+}
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// MarketStack endpoints, overridable so HTTP-mock tests can point the
+// provider at an httptest.Server.
+var (
+	marketStackEODURL        = "https://api.marketstack.com/v1/eod"
+	marketStackLatestURL     = "https://api.marketstack.com/v1/eod/latest"
+	marketStackIntradayURL   = "https://api.marketstack.com/v1/intraday"
+	marketStackTickersURL    = "https://api.marketstack.com/v1/tickers"
+	marketStackTickerInfoURL = "https://api.marketstack.com/v2/tickerinfo"
+)
+
+// eodPageSize is MarketStack's free-tier page size; bar requests page
+// through results eodPageSize rows at a time.
+const eodPageSize = 100
+
+// errNoAPIKey is returned by every MarketStack call when no key is set.
+var errNoAPIKey = errors.New("API key not configured")
+
+// MarketStack is the MarketDataProvider backed by marketstack.com.
+type MarketStack struct {
+	apiKey string
+	client *http.Client
+	quota  *QuotaTracker
+}
+
+// NewMarketStack builds the provider. client is the shared outbound client;
+// calls are capped at MarketStackTimeout on top of its own limit.
+func NewMarketStack(apiKey string, client *http.Client) *MarketStack {
+	return &MarketStack{apiKey: apiKey, client: util.ClientWithTimeout(client, MarketStackTimeout)}
+}
+
+// SetQuota counts every request, each page included, against quota, which
+// may refuse non-critical ones once the month's plan is nearly used.
+func (m *MarketStack) SetQuota(quota *QuotaTracker) {
+	m.quota = quota
+}
+
+func (m *MarketStack) Name() string { return ProviderMarketStack }
+
+// marketStackIndexSuffix is the exchange suffix MarketStack lists indexes
+// under: ^GSPC is GSPC.INDX there.
+const marketStackIndexSuffix = ".INDX"
+
+// marketStackSymbol returns symbol as MarketStack names it.
+func marketStackSymbol(symbol string) string {
+	if util.IsIndexSymbol(symbol) {
+		return strings.TrimPrefix(symbol, "^") + marketStackIndexSuffix
+	}
+	return symbol
+}
+
+// marketStackSymbols maps marketStackSymbol over symbols.
+func marketStackSymbols(symbols []string) []string {
+	out := make([]string, len(symbols))
+	for i, s := range symbols {
+		out[i] = marketStackSymbol(s)
+	}
+	return out
+}
+
+// fromMarketStackSymbol is the inverse of marketStackSymbol.
+func fromMarketStackSymbol(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	if base, ok := strings.CutSuffix(symbol, marketStackIndexSuffix); ok {
+		return "^" + base
+	}
+	return symbol
+}
+
+// GetQuote reads the latest close of each symbol from eod/latest.
+func (m *MarketStack) GetQuote(ctx context.Context, symbols []string) ([]*StockData, error) {
+	q := url.Values{}
+	q.Set("symbols", strings.Join(marketStackSymbols(symbols), ","))
+	q.Set("limit", fmt.Sprint(maxQuoteBatch))
+	resp, err := m.get(ctx, marketStackLatestURL, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, m.statusError(resp)
+	}
+
+	var apiResp struct {
+		Data []struct {
+			Close  float64 `json:"close"`
+			Symbol string  `json:"symbol"`
+			Date   string  `json:"date"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+
+	quotes := make([]*StockData, 0, len(apiResp.Data))
+	for _, entry := range apiResp.Data {
+		parsedDate, err := time.Parse(DateLayoutMarketStack, entry.Date)
+		if err != nil {
+			return nil, fmt.Errorf("parse date %q: %w", entry.Date, err)
+		}
+		quotes = append(quotes, &StockData{
+			Symbol: fromMarketStackSymbol(entry.Symbol),
+			Price:  decimal.NewFromFloatWithExponent(entry.Close, -maxPriceDecimals),
+			Date:   parsedDate.Format(DateLayoutUS),
+		})
+	}
+	return quotes, nil
+}
+
+func (m *MarketStack) GetEOD(ctx context.Context, req BarRequest) ([]Bar, error) {
+	req.Interval = ""
+	return m.bars(ctx, marketStackEODURL, req, true)
+}
+
+func (m *MarketStack) GetIntraday(ctx context.Context, req BarRequest) ([]Bar, error) {
+	return m.bars(ctx, marketStackIntradayURL, req, false)
+}
+
+// bars pages through endpoint eodPageSize rows at a time until req.Limit
+// rows (one page when unset) or a short page.
+func (m *MarketStack) bars(ctx context.Context, endpoint string, req BarRequest, daily bool) ([]Bar, error) {
+	if req.From.After(req.To) {
+		return nil, nil
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = eodPageSize
+	}
+	out := make([]Bar, 0, min(limit, 256))
+	for offset := 0; offset < limit; offset += eodPageSize {
+		rows, err := m.barPage(ctx, endpoint, req, daily, offset)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rows...)
+
+		// Short page (or empty) → no more results. When the true row count is
+		// an exact multiple of eodPageSize this still costs one extra request
+		// that returns zero rows; req.Limit caps the wasted calls.
+		if len(rows) < eodPageSize {
+			break
+		}
+	}
+	return out, nil
+}
+
+// barPage runs a single paginated request. Extracted so the response body
+// has a single defer that runs on every exit.
+func (m *MarketStack) barPage(ctx context.Context, endpoint string, req BarRequest, daily bool, offset int) ([]Bar, error) {
+	q := url.Values{}
+	q.Set("symbols", strings.Join(marketStackSymbols(req.Symbols), ","))
+	if req.Interval != "" {
+		q.Set("interval", req.Interval)
+	}
+	q.Set("date_from", req.From.Format(DateLayoutISO))
+	q.Set("date_to", req.To.Format(DateLayoutISO))
+	q.Set("limit", fmt.Sprint(eodPageSize))
+	q.Set("offset", fmt.Sprint(offset))
+	resp, err := m.get(ctx, endpoint, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, m.statusError(resp)
+	}
+
+	// Intraday bars still forming have no close yet; last is the latest
+	// trade in the bar.
+	var apiResp struct {
+		Data []struct {
+			Symbol string   `json:"symbol"`
+			Date   string   `json:"date"`
+			Open   *float64 `json:"open"`
+			High   *float64 `json:"high"`
+			Low    *float64 `json:"low"`
+			Close  *float64 `json:"close"`
+			Last   *float64 `json:"last"`
+			Volume *float64 `json:"volume"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+
+	out := make([]Bar, 0, len(apiResp.Data))
+	for _, row := range apiResp.Data {
+		t, err := time.Parse(DateLayoutMarketStack, row.Date)
+		if err != nil {
+			slog.Warn("skipping unparseable MarketStack date", "symbol", row.Symbol, "date", row.Date, "err", err)
+			continue
+		}
+		t = t.UTC()
+		if daily {
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		}
+		closePrice := row.Close
+		if closePrice == nil {
+			closePrice = row.Last
+		}
+		bar := Bar{
+			Symbol: fromMarketStackSymbol(row.Symbol),
+			Time:   t,
+			Open:   barPrice(row.Open),
+			High:   barPrice(row.High),
+			Low:    barPrice(row.Low),
+			Close:  barPrice(closePrice),
+		}
+		if row.Volume != nil {
+			bar.Volume = int64(*row.Volume)
+		}
+		out = append(out, bar)
+	}
+	return out, nil
+}
+
+// barPrice switches a MarketStack price from float64 to decimal at the
+// boundary, snapped to maxPriceDecimals places so sub-penny and crypto
+// prices survive; float noise below that is dropped. Missing is zero.
+func barPrice(v *float64) decimal.Decimal {
+	if v == nil {
+		return decimal.Zero
+	}
+	return decimal.NewFromFloatWithExponent(*v, -maxPriceDecimals)
+}
+
+// Search matches query against ticker symbols and company names.
+func (m *MarketStack) Search(ctx context.Context, query string, limit int) ([]SymbolMatch, error) {
+	q := url.Values{}
+	q.Set("search", query)
+	q.Set("limit", fmt.Sprint(limit))
+	resp, err := m.get(ctx, marketStackTickersURL, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, m.statusError(resp)
+	}
+
+	var apiResp struct {
+		Data []struct {
+			Name          string `json:"name"`
+			Symbol        string `json:"symbol"`
+			StockExchange struct {
+				MIC string `json:"mic"`
+			} `json:"stock_exchange"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	matches := make([]SymbolMatch, 0, len(apiResp.Data))
+	for _, d := range apiResp.Data {
+		if d.Symbol == "" {
+			continue
+		}
+		matches = append(matches, SymbolMatch{
+			Symbol:   fromMarketStackSymbol(d.Symbol),
+			Name:     strings.TrimSpace(d.Name),
+			Exchange: strings.ToUpper(d.StockExchange.MIC),
+		})
+	}
+	return matches[:min(len(matches), limit)], nil
+}
+
+// Instrument reads symbol from the tickers endpoint.
+func (m *MarketStack) Instrument(ctx context.Context, symbol string) (*data.Instrument, error) {
+	resp, err := m.get(ctx, marketStackTickersURL+"/"+url.PathEscape(marketStackSymbol(symbol)), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSymbolNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, m.statusError(resp)
+	}
+
+	var apiResp struct {
+		Name          string `json:"name"`
+		Symbol        string `json:"symbol"`
+		HasEOD        *bool  `json:"has_eod"`
+		HasIntraday   *bool  `json:"has_intraday"`
+		StockExchange struct {
+			MIC     string `json:"mic"`
+			Acronym string `json:"acronym"`
+		} `json:"stock_exchange"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	if apiResp.Symbol == "" {
+		return nil, ErrSymbolNotFound
+	}
+
+	inst := &data.Instrument{
+		Symbol:    symbol,
+		Name:      apiResp.Name,
+		Exchange:  strings.ToUpper(apiResp.StockExchange.MIC),
+		AssetType: "stock",
+	}
+	if util.IsIndexSymbol(symbol) {
+		inst.AssetType = "index"
+	}
+	// MarketStack has no explicit suspension field. A ticker that explicitly
+	// reports neither EOD nor intraday data is no longer being priced, which is
+	// the closest signal to a suspension; absent fields are treated as trading.
+	if apiResp.HasEOD != nil && apiResp.HasIntraday != nil && !*apiResp.HasEOD && !*apiResp.HasIntraday {
+		inst.Halted = true
+		inst.HaltReason = "suspended by the data provider"
+	}
+	return inst, nil
+}
+
+// Company reads symbol from the v2 ticker info endpoint.
+func (m *MarketStack) Company(ctx context.Context, symbol string) (*CompanyProfile, error) {
+	q := url.Values{}
+	q.Set("ticker", symbol)
+	resp, err := m.get(ctx, marketStackTickerInfoURL, q)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSymbolNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, m.statusError(resp)
+	}
+
+	var apiResp struct {
+		Data struct {
+			Name         string           `json:"name"`
+			Ticker       string           `json:"ticker"`
+			ExchangeCode string           `json:"exchange_code"`
+			Sector       string           `json:"sector"`
+			Industry     string           `json:"industry"`
+			MarketCap    *decimal.Decimal `json:"market_cap"`
+			About        string           `json:"about"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, err
+	}
+	d := apiResp.Data
+	if d.Ticker == "" {
+		return nil, ErrSymbolNotFound
+	}
+	profile := &CompanyProfile{
+		Symbol:      symbol,
+		Name:        strings.TrimSpace(d.Name),
+		Exchange:    strings.ToUpper(strings.TrimSpace(d.ExchangeCode)),
+		Sector:      strings.TrimSpace(d.Sector),
+		Industry:    strings.TrimSpace(d.Industry),
+		Description: strings.TrimSpace(d.About),
+	}
+	if d.MarketCap != nil && d.MarketCap.IsPositive() {
+		profile.MarketCap = d.MarketCap
+	}
+	return profile, nil
+}
+
+// get sends a GET to endpoint with q and the access key. The key travels
+// in the query string and a transport error quotes the request URL, so
+// the key is stripped from the error before it can reach a log. A transport
+// failure other than the caller giving up is a ProviderUnavailableError; a
+// request the quota tracker refuses is a QuotaShedError and never sent.
+func (m *MarketStack) get(ctx context.Context, endpoint string, q url.Values) (*http.Response, error) {
+	if m.apiKey == "" {
+		return nil, errNoAPIKey
+	}
+	if m.quota != nil {
+		if err := m.quota.Spend(ctx); err != nil {
+			return nil, err
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	q.Set("access_key", m.apiKey)
+	httpReq.URL.RawQuery = q.Encode()
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := m.client.Do(httpReq)
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		uerr.URL = util.RedactURL(httpReq.URL)
+	}
+	if err != nil && ctx.Err() == nil {
+		return nil, &ProviderUnavailableError{Provider: ProviderMarketStack, Err: err}
+	}
+	return resp, err
+}
+
+// statusError describes a non-200 MarketStack response, quoting the start
+// of its body with the access key masked in case the provider echoes it.
+// A 5xx or 429 is a ProviderUnavailableError.
+func (m *MarketStack) statusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	msg := strings.TrimSpace(string(body))
+	msg = strings.ReplaceAll(msg, m.apiKey, util.Redacted)
+	err := fmt.Errorf("API returned status %d: %s", resp.StatusCode, msg)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return &ProviderUnavailableError{Provider: ProviderMarketStack, Err: err}
+	}
+	return err
+}
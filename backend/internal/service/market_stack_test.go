@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMarketStackSearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("search") != "apple" || q.Get("limit") != "2" || q.Get("access_key") != "test-key" {
+			t.Errorf("query: got %v", q)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[
+			{"name":" Apple Inc ","symbol":"aapl","stock_exchange":{"mic":"xnas"}},
+			{"name":"No symbol","symbol":""},
+			{"name":"Apple Hospitality REIT","symbol":"APLE","stock_exchange":{"mic":"XNYS"}}]}`))
+	}))
+	prev := marketStackTickersURL
+	marketStackTickersURL = srv.URL
+	t.Cleanup(func() {
+		marketStackTickersURL = prev
+		srv.Close()
+	})
+
+	svc := NewMarketService(NewMarketStack("test-key", http.DefaultClient), nil, nil, nil)
+	got, err := svc.SearchSymbols(context.Background(), " apple ", 2)
+	if err != nil {
+		t.Fatalf("SearchSymbols: %v", err)
+	}
+	want := []SymbolMatch{{Symbol: "AAPL", Name: "Apple Inc", Exchange: "XNAS"}, {Symbol: "APLE", Name: "Apple Hospitality REIT", Exchange: "XNYS"}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestMarketStack_IndexSymbols(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("symbols"); got != "GSPC.INDX,QQQ" {
+			t.Errorf("symbols: got %q, want GSPC.INDX,QQQ", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[
+			{"symbol":"GSPC.INDX","close":5712.34,"date":"2026-10-15T00:00:00+0000"},
+			{"symbol":"QQQ","close":488.1,"date":"2026-10-15T00:00:00+0000"}]}`))
+	}))
+	prev := marketStackLatestURL
+	marketStackLatestURL = srv.URL
+	t.Cleanup(func() {
+		marketStackLatestURL = prev
+		srv.Close()
+	})
+
+	quotes, err := NewMarketStack("test-key", http.DefaultClient).GetQuote(context.Background(), []string{"^GSPC", "QQQ"})
+	if err != nil || len(quotes) != 2 {
+		t.Fatalf("GetQuote: got %v, %v", quotes, err)
+	}
+	if quotes[0].Symbol != "^GSPC" || quotes[0].Price.String() != "5712.34" || quotes[1].Symbol != "QQQ" {
+		t.Errorf("got %+v, %+v", quotes[0], quotes[1])
+	}
+}
+
+func TestNewMarketDataProvider(t *testing.T) {
+	p, err := NewMarketDataProvider(ProviderMarketStack, "key", http.DefaultClient)
+	if err != nil || p.Name() != ProviderMarketStack {
+		t.Errorf("marketstack: got %v, %v", p, err)
+	}
+	if _, err := NewMarketDataProvider("bloomberg", "key", http.DefaultClient); err == nil {
+		t.Error("unknown provider: want an error")
+	}
+}
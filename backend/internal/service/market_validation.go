@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// maxPriceMovePct is the largest move, in percent, a price may make from
+// the last accepted one before it has to be confirmed. Real moves that big
+// (splits, takeovers, collapses) are rare; a provider glitch is not.
+const maxPriceMovePct = 50
+
+// confirmTolerancePct is how close a second reading must be to a held-back
+// price to confirm it as the new level.
+const confirmTolerancePct = 1
+
+// priceMovePct returns how far price has moved from reference, in percent.
+func priceMovePct(price, reference decimal.Decimal) decimal.Decimal {
+	return price.Sub(reference).Abs().Div(reference).Mul(decimal.NewFromInt(100))
+}
+
+// quoteGuard remembers the last accepted quote per symbol so that a bad
+// provider tick can be refused in favour of it. A big move is held back the
+// first time it is seen and accepted once a later fetch confirms it, so a
+// real split only delays the new price by one cache lifetime.
+type quoteGuard struct {
+	mu      sync.Mutex
+	last    map[string]StockData
+	pending map[string]decimal.Decimal
+}
+
+func newQuoteGuard() *quoteGuard {
+	return &quoteGuard{last: make(map[string]StockData), pending: make(map[string]decimal.Decimal)}
+}
+
+// check accepts or refuses quote. On refusal it returns the reason and the
+// last accepted quote for the symbol, which is nil when there is none.
+func (g *quoteGuard) check(quote *StockData) (reason string, last *StockData) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	prev, ok := g.last[quote.Symbol]
+	if ok {
+		last = &prev
+	}
+	if !quote.Price.IsPositive() {
+		return data.QuarantineNonPositive, last
+	}
+	if ok && priceMovePct(quote.Price, prev.Price).GreaterThan(decimal.NewFromInt(maxPriceMovePct)) {
+		held, seen := g.pending[quote.Symbol]
+		if !seen || priceMovePct(quote.Price, held).GreaterThan(decimal.NewFromInt(confirmTolerancePct)) {
+			g.pending[quote.Symbol] = quote.Price
+			return data.QuarantineExcessiveMove, last
+		}
+	}
+	delete(g.pending, quote.Symbol)
+	g.last[quote.Symbol] = *quote
+	return "", nil
+}
+
+// rejectedClose is a daily close refused by sanitizeCloses.
+type rejectedClose struct {
+	point     data.StockHistoryPoint
+	reference *decimal.Decimal
+	reason    string
+}
+
+// sanitizeCloses sorts one symbol's daily closes oldest first and drops
+// those that cannot be right: non-positive prices, a second close for the
+// same date, and a close that moves more than maxPriceMovePct from the one
+// before without the next close staying near it. A spike that reverts is
+// therefore dropped while a real level change such as a split is kept; the
+// newest close has nothing after it, so a big move there is held back until
+// the next day's data confirms it.
+func sanitizeCloses(points []data.StockHistoryPoint) ([]data.StockHistoryPoint, []rejectedClose) {
+	sorted := append([]data.StockHistoryPoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].TradeDate.Before(sorted[j].TradeDate) })
+
+	limit := decimal.NewFromInt(maxPriceMovePct)
+	kept := make([]data.StockHistoryPoint, 0, len(sorted))
+	var rejected []rejectedClose
+	for i, p := range sorted {
+		if !p.Close.IsPositive() {
+			rejected = append(rejected, rejectedClose{point: p, reason: data.QuarantineNonPositive})
+			continue
+		}
+		if n := len(kept); n > 0 {
+			prev := kept[n-1]
+			if p.TradeDate.Equal(prev.TradeDate) {
+				rejected = append(rejected, rejectedClose{point: p, reference: &prev.Close, reason: data.QuarantineDuplicateDate})
+				continue
+			}
+			if priceMovePct(p.Close, prev.Close).GreaterThan(limit) && !confirmedByNext(sorted[i+1:], p) {
+				rejected = append(rejected, rejectedClose{point: p, reference: &prev.Close, reason: data.QuarantineExcessiveMove})
+				continue
+			}
+		}
+		kept = append(kept, p)
+	}
+	return kept, rejected
+}
+
+// confirmedByNext reports whether the first usable close after p stays
+// within maxPriceMovePct of it.
+func confirmedByNext(rest []data.StockHistoryPoint, p data.StockHistoryPoint) bool {
+	for _, next := range rest {
+		if !next.Close.IsPositive() || next.TradeDate.Equal(p.TradeDate) {
+			continue
+		}
+		return !priceMovePct(next.Close, p.Close).GreaterThan(decimal.NewFromInt(maxPriceMovePct))
+	}
+	return false
+}
+
+// SetQuarantineStore records the provider prices the sanity checks refuse.
+// Without one they are only logged.
+func (s *MarketService) SetQuarantineStore(store *data.MarketQuarantineStore) {
+	s.quarantine = store
+}
+
+// quarantinePrice logs and records a refused provider price.
+func (s *MarketService) quarantinePrice(ctx context.Context, q data.QuarantinedPrice) {
+	slog.Warn("provider price quarantined",
+		"symbol", q.Symbol, "source", q.Source, "date", q.TradeDate.Format(DateLayoutISO),
+		"price", q.Price, "reason", q.Reason, "component", "market")
+	if s.quarantine == nil {
+		return
+	}
+	if err := s.quarantine.Record(ctx, q); err != nil {
+		slog.Warn("failed to record quarantined price", "symbol", q.Symbol, "err", err, "component", "market")
+	}
+}
+
+// checkQuote vets a freshly fetched quote. A refused quote is quarantined and
+// the last accepted one served instead; with none to fall back on the
+// lookup fails rather than hand out a price that may be wrong.
+func (s *MarketService) checkQuote(ctx context.Context, quote *StockData) (*StockData, error) {
+	reason, last := s.guard.check(quote)
+	if reason == "" {
+		return quote, nil
+	}
+	q := data.QuarantinedPrice{Symbol: quote.Symbol, Source: "quote", Price: quote.Price, Reason: reason}
+	if d, err := time.Parse(DateLayoutUS, quote.Date); err == nil {
+		q.TradeDate = d
+	} else {
+		q.TradeDate = time.Now().UTC()
+	}
+	if last != nil {
+		q.ReferencePrice = &last.Price
+	}
+	s.quarantinePrice(ctx, q)
+	if last == nil {
+		return nil, &PriceUnavailableError{}
+	}
+	return last, nil
+}
+
+// sanitizeEOD runs sanitizeCloses over closes fetched for symbol and
+// quarantines what it drops.
+func (s *MarketService) sanitizeEOD(ctx context.Context, symbol string, points []data.StockHistoryPoint) []data.StockHistoryPoint {
+	kept, rejected := sanitizeCloses(points)
+	for _, r := range rejected {
+		s.quarantinePrice(ctx, data.QuarantinedPrice{
+			Symbol:         symbol,
+			Source:         "eod",
+			TradeDate:      r.point.TradeDate,
+			Price:          r.point.Close,
+			ReferencePrice: r.reference,
+			Reason:         r.reason,
+		})
+	}
+	return kept
+}
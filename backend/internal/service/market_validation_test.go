@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func TestSanitizeCloses(t *testing.T) {
+	day := func(d int, close string) data.StockHistoryPoint {
+		return data.StockHistoryPoint{Symbol: "AAPL", TradeDate: mustDate("2026-01-01").AddDate(0, 0, d), Close: decimal.RequireFromString(close)}
+	}
+	// Newest first, as MarketStack sends them.
+	points := []data.StockHistoryPoint{
+		day(8, "40"), // newest: a big move nothing confirms yet
+		day(7, "20"), // the next close moves on again, so not confirmed
+		day(6, "10"),
+		day(5, "10"), // 10:1 split, confirmed by the next close
+		day(4, "100"),
+		day(3, "0"),
+		day(2, "1000"), // spike that reverts
+		day(1, "101"),
+		day(1, "99"), // repeated date
+		day(0, "100"),
+	}
+	kept, rejected := sanitizeCloses(points)
+
+	var got []string
+	for _, p := range kept {
+		got = append(got, p.TradeDate.Format(DateLayoutISO)+"="+p.Close.String())
+	}
+	want := []string{"2026-01-01=100", "2026-01-02=101", "2026-01-05=100", "2026-01-06=10", "2026-01-07=10"}
+	if len(got) != len(want) {
+		t.Fatalf("kept: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("kept: got %v, want %v", got, want)
+		}
+	}
+	reasons := map[string]string{}
+	for _, r := range rejected {
+		reasons[r.point.TradeDate.Format(DateLayoutISO)] = r.reason
+	}
+	for date, reason := range map[string]string{
+		"2026-01-02": data.QuarantineDuplicateDate,
+		"2026-01-03": data.QuarantineExcessiveMove,
+		"2026-01-04": data.QuarantineNonPositive,
+		"2026-01-08": data.QuarantineExcessiveMove,
+		"2026-01-09": data.QuarantineExcessiveMove,
+	} {
+		if reasons[date] != reason {
+			t.Errorf("%s: got %q, want %q", date, reasons[date], reason)
+		}
+	}
+}
+
+func TestCheckQuote_FallsBackUntilConfirmed(t *testing.T) {
+	svc := &MarketService{guard: newQuoteGuard()}
+	ctx := context.Background()
+	quote := func(price string) *StockData {
+		return &StockData{Symbol: "AAPL", Date: "01/05/2026", Price: decimal.RequireFromString(price)}
+	}
+
+	var unavailable *PriceUnavailableError
+	if _, err := svc.checkQuote(ctx, quote("0")); !errors.As(err, &unavailable) {
+		t.Fatalf("first quote non-positive: got %v, want PriceUnavailableError", err)
+	}
+	if got, err := svc.checkQuote(ctx, quote("100")); err != nil || !got.Price.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("first good quote: got %v, %v", got, err)
+	}
+	// A bad tick is refused in favour of the last accepted price...
+	if got, _ := svc.checkQuote(ctx, quote("900")); !got.Price.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("spike: got %s, want 100", got.Price)
+	}
+	if got, _ := svc.checkQuote(ctx, quote("-5")); !got.Price.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("negative: got %s, want 100", got.Price)
+	}
+	// ...and a move seen twice in a row is taken as real.
+	if got, _ := svc.checkQuote(ctx, quote("10")); !got.Price.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("split, first reading: got %s, want 100", got.Price)
+	}
+	if got, _ := svc.checkQuote(ctx, quote("10.05")); !got.Price.Equal(decimal.RequireFromString("10.05")) {
+		t.Errorf("split, confirmed: got %s, want 10.05", got.Price)
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lruCache is a bounded, concurrency-safe map whose entries expire: past
+// max entries, the least recently used one is evicted.
+type lruCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+func newLRUCache(max int) *lruCache {
+	return &lruCache{max: max, order: list.New(), entries: make(map[string]*list.Element), now: time.Now}
+}
+
+// get returns key's value, or false when it is missing or has expired.
+func (c *lruCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*lruEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// set stores value under key for ttl.
+func (c *lruCache) set(key string, value any, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for c.order.Len() > c.max {
+		c.remove(c.order.Back())
+	}
+}
+
+// deletePrefix removes every key starting with prefix.
+func (c *lruCache) deletePrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (c *lruCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
+
+// MemoryStockCache is the in-process StockCache used while Redis is down
+// or not configured (see FallbackStockCache). It holds at most a fixed
+// number of quotes and is not shared between instances.
+type MemoryStockCache struct {
+	lru        *lruCache
+	defaultTTL time.Duration
+}
+
+// NewMemoryStockCache keeps up to maxEntries quotes; defaultTTL applies
+// when SetStock is called with a zero ttl.
+func NewMemoryStockCache(maxEntries int, defaultTTL time.Duration) *MemoryStockCache {
+	return &MemoryStockCache{lru: newLRUCache(maxEntries), defaultTTL: defaultTTL}
+}
+
+// GetStock implements StockCache. Each hit is a copy, as a Redis hit would
+// be, so callers may change it.
+func (c *MemoryStockCache) GetStock(_ context.Context, symbol, date string) (*StockData, error) {
+	v, ok := c.lru.get(stockKey(symbol, date))
+	if !ok {
+		return nil, nil
+	}
+	data := v.(StockData)
+	return &data, nil
+}
+
+// SetStock implements StockCache.
+func (c *MemoryStockCache) SetStock(_ context.Context, symbol, date string, data *StockData, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.defaultTTL
+	}
+	c.lru.set(stockKey(symbol, date), *data, ttl)
+	return nil
+}
+
+// InvalidateStock implements StockCache.
+func (c *MemoryStockCache) InvalidateStock(ctx context.Context, symbol string) error {
+	return c.InvalidateStocks(ctx, []string{symbol})
+}
+
+// InvalidateStocks implements StockCache.
+func (c *MemoryStockCache) InvalidateStocks(_ context.Context, symbols []string) error {
+	for _, symbol := range symbols {
+		c.lru.deletePrefix(stockKey(symbol, ""))
+	}
+	return nil
+}
+
+// MemoryHistoricalCache is the in-process HistoricalCache used while Redis
+// is down or not configured (see FallbackHistoricalCache).
+type MemoryHistoricalCache struct {
+	lru        *lruCache
+	defaultTTL time.Duration
+}
+
+// NewMemoryHistoricalCache keeps up to maxEntries ranges and empty-range
+// markers; defaultTTL applies when SetHistorical is called with a zero ttl.
+func NewMemoryHistoricalCache(maxEntries int, defaultTTL time.Duration) *MemoryHistoricalCache {
+	return &MemoryHistoricalCache{lru: newLRUCache(maxEntries), defaultTTL: defaultTTL}
+}
+
+// GetHistorical implements HistoricalCache, returning a copy like GetStock.
+func (c *MemoryHistoricalCache) GetHistorical(_ context.Context, symbol, startDate, endDate string) (*HistoricalData, error) {
+	v, ok := c.lru.get(historicalKey(symbol, startDate, endDate))
+	if !ok {
+		return nil, nil
+	}
+	data := v.(HistoricalData)
+	return &data, nil
+}
+
+// SetHistorical implements HistoricalCache.
+func (c *MemoryHistoricalCache) SetHistorical(_ context.Context, symbol, startDate, endDate string, data *HistoricalData, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = c.defaultTTL
+	}
+	c.lru.set(historicalKey(symbol, startDate, endDate), *data, ttl)
+	return nil
+}
+
+// IsRangeEmpty implements HistoricalCache.
+func (c *MemoryHistoricalCache) IsRangeEmpty(_ context.Context, symbol, startDate, endDate string) (bool, error) {
+	_, ok := c.lru.get(historicalEmptyKey(symbol, startDate, endDate))
+	return ok, nil
+}
+
+// MarkRangeEmpty implements HistoricalCache.
+func (c *MemoryHistoricalCache) MarkRangeEmpty(_ context.Context, symbol, startDate, endDate string, ttl time.Duration) error {
+	if ttl == 0 {
+		ttl = 6 * time.Hour
+	}
+	c.lru.set(historicalEmptyKey(symbol, startDate, endDate), true, ttl)
+	return nil
+}
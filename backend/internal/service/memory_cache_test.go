@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMemoryStockCache_EvictsLeastRecentlyUsedAndExpires(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	cache := NewMemoryStockCache(2, time.Minute)
+	cache.lru.now = func() time.Time { return now }
+
+	for _, symbol := range []string{"AAPL", "MSFT"} {
+		cache.SetStock(ctx, symbol, "10/16/2026", &StockData{Symbol: symbol, Price: decimal.NewFromInt(100)}, 0)
+	}
+	cache.GetStock(ctx, "AAPL", "10/16/2026") // MSFT is now the least recently used
+	cache.SetStock(ctx, "GOOG", "10/16/2026", &StockData{Symbol: "GOOG"}, 0)
+
+	if got, _ := cache.GetStock(ctx, "MSFT", "10/16/2026"); got != nil {
+		t.Error("MSFT should have been evicted")
+	}
+	got, _ := cache.GetStock(ctx, "AAPL", "10/16/2026")
+	if got == nil || !got.Price.Equal(decimal.NewFromInt(100)) {
+		t.Fatalf("AAPL: got %+v", got)
+	}
+	// A hit is a copy.
+	got.Price = decimal.NewFromInt(1)
+	if again, _ := cache.GetStock(ctx, "AAPL", "10/16/2026"); !again.Price.Equal(decimal.NewFromInt(100)) {
+		t.Error("changing a hit changed the cache")
+	}
+
+	now = now.Add(time.Minute)
+	if got, _ := cache.GetStock(ctx, "AAPL", "10/16/2026"); got != nil {
+		t.Error("AAPL should have expired")
+	}
+}
+
+func TestMemoryStockCache_InvalidatesOnlyTheSymbol(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryStockCache(10, time.Minute)
+	cache.SetStock(ctx, "AAPL", "10/15/2026", &StockData{Symbol: "AAPL"}, 0)
+	cache.SetStock(ctx, "AAPL", "10/16/2026", &StockData{Symbol: "AAPL"}, 0)
+	cache.SetStock(ctx, "AAPLX", "10/16/2026", &StockData{Symbol: "AAPLX"}, 0)
+
+	cache.InvalidateStock(ctx, "AAPL")
+	if got, _ := cache.GetStock(ctx, "AAPL", "10/16/2026"); got != nil {
+		t.Error("AAPL should have been invalidated")
+	}
+	if got, _ := cache.GetStock(ctx, "AAPLX", "10/16/2026"); got == nil {
+		t.Error("AAPLX should have been kept")
+	}
+}
+
+// downStockCache is a StockCache whose backend is unreachable.
+type downStockCache struct{ StockCache }
+
+var errCacheDown = errors.New("connection refused")
+
+func (downStockCache) GetStock(context.Context, string, string) (*StockData, error) {
+	return nil, errCacheDown
+}
+func (downStockCache) SetStock(context.Context, string, string, *StockData, time.Duration) error {
+	return errCacheDown
+}
+
+func TestFallbackStockCache_UsesMemoryWhilePrimaryIsDown(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemoryStockCache(10, time.Minute)
+	cache := NewFallbackStockCache(downStockCache{}, memory)
+
+	if err := cache.SetStock(ctx, "AAPL", "10/16/2026", &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(100)}, 0); err != nil {
+		t.Fatalf("SetStock: %v", err)
+	}
+	got, err := cache.GetStock(ctx, "AAPL", "10/16/2026")
+	if err != nil || got == nil || !got.Price.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("GetStock: %+v, %v", got, err)
+	}
+
+	// Once the primary answers again, the outage's entries are ignored.
+	cache.primary = emptyStockCache{}
+	if got, _ := cache.GetStock(ctx, "AAPL", "10/16/2026"); got != nil {
+		t.Errorf("got %+v from the fallback with the primary up", got)
+	}
+}
+
+// emptyStockCache is a reachable StockCache that holds nothing.
+type emptyStockCache struct{ StockCache }
+
+func (emptyStockCache) GetStock(context.Context, string, string) (*StockData, error) {
+	return nil, nil
+}
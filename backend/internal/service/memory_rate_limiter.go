@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 )
@@ -14,25 +15,32 @@ type MemoryRateLimiter struct {
 	userLimit int
 	ipLimit   int
 	window    time.Duration
+	stats     limiterStats
 }
 
-func NewMemoryRateLimiter() *MemoryRateLimiter {
+func NewMemoryRateLimiter(policy RateLimitPolicy) *MemoryRateLimiter {
 	return &MemoryRateLimiter{
 		counts:    make(map[string][]time.Time),
-		userLimit: DefaultUserLimit,
-		ipLimit:   DefaultIPLimit,
-		window:    DefaultWindowDuration,
+		userLimit: policy.UserLimit,
+		ipLimit:   policy.IPLimit,
+		window:    policy.Window,
 	}
 }
 
 // CheckLimit implements RateLimiter against the global default bucket.
 func (m *MemoryRateLimiter) CheckLimit(ctx context.Context, userID, ipAddress string) (*RateLimitResult, error) {
-	return m.CheckLimitWithBucket(ctx, "ratelimit", userID, ipAddress, m.userLimit, m.ipLimit, m.window)
+	return m.CheckLimitWithBucket(ctx, DefaultRateLimitBucket, userID, ipAddress, m.userLimit, m.ipLimit, m.window)
 }
 
 // CheckLimitWithBucket runs the same sliding-window check against a custom
 // bucket namespace and per-call limits/window.
 func (m *MemoryRateLimiter) CheckLimitWithBucket(_ context.Context, bucket, userID, ipAddress string, userLimit, ipLimit int, window time.Duration) (*RateLimitResult, error) {
+	result := m.checkLimit(bucket, userID, ipAddress, userLimit, ipLimit, window)
+	m.stats.record(bucket, result.Allowed, nil)
+	return result, nil
+}
+
+func (m *MemoryRateLimiter) checkLimit(bucket, userID, ipAddress string, userLimit, ipLimit int, window time.Duration) *RateLimitResult {
 	now := time.Now()
 	cutoff := now.Add(-window)
 	result := &RateLimitResult{ResetTime: now.Add(window)}
@@ -45,7 +53,7 @@ func (m *MemoryRateLimiter) CheckLimitWithBucket(_ context.Context, bucket, user
 		if !allowed {
 			result.Allowed = false
 			result.LimitExceeded = true
-			return result, nil
+			return result
 		}
 		result.Remaining = remaining
 	}
@@ -54,14 +62,94 @@ func (m *MemoryRateLimiter) CheckLimitWithBucket(_ context.Context, bucket, user
 	if !allowed {
 		result.Allowed = false
 		result.LimitExceeded = true
-		return result, nil
+		return result
 	}
 
 	result.Allowed = true
 	if userID == "" || remaining < result.Remaining {
 		result.Remaining = remaining
 	}
-	return result, nil
+	return result
+}
+
+// Usage implements RateLimiter.
+func (m *MemoryRateLimiter) Usage(_ context.Context, bucket, userID, ipAddress string, window time.Duration) (*RateLimitUsage, error) {
+	cutoff := time.Now().Add(-window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := &RateLimitUsage{}
+	if userID != "" {
+		usage.User = countSince(m.counts[bucket+":user:"+userID], cutoff)
+	}
+	if ipAddress != "" {
+		usage.IP = countSince(m.counts[bucket+":ip:"+ipAddress], cutoff)
+	}
+	return usage, nil
+}
+
+// Metrics implements RateLimitInspector.
+func (m *MemoryRateLimiter) Metrics() RateLimiterMetrics {
+	return m.stats.snapshot("memory")
+}
+
+// Inspect implements RateLimitInspector.
+func (m *MemoryRateLimiter) Inspect(_ context.Context, bucket, scope, id string, window time.Duration) (*RateLimitWindow, error) {
+	cutoff := time.Now().Add(-window)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	times := m.counts[bucket+":"+scope+":"+id]
+	times = times[len(times)-countSince(times, cutoff):]
+	w := &RateLimitWindow{Count: len(times)}
+	if len(times) > 0 {
+		oldest, newest := times[0], times[len(times)-1]
+		w.OldestAt, w.NewestAt = &oldest, &newest
+	}
+	return w, nil
+}
+
+// Reset implements RateLimitInspector.
+func (m *MemoryRateLimiter) Reset(_ context.Context, bucket, scope, id string) (bool, error) {
+	key := bucket + ":" + scope + ":" + id
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.counts[key]
+	delete(m.counts, key)
+	return ok, nil
+}
+
+// TopConsumers implements RateLimitInspector.
+func (m *MemoryRateLimiter) TopConsumers(_ context.Context, bucket, scope string, window time.Duration, n int) ([]RateLimitConsumer, error) {
+	prefix := bucket + ":" + scope + ":"
+	cutoff := time.Now().Add(-window)
+
+	m.mu.Lock()
+	var consumers []RateLimitConsumer
+	for key, times := range m.counts {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if c := countSince(times, cutoff); c > 0 {
+			consumers = append(consumers, RateLimitConsumer{ID: strings.TrimPrefix(key, prefix), Count: c})
+		}
+	}
+	m.mu.Unlock()
+
+	return sortConsumers(consumers, n), nil
+}
+
+// countSince counts the entries of times (oldest first) not before cutoff.
+func countSince(times []time.Time, cutoff time.Time) int {
+	start := 0
+	for start < len(times) && times[start].Before(cutoff) {
+		start++
+	}
+	return len(times) - start
 }
 
 func (m *MemoryRateLimiter) checkAndAdd(key string, limit int, cutoff, now time.Time) (bool, int) {
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"papertrader/internal/util"
+)
+
+// newsKept is how many articles a feed keeps, and so the most
+// GET /api/market/news can ask for.
+const newsKept = 50
+
+// DefaultNewsLimit is how many articles News returns when the caller
+// doesn't say.
+const DefaultNewsLimit = 20
+
+// NewsArticle is one headline. Symbols are the tickers the provider tagged
+// it with, in our notation; it may be empty for general market news.
+type NewsArticle struct {
+	Headline    string    `json:"headline"`
+	Summary     string    `json:"summary"`
+	Source      string    `json:"source"`
+	URL         string    `json:"url"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+	Symbols     []string  `json:"symbols"`
+}
+
+// NewsFeed is the latest news for one symbol, or for the market as a whole
+// when Symbol is empty, newest first.
+type NewsFeed struct {
+	Symbol    string        `json:"symbol,omitempty"`
+	Articles  []NewsArticle `json:"articles"`
+	Provider  string        `json:"provider"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// NewsProvider is an upstream news API. News returns recent articles about
+// symbol, or general market news for "", in any order.
+type NewsProvider interface {
+	Name() string
+	News(ctx context.Context, symbol string) ([]NewsArticle, error)
+}
+
+// NewsCache caches feeds per symbol ("" for the market feed).
+type NewsCache interface {
+	GetNews(ctx context.Context, symbol string) (*NewsFeed, error)
+	SetNews(ctx context.Context, feed *NewsFeed, ttl time.Duration) error
+}
+
+// RedisNewsCache implements NewsCache using Redis.
+type RedisNewsCache struct {
+	client *redis.Client
+}
+
+func NewRedisNewsCache(client *redis.Client) *RedisNewsCache {
+	return &RedisNewsCache{client: client}
+}
+
+func newsKey(symbol string) string {
+	if symbol == "" {
+		return "news:market"
+	}
+	return fmt.Sprintf("news:%s", symbol)
+}
+
+// GetNews returns the cached feed, or nil on a miss. Redis errors are
+// logged and treated as a miss.
+func (c *RedisNewsCache) GetNews(ctx context.Context, symbol string) (*NewsFeed, error) {
+	val, err := c.client.Get(ctx, newsKey(symbol)).Result()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Error("Redis error getting news", "symbol", symbol, "err", err, "component", "news_cache")
+		}
+		return nil, nil
+	}
+	var feed NewsFeed
+	if err := json.Unmarshal([]byte(val), &feed); err != nil {
+		slog.Error("failed to unmarshal news cache entry", "symbol", symbol, "err", err, "component", "news_cache")
+		return nil, nil
+	}
+	return &feed, nil
+}
+
+// SetNews stores feed under its symbol for ttl.
+func (c *RedisNewsCache) SetNews(ctx context.Context, feed *NewsFeed, ttl time.Duration) error {
+	raw, err := json.Marshal(feed)
+	if err != nil {
+		return fmt.Errorf("error marshaling news feed: %w", err)
+	}
+	if err := c.client.Set(ctx, newsKey(feed.Symbol), raw, ttl).Err(); err != nil {
+		slog.Error("failed to set news cache entry", "symbol", feed.Symbol, "err", err, "component", "news_cache")
+		return err
+	}
+	return nil
+}
+
+// NewsService serves headlines per symbol and for the market from a news
+// provider, cached for ttl so a busy symbol costs one provider call per
+// ttl however many users read it.
+type NewsService struct {
+	provider NewsProvider
+	cache    NewsCache
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewNewsService builds the service; feeds are cached for ttl once SetCache
+// gives it somewhere to keep them.
+func NewNewsService(provider NewsProvider, ttl time.Duration) *NewsService {
+	return &NewsService{provider: provider, ttl: ttl, now: time.Now}
+}
+
+// SetCache caches feeds; without one every request goes to the provider.
+func (s *NewsService) SetCache(cache NewsCache) {
+	s.cache = cache
+}
+
+// News returns the newest limit articles, 1 to 50, about symbol, or general
+// market news when symbol is empty.
+func (s *NewsService) News(ctx context.Context, symbol string, limit int) (*NewsFeed, error) {
+	if limit < 1 || limit > newsKept {
+		return nil, &util.ValidationError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", newsKept)}
+	}
+	if symbol != "" {
+		var err error
+		if symbol, err = util.ValidateSymbol(symbol); err != nil {
+			return nil, err
+		}
+	}
+
+	feed, err := s.feed(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+	out := *feed
+	out.Articles = out.Articles[:min(limit, len(out.Articles))]
+	return &out, nil
+}
+
+func (s *NewsService) feed(ctx context.Context, symbol string) (*NewsFeed, error) {
+	if s.cache != nil {
+		if cached, _ := s.cache.GetNews(ctx, symbol); cached != nil {
+			return cached, nil
+		}
+	}
+
+	articles, err := s.provider.News(ctx, symbol)
+	if err != nil {
+		slog.Warn("news fetch failed", "provider", s.provider.Name(), "symbol", symbol, "err", err, "component", "news")
+		return nil, err
+	}
+	if articles == nil {
+		articles = make([]NewsArticle, 0)
+	}
+	sort.SliceStable(articles, func(i, j int) bool { return articles[i].PublishedAt.After(articles[j].PublishedAt) })
+	feed := &NewsFeed{
+		Symbol:    symbol,
+		Articles:  articles[:min(newsKept, len(articles))],
+		Provider:  s.provider.Name(),
+		UpdatedAt: s.now().UTC(),
+	}
+	if s.cache != nil {
+		if err := s.cache.SetNews(ctx, feed, s.ttl); err != nil {
+			slog.Warn("failed to cache news", "symbol", symbol, "err", err, "component", "news")
+		}
+	}
+	return feed, nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+// avNewsTimeLayout is how NEWS_SENTIMENT stamps articles, in New York time.
+const avNewsTimeLayout = "20060102T150405"
+
+// News asks NEWS_SENTIMENT for the latest articles tagged with symbol, or
+// for the financial markets topic when symbol is empty. Crypto pairs are
+// asked for by their base asset (BTC-USD is CRYPTO:BTC there).
+func (a *AlphaVantage) News(ctx context.Context, symbol string) ([]NewsArticle, error) {
+	q := url.Values{"function": {"NEWS_SENTIMENT"}, "sort": {"LATEST"}, "limit": {"50"}}
+	switch {
+	case symbol == "":
+		q.Set("topics", "financial_markets")
+	case util.IsCryptoPair(symbol):
+		q.Set("tickers", "CRYPTO:"+strings.TrimSuffix(symbol, "-USD"))
+	default:
+		q.Set("tickers", avSymbol(symbol))
+	}
+	var resp struct {
+		Feed []struct {
+			Title     string `json:"title"`
+			URL       string `json:"url"`
+			Published string `json:"time_published"`
+			Summary   string `json:"summary"`
+			Image     string `json:"banner_image"`
+			Source    string `json:"source"`
+			Tickers   []struct {
+				Ticker string `json:"ticker"`
+			} `json:"ticker_sentiment"`
+		} `json:"feed"`
+	}
+	if err := a.query(ctx, q, &resp); err != nil {
+		return nil, err
+	}
+
+	articles := make([]NewsArticle, 0, len(resp.Feed))
+	for _, item := range resp.Feed {
+		published, err := time.ParseInLocation(avNewsTimeLayout, item.Published, newYork)
+		if err != nil || item.Title == "" || item.URL == "" {
+			continue
+		}
+		symbols := make([]string, 0, len(item.Tickers))
+		for _, t := range item.Tickers {
+			if base, ok := strings.CutPrefix(t.Ticker, "CRYPTO:"); ok {
+				symbols = append(symbols, base+"-USD")
+			} else if !strings.Contains(t.Ticker, ":") {
+				symbols = append(symbols, fromAVSymbol(t.Ticker))
+			}
+		}
+		articles = append(articles, NewsArticle{
+			Headline:    item.Title,
+			Summary:     item.Summary,
+			Source:      item.Source,
+			URL:         item.URL,
+			ImageURL:    item.Image,
+			PublishedAt: published.UTC(),
+			Symbols:     symbols,
+		})
+	}
+	return articles, nil
+}
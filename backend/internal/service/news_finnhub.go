@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+// ProviderFinnhub names the Finnhub news provider.
+const ProviderFinnhub = "finnhub"
+
+// finnhubURL is overridable so HTTP-mock tests can point the provider at an
+// httptest.Server.
+var finnhubURL = "https://finnhub.io/api/v1"
+
+// FinnhubTimeout caps each Finnhub call.
+const FinnhubTimeout = 15 * time.Second
+
+// finnhubNewsDays is how far back company news is asked for.
+const finnhubNewsDays = 7
+
+// Finnhub is the NewsProvider backed by finnhub.io: company news per
+// symbol and its general news category for the market feed. It covers US
+// listings only; other symbols have no news.
+type Finnhub struct {
+	apiKey string
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewFinnhub builds the provider. client is the shared outbound client;
+// calls are capped at FinnhubTimeout on top of its own limit.
+func NewFinnhub(apiKey string, client *http.Client) *Finnhub {
+	return &Finnhub{apiKey: apiKey, client: util.ClientWithTimeout(client, FinnhubTimeout), now: time.Now}
+}
+
+func (f *Finnhub) Name() string { return ProviderFinnhub }
+
+// News asks /company-news for the last week of symbol's news, or /news for
+// the general category when symbol is empty.
+func (f *Finnhub) News(ctx context.Context, symbol string) ([]NewsArticle, error) {
+	if symbol != "" && (util.SymbolExchange(symbol) != "" || util.IsCryptoPair(symbol)) {
+		return make([]NewsArticle, 0), nil
+	}
+	path, q := "/news", url.Values{"category": {"general"}}
+	if symbol != "" {
+		to := f.now().UTC()
+		path, q = "/company-news", url.Values{
+			"symbol": {symbol},
+			"from":   {to.AddDate(0, 0, -finnhubNewsDays).Format(DateLayoutISO)},
+			"to":     {to.Format(DateLayoutISO)},
+		}
+	}
+	var resp []struct {
+		Headline string `json:"headline"`
+		Summary  string `json:"summary"`
+		Source   string `json:"source"`
+		URL      string `json:"url"`
+		Image    string `json:"image"`
+		Datetime int64  `json:"datetime"`
+		Related  string `json:"related"`
+	}
+	if err := f.get(ctx, path, q, &resp); err != nil {
+		return nil, err
+	}
+
+	articles := make([]NewsArticle, 0, len(resp))
+	for _, item := range resp {
+		if item.Headline == "" || item.URL == "" {
+			continue
+		}
+		symbols := make([]string, 0)
+		for _, s := range strings.Split(item.Related, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				symbols = append(symbols, strings.ToUpper(s))
+			}
+		}
+		articles = append(articles, NewsArticle{
+			Headline:    item.Headline,
+			Summary:     item.Summary,
+			Source:      item.Source,
+			URL:         item.URL,
+			ImageURL:    item.Image,
+			PublishedAt: time.Unix(item.Datetime, 0).UTC(),
+			Symbols:     symbols,
+		})
+	}
+	return articles, nil
+}
+
+func (f *Finnhub) get(ctx context.Context, path string, q url.Values, out any) error {
+	if f.apiKey == "" {
+		return errNoAPIKey
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", finnhubURL+path, nil)
+	if err != nil {
+		return err
+	}
+	q.Set("token", f.apiKey)
+	httpReq.URL.RawQuery = q.Encode()
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(httpReq)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			uerr.URL = util.RedactURL(httpReq.URL)
+		}
+		if ctx.Err() == nil {
+			return &ProviderUnavailableError{Provider: ProviderFinnhub, Err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return &ProviderUnavailableError{Provider: ProviderFinnhub, Err: err}
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return errors.New("finnhub: API key rejected")
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		return &ProviderUnavailableError{Provider: ProviderFinnhub, Err: fmt.Errorf("API returned status %d", resp.StatusCode)}
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+	return json.Unmarshal(body, out)
+}
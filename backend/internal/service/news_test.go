@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+type fakeNewsProvider struct {
+	articles []NewsArticle
+	calls    []string
+}
+
+func (f *fakeNewsProvider) Name() string { return "fake" }
+
+func (f *fakeNewsProvider) News(_ context.Context, symbol string) ([]NewsArticle, error) {
+	f.calls = append(f.calls, symbol)
+	return append([]NewsArticle(nil), f.articles...), nil
+}
+
+type memoryNewsCache struct {
+	feeds map[string]*NewsFeed
+	ttl   time.Duration
+}
+
+func (c *memoryNewsCache) GetNews(_ context.Context, symbol string) (*NewsFeed, error) {
+	return c.feeds[symbol], nil
+}
+
+func (c *memoryNewsCache) SetNews(_ context.Context, feed *NewsFeed, ttl time.Duration) error {
+	c.feeds[feed.Symbol], c.ttl = feed, ttl
+	return nil
+}
+
+func TestNews_NewestFirstAndCached(t *testing.T) {
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	provider := &fakeNewsProvider{articles: []NewsArticle{
+		{Headline: "old", PublishedAt: day},
+		{Headline: "new", PublishedAt: day.Add(2 * time.Hour)},
+		{Headline: "mid", PublishedAt: day.Add(time.Hour)},
+	}}
+	cache := &memoryNewsCache{feeds: make(map[string]*NewsFeed)}
+	svc := NewNewsService(provider, 15*time.Minute)
+	svc.SetCache(cache)
+
+	feed, err := svc.News(context.Background(), "aapl", 2)
+	if err != nil {
+		t.Fatalf("News: %v", err)
+	}
+	if feed.Symbol != "AAPL" || len(feed.Articles) != 2 || feed.Articles[0].Headline != "new" || feed.Articles[1].Headline != "mid" {
+		t.Errorf("got %+v", feed)
+	}
+	if cached := cache.feeds["AAPL"]; cached == nil || len(cached.Articles) != 3 || cache.ttl != 15*time.Minute {
+		t.Errorf("cached %+v for %s, want all three articles", cached, cache.ttl)
+	}
+
+	if _, err := svc.News(context.Background(), "AAPL", 10); err != nil || len(provider.calls) != 1 {
+		t.Errorf("second read: got %v after %d provider calls, want the cached feed", err, len(provider.calls))
+	}
+	if _, err := svc.News(context.Background(), "", 10); err != nil || len(provider.calls) != 2 || provider.calls[1] != "" {
+		t.Errorf("market feed: got %v, calls %q", err, provider.calls)
+	}
+}
+
+func TestNews_Validation(t *testing.T) {
+	svc := NewNewsService(&fakeNewsProvider{}, time.Minute)
+	for _, c := range []struct {
+		symbol string
+		limit  int
+	}{{"AAPL", 0}, {"AAPL", 51}, {"not a symbol", 10}} {
+		var verr *util.ValidationError
+		if _, err := svc.News(context.Background(), c.symbol, c.limit); !errors.As(err, &verr) {
+			t.Errorf("%q, %d: got %v, want a validation error", c.symbol, c.limit, err)
+		}
+	}
+}
+
+func TestAlphaVantage_News(t *testing.T) {
+	withMockAlphaVantage(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("function") != "NEWS_SENTIMENT" || q.Get("tickers") != "VOD.LON" {
+			t.Errorf("query: got %v", q)
+		}
+		w.Write([]byte(`{"feed":[
+			{"title":"Vodafone beats","url":"https://example.com/a","time_published":"20261015T093000","summary":"s","source":"Wire",
+			 "ticker_sentiment":[{"ticker":"VOD.LON"},{"ticker":"FOREX:GBP"},{"ticker":"CRYPTO:BTC"}]},
+			{"title":"","url":"https://example.com/b","time_published":"20261015T100000"}
+		]}`))
+	})
+
+	articles, err := NewAlphaVantage("test-key", http.DefaultClient).News(context.Background(), "VOD.XLON")
+	if err != nil {
+		t.Fatalf("News: %v", err)
+	}
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles, want 1: %+v", len(articles), articles)
+	}
+	a := articles[0]
+	if !a.PublishedAt.Equal(time.Date(2026, 10, 15, 13, 30, 0, 0, time.UTC)) || len(a.Symbols) != 2 ||
+		a.Symbols[0] != "VOD.XLON" || a.Symbols[1] != "BTC-USD" {
+		t.Errorf("got %+v", a)
+	}
+}
+
+func TestFinnhub_News(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/company-news" || q.Get("symbol") != "AAPL" || q.Get("from") != "2026-10-09" || q.Get("to") != "2026-10-16" {
+			t.Errorf("request: got %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		w.Write([]byte(`[{"headline":"Apple ships","summary":"s","source":"Wire","url":"https://example.com/a","datetime":1792080000,"related":"AAPL, msft"}]`))
+	}))
+	defer srv.Close()
+	prev := finnhubURL
+	finnhubURL = srv.URL
+	defer func() { finnhubURL = prev }()
+
+	f := NewFinnhub("test-key", http.DefaultClient)
+	f.now = func() time.Time { return time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC) }
+	articles, err := f.News(context.Background(), "AAPL")
+	if err != nil {
+		t.Fatalf("News: %v", err)
+	}
+	if len(articles) != 1 || articles[0].PublishedAt.Unix() != 1792080000 || len(articles[0].Symbols) != 2 || articles[0].Symbols[1] != "MSFT" {
+		t.Errorf("got %+v", articles)
+	}
+
+	// Finnhub has no news outside the US; don't spend a call finding out.
+	if articles, err := f.News(context.Background(), "VOD.XLON"); err != nil || len(articles) != 0 {
+		t.Errorf("London listing: got %+v, %v", articles, err)
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+
+	"papertrader/internal/data"
+)
+
+// Notification kinds. Stable strings — the frontend keys icons off them.
+const (
+	NotificationTradingHalted  = "trading_halted"
+	NotificationTradingResumed = "trading_resumed"
+	NotificationSecurityAlert  = "security_alert"
+	NotificationOrderTriggered = "order_triggered"
+	NotificationOrderFailed    = "order_failed"
+	NotificationOrderExpired   = "order_expired"
+	NotificationRecurringRun   = "recurring_investment_executed"
+	NotificationRecurringSkip  = "recurring_investment_skipped"
+	NotificationTradeDispute   = "trade_dispute_resolved"
+	NotificationGoalMilestone  = "goal_milestone"
+	NotificationGoalReached    = "goal_reached"
+)
+
+const (
+	defaultNotificationLimit = 50
+	maxNotificationLimit     = 200
+)
+
+type NotificationService struct {
+	store *data.NotificationStore
+}
+
+func NewNotificationService(store *data.NotificationStore) *NotificationService {
+	return &NotificationService{store: store}
+}
+
+// Notify delivers the same in-app notification to every user in userIDs.
+func (s *NotificationService) Notify(ctx context.Context, userIDs []string, kind, title, body string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return s.store.CreateForUsers(ctx, userIDs, kind, title, body)
+}
+
+// List returns the user's notifications (newest first) and their unread count.
+// limit is clamped to [1, maxNotificationLimit]; zero selects the default.
+func (s *NotificationService) List(ctx context.Context, userID string, unreadOnly bool, limit int) ([]data.Notification, int, error) {
+	if limit <= 0 {
+		limit = defaultNotificationLimit
+	}
+	if limit > maxNotificationLimit {
+		limit = maxNotificationLimit
+	}
+
+	items, err := s.store.ListByUser(ctx, userID, unreadOnly, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	unread, err := s.store.CountUnread(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, unread, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID, id string) error {
+	return s.store.MarkRead(ctx, userID, id)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID string) error {
+	return s.store.MarkAllRead(ctx, userID)
+}
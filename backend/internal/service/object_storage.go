@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ObjectStorage stores small public blobs such as avatars. Keys are
+// slash-separated paths chosen by the caller; implementations return the URL
+// clients should use to fetch the object.
+type ObjectStorage interface {
+	Put(ctx context.Context, key, contentType string, body []byte) (url string, err error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalObjectStorage writes objects under a directory on disk. It is meant
+// for development and single-host deployments; LocalObjectStorage.Handler
+// serves the files back.
+type LocalObjectStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalObjectStorage stores objects under dir and returns URLs of the form
+// baseURL + "/" + key.
+func NewLocalObjectStorage(dir, baseURL string) (*LocalObjectStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+	return &LocalObjectStorage{dir: dir, baseURL: strings.TrimRight(baseURL, "/")}, nil
+}
+
+func (s *LocalObjectStorage) Put(_ context.Context, key, _ string, body []byte) (string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return "", err
+	}
+	// Write to a temp file and rename so readers never see a partial object.
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return s.baseURL + "/" + key, nil
+}
+
+func (s *LocalObjectStorage) Delete(_ context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Handler serves stored objects. Mount it with http.StripPrefix so request
+// paths are object keys. Directory listings are never served.
+func (s *LocalObjectStorage) Handler() http.Handler {
+	fs := http.FileServer(http.Dir(s.dir))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "" || strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		fs.ServeHTTP(w, r)
+	})
+}
+
+// path maps key to a file under s.dir, rejecting keys that would escape it.
+func (s *LocalObjectStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
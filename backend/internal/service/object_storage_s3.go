@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+const s3RequestTimeout = 15 * time.Second
+
+// S3Config configures S3ObjectStorage.
+type S3Config struct {
+	Endpoint        string // e.g. https://s3.us-east-1.amazonaws.com or https://storage.googleapis.com
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	PublicURL       string // base URL objects are served from; defaults to Endpoint/Bucket
+}
+
+// S3ObjectStorage talks to any S3-compatible API using path-style requests
+// signed with AWS Signature Version 4. That covers AWS S3, Google Cloud
+// Storage (XML API with HMAC keys), MinIO and Cloudflare R2 without pulling
+// in a vendor SDK. Objects must be made publicly readable by bucket policy;
+// no per-object ACL is sent.
+type S3ObjectStorage struct {
+	cfg    S3Config
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3ObjectStorage(cfg S3Config, client *http.Client) *S3ObjectStorage {
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	if cfg.PublicURL == "" {
+		cfg.PublicURL = cfg.Endpoint + "/" + cfg.Bucket
+	}
+	cfg.PublicURL = strings.TrimRight(cfg.PublicURL, "/")
+	return &S3ObjectStorage{
+		cfg:    cfg,
+		client: util.ClientWithTimeout(client, s3RequestTimeout),
+		now:    time.Now,
+	}
+}
+
+func (s *S3ObjectStorage) Put(ctx context.Context, key, contentType string, body []byte) (string, error) {
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	// Keys are never reused, so clients and CDNs may cache forever.
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	if err := s.do(req, body); err != nil {
+		return "", err
+	}
+	return s.cfg.PublicURL + "/" + key, nil
+}
+
+func (s *S3ObjectStorage) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, nil)
+}
+
+func (s *S3ObjectStorage) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u, err := url.Parse(s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + key)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+}
+
+func (s *S3ObjectStorage) do(req *http.Request, body []byte) error {
+	s.sign(req, body)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("object storage %s %s: status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign adds SigV4 headers to req. Every header set before sign is signed.
+func (s *S3ObjectStorage) sign(req *http.Request, body []byte) {
+	sigV4Signer{
+		region:          s.cfg.Region,
+		service:         "s3",
+		accessKeyID:     s.cfg.AccessKeyID,
+		secretAccessKey: s.cfg.SecretAccessKey,
+	}.sign(req, body, s.now())
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalObjectStorage_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalObjectStorage(dir, "/api/uploads/")
+	if err != nil {
+		t.Fatalf("NewLocalObjectStorage: %v", err)
+	}
+
+	url, err := s.Put(context.Background(), "avatars/u1/a.png", "image/png", pngHeader)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if url != "/api/uploads/avatars/u1/a.png" {
+		t.Errorf("url: got %q", url)
+	}
+
+	srv := httptest.NewServer(http.StripPrefix("/api/uploads", s.Handler()))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/api/uploads/avatars/u1/a.png")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != string(pngHeader) {
+		t.Errorf("serve: status %d body %q", resp.StatusCode, body)
+	}
+	resp, _ = http.Get(srv.URL + "/api/uploads/avatars/")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("directory listing should 404, got %d", resp.StatusCode)
+	}
+
+	if err := s.Delete(context.Background(), "avatars/u1/a.png"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "avatars", "u1", "a.png")); !os.IsNotExist(err) {
+		t.Errorf("file should be gone, stat err = %v", err)
+	}
+	if err := s.Delete(context.Background(), "avatars/u1/a.png"); err != nil {
+		t.Errorf("deleting a missing object should succeed, got %v", err)
+	}
+}
+
+func TestLocalObjectStorage_RejectsEscapingKeys(t *testing.T) {
+	s, err := NewLocalObjectStorage(t.TempDir(), "/api/uploads")
+	if err != nil {
+		t.Fatalf("NewLocalObjectStorage: %v", err)
+	}
+	for _, key := range []string{"", "../etc/passwd", "avatars/../../x", "/abs", "a//b"} {
+		if _, err := s.Put(context.Background(), key, "image/png", pngHeader); err == nil {
+			t.Errorf("key %q should be rejected", key)
+		}
+	}
+}
+
+func TestSigV4Key_MatchesAWSExample(t *testing.T) {
+	// Example from the AWS SigV4 documentation ("Deriving the signing key").
+	got := hex.EncodeToString(sigV4Key("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam"))
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got != want {
+		t.Errorf("signing key: got %s, want %s", got, want)
+	}
+}
+
+func TestS3ObjectStorage_SignedPut(t *testing.T) {
+	var gotPath, gotAuth, gotType, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth, gotType = r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+	}))
+	defer srv.Close()
+
+	s := NewS3ObjectStorage(S3Config{
+		Endpoint: srv.URL, Region: "auto", Bucket: "media",
+		AccessKeyID: "AKID", SecretAccessKey: "secret",
+		PublicURL: "https://cdn.example.com/",
+	}, nil)
+	s.now = func() time.Time { return time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC) }
+
+	url, err := s.Put(context.Background(), "avatars/u1/a.png", "image/png", pngHeader)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if url != "https://cdn.example.com/avatars/u1/a.png" {
+		t.Errorf("url: got %q", url)
+	}
+	if gotPath != "/media/avatars/u1/a.png" || gotType != "image/png" || gotBody != string(pngHeader) {
+		t.Errorf("request: path=%q type=%q body=%q", gotPath, gotType, gotBody)
+	}
+	if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/20240313/auto/s3/aws4_request, SignedHeaders=cache-control;content-type;host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("authorization header: %q", gotAuth)
+	}
+}
+
+func TestS3ObjectStorage_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	s := NewS3ObjectStorage(S3Config{Endpoint: srv.URL, Region: "us-east-1", Bucket: "media", AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil)
+	if err := s.Delete(context.Background(), "avatars/u1/a.png"); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("expected AccessDenied error, got %v", err)
+	}
+}
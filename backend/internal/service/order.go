@@ -0,0 +1,906 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+const (
+	defaultOrderLimit = 50
+	maxOrderLimit     = 200
+	// defaultOrderWait is the longest Wait blocks unless SetWaitLimit says
+	// otherwise.
+	defaultOrderWait = 30 * time.Second
+)
+
+// OrderRequest describes an order to place. Side may be left empty for
+// STOP_LOSS and TAKE_PROFIT, which always sell. TriggerPrice is left zero
+// for MARKET orders. TimeInForce is DAY or GTC, GTC when empty. ExpiresAt,
+// GTC only, must be in the future and within the GTC cap; the order is
+// expired once it passes. IdempotencyKey, optional, is the Idempotency-Key
+// of a buy or sell being queued: placing a second order with the same key
+// returns the first.
+type OrderRequest struct {
+	Symbol         string
+	Side           string
+	Type           string
+	Quantity       decimal.Decimal
+	TriggerPrice   decimal.Decimal
+	TimeInForce    string
+	ExpiresAt      *time.Time
+	IdempotencyKey string
+}
+
+// OCORequest describes a one-cancels-other pair on a long holding: a
+// take-profit above the market and a stop-loss below it, each selling
+// Quantity shares. Whichever triggers first fills and cancels the other.
+// TimeInForce and ExpiresAt apply to both, as for OrderRequest.
+type OCORequest struct {
+	Symbol      string
+	Quantity    decimal.Decimal
+	TakeProfit  decimal.Decimal
+	StopLoss    decimal.Decimal
+	TimeInForce string
+	ExpiresAt   *time.Time
+}
+
+// OCOOrders is the pair placed by CreateOCO.
+type OCOOrders struct {
+	GroupID    string      `json:"oco_group_id"`
+	TakeProfit *data.Order `json:"take_profit"`
+	StopLoss   *data.Order `json:"stop_loss"`
+}
+
+// BracketRequest describes a bracket: a buy entry of Quantity shares and an
+// OCO pair of exits on the same shares that becomes active once the entry
+// fills. EntryType is MARKET, LIMIT or STOP, LIMIT when empty; EntryPrice
+// is its limit or stop price, left zero for MARKET, and must lie between
+// StopLoss and TakeProfit. TimeInForce and ExpiresAt apply to the entry, as
+// for OrderRequest; the exits are GTC.
+type BracketRequest struct {
+	Symbol      string
+	Quantity    decimal.Decimal
+	EntryType   string
+	EntryPrice  decimal.Decimal
+	TakeProfit  decimal.Decimal
+	StopLoss    decimal.Decimal
+	TimeInForce string
+	ExpiresAt   *time.Time
+}
+
+// BracketOrders is the entry and exits placed by CreateBracket. The exits
+// are WAITING until the entry fills.
+type BracketOrders struct {
+	Entry *data.Order `json:"entry"`
+	OCOOrders
+}
+
+// OrderService manages the order book: resting limit, stop, stop-loss and
+// take-profit orders that fill at market once the quote crosses their
+// trigger price, and market orders queued while the market was closed that
+// fill once it opens. Fills go through InvestmentService's buy and sell paths, so
+// they hit the same row locks, pre-trade checks and observers as a manual
+// trade.
+type OrderService struct {
+	store         *data.OrderStore
+	investments   *InvestmentService
+	notifications *NotificationService
+	maxPending    int
+	hours         *MarketHours    // nil = fill around the clock
+	calendar      *MarketCalendar // nil = DAY orders unavailable
+	gtcMaxDays    int             // 0 = GTC orders may rest indefinitely
+	maxWait       time.Duration
+	events        *orderEvents
+	now           func() time.Time
+}
+
+// NewOrderService builds the service. notifications may be nil. maxPending
+// caps each user's PENDING orders.
+func NewOrderService(store *data.OrderStore, investments *InvestmentService, notifications *NotificationService, maxPending int) *OrderService {
+	return &OrderService{
+		store:         store,
+		investments:   investments,
+		notifications: notifications,
+		maxPending:    maxPending,
+		maxWait:       defaultOrderWait,
+		events:        newOrderEvents(),
+		now:           time.Now,
+	}
+}
+
+// SetMarketHours restricts fills to the regular session. Call during wiring,
+// before the service handles requests.
+func (s *OrderService) SetMarketHours(hours *MarketHours) {
+	s.hours = hours
+}
+
+// SetTimeInForce enables DAY orders, which expire at the close of the
+// calendar's next session, and caps how long a GTC order may rest: one
+// placed without an expiry expires gtcMaxDays after it was placed. Call
+// during wiring, before the service handles requests.
+func (s *OrderService) SetTimeInForce(calendar *MarketCalendar, gtcMaxDays int) {
+	s.calendar = calendar
+	s.gtcMaxDays = gtcMaxDays
+}
+
+// SetWaitLimit sets the longest Wait blocks, and the default wait. Call
+// during wiring, before the service handles requests.
+func (s *OrderService) SetWaitLimit(max time.Duration) {
+	s.maxWait = max
+}
+
+// expiry validates a time in force and works out when an order on symbol
+// expires: the close of its market's session in progress, or of the next
+// one while that market is closed, for DAY; expiresAt for GTC, defaulting
+// to the GTC cap.
+func (s *OrderService) expiry(symbol, timeInForce string, expiresAt *time.Time) (string, *time.Time, error) {
+	now := s.now()
+	switch tif := strings.ToUpper(strings.TrimSpace(timeInForce)); tif {
+	case "", data.OrderTIFGTC:
+		if expiresAt != nil && !expiresAt.After(now) {
+			return "", nil, &util.ValidationError{Field: "expires_at", Message: "must be in the future"}
+		}
+		if s.gtcMaxDays > 0 {
+			limit := now.AddDate(0, 0, s.gtcMaxDays)
+			if expiresAt == nil {
+				expiresAt = &limit
+			} else if expiresAt.After(limit) {
+				return "", nil, &util.ValidationError{Field: "expires_at", Message: fmt.Sprintf("must be within %d days", s.gtcMaxDays)}
+			}
+		}
+		return data.OrderTIFGTC, expiresAt, nil
+	case data.OrderTIFDay:
+		if s.calendar == nil {
+			return "", nil, &util.ValidationError{Field: "time_in_force", Message: "DAY orders are not available"}
+		}
+		if expiresAt != nil {
+			return "", nil, &util.ValidationError{Field: "expires_at", Message: "must be omitted for DAY orders"}
+		}
+		end := s.calendar.ForSymbol(symbol).NextSession(now).Close
+		return data.OrderTIFDay, &end, nil
+	}
+	return "", nil, &util.ValidationError{Field: "time_in_force", Message: "must be DAY or GTC"}
+}
+
+// Create places an order. Sell orders need the shares to be held when they
+// are placed; buy orders are checked for funds only when they fill. The
+// trigger price is not checked against the current quote: an order whose
+// condition already holds fills on the next poll.
+func (s *OrderService) Create(ctx context.Context, userID string, req OrderRequest) (*data.Order, error) {
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	orderType := strings.ToUpper(strings.TrimSpace(req.Type))
+	side := strings.ToUpper(strings.TrimSpace(req.Side))
+	switch orderType {
+	case data.OrderTypeStopLoss, data.OrderTypeTakeProfit:
+		if side == "" {
+			side = data.OrderSideSell
+		}
+		if side != data.OrderSideSell {
+			return nil, &util.ValidationError{Field: "side", Message: "must be SELL for STOP_LOSS and TAKE_PROFIT orders"}
+		}
+	case data.OrderTypeMarket, data.OrderTypeLimit, data.OrderTypeStop:
+		if side != data.OrderSideBuy && side != data.OrderSideSell {
+			return nil, &util.ValidationError{Field: "side", Message: "must be BUY or SELL"}
+		}
+	default:
+		return nil, &util.ValidationError{Field: "type", Message: "must be MARKET, LIMIT, STOP, STOP_LOSS or TAKE_PROFIT"}
+	}
+	if err := util.ValidateQuantity(req.Quantity, s.investments.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		return nil, err
+	}
+	var price *decimal.Decimal
+	if orderType == data.OrderTypeMarket {
+		if !req.TriggerPrice.IsZero() {
+			return nil, &util.ValidationError{Field: "trigger_price", Message: "must be omitted for MARKET orders"}
+		}
+	} else {
+		p := req.TriggerPrice
+		if err := validateTriggerPrice("trigger_price", p, s.investments.precisionOf(ctx, symbol)); err != nil {
+			return nil, err
+		}
+		price = &p
+	}
+	tif, expiresAt, err := s.expiry(symbol, req.TimeInForce, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Idempotency pre-check: a retried request gets the order it queued.
+	if req.IdempotencyKey != "" {
+		existing, err := s.store.GetByIdempotencyKey(ctx, userID, req.IdempotencyKey)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+	}
+
+	holding, err := s.investments.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
+	if err != nil && !errors.Is(err, data.ErrStockHoldingNotFound) {
+		return nil, err
+	}
+	if side == data.OrderSideSell {
+		if holding == nil {
+			return nil, &StockHoldingNotFoundError{}
+		}
+		if holding.Quantity.LessThan(req.Quantity) {
+			return nil, &InsufficientStockError{}
+		}
+	} else if holding != nil && holding.IsShort() {
+		return nil, &ShortPositionOpenError{}
+	}
+
+	// Count-then-insert is not atomic; two concurrent creates can overshoot
+	// the cap by one, which is harmless for a soft per-user limit.
+	pending, err := s.store.CountPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= s.maxPending {
+		return nil, &OrderLimitError{Limit: s.maxPending}
+	}
+
+	order, err := s.store.Create(ctx, &data.Order{
+		UserID:         userID,
+		Symbol:         symbol,
+		Side:           side,
+		OrderType:      orderType,
+		Quantity:       req.Quantity,
+		TriggerPrice:   price,
+		TimeInForce:    tif,
+		ExpiresAt:      expiresAt,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err != nil {
+		// Unique violation on the idempotency key: a concurrent retry
+		// queued the order first.
+		var pqErr *pq.Error
+		if req.IdempotencyKey != "" && errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			existing, fetchErr := s.store.GetByIdempotencyKey(ctx, userID, req.IdempotencyKey)
+			if fetchErr != nil {
+				return nil, fetchErr
+			}
+			if existing != nil {
+				return existing, nil
+			}
+			return nil, fmt.Errorf("idempotency conflict but no prior order found: %w", err)
+		}
+		return nil, err
+	}
+	slog.Info("order created",
+		"order_id", order.ID, "user_id", userID, "symbol", symbol, "side", side, "order_type", orderType,
+		"quantity", req.Quantity, "trigger_price", price, "time_in_force", tif, "expires_at", expiresAt, "component", "orders")
+	return order, nil
+}
+
+// CreateOCO places a take-profit and a stop-loss on the same shares as one
+// linked pair. Both are inserted in one transaction, so the pair is never
+// half placed; it counts as two orders against the pending cap.
+func (s *OrderService) CreateOCO(ctx context.Context, userID string, req OCORequest) (*OCOOrders, error) {
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := util.ValidateQuantity(req.Quantity, s.investments.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		return nil, err
+	}
+	prec := s.investments.precisionOf(ctx, symbol)
+	if err := validateTriggerPrice("take_profit", req.TakeProfit, prec); err != nil {
+		return nil, err
+	}
+	if err := validateTriggerPrice("stop_loss", req.StopLoss, prec); err != nil {
+		return nil, err
+	}
+	if !req.TakeProfit.GreaterThan(req.StopLoss) {
+		return nil, &util.ValidationError{Field: "take_profit", Message: "must be above stop_loss"}
+	}
+	tif, expiresAt, err := s.expiry(symbol, req.TimeInForce, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	holding, err := s.investments.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
+	if errors.Is(err, data.ErrStockHoldingNotFound) {
+		return nil, &StockHoldingNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if holding.Quantity.LessThan(req.Quantity) {
+		return nil, &InsufficientStockError{}
+	}
+
+	pending, err := s.store.CountPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending+2 > s.maxPending {
+		return nil, &OrderLimitError{Limit: s.maxPending}
+	}
+
+	tx, err := s.investments.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	store := data.NewOrderStore(tx)
+	pair := &OCOOrders{GroupID: uuid.New().String()}
+	legs := []struct {
+		orderType string
+		price     decimal.Decimal
+		dst       **data.Order
+	}{
+		{data.OrderTypeTakeProfit, req.TakeProfit, &pair.TakeProfit},
+		{data.OrderTypeStopLoss, req.StopLoss, &pair.StopLoss},
+	}
+	for _, leg := range legs {
+		price := leg.price
+		order, err := store.Create(ctx, &data.Order{
+			UserID:       userID,
+			Symbol:       symbol,
+			Side:         data.OrderSideSell,
+			OrderType:    leg.orderType,
+			Quantity:     req.Quantity,
+			TriggerPrice: &price,
+			TimeInForce:  tif,
+			ExpiresAt:    expiresAt,
+			OCOGroupID:   pair.GroupID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		*leg.dst = order
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	slog.Info("oco orders created",
+		"oco_group_id", pair.GroupID, "user_id", userID, "symbol", symbol, "quantity", req.Quantity,
+		"take_profit", req.TakeProfit, "stop_loss", req.StopLoss, "time_in_force", tif, "expires_at", expiresAt, "component", "orders")
+	return pair, nil
+}
+
+// CreateBracket places a bracket: a buy entry with a take-profit and a
+// stop-loss that wait on it. Filling the entry makes the exits PENDING in
+// the same transaction; they are then an ordinary OCO pair, so whichever
+// triggers first cancels the other. Cancelling, expiring or failing the
+// entry cancels the exits with it. The three orders are inserted in one
+// transaction and count as three against the pending cap.
+func (s *OrderService) CreateBracket(ctx context.Context, userID string, req BracketRequest) (*BracketOrders, error) {
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if err := util.ValidateQuantity(req.Quantity, s.investments.maxQuantity, util.IsCryptoPair(symbol)); err != nil {
+		return nil, err
+	}
+	entryType := strings.ToUpper(strings.TrimSpace(req.EntryType))
+	if entryType == "" {
+		entryType = data.OrderTypeLimit
+	}
+	prec := s.investments.precisionOf(ctx, symbol)
+	if err := validateTriggerPrice("take_profit", req.TakeProfit, prec); err != nil {
+		return nil, err
+	}
+	if err := validateTriggerPrice("stop_loss", req.StopLoss, prec); err != nil {
+		return nil, err
+	}
+	if !req.TakeProfit.GreaterThan(req.StopLoss) {
+		return nil, &util.ValidationError{Field: "take_profit", Message: "must be above stop_loss"}
+	}
+	var entryPrice *decimal.Decimal
+	switch entryType {
+	case data.OrderTypeMarket:
+		if !req.EntryPrice.IsZero() {
+			return nil, &util.ValidationError{Field: "entry_price", Message: "must be omitted for MARKET entries"}
+		}
+	case data.OrderTypeLimit, data.OrderTypeStop:
+		p := req.EntryPrice
+		if err := validateTriggerPrice("entry_price", p, prec); err != nil {
+			return nil, err
+		}
+		if !p.GreaterThan(req.StopLoss) || !p.LessThan(req.TakeProfit) {
+			return nil, &util.ValidationError{Field: "entry_price", Message: "must be between stop_loss and take_profit"}
+		}
+		entryPrice = &p
+	default:
+		return nil, &util.ValidationError{Field: "entry_type", Message: "must be MARKET, LIMIT or STOP"}
+	}
+	tif, expiresAt, err := s.expiry(symbol, req.TimeInForce, req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	_, exitExpiresAt, err := s.expiry(symbol, data.OrderTIFGTC, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	holding, err := s.investments.portfolioStore.GetPortfolioBySymbol(ctx, userID, symbol)
+	if err != nil && !errors.Is(err, data.ErrStockHoldingNotFound) {
+		return nil, err
+	}
+	if holding != nil && holding.IsShort() {
+		return nil, &ShortPositionOpenError{}
+	}
+
+	pending, err := s.store.CountPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending+3 > s.maxPending {
+		return nil, &OrderLimitError{Limit: s.maxPending}
+	}
+
+	tx, err := s.investments.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	store := data.NewOrderStore(tx)
+	bracket := &BracketOrders{OCOOrders: OCOOrders{GroupID: uuid.New().String()}}
+	bracket.Entry, err = store.Create(ctx, &data.Order{
+		UserID:       userID,
+		Symbol:       symbol,
+		Side:         data.OrderSideBuy,
+		OrderType:    entryType,
+		Quantity:     req.Quantity,
+		TriggerPrice: entryPrice,
+		TimeInForce:  tif,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	legs := []struct {
+		orderType string
+		price     decimal.Decimal
+		dst       **data.Order
+	}{
+		{data.OrderTypeTakeProfit, req.TakeProfit, &bracket.TakeProfit},
+		{data.OrderTypeStopLoss, req.StopLoss, &bracket.StopLoss},
+	}
+	for _, leg := range legs {
+		price := leg.price
+		order, err := store.Create(ctx, &data.Order{
+			UserID:        userID,
+			Symbol:        symbol,
+			Side:          data.OrderSideSell,
+			OrderType:     leg.orderType,
+			Quantity:      req.Quantity,
+			TriggerPrice:  &price,
+			TimeInForce:   data.OrderTIFGTC,
+			ExpiresAt:     exitExpiresAt,
+			OCOGroupID:    bracket.GroupID,
+			ParentOrderID: bracket.Entry.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		*leg.dst = order
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	slog.Info("bracket orders created",
+		"order_id", bracket.Entry.ID, "oco_group_id", bracket.GroupID, "user_id", userID, "symbol", symbol,
+		"quantity", req.Quantity, "entry_type", entryType, "entry_price", entryPrice, "take_profit", req.TakeProfit,
+		"stop_loss", req.StopLoss, "time_in_force", tif, "expires_at", expiresAt, "component", "orders")
+	return bracket, nil
+}
+
+// validateTriggerPrice checks p is a positive price on the instrument's
+// tick that fits orders.trigger_price, NUMERIC(20,8).
+func validateTriggerPrice(field string, p decimal.Decimal, prec Precision) error {
+	if !p.IsPositive() || p.GreaterThanOrEqual(maxPrice) {
+		return &util.ValidationError{Field: field, Message: "must be a positive amount"}
+	}
+	if !prec.OnTick(p) {
+		return &util.ValidationError{Field: field, Message: "must be a multiple of the tick size " + prec.At(p).TickSize.String()}
+	}
+	return nil
+}
+
+// Get returns one of the user's orders.
+func (s *OrderService) Get(ctx context.Context, userID, id string) (*data.Order, error) {
+	order, err := s.store.Get(ctx, userID, id)
+	if errors.Is(err, data.ErrOrderNotFound) {
+		return nil, &OrderNotFoundError{}
+	}
+	return order, err
+}
+
+// Wait returns one of the user's orders once it is no longer open, or as
+// it stands once timeout passes; a zero timeout waits as long as allowed.
+// It blocks on the order's events rather than reading the order repeatedly.
+// An order closed by another instance is seen when the wait runs out.
+func (s *OrderService) Wait(ctx context.Context, userID, id string, timeout time.Duration) (*data.Order, error) {
+	if timeout == 0 {
+		timeout = s.maxWait
+	}
+	if timeout < time.Second || timeout > s.maxWait {
+		return nil, &util.ValidationError{Field: "timeout", Message: fmt.Sprintf("must be between 1 and %d seconds", int(s.maxWait.Seconds()))}
+	}
+
+	// Subscribe before reading, so a fill in between still wakes us.
+	closed, stop := s.events.subscribe(id)
+	defer stop()
+	order, err := s.Get(ctx, userID, id)
+	if err != nil || (order.Status != data.OrderPending && order.Status != data.OrderWaiting) {
+		return order, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-closed:
+	case <-timer.C:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return s.Get(ctx, userID, id)
+}
+
+// List returns the user's orders, newest first. status filters by status
+// ("" for all); limit is clamped to [1, maxOrderLimit] with zero selecting
+// the default.
+func (s *OrderService) List(ctx context.Context, userID, status string, limit int) ([]data.Order, error) {
+	status = strings.ToUpper(strings.TrimSpace(status))
+	switch status {
+	case "", data.OrderWaiting, data.OrderPending, data.OrderFilled, data.OrderCancelled, data.OrderExpired, data.OrderFailed:
+	default:
+		return nil, &util.ValidationError{Field: "status", Message: "must be WAITING, PENDING, FILLED, CANCELLED, EXPIRED or FAILED"}
+	}
+	if limit <= 0 {
+		limit = defaultOrderLimit
+	}
+	limit = min(limit, maxOrderLimit)
+	return s.store.ListByUser(ctx, userID, status, limit)
+}
+
+// Cancel withdraws one of the user's open orders, with the other half of
+// its OCO pair if it has one and the exits of a bracket entry.
+func (s *OrderService) Cancel(ctx context.Context, userID, id string) (*data.Order, error) {
+	order, err := s.store.Cancel(ctx, userID, id)
+	switch {
+	case errors.Is(err, data.ErrOrderNotFound):
+		return nil, &OrderNotFoundError{}
+	case errors.Is(err, data.ErrOrderNotPending):
+		return nil, &OrderNotPendingError{}
+	case err != nil:
+		return nil, err
+	}
+	slog.Info("order cancelled", "order_id", id, "user_id", userID, "component", "orders")
+	s.events.publish(id)
+	if order.OCOGroupID != "" {
+		linked, err := s.store.CancelOCOGroup(ctx, order.OCOGroupID, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range linked {
+			slog.Info("linked order cancelled", "order_id", o.ID, "oco_group_id", order.OCOGroupID, "user_id", userID, "component", "orders")
+			s.events.publish(o.ID)
+		}
+	}
+	return order, nil
+}
+
+// conditionMet reports whether price has crossed the order's trigger. Limit
+// buys and stop sells wait for the price to fall to the trigger; limit
+// sells and stop buys wait for it to rise. Market orders fill at any price.
+func conditionMet(order *data.Order, price decimal.Decimal) bool {
+	if order.TriggerPrice == nil {
+		return order.OrderType == data.OrderTypeMarket
+	}
+	switch {
+	case order.OrderType == data.OrderTypeStopLoss,
+		order.OrderType == data.OrderTypeStop && order.Side == data.OrderSideSell,
+		order.OrderType == data.OrderTypeLimit && order.Side == data.OrderSideBuy:
+		return price.LessThanOrEqual(*order.TriggerPrice)
+	case order.OrderType == data.OrderTypeTakeProfit,
+		order.OrderType == data.OrderTypeStop && order.Side == data.OrderSideBuy,
+		order.OrderType == data.OrderTypeLimit && order.Side == data.OrderSideSell:
+		return price.GreaterThanOrEqual(*order.TriggerPrice)
+	}
+	return false
+}
+
+// CheckOrders expires PENDING orders past their expires_at, then makes one
+// pass over the rest, quoting each symbol once and filling the orders whose
+// condition holds. Returns how many filled. Quotes come from MarketService's
+// cache, so a pass costs at most one provider call per symbol. Orders on a
+// symbol whose market is closed wait; crypto never does. So do orders whose
+// only quote is too stale to trade at.
+func (s *OrderService) CheckOrders(ctx context.Context) (int, error) {
+	if err := s.expire(ctx); err != nil {
+		return 0, err
+	}
+	symbols, err := s.store.PendingSymbols(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	filled := 0
+	for _, symbol := range symbols {
+		if err := ctx.Err(); err != nil {
+			return filled, err
+		}
+		if s.hours != nil && !s.hours.IsOpenFor(symbol) {
+			continue
+		}
+		quote, err := s.investments.tradeQuote(ctx, symbol)
+		if err != nil {
+			slog.Warn("quote failed; skipping pending orders", "symbol", symbol, "err", err, "component", "orders")
+			continue
+		}
+		// A zero quote is missing data, not a crash to zero; it must not
+		// trip every stop-loss on the symbol.
+		if !quote.Price.IsPositive() {
+			continue
+		}
+
+		orders, err := s.store.ListPendingBySymbol(ctx, symbol)
+		if err != nil {
+			return filled, err
+		}
+		for i := range orders {
+			if conditionMet(&orders[i], quote.Price) && s.fill(ctx, &orders[i], quote.Price) {
+				filled++
+			}
+		}
+	}
+	return filled, nil
+}
+
+// expire closes every PENDING order whose expiry has passed and tells its
+// owner.
+func (s *OrderService) expire(ctx context.Context) error {
+	expired, err := s.store.ExpireDue(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	for _, order := range expired {
+		s.events.publish(order.ID)
+		slog.Info("order expired", "order_id", order.ID, "user_id", order.UserID, "symbol", order.Symbol,
+			"time_in_force", order.TimeInForce, "component", "orders")
+		when := "expired"
+		if order.TimeInForce == data.OrderTIFDay {
+			when = "expired at the close"
+		}
+		s.notify(ctx, order.UserID, NotificationOrderExpired,
+			orderLabel(&order)+" for "+order.Symbol+" expired",
+			fmt.Sprintf("Your order to %s %s %s %s %s without filling.",
+				strings.ToLower(order.Side), order.Quantity, order.Symbol, orderPrice(&order), when))
+	}
+	return nil
+}
+
+// fill executes the order against quote and reports whether it filled. The
+// fill price takes the simulated spread, except that a limit order never
+// fills past its limit. Orders held by a pre-trade check that can clear
+// (a halt, which an admin or the provider lifts) or hit by a transient error
+// stay PENDING and are retried on the next pass, until they expire; orders
+// that can no longer be filled (shares sold, cash spent, symbol restricted,
+// trade limits used up, email verification required) are closed as FAILED.
+// A queued MARKET order's trade takes the order's idempotency key, so a
+// retry of the original request replays the fill. Filling one half of an
+// OCO pair cancels the other in the same transaction.
+func (s *OrderService) fill(ctx context.Context, order *data.Order, quote decimal.Decimal) bool {
+	log := slog.With("order_id", order.ID, "user_id", order.UserID, "symbol", order.Symbol,
+		"side", order.Side, "order_type", order.OrderType, "component", "orders")
+
+	price, slippage := s.investments.fillPrice(order.Side, quote, s.investments.precisionOf(ctx, order.Symbol))
+	if order.OrderType == data.OrderTypeLimit && order.TriggerPrice != nil {
+		if order.Side == data.OrderSideBuy {
+			price = decimal.Min(price, *order.TriggerPrice)
+		} else {
+			price = decimal.Max(price, *order.TriggerPrice)
+		}
+		slippage = price.Sub(quote)
+	}
+
+	if err := s.investments.runPreTradeChecks(ctx, TradeIntent{
+		UserID:   order.UserID,
+		Symbol:   order.Symbol,
+		Action:   order.Side,
+		Quantity: order.Quantity,
+		Price:    price,
+	}); err != nil {
+		if !s.failUnfillable(ctx, log, order, err) {
+			log.Info("order held by pre-trade check", "err", err)
+		}
+		return false
+	}
+
+	trade := &data.Trade{
+		ID:             uuid.New().String(),
+		UserID:         order.UserID,
+		Symbol:         order.Symbol,
+		Action:         order.Side,
+		Quantity:       order.Quantity,
+		Price:          price,
+		Status:         "COMPLETED",
+		IdempotencyKey: order.IdempotencyKey,
+		OrderType:      order.OrderType,
+		Slippage:       slippage,
+	}
+	var linked []data.Order
+	claim := func(tx *sql.Tx) error {
+		store := data.NewOrderStore(tx)
+		if order.OCOGroupID == "" {
+			return store.MarkFilled(ctx, order.ID, trade.ID, price)
+		}
+		// Both halves can trigger on one quote; the group lock makes the
+		// second fill wait and then find its order cancelled.
+		if err := store.LockOCOGroup(ctx, order.OCOGroupID); err != nil {
+			return err
+		}
+		if err := store.MarkFilled(ctx, order.ID, trade.ID, price); err != nil {
+			return err
+		}
+		var err error
+		linked, err = store.CancelOCOGroup(ctx, order.OCOGroupID, order.ID)
+		return err
+	}
+	var err error
+	if order.Side == data.OrderSideBuy {
+		err = s.investments.executeBuy(ctx, trade, claim)
+	} else {
+		_, err = s.investments.executeSell(ctx, trade, claim)
+	}
+
+	if err == nil {
+		verb := "Sold"
+		if order.Side == data.OrderSideBuy {
+			verb = "Bought"
+		}
+		log.Info("order filled", "trade_id", trade.ID, "quantity", order.Quantity, "price", price)
+		s.events.publish(order.ID)
+		body := fmt.Sprintf("%s %s %s at $%s.", verb, order.Quantity, order.Symbol, price.StringFixed(2))
+		if order.TriggerPrice != nil {
+			body = fmt.Sprintf("%s %s %s at $%s (trigger $%s).",
+				verb, order.Quantity, order.Symbol, price.StringFixed(2), order.TriggerPrice.StringFixed(2))
+		}
+		if len(linked) > 0 {
+			body += fmt.Sprintf(" Your linked %s order was cancelled.", strings.ToLower(orderLabel(&linked[0])))
+			for _, o := range linked {
+				log.Info("linked order cancelled", "linked_order_id", o.ID, "oco_group_id", order.OCOGroupID)
+				s.events.publish(o.ID)
+			}
+		}
+		s.notify(ctx, order.UserID, NotificationOrderTriggered, orderLabel(order)+" filled for "+order.Symbol, body)
+		return true
+	}
+	if errors.Is(err, data.ErrOrderNotPending) {
+		// Cancelled, expired or filled by another instance since it was listed.
+		return false
+	}
+
+	if !s.failUnfillable(ctx, log, order, err) {
+		log.Error("order fill failed", "err", err)
+	}
+	return false
+}
+
+// failUnfillable closes the order as FAILED and tells its owner if err will
+// not go away on retry, and reports whether it did.
+func (s *OrderService) failUnfillable(ctx context.Context, log *slog.Logger, order *data.Order, err error) bool {
+	reason, detail := unfillableReason(order, err)
+	if reason == "" {
+		return false
+	}
+	if err := s.store.MarkFailed(ctx, order.ID, reason); err != nil {
+		if !errors.Is(err, data.ErrOrderNotPending) {
+			log.Error("failed to close unfillable order", "err", err)
+		}
+		return true
+	}
+	log.Info("order failed: " + reason)
+	s.events.publish(order.ID)
+	s.notify(ctx, order.UserID, NotificationOrderFailed,
+		orderLabel(order)+" for "+order.Symbol+" could not be filled", detail+" so the order was closed.")
+	return true
+}
+
+// unfillableReason maps a fill error or pre-trade rejection that will not go
+// away on retry to a failure reason and a sentence for the user. Returns ""
+// for anything else, including halts and a closed market.
+func unfillableReason(order *data.Order, err error) (reason, detail string) {
+	var holdingErr *StockHoldingNotFoundError
+	var stockErr *InsufficientStockError
+	var fundsErr *InsufficientFundsError
+	var shortErr *ShortPositionOpenError
+	var restrictedErr *SymbolRestrictedError
+	var holdingsErr *HoldingsLimitError
+	var pdtErr *PatternDayTraderError
+	var dailyErr *DailyTradeLimitError
+	var verifyErr *VerificationRequiredError
+	switch {
+	case errors.As(err, &holdingErr), errors.As(err, &stockErr):
+		return "insufficient shares", fmt.Sprintf("You no longer hold %s shares of %s,", order.Quantity, order.Symbol)
+	case errors.As(err, &fundsErr):
+		return "insufficient funds", fmt.Sprintf("Your balance did not cover %s shares of %s,", order.Quantity, order.Symbol)
+	case errors.As(err, &shortErr):
+		return "short position open", fmt.Sprintf("You are short %s,", order.Symbol)
+	case errors.As(err, &restrictedErr):
+		return "symbol restricted", fmt.Sprintf("Trading in %s is not allowed (%s),", order.Symbol, restrictedErr.Reason)
+	case errors.As(err, &holdingsErr):
+		return "holdings limit", fmt.Sprintf("Buying %s would take you past %d different symbols,", order.Symbol, holdingsErr.Limit)
+	case errors.As(err, &pdtErr):
+		return "pattern day trader", "The fill would have been a further day trade under the pattern day trader rule,"
+	case errors.As(err, &dailyErr):
+		return "daily trade limit", fmt.Sprintf("You had used all %d of today's trades,", dailyErr.Limit)
+	case errors.As(err, &verifyErr):
+		if verifyErr.TradeCap > 0 {
+			return "email unverified", fmt.Sprintf("You had made %d trades without verifying your email address,", verifyErr.TradeCap)
+		}
+		return "email unverified", "Your email address was not verified,"
+	}
+	return "", ""
+}
+
+// notify is best-effort: the order has already been recorded.
+func (s *OrderService) notify(ctx context.Context, userID, kind, title, body string) {
+	if s.notifications == nil {
+		return
+	}
+	if err := s.notifications.Notify(ctx, []string{userID}, kind, title, body); err != nil {
+		slog.Warn("failed to send order notification", "user_id", userID, "kind", kind, "err", err, "component", "orders")
+	}
+}
+
+// orderPrice describes the order's price for a notification.
+func orderPrice(order *data.Order) string {
+	if order.TriggerPrice == nil {
+		return "at market"
+	}
+	return "at $" + order.TriggerPrice.StringFixed(2)
+}
+
+func orderLabel(order *data.Order) string {
+	switch order.OrderType {
+	case data.OrderTypeMarket:
+		return "Market " + strings.ToLower(order.Side)
+	case data.OrderTypeTakeProfit:
+		return "Take-profit"
+	case data.OrderTypeStopLoss:
+		return "Stop-loss"
+	case data.OrderTypeLimit:
+		return "Limit " + strings.ToLower(order.Side)
+	default:
+		return "Stop " + strings.ToLower(order.Side)
+	}
+}
+
+// Run calls CheckOrders every interval until ctx is cancelled. Every API
+// instance may run it: the fill transaction claims the order row, so an order
+// fills at most once.
+func (s *OrderService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.CheckOrders(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("order check failed", "err", err, "component", "orders")
+		} else if n > 0 {
+			slog.Info("orders filled", "count", n, "component", "orders")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import "sync"
+
+// orderEvents wakes requests waiting on an order once it leaves PENDING:
+// OrderService publishes each order it fills, fails, expires or cancels, and
+// a waiter then reads the order once instead of polling for it. It is
+// in-process, so an order closed by another instance's order loop is only
+// seen when the wait runs out and the order is read again.
+type orderEvents struct {
+	mu      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+func newOrderEvents() *orderEvents {
+	return &orderEvents{waiters: make(map[string]map[chan struct{}]struct{})}
+}
+
+// subscribe returns a channel that receives once when order id is next
+// published, and a func to stop waiting, which must be called.
+func (e *orderEvents) subscribe(id string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	e.mu.Lock()
+	if e.waiters[id] == nil {
+		e.waiters[id] = make(map[chan struct{}]struct{})
+	}
+	e.waiters[id][ch] = struct{}{}
+	e.mu.Unlock()
+	return ch, func() {
+		e.mu.Lock()
+		delete(e.waiters[id], ch)
+		if len(e.waiters[id]) == 0 {
+			delete(e.waiters, id)
+		}
+		e.mu.Unlock()
+	}
+}
+
+// publish wakes everything waiting on ids.
+func (e *orderEvents) publish(ids ...string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		for ch := range e.waiters[id] {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// OrderHolds is what a user's PENDING orders would take if they all filled:
+// cash for buys and shares for sells. Nothing is set aside when an order is
+// placed (one that can't be covered when it triggers fails), so cash on
+// hold is part of the account's cash and equity; BuyingPower is what is
+// left once it is counted.
+type OrderHolds struct {
+	Cash   decimal.Decimal            `json:"cash_on_hold"`
+	Shares map[string]decimal.Decimal `json:"shares_on_hold"` // by symbol
+	// Partial is set when a queued MARKET buy had no quote and was left out
+	// of Cash.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// HoldsSource gives the holds of a user's pending orders. Satisfied by
+// *OrderService.
+type HoldsSource interface {
+	Holds(ctx context.Context, userID string) (*OrderHolds, error)
+}
+
+// Holds returns what userID's PENDING orders hold. A buy holds its quantity
+// at the trigger price, the most a limit buy pays and about what a stop buy
+// fills at; a queued MARKET buy holds it at the latest quote. A sell holds
+// its shares, counted once for the two halves of an OCO pair since only one
+// can fill. Each symbol is quoted at most once per call.
+func (s *OrderService) Holds(ctx context.Context, userID string) (*OrderHolds, error) {
+	orders, err := s.store.ListPendingByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	holds := &OrderHolds{Shares: make(map[string]decimal.Decimal)}
+	ocoShares := make(map[string]decimal.Decimal)
+	quotes := make(map[string]decimal.Decimal) // zero = no usable quote
+	for _, o := range orders {
+		if o.Side == data.OrderSideSell {
+			if o.OCOGroupID == "" {
+				holds.Shares[o.Symbol] = holds.Shares[o.Symbol].Add(o.Quantity)
+			} else if o.Quantity.GreaterThan(ocoShares[o.OCOGroupID]) {
+				holds.Shares[o.Symbol] = holds.Shares[o.Symbol].Add(o.Quantity.Sub(ocoShares[o.OCOGroupID]))
+				ocoShares[o.OCOGroupID] = o.Quantity
+			}
+			continue
+		}
+
+		price := decimal.Zero
+		if o.TriggerPrice != nil {
+			price = *o.TriggerPrice
+		} else {
+			quoted, ok := quotes[o.Symbol]
+			if !ok {
+				quoted = s.holdQuote(ctx, o.Symbol)
+				quotes[o.Symbol] = quoted
+			}
+			if quoted.IsZero() {
+				holds.Partial = true
+			}
+			price = quoted
+		}
+		holds.Cash = holds.Cash.Add(price.Mul(o.Quantity))
+	}
+	holds.Cash = holds.Cash.Round(2)
+	return holds, nil
+}
+
+// holdQuote is the price a queued MARKET buy of symbol holds at, or zero when
+// there is no usable quote.
+func (s *OrderService) holdQuote(ctx context.Context, symbol string) decimal.Decimal {
+	quote, err := s.investments.marketService.GetStock(ctx, symbol)
+	if err != nil {
+		slog.Warn("order holds: quote failed; market buys not counted", "symbol", symbol, "err", err, "component", "orders")
+		return decimal.Zero
+	}
+	if !quote.Price.IsPositive() {
+		return decimal.Zero
+	}
+	return quote.Price
+}
@@ -0,0 +1,804 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// orderCols matches the orders column list.
+var orderCols = []string{
+	"id", "user_id", "symbol", "side", "order_type", "quantity", "trigger_price", "status",
+	"created_at", "expires_at", "closed_at", "trade_id", "fill_price", "failure_reason", "oco_group_id", "time_in_force", "idempotency_key",
+	"parent_order_id",
+}
+
+func newOrderService(t *testing.T, price decimal.Decimal) (*OrderService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: price}}
+	investments := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+	return NewOrderService(data.NewOrderStore(db), investments, nil, 2), mock
+}
+
+func pendingOrderRow(side, orderType string, quantity int, trigger string) *sqlmock.Rows {
+	return sqlmock.NewRows(orderCols).AddRow(
+		"ord-1", "user-1", "AAPL", side, orderType, quantity, decimal.RequireFromString(trigger), "PENDING",
+		time.Now(), nil, nil, nil, nil, nil, nil, "GTC", nil, nil,
+	)
+}
+
+// expectNoneExpired expects the expiry sweep that starts every CheckOrders pass.
+func expectNoneExpired(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("UPDATE orders SET status = 'EXPIRED'").
+		WillReturnRows(sqlmock.NewRows(orderCols))
+}
+
+func TestOrderCreate_Validation(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name, side, orderType, price string
+		expiresAt                    *time.Time
+		field                        string
+	}{
+		{"unknown type", "BUY", "ICEBERG", "90", nil, "type"},
+		{"market without side", "", "MARKET", "0", nil, "side"},
+		{"market with trigger", "BUY", "MARKET", "90", nil, "trigger_price"},
+		{"limit without side", "", "LIMIT", "90", nil, "side"},
+		{"protective buy", "BUY", "STOP_LOSS", "90", nil, "side"},
+		{"zero price", "", "STOP_LOSS", "0", nil, "trigger_price"},
+		{"sub-cent price", "SELL", "LIMIT", "90.001", nil, "trigger_price"},
+		{"expiry in the past", "BUY", "LIMIT", "90", &past, "expires_at"},
+	}
+	for _, tc := range cases {
+		_, err := svc.Create(ctx, "user-1", OrderRequest{
+			Symbol: "AAPL", Side: tc.side, Type: tc.orderType, Quantity: decimal.NewFromInt(1),
+			TriggerPrice: decimal.RequireFromString(tc.price), ExpiresAt: tc.expiresAt,
+		})
+		var ve *util.ValidationError
+		if !errors.As(err, &ve) || ve.Field != tc.field {
+			t.Errorf("%s: expected validation error on %q, got %v", tc.name, tc.field, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+}
+
+func TestOrderCreate_SellMoreThanHeld(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
+			"port-1", "user-1", "AAPL", 3, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now(),
+		))
+
+	_, err := svc.Create(context.Background(), "user-1", OrderRequest{
+		Symbol: "AAPL", Side: "SELL", Type: "LIMIT", Quantity: decimal.NewFromInt(5), TriggerPrice: decimal.NewFromInt(110),
+	})
+	var ise *InsufficientStockError
+	if !errors.As(err, &ise) {
+		t.Errorf("expected InsufficientStockError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOrderCreate_LimitReached(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
+			"port-1", "user-1", "AAPL", 10, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now(),
+		))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	_, err := svc.Create(context.Background(), "user-1", OrderRequest{
+		Symbol: "aapl", Type: "take_profit", Quantity: decimal.NewFromInt(5), TriggerPrice: decimal.NewFromInt(120),
+	})
+	var le *OrderLimitError
+	if !errors.As(err, &le) || le.Limit != 2 {
+		t.Errorf("expected OrderLimitError{2}, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOrderCreate_ReplaysIdempotencyKey(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+
+	mock.ExpectQuery("FROM orders WHERE user_id = \\$1 AND idempotency_key = \\$2").
+		WithArgs("user-1", "key-1").
+		WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+			"ord-1", "user-1", "AAPL", "BUY", data.OrderTypeMarket, 5, nil, "PENDING",
+			time.Now(), nil, nil, nil, nil, nil, nil, "GTC", "key-1", nil))
+
+	order, err := svc.Create(context.Background(), "user-1", OrderRequest{
+		Symbol: "AAPL", Side: "BUY", Type: "MARKET", Quantity: decimal.NewFromInt(5), IdempotencyKey: "key-1",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if order.ID != "ord-1" || order.IdempotencyKey != "key-1" {
+		t.Errorf("got %+v, want the queued order", order)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOrderCreateOCO(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+	ctx := context.Background()
+
+	// Take-profit must sit above stop-loss.
+	var verr *util.ValidationError
+	if _, err := svc.CreateOCO(ctx, "user-1", OCORequest{
+		Symbol: "AAPL", Quantity: decimal.NewFromInt(5), TakeProfit: decimal.NewFromInt(90), StopLoss: decimal.NewFromInt(90),
+	}); !errors.As(err, &verr) || verr.Field != "take_profit" {
+		t.Errorf("inverted pair: got %v, want take_profit validation error", err)
+	}
+
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
+			"port-1", "user-1", "AAPL", 10, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now(),
+		))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	for _, leg := range []struct{ orderType, price string }{
+		{data.OrderTypeTakeProfit, "120"}, {data.OrderTypeStopLoss, "85"},
+	} {
+		mock.ExpectQuery("INSERT INTO orders").
+			WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "SELL", leg.orderType, decimal.NewFromInt(5),
+				decimal.RequireFromString(leg.price), sqlmock.AnyArg(), sqlmock.AnyArg(), data.OrderTIFGTC, sqlmock.AnyArg(),
+				sqlmock.AnyArg(), data.OrderPending).
+			WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+				"ord-"+leg.orderType, "user-1", "AAPL", "SELL", leg.orderType, 5, decimal.RequireFromString(leg.price),
+				"PENDING", time.Now(), nil, nil, nil, nil, nil, "grp-1", "GTC", nil, nil))
+	}
+	mock.ExpectCommit()
+
+	pair, err := svc.CreateOCO(ctx, "user-1", OCORequest{
+		Symbol: "aapl", Quantity: decimal.NewFromInt(5), TakeProfit: decimal.NewFromInt(120), StopLoss: decimal.NewFromInt(85),
+	})
+	if err != nil {
+		t.Fatalf("CreateOCO: %v", err)
+	}
+	if pair.GroupID == "" || pair.TakeProfit.OrderType != data.OrderTypeTakeProfit || pair.StopLoss.OrderType != data.OrderTypeStopLoss {
+		t.Errorf("got %+v", pair)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOrderCreateBracket(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+	svc.maxPending = 3
+	ctx := context.Background()
+
+	// The entry must sit between the exits.
+	var verr *util.ValidationError
+	if _, err := svc.CreateBracket(ctx, "user-1", BracketRequest{
+		Symbol: "AAPL", Quantity: decimal.NewFromInt(5), EntryPrice: decimal.NewFromInt(130),
+		TakeProfit: decimal.NewFromInt(120), StopLoss: decimal.NewFromInt(85),
+	}); !errors.As(err, &verr) || verr.Field != "entry_price" {
+		t.Errorf("entry above take-profit: got %v, want entry_price validation error", err)
+	}
+
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO orders").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "BUY", data.OrderTypeLimit, decimal.NewFromInt(5),
+			decimal.NewFromInt(95), sqlmock.AnyArg(), sqlmock.AnyArg(), data.OrderTIFGTC, sqlmock.AnyArg(),
+			sqlmock.AnyArg(), data.OrderPending).
+		WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+			"ord-entry", "user-1", "AAPL", "BUY", data.OrderTypeLimit, 5, decimal.NewFromInt(95),
+			"PENDING", time.Now(), nil, nil, nil, nil, nil, nil, "GTC", nil, nil))
+	for _, leg := range []struct{ orderType, price string }{
+		{data.OrderTypeTakeProfit, "120"}, {data.OrderTypeStopLoss, "85"},
+	} {
+		mock.ExpectQuery("INSERT INTO orders").
+			WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "SELL", leg.orderType, decimal.NewFromInt(5),
+				decimal.RequireFromString(leg.price), sqlmock.AnyArg(), sqlmock.AnyArg(), data.OrderTIFGTC, sqlmock.AnyArg(),
+				"ord-entry", data.OrderWaiting).
+			WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+				"ord-"+leg.orderType, "user-1", "AAPL", "SELL", leg.orderType, 5, decimal.RequireFromString(leg.price),
+				"WAITING", time.Now(), nil, nil, nil, nil, nil, "grp-1", "GTC", nil, "ord-entry"))
+	}
+	mock.ExpectCommit()
+
+	bracket, err := svc.CreateBracket(ctx, "user-1", BracketRequest{
+		Symbol: "aapl", Quantity: decimal.NewFromInt(5), EntryPrice: decimal.NewFromInt(95),
+		TakeProfit: decimal.NewFromInt(120), StopLoss: decimal.NewFromInt(85),
+	})
+	if err != nil {
+		t.Fatalf("CreateBracket: %v", err)
+	}
+	if bracket.Entry.ID != "ord-entry" || bracket.GroupID == "" ||
+		bracket.TakeProfit.ParentOrderID != "ord-entry" || bracket.StopLoss.Status != data.OrderWaiting {
+		t.Errorf("got %+v", bracket)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+
+	// A bracket counts three orders against the cap.
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	var limitErr *OrderLimitError
+	if _, err := svc.CreateBracket(ctx, "user-1", BracketRequest{
+		Symbol: "AAPL", Quantity: decimal.NewFromInt(5), EntryType: "market",
+		TakeProfit: decimal.NewFromInt(120), StopLoss: decimal.NewFromInt(85),
+	}); !errors.As(err, &limitErr) {
+		t.Errorf("over the cap: got %v, want OrderLimitError", err)
+	}
+}
+
+func TestOrderExpiry(t *testing.T) {
+	svc, _ := newOrderService(t, decimal.NewFromInt(100))
+	svc.SetTimeInForce(newCalendar(t), 90)
+	ny, _ := time.LoadLocation("America/New_York")
+	london, _ := time.LoadLocation("Europe/London")
+	// Friday October 16th 2026.
+	during := time.Date(2026, time.October, 16, 11, 0, 0, 0, ny)
+	after := time.Date(2026, time.October, 16, 17, 0, 0, 0, ny)
+	later := during.AddDate(0, 0, 30)
+	tooLate := during.AddDate(0, 0, 91)
+
+	cases := []struct {
+		name, tif string
+		symbol    string
+		now       time.Time
+		expiresAt *time.Time
+		wantTIF   string
+		want      time.Time
+		field     string
+	}{
+		{name: "day during session", tif: "day", now: during, wantTIF: "DAY", want: time.Date(2026, time.October, 16, 16, 0, 0, 0, ny)},
+		{name: "day after close", tif: "DAY", now: after, wantTIF: "DAY", want: time.Date(2026, time.October, 19, 16, 0, 0, 0, ny)},
+		{name: "day in london", tif: "DAY", symbol: "VOD.XLON", now: during, wantTIF: "DAY", want: time.Date(2026, time.October, 16, 16, 30, 0, 0, london)},
+		{name: "day with expiry", tif: "DAY", now: during, expiresAt: &later, field: "expires_at"},
+		{name: "gtc default", now: during, wantTIF: "GTC", want: during.AddDate(0, 0, 90)},
+		{name: "gtc expiry", tif: "GTC", now: during, expiresAt: &later, wantTIF: "GTC", want: later},
+		{name: "gtc past cap", tif: "GTC", now: during, expiresAt: &tooLate, field: "expires_at"},
+		{name: "unknown", tif: "IOC", now: during, field: "time_in_force"},
+	}
+	for _, tc := range cases {
+		svc.now = func() time.Time { return tc.now }
+		tif, expiresAt, err := svc.expiry(tc.symbol, tc.tif, tc.expiresAt)
+		if tc.field != "" {
+			var verr *util.ValidationError
+			if !errors.As(err, &verr) || verr.Field != tc.field {
+				t.Errorf("%s: got %v, want validation error on %q", tc.name, err, tc.field)
+			}
+			continue
+		}
+		if err != nil || tif != tc.wantTIF || expiresAt == nil || !expiresAt.Equal(tc.want) {
+			t.Errorf("%s: got (%q, %v, %v), want (%q, %v)", tc.name, tif, expiresAt, err, tc.wantTIF, tc.want)
+		}
+	}
+}
+
+func TestConditionMet(t *testing.T) {
+	cases := []struct {
+		side, orderType, trigger, price string
+		want                            bool
+	}{
+		{"SELL", data.OrderTypeStopLoss, "95", "96", false},
+		{"SELL", data.OrderTypeStopLoss, "95", "95", true},
+		{"SELL", data.OrderTypeStopLoss, "95", "80", true},
+		{"SELL", data.OrderTypeTakeProfit, "120", "119.99", false},
+		{"SELL", data.OrderTypeTakeProfit, "120", "120", true},
+		{"SELL", data.OrderTypeTakeProfit, "120", "130", true},
+		{"BUY", data.OrderTypeLimit, "100", "100.01", false},
+		{"BUY", data.OrderTypeLimit, "100", "99", true},
+		{"SELL", data.OrderTypeLimit, "100", "99", false},
+		{"SELL", data.OrderTypeLimit, "100", "101", true},
+		{"BUY", data.OrderTypeStop, "100", "99", false},
+		{"BUY", data.OrderTypeStop, "100", "100", true},
+		{"SELL", data.OrderTypeStop, "100", "101", false},
+		{"SELL", data.OrderTypeStop, "100", "100", true},
+		{"BUY", data.OrderTypeMarket, "", "100", true},
+		{"SELL", data.OrderTypeMarket, "", "0.01", true},
+	}
+	for _, tc := range cases {
+		order := &data.Order{Side: tc.side, OrderType: tc.orderType}
+		if tc.trigger != "" {
+			trigger := decimal.RequireFromString(tc.trigger)
+			order.TriggerPrice = &trigger
+		}
+		if got := conditionMet(order, decimal.RequireFromString(tc.price)); got != tc.want {
+			t.Errorf("%s %s trigger %s at %s: got %v, want %v", tc.side, tc.orderType, tc.trigger, tc.price, got, tc.want)
+		}
+	}
+}
+
+func TestCheckOrders_FillsStopLoss(t *testing.T) {
+	price := decimal.NewFromInt(90)
+	svc, mock := newOrderService(t, price)
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("SELL", data.OrderTypeStopLoss, 5, "95"))
+
+	mock.ExpectBegin()
+	// The order is claimed before the holding is touched.
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), price).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
+			"port-1", "user-1", "AAPL", 10, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now(),
+		))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(1000)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WithArgs(decimal.NewFromInt(1450), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "SELL", decimal.NewFromInt(5), price, "COMPLETED", sqlmock.AnyArg(), data.OrderTypeStopLoss, decimal.Zero).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE portfolio SET quantity").
+		WithArgs(decimal.NewFromInt(5), "user-1", "AAPL").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT COALESCE\\(s.cost_basis_method, 'AVERAGE'\\) FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"cost_basis_method"}).AddRow(data.CostBasisFIFO))
+	mock.ExpectQuery("FROM tax_lots").WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "symbol", "trade_id", "quantity", "remaining", "price", "acquired_at"}).
+			AddRow("lot-1", "user-1", "AAPL", "trade-0", 10, 10, decimal.NewFromInt(80), time.Now()))
+	mock.ExpectExec("UPDATE tax_lots SET remaining").WithArgs("lot-1", decimal.NewFromInt(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// FIFO realizes against the lot's 80, not the holding's average 100.
+	mock.ExpectQuery("INSERT INTO lot_disposals").
+		WithArgs(sqlmock.AnyArg(), "user-1", sqlmock.AnyArg(), "lot-1", "AAPL", decimal.NewFromInt(5),
+			decimal.NewFromInt(80), price, decimal.NewFromInt(50), data.CostBasisFIFO).
+		WillReturnRows(sqlmock.NewRows([]string{"disposed_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (1, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_OCOFillCancelsLinked(t *testing.T) {
+	price := decimal.NewFromInt(125)
+	svc, mock := newOrderService(t, price)
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+			"ord-1", "user-1", "AAPL", "SELL", data.OrderTypeTakeProfit, 5, decimal.NewFromInt(120), "PENDING",
+			time.Now(), nil, nil, nil, nil, nil, "grp-1", "GTC", nil, nil))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("FOR UPDATE").WithArgs("grp-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("ord-1").AddRow("ord-2"))
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), price).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE orders SET status = 'CANCELLED'").WithArgs("grp-1", "ord-1").
+		WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+			"ord-2", "user-1", "AAPL", "SELL", data.OrderTypeStopLoss, 5, decimal.NewFromInt(85), "CANCELLED",
+			time.Now(), nil, time.Now(), nil, nil, nil, "grp-1", "GTC", nil, nil))
+	// The fill's own failure rolls the claim and the cancellation back
+	// together.
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_ConditionNotMet(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(90))
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("SELL", data.OrderTypeTakeProfit, 5, "120"))
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_OnlyCryptoFillsWhileMarketClosed(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(90))
+	cal := newCalendar(t)
+	hours := NewMarketHours(cal, nil)
+	hours.now = func() time.Time { return ny(cal, 2026, time.March, 7, 12, 0) } // Saturday
+	svc.SetMarketHours(hours)
+
+	// Expiry still runs and symbols are listed, but stocks are not quoted.
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol FROM orders").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL").AddRow("TSLA"))
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_InsufficientSharesMarksFailed(t *testing.T) {
+	price := decimal.NewFromInt(90)
+	svc, mock := newOrderService(t, price)
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("SELL", data.OrderTypeStopLoss, 5, "95"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), price).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow(
+			"port-1", "user-1", "AAPL", 2, decimal.NewFromInt(100), decimal.Zero, time.Now(), time.Now(),
+		))
+	mock.ExpectRollback()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", "insufficient shares").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// rejectTrades is a PreTradeCheck that rejects every trade with err.
+type rejectTrades struct{ err error }
+
+func (c rejectTrades) CheckTrade(context.Context, TradeIntent) error { return c.err }
+
+func TestCheckOrders_PreTradeRejection(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		err    error
+		reason string // "" = stays PENDING
+	}{
+		{"halt is retried", &TradingHaltedError{Symbol: "AAPL"}, ""},
+		{"restricted fails", &SymbolRestrictedError{Reason: "price below minimum"}, "symbol restricted"},
+		{"holdings limit fails", &HoldingsLimitError{Limit: 10}, "holdings limit"},
+		{"pdt fails", &PatternDayTraderError{MaxDayTrades: 3}, "pattern day trader"},
+		{"daily limit fails", &DailyTradeLimitError{Limit: 20}, "daily trade limit"},
+		{"unverified email fails", &VerificationRequiredError{TradeCap: 10}, "email unverified"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, mock := newOrderService(t, decimal.NewFromInt(100))
+			svc.investments.checks = append(svc.investments.checks, rejectTrades{tc.err})
+
+			expectNoneExpired(mock)
+			mock.ExpectQuery("SELECT DISTINCT symbol").
+				WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+			mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+				WillReturnRows(pendingOrderRow("BUY", data.OrderTypeLimit, 5, "110"))
+			if tc.reason != "" {
+				mock.ExpectExec("UPDATE orders").
+					WithArgs("ord-1", tc.reason).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			n, err := svc.CheckOrders(context.Background())
+			if err != nil || n != 0 {
+				t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("unmet expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCheckOrders_AlreadyClaimedIsSkipped(t *testing.T) {
+	price := decimal.NewFromInt(90)
+	svc, mock := newOrderService(t, price)
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("SELL", data.OrderTypeStopLoss, 5, "95"))
+
+	// Cancelled (or filled by another instance) after it was listed.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), price).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_FillsLimitBuy(t *testing.T) {
+	price := decimal.NewFromInt(95)
+	svc, mock := newOrderService(t, price)
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("BUY", data.OrderTypeLimit, 4, "100"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), price).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(1000)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WithArgs(decimal.NewFromInt(620), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "BUY", decimal.NewFromInt(4), price, "COMPLETED", sqlmock.AnyArg(), data.OrderTypeLimit, decimal.Zero).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectExec("INSERT INTO portfolio").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", decimal.NewFromInt(4), price).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO tax_lots").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", sqlmock.AnyArg(), decimal.NewFromInt(4), price).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (1, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// With a 2% spread the buy would fill at 100.98; the limit caps it at 100.
+func TestCheckOrders_LimitCapsSlippage(t *testing.T) {
+	quote, limit := decimal.NewFromInt(99), decimal.NewFromInt(100)
+	svc, mock := newOrderService(t, quote)
+	svc.investments.SetSlippagePct(decimal.NewFromInt(2))
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("BUY", data.OrderTypeLimit, 4, "100"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), limit).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(1000)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WithArgs(decimal.NewFromInt(600), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "BUY", decimal.NewFromInt(4), limit, "COMPLETED", sqlmock.AnyArg(), data.OrderTypeLimit, decimal.NewFromInt(1)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectExec("INSERT INTO portfolio").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", decimal.NewFromInt(4), limit).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO tax_lots").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", sqlmock.AnyArg(), decimal.NewFromInt(4), limit).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (1, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_InsufficientFundsMarksFailed(t *testing.T) {
+	price := decimal.NewFromInt(95)
+	svc, mock := newOrderService(t, price)
+
+	expectNoneExpired(mock)
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}).AddRow("AAPL"))
+	mock.ExpectQuery("FROM orders").WithArgs("AAPL").
+		WillReturnRows(pendingOrderRow("BUY", data.OrderTypeLimit, 4, "100"))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", sqlmock.AnyArg(), price).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(100)))
+	mock.ExpectRollback()
+	mock.ExpectExec("UPDATE orders").
+		WithArgs("ord-1", "insufficient funds").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCheckOrders_ExpiresBeforeFilling(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(90))
+	now := time.Date(2026, 3, 2, 21, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mock.ExpectQuery("UPDATE orders SET status = 'EXPIRED'").
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows(orderCols).AddRow(
+			"ord-1", "user-1", "AAPL", "BUY", "LIMIT", 5, decimal.NewFromInt(85), "EXPIRED",
+			now.Add(-time.Hour), now, now, nil, nil, nil, nil, "GTC", nil, nil))
+	mock.ExpectQuery("SELECT DISTINCT symbol").
+		WillReturnRows(sqlmock.NewRows([]string{"symbol"}))
+
+	n, err := svc.CheckOrders(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("CheckOrders: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOrderWait(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+	svc.SetWaitLimit(10 * time.Second)
+	ctx := context.Background()
+
+	for _, timeout := range []time.Duration{time.Millisecond, 11 * time.Second} {
+		var verr *util.ValidationError
+		if _, err := svc.Wait(ctx, "user-1", "ord-1", timeout); !errors.As(err, &verr) || verr.Field != "timeout" {
+			t.Errorf("%v: got %v, want a timeout validation error", timeout, err)
+		}
+	}
+
+	filled := sqlmock.NewRows(orderCols).AddRow(
+		"ord-1", "user-1", "AAPL", "BUY", "LIMIT", 1, decimal.NewFromInt(90), "FILLED",
+		time.Now(), nil, time.Now(), "trade-1", decimal.NewFromInt(90), nil, nil, "GTC", nil, nil,
+	)
+	mock.ExpectQuery("SELECT .+ FROM orders WHERE id = \\$1").
+		WithArgs("ord-1", "user-1").
+		WillReturnRows(pendingOrderRow("BUY", "LIMIT", 1, "90"))
+	mock.ExpectQuery("SELECT .+ FROM orders WHERE id = \\$1").
+		WithArgs("ord-1", "user-1").
+		WillReturnRows(filled)
+
+	// The wait subscribes before its first read, so a publish at any point
+	// after that wakes it; keep publishing until it has returned.
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(10 * time.Millisecond):
+				svc.events.publish("ord-1")
+			}
+		}
+	}()
+	start := time.Now()
+	order, err := svc.Wait(ctx, "user-1", "ord-1", 0)
+	close(done)
+	if err != nil || order.Status != data.OrderFilled {
+		t.Fatalf("got %+v, %v; want the filled order", order, err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("wait took %v; the publish should have woken it", elapsed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestOrderHolds(t *testing.T) {
+	svc, mock := newOrderService(t, decimal.NewFromInt(100))
+	now := time.Now()
+	mock.ExpectQuery("FROM orders").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(orderCols).
+			AddRow("ord-1", "user-1", "AAPL", "BUY", data.OrderTypeLimit, 5, decimal.RequireFromString("90.5"), "PENDING",
+				now, nil, nil, nil, nil, nil, nil, "GTC", nil, nil).
+			// Queued market buys hold at the quote, fetched once per symbol.
+			AddRow("ord-2", "user-1", "AAPL", "BUY", data.OrderTypeMarket, 2, nil, "PENDING",
+				now, nil, nil, nil, nil, nil, nil, "GTC", nil, nil).
+			AddRow("ord-6", "user-1", "AAPL", "BUY", data.OrderTypeMarket, 1, nil, "PENDING",
+				now, nil, nil, nil, nil, nil, nil, "GTC", nil, nil).
+			AddRow("ord-3", "user-1", "MSFT", "SELL", data.OrderTypeLimit, 4, decimal.NewFromInt(400), "PENDING",
+				now, nil, nil, nil, nil, nil, nil, "GTC", nil, nil).
+			// Both halves of an OCO pair sell the same shares.
+			AddRow("ord-4", "user-1", "MSFT", "SELL", data.OrderTypeTakeProfit, 3, decimal.NewFromInt(450), "PENDING",
+				now, nil, nil, nil, nil, nil, "grp-1", "GTC", nil, nil).
+			AddRow("ord-5", "user-1", "MSFT", "SELL", data.OrderTypeStopLoss, 3, decimal.NewFromInt(350), "PENDING",
+				now, nil, nil, nil, nil, nil, "grp-1", "GTC", nil, nil))
+
+	holds, err := svc.Holds(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Holds: %v", err)
+	}
+	if !holds.Cash.Equal(decimal.RequireFromString("752.5")) || holds.Partial {
+		t.Errorf("cash on hold: got %s (partial %v), want 752.5", holds.Cash, holds.Partial)
+	}
+	if calls := svc.investments.marketService.(*mockMarket).stockCalls; calls != 1 {
+		t.Errorf("quoted %d times, want once for AAPL", calls)
+	}
+	if len(holds.Shares) != 1 || !holds.Shares["MSFT"].Equal(decimal.NewFromInt(7)) {
+		t.Errorf("shares on hold: got %v, want MSFT 7", holds.Shares)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,342 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"papertrader/internal/data"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+)
+
+const (
+	// PasskeyCeremonyTTL bounds the time between a begin and finish request,
+	// i.e. how long the user has to answer the authenticator prompt.
+	PasskeyCeremonyTTL = 5 * time.Minute
+
+	maxPasskeysPerUser = 10
+	maxPasskeyNameLen  = 64
+)
+
+// PasskeyConfig identifies the relying party to authenticators. RPID must be
+// the frontend's host or a parent domain of it; Origins are the exact origins
+// the browser reports (scheme://host[:port]).
+type PasskeyConfig struct {
+	RPID          string
+	RPDisplayName string
+	Origins       []string
+}
+
+// PasskeyService registers WebAuthn credentials (passkeys) on existing
+// accounts and signs users in with them. A passkey login produces the same
+// session token as a password login.
+//
+// Ceremony state (the challenge) travels to the client in a short-lived
+// signed token and comes back on the finish request. Login challenges are
+// also recorded server-side and deleted on first use, so a captured
+// ceremony token and assertion can't be replayed while the token is valid.
+type PasskeyService struct {
+	users      *data.UserStore
+	passkeys   *data.PasskeyStore
+	jwtService *JWTService
+	webauthn   *webauthn.WebAuthn
+	logins     LoginObserver
+	lockout    *LockoutService
+}
+
+// NewPasskeyService builds the service. logins may be nil.
+func NewPasskeyService(users *data.UserStore, passkeys *data.PasskeyStore, jwtService *JWTService, cfg PasskeyConfig, logins LoginObserver) (*PasskeyService, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.RPID,
+		RPDisplayName: cfg.RPDisplayName,
+		RPOrigins:     cfg.Origins,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("webauthn config: %w", err)
+	}
+	return &PasskeyService{
+		users:      users,
+		passkeys:   passkeys,
+		jwtService: jwtService,
+		webauthn:   w,
+		logins:     logins,
+	}, nil
+}
+
+// SetLockout refuses passkey logins to accounts an admin has locked.
+func (s *PasskeyService) SetLockout(lockout *LockoutService) {
+	s.lockout = lockout
+}
+
+// BeginRegistration starts adding a passkey to userID's account. It returns
+// the options for navigator.credentials.create() and the ceremony token to
+// send back to FinishRegistration.
+func (s *PasskeyService) BeginRegistration(ctx context.Context, userID string) (*protocol.CredentialCreation, string, time.Time, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if len(user.credentials) >= maxPasskeysPerUser {
+		return nil, "", time.Time{}, &PasskeyRegistrationError{
+			Reason: fmt.Sprintf("You can register at most %d passkeys", maxPasskeysPerUser),
+		}
+	}
+
+	exclude := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, c := range user.credentials {
+		exclude = append(exclude, c.Descriptor())
+	}
+	// Resident keys make the passkey discoverable, so login needs no email.
+	options, session, err := s.webauthn.BeginRegistration(user,
+		webauthn.WithExclusions(exclude),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+	)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	token, expiresAt, err := s.ceremonyToken(userID, session)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	return options, token, expiresAt, nil
+}
+
+// FinishRegistration verifies the authenticator's response (the JSON-encoded
+// PublicKeyCredential from navigator.credentials.create()) and stores the
+// new passkey under name.
+func (s *PasskeyService) FinishRegistration(ctx context.Context, userID, ceremony, name string, response io.Reader) (*data.Passkey, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxPasskeyNameLen {
+		return nil, &PasskeyRegistrationError{Reason: fmt.Sprintf("Passkey name must be at most %d characters", maxPasskeyNameLen)}
+	}
+
+	session, err := s.ceremony(ceremony, userID)
+	if err != nil {
+		return nil, &PasskeyRegistrationError{Reason: "Passkey registration expired. Please try again"}
+	}
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(response)
+	if err != nil {
+		return nil, &PasskeyRegistrationError{Reason: "Invalid passkey response"}
+	}
+	credential, err := s.webauthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		slog.Info("passkey registration rejected", "user_id", userID, "err", err, "component", "passkey")
+		return nil, &PasskeyRegistrationError{Reason: "Passkey could not be verified"}
+	}
+
+	encoded, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = fmt.Sprintf("Passkey %d", len(user.credentials)+1)
+	}
+	passkey := &data.Passkey{
+		ID:         base64.RawURLEncoding.EncodeToString(credential.ID),
+		UserID:     userID,
+		Name:       name,
+		Credential: encoded,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.passkeys.Create(ctx, passkey); err != nil {
+		if errors.Is(err, data.ErrPasskeyExists) {
+			return nil, &PasskeyRegistrationError{Reason: "This passkey is already registered"}
+		}
+		return nil, err
+	}
+	slog.Info("passkey registered", "user_id", userID, "component", "passkey")
+	return passkey, nil
+}
+
+// BeginLogin starts a passkey login. No account is named: the authenticator
+// offers the user's discoverable passkeys for this site.
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, time.Time, error) {
+	options, session, err := s.webauthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	token, expiresAt, err := s.ceremonyToken("", session)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if err := s.passkeys.CreateLoginChallenge(ctx, session.Challenge, expiresAt); err != nil {
+		return nil, "", time.Time{}, err
+	}
+	return options, token, expiresAt, nil
+}
+
+// FinishLogin verifies the assertion (the JSON-encoded PublicKeyCredential
+// from navigator.credentials.get()) and issues a session token. The
+// ceremony's challenge is spent before the assertion is checked, so each
+// ceremony token gets exactly one attempt.
+func (s *PasskeyService) FinishLogin(ctx context.Context, ceremony string, response io.Reader) (*data.User, string, error) {
+	session, err := s.ceremony(ceremony, "")
+	if err != nil {
+		return nil, "", &InvalidPasskeyError{}
+	}
+	fresh, err := s.passkeys.ConsumeLoginChallenge(ctx, session.Challenge)
+	if err != nil {
+		return nil, "", err
+	}
+	if !fresh {
+		return nil, "", &InvalidPasskeyError{}
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(response)
+	if err != nil {
+		return nil, "", &InvalidPasskeyError{}
+	}
+
+	var user *passkeyUser
+	lookup := func(rawID, userHandle []byte) (webauthn.User, error) {
+		passkey, err := s.passkeys.GetByID(ctx, base64.RawURLEncoding.EncodeToString(rawID))
+		if err != nil {
+			return nil, err
+		}
+		// The user handle is the account ID we registered the passkey
+		// under; a mismatch means the authenticator is confused or lying.
+		if passkey.UserID != string(userHandle) {
+			return nil, errors.New("user handle does not match credential owner")
+		}
+		user, err = s.loadUser(ctx, passkey.UserID)
+		return user, err
+	}
+	credential, err := s.webauthn.ValidateDiscoverableLogin(lookup, *session, parsed)
+	if err != nil {
+		slog.Info("passkey login rejected", "err", err, "component", "passkey")
+		return nil, "", &InvalidPasskeyError{}
+	}
+	// A sign counter that didn't advance suggests the private key was copied
+	// off the authenticator. Synced passkeys always report 0, which the
+	// library does not flag.
+	if credential.Authenticator.CloneWarning {
+		slog.Warn("passkey clone warning; login refused", "user_id", user.user.ID, "component", "passkey")
+		return nil, "", &InvalidPasskeyError{}
+	}
+
+	if encoded, err := json.Marshal(credential); err == nil {
+		id := base64.RawURLEncoding.EncodeToString(credential.ID)
+		if err := s.passkeys.RecordUse(ctx, id, encoded, time.Now()); err != nil {
+			slog.Warn("failed to record passkey use", "user_id", user.user.ID, "err", err, "component", "passkey")
+		}
+	}
+
+	if err := s.lockout.CheckAdminLock(ctx, user.user.ID); err != nil {
+		return nil, "", err
+	}
+	token, err := s.jwtService.GenerateToken(user.user.ID, user.user.Email, user.user.Role)
+	if err != nil {
+		return nil, "", &TokenGenerationError{}
+	}
+	if s.logins != nil {
+		s.logins.LoginSucceeded(ctx, user.user)
+	}
+	return user.user, token, nil
+}
+
+// List returns the user's passkeys.
+func (s *PasskeyService) List(ctx context.Context, userID string) ([]data.Passkey, error) {
+	return s.passkeys.ListByUser(ctx, userID)
+}
+
+// Delete removes one of the user's passkeys.
+func (s *PasskeyService) Delete(ctx context.Context, userID, id string) error {
+	err := s.passkeys.Delete(ctx, userID, id)
+	if errors.Is(err, data.ErrPasskeyNotFound) {
+		return &PasskeyNotFoundError{}
+	}
+	if err == nil {
+		slog.Info("passkey removed", "user_id", userID, "component", "passkey")
+	}
+	return err
+}
+
+func (s *PasskeyService) ceremonyToken(userID string, session *webauthn.SessionData) (string, time.Time, error) {
+	encoded, err := json.Marshal(session)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	token, expiresAt, err := s.jwtService.GenerateWebAuthnToken(userID, encoded, PasskeyCeremonyTTL)
+	if err != nil {
+		return "", time.Time{}, &TokenGenerationError{}
+	}
+	return token, expiresAt, nil
+}
+
+// ceremony unwraps a ceremony token, checking it was issued for userID (""
+// for login), so one user's registration can't be finished by another.
+func (s *PasskeyService) ceremony(token, userID string) (*webauthn.SessionData, error) {
+	claims, err := s.jwtService.ValidateWebAuthnToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != userID {
+		return nil, errTokenScope
+	}
+	var session webauthn.SessionData
+	if err := json.Unmarshal(claims.Ceremony, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (s *PasskeyService) loadUser(ctx context.Context, userID string) (*passkeyUser, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, &UserNotFoundError{}
+	}
+	stored, err := s.passkeys.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, p := range stored {
+		var c webauthn.Credential
+		if err := json.Unmarshal(p.Credential, &c); err != nil {
+			slog.Error("corrupt passkey credential", "passkey_id", p.ID, "err", err, "component", "passkey")
+			continue
+		}
+		credentials = append(credentials, c)
+	}
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+// passkeyUser adapts data.User to webauthn.User. The WebAuthn user handle is
+// the account ID, so it never reveals the email.
+type passkeyUser struct {
+	user        *data.User
+	credentials []webauthn.Credential
+}
+
+func (u *passkeyUser) WebAuthnID() []byte { return []byte(u.user.ID) }
+
+func (u *passkeyUser) WebAuthnName() string {
+	if u.user.Email != "" {
+		return u.user.Email
+	}
+	if u.user.Username != "" {
+		return u.user.Username
+	}
+	return u.user.ID
+}
+
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	if u.user.Username != "" {
+		return u.user.Username
+	}
+	return u.WebAuthnName()
+}
+
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"papertrader/internal/data"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+)
+
+var passkeyCols = []string{"id", "user_id", "name", "credential", "created_at", "last_used_at"}
+
+func newPasskeyService(t *testing.T) (*PasskeyService, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	svc, err := NewPasskeyService(data.NewUserStore(db), data.NewPasskeyStore(db),
+		NewJWTService("testsecretkey-32-chars-long-xxxxx"),
+		PasskeyConfig{RPID: "localhost", RPDisplayName: "PaperTrader", Origins: []string{"http://localhost:3000"}}, nil)
+	if err != nil {
+		t.Fatalf("NewPasskeyService: %v", err)
+	}
+	return svc, mock, func() { db.Close() }
+}
+
+func expectPasskeyUser(mock sqlmock.Sqlmock, userID string, passkeys int) {
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WithArgs(userID).
+		WillReturnRows(userRow(data.User{ID: userID, Email: userID + "@example.com", Password: "hash", Balance: decimal.NewFromInt(10000), EmailVerified: true}))
+	rows := sqlmock.NewRows(passkeyCols)
+	for i := 0; i < passkeys; i++ {
+		rows.AddRow(fmt.Sprintf("cred-%d", i), userID, "", []byte(`{"id":"AQID"}`), time.Now(), nil)
+	}
+	mock.ExpectQuery("FROM webauthn_credentials").WithArgs(userID).WillReturnRows(rows)
+}
+
+func TestPasskeyBeginRegistration_Limit(t *testing.T) {
+	svc, mock, cleanup := newPasskeyService(t)
+	defer cleanup()
+
+	expectPasskeyUser(mock, "user-alice", maxPasskeysPerUser)
+	_, _, _, err := svc.BeginRegistration(context.Background(), "user-alice")
+	var regErr *PasskeyRegistrationError
+	if !errors.As(err, &regErr) {
+		t.Fatalf("expected PasskeyRegistrationError, got %v", err)
+	}
+}
+
+func TestPasskeyFinishRegistration_CeremonyBoundToUser(t *testing.T) {
+	svc, mock, cleanup := newPasskeyService(t)
+	defer cleanup()
+
+	expectPasskeyUser(mock, "user-alice", 0)
+	_, ceremony, _, err := svc.BeginRegistration(context.Background(), "user-alice")
+	if err != nil {
+		t.Fatalf("BeginRegistration: %v", err)
+	}
+
+	_, err = svc.FinishRegistration(context.Background(), "user-bob", ceremony, "", strings.NewReader(`{}`))
+	var regErr *PasskeyRegistrationError
+	if !errors.As(err, &regErr) {
+		t.Errorf("another user's ceremony must be rejected, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestPasskeyFinishRegistration_NameTooLong(t *testing.T) {
+	svc, _, cleanup := newPasskeyService(t)
+	defer cleanup()
+
+	_, err := svc.FinishRegistration(context.Background(), "user-alice", "", strings.Repeat("x", maxPasskeyNameLen+1), strings.NewReader(`{}`))
+	var regErr *PasskeyRegistrationError
+	if !errors.As(err, &regErr) {
+		t.Errorf("expected PasskeyRegistrationError, got %v", err)
+	}
+}
+
+func TestPasskeyFinishLogin_RejectsOtherTokens(t *testing.T) {
+	svc, _, cleanup := newPasskeyService(t)
+	defer cleanup()
+
+	session, _ := svc.jwtService.GenerateToken("user-alice", "alice@example.com", data.RoleUser)
+	registration, _, _ := svc.jwtService.GenerateWebAuthnToken("user-alice", []byte(`{}`), time.Minute)
+
+	for name, token := range map[string]string{"empty": "", "session": session, "registration": registration} {
+		_, _, err := svc.FinishLogin(context.Background(), token, strings.NewReader(`{}`))
+		var invalid *InvalidPasskeyError
+		if !errors.As(err, &invalid) {
+			t.Errorf("%s token: expected InvalidPasskeyError, got %v", name, err)
+		}
+	}
+}
+
+func TestPasskeyFinishLogin_ChallengeUsedOnce(t *testing.T) {
+	svc, mock, cleanup := newPasskeyService(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM passkey_login_challenges WHERE expires_at").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO passkey_login_challenges").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, ceremony, _, err := svc.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+
+	// The first attempt spends the challenge even though its assertion is
+	// malformed; the replay finds it gone.
+	mock.ExpectExec("DELETE FROM passkey_login_challenges WHERE challenge").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM passkey_login_challenges WHERE challenge").WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < 2; i++ {
+		_, _, err := svc.FinishLogin(context.Background(), ceremony, strings.NewReader(`{}`))
+		var invalid *InvalidPasskeyError
+		if !errors.As(err, &invalid) {
+			t.Errorf("attempt %d: expected InvalidPasskeyError, got %v", i+1, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestPasskeyDelete_NotFound(t *testing.T) {
+	svc, mock, cleanup := newPasskeyService(t)
+	defer cleanup()
+
+	mock.ExpectExec("DELETE FROM webauthn_credentials").WillReturnResult(sqlmock.NewResult(0, 0))
+	err := svc.Delete(context.Background(), "user-alice", "cred-x")
+	var notFound *PasskeyNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Errorf("expected PasskeyNotFoundError, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// SymbolPnL is one symbol's profit and loss. Realized covers sells and
+// covers in the report's range; Unrealized is the open position marked to
+// the latest price, nil when there is no position or no price. AvgPrice is
+// the cost basis of the open shares: the remaining lots' average under FIFO
+// and LIFO, the position's average cost otherwise.
+type SymbolPnL struct {
+	Symbol       string           `json:"symbol"`
+	Quantity     decimal.Decimal  `json:"quantity"` // open position; negative when short
+	AvgPrice     decimal.Decimal  `json:"avg_price"`
+	CurrentPrice decimal.Decimal  `json:"current_price"`
+	Realized     decimal.Decimal  `json:"realized"`
+	Unrealized   *decimal.Decimal `json:"unrealized,omitempty"`
+	// ClosedQuantity is how many shares the realized figure covers.
+	ClosedQuantity decimal.Decimal `json:"closed_quantity"`
+}
+
+// PnLReport is a user's realized and unrealized profit and loss. Partial is
+// set when an open position had no current price, so Unrealized is short
+// by that position.
+type PnLReport struct {
+	Realized   decimal.Decimal `json:"realized"`
+	Unrealized decimal.Decimal `json:"unrealized"`
+	Total      decimal.Decimal `json:"total"`
+	Partial    bool            `json:"partial"`
+	Symbols    []SymbolPnL     `json:"symbols"`
+	From       *time.Time      `json:"from,omitempty"`
+	To         *time.Time      `json:"to,omitempty"`
+	Currency   string          `json:"currency,omitempty"`
+	Method     string          `json:"cost_basis_method"`
+}
+
+// PnLService reports profit and loss: realized gains from the tax lots
+// each sell drew from (replaying the trade ledger at average cost for sells
+// before lots were tracked), unrealized gains from the current holdings.
+type PnLService struct {
+	trades      *data.TradesStore
+	investments *InvestmentService
+	costBasis   *CostBasisService
+}
+
+func NewPnLService(trades *data.TradesStore, investments *InvestmentService, costBasis *CostBasisService) *PnLService {
+	return &PnLService{trades: trades, investments: investments, costBasis: costBasis}
+}
+
+// Report returns userID's P&L. from and to (zero = unbounded; from
+// inclusive, to exclusive) limit which sells and covers count as realized;
+// cost basis always comes from the full history, and unrealized is as of
+// now.
+func (s *PnLService) Report(ctx context.Context, userID string, from, to time.Time) (*PnLReport, error) {
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return nil, &util.ValidationError{Field: "to", Message: "must be after from"}
+	}
+	trades, err := s.trades.GetAllTradesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.investments.GetUserStocks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	lotGains, err := s.costBasis.lots.RealizedBySellTrade(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	lots, err := s.costBasis.Lots(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	bySymbol := realizedPnL(trades, lotGains, from, to)
+	for _, h := range holdings {
+		p, ok := bySymbol[h.Symbol]
+		if !ok {
+			p = &SymbolPnL{Symbol: h.Symbol}
+			bySymbol[h.Symbol] = p
+		}
+		p.Quantity = h.Quantity
+		p.AvgPrice = h.AvgPrice
+		p.CurrentPrice = h.CurrentStockPrice
+		p.Unrealized = h.UnrealizedPnL
+		if lots.Method != data.CostBasisAverage {
+			applyLotBasis(p, lots.Lots)
+		}
+	}
+
+	report := &PnLReport{Realized: decimal.Zero, Unrealized: decimal.Zero, Symbols: make([]SymbolPnL, 0, len(bySymbol)), Method: lots.Method}
+	if !from.IsZero() {
+		report.From = &from
+	}
+	if !to.IsZero() {
+		report.To = &to
+	}
+	for _, p := range bySymbol {
+		report.Realized = report.Realized.Add(p.Realized)
+		if p.Unrealized != nil {
+			report.Unrealized = report.Unrealized.Add(*p.Unrealized)
+		} else if !p.Quantity.IsZero() {
+			report.Partial = true
+		}
+		report.Symbols = append(report.Symbols, *p)
+	}
+	report.Unrealized = report.Unrealized.Round(2)
+	report.Total = report.Realized.Add(report.Unrealized)
+	sort.Slice(report.Symbols, func(i, j int) bool { return report.Symbols[i].Symbol < report.Symbols[j].Symbol })
+	return report, nil
+}
+
+// applyLotBasis re-marks p's open long position against the remaining
+// lots, for FIFO and LIFO users. It leaves p alone when the lots don't add
+// up to the position, which only happens if they have drifted from the
+// portfolio.
+func applyLotBasis(p *SymbolPnL, lots []data.TaxLot) {
+	if !p.Quantity.IsPositive() {
+		return
+	}
+	shares, cost := decimal.Zero, decimal.Zero
+	for _, lot := range lots {
+		if lot.Symbol == p.Symbol {
+			shares = shares.Add(lot.Remaining)
+			cost = cost.Add(lot.Price.Mul(lot.Remaining))
+		}
+	}
+	if !shares.Equal(p.Quantity) {
+		return
+	}
+	p.AvgPrice = cost.Div(shares).Round(4)
+	if p.Unrealized != nil {
+		u := p.CurrentPrice.Mul(shares).Sub(cost).Round(2)
+		p.Unrealized = &u
+	}
+}
+
+// closedTrade is a sell or cover with the gain it realized and how long,
+// on average, the shares it closed had been held.
+type closedTrade struct {
+	data.Trade
+	Gain decimal.Decimal
+	Held time.Duration
+}
+
+// closeTrades replays trades (oldest first) with weighted-average cost, the
+// same basis the portfolio table keeps: a sell realizes (price - average
+// cost) per share and a cover (average short price - price). A sell that
+// drew from tax lots uses the gain recorded in lotGains (by trade ID)
+// instead. Opening times are averaged the same way, weighted by shares.
+func closeTrades(trades []data.Trade, lotGains map[string]decimal.Decimal) []closedTrade {
+	type position struct {
+		long, short         decimal.Decimal
+		longAvg, shortAvg   decimal.Decimal
+		longOpen, shortOpen time.Time
+	}
+	positions := map[string]*position{}
+	out := make([]closedTrade, 0)
+
+	for _, t := range trades {
+		if t.Status != "" && t.Status != "COMPLETED" {
+			continue
+		}
+		pos, ok := positions[t.Symbol]
+		if !ok {
+			pos = &position{}
+			positions[t.Symbol] = pos
+		}
+		c := closedTrade{Trade: t}
+		switch t.Action {
+		case "BUY":
+			pos.longAvg = averageCost(pos.longAvg, pos.long, t.Price, t.Quantity)
+			pos.longOpen = averageTime(pos.longOpen, pos.long, t.ExecutedAt, t.Quantity)
+			pos.long = pos.long.Add(t.Quantity)
+			continue
+		case "SHORT":
+			pos.shortAvg = averageCost(pos.shortAvg, pos.short, t.Price, t.Quantity)
+			pos.shortOpen = averageTime(pos.shortOpen, pos.short, t.ExecutedAt, t.Quantity)
+			pos.short = pos.short.Add(t.Quantity)
+			continue
+		case "SELL":
+			c.Gain = t.Price.Sub(pos.longAvg).Mul(t.Quantity)
+			if g, ok := lotGains[t.ID]; ok {
+				c.Gain = g
+			}
+			c.Held = heldSince(pos.longOpen, t.ExecutedAt)
+			if pos.long = pos.long.Sub(t.Quantity); !pos.long.IsPositive() {
+				pos.long, pos.longAvg, pos.longOpen = decimal.Zero, decimal.Zero, time.Time{}
+			}
+		case "COVER":
+			c.Gain = pos.shortAvg.Sub(t.Price).Mul(t.Quantity)
+			c.Held = heldSince(pos.shortOpen, t.ExecutedAt)
+			if pos.short = pos.short.Sub(t.Quantity); !pos.short.IsPositive() {
+				pos.short, pos.shortAvg, pos.shortOpen = decimal.Zero, decimal.Zero, time.Time{}
+			}
+		default:
+			continue
+		}
+		c.Gain = c.Gain.Round(2)
+		out = append(out, c)
+	}
+	return out
+}
+
+// realizedPnL totals the gains of closeTrades by symbol, counting only
+// closes executed within [from, to). Symbols with nothing realized in the
+// range are left out.
+func realizedPnL(trades []data.Trade, lotGains map[string]decimal.Decimal, from, to time.Time) map[string]*SymbolPnL {
+	out := map[string]*SymbolPnL{}
+	for _, c := range closeTrades(trades, lotGains) {
+		if (!from.IsZero() && c.ExecutedAt.Before(from)) || (!to.IsZero() && !c.ExecutedAt.Before(to)) {
+			continue
+		}
+		p, ok := out[c.Symbol]
+		if !ok {
+			p = &SymbolPnL{Symbol: c.Symbol}
+			out[c.Symbol] = p
+		}
+		p.Realized = p.Realized.Add(c.Gain)
+		p.ClosedQuantity = p.ClosedQuantity.Add(c.Quantity)
+	}
+	return out
+}
+
+// averageCost is the weighted average of qty shares at avg and added
+// shares at price.
+func averageCost(avg, qty, price, added decimal.Decimal) decimal.Decimal {
+	total := qty.Add(added)
+	if !total.IsPositive() {
+		return decimal.Zero
+	}
+	return avg.Mul(qty).Add(price.Mul(added)).Div(total)
+}
+
+// averageTime is the share-weighted average of qty shares opened at avg and
+// added shares opened at at.
+func averageTime(avg time.Time, qty decimal.Decimal, at time.Time, added decimal.Decimal) time.Time {
+	if !qty.IsPositive() || avg.IsZero() {
+		return at
+	}
+	// In float: a long gap times a large share count overflows a Duration.
+	weight := added.Div(qty.Add(added)).InexactFloat64()
+	return avg.Add(time.Duration(float64(at.Sub(avg)) * weight))
+}
+
+// heldSince is how long a position opened at opened had been held at
+// closed, or zero if the opening is unknown.
+func heldSince(opened, closed time.Time) time.Duration {
+	if opened.IsZero() || closed.Before(opened) {
+		return 0
+	}
+	return closed.Sub(opened)
+}
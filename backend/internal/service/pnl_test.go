@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func pnlTrade(action, symbol string, qty int64, price string, at time.Time) data.Trade {
+	return data.Trade{Symbol: symbol, Action: action, Quantity: decimal.NewFromInt(qty), Price: decimal.RequireFromString(price), ExecutedAt: at, Status: "COMPLETED"}
+}
+
+func TestRealizedPnL_AverageCost(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 15, 0, 0, 0, time.UTC) }
+	trades := []data.Trade{
+		pnlTrade("BUY", "AAPL", 10, "100", day(2)),
+		pnlTrade("BUY", "AAPL", 10, "120", day(3)),  // average 110
+		pnlTrade("SELL", "AAPL", 5, "130", day(4)),  // +100
+		pnlTrade("SELL", "AAPL", 15, "100", day(5)), // -150
+		pnlTrade("BUY", "AAPL", 1, "90", day(6)),    // fresh position, average 90
+		pnlTrade("SHORT", "TSLA", 10, "50", day(2)),
+		pnlTrade("COVER", "TSLA", 4, "40", day(6)), // +40
+		{Symbol: "MSFT", Action: "SELL", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromInt(1), ExecutedAt: day(6), Status: "FAILED"},
+	}
+
+	got := realizedPnL(trades, nil, time.Time{}, time.Time{})
+	if len(got) != 2 {
+		t.Fatalf("symbols: got %d, want 2 (%v)", len(got), got)
+	}
+	if p := got["AAPL"]; !p.Realized.Equal(decimal.NewFromInt(-50)) || !p.ClosedQuantity.Equal(decimal.NewFromInt(20)) {
+		t.Errorf("AAPL: got %s over %s shares, want -50 over 20", p.Realized, p.ClosedQuantity)
+	}
+	if p := got["TSLA"]; !p.Realized.Equal(decimal.NewFromInt(40)) || !p.ClosedQuantity.Equal(decimal.NewFromInt(4)) {
+		t.Errorf("TSLA: got %s over %s shares, want 40 over 4", p.Realized, p.ClosedQuantity)
+	}
+
+	// A range still costs closes against the full history.
+	got = realizedPnL(trades, nil, day(5), day(6))
+	if len(got) != 1 || !got["AAPL"].Realized.Equal(decimal.NewFromInt(-150)) {
+		t.Errorf("ranged: got %v, want only AAPL at -150", got)
+	}
+}
+
+func TestRealizedPnL_UsesLotGains(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 15, 0, 0, 0, time.UTC) }
+	sell := pnlTrade("SELL", "AAPL", 5, "130", day(4))
+	sell.ID = "sell-1"
+	trades := []data.Trade{
+		pnlTrade("BUY", "AAPL", 10, "100", day(2)),
+		pnlTrade("BUY", "AAPL", 10, "120", day(3)),
+		sell, // FIFO: 5 shares of the 100 lot, +150
+	}
+
+	got := realizedPnL(trades, map[string]decimal.Decimal{"sell-1": decimal.NewFromInt(150)}, time.Time{}, time.Time{})
+	if p := got["AAPL"]; !p.Realized.Equal(decimal.NewFromInt(150)) || !p.ClosedQuantity.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("AAPL: got %s over %s shares, want 150 over 5", p.Realized, p.ClosedQuantity)
+	}
+}
+
+func TestApplyLotBasis(t *testing.T) {
+	unrealized := decimal.NewFromInt(225) // 15 * (130 - 115) at average cost
+	p := &SymbolPnL{Symbol: "AAPL", Quantity: decimal.NewFromInt(15), AvgPrice: decimal.NewFromInt(115),
+		CurrentPrice: decimal.NewFromInt(130), Unrealized: &unrealized}
+	lots := []data.TaxLot{
+		{Symbol: "AAPL", Remaining: decimal.NewFromInt(5), Price: decimal.NewFromInt(100)},
+		{Symbol: "AAPL", Remaining: decimal.NewFromInt(10), Price: decimal.NewFromInt(120)},
+		{Symbol: "MSFT", Remaining: decimal.NewFromInt(3), Price: decimal.NewFromInt(400)},
+	}
+
+	applyLotBasis(p, lots)
+	if !p.Unrealized.Equal(decimal.NewFromInt(250)) || !p.AvgPrice.Equal(decimal.RequireFromString("113.3333")) {
+		t.Errorf("got unrealized %s at avg %s, want 250 at 113.3333", p.Unrealized, p.AvgPrice)
+	}
+
+	// Lots that don't cover the position leave it at average cost.
+	p = &SymbolPnL{Symbol: "MSFT", Quantity: decimal.NewFromInt(4), AvgPrice: decimal.NewFromInt(390)}
+	applyLotBasis(p, lots)
+	if !p.AvgPrice.Equal(decimal.NewFromInt(390)) {
+		t.Errorf("drifted lots: avg changed to %s", p.AvgPrice)
+	}
+}
+
+func TestClosedTradeStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 15, 0, 0, 0, time.UTC) }
+	trades := []data.Trade{pnlTrade("BUY", "AAPL", 30, "100", day(2))}
+	for i, price := range []string{"110", "120", "90", "80", "70", "100"} { // +50 +100 -50 -100 -150 0
+		trades = append(trades, pnlTrade("SELL", "AAPL", 5, price, day(3+i)))
+	}
+
+	stats := closedTradeStats(closeTrades(trades, nil))
+	if stats.ClosedTrades != 6 || stats.LongestWinningStreak != 2 || stats.LongestLosingStreak != 3 {
+		t.Errorf("streaks: got %+v", stats)
+	}
+	if !stats.BestTrade.Gain.Equal(decimal.NewFromInt(100)) || !stats.BestTrade.ExecutedAt.Equal(day(4)) ||
+		!stats.WorstTrade.Gain.Equal(decimal.NewFromInt(-150)) {
+		t.Errorf("best %+v, worst %+v", stats.BestTrade, stats.WorstTrade)
+	}
+	// Held 1 to 6 days, 5 shares each.
+	if stats.AverageHoldDays == nil || !stats.AverageHoldDays.Equal(decimal.RequireFromString("3.5")) {
+		t.Errorf("average hold: got %v, want 3.5", stats.AverageHoldDays)
+	}
+
+	// Adding to a position moves its opening time to the share-weighted mean.
+	closes := closeTrades([]data.Trade{
+		pnlTrade("BUY", "MSFT", 10, "400", day(2)),
+		pnlTrade("BUY", "MSFT", 10, "400", day(4)),
+		pnlTrade("SELL", "MSFT", 20, "410", day(5)),
+	}, nil)
+	if len(closes) != 1 || closes[0].Held != 48*time.Hour {
+		t.Errorf("held: got %+v, want one close held 48h", closes)
+	}
+
+	if empty := closedTradeStats(nil); empty.BestTrade != nil || empty.AverageHoldDays != nil {
+		t.Errorf("no closes: got %+v", empty)
+	}
+}
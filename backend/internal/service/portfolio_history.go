@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// History ranges accepted by PortfolioHistoryService.History, as months back.
+var portfolioHistoryRanges = map[string]int{"1M": 1, "3M": 3, "1Y": 12}
+
+// PortfolioHistory is a user's daily account value over a range, for an
+// equity-curve chart.
+type PortfolioHistory struct {
+	Range    string                   `json:"range"`
+	Points   []data.PortfolioSnapshot `json:"points"`
+	Currency string                   `json:"currency,omitempty"`
+}
+
+// PortfolioHistoryService records each user's account value at every
+// trading day's close and serves it back as a series.
+type PortfolioHistoryService struct {
+	history *data.PortfolioHistoryStore
+	now     func() time.Time
+}
+
+func NewPortfolioHistoryService(history *data.PortfolioHistoryStore) *PortfolioHistoryService {
+	return &PortfolioHistoryService{history: history, now: time.Now}
+}
+
+// Snapshot is the end-of-day close step that records every user's value at
+// the close of eod's session, valuing holdings at its closes. A holding
+// without a close is valued at cost and the snapshot marked partial, rather
+// than holding up everyone's snapshot. It does nothing once the day has been
+// recorded, and returns the number of snapshots taken. On a rerun it instead
+// revalues the day's snapshots by each corrected close (see
+// PortfolioHistoryStore.Revalue) and returns how many changed; a holding
+// first valued at cost stays that way.
+func (s *PortfolioHistoryService) Snapshot(ctx context.Context, eod *EODClose) (int64, error) {
+	if eod.IsRerun() {
+		return s.revalue(ctx, eod)
+	}
+	done, err := s.history.HasSnapshot(ctx, eod.Session.Close)
+	if err != nil || done {
+		return 0, err
+	}
+	return s.history.Record(ctx, eod.Session.Close, eod.Closes)
+}
+
+func (s *PortfolioHistoryService) revalue(ctx context.Context, eod *EODClose) (int64, error) {
+	var symbols []string
+	var before, after []decimal.Decimal
+	for _, symbol := range slices.Sorted(maps.Keys(eod.Closes)) {
+		old, ok := eod.Previous[symbol]
+		if !ok || old.Equal(eod.Closes[symbol]) {
+			continue
+		}
+		symbols = append(symbols, symbol)
+		before = append(before, old)
+		after = append(after, eod.Closes[symbol])
+	}
+	if len(symbols) == 0 {
+		return 0, nil
+	}
+	return s.history.Revalue(ctx, eod.Session.Close, symbols, before, after)
+}
+
+// History returns userID's daily snapshots over rng: 1M, 3M or 1Y (default
+// 1M). Days before the account existed or before snapshots began are absent.
+func (s *PortfolioHistoryService) History(ctx context.Context, userID, rng string) (*PortfolioHistory, error) {
+	rng = strings.ToUpper(strings.TrimSpace(rng))
+	if rng == "" {
+		rng = "1M"
+	}
+	months, ok := portfolioHistoryRanges[rng]
+	if !ok {
+		return nil, &util.ValidationError{Field: "range", Message: "must be 1M, 3M or 1Y"}
+	}
+	points, err := s.history.Range(ctx, userID, startOfUTCDay(s.now()).AddDate(0, -months, 0))
+	if err != nil {
+		return nil, err
+	}
+	return &PortfolioHistory{Range: rng, Points: points}, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func newPortfolioHistoryService(t *testing.T, now time.Time) (*PortfolioHistoryService, sqlmock.Sqlmock, func()) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	svc := NewPortfolioHistoryService(data.NewPortfolioHistoryStore(db))
+	svc.now = func() time.Time { return now }
+	return svc, mock, func() { db.Close() }
+}
+
+func TestPortfolioSnapshot_RecordsSessionClose(t *testing.T) {
+	svc, mock, cleanup := newPortfolioHistoryService(t, time.Now())
+	defer cleanup()
+
+	session := newCalendar(t).PreviousSession(time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC))
+	eod := &EODClose{Session: session, Closes: map[string]decimal.Decimal{"AAPL": decimal.RequireFromString("231.5")}}
+
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM portfolio_history").
+		WithArgs("2026-03-06").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO portfolio_history").
+		WithArgs("2026-03-06", pq.Array([]string{"AAPL"}), pq.Array([]string{"231.5"}), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 12))
+
+	n, err := svc.Snapshot(context.Background(), eod)
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	if n != 12 {
+		t.Errorf("recorded: got %d, want 12", n)
+	}
+
+	// Already recorded: nothing else runs.
+	mock.ExpectQuery("SELECT EXISTS \\(SELECT 1 FROM portfolio_history").
+		WithArgs("2026-03-06").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	if n, err := svc.Snapshot(context.Background(), eod); n != 0 || err != nil {
+		t.Errorf("second run: got %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPortfolioSnapshot_RerunRevaluesCorrectedCloses(t *testing.T) {
+	svc, mock, cleanup := newPortfolioHistoryService(t, time.Now())
+	defer cleanup()
+
+	session := newCalendar(t).PreviousSession(time.Date(2026, time.March, 7, 0, 0, 0, 0, time.UTC))
+	eod := &EODClose{
+		Session:  session,
+		Closes:   map[string]decimal.Decimal{"AAPL": decimal.RequireFromString("232.75"), "MSFT": decimal.RequireFromString("410")},
+		Previous: map[string]decimal.Decimal{"AAPL": decimal.RequireFromString("2.31"), "MSFT": decimal.RequireFromString("410")},
+	}
+
+	mock.ExpectExec("UPDATE portfolio_history ph").
+		WithArgs("2026-03-06", pq.Array([]string{"AAPL"}), pq.Array([]string{"2.31"}), pq.Array([]string{"232.75"}), session.Close).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := svc.Snapshot(context.Background(), eod)
+	if err != nil || n != 4 {
+		t.Fatalf("Snapshot: got %d, %v", n, err)
+	}
+
+	// Nothing changed: nothing to revalue.
+	eod.Previous = eod.Closes
+	if n, err := svc.Snapshot(context.Background(), eod); n != 0 || err != nil {
+		t.Errorf("unchanged rerun: got %d, %v", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPortfolioHistory_Range(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	svc, mock, cleanup := newPortfolioHistoryService(t, now)
+	defer cleanup()
+
+	mock.ExpectQuery("FROM portfolio_history").
+		WithArgs("user-1", "2026-07-16").
+		WillReturnRows(sqlmock.NewRows([]string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}).
+			AddRow(time.Date(2026, time.July, 16, 0, 0, 0, 0, time.UTC), "4000.00", "6100.25", "10100.25", false, "0"))
+
+	h, err := svc.History(context.Background(), "user-1", "3m")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if h.Range != "3M" || len(h.Points) != 1 || h.Points[0].Date != "2026-07-16" || !h.Points[0].TotalValue.Equal(decimal.RequireFromString("10100.25")) {
+		t.Errorf("history: got %+v", h)
+	}
+
+	var ve *util.ValidationError
+	if _, err := svc.History(context.Background(), "user-1", "5Y"); !errors.As(err, &ve) || ve.Field != "range" {
+		t.Errorf("bad range: expected validation error, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
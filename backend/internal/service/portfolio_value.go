@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// portfolioValueTTL is how long an estimate is reused for the same user. A
+// dashboard polling every few seconds then costs one set of quote lookups
+// per window; a trade by the user drops the estimate at once.
+const portfolioValueTTL = 30 * time.Second
+
+// PortfolioValue is an estimate of a user's account value right now, with
+// holdings marked to the latest quotes. Previous* describe the last daily
+// snapshot dated before the user's current day, in their time zone, and
+// DayChange the move since it, less any bonus cash granted since; all three
+// are nil until there is one. BonusCash is the bonus cash granted so far,
+// part of Cash. AsOf is
+// in the user's time zone. Partial is set when a holding had no quote and
+// was valued at cost, or a pending market buy had none and was left out of
+// CashOnHold. CashOnHold is what the user's pending buys would
+// spend (see OrderHolds); it is part of Cash, so TotalValue includes it and
+// BuyingPower is Cash less it.
+type PortfolioValue struct {
+	Cash             decimal.Decimal  `json:"cash"`
+	CashOnHold       decimal.Decimal  `json:"cash_on_hold"`
+	BuyingPower      decimal.Decimal  `json:"buying_power"`
+	HoldingsValue    decimal.Decimal  `json:"holdings_value"`
+	TotalValue       decimal.Decimal  `json:"total_value"`
+	Partial          bool             `json:"partial"`
+	BonusCash        decimal.Decimal  `json:"bonus_cash"`
+	PreviousDate     string           `json:"previous_date,omitempty"` // YYYY-MM-DD
+	PreviousValue    *decimal.Decimal `json:"previous_value,omitempty"`
+	DayChange        *decimal.Decimal `json:"day_change,omitempty"`
+	DayChangePercent *decimal.Decimal `json:"day_change_percent,omitempty"` // nil when the previous value was not positive
+	AsOf             time.Time        `json:"as_of"`
+	Currency         string           `json:"currency,omitempty"`
+}
+
+type portfolioValueEntry struct {
+	value   PortfolioValue
+	expires time.Time
+}
+
+// PortfolioValueService estimates account value between daily snapshots.
+// It is a TradeObserver so a user's cached estimate never outlives their
+// own trades.
+type PortfolioValueService struct {
+	users     *data.UserStore
+	portfolio *data.PortfolioStore
+	history   *data.PortfolioHistoryStore
+	market    MarketPricer
+	holds     HoldsSource      // nil = nothing on hold
+	bonus     *data.BonusStore // nil = no bonus cash
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]portfolioValueEntry
+}
+
+func NewPortfolioValueService(users *data.UserStore, portfolio *data.PortfolioStore, history *data.PortfolioHistoryStore, market MarketPricer) *PortfolioValueService {
+	return &PortfolioValueService{
+		users:     users,
+		portfolio: portfolio,
+		history:   history,
+		market:    market,
+		now:       time.Now,
+		cache:     make(map[string]portfolioValueEntry),
+	}
+}
+
+// SetHolds counts pending orders' holds against buying power. Call during
+// wiring, before the service handles requests.
+func (s *PortfolioValueService) SetHolds(holds HoldsSource) {
+	s.holds = holds
+}
+
+// SetBonus takes bonus cash grants out of the day change. Call during wiring,
+// before the service handles requests.
+func (s *PortfolioValueService) SetBonus(bonus *data.BonusStore) {
+	s.bonus = bonus
+}
+
+// Estimate returns userID's current account value, valued the same way as
+// the daily snapshot but at the latest quotes (served from the quote cache
+// when fresh). Holds are read on every call, since placing or cancelling an
+// order doesn't drop the cached estimate. The result is the caller's to
+// modify.
+func (s *PortfolioValueService) Estimate(ctx context.Context, userID string) (*PortfolioValue, error) {
+	now := s.now()
+	s.mu.Lock()
+	entry, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		v := entry.value
+		return s.withHolds(ctx, userID, &v)
+	}
+
+	v, err := s.estimate(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	for id, e := range s.cache {
+		if !now.Before(e.expires) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[userID] = portfolioValueEntry{value: *v, expires: now.Add(portfolioValueTTL)}
+	s.mu.Unlock()
+	out := *v
+	return s.withHolds(ctx, userID, &out)
+}
+
+// withHolds sets v's cash on hold and buying power, and marks v partial when
+// the holds are.
+func (s *PortfolioValueService) withHolds(ctx context.Context, userID string, v *PortfolioValue) (*PortfolioValue, error) {
+	v.BuyingPower = v.Cash
+	if s.holds == nil {
+		return v, nil
+	}
+	holds, err := s.holds.Holds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	v.CashOnHold = holds.Cash
+	v.Partial = v.Partial || holds.Partial
+	v.BuyingPower = decimal.Max(v.Cash.Sub(holds.Cash), decimal.Zero)
+	return v, nil
+}
+
+func (s *PortfolioValueService) estimate(ctx context.Context, userID string, now time.Time) (*PortfolioValue, error) {
+	loc, err := userLocation(ctx, s.users, userID)
+	if err != nil {
+		return nil, err
+	}
+	cash, err := s.users.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.portfolio.GetPortfolioByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &PortfolioValue{Cash: cash, AsOf: now.In(loc)}
+	for _, h := range holdings {
+		if h.Quantity.IsZero() {
+			continue
+		}
+		price := h.AvgPrice
+		quote, err := s.market.GetStock(ctx, h.Symbol)
+		if err == nil && quote != nil && quote.Price.IsPositive() {
+			price = quote.Price
+		} else {
+			if err != nil {
+				slog.Warn("portfolio value: quote failed; valuing at cost", "symbol", h.Symbol, "err", err, "component", "portfolio_value")
+			}
+			v.Partial = true
+		}
+		// Longs count at market; shorts as their margin less the cost to
+		// buy them back (Quantity is negative).
+		v.HoldingsValue = v.HoldingsValue.Add(price.Mul(h.Quantity).Add(h.Margin))
+	}
+	v.HoldingsValue = v.HoldingsValue.Round(2)
+	v.TotalValue = v.Cash.Add(v.HoldingsValue)
+	if s.bonus != nil {
+		if v.BonusCash, err = s.bonus.Total(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	prev, err := s.history.LastBefore(ctx, userID, v.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		earned := v.TotalValue.Sub(v.BonusCash.Sub(prev.BonusCash))
+		change := earned.Sub(prev.TotalValue)
+		v.PreviousDate = prev.Date
+		v.PreviousValue = &prev.TotalValue
+		v.DayChange = &change
+		if prev.TotalValue.IsPositive() {
+			pct := percentChange(prev.TotalValue, earned)
+			v.DayChangePercent = &pct
+		}
+	}
+	return v, nil
+}
+
+// TradeExecuted drops the trader's cached estimate.
+func (s *PortfolioValueService) TradeExecuted(_ context.Context, exec TradeExecution) {
+	s.mu.Lock()
+	delete(s.cache, exec.UserID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func TestPortfolioValueEstimate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	market := &mockMarket{stock: &StockData{Symbol: "AAPL", Price: decimal.NewFromInt(120)}}
+	svc := NewPortfolioValueService(data.NewUserStore(db), data.NewPortfolioStore(db), data.NewPortfolioHistoryStore(db), market)
+	now := time.Date(2026, time.October, 16, 15, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	expectHoldings := func(timezone string) {
+		expectTimezone(mock, "user-1", timezone)
+		mock.ExpectQuery("SELECT balance FROM users").
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("5000"))
+		mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1").
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows(portfolioCols).
+				AddRow("p1", "user-1", "AAPL", 10, "100", "0", now, now).
+				AddRow("p2", "user-1", "TSLA", -5, "200", "500", now, now))
+	}
+	snapshotCols := []string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}
+
+	// Long 10 at 120, plus a short's 500 margin less 5 at 120 to cover. It
+	// is already the 17th in Tokyo, so the day change is from the last
+	// snapshot before it.
+	expectHoldings("Asia/Tokyo")
+	mock.ExpectQuery("ORDER BY snapshot_date DESC").
+		WithArgs("user-1", "2026-10-17").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC), "5000", "1000", "6000", false, "0"))
+	v, err := svc.Estimate(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if !v.TotalValue.Equal(decimal.NewFromInt(6100)) || v.Partial || v.PreviousDate != "2026-10-15" || v.AsOf.Format(time.DateOnly) != "2026-10-17" ||
+		!v.DayChange.Equal(decimal.NewFromInt(100)) || !v.DayChangePercent.Equal(decimal.RequireFromString("1.67")) {
+		t.Errorf("estimate: got %+v", v)
+	}
+
+	// Cached: no queries until the user trades.
+	v.TotalValue = decimal.Zero
+	if again, err := svc.Estimate(context.Background(), "user-1"); err != nil || !again.TotalValue.Equal(decimal.NewFromInt(6100)) {
+		t.Errorf("cached estimate: got %+v, %v", again, err)
+	}
+	svc.TradeExecuted(context.Background(), TradeExecution{TradeIntent: TradeIntent{UserID: "user-1"}})
+
+	// No quotes: valued at cost and marked partial. No snapshot yet.
+	market.stockErr = errors.New("upstream down")
+	expectHoldings("America/New_York")
+	mock.ExpectQuery("ORDER BY snapshot_date DESC").
+		WithArgs("user-1", "2026-10-16").
+		WillReturnRows(sqlmock.NewRows(snapshotCols))
+	v, err = svc.Estimate(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Estimate after trade: %v", err)
+	}
+	if !v.TotalValue.Equal(decimal.NewFromInt(5500)) || !v.Partial || v.DayChange != nil {
+		t.Errorf("estimate at cost: got %+v", v)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+type fixedHolds OrderHolds
+
+func (h *fixedHolds) Holds(context.Context, string) (*OrderHolds, error) {
+	out := OrderHolds(*h)
+	return &out, nil
+}
+
+func TestPortfolioValueEstimate_Holds(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewPortfolioValueService(data.NewUserStore(db), data.NewPortfolioStore(db), data.NewPortfolioHistoryStore(db), &mockMarket{})
+	holds := &fixedHolds{Cash: decimal.NewFromInt(1200)}
+	svc.SetHolds(holds)
+
+	expectTimezone(mock, "user-1", "America/New_York")
+	mock.ExpectQuery("SELECT balance FROM users").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("1000"))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectQuery("ORDER BY snapshot_date DESC").
+		WillReturnRows(sqlmock.NewRows([]string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}))
+
+	// Holds exceeding the cash leave no buying power, and don't touch equity.
+	v, err := svc.Estimate(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if !v.CashOnHold.Equal(decimal.NewFromInt(1200)) || !v.BuyingPower.IsZero() || !v.TotalValue.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("estimate: got %+v", v)
+	}
+
+	// A cached estimate still reads the current holds, and holds missing a
+	// quote make it partial.
+	holds.Cash = decimal.NewFromInt(250)
+	holds.Partial = true
+	if v, err := svc.Estimate(context.Background(), "user-1"); err != nil || !v.BuyingPower.Equal(decimal.NewFromInt(750)) || !v.Partial {
+		t.Errorf("cached estimate: got %+v, %v", v, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
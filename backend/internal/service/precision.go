@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// maxPriceDecimals is the finest precision prices are stored at: trades,
+// orders and lots keep prices as NUMERIC(20,8).
+const maxPriceDecimals = 8
+
+// maxPrice bounds a price to the twelve integer digits of NUMERIC(20,8).
+var maxPrice = decimal.New(1, 12)
+
+// Precision is the price grid an instrument trades on: prices are multiples
+// of TickSize and shown to Decimals places.
+type Precision struct {
+	TickSize decimal.Decimal `json:"tick_size"`
+	Decimals int32           `json:"price_decimals"`
+	// subDollar lets prices under $1 trade in 0.0001 increments, as Reg NMS
+	// Rule 612 allows for equities quoted below a dollar.
+	subDollar bool
+}
+
+// DefaultPrecision is the US equity penny tick, used for any symbol without
+// an instrument row.
+var DefaultPrecision = Precision{TickSize: decimal.New(1, -2), Decimals: 2, subDollar: true}
+
+var subDollarPrecision = Precision{TickSize: decimal.New(1, -4), Decimals: 4}
+
+// At returns the precision that applies to price.
+func (p Precision) At(price decimal.Decimal) Precision {
+	if p.subDollar && price.LessThan(decimal.NewFromInt(1)) && p.TickSize.GreaterThan(subDollarPrecision.TickSize) {
+		return subDollarPrecision
+	}
+	return p
+}
+
+// Round returns price rounded to the nearest tick.
+func (p Precision) Round(price decimal.Decimal) decimal.Decimal {
+	p = p.At(price)
+	return price.Div(p.TickSize).Round(0).Mul(p.TickSize).Round(maxPriceDecimals)
+}
+
+// OnTick reports whether price is a multiple of the tick that applies to it.
+func (p Precision) OnTick(price decimal.Decimal) bool {
+	return price.Mod(p.At(price).TickSize).IsZero()
+}
+
+// PrecisionSource gives the precision a symbol trades at. Satisfied by
+// *InstrumentService.
+type PrecisionSource interface {
+	Precision(ctx context.Context, symbol string) Precision
+}
+
+// Precision returns symbol's stored precision, reading only the instruments
+// table (no provider call) so it's cheap enough for every fill. Unknown
+// symbols and lookup failures get DefaultPrecision.
+func (s *InstrumentService) Precision(ctx context.Context, symbol string) Precision {
+	inst, err := s.store.GetInstrument(ctx, symbol)
+	if err != nil {
+		if !errors.Is(err, data.ErrInstrumentNotFound) {
+			slog.Warn("instrument precision lookup failed; using default",
+				"symbol", symbol, "err", err, "component", "instrument")
+		}
+		return DefaultPrecision
+	}
+	return instrumentPrecision(inst)
+}
+
+func instrumentPrecision(inst *data.Instrument) Precision {
+	if !inst.TickSize.IsPositive() {
+		return DefaultPrecision
+	}
+	return Precision{
+		TickSize:  inst.TickSize,
+		Decimals:  inst.PriceDecimals,
+		subDollar: (inst.AssetType == "stock" || inst.AssetType == "etf") && inst.TickSize.Equal(DefaultPrecision.TickSize),
+	}
+}
+
+// SetPrecision sets symbol's tick size and display places, creating a bare
+// instrument row if none exists yet. The tick must be positive and fit in
+// decimals places, which may be at most maxPriceDecimals.
+func (s *InstrumentService) SetPrecision(ctx context.Context, symbol string, tickSize decimal.Decimal, decimals int32) (*data.Instrument, error) {
+	symbol, err := util.ValidateSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if decimals < 0 || decimals > maxPriceDecimals {
+		return nil, &util.ValidationError{Field: "price_decimals", Message: fmt.Sprintf("must be between 0 and %d", maxPriceDecimals)}
+	}
+	if !tickSize.IsPositive() || !tickSize.Equal(tickSize.Round(decimals)) || tickSize.GreaterThanOrEqual(maxPrice) {
+		return nil, &util.ValidationError{Field: "tick_size", Message: "must be a positive amount with at most price_decimals decimal places"}
+	}
+	inst, err := s.store.SetPrecision(ctx, symbol, tickSize, decimals)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("instrument precision set", "symbol", symbol, "tick_size", tickSize, "price_decimals", decimals, "component", "instrument")
+	return inst, nil
+}
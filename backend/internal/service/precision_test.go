@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+func TestPrecision_RoundAndOnTick(t *testing.T) {
+	crypto := Precision{TickSize: decimal.RequireFromString("0.00000001"), Decimals: 8}
+	for _, tc := range []struct {
+		name  string
+		prec  Precision
+		price string
+		round string
+		on    bool
+	}{
+		{"penny", DefaultPrecision, "187.235", "187.24", false},
+		{"penny on tick", DefaultPrecision, "187.23", "187.23", true},
+		{"sub-dollar equity", DefaultPrecision, "0.51236", "0.5124", false},
+		{"sub-dollar on tick", DefaultPrecision, "0.5123", "0.5123", true},
+		{"crypto", crypto, "0.123456789", "0.12345679", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			price := decimal.RequireFromString(tc.price)
+			if got := tc.prec.Round(price); !got.Equal(decimal.RequireFromString(tc.round)) {
+				t.Errorf("Round: got %s, want %s", got, tc.round)
+			}
+			if got := tc.prec.OnTick(price); got != tc.on {
+				t.Errorf("OnTick: got %v, want %v", got, tc.on)
+			}
+		})
+	}
+}
+
+func TestInstrumentPrecision(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("BRK.A").
+		WillReturnRows(sqlmock.NewRows(instrumentCols).
+			AddRow("BRK.A", "Berkshire", "XNYS", "stock", "USD", time.Now(), time.Now(), false, "", "", nil, "1", 0))
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("NEW").
+		WillReturnError(errors.New("connection reset"))
+
+	svc := newTestInstrumentService(db, nil)
+	if got := svc.Precision(context.Background(), "BRK.A"); !got.TickSize.Equal(decimal.NewFromInt(1)) || got.Decimals != 0 {
+		t.Errorf("stored: got %s/%d, want 1/0", got.TickSize, got.Decimals)
+	}
+	if got := svc.Precision(context.Background(), "NEW"); got != DefaultPrecision {
+		t.Errorf("lookup failure: got %+v, want the default", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestInstrumentSetPrecision_Validates(t *testing.T) {
+	svc := newTestInstrumentService(nil, nil)
+	for _, tc := range []struct {
+		tick     string
+		decimals int32
+		field    string
+	}{
+		{"0.01", 9, "price_decimals"},
+		{"0", 2, "tick_size"},
+		{"0.005", 2, "tick_size"},
+	} {
+		_, err := svc.SetPrecision(context.Background(), "AAPL", decimal.RequireFromString(tc.tick), tc.decimals)
+		var ve *util.ValidationError
+		if !errors.As(err, &ve) || ve.Field != tc.field {
+			t.Errorf("%s/%d: got %v, want a %s validation error", tc.tick, tc.decimals, err, tc.field)
+		}
+	}
+}
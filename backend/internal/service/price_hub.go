@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+// liveBuffer is how many updates a subscription holds for a slow client.
+// Past it, updates are dropped; the client catches up at the symbol's next
+// change.
+const liveBuffer = 64
+
+// liveQuoter is the part of MarketService the hub reads prices from.
+type liveQuoter interface {
+	WarmQuotes(ctx context.Context, symbols []string) (int, error)
+	GetStock(ctx context.Context, symbol string) (*StockData, error)
+}
+
+// liveValuer is the part of PortfolioValueService the hub reads account
+// values from.
+type liveValuer interface {
+	Estimate(ctx context.Context, userID string) (*PortfolioValue, error)
+}
+
+// PriceHub pushes price changes to connected clients, over the market
+// WebSocket and event stream alike. Each client holds a PriceSubscription to
+// the symbols it watches; Run refreshes the union of those symbols every
+// interval, through the quote cache, and sends each subscriber the prices
+// that moved, and the account value of those watching their portfolio when
+// it moved. One refresh serves every client, so the provider sees the same
+// traffic however many are connected.
+type PriceHub struct {
+	quotes     liveQuoter
+	values     liveValuer
+	interval   time.Duration
+	maxClients int
+	maxSymbols int
+
+	mu      sync.Mutex
+	subs    map[*PriceSubscription]struct{}
+	last    map[string]*StockData
+	stopped bool
+}
+
+func NewPriceHub(quotes liveQuoter, values liveValuer, interval time.Duration, maxClients, maxSymbols int) *PriceHub {
+	return &PriceHub{
+		quotes:     quotes,
+		values:     values,
+		interval:   interval,
+		maxClients: maxClients,
+		maxSymbols: maxSymbols,
+		subs:       make(map[*PriceSubscription]struct{}),
+		last:       make(map[string]*StockData),
+	}
+}
+
+// PriceSubscription is one client's view of the hub. Updates delivers the
+// new price of a watched symbol whenever it changes, and Values the
+// client's account value, once WatchPortfolio is called; Done is closed when
+// the subscription ends, by Close or because the hub stopped.
+type PriceSubscription struct {
+	hub     *PriceHub
+	symbols map[string]struct{} // guarded by hub.mu
+	userID  string              // guarded by hub.mu; set by WatchPortfolio
+	total   *decimal.Decimal    // guarded by hub.mu; the last value sent
+	updates chan *StockData
+	values  chan *PortfolioValue
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Subscribe registers a client watching no symbols yet. It returns a
+// LiveCapacityError once maxClients are connected.
+func (h *PriceHub) Subscribe() (*PriceSubscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopped || len(h.subs) >= h.maxClients {
+		return nil, &LiveCapacityError{}
+	}
+	sub := &PriceSubscription{
+		hub:     h,
+		symbols: make(map[string]struct{}),
+		updates: make(chan *StockData, liveBuffer),
+		values:  make(chan *PortfolioValue, 1),
+		done:    make(chan struct{}),
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Clients is how many subscriptions are open.
+func (h *PriceHub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Add watches symbols, validated and upper-cased, on top of those already
+// watched, up to maxSymbols in all. It returns the last price the hub has for
+// each, so the client need not wait a refresh for a first value; symbols the
+// hub has not priced yet arrive with the next one.
+func (s *PriceSubscription) Add(symbols []string) ([]*StockData, error) {
+	valid := make([]string, 0, len(symbols))
+	for _, raw := range symbols {
+		symbol, err := util.ValidateQuoteSymbol(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(valid, symbol) {
+			valid = append(valid, symbol)
+		}
+	}
+
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := len(s.symbols)
+	for _, symbol := range valid {
+		if _, ok := s.symbols[symbol]; !ok {
+			n++
+		}
+	}
+	if n > h.maxSymbols {
+		return nil, &util.ValidationError{Field: "symbols", Message: fmt.Sprintf("at most %d symbols can be watched at once", h.maxSymbols)}
+	}
+	var known []*StockData
+	for _, symbol := range valid {
+		if _, ok := s.symbols[symbol]; ok {
+			continue
+		}
+		s.symbols[symbol] = struct{}{}
+		if q, ok := h.last[symbol]; ok {
+			known = append(known, q)
+		}
+	}
+	return known, nil
+}
+
+// Remove stops watching symbols. Unknown and invalid symbols are ignored.
+func (s *PriceSubscription) Remove(symbols []string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, raw := range symbols {
+		if symbol, err := util.ValidateQuoteSymbol(raw); err == nil {
+			delete(s.symbols, symbol)
+		}
+	}
+}
+
+// Symbols lists the watched symbols in alphabetical order.
+func (s *PriceSubscription) Symbols() []string {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	out := make([]string, 0, len(s.symbols))
+	for symbol := range s.symbols {
+		out = append(out, symbol)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// WatchPortfolio has the hub send userID's account value, from the next
+// refresh on and whenever it changes after that. Values are in USD.
+func (s *PriceSubscription) WatchPortfolio(userID string) {
+	s.hub.mu.Lock()
+	s.userID = userID
+	s.hub.mu.Unlock()
+}
+
+func (s *PriceSubscription) Updates() <-chan *StockData     { return s.updates }
+func (s *PriceSubscription) Values() <-chan *PortfolioValue { return s.values }
+func (s *PriceSubscription) Done() <-chan struct{}          { return s.done }
+
+// Close ends the subscription. It is safe to call more than once.
+func (s *PriceSubscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+	s.end()
+}
+
+func (s *PriceSubscription) end() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// Run refreshes watched prices every interval until ctx is cancelled, then
+// ends every subscription so their connections close.
+func (h *PriceHub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			h.Stop()
+			return
+		case <-ticker.C:
+			h.refresh(ctx)
+			h.revalue(ctx)
+		}
+	}
+}
+
+// Stop ends every subscription and refuses new ones. Run calls it on the
+// way out; call it sooner to close connections while the server drains.
+func (h *PriceHub) Stop() {
+	h.mu.Lock()
+	h.stopped = true
+	subs := h.subs
+	h.subs = make(map[*PriceSubscription]struct{})
+	h.mu.Unlock()
+	for sub := range subs {
+		sub.end()
+	}
+}
+
+// refresh prices every watched symbol once and fans out those that changed.
+// Symbols no one watches any more are forgotten.
+func (h *PriceHub) refresh(ctx context.Context) {
+	h.mu.Lock()
+	watched := make(map[string]struct{})
+	for sub := range h.subs {
+		for symbol := range sub.symbols {
+			watched[symbol] = struct{}{}
+		}
+	}
+	for symbol := range h.last {
+		if _, ok := watched[symbol]; !ok {
+			delete(h.last, symbol)
+		}
+	}
+	h.mu.Unlock()
+	if len(watched) == 0 {
+		return
+	}
+
+	symbols := make([]string, 0, len(watched))
+	for symbol := range watched {
+		symbols = append(symbols, symbol)
+	}
+	// Fetch the uncached symbols in batches first, so the reads below are
+	// cache hits rather than one provider call each.
+	if _, err := h.quotes.WarmQuotes(ctx, symbols); err != nil {
+		slog.Warn("live prices: warming quotes failed", "symbols", len(symbols), "err", err, "component", "live")
+	}
+	changed := make(map[string]*StockData)
+	for _, symbol := range symbols {
+		q, err := h.quotes.GetStock(ctx, symbol)
+		if err != nil {
+			slog.Debug("live prices: no quote", "symbol", symbol, "err", err, "component", "live")
+			continue
+		}
+		h.mu.Lock()
+		if prev, ok := h.last[symbol]; !ok || !prev.Price.Equal(q.Price) || prev.Date != q.Date {
+			h.last[symbol] = q
+			changed[symbol] = q
+		}
+		h.mu.Unlock()
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		for symbol := range sub.symbols {
+			q, ok := changed[symbol]
+			if !ok {
+				continue
+			}
+			select {
+			case sub.updates <- q:
+			default:
+				slog.Debug("live prices: slow client, update dropped", "symbol", symbol, "component", "live")
+			}
+		}
+	}
+}
+
+// revalue estimates the account value of each user watching their portfolio
+// and sends it where it differs from the last one sent. Estimates are cached
+// by the value service, so a user connected twice is valued once.
+func (h *PriceHub) revalue(ctx context.Context) {
+	if h.values == nil {
+		return
+	}
+	h.mu.Lock()
+	watching := make(map[*PriceSubscription]string)
+	for sub := range h.subs {
+		if sub.userID != "" {
+			watching[sub] = sub.userID
+		}
+	}
+	h.mu.Unlock()
+
+	for sub, userID := range watching {
+		v, err := h.values.Estimate(ctx, userID)
+		if err != nil {
+			slog.Warn("live prices: valuing portfolio failed", "user_id", userID, "err", err, "component", "live")
+			continue
+		}
+		h.mu.Lock()
+		changed := sub.total == nil || !sub.total.Equal(v.TotalValue)
+		if changed {
+			sub.total = &v.TotalValue
+		}
+		h.mu.Unlock()
+		if !changed {
+			continue
+		}
+		// Only the latest value matters; replace one the client hasn't read.
+		select {
+		case <-sub.values:
+		default:
+		}
+		select {
+		case sub.values <- v:
+		default:
+		}
+	}
+}
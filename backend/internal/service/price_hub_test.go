@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/util"
+)
+
+// fakeQuoter prices symbols from a map that tests change between refreshes.
+type fakeQuoter struct {
+	mu     sync.Mutex
+	prices map[string]int64
+	warmed [][]string
+}
+
+func (f *fakeQuoter) WarmQuotes(_ context.Context, symbols []string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warmed = append(f.warmed, symbols)
+	return len(symbols), nil
+}
+
+func (f *fakeQuoter) GetStock(_ context.Context, symbol string) (*StockData, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.prices[symbol]
+	if !ok {
+		return nil, &SymbolNotFoundError{}
+	}
+	return &StockData{Symbol: symbol, Date: "10/16/2026", Price: decimal.NewFromInt(p)}, nil
+}
+
+func (f *fakeQuoter) set(symbol string, price int64) {
+	f.mu.Lock()
+	f.prices[symbol] = price
+	f.mu.Unlock()
+}
+
+func TestPriceHub_PushesChanges(t *testing.T) {
+	quotes := &fakeQuoter{prices: map[string]int64{"AAPL": 150, "MSFT": 400}}
+	hub := NewPriceHub(quotes, nil, time.Hour, 2, 2)
+	sub, err := hub.Subscribe()
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if known, err := sub.Add([]string{"aapl"}); err != nil || len(known) != 0 {
+		t.Fatalf("Add: %v, %v", known, err)
+	}
+	other, _ := hub.Subscribe()
+	other.Add([]string{"MSFT"})
+
+	hub.refresh(context.Background())
+	if q := <-sub.Updates(); q.Symbol != "AAPL" || !q.Price.Equal(decimal.NewFromInt(150)) {
+		t.Errorf("first update: %+v", q)
+	}
+	if len(sub.Updates()) != 0 {
+		t.Error("MSFT should only reach its own subscriber")
+	}
+
+	// Unchanged prices are not sent again; a move is.
+	hub.refresh(context.Background())
+	if len(sub.Updates()) != 0 {
+		t.Error("unchanged price was pushed again")
+	}
+	quotes.set("AAPL", 151)
+	hub.refresh(context.Background())
+	if q := <-sub.Updates(); !q.Price.Equal(decimal.NewFromInt(151)) {
+		t.Errorf("moved price: %+v", q)
+	}
+
+	// A late subscriber gets the last price straight away.
+	other.Close()
+	late, err := hub.Subscribe()
+	if err != nil {
+		t.Fatalf("a closed subscription should free its slot: %v", err)
+	}
+	if known, _ := late.Add([]string{"AAPL"}); len(known) != 1 || !known[0].Price.Equal(decimal.NewFromInt(151)) {
+		t.Errorf("snapshot: %+v", known)
+	}
+}
+
+func TestPriceHub_Limits(t *testing.T) {
+	hub := NewPriceHub(&fakeQuoter{prices: map[string]int64{}}, nil, time.Hour, 1, 2)
+	sub, _ := hub.Subscribe()
+	var capErr *LiveCapacityError
+	if _, err := hub.Subscribe(); !errors.As(err, &capErr) {
+		t.Errorf("second client: got %v, want LiveCapacityError", err)
+	}
+
+	var verr *util.ValidationError
+	if _, err := sub.Add([]string{"AAPL", "MSFT", "NVDA"}); !errors.As(err, &verr) || verr.Field != "symbols" {
+		t.Errorf("too many symbols: got %v", err)
+	}
+	if _, err := sub.Add([]string{"AAPL", "MSFT", "AAPL"}); err != nil {
+		t.Errorf("duplicates count once: %v", err)
+	}
+	if _, err := sub.Add([]string{"not a symbol"}); err == nil {
+		t.Error("invalid symbol accepted")
+	}
+	sub.Remove([]string{"msft"})
+	if got := sub.Symbols(); len(got) != 1 || got[0] != "AAPL" {
+		t.Errorf("symbols after remove: %v", got)
+	}
+}
+
+func TestPriceHub_RunEndsSubscriptions(t *testing.T) {
+	hub := NewPriceHub(&fakeQuoter{prices: map[string]int64{}}, nil, time.Hour, 5, 5)
+	sub, _ := hub.Subscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { hub.Run(ctx); close(done) }()
+	cancel()
+	<-done
+
+	select {
+	case <-sub.Done():
+	default:
+		t.Error("subscription still open after the hub stopped")
+	}
+	if _, err := hub.Subscribe(); err == nil {
+		t.Error("a stopped hub accepted a client")
+	}
+}
+
+type fakeValuer struct{ total int64 }
+
+func (f *fakeValuer) Estimate(_ context.Context, userID string) (*PortfolioValue, error) {
+	return &PortfolioValue{TotalValue: decimal.NewFromInt(f.total)}, nil
+}
+
+func TestPriceHub_PortfolioValues(t *testing.T) {
+	values := &fakeValuer{total: 10000}
+	hub := NewPriceHub(&fakeQuoter{prices: map[string]int64{}}, values, time.Hour, 2, 2)
+	sub, _ := hub.Subscribe()
+	prices, _ := hub.Subscribe()
+
+	hub.revalue(context.Background())
+	if len(sub.Values()) != 0 || len(prices.Values()) != 0 {
+		t.Fatal("values sent before WatchPortfolio")
+	}
+	sub.WatchPortfolio("user-1")
+	hub.revalue(context.Background())
+	if v := <-sub.Values(); !v.TotalValue.Equal(decimal.NewFromInt(10000)) {
+		t.Errorf("first value: %+v", v)
+	}
+	hub.revalue(context.Background())
+	if len(sub.Values()) != 0 {
+		t.Error("unchanged value was sent again")
+	}
+
+	// An unread value is replaced by the newer one.
+	values.total = 10100
+	hub.revalue(context.Background())
+	values.total = 10200
+	hub.revalue(context.Background())
+	if v := <-sub.Values(); !v.TotalValue.Equal(decimal.NewFromInt(10200)) || len(sub.Values()) != 0 {
+		t.Errorf("latest value: %+v", v)
+	}
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+// Rate-limit scopes: every bucket keeps one window per user and one per IP.
+const (
+	RateLimitScopeUser = "user"
+	RateLimitScopeIP   = "ip"
+)
+
+const (
+	defaultTopConsumers = 20
+	maxTopConsumers     = 100
+)
+
+// RateLimitWindow is the state of one user's or IP's sliding window.
+type RateLimitWindow struct {
+	Bucket        string     `json:"bucket"`
+	Scope         string     `json:"scope"`
+	ID            string     `json:"id"`
+	Count         int        `json:"count"`
+	Limit         int        `json:"limit"`
+	Remaining     int        `json:"remaining"`
+	WindowSeconds int64      `json:"window_seconds"`
+	OldestAt      *time.Time `json:"oldest_at,omitempty"`
+	NewestAt      *time.Time `json:"newest_at,omitempty"`
+	// FreesAt is when the oldest request leaves the window, i.e. when a
+	// throttled caller gets a request back.
+	FreesAt *time.Time `json:"frees_at,omitempty"`
+}
+
+// RateLimitConsumer is one entry of a top-consumers listing.
+type RateLimitConsumer struct {
+	ID    string `json:"id"`
+	Count int    `json:"count"`
+}
+
+// RateLimitCounts tallies the outcome of limit checks for one bucket.
+// Errors are checks that failed open because the backend was unreachable.
+type RateLimitCounts struct {
+	Allowed int64 `json:"allowed"`
+	Denied  int64 `json:"denied"`
+	Errors  int64 `json:"errors"`
+}
+
+// RateLimiterMetrics are this process's limiter outcomes since Since. With
+// several API instances each reports only its own share.
+type RateLimiterMetrics struct {
+	Backend string                     `json:"backend"`
+	Since   time.Time                  `json:"since"`
+	Buckets map[string]RateLimitCounts `json:"buckets"`
+}
+
+// RateLimitInspector is the read/reset side of a RateLimiter, for operators.
+type RateLimitInspector interface {
+	// Inspect fills Count, OldestAt and NewestAt for one window.
+	Inspect(ctx context.Context, bucket, scope, id string, window time.Duration) (*RateLimitWindow, error)
+	// Reset discards the window, reporting whether there was one.
+	Reset(ctx context.Context, bucket, scope, id string) (bool, error)
+	// TopConsumers returns the n busiest windows in bucket and scope, busiest
+	// first.
+	TopConsumers(ctx context.Context, bucket, scope string, window time.Duration, n int) ([]RateLimitConsumer, error)
+	Metrics() RateLimiterMetrics
+}
+
+// limiterStats counts check outcomes per bucket. The zero value is ready to
+// use, so limiters built as struct literals (tests) work too.
+type limiterStats struct {
+	mu      sync.Mutex
+	since   time.Time
+	buckets map[string]RateLimitCounts
+}
+
+func (s *limiterStats) record(bucket string, allowed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets == nil {
+		s.buckets = make(map[string]RateLimitCounts)
+		if s.since.IsZero() {
+			s.since = time.Now()
+		}
+	}
+	c := s.buckets[bucket]
+	switch {
+	case err != nil:
+		c.Errors++
+	case allowed:
+		c.Allowed++
+	default:
+		c.Denied++
+	}
+	s.buckets[bucket] = c
+}
+
+func (s *limiterStats) snapshot(backend string) RateLimiterMetrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := RateLimiterMetrics{Backend: backend, Since: s.since, Buckets: make(map[string]RateLimitCounts, len(s.buckets))}
+	for b, c := range s.buckets {
+		m.Buckets[b] = c
+	}
+	return m
+}
+
+// sortConsumers orders by count, busiest first, and keeps the first n.
+func sortConsumers(consumers []RateLimitConsumer, n int) []RateLimitConsumer {
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].Count != consumers[j].Count {
+			return consumers[i].Count > consumers[j].Count
+		}
+		return consumers[i].ID < consumers[j].ID
+	})
+	if len(consumers) > n {
+		consumers = consumers[:n]
+	}
+	return consumers
+}
+
+// RateLimitAdminService lets admins diagnose throttling: look at or clear a
+// single user's or IP's window, find the heaviest callers, and read limiter
+// metrics. Only the configured buckets can be addressed.
+type RateLimitAdminService struct {
+	inspector RateLimitInspector
+	buckets   map[string]RateLimitBucket
+}
+
+// NewRateLimitAdminService builds the service. buckets are addressed by their
+// Name.
+func NewRateLimitAdminService(inspector RateLimitInspector, buckets ...RateLimitBucket) *RateLimitAdminService {
+	byName := make(map[string]RateLimitBucket, len(buckets))
+	for _, b := range buckets {
+		byName[b.Name] = b
+	}
+	return &RateLimitAdminService{inspector: inspector, buckets: byName}
+}
+
+// Buckets lists the addressable buckets.
+func (s *RateLimitAdminService) Buckets() []RateLimitBucket {
+	out := make([]RateLimitBucket, 0, len(s.buckets))
+	for _, b := range s.buckets {
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Inspect returns the current window for id in the named bucket and scope.
+func (s *RateLimitAdminService) Inspect(ctx context.Context, name, scope, id string) (*RateLimitWindow, error) {
+	b, err := s.resolve(name, scope)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, &util.ValidationError{Field: "id", Message: "id is required"}
+	}
+	w, err := s.inspector.Inspect(ctx, b.Bucket, scope, id, b.Window)
+	if err != nil {
+		return nil, err
+	}
+	w.Bucket, w.Scope, w.ID = b.Name, scope, id
+	w.Limit = b.limit(scope)
+	w.Remaining = max(w.Limit-w.Count, 0)
+	w.WindowSeconds = int64(b.Window / time.Second)
+	if w.OldestAt != nil {
+		frees := w.OldestAt.Add(b.Window)
+		w.FreesAt = &frees
+	}
+	return w, nil
+}
+
+// Reset clears id's window so its next request starts from zero.
+func (s *RateLimitAdminService) Reset(ctx context.Context, name, scope, id string) error {
+	b, err := s.resolve(name, scope)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return &util.ValidationError{Field: "id", Message: "id is required"}
+	}
+	existed, err := s.inspector.Reset(ctx, b.Bucket, scope, id)
+	if err != nil {
+		return err
+	}
+	if !existed {
+		return &RateLimitWindowNotFoundError{}
+	}
+	return nil
+}
+
+// TopConsumers lists the busiest windows in the named bucket and scope. n <= 0
+// means the default; it is capped at maxTopConsumers.
+func (s *RateLimitAdminService) TopConsumers(ctx context.Context, name, scope string, n int) ([]RateLimitConsumer, error) {
+	b, err := s.resolve(name, scope)
+	if err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		n = defaultTopConsumers
+	}
+	n = min(n, maxTopConsumers)
+	return s.inspector.TopConsumers(ctx, b.Bucket, scope, b.Window, n)
+}
+
+// Metrics returns the limiter's check outcomes, keyed by bucket name where
+// the bucket is configured and by raw namespace otherwise.
+func (s *RateLimitAdminService) Metrics() RateLimiterMetrics {
+	m := s.inspector.Metrics()
+	names := make(map[string]string, len(s.buckets))
+	for _, b := range s.buckets {
+		names[b.Bucket] = b.Name
+	}
+	renamed := make(map[string]RateLimitCounts, len(m.Buckets))
+	for ns, c := range m.Buckets {
+		if name, ok := names[ns]; ok {
+			ns = name
+		}
+		renamed[ns] = c
+	}
+	m.Buckets = renamed
+	return m
+}
+
+func (s *RateLimitAdminService) resolve(name, scope string) (RateLimitBucket, error) {
+	b, ok := s.buckets[name]
+	if !ok {
+		return RateLimitBucket{}, &RateLimitBucketNotFoundError{}
+	}
+	if scope != RateLimitScopeUser && scope != RateLimitScopeIP {
+		return RateLimitBucket{}, &util.ValidationError{Field: "scope", Message: `scope must be "user" or "ip"`}
+	}
+	return b, nil
+}
+
+func (b RateLimitBucket) limit(scope string) int {
+	if scope == RateLimitScopeUser {
+		return b.UserLimit
+	}
+	return b.IPLimit
+}
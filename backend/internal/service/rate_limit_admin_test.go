@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"papertrader/internal/util"
+)
+
+var testBucket = RateLimitBucket{Name: "global", Bucket: DefaultRateLimitBucket, UserLimit: 3, IPLimit: 10, Window: time.Hour}
+
+func newAdminWithTraffic(t *testing.T) (*RateLimitAdminService, *MemoryRateLimiter) {
+	t.Helper()
+	rl := NewMemoryRateLimiter(RateLimitPolicy{UserLimit: 3, IPLimit: 10, Window: time.Hour})
+	ctx := context.Background()
+	for i := 0; i < 4; i++ { // the 4th is denied
+		rl.CheckLimit(ctx, "heavy", "10.0.0.1")
+	}
+	rl.CheckLimit(ctx, "light", "10.0.0.2")
+	return NewRateLimitAdminService(rl, testBucket), rl
+}
+
+func TestRateLimitAdmin_Inspect(t *testing.T) {
+	svc, _ := newAdminWithTraffic(t)
+
+	w, err := svc.Inspect(context.Background(), "global", RateLimitScopeUser, "heavy")
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if w.Count != 3 || w.Limit != 3 || w.Remaining != 0 || w.WindowSeconds != 3600 {
+		t.Errorf("window = %+v", w)
+	}
+	if w.OldestAt == nil || w.NewestAt == nil || w.FreesAt == nil {
+		t.Fatalf("expected timestamps, got %+v", w)
+	}
+	if !w.FreesAt.Equal(w.OldestAt.Add(time.Hour)) {
+		t.Errorf("FreesAt = %v, want oldest + window", w.FreesAt)
+	}
+
+	empty, err := svc.Inspect(context.Background(), "global", RateLimitScopeIP, "10.9.9.9")
+	if err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+	if empty.Count != 0 || empty.Remaining != 10 || empty.OldestAt != nil {
+		t.Errorf("empty window = %+v", empty)
+	}
+}
+
+func TestRateLimitAdmin_Reset(t *testing.T) {
+	svc, rl := newAdminWithTraffic(t)
+	ctx := context.Background()
+
+	if err := svc.Reset(ctx, "global", RateLimitScopeUser, "heavy"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if r, _ := rl.CheckLimit(ctx, "heavy", "10.0.0.1"); !r.Allowed {
+		t.Error("request after reset should be allowed")
+	}
+
+	var notFound *RateLimitWindowNotFoundError
+	if err := svc.Reset(ctx, "global", RateLimitScopeUser, "nobody"); !errors.As(err, &notFound) {
+		t.Errorf("Reset(nobody) = %v, want RateLimitWindowNotFoundError", err)
+	}
+}
+
+func TestRateLimitAdmin_TopConsumers(t *testing.T) {
+	svc, _ := newAdminWithTraffic(t)
+
+	top, err := svc.TopConsumers(context.Background(), "global", RateLimitScopeUser, 0)
+	if err != nil {
+		t.Fatalf("TopConsumers: %v", err)
+	}
+	if len(top) != 2 || top[0] != (RateLimitConsumer{ID: "heavy", Count: 3}) || top[1].ID != "light" {
+		t.Errorf("top = %+v", top)
+	}
+
+	top, _ = svc.TopConsumers(context.Background(), "global", RateLimitScopeIP, 1)
+	if len(top) != 1 || top[0].ID != "10.0.0.1" {
+		t.Errorf("top ip = %+v", top)
+	}
+}
+
+func TestRateLimitAdmin_Metrics(t *testing.T) {
+	svc, rl := newAdminWithTraffic(t)
+	rl.CheckLimitWithBucket(context.Background(), "magiclink", "email:a@b.c", "10.0.0.1", 5, 5, time.Hour)
+
+	m := svc.Metrics()
+	if m.Backend != "memory" || m.Since.IsZero() {
+		t.Errorf("metrics = %+v", m)
+	}
+	if got := m.Buckets["global"]; got.Allowed != 4 || got.Denied != 1 {
+		t.Errorf("global counts = %+v, want 4 allowed, 1 denied", got)
+	}
+	if got := m.Buckets["magiclink"]; got.Allowed != 1 {
+		t.Errorf("unconfigured bucket should keep its namespace, got %+v", m.Buckets)
+	}
+}
+
+func TestRateLimitAdmin_RejectsUnknownBucketAndScope(t *testing.T) {
+	svc, _ := newAdminWithTraffic(t)
+	ctx := context.Background()
+
+	var notFound *RateLimitBucketNotFoundError
+	if _, err := svc.Inspect(ctx, "nope", RateLimitScopeUser, "heavy"); !errors.As(err, &notFound) {
+		t.Errorf("unknown bucket: got %v", err)
+	}
+	// Buckets are addressed by name, not by their key namespace.
+	if _, err := svc.Inspect(ctx, DefaultRateLimitBucket, RateLimitScopeUser, "heavy"); !errors.As(err, &notFound) {
+		t.Errorf("namespace as name: got %v", err)
+	}
+	var verr *util.ValidationError
+	if _, err := svc.TopConsumers(ctx, "global", "email", 5); !errors.As(err, &verr) || verr.Field != "scope" {
+		t.Errorf("bad scope: got %v", err)
+	}
+}
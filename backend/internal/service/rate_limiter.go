@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -17,6 +19,15 @@ type RateLimitResult struct {
 	LimitExceeded bool
 }
 
+// DefaultRateLimitBucket is the key namespace CheckLimit uses.
+const DefaultRateLimitBucket = "ratelimit"
+
+// RateLimitUsage is how many requests a bucket has admitted within its window.
+type RateLimitUsage struct {
+	User int
+	IP   int
+}
+
 // RateLimiter interface defines methods for rate limiting
 type RateLimiter interface {
 	CheckLimit(ctx context.Context, userID, ipAddress string) (*RateLimitResult, error)
@@ -25,6 +36,10 @@ type RateLimiter interface {
 	// tighter limits than the global default without colliding with the
 	// global ratelimit:user / ratelimit:ip keys.
 	CheckLimitWithBucket(ctx context.Context, bucket, userID, ipAddress string, userLimit, ipLimit int, window time.Duration) (*RateLimitResult, error)
+	// Usage counts the requests bucket has admitted for userID and ipAddress
+	// within the trailing window, without recording one. An empty identifier
+	// counts as 0.
+	Usage(ctx context.Context, bucket, userID, ipAddress string, window time.Duration) (*RateLimitUsage, error)
 }
 
 // RedisRateLimiter implements RateLimiter using Redis sliding window
@@ -33,24 +48,24 @@ type RedisRateLimiter struct {
 	userLimit      int           // requests per window
 	ipLimit        int           // requests per window
 	windowDuration time.Duration // time window
+	stats          limiterStats
 }
 
-const (
-	// DefaultUserLimit is the default rate limit for authenticated users (100 requests/hour)
-	DefaultUserLimit = 100
-	// DefaultIPLimit is the default rate limit for IP addresses (200 requests/hour)
-	DefaultIPLimit = 200
-	// DefaultWindowDuration is the default time window (1 hour)
-	DefaultWindowDuration = time.Hour
-)
+// RateLimitPolicy is the global limit applied by CheckLimit. Values come from
+// config.RateLimitConfig.
+type RateLimitPolicy struct {
+	UserLimit int           // requests per window per authenticated user
+	IPLimit   int           // requests per window per client IP
+	Window    time.Duration // sliding window length
+}
 
 // NewRedisRateLimiter creates a new Redis-based rate limiter
-func NewRedisRateLimiter(client *redis.Client) *RedisRateLimiter {
+func NewRedisRateLimiter(client *redis.Client, policy RateLimitPolicy) *RedisRateLimiter {
 	return &RedisRateLimiter{
 		client:         client,
-		userLimit:      DefaultUserLimit,
-		ipLimit:        DefaultIPLimit,
-		windowDuration: DefaultWindowDuration,
+		userLimit:      policy.UserLimit,
+		ipLimit:        policy.IPLimit,
+		windowDuration: policy.Window,
 	}
 }
 
@@ -86,13 +101,22 @@ return {1, count + 1}
 // CheckLimit checks both user and IP rate limits against the global default
 // bucket using the limiter's configured limits and window.
 func (r *RedisRateLimiter) CheckLimit(ctx context.Context, userID, ipAddress string) (*RateLimitResult, error) {
-	return r.CheckLimitWithBucket(ctx, "ratelimit", userID, ipAddress, r.userLimit, r.ipLimit, r.windowDuration)
+	return r.CheckLimitWithBucket(ctx, DefaultRateLimitBucket, userID, ipAddress, r.userLimit, r.ipLimit, r.windowDuration)
 }
 
 // CheckLimitWithBucket runs the sliding-window check against a custom bucket
 // namespace and per-call limits/window. Used by endpoints that need tighter
 // limits than the global default (e.g. /api/research/ask).
 func (r *RedisRateLimiter) CheckLimitWithBucket(ctx context.Context, bucket, userID, ipAddress string, userLimit, ipLimit int, window time.Duration) (*RateLimitResult, error) {
+	result, checkErr := r.checkLimit(ctx, bucket, userID, ipAddress, userLimit, ipLimit, window)
+	r.stats.record(bucket, result.Allowed, checkErr)
+	return result, nil
+}
+
+// checkLimit does the work of CheckLimitWithBucket. Redis errors fail open:
+// the returned result allows the request and the error is only reported for
+// metrics.
+func (r *RedisRateLimiter) checkLimit(ctx context.Context, bucket, userID, ipAddress string, userLimit, ipLimit int, window time.Duration) (*RateLimitResult, error) {
 	now := time.Now()
 	windowStart := now.Add(-window)
 
@@ -109,7 +133,7 @@ func (r *RedisRateLimiter) CheckLimitWithBucket(ctx context.Context, bucket, use
 				"err", err,
 				"component", "rate_limiter",
 			)
-			return &RateLimitResult{Allowed: true, Remaining: userLimit}, nil
+			return &RateLimitResult{Allowed: true, Remaining: userLimit}, err
 		}
 		if !userAllowed {
 			result.Allowed = false
@@ -134,7 +158,7 @@ func (r *RedisRateLimiter) CheckLimitWithBucket(ctx context.Context, bucket, use
 		}
 		result.Remaining = fallback
 		result.Allowed = true
-		return result, nil
+		return result, err
 	}
 	if !ipAllowed {
 		result.Allowed = false
@@ -153,6 +177,105 @@ func (r *RedisRateLimiter) CheckLimitWithBucket(ctx context.Context, bucket, use
 	return result, nil
 }
 
+// Usage counts admitted requests with ZCOUNT. Entries older than the window
+// may still be in the set (they are pruned on the next check), so the lower
+// bound is applied here rather than relying on ZCARD.
+func (r *RedisRateLimiter) Usage(ctx context.Context, bucket, userID, ipAddress string, window time.Duration) (*RateLimitUsage, error) {
+	min := "(" + strconv.FormatInt(time.Now().Add(-window).UnixNano(), 10)
+	usage := &RateLimitUsage{}
+	if userID != "" {
+		n, err := r.client.ZCount(ctx, bucket+":user:"+userID, min, "+inf").Result()
+		if err != nil {
+			return nil, err
+		}
+		usage.User = int(n)
+	}
+	if ipAddress != "" {
+		n, err := r.client.ZCount(ctx, bucket+":ip:"+ipAddress, min, "+inf").Result()
+		if err != nil {
+			return nil, err
+		}
+		usage.IP = int(n)
+	}
+	return usage, nil
+}
+
+// Metrics implements RateLimitInspector.
+func (r *RedisRateLimiter) Metrics() RateLimiterMetrics {
+	return r.stats.snapshot("redis")
+}
+
+// Inspect implements RateLimitInspector.
+func (r *RedisRateLimiter) Inspect(ctx context.Context, bucket, scope, id string, window time.Duration) (*RateLimitWindow, error) {
+	key := bucket + ":" + scope + ":" + id
+	min := "(" + strconv.FormatInt(time.Now().Add(-window).UnixNano(), 10)
+
+	pipe := r.client.Pipeline()
+	count := pipe.ZCount(ctx, key, min, "+inf")
+	oldest := pipe.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{Key: key, Start: min, Stop: "+inf", ByScore: true, Count: 1})
+	newest := pipe.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{Key: key, Start: min, Stop: "+inf", ByScore: true, Rev: true, Count: 1})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	w := &RateLimitWindow{Count: int(count.Val())}
+	if z := oldest.Val(); len(z) > 0 {
+		t := time.Unix(0, int64(z[0].Score))
+		w.OldestAt = &t
+	}
+	if z := newest.Val(); len(z) > 0 {
+		t := time.Unix(0, int64(z[0].Score))
+		w.NewestAt = &t
+	}
+	return w, nil
+}
+
+// Reset implements RateLimitInspector.
+func (r *RedisRateLimiter) Reset(ctx context.Context, bucket, scope, id string) (bool, error) {
+	n, err := r.client.Del(ctx, bucket+":"+scope+":"+id).Result()
+	return n > 0, err
+}
+
+// topConsumersScanLimit bounds how many keys TopConsumers examines, so one
+// admin request can't walk an unbounded keyspace.
+const topConsumersScanLimit = 10000
+
+// TopConsumers implements RateLimitInspector. It SCANs the bucket's keys and
+// counts each window; with more than topConsumersScanLimit keys the result
+// covers only the keys seen.
+func (r *RedisRateLimiter) TopConsumers(ctx context.Context, bucket, scope string, window time.Duration, n int) ([]RateLimitConsumer, error) {
+	prefix := bucket + ":" + scope + ":"
+	min := "(" + strconv.FormatInt(time.Now().Add(-window).UnixNano(), 10)
+
+	var keys []string
+	iter := r.client.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	for iter.Next(ctx) && len(keys) < topConsumersScanLimit {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	pipe := r.client.Pipeline()
+	counts := make([]*redis.IntCmd, len(keys))
+	for i, key := range keys {
+		counts[i] = pipe.ZCount(ctx, key, min, "+inf")
+	}
+	if len(keys) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	consumers := make([]RateLimitConsumer, 0, len(keys))
+	for i, key := range keys {
+		if c := int(counts[i].Val()); c > 0 {
+			consumers = append(consumers, RateLimitConsumer{ID: strings.TrimPrefix(key, prefix), Count: c})
+		}
+	}
+	return sortConsumers(consumers, n), nil
+}
+
 // checkWindowLimitWithTTL implements sliding window rate limiting using sorted
 // sets, with the TTL derived from the caller-supplied window. The check-and-add
 // must be atomic; see slidingWindowScript above.
@@ -6,8 +6,10 @@ import (
 	"time"
 )
 
+var testRateLimitPolicy = RateLimitPolicy{UserLimit: 100, IPLimit: 200, Window: time.Hour}
+
 func TestMemoryRateLimiter_AllowsUnderLimit(t *testing.T) {
-	rl := NewMemoryRateLimiter()
+	rl := NewMemoryRateLimiter(testRateLimitPolicy)
 	for i := 0; i < 50; i++ {
 		result, err := rl.CheckLimit(context.Background(), "", "127.0.0.1")
 		if err != nil {
@@ -22,9 +24,9 @@ func TestMemoryRateLimiter_AllowsUnderLimit(t *testing.T) {
 func TestMemoryRateLimiter_BlocksAtIPLimit(t *testing.T) {
 	rl := &MemoryRateLimiter{
 		counts:    make(map[string][]time.Time),
-		userLimit: DefaultUserLimit,
+		userLimit: testRateLimitPolicy.UserLimit,
 		ipLimit:   3,
-		window:    DefaultWindowDuration,
+		window:    testRateLimitPolicy.Window,
 	}
 
 	for i := 0; i < 3; i++ {
@@ -47,8 +49,8 @@ func TestMemoryRateLimiter_BlocksAtUserLimit(t *testing.T) {
 	rl := &MemoryRateLimiter{
 		counts:    make(map[string][]time.Time),
 		userLimit: 2,
-		ipLimit:   DefaultIPLimit,
-		window:    DefaultWindowDuration,
+		ipLimit:   testRateLimitPolicy.IPLimit,
+		window:    testRateLimitPolicy.Window,
 	}
 
 	rl.CheckLimit(context.Background(), "user-1", "10.0.0.1")
@@ -63,9 +65,9 @@ func TestMemoryRateLimiter_BlocksAtUserLimit(t *testing.T) {
 func TestMemoryRateLimiter_IndependentPerIP(t *testing.T) {
 	rl := &MemoryRateLimiter{
 		counts:    make(map[string][]time.Time),
-		userLimit: DefaultUserLimit,
+		userLimit: testRateLimitPolicy.UserLimit,
 		ipLimit:   1,
-		window:    DefaultWindowDuration,
+		window:    testRateLimitPolicy.Window,
 	}
 
 	r1, _ := rl.CheckLimit(context.Background(), "", "10.0.0.1")
@@ -83,7 +85,7 @@ func TestMemoryRateLimiter_IndependentPerIP(t *testing.T) {
 func TestMemoryRateLimiter_WindowExpiry(t *testing.T) {
 	rl := &MemoryRateLimiter{
 		counts:    make(map[string][]time.Time),
-		userLimit: DefaultUserLimit,
+		userLimit: testRateLimitPolicy.UserLimit,
 		ipLimit:   2,
 		window:    80 * time.Millisecond,
 	}
@@ -107,9 +109,9 @@ func TestMemoryRateLimiter_WindowExpiry(t *testing.T) {
 func TestMemoryRateLimiter_RemainingDecreases(t *testing.T) {
 	rl := &MemoryRateLimiter{
 		counts:    make(map[string][]time.Time),
-		userLimit: DefaultUserLimit,
+		userLimit: testRateLimitPolicy.UserLimit,
 		ipLimit:   5,
-		window:    DefaultWindowDuration,
+		window:    testRateLimitPolicy.Window,
 	}
 
 	var prev int = 5
@@ -123,7 +125,7 @@ func TestMemoryRateLimiter_RemainingDecreases(t *testing.T) {
 }
 
 func TestMemoryRateLimiter_BucketBlocksAtCustomLimit(t *testing.T) {
-	rl := NewMemoryRateLimiter()
+	rl := NewMemoryRateLimiter(testRateLimitPolicy)
 	ctx := context.Background()
 
 	// User limit (10) is tighter than IP limit (30) — user limit should bind first.
@@ -144,7 +146,7 @@ func TestMemoryRateLimiter_BucketBlocksAtCustomLimit(t *testing.T) {
 }
 
 func TestMemoryRateLimiter_BucketsAreIsolated(t *testing.T) {
-	rl := NewMemoryRateLimiter()
+	rl := NewMemoryRateLimiter(testRateLimitPolicy)
 	ctx := context.Background()
 
 	// Burn through a tight per-route bucket.
@@ -167,3 +169,36 @@ func TestMemoryRateLimiter_BucketsAreIsolated(t *testing.T) {
 		t.Error("global bucket should be unaffected by exhausted research_ask bucket")
 	}
 }
+
+func TestMemoryRateLimiter_UsageCountsWithoutConsuming(t *testing.T) {
+	rl := NewMemoryRateLimiter(testRateLimitPolicy)
+	ctx := context.Background()
+	rl.CheckLimit(ctx, "user-1", "10.0.0.1")
+	rl.CheckLimit(ctx, "user-1", "10.0.0.2")
+	rl.CheckLimitWithBucket(ctx, "other", "user-1", "10.0.0.1", 5, 5, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		usage, err := rl.Usage(ctx, DefaultRateLimitBucket, "user-1", "10.0.0.1", time.Hour)
+		if err != nil {
+			t.Fatalf("Usage: %v", err)
+		}
+		if usage.User != 2 || usage.IP != 1 {
+			t.Errorf("usage = %+v, want User=2 IP=1", usage)
+		}
+	}
+
+	usage, _ := rl.Usage(ctx, DefaultRateLimitBucket, "", "", time.Hour)
+	if usage.User != 0 || usage.IP != 0 {
+		t.Errorf("empty identifiers: got %+v, want zeros", usage)
+	}
+}
+
+func TestMemoryRateLimiter_UsageIgnoresExpiredEntries(t *testing.T) {
+	rl := NewMemoryRateLimiter(testRateLimitPolicy)
+	rl.counts["ratelimit:user:user-1"] = []time.Time{time.Now().Add(-2 * time.Hour), time.Now()}
+
+	usage, _ := rl.Usage(context.Background(), DefaultRateLimitBucket, "user-1", "", time.Hour)
+	if usage.User != 1 {
+		t.Errorf("User = %d, want 1", usage.User)
+	}
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// maxRebalanceTargets caps the symbols in one rebalance. Each costs a quote
+// lookup and possibly a trade.
+const maxRebalanceTargets = 25
+
+// RebalanceRequest asks for the account to be brought to Targets: symbol to
+// percent of account value (0-100, at most two decimal places, summing to at
+// most 100). Long holdings not listed are sold and whatever is left over is
+// held as cash. Execute places the orders; otherwise the plan is only
+// previewed. IdempotencyKey, when set, makes each executed order replayable.
+type RebalanceRequest struct {
+	Targets        map[string]decimal.Decimal
+	Execute        bool
+	IdempotencyKey string
+}
+
+// RebalancePosition is one symbol of a rebalance plan. Weights are percent
+// of the account's value.
+type RebalancePosition struct {
+	Symbol          string          `json:"symbol"`
+	Price           decimal.Decimal `json:"price"`
+	CurrentQuantity decimal.Decimal `json:"current_quantity"`
+	CurrentWeight   decimal.Decimal `json:"current_weight"`
+	TargetWeight    decimal.Decimal `json:"target_weight"`
+	TargetQuantity  decimal.Decimal `json:"target_quantity"`
+}
+
+// RebalanceOrder is a trade of a rebalance plan, estimated at Price. Status
+// and Error are set once the plan has been executed: FILLED, or FAILED with
+// the reason the trade was rejected.
+type RebalanceOrder struct {
+	Symbol    string          `json:"symbol"`
+	Side      string          `json:"side"` // data.OrderSideBuy or data.OrderSideSell
+	Quantity  decimal.Decimal `json:"quantity"`
+	Price     decimal.Decimal `json:"price"`
+	Amount    decimal.Decimal `json:"amount"`
+	Status    string          `json:"status,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ErrorCode string          `json:"error_code,omitempty"`
+}
+
+// Rebalance order statuses once executed.
+const (
+	RebalanceFilled = "FILLED"
+	RebalanceFailed = "FAILED"
+)
+
+// RebalancePlan is the set of trades that brings an account to its target
+// weights. TotalValue is cash plus long holdings at the latest quotes;
+// ProjectedCash is the cash left if every order fills at its quote.
+type RebalancePlan struct {
+	TotalValue    decimal.Decimal     `json:"total_value"`
+	Cash          decimal.Decimal     `json:"cash"`
+	ProjectedCash decimal.Decimal     `json:"projected_cash"`
+	Positions     []RebalancePosition `json:"positions"`
+	Orders        []RebalanceOrder    `json:"orders"`
+	Executed      bool                `json:"executed"`
+}
+
+// RebalanceTrader is the subset of InvestmentService used by
+// RebalanceService.
+type RebalanceTrader interface {
+	BuyStock(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)
+	SellStock(ctx context.Context, userID, symbol string, quantity decimal.Decimal, idempotencyKey string) (*data.UserStock, error)
+}
+
+// RebalanceService plans, and on request places, the trades that move an
+// account to target weights. Orders go through the trader like manual ones,
+// so they pass the same pre-trade checks.
+type RebalanceService struct {
+	users     *data.UserStore
+	portfolio *data.PortfolioStore
+	market    MarketPricer
+	trader    RebalanceTrader
+}
+
+func NewRebalanceService(users *data.UserStore, portfolio *data.PortfolioStore, market MarketPricer, trader RebalanceTrader) *RebalanceService {
+	return &RebalanceService{users: users, portfolio: portfolio, market: market, trader: trader}
+}
+
+// Rebalance builds userID's plan for req and executes it when req.Execute is
+// set. Target quantities are rounded down, to whole shares or to the
+// smallest crypto unit, so the account ends slightly under each weight.
+// Sells run before buys so their proceeds fund the buys; a rejected order is
+// reported on the plan and the rest still run.
+// Short positions are left alone and cannot be targeted.
+func (s *RebalanceService) Rebalance(ctx context.Context, userID string, req RebalanceRequest) (*RebalancePlan, error) {
+	targets, err := validateRebalanceTargets(req.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	cash, err := s.users.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.portfolio.GetPortfolioByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	held := make(map[string]decimal.Decimal, len(holdings))
+	for _, h := range holdings {
+		if h.IsShort() {
+			if _, ok := targets[h.Symbol]; ok {
+				return nil, &util.ValidationError{Field: "targets", Message: h.Symbol + " has an open short position"}
+			}
+			continue
+		}
+		held[h.Symbol] = h.Quantity
+		if _, ok := targets[h.Symbol]; !ok {
+			targets[h.Symbol] = decimal.Zero
+		}
+	}
+
+	symbols := make([]string, 0, len(targets))
+	for symbol := range targets {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	plan := &RebalancePlan{Cash: cash, TotalValue: cash}
+	prices := make(map[string]decimal.Decimal, len(symbols))
+	for _, symbol := range symbols {
+		quote, err := s.market.GetStock(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if quote == nil || !quote.Price.IsPositive() {
+			return nil, &util.ValidationError{Field: "targets", Message: "no price available for " + symbol}
+		}
+		prices[symbol] = quote.Price
+		plan.TotalValue = plan.TotalValue.Add(quote.Price.Mul(held[symbol]))
+	}
+	plan.TotalValue = plan.TotalValue.Round(2)
+
+	var buys, sells []RebalanceOrder
+	plan.ProjectedCash = cash
+	for _, symbol := range symbols {
+		price, current := prices[symbol], held[symbol]
+		places := int32(0)
+		if util.IsCryptoPair(symbol) {
+			places = util.QuantityDecimals
+		}
+		target := plan.TotalValue.Mul(targets[symbol]).Div(decimal.NewFromInt(100)).Div(price).RoundDown(places)
+		pos := RebalancePosition{
+			Symbol:          symbol,
+			Price:           price,
+			CurrentQuantity: current,
+			TargetWeight:    targets[symbol],
+			TargetQuantity:  target,
+		}
+		if plan.TotalValue.IsPositive() {
+			pos.CurrentWeight = price.Mul(current).Div(plan.TotalValue).Mul(decimal.NewFromInt(100)).Round(2)
+		}
+		plan.Positions = append(plan.Positions, pos)
+
+		order := RebalanceOrder{Symbol: symbol, Price: price}
+		switch {
+		case target.GreaterThan(current):
+			order.Side, order.Quantity = data.OrderSideBuy, target.Sub(current)
+		case target.LessThan(current):
+			order.Side, order.Quantity = data.OrderSideSell, current.Sub(target)
+		default:
+			continue
+		}
+		order.Amount = price.Mul(order.Quantity).Round(2)
+		if order.Side == data.OrderSideBuy {
+			plan.ProjectedCash = plan.ProjectedCash.Sub(order.Amount)
+			buys = append(buys, order)
+		} else {
+			plan.ProjectedCash = plan.ProjectedCash.Add(order.Amount)
+			sells = append(sells, order)
+		}
+	}
+	plan.Orders = append(append([]RebalanceOrder{}, sells...), buys...)
+
+	if !req.Execute {
+		return plan, nil
+	}
+	for i := range plan.Orders {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		s.execute(ctx, userID, req.IdempotencyKey, &plan.Orders[i])
+	}
+	plan.Executed = true
+	return plan, nil
+}
+
+func (s *RebalanceService) execute(ctx context.Context, userID, idempotencyKey string, order *RebalanceOrder) {
+	key := ""
+	if idempotencyKey != "" {
+		// One key per order, within the trades column's 255 characters.
+		sum := sha256.Sum256([]byte(idempotencyKey + "\x00" + order.Side + "\x00" + order.Symbol))
+		key = "rebalance:" + hex.EncodeToString(sum[:])
+	}
+	trade := s.trader.SellStock
+	if order.Side == data.OrderSideBuy {
+		trade = s.trader.BuyStock
+	}
+	if _, err := trade(ctx, userID, order.Symbol, order.Quantity, key); err != nil {
+		order.Status = RebalanceFailed
+		order.Error, _, order.ErrorCode = util.MapServiceError(err)
+		return
+	}
+	order.Status = RebalanceFilled
+}
+
+func validateRebalanceTargets(targets map[string]decimal.Decimal) (map[string]decimal.Decimal, error) {
+	if len(targets) == 0 {
+		return nil, &util.ValidationError{Field: "targets", Message: "must list at least one symbol"}
+	}
+	if len(targets) > maxRebalanceTargets {
+		return nil, &util.ValidationError{Field: "targets", Message: fmt.Sprintf("must list at most %d symbols", maxRebalanceTargets)}
+	}
+	out := make(map[string]decimal.Decimal, len(targets))
+	sum := decimal.Zero
+	for raw, weight := range targets {
+		symbol, err := util.ValidateSymbol(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := out[symbol]; dup {
+			return nil, &util.ValidationError{Field: "targets", Message: symbol + " is listed more than once"}
+		}
+		if weight.IsNegative() || weight.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, &util.ValidationError{Field: "targets", Message: symbol + " weight must be between 0 and 100"}
+		}
+		if !weight.Equal(weight.Round(2)) {
+			return nil, &util.ValidationError{Field: "targets", Message: symbol + " weight must have at most 2 decimal places"}
+		}
+		out[symbol] = weight
+		sum = sum.Add(weight)
+	}
+	if sum.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, &util.ValidationError{Field: "targets", Message: "weights must add up to at most 100"}
+	}
+	return out, nil
+}
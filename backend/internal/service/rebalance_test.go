@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// quoteMarket prices each symbol from a fixed table.
+type quoteMarket map[string]decimal.Decimal
+
+func (m quoteMarket) GetStock(_ context.Context, symbol string) (*StockData, error) {
+	price, ok := m[symbol]
+	if !ok {
+		return nil, errors.New("no quote")
+	}
+	return &StockData{Symbol: symbol, Price: price}, nil
+}
+
+func (m quoteMarket) GetBatchHistoricalData(_ context.Context, _ []string) (map[string]*HistoricalData, error) {
+	return nil, nil
+}
+
+// recordingTrader records the trades placed through it and rejects buys of
+// reject.
+type recordingTrader struct {
+	trades []string
+	keys   []string
+	reject string
+}
+
+func (t *recordingTrader) BuyStock(_ context.Context, _, symbol string, quantity decimal.Decimal, key string) (*data.UserStock, error) {
+	if symbol == t.reject {
+		return nil, &InsufficientFundsError{}
+	}
+	t.trades = append(t.trades, "BUY "+symbol)
+	t.keys = append(t.keys, key)
+	return &data.UserStock{Symbol: symbol, Quantity: quantity}, nil
+}
+
+func (t *recordingTrader) SellStock(_ context.Context, _, symbol string, quantity decimal.Decimal, key string) (*data.UserStock, error) {
+	t.trades = append(t.trades, "SELL "+symbol)
+	t.keys = append(t.keys, key)
+	return &data.UserStock{Symbol: symbol}, nil
+}
+
+func TestRebalance_PlansAndExecutes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	market := quoteMarket{"AAPL": decimal.NewFromInt(100), "MSFT": decimal.NewFromInt(200), "TSLA": decimal.NewFromInt(50), "GME": decimal.NewFromInt(20)}
+	trader := &recordingTrader{reject: "MSFT"}
+	svc := NewRebalanceService(data.NewUserStore(db), data.NewPortfolioStore(db), market, trader)
+	now := time.Date(2026, time.October, 16, 15, 0, 0, 0, time.UTC)
+
+	expectAccount := func() {
+		mock.ExpectQuery("SELECT balance FROM users").
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("2000"))
+		mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1").
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows(portfolioCols).
+				AddRow("p1", "user-1", "AAPL", 60, "90", "0", now, now).
+				AddRow("p2", "user-1", "TSLA", 40, "40", "0", now, now).
+				AddRow("p3", "user-1", "GME", -10, "25", "300", now, now))
+	}
+	// Account value: 2000 cash + 6000 AAPL + 2000 TSLA = 10000. The GME short
+	// is left alone; TSLA is not listed, so it is sold.
+	targets := map[string]decimal.Decimal{"aapl": decimal.NewFromInt(50), "MSFT": decimal.NewFromInt(45)}
+
+	expectAccount()
+	plan, err := svc.Rebalance(context.Background(), "user-1", RebalanceRequest{Targets: targets})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !plan.TotalValue.Equal(decimal.NewFromInt(10000)) || plan.Executed || len(trader.trades) != 0 {
+		t.Fatalf("preview: got %+v, trades %v", plan, trader.trades)
+	}
+	want := []RebalanceOrder{
+		{Symbol: "AAPL", Side: data.OrderSideSell, Quantity: decimal.NewFromInt(10)},
+		{Symbol: "TSLA", Side: data.OrderSideSell, Quantity: decimal.NewFromInt(40)},
+		{Symbol: "MSFT", Side: data.OrderSideBuy, Quantity: decimal.NewFromInt(22)},
+	}
+	if len(plan.Orders) != len(want) {
+		t.Fatalf("orders: got %+v", plan.Orders)
+	}
+	for i, o := range plan.Orders {
+		if o.Symbol != want[i].Symbol || o.Side != want[i].Side || !o.Quantity.Equal(want[i].Quantity) || o.Status != "" {
+			t.Errorf("order %d: got %+v, want %+v", i, o, want[i])
+		}
+	}
+	// 2000 + 1000 + 2000 - 4400.
+	if !plan.ProjectedCash.Equal(decimal.NewFromInt(600)) {
+		t.Errorf("projected cash: got %s", plan.ProjectedCash)
+	}
+
+	expectAccount()
+	plan, err = svc.Rebalance(context.Background(), "user-1", RebalanceRequest{Targets: targets, Execute: true, IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !plan.Executed || len(trader.trades) != 2 || trader.trades[0] != "SELL AAPL" || trader.trades[1] != "SELL TSLA" {
+		t.Fatalf("execute: got %+v, trades %v", plan, trader.trades)
+	}
+	if plan.Orders[0].Status != RebalanceFilled || plan.Orders[2].Status != RebalanceFailed || plan.Orders[2].ErrorCode != "INSUFFICIENT_FUNDS" {
+		t.Errorf("statuses: got %+v", plan.Orders)
+	}
+	if trader.keys[0] == "" || trader.keys[0] == trader.keys[1] || len(trader.keys[0]) > 255 {
+		t.Errorf("idempotency keys: got %q", trader.keys)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRebalance_RejectsBadTargets(t *testing.T) {
+	svc := NewRebalanceService(nil, nil, nil, nil)
+	for name, targets := range map[string]map[string]decimal.Decimal{
+		"empty":    {},
+		"over 100": {"AAPL": decimal.NewFromInt(60), "MSFT": decimal.NewFromInt(41)},
+		"negative": {"AAPL": decimal.NewFromInt(-1)},
+		"precise":  {"AAPL": decimal.RequireFromString("10.125")},
+		"symbol":   {"not a symbol": decimal.NewFromInt(10)},
+		"repeated": {"aapl": decimal.NewFromInt(10), "AAPL": decimal.NewFromInt(10)},
+	} {
+		var verr *util.ValidationError
+		if _, err := svc.Rebalance(context.Background(), "user-1", RebalanceRequest{Targets: targets}); !errors.As(err, &verr) {
+			t.Errorf("%s: got %v, want a validation error", name, err)
+		}
+	}
+}
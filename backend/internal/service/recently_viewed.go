@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Each user's recently-viewed list keeps at most recentlyViewedLimit symbols
+// and is dropped after recentlyViewedRetention without a new view.
+const (
+	recentlyViewedLimit     = 20
+	recentlyViewedRetention = 90 * 24 * time.Hour
+)
+
+// RecentlyViewed is one entry of a user's recently-viewed list.
+type RecentlyViewed struct {
+	Symbol   string    `json:"symbol"`
+	ViewedAt time.Time `json:"viewed_at"`
+}
+
+// RecentlyViewedStore keeps a capped, de-duplicated list of symbols per user.
+type RecentlyViewedStore interface {
+	// Add records a view of symbol, moving it to the front if already
+	// present, and trims the list to limit entries.
+	Add(ctx context.Context, userID, symbol string, at time.Time, limit int) error
+	// List returns up to limit entries, most recent first.
+	List(ctx context.Context, userID string, limit int) ([]RecentlyViewed, error)
+}
+
+// RedisRecentlyViewedStore keeps one sorted set per user under
+// recent:<userID>, scored by view time in milliseconds. Because the list lives
+// server-side it is shared by every device the user signs in from.
+type RedisRecentlyViewedStore struct {
+	client *redis.Client
+}
+
+func NewRedisRecentlyViewedStore(client *redis.Client) *RedisRecentlyViewedStore {
+	return &RedisRecentlyViewedStore{client: client}
+}
+
+func recentlyViewedKey(userID string) string {
+	return fmt.Sprintf("recent:%s", userID)
+}
+
+// Add implements RecentlyViewedStore.
+func (s *RedisRecentlyViewedStore) Add(ctx context.Context, userID, symbol string, at time.Time, limit int) error {
+	key := recentlyViewedKey(userID)
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(at.UnixMilli()), Member: symbol})
+	// Keep the newest limit members: drop ranks 0 .. -(limit+1).
+	pipe.ZRemRangeByRank(ctx, key, 0, int64(-limit-1))
+	pipe.Expire(ctx, key, recentlyViewedRetention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List implements RecentlyViewedStore.
+func (s *RedisRecentlyViewedStore) List(ctx context.Context, userID string, limit int) ([]RecentlyViewed, error) {
+	zs, err := s.client.ZRevRangeWithScores(ctx, recentlyViewedKey(userID), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RecentlyViewed, 0, len(zs))
+	for _, z := range zs {
+		symbol, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		out = append(out, RecentlyViewed{Symbol: symbol, ViewedAt: time.UnixMilli(int64(z.Score)).UTC()})
+	}
+	return out, nil
+}
+
+// MemoryRecentlyViewedStore is the in-process RecentlyViewedStore used when
+// Redis is unavailable. Lists are lost on restart and not shared between
+// instances.
+type MemoryRecentlyViewedStore struct {
+	mu    sync.Mutex
+	views map[string]map[string]time.Time // userID -> symbol -> last view
+}
+
+func NewMemoryRecentlyViewedStore() *MemoryRecentlyViewedStore {
+	return &MemoryRecentlyViewedStore{views: make(map[string]map[string]time.Time)}
+}
+
+// Add implements RecentlyViewedStore.
+func (s *MemoryRecentlyViewedStore) Add(_ context.Context, userID, symbol string, at time.Time, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user := s.views[userID]
+	if user == nil {
+		user = make(map[string]time.Time)
+		s.views[userID] = user
+	}
+	user[symbol] = at
+	if len(user) > limit {
+		for _, e := range sortRecentlyViewed(user)[limit:] {
+			delete(user, e.Symbol)
+		}
+	}
+	return nil
+}
+
+// List implements RecentlyViewedStore.
+func (s *MemoryRecentlyViewedStore) List(_ context.Context, userID string, limit int) ([]RecentlyViewed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := sortRecentlyViewed(s.views[userID])
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// sortRecentlyViewed returns the entries newest first; ties break by symbol
+// so the order is stable.
+func sortRecentlyViewed(views map[string]time.Time) []RecentlyViewed {
+	out := make([]RecentlyViewed, 0, len(views))
+	for symbol, at := range views {
+		out = append(out, RecentlyViewed{Symbol: symbol, ViewedAt: at.UTC()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ViewedAt.Equal(out[j].ViewedAt) {
+			return out[i].ViewedAt.After(out[j].ViewedAt)
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// RecentlyViewedService tracks the symbols each user has looked at so the
+// frontend can offer them again without keeping its own per-device history.
+type RecentlyViewedService struct {
+	store RecentlyViewedStore
+	now   func() time.Time
+}
+
+func NewRecentlyViewedService(store RecentlyViewedStore) *RecentlyViewedService {
+	return &RecentlyViewedService{store: store, now: time.Now}
+}
+
+// Record notes that userID viewed symbol. Failures are logged, not returned:
+// a lost history entry must never fail the quote it came from.
+func (s *RecentlyViewedService) Record(ctx context.Context, userID, symbol string) {
+	if userID == "" || symbol == "" {
+		return
+	}
+	if err := s.store.Add(ctx, userID, symbol, s.now(), recentlyViewedLimit); err != nil {
+		slog.Warn("failed to record recently viewed symbol", "user_id", userID, "symbol", symbol, "err", err, "component", "recently_viewed")
+	}
+}
+
+// List returns userID's recently viewed symbols, most recent first.
+func (s *RecentlyViewedService) List(ctx context.Context, userID string) ([]RecentlyViewed, error) {
+	return s.store.List(ctx, userID, recentlyViewedLimit)
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRecentlyViewedStore_DedupesAndCaps(t *testing.T) {
+	s := NewMemoryRecentlyViewedStore()
+	ctx := context.Background()
+	base := time.Unix(1_700_000_000, 0)
+	s.Add(ctx, "u1", "AAPL", base, 3)
+	s.Add(ctx, "u1", "MSFT", base.Add(time.Minute), 3)
+	s.Add(ctx, "u1", "TSLA", base.Add(2*time.Minute), 3)
+	s.Add(ctx, "u1", "AAPL", base.Add(3*time.Minute), 3) // moves to front
+	s.Add(ctx, "u1", "NVDA", base.Add(4*time.Minute), 3) // evicts MSFT
+	s.Add(ctx, "u2", "GOOGL", base, 3)
+
+	got, err := s.List(ctx, "u1", 3)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []string{"NVDA", "AAPL", "TSLA"}
+	if len(got) != len(want) {
+		t.Fatalf("List = %+v, want symbols %v", got, want)
+	}
+	for i, sym := range want {
+		if got[i].Symbol != sym {
+			t.Fatalf("List = %+v, want symbols %v", got, want)
+		}
+	}
+	if !got[1].ViewedAt.Equal(base.Add(3 * time.Minute)) {
+		t.Errorf("AAPL ViewedAt = %v, want the latest view", got[1].ViewedAt)
+	}
+}
+
+func TestRecentlyViewedService_RecordSkipsAnonymous(t *testing.T) {
+	svc := NewRecentlyViewedService(NewMemoryRecentlyViewedStore())
+	ctx := context.Background()
+	svc.Record(ctx, "", "AAPL")
+	svc.Record(ctx, "u1", "AAPL")
+
+	got, err := svc.List(ctx, "u1")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(got) != 1 || got[0].Symbol != "AAPL" {
+		t.Errorf("List = %+v, want [AAPL]", got)
+	}
+	if got, _ := svc.List(ctx, ""); len(got) != 0 {
+		t.Errorf("anonymous list = %+v, want empty", got)
+	}
+}
@@ -29,8 +29,8 @@ var avgEpsilon = decimal.RequireFromString("0.001")
 type Discrepancy struct {
 	UserID       string          `json:"user_id"`
 	Symbol       string          `json:"symbol"`
-	PortfolioQty int             `json:"portfolio_qty"`
-	LedgerQty    int             `json:"ledger_qty"`
+	PortfolioQty decimal.Decimal `json:"portfolio_qty"`
+	LedgerQty    decimal.Decimal `json:"ledger_qty"`
 	PortfolioAvg decimal.Decimal `json:"portfolio_avg"`
 	LedgerAvg    decimal.Decimal `json:"ledger_avg"`
 	Kind         string          `json:"kind"`
@@ -53,10 +53,24 @@ func NewReconcileService(db *sql.DB, portfolioStore *data.PortfolioStore, trades
 	}
 }
 
-// ledgerEntry holds the replayed position for a single symbol.
+// ledgerEntry holds the replayed position for a single symbol. Long shares
+// (BUY/SELL) and short shares (SHORT/COVER) are tracked apart so that a SELL
+// past zero is still caught as an anomaly rather than read as a short.
 type ledgerEntry struct {
-	qty      int
+	qty      decimal.Decimal
 	avgPrice decimal.Decimal
+	short    decimal.Decimal
+	shortAvg decimal.Decimal
+}
+
+// position returns the net quantity and average price the portfolio row
+// should hold. The service never lets a user be long and short the same
+// symbol, so at most one side is open.
+func (e *ledgerEntry) position() (decimal.Decimal, decimal.Decimal) {
+	if !e.short.IsZero() {
+		return e.qty.Sub(e.short), e.shortAvg
+	}
+	return e.qty, e.avgPrice
 }
 
 // Reconcile replays the trade ledger for userID and compares it against the
@@ -78,19 +92,29 @@ func (r *ReconcileService) Reconcile(ctx context.Context, userID string) ([]Disc
 		}
 		switch t.Action {
 		case "BUY":
-			newQty := entry.qty + t.Quantity
-			if newQty > 0 {
-				existingTotal := entry.avgPrice.Mul(decimal.NewFromInt(int64(entry.qty)))
-				addedTotal := t.Price.Mul(decimal.NewFromInt(int64(t.Quantity)))
-				entry.avgPrice = existingTotal.Add(addedTotal).Div(decimal.NewFromInt(int64(newQty)))
+			newQty := entry.qty.Add(t.Quantity)
+			if newQty.IsPositive() {
+				existingTotal := entry.avgPrice.Mul(entry.qty)
+				addedTotal := t.Price.Mul(t.Quantity)
+				entry.avgPrice = existingTotal.Add(addedTotal).Div(newQty)
 			}
 			entry.qty = newQty
 		case "SELL":
-			entry.qty -= t.Quantity
+			entry.qty = entry.qty.Sub(t.Quantity)
 			// avg price unchanged on sell
-			if entry.qty == 0 {
-				delete(expected, t.Symbol)
+		case "SHORT":
+			newShort := entry.short.Add(t.Quantity)
+			if newShort.IsPositive() {
+				existingTotal := entry.shortAvg.Mul(entry.short)
+				addedTotal := t.Price.Mul(t.Quantity)
+				entry.shortAvg = existingTotal.Add(addedTotal).Div(newShort)
 			}
+			entry.short = newShort
+		case "COVER":
+			entry.short = entry.short.Sub(t.Quantity)
+		}
+		if entry.qty.IsZero() && entry.short.IsZero() {
+			delete(expected, t.Symbol)
 		}
 	}
 
@@ -108,7 +132,7 @@ func (r *ReconcileService) Reconcile(ctx context.Context, userID string) ([]Disc
 
 	// Check expected symbols against actual.
 	for sym, exp := range expected {
-		if exp.qty < 0 {
+		if exp.qty.IsNegative() {
 			// More SELLs than BUYs in the ledger — a real anomaly.
 			discrepancies = append(discrepancies, Discrepancy{
 				UserID:    userID,
@@ -118,7 +142,8 @@ func (r *ReconcileService) Reconcile(ctx context.Context, userID string) ([]Disc
 			})
 			continue
 		}
-		if exp.qty == 0 {
+		qty, avgPrice := exp.position()
+		if qty.IsZero() {
 			continue
 		}
 		act, found := actual[sym]
@@ -126,32 +151,32 @@ func (r *ReconcileService) Reconcile(ctx context.Context, userID string) ([]Disc
 			discrepancies = append(discrepancies, Discrepancy{
 				UserID:       userID,
 				Symbol:       sym,
-				PortfolioQty: 0,
-				LedgerQty:    exp.qty,
+				PortfolioQty: decimal.Zero,
+				LedgerQty:    qty,
 				PortfolioAvg: decimal.Zero,
-				LedgerAvg:    exp.avgPrice,
+				LedgerAvg:    avgPrice,
 				Kind:         KindMissingPortfolio,
 			})
 			continue
 		}
-		if act.Quantity != exp.qty {
+		if !act.Quantity.Equal(qty) {
 			discrepancies = append(discrepancies, Discrepancy{
 				UserID:       userID,
 				Symbol:       sym,
 				PortfolioQty: act.Quantity,
-				LedgerQty:    exp.qty,
+				LedgerQty:    qty,
 				PortfolioAvg: act.AvgPrice,
-				LedgerAvg:    exp.avgPrice,
+				LedgerAvg:    avgPrice,
 				Kind:         KindQuantityMismatch,
 			})
-		} else if act.AvgPrice.Sub(exp.avgPrice).Abs().GreaterThan(avgEpsilon) {
+		} else if act.AvgPrice.Sub(avgPrice).Abs().GreaterThan(avgEpsilon) {
 			discrepancies = append(discrepancies, Discrepancy{
 				UserID:       userID,
 				Symbol:       sym,
 				PortfolioQty: act.Quantity,
-				LedgerQty:    exp.qty,
+				LedgerQty:    qty,
 				PortfolioAvg: act.AvgPrice,
-				LedgerAvg:    exp.avgPrice,
+				LedgerAvg:    avgPrice,
 				Kind:         KindAvgPriceMismatch,
 			})
 		}
@@ -164,7 +189,7 @@ func (r *ReconcileService) Reconcile(ctx context.Context, userID string) ([]Disc
 				UserID:       userID,
 				Symbol:       sym,
 				PortfolioQty: act.Quantity,
-				LedgerQty:    0,
+				LedgerQty:    decimal.Zero,
 				PortfolioAvg: act.AvgPrice,
 				LedgerAvg:    decimal.Zero,
 				Kind:         KindOrphanPortfolio,
@@ -51,7 +51,7 @@ func TestReconcile_DetectsDriftAfterDirectPortfolioMutation(t *testing.T) {
 	// Execute 10 BuyStock calls for AAPL (each with a unique idempotency key).
 	for i := 0; i < 10; i++ {
 		ikey := fmt.Sprintf("buy-setup-%d-%s", i, userID[:8])
-		if _, err := svc.BuyStock(context.Background(), userID, "AAPL", 1, ikey); err != nil {
+		if _, err := svc.BuyStock(context.Background(), userID, "AAPL", decimal.NewFromInt(1), ikey); err != nil {
 			t.Fatalf("BuyStock %d: %v", i, err)
 		}
 	}
@@ -59,7 +59,7 @@ func TestReconcile_DetectsDriftAfterDirectPortfolioMutation(t *testing.T) {
 	// Execute 2 SellStock calls for AAPL.
 	for i := 0; i < 2; i++ {
 		ikey := fmt.Sprintf("sell-setup-%d-%s", i, userID[:8])
-		if _, err := svc.SellStock(context.Background(), userID, "AAPL", 1, ikey); err != nil {
+		if _, err := svc.SellStock(context.Background(), userID, "AAPL", decimal.NewFromInt(1), ikey); err != nil {
 			t.Fatalf("SellStock %d: %v", i, err)
 		}
 	}
@@ -113,10 +113,10 @@ func TestReconcile_DetectsDriftAfterDirectPortfolioMutation(t *testing.T) {
 		t.Errorf("discrepancy symbol: got %q, want AAPL", d.Symbol)
 	}
 	// Ledger says 8; portfolio now says 9 (manually incremented).
-	if d.LedgerQty != 8 {
-		t.Errorf("LedgerQty: got %d, want 8", d.LedgerQty)
+	if !d.LedgerQty.Equal(decimal.NewFromInt(8)) {
+		t.Errorf("LedgerQty: got %s, want 8", d.LedgerQty)
 	}
-	if d.PortfolioQty != 9 {
-		t.Errorf("PortfolioQty: got %d, want 9", d.PortfolioQty)
+	if !d.PortfolioQty.Equal(decimal.NewFromInt(9)) {
+		t.Errorf("PortfolioQty: got %s, want 9", d.PortfolioQty)
 	}
 }
@@ -14,12 +14,12 @@ import (
 
 // allTradesCols matches GetAllTradesByUserID SELECT list.
 var allTradesCols = []string{
-	"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status", "idempotency_key",
+	"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status", "idempotency_key", "order_type", "slippage",
 }
 
 // portfolioRowCols matches GetPortfolioByUserID SELECT list.
 var portfolioRowCols = []string{
-	"id", "user_id", "symbol", "quantity", "avg_price", "created_at", "updated_at",
+	"id", "user_id", "symbol", "quantity", "avg_price", "margin", "created_at", "updated_at",
 }
 
 func newReconcileService(db *sql.DB) *ReconcileService {
@@ -29,7 +29,7 @@ func newReconcileService(db *sql.DB) *ReconcileService {
 // addTrade is a helper to add a trade row to sqlmock rows.
 func addTrade(rows *sqlmock.Rows, id, userID, symbol, action string, qty int, price decimal.Decimal, at time.Time) *sqlmock.Rows {
 	total := price.Mul(decimal.NewFromInt(int64(qty)))
-	return rows.AddRow(id, userID, symbol, action, qty, price, total, at, "COMPLETED", nil)
+	return rows.AddRow(id, userID, symbol, action, qty, price, total, at, "COMPLETED", nil, "MARKET", "0")
 }
 
 // ---- TestReconcile_NoDiscrepanciesAfterTrades ----
@@ -59,8 +59,8 @@ func TestReconcile_NoDiscrepanciesAfterTrades(t *testing.T) {
 
 	// Expected: AAPL qty=4, avg=106; TSLA qty=1, avg=200
 	portRows := sqlmock.NewRows(portfolioRowCols).
-		AddRow("p1", "user-1", "AAPL", 4, decimal.NewFromFloat(106.0), now, now).
-		AddRow("p2", "user-1", "TSLA", 1, decimal.NewFromFloat(200.0), now, now)
+		AddRow("p1", "user-1", "AAPL", 4, decimal.NewFromFloat(106.0), decimal.Zero, now, now).
+		AddRow("p2", "user-1", "TSLA", 1, decimal.NewFromFloat(200.0), decimal.Zero, now, now)
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1").
 		WillReturnRows(portRows)
@@ -99,7 +99,7 @@ func TestReconcile_DetectsQuantityMismatch(t *testing.T) {
 
 	// Portfolio: shows only 5 (drift)
 	portRows := sqlmock.NewRows(portfolioRowCols).
-		AddRow("p1", "user-1", "AAPL", 5, decimal.NewFromFloat(100.0), now, now)
+		AddRow("p1", "user-1", "AAPL", 5, decimal.NewFromFloat(100.0), decimal.Zero, now, now)
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1").
 		WillReturnRows(portRows)
@@ -114,11 +114,11 @@ func TestReconcile_DetectsQuantityMismatch(t *testing.T) {
 	if discrepancies[0].Kind != KindQuantityMismatch {
 		t.Errorf("kind: got %q, want %q", discrepancies[0].Kind, KindQuantityMismatch)
 	}
-	if discrepancies[0].LedgerQty != 7 {
-		t.Errorf("ledger qty: got %d, want 7", discrepancies[0].LedgerQty)
+	if !discrepancies[0].LedgerQty.Equal(decimal.NewFromInt(7)) {
+		t.Errorf("ledger qty: got %s, want 7", discrepancies[0].LedgerQty)
 	}
-	if discrepancies[0].PortfolioQty != 5 {
-		t.Errorf("portfolio qty: got %d, want 5", discrepancies[0].PortfolioQty)
+	if !discrepancies[0].PortfolioQty.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("portfolio qty: got %s, want 5", discrepancies[0].PortfolioQty)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled sql expectations: %v", err)
@@ -147,7 +147,7 @@ func TestReconcile_DetectsAvgPriceMismatch(t *testing.T) {
 
 	// Portfolio: same qty but wrong avg (150)
 	portRows := sqlmock.NewRows(portfolioRowCols).
-		AddRow("p1", "user-1", "AAPL", 5, decimal.NewFromFloat(150.0), now, now)
+		AddRow("p1", "user-1", "AAPL", 5, decimal.NewFromFloat(150.0), decimal.Zero, now, now)
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1").
 		WillReturnRows(portRows)
@@ -194,7 +194,7 @@ func TestReconcile_DetectsOrphanPortfolio(t *testing.T) {
 
 	// Portfolio: has AAPL (orphan)
 	portRows := sqlmock.NewRows(portfolioRowCols).
-		AddRow("p1", "user-1", "AAPL", 3, decimal.NewFromFloat(100.0), now, now)
+		AddRow("p1", "user-1", "AAPL", 3, decimal.NewFromFloat(100.0), decimal.Zero, now, now)
 	mock.ExpectQuery("SELECT id, user_id, symbol").
 		WithArgs("user-1").
 		WillReturnRows(portRows)
@@ -254,8 +254,8 @@ func TestReconcile_DetectsNegativeLedgerQty(t *testing.T) {
 	if d.Kind != KindNegativeLedgerQty {
 		t.Errorf("kind: got %q, want %q", d.Kind, KindNegativeLedgerQty)
 	}
-	if d.LedgerQty != -3 {
-		t.Errorf("LedgerQty: got %d, want -3", d.LedgerQty)
+	if !d.LedgerQty.Equal(decimal.NewFromInt(-3)) {
+		t.Errorf("LedgerQty: got %s, want -3", d.LedgerQty)
 	}
 	if d.Symbol != "AAPL" {
 		t.Errorf("symbol: got %q, want AAPL", d.Symbol)
@@ -300,8 +300,51 @@ func TestReconcile_DetectsMissingPortfolio(t *testing.T) {
 	if discrepancies[0].Kind != KindMissingPortfolio {
 		t.Errorf("kind: got %q, want %q", discrepancies[0].Kind, KindMissingPortfolio)
 	}
-	if discrepancies[0].LedgerQty != 5 {
-		t.Errorf("ledger qty: got %d, want 5", discrepancies[0].LedgerQty)
+	if !discrepancies[0].LedgerQty.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("ledger qty: got %s, want 5", discrepancies[0].LedgerQty)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+// ---- TestReconcile_ShortPositionMatches ----
+
+func TestReconcile_ShortPositionMatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	svc := newReconcileService(db)
+	now := time.Now()
+
+	// SHORT 2@100, SHORT 2@110 → short 4 at avg 105; COVER 1 → short 3 at 105.
+	// A fully covered TSLA short leaves nothing behind.
+	tradeRows := sqlmock.NewRows(allTradesCols)
+	addTrade(tradeRows, "t1", "user-1", "AAPL", "SHORT", 2, decimal.NewFromFloat(100.0), now.Add(-5*time.Hour))
+	addTrade(tradeRows, "t2", "user-1", "AAPL", "SHORT", 2, decimal.NewFromFloat(110.0), now.Add(-4*time.Hour))
+	addTrade(tradeRows, "t3", "user-1", "AAPL", "COVER", 1, decimal.NewFromFloat(95.0), now.Add(-3*time.Hour))
+	addTrade(tradeRows, "t4", "user-1", "TSLA", "SHORT", 1, decimal.NewFromFloat(200.0), now.Add(-2*time.Hour))
+	addTrade(tradeRows, "t5", "user-1", "TSLA", "COVER", 1, decimal.NewFromFloat(190.0), now.Add(-1*time.Hour))
+
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1").
+		WillReturnRows(tradeRows)
+
+	portRows := sqlmock.NewRows(portfolioRowCols).
+		AddRow("p1", "user-1", "AAPL", -3, decimal.NewFromFloat(105.0), decimal.NewFromInt(472), now, now)
+	mock.ExpectQuery("SELECT id, user_id, symbol").
+		WithArgs("user-1").
+		WillReturnRows(portRows)
+
+	discrepancies, err := svc.Reconcile(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(discrepancies) != 0 {
+		t.Errorf("expected no discrepancies, got %+v", discrepancies)
 	}
 	if err := mock.ExpectationsWereMet(); err != nil {
 		t.Errorf("unfulfilled sql expectations: %v", err)
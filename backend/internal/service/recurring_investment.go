@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// Recurring investment amount bounds, in dollars per run.
+var (
+	minRecurringAmount = decimal.NewFromInt(1)
+	maxRecurringAmount = decimal.NewFromInt(1000000)
+)
+
+// RecurringInvestmentRequest describes a plan to create. Frequency is DAILY,
+// WEEKLY or MONTHLY. Day is required for WEEKLY (1 = Monday .. 5 = Friday)
+// and MONTHLY (1-28, so every month has it) and must be omitted for DAILY.
+type RecurringInvestmentRequest struct {
+	Symbol    string
+	Amount    decimal.Decimal
+	Frequency string
+	Day       *int
+}
+
+// RecurringInvestmentService manages recurring investments ("buy $100 of
+// VOO every Monday") and runs them. Each run buys as many whole shares as
+// Amount covers at the current quote through InvestmentService.BuyStock, so
+// it passes the same pre-trade checks as a manual buy. A plan due on a day
+// the market is closed runs at the next session.
+type RecurringInvestmentService struct {
+	store         *data.RecurringInvestmentStore
+	investments   *InvestmentService
+	calendar      *MarketCalendar
+	notifications *NotificationService
+	maxPerUser    int // 0 = no cap
+	now           func() time.Time
+}
+
+// NewRecurringInvestmentService builds the service. notifications may be
+// nil. maxPerUser caps each user's plans, active or paused; 0 disables the
+// cap.
+func NewRecurringInvestmentService(store *data.RecurringInvestmentStore, investments *InvestmentService, calendar *MarketCalendar, notifications *NotificationService, maxPerUser int) *RecurringInvestmentService {
+	return &RecurringInvestmentService{
+		store:         store,
+		investments:   investments,
+		calendar:      calendar,
+		notifications: notifications,
+		maxPerUser:    maxPerUser,
+		now:           time.Now,
+	}
+}
+
+// Create adds a plan. Its first run is on the first scheduled date from the
+// next session on, so a "every Monday" plan created on a Monday during the
+// session runs that day.
+func (s *RecurringInvestmentService) Create(ctx context.Context, userID string, req RecurringInvestmentRequest) (*data.RecurringInvestment, error) {
+	symbol, err := util.ValidateSymbol(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	// recurring_investments.amount is NUMERIC(15,2).
+	amount := req.Amount
+	switch {
+	case amount.LessThan(minRecurringAmount):
+		return nil, &util.ValidationError{Field: "amount", Message: "must be at least $" + minRecurringAmount.StringFixed(2)}
+	case amount.GreaterThan(maxRecurringAmount):
+		return nil, &util.ValidationError{Field: "amount", Message: "must be at most $" + maxRecurringAmount.StringFixed(2)}
+	case !amount.Equal(amount.Round(2)):
+		return nil, &util.ValidationError{Field: "amount", Message: "must have at most 2 decimal places"}
+	}
+	frequency := strings.ToUpper(strings.TrimSpace(req.Frequency))
+	switch frequency {
+	case data.RecurringDaily:
+		if req.Day != nil {
+			return nil, &util.ValidationError{Field: "day", Message: "must be omitted for DAILY plans"}
+		}
+	case data.RecurringWeekly:
+		if req.Day == nil || *req.Day < int(time.Monday) || *req.Day > int(time.Friday) {
+			return nil, &util.ValidationError{Field: "day", Message: "must be 1 (Monday) to 5 (Friday) for WEEKLY plans"}
+		}
+	case data.RecurringMonthly:
+		if req.Day == nil || *req.Day < 1 || *req.Day > 28 {
+			return nil, &util.ValidationError{Field: "day", Message: "must be 1 to 28 for MONTHLY plans"}
+		}
+	default:
+		return nil, &util.ValidationError{Field: "frequency", Message: "must be DAILY, WEEKLY or MONTHLY"}
+	}
+
+	// Count-then-insert is not atomic; as with pending orders, two
+	// concurrent creates can overshoot the soft cap by one.
+	if s.maxPerUser > 0 {
+		n, err := s.store.CountByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if n >= s.maxPerUser {
+			return nil, &RecurringInvestmentLimitError{Limit: s.maxPerUser}
+		}
+	}
+
+	plan, err := s.store.Create(ctx, &data.RecurringInvestment{
+		UserID:    userID,
+		Symbol:    symbol,
+		Amount:    amount,
+		Frequency: frequency,
+		Day:       req.Day,
+		NextRunOn: s.firstRun(frequency, req.Day),
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("recurring investment created",
+		"recurring_id", plan.ID, "user_id", userID, "symbol", symbol, "amount", amount,
+		"frequency", frequency, "day", req.Day, "next_run_on", plan.NextRunOn, "component", "recurring")
+	return plan, nil
+}
+
+// List returns the user's plans, oldest first.
+func (s *RecurringInvestmentService) List(ctx context.Context, userID string) ([]data.RecurringInvestment, error) {
+	return s.store.ListByUser(ctx, userID)
+}
+
+// SetActive pauses or resumes a plan. A resumed plan is rescheduled as if
+// newly created, so runs missed while it was paused are not made up.
+func (s *RecurringInvestmentService) SetActive(ctx context.Context, userID, id string, active bool) (*data.RecurringInvestment, error) {
+	plan, err := s.store.Get(ctx, userID, id)
+	if errors.Is(err, data.ErrRecurringInvestmentNotFound) {
+		return nil, &RecurringInvestmentNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	plan, err = s.store.SetActive(ctx, userID, id, active, s.firstRun(plan.Frequency, plan.Day))
+	if errors.Is(err, data.ErrRecurringInvestmentNotFound) {
+		return nil, &RecurringInvestmentNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("recurring investment updated", "recurring_id", id, "user_id", userID, "active", active,
+		"next_run_on", plan.NextRunOn, "component", "recurring")
+	return plan, nil
+}
+
+// Delete removes a plan. Trades it already made are kept.
+func (s *RecurringInvestmentService) Delete(ctx context.Context, userID, id string) error {
+	err := s.store.Delete(ctx, userID, id)
+	if errors.Is(err, data.ErrRecurringInvestmentNotFound) {
+		return &RecurringInvestmentNotFoundError{}
+	}
+	if err != nil {
+		return err
+	}
+	slog.Info("recurring investment deleted", "recurring_id", id, "user_id", userID, "component", "recurring")
+	return nil
+}
+
+// firstRun is the first scheduled date on or after the next session's date.
+func (s *RecurringInvestmentService) firstRun(frequency string, day *int) string {
+	open := s.calendar.NextSession(s.now()).Open
+	y, m, d := open.Date()
+	return scheduledOnOrAfter(frequency, day, time.Date(y, m, d, 0, 0, 0, 0, time.UTC)).Format(time.DateOnly)
+}
+
+// scheduledOnOrAfter returns the first date from date on (both UTC
+// midnights standing for New York calendar dates) that the plan is
+// scheduled for. Whether that date is a trading day doesn't matter here.
+func scheduledOnOrAfter(frequency string, day *int, date time.Time) time.Time {
+	switch frequency {
+	case data.RecurringWeekly:
+		return date.AddDate(0, 0, (*day-int(date.Weekday())+7)%7)
+	case data.RecurringMonthly:
+		y, m, d := date.Date()
+		if d > *day {
+			m++
+		}
+		return time.Date(y, m, *day, 0, 0, 0, 0, time.UTC)
+	}
+	return date
+}
+
+// RunDue runs every active plan that is due, when the market is open and,
+// for a non-US listing, its own market is too.
+// Returns how many bought shares. A plan whose run fails for a reason that
+// retrying won't fix (too little cash, a halted symbol, an amount below one
+// share) is skipped until its next scheduled date and its owner told why;
+// any other failure leaves it due for the next pass.
+func (s *RecurringInvestmentService) RunDue(ctx context.Context) (int, error) {
+	now := s.now()
+	if !s.calendar.IsOpen(now) {
+		return 0, nil
+	}
+	today := now.In(s.calendar.loc).Format(time.DateOnly)
+	plans, err := s.store.ListDue(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+
+	bought := 0
+	for i := range plans {
+		if err := ctx.Err(); err != nil {
+			return bought, err
+		}
+		// A plan on a non-US listing waits, still due, for its own market.
+		if !s.calendar.ForSymbol(plans[i].Symbol).IsOpen(now) {
+			continue
+		}
+		if s.run(ctx, &plans[i], today) {
+			bought++
+		}
+	}
+	return bought, nil
+}
+
+// run makes one plan's purchase for today and moves it to its next
+// scheduled date. The buy's idempotency key names the plan and the day, so
+// a pass that dies before advancing the plan, or two instances running the
+// same plan, replay the first buy instead of buying twice.
+func (s *RecurringInvestmentService) run(ctx context.Context, plan *data.RecurringInvestment, today string) bool {
+	log := slog.With("recurring_id", plan.ID, "user_id", plan.UserID, "symbol", plan.Symbol, "component", "recurring")
+	key := "recurring:" + plan.ID + ":" + today
+
+	quantity, price, err := s.size(ctx, plan)
+	if err == nil {
+		_, err = s.investments.BuyStock(ctx, plan.UserID, plan.Symbol, quantity, key)
+	}
+	var reason, tradeID string
+	if err != nil {
+		message, status, _ := util.MapServiceError(err)
+		if status >= http.StatusInternalServerError {
+			log.Error("recurring investment failed; will retry", "err", err)
+			return false
+		}
+		reason = message
+	} else if trade, err := s.investments.tradesStore.GetTradeByIdempotencyKey(ctx, plan.UserID, key); err != nil {
+		log.Warn("failed to look up recurring investment trade", "err", err)
+	} else if trade != nil {
+		// On a replay the trade may predate this pass's quote.
+		tradeID, quantity, price = trade.ID, trade.Quantity, trade.Price
+	}
+
+	date, _ := time.Parse(time.DateOnly, today)
+	next := scheduledOnOrAfter(plan.Frequency, plan.Day, date.AddDate(0, 0, 1)).Format(time.DateOnly)
+	advanced, err := s.store.Advance(ctx, plan.ID, plan.NextRunOn, today, next, tradeID, reason)
+	if err != nil {
+		log.Error("failed to advance recurring investment", "err", err)
+		return false
+	}
+	if !advanced {
+		// Another instance ran it, or the user paused or deleted it.
+		return false
+	}
+
+	label := fmt.Sprintf("%s $%s investment in %s", strings.ToLower(plan.Frequency), plan.Amount.StringFixed(2), plan.Symbol)
+	if reason != "" {
+		log.Info("recurring investment skipped", "reason", reason, "next_run_on", next)
+		s.notify(ctx, plan.UserID, NotificationRecurringSkip, "Recurring investment in "+plan.Symbol+" skipped",
+			fmt.Sprintf("Your %s was skipped: %s.", label, strings.TrimSuffix(reason, ".")))
+		return false
+	}
+	log.Info("recurring investment bought", "trade_id", tradeID, "quantity", quantity, "price", price, "next_run_on", next)
+	s.notify(ctx, plan.UserID, NotificationRecurringRun, "Recurring investment in "+plan.Symbol,
+		fmt.Sprintf("Bought %s %s at $%s for your %s.", quantity, plan.Symbol, price.StringFixed(2), label))
+	return true
+}
+
+// size works out how many shares the plan's amount buys at the current
+// quote, allowing for slippage. Stocks are bought in whole shares and crypto
+// to the smallest unit; the remainder stays as cash.
+func (s *RecurringInvestmentService) size(ctx context.Context, plan *data.RecurringInvestment) (decimal.Decimal, decimal.Decimal, error) {
+	quote, err := s.investments.marketService.GetStock(ctx, plan.Symbol)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	if !quote.Price.IsPositive() {
+		return decimal.Zero, decimal.Zero, fmt.Errorf("no price for %s", plan.Symbol)
+	}
+	price, _ := s.investments.fillPrice("BUY", quote.Price, s.investments.precisionOf(ctx, plan.Symbol))
+	places := int32(0)
+	if util.IsCryptoPair(plan.Symbol) {
+		places = util.QuantityDecimals
+	}
+	quantity := plan.Amount.Div(price).RoundDown(places)
+	if !quantity.IsPositive() {
+		return decimal.Zero, price, &util.ValidationError{
+			Message: fmt.Sprintf("$%s does not buy one share at $%s", plan.Amount.StringFixed(2), price.StringFixed(2))}
+	}
+	return quantity, price, nil
+}
+
+// notify is best-effort: the run has already been recorded.
+func (s *RecurringInvestmentService) notify(ctx context.Context, userID, kind, title, body string) {
+	if s.notifications == nil {
+		return
+	}
+	if err := s.notifications.Notify(ctx, []string{userID}, kind, title, body); err != nil {
+		slog.Warn("failed to send recurring investment notification", "user_id", userID, "kind", kind, "err", err, "component", "recurring")
+	}
+}
+
+// Run calls RunDue every interval until ctx is cancelled. Every API instance
+// may run it: buys are idempotent per plan and day, and only one instance
+// advances each plan.
+func (s *RecurringInvestmentService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.RunDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("recurring investment pass failed", "err", err, "component", "recurring")
+		} else if n > 0 {
+			slog.Info("recurring investments bought", "count", n, "component", "recurring")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// recurringCols matches the recurring_investments column list.
+var recurringCols = []string{
+	"id", "user_id", "symbol", "amount", "frequency", "day", "active",
+	"next_run_on", "last_run_on", "last_trade_id", "last_error", "created_at",
+}
+
+// Monday 2 March 2026, 10:00 in New York: the market is open.
+var recurringNow = time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+func newRecurringService(t *testing.T, market *mockMarket) (*RecurringInvestmentService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	calendar, err := NewMarketCalendar()
+	if err != nil {
+		t.Fatalf("NewMarketCalendar: %v", err)
+	}
+	investments := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db))
+	svc := NewRecurringInvestmentService(data.NewRecurringInvestmentStore(db), investments, calendar, nil, 2)
+	svc.now = func() time.Time { return recurringNow }
+	return svc, mock
+}
+
+func dueWeeklyRow(amount string) *sqlmock.Rows {
+	return sqlmock.NewRows(recurringCols).AddRow(
+		"rec-1", "user-1", "VOO", decimal.RequireFromString(amount), "WEEKLY", 1, true,
+		time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), nil, nil, nil, time.Now(),
+	)
+}
+
+func TestScheduledOnOrAfter(t *testing.T) {
+	day := func(d int) *int { return &d }
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name      string
+		frequency string
+		day       *int
+		from      time.Time
+		want      time.Time
+	}{
+		{"daily", data.RecurringDaily, nil, date(2026, 3, 7), date(2026, 3, 7)},
+		{"weekly same day", data.RecurringWeekly, day(1), date(2026, 3, 2), date(2026, 3, 2)},
+		{"weekly later in week", data.RecurringWeekly, day(5), date(2026, 3, 2), date(2026, 3, 6)},
+		{"weekly next week", data.RecurringWeekly, day(1), date(2026, 3, 3), date(2026, 3, 9)},
+		{"monthly this month", data.RecurringMonthly, day(15), date(2026, 3, 2), date(2026, 3, 15)},
+		{"monthly next month", data.RecurringMonthly, day(1), date(2026, 3, 2), date(2026, 4, 1)},
+		{"monthly across year end", data.RecurringMonthly, day(28), date(2026, 12, 29), date(2027, 1, 28)},
+	}
+	for _, tc := range cases {
+		if got := scheduledOnOrAfter(tc.frequency, tc.day, tc.from); !got.Equal(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.name, got.Format(time.DateOnly), tc.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestRecurringCreate_Validation(t *testing.T) {
+	svc, mock := newRecurringService(t, &mockMarket{})
+	day := func(d int) *int { return &d }
+
+	cases := []struct {
+		name, amount, frequency string
+		day                     *int
+		field                   string
+	}{
+		{"zero amount", "0", "DAILY", nil, "amount"},
+		{"sub-cent amount", "10.005", "DAILY", nil, "amount"},
+		{"too large", "1000000.01", "DAILY", nil, "amount"},
+		{"unknown frequency", "100", "YEARLY", nil, "frequency"},
+		{"daily with day", "100", "DAILY", day(1), "day"},
+		{"weekly without day", "100", "WEEKLY", nil, "day"},
+		{"weekly on saturday", "100", "WEEKLY", day(6), "day"},
+		{"monthly on the 31st", "100", "MONTHLY", day(31), "day"},
+	}
+	for _, tc := range cases {
+		_, err := svc.Create(context.Background(), "user-1", RecurringInvestmentRequest{
+			Symbol: "VOO", Amount: decimal.RequireFromString(tc.amount), Frequency: tc.frequency, Day: tc.day,
+		})
+		var ve *util.ValidationError
+		if !errors.As(err, &ve) || ve.Field != tc.field {
+			t.Errorf("%s: expected validation error on %q, got %v", tc.name, tc.field, err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+}
+
+// Created during Monday's session, an every-Monday plan first runs that day.
+func TestRecurringCreate_FirstRunToday(t *testing.T) {
+	svc, mock := newRecurringService(t, &mockMarket{})
+	monday := 1
+
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("INSERT INTO recurring_investments").
+		WithArgs(sqlmock.AnyArg(), "user-1", "VOO", decimal.NewFromInt(100), "WEEKLY", sqlmock.AnyArg(), "2026-03-02").
+		WillReturnRows(dueWeeklyRow("100"))
+
+	plan, err := svc.Create(context.Background(), "user-1", RecurringInvestmentRequest{
+		Symbol: "voo", Amount: decimal.NewFromInt(100), Frequency: "weekly", Day: &monday,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if plan.NextRunOn != "2026-03-02" {
+		t.Errorf("next_run_on = %s, want 2026-03-02", plan.NextRunOn)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRecurringCreate_LimitReached(t *testing.T) {
+	svc, mock := newRecurringService(t, &mockMarket{})
+
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	_, err := svc.Create(context.Background(), "user-1", RecurringInvestmentRequest{
+		Symbol: "VOO", Amount: decimal.NewFromInt(100), Frequency: "DAILY",
+	})
+	var le *RecurringInvestmentLimitError
+	if !errors.As(err, &le) || le.Limit != 2 {
+		t.Errorf("expected RecurringInvestmentLimitError{2}, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// $1,000 at $150 buys 6 whole shares; the plan moves to the next Monday.
+func TestRunDue_BuysWholeSharesAndAdvances(t *testing.T) {
+	price := decimal.NewFromInt(150)
+	svc, mock := newRecurringService(t, &mockMarket{stock: &StockData{Symbol: "VOO", Price: price}})
+	key := "recurring:rec-1:2026-03-02"
+
+	mock.ExpectQuery("FROM recurring_investments").WithArgs("2026-03-02").
+		WillReturnRows(dueWeeklyRow("1000"))
+	mock.ExpectQuery("SELECT id, user_id, symbol").WithArgs("user-1", key).
+		WillReturnRows(sqlmock.NewRows(idempColsCols))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(newBalanceRow(decimal.NewFromInt(5000)))
+	mock.ExpectExec("UPDATE users SET balance").
+		WithArgs(decimal.NewFromInt(4100), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "VOO", "BUY", decimal.NewFromInt(6), price, "COMPLETED", key, data.OrderTypeMarket, decimal.Zero).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("SELECT id, user_id, symbol").WithArgs("user-1", "VOO").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectExec("INSERT INTO portfolio").
+		WithArgs(sqlmock.AnyArg(), "user-1", "VOO", decimal.NewFromInt(6), price).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery("INSERT INTO tax_lots").
+		WithArgs(sqlmock.AnyArg(), "user-1", "VOO", sqlmock.AnyArg(), decimal.NewFromInt(6), price).
+		WillReturnRows(sqlmock.NewRows([]string{"acquired_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT id, user_id, symbol").WithArgs("user-1", key).
+		WillReturnRows(sqlmock.NewRows(idempColsCols).AddRow(
+			"trade-1", "user-1", "VOO", "BUY", 6, price, decimal.NewFromInt(900), time.Now(), "COMPLETED",
+			key, "MARKET", "0",
+		))
+	mock.ExpectExec("UPDATE recurring_investments").
+		WithArgs("rec-1", "2026-03-02", "2026-03-02", "2026-03-09", "trade-1", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := svc.RunDue(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("RunDue: got (%d, %v), want (1, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRunDue_AmountBelowOneShareIsSkipped(t *testing.T) {
+	svc, mock := newRecurringService(t, &mockMarket{stock: &StockData{Symbol: "VOO", Price: decimal.NewFromInt(450)}})
+
+	mock.ExpectQuery("FROM recurring_investments").WithArgs("2026-03-02").
+		WillReturnRows(dueWeeklyRow("100"))
+	mock.ExpectExec("UPDATE recurring_investments").
+		WithArgs("rec-1", "2026-03-02", "2026-03-02", "2026-03-09", "", "$100.00 does not buy one share at $450.00").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	n, err := svc.RunDue(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("RunDue: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// A provider outage is not the user's problem: the plan stays due.
+func TestRunDue_QuoteFailureRetries(t *testing.T) {
+	svc, mock := newRecurringService(t, &mockMarket{stockErr: errors.New("provider down")})
+
+	mock.ExpectQuery("FROM recurring_investments").WithArgs("2026-03-02").
+		WillReturnRows(dueWeeklyRow("1000"))
+
+	n, err := svc.RunDue(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("RunDue: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRunDue_NothingRunsWhileMarketClosed(t *testing.T) {
+	svc, mock := newRecurringService(t, &mockMarket{})
+	svc.now = func() time.Time { return time.Date(2026, 3, 7, 15, 0, 0, 0, time.UTC) } // Saturday
+
+	n, err := svc.RunDue(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("RunDue: got (%d, %v), want (0, nil)", n, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unexpected queries: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// maxReplayMarkers caps the trades returned with a replay; the newest are
+// kept.
+const maxReplayMarkers = 500
+
+// TradeMarker is one of the user's trades placed on a price chart. Date is
+// the exchange (New York) date the trade executed on, matching the chart's
+// points.
+type TradeMarker struct {
+	TradeID    string          `json:"trade_id"`
+	Action     string          `json:"action"` // BUY, SELL, SHORT or COVER
+	Quantity   decimal.Decimal `json:"quantity"`
+	Price      decimal.Decimal `json:"price"`
+	Date       string          `json:"date"` // YYYY-MM-DD
+	ExecutedAt time.Time       `json:"executed_at"`
+	OrderType  string          `json:"order_type"`
+}
+
+// TradeReplay is a symbol's daily closes with the user's trades in it over
+// the same window, oldest first. Trades run to now, so today's trades sit
+// past the last close. Truncated is set when only the newest
+// maxReplayMarkers trades were kept.
+type TradeReplay struct {
+	Symbol    string                  `json:"symbol"`
+	From      string                  `json:"from"`
+	To        string                  `json:"to"`
+	Points    []HistoricalSeriesPoint `json:"points"`
+	Trades    []TradeMarker           `json:"trades"`
+	Truncated bool                    `json:"truncated"`
+	Currency  string                  `json:"currency,omitempty"`
+}
+
+// ReplayService puts a user's trades on a symbol's price history.
+type ReplayService struct {
+	series   HistoricalSeriesSource
+	trades   *data.TradesStore
+	calendar *MarketCalendar
+}
+
+func NewReplayService(series HistoricalSeriesSource, trades *data.TradesStore, calendar *MarketCalendar) *ReplayService {
+	return &ReplayService{series: series, trades: trades, calendar: calendar}
+}
+
+// Replay returns symbol's closes over the last days days (bounded as for
+// MarketService.GetHistoricalSeries) and userID's completed trades in
+// symbol since the first of them.
+func (s *ReplayService) Replay(ctx context.Context, userID, symbol string, days int) (*TradeReplay, error) {
+	symbol, err := util.ValidateSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	series, err := s.series.GetHistoricalSeries(ctx, symbol, days)
+	if err != nil {
+		return nil, err
+	}
+	from, err := time.ParseInLocation(time.DateOnly, series.From, s.calendar.loc)
+	if err != nil {
+		return nil, err
+	}
+	// One over the cap tells whether anything was cut.
+	trades, err := s.trades.GetTradesByUserID(ctx, userID, data.TradeQueryOpts{Symbol: symbol, From: from, Limit: maxReplayMarkers + 1})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &TradeReplay{Symbol: series.Symbol, From: series.From, To: series.To, Points: series.Points, Trades: make([]TradeMarker, 0, len(trades))}
+	if len(trades) > maxReplayMarkers {
+		trades, out.Truncated = trades[:maxReplayMarkers], true
+	}
+	for _, t := range slices.Backward(trades) {
+		if t.Status != "COMPLETED" {
+			continue
+		}
+		out.Trades = append(out.Trades, TradeMarker{
+			TradeID:    t.ID,
+			Action:     t.Action,
+			Quantity:   t.Quantity,
+			Price:      t.Price,
+			Date:       t.ExecutedAt.In(s.calendar.loc).Format(time.DateOnly),
+			ExecutedAt: t.ExecutedAt,
+			OrderType:  t.OrderType,
+		})
+	}
+	return out, nil
+}
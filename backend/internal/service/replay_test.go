@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+func TestReplay_MarksTradesOnSeries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	series := &mockSeries{series: &HistoricalSeries{Symbol: "AAPL", From: "2026-09-16", To: "2026-10-15", Points: []HistoricalSeriesPoint{
+		{Date: "2026-10-14", Close: decimal.NewFromInt(230)},
+		{Date: "2026-10-15", Close: decimal.NewFromInt(232)},
+	}}}
+	svc := NewReplayService(series, data.NewTradesStore(db), newCalendar(t))
+
+	// Newest first from the store. 01:30 UTC on the 15th is the evening of
+	// the 14th in New York.
+	evening := time.Date(2026, time.October, 15, 1, 30, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM trades").
+		WithArgs("user-1", "AAPL", time.Date(2026, time.September, 16, 4, 0, 0, 0, time.UTC), maxReplayMarkers+1, 0).
+		WillReturnRows(sqlmock.NewRows(idempColsCols).
+			AddRow("t3", "user-1", "AAPL", "SELL", 5, "232", "1160", evening.Add(24*time.Hour), "COMPLETED", nil, "LIMIT", "0").
+			AddRow("t2", "user-1", "AAPL", "BUY", 1, "231", "231", evening, "FAILED", nil, "MARKET", "0").
+			AddRow("t1", "user-1", "AAPL", "BUY", 10, "229.5", "2295", evening, "COMPLETED", nil, "MARKET", "0"))
+
+	got, err := svc.Replay(context.Background(), "user-1", "aapl", 30)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if series.symbol != "AAPL" || series.days != 30 || len(got.Points) != 2 || got.Truncated {
+		t.Errorf("replay: got %+v (series %q, %d days)", got, series.symbol, series.days)
+	}
+	if len(got.Trades) != 2 {
+		t.Fatalf("trades: got %+v, want t1 and t3", got.Trades)
+	}
+	if m := got.Trades[0]; m.TradeID != "t1" || m.Date != "2026-10-14" || !m.Quantity.Equal(decimal.NewFromInt(10)) || !m.Price.Equal(decimal.RequireFromString("229.5")) {
+		t.Errorf("first marker: got %+v", m)
+	}
+	if m := got.Trades[1]; m.TradeID != "t3" || m.Action != "SELL" || m.OrderType != "LIMIT" {
+		t.Errorf("second marker: got %+v", m)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
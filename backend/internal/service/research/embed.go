@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"papertrader/internal/util"
 )
 
 // Embedder embeds text into fixed-dimension float32 vectors.
@@ -28,9 +30,9 @@ type VoyageEmbedder struct {
 	model      string
 }
 
-func NewVoyageEmbedder(apiKey string) *VoyageEmbedder {
+func NewVoyageEmbedder(apiKey string, client *http.Client) *VoyageEmbedder {
 	return &VoyageEmbedder{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: util.ClientWithTimeout(client, 30*time.Second),
 		apiKey:     apiKey,
 		model:      "voyage-finance-2",
 	}
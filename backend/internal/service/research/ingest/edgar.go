@@ -11,6 +11,8 @@ import (
 
 	"golang.org/x/net/html"
 	"golang.org/x/time/rate"
+
+	"papertrader/internal/util"
 )
 
 // maxFilingBytes caps an individual filing-body read so a pathological 10-K
@@ -40,9 +42,9 @@ type Filing struct {
 	URL             string
 }
 
-func NewEdgarClient(userAgent string) *EdgarClient {
+func NewEdgarClient(userAgent string, client *http.Client) *EdgarClient {
 	return &EdgarClient{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+		httpClient: util.ClientWithTimeout(client, 30*time.Second),
 		userAgent:  userAgent,
 		// 10 req/sec with burst of 10 — SEC's documented limit.
 		limiter: rate.NewLimiter(10, 10),
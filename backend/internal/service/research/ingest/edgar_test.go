@@ -14,7 +14,7 @@ import (
 
 // buildTestEdgarClient returns an EdgarClient pointed at the given httptest server.
 func buildTestEdgarClient(srv *httptest.Server, userAgent string) *EdgarClient {
-	c := NewEdgarClient(userAgent)
+	c := NewEdgarClient(userAgent, nil)
 	c.httpClient = srv.Client()
 	return c
 }
@@ -30,7 +30,7 @@ func TestEdgarClient_UserAgentHeader(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewEdgarClient("PaperTrader test@example.com")
+	c := NewEdgarClient("PaperTrader test@example.com", nil)
 	c.httpClient = srv.Client()
 	// Point all requests to the test server.
 	c.httpClient.Transport = rewriteHostTransport{target: srv.URL, inner: srv.Client().Transport}
@@ -65,7 +65,7 @@ func TestEdgarClient_CIKPadding(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewEdgarClient("PaperTrader test@example.com")
+	c := NewEdgarClient("PaperTrader test@example.com", nil)
 	c.httpClient = srv.Client()
 	c.httpClient.Transport = rewriteHostTransport{target: srv.URL, inner: srv.Client().Transport}
 
@@ -101,7 +101,7 @@ func TestEdgarClient_PrimaryDocumentURL(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewEdgarClient("PaperTrader test@example.com")
+	c := NewEdgarClient("PaperTrader test@example.com", nil)
 	c.httpClient = srv.Client()
 	c.httpClient.Transport = rewriteHostTransport{target: srv.URL, inner: srv.Client().Transport}
 
@@ -131,7 +131,7 @@ func TestEdgarClient_RateLimiter(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	c := NewEdgarClient("PaperTrader test@example.com")
+	c := NewEdgarClient("PaperTrader test@example.com", nil)
 	c.httpClient = srv.Client()
 	c.httpClient.Transport = rewriteHostTransport{target: srv.URL, inner: srv.Client().Transport}
 
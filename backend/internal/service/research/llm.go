@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"time"
+
+	"papertrader/internal/util"
 )
 
 // LLMOpts configures a single LLM call.
@@ -41,9 +43,11 @@ type GroqClient struct {
 	model      string
 }
 
-func NewGroqClient(apiKey string) *GroqClient {
+// NewGroqClient constructs a GroqClient for the default generation model.
+// client is the shared outbound client; calls are capped at 60 seconds.
+func NewGroqClient(apiKey string, client *http.Client) *GroqClient {
 	return &GroqClient{
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		httpClient: util.ClientWithTimeout(client, 60*time.Second),
 		apiKey:     apiKey,
 		model:      "llama-3.3-70b-versatile",
 	}
@@ -52,9 +56,9 @@ func NewGroqClient(apiKey string) *GroqClient {
 // NewGroqClientWithModel constructs a GroqClient targeting the given model.
 // Useful when a caller needs a different model than the default generation model
 // (e.g., a lightweight judge model for eval).
-func NewGroqClientWithModel(apiKey, model string) *GroqClient {
+func NewGroqClientWithModel(apiKey, model string, client *http.Client) *GroqClient {
 	return &GroqClient{
-		httpClient: &http.Client{Timeout: 60 * time.Second},
+		httpClient: util.ClientWithTimeout(client, 60*time.Second),
 		apiKey:     apiKey,
 		model:      model,
 	}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+const (
+	// retentionInterval is how often Run looks for inactive accounts.
+	retentionInterval = time.Hour
+	// retentionBatch bounds the accounts one pass warns or anonymizes, so a
+	// backlog is worked through over several passes.
+	retentionBatch = 200
+)
+
+// RetentionSender is the subset of EmailService used by RetentionService.
+type RetentionSender interface {
+	SendInactivityNoticeEmail(to string, lastLogin, deadline time.Time) error
+}
+
+// RetentionPolicy configures RetentionService. A zero InactiveAfter turns
+// the job off.
+type RetentionPolicy struct {
+	InactiveAfter time.Duration // since the last login, before the warning
+	Grace         time.Duration // after the warning, before anonymization
+	Exclude       []string      // emails never warned or anonymized, e.g. ADMIN_EMAILS
+}
+
+// RetentionRun is the outcome of one pass.
+type RetentionRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	Warned     int       `json:"warned"`
+	Anonymized int       `json:"anonymized"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// RetentionReport is the admin view of the retention job.
+type RetentionReport struct {
+	Enabled      bool `json:"enabled"`
+	InactiveDays int  `json:"inactive_days"`
+	GraceDays    int  `json:"grace_days"`
+	*data.RetentionCounts
+	LastRun *RetentionRun `json:"last_run,omitempty"` // this instance's last pass
+}
+
+// RetentionService anonymizes accounts nobody uses any more. An account not
+// logged in for InactiveAfter is emailed a warning; if it still hasn't
+// logged in Grace later, its personal data is scrubbed while its trades and
+// holdings stay for aggregate statistics (see data.UserStore.AnonymizeWarned).
+// It also records every successful login, as a LoginObserver.
+type RetentionService struct {
+	users   *data.UserStore
+	email   RetentionSender
+	storage ObjectStorage
+	policy  RetentionPolicy
+	now     func() time.Time
+
+	mu      sync.Mutex
+	lastRun *RetentionRun
+}
+
+// NewRetentionService builds the service. email may be nil, in which case
+// no warnings can be sent and so nothing is anonymized; storage may be nil,
+// leaving avatar objects behind.
+func NewRetentionService(users *data.UserStore, email RetentionSender, storage ObjectStorage, policy RetentionPolicy) *RetentionService {
+	exclude := make([]string, len(policy.Exclude))
+	for i, e := range policy.Exclude {
+		exclude[i] = strings.ToLower(strings.TrimSpace(e))
+	}
+	policy.Exclude = exclude
+	return &RetentionService{users: users, email: email, storage: storage, policy: policy, now: time.Now}
+}
+
+// Enabled reports whether the job warns and anonymizes accounts.
+func (s *RetentionService) Enabled() bool {
+	return s.policy.InactiveAfter > 0 && s.email != nil
+}
+
+// LoginSucceeded implements LoginObserver: the login restarts the
+// inactivity clock and withdraws any warning.
+func (s *RetentionService) LoginSucceeded(ctx context.Context, user *data.User) {
+	if err := s.users.RecordLogin(ctx, user.ID); err != nil {
+		slog.Warn("failed to record login", "user_id", user.ID, "err", err, "component", "retention")
+	}
+}
+
+// LoginFailed implements LoginObserver.
+func (s *RetentionService) LoginFailed(context.Context, *data.User) {}
+
+// Run calls RunOnce every retentionInterval until ctx is done. Every
+// instance may run it: accounts are claimed with row locks.
+func (s *RetentionService) Run(ctx context.Context) {
+	if !s.Enabled() {
+		return
+	}
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		if run, err := s.RunOnce(ctx); err != nil {
+			if ctx.Err() == nil {
+				slog.Error("retention pass failed", "err", err, "component", "retention")
+			}
+		} else if run.Warned > 0 || run.Anonymized > 0 {
+			slog.Info("retention pass", "warned", run.Warned, "anonymized", run.Anonymized, "component", "retention")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce anonymizes the accounts whose warning has run out, then warns
+// newly inactive ones, a batch of each. Returns RetentionDisabledError when
+// the job is off.
+func (s *RetentionService) RunOnce(ctx context.Context) (*RetentionRun, error) {
+	if !s.Enabled() {
+		return nil, &RetentionDisabledError{}
+	}
+	now := s.now()
+	run := &RetentionRun{StartedAt: now.UTC()}
+	err := s.anonymize(ctx, run, now)
+	if err == nil {
+		err = s.warn(ctx, run, now)
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+	s.mu.Lock()
+	s.lastRun = run
+	s.mu.Unlock()
+	return run, err
+}
+
+func (s *RetentionService) anonymize(ctx context.Context, run *RetentionRun, now time.Time) error {
+	n, avatars, err := s.users.AnonymizeWarned(ctx, now.Add(-s.policy.Grace), now, retentionBatch)
+	if err != nil {
+		return err
+	}
+	run.Anonymized = n
+	if s.storage != nil {
+		for _, key := range avatars {
+			if err := s.storage.Delete(ctx, key); err != nil {
+				slog.Warn("failed to delete avatar object", "key", key, "err", err, "component", "retention")
+			}
+		}
+	}
+	return nil
+}
+
+// warn claims inactive accounts and emails each its warning. A claim whose
+// email fails is released, so the account is warned on a later pass before
+// its grace period starts.
+func (s *RetentionService) warn(ctx context.Context, run *RetentionRun, now time.Time) error {
+	due, err := s.users.ClaimInactive(ctx, now.Add(-s.policy.InactiveAfter), now, retentionBatch, s.policy.Exclude)
+	if err != nil {
+		return err
+	}
+	deadline := now.Add(s.policy.Grace)
+	for _, u := range due {
+		if err := s.email.SendInactivityNoticeEmail(u.Email, u.LastLoginAt, deadline); err != nil {
+			slog.Warn("inactivity notice failed", "user_id", u.ID, "err", err, "component", "retention")
+			if err := s.users.ReleaseInactivityNotice(ctx, u.ID); err != nil {
+				slog.Error("failed to release inactivity notice", "user_id", u.ID, "err", err, "component", "retention")
+			}
+			continue
+		}
+		run.Warned++
+	}
+	return nil
+}
+
+// Report returns the policy, how many accounts stand at each step, and this
+// instance's last pass.
+func (s *RetentionService) Report(ctx context.Context) (*RetentionReport, error) {
+	counts, err := s.users.RetentionCounts(ctx, s.now().Add(-s.policy.InactiveAfter))
+	if err != nil {
+		return nil, err
+	}
+	if s.policy.InactiveAfter <= 0 {
+		counts.Inactive = 0
+	}
+	s.mu.Lock()
+	lastRun := s.lastRun
+	s.mu.Unlock()
+	return &RetentionReport{
+		Enabled:         s.Enabled(),
+		InactiveDays:    int(s.policy.InactiveAfter / (24 * time.Hour)),
+		GraceDays:       int(s.policy.Grace / (24 * time.Hour)),
+		RetentionCounts: counts,
+		LastRun:         lastRun,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+type recordingRetentionSender struct {
+	sent []string
+	fail string
+}
+
+func (s *recordingRetentionSender) SendInactivityNoticeEmail(to string, _, _ time.Time) error {
+	if to == s.fail {
+		return errors.New("provider down")
+	}
+	s.sent = append(s.sent, to)
+	return nil
+}
+
+func TestRetention_DisabledWithoutThresholdOrEmail(t *testing.T) {
+	for name, svc := range map[string]*RetentionService{
+		"no threshold": NewRetentionService(nil, &recordingRetentionSender{}, nil, RetentionPolicy{Grace: time.Hour}),
+		"no email":     NewRetentionService(nil, nil, nil, RetentionPolicy{InactiveAfter: time.Hour, Grace: time.Hour}),
+	} {
+		var disabled *RetentionDisabledError
+		if _, err := svc.RunOnce(context.Background()); !errors.As(err, &disabled) {
+			t.Errorf("%s: got %v, want RetentionDisabledError", name, err)
+		}
+	}
+}
+
+func TestRetention_AnonymizesThenWarns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	sender := &recordingRetentionSender{fail: "b@example.com"}
+	storage := &fakeStorage{}
+	svc := NewRetentionService(data.NewUserStore(db), sender, storage, RetentionPolicy{
+		InactiveAfter: 365 * 24 * time.Hour,
+		Grace:         30 * 24 * time.Hour,
+		Exclude:       []string{" Admin@Example.com"},
+	})
+	now := time.Date(2026, time.October, 2, 15, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE users u").
+		WithArgs(now.AddDate(0, 0, -30), now, retentionBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"avatar_key"}).AddRow("avatars/old.png").AddRow(nil))
+	mock.ExpectCommit()
+	mock.ExpectQuery("UPDATE users u SET inactivity_notice_sent_at").
+		WithArgs(now.Add(-365*24*time.Hour), now, retentionBatch, `{"admin@example.com"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "last_login_at"}).
+			AddRow("user-1", "a@example.com", now.AddDate(-2, 0, 0)).
+			AddRow("user-2", "b@example.com", now.AddDate(-2, 0, 0)))
+	// The send fails: the claim is released so a later pass warns again.
+	mock.ExpectExec("UPDATE users SET inactivity_notice_sent_at = NULL").
+		WithArgs("user-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	run, err := svc.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if run.Anonymized != 2 || run.Warned != 1 {
+		t.Errorf("run: got %+v, want 2 anonymized and 1 warned", run)
+	}
+	if len(sender.sent) != 1 || sender.sent[0] != "a@example.com" {
+		t.Errorf("sent: got %v", sender.sent)
+	}
+	if len(storage.deleted) != 1 || storage.deleted[0] != "avatars/old.png" {
+		t.Errorf("deleted avatars: got %v", storage.deleted)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"math"
+
+	"github.com/shopspring/decimal"
+)
+
+// tradingDaysPerYear annualises statistics computed from daily returns.
+const tradingDaysPerYear = 252
+
+// SeriesRisk holds the statistics of one equity curve. Volatility is the
+// annualised standard deviation of daily returns and MaxDrawdown the largest
+// fall from a running peak, both in percent. A statistic is nil when there
+// are too few points to compute it.
+type SeriesRisk struct {
+	Volatility        *decimal.Decimal `json:"volatility"`
+	MaxDrawdown       *decimal.Decimal `json:"max_drawdown"`
+	MaxDrawdownPeak   string           `json:"max_drawdown_peak,omitempty"`   // YYYY-MM-DD
+	MaxDrawdownTrough string           `json:"max_drawdown_trough,omitempty"` // YYYY-MM-DD
+}
+
+// RiskMetrics are a user's volatility-adjusted performance statistics over a
+// range, computed from the daily portfolio snapshots set against a
+// benchmark (see BenchmarkService.Compare), with bonus cash grants taken
+// out of the portfolio's daily returns. Sharpe is annualised and uses
+// RiskFreeRate, an annual percentage; Beta is measured against Symbol.
+type RiskMetrics struct {
+	Range        string          `json:"range"`
+	Symbol       string          `json:"symbol"`
+	Days         int             `json:"days"` // daily returns the statistics use
+	RiskFreeRate decimal.Decimal `json:"risk_free_rate"`
+	SeriesRisk
+	Sharpe    *decimal.Decimal `json:"sharpe"`
+	Beta      *decimal.Decimal `json:"beta"`
+	Benchmark SeriesRisk       `json:"benchmark"`
+}
+
+// RiskService computes risk statistics from the benchmark comparison.
+type RiskService struct {
+	benchmark    *BenchmarkService
+	riskFreeRate decimal.Decimal
+}
+
+// NewRiskService builds the service. riskFreeRate is the annual rate, in
+// percent, Sharpe ratios are measured against.
+func NewRiskService(benchmark *BenchmarkService, riskFreeRate decimal.Decimal) *RiskService {
+	return &RiskService{benchmark: benchmark, riskFreeRate: riskFreeRate}
+}
+
+// Metrics returns userID's risk statistics over rng against symbol, or the
+// default benchmark when symbol is empty. A drawdown needs two snapshots and
+// the spread of daily returns three, so a new account gets nil statistics
+// rather than an error.
+func (s *RiskService) Metrics(ctx context.Context, userID, rng, symbol string) (*RiskMetrics, error) {
+	cmp, err := s.benchmark.Compare(ctx, userID, rng, symbol)
+	if err != nil {
+		return nil, err
+	}
+	out := &RiskMetrics{Range: cmp.Range, Symbol: cmp.Symbol, RiskFreeRate: s.riskFreeRate}
+
+	values := make([]float64, len(cmp.Points))
+	closes := make([]float64, len(cmp.Points))
+	dates := make([]string, len(cmp.Points))
+	for i, p := range cmp.Points {
+		values[i], _ = p.netValue.Float64()
+		closes[i], _ = p.BenchmarkClose.Float64()
+		dates[i] = p.Date
+	}
+	portfolio, benchmark := dailyReturns(values), dailyReturns(closes)
+	out.Days = len(portfolio)
+	out.SeriesRisk = seriesRisk(values, portfolio, dates)
+	out.Benchmark = seriesRisk(closes, benchmark, dates)
+
+	if sd := stddev(portfolio); sd > 0 {
+		rf, _ := s.riskFreeRate.Float64()
+		excess := mean(portfolio) - rf/100/tradingDaysPerYear
+		out.Sharpe = riskDecimal(excess/sd*math.Sqrt(tradingDaysPerYear), 2)
+	}
+	if v := variance(benchmark); v > 0 {
+		out.Beta = riskDecimal(covariance(portfolio, benchmark)/v, 2)
+	}
+	return out, nil
+}
+
+func seriesRisk(values, returns []float64, dates []string) SeriesRisk {
+	var out SeriesRisk
+	if len(returns) >= 2 {
+		out.Volatility = riskDecimal(stddev(returns)*math.Sqrt(tradingDaysPerYear)*100, 2)
+	}
+	if len(values) >= 2 {
+		worst, peak, peakAt := 0.0, values[0], 0
+		for i, v := range values {
+			if v > peak {
+				peak, peakAt = v, i
+			}
+			if dd := (peak - v) / peak; dd > worst {
+				worst = dd
+				out.MaxDrawdownPeak, out.MaxDrawdownTrough = dates[peakAt], dates[i]
+			}
+		}
+		out.MaxDrawdown = riskDecimal(worst*100, 2)
+	}
+	return out
+}
+
+// dailyReturns turns consecutive values into fractional returns. Values are
+// positive: Compare starts at the first non-zero snapshot, and closes are
+// always positive.
+func dailyReturns(values []float64) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+	out := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		if values[i-1] > 0 {
+			out[i-1] = values[i]/values[i-1] - 1
+		}
+	}
+	return out
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// covariance is the sample covariance of two equal-length series; zero for
+// fewer than two points.
+func covariance(xs, ys []float64) float64 {
+	if len(xs) < 2 || len(xs) != len(ys) {
+		return 0
+	}
+	mx, my := mean(xs), mean(ys)
+	sum := 0.0
+	for i := range xs {
+		sum += (xs[i] - mx) * (ys[i] - my)
+	}
+	return sum / float64(len(xs)-1)
+}
+
+func variance(xs []float64) float64 { return covariance(xs, xs) }
+
+func stddev(xs []float64) float64 { return math.Sqrt(variance(xs)) }
+
+func riskDecimal(f float64, places int32) *decimal.Decimal {
+	d := decimal.NewFromFloat(f).Round(places)
+	return &d
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSeriesRisk_Drawdown(t *testing.T) {
+	values := []float64{100, 120, 90, 110, 130, 117}
+	dates := []string{"2026-10-01", "2026-10-02", "2026-10-05", "2026-10-06", "2026-10-07", "2026-10-08"}
+	got := seriesRisk(values, dailyReturns(values), dates)
+
+	if got.MaxDrawdown == nil || got.MaxDrawdown.String() != "25" {
+		t.Fatalf("max drawdown: got %v, want 25", got.MaxDrawdown)
+	}
+	if got.MaxDrawdownPeak != "2026-10-02" || got.MaxDrawdownTrough != "2026-10-05" {
+		t.Errorf("drawdown window: got %s..%s", got.MaxDrawdownPeak, got.MaxDrawdownTrough)
+	}
+	if got.Volatility == nil || !got.Volatility.IsPositive() {
+		t.Errorf("volatility: got %v", got.Volatility)
+	}
+
+	if short := seriesRisk([]float64{100}, nil, []string{"2026-10-01"}); short.Volatility != nil || short.MaxDrawdown != nil {
+		t.Errorf("one snapshot: got %+v, want nil statistics", short)
+	}
+}
+
+func TestCovarianceAndBeta(t *testing.T) {
+	bench := []float64{0.01, -0.02, 0.015, 0.005}
+	port := make([]float64, len(bench))
+	for i, r := range bench {
+		port[i] = 2 * r
+	}
+	if beta := covariance(port, bench) / variance(bench); math.Abs(beta-2) > 1e-9 {
+		t.Errorf("beta: got %v, want 2", beta)
+	}
+	// Sample variance of 1..4 is 5/3.
+	if v := variance([]float64{1, 2, 3, 4}); math.Abs(v-5.0/3) > 1e-9 {
+		t.Errorf("variance: got %v, want 5/3", v)
+	}
+}
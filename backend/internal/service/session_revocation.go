@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// sessionTokenTTL is how long a session token is valid. Revocations are kept
+// for as long: a revoked session is never refreshed again, so every token it
+// was issued has expired by then.
+const sessionTokenTTL = 24 * time.Hour
+
+// SessionRevocations records signed-out sessions so their tokens are refused
+// before they expire.
+type SessionRevocations interface {
+	// RevokeSession refuses every token of the session sessionID (the jti
+	// sliding refresh carries over).
+	RevokeSession(ctx context.Context, sessionID string) error
+	// RevokeUser refuses every token userID was issued before at.
+	RevokeUser(ctx context.Context, userID string, at time.Time) error
+	// Revoked reports whether the token carrying claims has been revoked.
+	Revoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+func revokedSessionKey(sessionID string) string { return "revoked:session:" + sessionID }
+func revokedUserKey(userID string) string       { return "revoked:user:" + userID }
+
+// tokenRevoked applies a user's cutoff (zero for none) and a session's
+// revocation to claims. Both sides are to the millisecond, like the iat_ms
+// claim, so a sign-in straight after signing out everywhere isn't caught by
+// the cutoff.
+func tokenRevoked(claims *Claims, sessionRevoked bool, userCutoff time.Time) bool {
+	if sessionRevoked {
+		return true
+	}
+	issued := claims.issuedAt()
+	return !userCutoff.IsZero() && (issued.IsZero() || issued.Before(userCutoff.Truncate(time.Millisecond)))
+}
+
+// RedisSessionRevocations keeps revoked:session:<jti> and
+// revoked:user:<id> (the cutoff, Unix seconds to three decimal places),
+// shared by every instance.
+type RedisSessionRevocations struct {
+	client *redis.Client
+}
+
+func NewRedisSessionRevocations(client *redis.Client) *RedisSessionRevocations {
+	return &RedisSessionRevocations{client: client}
+}
+
+// RevokeSession implements SessionRevocations.
+func (s *RedisSessionRevocations) RevokeSession(ctx context.Context, sessionID string) error {
+	return s.client.Set(ctx, revokedSessionKey(sessionID), 1, sessionTokenTTL).Err()
+}
+
+// RevokeUser implements SessionRevocations.
+func (s *RedisSessionRevocations) RevokeUser(ctx context.Context, userID string, at time.Time) error {
+	cutoff := strconv.FormatFloat(float64(at.UnixMilli())/1000, 'f', 3, 64)
+	return s.client.Set(ctx, revokedUserKey(userID), cutoff, sessionTokenTTL).Err()
+}
+
+// Revoked implements SessionRevocations in one round trip.
+func (s *RedisSessionRevocations) Revoked(ctx context.Context, claims *Claims) (bool, error) {
+	keys := []string{revokedUserKey(claims.UserID)}
+	if claims.ID != "" {
+		keys = append(keys, revokedSessionKey(claims.ID))
+	}
+	vals, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	var cutoff time.Time
+	if v, ok := vals[0].(string); ok {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			cutoff = time.UnixMilli(int64(math.Round(secs * 1000)))
+		}
+	}
+	return tokenRevoked(claims, len(vals) > 1 && vals[1] != nil, cutoff), nil
+}
+
+// MemorySessionRevocations is the in-process SessionRevocations used without
+// Redis. Revocations are per instance and lost on restart.
+type MemorySessionRevocations struct {
+	mu      sync.Mutex
+	expires map[string]time.Time // by revokedSessionKey / revokedUserKey
+	cutoffs map[string]time.Time // by user ID
+	now     func() time.Time
+}
+
+func NewMemorySessionRevocations() *MemorySessionRevocations {
+	return &MemorySessionRevocations{
+		expires: make(map[string]time.Time),
+		cutoffs: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// RevokeSession implements SessionRevocations.
+func (s *MemorySessionRevocations) RevokeSession(_ context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	s.expires[revokedSessionKey(sessionID)] = s.now().Add(sessionTokenTTL)
+	return nil
+}
+
+// RevokeUser implements SessionRevocations.
+func (s *MemorySessionRevocations) RevokeUser(_ context.Context, userID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	s.expires[revokedUserKey(userID)] = s.now().Add(sessionTokenTTL)
+	s.cutoffs[userID] = at
+	return nil
+}
+
+// Revoked implements SessionRevocations.
+func (s *MemorySessionRevocations) Revoked(_ context.Context, claims *Claims) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	var cutoff time.Time
+	if exp, ok := s.expires[revokedUserKey(claims.UserID)]; ok && now.Before(exp) {
+		cutoff = s.cutoffs[claims.UserID]
+	}
+	sessionRevoked := false
+	if claims.ID != "" {
+		exp, ok := s.expires[revokedSessionKey(claims.ID)]
+		sessionRevoked = ok && now.Before(exp)
+	}
+	return tokenRevoked(claims, sessionRevoked, cutoff), nil
+}
+
+// prune drops expired revocations. Callers hold mu.
+func (s *MemorySessionRevocations) prune() {
+	now := s.now()
+	for key, exp := range s.expires {
+		if !now.Before(exp) {
+			delete(s.expires, key)
+		}
+	}
+	for userID := range s.cutoffs {
+		if _, ok := s.expires[revokedUserKey(userID)]; !ok {
+			delete(s.cutoffs, userID)
+		}
+	}
+}
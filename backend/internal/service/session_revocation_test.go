@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestMemorySessionRevocations(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 15, 0, 0, 0, time.UTC)
+	revocations := NewMemorySessionRevocations()
+	revocations.now = func() time.Time { return now }
+	svc := NewJWTService("testsecretkey-32-chars-long-xxxxx")
+	svc.SetRevocations(revocations)
+
+	claims := func(userID, sessionID string, issued time.Time) *Claims {
+		return &Claims{UserID: userID, IssuedAtMs: issued.UnixMilli(), RegisteredClaims: jwt.RegisteredClaims{ID: sessionID, IssuedAt: jwt.NewNumericDate(issued)}}
+	}
+
+	// Logging out revokes the session, not the user's others.
+	svc.RevokeSession(ctx, "phone")
+	if !svc.Revoked(ctx, claims("user-1", "phone", now.Add(-time.Hour))) {
+		t.Error("phone session should be revoked")
+	}
+	if svc.Revoked(ctx, claims("user-1", "laptop", now.Add(-time.Hour))) {
+		t.Error("laptop session should still be valid")
+	}
+
+	// Logging out everywhere revokes every token issued until then.
+	revocations.RevokeUser(ctx, "user-1", now)
+	if !svc.Revoked(ctx, claims("user-1", "laptop", now.Add(-time.Second))) {
+		t.Error("laptop session should be revoked")
+	}
+	if !svc.Revoked(ctx, claims("user-1", "", now.Add(-time.Second))) {
+		t.Error("a token without a session should be revoked too")
+	}
+	if svc.Revoked(ctx, claims("user-1", "new", now)) {
+		t.Error("a login after logging out everywhere should be valid")
+	}
+
+	// Within the second, a token issued before the cutoff is still revoked
+	// and one issued after it is not.
+	revocations.RevokeUser(ctx, "user-1", now.Add(500*time.Millisecond))
+	if !svc.Revoked(ctx, claims("user-1", "laptop", now.Add(200*time.Millisecond))) {
+		t.Error("a token issued earlier in the cutoff's second should be revoked")
+	}
+	if svc.Revoked(ctx, claims("user-1", "new", now.Add(800*time.Millisecond))) {
+		t.Error("a login later in the cutoff's second should be valid")
+	}
+	legacy := &Claims{UserID: "user-1", RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now.Add(800 * time.Millisecond))}}
+	if !svc.Revoked(ctx, legacy) {
+		t.Error("a token with only a whole-second iat in the cutoff's second should be revoked")
+	}
+	if svc.Revoked(ctx, claims("user-2", "other", now.Add(-time.Second))) {
+		t.Error("another user's session should be valid")
+	}
+
+	// Every token a revocation covers has expired by the time it's forgotten.
+	now = now.Add(sessionTokenTTL)
+	revocations.RevokeSession(ctx, "unrelated")
+	if len(revocations.expires) != 1 || len(revocations.cutoffs) != 0 {
+		t.Errorf("kept %d revocations and %d cutoffs, want 1 and 0", len(revocations.expires), len(revocations.cutoffs))
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The cases below are from the AWS Signature Version 4 test suite, which
+// signs for service "service" in us-east-1 at 20150830T123600Z.
+func TestSigV4Sign_AWSTestSuite(t *testing.T) {
+	signer := sigV4Signer{
+		region: "us-east-1", service: "service",
+		accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	tests := []struct {
+		name, method, url, contentType, body, want string
+	}{
+		{
+			name: "get-vanilla", method: http.MethodGet, url: "https://example.amazonaws.com/",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name: "get-vanilla-query-order-key-case", method: http.MethodGet, url: "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name: "post-x-www-form-urlencoded", method: http.MethodPost, url: "https://example.amazonaws.com/",
+			contentType: "application/x-www-form-urlencoded", body: "Param1=value1",
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			signer.sign(req, []byte(tt.body), now)
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization:\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/?b=2&a=z&a=y&a-b=1&sp=a%20b+c&slash=x/y", nil)
+	want := "a=y&a=z&a-b=1&b=2&slash=x%2Fy&sp=a%20b%20c"
+	if got := canonicalQuery(req.URL.Query()); got != want {
+		t.Errorf("canonicalQuery: got %s, want %s", got, want)
+	}
+}
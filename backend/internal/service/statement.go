@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// StatementBalance is the account's cash and total value at one point of a
+// statement: a day's close, or now for the month in progress.
+type StatementBalance struct {
+	Date       string          `json:"date"` // YYYY-MM-DD
+	Cash       decimal.Decimal `json:"cash"`
+	TotalValue decimal.Decimal `json:"total_value"`
+}
+
+// AccountStatement summarises one calendar month of an account, in the
+// user's time zone. Opening is the last close before the month began and
+// Closing the last close within it, or now while the month is in progress;
+// either is omitted when no snapshot exists, as before the account opened or
+// snapshots began.
+// NetPerformance is Closing less Opening total value, less BonusCash: bonus
+// cash granted in between is the only money put into a paper account, and
+// no gain, so every other change is trading. Fees is what the
+// simulated spread cost; dividends are not simulated and are always zero.
+// Final is set once the month has closed and the statement can no longer
+// change.
+type AccountStatement struct {
+	Month                 string            `json:"month"` // YYYY-MM
+	Opening               *StatementBalance `json:"opening,omitempty"`
+	Closing               *StatementBalance `json:"closing,omitempty"`
+	Trades                int               `json:"trades"`
+	Buys                  decimal.Decimal   `json:"buys"`
+	Sells                 decimal.Decimal   `json:"sells"`
+	Fees                  decimal.Decimal   `json:"fees"`
+	Dividends             decimal.Decimal   `json:"dividends"`
+	BonusCash             *decimal.Decimal  `json:"bonus_cash,omitempty"`
+	NetPerformance        *decimal.Decimal  `json:"net_performance,omitempty"`
+	NetPerformancePercent *decimal.Decimal  `json:"net_performance_percent,omitempty"`
+	Final                 bool              `json:"final"`
+	Currency              string            `json:"currency,omitempty"`
+}
+
+// Convert restates st's amounts in rate's currency.
+func (st *AccountStatement) Convert(rate *FXRate) {
+	for _, b := range []*StatementBalance{st.Opening, st.Closing} {
+		if b != nil {
+			b.Cash = rate.Apply(b.Cash)
+			b.TotalValue = rate.Apply(b.TotalValue)
+		}
+	}
+	st.Buys = rate.Apply(st.Buys)
+	st.Sells = rate.Apply(st.Sells)
+	st.Fees = rate.Apply(st.Fees)
+	st.Dividends = rate.Apply(st.Dividends)
+	if st.BonusCash != nil {
+		bonus := rate.Apply(*st.BonusCash)
+		st.BonusCash = &bonus
+	}
+	if st.NetPerformance != nil {
+		net := rate.Apply(*st.NetPerformance)
+		st.NetPerformance = &net
+	}
+	st.Currency = rate.To
+}
+
+// StatementService builds monthly statements from the daily snapshots and
+// the trade ledger, and stores them once final.
+type StatementService struct {
+	users      *data.UserStore
+	history    *data.PortfolioHistoryStore
+	trades     *data.TradesStore
+	statements *data.StatementStore
+	value      *PortfolioValueService
+	calendar   *MarketCalendar
+	now        func() time.Time
+}
+
+func NewStatementService(users *data.UserStore, history *data.PortfolioHistoryStore, trades *data.TradesStore, statements *data.StatementStore, value *PortfolioValueService, calendar *MarketCalendar) *StatementService {
+	return &StatementService{users: users, history: history, trades: trades, statements: statements, value: value, calendar: calendar, now: time.Now}
+}
+
+// Statement returns userID's statement for month (YYYY-MM), which may not
+// be after the current month. The month runs between midnights in the
+// user's time zone; a final statement keeps the time zone it was built in.
+func (s *StatementService) Statement(ctx context.Context, userID, month string) (*AccountStatement, error) {
+	parsed, err := time.Parse("2006-01", strings.TrimSpace(month))
+	if err != nil {
+		return nil, &util.ValidationError{Field: "month", Message: "must be YYYY-MM"}
+	}
+	loc, err := userLocation(ctx, s.users, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now().In(loc)
+	start := time.Date(parsed.Year(), parsed.Month(), 1, 0, 0, 0, 0, loc)
+	if start.After(now) {
+		return nil, &util.ValidationError{Field: "month", Message: "must not be in the future"}
+	}
+	month = start.Format("2006-01")
+
+	stored, err := s.statements.Get(ctx, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		var st AccountStatement
+		if err := json.Unmarshal(stored, &st); err != nil {
+			slog.Warn("stored statement unreadable; recomputing", "user_id", userID, "month", month, "err", err, "component", "statements")
+		} else {
+			return &st, nil
+		}
+	}
+
+	end := start.AddDate(0, 1, 0)
+	st := &AccountStatement{Month: month, Dividends: decimal.Zero}
+	openingClose := s.lastCloseBefore(start)
+	opening, err := s.history.LastBefore(ctx, userID, openingClose.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	if opening != nil {
+		st.Opening = &StatementBalance{Date: opening.Date, Cash: opening.Cash, TotalValue: opening.TotalValue}
+	}
+
+	var closingBonus decimal.Decimal
+	if end.After(now) {
+		v, err := s.value.Estimate(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		st.Closing = &StatementBalance{Date: now.Format(time.DateOnly), Cash: v.Cash, TotalValue: v.TotalValue}
+		closingBonus = v.BonusCash
+	} else {
+		last := s.lastCloseBefore(end)
+		closing, err := s.history.LastBefore(ctx, userID, last.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+		if closing != nil && closing.Date > openingClose.Format(time.DateOnly) {
+			st.Closing = &StatementBalance{Date: closing.Date, Cash: closing.Cash, TotalValue: closing.TotalValue}
+			closingBonus = closing.BonusCash
+			// Final once the month's last session has been snapshotted.
+			st.Final = closing.Date == last.Format(time.DateOnly)
+		}
+	}
+
+	totals, err := s.trades.Totals(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	st.Trades, st.Buys, st.Sells, st.Fees = totals.Count, totals.Bought, totals.Sold, totals.Spread
+
+	if st.Opening != nil && st.Closing != nil {
+		bonus := closingBonus.Sub(opening.BonusCash)
+		net := st.Closing.TotalValue.Sub(bonus).Sub(st.Opening.TotalValue)
+		st.BonusCash = &bonus
+		st.NetPerformance = &net
+		if st.Opening.TotalValue.IsPositive() {
+			pct := percentChange(st.Opening.TotalValue, st.Closing.TotalValue.Sub(bonus))
+			st.NetPerformancePercent = &pct
+		}
+	}
+
+	if st.Final {
+		// A failed save only costs recomputing next time.
+		if body, err := json.Marshal(st); err == nil {
+			if err := s.statements.Save(ctx, userID, month, body); err != nil {
+				slog.Warn("statement not stored", "user_id", userID, "month", month, "err", err, "component", "statements")
+			}
+		}
+	}
+	return st, nil
+}
+
+// lastCloseBefore returns the New York day of the last session to close at
+// or before t. Snapshots are dated by session, so that day's is the latest
+// a balance at t can use.
+func (s *StatementService) lastCloseBefore(t time.Time) time.Time {
+	c := s.calendar.PreviousSession(t).Close.In(s.calendar.loc)
+	return time.Date(c.Year(), c.Month(), c.Day(), 0, 0, 0, 0, s.calendar.loc)
+}
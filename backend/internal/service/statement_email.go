@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// statementEmailInterval is how often RunEmails checks for statements due.
+const statementEmailInterval = time.Hour
+
+// StatementSender is the subset of EmailService used by
+// StatementEmailService.
+type StatementSender interface {
+	SendStatementEmail(to, month string, pdf []byte) error
+}
+
+// StatementRater is the subset of FXService used by StatementEmailService.
+type StatementRater interface {
+	DisplayRate(ctx context.Context, userID, requested string) (*FXRate, error)
+}
+
+// StatementEmailService emails users who opted in their statement for each
+// month as a PDF once the month has closed, and records every statement sent.
+type StatementEmailService struct {
+	statements *StatementService
+	reports    *data.StatementReportStore
+	users      *data.UserStore
+	fx         StatementRater
+	email      StatementSender
+	now        func() time.Time
+}
+
+// NewStatementEmailService builds the service. email may be nil, in which
+// case the setting can still be changed but nothing is sent.
+func NewStatementEmailService(statements *StatementService, reports *data.StatementReportStore, users *data.UserStore, fx StatementRater, email StatementSender) *StatementEmailService {
+	return &StatementEmailService{statements: statements, reports: reports, users: users, fx: fx, email: email, now: time.Now}
+}
+
+// SetEnabled turns userID's monthly statement emails on or off and returns
+// the new setting.
+func (s *StatementEmailService) SetEnabled(ctx context.Context, userID string, enabled bool) (bool, error) {
+	if err := s.users.SetStatementEmails(ctx, userID, enabled); err != nil {
+		return false, err
+	}
+	return enabled, nil
+}
+
+// Reports lists the statements emailed to userID, newest month first.
+func (s *StatementEmailService) Reports(ctx context.Context, userID string) ([]data.StatementReport, error) {
+	return s.reports.List(ctx, userID)
+}
+
+// RunEmails sends the statements due (see Send) and then checks again every
+// statementEmailInterval until ctx is done.
+func (s *StatementEmailService) RunEmails(ctx context.Context) {
+	if s.email == nil {
+		return
+	}
+	ticker := time.NewTicker(statementEmailInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.Send(ctx); err != nil {
+			slog.Error("statement emails failed", "err", err, "component", "statement_email")
+		} else if n > 0 {
+			slog.Info("statement emails sent", "count", n, "component", "statement_email")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Send emails last month's statement (New York time) to every opted-in user
+// not yet sent it, in their display currency, and returns how many were
+// sent. A statement is only sent once final, so users are retried until the
+// month's last snapshot is in; a failed send is retried too.
+func (s *StatementEmailService) Send(ctx context.Context) (int, error) {
+	loc := s.statements.calendar.loc
+	now := s.now().In(loc)
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	month := end.AddDate(0, -1, 0).Format("2006-01")
+
+	recipients, err := s.reports.Pending(ctx, month, end)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, r := range recipients {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+		if s.sendOne(ctx, r, month) {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *StatementEmailService) sendOne(ctx context.Context, r data.StatementRecipient, month string) bool {
+	log := slog.With("user_id", r.UserID, "month", month, "component", "statement_email")
+	st, err := s.statements.Statement(ctx, r.UserID, month)
+	if err != nil {
+		log.Warn("statement email: statement failed", "err", err)
+		return false
+	}
+	if !st.Final {
+		return false
+	}
+	if s.fx != nil {
+		rate, err := s.fx.DisplayRate(ctx, r.UserID, "")
+		if err != nil {
+			log.Warn("statement email: display rate failed", "err", err)
+			return false
+		}
+		st.Convert(rate)
+	}
+	pdf := RenderStatementPDF(st)
+
+	claimed, err := s.reports.Claim(ctx, r.UserID, month)
+	if err != nil {
+		log.Warn("statement email: claim failed", "err", err)
+		return false
+	}
+	if !claimed {
+		// Another instance sent it first.
+		return false
+	}
+	if err := s.email.SendStatementEmail(r.Email, month, pdf); err != nil {
+		log.Warn("statement email send failed", "err", err)
+		if err := s.reports.Release(ctx, r.UserID, month); err != nil {
+			log.Error("statement email: release failed; month will not be retried", "err", err)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+type recordingStatementSender struct {
+	sent map[string][]byte
+	fail string
+}
+
+func (s *recordingStatementSender) SendStatementEmail(to, month string, pdf []byte) error {
+	if to == s.fail {
+		return errors.New("provider down")
+	}
+	s.sent[to+" "+month] = pdf
+	return nil
+}
+
+func TestStatementEmails_SendsClosedMonth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	cal := newCalendar(t)
+	statements := NewStatementService(data.NewUserStore(db), data.NewPortfolioHistoryStore(db), data.NewTradesStore(db), data.NewStatementStore(db), nil, cal)
+	sender := &recordingStatementSender{sent: map[string][]byte{}, fail: "b@example.com"}
+	svc := NewStatementEmailService(statements, data.NewStatementReportStore(db), data.NewUserStore(db), nil, sender)
+	now := time.Date(2026, time.October, 2, 15, 0, 0, 0, time.UTC)
+	statements.now = func() time.Time { return now }
+	svc.now = func() time.Time { return now }
+	final := []byte(`{"month":"2026-09","trades":3,"buys":"2000","final":true}`)
+
+	mock.ExpectQuery("FROM users u").
+		WithArgs("2026-09", time.Date(2026, time.October, 1, 0, 0, 0, 0, cal.loc)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).
+			AddRow("user-1", "a@example.com").
+			AddRow("user-2", "b@example.com").
+			AddRow("user-3", "c@example.com"))
+	expectTimezone(mock, "user-1", "America/New_York")
+	mock.ExpectQuery("FROM account_statements").
+		WithArgs("user-1", "2026-09").
+		WillReturnRows(sqlmock.NewRows([]string{"statement"}).AddRow(final))
+	mock.ExpectExec("INSERT INTO statement_reports").
+		WithArgs("user-1", "2026-09").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The send fails: the claim is released so the next run retries.
+	expectTimezone(mock, "user-2", "America/New_York")
+	mock.ExpectQuery("FROM account_statements").
+		WithArgs("user-2", "2026-09").
+		WillReturnRows(sqlmock.NewRows([]string{"statement"}).AddRow(final))
+	mock.ExpectExec("INSERT INTO statement_reports").
+		WithArgs("user-2", "2026-09").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM statement_reports").
+		WithArgs("user-2", "2026-09").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Already sent by another instance.
+	expectTimezone(mock, "user-3", "America/New_York")
+	mock.ExpectQuery("FROM account_statements").
+		WithArgs("user-3", "2026-09").
+		WillReturnRows(sqlmock.NewRows([]string{"statement"}).AddRow(final))
+	mock.ExpectExec("INSERT INTO statement_reports").
+		WithArgs("user-3", "2026-09").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	n, err := svc.Send(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("Send: got %d, %v; want 1 sent", n, err)
+	}
+	pdf, ok := sender.sent["a@example.com 2026-09"]
+	if !ok || len(sender.sent) != 1 {
+		t.Fatalf("sent: got %v", sender.sent)
+	}
+	if !bytes.Contains(pdf, []byte("(Month: 2026-09)")) || !bytes.Contains(pdf, []byte("(USD 2000.00)")) {
+		t.Errorf("pdf missing statement lines:\n%s", pdf)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRenderStatementPDF_Structure(t *testing.T) {
+	net, pct := decimal.NewFromInt(-250), decimal.RequireFromString("-2.5")
+	pdf := RenderStatementPDF(&AccountStatement{
+		Month:                 "2026-09",
+		Opening:               &StatementBalance{Date: "2026-08-31", Cash: decimal.NewFromInt(5000), TotalValue: decimal.NewFromInt(10000)},
+		NetPerformance:        &net,
+		NetPerformancePercent: &pct,
+		Final:                 true,
+		Currency:              "EUR",
+	})
+
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF:\n%s", pdf)
+	}
+	for _, want := range []string{"(EUR 10000.00 \\(2026-08-31\\))", "(EUR -250.00 \\(-2.50%\\))", "(Closing value) Tj", "(-) Tj"} {
+		if !bytes.Contains(pdf, []byte(want)) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	// Every xref entry points at its object.
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n0 7\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	if len(entries) != 6 {
+		t.Fatalf("xref entries: got %d, want 6", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if want := fmt.Sprintf("%d 0 obj\n", i+1); !bytes.HasPrefix(pdf[off:], []byte(want)) {
+			t.Errorf("object %d: offset %d points at %q", i+1, off, pdf[off:off+10])
+		}
+	}
+}
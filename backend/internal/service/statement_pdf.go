@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// pdfLine is one line of text on a statement page, at a fixed height from
+// the bottom of a US Letter page (612x792 points).
+type pdfLine struct {
+	x, y int
+	font string // F1 regular, F2 bold
+	size int
+	text string
+}
+
+// RenderStatementPDF lays st out on a single-page PDF. It writes the file by
+// hand with the standard Helvetica fonts, which every viewer has, rather
+// than pulling in a PDF library for one page of text.
+func RenderStatementPDF(st *AccountStatement) []byte {
+	currency := st.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	money := func(d decimal.Decimal) string { return currency + " " + d.StringFixed(2) }
+	balance := func(b *StatementBalance, field func(*StatementBalance) decimal.Decimal) string {
+		if b == nil {
+			return "-"
+		}
+		return money(field(b)) + " (" + b.Date + ")"
+	}
+	cash := func(b *StatementBalance) decimal.Decimal { return b.Cash }
+	total := func(b *StatementBalance) decimal.Decimal { return b.TotalValue }
+
+	performance := "-"
+	if st.NetPerformance != nil {
+		performance = money(*st.NetPerformance)
+		if st.NetPerformancePercent != nil {
+			performance += " (" + st.NetPerformancePercent.StringFixed(2) + "%)"
+		}
+	}
+	status := "Final"
+	if !st.Final {
+		status = "Provisional: the month has not closed"
+	}
+
+	rows := [][2]string{
+		{"Opening value", balance(st.Opening, total)},
+		{"Opening cash", balance(st.Opening, cash)},
+		{"Closing value", balance(st.Closing, total)},
+		{"Closing cash", balance(st.Closing, cash)},
+		{"Net performance", performance},
+		{"Trades", fmt.Sprint(st.Trades)},
+		{"Bought", money(st.Buys)},
+		{"Sold", money(st.Sells)},
+		{"Fees", money(st.Fees)},
+		{"Dividends", money(st.Dividends)},
+	}
+
+	lines := []pdfLine{
+		{x: 72, y: 720, font: "F2", size: 18, text: "PaperTrader account statement"},
+		{x: 72, y: 696, font: "F1", size: 12, text: "Month: " + st.Month},
+		{x: 72, y: 680, font: "F1", size: 12, text: "Status: " + status},
+	}
+	y := 644
+	for _, row := range rows {
+		lines = append(lines,
+			pdfLine{x: 72, y: y, font: "F2", size: 11, text: row[0]},
+			pdfLine{x: 240, y: y, font: "F1", size: 11, text: row[1]})
+		y -= 20
+	}
+	lines = append(lines, pdfLine{x: 72, y: 72, font: "F1", size: 9,
+		text: "Paper trading account: all balances and trades are simulated."})
+
+	var content bytes.Buffer
+	for _, l := range lines {
+		fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", l.font, l.size, l.x, l.y, pdfString(l.text))
+	}
+	return writePDF(content.Bytes())
+}
+
+// writePDF wraps one page's content stream in the objects a PDF needs and
+// the cross-reference table that locates them.
+func writePDF(content []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] " +
+			"/Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfString escapes s for a PDF literal string. Characters outside ASCII
+// are replaced, since the standard fonts are not given a Unicode mapping.
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
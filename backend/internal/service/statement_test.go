@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestStatement_ClosedMonthIsStored(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	cal := newCalendar(t)
+	svc := NewStatementService(data.NewUserStore(db), data.NewPortfolioHistoryStore(db), data.NewTradesStore(db), data.NewStatementStore(db), nil, cal)
+	svc.now = func() time.Time { return time.Date(2026, time.October, 16, 15, 0, 0, 0, time.UTC) }
+	snapshotCols := []string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}
+
+	expectTimezone(mock, "user-1", "America/New_York")
+	mock.ExpectQuery("FROM account_statements").
+		WithArgs("user-1", "2026-09").
+		WillReturnRows(sqlmock.NewRows([]string{"statement"}))
+	mock.ExpectQuery("snapshot_date < \\$2").
+		WithArgs("user-1", "2026-09-01").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, time.August, 31, 0, 0, 0, 0, time.UTC), "5000", "5000", "10000", false, "0"))
+	// September 30th is the month's last session.
+	mock.ExpectQuery("snapshot_date < \\$2").
+		WithArgs("user-1", "2026-10-01").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, time.September, 30, 0, 0, 0, 0, time.UTC), "5500", "5000", "10500", false, "0"))
+	mock.ExpectQuery("FROM trades").
+		WithArgs("user-1", time.Date(2026, time.September, 1, 4, 0, 0, 0, time.UTC), time.Date(2026, time.October, 1, 4, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "bought", "sold", "spread"}).AddRow(3, "2000", "1500", "4.5"))
+	mock.ExpectExec("INSERT INTO account_statements").
+		WithArgs("user-1", "2026-09", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	st, err := svc.Statement(context.Background(), "user-1", "2026-09")
+	if err != nil {
+		t.Fatalf("Statement: %v", err)
+	}
+	if !st.Final || st.Opening == nil || st.Opening.Date != "2026-08-31" || st.Closing == nil || !st.Closing.Cash.Equal(decimal.NewFromInt(5500)) {
+		t.Errorf("balances: got %+v (opening %+v, closing %+v)", st, st.Opening, st.Closing)
+	}
+	if st.Trades != 3 || !st.Buys.Equal(decimal.NewFromInt(2000)) || !st.Sells.Equal(decimal.NewFromInt(1500)) ||
+		!st.Fees.Equal(decimal.RequireFromString("4.5")) || !st.Dividends.IsZero() {
+		t.Errorf("activity: got %+v", st)
+	}
+	if st.NetPerformance == nil || !st.NetPerformance.Equal(decimal.NewFromInt(500)) ||
+		st.NetPerformancePercent == nil || !st.NetPerformancePercent.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("performance: got %v, %v", st.NetPerformance, st.NetPerformancePercent)
+	}
+
+	// Stored: served as is.
+	expectTimezone(mock, "user-1", "America/New_York")
+	mock.ExpectQuery("FROM account_statements").
+		WithArgs("user-1", "2026-09").
+		WillReturnRows(sqlmock.NewRows([]string{"statement"}).AddRow([]byte(`{"month":"2026-09","trades":3,"final":true}`)))
+	if st, err := svc.Statement(context.Background(), "user-1", "2026-09"); err != nil || st.Trades != 3 || !st.Final {
+		t.Errorf("stored statement: got %+v, %v", st, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestStatement_RejectsBadMonth(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewStatementService(data.NewUserStore(db), nil, nil, nil, nil, newCalendar(t))
+	svc.now = func() time.Time { return time.Date(2026, time.October, 16, 15, 0, 0, 0, time.UTC) }
+	expectTimezone(mock, "user-1", "America/New_York")
+	for _, month := range []string{"", "2026-13", "10/2026", "2026-11"} {
+		var verr *util.ValidationError
+		if _, err := svc.Statement(context.Background(), "user-1", month); !errors.As(err, &verr) {
+			t.Errorf("%q: got %v, want a validation error", month, err)
+		}
+	}
+}
+
+func TestStatement_MonthFollowsUserTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewStatementService(data.NewUserStore(db), data.NewPortfolioHistoryStore(db), data.NewTradesStore(db), data.NewStatementStore(db), nil, newCalendar(t))
+	svc.now = func() time.Time { return time.Date(2026, time.October, 16, 15, 0, 0, 0, time.UTC) }
+	snapshotCols := []string{"snapshot_date", "cash", "holdings_value", "total_value", "partial", "bonus_cash"}
+
+	// September in Tokyo starts at 15:00 UTC on August 31st, before that
+	// day's close, so the opening balance is the close of August 28th.
+	expectTimezone(mock, "user-1", "Asia/Tokyo")
+	mock.ExpectQuery("FROM account_statements").
+		WithArgs("user-1", "2026-09").
+		WillReturnRows(sqlmock.NewRows([]string{"statement"}))
+	mock.ExpectQuery("snapshot_date < \\$2").
+		WithArgs("user-1", "2026-08-29").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, time.August, 28, 0, 0, 0, 0, time.UTC), "5000", "5000", "10000", false, "0"))
+	mock.ExpectQuery("snapshot_date < \\$2").
+		WithArgs("user-1", "2026-09-30").
+		WillReturnRows(sqlmock.NewRows(snapshotCols).AddRow(time.Date(2026, time.September, 29, 0, 0, 0, 0, time.UTC), "5500", "5000", "10500", false, "0"))
+	mock.ExpectQuery("FROM trades").
+		WithArgs("user-1", time.Date(2026, time.August, 31, 15, 0, 0, 0, time.UTC), time.Date(2026, time.September, 30, 15, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "bought", "sold", "spread"}).AddRow(0, "0", "0", "0"))
+	mock.ExpectExec("INSERT INTO account_statements").
+		WithArgs("user-1", "2026-09", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	st, err := svc.Statement(context.Background(), "user-1", "2026-09")
+	if err != nil {
+		t.Fatalf("Statement: %v", err)
+	}
+	if st.Opening == nil || st.Opening.Date != "2026-08-28" || st.Closing == nil || st.Closing.Date != "2026-09-29" || !st.Final {
+		t.Errorf("got %+v (opening %+v, closing %+v)", st, st.Opening, st.Closing)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// TradeHighlight is one closing trade singled out by TradingStats.
+type TradeHighlight struct {
+	TradeID    string          `json:"trade_id"`
+	Symbol     string          `json:"symbol"`
+	Action     string          `json:"action"` // SELL or COVER
+	Quantity   decimal.Decimal `json:"quantity"`
+	Gain       decimal.Decimal `json:"gain"`
+	ExecutedAt time.Time       `json:"executed_at"`
+}
+
+// TradingStats are aggregates over a user's closed trades and account
+// value. Streaks count consecutive sells and covers with a gain (or a
+// loss); a break-even close ends both. AverageHoldDays is weighted by
+// shares closed. Drawdown is how far the current value sits below the
+// highest value on record, PeakValue, which is a daily snapshot or the
+// current value itself. Bonus cash is a deposit rather than a gain, so the
+// peak is the highest value net of the grants made by then, and PeakValue
+// adds on those granted since.
+type TradingStats struct {
+	ClosedTrades         int              `json:"closed_trades"`
+	LongestWinningStreak int              `json:"longest_winning_streak"`
+	LongestLosingStreak  int              `json:"longest_losing_streak"`
+	BestTrade            *TradeHighlight  `json:"best_trade,omitempty"`
+	WorstTrade           *TradeHighlight  `json:"worst_trade,omitempty"`
+	AverageHoldDays      *decimal.Decimal `json:"average_hold_days,omitempty"`
+	CurrentValue         decimal.Decimal  `json:"current_value"`
+	PeakValue            decimal.Decimal  `json:"peak_value"`
+	PeakDate             string           `json:"peak_date"` // YYYY-MM-DD
+	Drawdown             decimal.Decimal  `json:"drawdown"`
+	DrawdownPercent      decimal.Decimal  `json:"drawdown_percent"`
+	Currency             string           `json:"currency,omitempty"`
+}
+
+// StatsService computes TradingStats from the trade ledger, the tax lots
+// and the daily snapshots.
+type StatsService struct {
+	trades  *data.TradesStore
+	lots    *data.LotStore
+	history *data.PortfolioHistoryStore
+	value   *PortfolioValueService
+}
+
+func NewStatsService(trades *data.TradesStore, lots *data.LotStore, history *data.PortfolioHistoryStore, value *PortfolioValueService) *StatsService {
+	return &StatsService{trades: trades, lots: lots, history: history, value: value}
+}
+
+// Stats returns userID's trading statistics. Gains are realized the same
+// way as in the P&L report.
+func (s *StatsService) Stats(ctx context.Context, userID string) (*TradingStats, error) {
+	trades, err := s.trades.GetAllTradesByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	lotGains, err := s.lots.RealizedBySellTrade(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats := closedTradeStats(closeTrades(trades, lotGains))
+
+	current, err := s.value.Estimate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	peak, err := s.history.Peak(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stats.CurrentValue = current.TotalValue
+	stats.PeakValue, stats.PeakDate = current.TotalValue, current.AsOf.Format(time.DateOnly)
+	if peak != nil {
+		// The peak as it would stand with today's grants.
+		peakValue := peak.TotalValue.Add(current.BonusCash.Sub(peak.BonusCash))
+		if peakValue.GreaterThan(current.TotalValue) {
+			stats.PeakValue, stats.PeakDate = peakValue, peak.Date
+			stats.Drawdown = peakValue.Sub(current.TotalValue)
+			stats.DrawdownPercent = stats.Drawdown.Div(peakValue).Mul(decimal.NewFromInt(100)).Round(2)
+		}
+	}
+	return stats, nil
+}
+
+// closedTradeStats fills in the trade-based half of TradingStats from
+// closes in execution order.
+func closedTradeStats(closes []closedTrade) *TradingStats {
+	stats := &TradingStats{ClosedTrades: len(closes)}
+	wins, losses := 0, 0
+	var held float64
+	shares := 0.0
+	for i := range closes {
+		c := &closes[i]
+		switch c.Gain.Sign() {
+		case 1:
+			wins, losses = wins+1, 0
+		case -1:
+			wins, losses = 0, losses+1
+		default:
+			wins, losses = 0, 0
+		}
+		stats.LongestWinningStreak = max(stats.LongestWinningStreak, wins)
+		stats.LongestLosingStreak = max(stats.LongestLosingStreak, losses)
+
+		if stats.BestTrade == nil || c.Gain.GreaterThan(stats.BestTrade.Gain) {
+			stats.BestTrade = highlight(c)
+		}
+		if stats.WorstTrade == nil || c.Gain.LessThan(stats.WorstTrade.Gain) {
+			stats.WorstTrade = highlight(c)
+		}
+		held += c.Held.Hours() / 24 * c.Quantity.InexactFloat64()
+		shares += c.Quantity.InexactFloat64()
+	}
+	if shares > 0 {
+		days := decimal.NewFromFloat(held / shares).Round(2)
+		stats.AverageHoldDays = &days
+	}
+	return stats
+}
+
+func highlight(c *closedTrade) *TradeHighlight {
+	return &TradeHighlight{
+		TradeID:    c.ID,
+		Symbol:     c.Symbol,
+		Action:     c.Action,
+		Quantity:   c.Quantity,
+		Gain:       c.Gain,
+		ExecutedAt: c.ExecutedAt,
+	}
+}
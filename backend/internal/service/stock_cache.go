@@ -10,11 +10,14 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// StockCache interface defines methods for caching stock prices
+// StockCache interface defines methods for caching stock prices. GetStock
+// returns nil, nil on a miss; an error means the cache itself couldn't be
+// read, which callers treat as a miss (see FallbackStockCache).
 type StockCache interface {
 	GetStock(ctx context.Context, symbol, date string) (*StockData, error)
 	SetStock(ctx context.Context, symbol, date string, data *StockData, ttl time.Duration) error
 	InvalidateStock(ctx context.Context, symbol string) error
+	InvalidateStocks(ctx context.Context, symbols []string) error
 }
 
 // RedisStockCache implements StockCache using Redis
@@ -23,17 +26,22 @@ type RedisStockCache struct {
 	defaultTTL time.Duration
 }
 
-// NewRedisStockCache creates a new Redis-based stock cache
-func NewRedisStockCache(client *redis.Client) *RedisStockCache {
+// NewRedisStockCache creates a new Redis-based stock cache. defaultTTL
+// applies when SetStock is called with a zero ttl.
+func NewRedisStockCache(client *redis.Client, defaultTTL time.Duration) *RedisStockCache {
 	return &RedisStockCache{
 		client:     client,
-		defaultTTL: 15 * time.Minute,
+		defaultTTL: defaultTTL,
 	}
 }
 
+func stockKey(symbol, date string) string {
+	return fmt.Sprintf("stock:%s:%s", symbol, date)
+}
+
 // GetStock retrieves stock data from Redis cache
 func (c *RedisStockCache) GetStock(ctx context.Context, symbol, date string) (*StockData, error) {
-	key := fmt.Sprintf("stock:%s:%s", symbol, date)
+	key := stockKey(symbol, date)
 
 	val, err := c.client.Get(ctx, key).Result()
 	if err != nil {
@@ -41,14 +49,14 @@ func (c *RedisStockCache) GetStock(ctx context.Context, symbol, date string) (*S
 			// Cache miss - return nil, nil (not an error)
 			return nil, nil
 		}
-		// Redis error - log but don't fail, return nil to allow fallback
+		// Redis error - log and report it, so a fallback cache can answer
 		slog.Error("Redis error getting stock from cache",
 			"symbol", symbol,
 			"date", date,
 			"err", err,
 			"component", "stock_cache",
 		)
-		return nil, nil
+		return nil, err
 	}
 
 	var stockData StockData
@@ -71,7 +79,7 @@ func (c *RedisStockCache) SetStock(ctx context.Context, symbol, date string, dat
 		ttl = c.defaultTTL
 	}
 
-	key := fmt.Sprintf("stock:%s:%s", symbol, date)
+	key := stockKey(symbol, date)
 
 	jsonData, err := json.Marshal(data)
 	if err != nil {
@@ -93,29 +101,59 @@ func (c *RedisStockCache) SetStock(ctx context.Context, symbol, date string, dat
 
 // InvalidateStock removes all cache entries for a given symbol
 func (c *RedisStockCache) InvalidateStock(ctx context.Context, symbol string) error {
-	pattern := fmt.Sprintf("stock:%s:*", symbol)
-
-	keys, err := c.client.Keys(ctx, pattern).Result()
-	if err != nil {
-		slog.Error("failed to find cache keys for invalidation",
-			"symbol", symbol,
-			"err", err,
-			"component", "stock_cache",
-		)
-		return err
-	}
+	return c.InvalidateStocks(ctx, []string{symbol})
+}
 
-	if len(keys) > 0 {
-		if err := c.client.Del(ctx, keys...).Err(); err != nil {
-			slog.Error("failed to delete cache keys for symbol",
+// InvalidateStocks removes all cache entries for each of symbols.
+func (c *RedisStockCache) InvalidateStocks(ctx context.Context, symbols []string) error {
+	for _, symbol := range symbols {
+		n, err := unlinkMatching(ctx, c.client, stockKey(symbol, "*"))
+		if err != nil {
+			slog.Error("failed to invalidate stock cache entries",
 				"symbol", symbol,
 				"err", err,
 				"component", "stock_cache",
 			)
 			return err
 		}
-		slog.Info("invalidated stock cache entries", "symbol", symbol, "count", len(keys), "component", "stock_cache")
+		if n > 0 {
+			slog.Info("invalidated stock cache entries", "symbol", symbol, "count", n, "component", "stock_cache")
+		}
 	}
-
 	return nil
 }
+
+// unlinkScanCount is the COUNT hint unlinkMatching passes to SCAN, and the
+// most keys it unlinks in one command.
+const unlinkScanCount = 500
+
+// unlinkMatching removes every key matching pattern and returns how many
+// it removed. It walks the keyspace with SCAN rather than KEYS, and frees
+// the keys with UNLINK rather than DEL, so neither step blocks Redis for
+// other clients however many keys there are.
+func unlinkMatching(ctx context.Context, client *redis.Client, pattern string) (int, error) {
+	removed := 0
+	batch := make([]string, 0, unlinkScanCount)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := client.Unlink(ctx, batch...).Result()
+		removed += int(n)
+		batch = batch[:0]
+		return err
+	}
+	iter := client.Scan(ctx, 0, pattern, unlinkScanCount).Iterator()
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == unlinkScanCount {
+			if err := flush(); err != nil {
+				return removed, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return removed, err
+	}
+	return removed, flush()
+}
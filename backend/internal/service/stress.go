@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// StressScenarioCustom is the scenario of a stress test that brings its own
+// shocks.
+const StressScenarioCustom = "custom"
+
+// stressBetaDays is how much daily history a holding's beta is measured
+// over, and stressMinBetaReturns the fewest daily returns it needs.
+const (
+	stressBetaDays       = 365
+	stressMinBetaReturns = 20
+)
+
+// maxStressShock bounds a custom shock either way, in percent.
+var maxStressShock = decimal.NewFromInt(100)
+
+// StressScenario is a market shock: Sectors holds the fall (negative) or
+// rise of each GICS sector in percent, and Market the benchmark's, which
+// holdings outside the listed sectors take scaled by their beta.
+type StressScenario struct {
+	ID      string                     `json:"id"`
+	Name    string                     `json:"name"`
+	Market  decimal.Decimal            `json:"market"`
+	Sectors map[string]decimal.Decimal `json:"sectors"`
+}
+
+func shockPct(v int64) decimal.Decimal { return decimal.NewFromInt(v) }
+
+// StressScenarios are the historical scenarios, as the S&P 500 and its
+// sectors moved from peak to trough, rounded to the percent.
+var StressScenarios = []StressScenario{
+	{
+		ID: "gfc_2008", Name: "2008 financial crisis (Oct 2007 - Mar 2009)", Market: shockPct(-57),
+		Sectors: map[string]decimal.Decimal{
+			"Communication Services": shockPct(-50), "Consumer Discretionary": shockPct(-60), "Consumer Staples": shockPct(-33),
+			"Energy": shockPct(-55), "Financials": shockPct(-83), "Health Care": shockPct(-40), "Industrials": shockPct(-63),
+			"Information Technology": shockPct(-55), "Materials": shockPct(-60), "Real Estate": shockPct(-75), "Utilities": shockPct(-47),
+		},
+	},
+	{
+		ID: "covid_2020", Name: "2020 COVID crash (Feb - Mar 2020)", Market: shockPct(-34),
+		Sectors: map[string]decimal.Decimal{
+			"Communication Services": shockPct(-30), "Consumer Discretionary": shockPct(-34), "Consumer Staples": shockPct(-25),
+			"Energy": shockPct(-60), "Financials": shockPct(-43), "Health Care": shockPct(-28), "Industrials": shockPct(-43),
+			"Information Technology": shockPct(-31), "Materials": shockPct(-37), "Real Estate": shockPct(-42), "Utilities": shockPct(-37),
+		},
+	},
+	{
+		ID: "rate_hikes_2022", Name: "2022 rate hikes (Jan - Oct 2022)", Market: shockPct(-25),
+		Sectors: map[string]decimal.Decimal{
+			"Communication Services": shockPct(-43), "Consumer Discretionary": shockPct(-38), "Consumer Staples": shockPct(-13),
+			"Energy": shockPct(45), "Financials": shockPct(-22), "Health Care": shockPct(-14), "Industrials": shockPct(-20),
+			"Information Technology": shockPct(-34), "Materials": shockPct(-22), "Real Estate": shockPct(-33), "Utilities": shockPct(-8),
+		},
+	},
+}
+
+// StressTestRequest is the body of POST /api/tools/stress-test: one of
+// StressScenarios by ID, or StressScenarioCustom with Shocks (GICS sector to
+// percent) and optionally Market, the benchmark's move, default 0.
+type StressTestRequest struct {
+	Scenario string                     `json:"scenario"`
+	Shocks   map[string]decimal.Decimal `json:"shocks"`
+	Market   *decimal.Decimal           `json:"market"`
+}
+
+// StressPosition is one holding under a stress test. Basis says where its
+// shock came from: "sector", the scenario's move for its sector, or "beta",
+// the market move times Beta. Beta is nil when there was too little history
+// to measure it, and the market move is then taken as is.
+type StressPosition struct {
+	Symbol          string           `json:"symbol"`
+	Sector          string           `json:"sector,omitempty"`
+	Quantity        decimal.Decimal  `json:"quantity"`
+	Price           decimal.Decimal  `json:"price"`
+	Value           decimal.Decimal  `json:"value"`
+	Basis           string           `json:"basis"`
+	Beta            *decimal.Decimal `json:"beta,omitempty"`
+	Shock           decimal.Decimal  `json:"shock"`
+	ProjectedChange decimal.Decimal  `json:"projected_change"`
+}
+
+// StressTestResult projects the account through a scenario. Values are at
+// the latest quotes; cash is unaffected, and a short position gains from a
+// fall. ProjectedChangePct is of TotalValue.
+type StressTestResult struct {
+	Scenario           StressScenario   `json:"scenario"`
+	Benchmark          string           `json:"benchmark"`
+	Cash               decimal.Decimal  `json:"cash"`
+	HoldingsValue      decimal.Decimal  `json:"holdings_value"`
+	TotalValue         decimal.Decimal  `json:"total_value"`
+	ProjectedChange    decimal.Decimal  `json:"projected_change"`
+	ProjectedChangePct decimal.Decimal  `json:"projected_change_pct"`
+	ProjectedValue     decimal.Decimal  `json:"projected_value"`
+	Positions          []StressPosition `json:"positions"`
+}
+
+// SectorClassifier is the subset of ClassificationService used by
+// StressService.
+type SectorClassifier interface {
+	Classify(ctx context.Context, symbols []string) (map[string]data.Classification, error)
+}
+
+// StressService projects what historical or custom market shocks would do
+// to a user's current holdings. A classified holding takes its sector's
+// move; any other (ETFs, crypto, unclassified stocks) takes the market's,
+// scaled by its beta against the benchmark over the past year.
+type StressService struct {
+	users           *data.UserStore
+	portfolio       *data.PortfolioStore
+	market          MarketPricer
+	series          HistoricalSeriesSource
+	classifications SectorClassifier
+	benchmark       string
+}
+
+func NewStressService(users *data.UserStore, portfolio *data.PortfolioStore, market MarketPricer, series HistoricalSeriesSource, classifications SectorClassifier, benchmark string) *StressService {
+	return &StressService{users: users, portfolio: portfolio, market: market, series: series, classifications: classifications, benchmark: benchmark}
+}
+
+// Run stress-tests userID's account under req.
+func (s *StressService) Run(ctx context.Context, userID string, req StressTestRequest) (*StressTestResult, error) {
+	scenario, err := stressScenario(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cash, err := s.users.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.portfolio.GetPortfolioByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	symbols := make([]string, 0, len(holdings))
+	for _, h := range holdings {
+		symbols = append(symbols, h.Symbol)
+	}
+	classes, err := s.classifications.Classify(ctx, symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &StressTestResult{
+		Scenario:  *scenario,
+		Benchmark: s.benchmark,
+		Cash:      cash,
+		Positions: make([]StressPosition, 0, len(holdings)),
+	}
+	var benchmark *HistoricalSeries
+	for _, h := range holdings {
+		quote, err := s.market.GetStock(ctx, h.Symbol)
+		if err != nil {
+			return nil, err
+		}
+		if quote == nil || !quote.Price.IsPositive() {
+			return nil, &util.ValidationError{Field: "holdings", Message: "no price available for " + h.Symbol}
+		}
+		pos := StressPosition{
+			Symbol:   h.Symbol,
+			Quantity: h.Quantity,
+			Price:    quote.Price,
+			Value:    quote.Price.Mul(h.Quantity).Round(2),
+			Sector:   classes[h.Symbol].Sector,
+		}
+		if shock, ok := scenario.Sectors[pos.Sector]; ok && pos.Sector != "" {
+			pos.Basis, pos.Shock = "sector", shock
+		} else {
+			if benchmark == nil {
+				if benchmark, err = s.series.GetHistoricalSeries(ctx, s.benchmark, stressBetaDays); err != nil {
+					slog.Warn("stress test: benchmark history unavailable", "symbol", s.benchmark, "err", err, "component", "stress")
+					benchmark = &HistoricalSeries{}
+				}
+			}
+			pos.Basis, pos.Beta = "beta", s.beta(ctx, h.Symbol, benchmark)
+			pos.Shock = scenario.Market
+			if pos.Beta != nil {
+				pos.Shock = scenario.Market.Mul(*pos.Beta).Round(2)
+			}
+			// No holding can lose more than it is worth.
+			if pos.Shock.LessThan(maxStressShock.Neg()) {
+				pos.Shock = maxStressShock.Neg()
+			}
+		}
+		pos.ProjectedChange = pos.Value.Mul(pos.Shock).Div(decimal.NewFromInt(100)).Round(2)
+		out.HoldingsValue = out.HoldingsValue.Add(pos.Value)
+		out.ProjectedChange = out.ProjectedChange.Add(pos.ProjectedChange)
+		out.Positions = append(out.Positions, pos)
+	}
+	sort.Slice(out.Positions, func(i, j int) bool {
+		return out.Positions[i].ProjectedChange.LessThan(out.Positions[j].ProjectedChange)
+	})
+	out.TotalValue = cash.Add(out.HoldingsValue)
+	out.ProjectedValue = out.TotalValue.Add(out.ProjectedChange)
+	if out.TotalValue.IsPositive() {
+		out.ProjectedChangePct = out.ProjectedChange.Div(out.TotalValue).Mul(decimal.NewFromInt(100)).Round(2)
+	}
+	return out, nil
+}
+
+// beta measures symbol's beta against benchmark from their daily closes on
+// the days both traded, or nil when there is too little history.
+func (s *StressService) beta(ctx context.Context, symbol string, benchmark *HistoricalSeries) *decimal.Decimal {
+	if len(benchmark.Points) == 0 {
+		return nil
+	}
+	series, err := s.series.GetHistoricalSeries(ctx, symbol, stressBetaDays)
+	if err != nil {
+		slog.Warn("stress test: history unavailable", "symbol", symbol, "err", err, "component", "stress")
+		return nil
+	}
+	return seriesBeta(series.Points, benchmark.Points)
+}
+
+// seriesBeta is the beta of one close series against another, over the
+// dates in both, or nil with fewer than stressMinBetaReturns daily returns.
+func seriesBeta(points, benchmark []HistoricalSeriesPoint) *decimal.Decimal {
+	closes := make(map[string]decimal.Decimal, len(points))
+	for _, p := range points {
+		closes[p.Date] = p.Close
+	}
+	var xs, ys []float64
+	for _, b := range benchmark {
+		c, ok := closes[b.Date]
+		if !ok || !c.IsPositive() || !b.Close.IsPositive() {
+			continue
+		}
+		x, _ := c.Float64()
+		y, _ := b.Close.Float64()
+		xs, ys = append(xs, x), append(ys, y)
+	}
+	rx, ry := dailyReturns(xs), dailyReturns(ys)
+	if len(rx) < stressMinBetaReturns {
+		return nil
+	}
+	v := variance(ry)
+	if v <= 0 {
+		return nil
+	}
+	return riskDecimal(covariance(rx, ry)/v, 2)
+}
+
+// stressScenario resolves req to the scenario to run.
+func stressScenario(req StressTestRequest) (*StressScenario, error) {
+	id := strings.ToLower(strings.TrimSpace(req.Scenario))
+	if id != StressScenarioCustom {
+		if len(req.Shocks) > 0 || req.Market != nil {
+			return nil, &util.ValidationError{Field: "shocks", Message: "only allowed with the custom scenario"}
+		}
+		for _, sc := range StressScenarios {
+			if sc.ID == id {
+				return &sc, nil
+			}
+		}
+		ids := make([]string, 0, len(StressScenarios)+1)
+		for _, sc := range StressScenarios {
+			ids = append(ids, sc.ID)
+		}
+		return nil, &util.ValidationError{Field: "scenario", Message: "must be one of " + strings.Join(append(ids, StressScenarioCustom), ", ")}
+	}
+
+	if len(req.Shocks) == 0 && req.Market == nil {
+		return nil, &util.ValidationError{Field: "shocks", Message: "a custom scenario needs sector shocks or a market shock"}
+	}
+	sc := &StressScenario{ID: StressScenarioCustom, Name: "Custom", Sectors: make(map[string]decimal.Decimal, len(req.Shocks))}
+	for sector, shock := range req.Shocks {
+		canonical, ok := canonicalSector(sector)
+		if !ok {
+			return nil, &util.ValidationError{Field: "shocks", Message: sector + " is not a GICS sector"}
+		}
+		if err := validateStressShock("shocks", shock); err != nil {
+			return nil, err
+		}
+		sc.Sectors[canonical] = shock
+	}
+	if req.Market != nil {
+		if err := validateStressShock("market", *req.Market); err != nil {
+			return nil, err
+		}
+		sc.Market = *req.Market
+	}
+	return sc, nil
+}
+
+func validateStressShock(field string, shock decimal.Decimal) error {
+	if shock.Abs().GreaterThan(maxStressShock) {
+		return &util.ValidationError{Field: field, Message: "shocks must be between -100 and 100 percent"}
+	}
+	if !shock.Equal(shock.Round(2)) {
+		return &util.ValidationError{Field: field, Message: "shocks must have at most 2 decimal places"}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// seriesTable serves fixed close series by symbol.
+type seriesTable map[string][]HistoricalSeriesPoint
+
+func (t seriesTable) GetHistoricalSeries(_ context.Context, symbol string, _ int) (*HistoricalSeries, error) {
+	points, ok := t[symbol]
+	if !ok {
+		return nil, errors.New("no history")
+	}
+	return &HistoricalSeries{Symbol: symbol, Points: points}, nil
+}
+
+type sectorTable map[string]string
+
+func (t sectorTable) Classify(_ context.Context, symbols []string) (map[string]data.Classification, error) {
+	out := make(map[string]data.Classification)
+	for _, s := range symbols {
+		if sector, ok := t[s]; ok {
+			out[s] = data.Classification{Symbol: s, Sector: sector}
+		}
+	}
+	return out, nil
+}
+
+// closeSeries returns n daily closes starting at 100 whose daily returns
+// are scale times a fixed zig-zag.
+func closeSeries(n int, scale float64) []HistoricalSeriesPoint {
+	out := make([]HistoricalSeriesPoint, n)
+	c := 100.0
+	for i := range out {
+		if i > 0 {
+			r := 0.01
+			if i%3 == 0 {
+				r = -0.015
+			}
+			c *= 1 + scale*r
+		}
+		out[i] = HistoricalSeriesPoint{
+			Date:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i).Format(time.DateOnly),
+			Close: decimal.NewFromFloat(c),
+		}
+	}
+	return out
+}
+
+func TestSeriesBeta(t *testing.T) {
+	benchmark := closeSeries(40, 1)
+	if b := seriesBeta(closeSeries(40, 2), benchmark); b == nil || b.String() != "2" {
+		t.Errorf("got %v, want 2", b)
+	}
+	if b := seriesBeta(closeSeries(10, 2), benchmark); b != nil {
+		t.Errorf("short history: got %v, want nil", b)
+	}
+}
+
+func TestStressScenario_Validation(t *testing.T) {
+	drop := decimal.NewFromInt(-10)
+	for _, req := range []StressTestRequest{
+		{},
+		{Scenario: "1929"},
+		{Scenario: "covid_2020", Market: &drop},
+		{Scenario: "custom"},
+		{Scenario: "custom", Shocks: map[string]decimal.Decimal{"Crypto": drop}},
+		{Scenario: "custom", Shocks: map[string]decimal.Decimal{"Energy": decimal.NewFromInt(-150)}},
+		{Scenario: "custom", Shocks: map[string]decimal.Decimal{"Energy": decimal.RequireFromString("-1.001")}},
+	} {
+		var verr *util.ValidationError
+		if _, err := stressScenario(req); !errors.As(err, &verr) {
+			t.Errorf("%+v: got %v, want a validation error", req, err)
+		}
+	}
+
+	sc, err := stressScenario(StressTestRequest{Scenario: "Custom", Shocks: map[string]decimal.Decimal{"energy": drop}})
+	if err != nil || !sc.Sectors["Energy"].Equal(drop) || !sc.Market.IsZero() {
+		t.Errorf("custom: got %+v, %v", sc, err)
+	}
+}
+
+func TestStressTest(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	market := quoteMarket{"AAPL": decimal.NewFromInt(100), "SPY": decimal.NewFromInt(400), "GME": decimal.NewFromInt(20)}
+	series := seriesTable{"SPY": closeSeries(40, 1)}
+	svc := NewStressService(data.NewUserStore(db), data.NewPortfolioStore(db), market, series,
+		sectorTable{"AAPL": "Information Technology"}, "SPY")
+	now := time.Date(2026, time.October, 16, 15, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery("SELECT balance FROM users").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("2000"))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).
+			AddRow("p1", "user-1", "AAPL", 10, "90", "0", now, now).
+			AddRow("p2", "user-1", "SPY", 10, "380", "0", now, now).
+			AddRow("p3", "user-1", "GME", -10, "25", "300", now, now))
+
+	res, err := svc.Run(context.Background(), "user-1", StressTestRequest{Scenario: "covid_2020"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	// AAPL takes its sector's -31%; SPY, unclassified, the market's -34%
+	// times its beta of 1; GME has no history, so takes -34% as is, which
+	// a short gains from.
+	got := make(map[string]string)
+	for _, p := range res.Positions {
+		got[p.Symbol] = fmt.Sprintf("%s %s %s", p.Basis, p.Shock, p.ProjectedChange)
+	}
+	want := map[string]string{"AAPL": "sector -31 -310", "SPY": "beta -34 -1360", "GME": "beta -34 68"}
+	for symbol, w := range want {
+		if got[symbol] != w {
+			t.Errorf("%s: got %q, want %q", symbol, got[symbol], w)
+		}
+	}
+	if res.Positions[0].Symbol != "SPY" {
+		t.Errorf("positions should be worst first, got %s first", res.Positions[0].Symbol)
+	}
+	if res.TotalValue.String() != "6800" || res.ProjectedChange.String() != "-1602" ||
+		res.ProjectedChangePct.String() != "-23.56" || res.ProjectedValue.String() != "5198" {
+		t.Errorf("got total %s, change %s (%s%%), projected %s", res.TotalValue, res.ProjectedChange, res.ProjectedChangePct, res.ProjectedValue)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// symbolChangeDataset is the bundled corporate-actions data: one row per
+// ticker change with the old symbol, the new one and the first session it
+// traded under the new one. MarketStack has no ticker-change data on our
+// plan, so add rows here (or through the admin API) as renames happen.
+//
+//go:embed refdata/symbol_changes.csv
+var symbolChangeDataset []byte
+
+// maxAliasChain bounds how many renames Resolve follows (A -> B -> C), and
+// stops a cycle from looping.
+const maxAliasChain = 8
+
+// symbolAliasInterval is how often Run applies renames that have come into
+// effect and reloads aliases recorded on other instances.
+const symbolAliasInterval = time.Hour
+
+// SymbolAliasService tracks ticker changes. From a rename's effective date
+// Resolve maps the old symbol to the new one, so quotes and charts keep
+// working for holdings and history under the old ticker, and Apply re-keys
+// the rows stored under the old symbol once.
+//
+// The aliases in effect are held in memory so lookups don't touch the
+// database; Run reloads them, so an alias added on another instance is
+// picked up within symbolAliasInterval.
+type SymbolAliasService struct {
+	store *data.SymbolAliasStore
+	now   func() time.Time
+
+	mu      sync.RWMutex
+	aliases map[string]string // old symbol -> new symbol, effective aliases only
+}
+
+func NewSymbolAliasService(store *data.SymbolAliasStore) *SymbolAliasService {
+	return &SymbolAliasService{store: store, now: time.Now, aliases: map[string]string{}}
+}
+
+// Resolve returns the symbol symbol trades under now: itself, or the end of
+// its chain of renames.
+func (s *SymbolAliasService) Resolve(symbol string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for range maxAliasChain {
+		next, ok := s.aliases[symbol]
+		if !ok {
+			break
+		}
+		symbol = next
+	}
+	return symbol
+}
+
+// List returns every recorded rename.
+func (s *SymbolAliasService) List(ctx context.Context) ([]data.SymbolAlias, error) {
+	return s.store.List(ctx)
+}
+
+// LoadDataset writes the bundled dataset to the store, leaving admin
+// entries alone, and applies any renames now in effect. Returns the number
+// of rows written.
+func (s *SymbolAliasService) LoadDataset(ctx context.Context) (int, error) {
+	items, err := parseSymbolChangeDataset(symbolChangeDataset)
+	if err != nil {
+		return 0, fmt.Errorf("parse symbol change dataset: %w", err)
+	}
+	n, err := s.store.SaveDataset(ctx, items)
+	if err != nil {
+		return 0, err
+	}
+	slog.Info("symbol change dataset loaded", "renames", len(items), "written", n, "component", "symbol_alias")
+	if _, err := s.Apply(ctx); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// Record saves an admin rename of a.OldSymbol to a.NewSymbol, effective
+// a.EffectiveDate, and applies it straight away if that date has come.
+func (s *SymbolAliasService) Record(ctx context.Context, a data.SymbolAlias) (*data.SymbolAlias, error) {
+	if err := s.normalise(&a); err != nil {
+		return nil, err
+	}
+	saved, err := s.store.Save(ctx, &a)
+	if err != nil {
+		return nil, err
+	}
+	if !s.effective(*saved) {
+		return saved, s.reload(ctx)
+	}
+	if _, err := s.rekey(ctx, *saved); err != nil {
+		return nil, err
+	}
+	return saved, s.reload(ctx)
+}
+
+// Apply re-keys the rows of every rename that has taken effect but not yet
+// been migrated, and refreshes the aliases Resolve uses. A rename that
+// fails is logged and retried on the next pass; its alias still applies to
+// lookups. Returns the number of renames migrated.
+func (s *SymbolAliasService) Apply(ctx context.Context) (int, error) {
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	migrated := 0
+	// List is newest first; apply oldest first so a chain (A -> B, B -> C)
+	// moves rows along it in order.
+	for i := len(all) - 1; i >= 0; i-- {
+		a := all[i]
+		if a.MigratedAt != nil || !s.effective(a) {
+			continue
+		}
+		if _, err := s.rekey(ctx, a); err != nil {
+			if ctx.Err() != nil {
+				return migrated, ctx.Err()
+			}
+			slog.Error("symbol rename failed", "old_symbol", a.OldSymbol, "new_symbol", a.NewSymbol, "err", err, "component", "symbol_alias")
+			continue
+		}
+		migrated++
+	}
+	s.set(all)
+	return migrated, nil
+}
+
+// Run calls Apply every symbolAliasInterval until ctx is cancelled. Every
+// instance may run it: re-keying an already migrated rename moves nothing.
+func (s *SymbolAliasService) Run(ctx context.Context) {
+	ticker := time.NewTicker(symbolAliasInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.Apply(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("symbol rename pass failed", "err", err, "component", "symbol_alias")
+		}
+	}
+}
+
+func (s *SymbolAliasService) rekey(ctx context.Context, a data.SymbolAlias) (*data.SymbolRekey, error) {
+	moved, err := s.store.Rekey(ctx, a)
+	if err != nil {
+		if errors.Is(err, data.ErrSymbolRekeyConflict) {
+			return nil, &SymbolRenameConflictError{OldSymbol: a.OldSymbol, NewSymbol: a.NewSymbol}
+		}
+		return nil, err
+	}
+	slog.Info("symbol renamed", "old_symbol", a.OldSymbol, "new_symbol", a.NewSymbol,
+		"holdings", moved.Holdings, "lots", moved.Lots, "watchlist", moved.Watchlist,
+		"orders", moved.Orders, "recurring", moved.Recurring, "component", "symbol_alias")
+	return moved, nil
+}
+
+func (s *SymbolAliasService) reload(ctx context.Context) error {
+	all, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	s.set(all)
+	return nil
+}
+
+func (s *SymbolAliasService) set(all []data.SymbolAlias) {
+	aliases := make(map[string]string, len(all))
+	for _, a := range all {
+		if s.effective(a) {
+			aliases[a.OldSymbol] = a.NewSymbol
+		}
+	}
+	s.mu.Lock()
+	s.aliases = aliases
+	s.mu.Unlock()
+}
+
+// effective reports whether a's effective date has come. Dates are compared
+// in UTC, whose day starts after the New York close the evening before, so
+// a rename switches over between sessions.
+func (s *SymbolAliasService) effective(a data.SymbolAlias) bool {
+	today := s.now().UTC().Format(DateLayoutISO)
+	return a.EffectiveDate.UTC().Format(DateLayoutISO) <= today
+}
+
+// normalise validates a in place: two different valid symbols, a date, and
+// no rename back to a symbol that already resolves to the old one.
+func (s *SymbolAliasService) normalise(a *data.SymbolAlias) error {
+	oldSymbol, err := util.ValidateSymbol(a.OldSymbol)
+	if err != nil {
+		return err
+	}
+	newSymbol, err := util.ValidateSymbol(a.NewSymbol)
+	if err != nil {
+		return &util.ValidationError{Field: "new_symbol", Message: "invalid stock symbol format"}
+	}
+	if oldSymbol == newSymbol {
+		return &util.ValidationError{Field: "new_symbol", Message: "must differ from the old symbol"}
+	}
+	if s.Resolve(newSymbol) == oldSymbol {
+		return &util.ValidationError{Field: "new_symbol", Message: "already renamed to the old symbol"}
+	}
+	if a.EffectiveDate.IsZero() {
+		return &util.ValidationError{Field: "effective_date", Message: "is required"}
+	}
+	y, m, d := a.EffectiveDate.Date()
+	a.OldSymbol, a.NewSymbol, a.EffectiveDate = oldSymbol, newSymbol, time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+// parseSymbolChangeDataset reads the bundled CSV, validating every row the
+// same way as an admin entry.
+func parseSymbolChangeDataset(raw []byte) ([]data.SymbolAlias, error) {
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty dataset")
+	}
+
+	check := &SymbolAliasService{aliases: map[string]string{}}
+	items := make([]data.SymbolAlias, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		if len(rec) != 3 {
+			return nil, fmt.Errorf("line %d: want 3 fields, got %d", i+2, len(rec))
+		}
+		date, err := time.Parse(DateLayoutISO, rec[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		a := data.SymbolAlias{OldSymbol: rec[0], NewSymbol: rec[1], EffectiveDate: date}
+		if err := check.normalise(&a); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		check.aliases[a.OldSymbol] = a.NewSymbol
+		items = append(items, a)
+	}
+	return items, nil
+}
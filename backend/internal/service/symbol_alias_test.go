@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+var symbolAliasCols = []string{"old_symbol", "new_symbol", "effective_date", "source", "migrated_at", "created_at"}
+
+func TestParseSymbolChangeDataset(t *testing.T) {
+	items, err := parseSymbolChangeDataset(symbolChangeDataset)
+	if err != nil {
+		t.Fatalf("bundled dataset: %v", err)
+	}
+	if len(items) == 0 {
+		t.Fatal("bundled dataset is empty")
+	}
+
+	for name, raw := range map[string]string{
+		"same symbol": "old_symbol,new_symbol,effective_date\nFB,FB,2022-06-09\n",
+		"bad date":    "old_symbol,new_symbol,effective_date\nFB,META,June 9\n",
+		"cycle":       "old_symbol,new_symbol,effective_date\nFB,META,2022-06-09\nMETA,FB,2023-01-01\n",
+	} {
+		if _, err := parseSymbolChangeDataset([]byte(raw)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSymbolAliasResolve(t *testing.T) {
+	svc := NewSymbolAliasService(nil)
+	svc.now = func() time.Time { return time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC) }
+	svc.set([]data.SymbolAlias{
+		{OldSymbol: "AAA", NewSymbol: "BBB", EffectiveDate: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{OldSymbol: "BBB", NewSymbol: "CCC", EffectiveDate: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)},
+		{OldSymbol: "DDD", NewSymbol: "EEE", EffectiveDate: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+	})
+	for symbol, want := range map[string]string{"AAA": "CCC", "BBB": "CCC", "DDD": "DDD", "ZZZ": "ZZZ"} {
+		if got := svc.Resolve(symbol); got != want {
+			t.Errorf("Resolve(%s) = %s, want %s", symbol, got, want)
+		}
+	}
+
+	var verr *util.ValidationError
+	_, err := svc.Record(context.Background(), data.SymbolAlias{OldSymbol: "CCC", NewSymbol: "AAA", EffectiveDate: time.Now()})
+	if !errors.As(err, &verr) || verr.Field != "new_symbol" {
+		t.Errorf("renaming back along a chain: got %v, want a new_symbol validation error", err)
+	}
+}
+
+func TestSymbolAliasApply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewSymbolAliasService(data.NewSymbolAliasStore(db))
+	svc.now = func() time.Time { return time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC) }
+
+	effective := time.Date(2022, time.June, 9, 0, 0, 0, 0, time.UTC)
+	upcoming := time.Date(2026, time.November, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT .+ FROM symbol_aliases").WillReturnRows(sqlmock.NewRows(symbolAliasCols).
+		AddRow("OLD", "NEW", upcoming, "admin", nil, time.Now()).
+		AddRow("FB", "META", effective, "dataset", nil, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM portfolio o").WithArgs("FB", "META").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("UPDATE portfolio n").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM portfolio\s+WHERE symbol = \$1 AND user_id IN`).WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE portfolio SET symbol").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE tax_lots").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`DELETE FROM watchlist\s+WHERE symbol = \$1 AND user_id IN`).WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE watchlist").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE orders").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE recurring_investments").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE symbol_aliases SET migrated_at").WithArgs("FB", "META").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	n, err := svc.Apply(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("Apply = %d, %v; want 1 rename migrated", n, err)
+	}
+	if got := svc.Resolve("FB"); got != "META" {
+		t.Errorf("Resolve(FB) = %s, want META", got)
+	}
+	if got := svc.Resolve("OLD"); got != "OLD" {
+		t.Errorf("Resolve(OLD) = %s before its effective date, want OLD", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSymbolAliasApply_Conflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewSymbolAliasService(data.NewSymbolAliasStore(db))
+
+	mock.ExpectQuery("SELECT .+ FROM symbol_aliases").WillReturnRows(sqlmock.NewRows(symbolAliasCols).
+		AddRow("FB", "META", time.Date(2022, time.June, 9, 0, 0, 0, 0, time.UTC), "dataset", nil, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM portfolio o").WithArgs("FB", "META").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	// The rename is left for the next pass, but lookups follow it already.
+	if n, err := svc.Apply(context.Background()); err != nil || n != 0 {
+		t.Fatalf("Apply = %d, %v; want nothing migrated", n, err)
+	}
+	if got := svc.Resolve("FB"); got != "META" {
+		t.Errorf("Resolve(FB) = %s, want META", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
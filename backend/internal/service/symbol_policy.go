@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// InstrumentResolver returns reference data for a symbol. Satisfied by
+// *InstrumentService.
+type InstrumentResolver interface {
+	Resolve(ctx context.Context, symbol string) (*data.Instrument, error)
+}
+
+// SymbolPolicy rejects buys and short sales of penny stocks and of symbols
+// listed outside the allowed exchanges, keeping the game focused on liquid
+// names. Sells and covers are never blocked so users can always exit a
+// position that has since fallen below the threshold.
+type SymbolPolicy struct {
+	instruments      InstrumentResolver
+	minPrice         decimal.Decimal
+	allowedExchanges map[string]struct{}
+	allowlist        map[string]struct{}
+}
+
+// NewSymbolPolicy builds a policy. A zero minPrice disables the price rule and
+// an empty allowedExchanges disables the venue rule. Symbols in allowlist are
+// exempt from both.
+func NewSymbolPolicy(instruments InstrumentResolver, minPrice decimal.Decimal, allowedExchanges, allowlist []string) *SymbolPolicy {
+	return &SymbolPolicy{
+		instruments:      instruments,
+		minPrice:         minPrice,
+		allowedExchanges: toUpperSet(allowedExchanges),
+		allowlist:        toUpperSet(allowlist),
+	}
+}
+
+// CheckTrade implements PreTradeCheck.
+func (p *SymbolPolicy) CheckTrade(ctx context.Context, intent TradeIntent) error {
+	if intent.Action != "BUY" && intent.Action != "SHORT" {
+		return nil
+	}
+	if _, ok := p.allowlist[intent.Symbol]; ok {
+		return nil
+	}
+
+	if p.minPrice.IsPositive() && intent.Price.LessThan(p.minPrice) {
+		return &SymbolRestrictedError{Reason: "price is below the $" + p.minPrice.StringFixed(2) + " minimum"}
+	}
+
+	if len(p.allowedExchanges) == 0 {
+		return nil
+	}
+
+	inst, err := p.instruments.Resolve(ctx, intent.Symbol)
+	if err != nil {
+		// Fail open: a tickers-endpoint outage must not halt all trading. The
+		// price rule above has already run, which catches most OTC names.
+		slog.Warn("instrument lookup failed; skipping exchange check",
+			"symbol", intent.Symbol, "err", err, "component", "symbol_policy")
+		return nil
+	}
+	if inst.Exchange == "" {
+		return nil
+	}
+	if _, ok := p.allowedExchanges[inst.Exchange]; !ok {
+		return &SymbolRestrictedError{Reason: "listed on " + inst.Exchange + ", which is not a supported exchange"}
+	}
+	return nil
+}
+
+func toUpperSet(values []string) map[string]struct{} {
+	out := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			out[v] = struct{}{}
+		}
+	}
+	return out
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+var instrumentCols = []string{
+	"symbol", "name", "exchange", "asset_type", "currency", "created_at", "updated_at",
+	"halted", "halt_reason", "halt_source", "halted_at", "tick_size", "price_decimals",
+}
+
+// instrumentRow returns a freshly-updated, non-halted instrument row.
+func instrumentRow(symbol, exchange string) *sqlmock.Rows {
+	return sqlmock.NewRows(instrumentCols).
+		AddRow(symbol, symbol+" Inc", exchange, "stock", "USD", time.Now(), time.Now(), false, "", "", nil, "0.01", 2)
+}
+
+// fakeLookup implements InstrumentLookup for tests.
+type fakeLookup struct {
+	inst  *data.Instrument
+	err   error
+	calls int
+}
+
+func (f *fakeLookup) LookupInstrument(_ context.Context, _ string) (*data.Instrument, error) {
+	f.calls++
+	return f.inst, f.err
+}
+
+func newTestPolicy(db *sql.DB, lookup InstrumentLookup, allowlist ...string) *SymbolPolicy {
+	instruments := NewInstrumentService(data.NewInstrumentStore(db), lookup, nil, nil)
+	return NewSymbolPolicy(instruments, decimal.NewFromInt(1),
+		[]string{"XNAS", "XNYS"}, allowlist)
+}
+
+func buyIntent(symbol string, price float64) TradeIntent {
+	return TradeIntent{UserID: "user-1", Symbol: symbol, Action: "BUY", Quantity: decimal.NewFromInt(1), Price: decimal.NewFromFloat(price)}
+}
+
+func TestSymbolPolicy_RejectsPennyStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	err = newTestPolicy(db, nil).CheckTrade(context.Background(), buyIntent("PNNY", 0.42))
+	var restricted *SymbolRestrictedError
+	if !errors.As(err, &restricted) {
+		t.Fatalf("expected SymbolRestrictedError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("price rule should not touch the DB: %v", err)
+	}
+}
+
+func TestSymbolPolicy_RejectsDisallowedExchange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("OTCX").
+		WillReturnRows(instrumentRow("OTCX", "OTCM"))
+
+	err = newTestPolicy(db, nil).CheckTrade(context.Background(), buyIntent("OTCX", 12))
+	var restricted *SymbolRestrictedError
+	if !errors.As(err, &restricted) {
+		t.Fatalf("expected SymbolRestrictedError, got %v", err)
+	}
+}
+
+func TestSymbolPolicy_LooksUpAndPersistsUnknownInstrument(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("AAPL").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO instruments").
+		WithArgs("AAPL", "Apple Inc", "XNAS", "stock", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("AAPL").
+		WillReturnRows(instrumentRow("AAPL", "XNAS"))
+
+	lookup := &fakeLookup{inst: &data.Instrument{Symbol: "AAPL", Name: "Apple Inc", Exchange: "XNAS", AssetType: "stock"}}
+	if err := newTestPolicy(db, lookup).CheckTrade(context.Background(), buyIntent("AAPL", 190)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lookup.calls != 1 {
+		t.Errorf("lookup calls: got %d, want 1", lookup.calls)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestSymbolPolicy_FailsOpenWhenLookupFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT symbol, name, exchange, asset_type").
+		WithArgs("AAPL").
+		WillReturnError(sql.ErrNoRows)
+
+	lookup := &fakeLookup{err: errors.New("tickers endpoint down")}
+	if err := newTestPolicy(db, lookup).CheckTrade(context.Background(), buyIntent("AAPL", 190)); err != nil {
+		t.Errorf("expected fail-open, got %v", err)
+	}
+}
+
+func TestSymbolPolicy_SellsAndAllowlistBypass(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	policy := newTestPolicy(db, nil, "PNNY")
+
+	sell := buyIntent("OTCX", 0.10)
+	sell.Action = "SELL"
+	if err := policy.CheckTrade(context.Background(), sell); err != nil {
+		t.Errorf("sells must never be blocked, got %v", err)
+	}
+	if err := policy.CheckTrade(context.Background(), buyIntent("PNNY", 0.10)); err != nil {
+		t.Errorf("allowlisted symbol must bypass the policy, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("bypassed checks should not touch the DB: %v", err)
+	}
+}
+
+func TestBuyStock_PreTradeCheckRejectsBeforeTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	market := &mockMarket{stock: &StockData{Symbol: "PNNY", Price: decimal.NewFromFloat(0.50)}}
+	svc := NewInvestmentService(db, market, data.NewPortfolioStore(db), data.NewTradesStore(db),
+		newTestPolicy(db, nil))
+
+	_, err = svc.BuyStock(context.Background(), "user-1", "PNNY", decimal.NewFromInt(10), "")
+	var restricted *SymbolRestrictedError
+	if !errors.As(err, &restricted) {
+		t.Fatalf("expected SymbolRestrictedError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("no transaction should be opened: %v", err)
+	}
+}
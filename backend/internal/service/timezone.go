@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// TimezoneService manages the per-user time zone that days and months are
+// drawn in: daily trade limits, the day-trade window, day change and
+// statements. Timestamps are still stored and returned in UTC.
+type TimezoneService struct {
+	users *data.UserStore
+}
+
+func NewTimezoneService(users *data.UserStore) *TimezoneService {
+	return &TimezoneService{users: users}
+}
+
+// SetTimezone saves userID's time zone, an IANA name such as
+// "Europe/London", and returns its canonical form.
+func (s *TimezoneService) SetTimezone(ctx context.Context, userID, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	loc, err := time.LoadLocation(name)
+	// "" and "Local" load too, as UTC and the server's zone.
+	if err != nil || name == "" || name == "Local" {
+		return "", &util.ValidationError{Field: "timezone", Message: "must be an IANA time zone such as America/New_York"}
+	}
+	if err := s.users.SetTimezone(ctx, userID, loc.String()); err != nil {
+		return "", err
+	}
+	return loc.String(), nil
+}
+
+// userLocation loads userID's time zone. A stored name this build's zone
+// database does not know falls back to data.DefaultTimezone.
+func userLocation(ctx context.Context, users *data.UserStore, userID string) (*time.Location, error) {
+	name, err := users.GetTimezone(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		slog.Warn("unknown user time zone; using default", "user_id", userID, "timezone", name, "component", "timezone")
+		return time.LoadLocation(data.DefaultTimezone)
+	}
+	return loc, nil
+}
+
+// startOfDay returns midnight of t's calendar day in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
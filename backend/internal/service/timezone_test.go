@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestSetTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewTimezoneService(data.NewUserStore(db))
+
+	for _, name := range []string{"", "Local", "Mars/Olympus_Mons", "+05:00"} {
+		var verr *util.ValidationError
+		if _, err := svc.SetTimezone(context.Background(), "user-1", name); !errors.As(err, &verr) || verr.Field != "timezone" {
+			t.Errorf("%q: got %v, want a timezone validation error", name, err)
+		}
+	}
+
+	mock.ExpectExec("INSERT INTO user_settings \\(user_id, timezone\\)").
+		WithArgs("user-1", "Europe/London").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if got, err := svc.SetTimezone(context.Background(), "user-1", " Europe/London "); err != nil || got != "Europe/London" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
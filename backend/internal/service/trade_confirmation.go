@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// tradeConfirmationTimeout bounds the lookups and send behind one
+// confirmation email, which run after the trade's request has returned.
+const tradeConfirmationTimeout = 30 * time.Second
+
+// TradeConfirmation is what a trade confirmation email reports: the fill
+// and the cash left afterwards. Realized is set for sells. For buys and
+// sells, RemainingQuantity and AvgPrice describe the long position
+// afterwards; RemainingQuantity is 0 when a sell closed it.
+type TradeConfirmation struct {
+	Action            string // BUY, SELL, SHORT or COVER
+	Symbol            string
+	Quantity          decimal.Decimal
+	Price             decimal.Decimal
+	Total             decimal.Decimal
+	Realized          *decimal.Decimal
+	RemainingQuantity decimal.Decimal
+	AvgPrice          decimal.Decimal
+	CashBalance       decimal.Decimal
+}
+
+// TradeConfirmationSender is the subset of EmailService used by
+// TradeConfirmationService.
+type TradeConfirmationSender interface {
+	SendTradeConfirmationEmail(to string, c TradeConfirmation) error
+}
+
+// TradeConfirmationService emails users who opted in a confirmation of each
+// trade they make, with the realized gain of a sell under their cost-basis
+// method.
+type TradeConfirmationService struct {
+	users     *data.UserStore
+	portfolio *data.PortfolioStore
+	email     TradeConfirmationSender
+}
+
+// NewTradeConfirmationService builds the service. email may be nil, in which
+// case the setting can still be changed but nothing is sent.
+func NewTradeConfirmationService(users *data.UserStore, portfolio *data.PortfolioStore, email TradeConfirmationSender) *TradeConfirmationService {
+	return &TradeConfirmationService{users: users, portfolio: portfolio, email: email}
+}
+
+// SetEnabled turns userID's trade confirmation emails on or off and returns
+// the new setting.
+func (s *TradeConfirmationService) SetEnabled(ctx context.Context, userID string, enabled bool) (bool, error) {
+	if err := s.users.SetTradeConfirmationEmails(ctx, userID, enabled); err != nil {
+		return false, err
+	}
+	return enabled, nil
+}
+
+// TradeExecuted sends the confirmation for a trade in the background.
+func (s *TradeConfirmationService) TradeExecuted(ctx context.Context, exec TradeExecution) {
+	if s.email == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tradeConfirmationTimeout)
+		defer cancel()
+		s.send(ctx, exec)
+	}()
+}
+
+func (s *TradeConfirmationService) send(ctx context.Context, exec TradeExecution) {
+	user, err := s.users.GetUserByID(ctx, exec.UserID)
+	if err != nil {
+		slog.Warn("trade confirmation user lookup failed", "user_id", exec.UserID, "err", err, "component", "trade_confirmation")
+		return
+	}
+	// Guests have no email address.
+	if !user.TradeConfirmationEmails || user.Email == "" {
+		return
+	}
+
+	c := TradeConfirmation{
+		Action:      exec.Action,
+		Symbol:      exec.Symbol,
+		Quantity:    exec.Quantity,
+		Price:       exec.Price,
+		Total:       exec.Total,
+		Realized:    exec.Realized,
+		CashBalance: exec.BalanceAfter,
+	}
+	if exec.Action == "BUY" || exec.Action == "SELL" {
+		if !s.position(ctx, exec, &c) {
+			return
+		}
+	}
+
+	if err := s.email.SendTradeConfirmationEmail(user.Email, c); err != nil {
+		slog.Warn("trade confirmation email failed", "user_id", exec.UserID, "trade_id", exec.TradeID, "err", err, "component", "trade_confirmation")
+	}
+}
+
+// position fills in the long position exec left behind, reporting false
+// when it could not be read.
+func (s *TradeConfirmationService) position(ctx context.Context, exec TradeExecution, c *TradeConfirmation) bool {
+	holding, err := s.portfolio.GetPortfolioBySymbol(ctx, exec.UserID, exec.Symbol)
+	switch {
+	case errors.Is(err, data.ErrStockHoldingNotFound):
+	case err != nil:
+		slog.Warn("trade confirmation position lookup failed", "user_id", exec.UserID, "symbol", exec.Symbol, "err", err, "component", "trade_confirmation")
+		return false
+	default:
+		c.RemainingQuantity, c.AvgPrice = holding.Quantity, holding.AvgPrice
+	}
+	return true
+}
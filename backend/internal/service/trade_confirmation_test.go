@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+type mockConfirmationSender struct {
+	to   string
+	sent []TradeConfirmation
+}
+
+func (m *mockConfirmationSender) SendTradeConfirmationEmail(to string, c TradeConfirmation) error {
+	m.to = to
+	m.sent = append(m.sent, c)
+	return nil
+}
+
+func TestTradeConfirmationSend(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	sender := &mockConfirmationSender{}
+	svc := NewTradeConfirmationService(data.NewUserStore(db), data.NewPortfolioStore(db), sender)
+
+	realized := decimal.NewFromInt(-40)
+	exec := TradeExecution{
+		TradeIntent:  TradeIntent{UserID: "user-1", Symbol: "AAPL", Action: "SELL", Quantity: decimal.NewFromInt(4), Price: decimal.NewFromInt(90)},
+		TradeID:      "t1",
+		Total:        decimal.NewFromInt(360),
+		BalanceAfter: decimal.NewFromInt(5360),
+		Realized:     &realized,
+	}
+	user := func(enabled bool) *sqlmock.Rows {
+		return userRow(data.User{ID: "user-1", Email: "test@example.com", Password: "hashed",
+			Balance: decimal.NewFromInt(5360), EmailVerified: true, TradeConfirmationEmails: enabled})
+	}
+
+	// Opted out: nothing beyond the user lookup.
+	mock.ExpectQuery("FROM users LEFT JOIN user_settings").WithArgs("user-1").WillReturnRows(user(false))
+	svc.send(context.Background(), exec)
+	if len(sender.sent) != 0 {
+		t.Fatalf("sent while opted out: %+v", sender.sent)
+	}
+
+	// Opted in with shares left.
+	now := time.Now()
+	mock.ExpectQuery("FROM users LEFT JOIN user_settings").WithArgs("user-1").WillReturnRows(user(true))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("p1", "user-1", "AAPL", 6, "100", "0", now, now))
+	svc.send(context.Background(), exec)
+
+	// Opted in, position closed.
+	mock.ExpectQuery("FROM users LEFT JOIN user_settings").WithArgs("user-1").WillReturnRows(user(true))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	svc.send(context.Background(), exec)
+
+	if len(sender.sent) != 2 || sender.to != "test@example.com" {
+		t.Fatalf("sent: got %+v to %q, want two confirmations", sender.sent, sender.to)
+	}
+	if c := sender.sent[0]; c.Symbol != "AAPL" || !c.Quantity.Equal(decimal.NewFromInt(4)) || !c.Realized.Equal(realized) ||
+		!c.RemainingQuantity.Equal(decimal.NewFromInt(6)) || !c.AvgPrice.Equal(decimal.NewFromInt(100)) || !c.CashBalance.Equal(decimal.NewFromInt(5360)) {
+		t.Errorf("open position: got %+v", c)
+	}
+	if c := sender.sent[1]; !c.RemainingQuantity.IsZero() || !c.AvgPrice.IsZero() {
+		t.Errorf("closed position: got %+v", c)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTradeConfirmationSend_OtherActions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	sender := &mockConfirmationSender{}
+	svc := NewTradeConfirmationService(data.NewUserStore(db), data.NewPortfolioStore(db), sender)
+	user := func() *sqlmock.Rows {
+		return userRow(data.User{ID: "user-1", Email: "test@example.com", Password: "hashed",
+			Balance: decimal.NewFromInt(4000), EmailVerified: true, TradeConfirmationEmails: true})
+	}
+
+	// A buy reports the position it added to.
+	now := time.Now()
+	mock.ExpectQuery("FROM users LEFT JOIN user_settings").WithArgs("user-1").WillReturnRows(user())
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(portfolioCols).AddRow("p1", "user-1", "AAPL", 10, "95", "0", now, now))
+	svc.send(context.Background(), TradeExecution{
+		TradeIntent:  TradeIntent{UserID: "user-1", Symbol: "AAPL", Action: "BUY", Quantity: decimal.NewFromInt(5), Price: decimal.NewFromInt(90)},
+		Total:        decimal.NewFromInt(450),
+		BalanceAfter: decimal.NewFromInt(4000),
+	})
+
+	// A short has no long position to look up.
+	mock.ExpectQuery("FROM users LEFT JOIN user_settings").WithArgs("user-1").WillReturnRows(user())
+	svc.send(context.Background(), TradeExecution{
+		TradeIntent:  TradeIntent{UserID: "user-1", Symbol: "TSLA", Action: "SHORT", Quantity: decimal.NewFromInt(2), Price: decimal.NewFromInt(200)},
+		Total:        decimal.NewFromInt(400),
+		BalanceAfter: decimal.NewFromInt(4000),
+	})
+
+	if len(sender.sent) != 2 {
+		t.Fatalf("sent: got %+v, want two confirmations", sender.sent)
+	}
+	if c := sender.sent[0]; c.Action != "BUY" || c.Realized != nil || !c.RemainingQuantity.Equal(decimal.NewFromInt(10)) || !c.AvgPrice.Equal(decimal.NewFromInt(95)) {
+		t.Errorf("buy: got %+v", c)
+	}
+	if c := sender.sent[1]; c.Action != "SHORT" || c.Symbol != "TSLA" || !c.CashBalance.Equal(decimal.NewFromInt(4000)) {
+		t.Errorf("short: got %+v", c)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,414 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// Audit event kinds recorded for trade disputes.
+const (
+	AuditTradeDisputed        = "trade.disputed"
+	AuditTradeReversed        = "admin.trade_reversed"
+	AuditTradeDisputeRejected = "admin.trade_dispute_rejected"
+)
+
+const (
+	maxDisputeTextLength  = 1000 // characters, for reasons and resolution notes
+	defaultDisputeListing = 50
+	maxDisputeListing     = 500
+)
+
+// TradeDisputeService lets users flag one of their trades as wrong and
+// admins resolve the flag: reject it, or reverse the trade with a
+// compensating ADJUSTMENT trade that moves the cash and shares back. Every
+// step is recorded in the audit log.
+type TradeDisputeService struct {
+	db            *sql.DB
+	disputes      *data.TradeDisputeStore
+	audit         *data.AuditStore
+	notifications *NotificationService
+}
+
+func NewTradeDisputeService(db *sql.DB, disputes *data.TradeDisputeStore, audit *data.AuditStore, notifications *NotificationService) *TradeDisputeService {
+	return &TradeDisputeService{db: db, disputes: disputes, audit: audit, notifications: notifications}
+}
+
+// File opens a dispute on one of the user's trades. A trade can be disputed
+// once, and adjustments posted by an admin can't be disputed at all.
+func (s *TradeDisputeService) File(ctx context.Context, userID, tradeID, reason string) (*data.TradeDispute, error) {
+	reason, err := disputeText("reason", reason, true)
+	if err != nil {
+		return nil, err
+	}
+	trade, err := data.NewTradesStore(s.db).GetTradeByID(ctx, tradeID)
+	if errors.Is(err, data.ErrTradeNotFound) || (err == nil && trade.UserID != userID) {
+		return nil, &TradeNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if trade.OrderType == data.OrderTypeAdjustment {
+		return nil, &util.ValidationError{Field: "trade_id", Message: "adjustments can't be disputed"}
+	}
+
+	d, err := s.disputes.Create(ctx, userID, tradeID, reason)
+	switch {
+	case errors.Is(err, data.ErrTradeNotFound):
+		return nil, &TradeNotFoundError{}
+	case errors.Is(err, data.ErrTradeAlreadyDisputed):
+		return nil, &TradeAlreadyDisputedError{}
+	case err != nil:
+		return nil, err
+	}
+
+	s.record(ctx, userID, AuditTradeDisputed, map[string]any{
+		"dispute_id": d.ID, "trade_id": trade.ID, "symbol": trade.Symbol, "action": trade.Action,
+		"quantity": trade.Quantity, "price": trade.Price, "reason": reason,
+	})
+	slog.Info("trade disputed", "user_id", userID, "trade_id", trade.ID, "dispute_id", d.ID, "component", "trade_dispute")
+	return d, nil
+}
+
+// ListForUser returns the user's disputes, newest first.
+func (s *TradeDisputeService) ListForUser(ctx context.Context, userID string) ([]data.TradeDispute, error) {
+	return s.disputes.ListByUser(ctx, userID)
+}
+
+// List returns disputes across all users, oldest first. status is one of
+// the data.TradeDispute* statuses or "" for all; limit is clamped to
+// [1, maxDisputeListing] with zero selecting the default.
+func (s *TradeDisputeService) List(ctx context.Context, status string, limit int) ([]data.TradeDispute, error) {
+	switch status {
+	case "", data.TradeDisputeOpen, data.TradeDisputeReversed, data.TradeDisputeRejected:
+	default:
+		return nil, &util.ValidationError{Field: "status", Message: "must be OPEN, REVERSED or REJECTED"}
+	}
+	if limit <= 0 {
+		limit = defaultDisputeListing
+	}
+	return s.disputes.List(ctx, status, min(limit, maxDisputeListing))
+}
+
+// Reverse resolves an open dispute by undoing its trade: a BUY is reversed
+// by an ADJUSTMENT SELL of the same shares at the same price, refunding what
+// was paid, and a SELL by an ADJUSTMENT BUY, taking the proceeds back. The
+// reversal, the dispute's resolution and the audit event commit together.
+func (s *TradeDisputeService) Reverse(ctx context.Context, adminID, disputeID, note string) (*data.TradeDispute, error) {
+	note, err := disputeText("note", note, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	disputes := data.NewTradeDisputeStore(tx)
+	d, err := openDispute(ctx, disputes, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	trade, err := data.NewTradesStore(tx).GetTradeByID(ctx, d.TradeID)
+	if err != nil {
+		return nil, err
+	}
+	reversal, err := reverseTrade(ctx, tx, trade)
+	if err != nil {
+		return nil, err
+	}
+	if err := disputes.Resolve(ctx, d, data.TradeDisputeReversed, note, adminID, reversal.ID); err != nil {
+		return nil, err
+	}
+	details, _ := json.Marshal(map[string]any{
+		"dispute_id": d.ID, "user_id": d.UserID, "trade_id": trade.ID, "reversal_trade_id": reversal.ID,
+		"symbol": trade.Symbol, "action": reversal.Action, "quantity": reversal.Quantity,
+		"price": reversal.Price, "note": note,
+	})
+	if err := data.NewAuditStore(tx).Record(ctx, &data.AuditEvent{UserID: adminID, Kind: AuditTradeReversed, Details: details}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("trade reversed", "admin_id", adminID, "user_id", d.UserID, "trade_id", trade.ID,
+		"reversal_trade_id", reversal.ID, "component", "trade_dispute")
+	s.notify(ctx, d, fmt.Sprintf("Your %s of %s %s was reversed", trade.Action, trade.Quantity, trade.Symbol),
+		"We've undone the trade you disputed and moved the cash and shares back.")
+	return d, nil
+}
+
+// Reject resolves an open dispute without touching the trade.
+func (s *TradeDisputeService) Reject(ctx context.Context, adminID, disputeID, note string) (*data.TradeDispute, error) {
+	note, err := disputeText("note", note, true)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	disputes := data.NewTradeDisputeStore(tx)
+	d, err := openDispute(ctx, disputes, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := disputes.Resolve(ctx, d, data.TradeDisputeRejected, note, adminID, ""); err != nil {
+		return nil, err
+	}
+	details, _ := json.Marshal(map[string]any{
+		"dispute_id": d.ID, "user_id": d.UserID, "trade_id": d.TradeID, "note": note,
+	})
+	if err := data.NewAuditStore(tx).Record(ctx, &data.AuditEvent{UserID: adminID, Kind: AuditTradeDisputeRejected, Details: details}); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	slog.Info("trade dispute rejected", "admin_id", adminID, "user_id", d.UserID, "trade_id", d.TradeID, "component", "trade_dispute")
+	s.notify(ctx, d, "Your trade dispute was reviewed", "The trade stands: "+note)
+	return d, nil
+}
+
+// openDispute locks the dispute and checks it is still open.
+func openDispute(ctx context.Context, disputes *data.TradeDisputeStore, id string) (*data.TradeDispute, error) {
+	d, err := disputes.GetForUpdate(ctx, id)
+	if errors.Is(err, data.ErrTradeDisputeNotFound) {
+		return nil, &TradeDisputeNotFoundError{}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != data.TradeDisputeOpen {
+		return nil, &TradeDisputeClosedError{Status: d.Status}
+	}
+	return d, nil
+}
+
+// reverseTrade posts the ADJUSTMENT trade undoing trade inside tx and
+// returns it. Only BUYs and SELLs can be reversed, and only while the
+// account still has the shares (for a BUY) or the cash (for a SELL). The
+// tax lots are put back as they were before trade, and the holding's
+// average cost recomputed from them.
+func reverseTrade(ctx context.Context, tx *sql.Tx, trade *data.Trade) (*data.Trade, error) {
+	total := trade.Price.Mul(trade.Quantity).Round(2)
+	reversal := &data.Trade{
+		ID:        uuid.New().String(),
+		UserID:    trade.UserID,
+		Symbol:    trade.Symbol,
+		Quantity:  trade.Quantity,
+		Price:     trade.Price,
+		OrderType: data.OrderTypeAdjustment,
+	}
+
+	users := data.NewUserStore(tx)
+	portfolio := data.NewPortfolioStore(tx)
+	balance, err := users.GetBalanceForUpdate(ctx, trade.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch trade.Action {
+	case "BUY":
+		reversal.Action = "SELL"
+		holding, err := portfolio.GetPortfolioBySymbolForUpdate(ctx, trade.UserID, trade.Symbol)
+		if err != nil && !errors.Is(err, data.ErrStockHoldingNotFound) {
+			return nil, err
+		}
+		if holding == nil || holding.IsShort() || holding.Quantity.LessThan(trade.Quantity) {
+			return nil, &TradeNotReversibleError{Reason: "the account no longer holds the shares bought"}
+		}
+		if err := users.UpdateBalance(ctx, trade.UserID, balance.Add(total)); err != nil {
+			return nil, err
+		}
+		if err := data.NewTradesStore(tx).CreateTrade(ctx, reversal); err != nil {
+			return nil, err
+		}
+		if err := portfolio.UpdatePortfolioWithSell(ctx, trade.UserID, trade.Symbol, holding.Quantity, trade.Quantity); err != nil {
+			return nil, err
+		}
+		if err := unwindLot(ctx, tx, trade, reversal, holding); err != nil {
+			return nil, err
+		}
+		// What the shares no lot covers cost before the buy.
+		fallback := holding.AvgPrice
+		if rest := holding.Quantity.Sub(trade.Quantity); rest.IsPositive() {
+			if before := holding.AvgPrice.Mul(holding.Quantity).Sub(trade.Price.Mul(trade.Quantity)).Div(rest); before.IsPositive() {
+				fallback = before
+			}
+		}
+		if err := settleAvgPrice(ctx, tx, trade.UserID, trade.Symbol, fallback); err != nil {
+			return nil, err
+		}
+	case "SELL":
+		reversal.Action = "BUY"
+		if balance.LessThan(total) {
+			return nil, &TradeNotReversibleError{Reason: "the account no longer has the cash the sale raised"}
+		}
+		if err := users.UpdateBalance(ctx, trade.UserID, balance.Sub(total)); err != nil {
+			return nil, err
+		}
+		if err := data.NewTradesStore(tx).CreateTrade(ctx, reversal); err != nil {
+			return nil, err
+		}
+		cost, untracked, err := restoreLots(ctx, tx, trade)
+		if err != nil {
+			return nil, err
+		}
+		if err := portfolio.UpdatePortfolioWithBuy(ctx, trade.UserID, trade.Symbol, trade.Quantity, cost); err != nil {
+			if errors.Is(err, data.ErrShortPositionOpen) {
+				return nil, &TradeNotReversibleError{Reason: "the account is now short the symbol"}
+			}
+			return nil, err
+		}
+		if err := settleAvgPrice(ctx, tx, trade.UserID, trade.Symbol, untracked); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, &TradeNotReversibleError{Reason: "only buys and sells can be reversed"}
+	}
+	return reversal, nil
+}
+
+// unwindLot takes the shares a reversed BUY added off the lot it opened, so
+// no gain is realized on them. Shares already sold out of that lot are
+// drawn from the others per the user's cost-basis method.
+func unwindLot(ctx context.Context, tx *sql.Tx, buy, reversal *data.Trade, holding *data.UserStock) error {
+	lots := data.NewLotStore(tx)
+	open, err := lots.OpenLotsForUpdate(ctx, buy.UserID, buy.Symbol, false)
+	if err != nil {
+		return err
+	}
+	left := buy.Quantity
+	for _, lot := range open {
+		if lot.TradeID != buy.ID {
+			continue
+		}
+		take := decimal.Min(left, lot.Remaining)
+		if err := lots.ReduceLot(ctx, lot.ID, take); err != nil {
+			return err
+		}
+		left = left.Sub(take)
+	}
+	if left.IsZero() {
+		return nil
+	}
+	rest := *reversal
+	rest.Quantity = left
+	_, err = closeLots(ctx, tx, &rest, holding)
+	return err
+}
+
+// restoreLots undoes what a reversed SELL drew from the lots: its disposals
+// are deleted, so the gain they realized goes with them, and the shares go
+// back on the lots they came from. Returns what the shares cost per share,
+// and what those no lot took back cost; both are the sale price for a sell
+// from before lots were tracked.
+func restoreLots(ctx context.Context, tx *sql.Tx, sell *data.Trade) (cost, untracked decimal.Decimal, err error) {
+	lots := data.NewLotStore(tx)
+	disposals, err := lots.DeleteDisposals(ctx, sell.UserID, sell.ID)
+	if err != nil {
+		return decimal.Zero, decimal.Zero, err
+	}
+	quantity, basis := decimal.Zero, decimal.Zero
+	loose, looseBasis := decimal.Zero, decimal.Zero
+	for _, d := range disposals {
+		if d.LotID != "" {
+			if err := lots.RestoreLot(ctx, d.LotID, d.Quantity); err != nil {
+				return decimal.Zero, decimal.Zero, err
+			}
+		} else {
+			loose = loose.Add(d.Quantity)
+			looseBasis = looseBasis.Add(d.Quantity.Mul(d.CostPrice))
+		}
+		quantity = quantity.Add(d.Quantity)
+		basis = basis.Add(d.Quantity.Mul(d.CostPrice))
+	}
+	if quantity.IsZero() {
+		return sell.Price, sell.Price, nil
+	}
+	cost = basis.Div(quantity)
+	if loose.IsZero() {
+		return cost, cost, nil
+	}
+	return cost, looseBasis.Div(loose), nil
+}
+
+// settleAvgPrice sets the holding's average cost to what its open lots
+// paid, once a reversal has taken shares off them or put shares back.
+// Shares no lot covers (a holding older than lot tracking) are costed at
+// fallback. A position the reversal closed is left alone.
+func settleAvgPrice(ctx context.Context, tx *sql.Tx, userID, symbol string, fallback decimal.Decimal) error {
+	portfolio := data.NewPortfolioStore(tx)
+	holding, err := portfolio.GetPortfolioBySymbolForUpdate(ctx, userID, symbol)
+	if errors.Is(err, data.ErrStockHoldingNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	open, err := data.NewLotStore(tx).OpenLotsForUpdate(ctx, userID, symbol, false)
+	if err != nil {
+		return err
+	}
+	covered, cost := decimal.Zero, decimal.Zero
+	for _, lot := range open {
+		covered = covered.Add(lot.Remaining)
+		cost = cost.Add(lot.Remaining.Mul(lot.Price))
+	}
+	if rest := holding.Quantity.Sub(covered); rest.IsPositive() {
+		covered = holding.Quantity
+		cost = cost.Add(rest.Mul(fallback))
+	}
+	if covered.IsZero() {
+		return nil
+	}
+	return portfolio.SetAvgPrice(ctx, userID, symbol, cost.Div(covered))
+}
+
+func (s *TradeDisputeService) notify(ctx context.Context, d *data.TradeDispute, title, body string) {
+	if s.notifications == nil {
+		return
+	}
+	if err := s.notifications.Notify(ctx, []string{d.UserID}, NotificationTradeDispute, title, body); err != nil {
+		slog.Warn("trade dispute notification failed", "user_id", d.UserID, "dispute_id", d.ID, "err", err, "component", "trade_dispute")
+	}
+}
+
+// record writes an audit event outside a transaction. A failure is logged:
+// what it records has already happened.
+func (s *TradeDisputeService) record(ctx context.Context, userID, kind string, fields map[string]any) {
+	details, _ := json.Marshal(fields)
+	if err := s.audit.Record(ctx, &data.AuditEvent{UserID: userID, Kind: kind, Details: details}); err != nil {
+		slog.Warn("audit event not recorded", "user_id", userID, "kind", kind, "err", err, "component", "trade_dispute")
+	}
+}
+
+// disputeText sanitizes a reason or resolution note and checks its length.
+func disputeText(field, text string, required bool) (string, error) {
+	text = util.SanitizeString(text)
+	if required && text == "" {
+		return "", &util.ValidationError{Field: field, Message: "is required"}
+	}
+	if utf8.RuneCountInString(text) > maxDisputeTextLength {
+		return "", &util.ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d characters", maxDisputeTextLength)}
+	}
+	return text, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+var disputeCols = []string{"id", "trade_id", "user_id", "reason", "status", "resolution_note",
+	"resolved_by", "reversal_trade_id", "created_at", "resolved_at"}
+
+var tradeCols = []string{"id", "user_id", "symbol", "action", "quantity", "price", "total",
+	"executed_at", "status", "idempotency_key", "order_type", "slippage"}
+
+var (
+	holdingCols = []string{"id", "user_id", "symbol", "quantity", "avg_price", "margin", "created_at", "updated_at"}
+	lotCols     = []string{"id", "user_id", "symbol", "trade_id", "quantity", "remaining", "price", "acquired_at"}
+)
+
+func expectOpenDispute(mock sqlmock.Sqlmock, status string) {
+	mock.ExpectQuery("FROM trade_disputes WHERE id = \\$1 FOR UPDATE").
+		WithArgs("d-1").
+		WillReturnRows(sqlmock.NewRows(disputeCols).
+			AddRow("d-1", "t-1", "user-1", "filled at a stale price", status, "", nil, nil, time.Now(), nil))
+}
+
+func TestTradeDispute_ReverseBuy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	svc := NewTradeDisputeService(db, data.NewTradeDisputeStore(db), data.NewAuditStore(db), nil)
+
+	mock.ExpectBegin()
+	expectOpenDispute(mock, data.TradeDisputeOpen)
+	mock.ExpectQuery("FROM trades WHERE id = \\$1").
+		WithArgs("t-1").
+		WillReturnRows(sqlmock.NewRows(tradeCols).
+			AddRow("t-1", "user-1", "AAPL", "BUY", 10, "250.00", "2500.00", time.Now(), "COMPLETED", nil, "MARKET", "0"))
+	mock.ExpectQuery("SELECT balance FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("100.00"))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2 FOR UPDATE").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(holdingCols).
+			AddRow("p-1", "user-1", "AAPL", 15, "200.00", "0", time.Now(), time.Now()))
+	mock.ExpectExec("UPDATE users SET balance = \\$1 WHERE id = \\$2").
+		WithArgs(decimal.RequireFromString("2600"), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "SELL", decimal.NewFromInt(10), sqlmock.AnyArg(), "COMPLETED", nil, data.OrderTypeAdjustment, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE portfolio SET quantity = \\$1").
+		WithArgs(decimal.NewFromInt(5), "user-1", "AAPL").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The shares come off the disputed trade's own lot, realizing nothing.
+	mock.ExpectQuery("FROM tax_lots").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(lotCols).
+			AddRow("lot-0", "user-1", "AAPL", "t-0", 5, 5, "100.00", time.Now()).
+			AddRow("lot-1", "user-1", "AAPL", "t-1", 10, 10, "250.00", time.Now()))
+	mock.ExpectExec("UPDATE tax_lots SET remaining = remaining - \\$2").
+		WithArgs("lot-1", decimal.NewFromInt(10)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The remaining shares cost what their lot paid, not the blended 200.
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2 FOR UPDATE").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(holdingCols).
+			AddRow("p-1", "user-1", "AAPL", 5, "200.00", "0", time.Now(), time.Now()))
+	mock.ExpectQuery("FROM tax_lots").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(lotCols).
+			AddRow("lot-0", "user-1", "AAPL", "t-0", 5, 5, "100.00", time.Now()))
+	mock.ExpectExec("UPDATE portfolio SET avg_price = \\$1").
+		WithArgs(decimal.NewFromInt(100), "user-1", "AAPL").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE trade_disputes").
+		WithArgs("d-1", data.TradeDisputeReversed, "bad quote", "admin-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"resolved_at"}).AddRow(time.Now()))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), AuditTradeReversed, "", "", sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	d, err := svc.Reverse(context.Background(), "admin-1", "d-1", "bad quote")
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if d.Status != data.TradeDisputeReversed || d.ReversalTradeID == "" || d.ResolvedBy != "admin-1" {
+		t.Errorf("dispute = %+v, want reversed by admin-1 with a reversal trade", d)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTradeDispute_ReverseSell(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	svc := NewTradeDisputeService(db, data.NewTradeDisputeStore(db), data.NewAuditStore(db), nil)
+
+	mock.ExpectBegin()
+	expectOpenDispute(mock, data.TradeDisputeOpen)
+	mock.ExpectQuery("FROM trades WHERE id = \\$1").
+		WithArgs("t-1").
+		WillReturnRows(sqlmock.NewRows(tradeCols).
+			AddRow("t-1", "user-1", "AAPL", "SELL", 10, "250.00", "2500.00", time.Now(), "COMPLETED", nil, "MARKET", "0"))
+	mock.ExpectQuery("SELECT balance FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("3000.00"))
+	mock.ExpectExec("UPDATE users SET balance = \\$1 WHERE id = \\$2").
+		WithArgs(decimal.RequireFromString("500"), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO trades").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", "BUY", decimal.NewFromInt(10), sqlmock.AnyArg(), "COMPLETED", nil, data.OrderTypeAdjustment, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The sale drew 6 shares from a lot bought at 100 and 4 that predate
+	// lot tracking at the then average of 150; both go back at cost.
+	mock.ExpectQuery("DELETE FROM lot_disposals").
+		WithArgs("user-1", "t-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "sell_trade_id", "lot_id", "symbol", "quantity",
+			"cost_price", "sale_price", "realized", "method", "disposed_at"}).
+			AddRow("disp-1", "user-1", "t-1", "lot-0", "AAPL", 6, "100.00", "250.00", "900.00", "FIFO", time.Now()).
+			AddRow("disp-2", "user-1", "t-1", nil, "AAPL", 4, "150.00", "250.00", "400.00", "FIFO", time.Now()))
+	mock.ExpectExec("UPDATE tax_lots SET remaining = remaining \\+ \\$2").
+		WithArgs("lot-0", decimal.NewFromInt(6)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2 FOR UPDATE").
+		WithArgs("user-1", "AAPL").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec("INSERT INTO portfolio").
+		WithArgs(sqlmock.AnyArg(), "user-1", "AAPL", decimal.NewFromInt(10), decimal.NewFromInt(120)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM portfolio WHERE user_id = \\$1 AND symbol = \\$2 FOR UPDATE").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(holdingCols).
+			AddRow("p-1", "user-1", "AAPL", 10, "120.00", "0", time.Now(), time.Now()))
+	mock.ExpectQuery("FROM tax_lots").
+		WithArgs("user-1", "AAPL").
+		WillReturnRows(sqlmock.NewRows(lotCols).
+			AddRow("lot-0", "user-1", "AAPL", "t-0", 6, 6, "100.00", time.Now()))
+	mock.ExpectExec("UPDATE portfolio SET avg_price = \\$1").
+		WithArgs(decimal.NewFromInt(120), "user-1", "AAPL").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE trade_disputes").
+		WithArgs("d-1", data.TradeDisputeReversed, "", "admin-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"resolved_at"}).AddRow(time.Now()))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err := svc.Reverse(context.Background(), "admin-1", "d-1", ""); err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTradeDispute_ReverseSellNeedsCash(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	svc := NewTradeDisputeService(db, data.NewTradeDisputeStore(db), data.NewAuditStore(db), nil)
+
+	mock.ExpectBegin()
+	expectOpenDispute(mock, data.TradeDisputeOpen)
+	mock.ExpectQuery("FROM trades WHERE id = \\$1").
+		WithArgs("t-1").
+		WillReturnRows(sqlmock.NewRows(tradeCols).
+			AddRow("t-1", "user-1", "AAPL", "SELL", 10, "250.00", "2500.00", time.Now(), "COMPLETED", nil, "MARKET", "0"))
+	mock.ExpectQuery("SELECT balance FROM users WHERE id = \\$1 FOR UPDATE").
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("1000.00"))
+	mock.ExpectRollback()
+
+	var notReversible *TradeNotReversibleError
+	if _, err := svc.Reverse(context.Background(), "admin-1", "d-1", ""); !errors.As(err, &notReversible) {
+		t.Fatalf("got %v, want TradeNotReversibleError", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTradeDispute_RejectClosed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	svc := NewTradeDisputeService(db, data.NewTradeDisputeStore(db), data.NewAuditStore(db), nil)
+
+	var invalid *util.ValidationError
+	if _, err := svc.Reject(context.Background(), "admin-1", "d-1", " "); !errors.As(err, &invalid) {
+		t.Fatalf("empty note: got %v, want a validation error", err)
+	}
+
+	mock.ExpectBegin()
+	expectOpenDispute(mock, data.TradeDisputeReversed)
+	mock.ExpectRollback()
+
+	var closed *TradeDisputeClosedError
+	if _, err := svc.Reject(context.Background(), "admin-1", "d-1", "price was right"); !errors.As(err, &closed) {
+		t.Fatalf("got %v, want TradeDisputeClosedError", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTradeDispute_FileOthersTrade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	defer db.Close()
+	svc := NewTradeDisputeService(db, data.NewTradeDisputeStore(db), data.NewAuditStore(db), nil)
+
+	mock.ExpectQuery("FROM trades WHERE id = \\$1").
+		WithArgs("t-1").
+		WillReturnRows(sqlmock.NewRows(tradeCols).
+			AddRow("t-1", "user-2", "AAPL", "BUY", 10, "250.00", "2500.00", time.Now(), "COMPLETED", nil, "MARKET", "0"))
+
+	var notFound *TradeNotFoundError
+	if _, err := svc.File(context.Background(), "user-1", "t-1", "wrong price"); !errors.As(err, &notFound) {
+		t.Fatalf("got %v, want TradeNotFoundError", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// pdtWindowBusinessDays is the rolling window the pattern-day-trader rule
+// counts day trades over (FINRA Rule 4210 uses five business days).
+const pdtWindowBusinessDays = 5
+
+// TradeLimitPolicy configures TradeLimitService. Zero values disable the
+// corresponding rule.
+type TradeLimitPolicy struct {
+	MaxTradesPerDay    int
+	PDTEnabled         bool
+	PDTEquityThreshold decimal.Decimal
+	PDTMaxDayTrades    int
+}
+
+// TradeLimits is the per-user snapshot returned by GET /api/account/limits.
+type TradeLimits struct {
+	TradesToday     int        `json:"trades_today"`
+	MaxTradesPerDay int        `json:"max_trades_per_day"` // 0 = unlimited
+	TradesRemaining *int       `json:"trades_remaining"`   // null when unlimited
+	ResetsAt        time.Time  `json:"resets_at"`
+	PDT             *PDTStatus `json:"pdt,omitempty"` // omitted when the PDT rule is disabled
+}
+
+// PDTStatus describes where the user stands against the PDT rule.
+type PDTStatus struct {
+	Applies           bool            `json:"applies"` // equity is under the threshold
+	Equity            decimal.Decimal `json:"equity"`
+	EquityThreshold   decimal.Decimal `json:"equity_threshold"`
+	DayTradesInWindow int             `json:"day_trades_in_window"`
+	MaxDayTrades      int             `json:"max_day_trades"`
+	WindowStart       time.Time       `json:"window_start"`
+}
+
+// TradeLimitService enforces per-user trade-count limits and an educational
+// pattern-day-trader rule. Days are calendar days in the user's time zone.
+type TradeLimitService struct {
+	tradesStore    *data.TradesStore
+	userStore      *data.UserStore
+	portfolioStore *data.PortfolioStore
+	policy         TradeLimitPolicy
+	now            func() time.Time
+}
+
+func NewTradeLimitService(tradesStore *data.TradesStore, userStore *data.UserStore, portfolioStore *data.PortfolioStore, policy TradeLimitPolicy) *TradeLimitService {
+	return &TradeLimitService{
+		tradesStore:    tradesStore,
+		userStore:      userStore,
+		portfolioStore: portfolioStore,
+		policy:         policy,
+		now:            time.Now,
+	}
+}
+
+// CheckTrade implements PreTradeCheck. The daily limit is checked again by
+// CheckTradeTx; the PDT rule is only checked here, so two day trades placed at
+// the same moment can both pass it.
+func (s *TradeLimitService) CheckTrade(ctx context.Context, intent TradeIntent) error {
+	if s.policy.MaxTradesPerDay <= 0 && !s.policy.PDTEnabled {
+		return nil
+	}
+	loc, err := userLocation(ctx, s.userStore, intent.UserID)
+	if err != nil {
+		return err
+	}
+	dayStart := startOfDay(s.now(), loc)
+
+	if s.policy.MaxTradesPerDay > 0 {
+		count, err := s.tradesStore.CountCompletedTradesSince(ctx, intent.UserID, dayStart)
+		if err != nil {
+			return err
+		}
+		if count >= s.policy.MaxTradesPerDay {
+			return &DailyTradeLimitError{Limit: s.policy.MaxTradesPerDay}
+		}
+	}
+
+	if !s.policy.PDTEnabled {
+		return nil
+	}
+
+	// Only an order that closes out (or reverses) a same-day position on the
+	// symbol forms a day trade: a sell after a buy, or a cover after a short.
+	opposite, ok := oppositeAction[intent.Action]
+	if !ok {
+		return nil
+	}
+	formsDayTrade, err := s.tradesStore.HasTradeSince(ctx, intent.UserID, intent.Symbol, opposite, dayStart)
+	if err != nil || !formsDayTrade {
+		return err
+	}
+	// A second round trip in the same symbol on the same day doesn't add a
+	// new (symbol, day) pair, so it can't push the count over the limit.
+	alreadyDayTraded, err := s.tradesStore.HasTradeSince(ctx, intent.UserID, intent.Symbol, intent.Action, dayStart)
+	if err != nil || alreadyDayTraded {
+		return err
+	}
+
+	status, err := s.pdtStatus(ctx, intent.UserID, dayStart)
+	if err != nil {
+		return err
+	}
+	if status.Applies && status.DayTradesInWindow >= s.policy.PDTMaxDayTrades {
+		return &PatternDayTraderError{MaxDayTrades: s.policy.PDTMaxDayTrades}
+	}
+	return nil
+}
+
+// CheckTradeTx implements TxTradeCheck. It counts the day's trades with the
+// user's row locked, so concurrent trades by one user are counted one at a
+// time and can't all slip under the daily limit together.
+func (s *TradeLimitService) CheckTradeTx(ctx context.Context, tx *sql.Tx, intent TradeIntent) error {
+	if s.policy.MaxTradesPerDay <= 0 {
+		return nil
+	}
+	users := data.NewUserStore(tx)
+	if _, err := users.GetBalanceForUpdate(ctx, intent.UserID); err != nil {
+		return err
+	}
+	loc, err := userLocation(ctx, users, intent.UserID)
+	if err != nil {
+		return err
+	}
+	count, err := data.NewTradesStore(tx).CountCompletedTradesSince(ctx, intent.UserID, startOfDay(s.now(), loc))
+	if err != nil {
+		return err
+	}
+	if count >= s.policy.MaxTradesPerDay {
+		return &DailyTradeLimitError{Limit: s.policy.MaxTradesPerDay}
+	}
+	return nil
+}
+
+// GetLimits returns the user's current standing against every enabled rule.
+func (s *TradeLimitService) GetLimits(ctx context.Context, userID string) (*TradeLimits, error) {
+	loc, err := userLocation(ctx, s.userStore, userID)
+	if err != nil {
+		return nil, err
+	}
+	dayStart := startOfDay(s.now(), loc)
+
+	count, err := s.tradesStore.CountCompletedTradesSince(ctx, userID, dayStart)
+	if err != nil {
+		return nil, err
+	}
+	limits := &TradeLimits{
+		TradesToday:     count,
+		MaxTradesPerDay: s.policy.MaxTradesPerDay,
+		ResetsAt:        dayStart.AddDate(0, 0, 1),
+	}
+	if s.policy.MaxTradesPerDay > 0 {
+		remaining := s.policy.MaxTradesPerDay - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		limits.TradesRemaining = &remaining
+	}
+
+	if s.policy.PDTEnabled {
+		status, err := s.pdtStatus(ctx, userID, dayStart)
+		if err != nil {
+			return nil, err
+		}
+		limits.PDT = status
+	}
+	return limits, nil
+}
+
+// oppositeAction pairs each trade action with the one that closes it out.
+var oppositeAction = map[string]string{
+	"BUY":   "SELL",
+	"SELL":  "BUY",
+	"SHORT": "COVER",
+	"COVER": "SHORT",
+}
+
+// pdtStatus computes equity as cash plus holdings at cost basis. Cost basis
+// keeps the check free of market-data calls; it is an approximation of the
+// mark-to-market equity a real broker would use. A short counts as its margin
+// less the cost of buying it back at its sale price (Total is negative).
+// dayStart is the start of the user's current day.
+func (s *TradeLimitService) pdtStatus(ctx context.Context, userID string, dayStart time.Time) (*PDTStatus, error) {
+	balance, err := s.userStore.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.portfolioStore.GetPortfolioByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	equity := balance
+	for _, h := range holdings {
+		equity = equity.Add(h.Total).Add(h.Margin)
+	}
+
+	windowStart := businessDaysBack(dayStart, pdtWindowBusinessDays-1)
+	dayTrades, err := s.tradesStore.CountDayTradesSince(ctx, userID, windowStart, dayStart.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	return &PDTStatus{
+		Applies:           equity.LessThan(s.policy.PDTEquityThreshold),
+		Equity:            equity,
+		EquityThreshold:   s.policy.PDTEquityThreshold,
+		DayTradesInWindow: dayTrades,
+		MaxDayTrades:      s.policy.PDTMaxDayTrades,
+		WindowStart:       windowStart,
+	}, nil
+}
+
+func startOfUTCDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// businessDaysBack steps n weekdays back from day (weekends are skipped;
+// market holidays are not modelled).
+func businessDaysBack(day time.Time, n int) time.Time {
+	for n > 0 {
+		day = day.AddDate(0, 0, -1)
+		if wd := day.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			n--
+		}
+	}
+	return day
+}
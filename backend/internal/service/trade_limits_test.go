@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+)
+
+// wednesday is a fixed clock so the PDT window (5 business days) is stable:
+// Thu 2024-03-07 .. Wed 2024-03-13.
+var wednesday = time.Date(2024, 3, 13, 15, 0, 0, 0, time.UTC)
+
+func newTestLimits(db *sql.DB, policy TradeLimitPolicy) *TradeLimitService {
+	s := NewTradeLimitService(data.NewTradesStore(db), data.NewUserStore(db), data.NewPortfolioStore(db), policy)
+	s.now = func() time.Time { return wednesday }
+	return s
+}
+
+// expectTimezone expects the read of userID's time zone setting.
+func expectTimezone(mock sqlmock.Sqlmock, userID, name string) {
+	mock.ExpectQuery("SELECT COALESCE\\(s.timezone, 'America/New_York'\\) FROM users").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone"}).AddRow(name))
+}
+
+func countRow(n int) *sqlmock.Rows { return sqlmock.NewRows([]string{"count"}).AddRow(n) }
+
+func existsRow(b bool) *sqlmock.Rows { return sqlmock.NewRows([]string{"exists"}).AddRow(b) }
+
+func TestTradeLimits_RejectsOverDailyLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	expectTimezone(mock, "user-1", "UTC")
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades WHERE user_id = \\$1 AND status = 'COMPLETED'").
+		WithArgs("user-1", startOfUTCDay(wednesday)).
+		WillReturnRows(countRow(5))
+
+	err = newTestLimits(db, TradeLimitPolicy{MaxTradesPerDay: 5}).CheckTrade(context.Background(), buyIntent("AAPL", 100))
+	var limitErr *DailyTradeLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected DailyTradeLimitError, got %v", err)
+	}
+}
+
+func TestTradeLimits_CheckTradeTxCountsUnderLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT balance FROM users WHERE id = \\$1 FOR UPDATE").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(decimal.NewFromInt(10000)))
+	expectTimezone(mock, "user-1", "UTC")
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades WHERE user_id = \\$1 AND status = 'COMPLETED'").
+		WithArgs("user-1", startOfUTCDay(wednesday)).
+		WillReturnRows(countRow(5))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	defer tx.Rollback()
+	err = newTestLimits(db, TradeLimitPolicy{MaxTradesPerDay: 5}).CheckTradeTx(context.Background(), tx, buyIntent("AAPL", 100))
+	var limitErr *DailyTradeLimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("expected DailyTradeLimitError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestTradeLimits_PDTBlocksFourthDayTrade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	expectTimezone(mock, "user-1", "UTC")
+	// Bought AAPL today, now selling → forms a new day trade.
+	mock.ExpectQuery("SELECT EXISTS").WithArgs("user-1", "AAPL", "BUY", sqlmock.AnyArg()).WillReturnRows(existsRow(true))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs("user-1", "AAPL", "SELL", sqlmock.AnyArg()).WillReturnRows(existsRow(false))
+	mock.ExpectQuery("SELECT balance FROM users").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(decimal.NewFromInt(8000)))
+	mock.ExpectQuery("SELECT id, user_id, symbol").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows(portfolioCols))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM \\(").
+		WithArgs("user-1", time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), "UTC").
+		WillReturnRows(countRow(3))
+
+	sell := buyIntent("AAPL", 100)
+	sell.Action = "SELL"
+	err = newTestLimits(db, TradeLimitPolicy{PDTEnabled: true, PDTEquityThreshold: decimal.NewFromInt(25000), PDTMaxDayTrades: 3}).
+		CheckTrade(context.Background(), sell)
+	var pdtErr *PatternDayTraderError
+	if !errors.As(err, &pdtErr) {
+		t.Fatalf("expected PatternDayTraderError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestTradeLimits_PDTIgnoresOrdersThatDoNotDayTrade(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	expectTimezone(mock, "user-1", "UTC")
+	// No AAPL sell today → a buy cannot form a day trade; equity never loaded.
+	mock.ExpectQuery("SELECT EXISTS").WithArgs("user-1", "AAPL", "SELL", sqlmock.AnyArg()).WillReturnRows(existsRow(false))
+
+	err = newTestLimits(db, TradeLimitPolicy{PDTEnabled: true, PDTEquityThreshold: decimal.NewFromInt(25000), PDTMaxDayTrades: 3}).
+		CheckTrade(context.Background(), buyIntent("AAPL", 100))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestTradeLimits_GetLimits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	expectTimezone(mock, "user-1", "UTC")
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades WHERE user_id = \\$1 AND status = 'COMPLETED'").
+		WithArgs("user-1", startOfUTCDay(wednesday)).
+		WillReturnRows(countRow(7))
+
+	limits, err := newTestLimits(db, TradeLimitPolicy{MaxTradesPerDay: 10}).GetLimits(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if limits.TradesRemaining == nil || *limits.TradesRemaining != 3 {
+		t.Errorf("trades_remaining: got %v, want 3", limits.TradesRemaining)
+	}
+	if limits.PDT != nil {
+		t.Error("pdt should be omitted when disabled")
+	}
+	if want := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC); !limits.ResetsAt.Equal(want) {
+		t.Errorf("resets_at: got %v, want %v", limits.ResetsAt, want)
+	}
+}
+
+func TestTradeLimits_DayFollowsUserTimezone(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	// 15:00 UTC is 08:00 in Los Angeles, whose day began at 07:00 UTC.
+	expectTimezone(mock, "user-1", "America/Los_Angeles")
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM trades WHERE user_id = \\$1 AND status = 'COMPLETED'").
+		WithArgs("user-1", time.Date(2024, 3, 13, 0, 0, 0, 0, la)).
+		WillReturnRows(countRow(1))
+
+	limits, err := newTestLimits(db, TradeLimitPolicy{MaxTradesPerDay: 10}).GetLimits(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 3, 14, 7, 0, 0, 0, time.UTC); !limits.ResetsAt.Equal(want) {
+		t.Errorf("resets_at: got %v, want %v", limits.ResetsAt, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled sql expectations: %v", err)
+	}
+}
+
+func TestBusinessDaysBack_SkipsWeekends(t *testing.T) {
+	monday := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	if got, want := businessDaysBack(monday, 4), time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+const (
+	maxTradeNoteLength   = 2000 // characters
+	maxTradeTags         = 10
+	maxTradeSearchQuery  = 200
+	defaultTradeSearches = 20
+	maxTradeSearches     = 100
+)
+
+var tradeTagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Highlight markers passed to ts_headline. They are private-use code points,
+// stripped from notes on save, so after HTML-escaping the excerpt they can be
+// swapped for <mark> tags without ever matching user text.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+var highlightMarkup = strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>")
+
+// TradeNotesService lets users annotate their trades with a note and tags and
+// search their history by symbol, tag and note text.
+type TradeNotesService struct {
+	store *data.TradeNotesStore
+}
+
+func NewTradeNotesService(store *data.TradeNotesStore) *TradeNotesService {
+	return &TradeNotesService{store: store}
+}
+
+// SetNote replaces the note and tags on one of the user's trades. Tags are
+// lower-cased and de-duplicated.
+func (s *TradeNotesService) SetNote(ctx context.Context, userID, tradeID, note string, tags []string) (*data.TradeNote, error) {
+	note = strings.NewReplacer(highlightStart, "", highlightStop, "").Replace(util.SanitizeString(note))
+	if utf8.RuneCountInString(note) > maxTradeNoteLength {
+		return nil, &util.ValidationError{Field: "note", Message: fmt.Sprintf("must be at most %d characters", maxTradeNoteLength)}
+	}
+	tags, err := normalizeTradeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := s.store.Upsert(ctx, userID, tradeID, note, tags)
+	if errors.Is(err, data.ErrTradeNotFound) {
+		return nil, &TradeNotFoundError{}
+	}
+	return n, err
+}
+
+// DeleteNote removes the note and tags from one of the user's trades.
+func (s *TradeNotesService) DeleteNote(ctx context.Context, userID, tradeID string) error {
+	err := s.store.Delete(ctx, userID, tradeID)
+	if errors.Is(err, data.ErrTradeNotFound) {
+		return &TradeNotFoundError{}
+	}
+	return err
+}
+
+func normalizeTradeTags(tags []string) ([]string, error) {
+	if len(tags) > maxTradeTags {
+		return nil, &util.ValidationError{Field: "tags", Message: fmt.Sprintf("at most %d tags", maxTradeTags)}
+	}
+	out := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tradeTagRegex.MatchString(tag) {
+			return nil, &util.ValidationError{Field: "tags", Message: "tags must be 1-32 characters of a-z, 0-9, _ or -, starting with a letter or digit"}
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out, nil
+}
+
+// Search returns the user's trades matching query, best match first, and the
+// total number of matches. from/to (either may be zero) bound the execution
+// time, to exclusive. limit is clamped to [1, maxTradeSearches] with zero
+// selecting the default.
+//
+// Highlight in each result is HTML-escaped note text with matches wrapped in
+// <mark> tags, ready to render as HTML.
+func (s *TradeNotesService) Search(ctx context.Context, userID, query string, from, to time.Time, limit, offset int) ([]data.TradeSearchResult, int, error) {
+	query = util.SanitizeString(query)
+	if query == "" {
+		return nil, 0, &util.ValidationError{Field: "q", Message: "is required"}
+	}
+	if utf8.RuneCountInString(query) > maxTradeSearchQuery {
+		return nil, 0, &util.ValidationError{Field: "q", Message: fmt.Sprintf("must be at most %d characters", maxTradeSearchQuery)}
+	}
+	if !from.IsZero() && !to.IsZero() && !to.After(from) {
+		return nil, 0, &util.ValidationError{Field: "to", Message: "must be after from"}
+	}
+	if limit <= 0 {
+		limit = defaultTradeSearches
+	}
+	limit = min(limit, maxTradeSearches)
+	offset = max(offset, 0)
+
+	results, total, err := s.store.SearchTrades(ctx, userID, data.TradeSearchOpts{
+		Query:    query,
+		From:     from,
+		To:       to,
+		StartSel: highlightStart,
+		StopSel:  highlightStop,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range results {
+		results[i].Highlight = highlightMarkup.Replace(html.EscapeString(results[i].Highlight))
+	}
+	return results, total, nil
+}
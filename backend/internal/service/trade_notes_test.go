@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestNormalizeTradeTags(t *testing.T) {
+	got, err := normalizeTradeTags([]string{" Earnings ", "swing", "EARNINGS", "q1-2024"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(got, ",") != "earnings,swing,q1-2024" {
+		t.Errorf("got %v", got)
+	}
+
+	for _, bad := range [][]string{{""}, {"-leading"}, {"has space"}, {strings.Repeat("a", 33)}} {
+		if _, err := normalizeTradeTags(bad); err == nil {
+			t.Errorf("%q: expected a validation error", bad)
+		}
+	}
+	if _, err := normalizeTradeTags(make([]string, maxTradeTags+1)); err == nil {
+		t.Error("expected an error for too many tags")
+	}
+}
+
+func TestSetNote_TradeNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO trade_notes").
+		WithArgs("trade-1", "user-1", "gap up", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"trade_id", "note", "tags", "updated_at"}))
+
+	svc := NewTradeNotesService(data.NewTradeNotesStore(db))
+	// Highlight markers typed into a note are dropped.
+	_, err = svc.SetNote(context.Background(), "user-1", "trade-1", "gap"+highlightStart+" up", nil)
+	var nf *TradeNotFoundError
+	if !errors.As(err, &nf) {
+		t.Errorf("expected TradeNotFoundError, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSearch_Validation(t *testing.T) {
+	svc := NewTradeNotesService(nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	cases := []struct {
+		name     string
+		query    string
+		from, to time.Time
+		field    string
+	}{
+		{"empty query", "  ", time.Time{}, time.Time{}, "q"},
+		{"long query", strings.Repeat("x", maxTradeSearchQuery+1), time.Time{}, time.Time{}, "q"},
+		{"inverted range", "nvda", now, now.Add(-time.Hour), "to"},
+	}
+	for _, tc := range cases {
+		_, _, err := svc.Search(ctx, "user-1", tc.query, tc.from, tc.to, 10, 0)
+		var ve *util.ValidationError
+		if !errors.As(err, &ve) || ve.Field != tc.field {
+			t.Errorf("%s: expected validation error on %q, got %v", tc.name, tc.field, err)
+		}
+	}
+}
+
+func TestSearch_EscapesHighlight(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	cols := []string{
+		"id", "user_id", "symbol", "action", "quantity", "price", "total", "executed_at", "status",
+		"idempotency_key", "order_type", "slippage", "note", "tags", "rank", "highlight", "matches",
+	}
+	mock.ExpectQuery("websearch_to_tsquery").
+		WithArgs("user-1", "nvda", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), defaultTradeSearches, 0).
+		WillReturnRows(sqlmock.NewRows(cols).AddRow(
+			"trade-1", "user-1", "NVDA", "BUY", 1, decimal.NewFromInt(100), decimal.NewFromInt(100), time.Now(), "COMPLETED",
+			nil, "MARKET", "0", "<b>NVDA</b> earnings", "{earnings}", 0.6,
+			"<b>"+highlightStart+"NVDA"+highlightStop+"</b> earnings", 1,
+		))
+
+	svc := NewTradeNotesService(data.NewTradeNotesStore(db))
+	results, total, err := svc.Search(context.Background(), "user-1", "nvda", time.Time{}, time.Time{}, 0, 0)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if total != 1 || len(results) != 1 {
+		t.Fatalf("got %d results (total %d), want 1", len(results), total)
+	}
+	want := "&lt;b&gt;<mark>NVDA</mark>&lt;/b&gt; earnings"
+	if results[0].Highlight != want {
+		t.Errorf("highlight: got %q, want %q", results[0].Highlight, want)
+	}
+	if len(results[0].Tags) != 1 || results[0].Tags[0] != "earnings" {
+		t.Errorf("tags: got %v", results[0].Tags)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Usage metrics counted per user per hour.
+const (
+	UsageRequests   = "requests"    // authenticated API requests
+	UsageThrottled  = "throttled"   // requests rejected with 429
+	UsageMarketData = "market_data" // requests to /api/market
+)
+
+var usageMetrics = []string{UsageRequests, UsageThrottled, UsageMarketData}
+
+// usageHistoryHours is how far back the usage report looks; counters are kept
+// a little longer so the oldest hour is still complete when read.
+const (
+	usageHistoryHours = 24
+	usageRetention    = (usageHistoryHours + 1) * time.Hour
+)
+
+// UsageCounter stores hourly per-user counters. Hours are Unix hours
+// (unix seconds / 3600).
+type UsageCounter interface {
+	Incr(ctx context.Context, metric, userID string, at time.Time) error
+	// Hourly returns the counts for the hours from..to inclusive, oldest
+	// first. Missing hours count as 0.
+	Hourly(ctx context.Context, metric, userID string, from, to int64) ([]int64, error)
+}
+
+func unixHour(t time.Time) int64 { return t.Unix() / 3600 }
+
+// RedisUsageCounter keeps one key per user, metric and hour:
+// usage:<metric>:<userID>:<unix hour>, expiring after usageRetention.
+type RedisUsageCounter struct {
+	client *redis.Client
+}
+
+func NewRedisUsageCounter(client *redis.Client) *RedisUsageCounter {
+	return &RedisUsageCounter{client: client}
+}
+
+func usageKey(metric, userID string, hour int64) string {
+	return fmt.Sprintf("usage:%s:%s:%d", metric, userID, hour)
+}
+
+// Incr implements UsageCounter.
+func (c *RedisUsageCounter) Incr(ctx context.Context, metric, userID string, at time.Time) error {
+	key := usageKey(metric, userID, unixHour(at))
+	pipe := c.client.Pipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, usageRetention)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Hourly implements UsageCounter.
+func (c *RedisUsageCounter) Hourly(ctx context.Context, metric, userID string, from, to int64) ([]int64, error) {
+	if to < from {
+		return nil, nil
+	}
+	keys := make([]string, 0, to-from+1)
+	for h := from; h <= to; h++ {
+		keys = append(keys, usageKey(metric, userID, h))
+	}
+	vals, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]int64, len(vals))
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			out[i], _ = strconv.ParseInt(s, 10, 64)
+		}
+	}
+	return out, nil
+}
+
+// MemoryUsageCounter is the in-process UsageCounter used when Redis is
+// unavailable. Counts are lost on restart.
+type MemoryUsageCounter struct {
+	mu       sync.Mutex
+	counts   map[memoryUsageKey]int64
+	prunedAt int64 // last hour stale entries were dropped
+}
+
+type memoryUsageKey struct {
+	metric, userID string
+	hour           int64
+}
+
+func NewMemoryUsageCounter() *MemoryUsageCounter {
+	return &MemoryUsageCounter{counts: make(map[memoryUsageKey]int64)}
+}
+
+// Incr implements UsageCounter.
+func (c *MemoryUsageCounter) Incr(_ context.Context, metric, userID string, at time.Time) error {
+	hour := unixHour(at)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired hours once per hour rather than on every call.
+	if hour > c.prunedAt {
+		oldest := unixHour(at.Add(-usageRetention))
+		for k := range c.counts {
+			if k.hour < oldest {
+				delete(c.counts, k)
+			}
+		}
+		c.prunedAt = hour
+	}
+	c.counts[memoryUsageKey{metric, userID, hour}]++
+	return nil
+}
+
+// Hourly implements UsageCounter.
+func (c *MemoryUsageCounter) Hourly(_ context.Context, metric, userID string, from, to int64) ([]int64, error) {
+	if to < from {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	out := make([]int64, 0, to-from+1)
+	for h := from; h <= to; h++ {
+		out = append(out, c.counts[memoryUsageKey{metric, userID, h}])
+	}
+	return out, nil
+}
+
+// RateLimitBucket describes one rate-limit bucket for the usage report.
+type RateLimitBucket struct {
+	Name      string // shown to the user, e.g. "global"
+	Bucket    string // key namespace passed to the RateLimiter
+	UserLimit int
+	IPLimit   int
+	Window    time.Duration
+}
+
+// RateLimitConsumption is how much of one bucket's window the caller has used.
+// Scope is "user" or "ip"; both apply, and the first one exhausted wins.
+type RateLimitConsumption struct {
+	Name          string `json:"name"`
+	Scope         string `json:"scope"`
+	Used          int    `json:"used"`
+	Limit         int    `json:"limit"`
+	Remaining     int    `json:"remaining"`
+	WindowSeconds int64  `json:"window_seconds"`
+}
+
+// UsageTotals sums one metric over the report's windows.
+type UsageTotals struct {
+	CurrentHour int64 `json:"current_hour"`
+	Last24Hours int64 `json:"last_24_hours"`
+}
+
+// UsageHour is one hour of the usage history.
+type UsageHour struct {
+	Hour       time.Time `json:"hour"`
+	Requests   int64     `json:"requests"`
+	Throttled  int64     `json:"throttled"`
+	MarketData int64     `json:"market_data"`
+}
+
+// UsageReport is the response body of GET /api/account/usage.
+type UsageReport struct {
+	GeneratedAt time.Time              `json:"generated_at"`
+	RateLimits  []RateLimitConsumption `json:"rate_limits"`
+	Totals      map[string]UsageTotals `json:"totals"`
+	Hourly      []UsageHour            `json:"hourly"`
+}
+
+// UsageService counts each user's API traffic and reports it back to them
+// together with their standing in every rate-limit bucket, so bots can back
+// off before they are throttled.
+type UsageService struct {
+	counter UsageCounter
+	limiter RateLimiter
+	buckets []RateLimitBucket
+	now     func() time.Time
+}
+
+// NewUsageService builds the service. limiter may be nil, in which case the
+// report has no rate-limit section.
+func NewUsageService(counter UsageCounter, limiter RateLimiter, buckets ...RateLimitBucket) *UsageService {
+	return &UsageService{counter: counter, limiter: limiter, buckets: buckets, now: time.Now}
+}
+
+// Record counts one occurrence of metric for userID. Failures are logged, not
+// returned: usage accounting must never fail a request.
+func (s *UsageService) Record(ctx context.Context, metric, userID string) {
+	if err := s.counter.Incr(ctx, metric, userID, s.now()); err != nil {
+		slog.Warn("failed to record usage", "metric", metric, "user_id", userID, "err", err, "component", "usage")
+	}
+}
+
+// Report returns userID's usage. ipAddress is the caller's current IP; the
+// IP-scoped limits are reported for it.
+func (s *UsageService) Report(ctx context.Context, userID, ipAddress string) (*UsageReport, error) {
+	now := s.now().UTC()
+	report := &UsageReport{
+		GeneratedAt: now,
+		RateLimits:  make([]RateLimitConsumption, 0, 2*len(s.buckets)),
+		Totals:      make(map[string]UsageTotals, len(usageMetrics)),
+	}
+
+	if s.limiter != nil {
+		for _, b := range s.buckets {
+			usage, err := s.limiter.Usage(ctx, b.Bucket, userID, ipAddress, b.Window)
+			if err != nil {
+				return nil, err
+			}
+			report.RateLimits = append(report.RateLimits,
+				consumption(b, "user", usage.User, b.UserLimit),
+				consumption(b, "ip", usage.IP, b.IPLimit),
+			)
+		}
+	}
+
+	to := unixHour(now)
+	from := to - usageHistoryHours + 1
+	series := make(map[string][]int64, len(usageMetrics))
+	for _, metric := range usageMetrics {
+		counts, err := s.counter.Hourly(ctx, metric, userID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		var totals UsageTotals
+		for _, n := range counts {
+			totals.Last24Hours += n
+		}
+		if len(counts) > 0 {
+			totals.CurrentHour = counts[len(counts)-1]
+		}
+		report.Totals[metric] = totals
+		series[metric] = counts
+	}
+
+	report.Hourly = make([]UsageHour, 0, usageHistoryHours)
+	for i := 0; i < usageHistoryHours; i++ {
+		report.Hourly = append(report.Hourly, UsageHour{
+			Hour:       time.Unix((from+int64(i))*3600, 0).UTC(),
+			Requests:   countAt(series[UsageRequests], i),
+			Throttled:  countAt(series[UsageThrottled], i),
+			MarketData: countAt(series[UsageMarketData], i),
+		})
+	}
+	return report, nil
+}
+
+func consumption(b RateLimitBucket, scope string, used, limit int) RateLimitConsumption {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return RateLimitConsumption{
+		Name:          b.Name,
+		Scope:         scope,
+		Used:          used,
+		Limit:         limit,
+		Remaining:     remaining,
+		WindowSeconds: int64(b.Window / time.Second),
+	}
+}
+
+func countAt(counts []int64, i int) int64 {
+	if i < len(counts) {
+		return counts[i]
+	}
+	return 0
+}
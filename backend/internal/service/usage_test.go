@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryUsageCounter_HourlyFillsGaps(t *testing.T) {
+	c := NewMemoryUsageCounter()
+	ctx := context.Background()
+	now := time.Unix(1_000*3600+120, 0)
+	c.Incr(ctx, UsageRequests, "u1", now)
+	c.Incr(ctx, UsageRequests, "u1", now)
+	c.Incr(ctx, UsageRequests, "u1", now.Add(-2*time.Hour))
+	c.Incr(ctx, UsageRequests, "u2", now)
+
+	got, err := c.Hourly(ctx, UsageRequests, "u1", 998, 1000)
+	if err != nil {
+		t.Fatalf("Hourly: %v", err)
+	}
+	want := []int64{1, 0, 2}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Hourly = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMemoryUsageCounter_PrunesExpiredHours(t *testing.T) {
+	c := NewMemoryUsageCounter()
+	ctx := context.Background()
+	now := time.Now()
+	c.Incr(ctx, UsageRequests, "u1", now.Add(-48*time.Hour))
+	c.Incr(ctx, UsageRequests, "u1", now)
+
+	if len(c.counts) != 1 {
+		t.Errorf("expected the 48h-old counter to be pruned, have %d entries", len(c.counts))
+	}
+}
+
+func TestUsageService_Report(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewMemoryRateLimiter(RateLimitPolicy{UserLimit: 10, IPLimit: 20, Window: time.Minute})
+	limiter.CheckLimit(ctx, "u1", "10.0.0.1")
+	limiter.CheckLimit(ctx, "u1", "10.0.0.1")
+	limiter.CheckLimit(ctx, "", "10.0.0.1")
+
+	svc := NewUsageService(NewMemoryUsageCounter(), limiter, RateLimitBucket{
+		Name: "global", Bucket: DefaultRateLimitBucket, UserLimit: 10, IPLimit: 20, Window: time.Minute,
+	})
+	now := time.Unix(1_000*3600+1800, 0)
+	svc.now = func() time.Time { return now.Add(-3 * time.Hour) }
+	svc.Record(ctx, UsageRequests, "u1")
+	svc.now = func() time.Time { return now }
+	svc.Record(ctx, UsageRequests, "u1")
+	svc.Record(ctx, UsageRequests, "u1")
+	svc.Record(ctx, UsageMarketData, "u1")
+	svc.Record(ctx, UsageRequests, "u2")
+
+	report, err := svc.Report(ctx, "u1", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+
+	if len(report.RateLimits) != 2 {
+		t.Fatalf("expected user and ip entries, got %+v", report.RateLimits)
+	}
+	user, ip := report.RateLimits[0], report.RateLimits[1]
+	if user.Scope != "user" || user.Used != 2 || user.Remaining != 8 || user.WindowSeconds != 60 {
+		t.Errorf("user consumption = %+v", user)
+	}
+	if ip.Scope != "ip" || ip.Used != 3 || ip.Remaining != 17 {
+		t.Errorf("ip consumption = %+v", ip)
+	}
+
+	if got := report.Totals[UsageRequests]; got.CurrentHour != 2 || got.Last24Hours != 3 {
+		t.Errorf("requests totals = %+v, want current 2, 24h 3", got)
+	}
+	if got := report.Totals[UsageMarketData]; got.CurrentHour != 1 {
+		t.Errorf("market_data totals = %+v", got)
+	}
+	if got := report.Totals[UsageThrottled]; got.Last24Hours != 0 {
+		t.Errorf("throttled totals = %+v", got)
+	}
+
+	if len(report.Hourly) != usageHistoryHours {
+		t.Fatalf("expected %d hours, got %d", usageHistoryHours, len(report.Hourly))
+	}
+	last := report.Hourly[len(report.Hourly)-1]
+	if !last.Hour.Equal(time.Unix(1_000*3600, 0)) || last.Requests != 2 || last.MarketData != 1 {
+		t.Errorf("current hour = %+v", last)
+	}
+	if report.Hourly[len(report.Hourly)-4].Requests != 1 {
+		t.Errorf("3h-ago bucket = %+v", report.Hourly[len(report.Hourly)-4])
+	}
+}
+
+func TestUsageService_ReportWithoutLimiter(t *testing.T) {
+	svc := NewUsageService(NewMemoryUsageCounter(), nil)
+	report, err := svc.Report(context.Background(), "u1", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if report.RateLimits == nil || len(report.RateLimits) != 0 {
+		t.Errorf("expected empty rate_limits, got %+v", report.RateLimits)
+	}
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// Audit event kinds recorded for admin user management.
+const (
+	AuditUserBalanceAdjusted    = "admin.user_balance_adjusted"
+	AuditUserLocked             = "admin.user_locked"
+	AuditUserUnlocked           = "admin.user_unlocked"
+	AuditUserVerificationResent = "admin.user_verification_resent"
+	AuditUserDeleted            = "admin.user_deleted"
+)
+
+// User search limits. The query is matched against emails and usernames,
+// so it never needs to be longer than an email address.
+const (
+	defaultUserSearchLimit = 25
+	maxUserSearchLimit     = 100
+	maxUserSearchQuery     = 254
+	maxUserAdminReason     = 500
+	maxUserLockDuration    = 365 * 24 * time.Hour
+)
+
+// BalanceAdjustment is the outcome of AdjustBalance.
+type BalanceAdjustment struct {
+	UserID  string          `json:"user_id"`
+	Amount  decimal.Decimal `json:"amount"`
+	Balance decimal.Decimal `json:"balance"`
+	Reason  string          `json:"reason"`
+}
+
+// SetManagement enables the per-account actions (AdjustBalance, Lock,
+// Unlock, ResendVerification and Delete) and records each of them in
+// audit, with the admin as the event's user. Call during wiring.
+func (s *UserAdminService) SetManagement(audit *data.AuditStore, lockout *LockoutService, deletion *AccountDeletionService) {
+	s.audit = audit
+	s.lockout = lockout
+	s.deletion = deletion
+}
+
+// Search returns up to limit non-guest users whose ID is query or whose
+// email or username contains it, newest first. An empty query lists the
+// newest accounts.
+func (s *UserAdminService) Search(ctx context.Context, query string, limit int) ([]data.AdminUser, error) {
+	query = strings.TrimSpace(query)
+	if len(query) > maxUserSearchQuery {
+		return nil, &util.ValidationError{Field: "q", Message: fmt.Sprintf("must be at most %d characters", maxUserSearchQuery)}
+	}
+	if limit <= 0 {
+		limit = defaultUserSearchLimit
+	}
+	return s.users.SearchUsers(ctx, query, min(limit, maxUserSearchLimit))
+}
+
+// Get returns userID with its balance, holdings and trade aggregates.
+func (s *UserAdminService) Get(ctx context.Context, userID string) (*data.AdminUser, error) {
+	u, err := s.users.GetAdminUser(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &UserNotFoundError{}
+	}
+	return u, err
+}
+
+// AdjustBalance credits amount to userID's cash, or debits it when amount is
+// negative; reason is required and goes in the audit log. The balance is
+// never taken below zero. An adjustment is not a trade, so it shows in the
+// user's returns like a gain or loss would.
+func (s *UserAdminService) AdjustBalance(ctx context.Context, adminID, userID string, amount decimal.Decimal, reason string) (*BalanceAdjustment, error) {
+	if amount.IsZero() {
+		return nil, &util.ValidationError{Field: "amount", Message: "must not be zero"}
+	}
+	if !amount.Equal(amount.Round(2)) {
+		return nil, &util.ValidationError{Field: "amount", Message: "must have at most 2 decimal places"}
+	}
+	if amount.Abs().GreaterThan(maxStartingBalance) {
+		return nil, &util.ValidationError{Field: "amount", Message: "must be at most " + maxStartingBalance.String() + " either way"}
+	}
+	reason, err := userAdminReason(reason, true)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.target(ctx, adminID, userID); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.users.AdjustBalance(ctx, userID, amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, &UserNotFoundError{}
+		case errors.Is(err, data.ErrNegativeBalance):
+			return nil, &util.ValidationError{Field: "amount", Message: "would leave a negative balance"}
+		}
+		return nil, err
+	}
+	adj := &BalanceAdjustment{UserID: userID, Amount: amount, Balance: balance, Reason: reason}
+	s.record(ctx, adminID, AuditUserBalanceAdjusted, map[string]any{
+		"user_id": userID, "amount": amount, "balance": balance, "reason": reason,
+	})
+	return adj, nil
+}
+
+// Lock refuses every login and session of userID until until, and signs
+// out every session it has. Signing in another way doesn't lift it; only
+// Unlock does.
+func (s *UserAdminService) Lock(ctx context.Context, adminID, userID string, until time.Time, reason string) error {
+	now := time.Now()
+	if !until.After(now) {
+		return &util.ValidationError{Field: "until", Message: "must be in the future"}
+	}
+	if until.Sub(now) > maxUserLockDuration {
+		return &util.ValidationError{Field: "until", Message: "must be within a year"}
+	}
+	reason, err := userAdminReason(reason, false)
+	if err != nil {
+		return err
+	}
+	u, err := s.target(ctx, adminID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.lockout.Lock(ctx, u.ID, until); err != nil {
+		return err
+	}
+	s.revokeSessions(ctx, userID)
+	s.record(ctx, adminID, AuditUserLocked, map[string]any{
+		"user_id": userID, "until": until.UTC(), "reason": reason,
+	})
+	return nil
+}
+
+// Unlock lifts any lock on userID, automatic or not, and starts its failed
+// login count over.
+func (s *UserAdminService) Unlock(ctx context.Context, adminID, userID string) error {
+	if _, err := s.target(ctx, adminID, userID); err != nil {
+		return err
+	}
+	if err := s.lockout.Unlock(ctx, userID); err != nil {
+		return err
+	}
+	s.record(ctx, adminID, AuditUserUnlocked, map[string]any{"user_id": userID})
+	return nil
+}
+
+// ResendVerification emails userID a new verification link, replacing any
+// sent before.
+func (s *UserAdminService) ResendVerification(ctx context.Context, adminID, userID string) error {
+	u, err := s.target(ctx, adminID, userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerified {
+		return &EmailAlreadyVerifiedError{}
+	}
+	if s.emailService == nil {
+		return &EmailDisabledError{}
+	}
+
+	token := uuid.New().String()
+	if err := s.users.UpdateVerificationToken(ctx, userID, token, time.Now().Add(24*time.Hour)); err != nil {
+		return err
+	}
+	if err := s.emailService.SendVerificationEmail(u.Email, token); err != nil {
+		return err
+	}
+	s.record(ctx, adminID, AuditUserVerificationResent, map[string]any{"user_id": userID, "email": u.Email})
+	return nil
+}
+
+// Delete erases userID as if its owner had (see AccountDeletionService),
+// for requests that reach support instead, and signs out its sessions. The
+// audit event keeps the email address the account had.
+func (s *UserAdminService) Delete(ctx context.Context, adminID, userID string) error {
+	u, err := s.target(ctx, adminID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.deletion.Delete(ctx, userID); err != nil {
+		return err
+	}
+	s.revokeSessions(ctx, userID)
+	s.record(ctx, adminID, AuditUserDeleted, map[string]any{"user_id": userID, "email": u.Email})
+	return nil
+}
+
+// target returns the user an admin action applies to. Admins manage their
+// own account under /api/account, not here.
+func (s *UserAdminService) target(ctx context.Context, adminID, userID string) (*data.AdminUser, error) {
+	if userID == adminID {
+		return nil, &util.ValidationError{Field: "id", Message: "is your own account; use /api/account instead"}
+	}
+	return s.Get(ctx, userID)
+}
+
+// revokeSessions signs userID out everywhere. A failure is logged: the
+// action itself has already been applied.
+func (s *UserAdminService) revokeSessions(ctx context.Context, userID string) {
+	if err := s.jwtService.RevokeAllSessions(ctx, userID); err != nil {
+		slog.Warn("failed to revoke sessions", "user_id", userID, "err", err, "component", "user_admin")
+	}
+}
+
+func (s *UserAdminService) record(ctx context.Context, adminID, kind string, fields map[string]any) {
+	slog.Info("admin user action", "admin_id", adminID, "kind", kind, "user_id", fields["user_id"], "component", "user_admin")
+	details, _ := json.Marshal(fields)
+	if err := s.audit.Record(ctx, &data.AuditEvent{UserID: adminID, Kind: kind, Details: details}); err != nil {
+		slog.Warn("audit event not recorded", "user_id", adminID, "kind", kind, "err", err, "component", "user_admin")
+	}
+}
+
+// userAdminReason sanitizes the reason given for an admin action and checks
+// its length.
+func userAdminReason(reason string, required bool) (string, error) {
+	reason = util.SanitizeString(reason)
+	if required && reason == "" {
+		return "", &util.ValidationError{Field: "reason", Message: "is required"}
+	}
+	if utf8.RuneCountInString(reason) > maxUserAdminReason {
+		return "", &util.ValidationError{Field: "reason", Message: fmt.Sprintf("must be at most %d characters", maxUserAdminReason)}
+	}
+	return reason, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+var adminUserCols = []string{"id", "email", "username", "league", "created_via", "created_at", "balance",
+	"positions", "holdings_cost", "trade_count", "last_trade_at", "role", "email_verified", "locked_until", "admin_locked_until"}
+
+func adminUserRow(id string) *sqlmock.Rows {
+	return sqlmock.NewRows(adminUserCols).AddRow(id, "ada@example.com", "", "", "email", time.Now(), "1000.00",
+		0, "0", 0, nil, data.RoleUser, true, nil, nil)
+}
+
+func TestUserAdmin_AdjustBalance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewUserAdminService(data.NewUserStore(db), nil, nil, time.Hour)
+	svc.SetManagement(data.NewAuditStore(db), nil, nil)
+	ctx := context.Background()
+
+	var ve *util.ValidationError
+	for name, amount := range map[string]string{"zero": "0", "fractional cent": "1.005", "too large": "-10000001"} {
+		if _, err := svc.AdjustBalance(ctx, "admin-1", "user-1", decimal.RequireFromString(amount), "why"); !errors.As(err, &ve) || ve.Field != "amount" {
+			t.Errorf("%s: expected validation error on amount, got %v", name, err)
+		}
+	}
+	if _, err := svc.AdjustBalance(ctx, "admin-1", "user-1", decimal.NewFromInt(10), " "); !errors.As(err, &ve) || ve.Field != "reason" {
+		t.Errorf("blank reason: expected validation error on reason, got %v", err)
+	}
+	if _, err := svc.AdjustBalance(ctx, "admin-1", "admin-1", decimal.NewFromInt(10), "why"); !errors.As(err, &ve) || ve.Field != "id" {
+		t.Errorf("own account: expected validation error on id, got %v", err)
+	}
+
+	// A debit past zero is refused and nothing is recorded.
+	mock.ExpectQuery("SELECT u.id").WithArgs("user-1").WillReturnRows(adminUserRow("user-1"))
+	mock.ExpectQuery("UPDATE users SET balance = balance \\+ \\$2").WithArgs("user-1", "-2000").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}))
+	mock.ExpectQuery("SELECT EXISTS").WithArgs("user-1").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	if _, err := svc.AdjustBalance(ctx, "admin-1", "user-1", decimal.NewFromInt(-2000), "clawback"); !errors.As(err, &ve) || ve.Field != "amount" {
+		t.Errorf("overdraft: expected validation error on amount, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT u.id").WithArgs("user-1").WillReturnRows(adminUserRow("user-1"))
+	mock.ExpectQuery("UPDATE users SET balance = balance \\+ \\$2").WithArgs("user-1", "250.5").
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("1250.50"))
+	mock.ExpectExec("INSERT INTO audit_events").
+		WithArgs(sqlmock.AnyArg(), "admin-1", AuditUserBalanceAdjusted, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	adj, err := svc.AdjustBalance(ctx, "admin-1", "user-1", decimal.RequireFromString("250.50"), "missed payout")
+	if err != nil {
+		t.Fatalf("AdjustBalance: %v", err)
+	}
+	if !adj.Balance.Equal(decimal.RequireFromString("1250.50")) || adj.Reason != "missed payout" {
+		t.Errorf("adjustment: got %+v", adj)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUserAdmin_ActionsOnUnknownUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewUserAdminService(data.NewUserStore(db), nil, nil, time.Hour)
+	svc.SetManagement(data.NewAuditStore(db), nil, nil)
+	ctx := context.Background()
+
+	// Nothing is changed or recorded for a user that doesn't exist.
+	var notFound *UserNotFoundError
+	mock.ExpectQuery("SELECT u.id").WithArgs("nobody").WillReturnRows(sqlmock.NewRows(adminUserCols))
+	if err := svc.Unlock(ctx, "admin-1", "nobody"); !errors.As(err, &notFound) {
+		t.Errorf("Unlock: got %v, want UserNotFoundError", err)
+	}
+	mock.ExpectQuery("SELECT u.id").WithArgs("nobody").WillReturnRows(sqlmock.NewRows(adminUserCols))
+	if err := svc.Delete(ctx, "admin-1", "nobody"); !errors.As(err, &notFound) {
+		t.Errorf("Delete: got %v, want UserNotFoundError", err)
+	}
+
+	// A verified address gets no new link.
+	var verified *EmailAlreadyVerifiedError
+	mock.ExpectQuery("SELECT u.id").WithArgs("user-1").WillReturnRows(adminUserRow("user-1"))
+	if err := svc.ResendVerification(ctx, "admin-1", "user-1"); !errors.As(err, &verified) {
+		t.Errorf("ResendVerification: got %v, want EmailAlreadyVerifiedError", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
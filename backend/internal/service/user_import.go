@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+// Bulk import limits. A class or cohort is tens to hundreds of students;
+// the row cap keeps one request (and its invite emails) bounded.
+const (
+	maxUserImportRows = 1000
+	maxLeagueName     = 64
+)
+
+// maxStartingBalance caps a cohort's starting balance.
+var maxStartingBalance = decimal.NewFromInt(10000000)
+
+// Outcomes of one import row.
+const (
+	UserImportCreated = "created"
+	UserImportExists  = "exists"
+	UserImportInvalid = "invalid"
+)
+
+// UserImportRow is one data row of an import CSV. StartingBalance is the raw
+// cell; blank means the default balance.
+type UserImportRow struct {
+	Line            int
+	Email           string
+	StartingBalance string
+	League          string
+}
+
+// UserImportResult is the outcome of one row. Invited is set when the
+// invite email was sent.
+type UserImportResult struct {
+	Line    int    `json:"line"`
+	Email   string `json:"email"`
+	Status  string `json:"status"` // UserImportCreated, UserImportExists or UserImportInvalid
+	UserID  string `json:"user_id,omitempty"`
+	Invited bool   `json:"invited"`
+	Error   string `json:"error,omitempty"`
+}
+
+// UserImportReport summarises an import. Existing accounts are left
+// untouched, so re-running a file after a partial failure is safe.
+type UserImportReport struct {
+	Created  int                `json:"created"`
+	Existing int                `json:"existing"`
+	Invalid  int                `json:"invalid"`
+	Invited  int                `json:"invited"`
+	Results  []UserImportResult `json:"results"`
+}
+
+// UserAdminService bulk-creates accounts for classroom onboarding, exports
+// user lists with aggregate stats, and backs the per-account admin actions
+// in user_admin.go.
+type UserAdminService struct {
+	users        *data.UserStore
+	jwtService   *JWTService
+	emailService *EmailService // nil when email is not configured; invites are skipped
+	inviteTTL    time.Duration
+	audit        *data.AuditStore // see SetManagement
+	lockout      *LockoutService
+	deletion     *AccountDeletionService
+}
+
+func NewUserAdminService(users *data.UserStore, jwtService *JWTService, emailService *EmailService, inviteTTL time.Duration) *UserAdminService {
+	return &UserAdminService{users: users, jwtService: jwtService, emailService: emailService, inviteTTL: inviteTTL}
+}
+
+// ImportCSV parses an import file (see ParseUserImportCSV) and imports it.
+func (s *UserAdminService) ImportCSV(ctx context.Context, r io.Reader, invite bool) (*UserImportReport, error) {
+	rows, err := ParseUserImportCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	return s.Import(ctx, rows, invite)
+}
+
+// Import creates an account for each row with the row's starting balance
+// and league. Rows that fail validation or repeat an earlier email are
+// reported as invalid and rows whose email already has an account as
+// existing; neither stops the import. When invite is set, each new user is
+// emailed a login link valid for the invite TTL. A database error aborts the
+// import; rows before it have been created.
+func (s *UserAdminService) Import(ctx context.Context, rows []UserImportRow, invite bool) (*UserImportReport, error) {
+	if invite && s.emailService == nil {
+		slog.Warn("user import: email service not configured; invites will not be sent", "component", "user_import")
+	}
+
+	report := &UserImportReport{Results: make([]UserImportResult, 0, len(rows))}
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		res := UserImportResult{Line: row.Line, Email: strings.ToLower(strings.TrimSpace(row.Email))}
+		balance, league, err := validateImportRow(&row)
+		if err == nil && seen[res.Email] {
+			err = errors.New("email repeats an earlier row")
+		}
+		if err != nil {
+			res.Status, res.Error = UserImportInvalid, err.Error()
+			report.Invalid++
+			report.Results = append(report.Results, res)
+			continue
+		}
+		seen[res.Email] = true
+
+		user, err := s.users.CreateImportedUser(ctx, res.Email, &data.Cohort{StartingBalance: balance, League: league})
+		switch {
+		case errors.Is(err, data.ErrEmailTaken):
+			res.Status = UserImportExists
+			report.Existing++
+		case err != nil:
+			return nil, fmt.Errorf("line %d: %w", row.Line, err)
+		default:
+			res.Status, res.UserID = UserImportCreated, user.ID
+			report.Created++
+			if invite && s.emailService != nil {
+				res.Invited = s.sendInvite(ctx, user)
+				if res.Invited {
+					report.Invited++
+				}
+			}
+		}
+		report.Results = append(report.Results, res)
+	}
+	slog.Info("user import finished", "created", report.Created, "existing", report.Existing,
+		"invalid", report.Invalid, "invited", report.Invited, "component", "user_import")
+	return report, nil
+}
+
+// sendInvite emails user a magic login link. Failures are logged rather
+// than returned: the account exists, and the user can still request a
+// login link from the sign-in page.
+func (s *UserAdminService) sendInvite(ctx context.Context, user *data.User) bool {
+	token, jti, expiresAt, err := s.jwtService.GenerateMagicLinkToken(user.ID, s.inviteTTL)
+	if err != nil {
+		slog.Error("user import: failed to sign invite token", "user_id", user.ID, "err", err, "component", "user_import")
+		return false
+	}
+	if err := s.users.SetMagicLinkToken(ctx, user.ID, jti, expiresAt); err != nil {
+		slog.Error("user import: failed to store invite token", "user_id", user.ID, "err", err, "component", "user_import")
+		return false
+	}
+	if err := s.emailService.SendInviteEmail(user.Email, token, s.inviteTTL, user.League); err != nil {
+		slog.Error("user import: invite send failed", "user_id", user.ID, "err", err, "component", "user_import")
+		return false
+	}
+	return true
+}
+
+// validateImportRow checks row's email, balance (blank for the default; see
+// validateStartingBalance) and league, returning the parsed balance (nil
+// when blank) and trimmed league.
+func validateImportRow(row *UserImportRow) (*decimal.Decimal, string, error) {
+	email := strings.TrimSpace(row.Email)
+	if email == "" {
+		return nil, "", errors.New("email is required")
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return nil, "", errors.New("email is not a valid address")
+	}
+
+	var balance *decimal.Decimal
+	if raw := strings.TrimSpace(row.StartingBalance); raw != "" {
+		b, err := decimal.NewFromString(raw)
+		if err != nil {
+			return nil, "", errors.New("starting_balance is not a number")
+		}
+		if err := validateStartingBalance(b); err != nil {
+			return nil, "", fmt.Errorf("starting_balance %w", err)
+		}
+		balance = &b
+	}
+
+	league := strings.TrimSpace(row.League)
+	if len(league) > maxLeagueName {
+		return nil, "", fmt.Errorf("league must be at most %d characters", maxLeagueName)
+	}
+	return balance, league, nil
+}
+
+// validateStartingBalance checks a cohort's starting balance: between 0 and
+// maxStartingBalance with at most 2 decimal places.
+func validateStartingBalance(b decimal.Decimal) error {
+	if b.IsNegative() || b.GreaterThan(maxStartingBalance) {
+		return fmt.Errorf("must be between 0 and %s", maxStartingBalance)
+	}
+	if !b.Equal(b.Round(2)) {
+		return errors.New("must have at most 2 decimal places")
+	}
+	return nil
+}
+
+// ParseUserImportCSV reads an import file. The first row is a header naming
+// the columns, in any order: email (required), starting_balance and league.
+// Blank lines are skipped. Row-level problems are left to Import so one bad
+// row does not reject the file; a malformed file is a validation error.
+func ParseUserImportCSV(r io.Reader) ([]UserImportRow, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &util.ValidationError{Field: "file", Message: "is empty"}
+	}
+	if err != nil {
+		return nil, importReadError(err)
+	}
+
+	cols := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "email", "starting_balance", "league":
+		default:
+			return nil, &util.ValidationError{Field: "file", Message: "unknown column " + strconv.Quote(name)}
+		}
+		if _, dup := cols[name]; dup {
+			return nil, &util.ValidationError{Field: "file", Message: "column " + strconv.Quote(name) + " repeated"}
+		}
+		cols[name] = i
+	}
+	if _, ok := cols["email"]; !ok {
+		return nil, &util.ValidationError{Field: "file", Message: "missing email column"}
+	}
+	cell := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok {
+			return rec[i]
+		}
+		return ""
+	}
+
+	rows := make([]UserImportRow, 0)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, importReadError(err)
+		}
+		if len(rows) == maxUserImportRows {
+			return nil, &util.ValidationError{Field: "file", Message: fmt.Sprintf("at most %d rows per import", maxUserImportRows)}
+		}
+		line, _ := cr.FieldPos(0)
+		rows = append(rows, UserImportRow{
+			Line:            line,
+			Email:           cell(rec, "email"),
+			StartingBalance: cell(rec, "starting_balance"),
+			League:          cell(rec, "league"),
+		})
+	}
+	return rows, nil
+}
+
+// importReadError makes a CSV syntax error a validation error. Errors from
+// the underlying reader (such as a body size limit) are returned as is.
+func importReadError(err error) error {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return &util.ValidationError{Field: "file", Message: pe.Error()}
+	}
+	return err
+}
+
+// Export returns every non-guest user with aggregate stats, or only those
+// in league when it is non-empty.
+func (s *UserAdminService) Export(ctx context.Context, league string) ([]data.UserStats, error) {
+	league = strings.TrimSpace(league)
+	if len(league) > maxLeagueName {
+		return nil, &util.ValidationError{Field: "league", Message: fmt.Sprintf("must be at most %d characters", maxLeagueName)}
+	}
+	return s.users.ListUserStats(ctx, league)
+}
+
+// WriteUserStatsCSV writes stats as CSV with a header row, in the column
+// order of data.UserStats.
+func WriteUserStatsCSV(w io.Writer, stats []data.UserStats) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "email", "username", "league", "created_via", "created_at",
+		"balance", "positions", "holdings_cost", "trade_count", "last_trade_at"})
+	for _, st := range stats {
+		lastTrade := ""
+		if st.LastTradeAt != nil {
+			lastTrade = st.LastTradeAt.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			st.ID, st.Email, st.Username, st.League, st.CreatedVia, st.CreatedAt.UTC().Format(time.RFC3339),
+			st.Balance.StringFixed(2), strconv.Itoa(st.Positions), st.HoldingsCost.StringFixed(2),
+			strconv.Itoa(st.TradeCount), lastTrade,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
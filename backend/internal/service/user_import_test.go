@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestParseUserImportCSV(t *testing.T) {
+	raw := "League, Email\nfall-2026,ada@example.com\n\nspring, grace@example.com\n"
+	rows, err := ParseUserImportCSV(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseUserImportCSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows: got %d, want 2", len(rows))
+	}
+	if rows[1].Line != 4 || rows[1].Email != "grace@example.com" || rows[1].League != "spring" || rows[1].StartingBalance != "" {
+		t.Errorf("second row: got %+v", rows[1])
+	}
+
+	bad := map[string]string{
+		"empty":          "",
+		"no email":       "league\nfall\n",
+		"unknown column": "email,balance\na@example.com,100\n",
+		"ragged row":     "email,league\na@example.com\n",
+	}
+	for name, raw := range bad {
+		var ve *util.ValidationError
+		if _, err := ParseUserImportCSV(strings.NewReader(raw)); !errors.As(err, &ve) || ve.Field != "file" {
+			t.Errorf("%s: expected validation error on file, got %v", name, err)
+		}
+	}
+}
+
+func TestUserImport_RowOutcomes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	svc := NewUserAdminService(data.NewUserStore(db), nil, nil, time.Hour)
+
+	rows := []UserImportRow{
+		{Line: 2, Email: "Ada@Example.com", StartingBalance: "2500.50", League: "fall"},
+		{Line: 3, Email: "taken@example.com"},
+		{Line: 4, Email: "not-an-email"},
+		{Line: 5, Email: "b@example.com", StartingBalance: "1.005"},
+		{Line: 6, Email: "b@example.com", StartingBalance: "-1"},
+		{Line: 7, Email: "ada@example.com"},
+	}
+
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(sqlmock.AnyArg(), "ada@example.com", decimal.RequireFromString("2500.50"), "fall").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id, COALESCE\\(email, ''\\), password").
+		WillReturnRows(userRow(data.User{ID: "user-ada", Email: "ada@example.com", Balance: decimal.NewFromFloat(2500.50), CreatedVia: "import", League: "fall"}))
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(sqlmock.AnyArg(), "taken@example.com", decimal.NewFromInt(10000), "").
+		WillReturnError(&pq.Error{Code: "23505", Constraint: "users_email_key"})
+
+	// invite is set but no email service is configured, so nobody is invited.
+	report, err := svc.Import(context.Background(), rows, true)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Created != 1 || report.Existing != 1 || report.Invalid != 4 || report.Invited != 0 {
+		t.Errorf("counts: got %+v", report)
+	}
+	want := []string{UserImportCreated, UserImportExists, UserImportInvalid, UserImportInvalid, UserImportInvalid, UserImportInvalid}
+	for i, res := range report.Results {
+		if res.Status != want[i] {
+			t.Errorf("line %d: got %s (%s), want %s", res.Line, res.Status, res.Error, want[i])
+		}
+	}
+	if report.Results[0].UserID != "user-ada" {
+		t.Errorf("created user id: got %q", report.Results[0].UserID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestWriteUserStatsCSV(t *testing.T) {
+	last := time.Date(2026, time.March, 2, 15, 4, 5, 0, time.UTC)
+	var b strings.Builder
+	err := WriteUserStatsCSV(&b, []data.UserStats{{
+		ID: "u1", Email: "ada@example.com", League: "fall", CreatedVia: "import",
+		CreatedAt: time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), Balance: decimal.NewFromInt(9000),
+		Positions: 2, HoldingsCost: decimal.RequireFromString("1000.5"), TradeCount: 3, LastTradeAt: &last,
+	}})
+	if err != nil {
+		t.Fatalf("WriteUserStatsCSV: %v", err)
+	}
+	want := "id,email,username,league,created_via,created_at,balance,positions,holdings_cost,trade_count,last_trade_at\n" +
+		"u1,ada@example.com,,fall,import,2026-03-01T00:00:00Z,9000.00,2,1000.50,3,2026-03-02T15:04:05Z\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
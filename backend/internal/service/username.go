@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"papertrader/internal/data"
+)
+
+// usernamePattern allows 3–20 ASCII letters, digits and underscores, starting
+// with a letter so names never look like IDs or numbers.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{2,19}$`)
+
+// reservedUsernames could be mistaken for staff or system accounts. Matched
+// against the normalized form, so "Admin_1" is as reserved as "admin".
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true,
+	"support": true, "help": true, "staff": true, "moderator": true, "mod": true,
+	"papertrader": true, "official": true, "security": true, "billing": true,
+	"api": true, "null": true, "undefined": true, "anonymous": true,
+	"deleted": true, "everyone": true, "me": true,
+}
+
+// blockedUsernameTerms are rejected anywhere in the normalized name. The list
+// is deliberately short and avoids terms that commonly occur inside innocent
+// words; operators extend it with USERNAME_BLOCKED_TERMS.
+var blockedUsernameTerms = []string{
+	"fuck", "shit", "cunt", "bitch", "whore", "slut", "nazi", "hitler",
+}
+
+// leetReplacer undoes common digit-for-letter substitutions and drops
+// underscores before matching.
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b", "_", "",
+)
+
+// normalizeUsername reduces name to the form reserved words and blocked terms
+// are matched against, so "Adm1n_2" and "admin" compare equal. Trailing
+// digits are treated as a suffix rather than substituted letters.
+func normalizeUsername(name string) string {
+	n := strings.TrimRight(strings.ToLower(name), "0123456789")
+	return leetReplacer.Replace(n)
+}
+
+// UsernameService assigns public display names. Emails are never shown to
+// other users; a username is what identifies a user anywhere public.
+type UsernameService struct {
+	users        *data.UserStore
+	cooldown     time.Duration
+	blockedTerms []string
+	now          func() time.Time
+}
+
+// NewUsernameService builds the service. cooldown is the minimum time between
+// changes; extraBlocked adds to the built-in profanity list.
+func NewUsernameService(users *data.UserStore, cooldown time.Duration, extraBlocked []string) *UsernameService {
+	blocked := append([]string(nil), blockedUsernameTerms...)
+	for _, t := range extraBlocked {
+		if t = normalizeUsername(t); t != "" {
+			blocked = append(blocked, t)
+		}
+	}
+	return &UsernameService{users: users, cooldown: cooldown, blockedTerms: blocked, now: time.Now}
+}
+
+// Validate checks format, reserved words and blocked terms. It does not check
+// availability.
+func (s *UsernameService) Validate(name string) error {
+	return validateUsername(name, s.blockedTerms)
+}
+
+// Available reports whether name is valid and not held by anyone.
+func (s *UsernameService) Available(ctx context.Context, name string) (bool, error) {
+	if err := s.Validate(name); err != nil {
+		return false, err
+	}
+	exists, err := s.users.UsernameExists(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return !exists, nil
+}
+
+// Set changes the user's username, enforcing the change cooldown.
+func (s *UsernameService) Set(ctx context.Context, userID, name string) error {
+	if err := s.Validate(name); err != nil {
+		return err
+	}
+	now := s.now()
+	changedAt, err := s.users.SetUsername(ctx, userID, name, now.Add(-s.cooldown), now)
+	switch {
+	case errors.Is(err, data.ErrUsernameTaken):
+		return &UsernameTakenError{}
+	case errors.Is(err, data.ErrUsernameCooldown):
+		return &UsernameCooldownError{RetryAt: changedAt.Add(s.cooldown)}
+	}
+	return err
+}
+
+func validateUsername(name string, blocked []string) error {
+	if !usernamePattern.MatchString(name) {
+		return &InvalidUsernameError{Reason: "Username must be 3-20 letters, digits or underscores and start with a letter"}
+	}
+	normalized := normalizeUsername(name)
+	if reservedUsernames[normalized] {
+		return &InvalidUsernameError{Reason: "That username is reserved"}
+	}
+	for _, term := range blocked {
+		if strings.Contains(normalized, term) {
+			return &InvalidUsernameError{Reason: "That username is not allowed"}
+		}
+	}
+	return nil
+}
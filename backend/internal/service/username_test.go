@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+
+	"papertrader/internal/data"
+)
+
+func TestUsernameValidate(t *testing.T) {
+	svc := NewUsernameService(nil, time.Hour, []string{"Scam"})
+
+	valid := []string{"trader_joe", "Bull2026", "abc"}
+	for _, name := range valid {
+		if err := svc.Validate(name); err != nil {
+			t.Errorf("%q: unexpected error %v", name, err)
+		}
+	}
+
+	invalid := []string{
+		"ab", "1trader", "has space", "way_too_long_username_x", "émile", // format
+		"admin", "Adm1n", "support_2", "paper_trader", // reserved
+		"sh1tposter", "big_Fuck", "scammer", // blocked, incl. configured term
+	}
+	for _, name := range invalid {
+		var invalidErr *InvalidUsernameError
+		if err := svc.Validate(name); !errors.As(err, &invalidErr) {
+			t.Errorf("%q: expected InvalidUsernameError, got %v", name, err)
+		}
+	}
+}
+
+func TestUsernameSet_Cooldown(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	changedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("UPDATE users u SET username").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery("SELECT username_changed_at FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"username_changed_at"}).AddRow(changedAt))
+
+	svc := NewUsernameService(data.NewUserStore(db), 30*24*time.Hour, nil)
+	err = svc.Set(context.Background(), "user-1", "new_name")
+
+	var cooldown *UsernameCooldownError
+	if !errors.As(err, &cooldown) {
+		t.Fatalf("expected UsernameCooldownError, got %v", err)
+	}
+	if want := changedAt.Add(30 * 24 * time.Hour); !cooldown.RetryAt.Equal(want) {
+		t.Errorf("RetryAt: got %v, want %v", cooldown.RetryAt, want)
+	}
+}
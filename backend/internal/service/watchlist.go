@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"strings"
 
 	"github.com/shopspring/decimal"
 
@@ -29,26 +32,81 @@ type WatchlistEntryView struct {
 	HasPrice         bool            `json:"has_price"`
 }
 
+// CuratedSymbolView is one symbol of a curated list with its current price,
+// on the same terms as WatchlistEntryView.
+type CuratedSymbolView struct {
+	Symbol           string          `json:"symbol"`
+	Price            decimal.Decimal `json:"price"`
+	Change           decimal.Decimal `json:"change"`
+	ChangePercentage decimal.Decimal `json:"change_percentage"`
+	HasPrice         bool            `json:"has_price"`
+}
+
+// CuratedListView is a curated list with its symbols priced.
+type CuratedListView struct {
+	Slug        string              `json:"slug"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	IsDefault   bool                `json:"is_default"`
+	Subscribed  bool                `json:"subscribed"`
+	Items       []CuratedSymbolView `json:"items"`
+}
+
+// WatchlistView is everything the dashboard watchlist shows: the user's own
+// symbols and the curated lists they subscribe to.
+type WatchlistView struct {
+	Items []WatchlistEntryView `json:"items"`
+	Lists []CuratedListView    `json:"lists"`
+}
+
+// maxCuratedListSymbols caps a curated list so pricing it stays one
+// reasonably sized batch request.
+const maxCuratedListSymbols = 25
+
+var curatedListSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 // ErrSymbolNotFound is declared in errors.go alongside other typed service
 // errors so MapServiceError can pick up its HTTPError implementation.
 
 type WatchlistService struct {
 	store         *data.WatchlistStore
+	curated       *data.CuratedListStore
 	marketService WatchlistMarket
+	maxEntries    int // 0 = unlimited
+}
+
+func NewWatchlistService(store *data.WatchlistStore, curated *data.CuratedListStore, marketService WatchlistMarket) *WatchlistService {
+	return &WatchlistService{store: store, curated: curated, marketService: marketService}
 }
 
-func NewWatchlistService(store *data.WatchlistStore, marketService WatchlistMarket) *WatchlistService {
-	return &WatchlistService{store: store, marketService: marketService}
+// SetMaxEntries caps how many symbols each user may watch; 0 removes the
+// cap. Curated list subscriptions do not count. Call during wiring, before
+// the service handles requests.
+func (s *WatchlistService) SetMaxEntries(n int) {
+	s.maxEntries = n
 }
 
 // AddSymbol validates the symbol against MarketStack and inserts it.
 // Returns ErrSymbolNotFound if MarketStack has no data for the symbol.
-// Returns data.ErrWatchlistEntryExists if the user already watches it.
+// Returns data.ErrWatchlistEntryExists if the user already watches it, and
+// WatchlistLimitError if the watchlist is full.
 func (s *WatchlistService) AddSymbol(ctx context.Context, userID, rawSymbol string) (*WatchlistEntryView, error) {
-	symbol, err := util.ValidateSymbol(rawSymbol)
+	symbol, err := util.ValidateQuoteSymbol(rawSymbol)
 	if err != nil {
 		return nil, err
 	}
+	if s.maxEntries > 0 {
+		// Checked before the provider call so a full watchlist costs no
+		// quota. Like the order cap, count-then-insert can overshoot by one
+		// under concurrent adds.
+		n, err := s.store.Count(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if n >= s.maxEntries {
+			return nil, &WatchlistLimitError{Limit: s.maxEntries}
+		}
+	}
 
 	priced, err := s.marketService.GetBatchHistoricalData(ctx, []string{symbol})
 	if err != nil {
@@ -77,50 +135,193 @@ func (s *WatchlistService) AddSymbol(ctx context.Context, userID, rawSymbol stri
 
 // RemoveSymbol deletes the entry. Returns data.ErrWatchlistEntryNotFound if missing.
 func (s *WatchlistService) RemoveSymbol(ctx context.Context, userID, rawSymbol string) error {
-	symbol, err := util.ValidateSymbol(rawSymbol)
+	symbol, err := util.ValidateQuoteSymbol(rawSymbol)
 	if err != nil {
 		return err
 	}
 	return s.store.Remove(ctx, userID, symbol)
 }
 
-// List returns the user's watchlist enriched with current prices.
-// Entries without a price lookup still appear (HasPrice=false).
-func (s *WatchlistService) List(ctx context.Context, userID string) ([]WatchlistEntryView, error) {
+// List returns the user's watchlist and subscribed curated lists enriched
+// with current prices, fetched in one batch. Entries without a price lookup
+// still appear (HasPrice=false).
+func (s *WatchlistService) List(ctx context.Context, userID string) (*WatchlistView, error) {
 	entries, err := s.store.ListByUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	if len(entries) == 0 {
-		return []WatchlistEntryView{}, nil
+	lists, err := s.curated.ListSubscribed(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
 	symbols := make([]string, 0, len(entries))
 	for _, e := range entries {
 		symbols = append(symbols, e.Symbol)
 	}
-
-	// Best-effort price enrichment — if the API is down, return entries without prices.
-	priced, err := s.marketService.GetBatchHistoricalData(ctx, symbols)
-	if err != nil {
-		slog.Warn("watchlist price enrichment failed", "user_id", userID, "symbol_count", len(symbols), "err", err, "component", "watchlist")
-		priced = nil
+	for _, l := range lists {
+		symbols = append(symbols, l.Symbols...)
 	}
+	priced := s.price(ctx, userID, symbols)
 
-	views := make([]WatchlistEntryView, 0, len(entries))
+	view := &WatchlistView{
+		Items: make([]WatchlistEntryView, 0, len(entries)),
+		Lists: make([]CuratedListView, 0, len(lists)),
+	}
 	for _, e := range entries {
-		view := WatchlistEntryView{
+		entry := WatchlistEntryView{
 			ID:        e.ID,
 			Symbol:    e.Symbol,
 			CreatedAt: e.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		}
 		if hist, ok := priced[e.Symbol]; ok && hist != nil {
-			view.Price = hist.Price
-			view.Change = hist.Change
-			view.ChangePercentage = hist.ChangePercentage
-			view.HasPrice = true
+			entry.Price = hist.Price
+			entry.Change = hist.Change
+			entry.ChangePercentage = hist.ChangePercentage
+			entry.HasPrice = true
+		}
+		view.Items = append(view.Items, entry)
+	}
+	for _, l := range lists {
+		view.Lists = append(view.Lists, curatedListView(l, priced))
+	}
+	return view, nil
+}
+
+// price looks up current prices for symbols, de-duplicated. Best-effort: if
+// the API is down the result is nil and callers render entries without
+// prices.
+func (s *WatchlistService) price(ctx context.Context, userID string, symbols []string) map[string]*HistoricalData {
+	if len(symbols) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(symbols))
+	unique := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		if !seen[sym] {
+			seen[sym] = true
+			unique = append(unique, sym)
+		}
+	}
+
+	priced, err := s.marketService.GetBatchHistoricalData(ctx, unique)
+	if err != nil {
+		slog.Warn("watchlist price enrichment failed", "user_id", userID, "symbol_count", len(unique), "err", err, "component", "watchlist")
+		return nil
+	}
+	return priced
+}
+
+func curatedListView(l data.CuratedList, priced map[string]*HistoricalData) CuratedListView {
+	view := CuratedListView{
+		Slug:        l.Slug,
+		Name:        l.Name,
+		Description: l.Description,
+		IsDefault:   l.IsDefault,
+		Subscribed:  l.Subscribed,
+		Items:       make([]CuratedSymbolView, 0, len(l.Symbols)),
+	}
+	for _, sym := range l.Symbols {
+		item := CuratedSymbolView{Symbol: sym}
+		if hist, ok := priced[sym]; ok && hist != nil {
+			item.Price = hist.Price
+			item.Change = hist.Change
+			item.ChangePercentage = hist.ChangePercentage
+			item.HasPrice = true
 		}
-		views = append(views, view)
+		view.Items = append(view.Items, item)
+	}
+	return view
+}
+
+// CuratedLists returns every curated list, without prices, marked with
+// whether userID is subscribed.
+func (s *WatchlistService) CuratedLists(ctx context.Context, userID string) ([]data.CuratedList, error) {
+	return s.curated.List(ctx, userID)
+}
+
+// CuratedList returns one curated list with its symbols priced.
+func (s *WatchlistService) CuratedList(ctx context.Context, userID, slug string) (*CuratedListView, error) {
+	list, err := s.getCuratedList(ctx, userID, slug)
+	if err != nil {
+		return nil, err
+	}
+	view := curatedListView(*list, s.price(ctx, userID, list.Symbols))
+	return &view, nil
+}
+
+// SubscribeCuratedList adds the curated list to userID's watchlist.
+// Subscribing twice is not an error.
+func (s *WatchlistService) SubscribeCuratedList(ctx context.Context, userID, slug string) error {
+	if err := s.curated.Subscribe(ctx, userID, slug); err != nil {
+		if errors.Is(err, data.ErrCuratedListNotFound) {
+			return &CuratedListNotFoundError{}
+		}
+		return err
+	}
+	return nil
+}
+
+// UnsubscribeCuratedList removes the curated list from userID's watchlist.
+// Unsubscribing from a list the user doesn't follow is not an error.
+func (s *WatchlistService) UnsubscribeCuratedList(ctx context.Context, userID, slug string) error {
+	if _, err := s.getCuratedList(ctx, userID, slug); err != nil {
+		return err
+	}
+	return s.curated.Unsubscribe(ctx, userID, slug)
+}
+
+// SaveCuratedList creates or replaces a curated list (admin only). Symbols
+// are validated and de-duplicated, keeping their order.
+func (s *WatchlistService) SaveCuratedList(ctx context.Context, list data.CuratedList) (*data.CuratedList, error) {
+	if len(list.Slug) > 64 || !curatedListSlugPattern.MatchString(list.Slug) {
+		return nil, &util.ValidationError{Field: "slug", Message: "must be lowercase letters, digits and single hyphens, at most 64 characters"}
+	}
+	list.Name = strings.TrimSpace(list.Name)
+	if list.Name == "" || len(list.Name) > 100 {
+		return nil, &util.ValidationError{Field: "name", Message: "must be between 1 and 100 characters"}
+	}
+	list.Description = strings.TrimSpace(list.Description)
+
+	symbols := make([]string, 0, len(list.Symbols))
+	seen := make(map[string]bool, len(list.Symbols))
+	for _, raw := range list.Symbols {
+		symbol, err := util.ValidateQuoteSymbol(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 || len(symbols) > maxCuratedListSymbols {
+		return nil, &util.ValidationError{Field: "symbols", Message: fmt.Sprintf("must contain between 1 and %d symbols", maxCuratedListSymbols)}
+	}
+	list.Symbols = symbols
+
+	return s.curated.Save(ctx, &list)
+}
+
+// DeleteCuratedList removes a curated list and all subscriptions to it
+// (admin only).
+func (s *WatchlistService) DeleteCuratedList(ctx context.Context, slug string) error {
+	if err := s.curated.Delete(ctx, slug); err != nil {
+		if errors.Is(err, data.ErrCuratedListNotFound) {
+			return &CuratedListNotFoundError{}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *WatchlistService) getCuratedList(ctx context.Context, userID, slug string) (*data.CuratedList, error) {
+	list, err := s.curated.Get(ctx, userID, slug)
+	if err != nil {
+		if errors.Is(err, data.ErrCuratedListNotFound) {
+			return nil, &CuratedListNotFoundError{}
+		}
+		return nil, err
 	}
-	return views, nil
+	return list, nil
 }
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+
+	"papertrader/internal/data"
+	"papertrader/internal/util"
+)
+
+func TestSaveCuratedList_Validation(t *testing.T) {
+	svc := NewWatchlistService(nil, nil, nil)
+	cases := []struct {
+		name  string
+		list  data.CuratedList
+		field string
+	}{
+		{"bad slug", data.CuratedList{Slug: "Big Tech", Name: "Big tech", Symbols: []string{"AAPL"}}, "slug"},
+		{"blank name", data.CuratedList{Slug: "big-tech", Name: "  ", Symbols: []string{"AAPL"}}, "name"},
+		{"no symbols", data.CuratedList{Slug: "big-tech", Name: "Big tech"}, "symbols"},
+		{"bad symbol", data.CuratedList{Slug: "big-tech", Name: "Big tech", Symbols: []string{"AAPL", "not a symbol"}}, "symbol"},
+	}
+	for _, tc := range cases {
+		_, err := svc.SaveCuratedList(context.Background(), tc.list)
+		var vErr *util.ValidationError
+		if !errors.As(err, &vErr) || vErr.Field != tc.field {
+			t.Errorf("%s: err = %v, want validation error on %q", tc.name, err, tc.field)
+		}
+	}
+}
+
+func TestSaveCuratedList_NormalisesSymbols(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	want := []string{"AAPL", "MSFT"}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE curated_lists SET is_default = FALSE").
+		WithArgs("big-tech").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO curated_lists").
+		WithArgs("big-tech", "Big tech", "", pq.Array(want), true).
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "name", "description", "symbols", "is_default", "created_at", "updated_at"}).
+			AddRow("big-tech", "Big tech", "", "{AAPL,MSFT}", true, time.Now(), time.Now()))
+	mock.ExpectCommit()
+
+	svc := NewWatchlistService(nil, data.NewCuratedListStore(db), nil)
+	list, err := svc.SaveCuratedList(context.Background(), data.CuratedList{
+		Slug: "big-tech", Name: " Big tech ", Symbols: []string{"aapl", "MSFT", "AAPL"}, IsDefault: true,
+	})
+	if err != nil {
+		t.Fatalf("SaveCuratedList: %v", err)
+	}
+	if len(list.Symbols) != 2 || list.Symbols[0] != "AAPL" || list.Symbols[1] != "MSFT" {
+		t.Errorf("Symbols = %v, want %v", list.Symbols, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestAddSymbol_LimitReached(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+
+	// No market lookup: a full watchlist is rejected before the provider call.
+	svc := NewWatchlistService(data.NewWatchlistStore(db), nil, nil)
+	svc.SetMaxEntries(3)
+	mock.ExpectQuery("SELECT COUNT").WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	_, err = svc.AddSymbol(context.Background(), "user-1", "aapl")
+	var le *WatchlistLimitError
+	if !errors.As(err, &le) || le.Limit != 3 {
+		t.Errorf("expected WatchlistLimitError{3}, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/shopspring/decimal"
+)
+
+// timeLayout is how times are stored: UTC, to the millisecond as SQLite's
+// strftime('%f') writes the schema's defaults, in a form that sorts the same
+// as the instants and that the sqlite3 driver parses back into a time.Time
+// for TIMESTAMP and DATE columns.
+const timeLayout = "2006-01-02 15:04:05.000"
+
+// Driver is the database/sql driver registered as DriverName. It wraps the
+// sqlite3 driver, translating each statement and value on the way in and
+// each value and error on the way out.
+type Driver struct{}
+
+// Open opens one connection. Every connection gets its own copy of the
+// PostgreSQL compatibility functions, since set_config's settings are
+// per-session.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c := &conn{settings: map[string]string{}, local: map[string]string{}}
+	inner := &sqlite3.SQLiteDriver{ConnectHook: func(sc *sqlite3.SQLiteConn) error {
+		return registerFuncs(sc, c)
+	}}
+	ic, err := inner.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	c.inner = ic
+	return c, nil
+}
+
+type conn struct {
+	inner driver.Conn
+	// settings and local hold set_config's session and transaction-local
+	// values; local is cleared when the transaction ends.
+	settings map[string]string
+	local    map[string]string
+}
+
+var (
+	_ driver.ConnPrepareContext = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.Pinger             = (*conn)(nil)
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	s, err := c.inner.(driver.ConnPrepareContext).PrepareContext(ctx, translate(query))
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &stmt{inner: s}, nil
+}
+
+func (c *conn) Close() error {
+	return c.inner.Close()
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+// BeginTx ignores the isolation level: with _txlock=immediate every
+// transaction holds the database's write lock throughout, which is
+// serializable.
+func (c *conn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	t, err := c.inner.(driver.ConnBeginTx).BeginTx(ctx, driver.TxOptions{})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &tx{conn: c, inner: t}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	res, err := c.inner.(driver.ExecerContext).ExecContext(ctx, translate(query), args)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	r, err := c.inner.(driver.QueryerContext).QueryContext(ctx, translate(query), args)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return newRows(r), nil
+}
+
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.inner.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+type tx struct {
+	conn  *conn
+	inner driver.Tx
+}
+
+func (t *tx) Commit() error {
+	clear(t.conn.local)
+	return mapError(t.inner.Commit())
+}
+
+func (t *tx) Rollback() error {
+	clear(t.conn.local)
+	return mapError(t.inner.Rollback())
+}
+
+type stmt struct {
+	inner driver.Stmt
+}
+
+var (
+	_ driver.StmtExecContext   = (*stmt)(nil)
+	_ driver.StmtQueryContext  = (*stmt)(nil)
+	_ driver.NamedValueChecker = (*stmt)(nil)
+)
+
+func (s *stmt) Close() error  { return s.inner.Close() }
+func (s *stmt) NumInput() int { return s.inner.NumInput() }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), named(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	res, err := s.inner.(driver.StmtExecContext).ExecContext(ctx, args)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return res, nil
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	r, err := s.inner.(driver.StmtQueryContext).QueryContext(ctx, args)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return newRows(r), nil
+}
+
+func (s *stmt) CheckNamedValue(nv *driver.NamedValue) error {
+	return checkNamedValue(nv)
+}
+
+func named(args []driver.Value) []driver.NamedValue {
+	out := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		out[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return out
+}
+
+// checkNamedValue converts an argument into a value the sqlite3 driver
+// stores the way the schema expects: decimals as numbers rather than the
+// text decimal.Decimal's Valuer produces, and times as UTC text (a time at
+// UTC midnight as a bare date, which is how DATE columns are stored).
+func checkNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case decimal.Decimal:
+		nv.Value = v.InexactFloat64()
+		return nil
+	case *decimal.Decimal:
+		if v == nil {
+			nv.Value = nil
+		} else {
+			nv.Value = v.InexactFloat64()
+		}
+		return nil
+	case decimal.NullDecimal:
+		if v.Valid {
+			nv.Value = v.Decimal.InexactFloat64()
+		} else {
+			nv.Value = nil
+		}
+		return nil
+	}
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if t, ok := v.(time.Time); ok {
+		v = formatTime(t)
+	}
+	nv.Value = v
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	t = t.UTC()
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(timeLayout)
+}
+
+type rows struct {
+	inner driver.Rows
+	// scale is each column's NUMERIC scale, or -1 for a column that isn't
+	// NUMERIC(p,s); computed is set for columns with no declared type
+	// (expressions), whose times and dates arrive as text.
+	scale    []int
+	computed []bool
+}
+
+func newRows(r driver.Rows) *rows {
+	cols := r.Columns()
+	out := &rows{inner: r, scale: make([]int, len(cols)), computed: make([]bool, len(cols))}
+	typed, _ := r.(driver.RowsColumnTypeDatabaseTypeName)
+	for i := range cols {
+		out.scale[i] = -1
+		decl := ""
+		if typed != nil {
+			decl = strings.ToUpper(typed.ColumnTypeDatabaseTypeName(i))
+		}
+		out.computed[i] = decl == ""
+		if p, ok := strings.CutPrefix(decl, "NUMERIC("); ok {
+			if _, s, ok := strings.Cut(strings.TrimSuffix(p, ")"), ","); ok {
+				if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+					out.scale[i] = n
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (r *rows) Columns() []string { return r.inner.Columns() }
+func (r *rows) Close() error      { return r.inner.Close() }
+
+func (r *rows) ColumnTypeDatabaseTypeName(i int) string {
+	if typed, ok := r.inner.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return typed.ColumnTypeDatabaseTypeName(i)
+	}
+	return ""
+}
+
+// Next reads the next row. Decimals come back as text, as lib/pq returns
+// them: rounded to the column's scale, or to 15 significant digits for a
+// computed value, so float arithmetic doesn't leak 0.30000000000000004 into
+// a decimal.Decimal.
+func (r *rows) Next(dest []driver.Value) error {
+	if err := r.inner.Next(dest); err != nil {
+		if errors.Is(err, io.EOF) {
+			return err
+		}
+		return mapError(err)
+	}
+	for i, v := range dest {
+		switch v := v.(type) {
+		case float64:
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				continue
+			}
+			if r.scale[i] >= 0 {
+				dest[i] = []byte(strconv.FormatFloat(v, 'f', r.scale[i], 64))
+			} else {
+				dest[i] = []byte(strconv.FormatFloat(v, 'g', 15, 64))
+			}
+		case string:
+			if r.computed[i] {
+				if t, ok := parseTime(v); ok {
+					dest[i] = t
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// parseTime parses a stored timestamp or date. It is applied to computed
+// values only; a text column (quote_date, month) may hold a date that must
+// stay a string.
+func parseTime(s string) (time.Time, bool) {
+	if len(s) < len(time.DateOnly) || s[4] != '-' || s[7] != '-' {
+		return time.Time{}, false
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05", time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
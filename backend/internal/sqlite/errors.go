@@ -0,0 +1,69 @@
+//go:build cgo
+
+package sqlite
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// mapError reports a constraint violation as the *pq.Error PostgreSQL would
+// have returned, with its SQLSTATE and the constraint name PostgreSQL gives
+// the same constraint, which is what the stores match on. Other errors are
+// returned as they are.
+func mapError(err error) error {
+	var se sqlite3.Error
+	if err == nil || !errors.As(err, &se) || se.Code != sqlite3.ErrConstraint {
+		return err
+	}
+	pe := &pq.Error{Message: se.Error()}
+	switch se.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		pe.Code = "23505"
+		pe.Constraint = uniqueConstraint(se.Error(), se.ExtendedCode == sqlite3.ErrConstraintPrimaryKey)
+	case sqlite3.ErrConstraintForeignKey:
+		// SQLite doesn't say which foreign key failed.
+		pe.Code = "23503"
+	case sqlite3.ErrConstraintCheck:
+		pe.Code = "23514"
+	case sqlite3.ErrConstraintNotNull:
+		pe.Code = "23502"
+	case sqlite3.ErrConstraintTrigger:
+		// RAISE(ABORT) in a trigger, as RAISE EXCEPTION in PL/pgSQL.
+		pe.Code = "P0001"
+	default:
+		return err
+	}
+	return pe
+}
+
+// uniqueConstraint names the constraint in a SQLite unique violation the
+// way PostgreSQL names it: "UNIQUE constraint failed: users.email" is
+// users_email_key, a primary key is table_pkey, and a named unique index is
+// reported by name ("UNIQUE constraint failed: index 'idx_...'").
+func uniqueConstraint(msg string, primaryKey bool) string {
+	_, detail, ok := strings.Cut(msg, "constraint failed: ")
+	if !ok {
+		return ""
+	}
+	if name, ok := strings.CutPrefix(detail, "index '"); ok {
+		return strings.TrimSuffix(name, "'")
+	}
+	var table string
+	var cols []string
+	for _, col := range strings.Split(detail, ",") {
+		t, c, ok := strings.Cut(strings.TrimSpace(col), ".")
+		if !ok {
+			return ""
+		}
+		table = t
+		cols = append(cols, c)
+	}
+	if primaryKey {
+		return table + "_pkey"
+	}
+	return table + "_" + strings.Join(cols, "_") + "_key"
+}
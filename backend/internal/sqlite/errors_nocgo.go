@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package sqlite
+
+// mapError has nothing to map without cgo: the sqlite3 driver can't open a
+// database at all.
+func mapError(err error) error {
+	return err
+}
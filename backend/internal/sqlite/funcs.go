@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// registerFuncs adds the functions the translated statements call to a new
+// connection.
+func registerFuncs(sc *sqlite3.SQLiteConn, c *conn) error {
+	funcs := []struct {
+		name string
+		impl any
+		pure bool
+	}{
+		{"set_config", c.setConfig, false},
+		{"current_setting", c.currentSetting, false},
+		{"pg_array_json", arrayJSON, true},
+		{"pg_array_contains", arrayContains, true},
+		{"pg_timezone", atTimeZone, true},
+		{"sign", sign, true},
+		{"websearch_rank", websearchRank, true},
+		{"websearch_headline", websearchHeadline, true},
+	}
+	for _, f := range funcs {
+		if err := sc.RegisterFunc(f.name, f.impl, f.pure); err != nil {
+			return fmt.Errorf("sqlite: register %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// setConfig is PostgreSQL's set_config: a local setting lasts until the
+// current transaction ends.
+func (c *conn) setConfig(name, value string, isLocal bool) string {
+	if isLocal {
+		c.local[name] = value
+	} else {
+		c.settings[name] = value
+	}
+	return value
+}
+
+// currentSetting is PostgreSQL's current_setting. Only settings made with
+// set_config exist.
+func (c *conn) currentSetting(name string, missingOK ...bool) (any, error) {
+	if v, ok := c.local[name]; ok {
+		return v, nil
+	}
+	if v, ok := c.settings[name]; ok {
+		return v, nil
+	}
+	if len(missingOK) > 0 && missingOK[0] {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unrecognized configuration parameter %q", name)
+}
+
+// parseArray parses an array stored in PostgreSQL's text form; NULL is an
+// empty array.
+func parseArray(v any) ([]string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	var arr pq.StringArray
+	if err := arr.Scan(v); err != nil {
+		return nil, err
+	}
+	return arr, nil
+}
+
+// arrayJSON turns a PostgreSQL array into a JSON array, so json_each can
+// expand it: x = ANY(arr) is rewritten to x IN (SELECT value FROM
+// json_each(pg_array_json(arr))).
+func arrayJSON(v any) (string, error) {
+	arr, err := parseArray(v)
+	if err != nil {
+		return "", err
+	}
+	if arr == nil {
+		arr = []string{}
+	}
+	b, err := json.Marshal(arr)
+	return string(b), err
+}
+
+// arrayContains is arr @> ARRAY[elems...].
+func arrayContains(v any, elems ...string) (bool, error) {
+	arr, err := parseArray(v)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range elems {
+		if !slices.Contains(arr, e) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// atTimeZone is ts AT TIME ZONE zone for a stored (UTC) timestamp: the wall
+// clock time in zone.
+func atTimeZone(zone string, ts any) (any, error) {
+	var s string
+	switch v := ts.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return nil, fmt.Errorf("AT TIME ZONE: unsupported value %v", ts)
+	}
+	t, ok := parseTime(s)
+	if !ok {
+		return nil, fmt.Errorf("AT TIME ZONE: invalid timestamp %q", s)
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("time zone %q not recognized", zone)
+	}
+	return t.In(loc).Format(timeLayout), nil
+}
+
+func sign(x float64) int64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
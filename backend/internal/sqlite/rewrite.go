@@ -0,0 +1,423 @@
+package sqlite
+
+import (
+	"strings"
+	"sync"
+)
+
+// translate rewrites a statement written for PostgreSQL into SQLite:
+//
+//   - $1 placeholders become ?1
+//   - casts (x::date, $1::text[]) are dropped, except ::date, which becomes date(x)
+//   - x AT TIME ZONE z becomes pg_timezone(z, x)
+//   - x = ANY(arr) becomes x IN (SELECT value FROM json_each(pg_array_json(arr)))
+//   - arr @> ARRAY[a, b] becomes pg_array_contains(arr, a, b)
+//   - IS [NOT] DISTINCT FROM becomes IS NOT / IS
+//   - FOR UPDATE [SKIP LOCKED] is dropped: a transaction already holds the
+//     database's write lock
+//   - GREATEST, LEAST, BOOL_OR, BOOL_AND and strpos become MAX, MIN, MAX,
+//     MIN and instr
+//   - CURRENT_TIMESTAMP and NOW() become the current UTC time in the stored
+//     text form, CURRENT_DATE today's UTC date
+//   - UPDATE t alias SET gains the AS SQLite requires
+//
+// String literals and comments are left alone. Translations are cached, since
+// the stores issue the same few hundred statements over and over.
+func translate(query string) string {
+	if cached, ok := translations.Load(query); ok {
+		return cached.(string)
+	}
+	out := render(rewrite(lex(query)))
+	translations.Store(query, out)
+	return out
+}
+
+var translations sync.Map
+
+// nowExpr is CURRENT_TIMESTAMP in the form times are stored in.
+const nowExpr = "(strftime('%Y-%m-%d %H:%M:%f', 'now'))"
+
+type tokenKind int
+
+const (
+	tkSpace tokenKind = iota
+	tkComment
+	tkString
+	tkIdent
+	tkQuoted
+	tkParam
+	tkNumber
+	tkOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func (t token) is(word string) bool {
+	return t.kind == tkIdent && strings.EqualFold(t.text, word)
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentByte(c byte) bool {
+	return isIdentStart(c) || ('0' <= c && c <= '9')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// lex splits a statement into tokens. A qualified name (t.col, t.*) is one
+// identifier token.
+func lex(s string) []token {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		j := i + 1
+		kind := tkOp
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			for j < len(s) && strings.IndexByte(" \t\n\r", s[j]) >= 0 {
+				j++
+			}
+			kind = tkSpace
+		case c == '-' && strings.HasPrefix(s[i:], "--"):
+			if end := strings.IndexByte(s[i:], '\n'); end >= 0 {
+				j = i + end
+			} else {
+				j = len(s)
+			}
+			kind = tkComment
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			if end := strings.Index(s[i+2:], "*/"); end >= 0 {
+				j = i + 2 + end + 2
+			} else {
+				j = len(s)
+			}
+			kind = tkComment
+		case c == '\'' || c == '"':
+			for j < len(s) {
+				if s[j] == c {
+					if j+1 < len(s) && s[j+1] == c {
+						j += 2
+						continue
+					}
+					j++
+					break
+				}
+				j++
+			}
+			kind = tkString
+			if c == '"' {
+				kind = tkQuoted
+			}
+		case c == '$' && j < len(s) && isDigit(s[j]):
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+			kind = tkParam
+		case isIdentStart(c):
+			for j < len(s) {
+				switch {
+				case isIdentByte(s[j]):
+					j++
+					continue
+				case s[j] == '.' && j+1 < len(s) && (isIdentStart(s[j+1]) || s[j+1] == '*'):
+					j += 2
+					continue
+				}
+				break
+			}
+			kind = tkIdent
+		case isDigit(c):
+			for j < len(s) && (isDigit(s[j]) || s[j] == '.') {
+				j++
+			}
+			kind = tkNumber
+		default:
+			for _, op := range []string{"::", "@>", "<=", ">=", "<>", "!=", "||"} {
+				if strings.HasPrefix(s[i:], op) {
+					j = i + len(op)
+					break
+				}
+			}
+		}
+		toks = append(toks, token{kind, s[i:j]})
+		i = j
+	}
+	return toks
+}
+
+func render(toks []token) string {
+	var b strings.Builder
+	for _, t := range toks {
+		b.WriteString(t.text)
+	}
+	return b.String()
+}
+
+// keywords that can come before a parenthesis without being a function name.
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "IN": true, "IS": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "SELECT": true, "WHERE": true, "BY": true, "ON": true, "AS": true, "FROM": true,
+	"VALUES": true, "RETURNING": true, "SET": true, "JOIN": true, "HAVING": true, "USING": true,
+	"CASE": true, "LIKE": true, "BETWEEN": true, "DISTINCT": true, "ALL": true, "UNION": true,
+}
+
+type rewriter struct {
+	toks []token
+}
+
+func (r *rewriter) significant(i int) bool {
+	return i >= 0 && i < len(r.toks) && r.toks[i].kind != tkSpace && r.toks[i].kind != tkComment
+}
+
+// next returns the index of the first significant token after i, or
+// len(toks).
+func (r *rewriter) next(i int) int {
+	for i++; i < len(r.toks) && !r.significant(i); i++ {
+	}
+	return i
+}
+
+// prev returns the index of the last significant token before i, or -1.
+func (r *rewriter) prev(i int) int {
+	for i--; i >= 0 && !r.significant(i); i-- {
+	}
+	return i
+}
+
+func (r *rewriter) at(i int) token {
+	if i < 0 || i >= len(r.toks) {
+		return token{}
+	}
+	return r.toks[i]
+}
+
+// closing returns the index of the bracket that closes the one at i.
+func (r *rewriter) closing(i int) int {
+	open, shut := r.toks[i].text, map[string]string{"(": ")", "[": "]"}[r.toks[i].text]
+	depth := 0
+	for j := i; j < len(r.toks); j++ {
+		switch r.toks[j].text {
+		case open:
+			depth++
+		case shut:
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(r.toks) - 1
+}
+
+// opening returns the index of the parenthesis that the one at i closes.
+func (r *rewriter) opening(i int) int {
+	depth := 0
+	for j := i; j >= 0; j-- {
+		switch r.toks[j].text {
+		case ")":
+			depth++
+		case "(":
+			if depth--; depth == 0 {
+				return j
+			}
+		}
+	}
+	return 0
+}
+
+// operandBefore returns where the operand ending at end begins: a single
+// token, a parenthesised group, or a function call.
+func (r *rewriter) operandBefore(end int) int {
+	if r.at(end).text != ")" || r.at(end).kind != tkOp {
+		return end
+	}
+	open := r.opening(end)
+	if fn := r.prev(open); fn >= 0 && r.toks[fn].kind == tkIdent && !keywords[strings.ToUpper(r.toks[fn].text)] {
+		return fn
+	}
+	return open
+}
+
+// operandAfter returns where the operand starting at start ends.
+func (r *rewriter) operandAfter(start int) int {
+	if r.at(start).kind == tkIdent {
+		if open := r.next(start); r.at(open).text == "(" {
+			return r.closing(open)
+		}
+	}
+	if r.at(start).text == "(" {
+		return r.closing(start)
+	}
+	return start
+}
+
+func (r *rewriter) splice(from, to int, repl ...token) {
+	r.toks = append(r.toks[:from], append(repl, r.toks[to+1:]...)...)
+}
+
+func (r *rewriter) span(from, to int) []token {
+	return append([]token(nil), r.toks[from:to+1]...)
+}
+
+func op(text string) token { return token{tkOp, text} }
+
+func rewrite(toks []token) []token {
+	r := &rewriter{toks: toks}
+	r.simple()
+	r.atTimeZone()
+	r.casts()
+	r.arrays()
+	return r.toks
+}
+
+// simple handles the rewrites that replace tokens in place.
+func (r *rewriter) simple() {
+	renames := map[string]string{"GREATEST": "MAX", "LEAST": "MIN", "BOOL_OR": "MAX", "BOOL_AND": "MIN", "STRPOS": "instr"}
+	for i := 0; i < len(r.toks); i++ {
+		t := r.toks[i]
+		switch {
+		case t.kind == tkParam:
+			r.toks[i].text = "?" + t.text[1:]
+		case t.is("CURRENT_TIMESTAMP"):
+			r.toks[i] = op(nowExpr)
+		case t.is("CURRENT_DATE"):
+			r.toks[i] = op("date('now')")
+		case t.is("NOW") && r.at(r.next(i)).text == "(" && r.at(r.next(r.next(i))).text == ")":
+			r.splice(i, r.next(r.next(i)), op(nowExpr))
+		case t.kind == tkIdent && renames[strings.ToUpper(t.text)] != "" && r.at(r.next(i)).text == "(":
+			r.toks[i].text = renames[strings.ToUpper(t.text)]
+		case t.is("UPDATE"):
+			table := r.next(i)
+			alias := r.next(table)
+			if r.at(table).kind == tkIdent && r.at(alias).kind == tkIdent && !keywords[strings.ToUpper(r.at(alias).text)] &&
+				r.at(r.next(alias)).is("SET") {
+				r.toks[alias].text = "AS " + r.toks[alias].text
+			}
+		case t.is("FOR"):
+			if r.lockingClause(i) {
+				i-- // look at what followed the clause
+			}
+		case t.is("DISTINCT") && r.at(r.next(i)).is("FROM"):
+			is := r.prev(i)
+			not := false
+			if r.at(is).is("NOT") {
+				not, is = true, r.prev(is)
+			}
+			if !r.at(is).is("IS") {
+				continue
+			}
+			if not {
+				r.splice(is, r.next(i), token{tkIdent, "IS"})
+			} else {
+				r.splice(is, r.next(i), token{tkIdent, "IS NOT"})
+			}
+			i = is
+		}
+	}
+}
+
+// lockingClause drops FOR UPDATE / FOR SHARE / FOR NO KEY UPDATE / FOR KEY
+// SHARE, with any OF list and SKIP LOCKED or NOWAIT, starting at i. It
+// reports whether there was one.
+func (r *rewriter) lockingClause(i int) bool {
+	j := r.next(i)
+	switch {
+	case r.at(j).is("UPDATE"), r.at(j).is("SHARE"):
+	case r.at(j).is("NO") && r.at(r.next(j)).is("KEY") && r.at(r.next(r.next(j))).is("UPDATE"):
+		j = r.next(r.next(j))
+	case r.at(j).is("KEY") && r.at(r.next(j)).is("SHARE"):
+		j = r.next(j)
+	default:
+		return false
+	}
+	if r.at(r.next(j)).is("OF") {
+		j = r.next(r.next(j))
+		for r.at(r.next(j)).text == "," {
+			j = r.next(r.next(j))
+		}
+	}
+	switch {
+	case r.at(r.next(j)).is("SKIP") && r.at(r.next(r.next(j))).is("LOCKED"):
+		j = r.next(r.next(j))
+	case r.at(r.next(j)).is("NOWAIT"):
+		j = r.next(j)
+	}
+	r.splice(i, j)
+	return true
+}
+
+func (r *rewriter) atTimeZone() {
+	for i := 0; i < len(r.toks); i++ {
+		if !r.toks[i].is("AT") || !r.at(r.next(i)).is("TIME") || !r.at(r.next(r.next(i))).is("ZONE") {
+			continue
+		}
+		xEnd := r.prev(i)
+		xStart := r.operandBefore(xEnd)
+		yStart := r.next(r.next(r.next(i)))
+		yEnd := r.operandAfter(yStart)
+		repl := append([]token{{tkIdent, "pg_timezone"}, op("(")}, r.span(yStart, yEnd)...)
+		repl = append(repl, op(", "))
+		repl = append(repl, r.span(xStart, xEnd)...)
+		repl = append(repl, op(")"))
+		r.splice(xStart, yEnd, repl...)
+		i = xStart
+	}
+}
+
+func (r *rewriter) casts() {
+	for i := 0; i < len(r.toks); i++ {
+		if r.toks[i].kind != tkOp || r.toks[i].text != "::" {
+			continue
+		}
+		typ := r.next(i)
+		end := typ
+		if r.at(r.next(end)).text == "(" {
+			end = r.closing(r.next(end))
+		}
+		if r.at(r.next(end)).text == "[" {
+			end = r.closing(r.next(end))
+		}
+		isDate := r.at(typ).is("date")
+		xEnd := r.prev(i)
+		r.splice(i, end)
+		if isDate {
+			xStart := r.operandBefore(xEnd)
+			repl := append([]token{{tkIdent, "date"}, op("(")}, r.span(xStart, xEnd)...)
+			r.splice(xStart, xEnd, append(repl, op(")"))...)
+		}
+		i = xEnd
+	}
+}
+
+func (r *rewriter) arrays() {
+	for i := 0; i < len(r.toks); i++ {
+		t := r.toks[i]
+		switch {
+		case t.kind == tkOp && t.text == "=" && r.at(r.next(i)).is("ANY") && r.at(r.next(r.next(i))).text == "(":
+			anyTok := r.next(i)
+			open := r.next(anyTok)
+			shut := r.closing(open)
+			r.toks[shut].text = ")))"
+			r.toks[open].text = "(SELECT value FROM json_each(pg_array_json("
+			r.toks[anyTok].text = ""
+			r.toks[i] = token{tkIdent, "IN"}
+		case t.kind == tkOp && t.text == "@>" && r.at(r.next(i)).is("ARRAY") && r.at(r.next(r.next(i))).text == "[":
+			xEnd := r.prev(i)
+			xStart := r.operandBefore(xEnd)
+			open := r.next(r.next(i))
+			shut := r.closing(open)
+			repl := append([]token{{tkIdent, "pg_array_contains"}, op("(")}, r.span(xStart, xEnd)...)
+			repl = append(repl, op(", "))
+			repl = append(repl, r.span(open+1, shut-1)...)
+			repl = append(repl, op(")"))
+			r.splice(xStart, shut, repl...)
+			i = xStart
+		}
+	}
+}
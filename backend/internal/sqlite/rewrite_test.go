@@ -0,0 +1,53 @@
+package sqlite
+
+import "testing"
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"placeholders",
+			`SELECT id FROM users WHERE id = $1 AND email = $12`,
+			`SELECT id FROM users WHERE id = ?1 AND email = ?12`},
+		{"for update",
+			`SELECT id FROM users WHERE id = $1 FOR UPDATE`,
+			`SELECT id FROM users WHERE id = ?1 `},
+		{"skip locked",
+			`SELECT id FROM orders WHERE user_id = $1 ORDER BY id FOR UPDATE OF orders SKIP LOCKED`,
+			`SELECT id FROM orders WHERE user_id = ?1 ORDER BY id `},
+		{"update alias",
+			`UPDATE users u SET balance = u.balance + $2 WHERE u.id = $1`,
+			`UPDATE users AS u SET balance = u.balance + ?2 WHERE u.id = ?1`},
+		{"any",
+			`SELECT symbol FROM portfolio WHERE symbol = ANY($1) AND user_id <> $2`,
+			`SELECT symbol FROM portfolio WHERE symbol IN (SELECT value FROM json_each(pg_array_json(?1))) AND user_id <> ?2`},
+		{"array contains",
+			`SELECT symbol FROM instrument_classifications WHERE indices @> ARRAY[$1]::TEXT[]`,
+			`SELECT symbol FROM instrument_classifications WHERE pg_array_contains(indices, ?1)`},
+		{"at time zone and date cast",
+			`SELECT (executed_at AT TIME ZONE $3)::date FROM trades`,
+			`SELECT date((pg_timezone(?3, executed_at))) FROM trades`},
+		{"casts",
+			`SELECT NULLIF($6, '')::date, $2::numeric(20,8), $3::text[]`,
+			`SELECT date(NULLIF(?6, '')), ?2, ?3`},
+		{"functions",
+			`SELECT GREATEST(a, 0), LEAST(b, 1), BOOL_OR(c), strpos(x, 'y') FROM t`,
+			`SELECT MAX(a, 0), MIN(b, 1), MAX(c), instr(x, 'y') FROM t`},
+		{"current time",
+			`UPDATE t SET at = CURRENT_TIMESTAMP, seen = NOW(), d = CURRENT_DATE`,
+			`UPDATE t SET at = ` + nowExpr + `, seen = ` + nowExpr + `, d = date('now')`},
+		{"distinct from",
+			`SELECT 1 FROM t WHERE a IS DISTINCT FROM $1 AND b IS NOT DISTINCT FROM $2`,
+			`SELECT 1 FROM t WHERE a IS NOT ?1 AND b IS ?2`},
+		{"literals and comments untouched",
+			`SELECT 'a::b $1 FOR UPDATE' FROM t -- $9 = ANY(x)`,
+			`SELECT 'a::b $1 FOR UPDATE' FROM t -- $9 = ANY(x)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := translate(tt.in); got != tt.want {
+				t.Errorf("translate(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
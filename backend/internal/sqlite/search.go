@@ -0,0 +1,350 @@
+package sqlite
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The trade note search runs PostgreSQL full-text search (websearch_to_tsquery,
+// ts_rank, ts_headline) on the fly over one user's trades; with SQLite it
+// calls websearch_rank and websearch_headline below instead. They follow the
+// same rules closely enough for a search box: websearch syntax, English stop
+// words dropped, words reduced to a simple stem, and the symbol, tags and
+// note weighted as PostgreSQL's A, B and C.
+
+var sectionWeights = [...]float64{1.0, 0.4, 0.2}
+
+// searchTerm is a word, or a quoted phrase of several, that a document must
+// (or, negated, must not) contain.
+type searchTerm struct {
+	stems  []string
+	negate bool
+}
+
+// searchQuery is an OR of ANDs, as websearch_to_tsquery builds: in
+// "a b or c", AND binds tighter, so it is (a & b) | c.
+type searchQuery [][]searchTerm
+
+func parseSearch(q string) searchQuery {
+	var query searchQuery
+	var group []searchTerm
+	negate := false
+	add := func(words []string) {
+		var stems []string
+		for _, w := range words {
+			if s := stem(w); s != "" {
+				stems = append(stems, s)
+			}
+		}
+		if len(stems) > 0 {
+			group = append(group, searchTerm{stems: stems, negate: negate})
+		}
+		negate = false
+	}
+	for i := 0; i < len(q); {
+		switch {
+		case q[i] == '"':
+			end := strings.IndexByte(q[i+1:], '"')
+			if end < 0 {
+				end = len(q) - i - 1
+			}
+			add(words(q[i+1 : i+1+end]))
+			i += end + 2
+		case q[i] == '-' && (i == 0 || unicode.IsSpace(rune(q[i-1]))):
+			negate = true
+			i++
+		case isWordByte(q, i):
+			j := i
+			for j < len(q) && isWordByte(q, j) {
+				j++
+			}
+			if w := q[i:j]; strings.EqualFold(w, "or") && len(group) > 0 {
+				query = append(query, group)
+				group = nil
+			} else {
+				add([]string{w})
+			}
+			i = j
+		default:
+			negate = false
+			i++
+		}
+	}
+	if len(group) > 0 {
+		query = append(query, group)
+	}
+	return query
+}
+
+func isWordByte(s string, i int) bool {
+	r := rune(s[i])
+	return r >= 0x80 || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordSpan is a word's byte range in the text it came from.
+type wordSpan struct{ start, end int }
+
+func wordSpans(s string) []wordSpan {
+	var spans []wordSpan
+	for i := 0; i < len(s); {
+		if !isWordByte(s, i) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && isWordByte(s, j) {
+			j++
+		}
+		spans = append(spans, wordSpan{i, j})
+		i = j
+	}
+	return spans
+}
+
+func words(s string) []string {
+	spans := wordSpans(s)
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = s[sp.start:sp.end]
+	}
+	return out
+}
+
+// stems returns the stem of each word in s, "" for a stop word.
+func stems(s string) []string {
+	ws := words(s)
+	out := make([]string, len(ws))
+	for i, w := range ws {
+		out[i] = stem(w)
+	}
+	return out
+}
+
+// occurrences counts the times doc, the stems of one section, contains t.
+func (t searchTerm) occurrences(doc []string) int {
+	n := 0
+	for i := 0; i+len(t.stems) <= len(doc); i++ {
+		if slices.Equal(doc[i:i+len(t.stems)], t.stems) {
+			n++
+		}
+	}
+	return n
+}
+
+// score returns how well the sections match q, 0 when they don't.
+func (q searchQuery) score(sections ...[]string) float64 {
+	best := 0.0
+	for _, group := range q {
+		score, ok := 0.0, true
+		for _, t := range group {
+			found := false
+			for i, sec := range sections {
+				if n := t.occurrences(sec); n > 0 {
+					found = true
+					score += float64(n) * sectionWeights[i]
+				}
+			}
+			if found == t.negate {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			// A group of only negated terms matches with no hits to weigh.
+			best = max(best, score, 1e-6)
+		}
+	}
+	return best
+}
+
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// websearchRank ranks a trade against query over its symbol, tags (in
+// PostgreSQL array form) and note; 0 means it doesn't match.
+func websearchRank(query string, symbol, tags, note any) float64 {
+	q := parseSearch(query)
+	if len(q) == 0 {
+		return 0
+	}
+	tagList, _ := parseArray(tags)
+	return q.score(stems(text(symbol)), stems(strings.Join(tagList, " ")), stems(text(note)))
+}
+
+// websearchHeadline is ts_headline over a note: the excerpts around the
+// first matches, each matching word wrapped in StartSel and StopSel from
+// options, or "" when the note alone doesn't match.
+func websearchHeadline(query string, note any, options string) string {
+	q := parseSearch(query)
+	s := text(note)
+	if len(q) == 0 || q.score(stems(s)) == 0 {
+		return ""
+	}
+	opts := headlineOptions(options)
+	startSel, stopSel := opts.get("startsel", "<b>"), opts.get("stopsel", "</b>")
+	maxWords, _ := strconv.Atoi(opts.get("maxwords", "35"))
+	maxFragments, _ := strconv.Atoi(opts.get("maxfragments", "0"))
+	delimiter := opts.get("fragmentdelimiter", " ... ")
+
+	wanted := map[string]bool{}
+	for _, group := range q {
+		for _, t := range group {
+			if !t.negate {
+				for _, st := range t.stems {
+					wanted[st] = true
+				}
+			}
+		}
+	}
+	spans := wordSpans(s)
+	var hits []int
+	for i, sp := range spans {
+		if st := stem(s[sp.start:sp.end]); st != "" && wanted[st] {
+			hits = append(hits, i)
+		}
+	}
+	if len(spans) == 0 || maxWords <= 0 {
+		return ""
+	}
+
+	// Each fragment is a window of up to maxWords words around a hit; with
+	// MaxFragments=0 there is one, from the start of the note.
+	type window struct{ from, to int }
+	var windows []window
+	if maxFragments <= 0 || len(hits) == 0 {
+		windows = []window{{0, min(maxWords, len(spans))}}
+	} else {
+		for _, h := range hits {
+			if len(windows) == maxFragments {
+				break
+			}
+			if n := len(windows); n > 0 && h < windows[n-1].to {
+				continue
+			}
+			from := max(0, h-maxWords/2)
+			if n := len(windows); n > 0 {
+				from = max(from, windows[n-1].to)
+			}
+			windows = append(windows, window{from, min(len(spans), from+maxWords)})
+		}
+	}
+
+	isHit := map[int]bool{}
+	for _, h := range hits {
+		isHit[h] = true
+	}
+	var b strings.Builder
+	for wi, w := range windows {
+		if wi > 0 {
+			b.WriteString(delimiter)
+		}
+		pos := spans[w.from].start
+		for i := w.from; i < w.to; i++ {
+			sp := spans[i]
+			b.WriteString(s[pos:sp.start])
+			if isHit[i] {
+				b.WriteString(startSel + s[sp.start:sp.end] + stopSel)
+			} else {
+				b.WriteString(s[sp.start:sp.end])
+			}
+			pos = sp.end
+		}
+	}
+	return b.String()
+}
+
+type headlineOpts map[string]string
+
+func (o headlineOpts) get(key, def string) string {
+	if v, ok := o[key]; ok {
+		return v
+	}
+	return def
+}
+
+// headlineOptions parses ts_headline's options string, e.g.
+// StartSel="<mark>", StopSel="</mark>", MaxWords=20. Keys are folded to
+// lower case.
+func headlineOptions(s string) headlineOpts {
+	opts := headlineOpts{}
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.Trim(key, " ,"))
+		rest = strings.TrimLeft(rest, " ")
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			value, s = rest[1:1+end], rest[min(len(rest), end+2):]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value, s = strings.TrimSpace(rest[:end]), rest[end:]
+		}
+		opts[key] = value
+		s = strings.TrimLeft(s, " ,")
+	}
+	return opts
+}
+
+// stopWords are the most common English stop words, which PostgreSQL's
+// english configuration leaves out of documents and queries alike.
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`a about above after again against all am an and any are as at be
+		because been before being below between both but by can did do does doing down during each few
+		for from further had has have having he her here hers herself him himself his how i if in into is
+		it its itself just me more most my myself no nor not now of off on once only or other our ours
+		ourselves out over own same she should so some such than that the their theirs them themselves
+		then there these they this those through to too under until up very was we were what when where
+		which while who whom why will with you your yours yourself yourselves`) {
+		stopWords[w] = true
+	}
+}
+
+// stem lower-cases w and strips common English inflections (plurals, -ed,
+// -ing, a final y), so "earnings" matches "earning" and "earned". It
+// returns "" for a stop word.
+func stem(w string) string {
+	w = strings.ToLower(w)
+	if stopWords[w] {
+		return ""
+	}
+	hasVowel := func(s string) bool { return strings.ContainsAny(s, "aeiouy") }
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"), strings.HasSuffix(w, "is"):
+	case strings.HasSuffix(w, "s") && len(w) > 3:
+		w = w[:len(w)-1]
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if base, ok := strings.CutSuffix(w, suffix); ok && len(base) >= 3 && hasVowel(base) {
+			w = base
+			break
+		}
+	}
+	if base, ok := strings.CutSuffix(w, "y"); ok && len(base) >= 3 && hasVowel(base) {
+		w = base + "i"
+	}
+	return w
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import "testing"
+
+func TestWebsearchRank(t *testing.T) {
+	tests := []struct {
+		query, symbol, tags, note string
+		match                     bool
+	}{
+		{"earnings", "NVDA", "{swing}", "Bought ahead of the earnings call", true},
+		{"earning", "NVDA", "{}", "Earnings beat", true},
+		{"nvda", "NVDA", "{}", "", true},
+		{`"earnings call"`, "NVDA", "{}", "the call after earnings", false},
+		{"earnings -swing", "NVDA", "{swing}", "earnings", false},
+		{"earnings or dividend", "KO", "{}", "dividend raised", true},
+		{"BTC-USD", "BTC-USD", "{}", "", true},
+		{"the", "NVDA", "{}", "the", false},
+	}
+	for _, tt := range tests {
+		if got := websearchRank(tt.query, tt.symbol, tt.tags, tt.note) > 0; got != tt.match {
+			t.Errorf("websearchRank(%q) over %s %s %q matched = %v, want %v", tt.query, tt.symbol, tt.tags, tt.note, got, tt.match)
+		}
+	}
+
+	symbol := websearchRank("nvda", "NVDA", "{}", "")
+	note := websearchRank("nvda", "AMD", "{}", "sympathy with NVDA")
+	if symbol <= note {
+		t.Errorf("a symbol match should outrank a note match: %v <= %v", symbol, note)
+	}
+}
+
+func TestWebsearchHeadline(t *testing.T) {
+	got := websearchHeadline("earnings", "Bought ahead of the earnings call", `StartSel="<b>", StopSel="</b>", MaxFragments=2, MaxWords=20`)
+	if want := "Bought ahead of the <b>earnings</b> call"; got != want {
+		t.Errorf("headline = %q, want %q", got, want)
+	}
+	if got := websearchHeadline("nvda", "no mention here", `StartSel=<b>, StopSel=</b>`); got != "" {
+		t.Errorf("headline without a note match = %q, want empty", got)
+	}
+}
@@ -0,0 +1,82 @@
+// Package sqlite lets the server keep its data in a single SQLite file
+// instead of PostgreSQL, for standalone self-hosting.
+//
+// The stores in internal/data are written for PostgreSQL. Rather than keep a
+// second copy of every query, the driver registered here rewrites the
+// PostgreSQL forms the stores use ($1 placeholders, casts, = ANY(array),
+// FOR UPDATE, AT TIME ZONE, ...) into SQLite as each statement is prepared,
+// provides the PostgreSQL functions they call (set_config, current_setting)
+// and reports constraint violations as *pq.Error with PostgreSQL's codes and
+// constraint names, so the stores' error handling works unchanged. The few
+// statements that have no SQLite translation (data-modifying CTEs, LATERAL,
+// full-text search) have SQLite variants in the stores themselves.
+//
+// Values follow lib/pq's conventions: decimals are stored as numbers and
+// read back as text rounded to the column's scale, times are stored as UTC
+// text, and PostgreSQL arrays keep their {a,b} text form.
+//
+// SQLite support needs cgo; a binary built with CGO_ENABLED=0 can still be
+// pointed at PostgreSQL but fails to open a SQLite database.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DriverName is the database/sql driver name this package registers.
+const DriverName = "papertrader-sqlite"
+
+// Scheme is the prefix of a DATABASE_URL that names a SQLite file, as in
+// sqlite:papertrader.db or sqlite:///var/lib/papertrader/data.db.
+const Scheme = "sqlite:"
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// IsURL reports whether databaseURL names a SQLite file.
+func IsURL(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, Scheme)
+}
+
+// Path returns the file path a sqlite: URL names.
+func Path(databaseURL string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(databaseURL, Scheme), "//")
+}
+
+// Open opens (creating if needed) the SQLite database at path. Foreign keys
+// are enforced, the journal is in WAL mode so readers don't block the
+// writer, and every transaction takes the write lock when it begins, which
+// stands in for PostgreSQL's row locks: two transactions can't both read a
+// balance and then write it.
+func Open(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite: empty database path")
+	}
+	params := url.Values{}
+	params.Set("_foreign_keys", "1")
+	params.Set("_journal_mode", "WAL")
+	params.Set("_txlock", "immediate")
+	params.Set("_busy_timeout", "5000")
+	db, err := sql.Open(DriverName, "file:"+path+"?"+params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: open %s: %w", path, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite: open %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// Is reports whether db was opened by this package.
+func Is(db *sql.DB) bool {
+	if db == nil {
+		return false
+	}
+	_, ok := db.Driver().(*Driver)
+	return ok
+}
@@ -0,0 +1,281 @@
+//go:build cgo
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"papertrader/internal/data"
+	"papertrader/internal/migrations"
+	"papertrader/internal/service"
+	"papertrader/internal/sqlite"
+)
+
+// openDB opens a migrated database in a temporary file and switches the
+// stores to SQLite for the test.
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "papertrader.db"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if !sqlite.Is(db) {
+		t.Fatal("Is should report a database opened by Open")
+	}
+	if err := migrations.Run(db); err != nil {
+		t.Fatalf("migrations.Run: %v", err)
+	}
+	data.UseSQLite(true)
+	t.Cleanup(func() { data.UseSQLite(false) })
+	return db
+}
+
+func newGuest(t *testing.T, users *data.UserStore) *data.User {
+	t.Helper()
+	u, err := users.CreateGuestUser(context.Background(), time.Now().Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("CreateGuestUser: %v", err)
+	}
+	return u
+}
+
+type fixedMarket struct{ price decimal.Decimal }
+
+func (m fixedMarket) GetStock(_ context.Context, symbol string) (*service.StockData, error) {
+	return &service.StockData{Symbol: symbol, Date: time.Now().UTC().Format(time.DateOnly), Price: m.price}, nil
+}
+
+func (m fixedMarket) GetBatchHistoricalData(context.Context, []string) (map[string]*service.HistoricalData, error) {
+	return nil, nil
+}
+
+func TestMigrations(t *testing.T) {
+	db := openDB(t)
+	if v, dirty, err := migrations.Version(db); err != nil || dirty || v != 1 {
+		t.Fatalf("Version = %d, %v, %v; want 1, clean", v, dirty, err)
+	}
+	if err := migrations.Down(db); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if err := migrations.Run(db); err != nil {
+		t.Fatalf("Run after Down: %v", err)
+	}
+}
+
+func TestTrading(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	users := data.NewUserStore(db)
+	user := newGuest(t, users)
+
+	lists, err := data.NewCuratedListStore(db).ListSubscribed(ctx, user.ID)
+	if err != nil || len(lists) != 1 || lists[0].Slug != "getting-started" {
+		t.Fatalf("new users should follow the default list, got %+v, %v", lists, err)
+	}
+
+	svc := service.NewInvestmentService(db, fixedMarket{decimal.RequireFromString("100.25")},
+		data.NewPortfolioStore(db), data.NewTradesStore(db))
+	if _, err := svc.BuyStock(ctx, user.ID, "AAPL", decimal.NewFromInt(3), "buy-1"); err != nil {
+		t.Fatalf("BuyStock: %v", err)
+	}
+	if _, err := svc.BuyStock(ctx, user.ID, "AAPL", decimal.NewFromInt(3), "buy-1"); err != nil {
+		t.Fatalf("BuyStock replay: %v", err)
+	}
+	if _, err := svc.SellStock(ctx, user.ID, "AAPL", decimal.NewFromInt(1), ""); err != nil {
+		t.Fatalf("SellStock: %v", err)
+	}
+
+	balance, err := users.GetBalance(ctx, user.ID)
+	if err != nil || !balance.Equal(decimal.RequireFromString("9799.50")) {
+		t.Errorf("balance = %s, %v; want 9799.50", balance, err)
+	}
+	holding, err := data.NewPortfolioStore(db).GetPortfolioBySymbol(ctx, user.ID, "AAPL")
+	if err != nil || !holding.Quantity.Equal(decimal.NewFromInt(2)) || !holding.AvgPrice.Equal(decimal.RequireFromString("100.25")) {
+		t.Errorf("holding = %+v, %v; want 2 at 100.25", holding, err)
+	}
+
+	if _, err := db.Exec(`UPDATE trades SET price = 1 WHERE user_id = $1`, user.ID); err == nil || !strings.Contains(err.Error(), "append-only") {
+		t.Errorf("updating a trade should be refused, got %v", err)
+	}
+	if _, err := db.Exec(`DELETE FROM trades WHERE user_id = $1`, user.ID); err == nil || !strings.Contains(err.Error(), "append-only") {
+		t.Errorf("deleting a trade should be refused, got %v", err)
+	}
+
+	if err := users.ResetAccount(ctx, user.ID); err != nil {
+		t.Fatalf("ResetAccount: %v", err)
+	}
+	var trades int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM trades WHERE user_id = $1`, user.ID).Scan(&trades); err != nil || trades != 0 {
+		t.Errorf("trades after reset = %d, %v; want 0", trades, err)
+	}
+}
+
+func TestBracketExits(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	user := newGuest(t, data.NewUserStore(db))
+	orders := data.NewOrderStore(db)
+
+	bracket := func() (entry, exit *data.Order) {
+		t.Helper()
+		stop := decimal.NewFromInt(90)
+		entry, err := orders.Create(ctx, &data.Order{UserID: user.ID, Symbol: "AAPL", Side: data.OrderSideBuy,
+			OrderType: "MARKET", Quantity: decimal.NewFromInt(1)})
+		if err != nil {
+			t.Fatalf("Create entry: %v", err)
+		}
+		exit, err = orders.Create(ctx, &data.Order{UserID: user.ID, Symbol: "AAPL", Side: data.OrderSideSell,
+			OrderType: "STOP_LOSS", Quantity: decimal.NewFromInt(1), TriggerPrice: &stop, ParentOrderID: entry.ID})
+		if err != nil {
+			t.Fatalf("Create exit: %v", err)
+		}
+		return entry, exit
+	}
+	status := func(o *data.Order) string {
+		t.Helper()
+		got, err := orders.Get(ctx, user.ID, o.ID)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		return got.Status
+	}
+
+	entry, exit := bracket()
+	if err := orders.MarkFilled(ctx, entry.ID, "trade-1", decimal.NewFromInt(100)); err != nil {
+		t.Fatalf("MarkFilled: %v", err)
+	}
+	if got := status(exit); got != "PENDING" {
+		t.Errorf("exit of a filled entry = %s, want PENDING", got)
+	}
+
+	entry, exit = bracket()
+	if _, err := orders.Cancel(ctx, user.ID, entry.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if got := status(exit); got != "CANCELLED" {
+		t.Errorf("exit of a cancelled entry = %s, want CANCELLED", got)
+	}
+	if _, err := orders.Cancel(ctx, user.ID, entry.ID); !errors.Is(err, data.ErrOrderNotPending) {
+		t.Errorf("cancelling twice = %v, want ErrOrderNotPending", err)
+	}
+}
+
+func TestUserUpdates(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	users := data.NewUserStore(db)
+	alice, bob := newGuest(t, users), newGuest(t, users)
+	now := time.Now()
+
+	if _, err := users.SetUsername(ctx, alice.ID, "Alice", now, now); err != nil {
+		t.Fatalf("SetUsername: %v", err)
+	}
+	if _, err := users.SetUsername(ctx, bob.ID, "alice", now, now); !errors.Is(err, data.ErrUsernameTaken) {
+		t.Errorf("a case-insensitive clash = %v, want ErrUsernameTaken", err)
+	}
+	if _, err := users.SetUsername(ctx, alice.ID, "Alicia", now.Add(-time.Hour), now); err != nil {
+		t.Fatalf("replacing the first username: %v", err)
+	}
+	if _, err := users.SetUsername(ctx, alice.ID, "Ali", now.Add(-time.Hour), now); !errors.Is(err, data.ErrUsernameCooldown) {
+		t.Errorf("a second change within the cooldown = %v, want ErrUsernameCooldown", err)
+	}
+
+	if prev, err := users.SetAvatar(ctx, alice.ID, "avatars/1", "https://cdn/1"); err != nil || prev != "" {
+		t.Errorf("first SetAvatar = %q, %v", prev, err)
+	}
+	if prev, err := users.SetAvatar(ctx, alice.ID, "avatars/2", "https://cdn/2"); err != nil || prev != "avatars/1" {
+		t.Errorf("second SetAvatar = %q, %v; want avatars/1", prev, err)
+	}
+
+	bonus := data.NewBonusStore(db)
+	if balance, _, err := bonus.Grant(ctx, alice.ID, decimal.NewFromInt(500), now.Add(-24*time.Hour), now); err != nil ||
+		!balance.Equal(decimal.NewFromInt(10500)) {
+		t.Errorf("Grant = %s, %v; want 10500", balance, err)
+	}
+	if _, at, err := bonus.Grant(ctx, alice.ID, decimal.NewFromInt(500), now.Add(-24*time.Hour), now); !errors.Is(err, data.ErrBonusCooldown) ||
+		!at.Equal(now.UTC().Truncate(time.Millisecond)) {
+		t.Errorf("second Grant = %v, %v; want ErrBonusCooldown at %v", at, err, now)
+	}
+
+	instruments := data.NewInstrumentStore(db)
+	for i, want := range []bool{true, false} {
+		if changed, err := instruments.SetHalt(ctx, "AAPL", "news pending", "admin"); err != nil || changed != want {
+			t.Errorf("SetHalt #%d = %v, %v; want %v", i+1, changed, err, want)
+		}
+	}
+
+	deleted, err := users.DeleteAccount(ctx, alice.ID)
+	if err != nil || deleted.AvatarKey != "avatars/2" {
+		t.Fatalf("DeleteAccount = %+v, %v", deleted, err)
+	}
+	if _, err := users.GetUserByID(ctx, alice.ID); err == nil {
+		t.Error("the deleted user should be gone")
+	}
+	if n, err := users.DeleteExpiredGuests(ctx, now.Add(2*time.Hour), 10); err != nil || n != 1 {
+		t.Errorf("DeleteExpiredGuests = %d, %v; want 1", n, err)
+	}
+}
+
+func TestPortfolioHistory(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	user := newGuest(t, data.NewUserStore(db))
+	if err := data.NewPortfolioStore(db).UpdatePortfolioWithBuy(ctx, user.ID, "AAPL", decimal.NewFromInt(4), decimal.NewFromInt(100)); err != nil {
+		t.Fatalf("UpdatePortfolioWithBuy: %v", err)
+	}
+
+	history := data.NewPortfolioHistoryStore(db)
+	close := time.Now().Add(time.Minute)
+	prices := map[string]decimal.Decimal{"AAPL": decimal.RequireFromString("110.5")}
+	if n, err := history.Record(ctx, close, prices); err != nil || n != 1 {
+		t.Fatalf("Record = %d, %v; want 1", n, err)
+	}
+	if n, err := history.Record(ctx, close, prices); err != nil || n != 0 {
+		t.Errorf("Record rerun = %d, %v; want 0", n, err)
+	}
+	if n, err := history.Revalue(ctx, close, []string{"AAPL"},
+		[]decimal.Decimal{decimal.RequireFromString("110.5")}, []decimal.Decimal{decimal.NewFromInt(111)}); err != nil || n != 1 {
+		t.Errorf("Revalue = %d, %v; want 1", n, err)
+	}
+
+	snap, err := history.Latest(ctx, user.ID)
+	if err != nil || snap == nil {
+		t.Fatalf("Latest = %+v, %v", snap, err)
+	}
+	if snap.Date != close.UTC().Format(time.DateOnly) || !snap.HoldingsValue.Equal(decimal.NewFromInt(444)) || snap.Partial {
+		t.Errorf("snapshot = %+v; want 444 of holdings on %s", snap, close.UTC().Format(time.DateOnly))
+	}
+}
+
+func TestEODRunClaim(t *testing.T) {
+	db := openDB(t)
+	ctx := context.Background()
+	runs := data.NewEODRunStore(db)
+	session := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	if ok, err := runs.Claim(ctx, session, time.Hour); err != nil || !ok {
+		t.Fatalf("first Claim = %v, %v; want true", ok, err)
+	}
+	if ok, err := runs.Claim(ctx, session, time.Hour); err != nil || ok {
+		t.Errorf("a fresh claim should hold, got %v, %v", ok, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, err := runs.Claim(ctx, session, time.Millisecond); err != nil || !ok {
+		t.Errorf("a stale claim should be taken over, got %v, %v", ok, err)
+	}
+	if err := runs.Complete(ctx, &data.EODRun{SessionDate: session, Symbols: 3, Closes: 3}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if ok, err := runs.Claim(ctx, session, 0); err != nil || ok {
+		t.Errorf("a completed run should not be claimed, got %v, %v", ok, err)
+	}
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Redacted replaces secrets in logged URLs and provider error bodies.
+const Redacted = "REDACTED"
+
+// secretParams are query parameters that carry credentials: MarketStack's
+// access_key, the generic API key names, and the signature and credential of
+// an S3 presigned URL. Matched case-insensitively.
+var secretParams = map[string]bool{
+	"access_key":       true,
+	"api_key":          true,
+	"apikey":           true,
+	"key":              true,
+	"token":            true,
+	"x-amz-credential": true,
+	"x-amz-signature":  true,
+}
+
+// ClientWithTimeout returns a copy of client whose timeout is at most
+// timeout. The copy keeps client's transport, so it shares the connection
+// pool. A nil client gives a standalone client, which is what tests use.
+func ClientWithTimeout(client *http.Client, timeout time.Duration) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	c := *client
+	if c.Timeout == 0 || timeout < c.Timeout {
+		c.Timeout = timeout
+	}
+	return &c
+}
+
+// RedactURL renders u for logs and errors with credential query parameters
+// and any userinfo password replaced by Redacted.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	if c.User != nil {
+		if _, ok := c.User.Password(); ok {
+			c.User = url.UserPassword(c.User.Username(), Redacted)
+		}
+	}
+	if c.RawQuery != "" {
+		q := c.Query()
+		for k := range q {
+			if secretParams[strings.ToLower(k)] {
+				q[k] = []string{Redacted}
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// LoggingTransport wraps next so that every outbound request is logged with
+// its method, host, redacted URL, status and duration. Transport failures and
+// 5xx responses are logged as warnings.
+func LoggingTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return loggingTransport{next: next}
+}
+
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	args := []any{
+		"method", req.Method,
+		"host", req.URL.Host,
+		"url", RedactURL(req.URL),
+		"duration_ms", time.Since(start).Milliseconds(),
+		"component", "outbound",
+	}
+	switch {
+	case err != nil:
+		slog.Warn("outbound request failed", append(args, "err", err)...)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		slog.Warn("outbound request", append(args, "status", resp.StatusCode)...)
+	default:
+		slog.Info("outbound request", append(args, "status", resp.StatusCode)...)
+	}
+	return resp, err
+}
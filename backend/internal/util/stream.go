@@ -0,0 +1,134 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrStreamFull is returned by Stream.Row once the stream's item cap is
+// reached. The caller should stop producing items; the response is cut
+// there and marked truncated.
+var ErrStreamFull = errors.New("stream item limit reached")
+
+// streamWriteTimeout is how long each flush of a stream may take to reach
+// the client before the connection is dropped. It is extended at every
+// flush, so a long stream that keeps moving is never cut off by the
+// server's overall write timeout.
+const streamWriteTimeout = 30 * time.Second
+
+// Stream counts the items of a response written as it is produced, flushing
+// every flushEvery items so they reach the client instead of piling up in
+// buffers, and refusing more than maxItems so one request can't hold the
+// instance's memory or a connection indefinitely. A route using it should
+// be mounted with middleware.Streamed, or its flushes are buffered.
+type Stream struct {
+	rc         *http.ResponseController
+	flushEvery int
+	maxItems   int
+	items      int
+	truncated  bool
+}
+
+func NewStream(w http.ResponseWriter, flushEvery, maxItems int) *Stream {
+	return &Stream{rc: http.NewResponseController(w), flushEvery: flushEvery, maxItems: maxItems}
+}
+
+// Row records that one more item is about to be written. It returns
+// ErrStreamFull, and marks the stream truncated, instead once maxItems have
+// been written.
+func (s *Stream) Row() error {
+	if s.maxItems > 0 && s.items >= s.maxItems {
+		s.truncated = true
+		return ErrStreamFull
+	}
+	s.items++
+	return nil
+}
+
+// Wrote flushes if the last item completed a batch of flushEvery. before,
+// if set, runs first, to flush an encoder's own buffer.
+func (s *Stream) Wrote(before func()) {
+	if s.flushEvery <= 0 || s.items%s.flushEvery != 0 {
+		return
+	}
+	if before != nil {
+		before()
+	}
+	s.Flush()
+}
+
+// Flush sends what has been written so far and gives the next batch a
+// fresh write deadline. Writers that can't flush are left to buffer.
+func (s *Stream) Flush() {
+	s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	s.rc.Flush()
+}
+
+// Items is how many items have been written.
+func (s *Stream) Items() int { return s.items }
+
+// Truncated reports whether items were refused by the cap.
+func (s *Stream) Truncated() bool { return s.truncated }
+
+// JSONStream writes a list response one item at a time in the standard
+// envelope, {"success": true, "data": [...], "count": n, "truncated": b},
+// without holding the list in memory. Nothing is sent until the first
+// item or Close, so an error before then can still get its own status.
+type JSONStream struct {
+	*Stream
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	started bool
+}
+
+func NewJSONStream(w http.ResponseWriter, flushEvery, maxItems int) *JSONStream {
+	return &JSONStream{Stream: NewStream(w, flushEvery, maxItems), w: w, enc: json.NewEncoder(w)}
+}
+
+// Started reports whether the response has been sent, after which an error
+// can only cut the list short.
+func (s *JSONStream) Started() bool { return s.started }
+
+func (s *JSONStream) start() {
+	s.started = true
+	s.w.Header().Set("Content-Type", "application/json")
+	s.w.WriteHeader(http.StatusOK)
+	io.WriteString(s.w, `{"success":true,"data":[`)
+}
+
+// Write appends v to the list. It returns ErrStreamFull once the cap is
+// reached, and any error writing to the client.
+func (s *JSONStream) Write(v any) error {
+	if err := s.Row(); err != nil {
+		return err
+	}
+	if !s.started {
+		s.start()
+	} else if _, err := io.WriteString(s.w, ","); err != nil {
+		return err
+	}
+	// Encode ends each value with a newline, which JSON allows between
+	// elements and which keeps a long list readable line by line.
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+	s.Wrote(nil)
+	return nil
+}
+
+// Close ends the list and the envelope.
+func (s *JSONStream) Close() error {
+	if !s.started {
+		s.start()
+	}
+	tail, _ := json.Marshal(struct {
+		Count     int  `json:"count"`
+		Truncated bool `json:"truncated"`
+	}{s.Items(), s.Truncated()})
+	// tail is {"count":..}; splice its fields after the list.
+	_, err := io.WriteString(s.w, "],"+string(tail[1:]))
+	return err
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestJSONStream_CapAndEnvelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	s := NewJSONStream(w, 2, 3)
+	for i := 1; i <= 4; i++ {
+		err := s.Write(map[string]int{"n": i})
+		if i <= 3 && err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if i == 4 && !errors.Is(err, ErrStreamFull) {
+			t.Fatalf("item past the cap: got %v, want ErrStreamFull", err)
+		}
+	}
+	if !w.Flushed {
+		t.Error("expected a flush after the second item")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var got struct {
+		Success   bool             `json:"success"`
+		Data      []map[string]int `json:"data"`
+		Count     int              `json:"count"`
+		Truncated bool             `json:"truncated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("body is not JSON: %v\n%s", err, w.Body.String())
+	}
+	if !got.Success || len(got.Data) != 3 || got.Data[2]["n"] != 3 || got.Count != 3 || !got.Truncated {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestJSONStream_Empty(t *testing.T) {
+	w := httptest.NewRecorder()
+	s := NewJSONStream(w, 10, 0)
+	if s.Started() {
+		t.Fatal("nothing should be sent before the first item")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if want := `{"success":true,"data":[],"count":0,"truncated":false}`; w.Body.String() != want {
+		t.Errorf("body %s, want %s", w.Body.String(), want)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type %q", ct)
+	}
+}
@@ -4,16 +4,53 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-)
 
-// Validation constants
-const (
-	MinQuantity = 1
-	MaxQuantity = 1000000 // 1 million shares - reasonable upper limit
+	"github.com/shopspring/decimal"
 )
 
-// Stock symbol validation regex: 1-10 uppercase letters, optionally followed by . and 1-2 uppercase letters (for class shares)
-var symbolRegex = regexp.MustCompile(`^[A-Z]{1,10}(\.[A-Z]{1,2})?$`)
+// MinQuantity is the smallest order size in whole shares. The upper bound is
+// configurable (config.TradingConfig.MaxQuantity) and passed to
+// ValidateQuantity.
+const MinQuantity = 1
+
+// QuantityDecimals is the most decimal places a fractional (crypto) order
+// size may have.
+const QuantityDecimals = 8
+
+// Stock and ETF symbol validation regex: an uppercase letter then up to 9 more letters or digits (AAPL, QQQ, CSP1.XLON),
+// optionally followed by . and 1-2 uppercase letters (for class shares), then for a non-US listing . and its
+// exchange's MIC
+var symbolRegex = regexp.MustCompile(`^[A-Z][A-Z0-9]{0,9}(\.[A-Z]{1,2})?(\.(XLON|XTSE))?$`)
+
+// Index symbol regex: a caret then 1-10 uppercase letters or digits (^GSPC, ^DJI, ^N225)
+var indexSymbolRegex = regexp.MustCompile(`^\^[A-Z0-9]{1,10}$`)
+
+// IsIndexSymbol reports whether symbol names a market index such as ^GSPC.
+// Indexes have a level that can be quoted and charted but are not traded.
+func IsIndexSymbol(symbol string) bool {
+	return indexSymbolRegex.MatchString(symbol)
+}
+
+// exchangeSuffixRegex captures the exchange MIC a non-US listing ends in.
+var exchangeSuffixRegex = regexp.MustCompile(`\.(XLON|XTSE)$`)
+
+// SymbolExchange returns the MIC suffix of a non-US listing such as
+// VOD.XLON (London) or SHOP.XTSE (Toronto), or "" for a US listing.
+func SymbolExchange(symbol string) string {
+	if m := exchangeSuffixRegex.FindStringSubmatch(symbol); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Crypto pair regex: a 2-6 character base asset quoted in US dollars (e.g. BTC-USD)
+var cryptoPairRegex = regexp.MustCompile(`^[A-Z0-9]{2,6}-USD$`)
+
+// IsCryptoPair reports whether symbol is a crypto pair such as BTC-USD rather
+// than a stock symbol. Crypto trades around the clock in fractional units.
+func IsCryptoPair(symbol string) bool {
+	return cryptoPairRegex.MatchString(symbol)
+}
 
 // ValidationError represents a validation failure
 type ValidationError struct {
@@ -28,18 +65,36 @@ func (e *ValidationError) Error() string {
 	return e.Message
 }
 
-// ValidateQuantity validates that a quantity is within acceptable bounds
-func ValidateQuantity(quantity int) error {
-	if quantity < MinQuantity {
-		return &ValidationError{
-			Field:   "quantity",
-			Message: fmt.Sprintf("quantity must be at least %d", MinQuantity),
+// ValidateQuantity validates an order size. Whole-share instruments need a
+// whole number within [MinQuantity, maxQuantity]; fractional ones (crypto)
+// take any positive amount of at most QuantityDecimals places, up to
+// maxQuantity. maxQuantity <= 0 means no upper bound.
+func ValidateQuantity(quantity decimal.Decimal, maxQuantity int, fractional bool) error {
+	if fractional {
+		if !quantity.IsPositive() {
+			return &ValidationError{Field: "quantity", Message: "quantity must be positive"}
+		}
+		if !quantity.Equal(quantity.Truncate(QuantityDecimals)) {
+			return &ValidationError{
+				Field:   "quantity",
+				Message: fmt.Sprintf("quantity can have at most %d decimal places", QuantityDecimals),
+			}
+		}
+	} else {
+		if !quantity.IsInteger() {
+			return &ValidationError{Field: "quantity", Message: "quantity must be a whole number of shares"}
+		}
+		if quantity.LessThan(decimal.NewFromInt(MinQuantity)) {
+			return &ValidationError{
+				Field:   "quantity",
+				Message: fmt.Sprintf("quantity must be at least %d", MinQuantity),
+			}
 		}
 	}
-	if quantity > MaxQuantity {
+	if maxQuantity > 0 && quantity.GreaterThan(decimal.NewFromInt(int64(maxQuantity))) {
 		return &ValidationError{
 			Field:   "quantity",
-			Message: fmt.Sprintf("quantity cannot exceed %d", MaxQuantity),
+			Message: fmt.Sprintf("quantity cannot exceed %d", maxQuantity),
 		}
 	}
 	return nil
@@ -64,8 +119,9 @@ func SanitizeString(input string) string {
 	return strings.TrimSpace(result.String())
 }
 
-// ValidateSymbol validates and sanitizes a stock symbol
-// Returns the sanitized uppercase symbol or an error
+// ValidateSymbol validates and sanitizes a tradable symbol: a stock, ETF or
+// crypto pair. Returns the sanitized uppercase symbol or an error; an index
+// gets an error saying it can only be quoted.
 func ValidateSymbol(symbol string) (string, error) {
 	// Sanitize first
 	symbol = SanitizeString(symbol)
@@ -73,13 +129,31 @@ func ValidateSymbol(symbol string) (string, error) {
 	// Convert to uppercase
 	symbol = strings.ToUpper(symbol)
 
-	// Validate format: 1-10 uppercase letters, optionally followed by . and 1-2 uppercase letters
-	if !symbolRegex.MatchString(symbol) {
+	if IsIndexSymbol(symbol) {
 		return "", &ValidationError{
 			Field:   "symbol",
-			Message: "invalid stock symbol format. Must be 1-10 uppercase letters, optionally followed by . and 1-2 letters (e.g., AAPL, BRK.B)",
+			Message: fmt.Sprintf("%s is an index: its level can be quoted but it cannot be traded; trade an ETF that tracks it instead", symbol),
+		}
+	}
+
+	// Validate format: a letter then up to 9 letters or digits, optionally followed by . and 1-2 uppercase letters
+	// and an exchange suffix, or a crypto pair
+	if !symbolRegex.MatchString(symbol) && !IsCryptoPair(symbol) {
+		return "", &ValidationError{
+			Field:   "symbol",
+			Message: "invalid stock symbol format. Must be 1-10 uppercase letters or digits starting with a letter, optionally followed by . and 1-2 letters (e.g., AAPL, QQQ, BRK.B) and, outside the US, . and the exchange (VOD.XLON, SHOP.XTSE), or a crypto pair (e.g., BTC-USD)",
 		}
 	}
 
 	return symbol, nil
 }
+
+// ValidateQuoteSymbol is ValidateSymbol for symbols that are only looked
+// at, not traded: it accepts index symbols (^GSPC) as well.
+func ValidateQuoteSymbol(symbol string) (string, error) {
+	upper := strings.ToUpper(SanitizeString(symbol))
+	if IsIndexSymbol(upper) {
+		return upper, nil
+	}
+	return ValidateSymbol(symbol)
+}
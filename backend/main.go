@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
@@ -11,10 +15,13 @@ import (
 	"time"
 
 	"papertrader/internal/api/account"
+	"papertrader/internal/api/admin"
 	"papertrader/internal/api/investments"
 	"papertrader/internal/api/market"
 	"papertrader/internal/api/middleware"
+	"papertrader/internal/api/notifications"
 	apiresearch "papertrader/internal/api/research"
+	"papertrader/internal/api/tools"
 	"papertrader/internal/api/watchlist"
 	"papertrader/internal/config"
 	"papertrader/internal/data"
@@ -23,6 +30,7 @@ import (
 	"papertrader/internal/service/research"
 	"papertrader/internal/service/research/ingest"
 	researchsched "papertrader/internal/service/research/scheduler"
+	"papertrader/internal/sqlite"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -31,6 +39,9 @@ import (
 )
 
 func main() {
+	checkConfig := flag.Bool("check-config", false, "validate configuration from the environment (and .env), print any problems and exit")
+	flag.Parse()
+
 	// Configure shopspring/decimal to serialize as unquoted JSON numbers.
 	// Must run before any decimal value is marshalled.
 	data.EnableUnquotedDecimalJSON()
@@ -42,8 +53,23 @@ func main() {
 	}
 
 	cfg, err := config.Load()
+	if *checkConfig {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration OK")
+		os.Exit(0)
+	}
 	if err != nil {
-		slog.Error("invalid configuration", "err", err)
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			for _, p := range verr.Problems {
+				slog.Error("invalid configuration", "key", p.Key, "problem", p.Message)
+			}
+		} else {
+			slog.Error("invalid configuration", "err", err)
+		}
 		os.Exit(1)
 	}
 
@@ -56,6 +82,27 @@ func main() {
 	db := app.db
 	redisClient := app.redisClient
 	scheduler := app.scheduler
+
+	// Background loops run until shutdown: expired guest accounts are purged,
+	// pending orders are expired or checked against the quote, due recurring
+	// investments are bought, each trading day's close is processed (see
+	// eodClose), closed months' statements are emailed, and watched prices
+	// are pushed to live clients, inactive accounts are warned and then
+	// anonymized, and market movers are re-ranked. The quote cache is warmed
+	// once.
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	if app.cacheWarmer != nil {
+		go app.cacheWarmer.Run(backgroundCtx)
+	}
+	go app.guestService.RunPurge(backgroundCtx)
+	go app.orders.Run(backgroundCtx, cfg.Trading.ConditionalPollInterval)
+	go app.recurring.Run(backgroundCtx, cfg.Trading.RecurringPollInterval)
+	go app.eodClose.RunClose(backgroundCtx)
+	go app.statementEmails.RunEmails(backgroundCtx)
+	go app.priceHub.Run(backgroundCtx)
+	go app.symbolAliases.Run(backgroundCtx)
+	go app.retention.Run(backgroundCtx)
+	go app.movers.Run(backgroundCtx)
 	// Connections are closed in the graceful-shutdown block below; no defer
 	// here, since defer + explicit close logs spurious "already closed" errors
 	// (redis Close is not idempotent).
@@ -75,6 +122,10 @@ func main() {
 	// authenticated routes; public routes never see a forged value.
 	router.Use(middleware.StripUserHeaders())
 
+	// Attach client IP (and country, when the proxy supplies one) to the
+	// request context for the audit log and anomaly detection.
+	router.Use(middleware.ClientInfo(cfg.ClientCountryHeader))
+
 	router.Use(middleware.CORS(cfg.FrontendURL))
 
 	// CSRF defence: reject state-changing requests whose Origin doesn't match
@@ -82,24 +133,46 @@ func main() {
 	// belt-and-braces protection against cross-site forgery on cookie-auth
 	// endpoints. GET/HEAD/OPTIONS pass through.
 	router.Use(middleware.OriginCheck(cfg.FrontendURL))
+	// Double-submit CSRF token: unsafe methods must echo the csrf_token
+	// cookie in X-CSRF-Token. The frontend reads the cookie, or GET
+	// /api/csrf when it has none yet.
+	router.Use(middleware.CSRF(cfg.IsProduction()))
+
+	// Under overload, turn away market browsing with 503 before it competes
+	// with trading and auth. Inside CORS so the 503 is readable by the
+	// frontend; outside the timeout so in-flight counts whole handlers.
+	router.Use(middleware.LoadShed(cfg.LoadShed, db.Stats))
 
 	router.Use(middleware.RequestSizeLimitMiddleware(cfg.MaxRequestSize))
 	router.Use(middleware.RequestTimeoutMiddleware(cfg.RequestTimeout))
 
-	health := healthHandler(db, redisClient)
+	// Count authenticated requests per user. Inside the timeout so it runs on
+	// the handler's goroutine and reads the request headers after it, not
+	// concurrently with it.
+	router.Use(middleware.TrackUsage(app.usageService))
+
+	health := healthHandler(db, redisClient, app.marketFailover, app.cryptoFailover)
 	router.HandleFunc("/health", health).Methods("GET")
 
 	apiRouter := router.PathPrefix("/api").Subrouter()
 	apiRouter.HandleFunc("/health", health).Methods("GET")
+	apiRouter.HandleFunc("/csrf", middleware.CSRFToken).Methods("GET")
 
 	// Each feature mounts its routes onto a subrouter scoped to its prefix.
 	// Using Subrouter() (rather than the older PathPrefix + StripPrefix +
 	// custom-handler dance) means /api/investments and /api/investments/buy
 	// both match naturally without rewriting r.URL.Path.
-	account.Mount(apiRouter.PathPrefix("/account").Subrouter(), app.accountHandler, app.jwtService, app.rateLimiter, cfg)
-	market.Mount(apiRouter.PathPrefix("/market").Subrouter(), app.marketHandler, app.jwtService, app.rateLimiter, cfg)
-	investments.Mount(apiRouter.PathPrefix("/investments").Subrouter(), app.investmentsHandler, app.jwtService, cfg)
+	account.Mount(apiRouter.PathPrefix("/account").Subrouter(), app.accountHandler, app.jwtService, app.anomalyService, app.rateLimiter, cfg)
+	market.Mount(apiRouter.PathPrefix("/market").Subrouter(), app.marketHandler, app.liveHandler, app.jwtService, app.rateLimiter, cfg)
+	investments.Mount(apiRouter.PathPrefix("/investments").Subrouter(), app.investmentsHandler, app.jwtService, app.verification, cfg)
 	watchlist.Mount(apiRouter.PathPrefix("/watchlist").Subrouter(), app.watchlistHandler, app.jwtService, app.rateLimiter, cfg)
+	notifications.Mount(apiRouter.PathPrefix("/notifications").Subrouter(), app.notificationsHandler, app.jwtService, cfg)
+	tools.Mount(apiRouter.PathPrefix("/tools").Subrouter(), app.toolsHandler, app.jwtService, app.rateLimiter, cfg)
+	admin.Mount(apiRouter.PathPrefix("/admin").Subrouter(), app.adminHandler, app.jwtService, app.anomalyService, cfg)
+
+	if app.uploadsHandler != nil {
+		apiRouter.PathPrefix("/uploads/").Handler(http.StripPrefix("/api/uploads", app.uploadsHandler)).Methods("GET", "HEAD")
+	}
 
 	if app.researchHandler != nil {
 		apiresearch.Mount(apiRouter.PathPrefix("/research").Subrouter(), app.researchHandler, app.jwtService, app.rateLimiter, cfg)
@@ -115,13 +188,19 @@ func main() {
 	}
 
 	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
+		Addr: ":" + port,
+		// HEAD on GET routes, OPTIONS and 405s with Allow headers, the same
+		// on every subrouter.
+		Handler:      middleware.Methods(router, middleware.CORS(cfg.FrontendURL)),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Live connections never end on their own: close them as shutdown
+	// begins, or Shutdown waits out its timeout on open event streams.
+	srv.RegisterOnShutdown(app.priceHub.Stop)
+
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			slog.Error("server failed to start", "err", err)
@@ -144,6 +223,8 @@ func main() {
 		slog.Error("server forced to shutdown", "err", err)
 	}
 
+	stopBackground()
+
 	if scheduler != nil {
 		if err := scheduler.Stop(ctx); err != nil {
 			slog.Error("error stopping ingest scheduler", "err", err)
@@ -165,8 +246,11 @@ func main() {
 
 // healthHandler reports OK only when the DB and (if configured) Redis both
 // respond. Used at /health and /api/health so internal probes and the frontend
-// can hit either path.
-func healthHandler(db *sql.DB, redisClient *redis.Client) http.HandlerFunc {
+// can hit either path. Each market data provider's circuit breaker follows
+// on its own line; an open breaker is reported but doesn't fail the check,
+// since the fallback (or the breaker's next probe) covers it. Nil entries
+// in marketData are skipped.
+func healthHandler(db *sql.DB, redisClient *redis.Client, marketData ...*service.FailoverProvider) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := db.Ping(); err != nil {
 			w.WriteHeader(http.StatusServiceUnavailable)
@@ -184,6 +268,18 @@ func healthHandler(db *sql.DB, redisClient *redis.Client) http.HandlerFunc {
 
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
+		for _, f := range marketData {
+			if f == nil {
+				continue
+			}
+			for _, p := range f.Status() {
+				line := "\nmarket_data." + p.Name + ": " + p.State
+				if p.State == service.BreakerOpen {
+					line += ", retry in " + time.Until(p.RetryAt).Round(time.Second).String()
+				}
+				w.Write([]byte(line))
+			}
+		}
 	}
 }
 
@@ -191,48 +287,108 @@ func healthHandler(db *sql.DB, redisClient *redis.Client) http.HandlerFunc {
 // have to thread nine return values through. Field order is irrelevant; this
 // is purely a wiring container.
 type appDeps struct {
-	router             *mux.Router
-	accountHandler     *account.AccountHandler
-	marketHandler      *market.StockHandler
-	investmentsHandler *investments.InvestmentsHandler
-	watchlistHandler   *watchlist.WatchlistHandler
-	researchHandler    *apiresearch.Handler // nil when ResearchEnabled=false
-	db                 *sql.DB
-	redisClient        *redis.Client
-	jwtService         *service.JWTService
-	rateLimiter        service.RateLimiter
-	scheduler          *researchsched.IngestScheduler
+	router               *mux.Router
+	accountHandler       *account.AccountHandler
+	marketHandler        *market.StockHandler
+	liveHandler          *market.LiveHandler
+	priceHub             *service.PriceHub
+	investmentsHandler   *investments.InvestmentsHandler
+	watchlistHandler     *watchlist.WatchlistHandler
+	notificationsHandler *notifications.NotificationsHandler
+	toolsHandler         *tools.ToolsHandler
+	adminHandler         *admin.AdminHandler
+	anomalyService       *service.AnomalyService
+	verification         *service.VerificationService
+	guestService         *service.GuestService
+	orders               *service.OrderService
+	recurring            *service.RecurringInvestmentService
+	eodClose             *service.EODCloseService
+	symbolAliases        *service.SymbolAliasService
+	statementEmails      *service.StatementEmailService
+	retention            *service.RetentionService
+	movers               *service.MoversService
+	cacheWarmer          *service.CacheWarmer // nil when disabled or without a quote cache
+	marketFailover       *service.FailoverProvider
+	cryptoFailover       *service.FailoverProvider // nil when crypto trading is off
+	usageService         *service.UsageService
+	uploadsHandler       http.Handler         // nil unless STORAGE_DRIVER=local
+	researchHandler      *apiresearch.Handler // nil when ResearchEnabled=false
+	db                   *sql.DB
+	redisClient          *redis.Client
+	jwtService           *service.JWTService
+	rateLimiter          service.RateLimiter
+	scheduler            *researchsched.IngestScheduler
 }
 
 func initialize(cfg *config.Config) *appDeps {
-	// Initialize PostgreSQL database
-	db, err := config.ConnectPostgreSQL(cfg)
+	// Initialize the database: PostgreSQL, or SQLite for a sqlite:
+	// DATABASE_URL
+	db, err := config.ConnectDatabase(cfg)
 	if err != nil {
-		slog.Error("failed to connect to PostgreSQL", "err", err)
+		slog.Error("failed to connect to database", "err", err)
 		os.Exit(1)
 	}
+	data.UseSQLite(sqlite.Is(db))
 
-	// Initialize Redis
-	redisClient, err := config.ConnectRedis(cfg)
-	if err != nil {
-		slog.Warn("failed to connect to Redis; cache and rate limiting unavailable", "err", err)
-		redisClient = nil
+	// Initialize Redis; an empty REDIS_URL (the STANDALONE default) runs
+	// without it.
+	var redisClient *redis.Client
+	if cfg.RedisURL != "" {
+		redisClient, err = config.ConnectRedis(cfg)
+		if err != nil {
+			slog.Warn("failed to connect to Redis; cache and rate limiting unavailable", "err", err)
+			redisClient = nil
+		}
 	}
 
-	// Initialize cache services (nil if Redis unavailable)
+	// Initialize cache services (nil if Redis unavailable; see the in-process
+	// quote and EOD caches below)
 	var stockCache service.StockCache
 	var historicalCache service.HistoricalCache
 	var rateLimiter service.RateLimiter
+	var rateLimitInspector service.RateLimitInspector
+	var usageCounter service.UsageCounter
+	var quotaCounter service.QuotaCounter
+	var recentlyViewedStore service.RecentlyViewedStore
+	var sessionRevocations service.SessionRevocations
 
+	rateLimitPolicy := service.RateLimitPolicy{
+		UserLimit: cfg.RateLimits.UserLimit,
+		IPLimit:   cfg.RateLimits.IPLimit,
+		Window:    cfg.RateLimits.Window,
+	}
 	if redisClient != nil {
-		stockCache = service.NewRedisStockCache(redisClient)
-		historicalCache = service.NewRedisHistoricalCache(redisClient)
-		rateLimiter = service.NewRedisRateLimiter(redisClient)
+		stockCache = service.NewRedisStockCache(redisClient, cfg.Cache.StockTTL)
+		historicalCache = service.NewRedisHistoricalCache(redisClient, cfg.Cache.HistoricalTTL)
+		redisLimiter := service.NewRedisRateLimiter(redisClient, rateLimitPolicy)
+		rateLimiter, rateLimitInspector = redisLimiter, redisLimiter
+		usageCounter = service.NewRedisUsageCounter(redisClient)
+		quotaCounter = service.NewRedisQuotaCounter(redisClient)
+		recentlyViewedStore = service.NewRedisRecentlyViewedStore(redisClient)
+		sessionRevocations = service.NewRedisSessionRevocations(redisClient)
 		slog.Info("Redis cache and rate limiting services initialized")
 	} else {
-		rateLimiter = service.NewMemoryRateLimiter()
+		memoryLimiter := service.NewMemoryRateLimiter(rateLimitPolicy)
+		rateLimiter, rateLimitInspector = memoryLimiter, memoryLimiter
+		usageCounter = service.NewMemoryUsageCounter()
+		quotaCounter = service.NewMemoryQuotaCounter()
+		recentlyViewedStore = service.NewMemoryRecentlyViewedStore()
+		sessionRevocations = service.NewMemorySessionRevocations()
 		slog.Warn("Redis unavailable: using in-memory rate limiter (state resets on restart)")
 	}
+	// Quotes and EOD ranges are kept in process too, bounded, and read from
+	// there while Redis is unreachable or not configured, so an outage doesn't
+	// send every lookup to the provider.
+	if cfg.Cache.MemoryEntries > 0 {
+		memoryStocks := service.NewMemoryStockCache(cfg.Cache.MemoryEntries, cfg.Cache.StockTTL)
+		memoryHistorical := service.NewMemoryHistoricalCache(cfg.Cache.MemoryEntries, cfg.Cache.HistoricalTTL)
+		if stockCache != nil {
+			stockCache = service.NewFallbackStockCache(stockCache, memoryStocks)
+			historicalCache = service.NewFallbackHistoricalCache(historicalCache, memoryHistorical)
+		} else {
+			stockCache, historicalCache = memoryStocks, memoryHistorical
+		}
+	}
 
 	if cfg.MigrateOnStart {
 		if err := migrations.Run(db); err != nil {
@@ -246,10 +402,23 @@ func initialize(cfg *config.Config) *appDeps {
 
 	// Initialize stores
 	userStore := data.NewUserStore(db)
+	userStore.SetStartingBalance(cfg.StartingBalance)
 	tradeStore := data.NewTradesStore(db)
 	portfolioStore := data.NewPortfolioStore(db)
 	watchlistStore := data.NewWatchlistStore(db)
+	curatedListStore := data.NewCuratedListStore(db)
 	stockHistoryStore := data.NewStockHistoryStore(db)
+	instrumentStore := data.NewInstrumentStore(db)
+	notificationStore := data.NewNotificationStore(db)
+	auditStore := data.NewAuditStore(db)
+	passkeyStore := data.NewPasskeyStore(db)
+	orderStore := data.NewOrderStore(db)
+	tradeNotesStore := data.NewTradeNotesStore(db)
+	classificationStore := data.NewClassificationStore(db)
+
+	// One pooled client for every outbound call: market data, FX, Google,
+	// object storage and the research providers.
+	httpClient := config.NewHTTPClient(cfg)
 
 	// Research stores — used by the ingest scheduler and the answer handler.
 	docsStore := data.NewDocumentsStore(db)
@@ -260,14 +429,14 @@ func initialize(cfg *config.Config) *appDeps {
 	var ingestScheduler *researchsched.IngestScheduler
 	if cfg.IsProduction() && cfg.ResearchEnabled {
 		var embedder research.Embedder
-		voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey)
+		voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey, httpClient)
 		if redisClient != nil {
 			embedder = research.NewCachedEmbedder(voyage, redisClient)
 		} else {
 			embedder = voyage
 		}
 
-		edgarClient := ingest.NewEdgarClient(cfg.SecUserAgent)
+		edgarClient := ingest.NewEdgarClient(cfg.SecUserAgent, httpClient)
 		pipeline := ingest.NewPipeline(edgarClient, embedder, docsStore, chunksStore, embeddingsStore)
 
 		universe := researchsched.SplitTickerUniverse(cfg.ResearchTickerUniverse)
@@ -290,14 +459,14 @@ func initialize(cfg *config.Config) *appDeps {
 	var researchHandler *apiresearch.Handler
 	if cfg.ResearchEnabled {
 		var embedder research.Embedder
-		voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey)
+		voyage := research.NewVoyageEmbedder(cfg.VoyageAPIKey, httpClient)
 		if redisClient != nil {
 			embedder = research.NewCachedEmbedder(voyage, redisClient)
 		} else {
 			embedder = voyage
 		}
 		retrievalSvc := research.NewRetrievalService(embedder, embeddingsStore)
-		groqClient := research.NewGroqClient(cfg.GroqAPIKey)
+		groqClient := research.NewGroqClient(cfg.GroqAPIKey, httpClient)
 		answerSvc := research.NewAnswerService(retrievalSvc, groqClient, researchQueriesStore, redisClient)
 		researchHandler = apiresearch.NewHandler(answerSvc)
 		slog.Info("research answer handler initialized", "model", groqClient.Model())
@@ -307,14 +476,29 @@ func initialize(cfg *config.Config) *appDeps {
 
 	// Initialize JWT service with secret from config
 	jwtService := service.NewJWTService(cfg.JWTSecret)
+	jwtService.SetRevocations(sessionRevocations)
+	jwtService.SetRoles(userStore)
+	jwtService.SetAdminLocks(userStore)
 
 	// Initialize email service (optional, can be nil if not configured)
 	var emailService *service.EmailService
-	if cfg.ResendAPIKey != "" && cfg.FromEmail != "" {
-		emailService = service.NewEmailService(cfg.ResendAPIKey, cfg.FromEmail, cfg.FrontendURL)
-		slog.Info("email service initialized")
+	if cfg.Email.Enabled() {
+		sender, err := service.NewEmailSender(emailSenderConfig(cfg.Email), httpClient)
+		if err != nil {
+			slog.Error("failed to initialise email", "err", err)
+			os.Exit(1)
+		}
+		emailService = service.NewEmailService(sender, cfg.Email.FromEmail, cfg.FrontendURL, cfg.MobileAppScheme)
+		switch cfg.Email.Backend {
+		case service.EmailBackendOutbox:
+			slog.Info("email service initialized; writing emails to the outbox instead of sending them", "dir", cfg.Email.OutboxDir)
+		case service.EmailBackendLog:
+			slog.Info("email service initialized; logging emails instead of sending them")
+		default:
+			slog.Info("email service initialized", "backend", cfg.Email.Backend)
+		}
 	} else {
-		slog.Info("email service not configured (RESEND_API_KEY or FROM_EMAIL not set)")
+		slog.Info("email service not configured (EMAIL_BACKEND or FROM_EMAIL not set)")
 	}
 
 	// Initialize Google OAuth service. If GOOGLE_CLIENT_ID is empty the service
@@ -323,46 +507,518 @@ func initialize(cfg *config.Config) *appDeps {
 	if cfg.GoogleClientID == "" {
 		slog.Warn("GOOGLE_CLIENT_ID is not set; Google OAuth login will be rejected")
 	}
-	googleOAuthService := service.NewGoogleOAuthService(userStore, jwtService, cfg.GoogleClientID)
+	googleOAuthService := service.NewGoogleOAuthService(userStore, jwtService, cfg.GoogleClientID, httpClient)
+
+	notificationService := service.NewNotificationService(notificationStore)
+	notificationsHandler := notifications.NewNotificationsHandler(notificationService)
+
+	// Account anomaly detection: watches logins and trades, records to the
+	// audit log, and alerts the user by email and in-app notification.
+	anomalyService := service.NewAnomalyService(auditStore, userStore, emailService, notificationService, service.AnomalyPolicy{
+		Enabled:              cfg.AnomalyDetectionEnabled,
+		FailedLoginThreshold: cfg.AnomalyFailedLoginThreshold,
+		FailedLoginWindow:    cfg.AnomalyFailedLoginWindow,
+		BalanceChangePct:     cfg.AnomalyBalanceChangePct,
+		BalanceChangeMin:     cfg.AnomalyBalanceChangeMin,
+	})
+	if !cfg.AnomalyDetectionEnabled {
+		slog.Info("anomaly detection disabled (ANOMALY_DETECTION_ENABLED=false)")
+	} else if cfg.ClientCountryHeader == "" {
+		slog.Info("CLIENT_COUNTRY_HEADER is empty; new-country login detection is off")
+	}
+
+	// Inactive-account retention: with RETENTION_INACTIVE_DAYS set, accounts
+	// not logged in for that long are warned by email and anonymized after
+	// RETENTION_GRACE_DAYS. It also records each login, so it always runs as
+	// a login observer. Admin accounts are never touched.
+	avatarStorage, uploadsHandler := newObjectStorage(cfg, httpClient)
+	var retentionSender service.RetentionSender
+	if emailService != nil {
+		retentionSender = emailService
+	}
+	retentionService := service.NewRetentionService(userStore, retentionSender, avatarStorage, service.RetentionPolicy{
+		InactiveAfter: time.Duration(cfg.RetentionInactiveDays) * 24 * time.Hour,
+		Grace:         time.Duration(cfg.RetentionGraceDays) * 24 * time.Hour,
+		Exclude:       cfg.AdminEmails,
+	})
+	if cfg.RetentionInactiveDays > 0 && !retentionService.Enabled() {
+		slog.Warn("RETENTION_INACTIVE_DAYS is set but email is not configured; inactive accounts are not anonymized")
+	}
+	// Account lockout: too many wrong passwords lock the account and email
+	// its owner an unlock link.
+	var lockoutSender service.LockoutSender
+	if emailService != nil {
+		lockoutSender = emailService
+	}
+	lockoutService := service.NewLockoutService(userStore, jwtService, lockoutSender, service.LockoutPolicy{
+		Threshold:     cfg.LoginLockoutThreshold,
+		Duration:      cfg.LoginLockoutDuration,
+		MaxDuration:   cfg.LoginLockoutMaxDuration,
+		UnlockLinkTTL: cfg.MagicLinkTTL,
+	})
+	logins := service.LoginObservers{anomalyService, retentionService, lockoutService}
 
 	// Initialize auth service
-	authService := service.NewAuthService(userStore, jwtService, emailService, googleOAuthService)
+	usernameService := service.NewUsernameService(userStore, cfg.UsernameChangeCooldown, cfg.UsernameBlockedTerms)
+	authService := service.NewAuthService(userStore, jwtService, emailService, googleOAuthService, usernameService, logins)
+	authService.SetLockout(lockoutService)
+	authService.SetMagicLinks(rateLimiter, service.MagicLinkPolicy{
+		TTL:        cfg.MagicLinkTTL,
+		EmailLimit: cfg.MagicLinkEmailLimit,
+		IPLimit:    cfg.MagicLinkIPLimit,
+		Window:     cfg.MagicLinkWindow,
+	})
+	// Invite codes, managed under /api/admin. With REGISTRATION_INVITE_ONLY
+	// every new account (email, Google or guest) must redeem one.
+	inviteService := service.NewInviteService(data.NewInviteCodeStore(db), cfg.RegistrationInviteOnly)
+	authService.SetInvites(inviteService)
+	if cfg.RegistrationInviteOnly {
+		slog.Info("registration is invite-only (REGISTRATION_INVITE_ONLY=true)")
+	}
+
+	// Unverified accounts may be held back from trading until they verify
+	// their email (TRADING_EMAIL_VERIFICATION).
+	verificationService := service.NewVerificationService(userStore, tradeStore, service.VerificationPolicy{
+		Mode:     cfg.Trading.EmailVerification,
+		TradeCap: cfg.Trading.UnverifiedTradeCap,
+	})
+
+	// Per-user trade-count limits and the optional pattern-day-trader rule.
+	// Also surfaced read-only via GET /api/account/limits.
+	tradeLimitService := service.NewTradeLimitService(tradeStore, userStore, portfolioStore, service.TradeLimitPolicy{
+		MaxTradesPerDay:    cfg.Trading.MaxTradesPerDay,
+		PDTEnabled:         cfg.Trading.PDTEnabled,
+		PDTEquityThreshold: cfg.Trading.PDTEquityThreshold,
+		PDTMaxDayTrades:    cfg.Trading.PDTMaxDayTrades,
+	})
 
 	// Initialize account handler
-	accountHandler := account.NewAccountHandler(authService, cfg)
+	avatarService := service.NewAvatarService(userStore, avatarStorage, cfg.Storage.AvatarMaxBytes)
+	guestService := service.NewGuestService(userStore, jwtService, emailService, googleOAuthService, cfg.GuestAccountTTL)
+	guestService.SetInvites(inviteService)
+	passkeyService, err := service.NewPasskeyService(userStore, passkeyStore, jwtService, service.PasskeyConfig{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: "PaperTrader",
+		Origins:       []string{cfg.FrontendOrigin()},
+	}, logins)
+	if err != nil {
+		slog.Error("failed to initialise passkeys", "err", err)
+		os.Exit(1)
+	}
+	passkeyService.SetLockout(lockoutService)
+	// Rate-limit buckets shown to users by GET /api/account/usage and
+	// addressable by admins under /api/admin/ratelimits.
+	rateLimitBuckets := []service.RateLimitBucket{{
+		Name:      "global",
+		Bucket:    service.DefaultRateLimitBucket,
+		UserLimit: cfg.RateLimits.UserLimit,
+		IPLimit:   cfg.RateLimits.IPLimit,
+		Window:    cfg.RateLimits.Window,
+	}}
+	if cfg.ResearchEnabled {
+		rateLimitBuckets = append(rateLimitBuckets, service.RateLimitBucket{
+			Name:      "research_ask",
+			Bucket:    apiresearch.AskBucket,
+			UserLimit: cfg.RateLimits.AskUserLimit,
+			IPLimit:   cfg.RateLimits.AskIPLimit,
+			Window:    cfg.RateLimits.AskWindow,
+		})
+	}
+	usageService := service.NewUsageService(usageCounter, rateLimiter, rateLimitBuckets...)
+	// Exchange rates for the convert endpoint and display-currency responses.
+	fxService := service.NewFXService(cfg.FXAPIURL, httpClient, cfg.Cache.FXTTL, userStore)
+	// NYSE session calendar. The per-user after-hours setting is editable
+	// even when TRADING_MARKET_HOURS_ENABLED=false turns enforcement off.
+	marketCalendar, err := service.NewMarketCalendar()
+	if err != nil {
+		slog.Error("failed to load market calendar", "err", err)
+		os.Exit(1)
+	}
+	marketHours := service.NewMarketHours(marketCalendar, userStore)
+	// Tax lots and the per-user cost-basis method (FIFO, LIFO or average).
+	lotStore := data.NewLotStore(db)
+	costBasisService := service.NewCostBasisService(userStore, lotStore)
+	// A nil *EmailService must not reach the interface as a non-nil value.
+	var confirmationSender service.TradeConfirmationSender
+	if emailService != nil {
+		confirmationSender = emailService
+	}
+	tradeConfirmationService := service.NewTradeConfirmationService(userStore, portfolioStore, confirmationSender)
 
 	// Initialize market service with cache services and the persistent
 	// stock_history store (used by GetHistoricalSeries to avoid burning
-	// MarketStack quota on repeat chart loads).
-	marketService := service.NewMarketService(cfg.MarketStackKey, stockCache, historicalCache, stockHistoryStore)
+	// provider quota on repeat chart loads). MARKET_DATA_PROVIDER picks the
+	// upstream and MARKET_DATA_FALLBACK_PROVIDER the one used while it is
+	// down; each sits behind a circuit breaker. Config validation has
+	// already rejected unknown names. Every MarketStack request is counted
+	// against MARKETSTACK_MONTHLY_QUOTA.
+	marketStackQuota := service.NewQuotaTracker(service.ProviderMarketStack, quotaCounter,
+		cfg.MarketStackMonthlyQuota, cfg.MarketStackQuotaShedPercent)
+	var marketProviders []service.MarketDataProvider
+	for _, name := range []string{cfg.MarketDataProvider, cfg.MarketDataFallback} {
+		if name == "" {
+			continue
+		}
+		provider, err := service.NewMarketDataProvider(name, cfg.MarketDataKey(name), httpClient)
+		if err != nil {
+			slog.Error("failed to configure market data provider", "err", err)
+			os.Exit(1)
+		}
+		if ms, ok := provider.(*service.MarketStack); ok {
+			ms.SetQuota(marketStackQuota)
+		}
+		marketProviders = append(marketProviders, provider)
+	}
+	marketFailover := service.NewFailoverProvider(marketProviders, cfg.MarketDataBreakerThreshold, cfg.MarketDataBreakerCooldown)
+	// Crypto pairs (BTC-USD) are priced by CRYPTO_DATA_PROVIDER behind a
+	// breaker of their own; the router sends each symbol to its provider.
+	var cryptoFailover *service.FailoverProvider
+	var cryptoProvider service.MarketDataProvider
+	if cfg.CryptoDataProvider == service.ProviderCoinbase {
+		cryptoFailover = service.NewFailoverProvider([]service.MarketDataProvider{service.NewCoinbase(httpClient)},
+			cfg.MarketDataBreakerThreshold, cfg.MarketDataBreakerCooldown)
+		cryptoProvider = cryptoFailover
+	}
+	// London and Toronto listings are priced in GBP and CAD; ListingFX
+	// converts them so every price the app stores is in USD.
+	marketProvider := service.NewListingFX(service.NewAssetRouter(marketFailover, cryptoProvider), fxService)
+	marketService := service.NewMarketService(marketProvider, stockCache, historicalCache, stockHistoryStore)
+	if redisClient != nil {
+		marketService.SetChartCache(service.NewRedisChartCache(redisClient))
+		marketService.SetIntradayTTL(cfg.Cache.IntradayTTL)
+		marketService.SetCompanyCache(service.NewRedisCompanyCache(redisClient))
+	}
+	marketService.SetQuarantineStore(data.NewMarketQuarantineStore(db))
+	if cfg.Cache.StalePriceMaxAge > 0 {
+		marketService.SetLastPrices(data.NewLastPriceStore(db), cfg.Cache.StalePriceMaxAge)
+	}
+	// Quotes for held and watched symbols are fetched in batches at start-up
+	// and after each close (see the EOD steps below) rather than one by one
+	// by the first users after a restart or in the morning.
+	var cacheWarmer *service.CacheWarmer
+	if cfg.Cache.Warmup && stockCache != nil && cfg.MarketDataKey(cfg.MarketDataProvider) != "" {
+		cacheWarmer = service.NewCacheWarmer(portfolioStore, watchlistStore, marketService)
+	}
+	// Sector, industry and index membership, loaded from the bundled dataset
+	// on every start so a deploy picks up constituent changes. A failure
+	// (e.g. migrations not yet applied) leaves the previous rows in place.
+	classificationService := service.NewClassificationService(classificationStore)
+	loadCtx, cancelLoad := context.WithTimeout(context.Background(), 30*time.Second)
+	if _, err := classificationService.LoadDataset(loadCtx); err != nil {
+		slog.Warn("failed to load classification dataset", "err", err, "component", "classification")
+	}
+	// Ticker changes, from the bundled dataset and admins. Quote and history
+	// lookups follow them, and holdings under an old symbol are re-keyed.
+	symbolAliases := service.NewSymbolAliasService(data.NewSymbolAliasStore(db))
+	if _, err := symbolAliases.LoadDataset(loadCtx); err != nil {
+		slog.Warn("failed to load symbol change dataset", "err", err, "component", "symbol_alias")
+	}
+	marketService.SetAliases(symbolAliases)
+	cancelLoad()
 	// Initialize market handler
-	marketHandler := market.NewStockHandler(marketService)
+	marketHandler := market.NewStockHandler(marketService, service.NewRecentlyViewedService(recentlyViewedStore), fxService, marketHours, classificationService)
+
+	// Instrument reference data and trading halts. Halts notify holders.
+	instrumentService := service.NewInstrumentService(instrumentStore, marketService, portfolioStore, notificationService)
+	marketHandler.SetPrecision(instrumentService)
+	// The dashboard's gainers, losers and most active, ranked across an
+	// index, the configured symbols and everything held or watched, and
+	// refreshed in the background.
+	moversService := service.NewMoversService(marketService, classificationService, portfolioStore, watchlistStore,
+		cfg.Movers.Index, cfg.Movers.Symbols, cfg.Movers.Interval)
+	if redisClient != nil {
+		moversService.SetCache(service.NewRedisMoversCache(redisClient))
+	}
+	marketHandler.SetMovers(moversService)
+	// Headlines per symbol and for the market, from the configured news API
+	// and cached in Redis; /news answers 503 without a provider and key.
+	if cfg.News.Provider != "" && cfg.News.APIKey != "" {
+		var newsProvider service.NewsProvider
+		if cfg.News.Provider == service.ProviderFinnhub {
+			newsProvider = service.NewFinnhub(cfg.News.APIKey, httpClient)
+		} else {
+			newsProvider = service.NewAlphaVantage(cfg.News.APIKey, httpClient)
+		}
+		newsService := service.NewNewsService(newsProvider, cfg.News.CacheTTL)
+		if redisClient != nil {
+			newsService.SetCache(service.NewRedisNewsCache(redisClient))
+		}
+		marketHandler.SetNews(newsService)
+	} else {
+		slog.Info("news: disabled (NEWS_PROVIDER and NEWS_API_KEY required)")
+	}
+	// Watchlists, including the admin-curated lists managed under /api/admin.
+	watchlistService := service.NewWatchlistService(watchlistStore, curatedListStore, marketService)
+	watchlistService.SetMaxEntries(cfg.WatchlistMaxEntries)
+	// Users may erase their account, or ask support to; the confirmation
+	// goes out by email.
+	var deletionSender service.AccountDeletionSender
+	if emailService != nil {
+		deletionSender = emailService
+	}
+	accountDeletion := service.NewAccountDeletionService(userStore, deletionSender, avatarStorage, redisClient)
+	// Account transfer: bundles are signed with this deployment's key and
+	// imported only from the deployments whose public keys are trusted.
+	transferKey, trustedTransferKeys, err := service.ParseAccountTransferKeys(cfg.AccountTransferSigningKey, cfg.AccountTransferTrustedKeys)
+	if err != nil {
+		slog.Error("invalid account transfer keys", "err", err)
+		os.Exit(1)
+	}
+	if transferKey != nil {
+		slog.Info("account export enabled; trust this public key to import its bundles elsewhere",
+			"public_key", service.AccountTransferKeyID(transferKey.Public().(ed25519.PublicKey)))
+	}
+	accountTransferService := service.NewAccountTransferService(data.NewAccountTransferStore(db), transferKey, trustedTransferKeys, cfg.FrontendURL)
+	accountTransferService.SetAuditStore(auditStore)
+	// Bulk user import (classroom onboarding) and export, and the audited
+	// per-account actions under /api/admin/users.
+	userAdminService := service.NewUserAdminService(userStore, jwtService, emailService, cfg.InviteLinkTTL)
+	userAdminService.SetManagement(auditStore, lockoutService, accountDeletion)
+	// End-of-day close: once each session's closes are published, persist
+	// them for every active symbol and run the steps added below. Admins can
+	// rerun a past session after bad provider data.
+	eodClose := service.NewEODCloseService(data.NewEODRunStore(db), stockHistoryStore, portfolioStore,
+		watchlistStore, orderStore, marketService, marketCalendar)
+	eodClose.SetAuditStore(auditStore)
+	// Users flag bad fills; admins reject the flag or reverse the trade.
+	tradeDisputes := service.NewTradeDisputeService(db, data.NewTradeDisputeStore(db), auditStore, notificationService)
+	adminHandler := admin.NewAdminHandler(instrumentService, anomalyService,
+		service.NewRateLimitAdminService(rateLimitInspector, rateLimitBuckets...), watchlistService, classificationService, userAdminService, inviteService, eodClose, symbolAliases, retentionService, tradeDisputes,
+		service.NewMarketCacheAdminService(redisClient), marketStackQuota)
+	// ADMIN_EMAILS accounts with a verified email are promoted to the admin
+	// role, which /api/admin requires. Nobody is demoted: the role column is
+	// the source of truth.
+	if len(cfg.AdminEmails) > 0 {
+		grantCtx, cancelGrant := context.WithTimeout(context.Background(), 10*time.Second)
+		if promoted, err := userStore.GrantAdmin(grantCtx, cfg.AdminEmails); err != nil {
+			slog.Warn("failed to grant the admin role to ADMIN_EMAILS", "err", err)
+		} else if promoted > 0 {
+			slog.Info("granted the admin role to ADMIN_EMAILS accounts", "count", promoted)
+		}
+		cancelGrant()
+	}
+
+	// Pre-trade policy checks run before every buy/sell transaction, order
+	// fill and recurring buy. The halt, email-verification, trade-limit and
+	// holdings checks always run (the verification policy and limits are
+	// no-ops when off or 0); the market-hours and symbol policies are
+	// per-deployment choices.
+	tradeChecks := []service.PreTradeCheck{instrumentService, verificationService, tradeLimitService,
+		service.NewHoldingsQuota(portfolioStore, cfg.Trading.MaxHoldings)}
+	if cfg.Trading.RestrictionsEnabled {
+		tradeChecks = append(tradeChecks, service.NewSymbolPolicy(instrumentService,
+			cfg.Trading.MinPrice, cfg.Trading.AllowedExchanges, cfg.Trading.SymbolAllowlist))
+		slog.Info("trading symbol policy enabled",
+			"min_price", cfg.Trading.MinPrice,
+			"allowed_exchanges", cfg.Trading.AllowedExchanges,
+			"allowlist_size", len(cfg.Trading.SymbolAllowlist),
+		)
+	} else {
+		slog.Info("trading symbol policy disabled (TRADING_RESTRICTIONS_ENABLED=false)")
+	}
+	if cfg.Trading.MarketHoursEnabled {
+		// Last, so an after-hours trade is only queued once every other
+		// check has passed; one they reject is refused now, not at the open.
+		tradeChecks = append(tradeChecks, marketHours)
+	} else {
+		slog.Info("market hours not enforced (TRADING_MARKET_HOURS_ENABLED=false)")
+	}
 
 	// Initialize investment service (uses MarketService for stock prices, PortfolioStore for holdings, TradesStore for history)
-	investmentService := service.NewInvestmentService(db, marketService, portfolioStore, tradeStore)
+	investmentService := service.NewInvestmentService(db, marketService, portfolioStore, tradeStore, tradeChecks...)
+	// Intraday account value for the dashboard, cached briefly per user and
+	// dropped on each of the user's trades.
+	portfolioHistoryStore := data.NewPortfolioHistoryStore(db)
+	portfolioValueService := service.NewPortfolioValueService(userStore, portfolioStore, portfolioHistoryStore, marketService)
+	// Bonus cash is claimable once per cooldown and left out of returns.
+	bonusStore := data.NewBonusStore(db)
+	portfolioValueService.SetBonus(bonusStore)
+	// Live prices and account values for connected clients, refreshed
+	// through the quote cache.
+	priceHub := service.NewPriceHub(marketService, portfolioValueService, cfg.Live.Interval, cfg.Live.MaxClients, cfg.Live.MaxSymbols)
+	liveHandler := market.NewLiveHandler(priceHub, cfg.FrontendURL)
+	// Monthly statements, stored once the month has closed.
+	statementService := service.NewStatementService(userStore, portfolioHistoryStore, tradeStore, data.NewStatementStore(db), portfolioValueService, marketCalendar)
+	// Statements emailed as PDFs to users who opt in, once each month closes.
+	var statementSender service.StatementSender
+	if emailService != nil {
+		statementSender = emailService
+	}
+	statementEmailService := service.NewStatementEmailService(statementService, data.NewStatementReportStore(db), userStore, fxService, statementSender)
+	// Investment goals, measured from the daily snapshots.
+	investmentGoalService := service.NewInvestmentGoalService(data.NewInvestmentGoalStore(db), portfolioHistoryStore, notificationService)
+	bonusService := service.NewBonusService(bonusStore, cfg.BonusCashAmount, cfg.BonusCashCooldown)
+	accountHandler := account.NewAccountHandler(authService, jwtService, cfg, account.AccountServices{
+		Limits:     tradeLimitService,
+		Avatars:    avatarService,
+		Usernames:  usernameService,
+		Guests:     guestService,
+		Passkeys:   passkeyService,
+		Usage:      usageService,
+		Currency:   fxService,
+		AfterHours: marketHours,
+		CostBasis:  costBasisService,
+		Confirms:   tradeConfirmationService,
+		Statements: statementService,
+		Reports:    statementEmailService,
+		Resets:     service.NewAccountResetService(userStore, jwtService, cfg.AccountResetConfirm),
+		Timezones:  service.NewTimezoneService(userStore),
+		Goals:      investmentGoalService,
+		Bonus:      bonusService,
+		Transfers:  accountTransferService,
+		Deletions:  accountDeletion,
+	})
+	investmentService.AddObservers(anomalyService, portfolioValueService, tradeConfirmationService)
+	investmentService.SetMaxQuantity(cfg.Trading.MaxQuantity)
+	investmentService.SetShortMarginPct(cfg.Trading.ShortMarginPct)
+	investmentService.SetSlippagePct(cfg.Trading.SlippagePct)
+	investmentService.SetStaleQuoteMaxAge(cfg.Trading.StaleQuoteMaxAge)
+	investmentService.SetPrecision(instrumentService)
+	// Pending orders fill through the investment service.
+	orderService := service.NewOrderService(orderStore, investmentService,
+		notificationService, cfg.Trading.MaxConditionalOrders)
+	orderService.SetTimeInForce(marketCalendar, cfg.Trading.GTCMaxDays)
+	orderService.SetWaitLimit(cfg.Trading.OrderWaitMax)
+	if cfg.Trading.MarketHoursEnabled {
+		orderService.SetMarketHours(marketHours)
+	}
+	// The dashboard value shows what pending orders would spend against
+	// buying power.
+	portfolioValueService.SetHolds(orderService)
+	// Daily portfolio value snapshots, taken by the EOD close job below.
+	portfolioHistoryService := service.NewPortfolioHistoryService(portfolioHistoryStore)
+	// End-of-day close steps: snapshot portfolios, move investment goals along
+	// from the snapshots, then give pending orders a pass at the close (which
+	// also expires DAY orders). Orders fill on live quotes, not closes, so an
+	// admin's rerun of a past session skips them.
+	eodClose.AddStep("snapshots", func(ctx context.Context, eod *service.EODClose) error {
+		_, err := portfolioHistoryService.Snapshot(ctx, eod)
+		return err
+	})
+	eodClose.AddStep("goals", func(ctx context.Context, eod *service.EODClose) error {
+		_, err := investmentGoalService.Evaluate(ctx, eod)
+		return err
+	})
+	eodClose.AddStep("orders", func(ctx context.Context, eod *service.EODClose) error {
+		if eod.IsRerun() {
+			return nil
+		}
+		_, err := orderService.CheckOrders(ctx)
+		return err
+	})
+	// With the closes in, cache them as the quotes the next session's first
+	// requests will ask for.
+	if cacheWarmer != nil {
+		eodClose.AddStep("cache_warmup", func(ctx context.Context, eod *service.EODClose) error {
+			return cacheWarmer.WarmClose(ctx, marketCalendar.NextSession(eod.Session.Close))
+		})
+	}
 	// Initialize investments handler
-	investmentsHandler := investments.NewInvestmentsHandler(investmentService)
+	// Recurring investments buy through the investment service too.
+	recurringService := service.NewRecurringInvestmentService(data.NewRecurringInvestmentStore(db), investmentService,
+		marketCalendar, notificationService, cfg.Trading.MaxRecurringInvestments)
+	benchmarkService := service.NewBenchmarkService(portfolioHistoryService, marketService, cfg.Trading.BenchmarkSymbol)
+	investmentsHandler := investments.NewInvestmentsHandler(investmentService, orderService, recurringService,
+		service.NewTradeNotesService(tradeNotesStore), tradeDisputes, fxService, service.NewPnLService(tradeStore, investmentService, costBasisService), costBasisService,
+		portfolioHistoryService, benchmarkService, service.NewRiskService(benchmarkService, cfg.Trading.RiskFreeRatePct),
+		portfolioValueService, service.NewStatsService(tradeStore, lotStore, portfolioHistoryStore, portfolioValueService),
+		service.NewReplayService(marketService, tradeStore, marketCalendar),
+		service.NewRebalanceService(userStore, portfolioStore, marketService, investmentService), cfg.Trading.MaxQuantity)
 
-	// Initialize watchlist service + handler
-	watchlistService := service.NewWatchlistService(watchlistStore, marketService)
+	// Initialize watchlist handler
 	watchlistHandler := watchlist.NewWatchlistHandler(watchlistService)
 
+	// What-if tools over the user's holdings.
+	toolsHandler := tools.NewToolsHandler(service.NewStressService(userStore, portfolioStore, marketService, marketService,
+		classificationService, cfg.Trading.BenchmarkSymbol))
+
 	// Setup router. StrictSlash(false) is on by default; setting it explicitly
 	// guards against accidental 301 redirects (which break CORS preflight).
 	router := mux.NewRouter()
 	router.StrictSlash(false)
 
 	return &appDeps{
-		router:             router,
-		accountHandler:     accountHandler,
-		marketHandler:      marketHandler,
-		investmentsHandler: investmentsHandler,
-		watchlistHandler:   watchlistHandler,
-		researchHandler:    researchHandler,
-		db:                 db,
-		redisClient:        redisClient,
-		jwtService:         jwtService,
-		rateLimiter:        rateLimiter,
-		scheduler:          ingestScheduler,
+		router:               router,
+		accountHandler:       accountHandler,
+		marketHandler:        marketHandler,
+		liveHandler:          liveHandler,
+		priceHub:             priceHub,
+		investmentsHandler:   investmentsHandler,
+		watchlistHandler:     watchlistHandler,
+		notificationsHandler: notificationsHandler,
+		toolsHandler:         toolsHandler,
+		adminHandler:         adminHandler,
+		anomalyService:       anomalyService,
+		verification:         verificationService,
+		guestService:         guestService,
+		orders:               orderService,
+		recurring:            recurringService,
+		eodClose:             eodClose,
+		symbolAliases:        symbolAliases,
+		statementEmails:      statementEmailService,
+		retention:            retentionService,
+		movers:               moversService,
+		cacheWarmer:          cacheWarmer,
+		marketFailover:       marketFailover,
+		cryptoFailover:       cryptoFailover,
+		usageService:         usageService,
+		uploadsHandler:       uploadsHandler,
+		researchHandler:      researchHandler,
+		db:                   db,
+		redisClient:          redisClient,
+		jwtService:           jwtService,
+		rateLimiter:          rateLimiter,
+		scheduler:            ingestScheduler,
+	}
+}
+
+// emailSenderConfig maps the email settings onto the service's sender
+// configuration.
+func emailSenderConfig(e config.EmailConfig) service.EmailSenderConfig {
+	return service.EmailSenderConfig{
+		Backend:      e.Backend,
+		ResendAPIKey: e.ResendAPIKey,
+		SMTP: service.SMTPConfig{
+			Host:     e.SMTPHost,
+			Port:     e.SMTPPort,
+			Username: e.SMTPUsername,
+			Password: e.SMTPPassword,
+		},
+		SES: service.SESConfig{
+			Region:          e.SESRegion,
+			AccessKeyID:     e.SESAccessKeyID,
+			SecretAccessKey: e.SESSecretAccessKey,
+			Endpoint:        e.SESEndpoint,
+		},
+		OutboxDir: e.OutboxDir,
+	}
+}
+
+// newObjectStorage builds the storage backend for user uploads. For the local
+// driver it also returns the handler that serves the files at /api/uploads.
+func newObjectStorage(cfg *config.Config, client *http.Client) (service.ObjectStorage, http.Handler) {
+	st := cfg.Storage
+	if st.Driver == "s3" {
+		slog.Info("object storage: s3", "endpoint", st.S3Endpoint, "bucket", st.S3Bucket)
+		return service.NewS3ObjectStorage(service.S3Config{
+			Endpoint:        st.S3Endpoint,
+			Region:          st.S3Region,
+			Bucket:          st.S3Bucket,
+			AccessKeyID:     st.S3AccessKeyID,
+			SecretAccessKey: st.S3SecretAccessKey,
+			PublicURL:       st.PublicURL,
+		}, client), nil
+	}
+
+	baseURL := st.PublicURL
+	if baseURL == "" {
+		baseURL = "/api/uploads"
+	}
+	local, err := service.NewLocalObjectStorage(st.LocalDir, baseURL)
+	if err != nil {
+		slog.Error("failed to initialise local object storage", "dir", st.LocalDir, "err", err)
+		os.Exit(1)
 	}
+	slog.Info("object storage: local", "dir", st.LocalDir)
+	return local, local.Handler()
 }
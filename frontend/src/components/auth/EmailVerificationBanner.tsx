@@ -1,5 +1,5 @@
 import React, { useState } from 'react';
-import { apiRequest } from '../../services/api';
+import { ApiError, resendVerification } from '../../services/api';
 
 interface EmailVerificationBannerProps {
   email: string;
@@ -15,19 +15,14 @@ const EmailVerificationBanner: React.FC<EmailVerificationBannerProps> = ({ email
     setResendMessage('');
 
     try {
-      const response = await apiRequest('/account/resend-verification', {
-        method: 'POST',
-        body: JSON.stringify({ email }),
-      });
-
-      const data = await response.json();
-      if (response.ok && data.success) {
-        setResendMessage('Verification email sent — check your inbox.');
-      } else {
-        setResendMessage(data.message || 'Failed to resend verification email.');
-      }
-    } catch {
-      setResendMessage('Failed to resend verification email. Please try again.');
+      await resendVerification({ email });
+      setResendMessage('Verification email sent — check your inbox.');
+    } catch (err) {
+      setResendMessage(
+        err instanceof ApiError
+          ? err.message || 'Failed to resend verification email.'
+          : 'Failed to resend verification email. Please try again.'
+      );
     } finally {
       setResending(false);
       setTimeout(() => setResendMessage(''), 5000);
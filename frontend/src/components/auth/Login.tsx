@@ -1,8 +1,8 @@
 import React, { useState, FormEvent, ChangeEvent } from 'react';
 import { Link, useNavigate } from 'react-router-dom';
 import { GoogleLogin, CredentialResponse } from '@react-oauth/google';
-import { apiRequest } from '../../services/api';
-import { User, LoginRequest, AuthResponse } from '../../types';
+import { ApiError, googleLogin, login } from '../../services/api';
+import { User, LoginRequest } from '../../types';
 
 interface LoginProps {
   onLogin: (user: User) => void;
@@ -27,13 +27,8 @@ const Login: React.FC<LoginProps> = ({ onLogin }) => {
     setGoogleLoading(true);
     setError('');
     try {
-      const response = await apiRequest<AuthResponse>('/account/auth/google', {
-        method: 'POST',
-        body: JSON.stringify({ token: credential })
-      });
-
-      const data = await response.json() as AuthResponse;
-      if (response.ok && data.success && data.user) {
+      const data = await googleLogin({ token: credential });
+      if (data.success && data.user) {
         localStorage.setItem('user', JSON.stringify(data.user));
         onLogin(data.user);
         navigate('/dashboard');
@@ -42,7 +37,7 @@ const Login: React.FC<LoginProps> = ({ onLogin }) => {
       }
     } catch (error) {
       console.error('Google login error:', error);
-      setError('Failed to login with Google');
+      setError(error instanceof ApiError ? error.message : 'Failed to login with Google');
     } finally {
       setGoogleLoading(false);
     }
@@ -62,14 +57,9 @@ const Login: React.FC<LoginProps> = ({ onLogin }) => {
     setLoading(true);
 
     try {
-      const response = await apiRequest<AuthResponse>('/account/login', {
-        method: 'POST',
-        body: JSON.stringify(formData)
-      });
-
-      const data = await response.json() as AuthResponse;
+      const data = await login(formData);
 
-      if (response.ok && data.success && data.user) {
+      if (data.success && data.user) {
         // Token is set as HttpOnly cookie by backend, no need to store in localStorage
         localStorage.setItem('user', JSON.stringify(data.user));
         onLogin(data.user);
@@ -1,8 +1,8 @@
 import React, { useState, FormEvent, ChangeEvent, useMemo } from 'react';
 import { Link, useNavigate } from 'react-router-dom';
 import { GoogleLogin, CredentialResponse } from '@react-oauth/google';
-import { apiRequest } from '../../services/api';
-import { User, RegisterRequest, AuthResponse } from '../../types';
+import { ApiError, googleLogin, register } from '../../services/api';
+import { User, RegisterRequest } from '../../types';
 import { validatePassword, validateEmail } from '../../utils/validation';
 
 interface RegisterProps {
@@ -29,13 +29,8 @@ const Register: React.FC<RegisterProps> = ({ onLogin }) => {
     setGoogleLoading(true);
     setError('');
     try {
-      const response = await apiRequest<AuthResponse>('/account/auth/google', {
-        method: 'POST',
-        body: JSON.stringify({ token: credential })
-      });
-
-      const data = await response.json() as AuthResponse;
-      if (response.ok && data.success && data.user) {
+      const data = await googleLogin({ token: credential });
+      if (data.success && data.user) {
         localStorage.setItem('user', JSON.stringify(data.user));
         onLogin(data.user);
         navigate('/dashboard');
@@ -44,7 +39,7 @@ const Register: React.FC<RegisterProps> = ({ onLogin }) => {
       }
     } catch (error) {
       console.error('Google login error:', error);
-      setError('Failed to login with Google');
+      setError(error instanceof ApiError ? error.message : 'Failed to login with Google');
     } finally {
       setGoogleLoading(false);
     }
@@ -112,14 +107,9 @@ const Register: React.FC<RegisterProps> = ({ onLogin }) => {
     setLoading(true);
 
     try {
-      const response = await apiRequest<AuthResponse>('/account/register', {
-        method: 'POST',
-        body: JSON.stringify(formData)
-      });
-
-      const data = await response.json() as AuthResponse;
+      const data = await register(formData);
 
-      if (response.ok && data.success && data.user) {
+      if (data.success && data.user) {
         // Token is set as HttpOnly cookie by backend, no need to store in localStorage
         localStorage.setItem('user', JSON.stringify(data.user));
         onLogin(data.user);
@@ -128,7 +118,7 @@ const Register: React.FC<RegisterProps> = ({ onLogin }) => {
         setError(data.message || 'Registration failed');
       }
     } catch (error) {
-      setError('Network error. Please try again.');
+      setError(error instanceof ApiError ? error.message : 'Network error. Please try again.');
     } finally {
       setLoading(false);
     }
@@ -1,6 +1,6 @@
 import React, { useEffect, useState } from 'react';
 import { useNavigate, useSearchParams } from 'react-router-dom';
-import { apiRequest } from '../../services/api';
+import { ApiError, verifyEmail } from '../../services/api';
 import { useAuth } from '../../hooks/useAuth';
 
 const VerifyEmail: React.FC = () => {
@@ -18,30 +18,28 @@ const VerifyEmail: React.FC = () => {
       return;
     }
 
-    apiRequest(`/account/verify-email?token=${token}`, { method: 'GET' })
-      .then(res => res.json())
-      .then(async (data) => {
-        if (data.success) {
-          setStatus('success');
-          setMessage('Email verified successfully! Refreshing your account...');
-          
-          // Force refresh user profile to get updated email_verified status
-          // This bypasses cache to ensure we get the latest data
-          if (isAuthenticated) {
-            await refreshUser();
-            setMessage('Email verified successfully! Redirecting to dashboard...');
-            setTimeout(() => navigate('/dashboard'), 1500);
-          } else {
-            setTimeout(() => navigate('/login'), 2000);
-          }
+    verifyEmail({ token })
+      .then(async () => {
+        setStatus('success');
+        setMessage('Email verified successfully! Refreshing your account...');
+        
+        // Force refresh user profile to get updated email_verified status
+        // This bypasses cache to ensure we get the latest data
+        if (isAuthenticated) {
+          await refreshUser();
+          setMessage('Email verified successfully! Redirecting to dashboard...');
+          setTimeout(() => navigate('/dashboard'), 1500);
         } else {
-          setStatus('error');
-          setMessage(data.message || 'Verification failed');
+          setTimeout(() => navigate('/login'), 2000);
         }
       })
-      .catch(() => {
+      .catch((err) => {
         setStatus('error');
-        setMessage('Failed to verify email. Please try again.');
+        setMessage(
+          err instanceof ApiError
+            ? err.message || 'Verification failed'
+            : 'Failed to verify email. Please try again.'
+        );
       });
   }, [searchParams, navigate, refreshUser, isAuthenticated]);
 
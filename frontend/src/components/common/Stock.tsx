@@ -1,11 +1,7 @@
 import React, { useCallback, useEffect, useMemo, useState } from 'react';
 import { useNavigate, useParams } from 'react-router-dom';
-import { apiRequest } from '../../services/api';
-import {
-  HistoricalSeriesResponse,
-  StockResponse,
-  WatchlistEntry,
-} from '../../types';
+import { addToWatchlist, ApiError, getQuote, getSeries } from '../../services/api';
+import { HistoricalSeriesResponse, StockResponse } from '../../types';
 import { useAuth } from '../../hooks/useAuth';
 import { formatMoney, formatPercent, formatSignedMoney } from '../primitives/format';
 import PriceChart from './PriceChart';
@@ -66,17 +62,13 @@ const Stock: React.FC = () => {
     setQuoteLoading(true);
     setQuoteError(null);
     try {
-      const res = await apiRequest<{ data?: StockResponse }>(
-        `/market/stock?symbol=${encodeURIComponent(symbol)}`
-      );
-      if (!res.ok) {
-        setQuoteError(res.status === 404 ? 'Symbol not found.' : 'Could not fetch a price.');
+      const res = await getQuote({ symbol });
+      setQuote(res.data ?? null);
+    } catch (err) {
+      if (err instanceof ApiError) {
+        setQuoteError(err.status === 404 ? 'Symbol not found.' : 'Could not fetch a price.');
         return;
       }
-      const body = (await res.json()) as { data?: StockResponse } | StockResponse;
-      const data = (body as { data?: StockResponse }).data ?? (body as StockResponse);
-      setQuote(data ?? null);
-    } catch {
       setQuoteError('Network error fetching price.');
     } finally {
       setQuoteLoading(false);
@@ -91,27 +83,19 @@ const Stock: React.FC = () => {
     setSeriesLoading(true);
     setSeriesError(null);
     try {
-      const res = await apiRequest<{ data?: HistoricalSeriesResponse }>(
-        `/market/stock/historical/series?symbol=${encodeURIComponent(symbol)}&days=${range.days}`,
-        { signal }
-      );
+      const res = await getSeries({ symbol, days: range.days });
+      if (signal?.aborted) return;
+      setSeries(res.data ?? null);
+    } catch (err) {
       if (signal?.aborted) return;
-      if (!res.ok) {
+      if (err instanceof ApiError) {
         setSeriesError(
-          res.status === 404
+          err.status === 404
             ? 'No historical data for this symbol.'
-            : `Couldn't load chart (${res.status}).`
+            : `Couldn't load chart (${err.status}).`
         );
         return;
       }
-      const body = (await res.json()) as
-        | { data?: HistoricalSeriesResponse }
-        | HistoricalSeriesResponse;
-      const data = (body as { data?: HistoricalSeriesResponse }).data ?? (body as HistoricalSeriesResponse);
-      if (signal?.aborted) return;
-      setSeries(data ?? null);
-    } catch (err) {
-      if ((err as { name?: string })?.name === 'AbortError') return;
       setSeriesError('Network error loading chart.');
     } finally {
       if (!signal?.aborted) setSeriesLoading(false);
@@ -143,24 +127,16 @@ const Stock: React.FC = () => {
     setWatchAdding(true);
     setWatchMessage(null);
     try {
-      const res = await apiRequest<WatchlistEntry>('/watchlist', {
-        method: 'POST',
-        body: JSON.stringify({ symbol }),
-      });
-      if (res.ok) {
-        setWatchMessage({ kind: 'ok', text: 'Added to watchlist.' });
-      } else if (res.status === 409) {
+      await addToWatchlist({ symbol });
+      setWatchMessage({ kind: 'ok', text: 'Added to watchlist.' });
+    } catch (err) {
+      if (err instanceof ApiError && err.status === 409) {
         setWatchMessage({ kind: 'ok', text: 'Already in your watchlist.' });
+      } else if (err instanceof ApiError) {
+        setWatchMessage({ kind: 'err', text: err.message || 'Could not add to watchlist.' });
       } else {
-        const body = await res.json().catch(() => null as unknown);
-        const msg =
-          (body && typeof body === 'object' && 'message' in body
-            ? String((body as { message: unknown }).message)
-            : null) || 'Could not add to watchlist.';
-        setWatchMessage({ kind: 'err', text: msg });
+        setWatchMessage({ kind: 'err', text: 'Network error.' });
       }
-    } catch {
-      setWatchMessage({ kind: 'err', text: 'Network error.' });
     } finally {
       setWatchAdding(false);
     }
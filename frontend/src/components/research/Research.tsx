@@ -195,10 +195,10 @@ const Research: React.FC = () => {
     setAnswer(null);
 
     try {
-      const result = await askResearch(
-        trimmedQuery,
-        parsedSymbols.length > 0 ? parsedSymbols : undefined
-      );
+      const result = await askResearch({
+        query: trimmedQuery,
+        symbols: parsedSymbols.length > 0 ? parsedSymbols : undefined,
+      });
 
       // Defensive: empty answer text with refused=false → treat as model_error refusal
       if (!result.refused && !result.answer?.trim()) {
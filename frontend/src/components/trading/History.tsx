@@ -1,7 +1,7 @@
 import React, { useCallback, useEffect, useMemo, useState } from 'react';
 import { Link } from 'react-router-dom';
-import { apiRequest } from '../../services/api';
-import { Trade, TradeHistoryResponse } from '../../types';
+import { ApiError, listTrades } from '../../services/api';
+import { Trade } from '../../types';
 import { formatInteger, formatMoney } from '../primitives/format';
 
 const PAGE_SIZE = 50;
@@ -38,33 +38,25 @@ const History: React.FC = () => {
     setLoading(true);
     setError(null);
 
-    const params = new URLSearchParams();
-    params.set('limit', String(PAGE_SIZE));
-    params.set('offset', String(offset));
-    if (symbolQuery) params.set('symbol', symbolQuery.toUpperCase());
-    if (actionFilter !== 'ALL') params.set('action', actionFilter);
-
     try {
-      const res = await apiRequest<TradeHistoryResponse>(
-        `/investments/history?${params.toString()}`
-      );
-      if (!res.ok) {
-        if (res.status === 401) {
-          setError('Authentication required. Please log in again.');
-        } else if (res.status === 400) {
-          const txt = await res.text().catch(() => '');
-          setError(txt || 'Invalid filter.');
-        } else {
-          setError(`Failed to load trade history (${res.status}).`);
-        }
-        setLoading(false);
-        return;
-      }
-      const data = (await res.json()) as TradeHistoryResponse;
+      const data = await listTrades({
+        limit: PAGE_SIZE,
+        offset,
+        symbol: symbolQuery ? symbolQuery.toUpperCase() : undefined,
+        action: actionFilter !== 'ALL' ? actionFilter : undefined,
+      });
       setTrades(Array.isArray(data.trades) ? data.trades : []);
       setTotal(typeof data.total === 'number' ? data.total : 0);
     } catch (e) {
-      setError(e instanceof Error ? e.message : 'Failed to load trade history.');
+      if (e instanceof ApiError && e.status === 401) {
+        setError('Authentication required. Please log in again.');
+      } else if (e instanceof ApiError && e.status === 400) {
+        setError(e.message || 'Invalid filter.');
+      } else if (e instanceof ApiError) {
+        setError(`Failed to load trade history (${e.status}).`);
+      } else {
+        setError(e instanceof Error ? e.message : 'Failed to load trade history.');
+      }
     } finally {
       setLoading(false);
     }
@@ -1,12 +1,11 @@
 import React, { useState, useEffect, useCallback, useMemo, useRef } from 'react';
-import { apiRequest } from '../../services/api';
+import { ApiError, buy, getDailyBar, getDailyBars, getQuote, sell } from '../../services/api';
 import {
   StockResponse,
   HistoricalDataResponse,
   User,
   BuyStockRequest,
   SellStockRequest,
-  UserStock,
 } from '../../types';
 import { useAuth } from '../../hooks/useAuth';
 import { useWatchlist } from '../../hooks/useWatchlist';
@@ -147,39 +146,27 @@ const TradeModal: React.FC<TradeModalProps> = ({ stock, user, isOpen, onClose, o
     }
 
     try {
-      const endpoint = tradeType === 'buy' ? '/investments/buy' : '/investments/sell';
       const body: BuyStockRequest | SellStockRequest = {
         symbol: stock.symbol,
         quantity: quantityNum,
       };
 
-      const response = await apiRequest<UserStock>(endpoint, {
-        method: 'POST',
-        body: JSON.stringify(body),
-      });
-
-      if (response.ok) {
-        await response.json();
-        setMessage(
-          `${tradeType === 'buy' ? 'Bought' : 'Sold'} ${quantityNum} ${stock.symbol}.`
-        );
-        await refreshUser();
-        setQuantity('');
-        setTimeout(() => {
-          onTradeSuccess();
-          onClose();
-        }, 1200);
+      await (tradeType === 'buy' ? buy(body) : sell(body));
+      setMessage(
+        `${tradeType === 'buy' ? 'Bought' : 'Sold'} ${quantityNum} ${stock.symbol}.`
+      );
+      await refreshUser();
+      setQuantity('');
+      setTimeout(() => {
+        onTradeSuccess();
+        onClose();
+      }, 1200);
+    } catch (err) {
+      if (err instanceof ApiError) {
+        setError(err.message || `${tradeType === 'buy' ? 'Buy' : 'Sell'} failed.`);
       } else {
-        const txt = await response.text();
-        try {
-          const j = JSON.parse(txt) as { message?: string };
-          setError(j.message || txt);
-        } catch {
-          setError(txt || `${tradeType === 'buy' ? 'Buy' : 'Sell'} failed.`);
-        }
+        setError('Network error. Please try again.');
       }
-    } catch {
-      setError('Network error. Please try again.');
     } finally {
       setLoading(false);
     }
@@ -518,20 +505,7 @@ const Markets: React.FC = () => {
       if (symbols.length === 0) return result;
 
       try {
-        const encoded = symbols.map((s) => encodeURIComponent(s)).join(',');
-        const response = await apiRequest<{ [s: string]: HistoricalDataResponse }>(
-          `/market/stock/historical/daily/batch?symbols=${encoded}`
-        );
-        if (!response.ok) return result;
-
-        const responseData = (await response.json()) as {
-          success: boolean;
-          message: string;
-          data: { [s: string]: HistoricalDataResponse };
-        };
-        const batchData =
-          responseData.data ||
-          (responseData as unknown as { [s: string]: HistoricalDataResponse });
+        const batchData = (await getDailyBars({ symbols: symbols.join(',') })).data ?? {};
 
         for (const symbol of symbols) {
           const h = batchData[symbol];
@@ -556,64 +530,38 @@ const Markets: React.FC = () => {
   );
 
   const fetchStockData = useCallback(async (symbol: string): Promise<StockData | null> => {
+    const listing = [...POPULAR_STOCKS, ...POPULAR_INDEXES].find((s) => s.symbol === symbol);
+    let h: HistoricalDataResponse | undefined;
     try {
-      const historicalResponse = await apiRequest<HistoricalDataResponse>(
-        `/market/stock/historical/daily?symbol=${symbol}`
-      );
-
-      if (!historicalResponse.ok) {
-        try {
-          const priceResponse = await apiRequest<StockResponse>(
-            `/market/stock?symbol=${symbol}`
-          );
-          if (!priceResponse.ok) return null;
-          const pdr = (await priceResponse.json()) as {
-            success: boolean;
-            message: string;
-            data: StockResponse;
-          };
-          const pd = pdr.data || (pdr as unknown as StockResponse);
-          const listing = [...POPULAR_STOCKS, ...POPULAR_INDEXES].find((s) => s.symbol === symbol);
-          return {
-            symbol: pd.symbol,
-            name: listing?.name || symbol,
-            type: listing?.type || 'stock',
-            price: pd.price,
-            change: 0,
-            changePercentage: 0,
-            date: pd.date,
-          };
-        } catch {
-          return null;
-        }
-      }
-
-      let h: HistoricalDataResponse;
+      h = (await getDailyBar({ symbol })).data;
+    } catch {
+      // No daily bar: fall back to the latest price, without a change.
       try {
-        const hdr = (await historicalResponse.json()) as {
-          success: boolean;
-          message: string;
-          data: HistoricalDataResponse;
+        const pd: StockResponse = (await getQuote({ symbol })).data;
+        return {
+          symbol: pd.symbol,
+          name: listing?.name || symbol,
+          type: listing?.type || 'stock',
+          price: pd.price,
+          change: 0,
+          changePercentage: 0,
+          date: pd.date,
         };
-        h = hdr.data || (hdr as unknown as HistoricalDataResponse);
       } catch {
         return null;
       }
-      if (!h || h.price === undefined) return null;
-
-      const listing = [...POPULAR_STOCKS, ...POPULAR_INDEXES].find((s) => s.symbol === symbol);
-      return {
-        symbol: h.symbol,
-        name: listing?.name || symbol,
-        type: listing?.type || 'stock',
-        price: h.price,
-        change: h.change || 0,
-        changePercentage: h.change_percentage || 0,
-        date: h.date,
-      };
-    } catch {
-      return null;
     }
+    if (!h || h.price === undefined) return null;
+
+    return {
+      symbol: h.symbol,
+      name: listing?.name || symbol,
+      type: listing?.type || 'stock',
+      price: h.price,
+      change: h.change || 0,
+      changePercentage: h.change_percentage || 0,
+      date: h.date,
+    };
   }, []);
 
   const processBatchData = useCallback((batch: Map<string, StockData>) => {
@@ -1,13 +1,7 @@
 import React, { useState, FormEvent, ChangeEvent, useEffect, useMemo, useRef } from 'react';
 import { useNavigate, useSearchParams } from 'react-router-dom';
-import { apiRequest } from '../../services/api';
-import {
-  TradeAction,
-  BuyStockRequest,
-  SellStockRequest,
-  UserStock,
-  StockResponse,
-} from '../../types';
+import { ApiError, buy, getQuote, sell } from '../../services/api';
+import { TradeAction, BuyStockRequest, SellStockRequest, StockResponse } from '../../types';
 import { useAuth } from '../../hooks/useAuth';
 import { formatMoney } from '../primitives/format';
 
@@ -65,28 +59,11 @@ const Trade: React.FC = () => {
       inflightRef.current = ctrl;
       setQuote({ status: 'loading', symbol: trimmedSymbol });
 
-      apiRequest<StockResponse>(`/market/stock?symbol=${encodeURIComponent(trimmedSymbol)}`, {
-        signal: ctrl.signal,
-      })
-        .then(async (res) => {
+      // Aborting marks a lookup superseded; its answer is dropped.
+      getQuote({ symbol: trimmedSymbol })
+        .then((res) => {
           if (ctrl.signal.aborted) return;
-          if (!res.ok) {
-            const message =
-              res.status === 400
-                ? 'Invalid symbol.'
-                : res.status === 404
-                ? 'Symbol not found.'
-                : res.status === 429
-                ? 'Too many lookups — try again in a moment.'
-                : 'Could not fetch a price.';
-            setQuote({ status: 'error', symbol: trimmedSymbol, message });
-            return;
-          }
-          const body = (await res.json()) as
-            | { data?: StockResponse; success?: boolean }
-            | StockResponse;
-          const data: StockResponse =
-            (body as { data?: StockResponse }).data ?? (body as StockResponse);
+          const data: StockResponse | undefined = res.data;
           if (!data || typeof data.price !== 'number') {
             setQuote({
               status: 'error',
@@ -103,7 +80,19 @@ const Trade: React.FC = () => {
           });
         })
         .catch((err: unknown) => {
-          if ((err as { name?: string })?.name === 'AbortError') return;
+          if (ctrl.signal.aborted) return;
+          if (err instanceof ApiError) {
+            const message =
+              err.status === 400
+                ? 'Invalid symbol.'
+                : err.status === 404
+                ? 'Symbol not found.'
+                : err.status === 429
+                ? 'Too many lookups — try again in a moment.'
+                : 'Could not fetch a price.';
+            setQuote({ status: 'error', symbol: trimmedSymbol, message });
+            return;
+          }
           setQuote({
             status: 'error',
             symbol: trimmedSymbol,
@@ -134,38 +123,26 @@ const Trade: React.FC = () => {
 
     setLoading(true);
     try {
-      const endpoint = tradeType === 'buy' ? '/investments/buy' : '/investments/sell';
       const requestBody: BuyStockRequest | SellStockRequest = {
         symbol: symbol.toUpperCase().trim(),
         quantity: quantityNum,
       };
 
-      const response = await apiRequest<UserStock>(endpoint, {
-        method: 'POST',
-        body: JSON.stringify(requestBody),
-      });
-
-      if (response.ok) {
-        await response.json();
-        setMessage(
-          `${tradeType === 'buy' ? 'Bought' : 'Sold'} ${quantityNum} shares of ${symbol
-            .toUpperCase()
-            .trim()}.`
-        );
-        await refreshUser();
-        setSymbol('');
-        setQuantity('');
+      await (tradeType === 'buy' ? buy(requestBody) : sell(requestBody));
+      setMessage(
+        `${tradeType === 'buy' ? 'Bought' : 'Sold'} ${quantityNum} shares of ${symbol
+          .toUpperCase()
+          .trim()}.`
+      );
+      await refreshUser();
+      setSymbol('');
+      setQuantity('');
+    } catch (err) {
+      if (err instanceof ApiError) {
+        setError(err.message || `${tradeType === 'buy' ? 'Buy' : 'Sell'} failed.`);
       } else {
-        const errorData = await response.text();
-        try {
-          const jsonError = JSON.parse(errorData) as { message?: string };
-          setError(jsonError.message || errorData);
-        } catch {
-          setError(errorData || `${tradeType === 'buy' ? 'Buy' : 'Sell'} failed.`);
-        }
+        setError('Network error. Please try again.');
       }
-    } catch {
-      setError('Network error. Please try again.');
     } finally {
       setLoading(false);
     }
@@ -6,8 +6,8 @@
  */
 
 import { useState, useEffect, useCallback, useRef } from 'react';
-import { User, AuthResponse } from '../types';
-import { apiRequest } from '../services/api';
+import { User } from '../types';
+import { ApiError, checkAuth as fetchAuthStatus, getProfile, logout as signOut } from '../services/api';
 
 interface UseAuthReturn {
   user: User | null;
@@ -54,6 +54,31 @@ const AUTH_CACHE_KEY = 'papertrader_auth_user';
 const AUTH_TIMESTAMP_KEY = 'papertrader_auth_timestamp';
 const AUTH_CACHE_DURATION = 5 * 60 * 1000; // 5 minutes
 
+/**
+ * Whether the session is signed in; an error response (401) means no, a
+ * network failure is thrown
+ */
+const isSignedIn = async (): Promise<boolean> => {
+  try {
+    return (await fetchAuthStatus()).success;
+  } catch (error) {
+    if (error instanceof ApiError) return false;
+    throw error;
+  }
+};
+
+/**
+ * The signed-in user's profile, or null when the server answers with an error
+ */
+const fetchProfile = async (): Promise<User | null> => {
+  try {
+    return await getProfile();
+  } catch (error) {
+    if (error instanceof ApiError) return null;
+    throw error;
+  }
+};
+
 /**
  * Custom hook for authentication state management
  */
@@ -68,19 +93,15 @@ export const useAuth = (): UseAuthReturn => {
    */
   const verifyAuthInBackground = useCallback(async () => {
     try {
-      const response = await apiRequest<AuthResponse>('/account/auth');
-      if (response.ok) {
-        const data = await response.json() as AuthResponse;
-        if (!data.success) {
-          // Auth expired, clear cache
-          globalAuthState.isAuthenticated = false;
-          globalAuthState.user = null;
-          localStorage.removeItem(AUTH_CACHE_KEY);
-          localStorage.removeItem(AUTH_TIMESTAMP_KEY);
-          
-          // Notify all listeners of state change
-          notifyListeners();
-        }
+      if (!(await isSignedIn())) {
+        // Auth expired, clear cache
+        globalAuthState.isAuthenticated = false;
+        globalAuthState.user = null;
+        localStorage.removeItem(AUTH_CACHE_KEY);
+        localStorage.removeItem(AUTH_TIMESTAMP_KEY);
+        
+        // Notify all listeners of state change
+        notifyListeners();
       }
     } catch (error) {
       // Silently fail in background check
@@ -135,36 +156,21 @@ export const useAuth = (): UseAuthReturn => {
     // Create a promise for the auth check
     globalAuthState.checkPromise = (async () => {
       try {
-        const response = await apiRequest<AuthResponse>('/account/auth');
-        
-        if (response.ok) {
-          const data = await response.json() as AuthResponse;
-          if (data.success) {
-            // Fetch user profile only if not cached or cache expired
-            const profileResponse = await apiRequest<User>('/account/profile');
-            if (profileResponse.ok) {
-              const userData = await profileResponse.json() as User;
-              
-              // Update global state
-              globalAuthState.user = userData;
-              globalAuthState.isAuthenticated = true;
-              
-              // Cache user data
-              try {
-                localStorage.setItem(AUTH_CACHE_KEY, JSON.stringify(userData));
-                localStorage.setItem(AUTH_TIMESTAMP_KEY, Date.now().toString());
-              } catch (error) {
-                console.warn('[useAuth] Error caching user data:', error);
-              }
-              
-              // Notify all listeners of state change
-              notifyListeners();
+        if (await isSignedIn()) {
+          // Fetch user profile only if not cached or cache expired
+          const userData = await fetchProfile();
+          if (userData) {
+            // Update global state
+            globalAuthState.user = userData;
+            globalAuthState.isAuthenticated = true;
+            
+            // Cache user data
+            try {
+              localStorage.setItem(AUTH_CACHE_KEY, JSON.stringify(userData));
+              localStorage.setItem(AUTH_TIMESTAMP_KEY, Date.now().toString());
+            } catch (error) {
+              console.warn('[useAuth] Error caching user data:', error);
             }
-          } else {
-            globalAuthState.isAuthenticated = false;
-            globalAuthState.user = null;
-            localStorage.removeItem(AUTH_CACHE_KEY);
-            localStorage.removeItem(AUTH_TIMESTAMP_KEY);
             
             // Notify all listeners of state change
             notifyListeners();
@@ -269,7 +275,7 @@ export const useAuth = (): UseAuthReturn => {
    */
   const logout = useCallback(async () => {
     try {
-      await apiRequest('/account/logout', { method: 'POST' });
+      await signOut();
     } catch (error) {
       console.error('[useAuth] Logout failed', error);
     } finally {
@@ -296,31 +302,25 @@ export const useAuth = (): UseAuthReturn => {
       localStorage.removeItem(AUTH_TIMESTAMP_KEY);
       
       // Check if authenticated first
-      const authResponse = await apiRequest<AuthResponse>('/account/auth');
-      if (authResponse.ok) {
-        const authData = await authResponse.json() as AuthResponse;
-        if (authData.success) {
-          // Fetch fresh user profile
-          const profileResponse = await apiRequest<User>('/account/profile');
-          if (profileResponse.ok) {
-            const userData = await profileResponse.json() as User;
-            
-            // Update global state
-            globalAuthState.user = userData;
-            globalAuthState.isAuthenticated = true;
-            
-            // Update cache with fresh data
-            try {
-              localStorage.setItem(AUTH_CACHE_KEY, JSON.stringify(userData));
-              localStorage.setItem(AUTH_TIMESTAMP_KEY, Date.now().toString());
-            } catch (error) {
-              console.warn('[useAuth] Error caching user data:', error);
-            }
-            
-            // Notify all listeners of state change
-            // This ensures Dashboard and all other components update immediately
-            notifyListeners();
+      if (await isSignedIn()) {
+        // Fetch fresh user profile
+        const userData = await fetchProfile();
+        if (userData) {
+          // Update global state
+          globalAuthState.user = userData;
+          globalAuthState.isAuthenticated = true;
+          
+          // Update cache with fresh data
+          try {
+            localStorage.setItem(AUTH_CACHE_KEY, JSON.stringify(userData));
+            localStorage.setItem(AUTH_TIMESTAMP_KEY, Date.now().toString());
+          } catch (error) {
+            console.warn('[useAuth] Error caching user data:', error);
           }
+          
+          // Notify all listeners of state change
+          // This ensures Dashboard and all other components update immediately
+          notifyListeners();
         }
       }
     } catch (error) {
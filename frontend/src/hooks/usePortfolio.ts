@@ -1,5 +1,5 @@
 import { useState, useCallback } from 'react';
-import { apiRequest } from '../services/api';
+import { ApiError, listHoldings } from '../services/api';
 import { UserStock } from '../types';
 
 interface UsePortfolioReturn {
@@ -24,35 +24,7 @@ export const usePortfolio = (): UsePortfolioReturn => {
     setLoading(true);
     
     try {
-      const response = await apiRequest<UserStock[]>('/investments');
-      
-      // Check response status first
-      if (!response.ok) {
-        const errorText = await response.text().catch(() => 'Unknown error');
-        
-        if (response.status === 401) {
-          setError('Authentication required. Please log in again.');
-        } else if (response.status === 404) {
-          setError('API endpoint not found. Please check that the server is running and the route is correct.');
-        } else if (response.status >= 500) {
-          setError(`Server error (${response.status}). Please try again later.`);
-        } else {
-          setError(`Failed to load portfolio (${response.status}): ${errorText}`);
-        }
-        setLoading(false);
-        return;
-      }
-      
-      // Check content-type
-      const contentType = response.headers.get('content-type');
-      if (contentType && !contentType.includes('application/json')) {
-        setError('Invalid response format from server. Expected JSON.');
-        setLoading(false);
-        return;
-      }
-
-      // Parse and validate response
-      const data = await response.json();
+      const data: unknown = await listHoldings();
       
       // Validate response is an array
       if (!Array.isArray(data)) {
@@ -74,11 +46,9 @@ export const usePortfolio = (): UsePortfolioReturn => {
         }
         
         validStocks.push({
+          ...stock,
           id: stock.id || '',
           user_id: stock.user_id || '',
-          symbol: stock.symbol,
-          quantity: stock.quantity,
-          avg_price: stock.avg_price,
           total: stock.total || stock.avg_price * stock.quantity,
           current_stock_price: stock.current_stock_price || 0,
           created_at: stock.created_at || '',
@@ -89,7 +59,17 @@ export const usePortfolio = (): UsePortfolioReturn => {
       setStocks(validStocks);
       
     } catch (error) {
-      if (error instanceof SyntaxError) {
+      if (error instanceof ApiError) {
+        if (error.status === 401) {
+          setError('Authentication required. Please log in again.');
+        } else if (error.status === 404) {
+          setError('API endpoint not found. Please check that the server is running and the route is correct.');
+        } else if (error.status >= 500) {
+          setError(`Server error (${error.status}). Please try again later.`);
+        } else {
+          setError(`Failed to load portfolio (${error.status}): ${error.message}`);
+        }
+      } else if (error instanceof SyntaxError) {
         setError('Failed to parse server response. Please check the server is running correctly.');
       } else if (error instanceof Error) {
         setError(`Error loading portfolio: ${error.message}`);
@@ -0,0 +1,3051 @@
+// Code generated by apigen from docs/openapi.yaml; DO NOT EDIT.
+// Regenerate with scripts/generate-clients.sh.
+
+import { request } from './http';
+
+export interface ErrorBody {
+  success: boolean;
+  message: string;
+  error_code?: string;
+}
+
+export interface Status {
+  success: boolean;
+  message: string;
+}
+
+export interface CSRFToken {
+  csrf_token: string;
+}
+
+export interface User {
+  id: string;
+  email: string;
+  created_at: string;
+  balance: number;
+  email_verified: boolean;
+  /** How the account was created, e.g. password, google or guest */
+  created_via: string;
+  avatar_url?: string;
+  username?: string;
+  is_guest: boolean;
+  guest_expires_at?: string;
+  display_currency: string;
+  after_hours_orders: 'REJECT' | 'QUEUE';
+  league?: string;
+  cost_basis_method: 'FIFO' | 'LIFO' | 'AVERAGE';
+  trade_confirmation_emails: boolean;
+  timezone: string;
+  role: 'user' | 'admin';
+}
+
+export interface AuthResponse {
+  success: boolean;
+  message: string;
+  user?: User;
+  /** The session token, for clients that can't keep cookies */
+  token?: string;
+}
+
+export interface RegisterRequest {
+  email: string;
+  password: string;
+  username?: string;
+  /** Required when registration is invite-only */
+  invite_code?: string;
+}
+
+export interface LoginRequest {
+  email: string;
+  password: string;
+}
+
+export interface GoogleLoginRequest {
+  /** A Google ID token */
+  token: string;
+  /** Needed only when the sign-in creates an account and registration is invite-only */
+  invite_code?: string;
+}
+
+export interface GuestRequest {
+  invite_code?: string;
+}
+
+/** Either email and password, or google_token */
+export interface GuestUpgradeRequest {
+  email?: string;
+  password?: string;
+  google_token?: string;
+}
+
+export interface EmailRequest {
+  email: string;
+}
+
+export interface EnabledRequest {
+  enabled: boolean;
+}
+
+export interface UsernameAvailability {
+  username: string;
+  available: boolean;
+}
+
+/** Password accounts send password; Google accounts a fresh Google ID token */
+export interface SudoRequest {
+  password?: string;
+  google_token?: string;
+}
+
+export interface SudoResponse {
+  success: boolean;
+  message: string;
+  expires_at: string;
+}
+
+export interface AvatarResponse {
+  success: boolean;
+  message: string;
+  avatar_url: string;
+}
+
+export interface UsernameResponse {
+  success: boolean;
+  message: string;
+  username: string;
+}
+
+export interface DisplayCurrencyResponse {
+  success: boolean;
+  message: string;
+  display_currency: string;
+}
+
+export interface TimezoneResponse {
+  success: boolean;
+  message: string;
+  timezone: string;
+}
+
+export interface AfterHoursOrdersResponse {
+  success: boolean;
+  message: string;
+  after_hours_orders: 'REJECT' | 'QUEUE';
+}
+
+export interface CostBasisMethodResponse {
+  success: boolean;
+  message: string;
+  cost_basis_method: 'FIFO' | 'LIFO' | 'AVERAGE';
+}
+
+export interface TradeConfirmationEmailsResponse {
+  success: boolean;
+  message: string;
+  trade_confirmation_emails: boolean;
+}
+
+export interface StatementEmailsResponse {
+  success: boolean;
+  message: string;
+  statement_emails: boolean;
+}
+
+export interface ResetConfirmation {
+  success: boolean;
+  confirmation_token: string;
+  expires_at: string;
+}
+
+/**
+ * PublicKeyCredentialCreationOptions or PublicKeyCredentialRequestOptions, with binary fields
+ * base64url-encoded
+ */
+export interface WebAuthnOptions {
+  publicKey: Record<string, unknown>;
+  mediation?: string;
+}
+
+/** The PublicKeyCredential from the browser, with binary fields base64url-encoded */
+export interface WebAuthnCredential {
+}
+
+export interface Passkey {
+  /** The base64url credential ID */
+  id: string;
+  name: string;
+  created_at: string;
+  last_used_at?: string;
+}
+
+export interface TradeLimits {
+  trades_today: number;
+  /** 0 is unlimited */
+  max_trades_per_day: number;
+  /** null when unlimited */
+  trades_remaining: number | null;
+  resets_at: string;
+  pdt?: PDTStatus;
+}
+
+/** Absent when the pattern-day-trader rule is off */
+export interface PDTStatus {
+  /** Equity is under the threshold */
+  applies: boolean;
+  equity: number;
+  equity_threshold: number;
+  day_trades_in_window: number;
+  max_day_trades: number;
+  window_start: string;
+}
+
+export interface UsageReport {
+  generated_at: string;
+  rate_limits: RateLimitConsumption[];
+  totals: Record<string, UsageTotals>;
+  hourly: UsageHour[];
+}
+
+export interface RateLimitConsumption {
+  name: string;
+  scope: string;
+  used: number;
+  limit: number;
+  remaining: number;
+  window_seconds: number;
+}
+
+export interface UsageTotals {
+  current_hour: number;
+  last_24_hours: number;
+}
+
+export interface UsageHour {
+  hour: string;
+  requests: number;
+  throttled: number;
+  market_data: number;
+}
+
+export interface AccountStatement {
+  month: string;
+  opening?: StatementBalance;
+  closing?: StatementBalance;
+  trades: number;
+  buys: number;
+  sells: number;
+  fees: number;
+  dividends: number;
+  bonus_cash?: number;
+  net_performance?: number;
+  net_performance_percent?: number;
+  /** The month is over, so the figures won't change */
+  final: boolean;
+  currency?: string;
+}
+
+export interface StatementBalance {
+  date: string;
+  cash: number;
+  total_value: number;
+}
+
+export interface StatementReport {
+  month: string;
+  sent_at: string;
+}
+
+export interface StatementReportList {
+  reports: StatementReport[];
+}
+
+export interface Goal {
+  id: string;
+  user_id: string;
+  kind: 'VALUE' | 'RETURN';
+  name: string;
+  target: number;
+  target_date?: string;
+  baseline_value?: number;
+  last_milestone: number;
+  achieved_on?: string;
+  created_at: string;
+  status: 'active' | 'achieved' | 'missed';
+  /** Percent of the way to the target */
+  progress: number;
+  current_value?: number;
+  current_return?: number;
+  as_of?: string;
+}
+
+export interface GoalList {
+  goals: Goal[];
+}
+
+export interface GoalRequest {
+  kind: 'VALUE' | 'RETURN';
+  name: string;
+  target: number;
+  target_date?: string;
+}
+
+export interface BonusStatus {
+  enabled: boolean;
+  amount: number;
+  cooldown_seconds: number;
+  available: boolean;
+  last_claimed_at?: string;
+  next_claim_at?: string;
+  total_claimed: number;
+}
+
+export interface BonusClaim {
+  amount: number;
+  balance: number;
+  next_claim_at: string;
+}
+
+export interface AccountBundle {
+  /** The signed account state; import it byte for byte as exported */
+  payload: Record<string, unknown>;
+  /** Base64 Ed25519 signature of the payload */
+  signature: string;
+  key_id: string;
+}
+
+export interface AccountImport {
+  source?: string;
+  exported_at: string;
+  replaced: boolean;
+  holdings: number;
+  tax_lots: number;
+  trades: number;
+}
+
+export interface Holding {
+  id: string;
+  user_id: string;
+  symbol: string;
+  asset_class: 'equity' | 'crypto';
+  /** Negative for a short position */
+  quantity: number;
+  avg_price: number;
+  total: number;
+  /** Cash held against a short position */
+  margin: number;
+  current_stock_price: number;
+  /** Absent when there is no current price */
+  unrealized_pnl?: number;
+  currency?: string;
+  /** Shares pending sell orders would sell */
+  quantity_on_hold: number;
+  /** Set on a trade's result when it filled at the last known price during a provider outage */
+  stale?: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface TradeRequest {
+  symbol: string;
+  /** Whole shares, or a fraction of a crypto unit */
+  quantity: number;
+}
+
+export interface QueuedTrade {
+  queued: boolean;
+  order: Order;
+  next_open: string;
+}
+
+export interface Trade {
+  id: string;
+  user_id: string;
+  symbol: string;
+  action: 'BUY' | 'SELL' | 'SHORT' | 'COVER';
+  quantity: number;
+  price: number;
+  total: number;
+  executed_at: string;
+  status: 'PENDING' | 'COMPLETED' | 'FAILED';
+  idempotency_key?: string;
+  order_type: 'MARKET' | 'LIMIT' | 'STOP' | 'STOP_LOSS' | 'TAKE_PROFIT' | 'ADJUSTMENT';
+  asset_class: 'equity' | 'crypto';
+  /** Per share, how far the price moved from the quote under the simulated spread */
+  slippage: number;
+}
+
+export interface TradePage {
+  trades: Trade[];
+  /** Every trade matching the filters */
+  total: number;
+  limit: number;
+  offset: number;
+  page: number;
+}
+
+export interface TradeExport {
+  success: boolean;
+  data: Trade[];
+  count: number;
+  truncated: boolean;
+}
+
+export interface PortfolioSnapshot {
+  date: string;
+  cash: number;
+  holdings_value: number;
+  total_value: number;
+  /** Some holdings had no close that day and are left out */
+  partial: boolean;
+  bonus_cash: number;
+}
+
+export interface PortfolioHistory {
+  range: string;
+  points: PortfolioSnapshot[];
+  currency?: string;
+}
+
+export interface PortfolioValue {
+  cash: number;
+  cash_on_hold: number;
+  buying_power: number;
+  holdings_value: number;
+  total_value: number;
+  partial: boolean;
+  bonus_cash: number;
+  previous_date?: string;
+  previous_value?: number;
+  day_change?: number;
+  day_change_percent?: number;
+  as_of: string;
+  currency?: string;
+}
+
+export interface TradeHighlight {
+  trade_id: string;
+  symbol: string;
+  action: 'SELL' | 'COVER';
+  quantity: number;
+  gain: number;
+  executed_at: string;
+}
+
+export interface TradingStats {
+  closed_trades: number;
+  longest_winning_streak: number;
+  longest_losing_streak: number;
+  best_trade?: TradeHighlight;
+  worst_trade?: TradeHighlight;
+  average_hold_days?: number;
+  current_value: number;
+  peak_value: number;
+  peak_date: string;
+  drawdown: number;
+  drawdown_percent: number;
+  currency?: string;
+}
+
+export interface SeriesPoint {
+  date: string;
+  close: number;
+}
+
+export interface TradeMarker {
+  trade_id: string;
+  action: 'BUY' | 'SELL' | 'SHORT' | 'COVER';
+  quantity: number;
+  price: number;
+  date: string;
+  executed_at: string;
+  order_type: string;
+}
+
+export interface TradeReplay {
+  symbol: string;
+  from: string;
+  to: string;
+  points: SeriesPoint[];
+  trades: TradeMarker[];
+  truncated: boolean;
+  currency?: string;
+}
+
+export interface BenchmarkPoint {
+  date: string;
+  portfolio_value: number;
+  portfolio_return: number;
+  benchmark_close: number;
+  benchmark_return: number;
+}
+
+export interface BenchmarkComparison {
+  range: string;
+  symbol: string;
+  points: BenchmarkPoint[];
+  portfolio_return: number;
+  benchmark_return: number;
+  relative_return: number;
+  currency?: string;
+}
+
+export interface SeriesRisk {
+  volatility: number | null;
+  max_drawdown: number | null;
+  max_drawdown_peak?: string;
+  max_drawdown_trough?: string;
+}
+
+export interface RiskMetrics {
+  range: string;
+  symbol: string;
+  /** Daily returns the statistics use */
+  days: number;
+  risk_free_rate: number;
+  volatility: number | null;
+  max_drawdown: number | null;
+  max_drawdown_peak?: string;
+  max_drawdown_trough?: string;
+  sharpe: number | null;
+  beta: number | null;
+  benchmark: SeriesRisk;
+}
+
+export interface TradeSearchResult {
+  id: string;
+  user_id: string;
+  symbol: string;
+  action: 'BUY' | 'SELL' | 'SHORT' | 'COVER';
+  quantity: number;
+  price: number;
+  total: number;
+  executed_at: string;
+  status: string;
+  idempotency_key?: string;
+  order_type: string;
+  asset_class: string;
+  slippage: number;
+  note: string;
+  tags: string[];
+  rank: number;
+  /** The note excerpt with matches marked; empty when the note has no match */
+  highlight: string;
+}
+
+export interface TradeSearchPage {
+  results: TradeSearchResult[];
+  total: number;
+  limit: number;
+  offset: number;
+}
+
+export interface SymbolPnL {
+  symbol: string;
+  /** The open position; negative when short */
+  quantity: number;
+  avg_price: number;
+  current_price: number;
+  realized: number;
+  unrealized?: number;
+  closed_quantity: number;
+}
+
+export interface PnLReport {
+  realized: number;
+  unrealized: number;
+  total: number;
+  partial: boolean;
+  symbols: SymbolPnL[];
+  from?: string;
+  to?: string;
+  currency?: string;
+  cost_basis_method: 'FIFO' | 'LIFO' | 'AVERAGE';
+}
+
+export interface TaxLot {
+  id: string;
+  symbol: string;
+  /** Empty for lots backfilled from a holding */
+  trade_id?: string;
+  quantity: number;
+  remaining: number;
+  price: number;
+  acquired_at: string;
+}
+
+export interface LotsReport {
+  method: 'FIFO' | 'LIFO' | 'AVERAGE';
+  lots: TaxLot[];
+  currency: string;
+}
+
+export interface TradeNoteRequest {
+  note: string;
+  tags: string[];
+}
+
+export interface TradeNote {
+  trade_id: string;
+  note: string;
+  tags: string[];
+  updated_at: string;
+}
+
+export interface TradeDispute {
+  id: string;
+  trade_id: string;
+  user_id: string;
+  reason: string;
+  status: 'OPEN' | 'REVERSED' | 'REJECTED';
+  resolution_note?: string;
+  resolved_by?: string;
+  reversal_trade_id?: string;
+  created_at: string;
+  resolved_at?: string;
+}
+
+export interface TradeDisputeList {
+  items: TradeDispute[];
+}
+
+export interface Order {
+  id: string;
+  user_id: string;
+  symbol: string;
+  side: 'BUY' | 'SELL';
+  order_type: 'MARKET' | 'LIMIT' | 'STOP' | 'STOP_LOSS' | 'TAKE_PROFIT';
+  quantity: number;
+  time_in_force: 'DAY' | 'GTC';
+  trigger_price?: number;
+  status: 'WAITING' | 'PENDING' | 'FILLED' | 'CANCELLED' | 'EXPIRED' | 'FAILED';
+  created_at: string;
+  expires_at?: string;
+  closed_at?: string;
+  trade_id?: string;
+  fill_price?: number;
+  failure_reason?: string;
+  oco_group_id?: string;
+  idempotency_key?: string;
+  parent_order_id?: string;
+}
+
+export interface OrderList {
+  orders: Order[];
+}
+
+export interface OrderRequest {
+  symbol: string;
+  /** May be omitted for STOP_LOSS and TAKE_PROFIT */
+  side?: 'BUY' | 'SELL';
+  type: 'MARKET' | 'LIMIT' | 'STOP' | 'STOP_LOSS' | 'TAKE_PROFIT';
+  quantity: number;
+  /** Omitted for MARKET */
+  trigger_price?: number;
+  time_in_force?: 'DAY' | 'GTC';
+  /** GTC only */
+  expires_at?: string;
+}
+
+export interface OCORequest {
+  symbol: string;
+  quantity: number;
+  take_profit: number;
+  stop_loss: number;
+  time_in_force?: 'DAY' | 'GTC';
+  expires_at?: string;
+}
+
+export interface OCOOrders {
+  oco_group_id: string;
+  take_profit: Order;
+  stop_loss: Order;
+}
+
+export interface BracketRequest {
+  symbol: string;
+  quantity: number;
+  entry_type?: 'MARKET' | 'LIMIT' | 'STOP';
+  /** Omitted for MARKET */
+  entry_price?: number;
+  take_profit: number;
+  stop_loss: number;
+  time_in_force?: 'DAY' | 'GTC';
+  expires_at?: string;
+}
+
+export interface BracketOrders {
+  entry: Order;
+  oco_group_id: string;
+  take_profit: Order;
+  stop_loss: Order;
+}
+
+export interface RebalanceRequest {
+  /** Percent of account value by symbol */
+  targets: Record<string, number>;
+  /** Place the orders; otherwise they are only previewed */
+  execute?: boolean;
+}
+
+export interface RebalancePosition {
+  symbol: string;
+  price: number;
+  current_quantity: number;
+  current_weight: number;
+  target_weight: number;
+  target_quantity: number;
+}
+
+export interface RebalanceOrder {
+  symbol: string;
+  side: 'BUY' | 'SELL';
+  quantity: number;
+  price: number;
+  amount: number;
+  status?: string;
+  error?: string;
+  error_code?: string;
+}
+
+export interface RebalancePlan {
+  total_value: number;
+  cash: number;
+  projected_cash: number;
+  positions: RebalancePosition[];
+  orders: RebalanceOrder[];
+  executed: boolean;
+}
+
+export interface RecurringInvestment {
+  id: string;
+  user_id: string;
+  symbol: string;
+  amount: number;
+  frequency: 'DAILY' | 'WEEKLY' | 'MONTHLY';
+  day?: number;
+  active: boolean;
+  next_run_on: string;
+  last_run_on?: string;
+  last_trade_id?: string;
+  last_error?: string;
+  created_at: string;
+}
+
+export interface RecurringInvestmentList {
+  recurring_investments: RecurringInvestment[];
+}
+
+export interface RecurringInvestmentRequest {
+  symbol: string;
+  /** Dollars to buy each run */
+  amount: number;
+  frequency: 'DAILY' | 'WEEKLY' | 'MONTHLY';
+  /**
+   * The weekday (1 = Monday .. 5 = Friday) for WEEKLY, the day of the month (1-28) for MONTHLY;
+   * omitted for DAILY
+   */
+  day?: number;
+}
+
+export interface StockQuote {
+  symbol: string;
+  date: string;
+  /** In USD */
+  price: number;
+  /** Set when a display currency was requested */
+  currency?: string;
+  price_decimals?: number;
+  /** In the listing's own currency, for instruments not quoted in USD */
+  listing_price?: number;
+  listing_currency?: string;
+  /** The provider failed and this is the last cached quote */
+  stale?: boolean;
+  fetched_at?: string;
+}
+
+export interface QuoteResponse {
+  success: boolean;
+  message: string;
+  data: StockQuote;
+}
+
+export interface HistoricalData {
+  symbol: string;
+  date: string;
+  previous_price: number;
+  price: number;
+  volume: number;
+  change: number;
+  change_percentage: number;
+}
+
+export interface DailyBarResponse {
+  success: boolean;
+  message: string;
+  data: HistoricalData;
+}
+
+export interface DailyBarBatchResponse {
+  success: boolean;
+  message: string;
+  /** By symbol; symbols with no bar are left out */
+  data: Record<string, HistoricalData>;
+}
+
+export interface HistoricalSeries {
+  symbol: string;
+  from: string;
+  to: string;
+  points: SeriesPoint[];
+}
+
+export interface SeriesResponse {
+  success: boolean;
+  message: string;
+  data: HistoricalSeries;
+}
+
+export interface Candle {
+  time: string;
+  open: number;
+  high: number;
+  low: number;
+  close: number;
+  volume: number;
+}
+
+export interface Chart {
+  symbol: string;
+  range: string;
+  interval: string;
+  candles: Candle[];
+}
+
+export interface ChartResponse {
+  success: boolean;
+  message: string;
+  data: Chart;
+}
+
+export interface RecentlyViewed {
+  symbol: string;
+  viewed_at: string;
+}
+
+export interface RecentlyViewedResponse {
+  success: boolean;
+  message: string;
+  data: RecentlyViewed[];
+}
+
+export interface Conversion {
+  from: string;
+  to: string;
+  rate: number;
+  date: string;
+  amount: number;
+  converted: number;
+}
+
+export interface ConversionResponse {
+  success: boolean;
+  message: string;
+  data: Conversion;
+}
+
+export interface Exchange {
+  mic: string;
+  name: string;
+  currency: string;
+  timezone: string;
+}
+
+export interface MarketSession {
+  open: string;
+  close: string;
+  early_close: boolean;
+}
+
+export interface MarketStatus {
+  exchange: Exchange;
+  is_open: boolean;
+  session: MarketSession;
+}
+
+export interface MarketHoursResponse {
+  success: boolean;
+  message: string;
+  data: MarketStatus;
+}
+
+export interface CompanyProfile {
+  symbol: string;
+  name: string;
+  exchange: string;
+  sector: string;
+  industry: string;
+  market_cap: number | null;
+  description: string;
+}
+
+export interface CompanyResponse {
+  success: boolean;
+  message: string;
+  data: CompanyProfile;
+}
+
+export interface SymbolMatch {
+  symbol: string;
+  name: string;
+  exchange: string;
+}
+
+export interface SymbolSearchResponse {
+  success: boolean;
+  message: string;
+  data: SymbolMatch[];
+}
+
+export interface Movers {
+  gainers: HistoricalData[];
+  losers: HistoricalData[];
+  most_active: HistoricalData[];
+  universe: number;
+  updated_at: string;
+}
+
+export interface MoversResponse {
+  success: boolean;
+  message: string;
+  data: Movers;
+}
+
+export interface NewsArticle {
+  headline: string;
+  summary: string;
+  source: string;
+  url: string;
+  image_url?: string;
+  published_at: string;
+  symbols: string[];
+}
+
+export interface NewsFeed {
+  symbol?: string;
+  articles: NewsArticle[];
+  provider: string;
+  updated_at: string;
+}
+
+export interface NewsResponse {
+  success: boolean;
+  message: string;
+  data: NewsFeed;
+}
+
+export interface Classification {
+  symbol: string;
+  sector: string;
+  industry: string;
+  indices: string[];
+  source: string;
+  updated_at: string;
+}
+
+export interface ClassificationResponse {
+  success: boolean;
+  message: string;
+  data: Classification;
+}
+
+export interface ClassificationListResponse {
+  success: boolean;
+  message: string;
+  data: Classification[];
+}
+
+export interface LiveEvent {
+  type: 'price' | 'portfolio' | 'subscribed' | 'error' | 'heartbeat';
+  data?: StockQuote;
+  value?: PortfolioValue;
+  symbols?: string[];
+  message?: string;
+}
+
+export interface WatchlistEntry {
+  id: string;
+  symbol: string;
+  created_at: string;
+  price: number;
+  change: number;
+  change_percentage: number;
+  has_price: boolean;
+}
+
+export interface CuratedListItem {
+  symbol: string;
+  price: number;
+  change: number;
+  change_percentage: number;
+  has_price: boolean;
+}
+
+export interface CuratedListView {
+  slug: string;
+  name: string;
+  description: string;
+  is_default: boolean;
+  subscribed: boolean;
+  items: CuratedListItem[];
+}
+
+export interface Watchlist {
+  items: WatchlistEntry[];
+  lists: CuratedListView[];
+}
+
+export interface CuratedList {
+  slug: string;
+  name: string;
+  description: string;
+  symbols: string[];
+  is_default: boolean;
+  subscribed: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CuratedListList {
+  items: CuratedList[];
+}
+
+export interface Notification {
+  id: string;
+  user_id: string;
+  kind: string;
+  title: string;
+  body: string;
+  read_at?: string;
+  created_at: string;
+}
+
+export interface NotificationList {
+  items: Notification[];
+  unread_count: number;
+}
+
+export interface StressTestRequest {
+  /** A built-in scenario's id; omit to use shocks alone */
+  scenario?: string;
+  /** Percent moves by sector, overriding the scenario's */
+  shocks?: Record<string, number>;
+  /** Percent move of the benchmark, overriding the scenario's */
+  market?: number | null;
+}
+
+export interface StressScenario {
+  id: string;
+  name: string;
+  market: number;
+  sectors: Record<string, number>;
+}
+
+export interface StressPosition {
+  symbol: string;
+  sector?: string;
+  quantity: number;
+  price: number;
+  value: number;
+  /** What the shock came from, e.g. sector or beta */
+  basis: string;
+  beta?: number;
+  shock: number;
+  projected_change: number;
+}
+
+export interface StressTestResult {
+  scenario: StressScenario;
+  benchmark: string;
+  cash: number;
+  holdings_value: number;
+  total_value: number;
+  projected_change: number;
+  projected_change_pct: number;
+  projected_value: number;
+  positions: StressPosition[];
+}
+
+export interface ResearchAskRequest {
+  query: string;
+  symbols?: string[];
+  /** Passages to retrieve */
+  k?: number;
+  min_score?: number;
+}
+
+export interface ResearchCitation {
+  chunk_id: string;
+  source_url: string;
+  symbol?: string;
+  filed_at?: string;
+  excerpt: string;
+  score: number;
+}
+
+export interface ResearchAnswer {
+  query_id: string;
+  answer: string;
+  citations: ResearchCitation[];
+  refused: boolean;
+  refusal_reason?: string;
+  latency_ms: number;
+}
+
+export interface HaltRequest {
+  reason: string;
+}
+
+export interface PrecisionRequest {
+  tick_size: number;
+  price_decimals: number;
+}
+
+export interface Instrument {
+  symbol: string;
+  name: string;
+  /** ISO 10383 MIC, e.g. XNAS */
+  exchange: string;
+  asset_type: string;
+  currency: string;
+  created_at: string;
+  updated_at: string;
+  halted: boolean;
+  halt_reason?: string;
+  halt_source?: 'admin' | 'provider';
+  halted_at?: string;
+  tick_size: number;
+  price_decimals: number;
+}
+
+export interface InstrumentList {
+  items: Instrument[];
+}
+
+export interface AuditEvent {
+  id: string;
+  user_id?: string;
+  kind: string;
+  ip_address?: string;
+  /** ISO 3166-1 alpha-2 */
+  country?: string;
+  details?: Record<string, unknown>;
+  flagged: boolean;
+  created_at: string;
+}
+
+export interface AuditEventList {
+  items: AuditEvent[];
+}
+
+export interface RateLimitBucket {
+  name: string;
+  user_limit: number;
+  ip_limit: number;
+  window_seconds: number;
+}
+
+export interface RateLimitCounts {
+  allowed: number;
+  denied: number;
+  errors: number;
+}
+
+export interface RateLimiterMetrics {
+  backend: string;
+  since: string;
+  buckets: Record<string, RateLimitCounts>;
+}
+
+export interface RateLimitOverview {
+  buckets: RateLimitBucket[];
+  metrics: RateLimiterMetrics;
+}
+
+export interface RateLimitConsumer {
+  id: string;
+  count: number;
+}
+
+export interface RateLimitConsumerList {
+  items: RateLimitConsumer[];
+}
+
+export interface RateLimitWindow {
+  bucket: string;
+  scope: 'user' | 'ip';
+  id: string;
+  count: number;
+  limit: number;
+  remaining: number;
+  window_seconds: number;
+  oldest_at?: string;
+  newest_at?: string;
+  /** When the oldest request leaves the window; absent when the window has room */
+  frees_at?: string;
+}
+
+export interface CuratedListRequest {
+  name: string;
+  description?: string;
+  symbols: string[];
+  is_default?: boolean;
+}
+
+export interface ClassificationReload {
+  written: number;
+}
+
+export interface ClassificationRequest {
+  sector: string;
+  industry: string;
+  indices?: string[];
+}
+
+export interface SymbolRenameRequest {
+  new_symbol: string;
+  effective_date: string;
+}
+
+export interface SymbolAlias {
+  old_symbol: string;
+  new_symbol: string;
+  effective_date: string;
+  source: string;
+  migrated_at?: string;
+  created_at: string;
+}
+
+export interface SymbolRenameList {
+  items: SymbolAlias[];
+}
+
+export interface UserImportResult {
+  line: number;
+  email: string;
+  status: 'created' | 'exists' | 'invalid';
+  user_id?: string;
+  invited: boolean;
+  error?: string;
+}
+
+export interface UserImportReport {
+  created: number;
+  existing: number;
+  invalid: number;
+  invited: number;
+  results: UserImportResult[];
+}
+
+export interface UserStats {
+  id: string;
+  email: string;
+  username?: string;
+  league?: string;
+  created_via: string;
+  created_at: string;
+  balance: number;
+  positions: number;
+  holdings_cost: number;
+  trade_count: number;
+  last_trade_at?: string;
+}
+
+export interface UserStatsList {
+  items: UserStats[];
+}
+
+export interface AdminUser {
+  id: string;
+  email: string;
+  username?: string;
+  league?: string;
+  created_via: string;
+  created_at: string;
+  balance: number;
+  positions: number;
+  holdings_cost: number;
+  trade_count: number;
+  last_trade_at?: string;
+  role: 'user' | 'admin';
+  email_verified: boolean;
+  /** When the failed-login lock ends */
+  locked_until?: string;
+  /** When an admin's lock ends; only an unlock lifts it sooner */
+  admin_locked_until?: string;
+}
+
+export interface AdminUserList {
+  items: AdminUser[];
+}
+
+export interface BalanceAdjustmentRequest {
+  /** Positive to credit, negative to debit */
+  amount: number;
+  reason: string;
+}
+
+export interface BalanceAdjustment {
+  user_id: string;
+  amount: number;
+  balance: number;
+  reason: string;
+}
+
+export interface UserLockRequest {
+  until: string;
+  reason: string;
+}
+
+export interface InviteCodeRequest {
+  count?: number;
+  max_uses?: number;
+  expires_at?: string | null;
+  note?: string;
+  starting_balance?: number | null;
+  league?: string;
+}
+
+export interface InviteCode {
+  code: string;
+  note: string;
+  max_uses: number;
+  uses: number;
+  starting_balance?: number;
+  league?: string;
+  expires_at: string | null;
+  revoked_at: string | null;
+  created_by?: string;
+  created_at: string;
+  status: string;
+}
+
+export interface InviteCodeList {
+  items: InviteCode[];
+}
+
+export interface InviteRedemption {
+  user_id: string;
+  /** Empty for guests */
+  email?: string;
+  redeemed_at: string;
+}
+
+export interface InviteCodeDetail {
+  code: string;
+  note: string;
+  max_uses: number;
+  uses: number;
+  starting_balance?: number;
+  league?: string;
+  expires_at: string | null;
+  revoked_at: string | null;
+  created_by?: string;
+  created_at: string;
+  status: string;
+  redemptions: InviteRedemption[];
+}
+
+export interface EODRerunRequest {
+  date: string;
+}
+
+export interface EODRerun {
+  session_date: string;
+  symbols: number;
+  closes: number;
+  corrected: string[];
+  failed_steps: string[];
+}
+
+export interface MarketCacheInvalidateRequest {
+  symbols?: string[];
+  kinds?: string[];
+  all?: boolean;
+}
+
+export interface MarketCacheInvalidated {
+  symbols: string[];
+  kinds: string[];
+  all: boolean;
+  deleted: number;
+}
+
+export interface QuotaUsage {
+  provider: string;
+  day: string;
+  /** UTC, YYYY-MM */
+  month: string;
+  day_requests: number;
+  month_requests: number;
+  /** 0 when not configured */
+  monthly_quota: number;
+  remaining: number | null;
+  shed_percent: number;
+  shedding: boolean;
+  resets_at: string;
+}
+
+export interface TradeDisputeResolution {
+  note?: string;
+}
+
+export interface RetentionRun {
+  started_at: string;
+  warned: number;
+  anonymized: number;
+  error?: string;
+}
+
+export interface RetentionReport {
+  enabled: boolean;
+  inactive_days: number;
+  grace_days: number;
+  /** Past the threshold, not yet warned */
+  inactive?: number;
+  /** Warned, awaiting a login or anonymization */
+  warned?: number;
+  anonymized?: number;
+  last_run?: RetentionRun;
+}
+
+export interface VerifyEmailParams {
+  token: string;
+  /**
+   * Where to send the browser afterwards, with `status=verified` or `status=failed` added; on the
+   * FRONTEND_URL origin or the MOBILE_APP_SCHEME scheme
+   */
+  redirect?: string;
+}
+
+export interface CheckUsernameParams {
+  name: string;
+}
+
+export interface GetStatementParams {
+  /** YYYY-MM; the current month or earlier */
+  month: string;
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+  /** `pdf` answers with the statement as an `application/pdf` download */
+  format?: 'json' | 'pdf';
+}
+
+export interface SetUsernameBody {
+  username: string;
+}
+
+export interface SetDisplayCurrencyBody {
+  /** ISO 4217, any case */
+  currency: string;
+}
+
+export interface SetTimezoneBody {
+  /** An IANA time zone name */
+  timezone: string;
+}
+
+export interface SetAfterHoursOrdersBody {
+  /** REJECT or QUEUE, any case */
+  mode: string;
+}
+
+export interface SetCostBasisMethodBody {
+  /** FIFO, LIFO or AVERAGE, any case */
+  method: string;
+}
+
+export interface FinishPasskeyRegistrationParams {
+  /** A label of at most 64 characters; defaults to "Passkey N" */
+  name?: string;
+}
+
+export interface ChangePasswordBody {
+  password: string;
+}
+
+export interface ResetAccountBody {
+  /** From POST /account/reset/confirmation; optional when ACCOUNT_RESET_CONFIRM=false */
+  confirmation_token?: string;
+}
+
+export interface ImportAccountParams {
+  /** `replace` wipes an account in use first; without it such an account is refused */
+  mode?: 'replace';
+}
+
+export interface ListHoldingsParams {
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface BuyParams {
+  /** Makes a retry of the same request return the first one's result instead of trading again */
+  idempotencyKey?: string;
+}
+
+export interface SellParams {
+  /** Makes a retry of the same request return the first one's result instead of trading again */
+  idempotencyKey?: string;
+}
+
+export interface ShortParams {
+  /** Makes a retry of the same request return the first one's result instead of trading again */
+  idempotencyKey?: string;
+}
+
+export interface CoverParams {
+  /** Makes a retry of the same request return the first one's result instead of trading again */
+  idempotencyKey?: string;
+}
+
+export interface ListTradesParams {
+  symbol?: string;
+  action?: 'BUY' | 'SELL' | 'SHORT' | 'COVER';
+  /** First day, inclusive */
+  from?: string;
+  /** Last day, inclusive */
+  to?: string;
+  limit?: number;
+  offset?: number;
+  /** 1-based alternative to offset, in pages of limit */
+  page?: number;
+}
+
+export interface ExportTradesParams {
+  format?: 'csv' | 'json';
+  symbol?: string;
+  action?: 'BUY' | 'SELL' | 'SHORT' | 'COVER';
+  /** First day, inclusive */
+  from?: string;
+  /** Last day, inclusive */
+  to?: string;
+}
+
+export interface ExportHoldingsParams {
+  format?: 'csv';
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetPortfolioHistoryParams {
+  range: '1M' | '3M' | '1Y';
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetPortfolioValueParams {
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetTradingStatsParams {
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetTradeReplayParams {
+  symbol: string;
+  days?: number;
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface CompareToBenchmarkParams {
+  range: '1M' | '3M' | '1Y';
+  /** The benchmark; defaults to BENCHMARK_SYMBOL */
+  symbol?: string;
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetRiskMetricsParams {
+  range: '1M' | '3M' | '1Y';
+  /** The benchmark; defaults to BENCHMARK_SYMBOL */
+  symbol?: string;
+}
+
+export interface SearchTradesParams {
+  q: string;
+  /** First day, inclusive */
+  from?: string;
+  /** Last day, inclusive */
+  to?: string;
+  limit?: number;
+  offset?: number;
+}
+
+export interface GetPnLParams {
+  /** First day whose closes count as realized, inclusive */
+  from?: string;
+  /** Last day whose closes count as realized, inclusive */
+  to?: string;
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface ListLotsParams {
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface DisputeTradeBody {
+  reason: string;
+}
+
+export interface ListOrdersParams {
+  status?: 'WAITING' | 'PENDING' | 'FILLED' | 'CANCELLED' | 'EXPIRED' | 'FAILED';
+  limit?: number;
+}
+
+export interface CreateOrderParams {
+  /** Makes a retry of the same request return the first one's result instead of trading again */
+  idempotencyKey?: string;
+}
+
+export interface WaitOrderParams {
+  /** Seconds to wait; defaults to and is capped at TRADING_ORDER_WAIT_MAX_SECONDS */
+  timeout?: number;
+}
+
+export interface RebalanceParams {
+  /** Makes a retry of the same request return the first one's result instead of trading again */
+  idempotencyKey?: string;
+}
+
+export interface UpdateRecurringInvestmentBody {
+  active: boolean;
+}
+
+export interface GetQuoteParams {
+  symbol: string;
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetQuoteBySymbolParams {
+  /** ISO 4217 code to show amounts in; defaults to the account's display currency */
+  display_currency?: string;
+}
+
+export interface GetDailyBarParams {
+  symbol: string;
+}
+
+export interface GetDailyBarsParams {
+  /** Comma-separated, at most 15 */
+  symbols: string;
+}
+
+export interface GetSeriesParams {
+  symbol: string;
+  /** Days back from today; clamped to a year */
+  days?: number;
+}
+
+export interface GetSeriesBySymbolParams {
+  /** Days back from today; clamped to a year */
+  days?: number;
+}
+
+export interface GetChartParams {
+  symbol: string;
+  range: '1D' | '1W' | '1M' | '3M' | '1Y' | '5Y';
+}
+
+export interface GetChartBySymbolParams {
+  range: '1D' | '1W' | '1M' | '3M' | '1Y' | '5Y';
+}
+
+export interface ConvertCurrencyParams {
+  amount: number;
+  from?: string;
+  to: string;
+}
+
+export interface GetMarketHoursParams {
+  /** A MIC (XLON, XTSE); the NYSE without one */
+  exchange?: string;
+}
+
+export interface GetCompanyParams {
+  symbol: string;
+}
+
+export interface SearchSymbolsParams {
+  q: string;
+  limit?: number;
+}
+
+export interface GetMoversParams {
+  /** How many of each, at most 25 */
+  limit?: number;
+}
+
+export interface GetNewsParams {
+  symbol?: string;
+  limit?: number;
+}
+
+export interface GetClassificationParams {
+  symbol: string;
+}
+
+export interface ListClassificationsParams {
+  index?: 'DJI' | 'SPX' | 'NDX';
+  sector?: string;
+}
+
+export interface AddToWatchlistBody {
+  symbol: string;
+}
+
+export interface ListNotificationsParams {
+  /** `true` lists only the unread ones */
+  unread?: boolean;
+  limit?: number;
+}
+
+export interface ListAnomaliesParams {
+  limit?: number;
+}
+
+export interface ListTopRateLimitConsumersParams {
+  limit?: number;
+}
+
+export interface ImportUsersParams {
+  /** Email new users an invite link; defaults to true */
+  invite?: boolean;
+}
+
+export interface ExportUsersParams {
+  league?: string;
+  format?: 'json' | 'csv';
+}
+
+export interface SearchUsersParams {
+  q?: string;
+  limit?: number;
+}
+
+export interface ListTradeDisputesParams {
+  /** All statuses when omitted */
+  status?: 'OPEN' | 'REVERSED' | 'REJECTED';
+  limit?: number;
+}
+
+/**
+ * Whether the database and Redis are reachable, and each market data provider's circuit breaker.
+ *
+ * `GET /health`
+ */
+export const getHealth = (): Promise<Blob> =>
+  request<Blob>('GET', '/health', { accept: 'text/plain' });
+
+/**
+ * The caller's CSRF token, setting the cookie if needed.
+ *
+ * `GET /csrf`
+ */
+export const getCSRFToken = (): Promise<CSRFToken> =>
+  request<CSRFToken>('GET', '/csrf');
+
+/**
+ * Create an account with email and password, signing in.
+ *
+ * `POST /account/register`
+ */
+export const register = (body: RegisterRequest): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/register', { body });
+
+/**
+ * Sign in with email and password.
+ *
+ * `POST /account/login`
+ */
+export const login = (body: LoginRequest): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/login', { body });
+
+/**
+ * Sign in with a Google ID token, creating the account on first sign-in.
+ *
+ * `POST /account/auth/google`
+ */
+export const googleLogin = (body: GoogleLoginRequest): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/auth/google', { body });
+
+/**
+ * Mark the email verified with the token from the verification email.
+ *
+ * `GET /account/verify-email`
+ */
+export const verifyEmail = (params: VerifyEmailParams): Promise<Status> =>
+  request<Status>('GET', '/account/verify-email', { query: params });
+
+/**
+ * Send a new verification email.
+ *
+ * `POST /account/resend-verification`
+ */
+export const resendVerification = (body: EmailRequest): Promise<Status> =>
+  request<Status>('POST', '/account/resend-verification', { body });
+
+/**
+ * Whether a username is free.
+ *
+ * `GET /account/username/available`
+ */
+export const checkUsername = (params: CheckUsernameParams): Promise<UsernameAvailability> =>
+  request<UsernameAvailability>('GET', '/account/username/available', { query: params });
+
+/**
+ * Email a single-use login link; the answer is the same whether or not the account exists.
+ *
+ * `POST /account/magic-link`
+ */
+export const requestMagicLink = (body: EmailRequest): Promise<Status> =>
+  request<Status>('POST', '/account/magic-link', { body });
+
+/**
+ * Start a guest account that expires unless upgraded.
+ *
+ * `POST /account/guest`
+ */
+export const createGuest = (body?: GuestRequest): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/guest', { body });
+
+/**
+ * Start signing in with a passkey; pass the options to navigator.credentials.get().
+ *
+ * `POST /account/passkeys/login/begin`
+ */
+export const beginPasskeyLogin = (): Promise<WebAuthnOptions> =>
+  request<WebAuthnOptions>('POST', '/account/passkeys/login/begin');
+
+/**
+ * Sign in with the assertion from navigator.credentials.get().
+ *
+ * `POST /account/passkeys/login/finish`
+ */
+export const finishPasskeyLogin = (body: WebAuthnCredential): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/passkeys/login/finish', { body });
+
+/**
+ * Confirm the password or Google account to enter sudo mode, setting the `sudo_token` cookie.
+ *
+ * `POST /account/sudo`
+ */
+export const enterSudo = (body: SudoRequest): Promise<SudoResponse> =>
+  request<SudoResponse>('POST', '/account/sudo', { body });
+
+/**
+ * Leave sudo mode.
+ *
+ * `DELETE /account/sudo`
+ */
+export const leaveSudo = (): Promise<Status> =>
+  request<Status>('DELETE', '/account/sudo');
+
+/**
+ * Sign out, revoking the session and clearing its cookie.
+ *
+ * `POST /account/logout`
+ */
+export const logout = (): Promise<Status> =>
+  request<Status>('POST', '/account/logout');
+
+/**
+ * Revoke every session of the account, this one included.
+ *
+ * `POST /account/logout-all`
+ */
+export const logoutAll = (): Promise<Status> =>
+  request<Status>('POST', '/account/logout-all');
+
+/**
+ * The signed-in account.
+ *
+ * `GET /account/profile`
+ */
+export const getProfile = (): Promise<User> =>
+  request<User>('GET', '/account/profile');
+
+/**
+ * Whether the request is signed in; unauthenticated callers get 401.
+ *
+ * `GET /account/auth`
+ */
+export const checkAuth = (): Promise<Status> =>
+  request<Status>('GET', '/account/auth');
+
+/**
+ * The account's cash in USD.
+ *
+ * `GET /account/balance`
+ */
+export const getBalance = (): Promise<number> =>
+  request<number>('GET', '/account/balance');
+
+/**
+ * The account's standing against the daily trade limit and the pattern-day-trader rule.
+ *
+ * `GET /account/limits`
+ */
+export const getTradeLimits = (): Promise<TradeLimits> =>
+  request<TradeLimits>('GET', '/account/limits');
+
+/**
+ * The caller's rate-limit windows and recent request counts.
+ *
+ * `GET /account/usage`
+ */
+export const getUsage = (): Promise<UsageReport> =>
+  request<UsageReport>('GET', '/account/usage');
+
+/**
+ * A calendar month's account statement, as JSON or a PDF.
+ *
+ * `GET /account/statements`
+ */
+export const getStatement = (params: GetStatementParams): Promise<AccountStatement | Blob> =>
+  request<AccountStatement | Blob>('GET', '/account/statements', { query: params });
+
+/**
+ * The monthly statements emailed to the user, newest first.
+ *
+ * `GET /account/statements/reports`
+ */
+export const listStatementReports = (): Promise<StatementReportList> =>
+  request<StatementReportList>('GET', '/account/statements/reports');
+
+/**
+ * Replace the avatar with the raw image in the body (PNG, JPEG, GIF or WebP).
+ *
+ * `PUT /account/avatar`
+ */
+export const uploadAvatar = (body: Blob | string): Promise<AvatarResponse> =>
+  request<AvatarResponse>('PUT', '/account/avatar', {
+    body,
+    contentType: 'application/octet-stream',
+  });
+
+/**
+ * Remove the avatar.
+ *
+ * `DELETE /account/avatar`
+ */
+export const deleteAvatar = (): Promise<AvatarResponse> =>
+  request<AvatarResponse>('DELETE', '/account/avatar');
+
+/**
+ * Set or change the public display name.
+ *
+ * `PUT /account/username`
+ */
+export const setUsername = (body: SetUsernameBody): Promise<UsernameResponse> =>
+  request<UsernameResponse>('PUT', '/account/username', { body });
+
+/**
+ * Set the currency quotes and the portfolio are shown in by default.
+ *
+ * `PUT /account/display-currency`
+ */
+export const setDisplayCurrency = (
+  body: SetDisplayCurrencyBody
+): Promise<DisplayCurrencyResponse> =>
+  request<DisplayCurrencyResponse>('PUT', '/account/display-currency', { body });
+
+/**
+ * Set the time zone the user's days and months are drawn in.
+ *
+ * `PUT /account/timezone`
+ */
+export const setTimezone = (body: SetTimezoneBody): Promise<TimezoneResponse> =>
+  request<TimezoneResponse>('PUT', '/account/timezone', { body });
+
+/**
+ * Choose whether a buy or sell while the market is closed is refused or queued for the open.
+ *
+ * `PUT /account/after-hours-orders`
+ */
+export const setAfterHoursOrders = (
+  body: SetAfterHoursOrdersBody
+): Promise<AfterHoursOrdersResponse> =>
+  request<AfterHoursOrdersResponse>('PUT', '/account/after-hours-orders', { body });
+
+/**
+ * Choose which tax lots sells realize gains against.
+ *
+ * `PUT /account/cost-basis-method`
+ */
+export const setCostBasisMethod = (
+  body: SetCostBasisMethodBody
+): Promise<CostBasisMethodResponse> =>
+  request<CostBasisMethodResponse>('PUT', '/account/cost-basis-method', { body });
+
+/**
+ * Turn trade confirmation emails on or off.
+ *
+ * `PUT /account/trade-confirmation-emails`
+ */
+export const setTradeConfirmationEmails = (
+  body: EnabledRequest
+): Promise<TradeConfirmationEmailsResponse> =>
+  request<TradeConfirmationEmailsResponse>('PUT', '/account/trade-confirmation-emails', { body });
+
+/**
+ * Turn monthly statement emails on or off.
+ *
+ * `PUT /account/statement-emails`
+ */
+export const setStatementEmails = (body: EnabledRequest): Promise<StatementEmailsResponse> =>
+  request<StatementEmailsResponse>('PUT', '/account/statement-emails', { body });
+
+/**
+ * Attach email and password, or a Google account, to the caller's guest account.
+ *
+ * `POST /account/guest/upgrade`
+ */
+export const upgradeGuest = (body: GuestUpgradeRequest): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/guest/upgrade', { body });
+
+/**
+ * The account's passkeys.
+ *
+ * `GET /account/passkeys`
+ */
+export const listPasskeys = (): Promise<Passkey[]> =>
+  request<Passkey[]>('GET', '/account/passkeys');
+
+/**
+ * Start adding a passkey (requires sudo); pass the options to navigator.credentials.create().
+ *
+ * `POST /account/passkeys/register/begin`
+ */
+export const beginPasskeyRegistration = (): Promise<WebAuthnOptions> =>
+  request<WebAuthnOptions>('POST', '/account/passkeys/register/begin');
+
+/**
+ * Store the credential from navigator.credentials.create() (requires sudo).
+ *
+ * `POST /account/passkeys/register/finish`
+ */
+export const finishPasskeyRegistration = (
+  body: WebAuthnCredential,
+  params: FinishPasskeyRegistrationParams = {}
+): Promise<Passkey> =>
+  request<Passkey>('POST', '/account/passkeys/register/finish', { body, query: params });
+
+/**
+ * Remove a passkey (requires sudo).
+ *
+ * `DELETE /account/passkeys/{id}`
+ */
+export const deletePasskey = (id: string): Promise<Status> =>
+  request<Status>('DELETE', `/account/passkeys/${encodeURIComponent(id)}`);
+
+/**
+ * The user's investment goals and their progress.
+ *
+ * `GET /account/goals`
+ */
+export const listGoals = (): Promise<GoalList> =>
+  request<GoalList>('GET', '/account/goals');
+
+/**
+ * Set a goal of a total account value or a return.
+ *
+ * `POST /account/goals`
+ */
+export const createGoal = (body: GoalRequest): Promise<Goal> =>
+  request<Goal>('POST', '/account/goals', { body });
+
+/**
+ * Remove a goal.
+ *
+ * `DELETE /account/goals/{id}`
+ */
+export const deleteGoal = (id: string): Promise<Status> =>
+  request<Status>('DELETE', `/account/goals/${encodeURIComponent(id)}`);
+
+/**
+ * Whether bonus cash can be claimed, and when it next can.
+ *
+ * `GET /account/bonus`
+ */
+export const getBonus = (): Promise<BonusStatus> =>
+  request<BonusStatus>('GET', '/account/bonus');
+
+/**
+ * Credit BONUS_CASH_AMOUNT to the cash, at most once per cooldown.
+ *
+ * `POST /account/bonus`
+ */
+export const claimBonus = (): Promise<BonusClaim> =>
+  request<BonusClaim>('POST', '/account/bonus');
+
+/**
+ * Set a new password (requires sudo).
+ *
+ * `PUT /account/password`
+ */
+export const changePassword = (body: ChangePasswordBody): Promise<Status> =>
+  request<Status>('PUT', '/account/password', { body });
+
+/**
+ * Move the account to a new, unverified address (requires sudo).
+ *
+ * `PUT /account/email`
+ */
+export const changeEmail = (body: EmailRequest): Promise<AuthResponse> =>
+  request<AuthResponse>('PUT', '/account/email', { body });
+
+/**
+ * Erase the account and everything in it (requires sudo).
+ *
+ * `DELETE /account`
+ */
+export const deleteAccount = (): Promise<Status> =>
+  request<Status>('DELETE', '/account');
+
+/**
+ * A token, valid for 5 minutes, for resetting the account (requires sudo).
+ *
+ * `POST /account/reset/confirmation`
+ */
+export const requestResetConfirmation = (): Promise<ResetConfirmation> =>
+  request<ResetConfirmation>('POST', '/account/reset/confirmation');
+
+/**
+ * Start the paper account over at its starting balance (requires sudo).
+ *
+ * `POST /account/reset`
+ */
+export const resetAccount = (body?: ResetAccountBody): Promise<AuthResponse> =>
+  request<AuthResponse>('POST', '/account/reset', { body });
+
+/**
+ * The account as a signed bundle another deployment can import.
+ *
+ * `GET /account/transfer/export`
+ */
+export const exportAccount = (): Promise<AccountBundle> =>
+  request<AccountBundle>('GET', '/account/transfer/export');
+
+/**
+ * Write a bundle exported by a trusted deployment into the caller's account (requires sudo).
+ *
+ * `POST /account/transfer/import`
+ */
+export const importAccount = (
+  body: AccountBundle,
+  params: ImportAccountParams = {}
+): Promise<AccountImport> =>
+  request<AccountImport>('POST', '/account/transfer/import', { body, query: params });
+
+/**
+ * The account's positions, long and short.
+ *
+ * `GET /investments`
+ */
+export const listHoldings = (params: ListHoldingsParams = {}): Promise<Holding[]> =>
+  request<Holding[]>('GET', '/investments', { query: params });
+
+/**
+ * Buy at market.
+ *
+ * `POST /investments/buy`
+ */
+export const buy = (body: TradeRequest, params: BuyParams = {}): Promise<Holding | QueuedTrade> =>
+  request<Holding | QueuedTrade>('POST', '/investments/buy', {
+    body,
+    headers: { 'Idempotency-Key': params.idempotencyKey },
+  });
+
+/**
+ * Sell at market.
+ *
+ * `POST /investments/sell`
+ */
+export const sell = (body: TradeRequest, params: SellParams = {}): Promise<Holding | QueuedTrade> =>
+  request<Holding | QueuedTrade>('POST', '/investments/sell', {
+    body,
+    headers: { 'Idempotency-Key': params.idempotencyKey },
+  });
+
+/**
+ * Sell short at market, holding margin against the position.
+ *
+ * `POST /investments/short`
+ */
+export const short = (body: TradeRequest, params: ShortParams = {}): Promise<Holding> =>
+  request<Holding>('POST', '/investments/short', {
+    body,
+    headers: { 'Idempotency-Key': params.idempotencyKey },
+  });
+
+/**
+ * Buy back shares of a short position at market.
+ *
+ * `POST /investments/cover`
+ */
+export const cover = (body: TradeRequest, params: CoverParams = {}): Promise<Holding> =>
+  request<Holding>('POST', '/investments/cover', {
+    body,
+    headers: { 'Idempotency-Key': params.idempotencyKey },
+  });
+
+/**
+ * The trade history, newest first.
+ *
+ * `GET /investments/trades`
+ */
+export const listTrades = (params: ListTradesParams = {}): Promise<TradePage> =>
+  request<TradePage>('GET', '/investments/trades', { query: params });
+
+/**
+ * The whole trade history, oldest first, as a CSV download or a JSON list.
+ *
+ * `GET /investments/trades/export`
+ */
+export const exportTrades = (params: ExportTradesParams = {}): Promise<Blob | TradeExport> =>
+  request<Blob | TradeExport>('GET', '/investments/trades/export', { query: params });
+
+/**
+ * The holdings, priced as for GET /investments, as a CSV download.
+ *
+ * `GET /investments/export`
+ */
+export const exportHoldings = (params: ExportHoldingsParams = {}): Promise<Blob> =>
+  request<Blob>('GET', '/investments/export', { query: params, accept: 'text/csv' });
+
+/**
+ * The account's value at each trading day's close over the range.
+ *
+ * `GET /investments/history`
+ */
+export const getPortfolioHistory = (params: GetPortfolioHistoryParams): Promise<PortfolioHistory> =>
+  request<PortfolioHistory>('GET', '/investments/history', { query: params });
+
+/**
+ * The account's value marked to the latest quotes, with the change since the last daily snapshot.
+ *
+ * `GET /investments/value`
+ */
+export const getPortfolioValue = (params: GetPortfolioValueParams = {}): Promise<PortfolioValue> =>
+  request<PortfolioValue>('GET', '/investments/value', { query: params });
+
+/**
+ * Win and loss streaks, the best and worst closes, the average hold and the drawdown from the
+ * peak.
+ *
+ * `GET /investments/stats`
+ */
+export const getTradingStats = (params: GetTradingStatsParams = {}): Promise<TradingStats> =>
+  request<TradingStats>('GET', '/investments/stats', { query: params });
+
+/**
+ * A symbol's daily closes with the user's trades in it, for drawing the trades on the chart.
+ *
+ * `GET /investments/replay`
+ */
+export const getTradeReplay = (params: GetTradeReplayParams): Promise<TradeReplay> =>
+  request<TradeReplay>('GET', '/investments/replay', { query: params });
+
+/**
+ * The account's return over the range against a benchmark symbol's.
+ *
+ * `GET /investments/performance/vs-benchmark`
+ */
+export const compareToBenchmark = (
+  params: CompareToBenchmarkParams
+): Promise<BenchmarkComparison> =>
+  request<BenchmarkComparison>('GET', '/investments/performance/vs-benchmark', { query: params });
+
+/**
+ * Volatility, Sharpe ratio, beta and maximum drawdown over the range.
+ *
+ * `GET /investments/risk`
+ */
+export const getRiskMetrics = (params: GetRiskMetricsParams): Promise<RiskMetrics> =>
+  request<RiskMetrics>('GET', '/investments/risk', { query: params });
+
+/**
+ * Full-text search of the trades by symbol, tags and note, best match first.
+ *
+ * `GET /investments/search`
+ */
+export const searchTrades = (params: SearchTradesParams): Promise<TradeSearchPage> =>
+  request<TradeSearchPage>('GET', '/investments/search', { query: params });
+
+/**
+ * Realized gains from closed trades plus unrealized gains on open positions.
+ *
+ * `GET /investments/pnl`
+ */
+export const getPnL = (params: GetPnLParams = {}): Promise<PnLReport> =>
+  request<PnLReport>('GET', '/investments/pnl', { query: params });
+
+/**
+ * The cost-basis method and the open tax lots.
+ *
+ * `GET /investments/lots`
+ */
+export const listLots = (params: ListLotsParams = {}): Promise<LotsReport> =>
+  request<LotsReport>('GET', '/investments/lots', { query: params });
+
+/**
+ * Replace a trade's note and tags.
+ *
+ * `PUT /investments/trades/{id}/note`
+ */
+export const setTradeNote = (id: string, body: TradeNoteRequest): Promise<TradeNote> =>
+  request<TradeNote>('PUT', `/investments/trades/${encodeURIComponent(id)}/note`, { body });
+
+/**
+ * Remove a trade's note and tags.
+ *
+ * `DELETE /investments/trades/{id}/note`
+ */
+export const deleteTradeNote = (id: string): Promise<void> =>
+  request<void>('DELETE', `/investments/trades/${encodeURIComponent(id)}/note`);
+
+/**
+ * Ask an admin to review a trade; an admin may reverse it.
+ *
+ * `POST /investments/trades/{id}/dispute`
+ */
+export const disputeTrade = (id: string, body: DisputeTradeBody): Promise<TradeDispute> =>
+  request<TradeDispute>('POST', `/investments/trades/${encodeURIComponent(id)}/dispute`, { body });
+
+/**
+ * The user's trade disputes, newest first.
+ *
+ * `GET /investments/disputes`
+ */
+export const listDisputes = (): Promise<TradeDisputeList> =>
+  request<TradeDisputeList>('GET', '/investments/disputes');
+
+/**
+ * The user's orders, newest first.
+ *
+ * `GET /investments/orders`
+ */
+export const listOrders = (params: ListOrdersParams = {}): Promise<OrderList> =>
+  request<OrderList>('GET', '/investments/orders', { query: params });
+
+/**
+ * Place a market, limit, stop, stop-loss or take-profit order.
+ *
+ * `POST /investments/orders`
+ */
+export const createOrder = (body: OrderRequest, params: CreateOrderParams = {}): Promise<Order> =>
+  request<Order>('POST', '/investments/orders', {
+    body,
+    headers: { 'Idempotency-Key': params.idempotencyKey },
+  });
+
+/**
+ * Place a take-profit and a stop-loss on the same shares; whichever fills first cancels the other.
+ *
+ * `POST /investments/orders/oco`
+ */
+export const createOCOOrder = (body: OCORequest): Promise<OCOOrders> =>
+  request<OCOOrders>('POST', '/investments/orders/oco', { body });
+
+/**
+ * Place a buy entry with a take-profit and stop-loss that become active once it fills.
+ *
+ * `POST /investments/orders/bracket`
+ */
+export const createBracketOrder = (body: BracketRequest): Promise<BracketOrders> =>
+  request<BracketOrders>('POST', '/investments/orders/bracket', { body });
+
+/**
+ * One order.
+ *
+ * `GET /investments/orders/{id}`
+ */
+export const getOrder = (id: string): Promise<Order> =>
+  request<Order>('GET', `/investments/orders/${encodeURIComponent(id)}`);
+
+/**
+ * Cancel a waiting or pending order.
+ *
+ * `DELETE /investments/orders/{id}`
+ */
+export const cancelOrder = (id: string): Promise<Order> =>
+  request<Order>('DELETE', `/investments/orders/${encodeURIComponent(id)}`);
+
+/**
+ * Long-poll until the order closes, or answer with it as it stands after the timeout.
+ *
+ * `GET /investments/orders/{id}/wait`
+ */
+export const waitOrder = (id: string, params: WaitOrderParams = {}): Promise<Order> =>
+  request<Order>('GET', `/investments/orders/${encodeURIComponent(id)}/wait`, { query: params });
+
+/**
+ * Preview the orders that move the account to target weights, or place them.
+ *
+ * `POST /investments/rebalance`
+ */
+export const rebalance = (
+  body: RebalanceRequest,
+  params: RebalanceParams = {}
+): Promise<RebalancePlan> =>
+  request<RebalancePlan>('POST', '/investments/rebalance', {
+    body,
+    headers: { 'Idempotency-Key': params.idempotencyKey },
+  });
+
+/**
+ * The user's recurring investment plans.
+ *
+ * `GET /investments/recurring`
+ */
+export const listRecurringInvestments = (): Promise<RecurringInvestmentList> =>
+  request<RecurringInvestmentList>('GET', '/investments/recurring');
+
+/**
+ * Buy a dollar amount of a symbol every day, week or month.
+ *
+ * `POST /investments/recurring`
+ */
+export const createRecurringInvestment = (
+  body: RecurringInvestmentRequest
+): Promise<RecurringInvestment> =>
+  request<RecurringInvestment>('POST', '/investments/recurring', { body });
+
+/**
+ * Pause or resume a plan.
+ *
+ * `PUT /investments/recurring/{id}`
+ */
+export const updateRecurringInvestment = (
+  id: string,
+  body: UpdateRecurringInvestmentBody
+): Promise<RecurringInvestment> =>
+  request<RecurringInvestment>('PUT', `/investments/recurring/${encodeURIComponent(id)}`, {
+    body,
+  });
+
+/**
+ * Remove a plan.
+ *
+ * `DELETE /investments/recurring/{id}`
+ */
+export const deleteRecurringInvestment = (id: string): Promise<void> =>
+  request<void>('DELETE', `/investments/recurring/${encodeURIComponent(id)}`);
+
+/**
+ * The latest quote, in the display currency.
+ *
+ * `GET /market/stock`
+ */
+export const getQuote = (params: GetQuoteParams): Promise<QuoteResponse> =>
+  request<QuoteResponse>('GET', '/market/stock', { query: params });
+
+/**
+ * The latest quote, in the display currency (path style).
+ *
+ * `GET /market/stock/{symbol}`
+ */
+export const getQuoteBySymbol = (
+  symbol: string,
+  params: GetQuoteBySymbolParams = {}
+): Promise<QuoteResponse> =>
+  request<QuoteResponse>('GET', `/market/stock/${encodeURIComponent(symbol)}`, { query: params });
+
+/**
+ * The latest daily bar, with the change from the previous close.
+ *
+ * `GET /market/stock/historical/daily`
+ */
+export const getDailyBar = (params: GetDailyBarParams): Promise<DailyBarResponse> =>
+  request<DailyBarResponse>('GET', '/market/stock/historical/daily', { query: params });
+
+/**
+ * The latest daily bar of up to 15 symbols at once; exempt from the per-symbol rate limit.
+ *
+ * `GET /market/stock/historical/daily/batch`
+ */
+export const getDailyBars = (params: GetDailyBarsParams): Promise<DailyBarBatchResponse> =>
+  request<DailyBarBatchResponse>('GET', '/market/stock/historical/daily/batch', { query: params });
+
+/**
+ * The latest daily bar, with the change from the previous close (path style).
+ *
+ * `GET /market/stock/{symbol}/historical/daily`
+ */
+export const getDailyBarBySymbol = (symbol: string): Promise<DailyBarResponse> =>
+  request<DailyBarResponse>('GET', `/market/stock/${encodeURIComponent(symbol)}/historical/daily`);
+
+/**
+ * Daily closes over the last `days` days.
+ *
+ * `GET /market/stock/historical/series`
+ */
+export const getSeries = (params: GetSeriesParams): Promise<SeriesResponse> =>
+  request<SeriesResponse>('GET', '/market/stock/historical/series', { query: params });
+
+/**
+ * Daily closes over the last `days` days (path style).
+ *
+ * `GET /market/stock/{symbol}/historical/series`
+ */
+export const getSeriesBySymbol = (
+  symbol: string,
+  params: GetSeriesBySymbolParams = {}
+): Promise<SeriesResponse> =>
+  request<SeriesResponse>('GET', `/market/stock/${encodeURIComponent(symbol)}/historical/series`, {
+    query: params,
+  });
+
+/**
+ * OHLCV candles for a candlestick chart.
+ *
+ * `GET /market/stock/chart`
+ */
+export const getChart = (params: GetChartParams): Promise<ChartResponse> =>
+  request<ChartResponse>('GET', '/market/stock/chart', { query: params });
+
+/**
+ * OHLCV candles for a candlestick chart (path style).
+ *
+ * `GET /market/stock/{symbol}/chart`
+ */
+export const getChartBySymbol = (
+  symbol: string,
+  params: GetChartBySymbolParams
+): Promise<ChartResponse> =>
+  request<ChartResponse>('GET', `/market/stock/${encodeURIComponent(symbol)}/chart`, {
+    query: params,
+  });
+
+/**
+ * The symbols the user last looked up, most recent first.
+ *
+ * `GET /market/recent`
+ */
+export const listRecentlyViewed = (): Promise<RecentlyViewedResponse> =>
+  request<RecentlyViewedResponse>('GET', '/market/recent');
+
+/**
+ * Convert an amount between currencies at the day's rate.
+ *
+ * `GET /market/convert`
+ */
+export const convertCurrency = (params: ConvertCurrencyParams): Promise<ConversionResponse> =>
+  request<ConversionResponse>('GET', '/market/convert', { query: params });
+
+/**
+ * Whether the market is open, and the current or next session.
+ *
+ * `GET /market/hours`
+ */
+export const getMarketHours = (params: GetMarketHoursParams = {}): Promise<MarketHoursResponse> =>
+  request<MarketHoursResponse>('GET', '/market/hours', { query: params });
+
+/**
+ * A company's name, exchange, sector, industry, market cap and description.
+ *
+ * `GET /market/company`
+ */
+export const getCompany = (params: GetCompanyParams): Promise<CompanyResponse> =>
+  request<CompanyResponse>('GET', '/market/company', { query: params });
+
+/**
+ * A company's profile (path style).
+ *
+ * `GET /market/company/{symbol}`
+ */
+export const getCompanyBySymbol = (symbol: string): Promise<CompanyResponse> =>
+  request<CompanyResponse>('GET', `/market/company/${encodeURIComponent(symbol)}`);
+
+/**
+ * Listings whose symbol or company name matches, for lookup as the user types.
+ *
+ * `GET /market/search`
+ */
+export const searchSymbols = (params: SearchSymbolsParams): Promise<SymbolSearchResponse> =>
+  request<SymbolSearchResponse>('GET', '/market/search', { query: params });
+
+/**
+ * The day's top gainers, losers and most active symbols.
+ *
+ * `GET /market/movers`
+ */
+export const getMovers = (params: GetMoversParams = {}): Promise<MoversResponse> =>
+  request<MoversResponse>('GET', '/market/movers', { query: params });
+
+/**
+ * The latest headlines about a symbol, or general market news.
+ *
+ * `GET /market/news`
+ */
+export const getNews = (params: GetNewsParams = {}): Promise<NewsResponse> =>
+  request<NewsResponse>('GET', '/market/news', { query: params });
+
+/**
+ * A symbol's sector, industry and index memberships.
+ *
+ * `GET /market/classification`
+ */
+export const getClassification = (
+  params: GetClassificationParams
+): Promise<ClassificationResponse> =>
+  request<ClassificationResponse>('GET', '/market/classification', { query: params });
+
+/**
+ * A symbol's classification (path style).
+ *
+ * `GET /market/classification/{symbol}`
+ */
+export const getClassificationBySymbol = (symbol: string): Promise<ClassificationResponse> =>
+  request<ClassificationResponse>('GET', `/market/classification/${encodeURIComponent(symbol)}`);
+
+/**
+ * The members of one index or one GICS sector; exactly one filter is required.
+ *
+ * `GET /market/classifications`
+ */
+export const listClassifications = (
+  params: ListClassificationsParams = {}
+): Promise<ClassificationListResponse> =>
+  request<ClassificationListResponse>('GET', '/market/classifications', { query: params });
+
+/**
+ * The watchlist, priced, and the curated lists the user follows.
+ *
+ * `GET /watchlist`
+ */
+export const getWatchlist = (): Promise<Watchlist> =>
+  request<Watchlist>('GET', '/watchlist');
+
+/**
+ * Add a symbol to the watchlist.
+ *
+ * `POST /watchlist`
+ */
+export const addToWatchlist = (body: AddToWatchlistBody): Promise<WatchlistEntry> =>
+  request<WatchlistEntry>('POST', '/watchlist', { body });
+
+/**
+ * Remove a symbol from the watchlist.
+ *
+ * `DELETE /watchlist/{symbol}`
+ */
+export const removeFromWatchlist = (symbol: string): Promise<void> =>
+  request<void>('DELETE', `/watchlist/${encodeURIComponent(symbol)}`);
+
+/**
+ * Every curated list, with whether the user follows it.
+ *
+ * `GET /watchlist/lists`
+ */
+export const listCuratedLists = (): Promise<CuratedListList> =>
+  request<CuratedListList>('GET', '/watchlist/lists');
+
+/**
+ * One curated list, its symbols priced.
+ *
+ * `GET /watchlist/lists/{slug}`
+ */
+export const getCuratedList = (slug: string): Promise<CuratedListView> =>
+  request<CuratedListView>('GET', `/watchlist/lists/${encodeURIComponent(slug)}`);
+
+/**
+ * Follow a curated list, adding it to the watchlist view.
+ *
+ * `POST /watchlist/lists/{slug}/subscribe`
+ */
+export const followCuratedList = (slug: string): Promise<void> =>
+  request<void>('POST', `/watchlist/lists/${encodeURIComponent(slug)}/subscribe`);
+
+/**
+ * Stop following a curated list.
+ *
+ * `DELETE /watchlist/lists/{slug}/subscribe`
+ */
+export const unfollowCuratedList = (slug: string): Promise<void> =>
+  request<void>('DELETE', `/watchlist/lists/${encodeURIComponent(slug)}/subscribe`);
+
+/**
+ * The user's notifications, newest first, and how many are unread.
+ *
+ * `GET /notifications`
+ */
+export const listNotifications = (
+  params: ListNotificationsParams = {}
+): Promise<NotificationList> =>
+  request<NotificationList>('GET', '/notifications', { query: params });
+
+/**
+ * Mark every notification read.
+ *
+ * `POST /notifications/read-all`
+ */
+export const markAllNotificationsRead = (): Promise<void> =>
+  request<void>('POST', '/notifications/read-all');
+
+/**
+ * Mark one notification read.
+ *
+ * `POST /notifications/{id}/read`
+ */
+export const markNotificationRead = (id: string): Promise<void> =>
+  request<void>('POST', `/notifications/${encodeURIComponent(id)}/read`);
+
+/**
+ * The projected effect of a historical or custom market shock on the current holdings.
+ *
+ * `POST /tools/stress-test`
+ */
+export const runStressTest = (body: StressTestRequest): Promise<StressTestResult> =>
+  request<StressTestResult>('POST', '/tools/stress-test', { body });
+
+/**
+ * Answer a question from the filings corpus, with citations.
+ *
+ * `POST /research/ask`
+ */
+export const askResearch = (body: ResearchAskRequest): Promise<ResearchAnswer> =>
+  request<ResearchAnswer>('POST', '/research/ask', { body });
+
+/**
+ * Every halted instrument.
+ *
+ * `GET /admin/instruments/halted`
+ */
+export const listHaltedInstruments = (): Promise<InstrumentList> =>
+  request<InstrumentList>('GET', '/admin/instruments/halted');
+
+/**
+ * Halt trading in a symbol (requires sudo).
+ *
+ * `POST /admin/instruments/{symbol}/halt`
+ */
+export const haltInstrument = (symbol: string, body: HaltRequest): Promise<Instrument> =>
+  request<Instrument>('POST', `/admin/instruments/${encodeURIComponent(symbol)}/halt`, { body });
+
+/**
+ * Resume trading in a halted symbol (requires sudo).
+ *
+ * `DELETE /admin/instruments/{symbol}/halt`
+ */
+export const resumeInstrument = (symbol: string): Promise<Instrument> =>
+  request<Instrument>('DELETE', `/admin/instruments/${encodeURIComponent(symbol)}/halt`);
+
+/**
+ * Set a symbol's tick size and display decimals (requires sudo).
+ *
+ * `PUT /admin/instruments/{symbol}/precision`
+ */
+export const setInstrumentPrecision = (
+  symbol: string,
+  body: PrecisionRequest
+): Promise<Instrument> =>
+  request<Instrument>('PUT', `/admin/instruments/${encodeURIComponent(symbol)}/precision`, {
+    body,
+  });
+
+/**
+ * Flagged audit events, newest first.
+ *
+ * `GET /admin/audit/anomalies`
+ */
+export const listAnomalies = (params: ListAnomaliesParams = {}): Promise<AuditEventList> =>
+  request<AuditEventList>('GET', '/admin/audit/anomalies', { query: params });
+
+/**
+ * The configured rate-limit buckets and this instance's limiter metrics.
+ *
+ * `GET /admin/ratelimits`
+ */
+export const getRateLimitOverview = (): Promise<RateLimitOverview> =>
+  request<RateLimitOverview>('GET', '/admin/ratelimits');
+
+/**
+ * The callers with the most requests in a bucket's current window.
+ *
+ * `GET /admin/ratelimits/{bucket}/{scope}/top`
+ */
+export const listTopRateLimitConsumers = (
+  bucket: string,
+  scope: string,
+  params: ListTopRateLimitConsumersParams = {}
+): Promise<RateLimitConsumerList> =>
+  request<RateLimitConsumerList>('GET', `/admin/ratelimits/${encodeURIComponent(bucket)}/${encodeURIComponent(scope)}/top`, {
+    query: params,
+  });
+
+/**
+ * One caller's window in a bucket.
+ *
+ * `GET /admin/ratelimits/{bucket}/{scope}/{id}`
+ */
+export const inspectRateLimit = (
+  bucket: string,
+  scope: string,
+  id: string
+): Promise<RateLimitWindow> =>
+  request<RateLimitWindow>('GET', `/admin/ratelimits/${encodeURIComponent(bucket)}/${encodeURIComponent(scope)}/${encodeURIComponent(id)}`);
+
+/**
+ * Clear one caller's window in a bucket (requires sudo).
+ *
+ * `DELETE /admin/ratelimits/{bucket}/{scope}/{id}`
+ */
+export const resetRateLimit = (bucket: string, scope: string, id: string): Promise<void> =>
+  request<void>('DELETE', `/admin/ratelimits/${encodeURIComponent(bucket)}/${encodeURIComponent(scope)}/${encodeURIComponent(id)}`);
+
+/**
+ * Create a curated list or replace its contents (requires sudo).
+ *
+ * `PUT /admin/watchlists/{slug}`
+ */
+export const saveCuratedList = (slug: string, body: CuratedListRequest): Promise<CuratedList> =>
+  request<CuratedList>('PUT', `/admin/watchlists/${encodeURIComponent(slug)}`, { body });
+
+/**
+ * Remove a curated list (requires sudo).
+ *
+ * `DELETE /admin/watchlists/{slug}`
+ */
+export const deleteCuratedList = (slug: string): Promise<void> =>
+  request<void>('DELETE', `/admin/watchlists/${encodeURIComponent(slug)}`);
+
+/**
+ * Reload the bundled classification dataset; admin overrides are kept (requires sudo).
+ *
+ * `POST /admin/classifications/reload`
+ */
+export const reloadClassifications = (): Promise<ClassificationReload> =>
+  request<ClassificationReload>('POST', '/admin/classifications/reload');
+
+/**
+ * Override a symbol's sector, industry and index memberships (requires sudo).
+ *
+ * `PUT /admin/classifications/{symbol}`
+ */
+export const saveClassification = (
+  symbol: string,
+  body: ClassificationRequest
+): Promise<Classification> =>
+  request<Classification>('PUT', `/admin/classifications/${encodeURIComponent(symbol)}`, { body });
+
+/**
+ * Every recorded symbol rename.
+ *
+ * `GET /admin/symbols/renames`
+ */
+export const listSymbolRenames = (): Promise<SymbolRenameList> =>
+  request<SymbolRenameList>('GET', '/admin/symbols/renames');
+
+/**
+ * Record that a symbol trades under a new one from a date, migrating holdings then (requires
+ * sudo).
+ *
+ * `PUT /admin/symbols/renames/{symbol}`
+ */
+export const renameSymbol = (symbol: string, body: SymbolRenameRequest): Promise<SymbolAlias> =>
+  request<SymbolAlias>('PUT', `/admin/symbols/renames/${encodeURIComponent(symbol)}`, { body });
+
+/**
+ * Create users from a CSV of email, starting_balance and league (requires sudo).
+ *
+ * `POST /admin/users/import`
+ */
+export const importUsers = (
+  body: Blob | string,
+  params: ImportUsersParams = {}
+): Promise<UserImportReport> =>
+  request<UserImportReport>('POST', '/admin/users/import', {
+    body,
+    contentType: 'text/csv',
+    query: params,
+  });
+
+/**
+ * Every non-guest user with holdings and trade aggregates, as JSON or a CSV download.
+ *
+ * `GET /admin/users/export`
+ */
+export const exportUsers = (params: ExportUsersParams = {}): Promise<UserStatsList | Blob> =>
+  request<UserStatsList | Blob>('GET', '/admin/users/export', { query: params });
+
+/**
+ * Non-guest users whose ID is q or whose email or username contains it, newest first.
+ *
+ * `GET /admin/users`
+ */
+export const searchUsers = (params: SearchUsersParams = {}): Promise<AdminUserList> =>
+  request<AdminUserList>('GET', '/admin/users', { query: params });
+
+/**
+ * One user.
+ *
+ * `GET /admin/users/{id}`
+ */
+export const getUser = (id: string): Promise<AdminUser> =>
+  request<AdminUser>('GET', `/admin/users/${encodeURIComponent(id)}`);
+
+/**
+ * Erase a user and everything in the account (requires sudo).
+ *
+ * `DELETE /admin/users/{id}`
+ */
+export const deleteUser = (id: string): Promise<void> =>
+  request<void>('DELETE', `/admin/users/${encodeURIComponent(id)}`);
+
+/**
+ * Credit or debit a user's cash (requires sudo).
+ *
+ * `POST /admin/users/{id}/balance`
+ */
+export const adjustUserBalance = (
+  id: string,
+  body: BalanceAdjustmentRequest
+): Promise<BalanceAdjustment> =>
+  request<BalanceAdjustment>('POST', `/admin/users/${encodeURIComponent(id)}/balance`, { body });
+
+/**
+ * Lock a user out of every login and session until a time (requires sudo).
+ *
+ * `POST /admin/users/{id}/lock`
+ */
+export const lockUser = (id: string, body: UserLockRequest): Promise<void> =>
+  request<void>('POST', `/admin/users/${encodeURIComponent(id)}/lock`, { body });
+
+/**
+ * Lift a user's lock (requires sudo).
+ *
+ * `DELETE /admin/users/{id}/lock`
+ */
+export const unlockUser = (id: string): Promise<void> =>
+  request<void>('DELETE', `/admin/users/${encodeURIComponent(id)}/lock`);
+
+/**
+ * Send a user a new verification email (requires sudo).
+ *
+ * `POST /admin/users/{id}/verification`
+ */
+export const resendUserVerification = (id: string): Promise<void> =>
+  request<void>('POST', `/admin/users/${encodeURIComponent(id)}/verification`);
+
+/**
+ * Every invite code, newest first.
+ *
+ * `GET /admin/invite-codes`
+ */
+export const listInviteCodes = (): Promise<InviteCodeList> =>
+  request<InviteCodeList>('GET', '/admin/invite-codes');
+
+/**
+ * Generate invite codes (requires sudo).
+ *
+ * `POST /admin/invite-codes`
+ */
+export const createInviteCodes = (body: InviteCodeRequest): Promise<InviteCodeList> =>
+  request<InviteCodeList>('POST', '/admin/invite-codes', { body });
+
+/**
+ * One invite code and who redeemed it.
+ *
+ * `GET /admin/invite-codes/{code}`
+ */
+export const getInviteCode = (code: string): Promise<InviteCodeDetail> =>
+  request<InviteCodeDetail>('GET', `/admin/invite-codes/${encodeURIComponent(code)}`);
+
+/**
+ * Revoke an invite code (requires sudo).
+ *
+ * `DELETE /admin/invite-codes/{code}`
+ */
+export const revokeInviteCode = (code: string): Promise<void> =>
+  request<void>('DELETE', `/admin/invite-codes/${encodeURIComponent(code)}`);
+
+/**
+ * Re-run a session's end-of-day close (requires sudo).
+ *
+ * `POST /admin/eod/rerun`
+ */
+export const rerunEOD = (body: EODRerunRequest): Promise<EODRerun> =>
+  request<EODRerun>('POST', '/admin/eod/rerun', { body });
+
+/**
+ * Flush cached market data for some symbols, or every symbol (requires sudo).
+ *
+ * `POST /admin/market-cache/invalidate`
+ */
+export const invalidateMarketCache = (
+  body: MarketCacheInvalidateRequest
+): Promise<MarketCacheInvalidated> =>
+  request<MarketCacheInvalidated>('POST', '/admin/market-cache/invalidate', { body });
+
+/**
+ * The market data provider's request counts against its monthly quota.
+ *
+ * `GET /admin/market-data/quota`
+ */
+export const getMarketDataQuota = (): Promise<QuotaUsage> =>
+  request<QuotaUsage>('GET', '/admin/market-data/quota');
+
+/**
+ * Trade disputes, oldest first.
+ *
+ * `GET /admin/disputes`
+ */
+export const listTradeDisputes = (
+  params: ListTradeDisputesParams = {}
+): Promise<TradeDisputeList> =>
+  request<TradeDisputeList>('GET', '/admin/disputes', { query: params });
+
+/**
+ * Reverse the disputed trade and close the dispute (requires sudo).
+ *
+ * `POST /admin/disputes/{id}/reverse`
+ */
+export const reverseTradeDispute = (
+  id: string,
+  body: TradeDisputeResolution
+): Promise<TradeDispute> =>
+  request<TradeDispute>('POST', `/admin/disputes/${encodeURIComponent(id)}/reverse`, { body });
+
+/**
+ * Close the dispute, leaving the trade (requires sudo).
+ *
+ * `POST /admin/disputes/{id}/reject`
+ */
+export const rejectTradeDispute = (
+  id: string,
+  body: TradeDisputeResolution
+): Promise<TradeDispute> =>
+  request<TradeDispute>('POST', `/admin/disputes/${encodeURIComponent(id)}/reject`, { body });
+
+/**
+ * How many accounts are inactive, warned and anonymized.
+ *
+ * `GET /admin/retention`
+ */
+export const getRetentionReport = (): Promise<RetentionReport> =>
+  request<RetentionReport>('GET', '/admin/retention');
+
+/**
+ * Run a retention pass now (requires sudo).
+ *
+ * `POST /admin/retention/run`
+ */
+export const runRetention = (): Promise<RetentionRun> =>
+  request<RetentionRun>('POST', '/admin/retention/run');
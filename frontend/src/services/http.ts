@@ -0,0 +1,211 @@
+/**
+ * The transport the generated API client (api.gen.ts) runs on: the API base
+ * URL, the CSRF header and the error type every call throws
+ */
+
+// Runtime-injected env. The Docker entrypoint writes a small <script> tag with
+// `window.env = { REACT_APP_API_URL: "..." }` before the bundle loads, so the
+// same image can be deployed against different backends without a rebuild.
+declare global {
+  interface Window {
+    env?: {
+      REACT_APP_API_URL?: string;
+    };
+  }
+}
+
+/**
+ * API request options extending standard fetch options
+ */
+export interface ApiRequestOptions extends RequestInit {
+  headers?: HeadersInit;
+}
+
+const CSRF_COOKIE = 'csrf_token';
+const SAFE_METHODS = ['GET', 'HEAD', 'OPTIONS'];
+
+// Use relative path by default to leverage the dev server proxy
+const apiBase = (): string =>
+  window.env?.REACT_APP_API_URL ||
+  process.env.REACT_APP_API_URL ||
+  '/api';
+
+const readCookie = (name: string): string | undefined =>
+  document.cookie
+    .split('; ')
+    .find((c) => c.startsWith(`${name}=`))
+    ?.slice(name.length + 1);
+
+/**
+ * Returns the CSRF token the backend expects in X-CSRF-Token on
+ * state-changing requests: the csrf_token cookie, fetched from /csrf on the
+ * first such request if no response has set it yet.
+ */
+const csrfToken = async (base: string): Promise<string | undefined> => {
+  const token = readCookie(CSRF_COOKIE);
+  if (token) {
+    return token;
+  }
+  const response = await fetch(`${base}/csrf`);
+  if (!response.ok) {
+    return undefined;
+  }
+  const data = (await response.json()) as { csrf_token?: string };
+  return data.csrf_token;
+};
+
+/**
+ * Sends a request to an API endpoint and returns the raw Response
+ *
+ * @param endpoint - API endpoint path
+ * @param options - Fetch options (method, body, headers, etc.)
+ * @returns Promise resolving to the Response object
+ */
+// eslint-disable-next-line @typescript-eslint/no-unused-vars
+export const apiRequest = async <T = unknown>(
+  endpoint: string,
+  options: ApiRequestOptions = {}
+): Promise<Response> => {
+  const base = apiBase();
+
+  // Auth is handled exclusively via the httpOnly 'token' cookie set by the backend.
+  // The cookie is sent automatically by the browser for same-origin requests;
+  // no Authorization header is needed or written here. State-changing
+  // requests also echo the CSRF cookie in a header.
+  const method = (options.method || 'GET').toUpperCase();
+  const csrf = SAFE_METHODS.includes(method) ? undefined : await csrfToken(base);
+  const config: RequestInit = {
+    ...options,
+    headers: {
+      'Content-Type': 'application/json',
+      ...(csrf ? { 'X-CSRF-Token': csrf } : {}),
+      ...options.headers,
+    },
+  };
+
+  // Ensure endpoint starts with / if not present (unless it's a full URL)
+  const url = endpoint.startsWith('http')
+    ? endpoint
+    : `${base}${endpoint.startsWith('/') ? '' : '/'}${endpoint}`;
+
+  return fetch(url, config);
+};
+
+/**
+ * Type-safe API request that parses JSON response
+ *
+ * @template T - The expected response type
+ * @param endpoint - API endpoint path
+ * @param options - Fetch options
+ * @returns Promise resolving to parsed JSON data
+ * @throws Error if response is not ok or JSON parsing fails
+ */
+export const apiRequestJson = async <T = unknown>(
+  endpoint: string,
+  options: ApiRequestOptions = {}
+): Promise<T> => {
+  const response = await apiRequest<T>(endpoint, options);
+
+  if (!response.ok) {
+    const errorText = await response.text().catch(() => 'Unknown error');
+    throw new Error(`API request failed: ${response.status} ${errorText}`);
+  }
+
+  try {
+    const data = await response.json();
+    return data as T;
+  } catch (error) {
+    throw new Error(`Failed to parse JSON response: ${error}`);
+  }
+};
+
+export class ApiError extends Error {
+  status: number;
+  /** The response's error_code, e.g. INSUFFICIENT_FUNDS, when it has one */
+  code?: string;
+  constructor(message: string, status: number, code?: string) {
+    super(message);
+    this.status = status;
+    this.code = code;
+  }
+}
+
+/**
+ * Options for one generated call
+ */
+export interface RequestOptions {
+  /** Sent as JSON unless contentType is set */
+  body?: unknown;
+  contentType?: string;
+  /** Query parameters; undefined and empty values are left out */
+  query?: object;
+  /** Headers; undefined values are left out */
+  headers?: Record<string, string | undefined>;
+  /** The media type wanted, for endpoints that don't answer in JSON */
+  accept?: string;
+}
+
+/**
+ * Sends one API call and resolves to its answer: the parsed JSON, a Blob
+ * for any other content (CSV, PDF), or undefined when there is none.
+ * Throws ApiError with the response's message and error_code when it is
+ * not ok.
+ */
+export const request = async <T>(
+  method: string,
+  path: string,
+  options: RequestOptions = {}
+): Promise<T> => {
+  const params = new URLSearchParams();
+  Object.entries(options.query ?? {}).forEach(([key, value]) => {
+    if (value !== undefined && value !== null && value !== '') {
+      params.set(key, String(value));
+    }
+  });
+  const qs = params.toString();
+
+  const headers: Record<string, string> = {};
+  Object.entries(options.headers ?? {}).forEach(([key, value]) => {
+    if (value !== undefined) {
+      headers[key] = value;
+    }
+  });
+  if (options.accept) {
+    headers.Accept = options.accept;
+  }
+  let body: BodyInit | undefined;
+  if (options.contentType) {
+    headers['Content-Type'] = options.contentType;
+    body = options.body as BodyInit;
+  } else if (options.body !== undefined) {
+    body = JSON.stringify(options.body);
+  }
+
+  const response = await apiRequest<T>(`${path}${qs ? `?${qs}` : ''}`, { method, body, headers });
+  if (!response.ok) {
+    const text = await response.text().catch(() => '');
+    let message = text.trim() || `${response.status}`;
+    let code: string | undefined;
+    try {
+      const parsed = JSON.parse(text) as { message?: string; error_code?: string };
+      message = parsed.message || message;
+      code = parsed.error_code;
+    } catch {
+      // Plain-text errors from the auth middleware
+    }
+    throw new ApiError(message, response.status, code);
+  }
+
+  const contentType = response.headers.get('Content-Type') || '';
+  if (response.status === 204 || response.headers.get('Content-Length') === '0') {
+    return undefined as T;
+  }
+  if (!contentType.includes('application/json')) {
+    return (await response.blob()) as unknown as T;
+  }
+  try {
+    return (await response.json()) as T;
+  } catch {
+    throw new ApiError('Failed to parse response', response.status);
+  }
+};
@@ -1,206 +1,78 @@
 /**
  * API Type Definitions
  * 
- * These types match the backend DTOs for type-safe API communication
- */
-
-/**
- * User account information
- */
-export interface User {
-  id: string;
-  email: string;
-  created_at: string;
-  balance: number;
-  email_verified?: boolean;
-}
+ * The shapes the backend sends and accepts, as generated from
+ * docs/openapi.yaml into services/api.gen.ts. The names here are the ones
+ * the components were written against.
+ */
+
+import type {
+  HistoricalData,
+  HistoricalSeries,
+  Holding,
+  QueuedTrade,
+  SeriesPoint,
+  StockQuote,
+  TradePage,
+  TradeRequest,
+  Watchlist,
+} from '../services/api.gen';
+
+export type {
+  AuthResponse,
+  ErrorBody as ErrorResponse,
+  LoginRequest,
+  RegisterRequest,
+  ResearchAnswer,
+  ResearchAskRequest,
+  ResearchCitation,
+  Trade,
+  User,
+  WatchlistEntry,
+} from '../services/api.gen';
 
 /**
  * User's stock holding in portfolio
  */
-export interface UserStock {
-  id: string;
-  user_id: string;
-  symbol: string;
-  quantity: number;
-  avg_price: number;
-  total: number;
-  current_stock_price: number;
-  created_at: string;
-  updated_at: string;
-}
+export type UserStock = Holding;
 
 /**
- * Authentication response from login/register endpoints
+ * 202 response from buy/sell when the market is closed and the user queues
+ * after-hours trades: a MARKET order was placed instead
  */
-export interface AuthResponse {
-  success: boolean;
-  message: string;
-  user?: User;
-  token?: string;
-}
+export type QueuedTradeResponse = QueuedTrade;
 
 /**
- * Generic error response from API
+ * Buy and sell request payloads
  */
-export interface ErrorResponse {
-  success: boolean;
-  message: string;
-  error?: string;
-}
+export type BuyStockRequest = TradeRequest;
+export type SellStockRequest = TradeRequest;
 
 /**
- * Login request payload
- */
-export interface LoginRequest {
-  email: string;
-  password: string;
-}
-
-/**
- * Registration request payload
- */
-export interface RegisterRequest {
-  email: string;
-  password: string;
-}
-
-/**
- * Buy stock request payload
- */
-export interface BuyStockRequest {
-  symbol: string;
-  quantity: number;
-}
-
-/**
- * Sell stock request payload
- */
-export interface SellStockRequest {
-  symbol: string;
-  quantity: number;
-}
-
-/**
- * Trade response from buy/sell operations
- */
-export interface TradeResponse {
-  id: string;
-  symbol: string;
-  action: string;
-  quantity: number;
-  price: number;
-  total: number;
-  executed_at: string;
-}
-
-/**
- * A single trade row as returned by GET /investments/history.
- * total is computed server-side as quantity * price.
- * executed_at is an ISO 8601 timestamp.
- */
-export interface Trade {
-  id: string;
-  user_id: string;
-  symbol: string;
-  action: 'BUY' | 'SELL';
-  quantity: number;
-  price: number;
-  total: number;
-  executed_at: string;
-  status: string;
-}
-
-/**
- * Paginated response from GET /investments/history.
+ * Paginated response from GET /investments/trades.
  * total is the count of all trades matching the filter — independent of limit/offset.
  */
-export interface TradeHistoryResponse {
-  trades: Trade[];
-  total: number;
-  limit: number;
-  offset: number;
-}
+export type TradeHistoryResponse = TradePage;
 
 /**
  * Stock price response
  */
-export interface StockResponse {
-  symbol: string;
-  date: string;
-  price: number;
-}
+export type StockResponse = StockQuote;
 
 /**
  * Historical stock data response
  */
-export interface HistoricalDataResponse {
-  symbol: string;
-  date: string;
-  previous_price: number;
-  price: number;
-  volume: number;
-  change: number;
-  change_percentage: number;
-}
+export type HistoricalDataResponse = HistoricalData;
 
 /**
  * A single point in a stock-history time series.
  * date is ISO YYYY-MM-DD; close is in dollars (2dp on the wire).
  */
-export interface HistoricalSeriesPoint {
-  date: string;
-  close: number;
-}
+export type HistoricalSeriesPoint = SeriesPoint;
 
 /**
  * Response shape from GET /market/stock/historical/series.
  */
-export interface HistoricalSeriesResponse {
-  symbol: string;
-  from: string;
-  to: string;
-  points: HistoricalSeriesPoint[];
-}
-
-/**
- * A single watchlist entry as returned by GET /watchlist.
- * has_price is false when the price lookup failed (treat price/change as unknown).
- */
-export interface WatchlistEntry {
-  id: string;
-  symbol: string;
-  created_at: string;
-  price: number;
-  change: number;
-  change_percentage: number;
-  has_price: boolean;
-}
-
-export interface WatchlistResponse {
-  items: WatchlistEntry[];
-}
-
-export interface ResearchCitation {
-  chunk_id: string;
-  source_url: string;
-  symbol?: string;
-  filed_at?: string;
-  excerpt: string;
-  score: number;
-}
-
-export interface ResearchAnswer {
-  query_id: string;
-  answer: string;
-  citations: ResearchCitation[];
-  refused: boolean;
-  refusal_reason?: string;
-  latency_ms: number;
-}
-
-export interface ResearchAskRequest {
-  query: string;
-  symbols?: string[];
-}
+export type HistoricalSeriesResponse = HistoricalSeries;
 
+export type WatchlistResponse = Watchlist;
@@ -0,0 +1,14 @@
+#!/bin/bash
+# Regenerate the API clients from docs/openapi.yaml
+# Writes the Go client's types and methods (backend/client/client.gen.go) and
+# the frontend's typed calls (frontend/src/services/api.gen.ts).
+# Run after changing the spec; a backend test fails while they are stale.
+
+set -e
+
+cd "$(dirname "$0")/../backend"
+go run ./cmd/apigen -spec ../docs/openapi.yaml \
+  -go client/client.gen.go \
+  -ts ../frontend/src/services/api.gen.ts
+
+echo "Regenerated backend/client/client.gen.go and frontend/src/services/api.gen.ts"